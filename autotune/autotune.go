@@ -0,0 +1,130 @@
+// Package autotune provides aligning the Go runtime with the CPU and memory limits of the container.
+//
+// The Go runtime is not aware of cgroup limits: GOMAXPROCS defaults to the number of CPUs of the host,
+// which leads to CPU throttling when the container has a lower quota, and the GC does not collect
+// more aggressively when the heap approaches the memory limit, which leads to OOM kills.
+package autotune
+
+import (
+	"math"
+	"os"
+	"runtime"
+	"runtime/debug"
+
+	"github.com/beatlabs/patron/internal/cgroup"
+	"github.com/beatlabs/patron/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const defaultMemoryLimitRatio = 0.9
+
+var (
+	maxProcsGauge    prometheus.Gauge
+	gcPercentGauge   prometheus.Gauge
+	memoryLimitGauge prometheus.Gauge
+)
+
+func init() {
+	maxProcsGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "runtime",
+		Subsystem: "autotune",
+		Name:      "gomaxprocs",
+		Help:      "GOMAXPROCS chosen by the runtime tuning.",
+	})
+	gcPercentGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "runtime",
+		Subsystem: "autotune",
+		Name:      "gc_percent",
+		Help:      "GOGC chosen by the runtime tuning, -1 if the GC is disabled.",
+	})
+	memoryLimitGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "runtime",
+		Subsystem: "autotune",
+		Name:      "memory_limit_bytes",
+		Help:      "GOMEMLIMIT chosen by the runtime tuning, zero if no memory limit is set.",
+	})
+	prometheus.MustRegister(maxProcsGauge, gcPercentGauge, memoryLimitGauge)
+}
+
+// Settings of the runtime after tuning.
+type Settings struct {
+	GOMAXPROCS int
+	// GCPercent is the GOGC value, -1 if the GC is disabled.
+	GCPercent int
+	// MemoryLimit is the GOMEMLIMIT value in bytes, zero if no memory limit is set.
+	MemoryLimit int64
+}
+
+type config struct {
+	memoryLimitRatio float64
+	gcPercent        *int
+}
+
+// Apply tunes the runtime according to the cgroup limits of the container:
+//
+// - GOMAXPROCS is set to the CPU quota rounded down, with a minimum of 1
+//
+// - GOMEMLIMIT is set to a ratio of the memory limit (default 90%), which requires Go 1.19 or later
+//
+// - GOGC is set to the value provided with the GCPercent option, if there is a memory limit
+//
+// Values set explicitly with the GOMAXPROCS, GOMEMLIMIT and GOGC environment variables are always respected.
+// The resulting settings are exposed as metrics.
+func Apply(oo ...OptionFunc) (Settings, error) {
+	cfg := &config{memoryLimitRatio: defaultMemoryLimitRatio}
+	for _, option := range oo {
+		if err := option(cfg); err != nil {
+			return Settings{}, err
+		}
+	}
+
+	limits, err := cgroup.Read(cgroup.Root)
+	if err != nil {
+		return Settings{}, err
+	}
+
+	s := apply(cfg, limits, os.LookupEnv)
+	maxProcsGauge.Set(float64(s.GOMAXPROCS))
+	gcPercentGauge.Set(float64(s.GCPercent))
+	memoryLimitGauge.Set(float64(s.MemoryLimit))
+	return s, nil
+}
+
+func apply(cfg *config, limits cgroup.Limits, lookupEnv func(string) (string, bool)) Settings {
+	if _, ok := lookupEnv("GOMAXPROCS"); !ok && limits.CPU > 0 {
+		procs := int(math.Floor(limits.CPU))
+		if procs < 1 {
+			procs = 1
+		}
+		runtime.GOMAXPROCS(procs)
+		log.Debugf("GOMAXPROCS set to %d for a CPU quota of %.2f", procs, limits.CPU)
+	}
+
+	if limits.Memory > 0 {
+		if _, ok := lookupEnv("GOMEMLIMIT"); !ok {
+			limit := int64(float64(limits.Memory) * cfg.memoryLimitRatio)
+			if setMemoryLimit(limit) {
+				log.Debugf("GOMEMLIMIT set to %d bytes for a memory limit of %d bytes", limit, limits.Memory)
+			} else {
+				log.Warn("GOMEMLIMIT is not supported by the Go version, the memory limit of the container is not applied")
+			}
+		}
+		if _, ok := lookupEnv("GOGC"); !ok && cfg.gcPercent != nil {
+			debug.SetGCPercent(*cfg.gcPercent)
+			log.Debugf("GOGC set to %d", *cfg.gcPercent)
+		}
+	}
+
+	return Settings{
+		GOMAXPROCS:  runtime.GOMAXPROCS(0),
+		GCPercent:   gcPercent(),
+		MemoryLimit: memoryLimit(),
+	}
+}
+
+// gcPercent returns the current GOGC value, since the runtime does not provide reading it directly.
+func gcPercent() int {
+	p := debug.SetGCPercent(100)
+	debug.SetGCPercent(p)
+	return p
+}
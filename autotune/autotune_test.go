@@ -0,0 +1,94 @@
+package autotune
+
+import (
+	"math"
+	"runtime"
+	"runtime/debug"
+	"testing"
+
+	"github.com/beatlabs/patron/internal/cgroup"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_apply(t *testing.T) {
+	gc := func(p int) *int { return &p }
+
+	tests := map[string]struct {
+		cfg    config
+		limits cgroup.Limits
+		env    map[string]string
+		want   Settings
+	}{
+		"fractional quota": {
+			cfg:    config{memoryLimitRatio: 0.9},
+			limits: cgroup.Limits{CPU: 2.5},
+			want:   Settings{GOMAXPROCS: 2, GCPercent: 100},
+		},
+		"quota below one core": {
+			cfg:    config{memoryLimitRatio: 0.9},
+			limits: cgroup.Limits{CPU: 0.5},
+			want:   Settings{GOMAXPROCS: 1, GCPercent: 100},
+		},
+		"memory limit and gc percent": {
+			cfg:    config{memoryLimitRatio: 0.5, gcPercent: gc(200)},
+			limits: cgroup.Limits{CPU: 3, Memory: 1000},
+			want:   Settings{GOMAXPROCS: 3, GCPercent: 200, MemoryLimit: 500},
+		},
+		"gc percent without memory limit": {
+			cfg:    config{memoryLimitRatio: 0.9, gcPercent: gc(200)},
+			limits: cgroup.Limits{CPU: 3},
+			want:   Settings{GOMAXPROCS: 3, GCPercent: 100},
+		},
+		"explicit env vars": {
+			cfg:    config{memoryLimitRatio: 0.9, gcPercent: gc(200)},
+			limits: cgroup.Limits{CPU: 3, Memory: 1000},
+			env:    map[string]string{"GOMAXPROCS": "4", "GOMEMLIMIT": "1GiB", "GOGC": "100"},
+			want:   Settings{GOMAXPROCS: 4, GCPercent: 100},
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			restore(t)
+			runtime.GOMAXPROCS(4)
+			debug.SetGCPercent(100)
+
+			lookupEnv := func(key string) (string, bool) {
+				v, ok := tt.env[key]
+				return v, ok
+			}
+
+			got := apply(&tt.cfg, tt.limits, lookupEnv)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestApply_InvalidOption(t *testing.T) {
+	_, err := Apply(MemoryLimitRatio(2))
+	assert.EqualError(t, err, "memory limit ratio should be in (0, 1]")
+}
+
+func TestOptions(t *testing.T) {
+	cfg := &config{}
+	require.NoError(t, MemoryLimitRatio(0.8)(cfg))
+	assert.Equal(t, 0.8, cfg.memoryLimitRatio)
+	assert.EqualError(t, MemoryLimitRatio(0)(cfg), "memory limit ratio should be in (0, 1]")
+
+	require.NoError(t, GCPercent(-1)(cfg))
+	assert.Equal(t, -1, *cfg.gcPercent)
+	assert.EqualError(t, GCPercent(-2)(cfg), "gc percent should be -1 or greater")
+}
+
+// restore resets the runtime settings changed by the test.
+func restore(t *testing.T) {
+	procs := runtime.GOMAXPROCS(0)
+	gcPercent := debug.SetGCPercent(100)
+	debug.SetGCPercent(gcPercent)
+	t.Cleanup(func() {
+		runtime.GOMAXPROCS(procs)
+		debug.SetGCPercent(gcPercent)
+		setMemoryLimit(math.MaxInt64)
+	})
+}
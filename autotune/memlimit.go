@@ -0,0 +1,23 @@
+//go:build go1.19
+// +build go1.19
+
+package autotune
+
+import (
+	"math"
+	"runtime/debug"
+)
+
+func setMemoryLimit(limit int64) bool {
+	debug.SetMemoryLimit(limit)
+	return true
+}
+
+// memoryLimit returns the current GOMEMLIMIT value, zero if it is not set.
+func memoryLimit() int64 {
+	limit := debug.SetMemoryLimit(-1)
+	if limit == math.MaxInt64 {
+		return 0
+	}
+	return limit
+}
@@ -0,0 +1,12 @@
+//go:build !go1.19
+// +build !go1.19
+
+package autotune
+
+func setMemoryLimit(int64) bool {
+	return false
+}
+
+func memoryLimit() int64 {
+	return 0
+}
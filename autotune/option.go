@@ -0,0 +1,31 @@
+package autotune
+
+import "errors"
+
+// OptionFunc definition for configuring the runtime tuning in a functional way.
+type OptionFunc func(*config) error
+
+// MemoryLimitRatio option for setting the ratio of the container memory limit used as GOMEMLIMIT,
+// leaving headroom for memory not managed by the Go runtime, e.g. cgo allocations and thread stacks.
+func MemoryLimitRatio(ratio float64) OptionFunc {
+	return func(cfg *config) error {
+		if ratio <= 0 || ratio > 1 {
+			return errors.New("memory limit ratio should be in (0, 1]")
+		}
+		cfg.memoryLimitRatio = ratio
+		return nil
+	}
+}
+
+// GCPercent option for setting GOGC when the container has a memory limit.
+// With GOMEMLIMIT in place, a higher value, or -1 to collect only when approaching the limit,
+// reduces the CPU spent in GC for services with a steady heap.
+func GCPercent(percent int) OptionFunc {
+	return func(cfg *config) error {
+		if percent < -1 {
+			return errors.New("gc percent should be -1 or greater")
+		}
+		cfg.gcPercent = &percent
+		return nil
+	}
+}
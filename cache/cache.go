@@ -18,3 +18,17 @@ type TTLCache interface {
 	Cache
 	SetTTL(key string, value interface{}, ttl time.Duration) error
 }
+
+// PrefixRemover interface is implemented by caches which support evicting all keys sharing a prefix.
+type PrefixRemover interface {
+	RemovePrefix(prefix string) error
+}
+
+// Locker interface is implemented by caches shared across instances, which support locking keys cluster-wide,
+// so that only one instance at a time acts on them e.g. refreshes an expired entry.
+type Locker interface {
+	// TryLock acquires the lock of the key for the provided ttl, returning false if it is held by another owner.
+	TryLock(key string, ttl time.Duration) (bool, error)
+	// Unlock releases the lock of the key, if it is still held by this instance.
+	Unlock(key string) error
+}
@@ -2,6 +2,8 @@
 package lru
 
 import (
+	"strings"
+
 	lru "github.com/hashicorp/golang-lru"
 )
 
@@ -38,6 +40,16 @@ func (c *Cache) Remove(key string) error {
 	return nil
 }
 
+// RemovePrefix evicts all keys starting with the provided prefix from the cache.
+func (c *Cache) RemovePrefix(prefix string) error {
+	for _, k := range c.cache.Keys() {
+		if key, ok := k.(string); ok && strings.HasPrefix(key, prefix) {
+			c.cache.Remove(key)
+		}
+	}
+	return nil
+}
+
 // Set registers a key-value pair to the cache.
 func (c *Cache) Set(key string, value interface{}) error {
 	c.cache.Add(key, value)
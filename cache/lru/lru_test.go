@@ -64,6 +64,16 @@ func TestCacheOperations(t *testing.T) {
 		assert.NoError(t, err)
 	})
 
+	t.Run("testRemovePrefix", func(t *testing.T) {
+		assert.NoError(t, c.Set("/users/1:", "val1"))
+		assert.NoError(t, c.Set("/users/2:", "val2"))
+		assert.NoError(t, c.Set("/orders/1:", "val3"))
+
+		assert.NoError(t, c.RemovePrefix("/users/"))
+		assert.Equal(t, []interface{}{"/orders/1:"}, c.cache.Keys())
+		assert.NoError(t, c.Purge())
+	})
+
 	t.Run("testPurge", func(t *testing.T) {
 		err = c.Set("key1", "val1")
 		assert.NoError(t, err)
@@ -77,4 +77,115 @@ func TestCache(t *testing.T) {
 		assert.NoError(t, err)
 		assert.False(t, exists)
 	})
+	t.Run("remove prefix", func(t *testing.T) {
+		assert.NoError(t, cache.Set("/users/1:", val1))
+		assert.NoError(t, cache.Set("/users/2:", val2))
+		assert.NoError(t, cache.Set("/users*:", val3))
+
+		assert.NoError(t, cache.RemovePrefix("/users/"))
+		_, exists, err := cache.Get("/users/1:")
+		assert.NoError(t, err)
+		assert.False(t, exists)
+		_, exists, err = cache.Get("/users/2:")
+		assert.NoError(t, err)
+		assert.False(t, exists)
+		_, exists, err = cache.Get("/users*:")
+		assert.NoError(t, err)
+		assert.True(t, exists)
+
+		assert.NoError(t, cache.RemovePrefix("/users*"))
+		_, exists, err = cache.Get("/users*:")
+		assert.NoError(t, err)
+		assert.False(t, exists)
+	})
+}
+
+func TestCache_Lock(t *testing.T) {
+	opt := Options{Addr: dsn}
+	cache1, err := New(context.Background(), opt)
+	require.NoError(t, err)
+	cache2, err := New(context.Background(), opt)
+	require.NoError(t, err)
+
+	key := "lock-key"
+	ok, err := cache1.TryLock(key, time.Second)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	// held by another instance
+	ok, err = cache2.TryLock(key, time.Second)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	// releasing a lock held by another instance is a no-op
+	assert.NoError(t, cache2.Unlock(key))
+
+	assert.NoError(t, cache1.Unlock(key))
+	ok, err = cache2.TryLock(key, 100*time.Millisecond)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	// expired locks can be acquired again
+	time.Sleep(200 * time.Millisecond)
+	ok, err = cache1.TryLock(key, time.Second)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	// the expired lock of the other instance does not release the current one
+	assert.NoError(t, cache2.Unlock(key))
+	ok, err = cache2.TryLock(key, time.Second)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	assert.NoError(t, cache1.Unlock(key))
+}
+
+func TestCache_ClientSideCaching(t *testing.T) {
+	opt := Options{Addr: dsn}
+	cached, err := New(context.Background(), opt, ClientSideCaching(100, time.Minute, "tracked:"))
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, cached.Close())
+	}()
+	writer, err := New(context.Background(), opt)
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, writer.Close())
+	}()
+
+	key := "tracked:key"
+	require.NoError(t, writer.Set(key, "value1"))
+	got, exists, err := cached.Get(key)
+	require.NoError(t, err)
+	assert.True(t, exists)
+	assert.Equal(t, "value1", got)
+
+	// served from memory
+	_, ok := cached.local.get(key)
+	assert.True(t, ok)
+
+	// invalidated by the write of another client
+	require.NoError(t, writer.Set(key, "value2"))
+	assert.Eventually(t, func() bool {
+		_, ok := cached.local.get(key)
+		return !ok
+	}, time.Second, 10*time.Millisecond)
+	got, exists, err = cached.Get(key)
+	require.NoError(t, err)
+	assert.True(t, exists)
+	assert.Equal(t, "value2", got)
+
+	// keys outside the prefixes are not tracked
+	require.NoError(t, writer.Set("untracked:key", "value"))
+	_, _, err = cached.Get("untracked:key")
+	require.NoError(t, err)
+	_, ok = cached.local.get("untracked:key")
+	assert.False(t, ok)
+
+	// invalidated by a flush of the server
+	require.NoError(t, writer.Purge())
+	assert.Eventually(t, func() bool {
+		_, ok := cached.local.get(key)
+		return !ok
+	}, time.Second, 10*time.Millisecond)
+	_, exists, err = cached.Get(key)
+	require.NoError(t, err)
+	assert.False(t, exists)
 }
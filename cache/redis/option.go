@@ -0,0 +1,41 @@
+package redis
+
+import (
+	"errors"
+	"time"
+)
+
+type config struct {
+	clientSideCaching bool
+	localSize         int
+	localTTL          time.Duration
+	prefixes          []string
+}
+
+// OptionFunc definition for configuring the cache in a functional way.
+type OptionFunc func(*config) error
+
+// ClientSideCaching enables caching up to size values in memory, which are served without a round trip to Redis
+// until they are invalidated by the server, or for up to the provided ttl as a safety net.
+// The invalidations are broadcast by Redis 6 for the keys starting with the provided prefixes, or for all keys if none is provided,
+// so the prefixes should be limited to the keys of the cache, e.g. the keys of the route cache, in order not to receive the invalidations of every write to the server.
+func ClientSideCaching(size int, ttl time.Duration, prefixes ...string) OptionFunc {
+	return func(cfg *config) error {
+		if size <= 0 {
+			return errors.New("size should be positive")
+		}
+		if ttl <= 0 {
+			return errors.New("ttl should be positive")
+		}
+		for _, prefix := range prefixes {
+			if prefix == "" {
+				return errors.New("prefix is empty")
+			}
+		}
+		cfg.clientSideCaching = true
+		cfg.localSize = size
+		cfg.localTTL = ttl
+		cfg.prefixes = prefixes
+		return nil
+	}
+}
@@ -0,0 +1,39 @@
+package redis
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientSideCaching(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		size        int
+		ttl         time.Duration
+		prefixes    []string
+		expectedErr string
+	}{
+		"success":      {size: 100, ttl: time.Minute, prefixes: []string{"route:"}},
+		"invalid size": {size: 0, ttl: time.Minute, expectedErr: "size should be positive"},
+		"invalid ttl":  {size: 100, expectedErr: "ttl should be positive"},
+		"empty prefix": {size: 100, ttl: time.Minute, prefixes: []string{""}, expectedErr: "prefix is empty"},
+		"all keys":     {size: 100, ttl: time.Minute},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			cfg := &config{}
+			err := ClientSideCaching(tt.size, tt.ttl, tt.prefixes...)(cfg)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.False(t, cfg.clientSideCaching)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, &config{clientSideCaching: true, localSize: tt.size, localTTL: tt.ttl, prefixes: tt.prefixes}, cfg)
+			}
+		})
+	}
+}
@@ -4,29 +4,86 @@ package redis
 import (
 	"context"
 	"errors"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/beatlabs/patron/client/redis"
+	patronerrors "github.com/beatlabs/patron/errors"
+	"github.com/google/uuid"
 )
 
+const (
+	scanCount  = 100
+	lockPrefix = "lock:"
+)
+
+// unlockScript deletes the lock only if it is still held by the provided token,
+// so that an expired lock acquired by another owner is not released.
+const unlockScript = `
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`
+
+// globEscaper escapes the special characters of the redis glob-style patterns.
+var globEscaper = strings.NewReplacer(`\`, `\\`, "*", `\*`, "?", `\?`, "[", `\[`, "]", `\]`)
+
 // Cache encapsulates a Redis-based caching mechanism,
 // driven by go-redis/redis/v7.
 type Cache struct {
 	rdb redis.Client
 	ctx context.Context
+	// locks holds the tokens of the locks acquired by this instance per key.
+	locks sync.Map
+	// local caches the values in memory, if client-side caching is enabled.
+	local    *localCache
+	tracking *tracking
 }
 
 // Options exposes the struct from go-redis package.
 type Options redis.Options
 
 // New returns a new Redis client that will be used as the cache store.
-func New(ctx context.Context, opt Options) (*Cache, error) {
+func New(ctx context.Context, opt Options, oo ...OptionFunc) (*Cache, error) {
 	redisDB := redis.New(redis.Options(opt))
-	return &Cache{rdb: redisDB, ctx: ctx}, nil
+	c := &Cache{rdb: redisDB, ctx: ctx}
+
+	cfg := &config{}
+	for _, optionFunc := range oo {
+		if err := optionFunc(cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.clientSideCaching {
+		local, err := newLocalCache(cfg.localSize, cfg.localTTL, cfg.prefixes)
+		if err != nil {
+			return nil, err
+		}
+		t, err := newTracking(ctx, opt, local)
+		if err != nil {
+			_ = redisDB.Close()
+			return nil, err
+		}
+		c.local = local
+		c.tracking = t
+	}
+	return c, nil
 }
 
 // Get executes a lookup and returns whether a key exists in the cache along with its value.
+// With client-side caching, the values are served from memory until they are invalidated.
 func (c *Cache) Get(key string) (interface{}, bool, error) {
+	var generation uint64
+	if c.local != nil {
+		if v, ok := c.local.get(key); ok {
+			return v, true, nil
+		}
+		generation = c.local.currentGeneration()
+	}
+
 	res, err := c.rdb.Do(c.ctx, "get", key).Result()
 	if err != nil {
 		if errors.Is(err, redis.Nil) { // cache miss
@@ -34,25 +91,102 @@ func (c *Cache) Get(key string) (interface{}, bool, error) {
 		}
 		return nil, false, err
 	}
+	if c.local != nil {
+		c.local.set(key, res, generation)
+	}
 	return res, true, nil
 }
 
 // Set registers a key-value pair to the cache.
 func (c *Cache) Set(key string, value interface{}) error {
+	defer c.removeLocal(key)
 	return c.rdb.Do(c.ctx, "set", key, value).Err()
 }
 
 // Purge evicts all keys present in the cache.
 func (c *Cache) Purge() error {
+	if c.local != nil {
+		defer c.local.flush()
+	}
 	return c.rdb.FlushAll(c.ctx).Err()
 }
 
 // Remove evicts a specific key from the cache.
 func (c *Cache) Remove(key string) error {
+	defer c.removeLocal(key)
 	return c.rdb.Do(c.ctx, "del", key).Err()
 }
 
+// removeLocal removes the key from the client-side cache, if enabled, without waiting for its invalidation by the server.
+func (c *Cache) removeLocal(key string) {
+	if c.local != nil {
+		c.local.remove(key)
+	}
+}
+
+// Close closes the connections of the cache, stopping the client-side caching, if enabled.
+func (c *Cache) Close() error {
+	var ee []error
+	if c.tracking != nil {
+		if err := c.tracking.close(); err != nil {
+			ee = append(ee, err)
+		}
+	}
+	if err := c.rdb.Close(); err != nil {
+		ee = append(ee, err)
+	}
+	return patronerrors.Aggregate(ee...)
+}
+
+// RemovePrefix evicts all keys starting with the provided prefix from the cache.
+// The keys are iterated with SCAN, so that the server is not blocked while matching them.
+func (c *Cache) RemovePrefix(prefix string) error {
+	if c.local != nil {
+		defer c.local.removePrefix(prefix)
+	}
+	iter := c.rdb.Scan(c.ctx, 0, globEscaper.Replace(prefix)+"*", scanCount).Iterator()
+	keys := make([]string, 0, scanCount)
+	for iter.Next(c.ctx) {
+		keys = append(keys, iter.Val())
+		if len(keys) == scanCount {
+			if err := c.rdb.Del(c.ctx, keys...).Err(); err != nil {
+				return err
+			}
+			keys = keys[:0]
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	return c.rdb.Del(c.ctx, keys...).Err()
+}
+
 // SetTTL registers a key-value pair to the cache, specifying an expiry time.
 func (c *Cache) SetTTL(key string, value interface{}, ttl time.Duration) error {
+	defer c.removeLocal(key)
 	return c.rdb.Do(c.ctx, "set", key, value, "px", int(ttl.Milliseconds())).Err()
 }
+
+// TryLock acquires the lock of the key for the provided ttl, returning false if it is held by another instance.
+// The lock is stored in a separate key, prefixed with `lock:`.
+func (c *Cache) TryLock(key string, ttl time.Duration) (bool, error) {
+	token := uuid.New().String()
+	ok, err := c.rdb.SetNX(c.ctx, lockPrefix+key, token, ttl).Result()
+	if err != nil || !ok {
+		return false, err
+	}
+	c.locks.Store(key, token)
+	return true, nil
+}
+
+// Unlock releases the lock of the key, if it is still held by this instance.
+func (c *Cache) Unlock(key string) error {
+	token, ok := c.locks.LoadAndDelete(key)
+	if !ok {
+		return nil
+	}
+	return c.rdb.Eval(c.ctx, unlockScript, []string{lockPrefix + key}, token).Err()
+}
@@ -0,0 +1,256 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/beatlabs/patron/client/redis"
+	"github.com/beatlabs/patron/log"
+	goredis "github.com/go-redis/redis/v8"
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	invalidationChannel = "__redis__:invalidate"
+	subscribeTimeout    = 5 * time.Second
+	receiveBackoff      = 100 * time.Millisecond
+)
+
+var clientSideCacheMetrics *prometheus.CounterVec
+
+func init() {
+	clientSideCacheMetrics = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "cache",
+			Subsystem: "redis",
+			Name:      "client_side_cache_total",
+			Help:      "Events of the client-side cache: hits, misses, invalidated keys and flushes.",
+		},
+		[]string{"event"},
+	)
+	prometheus.MustRegister(clientSideCacheMetrics)
+}
+
+type localEntry struct {
+	value     interface{}
+	expiresAt time.Time
+}
+
+// localCache keeps the values read from Redis in memory, until the server invalidates them.
+// It serves values only while the subscription to the invalidations is up, and it is flushed whenever the subscription is (re)established or lost,
+// since invalidations may have been missed. Values read before a flush are not stored after it, which is tracked by the generation of the cache.
+type localCache struct {
+	entries    *lru.Cache
+	maxTTL     time.Duration
+	prefixes   []string
+	ready      int32
+	generation uint64
+	mu         sync.Mutex
+}
+
+func newLocalCache(size int, maxTTL time.Duration, prefixes []string) (*localCache, error) {
+	entries, err := lru.New(size)
+	if err != nil {
+		return nil, err
+	}
+	return &localCache{entries: entries, maxTTL: maxTTL, prefixes: prefixes}, nil
+}
+
+// tracked returns whether the invalidations of the key are broadcast, i.e. whether it starts with one of the prefixes, if any.
+func (lc *localCache) tracked(key string) bool {
+	if len(lc.prefixes) == 0 {
+		return true
+	}
+	for _, prefix := range lc.prefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (lc *localCache) get(key string) (interface{}, bool) {
+	if atomic.LoadInt32(&lc.ready) == 0 {
+		return nil, false
+	}
+	v, ok := lc.entries.Get(key)
+	if !ok {
+		clientSideCacheMetrics.WithLabelValues("miss").Inc()
+		return nil, false
+	}
+	e := v.(localEntry)
+	if time.Now().After(e.expiresAt) {
+		lc.entries.Remove(key)
+		clientSideCacheMetrics.WithLabelValues("miss").Inc()
+		return nil, false
+	}
+	clientSideCacheMetrics.WithLabelValues("hit").Inc()
+	return e.value, true
+}
+
+// currentGeneration returns the generation of the cache, which should be taken before reading a value from the server.
+func (lc *localCache) currentGeneration() uint64 {
+	return atomic.LoadUint64(&lc.generation)
+}
+
+// set stores the value read from the server, unless the cache has been flushed since the provided generation
+// or the key is not tracked.
+func (lc *localCache) set(key string, value interface{}, generation uint64) {
+	if !lc.tracked(key) {
+		return
+	}
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	if atomic.LoadInt32(&lc.ready) == 0 || atomic.LoadUint64(&lc.generation) != generation {
+		return
+	}
+	lc.entries.Add(key, localEntry{value: value, expiresAt: time.Now().Add(lc.maxTTL)})
+}
+
+func (lc *localCache) remove(keys ...string) {
+	for _, key := range keys {
+		lc.entries.Remove(key)
+	}
+}
+
+func (lc *localCache) removePrefix(prefix string) {
+	for _, k := range lc.entries.Keys() {
+		if key, ok := k.(string); ok && strings.HasPrefix(key, prefix) {
+			lc.entries.Remove(key)
+		}
+	}
+}
+
+func (lc *localCache) flush() {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	atomic.AddUint64(&lc.generation, 1)
+	lc.entries.Purge()
+	clientSideCacheMetrics.WithLabelValues("flush").Inc()
+}
+
+// setReady flushes the cache, and enables or disables serving values from it.
+func (lc *localCache) setReady(ready bool) {
+	lc.flush()
+	if ready {
+		atomic.StoreInt32(&lc.ready, 1)
+	} else {
+		atomic.StoreInt32(&lc.ready, 0)
+	}
+}
+
+// handle applies a message received from the subscription to the invalidations.
+func (lc *localCache) handle(msg interface{}) {
+	switch m := msg.(type) {
+	case *goredis.Subscription:
+		if m.Kind == "subscribe" {
+			lc.setReady(true)
+		}
+	case *goredis.Message:
+		// the server flushed its keys, which is sent without any keys
+		if len(m.PayloadSlice) == 0 && m.Payload == "" {
+			lc.flush()
+			return
+		}
+		keys := m.PayloadSlice
+		if len(keys) == 0 {
+			keys = []string{m.Payload}
+		}
+		lc.remove(keys...)
+		clientSideCacheMetrics.WithLabelValues("invalidation").Add(float64(len(keys)))
+	}
+}
+
+// tracking subscribes to the invalidations of the keys modified in the server, in the broadcasting mode of the client-side caching of Redis 6,
+// over a dedicated connection, which redirects the invalidations of its tracking to itself.
+type tracking struct {
+	local  *localCache
+	client redis.Client
+	pubSub *goredis.PubSub
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func newTracking(ctx context.Context, opt Options, local *localCache) (*tracking, error) {
+	onConnect := opt.OnConnect
+	opt.OnConnect = func(ctx context.Context, cn *goredis.Conn) error {
+		if onConnect != nil {
+			if err := onConnect(ctx, cn); err != nil {
+				return err
+			}
+		}
+		id, err := cn.ClientID(ctx).Result()
+		if err != nil {
+			return fmt.Errorf("failed to get client id: %w", err)
+		}
+		args := []interface{}{"client", "tracking", "on", "redirect", id, "bcast"}
+		for _, prefix := range local.prefixes {
+			args = append(args, "prefix", prefix)
+		}
+		if err := cn.Process(ctx, goredis.NewStatusCmd(ctx, args...)); err != nil {
+			return fmt.Errorf("failed to enable tracking: %w", err)
+		}
+		return nil
+	}
+
+	t := &tracking{local: local, client: redis.New(redis.Options(opt)), done: make(chan struct{})}
+	t.pubSub = t.client.Subscribe(ctx, invalidationChannel)
+
+	subCtx, cancel := context.WithTimeout(ctx, subscribeTimeout)
+	defer cancel()
+	msg, err := t.pubSub.Receive(subCtx)
+	if err != nil {
+		_ = t.pubSub.Close()
+		_ = t.client.Close()
+		return nil, fmt.Errorf("failed to subscribe to invalidations: %w", err)
+	}
+	local.handle(msg)
+
+	ctx, t.cancel = context.WithCancel(ctx)
+	go t.receive(ctx)
+	return t, nil
+}
+
+// receive applies the invalidations until the context is done, disabling the local cache while the subscription is down.
+// The subscription reconnects on the next receive after a failure, enabling the tracking of its new connection and resubscribing.
+func (t *tracking) receive(ctx context.Context) {
+	defer close(t.done)
+	for {
+		msg, err := t.pubSub.Receive(ctx)
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, goredis.ErrClosed) {
+				return
+			}
+			t.local.setReady(false)
+			log.FromContext(ctx).Warnf("failed to receive cache invalidations: %v", err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(receiveBackoff):
+			}
+			// force a new subscription, since the connection may have been kept after an unexpected message,
+			// e.g. the invalidation of a flush, which the client cannot decode
+			if err := t.pubSub.Unsubscribe(ctx, invalidationChannel); err == nil {
+				_ = t.pubSub.Subscribe(ctx, invalidationChannel)
+			}
+			continue
+		}
+		t.local.handle(msg)
+	}
+}
+
+func (t *tracking) close() error {
+	t.cancel()
+	err := t.pubSub.Close()
+	<-t.done
+	if cerr := t.client.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,84 @@
+package redis
+
+import (
+	"testing"
+	"time"
+
+	goredis "github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalCache(t *testing.T) {
+	t.Parallel()
+	lc, err := newLocalCache(10, time.Minute, nil)
+	require.NoError(t, err)
+
+	// values are neither stored nor served before the subscription is up
+	lc.set("key1", "value1", lc.currentGeneration())
+	_, ok := lc.get("key1")
+	assert.False(t, ok)
+
+	lc.handle(&goredis.Subscription{Kind: "subscribe", Channel: invalidationChannel, Count: 1})
+	lc.set("key1", "value1", lc.currentGeneration())
+	lc.set("key2", "value2", lc.currentGeneration())
+	lc.set("prefix:key3", "value3", lc.currentGeneration())
+	got, ok := lc.get("key1")
+	assert.True(t, ok)
+	assert.Equal(t, "value1", got)
+
+	lc.handle(&goredis.Message{Channel: invalidationChannel, PayloadSlice: []string{"key1"}})
+	_, ok = lc.get("key1")
+	assert.False(t, ok)
+	_, ok = lc.get("key2")
+	assert.True(t, ok)
+
+	lc.removePrefix("prefix:")
+	_, ok = lc.get("prefix:key3")
+	assert.False(t, ok)
+
+	// a value read before a flush is not stored after it
+	generation := lc.currentGeneration()
+	lc.handle(&goredis.Message{Channel: invalidationChannel})
+	_, ok = lc.get("key2")
+	assert.False(t, ok)
+	lc.set("key2", "stale", generation)
+	_, ok = lc.get("key2")
+	assert.False(t, ok)
+
+	// values are not served while the subscription is down
+	lc.set("key2", "value2", lc.currentGeneration())
+	lc.setReady(false)
+	lc.set("key2", "value2", lc.currentGeneration())
+	_, ok = lc.get("key2")
+	assert.False(t, ok)
+}
+
+func TestLocalCache_Expiry(t *testing.T) {
+	t.Parallel()
+	lc, err := newLocalCache(10, time.Millisecond, nil)
+	require.NoError(t, err)
+	lc.setReady(true)
+
+	lc.set("key1", "value1", lc.currentGeneration())
+	time.Sleep(5 * time.Millisecond)
+	_, ok := lc.get("key1")
+	assert.False(t, ok)
+}
+
+func TestLocalCache_Prefixes(t *testing.T) {
+	t.Parallel()
+	lc, err := newLocalCache(10, time.Minute, []string{"route:", "session:"})
+	require.NoError(t, err)
+	lc.setReady(true)
+
+	lc.set("route:key", "value1", lc.currentGeneration())
+	lc.set("session:key", "value2", lc.currentGeneration())
+	lc.set("other:key", "value3", lc.currentGeneration())
+	_, ok := lc.get("route:key")
+	assert.True(t, ok)
+	_, ok = lc.get("session:key")
+	assert.True(t, ok)
+	_, ok = lc.get("other:key")
+	assert.False(t, ok)
+}
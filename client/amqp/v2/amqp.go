@@ -8,9 +8,9 @@ import (
 	"strconv"
 	"time"
 
-	"github.com/beatlabs/patron/correlation"
 	patronerrors "github.com/beatlabs/patron/errors"
 	"github.com/beatlabs/patron/log"
+	"github.com/beatlabs/patron/propagation"
 	"github.com/beatlabs/patron/trace"
 	"github.com/opentracing/opentracing-go"
 	"github.com/opentracing/opentracing-go/ext"
@@ -84,6 +84,10 @@ func New(url string, oo ...OptionFunc) (*Publisher, error) {
 // Publish a message to an exchange.
 func (tc *Publisher) Publish(ctx context.Context, exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error {
 	sp := injectTraceHeaders(ctx, exchange, &msg)
+	// the consumers measure the age of the messages based on their timestamp
+	if msg.Timestamp.IsZero() {
+		msg.Timestamp = time.Now()
+	}
 
 	start := time.Now()
 	err := tc.channel.Publish(exchange, key, mandatory, immediate, msg)
@@ -104,12 +108,9 @@ func injectTraceHeaders(ctx context.Context, exchange string, msg *amqp.Publishi
 		msg.Headers = amqp.Table{}
 	}
 
-	c := amqpHeadersCarrier(msg.Headers)
-
-	if err := sp.Tracer().Inject(sp.Context(), opentracing.TextMap, c); err != nil {
+	if err := propagation.Inject(ctx, sp, propagation.AMQPHeaders(msg.Headers)); err != nil {
 		log.FromContext(ctx).Errorf("failed to inject tracing headers: %v", err)
 	}
-	msg.Headers[correlation.HeaderID] = correlation.IDFromContext(ctx)
 	return sp
 }
 
@@ -118,13 +119,6 @@ func (tc *Publisher) Close() error {
 	return patronerrors.Aggregate(tc.channel.Close(), tc.connection.Close())
 }
 
-type amqpHeadersCarrier map[string]interface{}
-
-// Set implements Set() of opentracing.TextMapWriter.
-func (c amqpHeadersCarrier) Set(key, val string) {
-	c[key] = val
-}
-
 func observePublish(ctx context.Context, span opentracing.Span, start time.Time, exchange string, err error) {
 	trace.SpanComplete(span, err)
 
@@ -0,0 +1,30 @@
+package rpc
+
+import (
+	"errors"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+// OptionFunc definition for configuring the client in a functional way.
+type OptionFunc func(*Client) error
+
+// Config option for providing dial configuration.
+func Config(cfg amqp.Config) OptionFunc {
+	return func(c *Client) error {
+		c.cfg = &cfg
+		return nil
+	}
+}
+
+// Timeout option for setting the timeout of the calls whose context has no deadline (default: 10s).
+func Timeout(timeout time.Duration) OptionFunc {
+	return func(c *Client) error {
+		if timeout <= 0 {
+			return errors.New("timeout should be positive")
+		}
+		c.timeout = timeout
+		return nil
+	}
+}
@@ -0,0 +1,45 @@
+package rpc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/streadway/amqp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfig(t *testing.T) {
+	cfg := amqp.Config{
+		Locale: "123",
+	}
+
+	c := Client{}
+	assert.NoError(t, Config(cfg)(&c))
+	assert.Equal(t, cfg, *c.cfg)
+}
+
+func TestTimeout(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		timeout     time.Duration
+		expectedErr string
+	}{
+		"success":          {timeout: time.Second},
+		"zero timeout":     {expectedErr: "timeout should be positive"},
+		"negative timeout": {timeout: -time.Second, expectedErr: "timeout should be positive"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			c := Client{}
+			err := Timeout(tt.timeout)(&c)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.timeout, c.timeout)
+			}
+		})
+	}
+}
@@ -0,0 +1,275 @@
+// Package rpc provides a request/reply client over AMQP with included tracing capabilities,
+// for backends which serve remote procedure calls over RabbitMQ.
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	patronerrors "github.com/beatlabs/patron/errors"
+	"github.com/beatlabs/patron/log"
+	"github.com/beatlabs/patron/propagation"
+	"github.com/beatlabs/patron/trace"
+	"github.com/google/uuid"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/streadway/amqp"
+)
+
+const (
+	rpcComponent   = "amqp-rpc-client"
+	defaultTimeout = 10 * time.Second
+)
+
+var (
+	// ErrClosed is returned by the calls of a client which is closed, or whose connection is lost.
+	ErrClosed = errors.New("rpc client is closed")
+	// ErrUnroutable is returned by the calls whose request is not routed to any queue, e.g. since the backend is not consuming.
+	ErrUnroutable = errors.New("request is unroutable")
+)
+
+var callDurationMetrics *prometheus.HistogramVec
+
+func init() {
+	callDurationMetrics = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "client",
+			Subsystem: "amqp",
+			Name:      "rpc_duration_seconds",
+			Help:      "AMQP RPC calls completed by the client.",
+		},
+		[]string{"exchange", "success"},
+	)
+	prometheus.MustRegister(callDurationMetrics)
+}
+
+// Client defines a RabbitMQ request/reply client with tracing instrumentation.
+// The replies of the concurrent calls are received on an exclusive reply queue of the client,
+// and matched to their requests by the correlation ID property of the messages.
+type Client struct {
+	cfg        *amqp.Config
+	timeout    time.Duration
+	connection *amqp.Connection
+	channel    *amqp.Channel
+	replyQueue string
+
+	mu      sync.Mutex
+	pending map[string]chan amqp.Delivery
+	err     error
+	done    chan struct{}
+}
+
+// New constructor, which declares the reply queue of the client and starts consuming it.
+func New(url string, oo ...OptionFunc) (*Client, error) {
+	if url == "" {
+		return nil, errors.New("url is required")
+	}
+
+	var err error
+	cl := &Client{timeout: defaultTimeout}
+
+	for _, option := range oo {
+		err = option(cl)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var conn *amqp.Connection
+
+	if cl.cfg == nil {
+		conn, err = amqp.Dial(url)
+	} else {
+		conn, err = amqp.DialConfig(url, *cl.cfg)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open connection: %w", err)
+	}
+
+	ch, err := conn.Channel()
+	if err != nil {
+		return nil, patronerrors.Aggregate(fmt.Errorf("failed to open channel: %w", err), conn.Close())
+	}
+
+	q, err := ch.QueueDeclare("", false, true, true, false, nil)
+	if err != nil {
+		return nil, patronerrors.Aggregate(fmt.Errorf("failed to declare reply queue: %w", err), ch.Close(), conn.Close())
+	}
+
+	replies, err := ch.Consume(q.Name, "", true, true, false, false, nil)
+	if err != nil {
+		return nil, patronerrors.Aggregate(fmt.Errorf("failed to consume reply queue: %w", err), ch.Close(), conn.Close())
+	}
+
+	cl.connection = conn
+	cl.channel = ch
+	cl.replyQueue = q.Name
+	cl.pending = make(map[string]chan amqp.Delivery)
+	cl.done = make(chan struct{})
+
+	go cl.receive(replies, ch.NotifyReturn(make(chan amqp.Return, 1)))
+	return cl, nil
+}
+
+// Call publishes the request to the exchange with the routing key, and waits for its reply until the context is done.
+// The timeout of the client applies to the contexts without a deadline. The reply-to and correlation ID properties of the request
+// are set by the client, and the request is published as mandatory, so that unroutable requests fail with ErrUnroutable
+// instead of timing out.
+func (c *Client) Call(ctx context.Context, exchange, key string, msg amqp.Publishing) (*amqp.Delivery, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	msg.CorrelationId = uuid.New().String()
+	msg.ReplyTo = c.replyQueue
+	sp := injectTraceHeaders(ctx, exchange, key, &msg)
+
+	start := time.Now()
+	dlv, err := c.call(ctx, exchange, key, msg)
+
+	observeCall(ctx, sp, start, exchange, err)
+	if err != nil {
+		return nil, err
+	}
+	return dlv, nil
+}
+
+func (c *Client) call(ctx context.Context, exchange, key string, msg amqp.Publishing) (*amqp.Delivery, error) {
+	reply, err := c.register(msg.CorrelationId)
+	if err != nil {
+		return nil, err
+	}
+	defer c.unregister(msg.CorrelationId)
+
+	if err := c.channel.Publish(exchange, key, true, false, msg); err != nil {
+		return nil, fmt.Errorf("failed to publish request: %w", err)
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("failed to receive reply: %w", ctx.Err())
+	case <-c.done:
+		return nil, c.closedErr()
+	case dlv, ok := <-reply:
+		if !ok {
+			return nil, ErrUnroutable
+		}
+		return &dlv, nil
+	}
+}
+
+// register adds a pending call, whose reply is delivered to the returned channel, or which is closed if the request is returned.
+func (c *Client) register(id string) (chan amqp.Delivery, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return nil, c.err
+	}
+	reply := make(chan amqp.Delivery, 1)
+	c.pending[id] = reply
+	return reply, nil
+}
+
+func (c *Client) unregister(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.pending, id)
+}
+
+// deliver passes the reply to its pending call, dropping the replies of the calls which are not pending anymore, e.g. timed out.
+func (c *Client) deliver(dlv amqp.Delivery) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	reply, ok := c.pending[dlv.CorrelationId]
+	if !ok {
+		log.Debugf("dropping reply with unknown correlation id %s", dlv.CorrelationId)
+		return
+	}
+	delete(c.pending, dlv.CorrelationId)
+	reply <- dlv
+}
+
+// reject fails the pending call of a returned request.
+func (c *Client) reject(ret amqp.Return) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	reply, ok := c.pending[ret.CorrelationId]
+	if !ok {
+		return
+	}
+	delete(c.pending, ret.CorrelationId)
+	close(reply)
+}
+
+// fail fails the pending calls and the calls to come with the error.
+func (c *Client) fail(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return
+	}
+	c.err = err
+	close(c.done)
+}
+
+func (c *Client) closedErr() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+// receive matches the replies and the returned requests to the pending calls, until the channel is closed.
+func (c *Client) receive(replies <-chan amqp.Delivery, returns <-chan amqp.Return) {
+	for {
+		select {
+		case dlv, ok := <-replies:
+			if !ok {
+				c.fail(ErrClosed)
+				return
+			}
+			c.deliver(dlv)
+		case ret, ok := <-returns:
+			if !ok {
+				returns = nil
+				continue
+			}
+			c.reject(ret)
+		}
+	}
+}
+
+// Close the channel and connection, failing the pending calls with ErrClosed.
+func (c *Client) Close() error {
+	c.fail(ErrClosed)
+	return patronerrors.Aggregate(c.channel.Close(), c.connection.Close())
+}
+
+func injectTraceHeaders(ctx context.Context, exchange, key string, msg *amqp.Publishing) opentracing.Span {
+	sp, _ := trace.ChildSpan(ctx, trace.ComponentOpName(rpcComponent, exchange), rpcComponent, ext.SpanKindRPCClient,
+		opentracing.Tag{Key: "exchange", Value: exchange}, opentracing.Tag{Key: "key", Value: key})
+
+	if msg.Headers == nil {
+		msg.Headers = amqp.Table{}
+	}
+
+	if err := propagation.Inject(ctx, sp, propagation.AMQPHeaders(msg.Headers)); err != nil {
+		log.FromContext(ctx).Errorf("failed to inject tracing headers: %v", err)
+	}
+	return sp
+}
+
+func observeCall(ctx context.Context, span opentracing.Span, start time.Time, exchange string, err error) {
+	trace.SpanComplete(span, err)
+
+	durationHistogram := trace.Histogram{
+		Observer: callDurationMetrics.WithLabelValues(exchange, strconv.FormatBool(err == nil)),
+	}
+	durationHistogram.Observe(ctx, time.Since(start).Seconds())
+}
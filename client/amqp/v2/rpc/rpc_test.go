@@ -0,0 +1,121 @@
+package rpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/streadway/amqp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+	type args struct {
+		url string
+		oo  []OptionFunc
+	}
+	tests := map[string]struct {
+		args        args
+		expectedErr string
+	}{
+		"fail, missing url":    {args: args{}, expectedErr: "url is required"},
+		"fail, invalid option": {args: args{url: "amqp://localhost", oo: []OptionFunc{Timeout(0)}}, expectedErr: "timeout should be positive"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := New(tt.args.url, tt.args.oo...)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, got)
+			}
+		})
+	}
+}
+
+func newTestClient() *Client {
+	return &Client{pending: make(map[string]chan amqp.Delivery), done: make(chan struct{})}
+}
+
+func TestClient_Multiplexing(t *testing.T) {
+	t.Parallel()
+	c := newTestClient()
+
+	first, err := c.register("1")
+	require.NoError(t, err)
+	second, err := c.register("2")
+	require.NoError(t, err)
+	returned, err := c.register("3")
+	require.NoError(t, err)
+
+	c.deliver(amqp.Delivery{CorrelationId: "2", Body: []byte("second")})
+	c.deliver(amqp.Delivery{CorrelationId: "1", Body: []byte("first")})
+	c.deliver(amqp.Delivery{CorrelationId: "unknown"})
+	c.reject(amqp.Return{CorrelationId: "3"})
+
+	assert.Equal(t, "first", string((<-first).Body))
+	assert.Equal(t, "second", string((<-second).Body))
+	_, ok := <-returned
+	assert.False(t, ok)
+	assert.Empty(t, c.pending)
+
+	// a late reply of a call which is not pending anymore is dropped
+	reply, err := c.register("4")
+	require.NoError(t, err)
+	c.unregister("4")
+	c.deliver(amqp.Delivery{CorrelationId: "4"})
+	assert.Empty(t, reply)
+}
+
+func TestClient_Fail(t *testing.T) {
+	t.Parallel()
+	c := newTestClient()
+	_, err := c.register("1")
+	require.NoError(t, err)
+
+	c.fail(ErrClosed)
+	c.fail(ErrClosed)
+	_, ok := <-c.done
+	assert.False(t, ok)
+	assert.Equal(t, ErrClosed, c.closedErr())
+
+	_, err = c.register("2")
+	assert.Equal(t, ErrClosed, err)
+}
+
+func TestClient_Receive(t *testing.T) {
+	t.Parallel()
+	c := newTestClient()
+	reply, err := c.register("1")
+	require.NoError(t, err)
+
+	replies := make(chan amqp.Delivery)
+	returns := make(chan amqp.Return)
+	go c.receive(replies, returns)
+
+	close(returns)
+	replies <- amqp.Delivery{CorrelationId: "1"}
+	assert.Equal(t, "1", (<-reply).CorrelationId)
+
+	close(replies)
+	<-c.done
+	assert.Equal(t, ErrClosed, c.closedErr())
+}
+
+func Test_injectTraceHeaders(t *testing.T) {
+	mtr := mocktracer.New()
+	opentracing.SetGlobalTracer(mtr)
+	t.Cleanup(func() { mtr.Reset() })
+	msg := amqp.Publishing{}
+	sp := injectTraceHeaders(context.Background(), "", "rpc-queue", &msg)
+	assert.NotNil(t, sp)
+	assert.NotEmpty(t, msg.Headers)
+}
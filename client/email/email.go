@@ -0,0 +1,288 @@
+// Package email provides an instrumented client for sending transactional emails, which renders templated messages,
+// drops suppressed recipients and redirects messages in sandbox mode, e.g. outside production. The messages are sent
+// via a Sender, which is either the SMTP sender of the package or an adapter of an email API provider.
+package email
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/beatlabs/patron/log"
+	"github.com/beatlabs/patron/trace"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	component = "email-client"
+
+	sentOutcome       = "sent"
+	failedOutcome     = "failed"
+	suppressedOutcome = "suppressed"
+	sandboxedOutcome  = "sandboxed"
+
+	// SandboxRecipientsHeader is the header holding the original recipients of a message redirected in sandbox mode.
+	SandboxRecipientsHeader = "X-Sandbox-Recipients"
+)
+
+var (
+	// ErrSuppressed is returned when all the recipients of a message are suppressed, and the message is not sent.
+	ErrSuppressed = errors.New("all recipients are suppressed")
+
+	messagesMetric     *prometheus.CounterVec
+	sendDurationMetric *prometheus.HistogramVec
+)
+
+func init() {
+	messagesMetric = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "client",
+			Subsystem: "email",
+			Name:      "messages_total",
+			Help:      "Total number of messages handled by the email client, classified by outcome.",
+		},
+		[]string{"outcome"},
+	)
+	sendDurationMetric = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "client",
+			Subsystem: "email",
+			Name:      "send_duration_seconds",
+			Help:      "Email messages sent by the client.",
+		},
+		[]string{"success"},
+	)
+	prometheus.MustRegister(messagesMetric, sendDurationMetric)
+}
+
+// Attachment is a file attached to a message, whose content is streamed while sending the message.
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Content     io.Reader
+}
+
+// Message is an email message. Addresses are in RFC 5322 format, e.g. "Jane Doe <jane@example.com>".
+type Message struct {
+	From        string
+	To          []string
+	Cc          []string
+	Bcc         []string
+	ReplyTo     string
+	Subject     string
+	Text        string
+	HTML        string
+	Headers     map[string]string
+	Attachments []Attachment
+}
+
+// Recipients returns the recipients of the message, including the blind carbon copies.
+func (m *Message) Recipients() []string {
+	rr := make([]string, 0, len(m.To)+len(m.Cc)+len(m.Bcc))
+	rr = append(rr, m.To...)
+	rr = append(rr, m.Cc...)
+	return append(rr, m.Bcc...)
+}
+
+func (m *Message) validate() error {
+	if m.From == "" {
+		return errors.New("sender is empty")
+	}
+	if _, err := mail.ParseAddress(m.From); err != nil {
+		return fmt.Errorf("invalid sender %q: %w", m.From, err)
+	}
+	if m.ReplyTo != "" {
+		if _, err := mail.ParseAddress(m.ReplyTo); err != nil {
+			return fmt.Errorf("invalid reply to %q: %w", m.ReplyTo, err)
+		}
+	}
+	rr := m.Recipients()
+	if len(rr) == 0 {
+		return errors.New("recipients are empty")
+	}
+	for _, r := range rr {
+		if _, err := mail.ParseAddress(r); err != nil {
+			return fmt.Errorf("invalid recipient %q: %w", r, err)
+		}
+	}
+	if m.Text == "" && m.HTML == "" {
+		return errors.New("body is empty")
+	}
+	for k := range m.Headers {
+		if k == "" || strings.ContainsAny(k, ": \t\r\n") {
+			return fmt.Errorf("invalid header %q", k)
+		}
+	}
+	for _, a := range m.Attachments {
+		if a.Filename == "" {
+			return errors.New("attachment filename is empty")
+		}
+		if a.Content == nil {
+			return fmt.Errorf("content of attachment %s is nil", a.Filename)
+		}
+	}
+	return nil
+}
+
+// Sender sends messages, e.g. over SMTP or via the API of an email provider.
+type Sender interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// SuppressFunc reports whether a recipient address is suppressed, e.g. because of previous bounces, complaints or unsubscribing.
+type SuppressFunc func(ctx context.Context, address string) (bool, error)
+
+// Client sends messages with a sender.
+type Client struct {
+	sender            Sender
+	suppress          SuppressFunc
+	sandbox           bool
+	sandboxRecipients []string
+}
+
+// New creates a client, which sends messages with the provided sender.
+func New(sender Sender, oo ...OptionFunc) (*Client, error) {
+	if sender == nil {
+		return nil, errors.New("sender is nil")
+	}
+
+	c := &Client{sender: sender}
+
+	for _, optionFunc := range oo {
+		if err := optionFunc(c); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// Send sends the message to its recipients, except for the suppressed ones. If all of them are suppressed,
+// the message is not sent and ErrSuppressed is returned. In sandbox mode, the message is sent to the sandbox recipients instead,
+// or only logged if there are none.
+func (c *Client) Send(ctx context.Context, msg *Message) error {
+	if msg == nil {
+		return errors.New("message is nil")
+	}
+	if err := msg.validate(); err != nil {
+		return err
+	}
+
+	sp, ctx := trace.ChildSpan(ctx, trace.ComponentOpName(component, "send"), component, ext.SpanKindRPCClient,
+		opentracing.Tag{Key: "recipients", Value: len(msg.Recipients())})
+
+	outcome, err := c.send(ctx, msg)
+	messagesMetric.WithLabelValues(outcome).Inc()
+	sp.SetTag("outcome", outcome)
+	trace.SpanComplete(sp, err)
+	return err
+}
+
+// SendTemplate renders the subject and the bodies of the message with the template and the provided data, and sends it.
+func (c *Client) SendTemplate(ctx context.Context, msg *Message, tmpl *Template, data interface{}) error {
+	if msg == nil {
+		return errors.New("message is nil")
+	}
+	if tmpl == nil {
+		return errors.New("template is nil")
+	}
+
+	rendered := *msg
+	var err error
+	rendered.Subject, rendered.Text, rendered.HTML, err = tmpl.Render(data)
+	if err != nil {
+		return err
+	}
+	return c.Send(ctx, &rendered)
+}
+
+func (c *Client) send(ctx context.Context, msg *Message) (string, error) {
+	if c.suppress != nil {
+		var err error
+		msg, err = c.unsuppressed(ctx, msg)
+		if err != nil {
+			return failedOutcome, err
+		}
+		if msg == nil {
+			return suppressedOutcome, ErrSuppressed
+		}
+	}
+
+	if c.sandbox {
+		if len(c.sandboxRecipients) == 0 {
+			log.FromContext(ctx).Infof("sandbox mode: skipping message %q to %s", msg.Subject, strings.Join(msg.Recipients(), ", "))
+			return sandboxedOutcome, nil
+		}
+		msg = c.redirect(msg)
+	}
+
+	start := time.Now()
+	err := c.sender.Send(ctx, msg)
+	sendDurationMetric.WithLabelValues(strconv.FormatBool(err == nil)).Observe(time.Since(start).Seconds())
+	if err != nil {
+		return failedOutcome, fmt.Errorf("failed to send message: %w", err)
+	}
+	if c.sandbox {
+		return sandboxedOutcome, nil
+	}
+	return sentOutcome, nil
+}
+
+// unsuppressed returns a copy of the message without the suppressed recipients, or nil if all of them are suppressed.
+func (c *Client) unsuppressed(ctx context.Context, msg *Message) (*Message, error) {
+	filter := func(rr []string) ([]string, error) {
+		var kept []string
+		for _, r := range rr {
+			addr, err := mail.ParseAddress(r)
+			if err != nil {
+				return nil, err
+			}
+			suppressed, err := c.suppress(ctx, addr.Address)
+			if err != nil {
+				return nil, fmt.Errorf("failed to check suppression of %s: %w", addr.Address, err)
+			}
+			if suppressed {
+				log.FromContext(ctx).Debugf("suppressing recipient %s", addr.Address)
+				continue
+			}
+			kept = append(kept, r)
+		}
+		return kept, nil
+	}
+
+	filtered := *msg
+	var err error
+	if filtered.To, err = filter(msg.To); err != nil {
+		return nil, err
+	}
+	if filtered.Cc, err = filter(msg.Cc); err != nil {
+		return nil, err
+	}
+	if filtered.Bcc, err = filter(msg.Bcc); err != nil {
+		return nil, err
+	}
+	if len(filtered.Recipients()) == 0 {
+		return nil, nil
+	}
+	return &filtered, nil
+}
+
+// redirect returns a copy of the message addressed to the sandbox recipients, recording the original ones in a header.
+func (c *Client) redirect(msg *Message) *Message {
+	redirected := *msg
+	redirected.Headers = make(map[string]string, len(msg.Headers)+1)
+	for k, v := range msg.Headers {
+		redirected.Headers[k] = v
+	}
+	redirected.Headers[SandboxRecipientsHeader] = strings.Join(msg.Recipients(), ", ")
+	redirected.To = c.sandboxRecipients
+	redirected.Cc = nil
+	redirected.Bcc = nil
+	return &redirected
+}
@@ -0,0 +1,200 @@
+package email
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeSender struct {
+	sync.Mutex
+	err  error
+	sent []*Message
+}
+
+func (fs *fakeSender) Send(_ context.Context, msg *Message) error {
+	fs.Lock()
+	defer fs.Unlock()
+	if fs.err != nil {
+		return fs.err
+	}
+	fs.sent = append(fs.sent, msg)
+	return nil
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		sender      Sender
+		oo          []OptionFunc
+		expectedErr string
+	}{
+		"success":        {sender: &fakeSender{}, oo: []OptionFunc{Sandbox()}},
+		"missing sender": {expectedErr: "sender is nil"},
+		"option failed":  {sender: &fakeSender{}, oo: []OptionFunc{Suppression(nil)}, expectedErr: "suppress func is nil"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			got, err := New(tt.sender, tt.oo...)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, got)
+			}
+		})
+	}
+}
+
+func testMessage() *Message {
+	return &Message{
+		From:    "Orders <orders@example.com>",
+		To:      []string{"jane@example.com"},
+		Cc:      []string{"John Doe <john@example.com>"},
+		Bcc:     []string{"audit@example.com"},
+		Subject: "Your order",
+		Text:    "Thank you for your order.",
+	}
+}
+
+func TestClient_Send_Validation(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		modify      func(*Message)
+		expectedErr string
+	}{
+		"missing sender":       {modify: func(m *Message) { m.From = "" }, expectedErr: "sender is empty"},
+		"invalid sender":       {modify: func(m *Message) { m.From = "orders" }, expectedErr: `invalid sender "orders": mail: missing '@' or angle-addr`},
+		"invalid reply to":     {modify: func(m *Message) { m.ReplyTo = "support" }, expectedErr: `invalid reply to "support": mail: missing '@' or angle-addr`},
+		"missing recipients":   {modify: func(m *Message) { m.To, m.Cc, m.Bcc = nil, nil, nil }, expectedErr: "recipients are empty"},
+		"invalid recipient":    {modify: func(m *Message) { m.Bcc = []string{"audit"} }, expectedErr: `invalid recipient "audit": mail: missing '@' or angle-addr`},
+		"missing body":         {modify: func(m *Message) { m.Text = "" }, expectedErr: "body is empty"},
+		"invalid header":       {modify: func(m *Message) { m.Headers = map[string]string{"X-Bad\r\nBcc": "x"} }, expectedErr: `invalid header "X-Bad\r\nBcc"`},
+		"missing filename":     {modify: func(m *Message) { m.Attachments = []Attachment{{Content: strings.NewReader("")}} }, expectedErr: "attachment filename is empty"},
+		"missing attachment":   {modify: func(m *Message) { m.Attachments = []Attachment{{Filename: "a.pdf"}} }, expectedErr: "content of attachment a.pdf is nil"},
+		"valid with html only": {modify: func(m *Message) { m.Text, m.HTML = "", "<p>Thank you</p>" }},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			c, err := New(&fakeSender{})
+			require.NoError(t, err)
+			msg := testMessage()
+			tt.modify(msg)
+			err = c.Send(context.Background(), msg)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+
+	c, err := New(&fakeSender{})
+	require.NoError(t, err)
+	assert.EqualError(t, c.Send(context.Background(), nil), "message is nil")
+}
+
+func TestClient_Send(t *testing.T) {
+	t.Parallel()
+	suppressed := map[string]bool{"jane@example.com": true, "john@example.com": true}
+	suppressAll := func(_ context.Context, address string) (bool, error) {
+		return suppressed[address] || address == "audit@example.com", nil
+	}
+	suppressSome := func(_ context.Context, address string) (bool, error) {
+		return suppressed[address], nil
+	}
+	suppressErr := func(context.Context, string) (bool, error) {
+		return false, errors.New("lookup failed")
+	}
+	tests := map[string]struct {
+		oo          []OptionFunc
+		senderErr   error
+		expectedErr string
+		expected    *Message
+	}{
+		"sent": {expected: testMessage()},
+		"send failure": {
+			senderErr: errors.New("connection refused"), expectedErr: "failed to send message: connection refused",
+		},
+		"some suppressed": {
+			oo:       []OptionFunc{Suppression(suppressSome)},
+			expected: &Message{From: "Orders <orders@example.com>", Bcc: []string{"audit@example.com"}, Subject: "Your order", Text: "Thank you for your order."},
+		},
+		"all suppressed": {
+			oo: []OptionFunc{Suppression(suppressAll)}, expectedErr: ErrSuppressed.Error(),
+		},
+		"suppression failure": {
+			oo: []OptionFunc{Suppression(suppressErr)}, expectedErr: "failed to check suppression of jane@example.com: lookup failed",
+		},
+		"sandbox without recipients": {
+			oo: []OptionFunc{Sandbox()},
+		},
+		"sandbox with recipients": {
+			oo: []OptionFunc{Sandbox("qa@example.com")},
+			expected: &Message{
+				From: "Orders <orders@example.com>", To: []string{"qa@example.com"}, Subject: "Your order", Text: "Thank you for your order.",
+				Headers: map[string]string{SandboxRecipientsHeader: "jane@example.com, John Doe <john@example.com>, audit@example.com"},
+			},
+		},
+		"sandbox of unsuppressed recipients": {
+			oo: []OptionFunc{Suppression(suppressSome), Sandbox("qa@example.com")},
+			expected: &Message{
+				From: "Orders <orders@example.com>", To: []string{"qa@example.com"}, Subject: "Your order", Text: "Thank you for your order.",
+				Headers: map[string]string{SandboxRecipientsHeader: "audit@example.com"},
+			},
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			sender := &fakeSender{err: tt.senderErr}
+			c, err := New(sender, tt.oo...)
+			require.NoError(t, err)
+			msg := testMessage()
+			err = c.Send(context.Background(), msg)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			if tt.expected == nil {
+				assert.Empty(t, sender.sent)
+			} else {
+				require.Len(t, sender.sent, 1)
+				assert.Equal(t, tt.expected, sender.sent[0])
+			}
+			assert.Equal(t, testMessage(), msg)
+		})
+	}
+}
+
+func TestClient_SendTemplate(t *testing.T) {
+	t.Parallel()
+	sender := &fakeSender{}
+	c, err := New(sender)
+	require.NoError(t, err)
+	tmpl, err := NewTemplate("Order {{.ID}}", "Order {{.ID}} is confirmed.", "<p>Order {{.ID}} is confirmed.</p>")
+	require.NoError(t, err)
+
+	assert.EqualError(t, c.SendTemplate(context.Background(), nil, tmpl, nil), "message is nil")
+	assert.EqualError(t, c.SendTemplate(context.Background(), testMessage(), nil, nil), "template is nil")
+	assert.EqualError(t, c.SendTemplate(context.Background(), testMessage(), tmpl, map[string]string{}),
+		`failed to render subject: template: subject:1:8: executing "subject" at <.ID>: map has no entry for key "ID"`)
+
+	require.NoError(t, c.SendTemplate(context.Background(), testMessage(), tmpl, map[string]string{"ID": "<42>"}))
+	require.Len(t, sender.sent, 1)
+	assert.Equal(t, "Order <42>", sender.sent[0].Subject)
+	assert.Equal(t, "Order <42> is confirmed.", sender.sent[0].Text)
+	assert.Equal(t, "<p>Order &lt;42&gt; is confirmed.</p>", sender.sent[0].HTML)
+}
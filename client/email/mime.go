@@ -0,0 +1,236 @@
+package email
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	maxLineLength = 76
+	crlf          = "\r\n"
+)
+
+type createPartFunc func(header textproto.MIMEHeader) (io.Writer, error)
+
+// WriteMIME writes the message in MIME format, e.g. for sending it over SMTP or via the API of a provider accepting raw messages.
+// The Bcc recipients are not written, and the content of the attachments is streamed to the writer.
+func (m *Message) WriteMIME(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+
+	if err := m.writeHeader(bw); err != nil {
+		return err
+	}
+	create := func(header textproto.MIMEHeader) (io.Writer, error) {
+		keys := make([]string, 0, len(header))
+		for k := range header {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if _, err := fmt.Fprintf(bw, "%s: %s%s", k, header.Get(k), crlf); err != nil {
+				return nil, err
+			}
+		}
+		_, err := bw.WriteString(crlf)
+		return bw, err
+	}
+
+	if len(m.Attachments) == 0 {
+		if err := writeBody(create, m.Text, m.HTML); err != nil {
+			return err
+		}
+		return bw.Flush()
+	}
+
+	mixed, err := createMultipart(create, "multipart/mixed")
+	if err != nil {
+		return err
+	}
+	if err := writeBody(mixed.CreatePart, m.Text, m.HTML); err != nil {
+		return err
+	}
+	for _, a := range m.Attachments {
+		if err := writeAttachment(mixed, a); err != nil {
+			return fmt.Errorf("failed to write attachment %s: %w", a.Filename, err)
+		}
+	}
+	if err := mixed.Close(); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+func (m *Message) writeHeader(w io.Writer) error {
+	header := func(key, value string) error {
+		_, err := fmt.Fprintf(w, "%s: %s%s", key, value, crlf)
+		return err
+	}
+	addresses := func(key string, rr []string) error {
+		if len(rr) == 0 {
+			return nil
+		}
+		formatted := make([]string, 0, len(rr))
+		for _, r := range rr {
+			addr, err := mail.ParseAddress(r)
+			if err != nil {
+				return err
+			}
+			formatted = append(formatted, addr.String())
+		}
+		return header(key, strings.Join(formatted, ", "))
+	}
+
+	if err := addresses("From", []string{m.From}); err != nil {
+		return err
+	}
+	if m.ReplyTo != "" {
+		if err := addresses("Reply-To", []string{m.ReplyTo}); err != nil {
+			return err
+		}
+	}
+	if err := addresses("To", m.To); err != nil {
+		return err
+	}
+	if err := addresses("Cc", m.Cc); err != nil {
+		return err
+	}
+	if err := header("Subject", mime.QEncoding.Encode("utf-8", m.Subject)); err != nil {
+		return err
+	}
+	if err := header("Date", time.Now().Format(time.RFC1123Z)); err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(m.Headers))
+	for k := range m.Headers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if err := header(textproto.CanonicalMIMEHeaderKey(k), mime.QEncoding.Encode("utf-8", m.Headers[k])); err != nil {
+			return err
+		}
+	}
+	return header("MIME-Version", "1.0")
+}
+
+// createMultipart creates a part of the provided multipart type, returning a writer of its nested parts.
+func createMultipart(create createPartFunc, mediaType string) (*multipart.Writer, error) {
+	boundary := multipart.NewWriter(ioutil.Discard).Boundary()
+	part, err := create(textproto.MIMEHeader{"Content-Type": {mime.FormatMediaType(mediaType, map[string]string{"boundary": boundary})}})
+	if err != nil {
+		return nil, err
+	}
+	mw := multipart.NewWriter(part)
+	if err := mw.SetBoundary(boundary); err != nil {
+		return nil, err
+	}
+	return mw, nil
+}
+
+// writeBody writes the text and HTML bodies as alternatives, or the one of them which is not empty.
+func writeBody(create createPartFunc, text, html string) error {
+	if text == "" || html == "" {
+		if text != "" {
+			return writeText(create, "text/plain", text)
+		}
+		return writeText(create, "text/html", html)
+	}
+
+	alternative, err := createMultipart(create, "multipart/alternative")
+	if err != nil {
+		return err
+	}
+	if err := writeText(alternative.CreatePart, "text/plain", text); err != nil {
+		return err
+	}
+	if err := writeText(alternative.CreatePart, "text/html", html); err != nil {
+		return err
+	}
+	return alternative.Close()
+}
+
+func writeText(create createPartFunc, mediaType, content string) error {
+	part, err := create(textproto.MIMEHeader{
+		"Content-Type":              {mime.FormatMediaType(mediaType, map[string]string{"charset": "utf-8"})},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	if err != nil {
+		return err
+	}
+	qw := quotedprintable.NewWriter(part)
+	if _, err := io.WriteString(qw, content); err != nil {
+		return err
+	}
+	return qw.Close()
+}
+
+func writeAttachment(mw *multipart.Writer, a Attachment) error {
+	contentType := a.ContentType
+	if contentType == "" {
+		contentType = mime.TypeByExtension(filepath.Ext(a.Filename))
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	part, err := mw.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {contentType},
+		"Content-Transfer-Encoding": {"base64"},
+		"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": a.Filename})},
+	})
+	if err != nil {
+		return err
+	}
+	enc := base64.NewEncoder(base64.StdEncoding, &lineWriter{w: part})
+	if _, err := io.Copy(enc, a.Content); err != nil {
+		return err
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	_, err = io.WriteString(part, crlf)
+	return err
+}
+
+// lineWriter breaks the written content into lines of the maximum length allowed in messages.
+type lineWriter struct {
+	w       io.Writer
+	written int
+}
+
+func (lw *lineWriter) Write(p []byte) (int, error) {
+	n := 0
+	for len(p) > 0 {
+		if lw.written == maxLineLength {
+			if _, err := io.WriteString(lw.w, crlf); err != nil {
+				return n, err
+			}
+			lw.written = 0
+		}
+		chunk := p
+		if len(chunk) > maxLineLength-lw.written {
+			chunk = chunk[:maxLineLength-lw.written]
+		}
+		written, err := lw.w.Write(chunk)
+		n += written
+		lw.written += written
+		if err != nil {
+			return n, err
+		}
+		p = p[written:]
+	}
+	return n, nil
+}
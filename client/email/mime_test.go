@@ -0,0 +1,120 @@
+package email
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessage_WriteMIME(t *testing.T) {
+	t.Parallel()
+	attachment := strings.Repeat("0123456789", 20)
+	msg := &Message{
+		From:    "Orders <orders@example.com>",
+		To:      []string{"jane@example.com", "John Doe <john@example.com>"},
+		Cc:      []string{"support@example.com"},
+		Bcc:     []string{"audit@example.com"},
+		ReplyTo: "support@example.com",
+		Subject: "Η παραγγελία σας",
+		Text:    "Thank you for your order.",
+		HTML:    "<p>Thank you for your order.</p>",
+		Headers: map[string]string{"x-campaign": "orders"},
+		Attachments: []Attachment{
+			{Filename: "invoice.pdf", Content: strings.NewReader(attachment)},
+			{Filename: "data", Content: strings.NewReader("data")},
+		},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, msg.WriteMIME(&buf))
+
+	for _, line := range strings.Split(buf.String(), "\r\n") {
+		assert.LessOrEqual(t, len(line), 998)
+	}
+	parsed, err := mail.ReadMessage(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, `"Orders" <orders@example.com>`, parsed.Header.Get("From"))
+	assert.Equal(t, `<jane@example.com>, "John Doe" <john@example.com>`, parsed.Header.Get("To"))
+	assert.Equal(t, "<support@example.com>", parsed.Header.Get("Cc"))
+	assert.Equal(t, "<support@example.com>", parsed.Header.Get("Reply-To"))
+	assert.Empty(t, parsed.Header.Get("Bcc"))
+	assert.Equal(t, "orders", parsed.Header.Get("X-Campaign"))
+	assert.Equal(t, "1.0", parsed.Header.Get("MIME-Version"))
+	assert.NotEmpty(t, parsed.Header.Get("Date"))
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	require.NoError(t, err)
+	assert.Equal(t, "Η παραγγελία σας", subject)
+
+	mixed := multipartReader(t, parsed.Header.Get("Content-Type"), "multipart/mixed", parsed.Body)
+
+	body, err := mixed.NextPart()
+	require.NoError(t, err)
+	alternative := multipartReader(t, body.Header.Get("Content-Type"), "multipart/alternative", body)
+	part, err := alternative.NextPart()
+	require.NoError(t, err)
+	assert.Equal(t, "text/plain; charset=utf-8", part.Header.Get("Content-Type"))
+	assert.Equal(t, "Thank you for your order.", readAll(t, part))
+	part, err = alternative.NextPart()
+	require.NoError(t, err)
+	assert.Equal(t, "text/html; charset=utf-8", part.Header.Get("Content-Type"))
+	assert.Equal(t, "<p>Thank you for your order.</p>", readAll(t, part))
+	_, err = alternative.NextPart()
+	assert.Equal(t, io.EOF, err)
+
+	part, err = mixed.NextPart()
+	require.NoError(t, err)
+	assert.Equal(t, "application/pdf", part.Header.Get("Content-Type"))
+	assert.Equal(t, "invoice.pdf", part.FileName())
+	assert.Equal(t, attachment, readAll(t, part))
+	part, err = mixed.NextPart()
+	require.NoError(t, err)
+	assert.Equal(t, "application/octet-stream", part.Header.Get("Content-Type"))
+	assert.Equal(t, "data", readAll(t, part))
+	_, err = mixed.NextPart()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestMessage_WriteMIME_SingleBody(t *testing.T) {
+	t.Parallel()
+	msg := &Message{From: "orders@example.com", To: []string{"jane@example.com"}, Subject: "Order", HTML: "<p>" + strings.Repeat("long line ", 20) + "</p>"}
+
+	var buf bytes.Buffer
+	require.NoError(t, msg.WriteMIME(&buf))
+
+	parsed, err := mail.ReadMessage(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, "Order", parsed.Header.Get("Subject"))
+	assert.Equal(t, "text/html; charset=utf-8", parsed.Header.Get("Content-Type"))
+	assert.Equal(t, "quoted-printable", parsed.Header.Get("Content-Transfer-Encoding"))
+	data, err := ioutil.ReadAll(quotedprintable.NewReader(parsed.Body))
+	require.NoError(t, err)
+	assert.Equal(t, msg.HTML, string(data))
+}
+
+func multipartReader(t *testing.T, contentType, expectedType string, r io.Reader) *multipart.Reader {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	require.NoError(t, err)
+	require.Equal(t, expectedType, mediaType)
+	return multipart.NewReader(r, params["boundary"])
+}
+
+// readAll reads the decoded content of a part, since the multipart reader decodes only quoted-printable parts.
+func readAll(t *testing.T, part *multipart.Part) string {
+	var r io.Reader = part
+	if part.Header.Get("Content-Transfer-Encoding") == "base64" {
+		r = base64.NewDecoder(base64.StdEncoding, part)
+	}
+	data, err := ioutil.ReadAll(r)
+	require.NoError(t, err)
+	return string(data)
+}
@@ -0,0 +1,36 @@
+package email
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+)
+
+// OptionFunc definition for configuring the client in a functional way.
+type OptionFunc func(*Client) error
+
+// Suppression sets the func which checks every recipient, in order to drop the suppressed ones.
+func Suppression(suppress SuppressFunc) OptionFunc {
+	return func(c *Client) error {
+		if suppress == nil {
+			return errors.New("suppress func is nil")
+		}
+		c.suppress = suppress
+		return nil
+	}
+}
+
+// Sandbox enables the sandbox mode e.g. for non-production environments, where messages are sent to the provided recipients
+// instead of their own, or are only logged if no recipients are provided.
+func Sandbox(recipients ...string) OptionFunc {
+	return func(c *Client) error {
+		for _, r := range recipients {
+			if _, err := mail.ParseAddress(r); err != nil {
+				return fmt.Errorf("invalid sandbox recipient %q: %w", r, err)
+			}
+		}
+		c.sandbox = true
+		c.sandboxRecipients = recipients
+		return nil
+	}
+}
@@ -0,0 +1,44 @@
+package email
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSuppression(t *testing.T) {
+	t.Parallel()
+	c := &Client{}
+	assert.EqualError(t, Suppression(nil)(c), "suppress func is nil")
+	assert.NoError(t, Suppression(func(context.Context, string) (bool, error) { return false, nil })(c))
+	assert.NotNil(t, c.suppress)
+}
+
+func TestSandbox(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		recipients  []string
+		expectedErr string
+	}{
+		"without recipients": {},
+		"with recipients":    {recipients: []string{"QA <qa@example.com>"}},
+		"invalid recipient":  {recipients: []string{"qa"}, expectedErr: `invalid sandbox recipient "qa": mail: missing '@' or angle-addr`},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			c := &Client{}
+			err := Sandbox(tt.recipients...)(c)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.False(t, c.sandbox)
+			} else {
+				assert.NoError(t, err)
+				assert.True(t, c.sandbox)
+				assert.Equal(t, tt.recipients, c.sandboxRecipients)
+			}
+		})
+	}
+}
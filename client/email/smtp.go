@@ -0,0 +1,117 @@
+package email
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+)
+
+// SMTP is a sender delivering messages to an SMTP server. A connection is opened per message,
+// which is upgraded with STARTTLS if the server supports it.
+type SMTP struct {
+	addr      string
+	host      string
+	auth      smtp.Auth
+	tlsConfig *tls.Config
+}
+
+// NewSMTP creates a sender for the SMTP server of the provided address, e.g. smtp.example.com:587,
+// which authenticates with the provided auth, if not nil.
+func NewSMTP(addr string, auth smtp.Auth) (*SMTP, error) {
+	if addr == "" {
+		return nil, errors.New("address is empty")
+	}
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid address %s: %w", addr, err)
+	}
+	return &SMTP{addr: addr, host: host, auth: auth, tlsConfig: &tls.Config{ServerName: host, MinVersion: tls.VersionTLS12}}, nil
+}
+
+// Send delivers the message to the server, streaming its attachments.
+func (s *SMTP) Send(ctx context.Context, msg *Message) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return fmt.Errorf("failed to dial: %w", err)
+	}
+	// closing the connection aborts the conversation with the server when the context is done, e.g. on its deadline
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = conn.Close()
+		case <-done:
+		}
+	}()
+
+	c, err := smtp.NewClient(conn, s.host)
+	if err != nil {
+		_ = conn.Close()
+		return contextErr(ctx, err)
+	}
+	defer func() {
+		_ = c.Close()
+	}()
+
+	if err := s.deliver(c, msg); err != nil {
+		return contextErr(ctx, err)
+	}
+	return nil
+}
+
+func (s *SMTP) deliver(c *smtp.Client, msg *Message) error {
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(s.tlsConfig); err != nil {
+			return fmt.Errorf("failed to start tls: %w", err)
+		}
+	}
+	if s.auth != nil {
+		if err := c.Auth(s.auth); err != nil {
+			return fmt.Errorf("failed to authenticate: %w", err)
+		}
+	}
+
+	from, err := mail.ParseAddress(msg.From)
+	if err != nil {
+		return err
+	}
+	if err := c.Mail(from.Address); err != nil {
+		return err
+	}
+	for _, r := range msg.Recipients() {
+		to, err := mail.ParseAddress(r)
+		if err != nil {
+			return err
+		}
+		if err := c.Rcpt(to.Address); err != nil {
+			return fmt.Errorf("recipient %s rejected: %w", to.Address, err)
+		}
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if err := msg.WriteMIME(w); err != nil {
+		_ = w.Close()
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// contextErr returns the error of the context, if the connection was closed because of it.
+func contextErr(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
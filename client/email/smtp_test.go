@@ -0,0 +1,156 @@
+package email
+
+import (
+	"context"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSMTPServer accepts a single message, rejecting the configured recipient.
+type fakeSMTPServer struct {
+	ln       net.Listener
+	reject   string
+	hang     bool
+	from     string
+	rcpts    []string
+	data     string
+	finished chan struct{}
+}
+
+func newFakeSMTPServer(t *testing.T, reject string, hang bool) *fakeSMTPServer {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := &fakeSMTPServer{ln: ln, reject: reject, hang: hang, finished: make(chan struct{})}
+	go s.serve()
+	t.Cleanup(func() {
+		_ = ln.Close()
+	})
+	return s
+}
+
+func (s *fakeSMTPServer) serve() {
+	defer close(s.finished)
+	conn, err := s.ln.Accept()
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+	tc := textproto.NewConn(conn)
+	if s.hang {
+		// wait for the client to close the connection
+		_, _ = tc.ReadLine()
+		return
+	}
+	_ = tc.PrintfLine("220 localhost ESMTP")
+	for {
+		line, err := tc.ReadLine()
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch {
+		case cmd == "EHLO":
+			_ = tc.PrintfLine("250 localhost")
+		case strings.HasPrefix(line, "MAIL FROM:"):
+			s.from = strings.TrimPrefix(line, "MAIL FROM:")
+			_ = tc.PrintfLine("250 OK")
+		case strings.HasPrefix(line, "RCPT TO:"):
+			rcpt := strings.TrimPrefix(line, "RCPT TO:")
+			if rcpt == "<"+s.reject+">" {
+				_ = tc.PrintfLine("550 mailbox unavailable")
+				continue
+			}
+			s.rcpts = append(s.rcpts, rcpt)
+			_ = tc.PrintfLine("250 OK")
+		case cmd == "DATA":
+			_ = tc.PrintfLine("354 go ahead")
+			data, err := tc.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			s.data = string(data)
+			_ = tc.PrintfLine("250 OK")
+		case cmd == "QUIT":
+			_ = tc.PrintfLine("221 bye")
+			return
+		default:
+			_ = tc.PrintfLine("502 not implemented")
+		}
+	}
+}
+
+func TestNewSMTP(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		addr        string
+		expectedErr string
+	}{
+		"success":         {addr: "smtp.example.com:587"},
+		"missing address": {expectedErr: "address is empty"},
+		"invalid address": {addr: "smtp.example.com", expectedErr: "invalid address smtp.example.com: address smtp.example.com: missing port in address"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			got, err := NewSMTP(tt.addr, nil)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, "smtp.example.com", got.host)
+			}
+		})
+	}
+}
+
+func TestSMTP_Send(t *testing.T) {
+	t.Parallel()
+	server := newFakeSMTPServer(t, "", false)
+	sender, err := NewSMTP(server.ln.Addr().String(), nil)
+	require.NoError(t, err)
+
+	msg := testMessage()
+	msg.Attachments = []Attachment{{Filename: "order.txt", Content: strings.NewReader("order")}}
+	require.NoError(t, sender.Send(context.Background(), msg))
+	<-server.finished
+
+	assert.Equal(t, "<orders@example.com>", server.from)
+	assert.Equal(t, []string{"<jane@example.com>", "<john@example.com>", "<audit@example.com>"}, server.rcpts)
+	assert.Contains(t, server.data, "Subject: Your order\n")
+	assert.Contains(t, server.data, "Thank you for your order.")
+	assert.Contains(t, server.data, `filename=order.txt`)
+	assert.NotContains(t, server.data, "audit@example.com")
+}
+
+func TestSMTP_Send_RejectedRecipient(t *testing.T) {
+	t.Parallel()
+	server := newFakeSMTPServer(t, "john@example.com", false)
+	sender, err := NewSMTP(server.ln.Addr().String(), nil)
+	require.NoError(t, err)
+
+	err = sender.Send(context.Background(), testMessage())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "recipient john@example.com rejected: 550")
+}
+
+func TestSMTP_Send_Cancelled(t *testing.T) {
+	t.Parallel()
+	server := newFakeSMTPServer(t, "", true)
+	sender, err := NewSMTP(server.ln.Addr().String(), nil)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err = sender.Send(ctx, testMessage())
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
@@ -0,0 +1,72 @@
+package email
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	texttemplate "text/template"
+)
+
+// Template renders the subject and the bodies of messages. The subject and the text body are rendered
+// with text/template, while the HTML body is rendered with html/template, which escapes the data.
+type Template struct {
+	subject *texttemplate.Template
+	text    *texttemplate.Template
+	html    *htmltemplate.Template
+}
+
+// NewTemplate parses the templates of the subject and the bodies of messages, one of which may be empty.
+func NewTemplate(subject, text, html string) (*Template, error) {
+	if subject == "" {
+		return nil, errors.New("subject template is empty")
+	}
+	if text == "" && html == "" {
+		return nil, errors.New("body templates are empty")
+	}
+
+	t := &Template{}
+	var err error
+	t.subject, err = texttemplate.New("subject").Option("missingkey=error").Parse(subject)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse subject template: %w", err)
+	}
+	if text != "" {
+		t.text, err = texttemplate.New("text").Option("missingkey=error").Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse text template: %w", err)
+		}
+	}
+	if html != "" {
+		t.html, err = htmltemplate.New("html").Option("missingkey=error").Parse(html)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse html template: %w", err)
+		}
+	}
+	return t, nil
+}
+
+// Render renders the subject and the bodies with the provided data.
+func (t *Template) Render(data interface{}) (subject, text, html string, err error) {
+	var buf bytes.Buffer
+	if err := t.subject.Execute(&buf, data); err != nil {
+		return "", "", "", fmt.Errorf("failed to render subject: %w", err)
+	}
+	subject = buf.String()
+
+	if t.text != nil {
+		buf.Reset()
+		if err := t.text.Execute(&buf, data); err != nil {
+			return "", "", "", fmt.Errorf("failed to render text: %w", err)
+		}
+		text = buf.String()
+	}
+	if t.html != nil {
+		buf.Reset()
+		if err := t.html.Execute(&buf, data); err != nil {
+			return "", "", "", fmt.Errorf("failed to render html: %w", err)
+		}
+		html = buf.String()
+	}
+	return subject, text, html, nil
+}
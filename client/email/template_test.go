@@ -0,0 +1,63 @@
+package email
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTemplate(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		subject, text, html string
+		expectedErr         string
+	}{
+		"success":         {subject: "Hi {{.Name}}", text: "Hello {{.Name}}", html: "<p>Hello {{.Name}}</p>"},
+		"text only":       {subject: "Hi", text: "Hello"},
+		"html only":       {subject: "Hi", html: "<p>Hello</p>"},
+		"missing subject": {text: "Hello", expectedErr: "subject template is empty"},
+		"missing bodies":  {subject: "Hi", expectedErr: "body templates are empty"},
+		"invalid subject": {subject: "{{.Name", text: "Hello", expectedErr: "failed to parse subject template: template: subject:1: unclosed action"},
+		"invalid text":    {subject: "Hi", text: "{{.Name", expectedErr: "failed to parse text template: template: text:1: unclosed action"},
+		"invalid html":    {subject: "Hi", html: "{{.Name", expectedErr: "failed to parse html template: template: html:1: unclosed action"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			got, err := NewTemplate(tt.subject, tt.text, tt.html)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, got)
+			}
+		})
+	}
+}
+
+func TestTemplate_Render(t *testing.T) {
+	t.Parallel()
+	tmpl, err := NewTemplate("Hi {{.Name}}", "Hello {{.Name}}", "<p>Hello {{.Name}}</p>")
+	require.NoError(t, err)
+
+	subject, text, html, err := tmpl.Render(struct{ Name string }{Name: "Tom & Jerry"})
+	assert.NoError(t, err)
+	assert.Equal(t, "Hi Tom & Jerry", subject)
+	assert.Equal(t, "Hello Tom & Jerry", text)
+	assert.Equal(t, "<p>Hello Tom &amp; Jerry</p>", html)
+
+	tmpl, err = NewTemplate("Hi", "Hello {{.Name}}", "")
+	require.NoError(t, err)
+	_, _, _, err = tmpl.Render(map[string]string{})
+	assert.EqualError(t, err, `failed to render text: template: text:1:8: executing "text" at <.Name>: map has no entry for key "Name"`)
+
+	tmpl, err = NewTemplate("Hi", "", "<p>{{.Name}}</p>")
+	require.NoError(t, err)
+	_, text, html, err = tmpl.Render(map[string]string{"Name": "Tom"})
+	assert.NoError(t, err)
+	assert.Empty(t, text)
+	assert.Equal(t, "<p>Tom</p>", html)
+}
@@ -0,0 +1,177 @@
+package es
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/beatlabs/patron/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	cacheMetrics *prometheus.CounterVec
+
+	// cacheableEndpoints are the idempotent read endpoints, whose responses can be cached.
+	cacheableEndpoints = map[string]struct{}{
+		"_search":  {},
+		"_msearch": {},
+		"_count":   {},
+	}
+)
+
+func init() {
+	cacheMetrics = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "client",
+			Subsystem: "elasticsearch",
+			Name:      "cache_total",
+			Help:      "Elasticsearch cacheable requests, classified by cache result (hit, miss or bypass).",
+		},
+		[]string{"result"},
+	)
+	prometheus.MustRegister(cacheMetrics)
+}
+
+type bypassCacheKey struct{}
+
+// BypassCache returns a context, which makes requests skip the response cache and reach Elasticsearch.
+// Fresh responses are still stored in the cache.
+func BypassCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, bypassCacheKey{}, true)
+}
+
+func cacheBypassed(ctx context.Context) bool {
+	bypass, _ := ctx.Value(bypassCacheKey{}).(bool)
+	return bypass
+}
+
+// cachedResponse is the cached representation of a response.
+type cachedResponse struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header"`
+	Body       []byte      `json:"body"`
+}
+
+// cacheKey returns the key of a cacheable request, which consists of the hash of the path,
+// the query parameters and the normalized body.
+func cacheKey(req *http.Request) (string, bool) {
+	if req.Method != http.MethodGet && req.Method != http.MethodPost {
+		return "", false
+	}
+	segments := strings.Split(strings.TrimRight(req.URL.Path, "/"), "/")
+	if _, ok := cacheableEndpoints[segments[len(segments)-1]]; !ok {
+		return "", false
+	}
+
+	var body []byte
+	if req.Body != nil {
+		raw, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			return "", false
+		}
+		req.Body = ioutil.NopCloser(bytes.NewReader(raw))
+		body = normalizeBody(raw)
+	}
+
+	h := sha256.New()
+	_, _ = h.Write([]byte(req.URL.Path))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write([]byte(req.URL.Query().Encode()))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write(body)
+	return "es:" + hex.EncodeToString(h.Sum(nil)), true
+}
+
+// normalizeBody re-encodes every JSON document of the body, which is NDJSON for multi searches,
+// so that formatting and key order differences of the same query DSL result in the same key.
+func normalizeBody(raw []byte) []byte {
+	var normalized bytes.Buffer
+	for _, line := range bytes.Split(raw, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		var doc interface{}
+		if err := json.Unmarshal(line, &doc); err != nil {
+			normalized.Write(line)
+		} else {
+			b, _ := json.Marshal(doc)
+			normalized.Write(b)
+		}
+		normalized.WriteByte('\n')
+	}
+	return normalized.Bytes()
+}
+
+// cached returns the cached response of the request, if any.
+func (c *transportClient) cached(req *http.Request, key string) (*http.Response, bool) {
+	if cacheBypassed(req.Context()) {
+		cacheMetrics.WithLabelValues("bypass").Inc()
+		return nil, false
+	}
+
+	val, ok, err := c.cache.Get(key)
+	if err != nil {
+		log.FromContext(req.Context()).Warnf("failed to get cached elasticsearch response: %v", err)
+	}
+	if !ok || err != nil {
+		cacheMetrics.WithLabelValues("miss").Inc()
+		return nil, false
+	}
+
+	var b []byte
+	switch v := val.(type) {
+	case []byte:
+		b = v
+	case string:
+		// some cache implementations, like redis, return strings
+		b = []byte(v)
+	}
+	cr := cachedResponse{}
+	if err := json.Unmarshal(b, &cr); err != nil {
+		log.FromContext(req.Context()).Warnf("failed to decode cached elasticsearch response: %v", err)
+		cacheMetrics.WithLabelValues("miss").Inc()
+		return nil, false
+	}
+
+	cacheMetrics.WithLabelValues("hit").Inc()
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", cr.StatusCode, http.StatusText(cr.StatusCode)),
+		StatusCode:    cr.StatusCode,
+		Header:        cr.Header,
+		Body:          ioutil.NopCloser(bytes.NewReader(cr.Body)),
+		ContentLength: int64(len(cr.Body)),
+		Request:       req,
+	}, true
+}
+
+// store caches successful responses, restoring their body for the caller.
+func (c *transportClient) store(req *http.Request, key string, rsp *http.Response) {
+	if rsp.StatusCode < http.StatusOK || rsp.StatusCode >= http.StatusMultipleChoices || rsp.Body == nil {
+		return
+	}
+
+	body, err := ioutil.ReadAll(rsp.Body)
+	_ = rsp.Body.Close()
+	rsp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		log.FromContext(req.Context()).Warnf("failed to read elasticsearch response for caching: %v", err)
+		return
+	}
+
+	b, err := json.Marshal(cachedResponse{StatusCode: rsp.StatusCode, Header: rsp.Header, Body: body})
+	if err != nil {
+		log.FromContext(req.Context()).Warnf("failed to encode elasticsearch response for caching: %v", err)
+		return
+	}
+	if err := c.cache.SetTTL(key, b, c.cacheTTL); err != nil {
+		log.FromContext(req.Context()).Warnf("failed to cache elasticsearch response: %v", err)
+	}
+}
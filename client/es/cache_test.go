@@ -0,0 +1,199 @@
+package es
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/beatlabs/patron/cache"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryCache struct {
+	sync.Mutex
+	values map[string]interface{}
+	ttls   map[string]time.Duration
+	getErr error
+}
+
+func newMemoryCache() *memoryCache {
+	return &memoryCache{values: make(map[string]interface{}), ttls: make(map[string]time.Duration)}
+}
+
+func (m *memoryCache) Get(key string) (interface{}, bool, error) {
+	m.Lock()
+	defer m.Unlock()
+	if m.getErr != nil {
+		return nil, false, m.getErr
+	}
+	v, ok := m.values[key]
+	return v, ok, nil
+}
+
+func (m *memoryCache) Purge() error {
+	m.Lock()
+	defer m.Unlock()
+	m.values = make(map[string]interface{})
+	return nil
+}
+
+func (m *memoryCache) Remove(key string) error {
+	m.Lock()
+	defer m.Unlock()
+	delete(m.values, key)
+	return nil
+}
+
+func (m *memoryCache) Set(key string, value interface{}) error {
+	return m.SetTTL(key, value, 0)
+}
+
+func (m *memoryCache) SetTTL(key string, value interface{}, ttl time.Duration) error {
+	m.Lock()
+	defer m.Unlock()
+	m.values[key] = value
+	m.ttls[key] = ttl
+	return nil
+}
+
+func TestCacheOption(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		cache  cache.TTLCache
+		ttl    time.Duration
+		expErr string
+	}{
+		"success":     {cache: newMemoryCache(), ttl: time.Minute},
+		"nil cache":   {ttl: time.Minute, expErr: "cache is nil"},
+		"invalid ttl": {cache: newMemoryCache(), expErr: "cache ttl should be positive"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			got, err := NewClient(Config{Addresses: []string{"http://localhost:9200"}}, Cache(tt.cache, tt.ttl))
+			if tt.expErr != "" {
+				assert.EqualError(t, err, tt.expErr)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, got)
+			}
+		})
+	}
+}
+
+func TestCacheKey(t *testing.T) {
+	t.Parallel()
+	newReq := func(method, url, body string) *http.Request {
+		req, err := http.NewRequest(method, url, strings.NewReader(body))
+		require.NoError(t, err)
+		return req
+	}
+
+	key1, ok := cacheKey(newReq(http.MethodPost, "/idx/_search?size=10&from=0", `{"query": {"match": {"a": 1}}, "size": 1}`))
+	assert.True(t, ok)
+	key2, ok := cacheKey(newReq(http.MethodPost, "/idx/_search?from=0&size=10", `{"size":1,"query":{"match":{"a":1}}}`))
+	assert.True(t, ok)
+	assert.Equal(t, key1, key2)
+
+	key3, ok := cacheKey(newReq(http.MethodPost, "/idx/_search?from=0&size=10", `{"size":2,"query":{"match":{"a":1}}}`))
+	assert.True(t, ok)
+	assert.NotEqual(t, key1, key3)
+
+	key4, ok := cacheKey(newReq(http.MethodPost, "/other/_search?from=0&size=10", `{"size":1,"query":{"match":{"a":1}}}`))
+	assert.True(t, ok)
+	assert.NotEqual(t, key1, key4)
+
+	msearch1, ok := cacheKey(newReq(http.MethodPost, "/_msearch", "{\"index\": \"idx\"}\n{\"query\": {}}\n"))
+	assert.True(t, ok)
+	msearch2, ok := cacheKey(newReq(http.MethodPost, "/_msearch", "{\"index\":\"idx\"}\n{\"query\":{}}"))
+	assert.True(t, ok)
+	assert.Equal(t, msearch1, msearch2)
+
+	_, ok = cacheKey(newReq(http.MethodGet, "/idx/_count", ""))
+	assert.True(t, ok)
+	_, ok = cacheKey(newReq(http.MethodPut, "/idx/_doc/1", `{}`))
+	assert.False(t, ok)
+	_, ok = cacheKey(newReq(http.MethodPost, "/idx/_doc", `{}`))
+	assert.False(t, ok)
+
+	// the body is still available after the key is calculated
+	req := newReq(http.MethodPost, "/idx/_search", `{"size":1}`)
+	_, ok = cacheKey(req)
+	assert.True(t, ok)
+	body, err := ioutil.ReadAll(req.Body)
+	require.NoError(t, err)
+	assert.Equal(t, `{"size":1}`, string(body))
+}
+
+func TestClient_Cache(t *testing.T) {
+	var requests int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("X-Elastic-Product", "Elasticsearch")
+		if strings.HasPrefix(r.URL.Path, "/missing") {
+			w.WriteHeader(http.StatusNotFound)
+		}
+		_, _ = w.Write([]byte(`{"hits":{"total":{"value":1}}}`))
+	}))
+	defer ts.Close()
+
+	t.Cleanup(reqDurationMetrics.Reset)
+
+	mc := newMemoryCache()
+	client, err := NewClient(Config{Addresses: []string{ts.URL}}, Cache(mc, time.Minute))
+	require.NoError(t, err)
+	cacheMetrics.Reset()
+
+	search := func(ctx context.Context, index string) string {
+		rsp, err := client.Search(client.Search.WithContext(ctx), client.Search.WithIndex(index),
+			client.Search.WithBody(strings.NewReader(`{"query":{"match_all":{}}}`)))
+		require.NoError(t, err)
+		defer func() { _ = rsp.Body.Close() }()
+		body, err := ioutil.ReadAll(rsp.Body)
+		require.NoError(t, err)
+		return string(body)
+	}
+
+	assert.Equal(t, `{"hits":{"total":{"value":1}}}`, search(context.Background(), "idx"))
+	assert.Equal(t, `{"hits":{"total":{"value":1}}}`, search(context.Background(), "idx"))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+	assert.Len(t, mc.values, 1)
+	for _, ttl := range mc.ttls {
+		assert.Equal(t, time.Minute, ttl)
+	}
+
+	// bypassing the cache reaches elasticsearch
+	assert.Equal(t, `{"hits":{"total":{"value":1}}}`, search(BypassCache(context.Background()), "idx"))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+
+	// unsuccessful responses are not cached
+	search(context.Background(), "missing")
+	search(context.Background(), "missing")
+	assert.Equal(t, int32(4), atomic.LoadInt32(&requests))
+
+	// non search requests are not cached
+	rsp, err := client.Index("idx", strings.NewReader(`{}`))
+	require.NoError(t, err)
+	_ = rsp.Body.Close()
+	assert.Equal(t, int32(5), atomic.LoadInt32(&requests))
+
+	// cache failures fall back to elasticsearch
+	mc.getErr = errors.New("cache failure")
+	search(context.Background(), "idx")
+	assert.Equal(t, int32(6), atomic.LoadInt32(&requests))
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(cacheMetrics.WithLabelValues("hit")))
+	assert.Equal(t, 4.0, testutil.ToFloat64(cacheMetrics.WithLabelValues("miss")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(cacheMetrics.WithLabelValues("bypass")))
+}
@@ -12,6 +12,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/beatlabs/patron/cache"
 	"github.com/beatlabs/patron/log"
 	"github.com/beatlabs/patron/trace"
 	"github.com/elastic/elastic-transport-go/v8/elastictransport"
@@ -97,12 +98,26 @@ func endSpan(sp opentracing.Span, rsp *http.Response) {
 }
 
 type transportClient struct {
-	client *elastictransport.Client
+	client   *elastictransport.Client
+	cache    cache.TTLCache
+	cacheTTL time.Duration
 	tracingInfo
 }
 
 // Perform wraps elasticsearch Perform with tracing functionality.
+// If a cache is configured, the responses of search requests are served from and stored to the cache.
 func (c *transportClient) Perform(req *http.Request) (*http.Response, error) {
+	var key string
+	var cacheable bool
+	if c.cache != nil {
+		key, cacheable = cacheKey(req)
+	}
+	if cacheable {
+		if rsp, ok := c.cached(req, key); ok {
+			return rsp, nil
+		}
+	}
+
 	sp, err := c.startSpan(req)
 	if err != nil {
 		log.FromContext(req.Context()).Errorf("failed to start span: %v", err)
@@ -115,6 +130,9 @@ func (c *transportClient) Perform(req *http.Request) (*http.Response, error) {
 	}
 
 	observeResponse(req, sp, rsp, start)
+	if cacheable {
+		c.store(req, key, rsp)
+	}
 	return rsp, nil
 }
 
@@ -135,13 +153,13 @@ type Client struct {
 }
 
 // NewDefaultClient returns an empty ES client with sane defaults.
-func NewDefaultClient() (*Client, error) {
-	return NewClient(Config{})
+func NewDefaultClient(oo ...OptionFunc) (*Client, error) {
+	return NewClient(Config{}, oo...)
 }
 
 // NewClient is a modified version of elasticsearch.NewClient
 // that injects a tracing-ready transport.
-func NewClient(cfg Config) (*Client, error) {
+func NewClient(cfg Config, oo ...OptionFunc) (*Client, error) {
 	urls, err := addrsToURLs(cfg.Addresses)
 	if err != nil {
 		return nil, fmt.Errorf("cannot create client: %w", err)
@@ -180,6 +198,13 @@ func NewClient(cfg Config) (*Client, error) {
 		tracingInfo: tracingInfo,
 	}
 
+	for _, o := range oo {
+		err := o(tp)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return &Client{
 		elasticsearch.Client{
 			Transport: tp,
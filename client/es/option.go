@@ -0,0 +1,27 @@
+package es
+
+import (
+	"errors"
+	"time"
+
+	"github.com/beatlabs/patron/cache"
+)
+
+// OptionFunc definition for configuring the client in a functional way.
+type OptionFunc func(*transportClient) error
+
+// Cache option for caching the responses of idempotent search requests for the provided TTL.
+// Requests are keyed by their path and the hash of their normalized query DSL.
+func Cache(c cache.TTLCache, ttl time.Duration) OptionFunc {
+	return func(tc *transportClient) error {
+		if c == nil {
+			return errors.New("cache is nil")
+		}
+		if ttl <= 0 {
+			return errors.New("cache ttl should be positive")
+		}
+		tc.cache = c
+		tc.cacheTTL = ttl
+		return nil
+	}
+}
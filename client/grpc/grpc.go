@@ -5,8 +5,9 @@ import (
 	"context"
 	"time"
 
-	"github.com/beatlabs/patron/correlation"
 	"github.com/beatlabs/patron/log"
+	"github.com/beatlabs/patron/propagation"
+	"github.com/beatlabs/patron/reliability/retry"
 	"github.com/beatlabs/patron/trace"
 	"github.com/opentracing/opentracing-go"
 	"github.com/opentracing/opentracing-go/ext"
@@ -54,13 +55,20 @@ func DialContext(ctx context.Context, target string, opts ...grpc.DialOption) (c
 	return grpc.DialContext(ctx, target, opts...)
 }
 
-type headersCarrier struct {
-	Ctx context.Context
+// WithRetry returns a dial option which retries failed unary calls.
+// Every attempt is recorded on the client span and in the shared retry metric.
+// Since all failed calls are retried, it should only be used with idempotent methods.
+func WithRetry(r *retry.Retry) grpc.DialOption {
+	return grpc.WithChainUnaryInterceptor(retryInterceptor(r))
 }
 
-// Set implements Set() of opentracing.TextMapWriter.
-func (c *headersCarrier) Set(key, val string) {
-	c.Ctx = metadata.AppendToOutgoingContext(c.Ctx, key, val)
+func retryInterceptor(r *retry.Retry) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		_, err := r.ExecuteTraced(opentracing.SpanFromContext(ctx), componentName, func() (interface{}, error) {
+			return nil, invoker(ctx, method, req, reply, cc, opts...)
+		})
+		return err
+	}
 }
 
 func unaryInterceptor(target string) grpc.UnaryClientInterceptor {
@@ -70,14 +78,17 @@ func unaryInterceptor(target string) grpc.UnaryClientInterceptor {
 			componentName,
 			ext.SpanKindProducer,
 		)
-		carrier := headersCarrier{Ctx: ctx}
-		err := span.Tracer().Inject(span.Context(), opentracing.TextMap, &carrier)
+		md, ok := metadata.FromOutgoingContext(ctx)
+		if ok {
+			md = md.Copy()
+		} else {
+			md = metadata.MD{}
+		}
+		err := propagation.Inject(ctx, span, propagation.GRPCMetadata(md))
 		if err != nil {
 			log.FromContext(ctx).Errorf("failed to inject tracing headers: %v", err)
 		}
-
-		corID := correlation.IDFromContext(carrier.Ctx)
-		ctx = metadata.AppendToOutgoingContext(carrier.Ctx, correlation.HeaderID, corID)
+		ctx = metadata.NewOutgoingContext(ctx, md)
 		invokeTime := time.Now()
 		err = invoker(ctx, method, req, reply, cc, opts...)
 		invokeDuration := time.Since(invokeTime)
@@ -9,6 +9,7 @@ import (
 	"testing"
 
 	"github.com/beatlabs/patron/examples"
+	"github.com/beatlabs/patron/reliability/retry"
 	"github.com/opentracing/opentracing-go"
 	"github.com/opentracing/opentracing-go/ext"
 	"github.com/opentracing/opentracing-go/mocktracer"
@@ -182,3 +183,35 @@ func TestSayHello(t *testing.T) {
 		})
 	}
 }
+
+func TestSayHello_Retry(t *testing.T) {
+	mtr := mocktracer.New()
+	opentracing.SetGlobalTracer(mtr)
+	defer rpcDurationMetrics.Reset()
+
+	r, err := retry.New(3, 0)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	conn, err := DialContext(ctx, target, grpc.WithContextDialer(bufDialer), grpc.WithInsecure(), WithRetry(r))
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, conn.Close())
+	}()
+
+	client := examples.NewGreeterClient(conn)
+
+	res, err := client.SayHello(ctx, &examples.HelloRequest{})
+	require.Nil(t, res)
+	require.Error(t, err)
+
+	sp := mtr.FinishedSpans()[0]
+	assert.Equal(t, 3, sp.Tag("attempts"))
+	assert.Len(t, sp.Logs(), 3)
+
+	mtr.Reset()
+	res, err = client.SayHello(ctx, &examples.HelloRequest{Firstname: "John"})
+	require.NoError(t, err)
+	require.Equal(t, "Hello John!", res.GetMessage())
+	assert.Equal(t, 1, mtr.FinishedSpans()[0].Tag("attempts"))
+}
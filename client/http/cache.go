@@ -0,0 +1,457 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/beatlabs/patron/cache"
+	"github.com/beatlabs/patron/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	cacheResultHit         = "hit"
+	cacheResultMiss        = "miss"
+	cacheResultRevalidated = "revalidated"
+	cacheResultBypass      = "bypass"
+
+	// maxCachedBodySize is the maximum size of the bodies of the cached responses.
+	maxCachedBodySize = 1 << 20
+)
+
+var cacheCounter *prometheus.CounterVec
+
+func init() {
+	cacheCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "client",
+			Subsystem: "http",
+			Name:      "cache_requests_total",
+			Help:      "HTTP requests of the client looked up in its cache, classified by host and result (hit, miss, revalidated or bypass).",
+		},
+		[]string{"host", "result"},
+	)
+	prometheus.MustRegister(cacheCounter)
+}
+
+// cacheableStatus are the status codes of the responses which are cacheable by default, according to RFC 7231.
+var cacheableStatus = map[int]bool{
+	http.StatusOK:                   true,
+	http.StatusNonAuthoritativeInfo: true,
+	http.StatusNoContent:            true,
+	http.StatusMultipleChoices:      true,
+	http.StatusMovedPermanently:     true,
+	http.StatusNotFound:             true,
+	http.StatusMethodNotAllowed:     true,
+	http.StatusGone:                 true,
+	http.StatusRequestURITooLong:    true,
+	http.StatusNotImplemented:       true,
+}
+
+// responseCache is the private cache of the responses of the GET requests of the client, according to RFC 7234.
+type responseCache struct {
+	cache    cache.TTLCache
+	staleTTL time.Duration
+}
+
+// cachedResponse is the entry of a response in the cache.
+type cachedResponse struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header"`
+	Body       []byte      `json:"body"`
+	// Vary are the values of the request headers, which the response varies by.
+	Vary map[string]string `json:"vary,omitempty"`
+	// ResponseTime is the time the response was received or revalidated.
+	ResponseTime time.Time `json:"response_time"`
+	// InitialAge is the age of the response when it was received or revalidated.
+	InitialAge time.Duration `json:"initial_age"`
+}
+
+// cacheControl are the directives of a Cache-Control header.
+type cacheControl map[string]string
+
+func parseCacheControl(header http.Header) cacheControl {
+	cc := cacheControl{}
+	for _, value := range header.Values("Cache-Control") {
+		for _, directive := range strings.Split(value, ",") {
+			directive = strings.TrimSpace(directive)
+			if directive == "" {
+				continue
+			}
+			name, arg := directive, ""
+			if i := strings.Index(directive, "="); i >= 0 {
+				name, arg = directive[:i], strings.Trim(directive[i+1:], `"`)
+			}
+			cc[strings.ToLower(name)] = arg
+		}
+	}
+	return cc
+}
+
+func (cc cacheControl) has(name string) bool {
+	_, ok := cc[name]
+	return ok
+}
+
+// seconds returns the duration of the directive with a delta-seconds argument, e.g. max-age=60.
+func (cc cacheControl) seconds(name string) (time.Duration, bool) {
+	arg, ok := cc[name]
+	if !ok {
+		return 0, false
+	}
+	s, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil || s < 0 {
+		return 0, false
+	}
+	return time.Duration(s) * time.Second, true
+}
+
+// do executes the request with the next function, unless a fresh response is cached, caching the cacheable responses of GET requests.
+// Stale responses with validators, i.e. an ETag or a Last-Modified header, are revalidated with a conditional request.
+// Non-error responses of unsafe requests, e.g. POST, invalidate the response of the URL.
+func (rc *responseCache) do(req *http.Request, next func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+	host := req.URL.Host
+	if req.Method != http.MethodGet {
+		rsp, err := next(req)
+		if err == nil && !safeMethod(req.Method) && rsp.StatusCode < http.StatusBadRequest {
+			rc.remove(req, cacheKey(req))
+		}
+		return rsp, err
+	}
+
+	reqCC := parseCacheControl(req.Header)
+	if reqCC.has("no-store") || req.Header.Get("If-None-Match") != "" || req.Header.Get("If-Modified-Since") != "" {
+		// the conditional requests of the caller are not answered from the cache, since they expect a 304 response
+		cacheCounter.WithLabelValues(host, cacheResultBypass).Inc()
+		return next(req)
+	}
+
+	key := cacheKey(req)
+	entry := rc.get(req, key)
+	if entry != nil && !entry.matches(req) {
+		entry = nil
+	}
+
+	if entry != nil && entry.fresh(reqCC) {
+		cacheCounter.WithLabelValues(host, cacheResultHit).Inc()
+		return entry.response(req), nil
+	}
+	if reqCC.has("only-if-cached") {
+		cacheCounter.WithLabelValues(host, cacheResultMiss).Inc()
+		return gatewayTimeout(req), nil
+	}
+
+	if entry != nil && entry.validatable() {
+		rsp, err := next(entry.conditional(req))
+		if err != nil {
+			return nil, err
+		}
+		if rsp.StatusCode == http.StatusNotModified {
+			drain(rsp)
+			entry.revalidate(rsp)
+			rc.set(req, key, entry)
+			cacheCounter.WithLabelValues(host, cacheResultRevalidated).Inc()
+			return entry.response(req), nil
+		}
+		cacheCounter.WithLabelValues(host, cacheResultMiss).Inc()
+		return rc.store(req, key, rsp), nil
+	}
+
+	rsp, err := next(req)
+	if err != nil {
+		return nil, err
+	}
+	cacheCounter.WithLabelValues(host, cacheResultMiss).Inc()
+	return rc.store(req, key, rsp), nil
+}
+
+// store caches the response, if cacheable, returning it with its body rewound.
+func (rc *responseCache) store(req *http.Request, key string, rsp *http.Response) *http.Response {
+	if !cacheable(req, rsp) {
+		return rsp
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(rsp.Body, maxCachedBodySize+1))
+	if err != nil || len(body) > maxCachedBodySize {
+		// the response is returned with the part of the body which was read, followed by the rest of it
+		rsp.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(body), rsp.Body), Closer: rsp.Body}
+		return rsp
+	}
+	_ = rsp.Body.Close()
+	rsp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	entry := &cachedResponse{
+		StatusCode:   rsp.StatusCode,
+		Header:       rsp.Header.Clone(),
+		Body:         body,
+		Vary:         varyValues(req, rsp.Header),
+		ResponseTime: time.Now(),
+		InitialAge:   initialAge(rsp.Header),
+	}
+	if entry.ttl(rc.staleTTL) > 0 {
+		rc.set(req, key, entry)
+	}
+	return rsp
+}
+
+func (rc *responseCache) get(req *http.Request, key string) *cachedResponse {
+	v, ok, err := rc.cache.Get(key)
+	if err != nil {
+		log.FromContext(req.Context()).Warnf("failed to get cached response of %s: %v", key, err)
+		return nil
+	}
+	if !ok {
+		return nil
+	}
+	var b []byte
+	switch value := v.(type) {
+	case []byte:
+		b = value
+	case string:
+		// the redis cache returns strings instead of bytes
+		b = []byte(value)
+	default:
+		log.FromContext(req.Context()).Warnf("cached response of %s has unexpected type %T", key, v)
+		return nil
+	}
+	entry := &cachedResponse{}
+	if err := json.Unmarshal(b, entry); err != nil {
+		log.FromContext(req.Context()).Warnf("failed to decode cached response of %s: %v", key, err)
+		return nil
+	}
+	return entry
+}
+
+func (rc *responseCache) set(req *http.Request, key string, entry *cachedResponse) {
+	b, err := json.Marshal(entry)
+	if err != nil {
+		log.FromContext(req.Context()).Warnf("failed to encode cached response of %s: %v", key, err)
+		return
+	}
+	if err := rc.cache.SetTTL(key, b, entry.ttl(rc.staleTTL)); err != nil {
+		log.FromContext(req.Context()).Warnf("failed to cache response of %s: %v", key, err)
+	}
+}
+
+func (rc *responseCache) remove(req *http.Request, key string) {
+	if err := rc.cache.Remove(key); err != nil {
+		log.FromContext(req.Context()).Warnf("failed to remove cached response of %s: %v", key, err)
+	}
+}
+
+func cacheKey(req *http.Request) string {
+	return "http-client:" + req.URL.String()
+}
+
+func safeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
+
+// cacheable returns true if the response can be stored, i.e. it has a cacheable status code, it is not marked with no-store or Vary: *,
+// and it is either fresh for some time or it can be revalidated.
+func cacheable(req *http.Request, rsp *http.Response) bool {
+	if !cacheableStatus[rsp.StatusCode] {
+		return false
+	}
+	if parseCacheControl(rsp.Header).has("no-store") {
+		return false
+	}
+	for _, name := range varyNames(rsp.Header) {
+		if name == "*" {
+			return false
+		}
+	}
+	_, explicit := freshnessLifetime(rsp.Header)
+	return explicit || rsp.Header.Get("ETag") != "" || rsp.Header.Get("Last-Modified") != ""
+}
+
+// freshnessLifetime returns the freshness lifetime of the response, from its max-age directive or its Expires header,
+// and false if there is none, in which case it is not fresh. Responses with a no-cache directive are never fresh.
+func freshnessLifetime(header http.Header) (time.Duration, bool) {
+	cc := parseCacheControl(header)
+	if cc.has("no-cache") {
+		return 0, false
+	}
+	if d, ok := cc.seconds("max-age"); ok {
+		return d, true
+	}
+	if expires := header.Get("Expires"); expires != "" {
+		t, err := http.ParseTime(expires)
+		if err != nil {
+			// invalid dates, e.g. 0, represent a time in the past
+			return 0, true
+		}
+		date := time.Now()
+		if d, err := http.ParseTime(header.Get("Date")); err == nil {
+			date = d
+		}
+		if lifetime := t.Sub(date); lifetime > 0 {
+			return lifetime, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// initialAge returns the age of the response when received, from its Age header or the time elapsed since its Date header.
+func initialAge(header http.Header) time.Duration {
+	var age time.Duration
+	if s, err := strconv.ParseInt(header.Get("Age"), 10, 64); err == nil && s > 0 {
+		age = time.Duration(s) * time.Second
+	}
+	if date, err := http.ParseTime(header.Get("Date")); err == nil {
+		if apparent := time.Since(date); apparent > age {
+			age = apparent
+		}
+	}
+	return age
+}
+
+func varyNames(header http.Header) []string {
+	var names []string
+	for _, value := range header.Values("Vary") {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, http.CanonicalHeaderKey(name))
+			}
+		}
+	}
+	return names
+}
+
+func varyValues(req *http.Request, header http.Header) map[string]string {
+	names := varyNames(header)
+	if len(names) == 0 {
+		return nil
+	}
+	values := make(map[string]string, len(names))
+	for _, name := range names {
+		values[name] = strings.Join(req.Header.Values(name), ",")
+	}
+	return values
+}
+
+// matches returns true if the request has the values of the headers which the response varies by.
+func (c *cachedResponse) matches(req *http.Request) bool {
+	for name, value := range c.Vary {
+		if strings.Join(req.Header.Values(name), ",") != value {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *cachedResponse) age() time.Duration {
+	return c.InitialAge + time.Since(c.ResponseTime)
+}
+
+// fresh returns true if the response can be used without revalidation, according to its freshness and the directives of the request.
+func (c *cachedResponse) fresh(reqCC cacheControl) bool {
+	if reqCC.has("no-cache") {
+		return false
+	}
+	lifetime, ok := freshnessLifetime(c.Header)
+	if !ok {
+		return false
+	}
+	if maxAge, ok := reqCC.seconds("max-age"); ok && lifetime > maxAge {
+		lifetime = maxAge
+	}
+	age := c.age()
+	if minFresh, ok := reqCC.seconds("min-fresh"); ok {
+		age += minFresh
+	}
+	return age < lifetime
+}
+
+func (c *cachedResponse) validatable() bool {
+	return c.Header.Get("ETag") != "" || c.Header.Get("Last-Modified") != ""
+}
+
+// ttl returns the time the response is kept in the cache, i.e. its remaining freshness,
+// extended by the stale ttl if it can be revalidated.
+func (c *cachedResponse) ttl(staleTTL time.Duration) time.Duration {
+	lifetime, _ := freshnessLifetime(c.Header)
+	ttl := lifetime - c.age()
+	if ttl < 0 {
+		ttl = 0
+	}
+	if c.validatable() {
+		ttl += staleTTL
+	}
+	return ttl
+}
+
+// conditional returns the request validating the response with its ETag and Last-Modified headers.
+func (c *cachedResponse) conditional(req *http.Request) *http.Request {
+	r := req.Clone(req.Context())
+	if etag := c.Header.Get("ETag"); etag != "" {
+		r.Header.Set("If-None-Match", etag)
+	}
+	if lastModified := c.Header.Get("Last-Modified"); lastModified != "" {
+		r.Header.Set("If-Modified-Since", lastModified)
+	}
+	return r
+}
+
+// revalidate updates the response with the headers of the 304 Not Modified response validating it.
+func (c *cachedResponse) revalidate(rsp *http.Response) {
+	for name, values := range rsp.Header {
+		if name == "Content-Length" {
+			continue
+		}
+		c.Header[name] = values
+	}
+	c.ResponseTime = time.Now()
+	c.InitialAge = initialAge(rsp.Header)
+}
+
+func (c *cachedResponse) response(req *http.Request) *http.Response {
+	header := c.Header.Clone()
+	header.Set("Age", strconv.FormatInt(int64(c.age()/time.Second), 10))
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", c.StatusCode, http.StatusText(c.StatusCode)),
+		StatusCode:    c.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(c.Body)),
+		ContentLength: int64(len(c.Body)),
+		Request:       req,
+	}
+}
+
+// gatewayTimeout returns the response of the requests with an only-if-cached directive, when there is no fresh response in the cache.
+func gatewayTimeout(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:     fmt.Sprintf("%d %s", http.StatusGatewayTimeout, http.StatusText(http.StatusGatewayTimeout)),
+		StatusCode: http.StatusGatewayTimeout,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     http.Header{},
+		Body:       http.NoBody,
+		Request:    req,
+	}
+}
+
+func drain(rsp *http.Response) {
+	_, _ = io.CopyN(ioutil.Discard, rsp.Body, maxRetryDrainSize)
+	_ = rsp.Body.Close()
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
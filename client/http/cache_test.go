@@ -0,0 +1,347 @@
+package http
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryTTLCache struct {
+	mu     sync.Mutex
+	values map[string]interface{}
+	ttls   map[string]time.Duration
+}
+
+func newMemoryTTLCache() *memoryTTLCache {
+	return &memoryTTLCache{values: make(map[string]interface{}), ttls: make(map[string]time.Duration)}
+}
+
+func (m *memoryTTLCache) Get(key string) (interface{}, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	v, ok := m.values[key]
+	return v, ok, nil
+}
+
+func (m *memoryTTLCache) Purge() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.values = make(map[string]interface{})
+	return nil
+}
+
+func (m *memoryTTLCache) Remove(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.values, key)
+	return nil
+}
+
+func (m *memoryTTLCache) Set(key string, value interface{}) error {
+	return m.SetTTL(key, value, 0)
+}
+
+func (m *memoryTTLCache) SetTTL(key string, value interface{}, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	// values are stored as strings, like in the redis cache
+	m.values[key] = string(value.([]byte))
+	m.ttls[key] = ttl
+	return nil
+}
+
+func (m *memoryTTLCache) len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.values)
+}
+
+func TestCache(t *testing.T) {
+	t.Parallel()
+	_, err := New(Cache(nil, 0))
+	assert.EqualError(t, err, "cache must be supplied")
+	_, err = New(Cache(newMemoryTTLCache(), -1))
+	assert.EqualError(t, err, "stale ttl should not be negative")
+	c, err := New(Cache(newMemoryTTLCache(), time.Minute))
+	assert.NoError(t, err)
+	assert.NotNil(t, c)
+}
+
+func TestTracedClient_Do_Cache(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		header           http.Header
+		requestHeader    http.Header
+		expectedRequests int32
+		expectedCached   bool
+	}{
+		"max-age":            {header: http.Header{"Cache-Control": {"max-age=60"}}, expectedRequests: 1, expectedCached: true},
+		"private max-age":    {header: http.Header{"Cache-Control": {"private, max-age=60"}}, expectedRequests: 1, expectedCached: true},
+		"expires":            {header: http.Header{"Expires": {time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)}}, expectedRequests: 1, expectedCached: true},
+		"expired":            {header: http.Header{"Expires": {"0"}}, expectedRequests: 2},
+		"stale by age":       {header: http.Header{"Cache-Control": {"max-age=60"}, "Age": {"120"}}, expectedRequests: 2},
+		"no-store":           {header: http.Header{"Cache-Control": {"no-store, max-age=60"}}, expectedRequests: 2},
+		"no-cache":           {header: http.Header{"Cache-Control": {"no-cache, max-age=60"}}, expectedRequests: 2},
+		"vary all":           {header: http.Header{"Cache-Control": {"max-age=60"}, "Vary": {"*"}}, expectedRequests: 2},
+		"no freshness":       {expectedRequests: 2},
+		"request no-cache":   {header: http.Header{"Cache-Control": {"max-age=60"}}, requestHeader: http.Header{"Cache-Control": {"no-cache"}}, expectedRequests: 2, expectedCached: true},
+		"request no-store":   {header: http.Header{"Cache-Control": {"max-age=60"}}, requestHeader: http.Header{"Cache-Control": {"no-store"}}, expectedRequests: 2},
+		"request max-age":    {header: http.Header{"Cache-Control": {"max-age=60"}, "Age": {"10"}}, requestHeader: http.Header{"Cache-Control": {"max-age=5"}}, expectedRequests: 2, expectedCached: true},
+		"request min-fresh":  {header: http.Header{"Cache-Control": {"max-age=60"}}, requestHeader: http.Header{"Cache-Control": {"min-fresh=120"}}, expectedRequests: 2, expectedCached: true},
+		"conditional caller": {header: http.Header{"Cache-Control": {"max-age=60"}}, requestHeader: http.Header{"If-None-Match": {`"v1"`}}, expectedRequests: 2},
+		"vary matching":      {header: http.Header{"Cache-Control": {"max-age=60"}, "Vary": {"Accept-Language"}}, requestHeader: http.Header{"Accept-Language": {"en"}}, expectedRequests: 1, expectedCached: true},
+		"not found":          {header: http.Header{"Cache-Control": {"max-age=60"}, "X-Status": {"404"}}, expectedRequests: 1, expectedCached: true},
+		"uncacheable status": {header: http.Header{"Cache-Control": {"max-age=60"}, "X-Status": {"500"}}, expectedRequests: 2},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			var requests int32
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := atomic.AddInt32(&requests, 1)
+				for k, v := range tt.header {
+					w.Header()[k] = v
+				}
+				status := http.StatusOK
+				if s := tt.header.Get("X-Status"); s != "" {
+					status, _ = strconv.Atoi(s)
+				}
+				w.WriteHeader(status)
+				_, _ = w.Write([]byte(strconv.Itoa(int(n))))
+			}))
+			t.Cleanup(ts.Close)
+
+			cc := newMemoryTTLCache()
+			c, err := New(Cache(cc, 0))
+			require.NoError(t, err)
+
+			var body string
+			for i := 0; i < 2; i++ {
+				req, err := http.NewRequest(http.MethodGet, ts.URL, nil)
+				require.NoError(t, err)
+				for k, v := range tt.requestHeader {
+					req.Header[k] = v
+				}
+				rsp, err := c.Do(req)
+				require.NoError(t, err)
+				b, err := ioutil.ReadAll(rsp.Body)
+				require.NoError(t, err)
+				assert.NoError(t, rsp.Body.Close())
+				body = string(b)
+			}
+			assert.Equal(t, tt.expectedRequests, atomic.LoadInt32(&requests))
+			assert.Equal(t, strconv.Itoa(int(tt.expectedRequests)), body)
+			assert.Equal(t, tt.expectedCached, cc.len() == 1)
+		})
+	}
+}
+
+func TestTracedClient_Do_CacheHit(t *testing.T) {
+	t.Parallel()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = w.Write([]byte("hello"))
+	}))
+	t.Cleanup(ts.Close)
+	host := ts.Listener.Addr().String()
+
+	cc := newMemoryTTLCache()
+	c, err := New(Cache(cc, time.Minute))
+	require.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		req, err := http.NewRequest(http.MethodGet, ts.URL, nil)
+		require.NoError(t, err)
+		rsp, err := c.Do(req)
+		require.NoError(t, err)
+		b, err := ioutil.ReadAll(rsp.Body)
+		require.NoError(t, err)
+		assert.NoError(t, rsp.Body.Close())
+		assert.Equal(t, "hello", string(b))
+		assert.Equal(t, http.StatusOK, rsp.StatusCode)
+		assert.Equal(t, "text/plain", rsp.Header.Get("Content-Type"))
+	}
+	assert.Equal(t, 1.0, testutil.ToFloat64(cacheCounter.WithLabelValues(host, cacheResultMiss)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(cacheCounter.WithLabelValues(host, cacheResultHit)))
+	// the ttl of the entry is its remaining freshness, since it cannot be revalidated, where its age is up to a second due to its Date header
+	req, err := http.NewRequest(http.MethodGet, ts.URL, nil)
+	require.NoError(t, err)
+	assert.InDelta(t, float64(59500*time.Millisecond), float64(cc.ttls[cacheKey(req)]), float64(time.Second/2))
+}
+
+func TestTracedClient_Do_CacheRevalidation(t *testing.T) {
+	t.Parallel()
+	var requests int32
+	etag := `"v1"`
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&requests, 1)
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("ETag", etag)
+		w.Header().Set("X-Request", strconv.Itoa(int(n)))
+		if n == 3 {
+			// the resource changes
+			w.Header().Set("ETag", `"v2"`)
+			_, _ = w.Write([]byte("changed"))
+			return
+		}
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		_, _ = w.Write([]byte("hello"))
+	}))
+	t.Cleanup(ts.Close)
+	host := ts.Listener.Addr().String()
+
+	cc := newMemoryTTLCache()
+	c, err := New(Cache(cc, time.Minute))
+	require.NoError(t, err)
+
+	expected := []struct {
+		body, request string
+	}{{"hello", "1"}, {"hello", "2"}, {"changed", "3"}}
+	for _, e := range expected {
+		req, err := http.NewRequest(http.MethodGet, ts.URL, nil)
+		require.NoError(t, err)
+		rsp, err := c.Do(req)
+		require.NoError(t, err)
+		b, err := ioutil.ReadAll(rsp.Body)
+		require.NoError(t, err)
+		assert.NoError(t, rsp.Body.Close())
+		assert.Equal(t, http.StatusOK, rsp.StatusCode)
+		assert.Equal(t, e.body, string(b))
+		// the headers of the 304 response update the cached ones
+		assert.Equal(t, e.request, rsp.Header.Get("X-Request"))
+	}
+	assert.Equal(t, int32(3), atomic.LoadInt32(&requests))
+	assert.Equal(t, 2.0, testutil.ToFloat64(cacheCounter.WithLabelValues(host, cacheResultMiss)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(cacheCounter.WithLabelValues(host, cacheResultRevalidated)))
+}
+
+func TestTracedClient_Do_CacheVary(t *testing.T) {
+	t.Parallel()
+	var requests int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Header().Set("Vary", "Accept-Language")
+		_, _ = w.Write([]byte(r.Header.Get("Accept-Language")))
+	}))
+	t.Cleanup(ts.Close)
+
+	c, err := New(Cache(newMemoryTTLCache(), 0))
+	require.NoError(t, err)
+	for _, lang := range []string{"en", "el", "el"} {
+		req, err := http.NewRequest(http.MethodGet, ts.URL, nil)
+		require.NoError(t, err)
+		req.Header.Set("Accept-Language", lang)
+		rsp, err := c.Do(req)
+		require.NoError(t, err)
+		b, err := ioutil.ReadAll(rsp.Body)
+		require.NoError(t, err)
+		assert.NoError(t, rsp.Body.Close())
+		assert.Equal(t, lang, string(b))
+	}
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+}
+
+func TestTracedClient_Do_CacheInvalidation(t *testing.T) {
+	t.Parallel()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=60")
+		if r.Method == http.MethodDelete && r.URL.Query().Get("fail") != "" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	t.Cleanup(ts.Close)
+
+	cc := newMemoryTTLCache()
+	c, err := New(Cache(cc, 0))
+	require.NoError(t, err)
+	do := func(method, url string) {
+		req, err := http.NewRequest(method, url, nil)
+		require.NoError(t, err)
+		rsp, err := c.Do(req)
+		require.NoError(t, err)
+		assert.NoError(t, rsp.Body.Close())
+	}
+
+	do(http.MethodGet, ts.URL+"/orders?fail=1")
+	assert.Equal(t, 1, cc.len())
+	do(http.MethodDelete, ts.URL+"/orders?fail=1")
+	assert.Equal(t, 1, cc.len())
+	do(http.MethodHead, ts.URL+"/orders?fail=1")
+	assert.Equal(t, 1, cc.len())
+
+	do(http.MethodGet, ts.URL+"/orders")
+	assert.Equal(t, 2, cc.len())
+	do(http.MethodPost, ts.URL+"/orders")
+	assert.Equal(t, 1, cc.len())
+}
+
+func TestTracedClient_Do_CacheOnlyIfCached(t *testing.T) {
+	t.Parallel()
+	var requests int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+	}))
+	t.Cleanup(ts.Close)
+
+	c, err := New(Cache(newMemoryTTLCache(), 0))
+	require.NoError(t, err)
+	req, err := http.NewRequest(http.MethodGet, ts.URL, nil)
+	require.NoError(t, err)
+	req.Header.Set("Cache-Control", "only-if-cached")
+	rsp, err := c.Do(req)
+	require.NoError(t, err)
+	assert.NoError(t, rsp.Body.Close())
+	assert.Equal(t, http.StatusGatewayTimeout, rsp.StatusCode)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&requests))
+}
+
+func TestTracedClient_Do_CacheLargeBody(t *testing.T) {
+	t.Parallel()
+	body := strings.Repeat("a", maxCachedBodySize+10)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=60")
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(ts.Close)
+
+	cc := newMemoryTTLCache()
+	c, err := New(Cache(cc, 0))
+	require.NoError(t, err)
+	req, err := http.NewRequest(http.MethodGet, ts.URL, nil)
+	require.NoError(t, err)
+	rsp, err := c.Do(req)
+	require.NoError(t, err)
+	b, err := ioutil.ReadAll(rsp.Body)
+	require.NoError(t, err)
+	assert.NoError(t, rsp.Body.Close())
+	assert.Equal(t, body, string(b))
+	assert.Equal(t, 0, cc.len())
+}
+
+func TestParseCacheControl(t *testing.T) {
+	t.Parallel()
+	cc := parseCacheControl(http.Header{"Cache-Control": {`Max-Age=60, no-cache="Set-Cookie"`, "private"}})
+	assert.Equal(t, cacheControl{"max-age": "60", "no-cache": "Set-Cookie", "private": ""}, cc)
+	d, ok := cc.seconds("max-age")
+	assert.True(t, ok)
+	assert.Equal(t, time.Minute, d)
+	_, ok = cc.seconds("private")
+	assert.False(t, ok)
+	_, ok = cc.seconds("s-maxage")
+	assert.False(t, ok)
+}
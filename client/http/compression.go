@@ -0,0 +1,114 @@
+package http
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/beatlabs/patron/encoding"
+	"github.com/klauspost/compress/zstd"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	gzipEncoding     = "gzip"
+	deflateEncoding  = "deflate"
+	zstdEncoding     = "zstd"
+	brotliEncoding   = "br"
+	identityEncoding = "identity"
+)
+
+var decompressedCounter *prometheus.CounterVec
+
+func init() {
+	decompressedCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "client",
+			Subsystem: "http",
+			Name:      "decompressed_responses_total",
+			Help:      "Responses decompressed by the client, classified by encoding.",
+		},
+		[]string{"encoding"},
+	)
+	prometheus.MustRegister(decompressedCounter)
+}
+
+// DecoderFunc creates a reader, which decompresses the data read from r.
+type DecoderFunc func(r io.Reader) (io.ReadCloser, error)
+
+var builtinDecoders = map[string]DecoderFunc{
+	gzipEncoding: func(r io.Reader) (io.ReadCloser, error) {
+		return gzip.NewReader(r)
+	},
+	deflateEncoding: func(r io.Reader) (io.ReadCloser, error) {
+		return flate.NewReader(r), nil
+	},
+	zstdEncoding: func(r io.Reader) (io.ReadCloser, error) {
+		dec, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, err
+		}
+		return dec.IOReadCloser(), nil
+	},
+	brotliEncoding: func(r io.Reader) (io.ReadCloser, error) {
+		return io.NopCloser(brotli.NewReader(r)), nil
+	},
+}
+
+// supportsEncoding returns true if the responses of the content coding are decompressed by the client.
+func (tc *TracedClient) supportsEncoding(name string) bool {
+	if _, ok := tc.decoders[name]; ok {
+		return true
+	}
+	_, ok := builtinDecoders[name]
+	return ok
+}
+
+// decompress returns the body of the response decoded by its Content-Encoding header,
+// or, lacking it, by the Accept-Encoding header of the request, as long as it names a single encoding.
+func (tc *TracedClient) decompress(hdr string, rsp *http.Response) io.ReadCloser {
+	name := strings.ToLower(strings.TrimSpace(rsp.Header.Get(encoding.ContentEncodingHeader)))
+	if name == "" {
+		name = strings.ToLower(strings.TrimSpace(hdr))
+	}
+
+	decoder, ok := tc.decoders[name]
+	if !ok {
+		decoder, ok = builtinDecoders[name]
+	}
+	if !ok {
+		return rsp.Body
+	}
+	reader, err := decoder(rsp.Body)
+	if err != nil {
+		return &decodedBody{ReadCloser: io.NopCloser(errorReader{err: err}), body: rsp.Body}
+	}
+	decompressedCounter.WithLabelValues(name).Inc()
+	return &decodedBody{ReadCloser: reader, body: rsp.Body}
+}
+
+// errorReader fails the reads of a body, whose decoder could not be created.
+type errorReader struct {
+	err error
+}
+
+func (e errorReader) Read([]byte) (int, error) {
+	return 0, e.err
+}
+
+// decodedBody closes the body of the response along with its decoder.
+type decodedBody struct {
+	io.ReadCloser
+	body io.Closer
+}
+
+func (d *decodedBody) Close() error {
+	err := d.ReadCloser.Close()
+	if bodyErr := d.body.Close(); err == nil {
+		err = bodyErr
+	}
+	return err
+}
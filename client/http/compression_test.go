@@ -0,0 +1,154 @@
+package http
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/beatlabs/patron/encoding"
+	"github.com/klauspost/compress/zstd"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecompress_ContentEncoding(t *testing.T) {
+	const msg = "hello, client!"
+	zstdBody := func(t *testing.T) []byte {
+		enc, err := zstd.NewWriter(nil)
+		require.NoError(t, err)
+		defer func() {
+			assert.NoError(t, enc.Close())
+		}()
+		return enc.EncodeAll([]byte(msg), nil)
+	}(t)
+	brotliBody := func(t *testing.T) []byte {
+		var buf bytes.Buffer
+		w := brotli.NewWriter(&buf)
+		_, err := w.Write([]byte(msg))
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+		return buf.Bytes()
+	}(t)
+	server := func(contentEncoding string, body []byte) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if contentEncoding != "" {
+				w.Header().Set(encoding.ContentEncodingHeader, contentEncoding)
+			}
+			_, _ = w.Write(body)
+		}))
+	}
+	upperDecoder := func(r io.Reader) (io.ReadCloser, error) {
+		data, err := ioutil.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		return ioutil.NopCloser(bytes.NewReader(bytes.ToLower(data))), nil
+	}
+
+	tests := map[string]struct {
+		acceptEncoding  string
+		contentEncoding string
+		body            []byte
+		expectedBody    string
+		expectedErr     string
+	}{
+		"zstd":                  {acceptEncoding: "gzip, zstd", contentEncoding: "zstd", body: zstdBody, expectedBody: msg},
+		"brotli":                {acceptEncoding: "gzip, br", contentEncoding: "br", body: brotliBody, expectedBody: msg},
+		"custom decoder":        {acceptEncoding: "upper, gzip", contentEncoding: "upper", body: []byte(strings.ToUpper(msg)), expectedBody: msg},
+		"not encoded":           {acceptEncoding: "gzip, zstd", body: []byte(msg), expectedBody: msg},
+		"unsupported encoding":  {acceptEncoding: "compress", contentEncoding: "compress", body: []byte(msg), expectedBody: msg},
+		"invalid encoded body":  {acceptEncoding: "gzip", contentEncoding: "gzip", body: []byte(msg), expectedErr: "gzip: invalid header"},
+		"no accepted encodings": {contentEncoding: "zstd", body: zstdBody, expectedBody: string(zstdBody)},
+	}
+	c, err := New(Decoder("upper", upperDecoder))
+	require.NoError(t, err)
+
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			ts := server(tt.contentEncoding, tt.body)
+			defer ts.Close()
+			req, err := http.NewRequest(http.MethodGet, ts.URL, nil)
+			require.NoError(t, err)
+			if tt.acceptEncoding != "" {
+				req.Header.Set(encoding.AcceptEncodingHeader, tt.acceptEncoding)
+			}
+
+			rsp, err := c.Do(req)
+			require.NoError(t, err)
+			b, err := ioutil.ReadAll(rsp.Body)
+			assert.NoError(t, rsp.Body.Close())
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedBody, string(b))
+		})
+	}
+}
+
+func TestDecompress_Metric(t *testing.T) {
+	enc, err := zstd.NewWriter(nil)
+	require.NoError(t, err)
+	body := enc.EncodeAll([]byte("hello, client!"), nil)
+	require.NoError(t, enc.Close())
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(encoding.ContentEncodingHeader, "zstd")
+		_, _ = w.Write(body)
+	}))
+	defer ts.Close()
+	c, err := New()
+	require.NoError(t, err)
+	before := testutil.ToFloat64(decompressedCounter.WithLabelValues("zstd"))
+
+	req, err := http.NewRequest(http.MethodGet, ts.URL, nil)
+	require.NoError(t, err)
+	req.Header.Set(encoding.AcceptEncodingHeader, "zstd")
+	rsp, err := c.Do(req)
+	require.NoError(t, err)
+	_, err = ioutil.ReadAll(rsp.Body)
+	assert.NoError(t, err)
+	assert.NoError(t, rsp.Body.Close())
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(decompressedCounter.WithLabelValues("zstd"))-before)
+}
+
+func TestAcceptEncoding_Default(t *testing.T) {
+	var acceptEncoding string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		acceptEncoding = r.Header.Get(encoding.AcceptEncodingHeader)
+		w.Header().Set(encoding.ContentEncodingHeader, "br")
+		bw := brotli.NewWriter(w)
+		_, _ = bw.Write([]byte("hello, client!"))
+		_ = bw.Close()
+	}))
+	defer ts.Close()
+	c, err := New(AcceptEncoding("zstd", "br", "gzip"))
+	require.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodGet, ts.URL, nil)
+	require.NoError(t, err)
+	rsp, err := c.Do(req)
+	require.NoError(t, err)
+	b, err := ioutil.ReadAll(rsp.Body)
+	assert.NoError(t, err)
+	assert.NoError(t, rsp.Body.Close())
+	assert.Equal(t, "zstd, br, gzip", acceptEncoding)
+	assert.Equal(t, "hello, client!", string(b))
+
+	// the header of the request takes precedence
+	req, err = http.NewRequest(http.MethodGet, ts.URL, nil)
+	require.NoError(t, err)
+	req.Header.Set(encoding.AcceptEncodingHeader, "br")
+	rsp, err = c.Do(req)
+	require.NoError(t, err)
+	assert.NoError(t, rsp.Body.Close())
+	assert.Equal(t, "br", acceptEncoding)
+}
@@ -0,0 +1,102 @@
+package http
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"runtime"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	bodyOutcomeDrained   = "drained"
+	bodyOutcomeUndrained = "undrained"
+	bodyOutcomeLeaked    = "leaked"
+
+	// DefaultMaxDrainSize is the default maximum number of bytes drained from a response body on close.
+	DefaultMaxDrainSize = 64 << 10
+)
+
+var bodyCloseCounter *prometheus.CounterVec
+
+func init() {
+	bodyCloseCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "client",
+			Subsystem: "http",
+			Name:      "response_body_close_total",
+			Help:      "Response bodies closed by the draining client, classified by host and outcome (drained, undrained, leaked).",
+		},
+		[]string{"host", "outcome"},
+	)
+	prometheus.MustRegister(bodyCloseCounter)
+}
+
+// DrainingClient decorates a client and makes sure that every response body is drained and closed,
+// which is required by the transport in order to reuse the underlying connection.
+// Bodies that are garbage collected without having been closed are reported as leaked and closed.
+type DrainingClient struct {
+	cl           Client
+	maxDrainSize int64
+}
+
+// NewDrainingClient decorates the provided client. On close at most maxDrainSize bytes of the remaining
+// body are read and discarded; bodies exceeding it are closed without draining and reported as undrained.
+func NewDrainingClient(cl Client, maxDrainSize int64) (*DrainingClient, error) {
+	if cl == nil {
+		return nil, errors.New("client is nil")
+	}
+	if maxDrainSize < 0 {
+		return nil, errors.New("max drain size must not be negative")
+	}
+	return &DrainingClient{cl: cl, maxDrainSize: maxDrainSize}, nil
+}
+
+// Do executes the request with the decorated client and wraps the response body.
+func (dc *DrainingClient) Do(req *http.Request) (*http.Response, error) {
+	rsp, err := dc.cl.Do(req)
+	if rsp == nil || rsp.Body == nil || rsp.Body == http.NoBody {
+		return rsp, err
+	}
+
+	rsp.Body = newDrainingBody(rsp.Body, req.URL.Host, dc.maxDrainSize)
+	return rsp, err
+}
+
+type drainingBody struct {
+	io.ReadCloser
+	host         string
+	maxDrainSize int64
+	once         sync.Once
+	err          error
+}
+
+func newDrainingBody(rc io.ReadCloser, host string, maxDrainSize int64) *drainingBody {
+	b := &drainingBody{ReadCloser: rc, host: host, maxDrainSize: maxDrainSize}
+	runtime.SetFinalizer(b, func(b *drainingBody) {
+		bodyCloseCounter.WithLabelValues(b.host, bodyOutcomeLeaked).Inc()
+		_ = b.ReadCloser.Close()
+	})
+	return b
+}
+
+// Close drains the remaining body up to the configured limit and closes it.
+func (b *drainingBody) Close() error {
+	b.once.Do(func() {
+		runtime.SetFinalizer(b, nil)
+
+		outcome := bodyOutcomeDrained
+		n, err := io.CopyN(io.Discard, b.ReadCloser, b.maxDrainSize+1)
+		if err == nil && n > b.maxDrainSize {
+			outcome = bodyOutcomeUndrained
+		} else if err != nil && !errors.Is(err, io.EOF) {
+			outcome = bodyOutcomeUndrained
+		}
+		bodyCloseCounter.WithLabelValues(b.host, outcome).Inc()
+
+		b.err = b.ReadCloser.Close()
+	})
+	return b.err
+}
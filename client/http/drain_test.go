@@ -0,0 +1,93 @@
+package http
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewDrainingClient(t *testing.T) {
+	cl, err := New()
+	require.NoError(t, err)
+
+	type args struct {
+		cl           Client
+		maxDrainSize int64
+	}
+	tests := map[string]struct {
+		args        args
+		expectedErr string
+	}{
+		"success":                      {args: args{cl: cl, maxDrainSize: DefaultMaxDrainSize}},
+		"failure, missing client":      {args: args{maxDrainSize: DefaultMaxDrainSize}, expectedErr: "client is nil"},
+		"failure, negative drain size": {args: args{cl: cl, maxDrainSize: -1}, expectedErr: "max drain size must not be negative"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			got, err := NewDrainingClient(tt.args.cl, tt.args.maxDrainSize)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, got)
+			}
+		})
+	}
+}
+
+func TestDrainingClient_Do(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, strings.Repeat("a", 100))
+	}))
+	defer ts.Close()
+	bodyCloseCounter.Reset()
+	defer reqDurationMetrics.Reset()
+
+	cl, err := New()
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		maxDrainSize int64
+		outcome      string
+	}{
+		"drained":   {maxDrainSize: 100, outcome: bodyOutcomeDrained},
+		"undrained": {maxDrainSize: 10, outcome: bodyOutcomeUndrained},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			dc, err := NewDrainingClient(cl, tt.maxDrainSize)
+			require.NoError(t, err)
+			req, err := http.NewRequest(http.MethodGet, ts.URL, nil)
+			require.NoError(t, err)
+
+			rsp, err := dc.Do(req)
+			require.NoError(t, err)
+			assert.NoError(t, rsp.Body.Close())
+			assert.NoError(t, rsp.Body.Close())
+			assert.Equal(t, 1.0, testutil.ToFloat64(bodyCloseCounter.WithLabelValues(req.URL.Host, tt.outcome)))
+		})
+	}
+}
+
+func TestDrainingBody_Leaked(t *testing.T) {
+	bodyCloseCounter.Reset()
+	newDrainingBody(io.NopCloser(strings.NewReader("body")), "host", DefaultMaxDrainSize)
+
+	leaked := bodyCloseCounter.WithLabelValues("host", bodyOutcomeLeaked)
+	for i := 0; i < 50 && testutil.ToFloat64(leaked) == 0; i++ {
+		runtime.GC()
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Equal(t, 1.0, testutil.ToFloat64(leaked))
+}
@@ -0,0 +1,257 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	hedgedTag = "hedged"
+
+	hedgeOutcomeWon    = "won"
+	hedgeOutcomeLost   = "lost"
+	hedgeOutcomeFailed = "failed"
+
+	// minHedgeSamples is the minimum number of latencies of a host, before hedging its requests after their percentile.
+	minHedgeSamples = 20
+)
+
+var hedgeCounter *prometheus.CounterVec
+
+func init() {
+	hedgeCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "client",
+			Subsystem: "http",
+			Name:      "hedged_requests_total",
+			Help:      "Hedged HTTP requests sent by the client, classified by host, method and outcome (won, lost or failed).",
+		},
+		[]string{"host", "method", "outcome"},
+	)
+	prometheus.MustRegister(hedgeCounter)
+}
+
+// HedgePolicy defines the hedging of the requests of the client.
+//
+// A hedged request is a second request sent to the same endpoint, when the response of the first one is late,
+// i.e. slower than the percentile of the latencies of the recent requests to the host, and the response which arrives first is used,
+// while the other request is canceled. Only the requests of idempotent methods, i.e. GET, HEAD, OPTIONS, TRACE, PUT and DELETE,
+// or with an Idempotency-Key header are hedged, if their body, if any, can be rewound.
+// Every request, including its retries, is traced in its own client span, and the hedged ones are tagged as hedged.
+type HedgePolicy struct {
+	// Percentile of the latencies of the recent requests to the host, after which the hedged request is sent, between 0 and 1, e.g. 0.95.
+	Percentile float64
+	// InitialDelay is the delay before the hedged request, until enough latencies of the host are observed.
+	InitialDelay time.Duration
+	// MinDelay is the minimum delay before the hedged request, which limits the load of hedging on hosts with low latencies.
+	MinDelay time.Duration
+	// Window is the number of the recent latencies of each host the percentile is calculated from, which should be at least 20.
+	Window int
+}
+
+// DefaultHedgePolicy returns a policy hedging the requests after the 95th percentile of the latencies of the last 200 requests,
+// with an initial delay of 100ms and a minimum delay of 10ms.
+func DefaultHedgePolicy() HedgePolicy {
+	return HedgePolicy{
+		Percentile:   0.95,
+		InitialDelay: 100 * time.Millisecond,
+		MinDelay:     10 * time.Millisecond,
+		Window:       200,
+	}
+}
+
+func (p HedgePolicy) validate() error {
+	if p.Percentile <= 0 || p.Percentile >= 1 {
+		return errors.New("percentile should be between 0 and 1")
+	}
+	if p.InitialDelay <= 0 {
+		return errors.New("initial delay should be positive")
+	}
+	if p.MinDelay < 0 {
+		return errors.New("min delay should not be negative")
+	}
+	if p.Window < minHedgeSamples {
+		return errors.New("window should be at least 20")
+	}
+	return nil
+}
+
+// hedger keeps the recent latencies of the hosts, in order to determine the delays of the hedged requests.
+type hedger struct {
+	policy    HedgePolicy
+	mu        sync.Mutex
+	latencies map[string]*latencyWindow
+}
+
+func newHedger(p HedgePolicy) *hedger {
+	return &hedger{policy: p, latencies: make(map[string]*latencyWindow)}
+}
+
+// hedgeable returns true if the request can be hedged according to its method, headers and body.
+func (h *hedger) hedgeable(req *http.Request) bool {
+	return rewindable(req) && idempotent(req)
+}
+
+// delay returns the delay before the hedged request to the host.
+func (h *hedger) delay(host string) time.Duration {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	w, ok := h.latencies[host]
+	if !ok || len(w.samples) < minHedgeSamples {
+		return h.policy.InitialDelay
+	}
+	d := w.percentile(h.policy.Percentile)
+	if d < h.policy.MinDelay {
+		return h.policy.MinDelay
+	}
+	return d
+}
+
+func (h *hedger) observe(host string, d time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	w, ok := h.latencies[host]
+	if !ok {
+		w = &latencyWindow{samples: make([]time.Duration, 0, h.policy.Window)}
+		h.latencies[host] = w
+	}
+	w.add(d)
+}
+
+// latencyWindow is a ring buffer of the recent latencies of a host.
+type latencyWindow struct {
+	samples []time.Duration
+	next    int
+}
+
+func (w *latencyWindow) add(d time.Duration) {
+	if len(w.samples) < cap(w.samples) {
+		w.samples = append(w.samples, d)
+		return
+	}
+	w.samples[w.next] = d
+	w.next = (w.next + 1) % len(w.samples)
+}
+
+func (w *latencyWindow) percentile(p float64) time.Duration {
+	sorted := append([]time.Duration(nil), w.samples...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted[int(p*float64(len(sorted)-1))]
+}
+
+type hedgeResult struct {
+	rsp    *http.Response
+	err    error
+	hedged bool
+}
+
+// doHedged executes the request, and a hedged request if its response is late, returning the response which arrives first.
+// The errors of a request are returned only if the other request fails too, or if it is not sent yet.
+func (tc *TracedClient) doHedged(req *http.Request) (*http.Response, error) {
+	host := req.URL.Host
+	start := time.Now()
+	results := make(chan hedgeResult, 2)
+	// the cancel functions of the request and the hedged request
+	var cancels [2]context.CancelFunc
+	send := func(hedged bool) error {
+		ctx, cancel := context.WithCancel(req.Context())
+		r := req.Clone(ctx)
+		if hedged && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				cancel()
+				return err
+			}
+			r.Body = body
+		}
+		cancels[hedgeIndex(hedged)] = cancel
+		go func() {
+			rsp, err := tc.doTraced(r, hedged)
+			results <- hedgeResult{rsp: rsp, err: err, hedged: hedged}
+		}()
+		return nil
+	}
+
+	if err := send(false); err != nil {
+		return nil, err
+	}
+	timer := time.NewTimer(tc.hg.delay(host))
+	defer timer.Stop()
+	delayed := timer.C
+
+	pending, hedged := 1, false
+	for {
+		select {
+		case <-delayed:
+			delayed = nil
+			if err := send(true); err != nil {
+				continue
+			}
+			pending++
+			hedged = true
+		case res := <-results:
+			pending--
+			if res.err != nil {
+				cancels[hedgeIndex(res.hedged)]()
+				if pending > 0 {
+					continue
+				}
+				if hedged {
+					hedgeCounter.WithLabelValues(host, req.Method, hedgeOutcomeFailed).Inc()
+				}
+				return nil, res.err
+			}
+
+			if pending > 0 {
+				// the other request is canceled, and its response, if any, is discarded
+				cancels[hedgeIndex(!res.hedged)]()
+				go discard(results)
+			}
+			if hedged {
+				outcome := hedgeOutcomeLost
+				if res.hedged {
+					outcome = hedgeOutcomeWon
+				}
+				hedgeCounter.WithLabelValues(host, req.Method, outcome).Inc()
+			}
+			tc.hg.observe(host, time.Since(start))
+			// the request is canceled when its body is closed, since it is read after returning
+			res.rsp.Body = cancelBody{ReadCloser: res.rsp.Body, cancel: cancels[hedgeIndex(res.hedged)]}
+			return res.rsp, nil
+		}
+	}
+}
+
+func hedgeIndex(hedged bool) int {
+	if hedged {
+		return 1
+	}
+	return 0
+}
+
+// discard closes the body of the response of the canceled request, if any.
+func discard(results <-chan hedgeResult) {
+	res := <-results
+	if res.rsp != nil {
+		_ = res.rsp.Body.Close()
+	}
+}
+
+// cancelBody cancels the context of the request of the response when closed.
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
@@ -0,0 +1,178 @@
+package http
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHedging(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		policy      func(*HedgePolicy)
+		expectedErr string
+	}{
+		"default":            {policy: func(*HedgePolicy) {}},
+		"no min delay":       {policy: func(p *HedgePolicy) { p.MinDelay = 0 }},
+		"zero percentile":    {policy: func(p *HedgePolicy) { p.Percentile = 0 }, expectedErr: "invalid hedge policy: percentile should be between 0 and 1"},
+		"percentile of one":  {policy: func(p *HedgePolicy) { p.Percentile = 1 }, expectedErr: "invalid hedge policy: percentile should be between 0 and 1"},
+		"zero initial delay": {policy: func(p *HedgePolicy) { p.InitialDelay = 0 }, expectedErr: "invalid hedge policy: initial delay should be positive"},
+		"negative min delay": {policy: func(p *HedgePolicy) { p.MinDelay = -1 }, expectedErr: "invalid hedge policy: min delay should not be negative"},
+		"small window":       {policy: func(p *HedgePolicy) { p.Window = 10 }, expectedErr: "invalid hedge policy: window should be at least 20"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			p := DefaultHedgePolicy()
+			tt.policy(&p)
+			got, err := New(Hedging(p))
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, got)
+			}
+		})
+	}
+}
+
+func TestTracedClient_Do_Hedging(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		method           string
+		header           http.Header
+		firstDelay       time.Duration
+		secondDelay      time.Duration
+		expectedBody     string
+		expectedRequests int32
+		expectedOutcome  string
+	}{
+		"hedged request won": {
+			method: http.MethodGet, firstDelay: time.Second, expectedBody: "2", expectedRequests: 2, expectedOutcome: hedgeOutcomeWon,
+		},
+		"hedged request lost": {
+			method: http.MethodPut, firstDelay: 50 * time.Millisecond, secondDelay: time.Second, expectedBody: "1", expectedRequests: 2,
+			expectedOutcome: hedgeOutcomeLost,
+		},
+		"fast request not hedged": {
+			method: http.MethodGet, expectedBody: "1", expectedRequests: 1,
+		},
+		"post not hedged": {
+			method: http.MethodPost, firstDelay: 50 * time.Millisecond, expectedBody: "1", expectedRequests: 1,
+		},
+		"post with idempotency key hedged": {
+			method: http.MethodPost, header: http.Header{IdempotencyKeyHeader: []string{"123"}}, firstDelay: time.Second,
+			expectedBody: "2", expectedRequests: 2, expectedOutcome: hedgeOutcomeWon,
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			var requests int32
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, err := ioutil.ReadAll(r.Body)
+				assert.NoError(t, err)
+				assert.Equal(t, "payload", string(body))
+				n := atomic.AddInt32(&requests, 1)
+				delay := tt.firstDelay
+				if n > 1 {
+					delay = tt.secondDelay
+				}
+				select {
+				case <-time.After(delay):
+				case <-r.Context().Done():
+					return
+				}
+				_, _ = w.Write([]byte{byte('0' + n)})
+			}))
+			t.Cleanup(ts.Close)
+			host := ts.Listener.Addr().String()
+
+			p := DefaultHedgePolicy()
+			p.InitialDelay = 10 * time.Millisecond
+			c, err := New(Hedging(p))
+			require.NoError(t, err)
+			req, err := http.NewRequest(tt.method, ts.URL, bytes.NewBufferString("payload"))
+			require.NoError(t, err)
+			for k, v := range tt.header {
+				req.Header[k] = v
+			}
+
+			start := time.Now()
+			rsp, err := c.Do(req)
+			require.NoError(t, err)
+			body, err := ioutil.ReadAll(rsp.Body)
+			require.NoError(t, err)
+			assert.NoError(t, rsp.Body.Close())
+			assert.Less(t, int64(time.Since(start)), int64(time.Second))
+			assert.Equal(t, tt.expectedBody, string(body))
+			assert.Equal(t, tt.expectedRequests, atomic.LoadInt32(&requests))
+			for _, outcome := range []string{hedgeOutcomeWon, hedgeOutcomeLost, hedgeOutcomeFailed} {
+				expected := 0.0
+				if outcome == tt.expectedOutcome {
+					expected = 1
+				}
+				assert.Equal(t, expected, testutil.ToFloat64(hedgeCounter.WithLabelValues(host, tt.method, outcome)), outcome)
+			}
+		})
+	}
+}
+
+func TestTracedClient_Do_HedgingFailed(t *testing.T) {
+	t.Parallel()
+	var requests int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		time.Sleep(20 * time.Millisecond)
+		conn, _, err := w.(http.Hijacker).Hijack()
+		assert.NoError(t, err)
+		assert.NoError(t, conn.Close())
+	}))
+	t.Cleanup(ts.Close)
+	host := ts.Listener.Addr().String()
+
+	p := DefaultHedgePolicy()
+	p.InitialDelay = time.Millisecond
+	c, err := New(Hedging(p))
+	require.NoError(t, err)
+	req, err := http.NewRequest(http.MethodGet, ts.URL, nil)
+	require.NoError(t, err)
+
+	rsp, err := c.Do(req)
+	assert.Error(t, err)
+	assert.Nil(t, rsp)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+	assert.Equal(t, 1.0, testutil.ToFloat64(hedgeCounter.WithLabelValues(host, http.MethodGet, hedgeOutcomeFailed)))
+}
+
+func TestHedger_Delay(t *testing.T) {
+	t.Parallel()
+	p := HedgePolicy{Percentile: 0.9, InitialDelay: time.Second, MinDelay: 5 * time.Millisecond, Window: 20}
+	h := newHedger(p)
+	assert.Equal(t, time.Second, h.delay("host"))
+
+	for i := 1; i <= 19; i++ {
+		h.observe("host", time.Duration(i)*time.Millisecond)
+	}
+	assert.Equal(t, time.Second, h.delay("host"))
+	h.observe("host", 20*time.Millisecond)
+	assert.Equal(t, 18*time.Millisecond, h.delay("host"))
+	assert.Equal(t, time.Second, h.delay("other"))
+
+	// the oldest latencies are replaced by the recent ones
+	for i := 0; i < 20; i++ {
+		h.observe("host", time.Millisecond)
+	}
+	assert.Equal(t, 5*time.Millisecond, h.delay("host"))
+}
@@ -2,27 +2,29 @@
 package http
 
 import (
-	"compress/flate"
-	"compress/gzip"
 	"errors"
-	"io"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/beatlabs/patron/propagation"
 	"github.com/opentracing-contrib/go-stdlib/nethttp"
 	"github.com/opentracing/opentracing-go"
 	"github.com/opentracing/opentracing-go/ext"
 	"github.com/prometheus/client_golang/prometheus"
 
-	"github.com/beatlabs/patron/correlation"
+	"github.com/beatlabs/patron/dependency"
 	"github.com/beatlabs/patron/encoding"
 	"github.com/beatlabs/patron/reliability/circuitbreaker"
+	"github.com/beatlabs/patron/reliability/retry"
 	"github.com/beatlabs/patron/trace"
 )
 
 const (
 	clientComponent = "http-client"
+	dependencyKind  = "http"
 )
 
 var reqDurationMetrics *prometheus.HistogramVec
@@ -49,6 +51,14 @@ type Client interface {
 type TracedClient struct {
 	cl *http.Client
 	cb *circuitbreaker.CircuitBreaker
+	rt *retry.Retry
+	rp *RetryPolicy
+	hg *hedger
+	rc *responseCache
+	// decoders of the responses on top of the built-in ones, keyed by their content coding
+	decoders map[string]DecoderFunc
+	// acceptEncoding holds the content codings accepted by default, in order of preference
+	acceptEncoding []string
 }
 
 // New creates a new HTTP client.
@@ -68,44 +78,115 @@ func New(oo ...OptionFunc) (*TracedClient, error) {
 		}
 	}
 
+	if tc.rt != nil && tc.rp != nil {
+		return nil, errors.New("retry and retry policy are mutually exclusive")
+	}
+
+	for _, e := range tc.acceptEncoding {
+		if e != identityEncoding && !tc.supportsEncoding(e) {
+			return nil, fmt.Errorf("accepted encoding %s has no decoder", e)
+		}
+	}
+
 	return tc, nil
 }
 
 // Do execute an HTTP request with integrated tracing and tracing propagation downstream.
 func (tc *TracedClient) Do(req *http.Request) (*http.Response, error) {
+	if len(tc.acceptEncoding) > 0 && req.Header.Get(encoding.AcceptEncodingHeader) == "" {
+		req.Header.Set(encoding.AcceptEncodingHeader, strings.Join(tc.acceptEncoding, ", "))
+	}
+	if tc.rc != nil {
+		return tc.rc.do(req, tc.send)
+	}
+	return tc.send(req)
+}
+
+// send executes the request, hedging it if enabled.
+func (tc *TracedClient) send(req *http.Request) (*http.Response, error) {
+	if tc.hg != nil && tc.hg.hedgeable(req) {
+		return tc.doHedged(req)
+	}
+	return tc.doTraced(req, false)
+}
+
+// doTraced executes the request, with its retries, if any, in a client span, which is tagged if the request is a hedged one.
+func (tc *TracedClient) doTraced(req *http.Request, hedged bool) (*http.Response, error) {
 	req, ht := nethttp.TraceRequest(opentracing.GlobalTracer(), req,
 		nethttp.OperationName(opName(req.Method, req.URL.Scheme, req.URL.Host)),
 		nethttp.ComponentName(clientComponent))
 	defer ht.Finish()
 
-	req.Header.Set(correlation.HeaderID, correlation.IDFromContext(req.Context()))
+	// the trace context is injected by the transport of the request
+	_ = propagation.Inject(req.Context(), nil, propagation.HTTPHeaders(req.Header))
 
 	start := time.Now()
 
-	rsp, err := tc.do(req)
+	rsp, err := tc.do(req, ht)
 
+	if hedged && ht.Span() != nil {
+		ht.Span().SetTag(hedgedTag, true)
+	}
 	ext.HTTPMethod.Set(ht.Span(), req.Method)
 	ext.HTTPUrl.Set(ht.Span(), req.URL.String())
 
 	if err != nil {
 		ext.Error.Set(ht.Span(), true)
+		dependency.Observe(dependencyKind, req.URL.Host, false, time.Since(start))
 		return rsp, err
 	}
 
 	ext.HTTPStatusCode.Set(ht.Span(), uint16(rsp.StatusCode))
+	duration := time.Since(start)
 	durationHistogram := trace.Histogram{
 		Observer: reqDurationMetrics.WithLabelValues(req.Method, req.URL.Host, strconv.Itoa(rsp.StatusCode)),
 	}
-	durationHistogram.Observe(req.Context(), time.Since(start).Seconds())
+	durationHistogram.Observe(req.Context(), duration.Seconds())
+	dependency.Observe(dependencyKind, req.URL.Host, rsp.StatusCode < http.StatusInternalServerError, duration)
 
 	if hdr := req.Header.Get(encoding.AcceptEncodingHeader); hdr != "" {
-		rsp.Body = decompress(hdr, rsp)
+		rsp.Body = tc.decompress(hdr, rsp)
 	}
 
 	return rsp, err
 }
 
-func (tc *TracedClient) do(req *http.Request) (*http.Response, error) {
+func (tc *TracedClient) do(req *http.Request, ht *nethttp.Tracer) (*http.Response, error) {
+	if tc.rp != nil {
+		return tc.doWithPolicy(req, ht)
+	}
+	if tc.rt == nil {
+		return tc.doOnce(req)
+	}
+
+	var rsp *http.Response
+	attempt := 0
+	_, err := tc.rt.Execute(func() (interface{}, error) {
+		attempt++
+		if attempt > 1 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+
+		var err error
+		rsp, err = tc.doOnce(req)
+		retry.ObserveAttempt(ht.Span(), clientComponent, attempt, err)
+		return rsp, err
+	})
+	retry.ObserveAttempts(ht.Span(), attempt)
+
+	return rsp, err
+}
+
+func (tc *TracedClient) doOnce(req *http.Request) (*http.Response, error) {
+	host := req.URL.Host
+	inFlightGauge.WithLabelValues(host).Inc()
+	defer inFlightGauge.WithLabelValues(host).Dec()
+
+	req = withPoolTrace(req)
 	if tc.cb == nil {
 		return tc.cl.Do(req)
 	}
@@ -128,17 +209,3 @@ func (tc *TracedClient) do(req *http.Request) (*http.Response, error) {
 func opName(method, scheme, host string) string {
 	return method + " " + scheme + "://" + host
 }
-
-func decompress(hdr string, rsp *http.Response) io.ReadCloser {
-	var reader io.ReadCloser
-	switch hdr {
-	case "gzip":
-		reader, _ = gzip.NewReader(rsp.Body)
-	case "deflate":
-		reader = flate.NewReader(rsp.Body)
-	default:
-		reader = rsp.Body
-	}
-
-	return reader
-}
@@ -9,15 +9,18 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/beatlabs/patron/encoding"
 	"github.com/beatlabs/patron/reliability/circuitbreaker"
+	"github.com/beatlabs/patron/reliability/retry"
 	"github.com/opentracing/opentracing-go"
 	"github.com/opentracing/opentracing-go/mocktracer"
 	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestTracedClient_Do(t *testing.T) {
@@ -234,3 +237,41 @@ func TestOpName(t *testing.T) {
 		})
 	}
 }
+
+func TestTracedClient_Do_Retry(t *testing.T) {
+	var requests int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		assert.NoError(t, err)
+		assert.Equal(t, "payload", string(body))
+		if atomic.AddInt32(&requests, 1) == 1 {
+			conn, _, err := w.(http.Hijacker).Hijack()
+			assert.NoError(t, err)
+			assert.NoError(t, conn.Close())
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer ts.Close()
+	mtr := mocktracer.New()
+	opentracing.SetGlobalTracer(mtr)
+	defer reqDurationMetrics.Reset()
+
+	r, err := retry.New(3, 0)
+	require.NoError(t, err)
+	c, err := New(Retry(r))
+	require.NoError(t, err)
+	req, err := http.NewRequest(http.MethodPost, ts.URL, bytes.NewBufferString("payload"))
+	require.NoError(t, err)
+
+	rsp, err := c.Do(req)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, rsp.StatusCode)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+
+	spans := mtr.FinishedSpans()
+	sp := spans[len(spans)-1]
+	assert.Equal(t, opName(http.MethodPost, req.URL.Scheme, req.URL.Host), sp.OperationName)
+	assert.Equal(t, 2, sp.Tag("attempts"))
+	assert.Len(t, sp.Logs(), 2)
+}
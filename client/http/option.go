@@ -4,9 +4,12 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
+	"github.com/beatlabs/patron/cache"
 	"github.com/beatlabs/patron/reliability/circuitbreaker"
+	"github.com/beatlabs/patron/reliability/retry"
 	"github.com/opentracing-contrib/go-stdlib/nethttp"
 )
 
@@ -36,6 +39,58 @@ func CircuitBreaker(name string, set circuitbreaker.Setting) OptionFunc {
 	}
 }
 
+// Retry option for retrying requests that fail with an error.
+// Every attempt is recorded on the client span and in the shared retry metric.
+func Retry(r *retry.Retry) OptionFunc {
+	return func(tc *TracedClient) error {
+		if r == nil {
+			return errors.New("retry must be supplied")
+		}
+		tc.rt = r
+		return nil
+	}
+}
+
+// Retries option for retrying requests according to the policy, e.g. DefaultRetryPolicy().
+// It is mutually exclusive with the Retry option.
+func Retries(p RetryPolicy) OptionFunc {
+	return func(tc *TracedClient) error {
+		if err := p.validate(); err != nil {
+			return fmt.Errorf("invalid retry policy: %w", err)
+		}
+		p.StatusCodes = append([]int(nil), p.StatusCodes...)
+		tc.rp = &p
+		return nil
+	}
+}
+
+// Hedging option for hedging the idempotent requests of the client according to the policy, e.g. DefaultHedgePolicy().
+func Hedging(p HedgePolicy) OptionFunc {
+	return func(tc *TracedClient) error {
+		if err := p.validate(); err != nil {
+			return fmt.Errorf("invalid hedge policy: %w", err)
+		}
+		tc.hg = newHedger(p)
+		return nil
+	}
+}
+
+// Cache option for caching the responses of the GET requests of the client, honoring their Cache-Control, Expires and Vary headers.
+// Stale responses with an ETag or a Last-Modified header are kept in the cache for the stale ttl, in order to be revalidated
+// with conditional requests.
+func Cache(c cache.TTLCache, staleTTL time.Duration) OptionFunc {
+	return func(tc *TracedClient) error {
+		if c == nil {
+			return errors.New("cache must be supplied")
+		}
+		if staleTTL < 0 {
+			return errors.New("stale ttl should not be negative")
+		}
+		tc.rc = &responseCache{cache: c, staleTTL: staleTTL}
+		return nil
+	}
+}
+
 // Transport option for setting the Transport for the client.
 func Transport(rt http.RoundTripper) OptionFunc {
 	return func(tc *TracedClient) error {
@@ -47,6 +102,103 @@ func Transport(rt http.RoundTripper) OptionFunc {
 	}
 }
 
+// MaxIdleConnsPerHost option for setting the maximum number of idle connections kept in the pool per host, which defaults to 2.
+// The tuning options apply to the transport of the Transport option, if set before them, which should be an *http.Transport.
+func MaxIdleConnsPerHost(n int) OptionFunc {
+	if n <= 0 {
+		return func(*TracedClient) error { return errors.New("max idle connections per host must be positive") }
+	}
+	return tuneTransport(func(t *http.Transport) {
+		t.MaxIdleConnsPerHost = n
+		if t.MaxIdleConns != 0 && t.MaxIdleConns < n {
+			t.MaxIdleConns = n
+		}
+	})
+}
+
+// MaxConnsPerHost option for limiting the number of connections per host, including the ones in use, which is unlimited by default.
+func MaxConnsPerHost(n int) OptionFunc {
+	if n <= 0 {
+		return func(*TracedClient) error { return errors.New("max connections per host must be positive") }
+	}
+	return tuneTransport(func(t *http.Transport) {
+		t.MaxConnsPerHost = n
+	})
+}
+
+// IdleConnTimeout option for setting the time idle connections are kept in the pool, which defaults to 90 seconds.
+func IdleConnTimeout(timeout time.Duration) OptionFunc {
+	if timeout <= 0 {
+		return func(*TracedClient) error { return errors.New("idle connection timeout must be positive") }
+	}
+	return tuneTransport(func(t *http.Transport) {
+		t.IdleConnTimeout = timeout
+	})
+}
+
+// TLSHandshakeTimeout option for setting the timeout of the TLS handshakes, which defaults to 10 seconds.
+func TLSHandshakeTimeout(timeout time.Duration) OptionFunc {
+	if timeout <= 0 {
+		return func(*TracedClient) error { return errors.New("TLS handshake timeout must be positive") }
+	}
+	return tuneTransport(func(t *http.Transport) {
+		t.TLSHandshakeTimeout = timeout
+	})
+}
+
+// HTTP2 option for enabling or disabling HTTP/2 over TLS, which is enabled by default.
+func HTTP2(enabled bool) OptionFunc {
+	return tuneTransport(func(t *http.Transport) {
+		if !enabled {
+			disableHTTP2(t)
+			return
+		}
+		t.ForceAttemptHTTP2 = true
+		t.TLSNextProto = nil
+	})
+}
+
+// Decoder option for decompressing the responses of the content coding on top of the built-in gzip, deflate, zstd and brotli (`br`),
+// or replacing the built-in one of the same name. Responses are decompressed only for requests with an Accept-Encoding header,
+// which should include the coding.
+func Decoder(name string, fn DecoderFunc) OptionFunc {
+	return func(tc *TracedClient) error {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			return errors.New("decoder name is empty")
+		}
+		if fn == nil {
+			return errors.New("decoder is nil")
+		}
+		if tc.decoders == nil {
+			tc.decoders = make(map[string]DecoderFunc)
+		}
+		tc.decoders[name] = fn
+		return nil
+	}
+}
+
+// AcceptEncoding option for setting the Accept-Encoding header of the requests lacking one to the provided content codings,
+// in order of preference e.g. `zstd`, `br` and `gzip`, so that their responses are compressed by the server and decompressed by the client.
+// The codings should be decompressed by the client, either built-in or provided with the Decoder option.
+func AcceptEncoding(encodings ...string) OptionFunc {
+	return func(tc *TracedClient) error {
+		if len(encodings) == 0 {
+			return errors.New("accepted encodings are empty")
+		}
+		ee := make([]string, 0, len(encodings))
+		for _, e := range encodings {
+			e = strings.ToLower(strings.TrimSpace(e))
+			if e == "" {
+				return errors.New("accepted encoding is empty")
+			}
+			ee = append(ee, e)
+		}
+		tc.acceptEncoding = ee
+		return nil
+	}
+}
+
 // CheckRedirect option for setting the CheckRedirect for the client.
 func CheckRedirect(cr func(req *http.Request, via []*http.Request) error) OptionFunc {
 	return func(tc *TracedClient) error {
@@ -1,13 +1,17 @@
 package http
 
 import (
+	"io"
+	"io/ioutil"
 	"net/http"
 	"reflect"
 	"runtime"
 	"testing"
 
+	"github.com/beatlabs/patron/reliability/retry"
 	"github.com/opentracing-contrib/go-stdlib/nethttp"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestTransport(t *testing.T) {
@@ -46,3 +50,75 @@ func TestCheckRedirect(t *testing.T) {
 	actFuncName := runtime.FuncForPC(reflect.ValueOf(client.cl.CheckRedirect).Pointer()).Name()
 	assert.Equal(t, expFuncName, actFuncName)
 }
+
+func TestDecoder(t *testing.T) {
+	decoder := func(r io.Reader) (io.ReadCloser, error) {
+		return ioutil.NopCloser(r), nil
+	}
+	tests := map[string]struct {
+		name        string
+		decoder     DecoderFunc
+		expectedErr string
+	}{
+		"success":     {name: " BR ", decoder: decoder},
+		"empty name":  {name: " ", decoder: decoder, expectedErr: "decoder name is empty"},
+		"nil decoder": {name: "br", expectedErr: "decoder is nil"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			client, err := New(Decoder(tt.name, tt.decoder))
+			if tt.expectedErr != "" {
+				assert.Nil(t, client)
+				assert.EqualError(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Contains(t, client.decoders, "br")
+		})
+	}
+}
+
+func TestAcceptEncoding(t *testing.T) {
+	decoder := func(r io.Reader) (io.ReadCloser, error) {
+		return ioutil.NopCloser(r), nil
+	}
+	tests := map[string]struct {
+		options     []OptionFunc
+		expected    []string
+		expectedErr string
+	}{
+		"built-in":        {options: []OptionFunc{AcceptEncoding(" ZSTD ", "br", "gzip")}, expected: []string{"zstd", "br", "gzip"}},
+		"custom decoder":  {options: []OptionFunc{AcceptEncoding("xz"), Decoder("xz", decoder)}, expected: []string{"xz"}},
+		"empty":           {options: []OptionFunc{AcceptEncoding()}, expectedErr: "accepted encodings are empty"},
+		"empty encoding":  {options: []OptionFunc{AcceptEncoding("gzip", " ")}, expectedErr: "accepted encoding is empty"},
+		"missing decoder": {options: []OptionFunc{AcceptEncoding("xz")}, expectedErr: "accepted encoding xz has no decoder"},
+		"identity":        {options: []OptionFunc{AcceptEncoding("br", "identity")}, expected: []string{"br", "identity"}},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			client, err := New(tt.options...)
+			if tt.expectedErr != "" {
+				assert.Nil(t, client)
+				assert.EqualError(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, client.acceptEncoding)
+		})
+	}
+}
+
+func TestRetry(t *testing.T) {
+	r, err := retry.New(3, 0)
+	require.NoError(t, err)
+
+	client, err := New(Retry(r))
+	assert.NoError(t, err)
+	assert.Equal(t, r, client.rt)
+
+	client, err = New(Retry(nil))
+	assert.Nil(t, client)
+	assert.EqualError(t, err, "retry must be supplied")
+}
@@ -0,0 +1,134 @@
+package http
+
+import (
+	"crypto/tls"
+	"errors"
+	"net/http"
+	"net/http/httptrace"
+	"time"
+
+	"github.com/opentracing-contrib/go-stdlib/nethttp"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	dialResultSuccess = "success"
+	dialResultError   = "error"
+	connStateNew      = "new"
+	connStateReused   = "reused"
+)
+
+var (
+	dialCounter       *prometheus.CounterVec
+	connectionCounter *prometheus.CounterVec
+	dnsDurationMetric *prometheus.HistogramVec
+	inFlightGauge     *prometheus.GaugeVec
+)
+
+func init() {
+	dialCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "client",
+			Subsystem: "http",
+			Name:      "dials_total",
+			Help:      "Connections dialed by the client, classified by host and result (success or error).",
+		},
+		[]string{"host", "result"},
+	)
+	connectionCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "client",
+			Subsystem: "http",
+			Name:      "connections_total",
+			Help:      "Connections obtained by the client for its requests, classified by host and state (new or reused from the pool).",
+		},
+		[]string{"host", "state"},
+	)
+	dnsDurationMetric = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "client",
+			Subsystem: "http",
+			Name:      "dns_duration_seconds",
+			Help:      "DNS lookups of the client, classified by host.",
+		},
+		[]string{"host"},
+	)
+	inFlightGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "client",
+			Subsystem: "http",
+			Name:      "in_flight_requests",
+			Help:      "Requests of the client waiting for their responses, classified by host.",
+		},
+		[]string{"host"},
+	)
+	prometheus.MustRegister(dialCounter, connectionCounter, dnsDurationMetric, inFlightGauge)
+}
+
+// withPoolTrace returns the request with the client trace observing the connections of the pool of the transport.
+func withPoolTrace(req *http.Request) *http.Request {
+	host := req.URL.Host
+	var dnsStart time.Time
+	trace := &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			dnsStart = time.Now()
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			if !dnsStart.IsZero() {
+				dnsDurationMetric.WithLabelValues(host).Observe(time.Since(dnsStart).Seconds())
+			}
+		},
+		ConnectDone: func(_, _ string, err error) {
+			result := dialResultSuccess
+			if err != nil {
+				result = dialResultError
+			}
+			dialCounter.WithLabelValues(host, result).Inc()
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			state := connStateNew
+			if info.Reused {
+				state = connStateReused
+			}
+			connectionCounter.WithLabelValues(host, state).Inc()
+		},
+	}
+	return req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+}
+
+// transport returns the transport of the client to tune, which is a clone of the default transport,
+// unless a transport is set with the Transport option.
+func (tc *TracedClient) transport() (*http.Transport, error) {
+	nt, ok := tc.cl.Transport.(*nethttp.Transport)
+	if !ok {
+		return nil, errors.New("transport cannot be tuned")
+	}
+	if nt.RoundTripper == nil {
+		t := http.DefaultTransport.(*http.Transport).Clone()
+		nt.RoundTripper = t
+		return t, nil
+	}
+	t, ok := nt.RoundTripper.(*http.Transport)
+	if !ok {
+		return nil, errors.New("transport is not an *http.Transport")
+	}
+	return t, nil
+}
+
+// tuneTransport applies the tuning to the transport of the client.
+func tuneTransport(tune func(*http.Transport)) OptionFunc {
+	return func(tc *TracedClient) error {
+		t, err := tc.transport()
+		if err != nil {
+			return err
+		}
+		tune(t)
+		return nil
+	}
+}
+
+// disableHTTP2 disables HTTP/2 in the transport, by setting a non-nil empty map of TLS protocol upgrades.
+func disableHTTP2(t *http.Transport) {
+	t.ForceAttemptHTTP2 = false
+	t.TLSNextProto = make(map[string]func(string, *tls.Conn) http.RoundTripper)
+}
@@ -0,0 +1,175 @@
+package http
+
+import (
+	"crypto/tls"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/opentracing-contrib/go-stdlib/nethttp"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestTransportTuning(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		oo          []OptionFunc
+		assert      func(*testing.T, *http.Transport)
+		expectedErr string
+	}{
+		"max idle connections per host": {
+			oo: []OptionFunc{MaxIdleConnsPerHost(200)},
+			assert: func(t *testing.T, tr *http.Transport) {
+				assert.Equal(t, 200, tr.MaxIdleConnsPerHost)
+				assert.Equal(t, 200, tr.MaxIdleConns)
+			},
+		},
+		"max connections per host": {
+			oo:     []OptionFunc{MaxConnsPerHost(10)},
+			assert: func(t *testing.T, tr *http.Transport) { assert.Equal(t, 10, tr.MaxConnsPerHost) },
+		},
+		"idle connection timeout": {
+			oo:     []OptionFunc{IdleConnTimeout(time.Minute)},
+			assert: func(t *testing.T, tr *http.Transport) { assert.Equal(t, time.Minute, tr.IdleConnTimeout) },
+		},
+		"TLS handshake timeout": {
+			oo:     []OptionFunc{TLSHandshakeTimeout(time.Second)},
+			assert: func(t *testing.T, tr *http.Transport) { assert.Equal(t, time.Second, tr.TLSHandshakeTimeout) },
+		},
+		"HTTP/2 disabled": {
+			oo: []OptionFunc{HTTP2(false)},
+			assert: func(t *testing.T, tr *http.Transport) {
+				assert.False(t, tr.ForceAttemptHTTP2)
+				assert.NotNil(t, tr.TLSNextProto)
+				assert.Empty(t, tr.TLSNextProto)
+			},
+		},
+		"HTTP/2 enabled again": {
+			oo: []OptionFunc{HTTP2(false), HTTP2(true)},
+			assert: func(t *testing.T, tr *http.Transport) {
+				assert.True(t, tr.ForceAttemptHTTP2)
+				assert.Nil(t, tr.TLSNextProto)
+			},
+		},
+		"transport tuned": {
+			oo: []OptionFunc{Transport(&http.Transport{TLSNextProto: map[string]func(string, *tls.Conn) http.RoundTripper{}}), HTTP2(true)},
+			assert: func(t *testing.T, tr *http.Transport) {
+				assert.True(t, tr.ForceAttemptHTTP2)
+				assert.Nil(t, tr.TLSNextProto)
+			},
+		},
+		"invalid max idle connections per host": {oo: []OptionFunc{MaxIdleConnsPerHost(0)}, expectedErr: "max idle connections per host must be positive"},
+		"invalid max connections per host":      {oo: []OptionFunc{MaxConnsPerHost(-1)}, expectedErr: "max connections per host must be positive"},
+		"invalid idle connection timeout":       {oo: []OptionFunc{IdleConnTimeout(0)}, expectedErr: "idle connection timeout must be positive"},
+		"invalid TLS handshake timeout":         {oo: []OptionFunc{TLSHandshakeTimeout(0)}, expectedErr: "TLS handshake timeout must be positive"},
+		"custom transport": {
+			oo:          []OptionFunc{Transport(roundTripperFunc(nil)), HTTP2(false)},
+			expectedErr: "transport is not an *http.Transport",
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			got, err := New(tt.oo...)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.Nil(t, got)
+				return
+			}
+			require.NoError(t, err)
+			tr, ok := got.cl.Transport.(*nethttp.Transport).RoundTripper.(*http.Transport)
+			require.True(t, ok)
+			tt.assert(t, tr)
+		})
+	}
+}
+
+func TestTransportTuning_DefaultTransportUnchanged(t *testing.T) {
+	t.Parallel()
+	_, err := New(MaxIdleConnsPerHost(500))
+	require.NoError(t, err)
+	assert.NotEqual(t, 500, http.DefaultTransport.(*http.Transport).MaxIdleConnsPerHost)
+}
+
+func TestTracedClient_Do_PoolMetrics(t *testing.T) {
+	t.Parallel()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	t.Cleanup(ts.Close)
+	host := ts.Listener.Addr().String()
+
+	c, err := New(MaxIdleConnsPerHost(1))
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		req, err := http.NewRequest(http.MethodGet, ts.URL, nil)
+		require.NoError(t, err)
+		rsp, err := c.Do(req)
+		require.NoError(t, err)
+		_, err = ioutil.ReadAll(rsp.Body)
+		require.NoError(t, err)
+		require.NoError(t, rsp.Body.Close())
+	}
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(dialCounter.WithLabelValues(host, dialResultSuccess)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(connectionCounter.WithLabelValues(host, connStateNew)))
+	assert.Equal(t, 2.0, testutil.ToFloat64(connectionCounter.WithLabelValues(host, connStateReused)))
+	assert.Equal(t, 0.0, testutil.ToFloat64(inFlightGauge.WithLabelValues(host)))
+}
+
+func TestTracedClient_Do_PoolMetrics_DialError(t *testing.T) {
+	t.Parallel()
+	ts := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	host := ts.Listener.Addr().String()
+	ts.Close()
+
+	c, err := New()
+	require.NoError(t, err)
+	req, err := http.NewRequest(http.MethodGet, ts.URL, nil)
+	require.NoError(t, err)
+	rsp, err := c.Do(req)
+	assert.Error(t, err)
+	assert.Nil(t, rsp)
+	assert.Equal(t, 1.0, testutil.ToFloat64(dialCounter.WithLabelValues(host, dialResultError)))
+	assert.Equal(t, 0.0, testutil.ToFloat64(inFlightGauge.WithLabelValues(host)))
+}
+
+func TestTracedClient_Do_InFlight(t *testing.T) {
+	t.Parallel()
+	started, release := make(chan struct{}), make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	}))
+	t.Cleanup(ts.Close)
+	host := ts.Listener.Addr().String()
+
+	c, err := New()
+	require.NoError(t, err)
+	req, err := http.NewRequest(http.MethodGet, ts.URL, nil)
+	require.NoError(t, err)
+	errCh := make(chan error, 1)
+	go func() {
+		rsp, err := c.Do(req)
+		if err == nil {
+			err = rsp.Body.Close()
+		}
+		errCh <- err
+	}()
+	<-started
+	assert.Equal(t, 1.0, testutil.ToFloat64(inFlightGauge.WithLabelValues(host)))
+	close(release)
+	assert.NoError(t, <-errCh)
+	assert.Equal(t, 0.0, testutil.ToFloat64(inFlightGauge.WithLabelValues(host)))
+}
@@ -0,0 +1,264 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/beatlabs/patron/reliability/retry"
+	"github.com/opentracing-contrib/go-stdlib/nethttp"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// IdempotencyKeyHeader marks requests of non-idempotent methods, e.g. POST, as safe to retry,
+	// since the server deduplicates the requests with the same key.
+	IdempotencyKeyHeader = "Idempotency-Key"
+
+	retryReasonError = "error"
+	// maxRetryDrainSize is the maximum number of bytes drained from the bodies of retried responses, so that their connections are reused.
+	maxRetryDrainSize = 4 << 10
+)
+
+var retryCounter *prometheus.CounterVec
+
+func init() {
+	retryCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "client",
+			Subsystem: "http",
+			Name:      "request_retries_total",
+			Help:      "HTTP requests retried by the client, classified by host, method and reason (status code or error).",
+		},
+		[]string{"host", "method", "reason"},
+	)
+	prometheus.MustRegister(retryCounter)
+}
+
+// RetryPolicy defines the retries of the requests of the client.
+//
+// Requests are retried on the responses with the status codes of the policy, and on network errors if enabled,
+// waiting for an exponential backoff with jitter between the attempts, or for the Retry-After header of the response, if any.
+// Only the requests of idempotent methods, i.e. GET, HEAD, OPTIONS, TRACE, PUT and DELETE, or with an Idempotency-Key header
+// are retried, unless the policy allows retrying non-idempotent requests. Requests with a body are retried only if it can be
+// rewound, which is the case for the requests created by http.NewRequest with a bytes.Buffer, bytes.Reader or strings.Reader body.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts, including the first one, which should be greater than 1.
+	MaxAttempts int
+	// InitialBackoff is the backoff before the first retry, which doubles on every retry.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the backoff and the Retry-After delay. There is no cap if it is zero.
+	MaxBackoff time.Duration
+	// Jitter is the random fraction of the backoff subtracted from it, between 0 and 1, which spreads the retries of concurrent requests.
+	Jitter float64
+	// StatusCodes are the status codes of the responses to retry.
+	StatusCodes []int
+	// NetworkErrors enables retrying requests which failed with network errors, e.g. refused or reset connections and timeouts.
+	NetworkErrors bool
+	// NonIdempotent enables retrying the requests of non-idempotent methods without an Idempotency-Key header.
+	NonIdempotent bool
+}
+
+// DefaultRetryPolicy returns a policy of 3 attempts with a backoff starting at 100ms, capped at 5s, with a jitter of 0.5,
+// retrying network errors and the 429, 502, 503 and 504 status codes.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     5 * time.Second,
+		Jitter:         0.5,
+		StatusCodes: []int{
+			http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout,
+		},
+		NetworkErrors: true,
+	}
+}
+
+func (p RetryPolicy) validate() error {
+	if p.MaxAttempts <= 1 {
+		return errors.New("max attempts should be greater than 1")
+	}
+	if p.InitialBackoff < 0 {
+		return errors.New("initial backoff should not be negative")
+	}
+	if p.MaxBackoff < 0 {
+		return errors.New("max backoff should not be negative")
+	}
+	if p.MaxBackoff > 0 && p.MaxBackoff < p.InitialBackoff {
+		return errors.New("max backoff should not be less than the initial backoff")
+	}
+	if p.Jitter < 0 || p.Jitter > 1 {
+		return errors.New("jitter should be between 0 and 1")
+	}
+	if len(p.StatusCodes) == 0 && !p.NetworkErrors {
+		return errors.New("status codes or network errors should be retried")
+	}
+	for _, code := range p.StatusCodes {
+		if code < 100 || code > 599 {
+			return fmt.Errorf("status code %d is invalid", code)
+		}
+	}
+	return nil
+}
+
+// retryable returns true if the request can be retried according to its method, headers and body.
+func (p RetryPolicy) retryable(req *http.Request) bool {
+	return rewindable(req) && (p.NonIdempotent || idempotent(req))
+}
+
+// idempotent returns true for the requests of idempotent methods, or with an Idempotency-Key header.
+func idempotent(req *http.Request) bool {
+	if req.Header.Get(IdempotencyKeyHeader) != "" {
+		return true
+	}
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// rewindable returns true if the body of the request, if any, can be sent again.
+func rewindable(req *http.Request) bool {
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+// retryReason returns the reason to retry the outcome of an attempt, if it should be retried.
+func (p RetryPolicy) retryReason(rsp *http.Response, err error) (string, bool) {
+	if err != nil {
+		if p.NetworkErrors && isNetworkError(err) {
+			return retryReasonError, true
+		}
+		return "", false
+	}
+	for _, code := range p.StatusCodes {
+		if rsp.StatusCode == code {
+			return strconv.Itoa(code), true
+		}
+	}
+	return "", false
+}
+
+// backoff returns the delay before the retry following the attempt, which is the Retry-After delay of the response, if any.
+func (p RetryPolicy) backoff(attempt int, rsp *http.Response) time.Duration {
+	if d, ok := retryAfter(rsp); ok {
+		return p.cap(d)
+	}
+	d := p.InitialBackoff
+	for i := 1; i < attempt && (p.MaxBackoff == 0 || d < p.MaxBackoff); i++ {
+		d *= 2
+	}
+	d = p.cap(d)
+	if p.Jitter > 0 {
+		d -= time.Duration(rand.Float64() * p.Jitter * float64(d)) // nolint:gosec
+	}
+	return d
+}
+
+func (p RetryPolicy) cap(d time.Duration) time.Duration {
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		return p.MaxBackoff
+	}
+	return d
+}
+
+// retryAfter returns the delay of the Retry-After header of the response, in seconds or as an HTTP date.
+func retryAfter(rsp *http.Response) (time.Duration, bool) {
+	if rsp == nil {
+		return 0, false
+	}
+	h := rsp.Header.Get("Retry-After")
+	if h == "" {
+		return 0, false
+	}
+	if s, err := strconv.Atoi(h); err == nil && s >= 0 {
+		return time.Duration(s) * time.Second, true
+	}
+	if t, err := http.ParseTime(h); err == nil {
+		d := time.Until(t)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+	return 0, false
+}
+
+// isNetworkError returns true for the errors of the connection to the server, e.g. refused or reset connections and timeouts.
+func isNetworkError(err error) bool {
+	// the errors of the client are url errors, which implement net.Error themselves
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		err = urlErr.Err
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// doWithPolicy executes the request, retrying it according to the policy of the client.
+func (tc *TracedClient) doWithPolicy(req *http.Request, ht *nethttp.Tracer) (*http.Response, error) {
+	maxAttempts := tc.rp.MaxAttempts
+	if !tc.rp.retryable(req) {
+		maxAttempts = 1
+	}
+
+	attempt := 1
+	defer func() {
+		retry.ObserveAttempts(ht.Span(), attempt)
+	}()
+	for ; ; attempt++ {
+		if attempt > 1 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+
+		rsp, err := tc.doOnce(req)
+		attemptErr := err
+		if err == nil && rsp.StatusCode >= http.StatusBadRequest {
+			attemptErr = fmt.Errorf("status code %d", rsp.StatusCode)
+		}
+		retry.ObserveAttempt(ht.Span(), clientComponent, attempt, attemptErr)
+
+		reason, ok := tc.rp.retryReason(rsp, err)
+		if !ok || attempt >= maxAttempts || req.Context().Err() != nil {
+			return rsp, err
+		}
+
+		delay := tc.rp.backoff(attempt, rsp)
+		if rsp != nil {
+			// the body is drained, so that the connection is reused by the retry
+			_, _ = io.CopyN(ioutil.Discard, rsp.Body, maxRetryDrainSize)
+			_ = rsp.Body.Close()
+		}
+		retryCounter.WithLabelValues(req.URL.Host, req.Method, reason).Inc()
+
+		if err := sleep(req.Context(), delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
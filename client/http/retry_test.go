@@ -0,0 +1,225 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/beatlabs/patron/reliability/retry"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetries(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		policy      func(*RetryPolicy)
+		expectedErr string
+	}{
+		"default":                   {policy: func(*RetryPolicy) {}},
+		"no max backoff":            {policy: func(p *RetryPolicy) { p.MaxBackoff = 0 }},
+		"one attempt":               {policy: func(p *RetryPolicy) { p.MaxAttempts = 1 }, expectedErr: "invalid retry policy: max attempts should be greater than 1"},
+		"negative initial backoff":  {policy: func(p *RetryPolicy) { p.InitialBackoff = -1 }, expectedErr: "invalid retry policy: initial backoff should not be negative"},
+		"negative max backoff":      {policy: func(p *RetryPolicy) { p.MaxBackoff = -1 }, expectedErr: "invalid retry policy: max backoff should not be negative"},
+		"max less than initial":     {policy: func(p *RetryPolicy) { p.MaxBackoff = time.Millisecond }, expectedErr: "invalid retry policy: max backoff should not be less than the initial backoff"},
+		"invalid jitter":            {policy: func(p *RetryPolicy) { p.Jitter = 1.5 }, expectedErr: "invalid retry policy: jitter should be between 0 and 1"},
+		"nothing to retry":          {policy: func(p *RetryPolicy) { p.StatusCodes = nil; p.NetworkErrors = false }, expectedErr: "invalid retry policy: status codes or network errors should be retried"},
+		"invalid status code":       {policy: func(p *RetryPolicy) { p.StatusCodes = []int{600} }, expectedErr: "invalid retry policy: status code 600 is invalid"},
+		"only network errors":       {policy: func(p *RetryPolicy) { p.StatusCodes = nil }},
+		"only status codes":         {policy: func(p *RetryPolicy) { p.NetworkErrors = false }},
+		"non idempotent and jitter": {policy: func(p *RetryPolicy) { p.NonIdempotent = true; p.Jitter = 1 }},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			p := DefaultRetryPolicy()
+			tt.policy(&p)
+			got, err := New(Retries(p))
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, got)
+			}
+		})
+	}
+}
+
+func TestRetries_MutuallyExclusive(t *testing.T) {
+	t.Parallel()
+	r, err := retry.New(3, 0)
+	require.NoError(t, err)
+	got, err := New(Retry(r), Retries(DefaultRetryPolicy()))
+	assert.EqualError(t, err, "retry and retry policy are mutually exclusive")
+	assert.Nil(t, got)
+}
+
+func TestTracedClient_Do_RetryPolicy(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		method           string
+		header           http.Header
+		nonIdempotent    bool
+		failures         int32
+		failWithError    bool
+		expectedStatus   int
+		expectedRequests int32
+	}{
+		"get retried": {
+			method: http.MethodGet, failures: 2, expectedStatus: http.StatusOK, expectedRequests: 3,
+		},
+		"get exhausted": {
+			method: http.MethodGet, failures: 5, expectedStatus: http.StatusServiceUnavailable, expectedRequests: 3,
+		},
+		"get network error retried": {
+			method: http.MethodGet, failures: 1, failWithError: true, expectedStatus: http.StatusOK, expectedRequests: 2,
+		},
+		"post not retried": {
+			method: http.MethodPost, failures: 1, expectedStatus: http.StatusServiceUnavailable, expectedRequests: 1,
+		},
+		"post with idempotency key retried": {
+			method: http.MethodPost, header: http.Header{IdempotencyKeyHeader: []string{"123"}}, failures: 1,
+			expectedStatus: http.StatusOK, expectedRequests: 2,
+		},
+		"post retried when non idempotent allowed": {
+			method: http.MethodPost, nonIdempotent: true, failures: 1, expectedStatus: http.StatusOK, expectedRequests: 2,
+		},
+		"put retried": {
+			method: http.MethodPut, failures: 1, expectedStatus: http.StatusOK, expectedRequests: 2,
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			var requests int32
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, err := ioutil.ReadAll(r.Body)
+				assert.NoError(t, err)
+				assert.Equal(t, "payload", string(body))
+				if atomic.AddInt32(&requests, 1) > tt.failures {
+					w.WriteHeader(http.StatusOK)
+					return
+				}
+				if tt.failWithError {
+					conn, _, err := w.(http.Hijacker).Hijack()
+					assert.NoError(t, err)
+					assert.NoError(t, conn.Close())
+					return
+				}
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}))
+			t.Cleanup(ts.Close)
+
+			p := DefaultRetryPolicy()
+			p.InitialBackoff = time.Millisecond
+			p.NonIdempotent = tt.nonIdempotent
+			c, err := New(Retries(p))
+			require.NoError(t, err)
+			req, err := http.NewRequest(tt.method, ts.URL, bytes.NewBufferString("payload"))
+			require.NoError(t, err)
+			for k, v := range tt.header {
+				req.Header[k] = v
+			}
+
+			rsp, err := c.Do(req)
+			require.NoError(t, err)
+			assert.NoError(t, rsp.Body.Close())
+			assert.Equal(t, tt.expectedStatus, rsp.StatusCode)
+			assert.Equal(t, tt.expectedRequests, atomic.LoadInt32(&requests))
+		})
+	}
+}
+
+func TestTracedClient_Do_RetryPolicyMetric(t *testing.T) {
+	t.Parallel()
+	var requests int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(ts.Close)
+	host := ts.Listener.Addr().String()
+
+	c, err := New(Retries(RetryPolicy{MaxAttempts: 2, StatusCodes: []int{http.StatusTooManyRequests}}))
+	require.NoError(t, err)
+	req, err := http.NewRequest(http.MethodDelete, ts.URL, nil)
+	require.NoError(t, err)
+	rsp, err := c.Do(req)
+	require.NoError(t, err)
+	assert.NoError(t, rsp.Body.Close())
+	assert.Equal(t, http.StatusOK, rsp.StatusCode)
+	assert.Equal(t, 1.0, testutil.ToFloat64(retryCounter.WithLabelValues(host, http.MethodDelete, "429")))
+}
+
+func TestTracedClient_Do_RetryPolicyCanceled(t *testing.T) {
+	t.Parallel()
+	var requests int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(ts.Close)
+
+	c, err := New(Retries(DefaultRetryPolicy()))
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	t.Cleanup(cancel)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL, nil)
+	require.NoError(t, err)
+
+	rsp, err := c.Do(req)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.Nil(t, rsp)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	t.Parallel()
+	p := RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+	assert.Equal(t, 100*time.Millisecond, p.backoff(1, nil))
+	assert.Equal(t, 200*time.Millisecond, p.backoff(2, nil))
+	assert.Equal(t, 800*time.Millisecond, p.backoff(4, nil))
+	assert.Equal(t, time.Second, p.backoff(5, nil))
+	assert.Equal(t, time.Second, p.backoff(100, nil))
+
+	p.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		d := p.backoff(2, nil)
+		assert.True(t, d >= 100*time.Millisecond && d <= 200*time.Millisecond, d)
+	}
+	p.Jitter = 0
+
+	rsp := &http.Response{Header: http.Header{"Retry-After": []string{"3"}}}
+	assert.Equal(t, time.Second, p.backoff(1, rsp))
+	p.MaxBackoff = 0
+	assert.Equal(t, 3*time.Second, p.backoff(1, rsp))
+	rsp.Header.Set("Retry-After", time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat))
+	assert.Equal(t, time.Duration(0), p.backoff(1, rsp))
+	rsp.Header.Set("Retry-After", "soon")
+	assert.Equal(t, 100*time.Millisecond, p.backoff(1, rsp))
+}
+
+func TestIsNetworkError(t *testing.T) {
+	t.Parallel()
+	assert.True(t, isNetworkError(&url.Error{Op: "Get", URL: "http://localhost", Err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}}))
+	assert.True(t, isNetworkError(&url.Error{Op: "Get", URL: "http://localhost", Err: io.EOF}))
+	assert.False(t, isNetworkError(&url.Error{Op: "Get", URL: "http://localhost", Err: context.Canceled}))
+	assert.False(t, isNetworkError(errors.New("circuit is open")))
+}
@@ -3,6 +3,7 @@ package kafka
 import (
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/beatlabs/patron/encoding"
@@ -169,6 +170,7 @@ func (ab *Builder) CreateSync() (*SyncProducer, error) {
 	ab.cfg.Producer.Return.Successes = true
 
 	p := SyncProducer{
+		brokers: strings.Join(ab.brokers, ","),
 		baseProducer: baseProducer{
 			messageStatus: messageStatus,
 			deliveryType:  "sync",
@@ -3,7 +3,9 @@ package kafka
 import (
 	"context"
 	"fmt"
+	"time"
 
+	"github.com/beatlabs/patron/dependency"
 	"github.com/beatlabs/patron/trace"
 
 	"github.com/Shopify/sarama"
@@ -16,6 +18,7 @@ type SyncProducer struct {
 	baseProducer
 
 	syncProd sarama.SyncProducer
+	brokers  string
 }
 
 // Send a message to a topic.
@@ -30,7 +33,9 @@ func (p *SyncProducer) Send(ctx context.Context, msg *Message) error {
 		return err
 	}
 
+	start := time.Now()
 	_, _, err = p.syncProd.SendMessage(pm)
+	dependency.Observe("kafka", p.brokers, err == nil, time.Since(start))
 	if err != nil {
 		p.statusCountInc(messageCreationErrors, msg.topic)
 		trace.SpanError(sp)
@@ -5,11 +5,13 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/Shopify/sarama"
-	"github.com/beatlabs/patron/correlation"
 	patronerrors "github.com/beatlabs/patron/errors"
 	"github.com/beatlabs/patron/internal/validation"
+	"github.com/beatlabs/patron/propagation"
+	"github.com/beatlabs/patron/reliability/retry"
 	"github.com/opentracing/opentracing-go"
 	"github.com/prometheus/client_golang/prometheus"
 )
@@ -120,7 +122,7 @@ func (b *Builder) Create() (*SyncProducer, error) {
 	p := SyncProducer{}
 
 	var err error
-	p.prodClient, err = sarama.NewClient(b.brokers, b.cfg)
+	p.prodClient, err = sarama.NewClient(b.brokers, b.observedConfig(componentTypeSync))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create producer client: %w", err)
 	}
@@ -145,7 +147,7 @@ func (b Builder) CreateAsync() (*AsyncProducer, <-chan error, error) {
 	}
 
 	var err error
-	ap.prodClient, err = sarama.NewClient(b.brokers, b.cfg)
+	ap.prodClient, err = sarama.NewClient(b.brokers, b.observedConfig(componentTypeAsync))
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to create producer client: %w", err)
 	}
@@ -160,20 +162,28 @@ func (b Builder) CreateAsync() (*AsyncProducer, <-chan error, error) {
 	return ap, chErr, nil
 }
 
-type kafkaHeadersCarrier []sarama.RecordHeader
+// observedConfig returns a copy of the Sarama configuration, whose producer and metadata retries are counted in the shared
+// retry metric of the client. Sarama reports neither the outcome nor the message of a retry, so the retries are counted
+// with an unknown outcome and are not recorded on the spans.
+func (b *Builder) observedConfig(client string) *sarama.Config {
+	cfg := *b.cfg
+	cfg.Producer.Retry.BackoffFunc = observedBackoff(client, cfg.Producer.Retry.BackoffFunc, cfg.Producer.Retry.Backoff)
+	cfg.Metadata.Retry.BackoffFunc = observedBackoff(client, cfg.Metadata.Retry.BackoffFunc, cfg.Metadata.Retry.Backoff)
+	return &cfg
+}
 
-// Set implements Set() of opentracing.TextMapWriter.
-func (c *kafkaHeadersCarrier) Set(key, val string) {
-	*c = append(*c, sarama.RecordHeader{Key: []byte(key), Value: []byte(val)})
+// observedBackoff wraps the backoff function, or the fixed backoff if there is none, to observe every retry.
+func observedBackoff(client string, backoffFunc func(retries, maxRetries int) time.Duration,
+	backoff time.Duration) func(retries, maxRetries int) time.Duration {
+	return func(retries, maxRetries int) time.Duration {
+		retry.ObserveRetry(client)
+		if backoffFunc != nil {
+			return backoffFunc(retries, maxRetries)
+		}
+		return backoff
+	}
 }
 
 func injectTracingAndCorrelationHeaders(ctx context.Context, msg *sarama.ProducerMessage, sp opentracing.Span) error {
-	msg.Headers = append(msg.Headers, sarama.RecordHeader{
-		Key:   []byte(correlation.HeaderID),
-		Value: []byte(correlation.IDFromContext(ctx)),
-	})
-	c := kafkaHeadersCarrier(msg.Headers)
-	err := sp.Tracer().Inject(sp.Context(), opentracing.TextMap, &c)
-	msg.Headers = c
-	return err
+	return propagation.Inject(ctx, sp, (*propagation.KafkaHeaders)(&msg.Headers))
 }
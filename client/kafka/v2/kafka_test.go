@@ -3,8 +3,10 @@ package v2
 import (
 	"context"
 	"fmt"
+	"net"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/Shopify/sarama"
 	"github.com/beatlabs/patron/correlation"
@@ -12,6 +14,7 @@ import (
 	"github.com/opentracing/opentracing-go"
 	"github.com/opentracing/opentracing-go/ext"
 	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -78,6 +81,83 @@ func TestDefaultProducerSaramaConfig(t *testing.T) {
 	require.False(t, sc.Producer.Idempotent)
 }
 
+func TestBuilder_observedConfig(t *testing.T) {
+	t.Parallel()
+	cfg := sarama.NewConfig()
+	cfg.Producer.Retry.Backoff = 10 * time.Millisecond
+	cfg.Metadata.Retry.BackoffFunc = func(retries, maxRetries int) time.Duration {
+		return time.Duration(retries) * time.Second
+	}
+
+	got := New([]string{"123"}, cfg).observedConfig(componentTypeSync)
+
+	require.NotNil(t, got.Producer.Retry.BackoffFunc)
+	assert.Equal(t, 10*time.Millisecond, got.Producer.Retry.BackoffFunc(1, 3))
+	assert.Equal(t, 2*time.Second, got.Metadata.Retry.BackoffFunc(2, 3))
+	// the configuration of the builder is left intact
+	assert.Nil(t, cfg.Producer.Retry.BackoffFunc)
+	assert.Equal(t, time.Second, cfg.Metadata.Retry.BackoffFunc(1, 3))
+}
+
+func TestBuilder_ObservesRetries(t *testing.T) {
+	// the broker refuses connections, so the metadata requests of the client are retried
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	broker := lis.Addr().String()
+	require.NoError(t, lis.Close())
+
+	tests := map[string]struct {
+		create func(b *Builder) error
+		client string
+	}{
+		"sync": {
+			create: func(b *Builder) error {
+				_, err := b.Create()
+				return err
+			},
+			client: componentTypeSync,
+		},
+		"async": {
+			create: func(b *Builder) error {
+				_, _, err := b.CreateAsync()
+				return err
+			},
+			client: componentTypeAsync,
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			cfg := sarama.NewConfig()
+			cfg.Metadata.Retry.Max = 2
+			cfg.Metadata.Retry.Backoff = time.Millisecond
+			before := retryAttempts(t, tt.client)
+
+			require.Error(t, tt.create(New([]string{broker}, cfg)))
+			assert.Equal(t, 2.0, retryAttempts(t, tt.client)-before)
+		})
+	}
+}
+
+// retryAttempts returns the retries of the client counted in the shared retry metric.
+func retryAttempts(t *testing.T, client string) float64 {
+	mfs, err := prometheus.DefaultGatherer.Gather()
+	require.NoError(t, err)
+	for _, mf := range mfs {
+		if mf.GetName() != "reliability_retry_attempts_total" {
+			continue
+		}
+		for _, m := range mf.GetMetric() {
+			for _, l := range m.GetLabel() {
+				if l.GetName() == "client" && l.GetValue() == client {
+					return m.GetCounter().GetValue()
+				}
+			}
+		}
+	}
+	return 0
+}
+
 func Test_injectTracingAndCorrelationHeaders(t *testing.T) {
 	mtr := mocktracer.New()
 	opentracing.SetGlobalTracer(mtr)
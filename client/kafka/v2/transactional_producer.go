@@ -0,0 +1,703 @@
+package v2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Shopify/sarama"
+	patronerrors "github.com/beatlabs/patron/errors"
+	"github.com/beatlabs/patron/trace"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	deliveryTypeTxn  = "transactional"
+	componentTypeTxn = "kafka-transactional-producer"
+
+	txnCommitted = "committed"
+	txnAborted   = "aborted"
+)
+
+var (
+	txnTag = opentracing.Tag{Key: "type", Value: deliveryTypeTxn}
+
+	// ErrProducerFenced is returned once another producer with the same transactional ID has been initialized,
+	// after which the producer can only be closed.
+	ErrProducerFenced = errors.New("producer fenced by another producer with the same transactional id")
+
+	transactions *prometheus.CounterVec
+)
+
+func init() {
+	transactions = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "client",
+			Subsystem: "kafka_producer",
+			Name:      "transactions_total",
+			Help:      "Transactions ended by the transactional producers, classified by result (committed or aborted)",
+		}, []string{"result"},
+	)
+	prometheus.MustRegister(transactions)
+}
+
+type txnState int
+
+const (
+	txnReady txnState = iota
+	txnInProgress
+	// txnAbortable is the state of the transactions which failed, and can only be aborted.
+	txnAbortable
+	// txnFatal is the state of the producers which were fenced, and can only be closed.
+	txnFatal
+)
+
+type topicPartition struct {
+	topic     string
+	partition int32
+}
+
+// TransactionalProducer publishes messages to multiple partitions atomically, along with the offsets of the messages they were derived from,
+// i.e. the exactly-once semantics of consume-transform-produce pipelines. The messages of a transaction are visible to the consumers
+// reading committed messages once it is committed, and never if it is aborted. Its messages are deduplicated by the brokers,
+// since the producer is idempotent, and it fences the producers previously initialized with the same transactional ID, e.g. the zombie
+// instances of a restarted service.
+type TransactionalProducer struct {
+	baseProducer
+	id      string
+	timeout time.Duration
+
+	mu           sync.Mutex
+	state        txnState
+	err          error
+	producerID   int64
+	epoch        int16
+	coordinator  *sarama.Broker
+	partitioners map[string]sarama.Partitioner
+	sequences    map[topicPartition]int32
+	// partitions and offsets added to the transaction in progress
+	partitions map[topicPartition]struct{}
+	offsets    bool
+}
+
+// CreateTransactional creates a new transactional producer of the transactional ID, which should be stable across the restarts
+// of an instance, e.g. derived from the consumer group and the partitions it consumes. The transactions not committed within the timeout
+// are aborted by the brokers. The Sarama configuration should be of Kafka 0.11.0.0 or later.
+func (b *Builder) CreateTransactional(transactionalID string, timeout time.Duration) (*TransactionalProducer, error) {
+	ee := append([]error{}, b.errs...)
+	if transactionalID == "" {
+		ee = append(ee, errors.New("transactional id is empty"))
+	}
+	if timeout <= 0 {
+		ee = append(ee, errors.New("transaction timeout should be positive"))
+	}
+	if b.cfg != nil && !b.cfg.Version.IsAtLeast(sarama.V0_11_0_0) {
+		ee = append(ee, errors.New("transactions require Kafka version 0.11.0.0 or later"))
+	}
+	if len(ee) > 0 {
+		return nil, patronerrors.Aggregate(ee...)
+	}
+
+	// the messages of the transactions are acknowledged by all the in-sync replicas, as they are by the idempotent producers
+	b.cfg.Producer.RequiredAcks = sarama.WaitForAll
+
+	p := &TransactionalProducer{
+		id:           transactionalID,
+		timeout:      timeout,
+		partitioners: make(map[string]sarama.Partitioner),
+		partitions:   make(map[topicPartition]struct{}),
+	}
+
+	var err error
+	p.prodClient, err = sarama.NewClient(b.brokers, b.observedConfig(componentTypeTxn))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create producer client: %w", err)
+	}
+	if err := p.initProducerID(); err != nil {
+		_ = p.prodClient.Close()
+		return nil, fmt.Errorf("failed to initialize transactional producer: %w", err)
+	}
+	return p, nil
+}
+
+// BeginTxn begins a transaction, which should be committed or aborted before the next one begins.
+func (p *TransactionalProducer) BeginTxn() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	switch p.state {
+	case txnInProgress:
+		return errors.New("transaction already in progress")
+	case txnAbortable:
+		return fmt.Errorf("transaction should be aborted: %w", p.err)
+	case txnFatal:
+		return p.err
+	}
+	p.state = txnInProgress
+	return nil
+}
+
+// Publish publishes the messages in the transaction in progress, returning once they are written by the brokers.
+// If it fails, the transaction can only be aborted.
+func (p *TransactionalProducer) Publish(ctx context.Context, messages ...*sarama.ProducerMessage) error {
+	if len(messages) == 0 {
+		return errors.New("messages are empty or nil")
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.inProgress(); err != nil {
+		return err
+	}
+
+	spans := make([]opentracing.Span, 0, len(messages))
+	for _, msg := range messages {
+		sp, _ := trace.ChildSpan(ctx, trace.ComponentOpName(componentTypeTxn, msg.Topic), componentTypeTxn,
+			ext.SpanKindProducer, txnTag, opentracing.Tag{Key: "topic", Value: msg.Topic})
+		spans = append(spans, sp)
+		if err := injectTracingAndCorrelationHeaders(ctx, msg, sp); err != nil {
+			statusCountBatchAdd(deliveryTypeTxn, deliveryStatusSendError, messages)
+			for _, sp := range spans {
+				trace.SpanError(sp)
+			}
+			return fmt.Errorf("failed to inject tracing headers: %w", err)
+		}
+	}
+
+	if err := p.publish(messages); err != nil {
+		err = p.fail(err)
+		statusCountBatchAdd(deliveryTypeTxn, deliveryStatusSendError, messages)
+		for _, sp := range spans {
+			trace.SpanError(sp)
+		}
+		return fmt.Errorf("failed to publish messages: %w", err)
+	}
+
+	statusCountBatchAdd(deliveryTypeTxn, deliveryStatusSent, messages)
+	for _, sp := range spans {
+		trace.SpanSuccess(sp)
+	}
+	return nil
+}
+
+// SendOffsetsToTxn commits the offsets following the consumed messages for the consumer group in the transaction in progress,
+// so that they are committed only if the messages published in the transaction are, and vice versa.
+// If it fails, the transaction can only be aborted.
+func (p *TransactionalProducer) SendOffsetsToTxn(groupID string, messages ...*sarama.ConsumerMessage) error {
+	if groupID == "" {
+		return errors.New("group id is empty")
+	}
+	if len(messages) == 0 {
+		return errors.New("messages are empty or nil")
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.inProgress(); err != nil {
+		return err
+	}
+
+	if err := p.sendOffsets(groupID, messages); err != nil {
+		return fmt.Errorf("failed to send offsets: %w", p.fail(err))
+	}
+	return nil
+}
+
+// CommitTxn commits the transaction in progress. If it fails, the transaction can only be aborted.
+func (p *TransactionalProducer) CommitTxn() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.inProgress(); err != nil {
+		return err
+	}
+
+	if err := p.endTxn(true); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", p.fail(err))
+	}
+	transactions.WithLabelValues(txnCommitted).Inc()
+	return nil
+}
+
+// AbortTxn aborts the transaction in progress, including the failed ones.
+func (p *TransactionalProducer) AbortTxn() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.abortTxn()
+}
+
+func (p *TransactionalProducer) abortTxn() error {
+	switch p.state {
+	case txnReady:
+		return errors.New("no transaction in progress")
+	case txnFatal:
+		return p.err
+	}
+
+	failed := p.state == txnAbortable
+	if err := p.endTxn(false); err != nil {
+		return fmt.Errorf("failed to abort transaction: %w", p.fail(err))
+	}
+	transactions.WithLabelValues(txnAborted).Inc()
+	if failed {
+		// the failed messages may have been written or not, so the sequences of their partitions are unknown,
+		// and are reset by bumping the epoch of the producer
+		if err := p.initProducerID(); err != nil {
+			return fmt.Errorf("failed to reinitialize producer: %w", p.fail(err))
+		}
+	}
+	return nil
+}
+
+// Close aborts the transaction in progress, if any, and closes the producer.
+func (p *TransactionalProducer) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var ee []error
+	if p.state == txnInProgress || p.state == txnAbortable {
+		if err := p.abortTxn(); err != nil {
+			ee = append(ee, err)
+		}
+	}
+	if p.coordinator != nil {
+		_ = p.coordinator.Close()
+		p.coordinator = nil
+	}
+	if err := p.prodClient.Close(); err != nil {
+		ee = append(ee, fmt.Errorf("failed to close transactional producer client: %w", err))
+	}
+	p.state = txnFatal
+	p.err = errors.New("producer is closed")
+	return patronerrors.Aggregate(ee...)
+}
+
+func (p *TransactionalProducer) inProgress() error {
+	switch p.state {
+	case txnReady:
+		return errors.New("no transaction in progress")
+	case txnAbortable:
+		return fmt.Errorf("transaction should be aborted: %w", p.err)
+	case txnFatal:
+		return p.err
+	}
+	return nil
+}
+
+// fail records the error of the transaction in progress, which fences the producer if another one has the same transactional ID,
+// and returns the recorded error.
+func (p *TransactionalProducer) fail(err error) error {
+	if errors.Is(err, sarama.ErrInvalidProducerEpoch) || errors.Is(err, sarama.ErrTransactionalIDAuthorizationFailed) {
+		p.state = txnFatal
+		p.err = fmt.Errorf("%w: %v", ErrProducerFenced, err)
+		return p.err
+	}
+	p.state = txnAbortable
+	p.err = err
+	return p.err
+}
+
+func (p *TransactionalProducer) initProducerID() error {
+	return p.retryCoordinator(func(coordinator *sarama.Broker) (sarama.KError, error) {
+		rsp, err := coordinator.InitProducerID(&sarama.InitProducerIDRequest{TransactionalID: &p.id, TransactionTimeout: p.timeout})
+		if err != nil {
+			return sarama.ErrNoError, err
+		}
+		if rsp.Err == sarama.ErrNoError {
+			p.producerID = rsp.ProducerID
+			p.epoch = rsp.ProducerEpoch
+			p.sequences = make(map[topicPartition]int32)
+			p.resetTxn(txnReady)
+		}
+		return rsp.Err, nil
+	})
+}
+
+func (p *TransactionalProducer) endTxn(commit bool) error {
+	if len(p.partitions) > 0 || p.offsets {
+		err := p.retryCoordinator(func(coordinator *sarama.Broker) (sarama.KError, error) {
+			rsp, err := coordinator.EndTxn(&sarama.EndTxnRequest{
+				TransactionalID: p.id, ProducerID: p.producerID, ProducerEpoch: p.epoch, TransactionResult: commit,
+			})
+			if err != nil {
+				return sarama.ErrNoError, err
+			}
+			return rsp.Err, nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	p.resetTxn(txnReady)
+	return nil
+}
+
+func (p *TransactionalProducer) resetTxn(state txnState) {
+	p.state = state
+	p.err = nil
+	p.partitions = make(map[topicPartition]struct{})
+	p.offsets = false
+}
+
+func (p *TransactionalProducer) publish(messages []*sarama.ProducerMessage) error {
+	batches := make(map[topicPartition][]*sarama.ProducerMessage)
+	var added []topicPartition
+	for _, msg := range messages {
+		partition, err := p.partition(msg)
+		if err != nil {
+			return err
+		}
+		msg.Partition = partition
+		tp := topicPartition{topic: msg.Topic, partition: partition}
+		if _, ok := p.partitions[tp]; !ok && len(batches[tp]) == 0 {
+			added = append(added, tp)
+		}
+		batches[tp] = append(batches[tp], msg)
+	}
+
+	if err := p.addPartitions(added); err != nil {
+		return err
+	}
+	return p.produce(batches)
+}
+
+func (p *TransactionalProducer) partition(msg *sarama.ProducerMessage) (int32, error) {
+	partitioner, ok := p.partitioners[msg.Topic]
+	if !ok {
+		partitioner = p.prodClient.Config().Producer.Partitioner(msg.Topic)
+		p.partitioners[msg.Topic] = partitioner
+	}
+
+	var partitions []int32
+	var err error
+	if partitioner.RequiresConsistency() {
+		partitions, err = p.prodClient.Partitions(msg.Topic)
+	} else {
+		partitions, err = p.prodClient.WritablePartitions(msg.Topic)
+	}
+	if err != nil {
+		return 0, err
+	}
+	if len(partitions) == 0 {
+		return 0, sarama.ErrLeaderNotAvailable
+	}
+
+	i, err := partitioner.Partition(msg, int32(len(partitions)))
+	if err != nil {
+		return 0, err
+	}
+	if i < 0 || int(i) >= len(partitions) {
+		return 0, sarama.ErrInvalidPartition
+	}
+	return partitions[i], nil
+}
+
+func (p *TransactionalProducer) addPartitions(added []topicPartition) error {
+	if len(added) == 0 {
+		return nil
+	}
+	req := &sarama.AddPartitionsToTxnRequest{
+		TransactionalID: p.id, ProducerID: p.producerID, ProducerEpoch: p.epoch, TopicPartitions: make(map[string][]int32),
+	}
+	for _, tp := range added {
+		req.TopicPartitions[tp.topic] = append(req.TopicPartitions[tp.topic], tp.partition)
+	}
+
+	err := p.retryCoordinator(func(coordinator *sarama.Broker) (sarama.KError, error) {
+		rsp, err := coordinator.AddPartitionsToTxn(req)
+		if err != nil {
+			return sarama.ErrNoError, err
+		}
+		return firstPartitionError(rsp.Errors), nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, tp := range added {
+		p.partitions[tp] = struct{}{}
+	}
+	return nil
+}
+
+// produce writes the batches of the partitions to their leaders, retrying the partitions whose leaders moved or were unavailable.
+// The retries are not duplicated, since the brokers deduplicate the batches by their sequence.
+func (p *TransactionalProducer) produce(batches map[topicPartition][]*sarama.ProducerMessage) error {
+	cfg := p.prodClient.Config()
+	var lastErr error
+	for attempt := 0; len(batches) > 0; attempt++ {
+		if attempt > 0 {
+			if attempt > cfg.Producer.Retry.Max {
+				return lastErr
+			}
+			time.Sleep(cfg.Producer.Retry.Backoff)
+			topics := make([]string, 0, len(batches))
+			for tp := range batches {
+				topics = append(topics, tp.topic)
+			}
+			if err := p.prodClient.RefreshMetadata(topics...); err != nil {
+				lastErr = err
+				continue
+			}
+		}
+
+		failed := make(map[topicPartition][]*sarama.ProducerMessage)
+		leaders := make(map[*sarama.Broker][]topicPartition)
+		for tp := range batches {
+			leader, err := p.prodClient.Leader(tp.topic, tp.partition)
+			if err != nil {
+				lastErr = err
+				failed[tp] = batches[tp]
+				continue
+			}
+			leaders[leader] = append(leaders[leader], tp)
+		}
+
+		for leader, tps := range leaders {
+			req, err := p.produceRequest(tps, batches)
+			if err != nil {
+				return err
+			}
+			rsp, err := leader.Produce(req)
+			if err != nil {
+				lastErr = err
+				for _, tp := range tps {
+					failed[tp] = batches[tp]
+				}
+				continue
+			}
+			for _, tp := range tps {
+				kerr := sarama.ErrNoError
+				if block := rsp.GetBlock(tp.topic, tp.partition); block != nil {
+					kerr = block.Err
+				} else {
+					lastErr = sarama.ErrIncompleteResponse
+					failed[tp] = batches[tp]
+					continue
+				}
+				switch kerr {
+				case sarama.ErrNoError, sarama.ErrDuplicateSequenceNumber:
+					p.sequences[tp] += int32(len(batches[tp]))
+				case sarama.ErrNotLeaderForPartition, sarama.ErrLeaderNotAvailable, sarama.ErrUnknownTopicOrPartition,
+					sarama.ErrRequestTimedOut, sarama.ErrNotEnoughReplicas, sarama.ErrNotEnoughReplicasAfterAppend:
+					lastErr = kerr
+					failed[tp] = batches[tp]
+				default:
+					return kerr
+				}
+			}
+		}
+		batches = failed
+	}
+	return nil
+}
+
+func (p *TransactionalProducer) produceRequest(tps []topicPartition, batches map[topicPartition][]*sarama.ProducerMessage) (*sarama.ProduceRequest, error) {
+	cfg := p.prodClient.Config()
+	req := &sarama.ProduceRequest{
+		TransactionalID: &p.id,
+		RequiredAcks:    sarama.WaitForAll,
+		Timeout:         int32(cfg.Producer.Timeout / time.Millisecond),
+		Version:         3,
+	}
+	for _, tp := range tps {
+		batch, err := p.recordBatch(tp, batches[tp])
+		if err != nil {
+			return nil, err
+		}
+		req.AddBatch(tp.topic, tp.partition, batch)
+	}
+	return req, nil
+}
+
+func (p *TransactionalProducer) recordBatch(tp topicPartition, messages []*sarama.ProducerMessage) (*sarama.RecordBatch, error) {
+	cfg := p.prodClient.Config()
+	now := time.Now().Truncate(time.Millisecond)
+	batch := &sarama.RecordBatch{
+		Version:          2,
+		Codec:            cfg.Producer.Compression,
+		CompressionLevel: cfg.Producer.CompressionLevel,
+		ProducerID:       p.producerID,
+		ProducerEpoch:    p.epoch,
+		FirstSequence:    p.sequences[tp],
+		IsTransactional:  true,
+		FirstTimestamp:   now,
+		MaxTimestamp:     now,
+		LastOffsetDelta:  int32(len(messages) - 1),
+		Records:          make([]*sarama.Record, 0, len(messages)),
+	}
+	for i, msg := range messages {
+		rec := &sarama.Record{OffsetDelta: int64(i)}
+		var err error
+		if msg.Key != nil {
+			if rec.Key, err = msg.Key.Encode(); err != nil {
+				return nil, fmt.Errorf("failed to encode key: %w", err)
+			}
+		}
+		if msg.Value != nil {
+			if rec.Value, err = msg.Value.Encode(); err != nil {
+				return nil, fmt.Errorf("failed to encode value: %w", err)
+			}
+		}
+		for j := range msg.Headers {
+			rec.Headers = append(rec.Headers, &msg.Headers[j])
+		}
+		batch.Records = append(batch.Records, rec)
+	}
+	return batch, nil
+}
+
+func (p *TransactionalProducer) sendOffsets(groupID string, messages []*sarama.ConsumerMessage) error {
+	if !p.offsets {
+		err := p.retryCoordinator(func(coordinator *sarama.Broker) (sarama.KError, error) {
+			rsp, err := coordinator.AddOffsetsToTxn(&sarama.AddOffsetsToTxnRequest{
+				TransactionalID: p.id, ProducerID: p.producerID, ProducerEpoch: p.epoch, GroupID: groupID,
+			})
+			if err != nil {
+				return sarama.ErrNoError, err
+			}
+			return rsp.Err, nil
+		})
+		if err != nil {
+			return err
+		}
+		p.offsets = true
+	}
+
+	// the committed offset of a partition is the one following its last consumed message
+	next := make(map[topicPartition]int64)
+	for _, msg := range messages {
+		tp := topicPartition{topic: msg.Topic, partition: msg.Partition}
+		if msg.Offset+1 > next[tp] {
+			next[tp] = msg.Offset + 1
+		}
+	}
+	req := &sarama.TxnOffsetCommitRequest{
+		TransactionalID: p.id, GroupID: groupID, ProducerID: p.producerID, ProducerEpoch: p.epoch,
+		Topics: make(map[string][]*sarama.PartitionOffsetMetadata),
+	}
+	for tp, offset := range next {
+		req.Topics[tp.topic] = append(req.Topics[tp.topic], &sarama.PartitionOffsetMetadata{Partition: tp.partition, Offset: offset})
+	}
+
+	cfg := p.prodClient.Config()
+	for attempt := 0; ; attempt++ {
+		kerr, err := p.commitOffsets(groupID, req)
+		if err == nil && kerr == sarama.ErrNoError {
+			return nil
+		}
+		if err == nil && !retriableCoordinatorError(kerr) {
+			return kerr
+		}
+		if attempt >= cfg.Producer.Retry.Max {
+			if err != nil {
+				return err
+			}
+			return kerr
+		}
+		_ = p.prodClient.RefreshCoordinator(groupID)
+		time.Sleep(cfg.Producer.Retry.Backoff)
+	}
+}
+
+func (p *TransactionalProducer) commitOffsets(groupID string, req *sarama.TxnOffsetCommitRequest) (sarama.KError, error) {
+	coordinator, err := p.prodClient.Coordinator(groupID)
+	if err != nil {
+		return sarama.ErrNoError, err
+	}
+	rsp, err := coordinator.TxnOffsetCommit(req)
+	if err != nil {
+		return sarama.ErrNoError, err
+	}
+	return firstPartitionError(rsp.Topics), nil
+}
+
+// retryCoordinator sends a request to the transaction coordinator, retrying the retriable errors up to the retries of the configuration,
+// and finding the coordinator again if it is unavailable or moved.
+func (p *TransactionalProducer) retryCoordinator(send func(coordinator *sarama.Broker) (sarama.KError, error)) error {
+	cfg := p.prodClient.Config()
+	for attempt := 0; ; attempt++ {
+		kerr := sarama.ErrNoError
+		coordinator, err := p.transactionCoordinator()
+		if err == nil {
+			kerr, err = send(coordinator)
+		}
+		if err == nil && kerr == sarama.ErrNoError {
+			return nil
+		}
+		if err == nil && !retriableCoordinatorError(kerr) {
+			return kerr
+		}
+		if attempt >= cfg.Producer.Retry.Max {
+			if err != nil {
+				return err
+			}
+			return kerr
+		}
+		if err != nil || kerr == sarama.ErrNotCoordinatorForConsumer || kerr == sarama.ErrConsumerCoordinatorNotAvailable {
+			if p.coordinator != nil {
+				_ = p.coordinator.Close()
+				p.coordinator = nil
+			}
+		}
+		time.Sleep(cfg.Producer.Retry.Backoff)
+	}
+}
+
+func (p *TransactionalProducer) transactionCoordinator() (*sarama.Broker, error) {
+	if p.coordinator != nil {
+		return p.coordinator, nil
+	}
+
+	cfg := p.prodClient.Config()
+	req := &sarama.FindCoordinatorRequest{Version: 1, CoordinatorKey: p.id, CoordinatorType: sarama.CoordinatorTransaction}
+	lastErr := errors.New("no brokers available")
+	for _, broker := range p.prodClient.Brokers() {
+		if connected, _ := broker.Connected(); !connected {
+			if err := broker.Open(cfg); err != nil && !errors.Is(err, sarama.ErrAlreadyConnected) {
+				lastErr = err
+				continue
+			}
+		}
+		rsp, err := broker.FindCoordinator(req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if rsp.Err != sarama.ErrNoError {
+			lastErr = rsp.Err
+			continue
+		}
+
+		coordinator := sarama.NewBroker(rsp.Coordinator.Addr())
+		if err := coordinator.Open(cfg); err != nil {
+			lastErr = err
+			continue
+		}
+		p.coordinator = coordinator
+		return coordinator, nil
+	}
+	return nil, fmt.Errorf("failed to find transaction coordinator: %w", lastErr)
+}
+
+func retriableCoordinatorError(kerr sarama.KError) bool {
+	switch kerr {
+	case sarama.ErrConcurrentTransactions, sarama.ErrOffsetsLoadInProgress, sarama.ErrConsumerCoordinatorNotAvailable,
+		sarama.ErrNotCoordinatorForConsumer, sarama.ErrRequestTimedOut:
+		return true
+	}
+	return false
+}
+
+func firstPartitionError(errs map[string][]*sarama.PartitionError) sarama.KError {
+	for _, pp := range errs {
+		for _, p := range pp {
+			if p.Err != sarama.ErrNoError {
+				return p.Err
+			}
+		}
+	}
+	return sarama.ErrNoError
+}
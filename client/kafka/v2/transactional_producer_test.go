@@ -0,0 +1,233 @@
+package v2
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	txnTopic = "orders"
+	txnID    = "orders-transformer-0"
+	txnGroup = "orders-transformer"
+)
+
+func TestBuilder_CreateTransactional(t *testing.T) {
+	t.Parallel()
+	oldCfg := sarama.NewConfig()
+	oldCfg.Version = sarama.V0_10_2_0
+
+	tests := map[string]struct {
+		brokers     []string
+		cfg         *sarama.Config
+		id          string
+		timeout     time.Duration
+		expectedErr string
+	}{
+		"missing brokers": {cfg: txnConfig(), id: txnID, timeout: time.Minute, expectedErr: "brokers are empty or have an empty value\n"},
+		"missing config":  {brokers: []string{"123"}, id: txnID, timeout: time.Minute, expectedErr: "no Sarama configuration specified\n"},
+		"missing id":      {brokers: []string{"123"}, cfg: txnConfig(), timeout: time.Minute, expectedErr: "transactional id is empty\n"},
+		"zero timeout":    {brokers: []string{"123"}, cfg: txnConfig(), id: txnID, expectedErr: "transaction timeout should be positive\n"},
+		"old version": {
+			brokers: []string{"123"}, cfg: oldCfg, id: txnID, timeout: time.Minute,
+			expectedErr: "transactions require Kafka version 0.11.0.0 or later\n",
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			got, err := New(tt.brokers, tt.cfg).CreateTransactional(tt.id, tt.timeout)
+
+			require.EqualError(t, err, tt.expectedErr)
+			require.Nil(t, got)
+		})
+	}
+}
+
+func TestTransactionalProducer(t *testing.T) {
+	broker := newTxnBroker(t, sarama.NewMockProduceResponse(t).SetVersion(3))
+	p, err := New([]string{broker.Addr()}, txnConfig()).CreateTransactional(txnID, time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1000), p.producerID)
+	assert.Equal(t, int16(1), p.epoch)
+	committed := testutil.ToFloat64(transactions.WithLabelValues(txnCommitted))
+	aborted := testutil.ToFloat64(transactions.WithLabelValues(txnAborted))
+
+	assert.EqualError(t, p.Publish(context.Background(), txnMessage(0)), "no transaction in progress")
+	assert.EqualError(t, p.CommitTxn(), "no transaction in progress")
+	assert.EqualError(t, p.AbortTxn(), "no transaction in progress")
+
+	// consume-transform-produce
+	require.NoError(t, p.BeginTxn())
+	assert.EqualError(t, p.BeginTxn(), "transaction already in progress")
+	assert.EqualError(t, p.Publish(context.Background()), "messages are empty or nil")
+	require.NoError(t, p.Publish(context.Background(), txnMessage(0), txnMessage(1), txnMessage(0)))
+	require.NoError(t, p.SendOffsetsToTxn(txnGroup,
+		&sarama.ConsumerMessage{Topic: "input", Partition: 0, Offset: 10},
+		&sarama.ConsumerMessage{Topic: "input", Partition: 0, Offset: 11},
+		&sarama.ConsumerMessage{Topic: "input", Partition: 3, Offset: 7},
+	))
+	require.NoError(t, p.CommitTxn())
+	assert.Equal(t, int32(2), p.sequences[topicPartition{topic: txnTopic, partition: 0}])
+	assert.Equal(t, int32(1), p.sequences[topicPartition{topic: txnTopic, partition: 1}])
+
+	addPartitions := txnRequests(broker, func(r interface{}) bool { _, ok := r.(*sarama.AddPartitionsToTxnRequest); return ok })
+	require.Len(t, addPartitions, 1)
+	assert.ElementsMatch(t, []int32{0, 1}, addPartitions[0].(*sarama.AddPartitionsToTxnRequest).TopicPartitions[txnTopic])
+
+	commits := txnRequests(broker, func(r interface{}) bool { _, ok := r.(*sarama.TxnOffsetCommitRequest); return ok })
+	require.Len(t, commits, 1)
+	offsets := commits[0].(*sarama.TxnOffsetCommitRequest).Topics["input"]
+	require.Len(t, offsets, 2)
+	for _, o := range offsets {
+		if o.Partition == 0 {
+			assert.Equal(t, int64(12), o.Offset)
+		} else {
+			assert.Equal(t, int64(8), o.Offset)
+		}
+	}
+
+	// the sequences continue in the next transaction, which is aborted
+	require.NoError(t, p.BeginTxn())
+	require.NoError(t, p.Publish(context.Background(), txnMessage(0)))
+	require.NoError(t, p.AbortTxn())
+	assert.Equal(t, int32(3), p.sequences[topicPartition{topic: txnTopic, partition: 0}])
+
+	// empty transactions are not sent to the coordinator
+	require.NoError(t, p.BeginTxn())
+	require.NoError(t, p.CommitTxn())
+
+	ends := txnRequests(broker, func(r interface{}) bool { _, ok := r.(*sarama.EndTxnRequest); return ok })
+	require.Len(t, ends, 2)
+	assert.True(t, ends[0].(*sarama.EndTxnRequest).TransactionResult)
+	assert.False(t, ends[1].(*sarama.EndTxnRequest).TransactionResult)
+	assert.Equal(t, committed+2, testutil.ToFloat64(transactions.WithLabelValues(txnCommitted)))
+	assert.Equal(t, aborted+1, testutil.ToFloat64(transactions.WithLabelValues(txnAborted)))
+
+	require.NoError(t, p.BeginTxn())
+	require.NoError(t, p.Publish(context.Background(), txnMessage(1)))
+	require.NoError(t, p.Close())
+	assert.EqualError(t, p.BeginTxn(), "producer is closed")
+	ends = txnRequests(broker, func(r interface{}) bool { _, ok := r.(*sarama.EndTxnRequest); return ok })
+	require.Len(t, ends, 3)
+	assert.False(t, ends[2].(*sarama.EndTxnRequest).TransactionResult)
+}
+
+func TestTransactionalProducer_Abortable(t *testing.T) {
+	t.Parallel()
+	broker := newTxnBroker(t, sarama.NewMockProduceResponse(t).SetVersion(3).SetError(txnTopic, 1, sarama.ErrMessageSizeTooLarge))
+	p, err := New([]string{broker.Addr()}, txnConfig()).CreateTransactional(txnID, time.Minute)
+	require.NoError(t, err)
+
+	require.NoError(t, p.BeginTxn())
+	require.NoError(t, p.Publish(context.Background(), txnMessage(0)))
+	err = p.Publish(context.Background(), txnMessage(1))
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, sarama.ErrMessageSizeTooLarge))
+
+	assert.Error(t, p.Publish(context.Background(), txnMessage(0)))
+	assert.Error(t, p.BeginTxn())
+	err = p.CommitTxn()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "transaction should be aborted")
+
+	// aborting resets the sequences of the producer
+	require.NoError(t, p.AbortTxn())
+	assert.Empty(t, p.sequences)
+	inits := txnRequests(broker, func(r interface{}) bool { _, ok := r.(*sarama.InitProducerIDRequest); return ok })
+	assert.Len(t, inits, 2)
+
+	require.NoError(t, p.BeginTxn())
+	require.NoError(t, p.Publish(context.Background(), txnMessage(0)))
+	require.NoError(t, p.CommitTxn())
+	require.NoError(t, p.Close())
+}
+
+func TestTransactionalProducer_Fenced(t *testing.T) {
+	t.Parallel()
+	broker := newTxnBroker(t, sarama.NewMockProduceResponse(t).SetVersion(3).SetError(txnTopic, 0, sarama.ErrInvalidProducerEpoch))
+	p, err := New([]string{broker.Addr()}, txnConfig()).CreateTransactional(txnID, time.Minute)
+	require.NoError(t, err)
+
+	require.NoError(t, p.BeginTxn())
+	err = p.Publish(context.Background(), txnMessage(0))
+	assert.True(t, errors.Is(err, ErrProducerFenced))
+	assert.True(t, errors.Is(p.BeginTxn(), ErrProducerFenced))
+	assert.True(t, errors.Is(p.AbortTxn(), ErrProducerFenced))
+	assert.NoError(t, p.Close())
+}
+
+func TestTransactionalProducer_SendOffsetsToTxn(t *testing.T) {
+	t.Parallel()
+	broker := newTxnBroker(t, sarama.NewMockProduceResponse(t).SetVersion(3))
+	p, err := New([]string{broker.Addr()}, txnConfig()).CreateTransactional(txnID, time.Minute)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, p.Close()) }()
+
+	msg := &sarama.ConsumerMessage{Topic: "input", Partition: 0, Offset: 10}
+	assert.EqualError(t, p.SendOffsetsToTxn("", msg), "group id is empty")
+	assert.EqualError(t, p.SendOffsetsToTxn(txnGroup), "messages are empty or nil")
+	assert.EqualError(t, p.SendOffsetsToTxn(txnGroup, msg), "no transaction in progress")
+
+	// offsets-only transactions are ended by the coordinator
+	require.NoError(t, p.BeginTxn())
+	require.NoError(t, p.SendOffsetsToTxn(txnGroup, msg))
+	require.NoError(t, p.CommitTxn())
+	ends := txnRequests(broker, func(r interface{}) bool { _, ok := r.(*sarama.EndTxnRequest); return ok })
+	assert.Len(t, ends, 1)
+}
+
+func txnConfig() *sarama.Config {
+	cfg := sarama.NewConfig()
+	cfg.Version = sarama.V0_11_0_0
+	cfg.Producer.Partitioner = sarama.NewManualPartitioner
+	cfg.Producer.Retry.Max = 1
+	cfg.Producer.Retry.Backoff = time.Millisecond
+	cfg.Metadata.Retry.Max = 0
+	return cfg
+}
+
+func txnMessage(partition int32) *sarama.ProducerMessage {
+	return &sarama.ProducerMessage{Topic: txnTopic, Partition: partition, Key: sarama.StringEncoder("key"), Value: sarama.StringEncoder("value")}
+}
+
+// newTxnBroker returns a broker leading the partitions of the topic and coordinating both the transactions and the consumer group.
+func newTxnBroker(t *testing.T, produce sarama.MockResponse) *sarama.MockBroker {
+	broker := sarama.NewMockBroker(t, 1)
+	t.Cleanup(broker.Close)
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"MetadataRequest": sarama.NewMockMetadataResponse(t).
+			SetBroker(broker.Addr(), broker.BrokerID()).
+			SetLeader(txnTopic, 0, broker.BrokerID()).
+			SetLeader(txnTopic, 1, broker.BrokerID()),
+		// the transaction coordinator is found first, when the producer is created, and the group coordinator afterwards
+		"FindCoordinatorRequest": sarama.NewMockSequence(
+			&sarama.FindCoordinatorResponse{Version: 1, Coordinator: sarama.NewBroker(broker.Addr())},
+			sarama.NewMockFindCoordinatorResponse(t).SetCoordinator(sarama.CoordinatorGroup, txnGroup, broker),
+		),
+		"InitProducerIDRequest":     sarama.NewMockWrapper(&sarama.InitProducerIDResponse{ProducerID: 1000, ProducerEpoch: 1}),
+		"AddPartitionsToTxnRequest": sarama.NewMockWrapper(&sarama.AddPartitionsToTxnResponse{}),
+		"AddOffsetsToTxnRequest":    sarama.NewMockWrapper(&sarama.AddOffsetsToTxnResponse{}),
+		"TxnOffsetCommitRequest":    sarama.NewMockWrapper(&sarama.TxnOffsetCommitResponse{}),
+		"EndTxnRequest":             sarama.NewMockWrapper(&sarama.EndTxnResponse{}),
+		"ProduceRequest":            produce,
+	})
+	return broker
+}
+
+func txnRequests(broker *sarama.MockBroker, match func(interface{}) bool) []interface{} {
+	var requests []interface{}
+	for _, rr := range broker.History() {
+		if match(rr.Request) {
+			requests = append(requests, rr.Request)
+		}
+	}
+	return requests
+}
@@ -0,0 +1,33 @@
+package kinesis
+
+import (
+	"errors"
+	"time"
+)
+
+// OptionFunc definition for configuring the producer in a functional way.
+type OptionFunc func(*Producer) error
+
+// Aggregation option for enabling or disabling the aggregation of records, which is enabled by default.
+// Records published without aggregation do not carry tracing and correlation headers.
+func Aggregation(enabled bool) OptionFunc {
+	return func(p *Producer) error {
+		p.aggregation = enabled
+		return nil
+	}
+}
+
+// Retries option for setting the retries of the records which failed to be published, and the backoff between them.
+func Retries(retries int, backoff time.Duration) OptionFunc {
+	return func(p *Producer) error {
+		if retries < 0 {
+			return errors.New("retries should be a positive number or zero")
+		}
+		if backoff < 0 {
+			return errors.New("backoff should be a positive number or zero")
+		}
+		p.retries = retries
+		p.backoff = backoff
+		return nil
+	}
+}
@@ -0,0 +1,351 @@
+// Package kinesis provides a producer for publishing records to AWS Kinesis data streams, which aggregates the records
+// in the format of the Kinesis Producer Library (KPL). Implementations in this package also include distributed tracing capabilities by default.
+package kinesis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/kinesis"
+	"github.com/aws/aws-sdk-go/service/kinesis/kinesisiface"
+	"github.com/beatlabs/patron/internal/kpl"
+	"github.com/beatlabs/patron/log"
+	"github.com/beatlabs/patron/propagation"
+	"github.com/beatlabs/patron/trace"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	producerComponent = "kinesis-producer"
+
+	// maxRequestRecords is the maximum number of records of a PutRecords request.
+	maxRequestRecords = 500
+	// maxRequestSize is the maximum size of a PutRecords request, i.e. the sum of the sizes of the data and partition keys of its records.
+	maxRequestSize = 5 * 1024 * 1024
+	// maxRecordSize is the maximum size of a record, i.e. its data and partition key.
+	maxRecordSize = 1024 * 1024
+	// maxPartitionKeyLength is the maximum length of a partition key, in Unicode characters.
+	maxPartitionKeyLength = 256
+	// aggregationOverhead is the size reserved in aggregated records for the magic number, digest and partition key table.
+	aggregationOverhead = 64 + maxPartitionKeyLength*4
+	// recordOverhead is the size reserved for the protobuf encoding of a user record, besides its data and tags.
+	recordOverhead = 32
+
+	defaultRetries = 3
+	defaultBackoff = 100 * time.Millisecond
+)
+
+var (
+	publishDurationMetrics *prometheus.HistogramVec
+	recordsMetrics         *prometheus.CounterVec
+)
+
+func init() {
+	publishDurationMetrics = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "client",
+			Subsystem: "kinesis",
+			Name:      "publish_duration_seconds",
+			Help:      "AWS Kinesis PutRecords requests completed by the client.",
+		},
+		[]string{"stream", "success"},
+	)
+	recordsMetrics = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "client",
+			Subsystem: "kinesis",
+			Name:      "records_total",
+			Help:      "AWS Kinesis user records classified by status (published, retried or failed).",
+		},
+		[]string{"stream", "status"},
+	)
+	prometheus.MustRegister(publishDurationMetrics, recordsMetrics)
+}
+
+// Record to be published to a stream.
+type Record struct {
+	// PartitionKey determines the shard of the stream the record is published to.
+	PartitionKey string
+	Data         []byte
+}
+
+// Producer is a wrapper with added distributed tracing capabilities.
+type Producer struct {
+	api         kinesisiface.KinesisAPI
+	aggregation bool
+	retries     int
+	backoff     time.Duration
+}
+
+// New creates a new Kinesis producer.
+func New(api kinesisiface.KinesisAPI, oo ...OptionFunc) (Producer, error) {
+	if api == nil {
+		return Producer{}, errors.New("missing api")
+	}
+	p := Producer{api: api, aggregation: true, retries: defaultRetries, backoff: defaultBackoff}
+	for _, option := range oo {
+		if err := option(&p); err != nil {
+			return Producer{}, err
+		}
+	}
+	return p, nil
+}
+
+// Publish publishes the records to the stream with the least PutRecords requests of up to 500 records and 5 MiB.
+// The records which fail with throughput or internal errors are retried, according to the Retries option, so records
+// of the same partition key may be published out of order when some of them fail.
+//
+// With aggregation enabled, the records of each partition key are aggregated into Kinesis records of up to 1 MiB,
+// which carry the tracing and correlation headers as tags of their user records.
+// Aggregated records are de-aggregated by the Kinesis consumer component and the Kinesis Client Library.
+//
+// The records which failed to be published are returned along with an error.
+func (p Producer) Publish(ctx context.Context, stream string, records ...Record) ([]Record, error) {
+	if stream == "" {
+		return nil, errors.New("stream is empty")
+	}
+	if len(records) == 0 {
+		return nil, errors.New("records are empty")
+	}
+	for i, r := range records {
+		if r.PartitionKey == "" {
+			return nil, fmt.Errorf("record %d has no partition key", i)
+		}
+		if len([]rune(r.PartitionKey)) > maxPartitionKeyLength {
+			return nil, fmt.Errorf("record %d has a partition key longer than %d characters", i, maxPartitionKeyLength)
+		}
+	}
+
+	span, _ := trace.ChildSpan(ctx, trace.ComponentOpName(producerComponent, stream), producerComponent, ext.SpanKindProducer)
+
+	pub := &publication{producer: p, stream: stream, records: records}
+	var entries []*entry
+	if p.aggregation {
+		tags, err := headers(ctx, span)
+		if err != nil {
+			log.FromContext(ctx).Errorf("failed to inject trace headers: %v", err)
+		}
+		entries = pub.aggregate(tags)
+	} else {
+		entries = pub.entries()
+	}
+	for _, chunk := range pub.chunks(entries) {
+		pub.send(ctx, chunk)
+	}
+
+	failed := make([]Record, 0, len(pub.failed))
+	for _, idx := range pub.failed {
+		failed = append(failed, records[idx])
+	}
+	recordsMetrics.WithLabelValues(stream, "published").Add(float64(len(records) - len(failed)))
+	recordsMetrics.WithLabelValues(stream, "failed").Add(float64(len(failed)))
+
+	var err error
+	if len(failed) > 0 {
+		err = fmt.Errorf("failed to publish %d of %d records: %w", len(failed), len(records), pub.lastErr)
+	}
+	trace.SpanComplete(span, err)
+	return failed, err
+}
+
+// entry of a PutRecords request, along with the indices of the records it contains.
+type entry struct {
+	input   *kinesis.PutRecordsRequestEntry
+	records []int
+}
+
+func (e *entry) size() int {
+	return len(e.input.Data) + len(*e.input.PartitionKey)
+}
+
+// publication keeps the state of publishing the records of a Publish call.
+type publication struct {
+	producer Producer
+	stream   string
+	records  []Record
+	failed   []int
+	lastErr  error
+}
+
+// entries maps each record to an entry as is.
+func (p *publication) entries() []*entry {
+	entries := make([]*entry, 0, len(p.records))
+	for i, r := range p.records {
+		entries = append(entries, &entry{
+			input:   &kinesis.PutRecordsRequestEntry{PartitionKey: aws.String(r.PartitionKey), Data: r.Data},
+			records: []int{i},
+		})
+	}
+	return entries
+}
+
+// aggregate packs the records of each partition key, in order, into aggregated records which do not exceed the maximum record size.
+func (p *publication) aggregate(tags map[string]string) []*entry {
+	tagsSize := 0
+	for k, v := range tags {
+		tagsSize += len(k) + len(v) + 8
+	}
+
+	var keys []string
+	groups := make(map[string][]int)
+	for i, r := range p.records {
+		if _, ok := groups[r.PartitionKey]; !ok {
+			keys = append(keys, r.PartitionKey)
+		}
+		groups[r.PartitionKey] = append(groups[r.PartitionKey], i)
+	}
+
+	var entries []*entry
+	for _, key := range keys {
+		var pending []int
+		size := aggregationOverhead
+		flush := func() {
+			if len(pending) == 0 {
+				return
+			}
+			rr := make([]kpl.Record, 0, len(pending))
+			for _, idx := range pending {
+				rr = append(rr, kpl.Record{PartitionKey: key, Data: p.records[idx].Data, Tags: tags})
+			}
+			entries = append(entries, &entry{
+				input:   &kinesis.PutRecordsRequestEntry{PartitionKey: aws.String(key), Data: kpl.Marshal(rr)},
+				records: pending,
+			})
+			pending, size = nil, aggregationOverhead
+		}
+		for _, idx := range groups[key] {
+			recordSize := len(p.records[idx].Data) + tagsSize + recordOverhead
+			if aggregationOverhead+recordSize > maxRecordSize {
+				p.fail([]int{idx}, fmt.Errorf("record size of %d bytes exceeds the limit of %d bytes", len(p.records[idx].Data), maxRecordSize))
+				continue
+			}
+			if size+recordSize > maxRecordSize {
+				flush()
+			}
+			pending = append(pending, idx)
+			size += recordSize
+		}
+		flush()
+	}
+	return entries
+}
+
+// chunks splits the entries into chunks, which fit in a single request. The entries exceeding the size of a record fail.
+func (p *publication) chunks(entries []*entry) [][]*entry {
+	var chunks [][]*entry
+	var chunk []*entry
+	chunkSize := 0
+	for _, e := range entries {
+		size := e.size()
+		if size > maxRecordSize {
+			p.fail(e.records, fmt.Errorf("record size of %d bytes exceeds the limit of %d bytes", size, maxRecordSize))
+			continue
+		}
+		if len(chunk) == maxRequestRecords || chunkSize+size > maxRequestSize {
+			chunks = append(chunks, chunk)
+			chunk, chunkSize = nil, 0
+		}
+		chunk = append(chunk, e)
+		chunkSize += size
+	}
+	if len(chunk) > 0 {
+		chunks = append(chunks, chunk)
+	}
+	return chunks
+}
+
+// send publishes the entries of the chunk, retrying the entries which failed.
+func (p *publication) send(ctx context.Context, chunk []*entry) {
+	pending := chunk
+	for attempt := 0; len(pending) > 0; attempt++ {
+		if attempt > 0 {
+			if err := p.wait(ctx); err != nil {
+				p.failEntries(pending, err)
+				return
+			}
+			recordsMetrics.WithLabelValues(p.stream, "retried").Add(float64(countRecords(pending)))
+		}
+
+		input := &kinesis.PutRecordsInput{StreamName: aws.String(p.stream), Records: make([]*kinesis.PutRecordsRequestEntry, 0, len(pending))}
+		for _, e := range pending {
+			input.Records = append(input.Records, e.input)
+		}
+
+		start := time.Now()
+		out, err := p.producer.api.PutRecordsWithContext(ctx, input)
+		durationHistogram := trace.Histogram{
+			Observer: publishDurationMetrics.WithLabelValues(p.stream, strconv.FormatBool(err == nil)),
+		}
+		durationHistogram.Observe(ctx, time.Since(start).Seconds())
+
+		if err != nil {
+			if attempt == p.producer.retries || ctx.Err() != nil {
+				p.failEntries(pending, fmt.Errorf("failed to put records: %w", err))
+				return
+			}
+			continue
+		}
+		if len(out.Records) != len(pending) {
+			p.failEntries(pending, fmt.Errorf("expected %d results but got %d", len(pending), len(out.Records)))
+			return
+		}
+
+		var next []*entry
+		for i, res := range out.Records {
+			if res.ErrorCode == nil {
+				continue
+			}
+			if attempt == p.producer.retries {
+				p.failEntries(pending[i:i+1], fmt.Errorf("%s: %s", aws.StringValue(res.ErrorCode), aws.StringValue(res.ErrorMessage)))
+				continue
+			}
+			next = append(next, pending[i])
+		}
+		pending = next
+	}
+}
+
+func (p *publication) failEntries(entries []*entry, err error) {
+	for _, e := range entries {
+		p.fail(e.records, err)
+	}
+}
+
+func (p *publication) fail(records []int, err error) {
+	p.failed = append(p.failed, records...)
+	p.lastErr = err
+}
+
+func (p *publication) wait(ctx context.Context) error {
+	timer := time.NewTimer(p.producer.backoff)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func countRecords(entries []*entry) int {
+	count := 0
+	for _, e := range entries {
+		count += len(e.records)
+	}
+	return count
+}
+
+// headers returns the opentracing headers and the correlation.HeaderID to be set as tags of aggregated user records.
+func headers(ctx context.Context, span opentracing.Span) (map[string]string, error) {
+	carrier := propagation.MapCarrier{}
+	if err := propagation.Inject(ctx, span, carrier); err != nil {
+		return carrier, fmt.Errorf("failed to inject tracing headers: %w", err)
+	}
+	return carrier, nil
+}
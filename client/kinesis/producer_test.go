@@ -0,0 +1,215 @@
+package kinesis
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/kinesis"
+	"github.com/aws/aws-sdk-go/service/kinesis/kinesisiface"
+	"github.com/beatlabs/patron/correlation"
+	"github.com/beatlabs/patron/internal/kpl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRecords(n int, key string) []Record {
+	rr := make([]Record, 0, n)
+	for i := 0; i < n; i++ {
+		rr = append(rr, Record{PartitionKey: key, Data: []byte("data " + strconv.Itoa(i))})
+	}
+	return rr
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		api         kinesisiface.KinesisAPI
+		oo          []OptionFunc
+		expectedErr string
+	}{
+		"success":        {api: &stubKinesisAPI{}, oo: []OptionFunc{Aggregation(false), Retries(1, 0)}},
+		"missing api":    {expectedErr: "missing api"},
+		"invalid option": {api: &stubKinesisAPI{}, oo: []OptionFunc{Retries(-1, 0)}, expectedErr: "retries should be a positive number or zero"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			p, err := New(tt.api, tt.oo...)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.Nil(t, p.api)
+			} else {
+				assert.NoError(t, err)
+				assert.False(t, p.aggregation)
+				assert.Equal(t, 1, p.retries)
+			}
+		})
+	}
+}
+
+func TestProducer_Publish_Validation(t *testing.T) {
+	t.Parallel()
+	p, err := New(&stubKinesisAPI{})
+	require.NoError(t, err)
+	longKey := make([]rune, maxPartitionKeyLength+1)
+	for i := range longKey {
+		longKey[i] = 'κ'
+	}
+
+	tests := map[string]struct {
+		stream      string
+		records     []Record
+		expectedErr string
+	}{
+		"missing stream":        {records: newRecords(1, "key"), expectedErr: "stream is empty"},
+		"missing records":       {stream: "stream", expectedErr: "records are empty"},
+		"missing partition key": {stream: "stream", records: newRecords(1, ""), expectedErr: "record 0 has no partition key"},
+		"long partition key": {
+			stream: "stream", records: newRecords(1, string(longKey)),
+			expectedErr: "record 0 has a partition key longer than 256 characters",
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			failed, err := p.Publish(context.Background(), tt.stream, tt.records...)
+			assert.EqualError(t, err, tt.expectedErr)
+			assert.Empty(t, failed)
+		})
+	}
+}
+
+func TestProducer_Publish_Aggregation(t *testing.T) {
+	t.Parallel()
+	api := &stubKinesisAPI{}
+	p, err := New(api)
+	require.NoError(t, err)
+	records := append(newRecords(3, "key-1"), newRecords(2, "key-2")...)
+	records[1], records[3] = records[3], records[1]
+	ctx := correlation.ContextWithID(context.Background(), "123")
+
+	failed, err := p.Publish(ctx, "stream", records...)
+	require.NoError(t, err)
+	assert.Empty(t, failed)
+
+	require.Len(t, api.inputs, 1)
+	input := api.inputs[0]
+	assert.Equal(t, "stream", *input.StreamName)
+	require.Len(t, input.Records, 2)
+
+	expected := map[string][]string{"key-1": {"data 0", "data 2", "data 1"}, "key-2": {"data 0", "data 1"}}
+	for _, entry := range input.Records {
+		rr, err := kpl.Unmarshal(entry.Data)
+		require.NoError(t, err)
+		var data []string
+		for _, r := range rr {
+			assert.Equal(t, *entry.PartitionKey, r.PartitionKey)
+			assert.Equal(t, "123", r.Tags[correlation.HeaderID])
+			data = append(data, string(r.Data))
+		}
+		assert.Equal(t, expected[*entry.PartitionKey], data)
+	}
+}
+
+func TestProducer_Publish_AggregationLimit(t *testing.T) {
+	t.Parallel()
+	api := &stubKinesisAPI{}
+	p, err := New(api)
+	require.NoError(t, err)
+	records := []Record{
+		{PartitionKey: "key", Data: make([]byte, 600*1024)},
+		{PartitionKey: "key", Data: make([]byte, 600*1024)},
+		{PartitionKey: "key", Data: make([]byte, maxRecordSize)},
+	}
+
+	failed, err := p.Publish(context.Background(), "stream", records...)
+	assert.EqualError(t, err, "failed to publish 1 of 3 records: record size of 1048576 bytes exceeds the limit of 1048576 bytes")
+	require.Len(t, failed, 1)
+	assert.Len(t, failed[0].Data, maxRecordSize)
+
+	// the first two records exceed the record size together, so they are aggregated into separate records
+	require.Len(t, api.inputs, 1)
+	assert.Len(t, api.inputs[0].Records, 2)
+}
+
+func TestProducer_Publish_Chunks(t *testing.T) {
+	t.Parallel()
+	api := &stubKinesisAPI{}
+	p, err := New(api, Aggregation(false))
+	require.NoError(t, err)
+	records := newRecords(maxRequestRecords+1, "key")
+	records = append(records, newRecords(6, "large")...)
+	for i := maxRequestRecords + 1; i < len(records); i++ {
+		records[i].Data = make([]byte, 1000*1024)
+	}
+
+	failed, err := p.Publish(context.Background(), "stream", records...)
+	require.NoError(t, err)
+	assert.Empty(t, failed)
+
+	require.Len(t, api.inputs, 3)
+	assert.Len(t, api.inputs[0].Records, maxRequestRecords)
+	assert.Len(t, api.inputs[1].Records, 6)
+	assert.Len(t, api.inputs[2].Records, 1)
+	assert.Equal(t, []byte("data 0"), api.inputs[0].Records[0].Data)
+}
+
+func TestProducer_Publish_Retries(t *testing.T) {
+	t.Parallel()
+	api := &stubKinesisAPI{failures: map[string]int{"data 1": 1, "data 2": 5}}
+	p, err := New(api, Aggregation(false), Retries(2, 0))
+	require.NoError(t, err)
+
+	failed, err := p.Publish(context.Background(), "stream", newRecords(4, "key")...)
+	assert.EqualError(t, err, "failed to publish 1 of 4 records: ProvisionedThroughputExceededException: rate exceeded")
+	assert.Equal(t, []Record{{PartitionKey: "key", Data: []byte("data 2")}}, failed)
+
+	require.Len(t, api.inputs, 3)
+	assert.Len(t, api.inputs[0].Records, 4)
+	assert.Len(t, api.inputs[1].Records, 2)
+	assert.Len(t, api.inputs[2].Records, 1)
+}
+
+func TestProducer_Publish_RequestError(t *testing.T) {
+	t.Parallel()
+	api := &stubKinesisAPI{err: errors.New("unavailable")}
+	p, err := New(api, Retries(1, 0))
+	require.NoError(t, err)
+
+	failed, err := p.Publish(context.Background(), "stream", newRecords(2, "key")...)
+	assert.EqualError(t, err, "failed to publish 2 of 2 records: failed to put records: unavailable")
+	assert.Len(t, failed, 2)
+	assert.Len(t, api.inputs, 2)
+}
+
+type stubKinesisAPI struct {
+	kinesisiface.KinesisAPI
+	err error
+	// failures maps the data of records to the number of times they fail.
+	failures map[string]int
+	inputs   []*kinesis.PutRecordsInput
+}
+
+func (s *stubKinesisAPI) PutRecordsWithContext(_ aws.Context, input *kinesis.PutRecordsInput, _ ...request.Option) (*kinesis.PutRecordsOutput, error) {
+	s.inputs = append(s.inputs, input)
+	if s.err != nil {
+		return nil, s.err
+	}
+	out := &kinesis.PutRecordsOutput{}
+	for i, r := range input.Records {
+		res := &kinesis.PutRecordsResultEntry{SequenceNumber: aws.String(strconv.Itoa(i)), ShardId: aws.String("shardId-000000000000")}
+		if s.failures[string(r.Data)] > 0 {
+			s.failures[string(r.Data)]--
+			res = &kinesis.PutRecordsResultEntry{ErrorCode: aws.String("ProvisionedThroughputExceededException"), ErrorMessage: aws.String("rate exceeded")}
+			out.FailedRecordCount = aws.Int64(aws.Int64Value(out.FailedRecordCount) + 1)
+		}
+		out.Records = append(out.Records, res)
+	}
+	return out, nil
+}
@@ -7,10 +7,12 @@ import (
 	"fmt"
 	"net/url"
 	"strconv"
+	"strings"
 	"time"
 
-	"github.com/beatlabs/patron/correlation"
+	"github.com/beatlabs/patron/dependency"
 	"github.com/beatlabs/patron/log"
+	"github.com/beatlabs/patron/propagation"
 	"github.com/beatlabs/patron/trace"
 	"github.com/eclipse/paho.golang/autopaho"
 	"github.com/eclipse/paho.golang/paho"
@@ -19,7 +21,10 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 )
 
-const componentType = "mqtt-publisher"
+const (
+	componentType  = "mqtt-publisher"
+	dependencyKind = "mqtt"
+)
 
 var publishDurationMetrics *prometheus.HistogramVec
 
@@ -73,7 +78,8 @@ func DefaultConfig(brokerURLs []*url.URL, clientID string) (autopaho.ClientConfi
 
 // Publisher definition.
 type Publisher struct {
-	cm *autopaho.ConnectionManager
+	cm     *autopaho.ConnectionManager
+	broker string
 }
 
 // New creates a publisher.
@@ -83,7 +89,12 @@ func New(ctx context.Context, cfg autopaho.ClientConfig) (*Publisher, error) {
 		return nil, fmt.Errorf("failed to create connection manager: %w", err)
 	}
 
-	return &Publisher{cm: cm}, nil
+	hosts := make([]string, 0, len(cfg.BrokerUrls))
+	for _, u := range cfg.BrokerUrls {
+		hosts = append(hosts, u.Host)
+	}
+
+	return &Publisher{cm: cm, broker: strings.Join(hosts, ",")}, nil
 }
 
 // Publish provides a instrumented publishing of a message.
@@ -95,22 +106,22 @@ func (p *Publisher) Publish(ctx context.Context, pub *paho.Publish) (*paho.Publi
 
 	err := p.cm.AwaitConnection(ctx)
 	if err != nil {
-		observePublish(ctx, sp, start, pub.Topic, err)
+		p.observePublish(ctx, sp, start, pub.Topic, err)
 		return nil, fmt.Errorf("connection is not up: %w", err)
 	}
 
 	if err = injectObservabilityHeaders(ctx, pub, sp); err != nil {
-		observePublish(ctx, sp, start, pub.Topic, err)
+		p.observePublish(ctx, sp, start, pub.Topic, err)
 		return nil, fmt.Errorf("failed to inject tracing headers: %w", err)
 	}
 
 	rsp, err := p.cm.Publish(ctx, pub)
 	if err != nil {
-		observePublish(ctx, sp, start, pub.Topic, err)
+		p.observePublish(ctx, sp, start, pub.Topic, err)
 		return nil, fmt.Errorf("failed to publish message: %w", err)
 	}
 
-	observePublish(ctx, sp, start, pub.Topic, err)
+	p.observePublish(ctx, sp, start, pub.Topic, err)
 	return rsp, nil
 }
 
@@ -119,23 +130,9 @@ func (p *Publisher) Disconnect(ctx context.Context) error {
 	return p.cm.Disconnect(ctx)
 }
 
-type mqttHeadersCarrier paho.UserProperties
-
-// Set implements Set() of opentracing.TextMapWriter.
-func (m *mqttHeadersCarrier) Set(key, val string) {
-	hdr := paho.UserProperties(*m)
-	hdr.Add(key, val)
-	*m = mqttHeadersCarrier(hdr)
-}
-
 func injectObservabilityHeaders(ctx context.Context, pub *paho.Publish, sp opentracing.Span) error {
 	ensurePublishingProperties(pub)
-	pub.Properties.User.Add(correlation.HeaderID, correlation.IDFromContext(ctx))
-
-	c := mqttHeadersCarrier(pub.Properties.User)
-	err := sp.Tracer().Inject(sp.Context(), opentracing.TextMap, &c)
-	pub.Properties.User = paho.UserProperties(c)
-	return err
+	return propagation.Inject(ctx, sp, (*propagation.MQTTUserProperties)(&pub.Properties.User))
 }
 
 func ensurePublishingProperties(pub *paho.Publish) {
@@ -150,11 +147,13 @@ func ensurePublishingProperties(pub *paho.Publish) {
 	}
 }
 
-func observePublish(ctx context.Context, span opentracing.Span, start time.Time, topic string, err error) {
+func (p *Publisher) observePublish(ctx context.Context, span opentracing.Span, start time.Time, topic string, err error) {
 	trace.SpanComplete(span, err)
 
+	duration := time.Since(start)
 	durationHistogram := trace.Histogram{
 		Observer: publishDurationMetrics.WithLabelValues(topic, strconv.FormatBool(err == nil)),
 	}
-	durationHistogram.Observe(ctx, time.Since(start).Seconds())
+	durationHistogram.Observe(ctx, duration.Seconds())
+	dependency.Observe(dependencyKind, p.broker, err == nil, duration)
 }
@@ -0,0 +1,221 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/beatlabs/patron/log"
+	"github.com/go-redis/redis/v8"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	broadcastPublished = "published"
+	broadcastReceived  = "received"
+	broadcastDelivered = "delivered"
+	broadcastDropped   = "dropped"
+)
+
+var (
+	broadcastMessagesMetrics      *prometheus.CounterVec
+	broadcastSubscriptionsMetrics prometheus.Gauge
+)
+
+func init() {
+	broadcastMessagesMetrics = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "client",
+			Subsystem: "redis",
+			Name:      "broadcast_messages",
+			Help:      "Broadcast messages, classified by operation (published, received, delivered and dropped).",
+		},
+		[]string{"operation"},
+	)
+	broadcastSubscriptionsMetrics = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: "client",
+			Subsystem: "redis",
+			Name:      "broadcast_subscriptions",
+			Help:      "Local subscriptions to broadcast channels.",
+		},
+	)
+	prometheus.MustRegister(broadcastMessagesMetrics, broadcastSubscriptionsMetrics)
+}
+
+// pubSub is the part of redis.PubSub used by the broadcast.
+type pubSub interface {
+	Subscribe(ctx context.Context, channels ...string) error
+	Unsubscribe(ctx context.Context, channels ...string) error
+	Channel(opts ...redis.ChannelOption) <-chan *redis.Message
+	Close() error
+}
+
+// publisher is the part of the client used by the broadcast.
+type publisher interface {
+	Publish(ctx context.Context, channel string, message interface{}) *redis.IntCmd
+}
+
+// Broadcast bridges messages over Redis pub/sub, so that messages published on any replica reach the subscribers of all replicas
+// e.g. for fanning out messages to the WebSocket or server-sent event connections held by each replica.
+// Every replica holds a single Redis subscription per channel, regardless of the number of its local subscribers.
+type Broadcast struct {
+	client publisher
+	pubSub pubSub
+	prefix string
+	buffer int
+	mu     sync.Mutex
+	subs   map[string]map[*Subscription]struct{}
+	closed bool
+}
+
+// NewBroadcast creates a broadcast using the provided client. The prefix is prepended to the channels in Redis,
+// so that multiple services can share the same Redis. The buffer is the number of messages buffered for every subscriber;
+// messages are dropped for subscribers with a full buffer, so that a slow subscriber cannot hold back the rest.
+func NewBroadcast(client *Client, prefix string, buffer int) (*Broadcast, error) {
+	if client == nil {
+		return nil, errors.New("client is nil")
+	}
+	if buffer <= 0 {
+		return nil, errors.New("buffer should be positive")
+	}
+	return newBroadcast(client, client.Subscribe(context.Background()), prefix, buffer), nil
+}
+
+func newBroadcast(client publisher, ps pubSub, prefix string, buffer int) *Broadcast {
+	return &Broadcast{
+		client: client,
+		pubSub: ps,
+		prefix: prefix,
+		buffer: buffer,
+		subs:   make(map[string]map[*Subscription]struct{}),
+	}
+}
+
+// Publish publishes the message to the subscribers of the channel on all replicas.
+func (b *Broadcast) Publish(ctx context.Context, channel string, msg []byte) error {
+	if err := b.client.Publish(ctx, b.prefix+channel, msg).Err(); err != nil {
+		return err
+	}
+	broadcastMessagesMetrics.WithLabelValues(broadcastPublished).Inc()
+	return nil
+}
+
+// Subscribe subscribes to the messages of the channel, which are delivered until the subscription is closed.
+func (b *Broadcast) Subscribe(ctx context.Context, channel string) (*Subscription, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil, errors.New("broadcast is closed")
+	}
+
+	subs, ok := b.subs[channel]
+	if !ok {
+		if err := b.pubSub.Subscribe(ctx, b.prefix+channel); err != nil {
+			return nil, err
+		}
+		subs = make(map[*Subscription]struct{})
+		b.subs[channel] = subs
+	}
+
+	sub := &Subscription{broadcast: b, channel: channel, messages: make(chan []byte, b.buffer)}
+	subs[sub] = struct{}{}
+	broadcastSubscriptionsMetrics.Inc()
+	return sub, nil
+}
+
+// Run delivers the received messages to the local subscribers until the context is done,
+// which allows the broadcast to be used as a component of the service.
+// The subscriptions are closed when it returns.
+func (b *Broadcast) Run(ctx context.Context) error {
+	defer b.close()
+
+	messages := b.pubSub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case msg, ok := <-messages:
+			if !ok {
+				return nil
+			}
+			b.dispatch(msg)
+		}
+	}
+}
+
+func (b *Broadcast) dispatch(msg *redis.Message) {
+	broadcastMessagesMetrics.WithLabelValues(broadcastReceived).Inc()
+	channel := strings.TrimPrefix(msg.Channel, b.prefix)
+	payload := []byte(msg.Payload)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for sub := range b.subs[channel] {
+		select {
+		case sub.messages <- payload:
+			broadcastMessagesMetrics.WithLabelValues(broadcastDelivered).Inc()
+		default:
+			broadcastMessagesMetrics.WithLabelValues(broadcastDropped).Inc()
+		}
+	}
+}
+
+func (b *Broadcast) close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for channel, subs := range b.subs {
+		for sub := range subs {
+			close(sub.messages)
+			broadcastSubscriptionsMetrics.Dec()
+		}
+		delete(b.subs, channel)
+	}
+	if err := b.pubSub.Close(); err != nil {
+		log.Warnf("could not close broadcast subscription: %v", err)
+	}
+}
+
+func (b *Broadcast) unsubscribe(ctx context.Context, sub *Subscription) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	subs, ok := b.subs[sub.channel]
+	if !ok {
+		return nil
+	}
+	if _, ok := subs[sub]; !ok {
+		return nil
+	}
+	delete(subs, sub)
+	close(sub.messages)
+	broadcastSubscriptionsMetrics.Dec()
+
+	if len(subs) > 0 {
+		return nil
+	}
+	delete(b.subs, sub.channel)
+	return b.pubSub.Unsubscribe(ctx, b.prefix+sub.channel)
+}
+
+// Subscription to the messages of a broadcast channel.
+type Subscription struct {
+	broadcast *Broadcast
+	channel   string
+	messages  chan []byte
+}
+
+// Messages returns the channel the messages are delivered to, which is closed when the subscription
+// or the broadcast is closed.
+func (s *Subscription) Messages() <-chan []byte {
+	return s.messages
+}
+
+// Close stops delivering messages to the subscription.
+func (s *Subscription) Close(ctx context.Context) error {
+	return s.broadcast.unsubscribe(ctx, s)
+}
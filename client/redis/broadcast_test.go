@@ -0,0 +1,181 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakePubSub struct {
+	sync.Mutex
+	subscribed   []string
+	unsubscribed []string
+	subErr       error
+	messages     chan *redis.Message
+	closed       bool
+}
+
+func newFakePubSub() *fakePubSub {
+	return &fakePubSub{messages: make(chan *redis.Message)}
+}
+
+func (f *fakePubSub) Subscribe(_ context.Context, channels ...string) error {
+	f.Lock()
+	defer f.Unlock()
+	if f.subErr != nil {
+		return f.subErr
+	}
+	f.subscribed = append(f.subscribed, channels...)
+	return nil
+}
+
+func (f *fakePubSub) Unsubscribe(_ context.Context, channels ...string) error {
+	f.Lock()
+	defer f.Unlock()
+	f.unsubscribed = append(f.unsubscribed, channels...)
+	return nil
+}
+
+func (f *fakePubSub) Channel(...redis.ChannelOption) <-chan *redis.Message {
+	return f.messages
+}
+
+func (f *fakePubSub) Close() error {
+	f.Lock()
+	defer f.Unlock()
+	f.closed = true
+	return nil
+}
+
+type fakePublisher struct {
+	channel string
+	message interface{}
+	err     error
+}
+
+func (f *fakePublisher) Publish(_ context.Context, channel string, message interface{}) *redis.IntCmd {
+	f.channel, f.message = channel, message
+	cmd := redis.NewIntCmd(context.Background())
+	cmd.SetErr(f.err)
+	return cmd
+}
+
+func TestNewBroadcast(t *testing.T) {
+	type args struct {
+		client *Client
+		buffer int
+	}
+	cl := New(Options{})
+	tests := map[string]struct {
+		args        args
+		expectedErr string
+	}{
+		"success":         {args: args{client: &cl, buffer: 1}},
+		"missing client":  {args: args{buffer: 1}, expectedErr: "client is nil"},
+		"invalid buffer":  {args: args{client: &cl, buffer: 0}, expectedErr: "buffer should be positive"},
+		"negative buffer": {args: args{client: &cl, buffer: -1}, expectedErr: "buffer should be positive"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			got, err := NewBroadcast(tt.args.client, "prefix:", tt.args.buffer)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, got)
+			}
+		})
+	}
+}
+
+func TestBroadcast_Publish(t *testing.T) {
+	pub := &fakePublisher{}
+	b := newBroadcast(pub, newFakePubSub(), "prefix:", 1)
+	require.NoError(t, b.Publish(context.Background(), "news", []byte("hello")))
+	assert.Equal(t, "prefix:news", pub.channel)
+	assert.Equal(t, []byte("hello"), pub.message)
+
+	pub.err = errors.New("publish error")
+	assert.EqualError(t, b.Publish(context.Background(), "news", []byte("hello")), "publish error")
+}
+
+func TestBroadcast_SubscribeUnsubscribe(t *testing.T) {
+	ps := newFakePubSub()
+	b := newBroadcast(&fakePublisher{}, ps, "prefix:", 1)
+	ctx := context.Background()
+
+	sub1, err := b.Subscribe(ctx, "news")
+	require.NoError(t, err)
+	sub2, err := b.Subscribe(ctx, "news")
+	require.NoError(t, err)
+	// a single redis subscription is held per channel
+	assert.Equal(t, []string{"prefix:news"}, ps.subscribed)
+
+	require.NoError(t, sub1.Close(ctx))
+	assert.Empty(t, ps.unsubscribed)
+	_, ok := <-sub1.Messages()
+	assert.False(t, ok)
+	// closing twice is a no-op
+	require.NoError(t, sub1.Close(ctx))
+
+	require.NoError(t, sub2.Close(ctx))
+	assert.Equal(t, []string{"prefix:news"}, ps.unsubscribed)
+
+	ps.subErr = errors.New("subscribe error")
+	_, err = b.Subscribe(ctx, "sports")
+	assert.EqualError(t, err, "subscribe error")
+}
+
+func TestBroadcast_Run(t *testing.T) {
+	ps := newFakePubSub()
+	b := newBroadcast(&fakePublisher{}, ps, "prefix:", 1)
+	ctx, cnl := context.WithCancel(context.Background())
+
+	news1, err := b.Subscribe(ctx, "news")
+	require.NoError(t, err)
+	news2, err := b.Subscribe(ctx, "news")
+	require.NoError(t, err)
+	sports, err := b.Subscribe(ctx, "sports")
+	require.NoError(t, err)
+
+	chErr := make(chan error)
+	go func() {
+		chErr <- b.Run(ctx)
+	}()
+
+	ps.messages <- &redis.Message{Channel: "prefix:news", Payload: "first"}
+	assert.Equal(t, []byte("first"), <-news1.Messages())
+	assert.Equal(t, []byte("first"), <-news2.Messages())
+
+	ps.messages <- &redis.Message{Channel: "prefix:news", Payload: "second"}
+	assert.Equal(t, []byte("second"), <-news1.Messages())
+	// the buffer of news2 is full, so the message is dropped for it only
+	ps.messages <- &redis.Message{Channel: "prefix:news", Payload: "third"}
+	assert.Equal(t, []byte("third"), <-news1.Messages())
+
+	cnl()
+	select {
+	case err := <-chErr:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		assert.Fail(t, "broadcast did not stop")
+	}
+
+	assert.Equal(t, []byte("second"), <-news2.Messages())
+	_, ok := <-news2.Messages()
+	assert.False(t, ok)
+	_, ok = <-sports.Messages()
+	assert.False(t, ok)
+	assert.True(t, ps.closed)
+
+	_, err = b.Subscribe(context.Background(), "news")
+	assert.EqualError(t, err, "broadcast is closed")
+}
@@ -5,8 +5,11 @@ package redis
 
 import (
 	"context"
+	"errors"
 	"testing"
+	"time"
 
+	"github.com/go-redis/redis/v8"
 	"github.com/opentracing/opentracing-go"
 	"github.com/opentracing/opentracing-go/mocktracer"
 	"github.com/prometheus/client_golang/prometheus/testutil"
@@ -40,3 +43,103 @@ func TestClient(t *testing.T) {
 	// Metrics
 	assert.Equal(t, 1, testutil.CollectAndCount(cmdDurationMetrics, "client_redis_cmd_duration_seconds"))
 }
+
+func TestClient_Pipeline(t *testing.T) {
+	mtr := mocktracer.New()
+	opentracing.SetGlobalTracer(mtr)
+	defer mtr.Reset()
+
+	cl := New(Options{Addr: dsn})
+	_, err := cl.TxPipelined(context.Background(), func(pipe redis.Pipeliner) error {
+		pipe.Set(context.Background(), "key", "value", 0)
+		pipe.Get(context.Background(), "key")
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Len(t, mtr.FinishedSpans(), 1)
+	assert.Len(t, mtr.FinishedSpans()[0].Logs(), 2)
+	assert.Equal(t, 1, testutil.CollectAndCount(pipelineSizeMetrics, "client_redis_pipeline_size"))
+}
+
+func TestClient_Transaction(t *testing.T) {
+	mtr := mocktracer.New()
+	opentracing.SetGlobalTracer(mtr)
+	defer mtr.Reset()
+
+	cl := New(Options{Addr: dsn})
+	ctx := context.Background()
+	attempts := 0
+	err := cl.Transaction(ctx, 2, func(tx *redis.Tx) error {
+		attempts++
+		n, err := tx.Get(ctx, "counter").Int()
+		if err != nil && !errors.Is(err, Nil) {
+			return err
+		}
+		if attempts == 1 {
+			// modify the watched key, so that the first attempt is aborted
+			cl.Set(ctx, "counter", n+10, 0)
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, "counter", n+1, 0)
+			return nil
+		})
+		return err
+	}, "counter")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, attempts)
+
+	var transaction *mocktracer.MockSpan
+	for _, sp := range mtr.FinishedSpans() {
+		if sp.OperationName == transactionOpName {
+			transaction = sp
+		}
+	}
+	assert.NotNil(t, transaction)
+	assert.Equal(t, 2, transaction.Tag("attempts"))
+}
+
+func TestSemaphore(t *testing.T) {
+	cl := New(Options{Addr: dsn})
+	ctx := context.Background()
+	assert.NoError(t, cl.Del(ctx, "semaphore").Err())
+
+	sem, err := NewSemaphore(&cl, "semaphore", 2, time.Second)
+	assert.NoError(t, err)
+
+	l1, err := sem.TryAcquire(ctx)
+	assert.NoError(t, err)
+	l2, err := sem.TryAcquire(ctx)
+	assert.NoError(t, err)
+	_, err = sem.TryAcquire(ctx)
+	assert.ErrorIs(t, err, ErrSemaphoreFull)
+
+	// leases are renewed beyond their duration
+	time.Sleep(1500 * time.Millisecond)
+	_, err = sem.TryAcquire(ctx)
+	assert.ErrorIs(t, err, ErrSemaphoreFull)
+
+	assert.NoError(t, l1.Release(ctx))
+	l3, err := sem.TryAcquire(ctx)
+	assert.NoError(t, err)
+	assert.NoError(t, l2.Release(ctx))
+	assert.NoError(t, l3.Release(ctx))
+}
+
+func TestRateLimiter(t *testing.T) {
+	cl := New(Options{Addr: dsn})
+	ctx := context.Background()
+	assert.NoError(t, cl.Del(ctx, "rate").Err())
+
+	rl, err := NewRateLimiter(&cl, "rate", 2, 200*time.Millisecond)
+	assert.NoError(t, err)
+
+	for _, expected := range []bool{true, true, false} {
+		allowed, err := rl.Allow(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, allowed)
+	}
+	time.Sleep(300 * time.Millisecond)
+	allowed, err := rl.Allow(ctx)
+	assert.NoError(t, err)
+	assert.True(t, allowed)
+}
@@ -0,0 +1,85 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/beatlabs/patron/reliability/retry"
+	"github.com/beatlabs/patron/trace"
+	"github.com/go-redis/redis/v8"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/log"
+)
+
+const (
+	// TxFailedErr represents the error which is returned in case a watched key has been modified and the transaction is aborted.
+	TxFailedErr = redis.TxFailedErr
+
+	transactionOpName = "transaction"
+	commandEvent      = "command"
+)
+
+// Transaction executes fn in an optimistic transaction, watching the provided keys for modifications.
+// The commands queued by fn with Tx.TxPipelined are executed atomically wrapped in MULTI/EXEC.
+// If a watched key is modified before the transaction is committed, fn is executed again up to maxRetries times.
+// All attempts are covered by a single span, which is the parent of the spans of the executed commands.
+func (c *Client) Transaction(ctx context.Context, maxRetries int, fn func(*redis.Tx) error, keys ...string) error {
+	sp, ctx := startSpan(ctx, c.Options().Addr, transactionOpName)
+
+	var err error
+	attempt := 0
+	for {
+		attempt++
+		err = c.Watch(ctx, fn, keys...)
+		retry.ObserveAttempt(sp, component, attempt, err)
+		if !errors.Is(err, TxFailedErr) || attempt > maxRetries {
+			break
+		}
+	}
+
+	retry.ObserveAttempts(sp, attempt)
+	trace.SpanComplete(sp, err)
+	return err
+}
+
+// isTransaction returns true if the pipeline commands are wrapped in MULTI/EXEC.
+func isTransaction(cmds []redis.Cmder) bool {
+	return len(cmds) >= 2 && cmds[0].Name() == "multi" && cmds[len(cmds)-1].Name() == "exec"
+}
+
+// queuedCommands returns the commands of the pipeline, excluding the MULTI/EXEC of transactions.
+func queuedCommands(cmds []redis.Cmder, tx bool) []redis.Cmder {
+	if tx {
+		return cmds[1 : len(cmds)-1]
+	}
+	return cmds
+}
+
+// pipelineError returns the first error of the pipeline commands, if any.
+func pipelineError(cmds []redis.Cmder) error {
+	for _, cmd := range cmds {
+		if err := cmd.Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// observeCommands records an event on the span for every command of the pipeline.
+func observeCommands(sp opentracing.Span, cmds []redis.Cmder, tx bool) {
+	if sp == nil {
+		return
+	}
+	for _, cmd := range queuedCommands(cmds, tx) {
+		ff := []log.Field{log.String("event", commandEvent), log.String(commandEvent, cmd.FullName())}
+		if err := cmd.Err(); err != nil {
+			ff = append(ff, log.Error(err))
+		}
+		sp.LogFields(ff...)
+	}
+}
+
+func observePipelineSize(cmds []redis.Cmder, tx bool) {
+	pipelineSizeMetrics.WithLabelValues(strconv.FormatBool(tx)).Observe(float64(len(queuedCommands(cmds, tx))))
+}
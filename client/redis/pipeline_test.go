@@ -0,0 +1,62 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsTransaction(t *testing.T) {
+	ctx := context.Background()
+	get := redis.NewStringCmd(ctx, "get", "key")
+
+	assert.False(t, isTransaction([]redis.Cmder{get}))
+	assert.True(t, isTransaction([]redis.Cmder{redis.NewStatusCmd(ctx, "multi"), get, redis.NewSliceCmd(ctx, "exec")}))
+}
+
+func TestPipelineError(t *testing.T) {
+	ctx := context.Background()
+	get := redis.NewStringCmd(ctx, "get", "key")
+	set := redis.NewStatusCmd(ctx, "set", "key", "value")
+
+	assert.NoError(t, pipelineError([]redis.Cmder{get, set}))
+
+	set.SetErr(errors.New("failed"))
+	assert.EqualError(t, pipelineError([]redis.Cmder{get, set}), "failed")
+}
+
+func TestAfterProcessPipeline(t *testing.T) {
+	mtr := mocktracer.New()
+	opentracing.SetGlobalTracer(mtr)
+	defer mtr.Reset()
+	pipelineSizeMetrics.Reset()
+
+	ctx := context.Background()
+	get := redis.NewStringCmd(ctx, "get", "key")
+	set := redis.NewStatusCmd(ctx, "set", "key", "value")
+	set.SetErr(errors.New("failed"))
+	cmds := []redis.Cmder{redis.NewStatusCmd(ctx, "multi"), get, set, redis.NewSliceCmd(ctx, "exec")}
+
+	th := tracingHook{address: "localhost"}
+	ctx, err := th.BeforeProcessPipeline(ctx, cmds)
+	assert.NoError(t, err)
+	assert.NoError(t, th.AfterProcessPipeline(ctx, cmds))
+
+	assert.Len(t, mtr.FinishedSpans(), 1)
+	sp := mtr.FinishedSpans()[0]
+	assert.Equal(t, "multi get set exec", sp.OperationName)
+	assert.Equal(t, true, sp.Tags()["error"])
+	logs := sp.Logs()
+	assert.Len(t, logs, 2)
+	assert.Equal(t, "get", logs[0].Fields[1].ValueString)
+	assert.Equal(t, "set", logs[1].Fields[1].ValueString)
+	assert.Len(t, logs[1].Fields, 3)
+
+	assert.Equal(t, 1, testutil.CollectAndCount(pipelineSizeMetrics, "client_redis_pipeline_size"))
+}
@@ -0,0 +1,56 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// The counter of the current window expires along with the window, so that the next request starts a new one.
+var allowScript = redis.NewScript(`
+local count = redis.call('INCR', KEYS[1])
+if count == 1 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[1])
+end
+if count > tonumber(ARGV[2]) then
+	return 0
+end
+return 1
+`)
+
+// RateLimiter is a distributed fixed window rate limiter, which allows multiple replicas to share a rate limit
+// towards a constrained resource.
+type RateLimiter struct {
+	client scripter
+	key    string
+	limit  int
+	window time.Duration
+}
+
+// NewRateLimiter creates a rate limiter, which allows the provided number of events per window, counted in the provided key.
+func NewRateLimiter(client *Client, key string, limit int, window time.Duration) (*RateLimiter, error) {
+	if client == nil {
+		return nil, errors.New("client is nil")
+	}
+	if key == "" {
+		return nil, errors.New("key is empty")
+	}
+	if limit <= 0 {
+		return nil, errors.New("limit should be positive")
+	}
+	if window < time.Millisecond {
+		return nil, errors.New("window should be at least 1ms")
+	}
+	return &RateLimiter{client: &client.Client, key: key, limit: limit, window: window}, nil
+}
+
+// Allow reports whether an event may happen now, counting it towards the limit of the current window.
+func (r *RateLimiter) Allow(ctx context.Context) (bool, error) {
+	allowed, err := allowScript.Run(ctx, r.client, []string{r.key}, r.window.Milliseconds(), r.limit).Int()
+	if err != nil {
+		return false, err
+	}
+	return allowed == 1, nil
+}
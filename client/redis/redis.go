@@ -3,9 +3,11 @@ package redis
 
 import (
 	"context"
+	"errors"
 	"strconv"
 	"time"
 
+	"github.com/beatlabs/patron/dependency"
 	"github.com/beatlabs/patron/log"
 	"github.com/beatlabs/patron/trace"
 	"github.com/go-redis/redis/extra/rediscmd"
@@ -23,8 +25,9 @@ const (
 )
 
 var (
-	cmdDurationMetrics *prometheus.HistogramVec
-	_                  redis.Hook = tracingHook{}
+	cmdDurationMetrics  *prometheus.HistogramVec
+	pipelineSizeMetrics *prometheus.HistogramVec
+	_                   redis.Hook = tracingHook{}
 )
 
 func init() {
@@ -37,7 +40,17 @@ func init() {
 		},
 		[]string{"command", "success"},
 	)
-	prometheus.MustRegister(cmdDurationMetrics)
+	pipelineSizeMetrics = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "client",
+			Subsystem: "redis",
+			Name:      "pipeline_size",
+			Help:      "Number of commands of the pipelines and transactions executed by the client.",
+			Buckets:   []float64{1, 2, 5, 10, 20, 50, 100, 200, 500, 1000},
+		},
+		[]string{"transaction"},
+	)
+	prometheus.MustRegister(cmdDurationMetrics, pipelineSizeMetrics)
 }
 
 type duration struct{}
@@ -70,25 +83,29 @@ func (th tracingHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (conte
 func (th tracingHook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
 	span := opentracing.SpanFromContext(ctx)
 	trace.SpanComplete(span, cmd.Err())
-	observeDuration(ctx, cmd.FullName(), cmd.Err())
+	th.observeDuration(ctx, cmd.FullName(), cmd.Err())
 	return nil
 }
 
 func (th tracingHook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
-	_, opName := rediscmd.CmdsString(cmds)
+	opName, _ := rediscmd.CmdsString(cmds)
 	_, ctx = startSpan(ctx, th.address, opName)
 	return context.WithValue(ctx, duration{}, time.Now()), nil
 }
 
 func (th tracingHook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
 	span := opentracing.SpanFromContext(ctx)
-	trace.SpanComplete(span, cmds[0].Err())
-	_, opName := rediscmd.CmdsString(cmds)
-	observeDuration(ctx, opName, cmds[0].Err())
+	err := pipelineError(cmds)
+	tx := isTransaction(cmds)
+	observeCommands(span, cmds, tx)
+	trace.SpanComplete(span, err)
+	opName, _ := rediscmd.CmdsString(cmds)
+	th.observeDuration(ctx, opName, err)
+	observePipelineSize(cmds, tx)
 	return nil
 }
 
-func observeDuration(ctx context.Context, cmd string, err error) {
+func (th tracingHook) observeDuration(ctx context.Context, cmd string, err error) {
 	start, ok := ctx.Value(duration{}).(time.Time)
 	if !ok {
 		log.FromContext(ctx).Error("failed to type assert to time")
@@ -99,6 +116,8 @@ func observeDuration(ctx context.Context, cmd string, err error) {
 		Observer: cmdDurationMetrics.WithLabelValues(cmd, strconv.FormatBool(err == nil)),
 	}
 	durationHistogram.Observe(ctx, dur.Seconds())
+	// a missing key is a successful call of the dependency
+	dependency.Observe(component, th.address, err == nil || errors.Is(err, Nil), dur)
 }
 
 func startSpan(ctx context.Context, address, opName string) (opentracing.Span, context.Context) {
@@ -0,0 +1,214 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/beatlabs/patron/log"
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrSemaphoreFull is returned when all the slots of the semaphore are held.
+	ErrSemaphoreFull = errors.New("semaphore is full")
+	// ErrLeaseLost is returned when a lease has expired, before it could be renewed or released.
+	ErrLeaseLost = errors.New("semaphore lease lost")
+)
+
+// The holders of a semaphore are kept in a sorted set, scored by the expiry of their lease in milliseconds.
+// Expired leases are removed before any operation, so that crashed holders cannot keep the slots forever.
+// The server time is used, so that the expiry does not depend on the clocks of the replicas.
+var (
+	acquireScript = redis.NewScript(`
+local t = redis.call('TIME')
+local now = t[1] * 1000 + math.floor(t[2] / 1000)
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now)
+if redis.call('ZCARD', KEYS[1]) >= tonumber(ARGV[1]) then
+	return 0
+end
+redis.call('ZADD', KEYS[1], now + tonumber(ARGV[2]), ARGV[3])
+redis.call('PEXPIRE', KEYS[1], ARGV[2])
+return 1
+`)
+	renewScript = redis.NewScript(`
+local t = redis.call('TIME')
+local now = t[1] * 1000 + math.floor(t[2] / 1000)
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now)
+if redis.call('ZSCORE', KEYS[1], ARGV[2]) == false then
+	return 0
+end
+redis.call('ZADD', KEYS[1], now + tonumber(ARGV[1]), ARGV[2])
+if redis.call('PTTL', KEYS[1]) < tonumber(ARGV[1]) then
+	redis.call('PEXPIRE', KEYS[1], ARGV[1])
+end
+return 1
+`)
+)
+
+// scripter is the part of the client needed by the coordination primitives.
+type scripter interface {
+	redis.Scripter
+	ZRem(ctx context.Context, key string, members ...interface{}) *redis.IntCmd
+}
+
+// Semaphore is a distributed counting semaphore, which allows multiple replicas to coordinate access to a constrained resource,
+// e.g. a downstream service which can handle a limited number of concurrent requests.
+type Semaphore struct {
+	client scripter
+	key    string
+	limit  int
+	lease  time.Duration
+}
+
+// NewSemaphore creates a semaphore with the provided number of slots, which is stored in the provided key.
+// Slots are held for the lease duration and are renewed in the background until they are released,
+// so that the slots of crashed holders become available again after the lease expires.
+func NewSemaphore(client *Client, key string, limit int, lease time.Duration) (*Semaphore, error) {
+	if client == nil {
+		return nil, errors.New("client is nil")
+	}
+	if key == "" {
+		return nil, errors.New("key is empty")
+	}
+	if limit <= 0 {
+		return nil, errors.New("limit should be positive")
+	}
+	if lease < 10*time.Millisecond {
+		return nil, errors.New("lease should be at least 10ms")
+	}
+	return &Semaphore{client: &client.Client, key: key, limit: limit, lease: lease}, nil
+}
+
+// TryAcquire acquires a slot of the semaphore, or returns ErrSemaphoreFull if all slots are held.
+func (s *Semaphore) TryAcquire(ctx context.Context) (*Lease, error) {
+	id := uuid.New().String()
+	acquired, err := acquireScript.Run(ctx, s.client, []string{s.key}, s.limit, s.lease.Milliseconds(), id).Int()
+	if err != nil {
+		return nil, err
+	}
+	if acquired == 0 {
+		return nil, ErrSemaphoreFull
+	}
+	return newLease(s, id), nil
+}
+
+// Acquire acquires a slot of the semaphore, waiting until one is available or the context is done.
+func (s *Semaphore) Acquire(ctx context.Context) (*Lease, error) {
+	ticker := time.NewTicker(s.lease / 10)
+	defer ticker.Stop()
+	for {
+		lease, err := s.TryAcquire(ctx)
+		if !errors.Is(err, ErrSemaphoreFull) {
+			return lease, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Do executes fn while holding a slot of the semaphore, limiting the concurrency of fn across all replicas.
+// The context passed to fn is canceled if the lease is lost.
+func (s *Semaphore) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	lease, err := s.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	ctx, cnl := context.WithCancel(ctx)
+	defer cnl()
+	go func() {
+		select {
+		case <-lease.Lost():
+			cnl()
+		case <-ctx.Done():
+		}
+	}()
+
+	err = fn(ctx)
+	// the slot should be released even if the context of the caller is done
+	if relErr := lease.Release(context.Background()); relErr != nil && !errors.Is(relErr, ErrLeaseLost) {
+		log.FromContext(ctx).Warnf("could not release semaphore lease of %s: %v", s.key, relErr)
+	}
+	return err
+}
+
+// Lease represents a slot held in a semaphore. It is renewed in the background until it is released or lost.
+type Lease struct {
+	sem     *Semaphore
+	id      string
+	lost    chan struct{}
+	stop    chan struct{}
+	stopped chan struct{}
+	once    sync.Once
+}
+
+func newLease(sem *Semaphore, id string) *Lease {
+	l := &Lease{
+		sem:     sem,
+		id:      id,
+		lost:    make(chan struct{}),
+		stop:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	go l.keepAlive()
+	return l
+}
+
+// Lost returns a channel, which is closed when the lease expires, before it could be renewed.
+func (l *Lease) Lost() <-chan struct{} {
+	return l.lost
+}
+
+// Release stops renewing the lease and frees the slot in the semaphore.
+// It returns ErrLeaseLost if the slot has already expired.
+func (l *Lease) Release(ctx context.Context) error {
+	l.once.Do(func() {
+		close(l.stop)
+	})
+	<-l.stopped
+
+	select {
+	case <-l.lost:
+		return ErrLeaseLost
+	default:
+	}
+
+	return l.sem.client.ZRem(ctx, l.sem.key, l.id).Err()
+}
+
+// keepAlive renews the lease at a third of its duration. Failed renewals are retried until the lease expires.
+func (l *Lease) keepAlive() {
+	defer close(l.stopped)
+
+	ticker := time.NewTicker(l.sem.lease / 3)
+	defer ticker.Stop()
+	expiry := time.Now().Add(l.sem.lease)
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+		}
+
+		renewed, err := renewScript.Run(context.Background(), l.sem.client, []string{l.sem.key}, l.sem.lease.Milliseconds(), l.id).Int()
+		switch {
+		case err == nil && renewed == 1:
+			expiry = time.Now().Add(l.sem.lease)
+			continue
+		case err == nil:
+			log.Warnf("semaphore lease of %s has expired", l.sem.key)
+		case time.Now().Before(expiry):
+			log.Warnf("could not renew semaphore lease of %s: %v", l.sem.key, err)
+			continue
+		default:
+			log.Warnf("could not renew semaphore lease of %s before it expired: %v", l.sem.key, err)
+		}
+		close(l.lost)
+		return
+	}
+}
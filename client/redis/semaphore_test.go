@@ -0,0 +1,211 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeScripter struct {
+	sync.Mutex
+	results map[string][]interface{}
+	removed []interface{}
+}
+
+func newFakeScripter() *fakeScripter {
+	return &fakeScripter{results: map[string][]interface{}{}}
+}
+
+// push queues the results of a script, the last of which is returned repeatedly.
+func (f *fakeScripter) push(script *redis.Script, rr ...interface{}) {
+	f.Lock()
+	defer f.Unlock()
+	f.results[script.Hash()] = append(f.results[script.Hash()], rr...)
+}
+
+func (f *fakeScripter) EvalSha(_ context.Context, sha1 string, _ []string, _ ...interface{}) *redis.Cmd {
+	f.Lock()
+	defer f.Unlock()
+	rr := f.results[sha1]
+	if len(rr) == 0 {
+		return redis.NewCmdResult(nil, errors.New("unexpected script"))
+	}
+	r := rr[0]
+	if len(rr) > 1 {
+		f.results[sha1] = rr[1:]
+	}
+	if err, ok := r.(error); ok {
+		return redis.NewCmdResult(nil, err)
+	}
+	return redis.NewCmdResult(r, nil)
+}
+
+func (f *fakeScripter) Eval(ctx context.Context, script string, keys []string, args ...interface{}) *redis.Cmd {
+	return redis.NewCmdResult(nil, errors.New("not implemented"))
+}
+
+func (f *fakeScripter) ScriptExists(context.Context, ...string) *redis.BoolSliceCmd {
+	return redis.NewBoolSliceResult(nil, errors.New("not implemented"))
+}
+
+func (f *fakeScripter) ScriptLoad(context.Context, string) *redis.StringCmd {
+	return redis.NewStringResult("", errors.New("not implemented"))
+}
+
+func (f *fakeScripter) ZRem(_ context.Context, _ string, members ...interface{}) *redis.IntCmd {
+	f.Lock()
+	defer f.Unlock()
+	f.removed = append(f.removed, members...)
+	return redis.NewIntResult(int64(len(members)), nil)
+}
+
+func TestNewSemaphore(t *testing.T) {
+	cl := New(Options{})
+	tests := map[string]struct {
+		client      *Client
+		key         string
+		limit       int
+		lease       time.Duration
+		expectedErr string
+	}{
+		"success":        {client: &cl, key: "key", limit: 1, lease: time.Second},
+		"missing client": {key: "key", limit: 1, lease: time.Second, expectedErr: "client is nil"},
+		"missing key":    {client: &cl, limit: 1, lease: time.Second, expectedErr: "key is empty"},
+		"invalid limit":  {client: &cl, key: "key", lease: time.Second, expectedErr: "limit should be positive"},
+		"invalid lease":  {client: &cl, key: "key", limit: 1, lease: time.Millisecond, expectedErr: "lease should be at least 10ms"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			got, err := NewSemaphore(tt.client, tt.key, tt.limit, tt.lease)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, got)
+			}
+		})
+	}
+}
+
+func TestSemaphore_TryAcquire(t *testing.T) {
+	fs := newFakeScripter()
+	sem := &Semaphore{client: fs, key: "key", limit: 1, lease: time.Minute}
+
+	fs.push(acquireScript, int64(1), int64(0), errors.New("failed"))
+	lease, err := sem.TryAcquire(context.Background())
+	require.NoError(t, err)
+	_, err = sem.TryAcquire(context.Background())
+	assert.ErrorIs(t, err, ErrSemaphoreFull)
+	_, err = sem.TryAcquire(context.Background())
+	assert.EqualError(t, err, "failed")
+
+	assert.NoError(t, lease.Release(context.Background()))
+	assert.Equal(t, []interface{}{lease.id}, fs.removed)
+	// releasing again is safe
+	assert.NoError(t, lease.Release(context.Background()))
+}
+
+func TestSemaphore_Acquire(t *testing.T) {
+	fs := newFakeScripter()
+	sem := &Semaphore{client: fs, key: "key", limit: 1, lease: 100 * time.Millisecond}
+
+	fs.push(acquireScript, int64(0), int64(0), int64(1))
+	fs.push(renewScript, int64(1))
+	lease, err := sem.Acquire(context.Background())
+	require.NoError(t, err)
+	assert.NoError(t, lease.Release(context.Background()))
+
+	fs = newFakeScripter()
+	sem.client = fs
+	fs.push(acquireScript, int64(0))
+	ctx, cnl := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cnl()
+	_, err = sem.Acquire(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestLease_Lost(t *testing.T) {
+	fs := newFakeScripter()
+	sem := &Semaphore{client: fs, key: "key", limit: 1, lease: 30 * time.Millisecond}
+
+	fs.push(acquireScript, int64(1))
+	fs.push(renewScript, int64(1), int64(0))
+	lease, err := sem.TryAcquire(context.Background())
+	require.NoError(t, err)
+
+	select {
+	case <-lease.Lost():
+	case <-time.After(time.Second):
+		assert.Fail(t, "lease should have been lost")
+	}
+	assert.ErrorIs(t, lease.Release(context.Background()), ErrLeaseLost)
+	assert.Empty(t, fs.removed)
+}
+
+func TestLease_LostAfterFailedRenewals(t *testing.T) {
+	fs := newFakeScripter()
+	sem := &Semaphore{client: fs, key: "key", limit: 1, lease: 30 * time.Millisecond}
+
+	fs.push(acquireScript, int64(1))
+	fs.push(renewScript, errors.New("failed"))
+	lease, err := sem.TryAcquire(context.Background())
+	require.NoError(t, err)
+
+	select {
+	case <-lease.Lost():
+	case <-time.After(time.Second):
+		assert.Fail(t, "lease should have been lost")
+	}
+}
+
+func TestSemaphore_Do(t *testing.T) {
+	fs := newFakeScripter()
+	sem := &Semaphore{client: fs, key: "key", limit: 1, lease: 30 * time.Millisecond}
+
+	fs.push(acquireScript, int64(1))
+	fs.push(renewScript, int64(1))
+	err := sem.Do(context.Background(), func(ctx context.Context) error {
+		return errors.New("failed")
+	})
+	assert.EqualError(t, err, "failed")
+	assert.Len(t, fs.removed, 1)
+
+	// the context of the function is canceled when the lease is lost
+	fs.push(renewScript, int64(0))
+	err = sem.Do(context.Background(), func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Len(t, fs.removed, 1)
+}
+
+func TestRateLimiter_Allow(t *testing.T) {
+	cl := New(Options{})
+	_, err := NewRateLimiter(&cl, "key", 0, time.Second)
+	assert.EqualError(t, err, "limit should be positive")
+	_, err = NewRateLimiter(&cl, "key", 1, 0)
+	assert.EqualError(t, err, "window should be at least 1ms")
+
+	fs := newFakeScripter()
+	rl := &RateLimiter{client: fs, key: "key", limit: 1, window: time.Second}
+	fs.push(allowScript, int64(1), int64(0), errors.New("failed"))
+
+	allowed, err := rl.Allow(context.Background())
+	assert.NoError(t, err)
+	assert.True(t, allowed)
+	allowed, err = rl.Allow(context.Background())
+	assert.NoError(t, err)
+	assert.False(t, allowed)
+	allowed, err = rl.Allow(context.Background())
+	assert.EqualError(t, err, "failed")
+	assert.False(t, allowed)
+}
@@ -0,0 +1,20 @@
+package servicebus
+
+import (
+	"errors"
+	"net/http"
+)
+
+// OptionFunc definition for configuring the sender in a functional way.
+type OptionFunc func(*Sender) error
+
+// Client option for setting the HTTP client of the requests to Service Bus.
+func Client(client *http.Client) OptionFunc {
+	return func(s *Sender) error {
+		if client == nil {
+			return errors.New("client is nil")
+		}
+		s.ns.Client = client
+		return nil
+	}
+}
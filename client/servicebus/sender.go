@@ -0,0 +1,181 @@
+// Package servicebus provides a client for sending messages to Azure Service Bus queues and topics over its REST API.
+// Implementations in this package also include distributed tracing capabilities by default.
+package servicebus
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/beatlabs/patron/internal/servicebus"
+	"github.com/beatlabs/patron/log"
+	"github.com/beatlabs/patron/propagation"
+	"github.com/beatlabs/patron/trace"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	senderComponent = "servicebus-sender"
+
+	batchContentType = "application/vnd.microsoft.servicebus.json"
+	// maxBatchSize is the maximum size of a batch of messages, on the standard tier.
+	maxBatchSize = 256 * 1024
+)
+
+var sendDurationMetrics *prometheus.HistogramVec
+
+func init() {
+	sendDurationMetrics = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "client",
+			Subsystem: "servicebus",
+			Name:      "send_duration_seconds",
+			Help:      "Azure Service Bus send requests completed by the client.",
+		},
+		[]string{"entity", "success"},
+	)
+	prometheus.MustRegister(sendDurationMetrics)
+}
+
+// BrokerProperties of a message, e.g. its message, session and correlation IDs.
+type BrokerProperties = servicebus.BrokerProperties
+
+// Message to be sent.
+type Message struct {
+	Body        []byte
+	ContentType string
+	// Properties are the custom properties of the message.
+	Properties map[string]string
+	// BrokerProperties of the message. Messages of session-enabled entities require a session ID.
+	BrokerProperties BrokerProperties
+}
+
+// Sender is a wrapper with added distributed tracing capabilities.
+type Sender struct {
+	ns *servicebus.Namespace
+}
+
+// New creates a new sender for the namespace of the connection string.
+func New(connectionString string, oo ...OptionFunc) (*Sender, error) {
+	ns, err := servicebus.ParseConnectionString(connectionString)
+	if err != nil {
+		return nil, err
+	}
+	s := &Sender{ns: ns}
+	for _, option := range oo {
+		if err := option(s); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// Send the message to the entity, i.e. a queue or topic. It also stores tracing information.
+func (s *Sender) Send(ctx context.Context, entity string, msg Message) error {
+	if entity == "" {
+		return errors.New("entity is empty")
+	}
+	span, _ := trace.ChildSpan(ctx, trace.ComponentOpName(senderComponent, entity), senderComponent, ext.SpanKindProducer)
+	props, err := properties(ctx, span, msg.Properties)
+	if err != nil {
+		log.FromContext(ctx).Errorf("failed to inject trace headers: %v", err)
+	}
+
+	brokerProperties, err := json.Marshal(msg.BrokerProperties)
+	if err != nil {
+		trace.SpanError(span)
+		return fmt.Errorf("failed to encode broker properties: %w", err)
+	}
+	hdr := http.Header{}
+	hdr.Set(servicebus.BrokerPropertiesHeader, string(brokerProperties))
+	if msg.ContentType != "" {
+		hdr.Set("Content-Type", msg.ContentType)
+	}
+	for k, v := range props {
+		hdr.Set(k, servicebus.EncodeProperty(v))
+	}
+
+	err = s.send(ctx, span, entity, hdr, msg.Body)
+	if err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	return nil
+}
+
+type batchMessage struct {
+	Body             string            `json:"Body"`
+	BrokerProperties BrokerProperties  `json:"BrokerProperties"`
+	UserProperties   map[string]string `json:"UserProperties,omitempty"`
+}
+
+// SendBatch sends the messages to the entity with a single request. The bodies of the messages are sent as strings,
+// and the size of the batch, i.e. the sum of the sizes of the bodies and properties, should not exceed 256 KiB.
+// The content type of the messages is ignored.
+func (s *Sender) SendBatch(ctx context.Context, entity string, msgs []Message) error {
+	if entity == "" {
+		return errors.New("entity is empty")
+	}
+	if len(msgs) == 0 {
+		return errors.New("messages are empty")
+	}
+	span, _ := trace.ChildSpan(ctx, trace.ComponentOpName(senderComponent, entity), senderComponent, ext.SpanKindProducer)
+
+	batch := make([]batchMessage, 0, len(msgs))
+	for _, msg := range msgs {
+		props, err := properties(ctx, span, msg.Properties)
+		if err != nil {
+			log.FromContext(ctx).Errorf("failed to inject trace headers: %v", err)
+		}
+		batch = append(batch, batchMessage{Body: string(msg.Body), BrokerProperties: msg.BrokerProperties, UserProperties: props})
+	}
+	body, err := json.Marshal(batch)
+	if err != nil {
+		trace.SpanError(span)
+		return fmt.Errorf("failed to encode batch: %w", err)
+	}
+	if len(body) > maxBatchSize {
+		trace.SpanError(span)
+		return fmt.Errorf("batch size of %d bytes exceeds the limit of %d bytes", len(body), maxBatchSize)
+	}
+
+	hdr := http.Header{}
+	hdr.Set("Content-Type", batchContentType)
+	err = s.send(ctx, span, entity, hdr, body)
+	if err != nil {
+		return fmt.Errorf("failed to send batch: %w", err)
+	}
+	return nil
+}
+
+func (s *Sender) send(ctx context.Context, span opentracing.Span, entity string, hdr http.Header, body []byte) error {
+	start := time.Now()
+	rsp, err := s.ns.Do(ctx, http.MethodPost, s.ns.URL(entity+"/messages"), hdr, bytes.NewReader(body), http.StatusCreated)
+	if err == nil {
+		err = rsp.Body.Close()
+	}
+	trace.SpanComplete(span, err)
+	durationHistogram := trace.Histogram{
+		Observer: sendDurationMetrics.WithLabelValues(entity, strconv.FormatBool(err == nil)),
+	}
+	durationHistogram.Observe(ctx, time.Since(start).Seconds())
+	return err
+}
+
+// properties returns the custom properties of the message along with the opentracing headers and the correlation.HeaderID, if it's not set already.
+func properties(ctx context.Context, span opentracing.Span, props map[string]string) (map[string]string, error) {
+	carrier := make(propagation.MapCarrier, len(props)+2)
+	for k, v := range props {
+		carrier[k] = v
+	}
+	if err := propagation.Inject(ctx, span, carrier); err != nil {
+		return carrier, fmt.Errorf("failed to inject tracing headers: %w", err)
+	}
+	return carrier, nil
+}
@@ -0,0 +1,107 @@
+package servicebus
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/beatlabs/patron/correlation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		connectionString string
+		options          []OptionFunc
+		expectedErr      string
+	}{
+		"success":            {connectionString: "Endpoint=sb://ns/;SharedAccessKeyName=root;SharedAccessKey=key", options: []OptionFunc{Client(&http.Client{})}},
+		"invalid connection": {expectedErr: "connection string is empty"},
+		"invalid client":     {connectionString: "Endpoint=sb://ns/;SharedAccessKeyName=root;SharedAccessKey=key", options: []OptionFunc{Client(nil)}, expectedErr: "client is nil"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			got, err := New(tt.connectionString, tt.options...)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, got)
+			}
+		})
+	}
+}
+
+func TestSender_Send(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		if r.URL.Path == "/missing/messages" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		assert.Equal(t, "/orders/messages", r.URL.Path)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.Equal(t, `{"MessageId":"1","SessionId":"session"}`, r.Header.Get("BrokerProperties"))
+		assert.Equal(t, `"value"`, r.Header.Get("Custom"))
+		assert.Equal(t, `"123"`, r.Header.Get(correlation.HeaderID))
+		body, err := ioutil.ReadAll(r.Body)
+		assert.NoError(t, err)
+		assert.Equal(t, `{"id":1}`, string(body))
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer srv.Close()
+	s, err := New("Endpoint=" + srv.URL + ";SharedAccessKeyName=root;SharedAccessKey=key")
+	require.NoError(t, err)
+	ctx := correlation.ContextWithID(context.Background(), "123")
+	msg := Message{
+		Body:             []byte(`{"id":1}`),
+		ContentType:      "application/json",
+		Properties:       map[string]string{"Custom": "value"},
+		BrokerProperties: BrokerProperties{MessageID: "1", SessionID: "session"},
+	}
+
+	assert.NoError(t, s.Send(ctx, "orders", msg))
+	assert.EqualError(t, s.Send(ctx, "missing", msg), "failed to send message: unexpected status 404")
+	assert.EqualError(t, s.Send(ctx, "", msg), "entity is empty")
+}
+
+func TestSender_SendBatch(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/orders/messages", r.URL.Path)
+		assert.Equal(t, batchContentType, r.Header.Get("Content-Type"))
+		var batch []batchMessage
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&batch))
+		require.Len(t, batch, 2)
+		assert.Equal(t, "first", batch[0].Body)
+		assert.Equal(t, "1", batch[0].BrokerProperties.MessageID)
+		assert.Equal(t, "value", batch[0].UserProperties["Custom"])
+		assert.Equal(t, "123", batch[0].UserProperties[correlation.HeaderID])
+		assert.Equal(t, "second", batch[1].Body)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer srv.Close()
+	s, err := New("Endpoint=" + srv.URL + ";SharedAccessKeyName=root;SharedAccessKey=key")
+	require.NoError(t, err)
+	ctx := correlation.ContextWithID(context.Background(), "123")
+	msgs := []Message{
+		{Body: []byte("first"), Properties: map[string]string{"Custom": "value"}, BrokerProperties: BrokerProperties{MessageID: "1"}},
+		{Body: []byte("second")},
+	}
+
+	assert.NoError(t, s.SendBatch(ctx, "orders", msgs))
+	assert.EqualError(t, s.SendBatch(ctx, "orders", nil), "messages are empty")
+	assert.EqualError(t, s.SendBatch(ctx, "", msgs), "entity is empty")
+	tooLarge := []Message{{Body: []byte(strings.Repeat("a", maxBatchSize))}}
+	assert.Error(t, s.SendBatch(ctx, "orders", tooLarge))
+}
@@ -0,0 +1,35 @@
+package sftp
+
+import (
+	"errors"
+	"time"
+)
+
+// OptionFunc definition for configuring the client in a functional way.
+type OptionFunc func(*Client) error
+
+// PoolSize sets the maximum number of concurrent sessions to the server.
+func PoolSize(size int) OptionFunc {
+	return func(c *Client) error {
+		if size <= 0 {
+			return errors.New("pool size should be positive")
+		}
+		c.poolSize = size
+		return nil
+	}
+}
+
+// Retries sets the number of times a failed operation is retried, and the backoff between the attempts,
+// which doubles after every attempt up to the max backoff.
+func Retries(count uint, backoff, maxBackoff time.Duration) OptionFunc {
+	return func(c *Client) error {
+		if backoff <= 0 {
+			return errors.New("backoff should be positive")
+		}
+		if maxBackoff < backoff {
+			return errors.New("max backoff should not be less than backoff")
+		}
+		c.retryCfg = retryConfig{count: count, backoff: backoff, maxBackoff: maxBackoff}
+		return nil
+	}
+}
@@ -0,0 +1,43 @@
+package sftp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPoolSize(t *testing.T) {
+	t.Parallel()
+	c := &Client{}
+	assert.NoError(t, PoolSize(8)(c))
+	assert.Equal(t, 8, c.poolSize)
+	assert.EqualError(t, PoolSize(-1)(c), "pool size should be positive")
+}
+
+func TestRetries(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		backoff     time.Duration
+		maxBackoff  time.Duration
+		expectedErr string
+	}{
+		"success":             {backoff: time.Second, maxBackoff: time.Minute},
+		"zero backoff":        {backoff: 0, maxBackoff: time.Minute, expectedErr: "backoff should be positive"},
+		"max backoff too low": {backoff: time.Second, maxBackoff: time.Millisecond, expectedErr: "max backoff should not be less than backoff"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			c := &Client{}
+			err := Retries(5, tt.backoff, tt.maxBackoff)(c)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, retryConfig{count: 5, backoff: tt.backoff, maxBackoff: tt.maxBackoff}, c.retryCfg)
+			}
+		})
+	}
+}
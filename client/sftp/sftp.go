@@ -0,0 +1,356 @@
+// Package sftp provides an instrumented SFTP client, which pools sessions, retries failed operations with exponential backoff
+// and resumes interrupted transfers. The client is agnostic of the SFTP implementation e.g. github.com/pkg/sftp,
+// which is provided by a DialFunc.
+package sftp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/beatlabs/patron/reliability/retry"
+	"github.com/beatlabs/patron/trace"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	component = "sftp-client"
+
+	defaultPoolSize   = 4
+	defaultRetries    = 3
+	defaultBackoff    = 100 * time.Millisecond
+	defaultMaxBackoff = 5 * time.Second
+)
+
+var (
+	// ErrClosed is returned for operations on a closed client.
+	ErrClosed = errors.New("client is closed")
+
+	operationDurationMetrics *prometheus.HistogramVec
+)
+
+func init() {
+	operationDurationMetrics = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "client",
+			Subsystem: "sftp",
+			Name:      "operation_duration_seconds",
+			Help:      "SFTP operations completed by the client, including their retries.",
+		},
+		[]string{"operation", "success"},
+	)
+	prometheus.MustRegister(operationDurationMetrics)
+}
+
+// File is a remote file opened in a session.
+type File interface {
+	io.Reader
+	io.Writer
+	io.Seeker
+	io.Closer
+}
+
+// Session is an SFTP session over an SSH connection, e.g. an adapter of a github.com/pkg/sftp client.
+// Errors about missing files and permissions should wrap os.ErrNotExist and os.ErrPermission,
+// in order not to be retried.
+type Session interface {
+	// OpenFile opens the file with the provided flags, e.g. os.O_WRONLY|os.O_CREATE.
+	OpenFile(path string, flag int) (File, error)
+	Stat(path string) (os.FileInfo, error)
+	ReadDir(path string) ([]os.FileInfo, error)
+	Rename(oldPath, newPath string) error
+	Remove(path string) error
+	Close() error
+}
+
+// DialFunc opens a new session to the server.
+type DialFunc func(ctx context.Context) (Session, error)
+
+type retryConfig struct {
+	count      uint
+	backoff    time.Duration
+	maxBackoff time.Duration
+}
+
+// Client is an SFTP client, pooling the sessions to a server.
+type Client struct {
+	addr     string
+	dial     DialFunc
+	poolSize int
+	retryCfg retryConfig
+
+	// idle holds the sessions available for reuse and slots the sessions which are open
+	idle  chan Session
+	slots chan struct{}
+
+	mu     sync.Mutex
+	closed bool
+}
+
+// New creates a client for the server of the provided address, which opens up to 4 concurrent sessions with the dial func by default.
+// Failed operations are retried 3 times, with an exponential backoff starting at 100ms and capped at 5s.
+func New(addr string, dial DialFunc, oo ...OptionFunc) (*Client, error) {
+	if addr == "" {
+		return nil, errors.New("address is empty")
+	}
+	if dial == nil {
+		return nil, errors.New("dial func is nil")
+	}
+
+	c := &Client{
+		addr:     addr,
+		dial:     dial,
+		poolSize: defaultPoolSize,
+		retryCfg: retryConfig{
+			count:      defaultRetries,
+			backoff:    defaultBackoff,
+			maxBackoff: defaultMaxBackoff,
+		},
+	}
+
+	for _, optionFunc := range oo {
+		if err := optionFunc(c); err != nil {
+			return nil, err
+		}
+	}
+
+	c.idle = make(chan Session, c.poolSize)
+	c.slots = make(chan struct{}, c.poolSize)
+	return c, nil
+}
+
+// Upload writes the content of src to the remote path, replacing any existing file.
+// If the transfer is interrupted, the retries resume it from the size of the remote file.
+func (c *Client) Upload(ctx context.Context, path string, src io.ReadSeeker) error {
+	truncated := false
+	return c.do(ctx, "upload", path, true, func(s Session) error {
+		flag := os.O_WRONLY | os.O_CREATE
+		var offset int64
+		if !truncated {
+			flag |= os.O_TRUNC
+		} else {
+			fi, err := s.Stat(path)
+			if err != nil && !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+			if err == nil {
+				offset = fi.Size()
+			}
+		}
+
+		f, err := s.OpenFile(path, flag)
+		if err != nil {
+			return err
+		}
+		truncated = true
+
+		if err := transfer(f, src, offset); err != nil {
+			_ = f.Close()
+			return err
+		}
+		return f.Close()
+	})
+}
+
+func transfer(f File, src io.ReadSeeker, offset int64) error {
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+	if _, err := src.Seek(offset, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to seek source: %w", err)
+	}
+	_, err := io.Copy(f, src)
+	return err
+}
+
+// Download writes the content of the remote file to dst.
+// If the transfer is interrupted, the retries resume it from the bytes already written to dst.
+func (c *Client) Download(ctx context.Context, path string, dst io.Writer) error {
+	var written int64
+	return c.do(ctx, "download", path, true, func(s Session) error {
+		f, err := s.OpenFile(path, os.O_RDONLY)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = f.Close()
+		}()
+
+		if _, err := f.Seek(written, io.SeekStart); err != nil {
+			return err
+		}
+		n, err := io.Copy(dst, f)
+		written += n
+		return err
+	})
+}
+
+// Stat returns the info of the remote file.
+func (c *Client) Stat(ctx context.Context, path string) (os.FileInfo, error) {
+	var fi os.FileInfo
+	err := c.do(ctx, "stat", path, true, func(s Session) error {
+		var err error
+		fi, err = s.Stat(path)
+		return err
+	})
+	return fi, err
+}
+
+// ReadDir returns the info of the files in the remote directory.
+func (c *Client) ReadDir(ctx context.Context, path string) ([]os.FileInfo, error) {
+	var ff []os.FileInfo
+	err := c.do(ctx, "readdir", path, true, func(s Session) error {
+		var err error
+		ff, err = s.ReadDir(path)
+		return err
+	})
+	return ff, err
+}
+
+// Rename renames the remote file.
+func (c *Client) Rename(ctx context.Context, oldPath, newPath string) error {
+	return c.do(ctx, "rename", oldPath, true, func(s Session) error {
+		return s.Rename(oldPath, newPath)
+	})
+}
+
+// Remove removes the remote file.
+func (c *Client) Remove(ctx context.Context, path string) error {
+	return c.do(ctx, "remove", path, true, func(s Session) error {
+		return s.Remove(path)
+	})
+}
+
+// Ping checks the health of the server by stating its working directory, without retries,
+// e.g. as part of a readiness check.
+func (c *Client) Ping(ctx context.Context) error {
+	return c.do(ctx, "ping", ".", false, func(s Session) error {
+		_, err := s.Stat(".")
+		return err
+	})
+}
+
+// Close closes the idle sessions. Sessions in use are closed once their operations complete.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	c.closed = true
+	c.mu.Unlock()
+
+	var err error
+	for {
+		select {
+		case s := <-c.idle:
+			<-c.slots
+			if closeErr := s.Close(); closeErr != nil {
+				err = closeErr
+			}
+		default:
+			return err
+		}
+	}
+}
+
+// do executes the operation on a pooled session in a span, retrying on failure unless the file is missing or not permitted.
+func (c *Client) do(ctx context.Context, op, path string, retries bool, fn func(Session) error) error {
+	sp, ctx := trace.ChildSpan(ctx, trace.ComponentOpName(component, op), component, ext.SpanKindRPCClient,
+		opentracing.Tag{Key: "address", Value: c.addr}, opentracing.Tag{Key: "path", Value: path})
+	start := time.Now()
+
+	var err error
+	attempt := 0
+	backoff := c.retryCfg.backoff
+	for {
+		attempt++
+		err = c.attempt(ctx, fn)
+		retry.ObserveAttempt(sp, component, attempt, err)
+		if err == nil || !retries || !retryable(err) || uint(attempt) > c.retryCfg.count {
+			break
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(backoff):
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		backoff *= 2
+		if backoff > c.retryCfg.maxBackoff {
+			backoff = c.retryCfg.maxBackoff
+		}
+	}
+
+	retry.ObserveAttempts(sp, attempt)
+	operationDurationMetrics.WithLabelValues(op, strconv.FormatBool(err == nil)).Observe(time.Since(start).Seconds())
+	trace.SpanComplete(sp, err)
+	if err != nil {
+		return fmt.Errorf("failed to %s %s: %w", op, path, err)
+	}
+	return nil
+}
+
+func (c *Client) attempt(ctx context.Context, fn func(Session) error) error {
+	s, err := c.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	err = fn(s)
+	c.release(s, err)
+	return err
+}
+
+// acquire returns an idle session, or dials a new one if the pool is not full, or else waits for a session to be released.
+func (c *Client) acquire(ctx context.Context) (Session, error) {
+	c.mu.Lock()
+	closed := c.closed
+	c.mu.Unlock()
+	if closed {
+		return nil, ErrClosed
+	}
+
+	select {
+	case s := <-c.idle:
+		return s, nil
+	default:
+	}
+
+	select {
+	case s := <-c.idle:
+		return s, nil
+	case c.slots <- struct{}{}:
+		s, err := c.dial(ctx)
+		if err != nil {
+			<-c.slots
+			return nil, fmt.Errorf("failed to dial: %w", err)
+		}
+		return s, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// release returns the session to the pool, unless the operation failed for reasons other than the file,
+// which might have left the session broken, or the client is closed.
+func (c *Client) release(s Session, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed || (err != nil && retryable(err)) {
+		_ = s.Close()
+		<-c.slots
+		return
+	}
+	// the pool has capacity for all open sessions, so this never blocks
+	c.idle <- s
+}
+
+func retryable(err error) bool {
+	return !errors.Is(err, os.ErrNotExist) && !errors.Is(err, os.ErrPermission) && !errors.Is(err, ErrClosed) &&
+		!errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+}
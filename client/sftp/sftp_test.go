@@ -0,0 +1,356 @@
+package sftp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeServer holds the files of the sessions it dials, failing the transfers after the configured number of bytes.
+type fakeServer struct {
+	sync.Mutex
+	files     map[string][]byte
+	dials     int
+	closed    int
+	dialErr   error
+	failAfter int
+	failures  int
+}
+
+func newFakeServer() *fakeServer {
+	return &fakeServer{files: make(map[string][]byte)}
+}
+
+func (fs *fakeServer) dial(context.Context) (Session, error) {
+	fs.Lock()
+	defer fs.Unlock()
+	if fs.dialErr != nil {
+		return nil, fs.dialErr
+	}
+	fs.dials++
+	return &fakeSession{fs: fs}, nil
+}
+
+// failing returns true if the transfer should fail.
+func (fs *fakeServer) failing(transferred int) bool {
+	fs.Lock()
+	defer fs.Unlock()
+	if fs.failures > 0 && transferred >= fs.failAfter {
+		fs.failures--
+		return true
+	}
+	return false
+}
+
+type fakeSession struct {
+	fs *fakeServer
+}
+
+func (s *fakeSession) OpenFile(path string, flag int) (File, error) {
+	s.fs.Lock()
+	defer s.fs.Unlock()
+	_, ok := s.fs.files[path]
+	if !ok && flag&os.O_CREATE == 0 {
+		return nil, os.ErrNotExist
+	}
+	if !ok || flag&os.O_TRUNC != 0 {
+		s.fs.files[path] = nil
+	}
+	return &fakeFile{fs: s.fs, path: path}, nil
+}
+
+func (s *fakeSession) Stat(path string) (os.FileInfo, error) {
+	s.fs.Lock()
+	defer s.fs.Unlock()
+	data, ok := s.fs.files[path]
+	if !ok && path != "." {
+		return nil, os.ErrNotExist
+	}
+	return fakeFileInfo{name: path, size: int64(len(data))}, nil
+}
+
+func (s *fakeSession) ReadDir(string) ([]os.FileInfo, error) {
+	s.fs.Lock()
+	defer s.fs.Unlock()
+	ff := make([]os.FileInfo, 0, len(s.fs.files))
+	for name, data := range s.fs.files {
+		ff = append(ff, fakeFileInfo{name: name, size: int64(len(data))})
+	}
+	return ff, nil
+}
+
+func (s *fakeSession) Rename(oldPath, newPath string) error {
+	s.fs.Lock()
+	defer s.fs.Unlock()
+	data, ok := s.fs.files[oldPath]
+	if !ok {
+		return os.ErrNotExist
+	}
+	delete(s.fs.files, oldPath)
+	s.fs.files[newPath] = data
+	return nil
+}
+
+func (s *fakeSession) Remove(path string) error {
+	s.fs.Lock()
+	defer s.fs.Unlock()
+	if _, ok := s.fs.files[path]; !ok {
+		return os.ErrNotExist
+	}
+	delete(s.fs.files, path)
+	return nil
+}
+
+func (s *fakeSession) Close() error {
+	s.fs.Lock()
+	defer s.fs.Unlock()
+	s.fs.closed++
+	return nil
+}
+
+// fakeFile transfers a byte per read or write.
+type fakeFile struct {
+	fs          *fakeServer
+	path        string
+	pos         int64
+	transferred int
+}
+
+func (f *fakeFile) Read(p []byte) (int, error) {
+	if f.fs.failing(f.transferred) {
+		return 0, errors.New("connection lost")
+	}
+	f.fs.Lock()
+	defer f.fs.Unlock()
+	data := f.fs.files[f.path]
+	if f.pos >= int64(len(data)) {
+		return 0, io.EOF
+	}
+	p[0] = data[f.pos]
+	f.pos++
+	f.transferred++
+	return 1, nil
+}
+
+func (f *fakeFile) Write(p []byte) (int, error) {
+	for i := range p {
+		if f.fs.failing(f.transferred) {
+			return i, errors.New("connection lost")
+		}
+		f.fs.Lock()
+		data := f.fs.files[f.path]
+		if f.pos < int64(len(data)) {
+			data[f.pos] = p[i]
+		} else {
+			data = append(data, p[i])
+		}
+		f.fs.files[f.path] = data
+		f.fs.Unlock()
+		f.pos++
+		f.transferred++
+	}
+	return len(p), nil
+}
+
+func (f *fakeFile) Seek(offset int64, whence int) (int64, error) {
+	if whence != io.SeekStart {
+		return 0, errors.New("unsupported whence")
+	}
+	f.pos = offset
+	return offset, nil
+}
+
+func (f *fakeFile) Close() error {
+	return nil
+}
+
+type fakeFileInfo struct {
+	name string
+	size int64
+}
+
+func (fi fakeFileInfo) Name() string       { return fi.name }
+func (fi fakeFileInfo) Size() int64        { return fi.size }
+func (fi fakeFileInfo) Mode() os.FileMode  { return 0o600 }
+func (fi fakeFileInfo) ModTime() time.Time { return time.Time{} }
+func (fi fakeFileInfo) IsDir() bool        { return false }
+func (fi fakeFileInfo) Sys() interface{}   { return nil }
+
+func newTestClient(t *testing.T, fs *fakeServer, oo ...OptionFunc) *Client {
+	oo = append([]OptionFunc{Retries(3, time.Millisecond, time.Millisecond)}, oo...)
+	c, err := New("localhost:22", fs.dial, oo...)
+	require.NoError(t, err)
+	return c
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+	dial := newFakeServer().dial
+	tests := map[string]struct {
+		addr        string
+		dial        DialFunc
+		oo          []OptionFunc
+		expectedErr string
+	}{
+		"success":        {addr: "localhost:22", dial: dial, oo: []OptionFunc{PoolSize(2)}},
+		"missing addr":   {dial: dial, expectedErr: "address is empty"},
+		"missing dial":   {addr: "localhost:22", expectedErr: "dial func is nil"},
+		"option failure": {addr: "localhost:22", dial: dial, oo: []OptionFunc{PoolSize(0)}, expectedErr: "pool size should be positive"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			got, err := New(tt.addr, tt.dial, tt.oo...)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, got)
+			}
+		})
+	}
+}
+
+func TestClient_Upload(t *testing.T) {
+	t.Parallel()
+	t.Run("replaces existing file", func(t *testing.T) {
+		t.Parallel()
+		fs := newFakeServer()
+		fs.files["/data.csv"] = []byte("a much longer existing content")
+		c := newTestClient(t, fs)
+
+		require.NoError(t, c.Upload(context.Background(), "/data.csv", strings.NewReader("content")))
+		assert.Equal(t, "content", string(fs.files["/data.csv"]))
+	})
+
+	t.Run("resumes interrupted transfer", func(t *testing.T) {
+		t.Parallel()
+		fs := newFakeServer()
+		fs.failAfter, fs.failures = 3, 2
+		c := newTestClient(t, fs)
+
+		require.NoError(t, c.Upload(context.Background(), "/data.csv", strings.NewReader("0123456789")))
+		assert.Equal(t, "0123456789", string(fs.files["/data.csv"]))
+		// the broken sessions are discarded
+		assert.Equal(t, 3, fs.dials)
+		assert.Equal(t, 2, fs.closed)
+	})
+
+	t.Run("fails after retries", func(t *testing.T) {
+		t.Parallel()
+		fs := newFakeServer()
+		fs.failAfter, fs.failures = 0, 10
+		c := newTestClient(t, fs)
+
+		err := c.Upload(context.Background(), "/data.csv", strings.NewReader("0123456789"))
+		assert.EqualError(t, err, "failed to upload /data.csv: connection lost")
+		assert.Equal(t, 4, fs.dials)
+	})
+}
+
+func TestClient_Download(t *testing.T) {
+	t.Parallel()
+	t.Run("resumes interrupted transfer", func(t *testing.T) {
+		t.Parallel()
+		fs := newFakeServer()
+		fs.files["/data.csv"] = []byte("0123456789")
+		fs.failAfter, fs.failures = 4, 2
+		c := newTestClient(t, fs)
+
+		var buf bytes.Buffer
+		require.NoError(t, c.Download(context.Background(), "/data.csv", &buf))
+		assert.Equal(t, "0123456789", buf.String())
+	})
+
+	t.Run("missing file is not retried", func(t *testing.T) {
+		t.Parallel()
+		fs := newFakeServer()
+		c := newTestClient(t, fs)
+
+		err := c.Download(context.Background(), "/missing.csv", &bytes.Buffer{})
+		assert.True(t, errors.Is(err, os.ErrNotExist))
+		// the session is still healthy and reused
+		assert.Equal(t, 1, fs.dials)
+		assert.Zero(t, fs.closed)
+		assert.Len(t, c.idle, 1)
+	})
+}
+
+func TestClient_Operations(t *testing.T) {
+	t.Parallel()
+	fs := newFakeServer()
+	fs.files["/a.csv"] = []byte("a")
+	c := newTestClient(t, fs)
+	ctx := context.Background()
+
+	fi, err := c.Stat(ctx, "/a.csv")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), fi.Size())
+
+	require.NoError(t, c.Rename(ctx, "/a.csv", "/b.csv"))
+	ff, err := c.ReadDir(ctx, "/")
+	require.NoError(t, err)
+	require.Len(t, ff, 1)
+	assert.Equal(t, "/b.csv", ff[0].Name())
+
+	require.NoError(t, c.Remove(ctx, "/b.csv"))
+	_, err = c.Stat(ctx, "/b.csv")
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+
+	// all operations share a single session
+	assert.Equal(t, 1, fs.dials)
+}
+
+func TestClient_Ping(t *testing.T) {
+	t.Parallel()
+	fs := newFakeServer()
+	c := newTestClient(t, fs)
+	assert.NoError(t, c.Ping(context.Background()))
+
+	fs.dialErr = errors.New("connection refused")
+	require.NoError(t, c.Close())
+	c = newTestClient(t, fs)
+	assert.EqualError(t, c.Ping(context.Background()), "failed to ping .: failed to dial: connection refused")
+}
+
+func TestClient_Pool(t *testing.T) {
+	t.Parallel()
+	fs := newFakeServer()
+	c := newTestClient(t, fs, PoolSize(2))
+	ctx := context.Background()
+
+	s1, err := c.acquire(ctx)
+	require.NoError(t, err)
+	s2, err := c.acquire(ctx)
+	require.NoError(t, err)
+
+	// the pool is exhausted
+	ctxTimeout, cnl := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cnl()
+	_, err = c.acquire(ctxTimeout)
+	assert.Equal(t, context.DeadlineExceeded, err)
+
+	c.release(s1, nil)
+	s3, err := c.acquire(ctx)
+	require.NoError(t, err)
+	assert.Same(t, s1, s3)
+	assert.Equal(t, 2, fs.dials)
+
+	c.release(s2, nil)
+	c.release(s3, nil)
+	require.NoError(t, c.Close())
+	assert.Equal(t, 2, fs.closed)
+	_, err = c.acquire(ctx)
+	assert.Equal(t, ErrClosed, err)
+}
@@ -12,7 +12,9 @@ import (
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/sns"
 	"github.com/aws/aws-sdk-go/service/sns/snsiface"
+	"github.com/beatlabs/patron/internal/awsretry"
 	"github.com/beatlabs/patron/log"
+	"github.com/beatlabs/patron/propagation"
 	"github.com/beatlabs/patron/trace"
 	"github.com/opentracing/opentracing-go"
 	"github.com/opentracing/opentracing-go/ext"
@@ -20,8 +22,6 @@ import (
 )
 
 const (
-	attributeDataTypeString = "String"
-
 	publisherComponent = "sns-publisher"
 
 	tracingTargetUnknown   = "unknown"
@@ -62,12 +62,12 @@ func New(api snsiface.SNSAPI) (Publisher, error) {
 func (p Publisher) Publish(ctx context.Context, input *sns.PublishInput) (messageID string, err error) {
 	span, _ := trace.ChildSpan(ctx, trace.ComponentOpName(publisherComponent, tracingTarget(input)), publisherComponent, ext.SpanKindProducer)
 
-	if err := injectHeaders(span, input); err != nil {
+	if err := injectHeaders(ctx, span, input); err != nil {
 		log.FromContext(ctx).Warnf("failed to inject tracing header: %v", err)
 	}
 
 	start := time.Now()
-	out, err := p.api.PublishWithContext(ctx, input)
+	out, err := p.api.PublishWithContext(ctx, input, awsretry.Observe(span, publisherComponent))
 	if input.TopicArn != nil {
 		observePublish(ctx, span, start, *input.TopicArn, err)
 	}
@@ -85,13 +85,6 @@ func (p Publisher) Publish(ctx context.Context, input *sns.PublishInput) (messag
 	return *out.MessageId, nil
 }
 
-type snsHeadersCarrier map[string]interface{}
-
-// Set implements Set() of opentracing.TextMapWriter.
-func (c snsHeadersCarrier) Set(key, val string) {
-	c[key] = val
-}
-
 func tracingTarget(input *sns.PublishInput) string {
 	if input.TopicArn != nil {
 		return fmt.Sprintf("%s:%s", tracingTargetTopicArn, aws.StringValue(input.TopicArn))
@@ -104,27 +97,14 @@ func tracingTarget(input *sns.PublishInput) string {
 	return tracingTargetUnknown
 }
 
-// injectHeaders injects the SNS headers carrier's headers into the message's attributes.
-func injectHeaders(span opentracing.Span, input *sns.PublishInput) error {
+// injectHeaders injects opentracing headers and the correlation.HeaderID, if it's not set already, into the message's attributes.
+func injectHeaders(ctx context.Context, span opentracing.Span, input *sns.PublishInput) error {
 	if input.MessageAttributes == nil {
 		input.MessageAttributes = make(map[string]*sns.MessageAttributeValue)
 	}
-
-	carrier := snsHeadersCarrier{}
-	if err := span.Tracer().Inject(span.Context(), opentracing.TextMap, &carrier); err != nil {
+	if err := propagation.Inject(ctx, span, propagation.SNSAttributes(input.MessageAttributes)); err != nil {
 		return fmt.Errorf("failed to inject tracing headers: %w", err)
 	}
-
-	for k, v := range carrier {
-		val, ok := v.(string)
-		if !ok {
-			return errors.New("failed to type assert string")
-		}
-		input.MessageAttributes[k] = &sns.MessageAttributeValue{
-			DataType:    aws.String(attributeDataTypeString),
-			StringValue: aws.String(val),
-		}
-	}
 	return nil
 }
 
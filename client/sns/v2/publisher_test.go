@@ -13,6 +13,7 @@ import (
 	"github.com/aws/aws-sdk-go/service/sns"
 	"github.com/aws/aws-sdk-go/service/sns/snsiface"
 	"github.com/beatlabs/patron/log"
+	"github.com/beatlabs/patron/reliability/retry"
 	"github.com/opentracing/opentracing-go"
 	"github.com/opentracing/opentracing-go/mocktracer"
 	"github.com/stretchr/testify/assert"
@@ -88,6 +89,24 @@ func Test_Publisher_Publish(t *testing.T) {
 	}
 }
 
+func Test_Publisher_Publish_ObservesAttempts(t *testing.T) {
+	mtr := mocktracer.New()
+	defer mtr.Reset()
+	opentracing.SetGlobalTracer(mtr)
+
+	p, err := New(&retryingSNSAPI{output: (&sns.PublishOutput{}).SetMessageId("msgID"), errs: []error{errors.New("throttled"), nil}})
+	require.NoError(t, err)
+
+	msgID, err := p.Publish(context.Background(), &sns.PublishInput{TopicArn: aws.String("123")})
+	require.NoError(t, err)
+	assert.Equal(t, "msgID", msgID)
+
+	spans := mtr.FinishedSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, 2, spans[0].Tag(retry.AttemptsTag))
+	assert.Len(t, spans[0].Logs(), 2)
+}
+
 type stubSNSAPI struct {
 	snsiface.SNSAPI // Implement the interface's methods without defining all of them (just override what we need)
 
@@ -103,6 +122,25 @@ func (s *stubSNSAPI) PublishWithContext(_ context.Context, _ *sns.PublishInput,
 	return s.output, s.err
 }
 
+// retryingSNSAPI runs the request handlers of the options like the SDK does, with an attempt for each of the errors.
+type retryingSNSAPI struct {
+	snsiface.SNSAPI
+
+	output *sns.PublishOutput
+	errs   []error
+}
+
+func (s *retryingSNSAPI) PublishWithContext(_ context.Context, _ *sns.PublishInput, opts ...request.Option) (*sns.PublishOutput, error) {
+	r := &request.Request{}
+	r.ApplyOptions(opts...)
+	for _, err := range s.errs {
+		r.Error = err
+		r.Handlers.CompleteAttempt.Run(r)
+	}
+	r.Handlers.Complete.Run(r)
+	return s.output, r.Error
+}
+
 func ExamplePublisher() {
 	// Create the SNS API with the required config, credentials, etc.
 	sess, err := session.NewSession(
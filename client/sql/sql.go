@@ -9,6 +9,7 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/beatlabs/patron/dependency"
 	"github.com/beatlabs/patron/trace"
 	"github.com/opentracing/opentracing-go"
 	"github.com/opentracing/opentracing-go/ext"
@@ -75,7 +76,7 @@ func (c *Conn) BeginTx(ctx context.Context, opts *sql.TxOptions) (*Tx, error) {
 	sp, _ := c.startSpan(ctx, op, "")
 	start := time.Now()
 	tx, err := c.conn.BeginTx(ctx, opts)
-	observeDuration(ctx, sp, start, op, err)
+	c.observeDuration(ctx, sp, start, op, err)
 	if err != nil {
 		return nil, err
 	}
@@ -89,7 +90,7 @@ func (c *Conn) Close(ctx context.Context) error {
 	sp, _ := c.startSpan(ctx, op, "")
 	start := time.Now()
 	err := c.conn.Close()
-	observeDuration(ctx, sp, start, op, err)
+	c.observeDuration(ctx, sp, start, op, err)
 	return err
 }
 
@@ -99,7 +100,7 @@ func (c *Conn) Exec(ctx context.Context, query string, args ...interface{}) (sql
 	sp, _ := c.startSpan(ctx, op, query)
 	start := time.Now()
 	res, err := c.conn.ExecContext(ctx, query, args...)
-	observeDuration(ctx, sp, start, op, err)
+	c.observeDuration(ctx, sp, start, op, err)
 	return res, err
 }
 
@@ -109,7 +110,7 @@ func (c *Conn) Ping(ctx context.Context) error {
 	sp, _ := c.startSpan(ctx, op, "")
 	start := time.Now()
 	err := c.conn.PingContext(ctx)
-	observeDuration(ctx, sp, start, op, err)
+	c.observeDuration(ctx, sp, start, op, err)
 	return err
 }
 
@@ -119,7 +120,7 @@ func (c *Conn) Prepare(ctx context.Context, query string) (*Stmt, error) {
 	sp, _ := c.startSpan(ctx, op, query)
 	start := time.Now()
 	stmt, err := c.conn.PrepareContext(ctx, query)
-	observeDuration(ctx, sp, start, op, err)
+	c.observeDuration(ctx, sp, start, op, err)
 	if err != nil {
 		return nil, err
 	}
@@ -132,7 +133,7 @@ func (c *Conn) Query(ctx context.Context, query string, args ...interface{}) (*s
 	sp, _ := c.startSpan(ctx, op, query)
 	start := time.Now()
 	rows, err := c.conn.QueryContext(ctx, query, args...)
-	observeDuration(ctx, sp, start, op, err)
+	c.observeDuration(ctx, sp, start, op, err)
 	if err != nil {
 		return nil, err
 	}
@@ -146,7 +147,7 @@ func (c *Conn) QueryRow(ctx context.Context, query string, args ...interface{})
 	sp, _ := c.startSpan(ctx, op, query)
 	start := time.Now()
 	row := c.conn.QueryRowContext(ctx, query, args...)
-	observeDuration(ctx, sp, start, op, nil)
+	c.observeDuration(ctx, sp, start, op, nil)
 	return row
 }
 
@@ -191,7 +192,7 @@ func (db *DB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*Tx, error) {
 	sp, _ := db.startSpan(ctx, op, "")
 	start := time.Now()
 	tx, err := db.db.BeginTx(ctx, opts)
-	observeDuration(ctx, sp, start, op, err)
+	db.observeDuration(ctx, sp, start, op, err)
 	if err != nil {
 		return nil, err
 	}
@@ -204,7 +205,7 @@ func (db *DB) Close(ctx context.Context) error {
 	sp, _ := db.startSpan(ctx, op, "")
 	start := time.Now()
 	err := db.db.Close()
-	observeDuration(ctx, sp, start, op, err)
+	db.observeDuration(ctx, sp, start, op, err)
 	return err
 }
 
@@ -214,7 +215,7 @@ func (db *DB) Conn(ctx context.Context) (*Conn, error) {
 	sp, _ := db.startSpan(ctx, op, "")
 	start := time.Now()
 	conn, err := db.db.Conn(ctx)
-	observeDuration(ctx, sp, start, op, err)
+	db.observeDuration(ctx, sp, start, op, err)
 	if err != nil {
 		return nil, err
 	}
@@ -228,7 +229,7 @@ func (db *DB) Driver(ctx context.Context) driver.Driver {
 	sp, _ := db.startSpan(ctx, op, "")
 	start := time.Now()
 	drv := db.db.Driver()
-	observeDuration(ctx, sp, start, op, nil)
+	db.observeDuration(ctx, sp, start, op, nil)
 	return drv
 }
 
@@ -238,7 +239,7 @@ func (db *DB) Exec(ctx context.Context, query string, args ...interface{}) (sql.
 	sp, _ := db.startSpan(ctx, op, query)
 	start := time.Now()
 	res, err := db.db.ExecContext(ctx, query, args...)
-	observeDuration(ctx, sp, start, op, err)
+	db.observeDuration(ctx, sp, start, op, err)
 	if err != nil {
 		return nil, err
 	}
@@ -252,7 +253,7 @@ func (db *DB) Ping(ctx context.Context) error {
 	sp, _ := db.startSpan(ctx, op, "")
 	start := time.Now()
 	err := db.db.PingContext(ctx)
-	observeDuration(ctx, sp, start, op, err)
+	db.observeDuration(ctx, sp, start, op, err)
 	return err
 }
 
@@ -262,7 +263,7 @@ func (db *DB) Prepare(ctx context.Context, query string) (*Stmt, error) {
 	sp, _ := db.startSpan(ctx, op, query)
 	start := time.Now()
 	stmt, err := db.db.PrepareContext(ctx, query)
-	observeDuration(ctx, sp, start, op, err)
+	db.observeDuration(ctx, sp, start, op, err)
 	if err != nil {
 		return nil, err
 	}
@@ -276,7 +277,7 @@ func (db *DB) Query(ctx context.Context, query string, args ...interface{}) (*sq
 	sp, _ := db.startSpan(ctx, op, query)
 	start := time.Now()
 	rows, err := db.db.QueryContext(ctx, query, args...)
-	observeDuration(ctx, sp, start, op, err)
+	db.observeDuration(ctx, sp, start, op, err)
 	if err != nil {
 		return nil, err
 	}
@@ -290,7 +291,7 @@ func (db *DB) QueryRow(ctx context.Context, query string, args ...interface{}) *
 	sp, _ := db.startSpan(ctx, op, query)
 	start := time.Now()
 	row := db.db.QueryRowContext(ctx, query, args...)
-	observeDuration(ctx, sp, start, op, nil)
+	db.observeDuration(ctx, sp, start, op, nil)
 	return row
 }
 
@@ -315,7 +316,7 @@ func (db *DB) Stats(ctx context.Context) sql.DBStats {
 	sp, _ := db.startSpan(ctx, op, "")
 	start := time.Now()
 	stats := db.db.Stats()
-	observeDuration(ctx, sp, start, op, nil)
+	db.observeDuration(ctx, sp, start, op, nil)
 	return stats
 }
 
@@ -332,7 +333,7 @@ func (s *Stmt) Close(ctx context.Context) error {
 	sp, _ := s.startSpan(ctx, op, "")
 	start := time.Now()
 	err := s.stmt.Close()
-	observeDuration(ctx, sp, start, op, err)
+	s.observeDuration(ctx, sp, start, op, err)
 	return err
 }
 
@@ -342,7 +343,7 @@ func (s *Stmt) Exec(ctx context.Context, args ...interface{}) (sql.Result, error
 	sp, _ := s.startSpan(ctx, op, s.query)
 	start := time.Now()
 	res, err := s.stmt.ExecContext(ctx, args...)
-	observeDuration(ctx, sp, start, op, err)
+	s.observeDuration(ctx, sp, start, op, err)
 	if err != nil {
 		return nil, err
 	}
@@ -356,7 +357,7 @@ func (s *Stmt) Query(ctx context.Context, args ...interface{}) (*sql.Rows, error
 	sp, _ := s.startSpan(ctx, op, s.query)
 	start := time.Now()
 	rows, err := s.stmt.QueryContext(ctx, args...)
-	observeDuration(ctx, sp, start, op, err)
+	s.observeDuration(ctx, sp, start, op, err)
 	if err != nil {
 		return nil, err
 	}
@@ -370,7 +371,7 @@ func (s *Stmt) QueryRow(ctx context.Context, args ...interface{}) *sql.Row {
 	sp, _ := s.startSpan(ctx, op, s.query)
 	start := time.Now()
 	row := s.stmt.QueryRowContext(ctx, args...)
-	observeDuration(ctx, sp, start, op, nil)
+	s.observeDuration(ctx, sp, start, op, nil)
 	return row
 }
 
@@ -386,7 +387,7 @@ func (tx *Tx) Commit(ctx context.Context) error {
 	sp, _ := tx.startSpan(ctx, op, "")
 	start := time.Now()
 	err := tx.tx.Commit()
-	observeDuration(ctx, sp, start, op, err)
+	tx.observeDuration(ctx, sp, start, op, err)
 	return err
 }
 
@@ -396,7 +397,7 @@ func (tx *Tx) Exec(ctx context.Context, query string, args ...interface{}) (sql.
 	sp, _ := tx.startSpan(ctx, op, query)
 	start := time.Now()
 	res, err := tx.tx.ExecContext(ctx, query, args...)
-	observeDuration(ctx, sp, start, op, err)
+	tx.observeDuration(ctx, sp, start, op, err)
 	if err != nil {
 		return nil, err
 	}
@@ -410,7 +411,7 @@ func (tx *Tx) Prepare(ctx context.Context, query string) (*Stmt, error) {
 	sp, _ := tx.startSpan(ctx, op, query)
 	start := time.Now()
 	stmt, err := tx.tx.PrepareContext(ctx, query)
-	observeDuration(ctx, sp, start, op, err)
+	tx.observeDuration(ctx, sp, start, op, err)
 	if err != nil {
 		return nil, err
 	}
@@ -424,7 +425,7 @@ func (tx *Tx) Query(ctx context.Context, query string, args ...interface{}) (*sq
 	sp, _ := tx.startSpan(ctx, op, query)
 	start := time.Now()
 	rows, err := tx.tx.QueryContext(ctx, query, args...)
-	observeDuration(ctx, sp, start, op, err)
+	tx.observeDuration(ctx, sp, start, op, err)
 	if err != nil {
 		return nil, err
 	}
@@ -437,7 +438,7 @@ func (tx *Tx) QueryRow(ctx context.Context, query string, args ...interface{}) *
 	sp, _ := tx.startSpan(ctx, op, query)
 	start := time.Now()
 	row := tx.tx.QueryRowContext(ctx, query, args...)
-	observeDuration(ctx, sp, start, op, nil)
+	tx.observeDuration(ctx, sp, start, op, nil)
 	return row
 }
 
@@ -447,7 +448,7 @@ func (tx *Tx) Rollback(ctx context.Context) error {
 	sp, _ := tx.startSpan(ctx, op, "")
 	start := time.Now()
 	err := tx.tx.Rollback()
-	observeDuration(ctx, sp, start, op, err)
+	tx.observeDuration(ctx, sp, start, op, err)
 	return err
 }
 
@@ -457,7 +458,7 @@ func (tx *Tx) Stmt(ctx context.Context, stmt *Stmt) *Stmt {
 	sp, _ := tx.startSpan(ctx, op, stmt.query)
 	start := time.Now()
 	st := &Stmt{stmt: tx.tx.StmtContext(ctx, stmt.stmt), connInfo: tx.connInfo, query: stmt.query}
-	observeDuration(ctx, sp, start, op, nil)
+	tx.observeDuration(ctx, sp, start, op, nil)
 	return st
 }
 
@@ -491,11 +492,13 @@ func parseDSN(dsn string) DSNInfo {
 	return res
 }
 
-func observeDuration(ctx context.Context, span opentracing.Span, start time.Time, op string, err error) {
+func (c *connInfo) observeDuration(ctx context.Context, span opentracing.Span, start time.Time, op string, err error) {
 	trace.SpanComplete(span, err)
 
+	duration := time.Since(start)
 	durationHistogram := trace.Histogram{
 		Observer: opDurationMetrics.WithLabelValues(op, strconv.FormatBool(err == nil)),
 	}
-	durationHistogram.Observe(ctx, time.Since(start).Seconds())
+	durationHistogram.Observe(ctx, duration.Seconds())
+	dependency.Observe(component, c.instance, err == nil, duration)
 }
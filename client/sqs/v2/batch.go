@@ -0,0 +1,315 @@
+package v2
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/beatlabs/patron/internal/awsretry"
+	"github.com/beatlabs/patron/log"
+	"github.com/beatlabs/patron/trace"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// maxBatchEntries is the maximum number of entries of a SendMessageBatch request.
+	maxBatchEntries = 10
+	// maxBatchSize is the maximum size of the payload of a SendMessageBatch request, i.e. the sum of the sizes of its messages.
+	maxBatchSize = 256 * 1024
+
+	defaultBatchRetries = 3
+	defaultBatchBackoff = 100 * time.Millisecond
+
+	fifoSuffix = ".fifo"
+)
+
+var (
+	batchPublishDurationMetrics *prometheus.HistogramVec
+	batchMessagesMetrics        *prometheus.CounterVec
+)
+
+func init() {
+	batchPublishDurationMetrics = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "client",
+			Subsystem: "sqs",
+			Name:      "batch_publish_duration_seconds",
+			Help:      "AWS SQS batch publish requests completed by the client.",
+		},
+		[]string{"queue", "success"},
+	)
+	batchMessagesMetrics = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "client",
+			Subsystem: "sqs",
+			Name:      "batch_messages_total",
+			Help:      "AWS SQS messages of batches classified by status (published, retried or failed).",
+		},
+		[]string{"queue", "status"},
+	)
+	prometheus.MustRegister(batchPublishDurationMetrics, batchMessagesMetrics)
+}
+
+// BatchResult of publishing a batch of messages.
+type BatchResult struct {
+	// MessageIDs maps the IDs of the published entries to the IDs of their messages.
+	MessageIDs map[string]string
+	// Failed maps the IDs of the entries which failed to be published to their errors.
+	Failed map[string]error
+}
+
+// EntryError of an entry which failed to be published, as reported by SQS.
+type EntryError struct {
+	Code        string
+	Message     string
+	SenderFault bool
+}
+
+func (e *EntryError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+// PublishBatch publishes the entries to the queue with the least SendMessageBatch requests, chunking them into batches
+// of up to 10 entries and 256 KiB. Entries without an ID are assigned their index, e.g. "0", as ID.
+// The entries which fail with errors which are not sender faults are retried, according to the BatchRetries option.
+//
+// Every entry of a FIFO queue requires a message group ID. Its deduplication ID defaults to the SHA-256 hash of its body,
+// as with content-based deduplication. Once an entry fails, the entries of its group in the following batches are not published,
+// so that the group is not published further out of order.
+//
+// The result contains the entries published, even if some failed, in which case an error is also returned.
+func (p Publisher) PublishBatch(ctx context.Context, queueURL string, entries []*sqs.SendMessageBatchRequestEntry) (BatchResult, error) {
+	if queueURL == "" {
+		return BatchResult{}, errors.New("queue URL is empty")
+	}
+	if len(entries) == 0 {
+		return BatchResult{}, errors.New("entries are empty")
+	}
+	fifo := strings.HasSuffix(queueURL, fifoSuffix)
+	if err := prepareEntries(entries, fifo); err != nil {
+		return BatchResult{}, err
+	}
+
+	span, _ := trace.ChildSpan(ctx, trace.ComponentOpName(publisherComponent, queueURL), publisherComponent, ext.SpanKindProducer)
+	for _, entry := range entries {
+		if entry.MessageAttributes == nil {
+			entry.MessageAttributes = make(map[string]*sqs.MessageAttributeValue)
+		}
+		if err := injectAttributes(ctx, span, entry.MessageAttributes); err != nil {
+			log.FromContext(ctx).Errorf("failed to inject trace headers: %v", err)
+		}
+	}
+
+	b := &batch{
+		publisher:    p,
+		span:         span,
+		queue:        queueURL,
+		fifo:         fifo,
+		result:       BatchResult{MessageIDs: make(map[string]string), Failed: make(map[string]error)},
+		failedGroups: make(map[string]string),
+	}
+	for _, chunk := range b.chunks(entries) {
+		b.send(ctx, chunk)
+	}
+
+	batchMessagesMetrics.WithLabelValues(queueURL, "published").Add(float64(len(b.result.MessageIDs)))
+	batchMessagesMetrics.WithLabelValues(queueURL, "failed").Add(float64(len(b.result.Failed)))
+
+	var err error
+	if len(b.result.Failed) > 0 {
+		err = fmt.Errorf("failed to publish %d of %d messages", len(b.result.Failed), len(entries))
+	}
+	trace.SpanComplete(span, err)
+	return b.result, err
+}
+
+// prepareEntries assigns the IDs of the entries without one, and the deduplication IDs of the entries of FIFO queues.
+func prepareEntries(entries []*sqs.SendMessageBatchRequestEntry, fifo bool) error {
+	ids := make(map[string]struct{}, len(entries))
+	for i, entry := range entries {
+		if entry == nil {
+			return errors.New("entry is nil")
+		}
+		if entry.MessageBody == nil {
+			return fmt.Errorf("entry %d has no message body", i)
+		}
+		if aws.StringValue(entry.Id) == "" {
+			entry.Id = aws.String(strconv.Itoa(i))
+		}
+		id := *entry.Id
+		if _, ok := ids[id]; ok {
+			return fmt.Errorf("entry ID %s is duplicated", id)
+		}
+		ids[id] = struct{}{}
+
+		if !fifo {
+			continue
+		}
+		if aws.StringValue(entry.MessageGroupId) == "" {
+			return fmt.Errorf("entry %s has no message group ID", id)
+		}
+		if aws.StringValue(entry.MessageDeduplicationId) == "" {
+			sum := sha256.Sum256([]byte(*entry.MessageBody))
+			entry.MessageDeduplicationId = aws.String(hex.EncodeToString(sum[:]))
+		}
+	}
+	return nil
+}
+
+// batch keeps the state of publishing the entries of a PublishBatch call.
+type batch struct {
+	publisher Publisher
+	span      opentracing.Span
+	queue     string
+	fifo      bool
+	result    BatchResult
+	// failedGroups maps the FIFO groups with a failed entry to its ID.
+	failedGroups map[string]string
+}
+
+// chunks splits the entries into chunks, which fit in a single request. The entries exceeding the size of a request on their own fail.
+func (b *batch) chunks(entries []*sqs.SendMessageBatchRequestEntry) [][]*sqs.SendMessageBatchRequestEntry {
+	var chunks [][]*sqs.SendMessageBatchRequestEntry
+	var chunk []*sqs.SendMessageBatchRequestEntry
+	chunkSize := 0
+	for _, entry := range entries {
+		size := entrySize(entry)
+		if size > maxBatchSize {
+			b.fail([]*sqs.SendMessageBatchRequestEntry{entry}, fmt.Errorf("message size of %d bytes exceeds the limit of %d bytes", size, maxBatchSize))
+			continue
+		}
+		if len(chunk) == maxBatchEntries || chunkSize+size > maxBatchSize {
+			chunks = append(chunks, chunk)
+			chunk, chunkSize = nil, 0
+		}
+		chunk = append(chunk, entry)
+		chunkSize += size
+	}
+	if len(chunk) > 0 {
+		chunks = append(chunks, chunk)
+	}
+	return chunks
+}
+
+// send publishes the entries of the chunk, retrying the entries which failed with errors which are not sender faults.
+func (b *batch) send(ctx context.Context, chunk []*sqs.SendMessageBatchRequestEntry) {
+	pending := b.skipFailedGroups(chunk)
+	for attempt := 0; len(pending) > 0; attempt++ {
+		if attempt > 0 {
+			if err := b.wait(ctx); err != nil {
+				b.fail(pending, err)
+				return
+			}
+			batchMessagesMetrics.WithLabelValues(b.queue, "retried").Add(float64(len(pending)))
+		}
+
+		start := time.Now()
+		out, err := b.publisher.api.SendMessageBatchWithContext(ctx, &sqs.SendMessageBatchInput{QueueUrl: aws.String(b.queue), Entries: pending},
+			awsretry.Observe(b.span, publisherComponent))
+		durationHistogram := trace.Histogram{
+			Observer: batchPublishDurationMetrics.WithLabelValues(b.queue, strconv.FormatBool(err == nil)),
+		}
+		durationHistogram.Observe(ctx, time.Since(start).Seconds())
+
+		if err != nil {
+			if attempt == b.publisher.batchRetries || ctx.Err() != nil {
+				b.fail(pending, fmt.Errorf("failed to publish batch: %w", err))
+				return
+			}
+			continue
+		}
+
+		for _, entry := range out.Successful {
+			b.result.MessageIDs[aws.StringValue(entry.Id)] = aws.StringValue(entry.MessageId)
+		}
+		retryable := make(map[string]error)
+		for _, entry := range out.Failed {
+			entryErr := &EntryError{
+				Code:        aws.StringValue(entry.Code),
+				Message:     aws.StringValue(entry.Message),
+				SenderFault: aws.BoolValue(entry.SenderFault),
+			}
+			if entryErr.SenderFault || attempt == b.publisher.batchRetries {
+				b.fail(byID(pending, aws.StringValue(entry.Id)), entryErr)
+				continue
+			}
+			retryable[aws.StringValue(entry.Id)] = entryErr
+		}
+
+		var next []*sqs.SendMessageBatchRequestEntry
+		for _, entry := range pending {
+			if _, ok := retryable[*entry.Id]; ok {
+				next = append(next, entry)
+			}
+		}
+		pending = b.skipFailedGroups(next)
+	}
+}
+
+// skipFailedGroups fails the entries of the FIFO groups with a failed entry, returning the rest.
+func (b *batch) skipFailedGroups(entries []*sqs.SendMessageBatchRequestEntry) []*sqs.SendMessageBatchRequestEntry {
+	if !b.fifo || len(b.failedGroups) == 0 {
+		return entries
+	}
+	pending := make([]*sqs.SendMessageBatchRequestEntry, 0, len(entries))
+	for _, entry := range entries {
+		if id, ok := b.failedGroups[*entry.MessageGroupId]; ok {
+			b.result.Failed[*entry.Id] = fmt.Errorf("entry %s of message group %s failed to be published", id, *entry.MessageGroupId)
+			continue
+		}
+		pending = append(pending, entry)
+	}
+	return pending
+}
+
+func (b *batch) fail(entries []*sqs.SendMessageBatchRequestEntry, err error) {
+	for _, entry := range entries {
+		b.result.Failed[*entry.Id] = err
+		if b.fifo {
+			if _, ok := b.failedGroups[*entry.MessageGroupId]; !ok {
+				b.failedGroups[*entry.MessageGroupId] = *entry.Id
+			}
+		}
+	}
+}
+
+func (b *batch) wait(ctx context.Context) error {
+	timer := time.NewTimer(b.publisher.batchBackoff)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func byID(entries []*sqs.SendMessageBatchRequestEntry, id string) []*sqs.SendMessageBatchRequestEntry {
+	for _, entry := range entries {
+		if *entry.Id == id {
+			return []*sqs.SendMessageBatchRequestEntry{entry}
+		}
+	}
+	return nil
+}
+
+// entrySize returns the size of the message of the entry, as counted against the limits of SQS, i.e. its body and attributes.
+func entrySize(entry *sqs.SendMessageBatchRequestEntry) int {
+	size := len(aws.StringValue(entry.MessageBody))
+	for name, attr := range entry.MessageAttributes {
+		if attr == nil {
+			continue
+		}
+		size += len(name) + len(aws.StringValue(attr.DataType)) + len(aws.StringValue(attr.StringValue)) + len(attr.BinaryValue)
+	}
+	return size
+}
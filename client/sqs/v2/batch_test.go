@@ -0,0 +1,179 @@
+package v2
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
+	"github.com/beatlabs/patron/correlation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newEntries(n int, group string) []*sqs.SendMessageBatchRequestEntry {
+	entries := make([]*sqs.SendMessageBatchRequestEntry, 0, n)
+	for i := 0; i < n; i++ {
+		entry := &sqs.SendMessageBatchRequestEntry{MessageBody: aws.String("body " + strconv.Itoa(i))}
+		if group != "" {
+			entry.MessageGroupId = aws.String(group)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func Test_Publisher_PublishBatch_Validation(t *testing.T) {
+	p, err := New(&stubBatchSQSAPI{})
+	require.NoError(t, err)
+
+	testCases := map[string]struct {
+		queue       string
+		entries     []*sqs.SendMessageBatchRequestEntry
+		expectedErr string
+	}{
+		"missing queue":   {entries: newEntries(1, ""), expectedErr: "queue URL is empty"},
+		"missing entries": {queue: "url", expectedErr: "entries are empty"},
+		"nil entry":       {queue: "url", entries: []*sqs.SendMessageBatchRequestEntry{nil}, expectedErr: "entry is nil"},
+		"missing body":    {queue: "url", entries: []*sqs.SendMessageBatchRequestEntry{{}}, expectedErr: "entry 0 has no message body"},
+		"duplicate ID": {
+			queue: "url",
+			entries: []*sqs.SendMessageBatchRequestEntry{
+				{Id: aws.String("1"), MessageBody: aws.String("body")},
+				{MessageBody: aws.String("body")},
+			},
+			expectedErr: "entry ID 1 is duplicated",
+		},
+		"missing group": {queue: "url.fifo", entries: newEntries(1, ""), expectedErr: "entry 0 has no message group ID"},
+	}
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			_, err := p.PublishBatch(context.Background(), tt.queue, tt.entries)
+			assert.EqualError(t, err, tt.expectedErr)
+		})
+	}
+}
+
+func Test_Publisher_PublishBatch_Chunks(t *testing.T) {
+	api := &stubBatchSQSAPI{}
+	p, err := New(api)
+	require.NoError(t, err)
+
+	entries := newEntries(23, "")
+	entries[5].MessageBody = aws.String(strings.Repeat("a", 200*1024))
+	entries[6].MessageBody = aws.String(strings.Repeat("b", 100*1024))
+	entries[20].MessageBody = aws.String(strings.Repeat("c", maxBatchSize+1))
+
+	result, err := p.PublishBatch(context.Background(), "url", entries)
+	assert.EqualError(t, err, "failed to publish 1 of 23 messages")
+	assert.Len(t, result.MessageIDs, 22)
+	assert.Equal(t, "message-0", result.MessageIDs["0"])
+	assert.Contains(t, result.Failed["20"].Error(), "exceeds the limit of 262144 bytes")
+
+	require.Len(t, api.requests, 3)
+	assert.Len(t, api.requests[0].Entries, 6)
+	assert.Len(t, api.requests[1].Entries, 10)
+	assert.Len(t, api.requests[2].Entries, 6)
+	for _, entry := range api.requests[0].Entries {
+		assert.Contains(t, entry.MessageAttributes, correlation.HeaderID)
+	}
+}
+
+func Test_Publisher_PublishBatch_Retries(t *testing.T) {
+	api := &stubBatchSQSAPI{failures: []map[string]*sqs.BatchResultErrorEntry{
+		{
+			"1": {Code: aws.String("InternalError"), Message: aws.String("internal"), SenderFault: aws.Bool(false)},
+			"2": {Code: aws.String("InvalidMessageContents"), Message: aws.String("invalid"), SenderFault: aws.Bool(true)},
+			"3": {Code: aws.String("InternalError"), Message: aws.String("internal"), SenderFault: aws.Bool(false)},
+		},
+		{
+			"3": {Code: aws.String("InternalError"), Message: aws.String("internal"), SenderFault: aws.Bool(false)},
+		},
+	}}
+	p, err := New(api, BatchRetries(1, 0))
+	require.NoError(t, err)
+
+	result, err := p.PublishBatch(context.Background(), "url", newEntries(4, ""))
+	assert.EqualError(t, err, "failed to publish 2 of 4 messages")
+	assert.Equal(t, map[string]string{"0": "message-0", "1": "message-1"}, result.MessageIDs)
+	assert.Equal(t, &EntryError{Code: "InvalidMessageContents", Message: "invalid", SenderFault: true}, result.Failed["2"])
+	assert.EqualError(t, result.Failed["3"], "InternalError: internal")
+
+	require.Len(t, api.requests, 2)
+	assert.Len(t, api.requests[1].Entries, 2)
+}
+
+func Test_Publisher_PublishBatch_RequestError(t *testing.T) {
+	api := &stubBatchSQSAPI{err: errors.New("connection reset")}
+	p, err := New(api, BatchRetries(2, 0))
+	require.NoError(t, err)
+
+	result, err := p.PublishBatch(context.Background(), "url", newEntries(3, ""))
+	assert.EqualError(t, err, "failed to publish 3 of 3 messages")
+	assert.Empty(t, result.MessageIDs)
+	assert.EqualError(t, result.Failed["0"], "failed to publish batch: connection reset")
+	assert.Len(t, api.requests, 3)
+}
+
+func Test_Publisher_PublishBatch_FIFO(t *testing.T) {
+	api := &stubBatchSQSAPI{failures: []map[string]*sqs.BatchResultErrorEntry{
+		{"1": {Code: aws.String("InvalidParameterValue"), Message: aws.String("invalid"), SenderFault: aws.Bool(true)}},
+	}}
+	p, err := New(api)
+	require.NoError(t, err)
+
+	entries := append(newEntries(12, "a"), newEntries(2, "b")...)
+	entries[12].Id = aws.String("b0")
+	entries[13].Id = aws.String("b1")
+	entries[13].MessageDeduplicationId = aws.String("dedup")
+
+	result, err := p.PublishBatch(context.Background(), "url.fifo", entries)
+	assert.EqualError(t, err, "failed to publish 3 of 14 messages")
+	assert.Len(t, result.MessageIDs, 11)
+	assert.Contains(t, result.MessageIDs, "9")
+	assert.Contains(t, result.MessageIDs, "b0")
+	assert.EqualError(t, result.Failed["10"], "entry 1 of message group a failed to be published")
+	assert.EqualError(t, result.Failed["11"], "entry 1 of message group a failed to be published")
+
+	require.Len(t, api.requests, 2)
+	assert.Len(t, api.requests[1].Entries, 2)
+	assert.Equal(t, "03d9cc741a0dc36e8752b9e5c09baaed7431a9bce401dc710199a23a2f01aad3", *api.requests[0].Entries[0].MessageDeduplicationId)
+	assert.Equal(t, "dedup", *api.requests[1].Entries[1].MessageDeduplicationId)
+}
+
+type stubBatchSQSAPI struct {
+	sqsiface.SQSAPI
+
+	err      error
+	failures []map[string]*sqs.BatchResultErrorEntry
+	requests []*sqs.SendMessageBatchInput
+}
+
+func (s *stubBatchSQSAPI) SendMessageBatchWithContext(
+	_ context.Context, input *sqs.SendMessageBatchInput, _ ...request.Option,
+) (*sqs.SendMessageBatchOutput, error) {
+	s.requests = append(s.requests, input)
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	var failures map[string]*sqs.BatchResultErrorEntry
+	if len(s.failures) > 0 {
+		failures, s.failures = s.failures[0], s.failures[1:]
+	}
+	out := &sqs.SendMessageBatchOutput{}
+	for _, entry := range input.Entries {
+		if failure, ok := failures[*entry.Id]; ok {
+			failure.Id = entry.Id
+			out.Failed = append(out.Failed, failure)
+			continue
+		}
+		out.Successful = append(out.Successful, &sqs.SendMessageBatchResultEntry{Id: entry.Id, MessageId: aws.String("message-" + *entry.Id)})
+	}
+	return out, nil
+}
@@ -0,0 +1,24 @@
+package v2
+
+import (
+	"errors"
+	"time"
+)
+
+// OptionFunc definition for configuring the publisher in a functional way.
+type OptionFunc func(*Publisher) error
+
+// BatchRetries option for setting the retries of the entries of a batch which failed to be published, and the backoff between them.
+func BatchRetries(retries int, backoff time.Duration) OptionFunc {
+	return func(p *Publisher) error {
+		if retries < 0 {
+			return errors.New("batch retries should be a positive number or zero")
+		}
+		if backoff < 0 {
+			return errors.New("batch backoff should be a positive number or zero")
+		}
+		p.batchRetries = retries
+		p.batchBackoff = backoff
+		return nil
+	}
+}
@@ -0,0 +1,34 @@
+package v2
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBatchRetries(t *testing.T) {
+	testCases := map[string]struct {
+		retries     int
+		backoff     time.Duration
+		expectedErr string
+	}{
+		"success":          {retries: 5, backoff: time.Second},
+		"no retries":       {},
+		"negative retries": {retries: -1, expectedErr: "batch retries should be a positive number or zero"},
+		"negative backoff": {retries: 1, backoff: -1, expectedErr: "batch backoff should be a positive number or zero"},
+	}
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			p := &Publisher{}
+			err := BatchRetries(tt.retries, tt.backoff)(p)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.retries, p.batchRetries)
+				assert.Equal(t, tt.backoff, p.batchBackoff)
+			}
+		})
+	}
+}
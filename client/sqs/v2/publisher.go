@@ -9,21 +9,18 @@ import (
 	"strconv"
 	"time"
 
-	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/sqs"
 	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
-	"github.com/beatlabs/patron/correlation"
+	"github.com/beatlabs/patron/internal/awsretry"
 	"github.com/beatlabs/patron/log"
+	"github.com/beatlabs/patron/propagation"
 	"github.com/beatlabs/patron/trace"
 	"github.com/opentracing/opentracing-go"
 	"github.com/opentracing/opentracing-go/ext"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
-const (
-	publisherComponent      = "sqs-publisher"
-	attributeDataTypeString = "String"
-)
+const publisherComponent = "sqs-publisher"
 
 var publishDurationMetrics *prometheus.HistogramVec
 
@@ -42,15 +39,23 @@ func init() {
 
 // Publisher is a wrapper with added distributed tracing capabilities.
 type Publisher struct {
-	api sqsiface.SQSAPI
+	api          sqsiface.SQSAPI
+	batchRetries int
+	batchBackoff time.Duration
 }
 
 // New creates a new SQS publisher.
-func New(api sqsiface.SQSAPI) (Publisher, error) {
+func New(api sqsiface.SQSAPI, oo ...OptionFunc) (Publisher, error) {
 	if api == nil {
 		return Publisher{}, errors.New("missing api")
 	}
-	return Publisher{api: api}, nil
+	p := Publisher{api: api, batchRetries: defaultBatchRetries, batchBackoff: defaultBatchBackoff}
+	for _, option := range oo {
+		if err := option(&p); err != nil {
+			return Publisher{}, err
+		}
+	}
+	return p, nil
 }
 
 // Publish tries to publish a new message to SQS. It also stores tracing information.
@@ -62,7 +67,7 @@ func (p Publisher) Publish(ctx context.Context, msg *sqs.SendMessageInput) (mess
 	}
 
 	start := time.Now()
-	out, err := p.api.SendMessageWithContext(ctx, msg)
+	out, err := p.api.SendMessageWithContext(ctx, msg, awsretry.Observe(span, publisherComponent))
 	observePublish(ctx, span, start, *msg.QueueUrl, err)
 	if err != nil {
 		return "", fmt.Errorf("failed to publish message: %w", err)
@@ -75,42 +80,20 @@ func (p Publisher) Publish(ctx context.Context, msg *sqs.SendMessageInput) (mess
 	return *out.MessageId, nil
 }
 
-type sqsHeadersCarrier map[string]interface{}
-
-// Set implements Set() of opentracing.TextMapWriter.
-func (c sqsHeadersCarrier) Set(key, val string) {
-	c[key] = val
-}
-
 // injectHeaders injects opentracing headers into SQS message attributes.
 // It also injects a message attribute for correlation.HeaderID if it's not set already.
 func injectHeaders(ctx context.Context, span opentracing.Span, input *sqs.SendMessageInput) error {
-	carrier := sqsHeadersCarrier{}
-	if err := span.Tracer().Inject(span.Context(), opentracing.TextMap, &carrier); err != nil {
-		return fmt.Errorf("failed to inject tracing headers: %w", err)
-	}
 	if input.MessageAttributes == nil {
 		input.MessageAttributes = make(map[string]*sqs.MessageAttributeValue)
 	}
+	return injectAttributes(ctx, span, input.MessageAttributes)
+}
 
-	for k, v := range carrier {
-		val, ok := v.(string)
-		if !ok {
-			return errors.New("failed to type assert string")
-		}
-		input.MessageAttributes[k] = &sqs.MessageAttributeValue{
-			DataType:    aws.String(attributeDataTypeString),
-			StringValue: aws.String(val),
-		}
-	}
-
-	if _, ok := input.MessageAttributes[correlation.HeaderID]; !ok {
-		input.MessageAttributes[correlation.HeaderID] = &sqs.MessageAttributeValue{
-			DataType:    aws.String(attributeDataTypeString),
-			StringValue: aws.String(correlation.IDFromContext(ctx)),
-		}
+// injectAttributes injects opentracing headers and the correlation.HeaderID, if it's not set already, into the message attributes.
+func injectAttributes(ctx context.Context, span opentracing.Span, attributes map[string]*sqs.MessageAttributeValue) error {
+	if err := propagation.Inject(ctx, span, propagation.SQSAttributes(attributes)); err != nil {
+		return fmt.Errorf("failed to inject tracing headers: %w", err)
 	}
-
 	return nil
 }
 
@@ -14,6 +14,7 @@ import (
 	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
 	"github.com/beatlabs/patron/correlation"
 	"github.com/beatlabs/patron/log"
+	"github.com/beatlabs/patron/reliability/retry"
 	"github.com/opentracing/opentracing-go"
 	"github.com/opentracing/opentracing-go/mocktracer"
 	"github.com/stretchr/testify/assert"
@@ -243,3 +244,40 @@ func ExamplePublisher() {
 
 	fmt.Println(msgID)
 }
+
+func Test_Publisher_Publish_ObservesAttempts(t *testing.T) {
+	mtr := mocktracer.New()
+	defer mtr.Reset()
+	opentracing.SetGlobalTracer(mtr)
+
+	p, err := New(&retryingSQSAPI{output: (&sqs.SendMessageOutput{}).SetMessageId("msgID"), errs: []error{errors.New("throttled"), nil}})
+	require.NoError(t, err)
+
+	msgID, err := p.Publish(context.Background(), &sqs.SendMessageInput{MessageBody: aws.String("body"), QueueUrl: aws.String("url")})
+	require.NoError(t, err)
+	assert.Equal(t, "msgID", msgID)
+
+	spans := mtr.FinishedSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, 2, spans[0].Tag(retry.AttemptsTag))
+	assert.Len(t, spans[0].Logs(), 2)
+}
+
+// retryingSQSAPI runs the request handlers of the options like the SDK does, with an attempt for each of the errors.
+type retryingSQSAPI struct {
+	sqsiface.SQSAPI
+
+	output *sqs.SendMessageOutput
+	errs   []error
+}
+
+func (s *retryingSQSAPI) SendMessageWithContext(_ context.Context, _ *sqs.SendMessageInput, opts ...request.Option) (*sqs.SendMessageOutput, error) {
+	r := &request.Request{}
+	r.ApplyOptions(opts...)
+	for _, err := range s.errs {
+		r.Error = err
+		r.Handlers.CompleteAttempt.Run(r)
+	}
+	r.Handlers.Complete.Run(r)
+	return s.output, r.Error
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/beatlabs/patron/correlation"
 	patronerrors "github.com/beatlabs/patron/errors"
 	"github.com/beatlabs/patron/log"
+	"github.com/beatlabs/patron/propagation"
 	"github.com/beatlabs/patron/trace"
 	"github.com/google/uuid"
 	"github.com/opentracing/opentracing-go"
@@ -41,6 +42,7 @@ var (
 	messageAge        *prometheus.GaugeVec
 	messageCounterVec *prometheus.CounterVec
 	queueSize         *prometheus.GaugeVec
+	processingAge     *prometheus.HistogramVec
 )
 
 func init() {
@@ -74,6 +76,17 @@ func init() {
 		[]string{"queue"},
 	)
 	prometheus.MustRegister(queueSize)
+	processingAge = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "component",
+			Subsystem: "amqp",
+			Name:      "message_processing_age_seconds",
+			Help:      "Time elapsed between publishing the messages and processing them, based on the AMQP timestamp",
+			Buckets:   prometheus.ExponentialBuckets(0.01, 2, 20),
+		},
+		[]string{"queue"},
+	)
+	prometheus.MustRegister(processingAge)
 }
 
 // ProcessorFunc definition of an async processor.
@@ -238,6 +251,14 @@ func observeReceivedMessageStats(queue string, timestamp time.Time) {
 	messageCountInc(queue, fetchedMessageState, nil)
 }
 
+// observeProcessingAge observes the time elapsed since the message was published, unless the publisher did not set its timestamp.
+func observeProcessingAge(queue string, timestamp time.Time) {
+	if timestamp.IsZero() {
+		return
+	}
+	processingAge.WithLabelValues(queue).Observe(time.Since(timestamp).Seconds())
+}
+
 type subscription struct {
 	conn       *amqp.Connection
 	channel    *amqp.Channel
@@ -286,12 +307,10 @@ func (c *Component) subscribe() (subscription, error) {
 }
 
 func (c *Component) createMessage(ctx context.Context, delivery amqp.Delivery) *message {
-	corID := getCorrelationID(delivery.Headers)
-	sp, ctxMsg := trace.ConsumerSpan(ctx, trace.ComponentOpName(consumerComponent, c.queueCfg.queue),
-		consumerComponent, corID, mapHeader(delivery.Headers), c.traceTag)
+	sp, ctxMsg := propagation.ConsumerSpan(ctx, trace.ComponentOpName(consumerComponent, c.queueCfg.queue),
+		consumerComponent, propagation.AMQPHeaders(delivery.Headers), c.traceTag)
 
-	ctxMsg = correlation.ContextWithID(ctxMsg, corID)
-	ctxMsg = log.WithContext(ctxMsg, log.Sub(map[string]interface{}{correlation.ID: corID}))
+	ctxMsg = log.WithContext(ctxMsg, log.Sub(map[string]interface{}{correlation.ID: correlation.IDFromContext(ctxMsg)}))
 
 	return &message{
 		ctx:     ctxMsg,
@@ -315,6 +334,9 @@ func (c *Component) sendBatch(ctx context.Context, btc *batch) {
 }
 
 func (c *Component) processAndResetBatch(ctx context.Context, btc *batch) {
+	for _, msg := range btc.messages {
+		observeProcessingAge(c.queueCfg.queue, msg.Message().Timestamp)
+	}
 	c.proc(ctx, btc)
 	btc.reset()
 }
@@ -336,24 +358,3 @@ func messageCountInc(queue string, state messageState, err error) {
 	}
 	messageCounterVec.WithLabelValues(queue, string(state), hasError).Inc()
 }
-
-func mapHeader(hh amqp.Table) map[string]string {
-	mp := make(map[string]string)
-	for k, v := range hh {
-		mp[k] = fmt.Sprint(v)
-	}
-	return mp
-}
-
-func getCorrelationID(hh amqp.Table) string {
-	for key, value := range hh {
-		if key == correlation.HeaderID {
-			val, ok := value.(string)
-			if ok && val != "" {
-				return val
-			}
-			break
-		}
-	}
-	return uuid.New().String()
-}
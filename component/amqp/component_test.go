@@ -5,7 +5,10 @@ import (
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNew(t *testing.T) {
@@ -85,3 +88,15 @@ func TestNew(t *testing.T) {
 		})
 	}
 }
+
+func Test_observeProcessingAge(t *testing.T) {
+	t.Parallel()
+	observeProcessingAge("processing-age", time.Now().Add(-time.Minute))
+	// messages published without a timestamp are not observed
+	observeProcessingAge("processing-age", time.Time{})
+
+	m := &dto.Metric{}
+	require.NoError(t, processingAge.WithLabelValues("processing-age").(prometheus.Histogram).Write(m))
+	assert.Equal(t, uint64(1), m.GetHistogram().GetSampleCount())
+	assert.GreaterOrEqual(t, m.GetHistogram().GetSampleSum(), time.Minute.Seconds())
+}
@@ -68,6 +68,11 @@ func (m *message) Source() string {
 	return m.source
 }
 
+// Timestamp returns the time the message was published, if the publisher set it.
+func (m *message) Timestamp() time.Time {
+	return m.del.Timestamp
+}
+
 // Payload returns the message payload.
 func (m *message) Payload() []byte {
 	return m.del.Body
@@ -4,10 +4,13 @@ package async
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/beatlabs/patron/encoding"
 	"github.com/beatlabs/patron/encoding/json"
 	"github.com/beatlabs/patron/encoding/protobuf"
+	"github.com/beatlabs/patron/event"
+	"github.com/beatlabs/patron/event/cloudevents"
 )
 
 // FailStrategy type definition.
@@ -36,6 +39,12 @@ type Message interface {
 	Raw() interface{}
 }
 
+// TimestampedMessage is implemented by the messages which carry the time they were produced, whose age at processing
+// is measured by the component. The time is zero, if the message does not carry it.
+type TimestampedMessage interface {
+	Timestamp() time.Time
+}
+
 // ConsumerFactory interface for creating consumers.
 type ConsumerFactory interface {
 	Create() (Consumer, error)
@@ -55,6 +64,12 @@ func DetermineDecoder(contentType string) (encoding.DecodeRawFunc, error) {
 		return json.DecodeRaw, nil
 	case protobuf.Type, protobuf.TypeGoogle:
 		return protobuf.DecodeRaw, nil
+	case event.ProtobufType:
+		return event.DecodeRawProtobuf, nil
+	case event.JSONType:
+		return event.DecodeRawJSON, nil
+	case cloudevents.JSONType:
+		return cloudevents.DecodeRaw, nil
 	}
 	return nil, fmt.Errorf("content header %s is unsupported", contentType)
 }
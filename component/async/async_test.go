@@ -5,6 +5,8 @@ import (
 
 	"github.com/beatlabs/patron/encoding/json"
 	"github.com/beatlabs/patron/encoding/protobuf"
+	"github.com/beatlabs/patron/event"
+	"github.com/beatlabs/patron/event/cloudevents"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -19,6 +21,9 @@ func TestDetermineDecoder(t *testing.T) {
 	}{
 		{"success json", args{contentType: json.Type}, false},
 		{"success protobuf", args{contentType: protobuf.Type}, false},
+		{"success protobuf event", args{contentType: event.ProtobufType}, false},
+		{"success json event", args{contentType: event.JSONType}, false},
+		{"success cloud event", args{contentType: cloudevents.JSONType}, false},
 		{"failure", args{contentType: "XXX"}, true},
 	}
 	for _, tt := range tests {
@@ -0,0 +1,313 @@
+// Package asyncapi generates the AsyncAPI 2 document of the channels of the service, i.e. the topics, queues and exchanges
+// it consumes messages from and produces messages to, and serves it on the HTTP component, like the OpenAPI document of package openapi.
+//
+// The operations of the document are described from the perspective of the clients of the service, as defined by AsyncAPI 2:
+// the channels the service consumes from have a publish operation, and the channels it produces to a subscribe one.
+package asyncapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	v2 "github.com/beatlabs/patron/component/http/v2"
+	"github.com/beatlabs/patron/component/http/v2/openapi"
+)
+
+const (
+	// SpecPath is the path the document is served at.
+	SpecPath = "/asyncapi.json"
+	// Version of the AsyncAPI specification of the document.
+	Version = "2.6.0"
+
+	defaultContentType = "application/json"
+)
+
+// Direction of the messages of a channel, relative to the service.
+type Direction int
+
+const (
+	// Consume is the direction of the channels the service consumes messages from, e.g. the topics of a Kafka consumer.
+	Consume Direction = iota + 1
+	// Produce is the direction of the channels the service produces messages to, e.g. the queues of an SQS producer.
+	Produce
+)
+
+func (d Direction) String() string {
+	switch d {
+	case Consume:
+		return "consume"
+	case Produce:
+		return "produce"
+	}
+	return "unknown"
+}
+
+// Info of the service.
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// Server of the channels, i.e. a broker, e.g. a Kafka cluster or a RabbitMQ instance.
+type Server struct {
+	URL         string `json:"url"`
+	Protocol    string `json:"protocol"`
+	Description string `json:"description,omitempty"`
+}
+
+// Config of the document.
+type Config struct {
+	Info Info
+	// Servers of the channels, keyed by name.
+	Servers map[string]Server
+	// DefaultContentType of the messages without a content type, which defaults to application/json.
+	DefaultContentType string
+}
+
+// Channel the service consumes messages from or produces messages to.
+type Channel struct {
+	// Name of the channel, i.e. the topic, queue or exchange.
+	Name        string
+	Description string
+	Direction   Direction
+	// Servers are the names of the servers of the config hosting the channel, which defaults to all of them.
+	Servers []string
+	// Messages are the types of the messages of the channel.
+	Messages []Message
+	// Bindings are the protocol-specific properties of the channel, e.g. the consumer group of a Kafka topic.
+	Bindings Bindings
+}
+
+// Message type of a channel.
+type Message struct {
+	// Name of the message type, e.g. the type of an event of package event.
+	Name    string
+	Title   string
+	Summary string
+	// ContentType of the message, which defaults to the default content type of the config.
+	ContentType string
+	// Payload is a value of the Go type of the payload, whose schema is generated like the schemas of the OpenAPI document.
+	Payload interface{}
+	// Headers is a value of the Go struct type of the headers, if any.
+	Headers interface{}
+}
+
+// Bindings of a channel, its operation and its messages, keyed by protocol, e.g. kafka, amqp, sqs, sns or mqtt.
+// See https://github.com/asyncapi/bindings.
+type Bindings struct {
+	Channel   map[string]interface{}
+	Operation map[string]interface{}
+	Message   map[string]interface{}
+}
+
+// Document is an AsyncAPI 2 document.
+type Document struct {
+	AsyncAPI           string                 `json:"asyncapi"`
+	Info               Info                   `json:"info"`
+	Servers            map[string]Server      `json:"servers,omitempty"`
+	DefaultContentType string                 `json:"defaultContentType"`
+	Channels           map[string]ChannelItem `json:"channels"`
+	Components         Components             `json:"components,omitempty"`
+}
+
+// Components of the document.
+type Components struct {
+	Schemas map[string]*openapi.Schema `json:"schemas,omitempty"`
+}
+
+// ChannelItem of the document.
+type ChannelItem struct {
+	Description string                 `json:"description,omitempty"`
+	Servers     []string               `json:"servers,omitempty"`
+	Publish     *Operation             `json:"publish,omitempty"`
+	Subscribe   *Operation             `json:"subscribe,omitempty"`
+	Bindings    map[string]interface{} `json:"bindings,omitempty"`
+}
+
+// Operation of a channel.
+type Operation struct {
+	OperationID string                 `json:"operationId"`
+	Bindings    map[string]interface{} `json:"bindings,omitempty"`
+	Message     *OperationMessage      `json:"message,omitempty"`
+}
+
+// OperationMessage is the message of an operation, or the messages of the operation in OneOf, if there are more than one.
+type OperationMessage struct {
+	MessageObject
+	OneOf []MessageObject `json:"oneOf,omitempty"`
+}
+
+// MessageObject describes a message type of the document.
+type MessageObject struct {
+	Name        string                 `json:"name,omitempty"`
+	Title       string                 `json:"title,omitempty"`
+	Summary     string                 `json:"summary,omitempty"`
+	ContentType string                 `json:"contentType,omitempty"`
+	Headers     *openapi.Schema        `json:"headers,omitempty"`
+	Payload     *openapi.Schema        `json:"payload,omitempty"`
+	Bindings    map[string]interface{} `json:"bindings,omitempty"`
+}
+
+// New generates the document of the channels, from their directions, messages and bindings.
+func New(cfg Config, channels ...Channel) (*Document, error) {
+	if cfg.Info.Title == "" {
+		return nil, errors.New("title is empty")
+	}
+	if cfg.Info.Version == "" {
+		return nil, errors.New("version is empty")
+	}
+	for name, server := range cfg.Servers {
+		if server.URL == "" || server.Protocol == "" {
+			return nil, fmt.Errorf("url or protocol of server %s is empty", name)
+		}
+	}
+
+	doc := &Document{
+		AsyncAPI:           Version,
+		Info:               cfg.Info,
+		Servers:            cfg.Servers,
+		DefaultContentType: cfg.DefaultContentType,
+		Channels:           make(map[string]ChannelItem),
+	}
+	if doc.DefaultContentType == "" {
+		doc.DefaultContentType = defaultContentType
+	}
+	g := openapi.NewSchemaGenerator()
+
+	for _, ch := range channels {
+		if err := validate(cfg, ch); err != nil {
+			return nil, err
+		}
+		item := doc.Channels[ch.Name]
+		if item.Description == "" {
+			item.Description = ch.Description
+		}
+		item.Servers = mergeServers(item.Servers, ch.Servers)
+		item.Bindings = mergeBindings(item.Bindings, ch.Bindings.Channel)
+
+		op := &Operation{
+			OperationID: operationID(ch.Direction, ch.Name),
+			Bindings:    ch.Bindings.Operation,
+			Message:     operationMessage(g, ch),
+		}
+		switch ch.Direction {
+		case Consume:
+			if item.Publish != nil {
+				return nil, fmt.Errorf("channel %s is consumed more than once", ch.Name)
+			}
+			item.Publish = op
+		case Produce:
+			if item.Subscribe != nil {
+				return nil, fmt.Errorf("channel %s is produced more than once", ch.Name)
+			}
+			item.Subscribe = op
+		}
+		doc.Channels[ch.Name] = item
+	}
+
+	if len(g.Components()) > 0 {
+		doc.Components.Schemas = g.Components()
+	}
+	return doc, nil
+}
+
+func validate(cfg Config, ch Channel) error {
+	if ch.Name == "" {
+		return errors.New("channel name is empty")
+	}
+	if ch.Direction != Consume && ch.Direction != Produce {
+		return fmt.Errorf("direction of channel %s is invalid", ch.Name)
+	}
+	for _, server := range ch.Servers {
+		if _, ok := cfg.Servers[server]; !ok {
+			return fmt.Errorf("server %s of channel %s is not defined", server, ch.Name)
+		}
+	}
+	return nil
+}
+
+func operationMessage(g *openapi.SchemaGenerator, ch Channel) *OperationMessage {
+	if len(ch.Messages) == 0 {
+		return nil
+	}
+	mm := make([]MessageObject, 0, len(ch.Messages))
+	for _, m := range ch.Messages {
+		msg := MessageObject{
+			Name:        m.Name,
+			Title:       m.Title,
+			Summary:     m.Summary,
+			ContentType: m.ContentType,
+			Bindings:    ch.Bindings.Message,
+		}
+		if m.Payload != nil {
+			msg.Payload = g.Of(m.Payload)
+		}
+		if m.Headers != nil {
+			msg.Headers = g.Of(m.Headers)
+		}
+		mm = append(mm, msg)
+	}
+	if len(mm) == 1 {
+		return &OperationMessage{MessageObject: mm[0]}
+	}
+	return &OperationMessage{OneOf: mm}
+}
+
+func mergeServers(servers, other []string) []string {
+	for _, s := range other {
+		found := false
+		for _, existing := range servers {
+			if existing == s {
+				found = true
+				break
+			}
+		}
+		if !found {
+			servers = append(servers, s)
+		}
+	}
+	return servers
+}
+
+func mergeBindings(bindings, other map[string]interface{}) map[string]interface{} {
+	if len(other) == 0 {
+		return bindings
+	}
+	if bindings == nil {
+		bindings = make(map[string]interface{}, len(other))
+	}
+	for k, v := range other {
+		bindings[k] = v
+	}
+	return bindings
+}
+
+// operationID returns a unique ID of the operation, e.g. consume-orders.created for consuming the orders.created topic.
+func operationID(d Direction, name string) string {
+	return d.String() + "-" + strings.Trim(strings.ReplaceAll(name, "/", "-"), "-")
+}
+
+// Routes creates the route serving the document at SpecPath.
+func Routes(cfg Config, channels ...Channel) ([]*v2.Route, error) {
+	doc, err := New(cfg, channels...)
+	if err != nil {
+		return nil, err
+	}
+	body, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode document: %w", err)
+	}
+
+	spec, err := v2.NewGetRoute(SpecPath, func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(body)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return []*v2.Route{spec}, nil
+}
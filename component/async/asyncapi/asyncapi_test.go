@@ -0,0 +1,174 @@
+package asyncapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type orderCreated struct {
+	ID        string    `json:"id" validate:"required"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type orderCanceled struct {
+	ID     string `json:"id"`
+	Reason string `json:"reason,omitempty"`
+}
+
+type headers struct {
+	CorrelationID string `json:"X-Correlation-Id"`
+}
+
+var testInfo = Info{Title: "orders", Version: "1.0.0"}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		cfg         Config
+		channels    []Channel
+		expectedErr string
+	}{
+		"success":             {cfg: Config{Info: testInfo}, channels: []Channel{{Name: "orders", Direction: Consume}}},
+		"consume and produce": {cfg: Config{Info: testInfo}, channels: []Channel{{Name: "orders", Direction: Consume}, {Name: "orders", Direction: Produce}}},
+		"missing title":       {cfg: Config{Info: Info{Version: "1.0.0"}}, expectedErr: "title is empty"},
+		"missing version":     {cfg: Config{Info: Info{Title: "orders"}}, expectedErr: "version is empty"},
+		"invalid server":      {cfg: Config{Info: testInfo, Servers: map[string]Server{"kafka": {URL: "localhost:9092"}}}, expectedErr: "url or protocol of server kafka is empty"},
+		"missing name":        {cfg: Config{Info: testInfo}, channels: []Channel{{Direction: Consume}}, expectedErr: "channel name is empty"},
+		"missing direction":   {cfg: Config{Info: testInfo}, channels: []Channel{{Name: "orders"}}, expectedErr: "direction of channel orders is invalid"},
+		"undefined server": {
+			cfg: Config{Info: testInfo}, channels: []Channel{{Name: "orders", Direction: Consume, Servers: []string{"kafka"}}},
+			expectedErr: "server kafka of channel orders is not defined",
+		},
+		"consumed twice": {
+			cfg: Config{Info: testInfo}, channels: []Channel{{Name: "orders", Direction: Consume}, {Name: "orders", Direction: Consume}},
+			expectedErr: "channel orders is consumed more than once",
+		},
+		"produced twice": {
+			cfg: Config{Info: testInfo}, channels: []Channel{{Name: "orders", Direction: Produce}, {Name: "orders", Direction: Produce}},
+			expectedErr: "channel orders is produced more than once",
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			got, err := New(tt.cfg, tt.channels...)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, got)
+			}
+		})
+	}
+}
+
+func TestNew_Document(t *testing.T) {
+	t.Parallel()
+	cfg := Config{
+		Info: testInfo,
+		Servers: map[string]Server{
+			"kafka":    {URL: "localhost:9092", Protocol: "kafka"},
+			"rabbitmq": {URL: "amqp://localhost:5672", Protocol: "amqp"},
+		},
+	}
+	doc, err := New(cfg,
+		Channel{
+			Name: "orders", Description: "Order events", Direction: Consume, Servers: []string{"kafka"},
+			Messages: []Message{
+				{Name: "order.created", Payload: orderCreated{}, Headers: headers{}},
+				{Name: "order.canceled", ContentType: "application/cloudevents+json", Payload: &orderCanceled{}},
+			},
+			Bindings: KafkaBindings("orders-service"),
+		},
+		Channel{Name: "orders", Direction: Produce, Servers: []string{"kafka"}, Messages: []Message{{Name: "order.created", Payload: orderCreated{}}}},
+		Channel{Name: "invoices", Direction: Produce, Servers: []string{"rabbitmq"}, Bindings: AMQPBindings("invoices", "fanout", "")},
+	)
+	require.NoError(t, err)
+	got, err := json.Marshal(doc)
+	require.NoError(t, err)
+
+	expected := `{
+  "asyncapi": "2.6.0",
+  "info": {"title": "orders", "version": "1.0.0"},
+  "servers": {
+    "kafka": {"url": "localhost:9092", "protocol": "kafka"},
+    "rabbitmq": {"url": "amqp://localhost:5672", "protocol": "amqp"}
+  },
+  "defaultContentType": "application/json",
+  "channels": {
+    "orders": {
+      "description": "Order events",
+      "servers": ["kafka"],
+      "publish": {
+        "operationId": "consume-orders",
+        "bindings": {"kafka": {"groupId": {"type": "string", "enum": ["orders-service"]}, "bindingVersion": "0.4.0"}},
+        "message": {"oneOf": [
+          {
+            "name": "order.created",
+            "headers": {"$ref": "#/components/schemas/headers"},
+            "payload": {"$ref": "#/components/schemas/orderCreated"}
+          },
+          {
+            "name": "order.canceled",
+            "contentType": "application/cloudevents+json",
+            "payload": {"$ref": "#/components/schemas/orderCanceled"}
+          }
+        ]}
+      },
+      "subscribe": {
+        "operationId": "produce-orders",
+        "message": {"name": "order.created", "payload": {"$ref": "#/components/schemas/orderCreated"}}
+      }
+    },
+    "invoices": {
+      "servers": ["rabbitmq"],
+      "subscribe": {"operationId": "produce-invoices"},
+      "bindings": {"amqp": {"is": "routingKey", "exchange": {"name": "invoices", "type": "fanout"}, "bindingVersion": "0.2.0"}}
+    }
+  },
+  "components": {
+    "schemas": {
+      "headers": {"type": "object", "properties": {"X-Correlation-Id": {"type": "string"}}},
+      "orderCreated": {
+        "type": "object",
+        "properties": {"id": {"type": "string"}, "created_at": {"type": "string", "format": "date-time"}},
+        "required": ["id"]
+      },
+      "orderCanceled": {"type": "object", "properties": {"id": {"type": "string"}, "reason": {"type": "string"}}}
+    }
+  }
+}`
+	assert.JSONEq(t, expected, string(got))
+}
+
+func TestOperationID(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, "consume-orders.created", operationID(Consume, "orders.created"))
+	assert.Equal(t, "produce-devices-1-status", operationID(Produce, "/devices/1/status"))
+}
+
+func TestRoutes(t *testing.T) {
+	t.Parallel()
+	_, err := Routes(Config{})
+	assert.EqualError(t, err, "title is empty")
+
+	routes, err := Routes(Config{Info: testInfo, DefaultContentType: "application/x-protobuf"}, Channel{Name: "orders", Direction: Consume})
+	require.NoError(t, err)
+	require.Len(t, routes, 1)
+	assert.Equal(t, http.MethodGet, routes[0].Method())
+	assert.Equal(t, SpecPath, routes[0].Path())
+
+	rc := httptest.NewRecorder()
+	routes[0].Handler().ServeHTTP(rc, httptest.NewRequest(http.MethodGet, SpecPath, nil))
+	assert.Equal(t, http.StatusOK, rc.Code)
+	assert.Equal(t, "application/json", rc.Header().Get("Content-Type"))
+	assert.Contains(t, rc.Body.String(), `"defaultContentType":"application/x-protobuf"`)
+}
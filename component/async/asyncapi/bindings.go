@@ -0,0 +1,46 @@
+package asyncapi
+
+// KafkaBindings returns the bindings of a Kafka topic, consumed by the consumer group, if any.
+func KafkaBindings(group string) Bindings {
+	op := map[string]interface{}{"bindingVersion": "0.4.0"}
+	if group != "" {
+		op["groupId"] = map[string]interface{}{"type": "string", "enum": []string{group}}
+	}
+	return Bindings{Operation: map[string]interface{}{"kafka": op}}
+}
+
+// AMQPBindings returns the bindings of an AMQP queue, if not empty, or else of the exchange of the given type, e.g. fanout or topic.
+func AMQPBindings(exchange, exchangeType, queue string) Bindings {
+	ch := map[string]interface{}{"bindingVersion": "0.2.0"}
+	if exchange != "" {
+		ch["exchange"] = map[string]interface{}{"name": exchange, "type": exchangeType}
+	}
+	if queue != "" {
+		ch["is"] = "queue"
+		ch["queue"] = map[string]interface{}{"name": queue}
+	} else {
+		ch["is"] = "routingKey"
+	}
+	return Bindings{Channel: map[string]interface{}{"amqp": ch}}
+}
+
+// SQSBindings returns the bindings of an AWS SQS queue.
+func SQSBindings(queue string) Bindings {
+	return Bindings{Channel: map[string]interface{}{
+		"sqs": map[string]interface{}{"queue": map[string]interface{}{"name": queue}, "bindingVersion": "0.2.0"},
+	}}
+}
+
+// SNSBindings returns the bindings of an AWS SNS topic.
+func SNSBindings(topic string) Bindings {
+	return Bindings{Channel: map[string]interface{}{
+		"sns": map[string]interface{}{"name": topic, "bindingVersion": "0.1.0"},
+	}}
+}
+
+// MQTTBindings returns the bindings of an MQTT topic, with the QoS level and the retain flag of its messages.
+func MQTTBindings(qos byte, retain bool) Bindings {
+	return Bindings{Operation: map[string]interface{}{
+		"mqtt": map[string]interface{}{"qos": qos, "retain": retain, "bindingVersion": "0.1.0"},
+	}}
+}
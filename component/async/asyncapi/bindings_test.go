@@ -0,0 +1,56 @@
+package asyncapi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBindings(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		bindings Bindings
+		expected Bindings
+	}{
+		"kafka": {
+			bindings: KafkaBindings("group"),
+			expected: Bindings{Operation: map[string]interface{}{"kafka": map[string]interface{}{
+				"groupId": map[string]interface{}{"type": "string", "enum": []string{"group"}}, "bindingVersion": "0.4.0",
+			}}},
+		},
+		"kafka without group": {
+			bindings: KafkaBindings(""),
+			expected: Bindings{Operation: map[string]interface{}{"kafka": map[string]interface{}{"bindingVersion": "0.4.0"}}},
+		},
+		"amqp queue": {
+			bindings: AMQPBindings("orders", "topic", "orders-service"),
+			expected: Bindings{Channel: map[string]interface{}{"amqp": map[string]interface{}{
+				"is": "queue", "queue": map[string]interface{}{"name": "orders-service"},
+				"exchange": map[string]interface{}{"name": "orders", "type": "topic"}, "bindingVersion": "0.2.0",
+			}}},
+		},
+		"sqs": {
+			bindings: SQSBindings("orders"),
+			expected: Bindings{Channel: map[string]interface{}{"sqs": map[string]interface{}{
+				"queue": map[string]interface{}{"name": "orders"}, "bindingVersion": "0.2.0",
+			}}},
+		},
+		"sns": {
+			bindings: SNSBindings("orders"),
+			expected: Bindings{Channel: map[string]interface{}{"sns": map[string]interface{}{"name": "orders", "bindingVersion": "0.1.0"}}},
+		},
+		"mqtt": {
+			bindings: MQTTBindings(1, true),
+			expected: Bindings{Operation: map[string]interface{}{"mqtt": map[string]interface{}{
+				"qos": byte(1), "retain": true, "bindingVersion": "0.1.0",
+			}}},
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.expected, tt.bindings)
+		})
+	}
+}
@@ -8,12 +8,16 @@ import (
 
 	patronErrors "github.com/beatlabs/patron/errors"
 	"github.com/beatlabs/patron/log"
+	"github.com/beatlabs/patron/slo"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
 const propSetMSG = "property '%s' set for '%s'"
 
-var consumerErrors *prometheus.CounterVec
+var (
+	consumerErrors *prometheus.CounterVec
+	processingAge  *prometheus.HistogramVec
+)
 
 func init() {
 	consumerErrors = prometheus.NewCounterVec(
@@ -25,7 +29,17 @@ func init() {
 		},
 		[]string{"name"},
 	)
-	prometheus.MustRegister(consumerErrors)
+	processingAge = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "component",
+			Subsystem: "async",
+			Name:      "message_processing_age_seconds",
+			Help:      "Time elapsed between producing the messages and processing them, classified by name and source",
+			Buckets:   prometheus.ExponentialBuckets(0.01, 2, 20),
+		},
+		[]string{"name", "source"},
+	)
+	prometheus.MustRegister(consumerErrors, processingAge)
 }
 
 func consumerErrorsInc(name string) {
@@ -41,6 +55,7 @@ type Component struct {
 	retries      int
 	retryWait    time.Duration
 	concurrency  int
+	objective    *slo.Objective
 	jobs         chan Message
 	jobErr       chan error
 }
@@ -55,6 +70,7 @@ type Builder struct {
 	retries      uint
 	retryWait    time.Duration
 	concurrency  uint
+	objective    *slo.Objective
 }
 
 // New initializes a new builder for a component with the given name
@@ -121,6 +137,19 @@ func (cb *Builder) WithRetryWait(retryWait time.Duration) *Builder {
 	return cb
 }
 
+// WithSLO records the processing of the messages as events of the service level objective,
+// which are successful if the processor does not return an error
+// it will append an error to the builder if the objective is nil.
+func (cb *Builder) WithSLO(objective *slo.Objective) *Builder {
+	if objective == nil {
+		cb.errors = append(cb.errors, errors.New("objective is nil"))
+	} else {
+		log.Debugf(propSetMSG, "slo", cb.name)
+		cb.objective = objective
+	}
+	return cb
+}
+
 // Create constructs the Component applying.
 func (cb *Builder) Create() (*Component, error) {
 	if len(cb.errors) > 0 {
@@ -135,6 +164,7 @@ func (cb *Builder) Create() (*Component, error) {
 		retries:      int(cb.retries),
 		retryWait:    cb.retryWait,
 		concurrency:  int(cb.concurrency),
+		objective:    cb.objective,
 		jobs:         make(chan Message),
 		jobErr:       make(chan error),
 	}
@@ -221,7 +251,14 @@ func (c *Component) dispatchMessage(msg Message) error {
 }
 
 func (c *Component) processMessage(msg Message) error {
+	start := time.Now()
+	if tm, ok := msg.(TimestampedMessage); ok && !tm.Timestamp().IsZero() {
+		processingAge.WithLabelValues(c.name, msg.Source()).Observe(start.Sub(tm.Timestamp()).Seconds())
+	}
 	err := c.proc(msg)
+	if c.objective != nil {
+		c.objective.Record(err == nil, time.Since(start))
+	}
 	if err != nil {
 		return c.executeFailureStrategy(msg, err)
 	}
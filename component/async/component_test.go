@@ -7,6 +7,9 @@ import (
 	"testing"
 	"time"
 
+	"github.com/beatlabs/patron/slo"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -433,6 +436,39 @@ func TestRun_Process_Error_InvalidStrategy(t *testing.T) {
 	assert.Equal(t, 1, proc.execs)
 }
 
+func TestRun_Process_SLO(t *testing.T) {
+	objective, err := slo.New("async-test", 0.5, slo.BurnRateWindows(time.Minute))
+	require.NoError(t, err)
+	defer objective.Close()
+
+	_, err = New("test", &mockConsumerFactory{}, (&mockProcessor{}).Process).WithSLO(nil).Create()
+	assert.EqualError(t, err, "objective is nil\n")
+
+	cnr := mockConsumer{
+		chMsg: make(chan Message, 10),
+		chErr: make(chan error, 10),
+	}
+	proc := mockProcessor{errReturn: true}
+	cmp, err := New("test", &mockConsumerFactory{c: &cnr}, proc.Process).
+		WithFailureStrategy(AckStrategy).
+		WithSLO(objective).
+		Create()
+	require.NoError(t, err)
+
+	ctx, cnl := context.WithCancel(context.Background())
+	cnr.chMsg <- &mockMessage{ctx: ctx}
+	ch := make(chan bool)
+	go func() {
+		assert.NoError(t, cmp.Run(ctx))
+		ch <- true
+	}()
+	time.Sleep(10 * time.Millisecond)
+	cnl()
+	assert.True(t, <-ch)
+	// a single failed event burns the error budget twice as fast
+	assert.Equal(t, 2.0, objective.BurnRate(time.Minute))
+}
+
 type mockMessage struct {
 	ctx       context.Context
 	ackError  bool
@@ -547,3 +583,30 @@ func (mc *mockConsumer) Close() error {
 	}
 	return nil
 }
+
+type timestampedMessage struct {
+	mockMessage
+	timestamp time.Time
+}
+
+func (m *timestampedMessage) Source() string {
+	return "orders"
+}
+
+func (m *timestampedMessage) Timestamp() time.Time {
+	return m.timestamp
+}
+
+func TestComponent_processMessage_ProcessingAge(t *testing.T) {
+	cmp, err := New("age-test", &mockConsumerFactory{}, (&mockProcessor{}).Process).Create()
+	require.NoError(t, err)
+
+	require.NoError(t, cmp.processMessage(&timestampedMessage{mockMessage: mockMessage{ctx: context.Background()}, timestamp: time.Now().Add(-time.Minute)}))
+	require.NoError(t, cmp.processMessage(&timestampedMessage{mockMessage: mockMessage{ctx: context.Background()}}))
+	require.NoError(t, cmp.processMessage(&mockMessage{ctx: context.Background()}))
+
+	m := &dto.Metric{}
+	require.NoError(t, processingAge.WithLabelValues("age-test", "orders").(prometheus.Histogram).Write(m))
+	assert.Equal(t, uint64(1), m.GetHistogram().GetSampleCount())
+	assert.GreaterOrEqual(t, m.GetHistogram().GetSampleSum(), time.Minute.Seconds())
+}
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/Shopify/sarama"
@@ -139,6 +140,11 @@ func (m *message) Source() string {
 	return m.msg.Topic
 }
 
+// Timestamp returns the time the message was produced, which is zero for messages produced with a version of the protocol preceding 0.10.
+func (m *message) Timestamp() time.Time {
+	return m.msg.Timestamp
+}
+
 // Payload returns the message payload.
 func (m *message) Payload() []byte {
 	return m.msg.Value
@@ -208,7 +214,7 @@ func getCorrelationID(hh []*sarama.RecordHeader) string {
 
 func determineContentType(hdr []*sarama.RecordHeader) (string, error) {
 	for _, h := range hdr {
-		if string(h.Key) == encoding.ContentTypeHeader {
+		if strings.EqualFold(string(h.Key), encoding.ContentTypeHeader) {
 			return string(h.Value), nil
 		}
 	}
@@ -30,6 +30,10 @@ func Test_determineContentType(t *testing.T) {
 		Key:   []byte(encoding.ContentTypeHeader),
 		Value: []byte("val1"),
 	}
+	lowerCaseHdr := &sarama.RecordHeader{
+		Key:   []byte("content-type"),
+		Value: []byte("val1"),
+	}
 
 	tests := []struct {
 		name    string
@@ -39,6 +43,7 @@ func Test_determineContentType(t *testing.T) {
 	}{
 		{"failure", args{hdr: []*sarama.RecordHeader{}}, "", true},
 		{"success", args{hdr: []*sarama.RecordHeader{validHdr}}, "", false},
+		{"success lower case", args{hdr: []*sarama.RecordHeader{lowerCaseHdr}}, "", false},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
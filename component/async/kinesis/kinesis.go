@@ -0,0 +1,541 @@
+// Package kinesis provides consumer implementation for AWS Kinesis data streams with included tracing capabilities.
+//
+// The shards of the stream are shared between the workers of a consumer group by leases, which are stored along with the
+// checkpoints of the shards in a DynamoDB table. The shards are consumed either by polling or with enhanced fan-out,
+// and aggregated records of the Kinesis Producer Library are de-aggregated into separate messages.
+package kinesis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/kinesis"
+	"github.com/aws/aws-sdk-go/service/kinesis/kinesisiface"
+	"github.com/beatlabs/patron/component/async"
+	patronErrors "github.com/beatlabs/patron/errors"
+	"github.com/beatlabs/patron/log"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	consumerComponent = "kinesis-consumer"
+
+	// workerPrefix prefixes the leases which are used as heartbeats of the workers of the consumer group.
+	workerPrefix = "worker/"
+
+	defaultPollInterval       = time.Second
+	defaultBatchSize          = 1000
+	defaultCheckpointInterval = 5 * time.Second
+	defaultLeaseDuration      = 30 * time.Second
+
+	throttleBackoff = time.Second
+	closeTimeout    = 10 * time.Second
+)
+
+type messageState string
+
+const (
+	ackMessageState     messageState = "ACK"
+	nackMessageState    messageState = "NACK"
+	fetchedMessageState messageState = "FETCHED"
+)
+
+var (
+	messageCounter     *prometheus.CounterVec
+	millisBehindLatest *prometheus.GaugeVec
+	ownedShards        *prometheus.GaugeVec
+)
+
+func init() {
+	messageCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "component",
+			Subsystem: "kinesis_consumer",
+			Name:      "message_counter",
+			Help:      "Message counter",
+		},
+		[]string{"stream", "state", "hasError"},
+	)
+	millisBehindLatest = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "component",
+			Subsystem: "kinesis_consumer",
+			Name:      "millis_behind_latest",
+			Help:      "Milliseconds the consumer is behind the tip of the shard, as reported by Kinesis",
+		},
+		[]string{"stream", "shard"},
+	)
+	ownedShards = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "component",
+			Subsystem: "kinesis_consumer",
+			Name:      "owned_shards",
+			Help:      "Number of shards whose leases are owned by the worker",
+		},
+		[]string{"stream"},
+	)
+	prometheus.MustRegister(messageCounter, millisBehindLatest, ownedShards)
+}
+
+// Factory for creating Kinesis consumers.
+type Factory struct {
+	api                kinesisiface.KinesisAPI
+	stream             string
+	group              string
+	workerID           string
+	leases             leaseStore
+	initialPosition    string
+	pollInterval       time.Duration
+	batchSize          int64
+	checkpointInterval time.Duration
+	leaseDuration      time.Duration
+	fanOut             bool
+}
+
+// NewFactory creates a new consumer factory for the consumer group of the stream.
+func NewFactory(api kinesisiface.KinesisAPI, stream, group string, oo ...OptionFunc) (*Factory, error) {
+	if api == nil {
+		return nil, errors.New("kinesis API is nil")
+	}
+	if stream == "" {
+		return nil, errors.New("stream is empty")
+	}
+	if group == "" {
+		return nil, errors.New("consumer group is empty")
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = consumerComponent
+	}
+
+	f := &Factory{
+		api:                api,
+		stream:             stream,
+		group:              group,
+		workerID:           hostname + "-" + uuid.New().String(),
+		leases:             newMemoryLeases(),
+		initialPosition:    kinesis.ShardIteratorTypeLatest,
+		pollInterval:       defaultPollInterval,
+		batchSize:          defaultBatchSize,
+		checkpointInterval: defaultCheckpointInterval,
+		leaseDuration:      defaultLeaseDuration,
+	}
+
+	for _, o := range oo {
+		err := o(f)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return f, nil
+}
+
+// Create a new Kinesis consumer.
+func (f *Factory) Create() (async.Consumer, error) {
+	return &consumer{Factory: f, shards: make(map[string]*shard)}, nil
+}
+
+type consumer struct {
+	*Factory
+	consumerARN string
+	msgs        chan async.Message
+	errs        chan error
+	cnl         context.CancelFunc
+	wg          sync.WaitGroup
+	mu          sync.Mutex
+	shards      map[string]*shard
+	closeOnce   sync.Once
+	closeErr    error
+}
+
+// OutOfOrder returns false, since the records of each shard are processed in order.
+func (c *consumer) OutOfOrder() bool {
+	return false
+}
+
+// Consume the shards of the stream, whose leases are acquired by the worker, and send their records to the channel.
+func (c *consumer) Consume(ctx context.Context) (<-chan async.Message, <-chan error, error) {
+	ctx, cnl := context.WithCancel(ctx)
+	c.cnl = cnl
+	c.msgs = make(chan async.Message)
+	c.errs = make(chan error)
+
+	if c.fanOut {
+		arn, err := c.registerConsumer(ctx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to register stream consumer: %w", err)
+		}
+		c.consumerARN = arn
+	}
+
+	if err := c.balance(ctx); err != nil {
+		return nil, nil, err
+	}
+
+	c.wg.Add(1)
+	go c.run(ctx)
+	return c.msgs, c.errs, nil
+}
+
+// Close the consumer, checkpointing the acknowledged positions and releasing the leases of its shards.
+func (c *consumer) Close() error {
+	c.closeOnce.Do(func() {
+		if c.cnl == nil {
+			return
+		}
+		c.cnl()
+		c.wg.Wait()
+
+		ctx, cnl := context.WithTimeout(context.Background(), closeTimeout)
+		defer cnl()
+		var ee []error
+		for _, s := range c.owned() {
+			c.stop(s)
+			if _, err := c.flush(ctx, s); err != nil {
+				ee = append(ee, fmt.Errorf("failed to checkpoint shard %s: %w", s.id, err))
+			}
+			if err := c.leases.release(ctx, s.id, c.workerID); err != nil {
+				ee = append(ee, fmt.Errorf("failed to release lease of shard %s: %w", s.id, err))
+			}
+		}
+		if err := c.leases.release(ctx, workerPrefix+c.workerID, c.workerID); err != nil {
+			ee = append(ee, fmt.Errorf("failed to release worker lease: %w", err))
+		}
+		ownedShards.WithLabelValues(c.stream).Set(0)
+		c.closeErr = patronErrors.Aggregate(ee...)
+	})
+	return c.closeErr
+}
+
+// run renews the leases and rebalances the shards every third of the lease duration, and checkpoints the shards periodically.
+func (c *consumer) run(ctx context.Context) {
+	defer c.wg.Done()
+	balanceTicker := time.NewTicker(c.leaseDuration / 3)
+	defer balanceTicker.Stop()
+	checkpointTicker := time.NewTicker(c.checkpointInterval)
+	defer checkpointTicker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-balanceTicker.C:
+			if err := c.balance(ctx); err != nil {
+				c.fail(ctx, err)
+				return
+			}
+		case <-checkpointTicker.C:
+			if err := c.checkpoint(ctx); err != nil {
+				c.fail(ctx, err)
+				return
+			}
+		}
+	}
+}
+
+// balance renews the leases of the worker, and acquires or releases leases, so that each active worker of the consumer group
+// owns an equal share of the shards which are ready to be consumed, i.e. they have not ended, and their parents have.
+func (c *consumer) balance(ctx context.Context) error {
+	shards, err := c.listShards(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list shards: %w", err)
+	}
+	leases, err := c.leases.list(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list leases: %w", err)
+	}
+
+	now := time.Now()
+	expires := now.Add(c.leaseDuration)
+	if _, _, err := c.leases.acquire(ctx, workerPrefix+c.workerID, c.workerID, expires, now); err != nil {
+		return fmt.Errorf("failed to renew worker lease: %w", err)
+	}
+
+	byShard := make(map[string]lease, len(leases))
+	workers := map[string]struct{}{c.workerID: {}}
+	for _, l := range leases {
+		byShard[l.shard] = l
+		if strings.HasPrefix(l.shard, workerPrefix) && l.expires.After(now) {
+			workers[l.owner] = struct{}{}
+		}
+	}
+
+	for _, s := range c.owned() {
+		ok, err := c.leases.renew(ctx, s.id, c.workerID, expires)
+		if err != nil {
+			return fmt.Errorf("failed to renew lease of shard %s: %w", s.id, err)
+		}
+		if !ok {
+			log.Warnf("lease of shard %s of stream %s was lost", s.id, c.stream)
+			c.stop(s)
+		}
+	}
+
+	listed := make(map[string]struct{}, len(shards))
+	for _, sh := range shards {
+		listed[aws.StringValue(sh.ShardId)] = struct{}{}
+	}
+	var ready []*kinesis.Shard
+	for _, sh := range shards {
+		if byShard[aws.StringValue(sh.ShardId)].checkpoint == shardEnd {
+			continue
+		}
+		if parentsEnded(sh, listed, byShard) {
+			ready = append(ready, sh)
+		}
+	}
+
+	target := (len(ready) + len(workers) - 1) / len(workers)
+	owned := c.owned()
+	for len(owned) > target {
+		s := owned[len(owned)-1]
+		owned = owned[:len(owned)-1]
+		c.stop(s)
+		if _, err := c.flush(ctx, s); err != nil {
+			return fmt.Errorf("failed to checkpoint shard %s: %w", s.id, err)
+		}
+		if err := c.leases.release(ctx, s.id, c.workerID); err != nil {
+			return fmt.Errorf("failed to release lease of shard %s: %w", s.id, err)
+		}
+	}
+
+	count := len(owned)
+	for _, sh := range ready {
+		if count >= target {
+			break
+		}
+		id := aws.StringValue(sh.ShardId)
+		if c.get(id) != nil {
+			continue
+		}
+		if l, ok := byShard[id]; ok && !l.free(c.workerID, now) {
+			continue
+		}
+		l, ok, err := c.leases.acquire(ctx, id, c.workerID, expires, now)
+		if err != nil {
+			return fmt.Errorf("failed to acquire lease of shard %s: %w", id, err)
+		}
+		if !ok {
+			continue
+		}
+		c.start(ctx, id, l.checkpoint, hasParent(sh, listed))
+		count++
+	}
+	ownedShards.WithLabelValues(c.stream).Set(float64(count))
+	return nil
+}
+
+// checkpoint stores the acknowledged positions of the shards, and releases the shards which have been consumed completely.
+func (c *consumer) checkpoint(ctx context.Context) error {
+	for _, s := range c.owned() {
+		ended, err := c.flush(ctx, s)
+		if err != nil {
+			return fmt.Errorf("failed to checkpoint shard %s: %w", s.id, err)
+		}
+		if !ended {
+			continue
+		}
+		log.Debugf("shard %s of stream %s has been consumed completely", s.id, c.stream)
+		c.stop(s)
+		if err := c.leases.release(ctx, s.id, c.workerID); err != nil {
+			return fmt.Errorf("failed to release lease of shard %s: %w", s.id, err)
+		}
+	}
+	return nil
+}
+
+// flush stores the acknowledged position of the shard, if it has changed, returning whether the shard has been consumed completely.
+func (c *consumer) flush(ctx context.Context, s *shard) (bool, error) {
+	cp, changed := s.pending()
+	if !changed {
+		return false, nil
+	}
+	ok, err := c.leases.checkpoint(ctx, s.id, c.workerID, cp)
+	if err != nil {
+		return false, err
+	}
+	if !ok {
+		log.Warnf("lease of shard %s of stream %s was lost", s.id, c.stream)
+		c.stop(s)
+		return false, nil
+	}
+	s.checkpointed(cp)
+	return cp == shardEnd, nil
+}
+
+// start consuming the shard from its checkpoint in the background.
+func (c *consumer) start(ctx context.Context, id, checkpoint string, fromParent bool) {
+	ctx, cnl := context.WithCancel(ctx)
+	s := newShard(id, checkpoint, cnl)
+	c.mu.Lock()
+	c.shards[id] = s
+	c.mu.Unlock()
+
+	initial := c.initialPosition
+	if fromParent {
+		initial = kinesis.ShardIteratorTypeTrimHorizon
+	}
+	pos := s.position(initial)
+	log.Debugf("consuming shard %s of stream %s from %s", id, c.stream, pos)
+
+	go func() {
+		defer close(s.done)
+		var err error
+		if c.fanOut {
+			err = c.subscribe(ctx, s, pos)
+		} else {
+			err = c.poll(ctx, s, pos)
+		}
+		if err != nil && ctx.Err() == nil {
+			c.fail(ctx, fmt.Errorf("failed to consume shard %s: %w", id, err))
+		}
+	}()
+}
+
+// stop consuming the shard, waiting for its reader to return.
+func (c *consumer) stop(s *shard) {
+	s.cnl()
+	<-s.done
+	c.mu.Lock()
+	if c.shards[s.id] == s {
+		delete(c.shards, s.id)
+	}
+	c.mu.Unlock()
+}
+
+func (c *consumer) get(id string) *shard {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.shards[id]
+}
+
+// owned returns the shards owned by the worker, sorted by their IDs.
+func (c *consumer) owned() []*shard {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	shards := make([]*shard, 0, len(c.shards))
+	for _, s := range c.shards {
+		shards = append(shards, s)
+	}
+	sort.Slice(shards, func(i, j int) bool { return shards[i].id < shards[j].id })
+	return shards
+}
+
+func (c *consumer) fail(ctx context.Context, err error) {
+	select {
+	case c.errs <- err:
+	case <-ctx.Done():
+	}
+}
+
+func (c *consumer) listShards(ctx context.Context) ([]*kinesis.Shard, error) {
+	var shards []*kinesis.Shard
+	input := &kinesis.ListShardsInput{StreamName: aws.String(c.stream)}
+	for {
+		out, err := c.api.ListShardsWithContext(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+		shards = append(shards, out.Shards...)
+		if out.NextToken == nil {
+			return shards, nil
+		}
+		input = &kinesis.ListShardsInput{NextToken: out.NextToken}
+	}
+}
+
+// registerConsumer registers the consumer group as a consumer of the stream, if it is not registered already, and waits until it is active.
+func (c *consumer) registerConsumer(ctx context.Context) (string, error) {
+	summary, err := c.api.DescribeStreamSummaryWithContext(ctx, &kinesis.DescribeStreamSummaryInput{StreamName: aws.String(c.stream)})
+	if err != nil {
+		return "", err
+	}
+	streamARN := summary.StreamDescriptionSummary.StreamARN
+
+	_, err = c.api.RegisterStreamConsumerWithContext(ctx, &kinesis.RegisterStreamConsumerInput{
+		StreamARN:    streamARN,
+		ConsumerName: aws.String(c.group),
+	})
+	if err != nil && errorCode(err) != kinesis.ErrCodeResourceInUseException {
+		return "", err
+	}
+
+	for {
+		out, err := c.api.DescribeStreamConsumerWithContext(ctx, &kinesis.DescribeStreamConsumerInput{
+			StreamARN:    streamARN,
+			ConsumerName: aws.String(c.group),
+		})
+		if err != nil {
+			return "", err
+		}
+		if aws.StringValue(out.ConsumerDescription.ConsumerStatus) == kinesis.ConsumerStatusActive {
+			return aws.StringValue(out.ConsumerDescription.ConsumerARN), nil
+		}
+		if !wait(ctx, time.Second) {
+			return "", ctx.Err()
+		}
+	}
+}
+
+// parentsEnded returns whether the parents of the shard, which still exist in the stream, have been consumed completely.
+func parentsEnded(sh *kinesis.Shard, listed map[string]struct{}, leases map[string]lease) bool {
+	for _, parent := range []*string{sh.ParentShardId, sh.AdjacentParentShardId} {
+		if parent == nil {
+			continue
+		}
+		if _, ok := listed[*parent]; ok && leases[*parent].checkpoint != shardEnd {
+			return false
+		}
+	}
+	return true
+}
+
+func hasParent(sh *kinesis.Shard, listed map[string]struct{}) bool {
+	for _, parent := range []*string{sh.ParentShardId, sh.AdjacentParentShardId} {
+		if parent == nil {
+			continue
+		}
+		if _, ok := listed[*parent]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func errorCode(err error) string {
+	var awsErr awserr.Error
+	if errors.As(err, &awsErr) {
+		return awsErr.Code()
+	}
+	return ""
+}
+
+func wait(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+func messageCountInc(stream string, state messageState, count int) {
+	messageCounter.WithLabelValues(stream, string(state), "false").Add(float64(count))
+}
+
+func messageCountErrorInc(stream string, state messageState, count int) {
+	messageCounter.WithLabelValues(stream, string(state), "true").Add(float64(count))
+}
@@ -0,0 +1,526 @@
+package kinesis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/kinesis"
+	"github.com/aws/aws-sdk-go/service/kinesis/kinesisiface"
+	"github.com/beatlabs/patron/component/async"
+	"github.com/beatlabs/patron/correlation"
+	"github.com/beatlabs/patron/internal/kpl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewFactory(t *testing.T) {
+	t.Parallel()
+	type args struct {
+		api    kinesisiface.KinesisAPI
+		stream string
+		group  string
+		oo     []OptionFunc
+	}
+	tests := map[string]struct {
+		args        args
+		expectedErr string
+	}{
+		"success": {
+			args: args{api: &stubKinesis{}, stream: "stream", group: "group", oo: []OptionFunc{EnhancedFanOut()}},
+		},
+		"missing API": {
+			args:        args{stream: "stream", group: "group"},
+			expectedErr: "kinesis API is nil",
+		},
+		"missing stream": {
+			args:        args{api: &stubKinesis{}, group: "group"},
+			expectedErr: "stream is empty",
+		},
+		"missing group": {
+			args:        args{api: &stubKinesis{}, stream: "stream"},
+			expectedErr: "consumer group is empty",
+		},
+		"invalid option": {
+			args:        args{api: &stubKinesis{}, stream: "stream", group: "group", oo: []OptionFunc{BatchSize(0)}},
+			expectedErr: "batch size should be between 1 and 10000",
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			got, err := NewFactory(tt.args.api, tt.args.stream, tt.args.group, tt.args.oo...)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, got)
+				assert.NotEmpty(t, got.workerID)
+				assert.IsType(t, &memoryLeases{}, got.leases)
+			}
+		})
+	}
+}
+
+func TestConsumer_Poll(t *testing.T) {
+	t.Parallel()
+	api := newStubKinesis()
+	api.addShard("shard-0", nil, false,
+		plainRecord(`{"id":1}`),
+		aggregatedRecord(t, map[string]string{correlation.HeaderID: "123"}, `{"id":2}`, `{"id":3}`),
+	)
+	f, err := NewFactory(api, "stream", "group", InitialPosition(kinesis.ShardIteratorTypeTrimHorizon), PollInterval(time.Millisecond))
+	require.NoError(t, err)
+	cns, err := f.Create()
+	require.NoError(t, err)
+
+	chMsg, _, err := cns.Consume(context.Background())
+	require.NoError(t, err)
+
+	msgs := receive(t, chMsg, 3)
+	ids := make([]int, 0, len(msgs))
+	for _, msg := range msgs {
+		var v struct{ ID int }
+		require.NoError(t, msg.Decode(&v))
+		ids = append(ids, v.ID)
+		assert.Equal(t, "stream", msg.Source())
+		assert.NotEmpty(t, correlation.IDFromContext(msg.Context()))
+		assert.NoError(t, msg.Ack())
+	}
+	assert.Equal(t, []int{1, 2, 3}, ids)
+	assert.Equal(t, "123", correlation.IDFromContext(msgs[1].Context()))
+	assert.Equal(t, []byte(`{"id":2}`), msgs[1].Payload())
+	assert.Equal(t, "2", *msgs[1].Raw().(*kinesis.Record).SequenceNumber)
+	assert.False(t, cns.OutOfOrder())
+
+	require.NoError(t, cns.Close())
+	leases, err := f.leases.list(context.Background())
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"", "2"}, checkpoints(leases))
+	for _, l := range leases {
+		assert.Empty(t, l.owner)
+	}
+}
+
+func TestConsumer_Resume(t *testing.T) {
+	t.Parallel()
+	api := newStubKinesis()
+	api.addShard("shard-0", nil, true,
+		plainRecord(`{"id":1}`),
+		aggregatedRecord(t, nil, `{"id":2}`, `{"id":3}`, `{"id":4}`),
+		plainRecord(`{"id":5}`),
+	)
+	f, err := NewFactory(api, "stream", "group", PollInterval(time.Millisecond))
+	require.NoError(t, err)
+	// the first user record of the aggregated record has been acknowledged
+	_, _, err = f.leases.acquire(context.Background(), "shard-0", "other", time.Now(), time.Now())
+	require.NoError(t, err)
+	_, err = f.leases.checkpoint(context.Background(), "shard-0", "other", "2/0")
+	require.NoError(t, err)
+
+	cns, err := f.Create()
+	require.NoError(t, err)
+	chMsg, _, err := cns.Consume(context.Background())
+	require.NoError(t, err)
+
+	msgs := receive(t, chMsg, 3)
+	assert.Equal(t, []string{`{"id":3}`, `{"id":4}`, `{"id":5}`}, payloads(msgs))
+	require.NoError(t, msgs[0].Ack())
+	require.NoError(t, msgs[1].Nack())
+	require.NoError(t, cns.Close())
+
+	assert.Equal(t, []string{"AT_SEQUENCE_NUMBER 2"}, api.iteratorRequests())
+	leases, err := f.leases.list(context.Background())
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"", "2/1"}, checkpoints(leases))
+}
+
+func TestConsumer_Resharding(t *testing.T) {
+	t.Parallel()
+	api := newStubKinesis()
+	api.addShard("shard-0", nil, true, plainRecord(`{"id":1}`))
+	api.addShard("shard-1", aws.String("shard-0"), false, plainRecord(`{"id":2}`))
+	f, err := NewFactory(api, "stream", "group", PollInterval(time.Millisecond), CheckpointInterval(5*time.Millisecond),
+		LeaseDuration(15*time.Millisecond))
+	require.NoError(t, err)
+	cns, err := f.Create()
+	require.NoError(t, err)
+
+	chMsg, _, err := cns.Consume(context.Background())
+	require.NoError(t, err)
+
+	// the parent is consumed from the initial position, and the child from its beginning, once the parent has ended
+	msgs := receive(t, chMsg, 1)
+	assert.Equal(t, []string{`{"id":1}`}, payloads(msgs))
+	select {
+	case msg := <-chMsg:
+		assert.Fail(t, "unexpected message before the parent is acknowledged", string(msg.Payload()))
+	case <-time.After(50 * time.Millisecond):
+	}
+	require.NoError(t, msgs[0].Ack())
+	msgs = receive(t, chMsg, 1)
+	assert.Equal(t, []string{`{"id":2}`}, payloads(msgs))
+	require.NoError(t, cns.Close())
+
+	assert.Equal(t, []string{"LATEST", "TRIM_HORIZON"}, api.iteratorRequests())
+	leases, err := f.leases.list(context.Background())
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{shardEnd, "", ""}, checkpoints(leases))
+}
+
+func TestConsumer_Balance(t *testing.T) {
+	t.Parallel()
+	api := newStubKinesis()
+	api.addShard("shard-0", nil, false)
+	api.addShard("shard-1", nil, false)
+	api.addShard("shard-2", nil, false)
+	api.addShard("shard-3", nil, false)
+	f, err := NewFactory(api, "stream", "group", WorkerID("worker-1"))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	expires := time.Now().Add(time.Minute)
+	_, _, err = f.leases.acquire(ctx, workerPrefix+"worker-2", "worker-2", expires, time.Now())
+	require.NoError(t, err)
+	_, _, err = f.leases.acquire(ctx, "shard-0", "worker-2", expires, time.Now())
+	require.NoError(t, err)
+
+	cns, err := f.Create()
+	require.NoError(t, err)
+	_, _, err = cns.Consume(ctx)
+	require.NoError(t, err)
+	c := cns.(*consumer)
+	assert.Equal(t, []string{"shard-1", "shard-2"}, ownedIDs(c))
+
+	// two more workers join, so the worker releases a shard
+	for _, worker := range []string{"worker-3", "worker-4"} {
+		_, _, err = f.leases.acquire(ctx, workerPrefix+worker, worker, expires, time.Now())
+		require.NoError(t, err)
+	}
+	require.NoError(t, c.balance(ctx))
+	assert.Equal(t, []string{"shard-1"}, ownedIDs(c))
+
+	// the lease expires and is taken over, so the worker stops consuming the shard once it fails to checkpoint
+	_, err = f.leases.renew(ctx, "shard-1", "worker-1", time.Now())
+	require.NoError(t, err)
+	_, ok, err := f.leases.acquire(ctx, "shard-1", "worker-3", expires, time.Now())
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.NoError(t, c.flushAll(ctx))
+	assert.Empty(t, ownedIDs(c))
+
+	require.NoError(t, cns.Close())
+	leases, err := f.leases.list(ctx)
+	require.NoError(t, err)
+	owners := make(map[string]string)
+	for _, l := range leases {
+		owners[l.shard] = l.owner
+	}
+	assert.Equal(t, map[string]string{
+		"shard-0": "worker-2", "shard-1": "worker-3", "shard-2": "",
+		workerPrefix + "worker-1": "", workerPrefix + "worker-2": "worker-2",
+		workerPrefix + "worker-3": "worker-3", workerPrefix + "worker-4": "worker-4",
+	}, owners)
+}
+
+func TestConsumer_FanOut(t *testing.T) {
+	t.Parallel()
+	api := newStubKinesis()
+	api.addShard("shard-0", nil, false)
+	api.events = [][]kinesis.SubscribeToShardEventStreamEvent{
+		{
+			&kinesis.SubscribeToShardEvent{Records: []*kinesis.Record{plainRecord(`{"id":1}`)}, ContinuationSequenceNumber: aws.String("1"), MillisBehindLatest: aws.Int64(0)},
+		},
+		{
+			&kinesis.SubscribeToShardEvent{Records: []*kinesis.Record{plainRecord(`{"id":2}`)}, MillisBehindLatest: aws.Int64(0)},
+		},
+	}
+	f, err := NewFactory(api, "stream", "group", EnhancedFanOut(), CheckpointInterval(5*time.Millisecond))
+	require.NoError(t, err)
+	cns, err := f.Create()
+	require.NoError(t, err)
+
+	chMsg, _, err := cns.Consume(context.Background())
+	require.NoError(t, err)
+	msgs := receive(t, chMsg, 2)
+	assert.Equal(t, []string{`{"id":1}`, `{"id":2}`}, payloads(msgs))
+	for _, msg := range msgs {
+		require.NoError(t, msg.Ack())
+	}
+	assert.Eventually(t, func() bool { return len(ownedIDs(cns.(*consumer))) == 0 }, time.Second, time.Millisecond)
+	require.NoError(t, cns.Close())
+
+	assert.Equal(t, "arn:consumer", cns.(*consumer).consumerARN)
+	assert.Equal(t, []string{"LATEST", "AFTER_SEQUENCE_NUMBER 1"}, api.subscriptionRequests())
+	leases, err := f.leases.list(context.Background())
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{shardEnd, ""}, checkpoints(leases))
+}
+
+func TestConsumer_Error(t *testing.T) {
+	t.Parallel()
+	api := newStubKinesis()
+	api.addShard("shard-0", nil, false)
+	api.getRecordsErr = errors.New("failure")
+	f, err := NewFactory(api, "stream", "group")
+	require.NoError(t, err)
+	cns, err := f.Create()
+	require.NoError(t, err)
+
+	_, chErr, err := cns.Consume(context.Background())
+	require.NoError(t, err)
+	select {
+	case err := <-chErr:
+		assert.EqualError(t, err, "failed to consume shard shard-0: failure")
+	case <-time.After(time.Second):
+		assert.Fail(t, "expected an error")
+	}
+	require.NoError(t, cns.Close())
+
+	api.listShardsErr = errors.New("failure")
+	cns, err = f.Create()
+	require.NoError(t, err)
+	_, _, err = cns.Consume(context.Background())
+	assert.EqualError(t, err, "failed to list shards: failure")
+	require.NoError(t, cns.Close())
+}
+
+func TestParseCheckpoint(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		checkpoint string
+		expected   checkpointPosition
+	}{
+		"sequence number":         {checkpoint: "123", expected: checkpointPosition{sequenceNumber: "123", index: -1}},
+		"user record":             {checkpoint: "123/4", expected: checkpointPosition{sequenceNumber: "123", index: 4}},
+		"invalid user record":     {checkpoint: "123/a", expected: checkpointPosition{sequenceNumber: "123", index: -1}},
+		"empty sequence number":   {checkpoint: "", expected: checkpointPosition{index: -1}},
+		"multiple user separator": {checkpoint: "123/4/5", expected: checkpointPosition{sequenceNumber: "123", index: -1}},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.expected, parseCheckpoint(tt.checkpoint))
+		})
+	}
+}
+
+// flushAll checkpoints the owned shards, as done periodically by the consumer.
+func (c *consumer) flushAll(ctx context.Context) error {
+	for _, s := range c.owned() {
+		s.ack(s.id)
+	}
+	return c.checkpoint(ctx)
+}
+
+func receive(t *testing.T, ch <-chan async.Message, n int) []async.Message {
+	msgs := make([]async.Message, 0, n)
+	for len(msgs) < n {
+		select {
+		case msg := <-ch:
+			msgs = append(msgs, msg)
+		case <-time.After(time.Second):
+			require.FailNow(t, fmt.Sprintf("received %d of %d messages", len(msgs), n))
+		}
+	}
+	return msgs
+}
+
+func payloads(msgs []async.Message) []string {
+	pp := make([]string, 0, len(msgs))
+	for _, msg := range msgs {
+		pp = append(pp, string(msg.Payload()))
+	}
+	return pp
+}
+
+func checkpoints(leases []lease) []string {
+	cc := make([]string, 0, len(leases))
+	for _, l := range leases {
+		cc = append(cc, l.checkpoint)
+	}
+	return cc
+}
+
+func ownedIDs(c *consumer) []string {
+	var ids []string
+	for _, s := range c.owned() {
+		ids = append(ids, s.id)
+	}
+	return ids
+}
+
+func plainRecord(data string) *kinesis.Record {
+	return &kinesis.Record{PartitionKey: aws.String("key"), Data: []byte(data)}
+}
+
+func aggregatedRecord(t *testing.T, tags map[string]string, data ...string) *kinesis.Record {
+	rr := make([]kpl.Record, 0, len(data))
+	for _, d := range data {
+		rr = append(rr, kpl.Record{PartitionKey: "key", Data: []byte(d), Tags: tags})
+	}
+	r := kpl.Marshal(rr)
+	require.True(t, kpl.IsAggregated(r))
+	return &kinesis.Record{PartitionKey: aws.String("key"), Data: r}
+}
+
+type stubShard struct {
+	parent  *string
+	closed  bool
+	records []*kinesis.Record
+}
+
+type stubKinesis struct {
+	kinesisiface.KinesisAPI
+	mu            sync.Mutex
+	ids           []string
+	shards        map[string]*stubShard
+	seq           int
+	iterators     []string
+	subscriptions []string
+	// events are sent over the subscriptions, one slice per subscription.
+	events        [][]kinesis.SubscribeToShardEventStreamEvent
+	listShardsErr error
+	getRecordsErr error
+}
+
+func newStubKinesis() *stubKinesis {
+	return &stubKinesis{shards: make(map[string]*stubShard)}
+}
+
+// addShard adds a shard with the records, assigning them increasing sequence numbers.
+func (s *stubKinesis) addShard(id string, parent *string, closed bool, records ...*kinesis.Record) {
+	for _, r := range records {
+		s.seq++
+		r.SequenceNumber = aws.String(strconv.Itoa(s.seq))
+	}
+	s.ids = append(s.ids, id)
+	s.shards[id] = &stubShard{parent: parent, closed: closed, records: records}
+}
+
+func (s *stubKinesis) iteratorRequests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string{}, s.iterators...)
+}
+
+func (s *stubKinesis) subscriptionRequests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string{}, s.subscriptions...)
+}
+
+func (s *stubKinesis) ListShardsWithContext(aws.Context, *kinesis.ListShardsInput, ...request.Option) (*kinesis.ListShardsOutput, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.listShardsErr != nil {
+		return nil, s.listShardsErr
+	}
+	out := &kinesis.ListShardsOutput{}
+	for _, id := range s.ids {
+		out.Shards = append(out.Shards, &kinesis.Shard{ShardId: aws.String(id), ParentShardId: s.shards[id].parent})
+	}
+	return out, nil
+}
+
+func (s *stubKinesis) GetShardIteratorWithContext(_ aws.Context, input *kinesis.GetShardIteratorInput, _ ...request.Option) (*kinesis.GetShardIteratorOutput, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	pos := startingPosition{iteratorType: *input.ShardIteratorType, sequenceNumber: aws.StringValue(input.StartingSequenceNumber)}
+	s.iterators = append(s.iterators, pos.String())
+	records := s.shards[*input.ShardId].records
+	// the records of the stub shards are written before the consumer starts, so LATEST is treated as TRIM_HORIZON
+	idx := 0
+	switch pos.iteratorType {
+	case kinesis.ShardIteratorTypeAtSequenceNumber, kinesis.ShardIteratorTypeAfterSequenceNumber:
+		for i, r := range records {
+			if *r.SequenceNumber == pos.sequenceNumber {
+				idx = i
+			}
+		}
+		if pos.iteratorType == kinesis.ShardIteratorTypeAfterSequenceNumber {
+			idx++
+		}
+	}
+	return &kinesis.GetShardIteratorOutput{ShardIterator: aws.String(*input.ShardId + "|" + strconv.Itoa(idx))}, nil
+}
+
+func (s *stubKinesis) GetRecordsWithContext(_ aws.Context, input *kinesis.GetRecordsInput, _ ...request.Option) (*kinesis.GetRecordsOutput, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.getRecordsErr != nil {
+		return nil, s.getRecordsErr
+	}
+	parts := strings.Split(*input.ShardIterator, "|")
+	sh := s.shards[parts[0]]
+	idx, _ := strconv.Atoi(parts[1])
+	out := &kinesis.GetRecordsOutput{Records: sh.records[idx:], MillisBehindLatest: aws.Int64(0)}
+	if !sh.closed {
+		out.NextShardIterator = aws.String(parts[0] + "|" + strconv.Itoa(len(sh.records)))
+	}
+	return out, nil
+}
+
+func (s *stubKinesis) DescribeStreamSummaryWithContext(aws.Context, *kinesis.DescribeStreamSummaryInput, ...request.Option) (*kinesis.DescribeStreamSummaryOutput, error) {
+	return &kinesis.DescribeStreamSummaryOutput{StreamDescriptionSummary: &kinesis.StreamDescriptionSummary{StreamARN: aws.String("arn:stream")}}, nil
+}
+
+func (s *stubKinesis) RegisterStreamConsumerWithContext(aws.Context, *kinesis.RegisterStreamConsumerInput, ...request.Option) (*kinesis.RegisterStreamConsumerOutput, error) {
+	return nil, awserr.New(kinesis.ErrCodeResourceInUseException, "consumer exists", nil)
+}
+
+func (s *stubKinesis) DescribeStreamConsumerWithContext(aws.Context, *kinesis.DescribeStreamConsumerInput, ...request.Option) (*kinesis.DescribeStreamConsumerOutput, error) {
+	return &kinesis.DescribeStreamConsumerOutput{ConsumerDescription: &kinesis.ConsumerDescription{
+		ConsumerARN:    aws.String("arn:consumer"),
+		ConsumerStatus: aws.String(kinesis.ConsumerStatusActive),
+	}}, nil
+}
+
+func (s *stubKinesis) SubscribeToShardWithContext(_ aws.Context, input *kinesis.SubscribeToShardInput, _ ...request.Option) (*kinesis.SubscribeToShardOutput, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	pos := startingPosition{iteratorType: *input.StartingPosition.Type, sequenceNumber: aws.StringValue(input.StartingPosition.SequenceNumber)}
+	s.subscriptions = append(s.subscriptions, pos.String())
+	var events []kinesis.SubscribeToShardEventStreamEvent
+	if len(s.events) > 0 {
+		events, s.events = s.events[0], s.events[1:]
+	}
+	ch := make(chan kinesis.SubscribeToShardEventStreamEvent, len(events))
+	for _, ev := range events {
+		ch <- ev
+	}
+	close(ch)
+	stream := kinesis.NewSubscribeToShardEventStream(func(es *kinesis.SubscribeToShardEventStream) {
+		es.Reader = &stubEventReader{events: ch}
+		es.StreamCloser = ioutil.NopCloser(nil)
+	})
+	return &kinesis.SubscribeToShardOutput{EventStream: stream}, nil
+}
+
+type stubEventReader struct {
+	events chan kinesis.SubscribeToShardEventStreamEvent
+}
+
+func (r *stubEventReader) Events() <-chan kinesis.SubscribeToShardEventStreamEvent {
+	return r.events
+}
+
+func (r *stubEventReader) Close() error {
+	return nil
+}
+
+func (r *stubEventReader) Err() error {
+	return nil
+}
@@ -0,0 +1,267 @@
+package kinesis
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+)
+
+const (
+	// shardEnd is the checkpoint of shards which have been consumed completely.
+	shardEnd = "SHARD_END"
+
+	attrGroup      = "group"
+	attrShard      = "shard"
+	attrOwner      = "owner"
+	attrExpires    = "expires"
+	attrCheckpoint = "checkpoint"
+)
+
+// lease of a shard, which allows a single worker of a consumer group to consume it.
+type lease struct {
+	shard      string
+	owner      string
+	expires    time.Time
+	checkpoint string
+}
+
+// free returns whether the lease can be acquired by the owner.
+func (l lease) free(owner string, now time.Time) bool {
+	return l.owner == "" || l.owner == owner || !l.expires.After(now)
+}
+
+// leaseStore stores the leases and checkpoints of the shards of a stream for a consumer group.
+// Updates of a lease are only applied when the lease is owned by the given owner, except for acquiring a free lease.
+type leaseStore interface {
+	// list the leases of all shards.
+	list(ctx context.Context) ([]lease, error)
+	// acquire the lease of the shard, if it is free, returning whether it was acquired.
+	acquire(ctx context.Context, shard, owner string, expires, now time.Time) (lease, bool, error)
+	// renew the lease of the shard, returning whether it is still owned.
+	renew(ctx context.Context, shard, owner string, expires time.Time) (bool, error)
+	// checkpoint the position of the shard, returning whether the lease is still owned.
+	checkpoint(ctx context.Context, shard, owner, checkpoint string) (bool, error)
+	// release the lease of the shard.
+	release(ctx context.Context, shard, owner string) error
+}
+
+// memoryLeases stores the leases in memory, which allows a single worker per consumer group and keeps checkpoints
+// only for the lifetime of the process.
+type memoryLeases struct {
+	mu     sync.Mutex
+	leases map[string]lease
+}
+
+func newMemoryLeases() *memoryLeases {
+	return &memoryLeases{leases: make(map[string]lease)}
+}
+
+func (m *memoryLeases) list(_ context.Context) ([]lease, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	leases := make([]lease, 0, len(m.leases))
+	for _, l := range m.leases {
+		leases = append(leases, l)
+	}
+	return leases, nil
+}
+
+func (m *memoryLeases) acquire(_ context.Context, shard, owner string, expires, now time.Time) (lease, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	l := m.leases[shard]
+	if !l.free(owner, now) {
+		return lease{}, false, nil
+	}
+	l.shard, l.owner, l.expires = shard, owner, expires
+	m.leases[shard] = l
+	return l, true, nil
+}
+
+func (m *memoryLeases) renew(_ context.Context, shard, owner string, expires time.Time) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	l, ok := m.leases[shard]
+	if !ok || l.owner != owner {
+		return false, nil
+	}
+	l.expires = expires
+	m.leases[shard] = l
+	return true, nil
+}
+
+func (m *memoryLeases) checkpoint(_ context.Context, shard, owner, checkpoint string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	l, ok := m.leases[shard]
+	if !ok || l.owner != owner {
+		return false, nil
+	}
+	l.checkpoint = checkpoint
+	m.leases[shard] = l
+	return true, nil
+}
+
+func (m *memoryLeases) release(_ context.Context, shard, owner string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	l, ok := m.leases[shard]
+	if !ok || l.owner != owner {
+		return nil
+	}
+	l.owner, l.expires = "", time.Time{}
+	m.leases[shard] = l
+	return nil
+}
+
+// dynamoLeases stores the leases in a DynamoDB table, with the group as partition key and the shard ID as sort key,
+// both of type string. The group of the leases is the consumer group along with the stream name.
+type dynamoLeases struct {
+	db    dynamodbiface.DynamoDBAPI
+	table string
+	group string
+}
+
+func (d *dynamoLeases) list(ctx context.Context) ([]lease, error) {
+	input := &dynamodb.QueryInput{
+		TableName:                 aws.String(d.table),
+		ConsistentRead:            aws.Bool(true),
+		KeyConditionExpression:    aws.String("#group = :group"),
+		ExpressionAttributeNames:  map[string]*string{"#group": aws.String(attrGroup)},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{":group": {S: aws.String(d.group)}},
+	}
+	var leases []lease
+	for {
+		out, err := d.db.QueryWithContext(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range out.Items {
+			l, err := leaseFromItem(item)
+			if err != nil {
+				return nil, err
+			}
+			leases = append(leases, l)
+		}
+		if len(out.LastEvaluatedKey) == 0 {
+			return leases, nil
+		}
+		input.ExclusiveStartKey = out.LastEvaluatedKey
+	}
+}
+
+func (d *dynamoLeases) acquire(ctx context.Context, shard, owner string, expires, now time.Time) (lease, bool, error) {
+	out, err := d.db.UpdateItemWithContext(ctx, &dynamodb.UpdateItemInput{
+		TableName:           aws.String(d.table),
+		Key:                 d.key(shard),
+		UpdateExpression:    aws.String("SET #owner = :owner, #expires = :expires"),
+		ConditionExpression: aws.String("attribute_not_exists(#owner) OR #owner = :owner OR #expires <= :now"),
+		ExpressionAttributeNames: map[string]*string{
+			"#owner":   aws.String(attrOwner),
+			"#expires": aws.String(attrExpires),
+		},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":owner":   {S: aws.String(owner)},
+			":expires": timeValue(expires),
+			":now":     timeValue(now),
+		},
+		ReturnValues: aws.String(dynamodb.ReturnValueAllNew),
+	})
+	if err != nil {
+		if conditionFailed(err) {
+			return lease{}, false, nil
+		}
+		return lease{}, false, err
+	}
+	l, err := leaseFromItem(out.Attributes)
+	if err != nil {
+		return lease{}, false, err
+	}
+	return l, true, nil
+}
+
+func (d *dynamoLeases) renew(ctx context.Context, shard, owner string, expires time.Time) (bool, error) {
+	return d.update(ctx, shard, owner, attrExpires, timeValue(expires))
+}
+
+func (d *dynamoLeases) checkpoint(ctx context.Context, shard, owner, checkpoint string) (bool, error) {
+	return d.update(ctx, shard, owner, attrCheckpoint, &dynamodb.AttributeValue{S: aws.String(checkpoint)})
+}
+
+func (d *dynamoLeases) release(ctx context.Context, shard, owner string) error {
+	_, err := d.db.UpdateItemWithContext(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 aws.String(d.table),
+		Key:                       d.key(shard),
+		UpdateExpression:          aws.String("REMOVE #owner, #expires"),
+		ConditionExpression:       aws.String("#owner = :owner"),
+		ExpressionAttributeNames:  map[string]*string{"#owner": aws.String(attrOwner), "#expires": aws.String(attrExpires)},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{":owner": {S: aws.String(owner)}},
+	})
+	if err != nil && !conditionFailed(err) {
+		return err
+	}
+	return nil
+}
+
+// update sets the attribute of the lease to the value, if the lease is owned by the owner.
+func (d *dynamoLeases) update(ctx context.Context, shard, owner, attr string, value *dynamodb.AttributeValue) (bool, error) {
+	_, err := d.db.UpdateItemWithContext(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 aws.String(d.table),
+		Key:                       d.key(shard),
+		UpdateExpression:          aws.String("SET #attr = :value"),
+		ConditionExpression:       aws.String("#owner = :owner"),
+		ExpressionAttributeNames:  map[string]*string{"#owner": aws.String(attrOwner), "#attr": aws.String(attr)},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{":owner": {S: aws.String(owner)}, ":value": value},
+	})
+	if err != nil {
+		if conditionFailed(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (d *dynamoLeases) key(shard string) map[string]*dynamodb.AttributeValue {
+	return map[string]*dynamodb.AttributeValue{
+		attrGroup: {S: aws.String(d.group)},
+		attrShard: {S: aws.String(shard)},
+	}
+}
+
+func leaseFromItem(item map[string]*dynamodb.AttributeValue) (lease, error) {
+	l := lease{}
+	if v, ok := item[attrShard]; ok {
+		l.shard = aws.StringValue(v.S)
+	}
+	if v, ok := item[attrOwner]; ok {
+		l.owner = aws.StringValue(v.S)
+	}
+	if v, ok := item[attrCheckpoint]; ok {
+		l.checkpoint = aws.StringValue(v.S)
+	}
+	if v, ok := item[attrExpires]; ok {
+		ms, err := strconv.ParseInt(aws.StringValue(v.N), 10, 64)
+		if err != nil {
+			return lease{}, errors.New("lease expiration is not a number")
+		}
+		l.expires = time.Unix(0, ms*int64(time.Millisecond))
+	}
+	return l, nil
+}
+
+func timeValue(t time.Time) *dynamodb.AttributeValue {
+	return &dynamodb.AttributeValue{N: aws.String(strconv.FormatInt(t.UnixNano()/int64(time.Millisecond), 10))}
+}
+
+func conditionFailed(err error) bool {
+	var awsErr awserr.Error
+	return errors.As(err, &awsErr) && awsErr.Code() == dynamodb.ErrCodeConditionalCheckFailedException
+}
@@ -0,0 +1,141 @@
+package kinesis
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryLeases(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	now := time.Now()
+	m := newMemoryLeases()
+
+	l, ok, err := m.acquire(ctx, "shard-0", "worker-1", now.Add(time.Minute), now)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, lease{shard: "shard-0", owner: "worker-1", expires: now.Add(time.Minute)}, l)
+
+	_, ok, err = m.acquire(ctx, "shard-0", "worker-2", now.Add(time.Minute), now)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	ok, err = m.checkpoint(ctx, "shard-0", "worker-2", "1")
+	require.NoError(t, err)
+	assert.False(t, ok)
+	ok, err = m.renew(ctx, "shard-0", "worker-2", now.Add(time.Minute))
+	require.NoError(t, err)
+	assert.False(t, ok)
+	require.NoError(t, m.release(ctx, "shard-0", "worker-2"))
+
+	ok, err = m.checkpoint(ctx, "shard-0", "worker-1", "1")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = m.renew(ctx, "shard-0", "worker-1", now)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	// the expired lease is acquired along with its checkpoint
+	l, ok, err = m.acquire(ctx, "shard-0", "worker-2", now.Add(time.Minute), now)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "1", l.checkpoint)
+	require.NoError(t, m.release(ctx, "shard-0", "worker-2"))
+
+	leases, err := m.list(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []lease{{shard: "shard-0", checkpoint: "1"}}, leases)
+}
+
+func TestDynamoLeases(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	expires := time.Unix(1600000000, 0)
+	db := &stubDynamoDB{}
+	d := &dynamoLeases{db: db, table: "leases", group: "group/stream"}
+
+	db.items = [][]map[string]*dynamodb.AttributeValue{
+		{{attrShard: {S: aws.String("shard-0")}, attrOwner: {S: aws.String("worker-1")}, attrExpires: {N: aws.String("1600000000000")}}},
+		{{attrShard: {S: aws.String("shard-1")}, attrCheckpoint: {S: aws.String("1")}}},
+	}
+	leases, err := d.list(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []lease{{shard: "shard-0", owner: "worker-1", expires: expires}, {shard: "shard-1", checkpoint: "1"}}, leases)
+	require.Len(t, db.queries, 2)
+	assert.Equal(t, "group/stream", *db.queries[0].ExpressionAttributeValues[":group"].S)
+	assert.True(t, *db.queries[0].ConsistentRead)
+	assert.Equal(t, "shard-0", *db.queries[1].ExclusiveStartKey[attrShard].S)
+
+	db.attributes = map[string]*dynamodb.AttributeValue{attrShard: {S: aws.String("shard-0")}, attrCheckpoint: {S: aws.String("2")}}
+	l, ok, err := d.acquire(ctx, "shard-0", "worker-1", expires, expires)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, lease{shard: "shard-0", checkpoint: "2"}, l)
+	update := db.updates[0]
+	assert.Equal(t, "leases", *update.TableName)
+	assert.Equal(t, "group/stream", *update.Key[attrGroup].S)
+	assert.Equal(t, "shard-0", *update.Key[attrShard].S)
+	assert.Equal(t, "1600000000000", *update.ExpressionAttributeValues[":expires"].N)
+
+	ok, err = d.checkpoint(ctx, "shard-0", "worker-1", "3")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "3", *db.updates[1].ExpressionAttributeValues[":value"].S)
+	assert.Equal(t, attrCheckpoint, *db.updates[1].ExpressionAttributeNames["#attr"])
+
+	db.err = awserr.New(dynamodb.ErrCodeConditionalCheckFailedException, "condition failed", nil)
+	_, ok, err = d.acquire(ctx, "shard-0", "worker-2", expires, expires)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	ok, err = d.renew(ctx, "shard-0", "worker-2", expires)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	require.NoError(t, d.release(ctx, "shard-0", "worker-2"))
+
+	db.err = errors.New("failure")
+	_, err = d.checkpoint(ctx, "shard-0", "worker-1", "3")
+	assert.EqualError(t, err, "failure")
+	assert.EqualError(t, d.release(ctx, "shard-0", "worker-1"), "failure")
+	_, err = d.list(ctx)
+	assert.EqualError(t, err, "failure")
+}
+
+type stubDynamoDB struct {
+	dynamodbiface.DynamoDBAPI
+	err error
+	// items are returned by the queries, one page per query.
+	items      [][]map[string]*dynamodb.AttributeValue
+	attributes map[string]*dynamodb.AttributeValue
+	queries    []*dynamodb.QueryInput
+	updates    []*dynamodb.UpdateItemInput
+}
+
+func (s *stubDynamoDB) QueryWithContext(_ aws.Context, input *dynamodb.QueryInput, _ ...request.Option) (*dynamodb.QueryOutput, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	s.queries = append(s.queries, input)
+	out := &dynamodb.QueryOutput{Items: s.items[0]}
+	s.items = s.items[1:]
+	if len(s.items) > 0 {
+		out.LastEvaluatedKey = map[string]*dynamodb.AttributeValue{attrShard: out.Items[len(out.Items)-1][attrShard]}
+	}
+	return out, nil
+}
+
+func (s *stubDynamoDB) UpdateItemWithContext(_ aws.Context, input *dynamodb.UpdateItemInput, _ ...request.Option) (*dynamodb.UpdateItemOutput, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	s.updates = append(s.updates, input)
+	return &dynamodb.UpdateItemOutput{Attributes: s.attributes}, nil
+}
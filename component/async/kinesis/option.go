@@ -0,0 +1,113 @@
+package kinesis
+
+import (
+	"errors"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/aws/aws-sdk-go/service/kinesis"
+)
+
+// OptionFunc definition for configuring the consumer in a functional way.
+type OptionFunc func(*Factory) error
+
+// Checkpoint option for storing the leases and checkpoints of the shards in a DynamoDB table, which allows multiple workers
+// of the consumer group to share the shards of the stream, and to resume from the last checkpoint after a restart.
+// The table should have a string partition key named "group" and a string sort key named "shard".
+// If this option is unused, leases and checkpoints are kept in memory, which only supports a single worker per consumer group.
+func Checkpoint(db dynamodbiface.DynamoDBAPI, table string) OptionFunc {
+	return func(f *Factory) error {
+		if db == nil {
+			return errors.New("DynamoDB API is nil")
+		}
+		if table == "" {
+			return errors.New("lease table is empty")
+		}
+		f.leases = &dynamoLeases{db: db, table: table, group: f.group + "/" + f.stream}
+		return nil
+	}
+}
+
+// InitialPosition option for setting the position of the shards without a checkpoint, either LATEST or TRIM_HORIZON.
+// The shards which are created by resharding are always consumed from their beginning, once their parents have been consumed.
+// If this option is unused, it defaults to LATEST.
+func InitialPosition(position string) OptionFunc {
+	return func(f *Factory) error {
+		if position != kinesis.ShardIteratorTypeLatest && position != kinesis.ShardIteratorTypeTrimHorizon {
+			return errors.New("initial position should be either LATEST or TRIM_HORIZON")
+		}
+		f.initialPosition = position
+		return nil
+	}
+}
+
+// PollInterval option for setting the interval between the GetRecords requests of a shard, when polling.
+// Kinesis allows up to five GetRecords requests per second for each shard, shared by all consumer groups.
+// If this option is unused, it defaults to 1 second.
+func PollInterval(interval time.Duration) OptionFunc {
+	return func(f *Factory) error {
+		if interval <= 0 {
+			return errors.New("poll interval should be positive")
+		}
+		f.pollInterval = interval
+		return nil
+	}
+}
+
+// BatchSize option for setting the maximum number of records of a GetRecords request, when polling.
+// Allowed values are between 1 and 10000. If this option is unused, it defaults to 1000.
+func BatchSize(size int64) OptionFunc {
+	return func(f *Factory) error {
+		if size <= 0 || size > 10000 {
+			return errors.New("batch size should be between 1 and 10000")
+		}
+		f.batchSize = size
+		return nil
+	}
+}
+
+// CheckpointInterval option for setting the interval at which the acknowledged positions of the shards are checkpointed.
+// The positions are also checkpointed when the consumer is closed. If this option is unused, it defaults to 5 seconds.
+func CheckpointInterval(interval time.Duration) OptionFunc {
+	return func(f *Factory) error {
+		if interval <= 0 {
+			return errors.New("checkpoint interval should be positive")
+		}
+		f.checkpointInterval = interval
+		return nil
+	}
+}
+
+// LeaseDuration option for setting the duration of the shard leases, after which the shards of a failed worker are taken over.
+// The leases are renewed, and the shards are rebalanced between the workers, every third of the duration.
+// If this option is unused, it defaults to 30 seconds.
+func LeaseDuration(duration time.Duration) OptionFunc {
+	return func(f *Factory) error {
+		if duration <= 0 {
+			return errors.New("lease duration should be positive")
+		}
+		f.leaseDuration = duration
+		return nil
+	}
+}
+
+// EnhancedFanOut option for consuming the shards with enhanced fan-out, instead of polling. The consumer group is registered
+// as a stream consumer, which has dedicated throughput and receives the records pushed over SubscribeToShard.
+func EnhancedFanOut() OptionFunc {
+	return func(f *Factory) error {
+		f.fanOut = true
+		return nil
+	}
+}
+
+// WorkerID option for setting the ID of the worker, which owns the leases of the shards it consumes.
+// If this option is unused, it defaults to the hostname followed by a random UUID.
+func WorkerID(id string) OptionFunc {
+	return func(f *Factory) error {
+		if id == "" {
+			return errors.New("worker ID is empty")
+		}
+		f.workerID = id
+		return nil
+	}
+}
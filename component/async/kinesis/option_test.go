@@ -0,0 +1,55 @@
+package kinesis
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/kinesis"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOptions(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		option      OptionFunc
+		expectedErr string
+	}{
+		"checkpoint":                  {option: Checkpoint(&stubDynamoDB{}, "leases")},
+		"checkpoint without API":      {option: Checkpoint(nil, "leases"), expectedErr: "DynamoDB API is nil"},
+		"checkpoint without table":    {option: Checkpoint(&stubDynamoDB{}, ""), expectedErr: "lease table is empty"},
+		"initial position":            {option: InitialPosition(kinesis.ShardIteratorTypeTrimHorizon)},
+		"invalid initial position":    {option: InitialPosition(kinesis.ShardIteratorTypeAtTimestamp), expectedErr: "initial position should be either LATEST or TRIM_HORIZON"},
+		"poll interval":               {option: PollInterval(time.Second)},
+		"invalid poll interval":       {option: PollInterval(0), expectedErr: "poll interval should be positive"},
+		"batch size":                  {option: BatchSize(10000)},
+		"invalid batch size":          {option: BatchSize(10001), expectedErr: "batch size should be between 1 and 10000"},
+		"checkpoint interval":         {option: CheckpointInterval(time.Second)},
+		"invalid checkpoint interval": {option: CheckpointInterval(-time.Second), expectedErr: "checkpoint interval should be positive"},
+		"lease duration":              {option: LeaseDuration(time.Minute)},
+		"invalid lease duration":      {option: LeaseDuration(0), expectedErr: "lease duration should be positive"},
+		"enhanced fan-out":            {option: EnhancedFanOut()},
+		"worker ID":                   {option: WorkerID("worker-1")},
+		"empty worker ID":             {option: WorkerID(""), expectedErr: "worker ID is empty"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			f := &Factory{stream: "stream", group: "group"}
+			err := tt.option(f)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestCheckpoint(t *testing.T) {
+	t.Parallel()
+	db := &stubDynamoDB{}
+	f := &Factory{stream: "stream", group: "group"}
+	assert.NoError(t, Checkpoint(db, "leases")(f))
+	assert.Equal(t, &dynamoLeases{db: db, table: "leases", group: "group/stream"}, f.leases)
+}
@@ -0,0 +1,423 @@
+package kinesis
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/kinesis"
+	"github.com/beatlabs/patron/component/async"
+	"github.com/beatlabs/patron/correlation"
+	"github.com/beatlabs/patron/encoding"
+	"github.com/beatlabs/patron/encoding/json"
+	"github.com/beatlabs/patron/internal/kpl"
+	"github.com/beatlabs/patron/log"
+	"github.com/beatlabs/patron/trace"
+	"github.com/google/uuid"
+	"github.com/opentracing/opentracing-go"
+)
+
+// shard keeps the state of consuming a shard owned by the worker.
+//
+// Positions in the shard are either the sequence number of a record, or the sequence number followed by a slash and the index
+// of a user record of an aggregated record, e.g. "49590338271490256608559692538361571095921575989136588898/3".
+// The latter is used for all user records of an aggregated record but the last one.
+type shard struct {
+	id   string
+	cnl  context.CancelFunc
+	done chan struct{}
+
+	mu sync.Mutex
+	// acked is the position of the last acknowledged message.
+	acked string
+	// stored is the last checkpoint stored.
+	stored string
+	// delivered is the position of the last message sent to the consumer.
+	delivered string
+	// ended is set when the end of the shard has been reached.
+	ended bool
+	// skip is set when the consumption resumes from a user record of an aggregated record.
+	skip *checkpointPosition
+}
+
+func newShard(id, checkpoint string, cnl context.CancelFunc) *shard {
+	return &shard{id: id, cnl: cnl, done: make(chan struct{}), acked: checkpoint, stored: checkpoint, delivered: checkpoint}
+}
+
+func (s *shard) ack(pos string) {
+	s.mu.Lock()
+	s.acked = pos
+	s.mu.Unlock()
+}
+
+func (s *shard) deliver(pos string) {
+	s.mu.Lock()
+	s.delivered = pos
+	s.mu.Unlock()
+}
+
+func (s *shard) end() {
+	s.mu.Lock()
+	s.ended = true
+	s.mu.Unlock()
+}
+
+// pending returns the checkpoint of the shard, and whether it differs from the one stored.
+// Once the end of the shard has been reached and all messages have been acknowledged, the checkpoint is SHARD_END.
+func (s *shard) pending() (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cp := s.acked
+	if s.ended && s.acked == s.delivered {
+		cp = shardEnd
+	}
+	return cp, cp != s.stored
+}
+
+func (s *shard) checkpointed(cp string) {
+	s.mu.Lock()
+	s.stored = cp
+	s.mu.Unlock()
+}
+
+// position returns the starting position of the shard after the last message delivered, or the initial position.
+func (s *shard) position(initial string) startingPosition {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.delivered == "" {
+		return startingPosition{iteratorType: initial}
+	}
+	cp := parseCheckpoint(s.delivered)
+	if cp.index < 0 {
+		return startingPosition{iteratorType: kinesis.ShardIteratorTypeAfterSequenceNumber, sequenceNumber: cp.sequenceNumber}
+	}
+	s.skip = &cp
+	return startingPosition{iteratorType: kinesis.ShardIteratorTypeAtSequenceNumber, sequenceNumber: cp.sequenceNumber}
+}
+
+// skipped returns whether the user record at the index of the record has been delivered already.
+func (s *shard) skipped(sequenceNumber string, index int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.skip == nil {
+		return false
+	}
+	if s.skip.sequenceNumber != sequenceNumber {
+		s.skip = nil
+		return false
+	}
+	return index <= s.skip.index
+}
+
+type checkpointPosition struct {
+	sequenceNumber string
+	// index of the user record of an aggregated record, or -1.
+	index int
+}
+
+func parseCheckpoint(cp string) checkpointPosition {
+	seq, idx, ok := cut(cp, "/")
+	if !ok {
+		return checkpointPosition{sequenceNumber: cp, index: -1}
+	}
+	index, err := strconv.Atoi(idx)
+	if err != nil {
+		return checkpointPosition{sequenceNumber: seq, index: -1}
+	}
+	return checkpointPosition{sequenceNumber: seq, index: index}
+}
+
+func cut(s, sep string) (string, string, bool) {
+	if i := strings.Index(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return s, "", false
+}
+
+type startingPosition struct {
+	iteratorType   string
+	sequenceNumber string
+}
+
+func (p startingPosition) String() string {
+	if p.sequenceNumber == "" {
+		return p.iteratorType
+	}
+	return p.iteratorType + " " + p.sequenceNumber
+}
+
+// poll the records of the shard with GetRecords, until the end of the shard is reached.
+func (c *consumer) poll(ctx context.Context, s *shard, pos startingPosition) error {
+	iterator, err := c.shardIterator(ctx, s.id, pos)
+	if err != nil {
+		return err
+	}
+	for {
+		out, err := c.api.GetRecordsWithContext(ctx, &kinesis.GetRecordsInput{ShardIterator: iterator, Limit: aws.Int64(c.batchSize)})
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			switch errorCode(err) {
+			case kinesis.ErrCodeProvisionedThroughputExceededException, kinesis.ErrCodeKMSThrottlingException:
+				log.Debugf("GetRecords of shard %s of stream %s throttled: %v", s.id, c.stream, err)
+				if !wait(ctx, throttleBackoff) {
+					return nil
+				}
+				continue
+			case kinesis.ErrCodeExpiredIteratorException:
+				iterator, err = c.shardIterator(ctx, s.id, s.position(pos.iteratorType))
+				if err != nil {
+					return err
+				}
+				continue
+			}
+			return err
+		}
+
+		millisBehindLatest.WithLabelValues(c.stream, s.id).Set(float64(aws.Int64Value(out.MillisBehindLatest)))
+		if !c.deliver(ctx, s, out.Records) {
+			return nil
+		}
+		if out.NextShardIterator == nil {
+			s.end()
+			return nil
+		}
+		iterator = out.NextShardIterator
+		if !wait(ctx, c.pollInterval) {
+			return nil
+		}
+	}
+}
+
+func (c *consumer) shardIterator(ctx context.Context, id string, pos startingPosition) (*string, error) {
+	input := &kinesis.GetShardIteratorInput{
+		StreamName:        aws.String(c.stream),
+		ShardId:           aws.String(id),
+		ShardIteratorType: aws.String(pos.iteratorType),
+	}
+	if pos.sequenceNumber != "" {
+		input.StartingSequenceNumber = aws.String(pos.sequenceNumber)
+	}
+	out, err := c.api.GetShardIteratorWithContext(ctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get shard iterator: %w", err)
+	}
+	return out.ShardIterator, nil
+}
+
+// subscribe to the shard with enhanced fan-out, until the end of the shard is reached.
+// Subscriptions expire after five minutes, after which the shard is subscribed to again from the continuation sequence number.
+func (c *consumer) subscribe(ctx context.Context, s *shard, pos startingPosition) error {
+	for {
+		input := &kinesis.SubscribeToShardInput{
+			ConsumerARN:      aws.String(c.consumerARN),
+			ShardId:          aws.String(s.id),
+			StartingPosition: &kinesis.StartingPosition{Type: aws.String(pos.iteratorType)},
+		}
+		if pos.sequenceNumber != "" {
+			input.StartingPosition.SequenceNumber = aws.String(pos.sequenceNumber)
+		}
+		out, err := c.api.SubscribeToShardWithContext(ctx, input)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			switch errorCode(err) {
+			case kinesis.ErrCodeResourceInUseException, kinesis.ErrCodeLimitExceededException:
+				log.Debugf("subscription to shard %s of stream %s throttled: %v", s.id, c.stream, err)
+				if !wait(ctx, throttleBackoff) {
+					return nil
+				}
+				continue
+			}
+			return fmt.Errorf("failed to subscribe to shard: %w", err)
+		}
+
+		continuation, ended, err := c.events(ctx, s, out.GetStream())
+		if ended {
+			s.end()
+			return nil
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if continuation != "" {
+			pos = startingPosition{iteratorType: kinesis.ShardIteratorTypeAfterSequenceNumber, sequenceNumber: continuation}
+		}
+	}
+}
+
+// events delivers the records of the events of the subscription, returning the last continuation sequence number,
+// and whether the end of the shard has been reached.
+func (c *consumer) events(ctx context.Context, s *shard, stream *kinesis.SubscribeToShardEventStream) (string, bool, error) {
+	defer func() {
+		if err := stream.Close(); err != nil {
+			log.Debugf("failed to close subscription to shard %s of stream %s: %v", s.id, c.stream, err)
+		}
+	}()
+	continuation := ""
+	for {
+		select {
+		case <-ctx.Done():
+			return continuation, false, nil
+		case ev, ok := <-stream.Events():
+			if !ok {
+				if err := stream.Err(); err != nil {
+					return "", false, fmt.Errorf("subscription to shard failed: %w", err)
+				}
+				return continuation, false, nil
+			}
+			event, ok := ev.(*kinesis.SubscribeToShardEvent)
+			if !ok {
+				continue
+			}
+			millisBehindLatest.WithLabelValues(c.stream, s.id).Set(float64(aws.Int64Value(event.MillisBehindLatest)))
+			if !c.deliver(ctx, s, event.Records) {
+				return continuation, false, nil
+			}
+			if event.ContinuationSequenceNumber == nil {
+				return "", true, nil
+			}
+			continuation = *event.ContinuationSequenceNumber
+		}
+	}
+}
+
+// deliver the records to the consumer, de-aggregating aggregated records, returning false if the context is done.
+func (c *consumer) deliver(ctx context.Context, s *shard, records []*kinesis.Record) bool {
+	messageCountInc(c.stream, fetchedMessageState, len(records))
+	for _, r := range records {
+		seq := aws.StringValue(r.SequenceNumber)
+		userRecords := []kpl.Record{{PartitionKey: aws.StringValue(r.PartitionKey), Data: r.Data}}
+		if kpl.IsAggregated(r.Data) {
+			rr, err := kpl.Unmarshal(r.Data)
+			if err != nil {
+				messageCountErrorInc(c.stream, fetchedMessageState, 1)
+				log.Errorf("failed to de-aggregate record %s of shard %s: %v", seq, s.id, err)
+				continue
+			}
+			userRecords = rr
+		}
+
+		for i, ur := range userRecords {
+			if s.skipped(seq, i) {
+				continue
+			}
+			pos := seq
+			if i < len(userRecords)-1 {
+				pos = seq + "/" + strconv.Itoa(i)
+			}
+			msg := c.message(ctx, s, r, ur, pos)
+			if msg == nil {
+				continue
+			}
+			s.deliver(pos)
+			select {
+			case c.msgs <- msg:
+			case <-ctx.Done():
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (c *consumer) message(ctx context.Context, s *shard, r *kinesis.Record, ur kpl.Record, pos string) async.Message {
+	corID, ok := ur.Tags[correlation.HeaderID]
+	if !ok || corID == "" {
+		corID = uuid.New().String()
+	}
+
+	sp, ctxCh := trace.ConsumerSpan(ctx, trace.ComponentOpName(consumerComponent, c.stream), consumerComponent, corID, ur.Tags)
+	ctxCh = correlation.ContextWithID(ctxCh, corID)
+	logger := log.Sub(map[string]interface{}{correlation.ID: corID})
+	ctxCh = log.WithContext(ctxCh, logger)
+
+	ct, ok := ur.Tags[encoding.ContentTypeHeader]
+	if !ok {
+		ct = json.Type
+	}
+	dec, err := async.DetermineDecoder(ct)
+	if err != nil {
+		messageCountErrorInc(c.stream, fetchedMessageState, 1)
+		trace.SpanError(sp)
+		logger.Errorf("failed to determine decoder: %v", err)
+		return nil
+	}
+
+	return &message{
+		ctx:      ctxCh,
+		span:     sp,
+		dec:      dec,
+		stream:   c.stream,
+		shard:    s,
+		record:   r,
+		data:     ur.Data,
+		position: pos,
+	}
+}
+
+type message struct {
+	ctx      context.Context
+	span     opentracing.Span
+	dec      encoding.DecodeRawFunc
+	stream   string
+	shard    *shard
+	record   *kinesis.Record
+	data     []byte
+	position string
+}
+
+// Context of the message.
+func (m *message) Context() context.Context {
+	return m.ctx
+}
+
+// Decode the message to the provided argument.
+func (m *message) Decode(v interface{}) error {
+	return m.dec(m.data, v)
+}
+
+// Ack the message, which advances the checkpoint of its shard to the message.
+func (m *message) Ack() error {
+	m.shard.ack(m.position)
+	messageCountInc(m.stream, ackMessageState, 1)
+	trace.SpanSuccess(m.span)
+	return nil
+}
+
+// Nack the message. Kinesis does not support Nack, the checkpoint of the shard is not advanced to the message,
+// so it is consumed again after a restart, unless a following message is acknowledged.
+func (m *message) Nack() error {
+	messageCountInc(m.stream, nackMessageState, 1)
+	trace.SpanError(m.span)
+	return nil
+}
+
+// Source returns the stream's name where the message arrived.
+func (m *message) Source() string {
+	return m.stream
+}
+
+// Timestamp returns the approximate time the record was inserted into the stream.
+func (m *message) Timestamp() time.Time {
+	return aws.TimeValue(m.record.ApproximateArrivalTimestamp)
+}
+
+// Payload returns the message payload, i.e. the data of the user record for aggregated records.
+func (m *message) Payload() []byte {
+	return m.data
+}
+
+// Raw returns the Kinesis record.
+func (m *message) Raw() interface{} {
+	return m.record
+}
@@ -0,0 +1,67 @@
+package servicebus
+
+import (
+	"errors"
+	"net/http"
+	"time"
+)
+
+// OptionFunc definition for configuring the consumer in a functional way.
+type OptionFunc func(*Factory) error
+
+// Subscription option for consuming a subscription of the topic of the factory.
+func Subscription(name string) OptionFunc {
+	return func(f *Factory) error {
+		if name == "" {
+			return errors.New("subscription is empty")
+		}
+		f.entity += "/subscriptions/" + name
+		return nil
+	}
+}
+
+// DeadLetterQueue option for consuming the dead-letter queue of the entity, instead of the entity itself.
+// It should follow the Subscription option, when the dead-letter queue of a subscription is consumed.
+func DeadLetterQueue() OptionFunc {
+	return func(f *Factory) error {
+		f.entity += "/" + deadLetterQueue
+		return nil
+	}
+}
+
+// Prefetch option for setting the number of messages which are received and locked ahead of processing.
+// Since the REST API receives a single message per request, this is also the number of concurrent receive requests.
+// If this option is unused, it defaults to 1.
+func Prefetch(count int) OptionFunc {
+	return func(f *Factory) error {
+		if count <= 0 {
+			return errors.New("prefetch count should be positive")
+		}
+		f.prefetch = count
+		return nil
+	}
+}
+
+// ReceiveTimeout option for setting the time a receive request waits for a message to become available.
+// If this option is unused, it defaults to 30 seconds.
+func ReceiveTimeout(timeout time.Duration) OptionFunc {
+	return func(f *Factory) error {
+		if timeout < time.Second {
+			return errors.New("receive timeout should be at least a second")
+		}
+		f.receiveTimeout = timeout
+		return nil
+	}
+}
+
+// Client option for setting the HTTP client of the requests to Service Bus.
+// Its timeout, if any, should exceed the receive timeout.
+func Client(client *http.Client) OptionFunc {
+	return func(f *Factory) error {
+		if client == nil {
+			return errors.New("client is nil")
+		}
+		f.ns.Client = client
+		return nil
+	}
+}
@@ -0,0 +1,42 @@
+package servicebus
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/beatlabs/patron/internal/servicebus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOptions(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		option      OptionFunc
+		expectedErr string
+	}{
+		"subscription":            {option: Subscription("billing")},
+		"empty subscription":      {option: Subscription(""), expectedErr: "subscription is empty"},
+		"dead-letter queue":       {option: DeadLetterQueue()},
+		"prefetch":                {option: Prefetch(10)},
+		"invalid prefetch":        {option: Prefetch(-1), expectedErr: "prefetch count should be positive"},
+		"receive timeout":         {option: ReceiveTimeout(time.Minute)},
+		"invalid receive timeout": {option: ReceiveTimeout(time.Millisecond), expectedErr: "receive timeout should be at least a second"},
+		"client":                  {option: Client(&http.Client{})},
+		"invalid client":          {option: Client(nil), expectedErr: "client is nil"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			f := &Factory{ns: &servicebus.Namespace{Endpoint: &url.URL{}}, entity: "orders"}
+			err := tt.option(f)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
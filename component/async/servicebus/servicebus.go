@@ -0,0 +1,403 @@
+// Package servicebus provides consumer implementation for Azure Service Bus queues and topic subscriptions
+// with included tracing capabilities.
+//
+// Messages are received over the REST API of Service Bus with peek-lock semantics: acknowledging a message completes it,
+// while nacking a message abandons it, so that it is delivered again until the maximum delivery count of the entity
+// is reached and the broker moves it to the dead-letter queue.
+package servicebus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/beatlabs/patron/component/async"
+	"github.com/beatlabs/patron/correlation"
+	"github.com/beatlabs/patron/encoding"
+	"github.com/beatlabs/patron/encoding/json"
+	"github.com/beatlabs/patron/internal/servicebus"
+	"github.com/beatlabs/patron/log"
+	"github.com/beatlabs/patron/trace"
+	"github.com/google/uuid"
+	"github.com/opentracing/opentracing-go"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type messageState string
+
+const (
+	consumerComponent = "servicebus-consumer"
+
+	deadLetterQueue = "$DeadLetterQueue"
+
+	defaultPrefetch       = 1
+	defaultReceiveTimeout = 30 * time.Second
+
+	// receiveBackoff is the interval between receive requests, after one of them has failed.
+	receiveBackoff = time.Second
+
+	ackMessageState     messageState = "ACK"
+	nackMessageState    messageState = "NACK"
+	fetchedMessageState messageState = "FETCHED"
+)
+
+// ignoredHeaders are the headers of a received message, which are not custom properties.
+var ignoredHeaders = map[string]struct{}{
+	servicebus.BrokerPropertiesHeader: {},
+	"Content-Length":                  {},
+	"Content-Type":                    {},
+	"Date":                            {},
+	"Location":                        {},
+	"Server":                          {},
+	"Strict-Transport-Security":       {},
+	"Transfer-Encoding":               {},
+}
+
+var (
+	messageAge     *prometheus.GaugeVec
+	messageCounter *prometheus.CounterVec
+)
+
+func init() {
+	messageAge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "component",
+			Subsystem: "servicebus_consumer",
+			Name:      "message_age",
+			Help:      "Message age based on the EnqueuedTimeUtc broker property",
+		},
+		[]string{"entity"},
+	)
+	messageCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "component",
+			Subsystem: "servicebus_consumer",
+			Name:      "message_counter",
+			Help:      "Message counter",
+		},
+		[]string{"entity", "state", "hasError"},
+	)
+	prometheus.MustRegister(messageAge, messageCounter)
+}
+
+// BrokerProperties of a received message, e.g. its sequence number and delivery count.
+type BrokerProperties = servicebus.BrokerProperties
+
+// ReceivedMessage is the raw message received from Service Bus.
+type ReceivedMessage struct {
+	Body        []byte
+	ContentType string
+	// Properties are the custom properties of the message, keyed by their canonical header names.
+	Properties       map[string]string
+	BrokerProperties BrokerProperties
+	// LockURI is the URI of the lock of the message, which is used to complete or abandon it.
+	LockURI string
+}
+
+type message struct {
+	entity string
+	ns     *servicebus.Namespace
+	ctx    context.Context
+	msg    *ReceivedMessage
+	span   opentracing.Span
+	dec    encoding.DecodeRawFunc
+}
+
+// Context of the message.
+func (m *message) Context() context.Context {
+	return m.ctx
+}
+
+// Decode the message to the provided argument.
+func (m *message) Decode(v interface{}) error {
+	return m.dec(m.msg.Body, v)
+}
+
+// Ack completes the message, which removes it from the entity.
+func (m *message) Ack() error {
+	err := m.settle(http.MethodDelete)
+	if err != nil {
+		messageCountErrorInc(m.entity, ackMessageState, 1)
+		trace.SpanError(m.span)
+		return fmt.Errorf("failed to complete message: %w", err)
+	}
+	messageCountInc(m.entity, ackMessageState, 1)
+	trace.SpanSuccess(m.span)
+	return nil
+}
+
+// Nack abandons the message, which unlocks it for another delivery. Once the maximum delivery count of the entity
+// has been reached, the message is moved to the dead-letter queue.
+func (m *message) Nack() error {
+	err := m.settle(http.MethodPut)
+	if err != nil {
+		messageCountErrorInc(m.entity, nackMessageState, 1)
+		trace.SpanError(m.span)
+		return fmt.Errorf("failed to abandon message: %w", err)
+	}
+	messageCountInc(m.entity, nackMessageState, 1)
+	trace.SpanError(m.span)
+	return nil
+}
+
+func (m *message) settle(method string) error {
+	rsp, err := m.ns.Do(m.ctx, method, m.msg.LockURI, nil, nil, http.StatusOK)
+	if err != nil {
+		return err
+	}
+	return rsp.Body.Close()
+}
+
+// Source returns the entity where the message arrived.
+func (m *message) Source() string {
+	return m.entity
+}
+
+// Timestamp returns the time the message was enqueued, based on the EnqueuedTimeUtc broker property.
+func (m *message) Timestamp() time.Time {
+	t, err := time.Parse(time.RFC1123, m.msg.BrokerProperties.EnqueuedTimeUtc)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// Payload returns the message payload.
+func (m *message) Payload() []byte {
+	return m.msg.Body
+}
+
+// Raw returns the *ReceivedMessage.
+func (m *message) Raw() interface{} {
+	return m.msg
+}
+
+// Factory for creating Service Bus consumers.
+type Factory struct {
+	ns             *servicebus.Namespace
+	entity         string
+	prefetch       int
+	receiveTimeout time.Duration
+}
+
+// NewFactory creates a new consumer factory for the entity, i.e. a queue or a topic, of the namespace of the connection string.
+// If the entity is empty, the entity path of the connection string is used.
+func NewFactory(connectionString, entity string, oo ...OptionFunc) (*Factory, error) {
+	ns, err := servicebus.ParseConnectionString(connectionString)
+	if err != nil {
+		return nil, err
+	}
+	if entity == "" {
+		entity = ns.EntityPath
+	}
+	if entity == "" {
+		return nil, errors.New("entity is empty")
+	}
+
+	f := &Factory{
+		ns:             ns,
+		entity:         entity,
+		prefetch:       defaultPrefetch,
+		receiveTimeout: defaultReceiveTimeout,
+	}
+
+	for _, o := range oo {
+		err := o(f)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return f, nil
+}
+
+// Create a new Service Bus consumer.
+func (f *Factory) Create() (async.Consumer, error) {
+	return &consumer{
+		ns:             f.ns,
+		entity:         f.entity,
+		prefetch:       f.prefetch,
+		receiveTimeout: f.receiveTimeout,
+	}, nil
+}
+
+type consumer struct {
+	ns             *servicebus.Namespace
+	entity         string
+	prefetch       int
+	receiveTimeout time.Duration
+	cnl            context.CancelFunc
+}
+
+func (c *consumer) OutOfOrder() bool {
+	return true
+}
+
+// Consume messages from Service Bus and send them to the channel.
+// Each of the prefetched messages is received by a separate request, which keeps it locked until it is settled.
+func (c *consumer) Consume(ctx context.Context) (<-chan async.Message, <-chan error, error) {
+	chMsg := make(chan async.Message, c.prefetch)
+	chErr := make(chan error)
+	sbCtx, cnl := context.WithCancel(ctx)
+	c.cnl = cnl
+
+	var once sync.Once
+	for i := 0; i < c.prefetch; i++ {
+		go func() {
+			for {
+				if sbCtx.Err() != nil {
+					return
+				}
+				msg, err := c.receive(sbCtx)
+				if err != nil {
+					if sbCtx.Err() != nil {
+						return
+					}
+					// only the first failure is reported, since the consumer is recreated after it.
+					once.Do(func() {
+						select {
+						case chErr <- fmt.Errorf("failed to receive message: %w", err):
+						case <-sbCtx.Done():
+						}
+					})
+					select {
+					case <-time.After(receiveBackoff):
+					case <-sbCtx.Done():
+					}
+					continue
+				}
+				if msg == nil {
+					continue
+				}
+				select {
+				case chMsg <- msg:
+				case <-sbCtx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	return chMsg, chErr, nil
+}
+
+// receive a message, returning nil if no message was available before the receive timeout.
+func (c *consumer) receive(ctx context.Context) (async.Message, error) {
+	u := fmt.Sprintf("%s?timeout=%d", c.ns.URL(c.entity+"/messages/head"), int(c.receiveTimeout.Seconds()))
+	rsp, err := c.ns.Do(ctx, http.MethodPost, u, nil, nil, http.StatusCreated, http.StatusNoContent)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rsp.Body.Close()
+	}()
+	if rsp.StatusCode == http.StatusNoContent {
+		return nil, nil
+	}
+
+	body, err := ioutil.ReadAll(rsp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read message body: %w", err)
+	}
+	raw, err := receivedMessage(rsp.Header, body)
+	if err != nil {
+		return nil, err
+	}
+	messageCountInc(c.entity, fetchedMessageState, 1)
+	observeMessageAge(c.entity, raw.BrokerProperties.EnqueuedTimeUtc)
+
+	corID := getCorrelationID(raw.Properties)
+	sp, ctxCh := trace.ConsumerSpan(ctx, trace.ComponentOpName(consumerComponent, c.entity),
+		consumerComponent, corID, raw.Properties)
+	ctxCh = correlation.ContextWithID(ctxCh, corID)
+	logger := log.Sub(map[string]interface{}{correlation.ID: corID})
+	ctxCh = log.WithContext(ctxCh, logger)
+
+	ct := raw.ContentType
+	if ct == "" {
+		ct = json.Type
+	}
+	dec, err := async.DetermineDecoder(ct)
+	if err != nil {
+		messageCountErrorInc(c.entity, fetchedMessageState, 1)
+		trace.SpanError(sp)
+		logger.Errorf("failed to determine decoder: %v", err)
+		// the message is abandoned, so that it is eventually dead-lettered.
+		m := &message{entity: c.entity, ns: c.ns, ctx: ctxCh, msg: raw, span: sp}
+		if err := m.settle(http.MethodPut); err != nil {
+			logger.Errorf("failed to abandon message: %v", err)
+		}
+		return nil, nil
+	}
+
+	return &message{
+		entity: c.entity,
+		ns:     c.ns,
+		ctx:    ctxCh,
+		msg:    raw,
+		span:   sp,
+		dec:    dec,
+	}, nil
+}
+
+// Close the consumer. The prefetched messages which have not been processed are unlocked once their lock expires.
+func (c *consumer) Close() error {
+	if c.cnl != nil {
+		c.cnl()
+	}
+	return nil
+}
+
+func receivedMessage(hdr http.Header, body []byte) (*ReceivedMessage, error) {
+	msg := &ReceivedMessage{
+		Body:        body,
+		ContentType: hdr.Get("Content-Type"),
+		Properties:  make(map[string]string),
+		LockURI:     hdr.Get("Location"),
+	}
+	if msg.LockURI == "" {
+		return nil, errors.New("received message has no lock URI")
+	}
+	if bp := hdr.Get(servicebus.BrokerPropertiesHeader); bp != "" {
+		if err := json.DecodeRaw([]byte(bp), &msg.BrokerProperties); err != nil {
+			return nil, fmt.Errorf("failed to decode broker properties: %w", err)
+		}
+	}
+	for key, values := range hdr {
+		if _, ok := ignoredHeaders[key]; ok || len(values) == 0 {
+			continue
+		}
+		msg.Properties[key] = servicebus.DecodeProperty(values[0])
+	}
+	return msg, nil
+}
+
+func getCorrelationID(properties map[string]string) string {
+	if corID, ok := properties[correlation.HeaderID]; ok && corID != "" {
+		return corID
+	}
+	return uuid.New().String()
+}
+
+func observeMessageAge(entity, enqueued string) {
+	if enqueued == "" {
+		return
+	}
+	t, err := time.Parse(time.RFC1123, enqueued)
+	if err != nil {
+		return
+	}
+	messageAge.WithLabelValues(entity).Set(time.Now().UTC().Sub(t).Seconds())
+}
+
+func messageCountInc(entity string, state messageState, count int) {
+	messageCounter.WithLabelValues(entity, string(state), "false").Add(float64(count))
+}
+
+func messageCountErrorInc(entity string, state messageState, count int) {
+	messageCounter.WithLabelValues(entity, string(state), "true").Add(float64(count))
+}
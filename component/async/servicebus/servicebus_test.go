@@ -0,0 +1,169 @@
+package servicebus
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/beatlabs/patron/component/async"
+	"github.com/beatlabs/patron/correlation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type broker struct {
+	sync.Mutex
+	messages  []string
+	completed []string
+	abandoned []string
+	srv       *httptest.Server
+}
+
+func newBroker(t *testing.T, messages ...string) *broker {
+	b := &broker{messages: messages}
+	b.srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b.Lock()
+		defer b.Unlock()
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/orders/messages/head":
+			assert.Equal(t, "1", r.URL.Query().Get("timeout"))
+			if len(b.messages) == 0 {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			body := b.messages[0]
+			b.messages = b.messages[1:]
+			w.Header().Set("Location", b.srv.URL+"/orders/messages/1/"+body)
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("BrokerProperties", `{"MessageId":"1","DeliveryCount":2,"EnqueuedTimeUtc":"Wed, 02 Mar 2016 09:30:00 GMT"}`)
+			w.Header().Set(correlation.HeaderID, `"123"`)
+			w.Header().Set("Custom", `"value"`)
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(body))
+		case r.Method == http.MethodDelete:
+			b.completed = append(b.completed, r.URL.Path)
+		case r.Method == http.MethodPut:
+			b.abandoned = append(b.abandoned, r.URL.Path)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	return b
+}
+
+func TestNewFactory(t *testing.T) {
+	t.Parallel()
+	connectionString := "Endpoint=sb://ns/;SharedAccessKeyName=root;SharedAccessKey=key"
+	tests := map[string]struct {
+		connectionString string
+		entity           string
+		options          []OptionFunc
+		expectedEntity   string
+		expectedErr      string
+	}{
+		"success":                  {connectionString: connectionString, entity: "orders", expectedEntity: "orders"},
+		"entity of connection":     {connectionString: connectionString + ";EntityPath=orders", expectedEntity: "orders"},
+		"subscription dead-letter": {connectionString: connectionString, entity: "orders", options: []OptionFunc{Subscription("billing"), DeadLetterQueue()}, expectedEntity: "orders/subscriptions/billing/$DeadLetterQueue"},
+		"invalid connection":       {entity: "orders", expectedErr: "connection string is empty"},
+		"missing entity":           {connectionString: connectionString, expectedErr: "entity is empty"},
+		"invalid option":           {connectionString: connectionString, entity: "orders", options: []OptionFunc{Prefetch(0)}, expectedErr: "prefetch count should be positive"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			got, err := NewFactory(tt.connectionString, tt.entity, tt.options...)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.Nil(t, got)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.expectedEntity, got.entity)
+			}
+		})
+	}
+}
+
+func TestConsumer_Consume(t *testing.T) {
+	t.Parallel()
+	b := newBroker(t, `{"id":1}`, `{"id":2}`)
+	defer b.srv.Close()
+	f, err := NewFactory("Endpoint="+b.srv.URL+";SharedAccessKeyName=root;SharedAccessKey=key", "orders",
+		Prefetch(2), ReceiveTimeout(time.Second))
+	require.NoError(t, err)
+	cns, err := f.Create()
+	require.NoError(t, err)
+	assert.True(t, cns.OutOfOrder())
+
+	ctx, cnl := context.WithCancel(context.Background())
+	defer cnl()
+	chMsg, chErr, err := cns.Consume(ctx)
+	require.NoError(t, err)
+
+	ids := make(map[int]bool)
+	for i := 0; i < 2; i++ {
+		select {
+		case msg := <-chMsg:
+			assert.Equal(t, "orders", msg.Source())
+			assert.Equal(t, "123", correlation.IDFromContext(msg.Context()))
+			raw := msg.Raw().(*ReceivedMessage)
+			assert.Equal(t, "value", raw.Properties["Custom"])
+			assert.Equal(t, 2, raw.BrokerProperties.DeliveryCount)
+			assert.Equal(t, time.Date(2016, time.March, 2, 9, 30, 0, 0, time.UTC), msg.(async.TimestampedMessage).Timestamp().UTC())
+			var v struct{ ID int }
+			require.NoError(t, msg.Decode(&v))
+			ids[v.ID] = true
+			if v.ID == 1 {
+				assert.NoError(t, msg.Ack())
+			} else {
+				assert.NoError(t, msg.Nack())
+			}
+		case err := <-chErr:
+			require.NoError(t, err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for messages")
+		}
+	}
+	assert.Equal(t, map[int]bool{1: true, 2: true}, ids)
+	assert.NoError(t, cns.Close())
+	assert.NoError(t, cns.Close())
+
+	b.Lock()
+	defer b.Unlock()
+	assert.Equal(t, []string{`/orders/messages/1/{"id":1}`}, b.completed)
+	assert.Equal(t, []string{`/orders/messages/1/{"id":2}`}, b.abandoned)
+}
+
+func TestConsumer_Consume_Error(t *testing.T) {
+	t.Parallel()
+	b := newBroker(t)
+	defer b.srv.Close()
+	f, err := NewFactory("Endpoint="+b.srv.URL+";SharedAccessKeyName=root;SharedAccessKey=key", "missing",
+		Prefetch(3), ReceiveTimeout(time.Second))
+	require.NoError(t, err)
+	cns, err := f.Create()
+	require.NoError(t, err)
+
+	_, chErr, err := cns.Consume(context.Background())
+	require.NoError(t, err)
+	select {
+	case err := <-chErr:
+		assert.EqualError(t, err, "failed to receive message: unexpected status 404")
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for error")
+	}
+	assert.NoError(t, cns.Close())
+}
+
+func TestReceivedMessage(t *testing.T) {
+	t.Parallel()
+	_, err := receivedMessage(http.Header{}, nil)
+	assert.EqualError(t, err, "received message has no lock URI")
+
+	hdr := http.Header{"Location": []string{"uri"}, "Brokerproperties": []string{"{"}}
+	_, err = receivedMessage(hdr, nil)
+	assert.EqualError(t, err, "failed to decode broker properties: unexpected end of JSON input")
+}
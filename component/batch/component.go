@@ -0,0 +1,321 @@
+// Package batch provides a component for finite workloads, e.g. processing a file, a table or a topic snapshot,
+// which are split into chunks processed concurrently, checkpointing the progress so that the job can be resumed after a crash.
+// The component returns once the workload has been processed, which leads to a clean exit of the service.
+package batch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/beatlabs/patron/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	defaultConcurrency = 1
+	defaultRetries     = 3
+	defaultRetryDelay  = time.Second
+
+	chunkProcessed = "processed"
+	chunkRetried   = "retried"
+	chunkFailed    = "failed"
+)
+
+var (
+	chunkStatus *prometheus.CounterVec
+	progress    *prometheus.GaugeVec
+)
+
+func init() {
+	chunkStatus = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "component",
+			Subsystem: "batch",
+			Name:      "chunk_status",
+			Help:      "Chunk status counter (processed, retried, failed) classified by job",
+		},
+		[]string{"job", "status"},
+	)
+	progress = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "component",
+			Subsystem: "batch",
+			Name:      "progress",
+			Help:      "Ratio of the checkpointed chunks to the total chunks, classified by job",
+		},
+		[]string{"job"},
+	)
+	prometheus.MustRegister(chunkStatus, progress)
+}
+
+// Chunk is a unit of work of the job.
+type Chunk struct {
+	// Cursor identifies the position of the workload after the chunk e.g. the last ID of a page of a table,
+	// which is checkpointed once the chunk and all the previous ones have been processed.
+	Cursor string
+	// Data holds the data of the chunk.
+	Data interface{}
+}
+
+// Source reads the chunks of the workload in order.
+type Source interface {
+	// Next returns the chunk following the provided cursor, which is empty for the first chunk.
+	// It returns io.EOF once the workload has been exhausted.
+	Next(ctx context.Context, cursor string) (Chunk, error)
+}
+
+// Sizer is implemented by sources, which know the number of chunks of the workload, in order to report the progress of the job.
+type Sizer interface {
+	// Size returns the number of chunks following the provided cursor, which is empty for the whole workload.
+	Size(ctx context.Context, cursor string) (int, error)
+}
+
+// CheckpointStore persists the checkpoints of the jobs.
+type CheckpointStore interface {
+	// Load returns the last checkpointed cursor of the job, or an empty cursor if the job has not been checkpointed.
+	Load(ctx context.Context, job string) (string, error)
+	// Save persists the cursor of the job.
+	Save(ctx context.Context, job, cursor string) error
+}
+
+// ProcessorFunc definition of a chunk processor.
+type ProcessorFunc func(ctx context.Context, chunk Chunk) error
+
+type retryConfig struct {
+	count uint
+	delay time.Duration
+}
+
+// Component processing the chunks of a finite workload.
+type Component struct {
+	name        string
+	src         Source
+	proc        ProcessorFunc
+	store       CheckpointStore
+	concurrency int
+	retryCfg    retryConfig
+}
+
+// New creates a batch job with the provided name, which identifies its checkpoints.
+// By default, chunks are processed sequentially, failed chunks are retried 3 times and no checkpoints are persisted.
+func New(name string, src Source, proc ProcessorFunc, oo ...OptionFunc) (*Component, error) {
+	if name == "" {
+		return nil, errors.New("name is required")
+	}
+	if src == nil {
+		return nil, errors.New("source is required")
+	}
+	if proc == nil {
+		return nil, errors.New("processor is required")
+	}
+
+	cmp := &Component{
+		name:        name,
+		src:         src,
+		proc:        proc,
+		concurrency: defaultConcurrency,
+		retryCfg: retryConfig{
+			count: defaultRetries,
+			delay: defaultRetryDelay,
+		},
+	}
+
+	for _, optionFunc := range oo {
+		if err := optionFunc(cmp); err != nil {
+			return nil, err
+		}
+	}
+
+	return cmp, nil
+}
+
+// sequencedChunk is a chunk along with its position in the order of the source.
+type sequencedChunk struct {
+	seq int
+	Chunk
+}
+
+// Run processes the chunks following the last checkpoint and returns once the workload has been processed,
+// or the job has failed. If the context is done, the job stops after the in-flight chunks, checkpointing its progress.
+func (c *Component) Run(ctx context.Context) error {
+	cursor, err := c.load(ctx)
+	if err != nil {
+		return err
+	}
+	total := c.size(ctx, cursor)
+
+	ctx, cnl := context.WithCancel(ctx)
+	defer cnl()
+
+	chChunks := make(chan sequencedChunk, c.concurrency)
+	chDone := make(chan sequencedChunk, c.concurrency)
+	chErr := make(chan error, c.concurrency+1)
+
+	go func() {
+		defer close(chChunks)
+		if err := c.read(ctx, cursor, chChunks); err != nil {
+			chErr <- err
+		}
+	}()
+
+	wg := sync.WaitGroup{}
+	wg.Add(c.concurrency)
+	for i := 0; i < c.concurrency; i++ {
+		go func() {
+			defer wg.Done()
+			for chunk := range chChunks {
+				if err := c.process(ctx, chunk.Chunk); err != nil {
+					// chunks interrupted by the cancellation of the job are processed again when it is resumed
+					if ctx.Err() == nil {
+						chErr <- fmt.Errorf("failed to process chunk with cursor %s: %w", chunk.Cursor, err)
+						cnl()
+					}
+					return
+				}
+				chDone <- chunk
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(chDone)
+	}()
+
+	processed, err := c.checkpoint(chDone, cnl, cursor, total)
+	close(chErr)
+	// the first error is the cause of the failure, the rest are caused by the cancellation
+	for e := range chErr {
+		if err == nil {
+			err = e
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("batch job %s failed after %d chunks: %w", c.name, processed, err)
+	}
+	if ctx.Err() != nil {
+		log.Infof("batch job %s stopped after %d chunks", c.name, processed)
+		return nil
+	}
+	log.Infof("batch job %s completed after %d chunks", c.name, processed)
+	return nil
+}
+
+func (c *Component) load(ctx context.Context) (string, error) {
+	if c.store == nil {
+		return "", nil
+	}
+	cursor, err := c.store.Load(ctx, c.name)
+	if err != nil {
+		return "", fmt.Errorf("failed to load checkpoint of batch job %s: %w", c.name, err)
+	}
+	if cursor != "" {
+		log.Infof("resuming batch job %s from cursor %s", c.name, cursor)
+	}
+	return cursor, nil
+}
+
+// size returns the number of chunks following the cursor, or zero if it is unknown.
+func (c *Component) size(ctx context.Context, cursor string) int {
+	sizer, ok := c.src.(Sizer)
+	if !ok {
+		return 0
+	}
+	total, err := sizer.Size(ctx, cursor)
+	if err != nil {
+		log.Warnf("failed to get size of batch job %s: %v", c.name, err)
+		return 0
+	}
+	return total
+}
+
+// read reads the chunks of the source in order, until it has been exhausted or the context is done.
+func (c *Component) read(ctx context.Context, cursor string, chChunks chan<- sequencedChunk) error {
+	for seq := 0; ; seq++ {
+		chunk, err := c.src.Next(ctx, cursor)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("failed to read chunk after cursor %s: %w", cursor, err)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case chChunks <- sequencedChunk{seq: seq, Chunk: chunk}:
+		}
+		cursor = chunk.Cursor
+	}
+}
+
+// process processes the chunk, retrying on failure.
+func (c *Component) process(ctx context.Context, chunk Chunk) error {
+	var err error
+	for attempt := uint(0); attempt <= c.retryCfg.count; attempt++ {
+		if attempt > 0 {
+			chunkStatus.WithLabelValues(c.name, chunkRetried).Inc()
+			select {
+			case <-ctx.Done():
+				return err
+			case <-time.After(c.retryCfg.delay):
+			}
+		}
+		if err = c.proc(ctx, chunk); err == nil {
+			chunkStatus.WithLabelValues(c.name, chunkProcessed).Inc()
+			return nil
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	chunkStatus.WithLabelValues(c.name, chunkFailed).Inc()
+	return err
+}
+
+// checkpoint persists the cursor of the processed chunks, once all the previous chunks have been processed as well,
+// since chunks processed concurrently complete out of order. Failing to persist a checkpoint fails the job.
+// It returns the number of the checkpointed chunks.
+func (c *Component) checkpoint(chDone <-chan sequencedChunk, cnl context.CancelFunc, cursor string, total int) (int, error) {
+	pending := make(map[int]string)
+	next := 0
+	var err error
+	for chunk := range chDone {
+		pending[chunk.seq] = chunk.Cursor
+		advanced := false
+		for {
+			cur, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			cursor = cur
+			next++
+			advanced = true
+		}
+		if !advanced || err != nil {
+			continue
+		}
+		if total > 0 {
+			progress.WithLabelValues(c.name).Set(float64(next) / float64(total))
+		}
+		// checkpoints are saved even if the job is stopping, so the store should not depend on the context of the job
+		if err = c.save(context.Background(), cursor); err != nil {
+			err = fmt.Errorf("failed to checkpoint cursor %s: %w", cursor, err)
+			cnl()
+		}
+	}
+	return next, err
+}
+
+func (c *Component) save(ctx context.Context, cursor string) error {
+	if c.store == nil {
+		return nil
+	}
+	return c.store.Save(ctx, c.name, cursor)
+}
@@ -0,0 +1,242 @@
+package batch
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sliceSource returns a chunk per item, whose cursor is the index of the item.
+type sliceSource struct {
+	items []int
+	err   error
+}
+
+func (s *sliceSource) Next(_ context.Context, cursor string) (Chunk, error) {
+	next := 0
+	if cursor != "" {
+		i, err := strconv.Atoi(cursor)
+		if err != nil {
+			return Chunk{}, err
+		}
+		next = i + 1
+	}
+	if s.err != nil && next == 3 {
+		return Chunk{}, s.err
+	}
+	if next >= len(s.items) {
+		return Chunk{}, io.EOF
+	}
+	return Chunk{Cursor: strconv.Itoa(next), Data: s.items[next]}, nil
+}
+
+func (s *sliceSource) Size(_ context.Context, cursor string) (int, error) {
+	if cursor == "" {
+		return len(s.items), nil
+	}
+	i, err := strconv.Atoi(cursor)
+	if err != nil {
+		return 0, err
+	}
+	return len(s.items) - i - 1, nil
+}
+
+type memoryStore struct {
+	sync.Mutex
+	cursors map[string]string
+	saves   int
+	saveErr error
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{cursors: make(map[string]string)}
+}
+
+func (m *memoryStore) Load(_ context.Context, job string) (string, error) {
+	m.Lock()
+	defer m.Unlock()
+	return m.cursors[job], nil
+}
+
+func (m *memoryStore) Save(_ context.Context, job, cursor string) error {
+	m.Lock()
+	defer m.Unlock()
+	if m.saveErr != nil {
+		return m.saveErr
+	}
+	m.saves++
+	m.cursors[job] = cursor
+	return nil
+}
+
+type recorder struct {
+	sync.Mutex
+	items []int
+}
+
+func (r *recorder) proc(ctx context.Context, chunk Chunk) error {
+	r.Lock()
+	defer r.Unlock()
+	r.items = append(r.items, chunk.Data.(int))
+	return nil
+}
+
+func newItems(n int) []int {
+	items := make([]int, n)
+	for i := range items {
+		items[i] = i
+	}
+	return items
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+	proc := func(context.Context, Chunk) error { return nil }
+	type args struct {
+		name string
+		src  Source
+		proc ProcessorFunc
+		oo   []OptionFunc
+	}
+	tests := map[string]struct {
+		args        args
+		expectedErr string
+	}{
+		"success":           {args: args{name: "job", src: &sliceSource{}, proc: proc, oo: []OptionFunc{Concurrency(2)}}},
+		"missing name":      {args: args{src: &sliceSource{}, proc: proc}, expectedErr: "name is required"},
+		"missing source":    {args: args{name: "job", proc: proc}, expectedErr: "source is required"},
+		"missing processor": {args: args{name: "job", src: &sliceSource{}}, expectedErr: "processor is required"},
+		"option failure":    {args: args{name: "job", src: &sliceSource{}, proc: proc, oo: []OptionFunc{Concurrency(0)}}, expectedErr: "concurrency should be positive"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			got, err := New(tt.args.name, tt.args.src, tt.args.proc, tt.args.oo...)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, got)
+			}
+		})
+	}
+}
+
+func TestComponent_Run(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		concurrency int
+	}{
+		"sequential": {concurrency: 1},
+		"concurrent": {concurrency: 4},
+	}
+	for name, tt := range tests {
+		name, tt := name, tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			store := newMemoryStore()
+			rec := &recorder{}
+			cmp, err := New(name, &sliceSource{items: newItems(20)}, func(ctx context.Context, chunk Chunk) error {
+				// later chunks complete first
+				time.Sleep(time.Duration(20-chunk.Data.(int)) * 100 * time.Microsecond)
+				return rec.proc(ctx, chunk)
+			}, Concurrency(tt.concurrency), Checkpoints(store))
+			require.NoError(t, err)
+
+			require.NoError(t, cmp.Run(context.Background()))
+			assert.ElementsMatch(t, newItems(20), rec.items)
+			assert.Equal(t, "19", store.cursors[name])
+		})
+	}
+}
+
+func TestComponent_Run_Resume(t *testing.T) {
+	t.Parallel()
+	store := newMemoryStore()
+	store.cursors["job"] = "14"
+	rec := &recorder{}
+	cmp, err := New("job", &sliceSource{items: newItems(20)}, rec.proc, Checkpoints(store))
+	require.NoError(t, err)
+
+	require.NoError(t, cmp.Run(context.Background()))
+	assert.Equal(t, []int{15, 16, 17, 18, 19}, rec.items)
+	assert.Equal(t, "19", store.cursors["job"])
+
+	// a completed job has nothing left to process
+	rec.items = nil
+	require.NoError(t, cmp.Run(context.Background()))
+	assert.Empty(t, rec.items)
+}
+
+func TestComponent_Run_Failure(t *testing.T) {
+	t.Parallel()
+	t.Run("processor", func(t *testing.T) {
+		t.Parallel()
+		store := newMemoryStore()
+		attempts := 0
+		cmp, err := New("job", &sliceSource{items: newItems(10)}, func(ctx context.Context, chunk Chunk) error {
+			if chunk.Data.(int) == 5 {
+				attempts++
+				return errors.New("processor error")
+			}
+			return nil
+		}, Checkpoints(store), Retries(2, 0))
+		require.NoError(t, err)
+
+		err = cmp.Run(context.Background())
+		assert.EqualError(t, err, "batch job job failed after 5 chunks: failed to process chunk with cursor 5: processor error")
+		assert.Equal(t, 3, attempts)
+		assert.Equal(t, "4", store.cursors["job"])
+	})
+
+	t.Run("source", func(t *testing.T) {
+		t.Parallel()
+		store := newMemoryStore()
+		cmp, err := New("job", &sliceSource{items: newItems(10), err: errors.New("source error")}, (&recorder{}).proc, Checkpoints(store))
+		require.NoError(t, err)
+
+		err = cmp.Run(context.Background())
+		assert.EqualError(t, err, "batch job job failed after 3 chunks: failed to read chunk after cursor 2: source error")
+		assert.Equal(t, "2", store.cursors["job"])
+	})
+
+	t.Run("checkpoint", func(t *testing.T) {
+		t.Parallel()
+		store := newMemoryStore()
+		store.saveErr = errors.New("save error")
+		cmp, err := New("job", &sliceSource{items: newItems(10)}, (&recorder{}).proc, Checkpoints(store))
+		require.NoError(t, err)
+
+		err = cmp.Run(context.Background())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to checkpoint cursor 0: save error")
+	})
+}
+
+func TestComponent_Run_Canceled(t *testing.T) {
+	t.Parallel()
+	store := newMemoryStore()
+	ctx, cnl := context.WithCancel(context.Background())
+	cmp, err := New("job", &sliceSource{items: newItems(100)}, func(ctx context.Context, chunk Chunk) error {
+		if chunk.Data.(int) == 10 {
+			cnl()
+			return ctx.Err()
+		}
+		return nil
+	}, Checkpoints(store), Concurrency(2))
+	require.NoError(t, err)
+
+	assert.NoError(t, cmp.Run(ctx))
+	checkpoint, err := strconv.Atoi(store.cursors["job"])
+	require.NoError(t, err)
+	assert.Less(t, checkpoint, 10)
+}
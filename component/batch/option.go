@@ -0,0 +1,45 @@
+package batch
+
+import (
+	"errors"
+	"time"
+)
+
+// OptionFunc definition for configuring the component in a functional way.
+type OptionFunc func(*Component) error
+
+// Concurrency sets the number of chunks processed concurrently.
+func Concurrency(n int) OptionFunc {
+	return func(c *Component) error {
+		if n <= 0 {
+			return errors.New("concurrency should be positive")
+		}
+		c.concurrency = n
+		return nil
+	}
+}
+
+// Retries sets the number of times a failed chunk is processed again and the delay between the attempts,
+// before the job fails.
+func Retries(count uint, delay time.Duration) OptionFunc {
+	return func(c *Component) error {
+		if delay < 0 {
+			return errors.New("retry delay should not be negative")
+		}
+		c.retryCfg.count = count
+		c.retryCfg.delay = delay
+		return nil
+	}
+}
+
+// Checkpoints sets the store the progress of the job is persisted to, so that it can be resumed after a crash
+// from the last checkpoint. Since the chunks following the checkpoint are processed again, processing should be idempotent.
+func Checkpoints(store CheckpointStore) OptionFunc {
+	return func(c *Component) error {
+		if store == nil {
+			return errors.New("checkpoint store is nil")
+		}
+		c.store = store
+		return nil
+	}
+}
@@ -0,0 +1,51 @@
+package batch
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConcurrency(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		n           int
+		expectedErr string
+	}{
+		"success":  {n: 4},
+		"zero":     {n: 0, expectedErr: "concurrency should be positive"},
+		"negative": {n: -1, expectedErr: "concurrency should be positive"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			c := &Component{}
+			err := Concurrency(tt.n)(c)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.n, c.concurrency)
+			}
+		})
+	}
+}
+
+func TestRetries(t *testing.T) {
+	t.Parallel()
+	c := &Component{}
+	assert.NoError(t, Retries(5, time.Second)(c))
+	assert.Equal(t, retryConfig{count: 5, delay: time.Second}, c.retryCfg)
+	assert.EqualError(t, Retries(5, -time.Second)(c), "retry delay should not be negative")
+}
+
+func TestCheckpoints(t *testing.T) {
+	t.Parallel()
+	c := &Component{}
+	store := newMemoryStore()
+	assert.NoError(t, Checkpoints(store)(c))
+	assert.Equal(t, store, c.store)
+	assert.EqualError(t, Checkpoints(nil)(c), "checkpoint store is nil")
+}
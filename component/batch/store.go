@@ -0,0 +1,77 @@
+package batch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// FileStore persists the checkpoints of the jobs in a directory, in a JSON file per job.
+// It fits jobs running on a persistent volume, while jobs running on ephemeral hosts should use a shared store instead.
+type FileStore struct {
+	dir string
+}
+
+type fileCheckpoint struct {
+	Cursor string `json:"cursor"`
+}
+
+// NewFileStore creates a file store in the provided directory, which is created if it does not exist.
+func NewFileStore(dir string) (*FileStore, error) {
+	if dir == "" {
+		return nil, errors.New("directory is empty")
+	}
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create checkpoint directory: %w", err)
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+// Load returns the last checkpointed cursor of the job, or an empty cursor if the job has not been checkpointed.
+func (fs *FileStore) Load(_ context.Context, job string) (string, error) {
+	b, err := ioutil.ReadFile(fs.path(job))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	var cp fileCheckpoint
+	if err := json.Unmarshal(b, &cp); err != nil {
+		return "", fmt.Errorf("failed to decode checkpoint: %w", err)
+	}
+	return cp.Cursor, nil
+}
+
+// Save persists the cursor of the job. The file is replaced atomically, so that a crash cannot corrupt the checkpoint.
+func (fs *FileStore) Save(_ context.Context, job, cursor string) error {
+	b, err := json.Marshal(fileCheckpoint{Cursor: cursor})
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(fs.dir, job+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err := tmp.Write(b); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), fs.path(job))
+}
+
+func (fs *FileStore) path(job string) string {
+	return filepath.Join(fs.dir, job+".json")
+}
@@ -0,0 +1,40 @@
+package batch
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileStore(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "checkpoints")
+	fs, err := NewFileStore(dir)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	cursor, err := fs.Load(ctx, "job")
+	assert.NoError(t, err)
+	assert.Empty(t, cursor)
+
+	require.NoError(t, fs.Save(ctx, "job", "10"))
+	require.NoError(t, fs.Save(ctx, "job", "20"))
+	cursor, err = fs.Load(ctx, "job")
+	assert.NoError(t, err)
+	assert.Equal(t, "20", cursor)
+
+	// no temporary files are left behind
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, files, 1)
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "corrupted.json"), []byte("{"), 0o600))
+	_, err = fs.Load(ctx, "corrupted")
+	assert.Error(t, err)
+
+	_, err = NewFileStore("")
+	assert.EqualError(t, err, "directory is empty")
+}
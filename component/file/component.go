@@ -0,0 +1,239 @@
+// Package file provides a component, which ingests the files dropped in a directory or an S3 prefix,
+// for integrations with legacy systems exchanging files. The location is polled for new files,
+// which are dispatched to a handler with at-least-once semantics.
+package file
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/beatlabs/patron/correlation"
+	"github.com/beatlabs/patron/log"
+	"github.com/beatlabs/patron/trace"
+	"github.com/google/uuid"
+	"github.com/opentracing/opentracing-go"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	componentType = "file-ingestion"
+
+	defaultPollInterval = 10 * time.Second
+	defaultMinAge       = 5 * time.Second
+	defaultRetries      = 3
+	defaultRetryDelay   = time.Second
+
+	processedState   = "processed"
+	retriedState     = "retried"
+	failedState      = "failed"
+	quarantinedState = "quarantined"
+)
+
+var fileCounterVec *prometheus.CounterVec
+
+func init() {
+	fileCounterVec = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "component",
+			Subsystem: "file",
+			Name:      "ingestion_counter",
+			Help:      "File ingestion counter, classified by component and state",
+		},
+		[]string{"component", "state"},
+	)
+	prometheus.MustRegister(fileCounterVec)
+}
+
+// HandlerFunc definition of a file handler, which reads the content of the file from r.
+type HandlerFunc func(ctx context.Context, f File, r io.Reader) error
+
+type retryConfig struct {
+	count uint
+	delay time.Duration
+}
+
+// Component ingesting the files of a store.
+type Component struct {
+	name          string
+	store         Store
+	handler       HandlerFunc
+	pollInterval  time.Duration
+	minAge        time.Duration
+	pattern       string
+	retryCfg      retryConfig
+	archiveDir    string
+	quarantineDir string
+}
+
+// New creates a file ingestion component, which polls the store every 10 seconds and hands the files, which have not been
+// modified for 5 seconds, to the handler in the order of their modification. Failed files are retried 3 times.
+func New(name string, store Store, handler HandlerFunc, oo ...OptionFunc) (*Component, error) {
+	if name == "" {
+		return nil, errors.New("name is required")
+	}
+	if store == nil {
+		return nil, errors.New("store is required")
+	}
+	if handler == nil {
+		return nil, errors.New("handler is required")
+	}
+
+	cmp := &Component{
+		name:         name,
+		store:        store,
+		handler:      handler,
+		pollInterval: defaultPollInterval,
+		minAge:       defaultMinAge,
+		retryCfg: retryConfig{
+			count: defaultRetries,
+			delay: defaultRetryDelay,
+		},
+	}
+
+	for _, optionFunc := range oo {
+		if err := optionFunc(cmp); err != nil {
+			return nil, err
+		}
+	}
+
+	if cmp.archiveDir != "" && cmp.archiveDir == cmp.quarantineDir {
+		return nil, errors.New("archive and quarantine directories should differ")
+	}
+
+	return cmp, nil
+}
+
+// Run polls the store for new files until the context is done.
+// Files are removed or archived only after they have been handled successfully, so that files interrupted by a shutdown
+// or a crash are handled again on the next run.
+func (c *Component) Run(ctx context.Context) error {
+	ticker := time.NewTicker(c.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		c.poll(ctx)
+		select {
+		case <-ctx.Done():
+			log.FromContext(ctx).Info("context cancellation received. exiting...")
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll handles the pending files of the store, failing to list them is logged and retried in the next poll.
+func (c *Component) poll(ctx context.Context) {
+	files, err := c.store.List(ctx)
+	if err != nil {
+		log.FromContext(ctx).Errorf("failed to list files of %s: %v", c.name, err)
+		return
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		if files[i].ModTime.Equal(files[j].ModTime) {
+			return files[i].Name < files[j].Name
+		}
+		return files[i].ModTime.Before(files[j].ModTime)
+	})
+
+	now := time.Now()
+	for _, f := range files {
+		if ctx.Err() != nil {
+			return
+		}
+		if now.Sub(f.ModTime) < c.minAge || !c.matches(f.Name) {
+			continue
+		}
+		c.process(ctx, f)
+	}
+}
+
+func (c *Component) matches(name string) bool {
+	if c.pattern == "" {
+		return true
+	}
+	matched, _ := path.Match(c.pattern, name)
+	return matched
+}
+
+// process hands the file to the handler, retrying failed attempts, and archives or removes it on success.
+// Files failing all attempts are moved to the quarantine directory, if any.
+func (c *Component) process(ctx context.Context, f File) {
+	corID := uuid.New().String()
+	sp, ctx := trace.ConsumerSpan(ctx, trace.ComponentOpName(componentType, c.name), componentType, corID, nil,
+		opentracing.Tag{Key: "file", Value: f.Name})
+	ctx = correlation.ContextWithID(ctx, corID)
+	logger := log.Sub(map[string]interface{}{correlation.ID: corID})
+	ctx = log.WithContext(ctx, logger)
+
+	err := c.handleWithRetries(ctx, f)
+	if err == nil {
+		fileCounterVec.WithLabelValues(c.name, processedState).Inc()
+		if err := c.complete(ctx, f); err != nil {
+			// the file is handled again in the next poll
+			logger.Errorf("failed to complete handled file %s: %v", f.Name, err)
+		}
+		trace.SpanSuccess(sp)
+		return
+	}
+
+	if ctx.Err() != nil {
+		// the file is left in place, in order to be handled again on the next run
+		trace.SpanError(sp)
+		return
+	}
+
+	logger.Errorf("failed to handle file %s: %v", f.Name, err)
+	fileCounterVec.WithLabelValues(c.name, failedState).Inc()
+	if c.quarantineDir != "" {
+		if err := c.store.Move(ctx, f.Name, c.quarantineDir); err != nil {
+			logger.Errorf("failed to quarantine file %s: %v", f.Name, err)
+		} else {
+			fileCounterVec.WithLabelValues(c.name, quarantinedState).Inc()
+		}
+	}
+	trace.SpanError(sp)
+}
+
+func (c *Component) handleWithRetries(ctx context.Context, f File) error {
+	var err error
+	for attempt := uint(0); attempt <= c.retryCfg.count; attempt++ {
+		if attempt > 0 {
+			fileCounterVec.WithLabelValues(c.name, retriedState).Inc()
+			select {
+			case <-ctx.Done():
+				return err
+			case <-time.After(c.retryCfg.delay):
+			}
+		}
+		if err = c.handle(ctx, f); err == nil || ctx.Err() != nil {
+			return err
+		}
+	}
+	return err
+}
+
+func (c *Component) handle(ctx context.Context, f File) error {
+	rc, err := c.store.Open(ctx, f.Name)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	defer func() {
+		if err := rc.Close(); err != nil {
+			log.FromContext(ctx).Warnf("failed to close file %s: %v", f.Name, err)
+		}
+	}()
+	return c.handler(ctx, f, rc)
+}
+
+func (c *Component) complete(ctx context.Context, f File) error {
+	if c.archiveDir != "" {
+		return c.store.Move(ctx, f.Name, c.archiveDir)
+	}
+	return c.store.Remove(ctx, f.Name)
+}
@@ -0,0 +1,228 @@
+package file
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type handled struct {
+	sync.Mutex
+	names    []string
+	contents []string
+}
+
+func (h *handled) handler(err error) HandlerFunc {
+	return func(_ context.Context, f File, r io.Reader) error {
+		data, rErr := ioutil.ReadAll(r)
+		if rErr != nil {
+			return rErr
+		}
+		h.Lock()
+		defer h.Unlock()
+		h.names = append(h.names, f.Name)
+		h.contents = append(h.contents, string(data))
+		return err
+	}
+}
+
+func (h *handled) count() int {
+	h.Lock()
+	defer h.Unlock()
+	return len(h.names)
+}
+
+func newTestDirectory(t *testing.T, files map[string]time.Time) (string, *Directory) {
+	dir := t.TempDir()
+	for name, modTime := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, ioutil.WriteFile(path, []byte("content of "+name), 0o600))
+		require.NoError(t, os.Chtimes(path, modTime, modTime))
+	}
+	store, err := NewDirectory(dir)
+	require.NoError(t, err)
+	return dir, store
+}
+
+func listDir(t *testing.T, dir string) []string {
+	ff, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	require.NoError(t, err)
+	names := make([]string, 0, len(ff))
+	for _, fi := range ff {
+		if !fi.IsDir() {
+			names = append(names, fi.Name())
+		}
+	}
+	return names
+}
+
+type failingStore struct {
+	Store
+	listErr error
+}
+
+func (f failingStore) List(context.Context) ([]File, error) {
+	return nil, f.listErr
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+	handler := func(context.Context, File, io.Reader) error { return nil }
+	store := &Directory{path: "."}
+	type args struct {
+		name    string
+		store   Store
+		handler HandlerFunc
+		oo      []OptionFunc
+	}
+	tests := map[string]struct {
+		args        args
+		expectedErr string
+	}{
+		"success":         {args: args{name: "name", store: store, handler: handler, oo: []OptionFunc{Archive("archive"), Quarantine("quarantine")}}},
+		"missing name":    {args: args{store: store, handler: handler}, expectedErr: "name is required"},
+		"missing store":   {args: args{name: "name", handler: handler}, expectedErr: "store is required"},
+		"missing handler": {args: args{name: "name", store: store}, expectedErr: "handler is required"},
+		"option failure":  {args: args{name: "name", store: store, handler: handler, oo: []OptionFunc{PollInterval(0)}}, expectedErr: "poll interval should be positive"},
+		"same directories": {
+			args:        args{name: "name", store: store, handler: handler, oo: []OptionFunc{Archive("done"), Quarantine("done")}},
+			expectedErr: "archive and quarantine directories should differ",
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			got, err := New(tt.args.name, tt.args.store, tt.args.handler, tt.args.oo...)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, got)
+			}
+		})
+	}
+}
+
+func TestComponent_Poll(t *testing.T) {
+	t.Parallel()
+	old := time.Now().Add(-time.Hour)
+	files := map[string]time.Time{
+		"b.csv":    old,
+		"a.csv":    old,
+		"c.csv":    old.Add(-time.Minute),
+		"d.txt":    old,
+		"e.csv":    time.Now(),
+		".tmp.csv": old,
+	}
+	tests := map[string]struct {
+		handlerErr         error
+		oo                 []OptionFunc
+		expectedHandled    []string
+		expectedRemaining  []string
+		expectedArchived   []string
+		expectedQuarantine []string
+	}{
+		"removed on success": {
+			expectedHandled:   []string{"c.csv", "a.csv", "b.csv", "d.txt"},
+			expectedRemaining: []string{".tmp.csv", "e.csv"},
+		},
+		"archived on success": {
+			oo:                []OptionFunc{Archive("archive"), Pattern("*.csv")},
+			expectedHandled:   []string{"c.csv", "a.csv", "b.csv"},
+			expectedRemaining: []string{".tmp.csv", "d.txt", "e.csv"},
+			expectedArchived:  []string{"a.csv", "b.csv", "c.csv"},
+		},
+		"quarantined on failure": {
+			handlerErr:         errors.New("handler error"),
+			oo:                 []OptionFunc{Quarantine("quarantine"), Pattern("a.*"), Retries(1, 0)},
+			expectedHandled:    []string{"a.csv", "a.csv"},
+			expectedRemaining:  []string{".tmp.csv", "b.csv", "c.csv", "d.txt", "e.csv"},
+			expectedQuarantine: []string{"a.csv"},
+		},
+		"left in place on failure": {
+			handlerErr:        errors.New("handler error"),
+			oo:                []OptionFunc{Pattern("a.*"), Retries(0, 0)},
+			expectedHandled:   []string{"a.csv"},
+			expectedRemaining: []string{".tmp.csv", "a.csv", "b.csv", "c.csv", "d.txt", "e.csv"},
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			dir, store := newTestDirectory(t, files)
+			h := &handled{}
+			cmp, err := New("ingestion", store, h.handler(tt.handlerErr), tt.oo...)
+			require.NoError(t, err)
+
+			cmp.poll(context.Background())
+
+			assert.Equal(t, tt.expectedHandled, h.names)
+			assert.Equal(t, "content of "+tt.expectedHandled[0], h.contents[0])
+			assert.Equal(t, tt.expectedRemaining, listDir(t, dir))
+			assert.Equal(t, tt.expectedArchived, listDir(t, filepath.Join(dir, "archive")))
+			assert.Equal(t, tt.expectedQuarantine, listDir(t, filepath.Join(dir, "quarantine")))
+		})
+	}
+}
+
+func TestComponent_Poll_ListFailure(t *testing.T) {
+	t.Parallel()
+	h := &handled{}
+	cmp, err := New("ingestion", failingStore{listErr: errors.New("list error")}, h.handler(nil))
+	require.NoError(t, err)
+
+	cmp.poll(context.Background())
+	assert.Zero(t, h.count())
+}
+
+func TestComponent_Poll_Canceled(t *testing.T) {
+	t.Parallel()
+	dir, store := newTestDirectory(t, map[string]time.Time{"a.csv": time.Now().Add(-time.Hour)})
+	ctx, cnl := context.WithCancel(context.Background())
+	cmp, err := New("ingestion", store, func(ctx context.Context, f File, r io.Reader) error {
+		cnl()
+		return ctx.Err()
+	}, Quarantine("quarantine"))
+	require.NoError(t, err)
+
+	cmp.poll(ctx)
+	assert.Equal(t, []string{"a.csv"}, listDir(t, dir))
+}
+
+func TestComponent_Run(t *testing.T) {
+	t.Parallel()
+	dir, store := newTestDirectory(t, nil)
+	h := &handled{}
+	cmp, err := New("ingestion", store, h.handler(nil), PollInterval(10*time.Millisecond), MinAge(0))
+	require.NoError(t, err)
+
+	ctx, cnl := context.WithCancel(context.Background())
+	chDone := make(chan error)
+	go func() {
+		chDone <- cmp.Run(ctx)
+	}()
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "a.csv"), []byte("a"), 0o600))
+	assert.Eventually(t, func() bool { return h.count() == 1 }, time.Second, 10*time.Millisecond)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "b.csv"), []byte("b"), 0o600))
+	assert.Eventually(t, func() bool { return h.count() == 2 }, time.Second, 10*time.Millisecond)
+
+	cnl()
+	assert.NoError(t, <-chDone)
+	assert.Empty(t, listDir(t, dir))
+}
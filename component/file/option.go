@@ -0,0 +1,84 @@
+package file
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"time"
+)
+
+// OptionFunc definition for configuring the component in a functional way.
+type OptionFunc func(*Component) error
+
+// PollInterval sets the interval between listing the files of the store.
+func PollInterval(interval time.Duration) OptionFunc {
+	return func(c *Component) error {
+		if interval <= 0 {
+			return errors.New("poll interval should be positive")
+		}
+		c.pollInterval = interval
+		return nil
+	}
+}
+
+// MinAge sets the time which should have elapsed since the last modification of a file, before it is ingested,
+// so that files which are still being written are not picked up.
+func MinAge(age time.Duration) OptionFunc {
+	return func(c *Component) error {
+		if age < 0 {
+			return errors.New("min age should not be negative")
+		}
+		c.minAge = age
+		return nil
+	}
+}
+
+// Pattern sets the shell pattern, e.g. `*.csv`, the names of the ingested files should match.
+func Pattern(pattern string) OptionFunc {
+	return func(c *Component) error {
+		if pattern == "" {
+			return errors.New("pattern is empty")
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid pattern %s: %w", pattern, err)
+		}
+		c.pattern = pattern
+		return nil
+	}
+}
+
+// Retries sets the number of times a file is handled again, after the handler has failed, and the delay between the attempts.
+func Retries(count uint, delay time.Duration) OptionFunc {
+	return func(c *Component) error {
+		if delay < 0 {
+			return errors.New("retry delay should not be negative")
+		}
+		c.retryCfg.count = count
+		c.retryCfg.delay = delay
+		return nil
+	}
+}
+
+// Archive sets the directory files are moved to, after they have been handled successfully.
+// Without it, the handled files are removed.
+func Archive(dir string) OptionFunc {
+	return func(c *Component) error {
+		if dir == "" {
+			return errors.New("archive directory is empty")
+		}
+		c.archiveDir = dir
+		return nil
+	}
+}
+
+// Quarantine sets the directory files are moved to, after they have failed all attempts.
+// Without it, the failed files are left in place and handled again in the next poll.
+func Quarantine(dir string) OptionFunc {
+	return func(c *Component) error {
+		if dir == "" {
+			return errors.New("quarantine directory is empty")
+		}
+		c.quarantineDir = dir
+		return nil
+	}
+}
@@ -0,0 +1,42 @@
+package file
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOptions(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		option      OptionFunc
+		expectedErr string
+	}{
+		"poll interval":         {option: PollInterval(time.Second)},
+		"invalid poll interval": {option: PollInterval(-time.Second), expectedErr: "poll interval should be positive"},
+		"min age":               {option: MinAge(0)},
+		"invalid min age":       {option: MinAge(-time.Second), expectedErr: "min age should not be negative"},
+		"pattern":               {option: Pattern("*.csv")},
+		"empty pattern":         {option: Pattern(""), expectedErr: "pattern is empty"},
+		"invalid pattern":       {option: Pattern("[a"), expectedErr: "invalid pattern [a: syntax error in pattern"},
+		"retries":               {option: Retries(5, time.Second)},
+		"invalid retry delay":   {option: Retries(5, -time.Second), expectedErr: "retry delay should not be negative"},
+		"archive":               {option: Archive("archive")},
+		"empty archive":         {option: Archive(""), expectedErr: "archive directory is empty"},
+		"quarantine":            {option: Quarantine("quarantine")},
+		"empty quarantine":      {option: Quarantine(""), expectedErr: "quarantine directory is empty"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			err := tt.option(&Component{})
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package file
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// File describes a file pending ingestion.
+type File struct {
+	// Name of the file, relative to the watched location.
+	Name string
+	// Size of the file in bytes.
+	Size int64
+	// ModTime is the last modification time of the file.
+	ModTime time.Time
+}
+
+// Store abstracts the location watched for new files, e.g. a local directory or an S3 prefix.
+type Store interface {
+	// List returns the files of the watched location, excluding the ones of its subdirectories.
+	List(ctx context.Context) ([]File, error)
+	// Open opens the file for reading.
+	Open(ctx context.Context, name string) (io.ReadCloser, error)
+	// Move moves the file under the provided directory, which is relative to the watched location unless absolute.
+	Move(ctx context.Context, name, dir string) error
+	// Remove removes the file.
+	Remove(ctx context.Context, name string) error
+}
+
+// Directory is a store watching a local directory.
+// Hidden files are ignored, so that writers can write to a hidden file and rename it, once it has been completely written.
+type Directory struct {
+	path string
+}
+
+// NewDirectory creates a store watching the provided directory, which should exist.
+func NewDirectory(path string) (*Directory, error) {
+	if path == "" {
+		return nil, errors.New("path is empty")
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat directory: %w", err)
+	}
+	if !fi.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", path)
+	}
+	return &Directory{path: path}, nil
+}
+
+// List returns the regular files of the directory.
+func (d *Directory) List(_ context.Context) ([]File, error) {
+	ff, err := ioutil.ReadDir(d.path)
+	if err != nil {
+		return nil, err
+	}
+	files := make([]File, 0, len(ff))
+	for _, fi := range ff {
+		if !fi.Mode().IsRegular() || strings.HasPrefix(fi.Name(), ".") {
+			continue
+		}
+		files = append(files, File{Name: fi.Name(), Size: fi.Size(), ModTime: fi.ModTime()})
+	}
+	return files, nil
+}
+
+// Open opens the file for reading.
+func (d *Directory) Open(_ context.Context, name string) (io.ReadCloser, error) {
+	return os.Open(filepath.Join(d.path, name))
+}
+
+// Move moves the file under the provided directory, creating it if needed. An existing file with the same name is replaced.
+// The directory should be on the same file system, since the file is renamed.
+func (d *Directory) Move(_ context.Context, name, dir string) error {
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(d.path, dir)
+	}
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return err
+	}
+	return os.Rename(filepath.Join(d.path, name), filepath.Join(dir, name))
+}
+
+// Remove removes the file.
+func (d *Directory) Remove(_ context.Context, name string) error {
+	return os.Remove(filepath.Join(d.path, name))
+}
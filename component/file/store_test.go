@@ -0,0 +1,67 @@
+package file
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewDirectory(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	file := filepath.Join(dir, "file")
+	require.NoError(t, ioutil.WriteFile(file, nil, 0o600))
+
+	got, err := NewDirectory(dir)
+	assert.NoError(t, err)
+	assert.NotNil(t, got)
+
+	_, err = NewDirectory("")
+	assert.EqualError(t, err, "path is empty")
+	_, err = NewDirectory(filepath.Join(dir, "missing"))
+	assert.Error(t, err)
+	_, err = NewDirectory(file)
+	assert.EqualError(t, err, file+" is not a directory")
+}
+
+func TestDirectory(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	archive := t.TempDir()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "a.csv"), []byte("a"), 0o600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "b.csv"), []byte("b"), 0o600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, ".c.csv"), []byte("c"), 0o600))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "subdir"), 0o750))
+	store, err := NewDirectory(dir)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	files, err := store.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	assert.Equal(t, "a.csv", files[0].Name)
+	assert.Equal(t, int64(1), files[0].Size)
+	assert.False(t, files[0].ModTime.IsZero())
+
+	rc, err := store.Open(ctx, "a.csv")
+	require.NoError(t, err)
+	data, err := ioutil.ReadAll(rc)
+	assert.NoError(t, err)
+	assert.Equal(t, "a", string(data))
+	assert.NoError(t, rc.Close())
+
+	assert.NoError(t, store.Move(ctx, "a.csv", "done"))
+	assert.FileExists(t, filepath.Join(dir, "done", "a.csv"))
+	assert.NoError(t, store.Move(ctx, "b.csv", archive))
+	assert.FileExists(t, filepath.Join(archive, "b.csv"))
+	assert.NoError(t, store.Remove(ctx, ".c.csv"))
+
+	files, err = store.List(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, files)
+}
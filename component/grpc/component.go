@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net"
 
+	"github.com/beatlabs/patron/cache"
 	"github.com/beatlabs/patron/errors"
 	"github.com/beatlabs/patron/log"
 	"google.golang.org/grpc"
@@ -42,6 +43,8 @@ func (c *Component) Run(ctx context.Context) error {
 type Builder struct {
 	port          int
 	serverOptions []grpc.ServerOption
+	requestLogger *requestLogger
+	deduplicator  *deduplicator
 	errors        []error
 }
 
@@ -65,6 +68,37 @@ func (b *Builder) WithOptions(oo ...grpc.ServerOption) *Builder {
 	return b
 }
 
+// WithRequestLogging enables logging every call with its method, peer, status code and duration,
+// along with sampled and redacted payloads of unary calls, if configured.
+func (b *Builder) WithRequestLogging(oo ...LoggingOptionFunc) *Builder {
+	if len(b.errors) != 0 {
+		return b
+	}
+	rl, err := newRequestLogger(oo...)
+	if err != nil {
+		b.errors = append(b.errors, err)
+		return b
+	}
+	b.requestLogger = rl
+	return b
+}
+
+// WithIdempotency enables deduplicating the unary calls with an idempotency key in their metadata (see IdempotencyKeyMetadata),
+// replaying the response or the error of the first call from the replay store to the calls with the same key,
+// so that retried mutations are executed once. Transient errors, e.g. Unavailable, are not replayed.
+func (b *Builder) WithIdempotency(store cache.TTLCache, oo ...IdempotencyOptionFunc) *Builder {
+	if len(b.errors) != 0 {
+		return b
+	}
+	d, err := newDeduplicator(store, oo...)
+	if err != nil {
+		b.errors = append(b.errors, err)
+		return b
+	}
+	b.deduplicator = d
+	return b
+}
+
 // Create the gRPC component.
 func (b *Builder) Create() (*Component, error) {
 	if len(b.errors) != 0 {
@@ -73,6 +107,14 @@ func (b *Builder) Create() (*Component, error) {
 
 	b.serverOptions = append(b.serverOptions, grpc.UnaryInterceptor(observableUnaryInterceptor),
 		grpc.StreamInterceptor(observableStreamInterceptor))
+	if b.requestLogger != nil {
+		// chained interceptors run after the observable ones, so the context logger carries the correlation ID
+		b.serverOptions = append(b.serverOptions, grpc.ChainUnaryInterceptor(b.requestLogger.unaryInterceptor),
+			grpc.ChainStreamInterceptor(b.requestLogger.streamInterceptor))
+	}
+	if b.deduplicator != nil {
+		b.serverOptions = append(b.serverOptions, grpc.ChainUnaryInterceptor(b.deduplicator.unaryInterceptor))
+	}
 
 	srv := grpc.NewServer(b.serverOptions...)
 
@@ -0,0 +1,328 @@
+package grpc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/beatlabs/patron/cache"
+	"github.com/beatlabs/patron/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+const (
+	// IdempotencyKeyMetadata is the metadata key of the idempotency key of a call, which is unique per mutation, e.g. a UUID.
+	IdempotencyKeyMetadata = "idempotency-key"
+	// IdempotentReplayedMetadata is the header metadata key marking the responses replayed from the replay store.
+	IdempotentReplayedMetadata = "idempotent-replayed"
+
+	idempotencyResultStored     = "stored"
+	idempotencyResultReplayed   = "replayed"
+	idempotencyResultConflict   = "conflict"
+	idempotencyResultInProgress = "in_progress"
+
+	defaultIdempotencyTTL     = 24 * time.Hour
+	defaultIdempotencyLockTTL = time.Minute
+)
+
+var idempotencyMetric *prometheus.CounterVec
+
+func init() {
+	idempotencyMetric = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "component",
+			Subsystem: "grpc",
+			Name:      "idempotent_calls_total",
+			Help:      "Unary calls with an idempotency key, classified by method and result (stored, replayed, conflict or in_progress).",
+		},
+		[]string{"grpc_service", "grpc_method", "result"},
+	)
+	prometheus.MustRegister(idempotencyMetric)
+}
+
+// IdempotencyOptionFunc definition for configuring the deduplication of calls in a functional way.
+type IdempotencyOptionFunc func(*deduplicator) error
+
+// IdempotencyTTL option for setting the time the outcomes of the calls are replayed for, which defaults to 24 hours.
+func IdempotencyTTL(ttl time.Duration) IdempotencyOptionFunc {
+	return func(d *deduplicator) error {
+		if ttl <= 0 {
+			return errors.New("idempotency ttl should be positive")
+		}
+		d.ttl = ttl
+		return nil
+	}
+}
+
+// IdempotencyLockTTL option for setting the time a call holds its idempotency key in caches shared across instances,
+// i.e. caches implementing cache.Locker, which should exceed the duration of the calls, and defaults to 1 minute.
+func IdempotencyLockTTL(ttl time.Duration) IdempotencyOptionFunc {
+	return func(d *deduplicator) error {
+		if ttl <= 0 {
+			return errors.New("idempotency lock ttl should be positive")
+		}
+		d.lockTTL = ttl
+		return nil
+	}
+}
+
+// IdempotentMethods option for deduplicating only the calls of the given full method names, e.g. "/examples.Greeter/SayHello",
+// instead of every unary call with an idempotency key.
+func IdempotentMethods(methods ...string) IdempotencyOptionFunc {
+	return func(d *deduplicator) error {
+		if len(methods) == 0 {
+			return errors.New("idempotent methods are empty")
+		}
+		d.methods = make(map[string]struct{}, len(methods))
+		for _, m := range methods {
+			d.methods[m] = struct{}{}
+		}
+		return nil
+	}
+}
+
+// deduplicator replays the outcomes of the unary calls with the same idempotency key from the replay store,
+// so that retried mutations are executed once.
+type deduplicator struct {
+	store   cache.TTLCache
+	ttl     time.Duration
+	lockTTL time.Duration
+	methods map[string]struct{}
+
+	mu       sync.Mutex
+	inFlight map[string]struct{}
+}
+
+func newDeduplicator(store cache.TTLCache, oo ...IdempotencyOptionFunc) (*deduplicator, error) {
+	if store == nil {
+		return nil, errors.New("idempotency replay store is nil")
+	}
+	d := &deduplicator{
+		store:    store,
+		ttl:      defaultIdempotencyTTL,
+		lockTTL:  defaultIdempotencyLockTTL,
+		inFlight: make(map[string]struct{}),
+	}
+
+	for _, o := range oo {
+		err := o(d)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return d, nil
+}
+
+// outcome of a call in the replay store.
+type outcome struct {
+	// Fingerprint of the request, which detects keys reused for different requests.
+	Fingerprint string `json:"fingerprint"`
+	// Response is the response of a successful call, marshaled as an Any message.
+	Response []byte `json:"response,omitempty"`
+	Code     uint32 `json:"code,omitempty"`
+	Message  string `json:"message,omitempty"`
+}
+
+func (d *deduplicator) unaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler) (interface{}, error) {
+	idemKey := idempotencyKey(ctx)
+	msg, ok := req.(proto.Message)
+	if idemKey == "" || !ok || !d.deduplicated(info.FullMethod) {
+		return handler(ctx, req)
+	}
+	svc, meth := splitMethodName(info.FullMethod)
+	fingerprint, err := requestFingerprint(msg)
+	if err != nil {
+		return handler(ctx, req)
+	}
+	key := "grpc-idempotency:" + info.FullMethod + ":" + idemKey
+
+	stored, err := d.get(key)
+	if err != nil {
+		log.FromContext(ctx).Warnf("failed to get outcome of idempotency key %s: %v", idemKey, err)
+	}
+	if stored != nil {
+		return d.replay(ctx, svc, meth, fingerprint, stored)
+	}
+
+	if !d.lock(ctx, key) {
+		idempotencyMetric.WithLabelValues(svc, meth, idempotencyResultInProgress).Inc()
+		return nil, status.Error(codes.Aborted, "call with the same idempotency key is in progress")
+	}
+	defer d.unlock(ctx, key)
+
+	// the outcome may have been stored while acquiring the lock
+	if stored, err := d.get(key); err == nil && stored != nil {
+		return d.replay(ctx, svc, meth, fingerprint, stored)
+	}
+
+	resp, err := handler(ctx, req)
+	if o, ok := newOutcome(fingerprint, resp, err); ok {
+		if setErr := d.set(key, o); setErr != nil {
+			log.FromContext(ctx).Warnf("failed to store outcome of idempotency key %s: %v", idemKey, setErr)
+		} else {
+			idempotencyMetric.WithLabelValues(svc, meth, idempotencyResultStored).Inc()
+		}
+	}
+	return resp, err
+}
+
+func (d *deduplicator) deduplicated(fullMethod string) bool {
+	if d.methods == nil {
+		return true
+	}
+	_, ok := d.methods[fullMethod]
+	return ok
+}
+
+// replay returns the stored outcome of a call, if it was made with the same request.
+func (d *deduplicator) replay(ctx context.Context, svc, meth, fingerprint string, o *outcome) (interface{}, error) {
+	if o.Fingerprint != fingerprint {
+		idempotencyMetric.WithLabelValues(svc, meth, idempotencyResultConflict).Inc()
+		return nil, status.Error(codes.InvalidArgument, "idempotency key is reused with a different request")
+	}
+	idempotencyMetric.WithLabelValues(svc, meth, idempotencyResultReplayed).Inc()
+	// the header is not set when the interceptor is called outside of a server transport, e.g. in tests
+	_ = grpc.SetHeader(ctx, metadata.Pairs(IdempotentReplayedMetadata, "true"))
+
+	if codes.Code(o.Code) != codes.OK {
+		return nil, status.Error(codes.Code(o.Code), o.Message)
+	}
+	var a anypb.Any
+	if err := proto.Unmarshal(o.Response, &a); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to decode replayed response: %v", err)
+	}
+	resp, err := a.UnmarshalNew()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to decode replayed response: %v", err)
+	}
+	return resp, nil
+}
+
+// lock acquires the idempotency key in this instance, and across instances if the store is a cache.Locker.
+func (d *deduplicator) lock(ctx context.Context, key string) bool {
+	d.mu.Lock()
+	if _, ok := d.inFlight[key]; ok {
+		d.mu.Unlock()
+		return false
+	}
+	d.inFlight[key] = struct{}{}
+	d.mu.Unlock()
+
+	locker, ok := d.store.(cache.Locker)
+	if !ok {
+		return true
+	}
+	locked, err := locker.TryLock(key, d.lockTTL)
+	if err != nil {
+		// the call proceeds, since the store is unavailable anyway
+		log.FromContext(ctx).Warnf("failed to lock idempotency key: %v", err)
+		return true
+	}
+	if !locked {
+		d.mu.Lock()
+		delete(d.inFlight, key)
+		d.mu.Unlock()
+	}
+	return locked
+}
+
+func (d *deduplicator) unlock(ctx context.Context, key string) {
+	if locker, ok := d.store.(cache.Locker); ok {
+		if err := locker.Unlock(key); err != nil {
+			log.FromContext(ctx).Warnf("failed to unlock idempotency key: %v", err)
+		}
+	}
+	d.mu.Lock()
+	delete(d.inFlight, key)
+	d.mu.Unlock()
+}
+
+func (d *deduplicator) get(key string) (*outcome, error) {
+	v, ok, err := d.store.Get(key)
+	if err != nil || !ok {
+		return nil, err
+	}
+	var b []byte
+	switch value := v.(type) {
+	case []byte:
+		b = value
+	case string:
+		// the redis cache returns strings instead of bytes
+		b = []byte(value)
+	default:
+		return nil, fmt.Errorf("outcome has unexpected type %T", v)
+	}
+	o := &outcome{}
+	if err := json.Unmarshal(b, o); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+func (d *deduplicator) set(key string, o *outcome) error {
+	b, err := json.Marshal(o)
+	if err != nil {
+		return err
+	}
+	return d.store.SetTTL(key, b, d.ttl)
+}
+
+// newOutcome returns the outcome of the call to store, which is either its response or its error, unless it is transient,
+// e.g. Unavailable, in which case the call is executed again when retried.
+func newOutcome(fingerprint string, resp interface{}, err error) (*outcome, bool) {
+	if err != nil {
+		st := status.Convert(err)
+		switch st.Code() {
+		case codes.Unknown, codes.Internal, codes.Unavailable, codes.DeadlineExceeded, codes.Canceled, codes.ResourceExhausted, codes.Aborted:
+			return nil, false
+		}
+		return &outcome{Fingerprint: fingerprint, Code: uint32(st.Code()), Message: st.Message()}, true
+	}
+	msg, ok := resp.(proto.Message)
+	if !ok {
+		return nil, false
+	}
+	a, err := anypb.New(msg)
+	if err != nil {
+		return nil, false
+	}
+	b, err := proto.Marshal(a)
+	if err != nil {
+		return nil, false
+	}
+	return &outcome{Fingerprint: fingerprint, Response: b}, true
+}
+
+func idempotencyKey(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	values := md.Get(IdempotencyKeyMetadata)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+func requestFingerprint(msg proto.Message) (string, error) {
+	b, err := proto.MarshalOptions{Deterministic: true}.Marshal(msg)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,309 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/beatlabs/patron/examples"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+type memoryTTLCache struct {
+	mu     sync.Mutex
+	values map[string]interface{}
+	locks  map[string]struct{}
+	err    error
+}
+
+func newMemoryTTLCache() *memoryTTLCache {
+	return &memoryTTLCache{values: make(map[string]interface{}), locks: make(map[string]struct{})}
+}
+
+func (m *memoryTTLCache) Get(key string) (interface{}, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.err != nil {
+		return nil, false, m.err
+	}
+	v, ok := m.values[key]
+	return v, ok, nil
+}
+
+func (m *memoryTTLCache) Purge() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.values = make(map[string]interface{})
+	return nil
+}
+
+func (m *memoryTTLCache) Remove(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.values, key)
+	return nil
+}
+
+func (m *memoryTTLCache) Set(key string, value interface{}) error {
+	return m.SetTTL(key, value, 0)
+}
+
+func (m *memoryTTLCache) SetTTL(key string, value interface{}, _ time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.err != nil {
+		return m.err
+	}
+	// values are stored as strings, like in the redis cache
+	m.values[key] = string(value.([]byte))
+	return nil
+}
+
+// lockingTTLCache is a cache shared across instances, whose locks are held by another instance when the key is in locks.
+type lockingTTLCache struct {
+	*memoryTTLCache
+}
+
+func (m lockingTTLCache) TryLock(key string, _ time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, held := m.locks[key]
+	return !held, nil
+}
+
+func (m lockingTTLCache) Unlock(string) error {
+	return nil
+}
+
+func TestNewDeduplicator(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		store  *memoryTTLCache
+		oo     []IdempotencyOptionFunc
+		expErr string
+	}{
+		"success":          {store: newMemoryTTLCache(), oo: []IdempotencyOptionFunc{IdempotencyTTL(time.Hour), IdempotencyLockTTL(time.Second), IdempotentMethods("/examples.Greeter/SayHello")}},
+		"nil store":        {expErr: "idempotency replay store is nil"},
+		"invalid ttl":      {store: newMemoryTTLCache(), oo: []IdempotencyOptionFunc{IdempotencyTTL(0)}, expErr: "idempotency ttl should be positive"},
+		"invalid lock ttl": {store: newMemoryTTLCache(), oo: []IdempotencyOptionFunc{IdempotencyLockTTL(-1)}, expErr: "idempotency lock ttl should be positive"},
+		"no methods":       {store: newMemoryTTLCache(), oo: []IdempotencyOptionFunc{IdempotentMethods()}, expErr: "idempotent methods are empty"},
+		"defaults":         {store: newMemoryTTLCache()},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			var got *deduplicator
+			var err error
+			if tt.store == nil {
+				got, err = newDeduplicator(nil, tt.oo...)
+			} else {
+				got, err = newDeduplicator(tt.store, tt.oo...)
+			}
+			if tt.expErr != "" {
+				assert.EqualError(t, err, tt.expErr)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, got)
+			}
+		})
+	}
+}
+
+func TestBuilder_WithIdempotency(t *testing.T) {
+	t.Parallel()
+	cmp, err := New(60000).WithIdempotency(newMemoryTTLCache(), IdempotencyTTL(0)).Create()
+	assert.EqualError(t, err, "idempotency ttl should be positive\n")
+	assert.Nil(t, cmp)
+
+	cmp, err = New(60000).WithIdempotency(newMemoryTTLCache()).Create()
+	assert.NoError(t, err)
+	assert.NotNil(t, cmp)
+}
+
+func idempotentContext(key string) context.Context {
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs(IdempotencyKeyMetadata, key))
+}
+
+func TestDeduplicator_UnaryInterceptor(t *testing.T) {
+	t.Parallel()
+	info := &grpc.UnaryServerInfo{FullMethod: "/examples.Greeter/SayHello"}
+	tests := map[string]struct {
+		oo            []IdempotencyOptionFunc
+		ctx           context.Context
+		requests      []*examples.HelloRequest
+		handlerErr    error
+		expectedCalls int32
+		expectedCode  codes.Code
+		expectedMsg   string
+	}{
+		"replayed": {
+			ctx:           idempotentContext("1"),
+			requests:      []*examples.HelloRequest{{Firstname: "John"}, {Firstname: "John"}},
+			expectedCalls: 1,
+		},
+		"no idempotency key": {
+			ctx:           context.Background(),
+			requests:      []*examples.HelloRequest{{Firstname: "John"}, {Firstname: "John"}},
+			expectedCalls: 2,
+		},
+		"method not deduplicated": {
+			oo:            []IdempotencyOptionFunc{IdempotentMethods("/examples.Greeter/SayHelloStream")},
+			ctx:           idempotentContext("1"),
+			requests:      []*examples.HelloRequest{{Firstname: "John"}, {Firstname: "John"}},
+			expectedCalls: 2,
+		},
+		"different request": {
+			ctx:           idempotentContext("1"),
+			requests:      []*examples.HelloRequest{{Firstname: "John"}, {Firstname: "Jane"}},
+			expectedCalls: 1,
+			expectedCode:  codes.InvalidArgument,
+			expectedMsg:   "idempotency key is reused with a different request",
+		},
+		"error replayed": {
+			ctx:           idempotentContext("1"),
+			requests:      []*examples.HelloRequest{{Firstname: "John"}, {Firstname: "John"}},
+			handlerErr:    status.Error(codes.FailedPrecondition, "no greetings"),
+			expectedCalls: 1,
+			expectedCode:  codes.FailedPrecondition,
+			expectedMsg:   "no greetings",
+		},
+		"transient error not replayed": {
+			ctx:           idempotentContext("1"),
+			requests:      []*examples.HelloRequest{{Firstname: "John"}, {Firstname: "John"}},
+			handlerErr:    status.Error(codes.Unavailable, "try again"),
+			expectedCalls: 2,
+			expectedCode:  codes.Unavailable,
+			expectedMsg:   "try again",
+		},
+		"unknown error not replayed": {
+			ctx:           idempotentContext("1"),
+			requests:      []*examples.HelloRequest{{Firstname: "John"}, {Firstname: "John"}},
+			handlerErr:    errors.New("failure"),
+			expectedCalls: 2,
+			expectedCode:  codes.Unknown,
+			expectedMsg:   "failure",
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			d, err := newDeduplicator(newMemoryTTLCache(), tt.oo...)
+			require.NoError(t, err)
+
+			var calls int32
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				atomic.AddInt32(&calls, 1)
+				if tt.handlerErr != nil {
+					return nil, tt.handlerErr
+				}
+				return &examples.HelloReply{Message: "Hello " + req.(*examples.HelloRequest).GetFirstname()}, nil
+			}
+
+			var resp interface{}
+			for _, req := range tt.requests {
+				resp, err = d.unaryInterceptor(tt.ctx, req, info, handler)
+			}
+			assert.Equal(t, tt.expectedCalls, atomic.LoadInt32(&calls))
+			if tt.expectedCode != codes.OK {
+				assert.Equal(t, tt.expectedCode, status.Code(err))
+				assert.Equal(t, tt.expectedMsg, status.Convert(err).Message())
+				assert.Nil(t, resp)
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, proto.Equal(&examples.HelloReply{Message: "Hello John"}, resp.(proto.Message)))
+		})
+	}
+}
+
+func TestDeduplicator_UnaryInterceptor_InProgress(t *testing.T) {
+	t.Parallel()
+	info := &grpc.UnaryServerInfo{FullMethod: "/examples.Greeter/SayHello"}
+	req := &examples.HelloRequest{Firstname: "John"}
+	d, err := newDeduplicator(newMemoryTTLCache())
+	require.NoError(t, err)
+
+	started, release := make(chan struct{}), make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, err := d.unaryInterceptor(idempotentContext("1"), req, info, func(context.Context, interface{}) (interface{}, error) {
+			close(started)
+			<-release
+			return &examples.HelloReply{Message: "Hello John"}, nil
+		})
+		assert.NoError(t, err)
+	}()
+	<-started
+
+	_, err = d.unaryInterceptor(idempotentContext("1"), req, info, func(context.Context, interface{}) (interface{}, error) {
+		t.Fatal("handler should not be called")
+		return nil, nil
+	})
+	assert.Equal(t, codes.Aborted, status.Code(err))
+	close(release)
+	<-done
+
+	resp, err := d.unaryInterceptor(idempotentContext("1"), req, info, func(context.Context, interface{}) (interface{}, error) {
+		t.Fatal("handler should not be called")
+		return nil, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Hello John", resp.(*examples.HelloReply).GetMessage())
+}
+
+func TestDeduplicator_UnaryInterceptor_Locker(t *testing.T) {
+	t.Parallel()
+	info := &grpc.UnaryServerInfo{FullMethod: "/examples.Greeter/SayHello"}
+	store := lockingTTLCache{memoryTTLCache: newMemoryTTLCache()}
+	store.locks["grpc-idempotency:/examples.Greeter/SayHello:1"] = struct{}{}
+	d, err := newDeduplicator(store)
+	require.NoError(t, err)
+
+	_, err = d.unaryInterceptor(idempotentContext("1"), &examples.HelloRequest{}, info, func(context.Context, interface{}) (interface{}, error) {
+		t.Fatal("handler should not be called")
+		return nil, nil
+	})
+	assert.Equal(t, codes.Aborted, status.Code(err))
+
+	// the lock of this instance is released, when the lock of the other instance is held
+	delete(store.locks, "grpc-idempotency:/examples.Greeter/SayHello:1")
+	resp, err := d.unaryInterceptor(idempotentContext("1"), &examples.HelloRequest{}, info, func(context.Context, interface{}) (interface{}, error) {
+		return &examples.HelloReply{Message: "Hello"}, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Hello", resp.(*examples.HelloReply).GetMessage())
+}
+
+func TestDeduplicator_UnaryInterceptor_StoreFailure(t *testing.T) {
+	t.Parallel()
+	info := &grpc.UnaryServerInfo{FullMethod: "/examples.Greeter/SayHello"}
+	store := newMemoryTTLCache()
+	store.err = errors.New("unavailable")
+	d, err := newDeduplicator(store)
+	require.NoError(t, err)
+
+	var calls int32
+	for i := 0; i < 2; i++ {
+		resp, err := d.unaryInterceptor(idempotentContext("1"), &examples.HelloRequest{}, info, func(context.Context, interface{}) (interface{}, error) {
+			atomic.AddInt32(&calls, 1)
+			return &examples.HelloReply{Message: "Hello"}, nil
+		})
+		require.NoError(t, err)
+		assert.NotNil(t, resp)
+	}
+	// the calls are executed, since the outcomes cannot be stored
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
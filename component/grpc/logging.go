@@ -0,0 +1,182 @@
+package grpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/beatlabs/patron/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+const redactedValue = "[REDACTED]"
+
+// LoggingOptionFunc definition for configuring the request logging in a functional way.
+type LoggingOptionFunc func(*requestLogger) error
+
+// PayloadSampling option for logging the request and response payloads of the given fraction of unary calls, between 0 and 1.
+func PayloadSampling(rate float64) LoggingOptionFunc {
+	return func(rl *requestLogger) error {
+		if rate <= 0 || rate > 1 {
+			return errors.New("payload sampling rate should be in the range (0, 1]")
+		}
+		rl.sampling = rate
+		return nil
+	}
+}
+
+// RedactFields option for redacting fields in the logged payloads by their full name, e.g. "examples.HelloRequest.lastname".
+// It complements the (patron.redact) field option, for messages which cannot be annotated.
+func RedactFields(names ...string) LoggingOptionFunc {
+	return func(rl *requestLogger) error {
+		if len(names) == 0 {
+			return errors.New("redacted fields are empty")
+		}
+		for _, name := range names {
+			rl.redacted[protoreflect.FullName(name)] = struct{}{}
+		}
+		return nil
+	}
+}
+
+// requestLogger logs every call, along with sampled payloads, similarly to an HTTP access log.
+type requestLogger struct {
+	sampling float64
+	redacted map[protoreflect.FullName]struct{}
+}
+
+func newRequestLogger(oo ...LoggingOptionFunc) (*requestLogger, error) {
+	rl := &requestLogger{redacted: make(map[protoreflect.FullName]struct{})}
+
+	for _, o := range oo {
+		err := o(rl)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return rl, nil
+}
+
+func (rl *requestLogger) unaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler) (interface{}, error) {
+	started := time.Now()
+	resp, err := handler(ctx, req)
+
+	fields := rl.fields(ctx, unary, info.FullMethod, started, err)
+	if rl.sampled() {
+		fields["request"] = rl.payload(req)
+		if err == nil {
+			fields["response"] = rl.payload(resp)
+		}
+	}
+	log.FromContext(ctx).Sub(fields).Info("request log")
+	return resp, err
+}
+
+func (rl *requestLogger) streamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler) error {
+	started := time.Now()
+	err := handler(srv, ss)
+
+	log.FromContext(ss.Context()).Sub(rl.fields(ss.Context(), stream, info.FullMethod, started, err)).Info("request log")
+	return err
+}
+
+func (rl *requestLogger) fields(ctx context.Context, typ, fullMethod string, started time.Time, err error) map[string]interface{} {
+	st, _ := status.FromError(err)
+	fields := map[string]interface{}{
+		"server-type": "grpc",
+		"type":        typ,
+		method:        fullMethod,
+		"code":        st.Code().String(),
+		"duration":    time.Since(started).String(),
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		fields["peer"] = p.Addr.String()
+	}
+	if err != nil {
+		fields["error"] = st.Message()
+	}
+	return fields
+}
+
+func (rl *requestLogger) sampled() bool {
+	return rl.sampling > 0 && rand.Float64() < rl.sampling // nolint:gosec
+}
+
+// payload returns the JSON representation of the message, with the redacted fields masked.
+func (rl *requestLogger) payload(v interface{}) string {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return fmt.Sprintf("%v", v)
+	}
+	msg = proto.Clone(msg)
+	rl.redact(msg.ProtoReflect())
+	b, err := protojson.Marshal(msg)
+	if err != nil {
+		return fmt.Sprintf("failed to encode payload: %v", err)
+	}
+	// protojson output is deliberately unstable in terms of whitespace
+	buf := bytes.Buffer{}
+	if err := json.Compact(&buf, b); err != nil {
+		return string(b)
+	}
+	return buf.String()
+}
+
+// redact masks the redacted string fields and clears every other redacted field, recursively.
+func (rl *requestLogger) redact(m protoreflect.Message) {
+	var redacted []protoreflect.FieldDescriptor
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		switch {
+		case rl.isRedacted(fd):
+			redacted = append(redacted, fd)
+		case fd.IsMap():
+			if fd.MapValue().Message() != nil {
+				v.Map().Range(func(_ protoreflect.MapKey, mv protoreflect.Value) bool {
+					rl.redact(mv.Message())
+					return true
+				})
+			}
+		case fd.IsList():
+			if fd.Message() != nil {
+				for i := 0; i < v.List().Len(); i++ {
+					rl.redact(v.List().Get(i).Message())
+				}
+			}
+		case fd.Message() != nil:
+			rl.redact(v.Message())
+		}
+		return true
+	})
+
+	for _, fd := range redacted {
+		if fd.Kind() == protoreflect.StringKind && fd.Cardinality() != protoreflect.Repeated {
+			m.Set(fd, protoreflect.ValueOfString(redactedValue))
+			continue
+		}
+		m.Clear(fd)
+	}
+}
+
+func (rl *requestLogger) isRedacted(fd protoreflect.FieldDescriptor) bool {
+	if _, ok := rl.redacted[fd.FullName()]; ok {
+		return true
+	}
+	opts := fd.Options()
+	if opts == nil {
+		return false
+	}
+	redact, _ := proto.GetExtension(opts, E_Redact).(bool)
+	return redact
+}
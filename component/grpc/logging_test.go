@@ -0,0 +1,198 @@
+package grpc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/beatlabs/patron/examples"
+	"github.com/beatlabs/patron/log"
+	"github.com/beatlabs/patron/log/std"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func TestNewRequestLogger(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		oo     []LoggingOptionFunc
+		expErr string
+	}{
+		"success":          {oo: []LoggingOptionFunc{PayloadSampling(0.5), RedactFields("examples.HelloRequest.lastname")}},
+		"no options":       {},
+		"invalid sampling": {oo: []LoggingOptionFunc{PayloadSampling(1.5)}, expErr: "payload sampling rate should be in the range (0, 1]"},
+		"zero sampling":    {oo: []LoggingOptionFunc{PayloadSampling(0)}, expErr: "payload sampling rate should be in the range (0, 1]"},
+		"no fields":        {oo: []LoggingOptionFunc{RedactFields()}, expErr: "redacted fields are empty"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			got, err := newRequestLogger(tt.oo...)
+			if tt.expErr != "" {
+				assert.EqualError(t, err, tt.expErr)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, got)
+			}
+		})
+	}
+}
+
+func TestBuilder_WithRequestLogging(t *testing.T) {
+	t.Parallel()
+	cmp, err := New(60000).WithRequestLogging(PayloadSampling(2)).Create()
+	assert.EqualError(t, err, "payload sampling rate should be in the range (0, 1]\n")
+	assert.Nil(t, cmp)
+
+	cmp, err = New(60000).WithRequestLogging(PayloadSampling(1)).Create()
+	assert.NoError(t, err)
+	assert.NotNil(t, cmp)
+}
+
+func TestRequestLogger_UnaryInterceptor(t *testing.T) {
+	t.Parallel()
+	info := &grpc.UnaryServerInfo{FullMethod: "/examples.Greeter/SayHello"}
+	req := &examples.HelloRequest{Firstname: "John", Lastname: "Doe"}
+
+	tests := map[string]struct {
+		oo          []LoggingOptionFunc
+		handlerErr  error
+		expected    []string
+		notExpected []string
+	}{
+		"success without payloads": {
+			expected:    []string{"method=/examples.Greeter/SayHello", "code=OK", "type=unary", "peer=127.0.0.1:1234", "request log"},
+			notExpected: []string{"request={", "response={"},
+		},
+		"success with redacted payloads": {
+			oo: []LoggingOptionFunc{PayloadSampling(1), RedactFields("examples.HelloRequest.lastname")},
+			expected: []string{
+				`request={"firstname":"John","lastname":"[REDACTED]"}`,
+				`response={"message":"Hello John"}`,
+			},
+		},
+		"error": {
+			oo:          []LoggingOptionFunc{PayloadSampling(1)},
+			handlerErr:  errors.New("failure"),
+			expected:    []string{"code=Unknown", "error=failure", `request={"firstname":"John","lastname":"Doe"}`},
+			notExpected: []string{"response="},
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			rl, err := newRequestLogger(tt.oo...)
+			require.NoError(t, err)
+
+			var b bytes.Buffer
+			ctx := log.WithContext(context.Background(), std.New(&b, log.DebugLevel, nil))
+			ctx = peer.NewContext(ctx, &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 1234}})
+
+			resp, err := rl.unaryInterceptor(ctx, req, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+				if tt.handlerErr != nil {
+					return nil, tt.handlerErr
+				}
+				return &examples.HelloReply{Message: "Hello John"}, nil
+			})
+			assert.Equal(t, tt.handlerErr, err)
+			if tt.handlerErr == nil {
+				assert.NotNil(t, resp)
+			}
+			for _, exp := range tt.expected {
+				assert.Contains(t, b.String(), exp)
+			}
+			for _, exp := range tt.notExpected {
+				assert.NotContains(t, b.String(), exp)
+			}
+			// the request itself is not modified by the redaction
+			assert.Equal(t, "Doe", req.GetLastname())
+		})
+	}
+}
+
+type testServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *testServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func TestRequestLogger_StreamInterceptor(t *testing.T) {
+	t.Parallel()
+	rl, err := newRequestLogger(PayloadSampling(1))
+	require.NoError(t, err)
+
+	var b bytes.Buffer
+	ss := &testServerStream{ctx: log.WithContext(context.Background(), std.New(&b, log.DebugLevel, nil))}
+	info := &grpc.StreamServerInfo{FullMethod: "/examples.Greeter/SayHelloStream"}
+
+	err = rl.streamInterceptor(nil, ss, info, func(srv interface{}, stream grpc.ServerStream) error {
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Contains(t, b.String(), "method=/examples.Greeter/SayHelloStream")
+	assert.Contains(t, b.String(), "type=stream")
+	assert.Contains(t, b.String(), "code=OK")
+	assert.NotContains(t, b.String(), "request=")
+}
+
+func TestRequestLogger_isRedacted_Option(t *testing.T) {
+	t.Parallel()
+	redacted := &descriptorpb.FieldOptions{}
+	proto.SetExtension(redacted, E_Redact, true)
+
+	fdp := &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("redact_test.proto"),
+		Package: proto.String("patron.test"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("Credentials"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				{
+					Name:   proto.String("username"),
+					Number: proto.Int32(1),
+					Label:  descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+					Type:   descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+				},
+				{
+					Name:    proto.String("password"),
+					Number:  proto.Int32(2),
+					Label:   descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+					Type:    descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+					Options: redacted,
+				},
+			},
+		}},
+	}
+	fd, err := protodesc.NewFile(fdp, protoregistry.GlobalFiles)
+	require.NoError(t, err)
+
+	rl, err := newRequestLogger()
+	require.NoError(t, err)
+	fields := fd.Messages().Get(0).Fields()
+	assert.False(t, rl.isRedacted(fields.ByName("username")))
+	assert.True(t, rl.isRedacted(fields.ByName("password")))
+}
+
+func TestRequestLogger_payload(t *testing.T) {
+	t.Parallel()
+	rl, err := newRequestLogger(RedactFields("examples.HelloRequest.firstname"))
+	require.NoError(t, err)
+
+	assert.Equal(t, `{"lastname":"Doe"}`, rl.payload(&examples.HelloRequest{Lastname: "Doe"}))
+	assert.Equal(t, `{"firstname":"[REDACTED]","lastname":"Doe"}`, rl.payload(&examples.HelloRequest{Firstname: "John", Lastname: "Doe"}))
+	assert.Equal(t, "not a proto", rl.payload("not a proto"))
+}
@@ -7,8 +7,8 @@ import (
 
 	"github.com/beatlabs/patron/correlation"
 	"github.com/beatlabs/patron/log"
+	"github.com/beatlabs/patron/propagation"
 	"github.com/beatlabs/patron/trace"
-	"github.com/google/uuid"
 	"github.com/opentracing/opentracing-go"
 	"github.com/prometheus/client_golang/prometheus"
 	"google.golang.org/grpc"
@@ -62,10 +62,9 @@ type observer struct {
 }
 
 func newObserver(ctx context.Context, typ, fullMethodName string) *observer {
-	md := grpcMetadata(ctx)
-	corID := getCorrelationID(md)
-
-	sp, ctx := grpcSpan(ctx, fullMethodName, corID, md)
+	sp, ctx := propagation.ConsumerSpan(ctx, trace.ComponentOpName(componentName, fullMethodName), componentName,
+		propagation.GRPCMetadata(grpcMetadata(ctx)))
+	corID := correlation.IDFromContext(ctx)
 
 	ctx = log.WithContext(ctx, log.Sub(map[string]interface{}{correlation.ID: corID}))
 
@@ -148,27 +147,6 @@ func splitMethodName(fullMethodName string) (string, string) {
 	return "unknown", "unknown"
 }
 
-func getCorrelationID(md metadata.MD) string {
-	values := md.Get(correlation.HeaderID)
-	if len(values) == 0 {
-		return uuid.New().String()
-	}
-	return values[0]
-}
-
-func mapHeader(md metadata.MD) map[string]string {
-	mp := make(map[string]string, md.Len())
-	for key, values := range md {
-		mp[key] = values[0]
-	}
-	return mp
-}
-
-func grpcSpan(ctx context.Context, fullName, corID string, md metadata.MD) (opentracing.Span, context.Context) {
-	return trace.ConsumerSpan(ctx, trace.ComponentOpName(componentName, fullName), componentName,
-		corID, mapHeader(md))
-}
-
 func grpcMetadata(ctx context.Context) metadata.MD {
 	md, ok := metadata.FromIncomingContext(ctx)
 	if !ok {
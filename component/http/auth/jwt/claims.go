@@ -0,0 +1,94 @@
+package jwt
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// Claims of a verified token.
+type Claims map[string]interface{}
+
+type claimsKey struct{}
+
+// ClaimsFromContext returns the claims of the token of the request, which is authenticated by the middleware.
+func ClaimsFromContext(ctx context.Context) (Claims, bool) {
+	c, ok := ctx.Value(claimsKey{}).(Claims)
+	return c, ok
+}
+
+func contextWithClaims(ctx context.Context, c Claims) context.Context {
+	return context.WithValue(ctx, claimsKey{}, c)
+}
+
+// Subject returns the sub claim.
+func (c Claims) Subject() string {
+	s, _ := c["sub"].(string)
+	return s
+}
+
+// Issuer returns the iss claim.
+func (c Claims) Issuer() string {
+	s, _ := c["iss"].(string)
+	return s
+}
+
+// Audience returns the aud claim, which is either a string or an array of strings.
+func (c Claims) Audience() []string {
+	return c.Strings("aud")
+}
+
+// Scopes returns the scopes of the space-delimited scope claim, or of the scp claim of some providers.
+func (c Claims) Scopes() []string {
+	if scope, ok := c["scope"].(string); ok {
+		return strings.Fields(scope)
+	}
+	return c.Strings("scp")
+}
+
+// Strings returns the values of the claim, which is either a string or an array of strings.
+// The path of nested claims is dot-separated, e.g. realm_access.roles.
+func (c Claims) Strings(path string) []string {
+	var v interface{} = map[string]interface{}(c)
+	for _, key := range strings.Split(path, ".") {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		v = m[key]
+	}
+
+	switch vv := v.(type) {
+	case string:
+		return []string{vv}
+	case []interface{}:
+		ss := make([]string, 0, len(vv))
+		for _, item := range vv {
+			if s, ok := item.(string); ok {
+				ss = append(ss, s)
+			}
+		}
+		return ss
+	default:
+		return nil
+	}
+}
+
+// time returns the time of the NumericDate claim, and false if it is missing.
+func (c Claims) time(key string) (time.Time, bool, error) {
+	v, ok := c[key]
+	if !ok {
+		return time.Time{}, false, nil
+	}
+	n, ok := v.(json.Number)
+	if !ok {
+		return time.Time{}, false, invalidToken("claim %s is not a number", key)
+	}
+	f, err := n.Float64()
+	if err != nil {
+		return time.Time{}, false, invalidToken("claim %s is not a number", key)
+	}
+	sec := int64(f)
+	return time.Unix(sec, int64((f-float64(sec))*float64(time.Second))), true, nil
+}
@@ -0,0 +1,231 @@
+package jwt
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/beatlabs/patron/log"
+)
+
+// jwk is a JSON web key of RFC 7517.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	// RSA keys
+	N string `json:"n"`
+	E string `json:"e"`
+	// EC keys
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// publicKey is a verification key of the key set.
+type publicKey struct {
+	key crypto.PublicKey
+	// alg restricts the algorithm of the key, if set.
+	alg string
+}
+
+// keySet caches the keys of a JWKS endpoint, which are fetched again once the refresh interval elapses,
+// or when a token is signed with an unknown key, e.g. after the keys are rotated. The endpoint is not fetched more often than the minimum refresh interval.
+type keySet struct {
+	url        string
+	client     *http.Client
+	refresh    time.Duration
+	minRefresh time.Duration
+
+	mu        sync.RWMutex
+	keys      map[string]publicKey
+	fetched   time.Time
+	attempted time.Time
+	err       error
+	// fetchMu serializes the fetches of the keys.
+	fetchMu sync.Mutex
+}
+
+func (ks *keySet) key(ctx context.Context, kid string) (publicKey, error) {
+	ks.mu.RLock()
+	keys, fetched, attempted, lastErr := ks.keys, ks.fetched, ks.attempted, ks.err
+	ks.mu.RUnlock()
+
+	key, found := lookup(keys, kid)
+	if found && time.Since(fetched) < ks.refresh {
+		return key, nil
+	}
+	if time.Since(attempted) < ks.minRefresh {
+		if keys == nil {
+			return publicKey{}, lastErr
+		}
+		return key, keyError(found, kid)
+	}
+
+	if err := ks.fetch(ctx, attempted); err != nil {
+		if keys == nil {
+			return publicKey{}, err
+		}
+		// the cached keys are still served while the endpoint is unavailable
+		log.FromContext(ctx).Warnf("failed to refresh JWKS, using cached keys: %v", err)
+	}
+
+	ks.mu.RLock()
+	key, found = lookup(ks.keys, kid)
+	ks.mu.RUnlock()
+	return key, keyError(found, kid)
+}
+
+func lookup(keys map[string]publicKey, kid string) (publicKey, bool) {
+	if kid == "" && len(keys) == 1 {
+		for _, key := range keys {
+			return key, true
+		}
+	}
+	key, ok := keys[kid]
+	return key, ok
+}
+
+func keyError(found bool, kid string) error {
+	if found {
+		return nil
+	}
+	return invalidToken("key %s is unknown", kid)
+}
+
+// fetch fetches the keys, unless a concurrent call attempted to fetch them since the provided time, returning the error of its attempt.
+func (ks *keySet) fetch(ctx context.Context, since time.Time) error {
+	ks.fetchMu.Lock()
+	defer ks.fetchMu.Unlock()
+
+	ks.mu.RLock()
+	attempted, lastErr := ks.attempted, ks.err
+	ks.mu.RUnlock()
+	if attempted.After(since) {
+		return lastErr
+	}
+
+	keys, err := ks.get(ctx)
+
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	ks.attempted = time.Now()
+	ks.err = err
+	if err != nil {
+		return err
+	}
+	ks.keys = keys
+	ks.fetched = ks.attempted
+	return nil
+}
+
+func (ks *keySet) get(ctx context.Context) (map[string]publicKey, error) {
+	var doc struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := getJSON(ctx, ks.client, ks.url, &doc); err != nil {
+		return nil, fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+
+	keys := make(map[string]publicKey, len(doc.Keys))
+	for _, k := range doc.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			log.FromContext(ctx).Warnf("skipping JWKS key %s: %v", k.Kid, err)
+			continue
+		}
+		keys[k.Kid] = publicKey{key: key, alg: k.Alg}
+	}
+	return keys, nil
+}
+
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid modulus: %w", err)
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exponent: %w", err)
+		}
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, errors.New("invalid exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %s", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("invalid x coordinate: %w", err)
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("invalid y coordinate: %w", err)
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("point is not on the curve")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %s", k.Kty)
+	}
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == 0 {
+		return nil, errors.New("value is empty")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+func getJSON(ctx context.Context, client *http.Client, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	rsp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_, _ = io.Copy(ioutil.Discard, rsp.Body)
+		_ = rsp.Body.Close()
+	}()
+	if rsp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d", rsp.StatusCode)
+	}
+	return json.NewDecoder(rsp.Body).Decode(v)
+}
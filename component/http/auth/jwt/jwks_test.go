@@ -0,0 +1,121 @@
+package jwt
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeySet_Caching(t *testing.T) {
+	t.Parallel()
+	jwks := newJWKSServer(t, rsaJWK("rsa", rsaKey))
+	a, err := New(jwks.URL)
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		_, err = a.Verify(context.Background(), sign(t, "RS256", "rsa", rsaKey, validClaims()))
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&jwks.requests))
+
+	// unknown keys are not fetched again within the minimum refresh interval
+	_, err = a.Verify(context.Background(), sign(t, "RS256", "unknown", rsaKey, validClaims()))
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&jwks.requests))
+}
+
+func TestKeySet_Rotation(t *testing.T) {
+	t.Parallel()
+	jwks := newJWKSServer(t, rsaJWK("rsa", rsaKey))
+	a, err := New(jwks.URL, RefreshInterval(time.Hour, 0))
+	require.NoError(t, err)
+
+	_, err = a.Verify(context.Background(), sign(t, "RS256", "rsa", rsaKey, validClaims()))
+	require.NoError(t, err)
+
+	jwks.keys.Store([]jwk{rsaJWK("rsa", rsaKey), ecJWK("ec", ecKey)})
+	_, err = a.Verify(context.Background(), sign(t, "ES256", "ec", ecKey, validClaims()))
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&jwks.requests))
+}
+
+func TestKeySet_Unavailable(t *testing.T) {
+	t.Parallel()
+	jwks := newJWKSServer(t, rsaJWK("rsa", rsaKey))
+	atomic.StoreInt32(&jwks.fail, 1)
+	a, err := New(jwks.URL, RefreshInterval(time.Millisecond, time.Millisecond))
+	require.NoError(t, err)
+
+	token := sign(t, "RS256", "rsa", rsaKey, validClaims())
+	_, err = a.Verify(context.Background(), token)
+	assert.EqualError(t, err, "failed to fetch JWKS: unexpected status 503")
+	assert.NotErrorIs(t, err, ErrInvalidToken)
+
+	atomic.StoreInt32(&jwks.fail, 0)
+	time.Sleep(2 * time.Millisecond)
+	_, err = a.Verify(context.Background(), token)
+	require.NoError(t, err)
+
+	// the cached keys are used while the endpoint is unavailable
+	atomic.StoreInt32(&jwks.fail, 1)
+	time.Sleep(2 * time.Millisecond)
+	_, err = a.Verify(context.Background(), token)
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&jwks.requests))
+}
+
+func TestKeySet_Throttling(t *testing.T) {
+	t.Parallel()
+	jwks := newJWKSServer(t)
+	atomic.StoreInt32(&jwks.fail, 1)
+	a, err := New(jwks.URL)
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		_, err = a.Verify(context.Background(), sign(t, "RS256", "rsa", rsaKey, validClaims()))
+		assert.EqualError(t, err, "failed to fetch JWKS: unexpected status 503")
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&jwks.requests))
+}
+
+func TestJWK_PublicKey(t *testing.T) {
+	t.Parallel()
+	otherCurve, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+	offCurve := ecJWK("ec", ecKey)
+	offCurve.X, offCurve.Y = encodeBigInt(otherCurve.X), encodeBigInt(otherCurve.Y)
+
+	tests := map[string]struct {
+		jwk         jwk
+		expectedErr string
+	}{
+		"RSA":               {jwk: rsaJWK("rsa", rsaKey)},
+		"EC":                {jwk: ecJWK("ec", ecKey)},
+		"unsupported type":  {jwk: jwk{Kty: "oct"}, expectedErr: "unsupported key type oct"},
+		"missing modulus":   {jwk: jwk{Kty: "RSA", E: "AQAB"}, expectedErr: "invalid modulus: value is empty"},
+		"invalid exponent":  {jwk: jwk{Kty: "RSA", N: "AQAB", E: "!"}, expectedErr: "invalid exponent: illegal base64 data at input byte 0"},
+		"unsupported curve": {jwk: jwk{Kty: "EC", Crv: "P-192"}, expectedErr: "unsupported curve P-192"},
+		"off curve":         {jwk: offCurve, expectedErr: "point is not on the curve"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			key, err := tt.jwk.publicKey()
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.Nil(t, key)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, key)
+			}
+		})
+	}
+}
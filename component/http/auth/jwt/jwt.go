@@ -0,0 +1,270 @@
+// Package jwt is a concrete implementation of the auth abstractions, which authenticates the requests by the JWT bearer tokens
+// of their Authorization header, e.g. the access tokens of an OpenID Connect provider.
+package jwt
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	// registers the hash functions of the algorithms
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+)
+
+const (
+	defaultRefreshInterval    = time.Hour
+	defaultMinRefreshInterval = time.Minute
+	defaultRolesClaim         = "roles"
+)
+
+// ErrInvalidToken is wrapped by the errors of the tokens which fail verification.
+var ErrInvalidToken = errors.New("invalid token")
+
+func invalidToken(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrInvalidToken, fmt.Sprintf(format, args...))
+}
+
+// algorithms maps the supported signing algorithms to their hash functions. HMAC and none are not supported.
+var algorithms = map[string]crypto.Hash{
+	"RS256": crypto.SHA256, "RS384": crypto.SHA384, "RS512": crypto.SHA512,
+	"PS256": crypto.SHA256, "PS384": crypto.SHA384, "PS512": crypto.SHA512,
+	"ES256": crypto.SHA256, "ES384": crypto.SHA384, "ES512": crypto.SHA512,
+}
+
+// Authenticator verifies JWT bearer tokens, signed with the RSA or ECDSA keys of a JWKS endpoint.
+type Authenticator struct {
+	keys       *keySet
+	issuer     string
+	audiences  []string
+	leeway     time.Duration
+	rolesClaim string
+}
+
+// New creates an authenticator of the tokens signed with the keys of the JWKS endpoint.
+func New(jwksURL string, oo ...OptionFunc) (*Authenticator, error) {
+	if jwksURL == "" {
+		return nil, errors.New("JWKS URL is empty")
+	}
+	a, err := newAuthenticator(oo...)
+	if err != nil {
+		return nil, err
+	}
+	a.keys.url = jwksURL
+	return a, nil
+}
+
+// NewOIDC creates an authenticator of the tokens of the OpenID Connect provider, whose JWKS endpoint is discovered from the configuration
+// of the issuer, i.e. {issuer}/.well-known/openid-configuration. The tokens are required to be issued by the issuer.
+func NewOIDC(ctx context.Context, issuer string, oo ...OptionFunc) (*Authenticator, error) {
+	if issuer == "" {
+		return nil, errors.New("issuer is empty")
+	}
+	a, err := newAuthenticator(oo...)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg struct {
+		Issuer  string `json:"issuer"`
+		JWKSURI string `json:"jwks_uri"`
+	}
+	if err := getJSON(ctx, a.keys.client, strings.TrimSuffix(issuer, "/")+"/.well-known/openid-configuration", &cfg); err != nil {
+		return nil, fmt.Errorf("failed to discover OpenID configuration: %w", err)
+	}
+	if cfg.Issuer != issuer {
+		return nil, fmt.Errorf("issuer %s of the OpenID configuration does not match %s", cfg.Issuer, issuer)
+	}
+	if cfg.JWKSURI == "" {
+		return nil, errors.New("OpenID configuration has no JWKS URI")
+	}
+	a.keys.url = cfg.JWKSURI
+	if a.issuer == "" {
+		a.issuer = issuer
+	}
+	return a, nil
+}
+
+func newAuthenticator(oo ...OptionFunc) (*Authenticator, error) {
+	a := &Authenticator{
+		keys: &keySet{
+			client:     &http.Client{Timeout: 10 * time.Second},
+			refresh:    defaultRefreshInterval,
+			minRefresh: defaultMinRefreshInterval,
+		},
+		rolesClaim: defaultRolesClaim,
+	}
+	for _, option := range oo {
+		if err := option(a); err != nil {
+			return nil, err
+		}
+	}
+	return a, nil
+}
+
+// Authenticate verifies the bearer token of the Authorization header of the request.
+// Requests without a valid token are not authenticated, while the failures to fetch the keys are returned as errors.
+func (a *Authenticator) Authenticate(req *http.Request) (bool, error) {
+	_, err := a.authenticate(req)
+	if errors.Is(err, ErrInvalidToken) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (a *Authenticator) authenticate(req *http.Request) (Claims, error) {
+	token, ok := bearerToken(req)
+	if !ok {
+		return nil, invalidToken("bearer token is missing")
+	}
+	return a.Verify(req.Context(), token)
+}
+
+func bearerToken(req *http.Request) (string, bool) {
+	auth := strings.SplitN(req.Header.Get("Authorization"), " ", 2)
+	if len(auth) != 2 || !strings.EqualFold(auth[0], "bearer") || auth[1] == "" {
+		return "", false
+	}
+	return auth[1], true
+}
+
+// Verify verifies the signature and the claims of the token, i.e. its expiry, not-before, issuer and audience, returning its claims.
+// The errors of invalid tokens wrap ErrInvalidToken.
+func (a *Authenticator) Verify(ctx context.Context, token string) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, invalidToken("token is malformed")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, invalidToken("header is malformed")
+	}
+	hash, ok := algorithms[header.Alg]
+	if !ok {
+		return nil, invalidToken("algorithm %s is not supported", header.Alg)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, invalidToken("signature is malformed")
+	}
+
+	key, err := a.keys.key(ctx, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	if key.alg != "" && key.alg != header.Alg {
+		return nil, invalidToken("algorithm %s does not match the algorithm of key %s", header.Alg, header.Kid)
+	}
+	if err := verifySignature(header.Alg, hash, key.key, parts[0]+"."+parts[1], signature); err != nil {
+		return nil, err
+	}
+
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, invalidToken("claims are malformed")
+	}
+	if err := a.verifyClaims(claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
+func verifySignature(alg string, hash crypto.Hash, key crypto.PublicKey, signed string, signature []byte) error {
+	h := hash.New()
+	_, _ = h.Write([]byte(signed))
+	digest := h.Sum(nil)
+
+	valid := false
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		switch alg[:2] {
+		case "RS":
+			valid = rsa.VerifyPKCS1v15(k, hash, digest, signature) == nil
+		case "PS":
+			valid = rsa.VerifyPSS(k, hash, digest, signature, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash}) == nil
+		default:
+			return invalidToken("algorithm %s does not match the RSA key", alg)
+		}
+	case *ecdsa.PublicKey:
+		if alg[:2] != "ES" {
+			return invalidToken("algorithm %s does not match the EC key", alg)
+		}
+		size := (k.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return invalidToken("signature is malformed")
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		valid = ecdsa.Verify(k, digest, r, s)
+	default:
+		return invalidToken("key type %T is not supported", key)
+	}
+	if !valid {
+		return invalidToken("signature is invalid")
+	}
+	return nil
+}
+
+func (a *Authenticator) verifyClaims(c Claims) error {
+	now := time.Now()
+	exp, ok, err := c.time("exp")
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return invalidToken("token has no expiry")
+	}
+	if now.After(exp.Add(a.leeway)) {
+		return invalidToken("token is expired")
+	}
+	nbf, ok, err := c.time("nbf")
+	if err != nil {
+		return err
+	}
+	if ok && now.Add(a.leeway).Before(nbf) {
+		return invalidToken("token is not valid yet")
+	}
+
+	if a.issuer != "" && c.Issuer() != a.issuer {
+		return invalidToken("issuer %s is not accepted", c.Issuer())
+	}
+	if len(a.audiences) > 0 && !containsAny(c.Audience(), a.audiences) {
+		return invalidToken("audience is not accepted")
+	}
+	return nil
+}
+
+func containsAny(values, expected []string) bool {
+	for _, v := range values {
+		for _, e := range expected {
+			if v == e {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,310 @@
+package jwt
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	rsaKey *rsa.PrivateKey
+	ecKey  *ecdsa.PrivateKey
+)
+
+func init() {
+	var err error
+	rsaKey, err = rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+	ecKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func encodeBigInt(i *big.Int) string {
+	return base64.RawURLEncoding.EncodeToString(i.Bytes())
+}
+
+func rsaJWK(kid string, key *rsa.PrivateKey) jwk {
+	return jwk{Kty: "RSA", Kid: kid, Use: "sig", N: encodeBigInt(key.N), E: encodeBigInt(big.NewInt(int64(key.E)))}
+}
+
+func ecJWK(kid string, key *ecdsa.PrivateKey) jwk {
+	return jwk{Kty: "EC", Kid: kid, Crv: "P-256", X: encodeBigInt(key.X), Y: encodeBigInt(key.Y)}
+}
+
+// jwksServer serves the keys, counting the requests.
+type jwksServer struct {
+	*httptest.Server
+	keys     atomic.Value
+	requests int32
+	fail     int32
+}
+
+func newJWKSServer(t *testing.T, keys ...jwk) *jwksServer {
+	s := &jwksServer{}
+	s.keys.Store(keys)
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&s.requests, 1)
+		if atomic.LoadInt32(&s.fail) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"keys": s.keys.Load()})
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func sign(t *testing.T, alg, kid string, key crypto.Signer, claims map[string]interface{}) string {
+	header := map[string]string{"alg": alg, "typ": "JWT"}
+	if kid != "" {
+		header["kid"] = kid
+	}
+	h, err := json.Marshal(header)
+	require.NoError(t, err)
+	c, err := json.Marshal(claims)
+	require.NoError(t, err)
+	signed := base64.RawURLEncoding.EncodeToString(h) + "." + base64.RawURLEncoding.EncodeToString(c)
+
+	hash := algorithms[alg]
+	hasher := hash.New()
+	_, _ = hasher.Write([]byte(signed))
+	digest := hasher.Sum(nil)
+
+	var signature []byte
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		if strings.HasPrefix(alg, "PS") {
+			signature, err = rsa.SignPSS(rand.Reader, k, hash, digest, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+		} else {
+			signature, err = rsa.SignPKCS1v15(rand.Reader, k, hash, digest)
+		}
+		require.NoError(t, err)
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, k, digest)
+		require.NoError(t, err)
+		size := (k.Curve.Params().BitSize + 7) / 8
+		signature = make([]byte, 2*size)
+		r.FillBytes(signature[:size])
+		s.FillBytes(signature[size:])
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func validClaims() map[string]interface{} {
+	return map[string]interface{}{
+		"iss":   "https://issuer.example.com",
+		"aud":   []string{"orders", "payments"},
+		"sub":   "user-1",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"scope": "orders:read orders:write",
+		"roles": []string{"support"},
+	}
+}
+
+func withClaim(key string, value interface{}) map[string]interface{} {
+	c := validClaims()
+	if value == nil {
+		delete(c, key)
+	} else {
+		c[key] = value
+	}
+	return c
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+	got, err := New("")
+	assert.EqualError(t, err, "JWKS URL is empty")
+	assert.Nil(t, got)
+
+	got, err = New("https://example.com/jwks", Issuer(""))
+	assert.EqualError(t, err, "issuer is empty")
+	assert.Nil(t, got)
+
+	got, err = New("https://example.com/jwks", Audience("orders"))
+	assert.NoError(t, err)
+	assert.Equal(t, "https://example.com/jwks", got.keys.url)
+	assert.Equal(t, defaultRefreshInterval, got.keys.refresh)
+	assert.Equal(t, defaultRolesClaim, got.rolesClaim)
+}
+
+func TestNewOIDC(t *testing.T) {
+	t.Parallel()
+	jwks := newJWKSServer(t, rsaJWK("rsa", rsaKey))
+	var issuer string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			_ = json.NewEncoder(w).Encode(map[string]string{"issuer": issuer, "jwks_uri": jwks.URL})
+		case "/other/.well-known/openid-configuration":
+			_ = json.NewEncoder(w).Encode(map[string]string{"issuer": "https://other.example.com", "jwks_uri": jwks.URL})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	issuer = srv.URL
+
+	got, err := NewOIDC(context.Background(), issuer, Audience("orders"))
+	require.NoError(t, err)
+	assert.Equal(t, jwks.URL, got.keys.url)
+	assert.Equal(t, issuer, got.issuer)
+
+	claims := withClaim("iss", issuer)
+	_, err = got.Verify(context.Background(), sign(t, "RS256", "rsa", rsaKey, claims))
+	assert.NoError(t, err)
+
+	_, err = NewOIDC(context.Background(), "")
+	assert.EqualError(t, err, "issuer is empty")
+	_, err = NewOIDC(context.Background(), srv.URL+"/other")
+	assert.EqualError(t, err, "issuer https://other.example.com of the OpenID configuration does not match "+srv.URL+"/other")
+	_, err = NewOIDC(context.Background(), srv.URL+"/missing")
+	assert.EqualError(t, err, "failed to discover OpenID configuration: unexpected status 404")
+}
+
+func TestAuthenticator_Verify(t *testing.T) {
+	t.Parallel()
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	restricted := rsaJWK("restricted", rsaKey)
+	restricted.Alg = "RS512"
+	jwks := newJWKSServer(t, rsaJWK("rsa", rsaKey), ecJWK("ec", ecKey), restricted,
+		jwk{Kty: "RSA", Kid: "enc", Use: "enc", N: encodeBigInt(rsaKey.N), E: "AQAB"})
+	a, err := New(jwks.URL, Issuer("https://issuer.example.com"), Audience("orders"), Leeway(time.Minute))
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		token       string
+		expectedErr string
+	}{
+		"RS256":                 {token: sign(t, "RS256", "rsa", rsaKey, validClaims())},
+		"RS512":                 {token: sign(t, "RS512", "restricted", rsaKey, validClaims())},
+		"PS384":                 {token: sign(t, "PS384", "rsa", rsaKey, validClaims())},
+		"ES256":                 {token: sign(t, "ES256", "ec", ecKey, validClaims())},
+		"audience string":       {token: sign(t, "RS256", "rsa", rsaKey, withClaim("aud", "orders"))},
+		"expired within leeway": {token: sign(t, "RS256", "rsa", rsaKey, withClaim("exp", time.Now().Add(-30*time.Second).Unix()))},
+		"malformed":             {token: "a.b", expectedErr: "invalid token: token is malformed"},
+		"malformed header":      {token: "a.b.c", expectedErr: "invalid token: header is malformed"},
+		"none": {
+			token:       base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`)) + ".e30.",
+			expectedErr: "invalid token: algorithm none is not supported",
+		},
+		"HS256": {
+			token:       base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","kid":"rsa"}`)) + ".e30.c2ln",
+			expectedErr: "invalid token: algorithm HS256 is not supported",
+		},
+		"unknown key":      {token: sign(t, "RS256", "unknown", rsaKey, validClaims()), expectedErr: "invalid token: key unknown is unknown"},
+		"encryption key":   {token: sign(t, "RS256", "enc", rsaKey, validClaims()), expectedErr: "invalid token: key enc is unknown"},
+		"restricted key":   {token: sign(t, "RS256", "restricted", rsaKey, validClaims()), expectedErr: "invalid token: algorithm RS256 does not match the algorithm of key restricted"},
+		"key type":         {token: sign(t, "ES256", "rsa", ecKey, validClaims()), expectedErr: "invalid token: algorithm ES256 does not match the RSA key"},
+		"other signer":     {token: sign(t, "RS256", "rsa", otherKey, validClaims()), expectedErr: "invalid token: signature is invalid"},
+		"no expiry":        {token: sign(t, "RS256", "rsa", rsaKey, withClaim("exp", nil)), expectedErr: "invalid token: token has no expiry"},
+		"invalid expiry":   {token: sign(t, "RS256", "rsa", rsaKey, withClaim("exp", "tomorrow")), expectedErr: "invalid token: claim exp is not a number"},
+		"expired":          {token: sign(t, "RS256", "rsa", rsaKey, withClaim("exp", time.Now().Add(-2*time.Minute).Unix())), expectedErr: "invalid token: token is expired"},
+		"not valid yet":    {token: sign(t, "RS256", "rsa", rsaKey, withClaim("nbf", time.Now().Add(2*time.Minute).Unix())), expectedErr: "invalid token: token is not valid yet"},
+		"other issuer":     {token: sign(t, "RS256", "rsa", rsaKey, withClaim("iss", "https://other.example.com")), expectedErr: "invalid token: issuer https://other.example.com is not accepted"},
+		"other audience":   {token: sign(t, "RS256", "rsa", rsaKey, withClaim("aud", "payments")), expectedErr: "invalid token: audience is not accepted"},
+		"missing audience": {token: sign(t, "RS256", "rsa", rsaKey, withClaim("aud", nil)), expectedErr: "invalid token: audience is not accepted"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			claims, err := a.Verify(context.Background(), tt.token)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.ErrorIs(t, err, ErrInvalidToken)
+				assert.Nil(t, claims)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, "user-1", claims.Subject())
+			}
+		})
+	}
+}
+
+func TestAuthenticator_Authenticate(t *testing.T) {
+	t.Parallel()
+	jwks := newJWKSServer(t, rsaJWK("rsa", rsaKey))
+	a, err := New(jwks.URL)
+	require.NoError(t, err)
+	unavailable, err := New("http://127.0.0.1:1/jwks")
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		auth          *Authenticator
+		header        string
+		authenticated bool
+		expectedErr   bool
+	}{
+		"valid token":      {auth: a, header: "Bearer " + sign(t, "RS256", "rsa", rsaKey, validClaims()), authenticated: true},
+		"lowercase":        {auth: a, header: "bearer " + sign(t, "RS256", "rsa", rsaKey, validClaims()), authenticated: true},
+		"missing header":   {auth: a},
+		"other scheme":     {auth: a, header: "Apikey 123"},
+		"invalid token":    {auth: a, header: "Bearer 123"},
+		"JWKS unavailable": {auth: unavailable, header: "Bearer " + sign(t, "RS256", "rsa", rsaKey, validClaims()), expectedErr: true},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Authorization", tt.header)
+			authenticated, err := tt.auth.Authenticate(req)
+			assert.Equal(t, tt.authenticated, authenticated)
+			if tt.expectedErr {
+				assert.Error(t, err)
+				assert.NotErrorIs(t, err, ErrInvalidToken)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestClaims(t *testing.T) {
+	t.Parallel()
+	c := Claims{
+		"sub":          "user-1",
+		"iss":          "issuer",
+		"aud":          "orders",
+		"scp":          []interface{}{"orders:read", 1},
+		"realm_access": map[string]interface{}{"roles": []interface{}{"admin"}},
+	}
+	assert.Equal(t, "user-1", c.Subject())
+	assert.Equal(t, "issuer", c.Issuer())
+	assert.Equal(t, []string{"orders"}, c.Audience())
+	assert.Equal(t, []string{"orders:read"}, c.Scopes())
+	assert.Equal(t, []string{"admin"}, c.Strings("realm_access.roles"))
+	assert.Nil(t, c.Strings("sub.roles"))
+	assert.Nil(t, c.Strings("missing"))
+
+	c["scope"] = "a b"
+	assert.Equal(t, []string{"a", "b"}, c.Scopes())
+
+	ctx := contextWithClaims(context.Background(), c)
+	got, ok := ClaimsFromContext(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, c, got)
+	_, ok = ClaimsFromContext(context.Background())
+	assert.False(t, ok)
+}
@@ -0,0 +1,73 @@
+package jwt
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/beatlabs/patron/component/http/middleware"
+	"github.com/beatlabs/patron/component/http/problem"
+)
+
+// Requirement of the claims of the tokens of a route.
+type Requirement struct {
+	// Scopes are required all, e.g. orders:read orders:write.
+	Scopes []string
+	// Roles are accepted any, e.g. admin or support, from the roles claim of the authenticator.
+	Roles []string
+}
+
+// NewMiddleware creates a middleware authenticating the requests by their bearer tokens, and authorizing them by the requirement.
+// The claims of the token are added to the context of the request (see ClaimsFromContext).
+// The requests without a valid token are rejected with a 401 Unauthorized problem, and the requests failing the requirement
+// with a 403 Forbidden one, along with the WWW-Authenticate header of RFC 6750.
+func NewMiddleware(auth *Authenticator, req Requirement) (middleware.Func, error) {
+	if auth == nil {
+		return nil, errors.New("authenticator is nil")
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, err := auth.authenticate(r)
+			if err != nil {
+				if !errors.Is(err, ErrInvalidToken) {
+					problem.Write(w, r, err)
+					return
+				}
+				if _, ok := bearerToken(r); ok {
+					w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer error="invalid_token", error_description=%q`, err.Error()))
+				} else {
+					w.Header().Set("WWW-Authenticate", "Bearer")
+				}
+				problem.Write(w, r, problem.Wrap(http.StatusUnauthorized, err))
+				return
+			}
+
+			if missing := missingScopes(claims.Scopes(), req.Scopes); len(missing) > 0 {
+				w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer error="insufficient_scope", scope=%q`, strings.Join(req.Scopes, " ")))
+				problem.Write(w, r, problem.New(http.StatusForbidden, "token is missing the scopes "+strings.Join(missing, " ")))
+				return
+			}
+			if len(req.Roles) > 0 && !containsAny(claims.Strings(auth.rolesClaim), req.Roles) {
+				problem.Write(w, r, problem.New(http.StatusForbidden, "token has none of the roles "+strings.Join(req.Roles, " ")))
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(contextWithClaims(r.Context(), claims)))
+		})
+	}, nil
+}
+
+func missingScopes(scopes, required []string) []string {
+	granted := make(map[string]struct{}, len(scopes))
+	for _, scope := range scopes {
+		granted[scope] = struct{}{}
+	}
+	var missing []string
+	for _, scope := range required {
+		if _, ok := granted[scope]; !ok {
+			missing = append(missing, scope)
+		}
+	}
+	return missing
+}
@@ -0,0 +1,88 @@
+package jwt
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/beatlabs/patron/component/http/problem"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewMiddleware(t *testing.T) {
+	t.Parallel()
+	mw, err := NewMiddleware(nil, Requirement{})
+	assert.EqualError(t, err, "authenticator is nil")
+	assert.Nil(t, mw)
+
+	jwks := newJWKSServer(t, rsaJWK("rsa", rsaKey))
+	a, err := New(jwks.URL, RolesClaim("realm_access.roles"))
+	require.NoError(t, err)
+	unavailable, err := New("http://127.0.0.1:1/jwks")
+	require.NoError(t, err)
+
+	admin := validClaims()
+	admin["realm_access"] = map[string]interface{}{"roles": []string{"admin"}}
+
+	tests := map[string]struct {
+		auth                    *Authenticator
+		req                     Requirement
+		header                  string
+		expectedStatus          int
+		expectedWWWAuthenticate string
+	}{
+		"authorized": {
+			auth: a, req: Requirement{Scopes: []string{"orders:read"}, Roles: []string{"admin", "support"}},
+			header: "Bearer " + sign(t, "RS256", "rsa", rsaKey, admin), expectedStatus: http.StatusOK,
+		},
+		"no requirement": {auth: a, header: "Bearer " + sign(t, "RS256", "rsa", rsaKey, validClaims()), expectedStatus: http.StatusOK},
+		"missing token":  {auth: a, expectedStatus: http.StatusUnauthorized, expectedWWWAuthenticate: "Bearer"},
+		"invalid token": {
+			auth: a, header: "Bearer 123", expectedStatus: http.StatusUnauthorized,
+			expectedWWWAuthenticate: `Bearer error="invalid_token", error_description="invalid token: token is malformed"`,
+		},
+		"missing scope": {
+			auth: a, req: Requirement{Scopes: []string{"orders:read", "orders:delete"}},
+			header: "Bearer " + sign(t, "RS256", "rsa", rsaKey, validClaims()), expectedStatus: http.StatusForbidden,
+			expectedWWWAuthenticate: `Bearer error="insufficient_scope", scope="orders:read orders:delete"`,
+		},
+		"missing role": {
+			auth: a, req: Requirement{Roles: []string{"admin"}},
+			header: "Bearer " + sign(t, "RS256", "rsa", rsaKey, validClaims()), expectedStatus: http.StatusForbidden,
+		},
+		"JWKS unavailable": {
+			auth: unavailable, header: "Bearer " + sign(t, "RS256", "rsa", rsaKey, validClaims()), expectedStatus: http.StatusInternalServerError,
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			mw, err := NewMiddleware(tt.auth, tt.req)
+			require.NoError(t, err)
+			var subject string
+			handler := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				claims, ok := ClaimsFromContext(r.Context())
+				require.True(t, ok)
+				subject = claims.Subject()
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rc := httptest.NewRecorder()
+			handler.ServeHTTP(rc, req)
+
+			assert.Equal(t, tt.expectedStatus, rc.Code)
+			assert.Equal(t, tt.expectedWWWAuthenticate, rc.Header().Get("WWW-Authenticate"))
+			if tt.expectedStatus == http.StatusOK {
+				assert.Equal(t, "user-1", subject)
+			} else {
+				assert.Equal(t, problem.ContentType, rc.Header().Get("Content-Type"))
+				assert.Empty(t, subject)
+			}
+		})
+	}
+}
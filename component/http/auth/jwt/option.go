@@ -0,0 +1,81 @@
+package jwt
+
+import (
+	"errors"
+	"net/http"
+	"time"
+)
+
+// OptionFunc definition for configuring the authenticator in a functional way.
+type OptionFunc func(*Authenticator) error
+
+// Issuer option for accepting only the tokens of the issuer, i.e. of the iss claim.
+func Issuer(issuer string) OptionFunc {
+	return func(a *Authenticator) error {
+		if issuer == "" {
+			return errors.New("issuer is empty")
+		}
+		a.issuer = issuer
+		return nil
+	}
+}
+
+// Audience option for accepting only the tokens of any of the audiences, i.e. of the aud claim.
+func Audience(audiences ...string) OptionFunc {
+	return func(a *Authenticator) error {
+		if len(audiences) == 0 {
+			return errors.New("audiences are empty")
+		}
+		a.audiences = audiences
+		return nil
+	}
+}
+
+// Leeway option for tolerating the clock skew with the issuer, when checking the expiry and not-before claims.
+func Leeway(leeway time.Duration) OptionFunc {
+	return func(a *Authenticator) error {
+		if leeway < 0 {
+			return errors.New("leeway should be a positive number or zero")
+		}
+		a.leeway = leeway
+		return nil
+	}
+}
+
+// RefreshInterval option for setting the interval the keys are fetched again at (default: 1h), and the minimum interval
+// between the fetches of unknown keys (default: 1m).
+func RefreshInterval(refresh, minRefresh time.Duration) OptionFunc {
+	return func(a *Authenticator) error {
+		if refresh <= 0 {
+			return errors.New("refresh interval should be positive")
+		}
+		if minRefresh < 0 || minRefresh > refresh {
+			return errors.New("minimum refresh interval should be between zero and the refresh interval")
+		}
+		a.keys.refresh = refresh
+		a.keys.minRefresh = minRefresh
+		return nil
+	}
+}
+
+// Client option for setting the HTTP client fetching the keys and the OpenID configuration.
+func Client(client *http.Client) OptionFunc {
+	return func(a *Authenticator) error {
+		if client == nil {
+			return errors.New("client is nil")
+		}
+		a.keys.client = client
+		return nil
+	}
+}
+
+// RolesClaim option for setting the claim of the roles (default: roles), whose path is dot-separated if nested, e.g. realm_access.roles.
+func RolesClaim(claim string) OptionFunc {
+	return func(a *Authenticator) error {
+		if claim == "" {
+			return errors.New("roles claim is empty")
+		}
+		a.rolesClaim = claim
+		return nil
+	}
+}
@@ -0,0 +1,46 @@
+package jwt
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOptions(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		option      OptionFunc
+		expectedErr string
+	}{
+		"issuer":                   {option: Issuer("https://issuer.example.com")},
+		"empty issuer":             {option: Issuer(""), expectedErr: "issuer is empty"},
+		"audience":                 {option: Audience("orders")},
+		"empty audiences":          {option: Audience(), expectedErr: "audiences are empty"},
+		"leeway":                   {option: Leeway(time.Minute)},
+		"negative leeway":          {option: Leeway(-time.Second), expectedErr: "leeway should be a positive number or zero"},
+		"refresh interval":         {option: RefreshInterval(time.Hour, time.Minute)},
+		"invalid refresh interval": {option: RefreshInterval(0, 0), expectedErr: "refresh interval should be positive"},
+		"invalid minimum refresh interval": {
+			option:      RefreshInterval(time.Minute, time.Hour),
+			expectedErr: "minimum refresh interval should be between zero and the refresh interval",
+		},
+		"client":            {option: Client(&http.Client{})},
+		"nil client":        {option: Client(nil), expectedErr: "client is nil"},
+		"roles claim":       {option: RolesClaim("realm_access.roles")},
+		"empty roles claim": {option: RolesClaim(""), expectedErr: "roles claim is empty"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			_, err := newAuthenticator(tt.option)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
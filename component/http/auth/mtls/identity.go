@@ -0,0 +1,55 @@
+package mtls
+
+import (
+	"context"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/url"
+)
+
+// Identity of a client, as stated by its verified certificate.
+type Identity struct {
+	Subject        pkix.Name
+	DNSNames       []string
+	EmailAddresses []string
+	// URIs are the URI names of the certificate, e.g. the SPIFFE ID of a workload.
+	URIs        []*url.URL
+	Certificate *x509.Certificate
+}
+
+type identityKey struct{}
+
+// IdentityFromContext returns the identity of the client of the request, whose certificate was verified by the server.
+func IdentityFromContext(ctx context.Context) (Identity, bool) {
+	id, ok := ctx.Value(identityKey{}).(Identity)
+	return id, ok
+}
+
+func contextWithIdentity(ctx context.Context, id Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, id)
+}
+
+// Identify wraps the handler, adding the identity of the verified client certificate of each request to its context.
+func Identify(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if id, ok := identityFromRequest(r); ok {
+			r = r.WithContext(contextWithIdentity(r.Context(), id))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func identityFromRequest(r *http.Request) (Identity, bool) {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return Identity{}, false
+	}
+	cert := r.TLS.VerifiedChains[0][0]
+	return Identity{
+		Subject:        cert.Subject,
+		DNSNames:       cert.DNSNames,
+		EmailAddresses: cert.EmailAddresses,
+		URIs:           cert.URIs,
+		Certificate:    cert,
+	}, true
+}
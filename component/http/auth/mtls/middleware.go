@@ -0,0 +1,74 @@
+package mtls
+
+import (
+	"net/http"
+
+	"github.com/beatlabs/patron/component/http/middleware"
+	"github.com/beatlabs/patron/component/http/problem"
+)
+
+// Requirement of the identities of the clients of a route. Each non-empty list of the requirement should contain
+// a value of the identity, while an empty requirement accepts any verified client.
+type Requirement struct {
+	// CommonNames of the subject, e.g. orders-service.
+	CommonNames []string
+	// Organizations of the subject.
+	Organizations []string
+	// OrganizationalUnits of the subject.
+	OrganizationalUnits []string
+	// DNSNames of the certificate.
+	DNSNames []string
+	// URIs of the certificate, e.g. spiffe://cluster.local/ns/default/sa/orders.
+	URIs []string
+}
+
+// NewMiddleware creates a middleware authorizing the requests by the identities of their verified client certificates.
+// The requests without a verified certificate are rejected with a 401 Unauthorized problem, and the requests failing
+// the requirement with a 403 Forbidden one. The identity is added to the context of the request (see IdentityFromContext).
+func NewMiddleware(req Requirement) middleware.Func {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id, ok := IdentityFromContext(r.Context())
+			if !ok {
+				id, ok = identityFromRequest(r)
+				if !ok {
+					problem.Write(w, r, problem.New(http.StatusUnauthorized, "verified client certificate is required"))
+					return
+				}
+				r = r.WithContext(contextWithIdentity(r.Context(), id))
+			}
+			if !req.allows(id) {
+				problem.Write(w, r, problem.New(http.StatusForbidden, "client certificate is not authorized"))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func (req Requirement) allows(id Identity) bool {
+	uris := make([]string, 0, len(id.URIs))
+	for _, u := range id.URIs {
+		uris = append(uris, u.String())
+	}
+	return matchesAny(req.CommonNames, []string{id.Subject.CommonName}) &&
+		matchesAny(req.Organizations, id.Subject.Organization) &&
+		matchesAny(req.OrganizationalUnits, id.Subject.OrganizationalUnit) &&
+		matchesAny(req.DNSNames, id.DNSNames) &&
+		matchesAny(req.URIs, uris)
+}
+
+// matchesAny returns true if the required values are empty, or contain any of the values.
+func matchesAny(required, values []string) bool {
+	if len(required) == 0 {
+		return true
+	}
+	for _, r := range required {
+		for _, v := range values {
+			if r == v {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+package mtls
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewMiddleware(t *testing.T) {
+	t.Parallel()
+	spiffe, _ := url.Parse("spiffe://cluster.local/ns/default/sa/orders")
+	cert := &x509.Certificate{
+		Subject:  pkix.Name{CommonName: "orders", Organization: []string{"Patron"}, OrganizationalUnit: []string{"Payments"}},
+		DNSNames: []string{"orders.example.com"},
+		URIs:     []*url.URL{spiffe},
+	}
+
+	tests := map[string]struct {
+		requirement    Requirement
+		tls            *tls.ConnectionState
+		expectedStatus int
+	}{
+		"any client": {tls: &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}, expectedStatus: http.StatusOK},
+		"matching requirement": {requirement: Requirement{
+			CommonNames:         []string{"billing", "orders"},
+			Organizations:       []string{"Patron"},
+			OrganizationalUnits: []string{"Payments"},
+			DNSNames:            []string{"orders.example.com"},
+			URIs:                []string{"spiffe://cluster.local/ns/default/sa/orders"},
+		}, tls: &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}, expectedStatus: http.StatusOK},
+		"other common name":      {requirement: Requirement{CommonNames: []string{"billing"}}, tls: &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}, expectedStatus: http.StatusForbidden},
+		"other URI":              {requirement: Requirement{URIs: []string{"spiffe://cluster.local/ns/default/sa/billing"}}, tls: &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}, expectedStatus: http.StatusForbidden},
+		"without TLS":            {expectedStatus: http.StatusUnauthorized},
+		"unverified certificate": {tls: &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}, expectedStatus: http.StatusUnauthorized},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			handler := NewMiddleware(tt.requirement)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				id, ok := IdentityFromContext(r.Context())
+				assert.True(t, ok)
+				assert.Equal(t, "orders", id.Subject.CommonName)
+				assert.Equal(t, cert, id.Certificate)
+				w.WriteHeader(http.StatusOK)
+			}))
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.TLS = tt.tls
+			rsp := httptest.NewRecorder()
+			handler.ServeHTTP(rsp, req)
+			assert.Equal(t, tt.expectedStatus, rsp.Code)
+		})
+	}
+}
+
+func TestIdentify(t *testing.T) {
+	t.Parallel()
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: "orders"}}
+	var got Identity
+	var found bool
+	handler := Identify(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		got, found = IdentityFromContext(r.Context())
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	assert.False(t, found)
+
+	req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	assert.True(t, found)
+	assert.Equal(t, "orders", got.Subject.CommonName)
+}
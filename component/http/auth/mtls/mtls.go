@@ -0,0 +1,214 @@
+// Package mtls is a concrete implementation of the auth abstractions, which authenticates the requests by the verified
+// TLS certificates of their clients, i.e. mutual TLS, and authorizes them by the identities of the certificates.
+package mtls
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/beatlabs/patron/log"
+	"golang.org/x/crypto/ocsp"
+)
+
+const (
+	ocspTimeout     = 5 * time.Second
+	ocspContentType = "application/ocsp-request"
+)
+
+// Config of the verification of the client certificates.
+type Config struct {
+	// CAFile contains the PEM-encoded certificates of the authorities issuing the client certificates.
+	CAFile string
+	// Optional accepts the clients without certificates, while verifying the certificates of the rest.
+	// The routes requiring a certificate should be protected by the middleware.
+	Optional bool
+	// CRLFiles contain the PEM or DER-encoded revocation lists of the authorities, which are read once.
+	CRLFiles []string
+	// OCSP checks the status of the client certificates with the OCSP responders of their authorities, if any,
+	// caching the responses until their next update.
+	OCSP bool
+	// OCSPFailOpen accepts the certificates whose status could not be determined, e.g. due to an unavailable responder.
+	OCSPFailOpen bool
+	// OCSPClient sends the OCSP requests, defaulting to a client with a 5 second timeout.
+	OCSPClient *http.Client
+}
+
+// TLSConfig returns the TLS configuration of a server verifying the client certificates according to the config.
+func (cfg Config) TLSConfig() (*tls.Config, error) {
+	if cfg.CAFile == "" {
+		return nil, errors.New("CA file is empty")
+	}
+	ca, err := ioutil.ReadFile(cfg.CAFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA file: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, errors.New("CA file contains no certificates")
+	}
+
+	v := &verifier{
+		ocsp:     cfg.OCSP,
+		failOpen: cfg.OCSPFailOpen,
+		client:   cfg.OCSPClient,
+		cache:    make(map[string]*ocsp.Response),
+	}
+	if v.client == nil {
+		v.client = &http.Client{Timeout: ocspTimeout}
+	}
+	for _, file := range cfg.CRLFiles {
+		crl, err := readCRL(file)
+		if err != nil {
+			return nil, err
+		}
+		v.crls = append(v.crls, crl)
+	}
+
+	tlsCfg := &tls.Config{
+		ClientCAs:  pool,
+		ClientAuth: tls.RequireAndVerifyClientCert,
+		MinVersion: tls.VersionTLS12,
+	}
+	if cfg.Optional {
+		tlsCfg.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+	if len(v.crls) > 0 || v.ocsp {
+		tlsCfg.VerifyPeerCertificate = v.verify
+	}
+	return tlsCfg, nil
+}
+
+func readCRL(file string) (*pkix.CertificateList, error) {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CRL file: %w", err)
+	}
+	// ParseCRL accepts both PEM and DER-encoded lists
+	crl, err := x509.ParseCRL(b) // nolint:staticcheck
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CRL file %s: %w", file, err)
+	}
+	return crl, nil
+}
+
+// verifier checks the revocation status of the verified certificate chains.
+type verifier struct {
+	crls     []*pkix.CertificateList
+	ocsp     bool
+	failOpen bool
+	client   *http.Client
+	mu       sync.Mutex
+	cache    map[string]*ocsp.Response
+}
+
+// verify accepts the certificate if any of its verified chains contains no revoked certificates.
+func (v *verifier) verify(_ [][]byte, chains [][]*x509.Certificate) error {
+	var err error
+	for _, chain := range chains {
+		err = v.verifyChain(chain)
+		if err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+func (v *verifier) verifyChain(chain []*x509.Certificate) error {
+	for i := 0; i < len(chain)-1; i++ {
+		cert, issuer := chain[i], chain[i+1]
+		if err := v.checkCRLs(cert, issuer); err != nil {
+			return err
+		}
+		if !v.ocsp {
+			continue
+		}
+		if err := v.checkOCSP(cert, issuer); err != nil {
+			if v.failOpen && !errors.Is(err, errRevoked) {
+				log.Warnf("accepting certificate %s: %v", cert.Subject, err)
+				continue
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+var errRevoked = errors.New("certificate is revoked")
+
+func (v *verifier) checkCRLs(cert, issuer *x509.Certificate) error {
+	for _, crl := range v.crls {
+		if crl.TBSCertList.Issuer.String() != issuer.Subject.ToRDNSequence().String() {
+			continue
+		}
+		if err := issuer.CheckCRLSignature(crl); err != nil { // nolint:staticcheck
+			continue
+		}
+		for _, revoked := range crl.TBSCertList.RevokedCertificates {
+			if revoked.SerialNumber.Cmp(cert.SerialNumber) == 0 {
+				return fmt.Errorf("%w: serial number %s", errRevoked, cert.SerialNumber)
+			}
+		}
+	}
+	return nil
+}
+
+func (v *verifier) checkOCSP(cert, issuer *x509.Certificate) error {
+	if len(cert.OCSPServer) == 0 {
+		return nil
+	}
+	key := string(issuer.RawSubject) + cert.SerialNumber.String()
+	v.mu.Lock()
+	rsp, ok := v.cache[key]
+	v.mu.Unlock()
+	if !ok || rsp.NextUpdate.Before(time.Now()) {
+		var err error
+		rsp, err = v.fetchOCSP(cert, issuer)
+		if err != nil {
+			return fmt.Errorf("failed to check OCSP status: %w", err)
+		}
+		if !rsp.NextUpdate.IsZero() {
+			v.mu.Lock()
+			v.cache[key] = rsp
+			v.mu.Unlock()
+		}
+	}
+
+	switch rsp.Status {
+	case ocsp.Good:
+		return nil
+	case ocsp.Revoked:
+		return fmt.Errorf("%w: serial number %s", errRevoked, cert.SerialNumber)
+	default:
+		return errors.New("OCSP status of certificate is unknown")
+	}
+}
+
+func (v *verifier) fetchOCSP(cert, issuer *x509.Certificate) (*ocsp.Response, error) {
+	req, err := ocsp.CreateRequest(cert, issuer, nil)
+	if err != nil {
+		return nil, err
+	}
+	httpRsp, err := v.client.Post(cert.OCSPServer[0], ocspContentType, bytes.NewReader(req))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = httpRsp.Body.Close()
+	}()
+	if httpRsp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d of OCSP responder", httpRsp.StatusCode)
+	}
+	body, err := ioutil.ReadAll(httpRsp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return ocsp.ParseResponseForCert(body, cert, issuer)
+}
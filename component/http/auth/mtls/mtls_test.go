@@ -0,0 +1,289 @@
+package mtls
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ocsp"
+)
+
+type authority struct {
+	cert *x509.Certificate
+	key  crypto.Signer
+	dir  string
+}
+
+func newAuthority(t *testing.T) *authority {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, key.Public(), key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &authority{cert: cert, key: key, dir: t.TempDir()}
+}
+
+func (a *authority) caFile(t *testing.T) string {
+	file := filepath.Join(a.dir, "ca.pem")
+	require.NoError(t, ioutil.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: a.cert.Raw}), 0o600))
+	return file
+}
+
+func (a *authority) crlFile(t *testing.T, revoked ...*x509.Certificate) string {
+	rr := make([]pkix.RevokedCertificate, 0, len(revoked))
+	for _, cert := range revoked {
+		rr = append(rr, pkix.RevokedCertificate{SerialNumber: cert.SerialNumber, RevocationTime: time.Now()})
+	}
+	der, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		RevokedCertificates: rr,
+		Number:              big.NewInt(1),
+		ThisUpdate:          time.Now().Add(-time.Minute),
+		NextUpdate:          time.Now().Add(time.Hour),
+	}, a.cert, a.key)
+	require.NoError(t, err)
+	file := filepath.Join(a.dir, "ca.crl")
+	require.NoError(t, ioutil.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: der}), 0o600))
+	return file
+}
+
+func (a *authority) issue(t *testing.T, serial int64, cn string, ocspServer string) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	spiffe, err := url.Parse("spiffe://cluster.local/ns/default/sa/" + cn)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: cn, Organization: []string{"Patron"}},
+		DNSNames:     []string{cn + ".example.com"},
+		URIs:         []*url.URL{spiffe},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	if ocspServer != "" {
+		tmpl.OCSPServer = []string{ocspServer}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, a.cert, key.Public(), a.key)
+	require.NoError(t, err)
+	leaf, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+}
+
+// ocspResponder responds with the status of the serial numbers, or unknown.
+func (a *authority) ocspResponder(t *testing.T, statuses map[int64]int, requests *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(requests, 1)
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		req, err := ocsp.ParseRequest(body)
+		require.NoError(t, err)
+		status, ok := statuses[req.SerialNumber.Int64()]
+		if !ok {
+			status = ocsp.Unknown
+		}
+		rsp, err := ocsp.CreateResponse(a.cert, a.cert, ocsp.Response{
+			Status:       status,
+			SerialNumber: req.SerialNumber,
+			ThisUpdate:   time.Now().Add(-time.Minute),
+			NextUpdate:   time.Now().Add(time.Hour),
+			RevokedAt:    time.Now().Add(-time.Minute),
+		}, a.key)
+		require.NoError(t, err)
+		_, _ = w.Write(rsp)
+	}))
+}
+
+func TestConfig_TLSConfig(t *testing.T) {
+	t.Parallel()
+	ca := newAuthority(t)
+	caFile := ca.caFile(t)
+	invalid := filepath.Join(ca.dir, "invalid.pem")
+	require.NoError(t, ioutil.WriteFile(invalid, []byte("invalid"), 0o600))
+
+	tests := map[string]struct {
+		cfg                Config
+		expectedClientAuth tls.ClientAuthType
+		expectedVerify     bool
+		expectedErr        string
+	}{
+		"required":         {cfg: Config{CAFile: caFile}, expectedClientAuth: tls.RequireAndVerifyClientCert},
+		"optional":         {cfg: Config{CAFile: caFile, Optional: true}, expectedClientAuth: tls.VerifyClientCertIfGiven},
+		"revocation":       {cfg: Config{CAFile: caFile, CRLFiles: []string{ca.crlFile(t)}}, expectedClientAuth: tls.RequireAndVerifyClientCert, expectedVerify: true},
+		"ocsp":             {cfg: Config{CAFile: caFile, OCSP: true}, expectedClientAuth: tls.RequireAndVerifyClientCert, expectedVerify: true},
+		"missing CA file":  {cfg: Config{}, expectedErr: "CA file is empty"},
+		"invalid CA file":  {cfg: Config{CAFile: invalid}, expectedErr: "CA file contains no certificates"},
+		"unknown CA file":  {cfg: Config{CAFile: filepath.Join(ca.dir, "unknown.pem")}, expectedErr: "failed to read CA file"},
+		"invalid CRL file": {cfg: Config{CAFile: caFile, CRLFiles: []string{invalid}}, expectedErr: "failed to parse CRL file"},
+		"unknown CRL file": {cfg: Config{CAFile: caFile, CRLFiles: []string{filepath.Join(ca.dir, "unknown.crl")}}, expectedErr: "failed to read CRL file"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			got, err := tt.cfg.TLSConfig()
+			if tt.expectedErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedErr)
+				assert.Nil(t, got)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.expectedClientAuth, got.ClientAuth)
+				assert.NotNil(t, got.ClientCAs)
+				assert.Equal(t, tt.expectedVerify, got.VerifyPeerCertificate != nil)
+			}
+		})
+	}
+}
+
+func TestVerifier_CRL(t *testing.T) {
+	t.Parallel()
+	ca := newAuthority(t)
+	valid := ca.issue(t, 10, "orders", "")
+	revoked := ca.issue(t, 11, "billing", "")
+	cfg, err := Config{CAFile: ca.caFile(t), CRLFiles: []string{ca.crlFile(t, revoked.Leaf)}}.TLSConfig()
+	require.NoError(t, err)
+
+	assert.NoError(t, cfg.VerifyPeerCertificate(nil, [][]*x509.Certificate{{valid.Leaf, ca.cert}}))
+	err = cfg.VerifyPeerCertificate(nil, [][]*x509.Certificate{{revoked.Leaf, ca.cert}})
+	assert.EqualError(t, err, "certificate is revoked: serial number 11")
+}
+
+func TestVerifier_OCSP(t *testing.T) {
+	t.Parallel()
+	ca := newAuthority(t)
+	var requests int32
+	responder := ca.ocspResponder(t, map[int64]int{10: ocsp.Good, 11: ocsp.Revoked}, &requests)
+	t.Cleanup(responder.Close)
+	good := ca.issue(t, 10, "orders", responder.URL)
+	revoked := ca.issue(t, 11, "billing", responder.URL)
+	unknown := ca.issue(t, 12, "search", responder.URL)
+	unavailable := ca.issue(t, 13, "users", "http://127.0.0.1:1")
+	withoutResponder := ca.issue(t, 14, "payments", "")
+
+	tests := map[string]struct {
+		failOpen    bool
+		cert        *x509.Certificate
+		expectedErr string
+	}{
+		"good":                  {cert: good.Leaf},
+		"revoked":               {cert: revoked.Leaf, expectedErr: "certificate is revoked: serial number 11"},
+		"revoked fail open":     {cert: revoked.Leaf, failOpen: true, expectedErr: "certificate is revoked: serial number 11"},
+		"unknown":               {cert: unknown.Leaf, expectedErr: "OCSP status of certificate is unknown"},
+		"unknown fail open":     {cert: unknown.Leaf, failOpen: true},
+		"unavailable":           {cert: unavailable.Leaf, expectedErr: "failed to check OCSP status"},
+		"unavailable fail open": {cert: unavailable.Leaf, failOpen: true},
+		"without responder":     {cert: withoutResponder.Leaf},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			cfg, err := Config{CAFile: ca.caFile(t), OCSP: true, OCSPFailOpen: tt.failOpen}.TLSConfig()
+			require.NoError(t, err)
+			err = cfg.VerifyPeerCertificate(nil, [][]*x509.Certificate{{tt.cert, ca.cert}})
+			if tt.expectedErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestVerifier_OCSP_Cache(t *testing.T) {
+	t.Parallel()
+	ca := newAuthority(t)
+	var requests int32
+	responder := ca.ocspResponder(t, map[int64]int{10: ocsp.Good}, &requests)
+	t.Cleanup(responder.Close)
+	cert := ca.issue(t, 10, "orders", responder.URL)
+	cfg, err := Config{CAFile: ca.caFile(t), OCSP: true}.TLSConfig()
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, cfg.VerifyPeerCertificate(nil, [][]*x509.Certificate{{cert.Leaf, ca.cert}}))
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+}
+
+func TestServer(t *testing.T) {
+	t.Parallel()
+	ca := newAuthority(t)
+	valid := ca.issue(t, 10, "orders", "")
+	revoked := ca.issue(t, 11, "billing", "")
+	other := newAuthority(t).issue(t, 12, "search", "")
+	cfg, err := Config{CAFile: ca.caFile(t), CRLFiles: []string{ca.crlFile(t, revoked.Leaf)}, Optional: true}.TLSConfig()
+	require.NoError(t, err)
+
+	handler := NewMiddleware(Requirement{CommonNames: []string{"orders"}})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, ok := IdentityFromContext(r.Context())
+		assert.True(t, ok)
+		_, _ = w.Write([]byte(id.Subject.CommonName))
+	}))
+	srv := httptest.NewUnstartedServer(Identify(handler))
+	srv.TLS = cfg
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+
+	tests := map[string]struct {
+		cert           *tls.Certificate
+		expectedStatus int
+		expectedErr    bool
+	}{
+		"valid":               {cert: &valid, expectedStatus: http.StatusOK},
+		"without certificate": {expectedStatus: http.StatusUnauthorized},
+		"revoked":             {cert: &revoked, expectedErr: true},
+		"unknown authority":   {cert: &other, expectedErr: true},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			tlsCfg := &tls.Config{RootCAs: x509.NewCertPool(), MinVersion: tls.VersionTLS12}
+			tlsCfg.RootCAs.AddCert(srv.Certificate())
+			if tt.cert != nil {
+				tlsCfg.Certificates = []tls.Certificate{*tt.cert}
+			}
+			client := &http.Client{Transport: &http.Transport{TLSClientConfig: tlsCfg}}
+			rsp, err := client.Get(srv.URL)
+			if tt.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			defer func() {
+				_ = rsp.Body.Close()
+			}()
+			assert.Equal(t, tt.expectedStatus, rsp.StatusCode)
+		})
+	}
+}
@@ -0,0 +1,203 @@
+// Package bulk provides helpers for batch endpoints, which process an array of operations with bounded concurrency
+// and respond with a per item result, allowing partial success.
+package bulk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/beatlabs/patron/encoding"
+	patronjson "github.com/beatlabs/patron/encoding/json"
+	"github.com/beatlabs/patron/log"
+)
+
+const (
+	defaultMaxItems    = 100
+	defaultConcurrency = 10
+)
+
+// OperationFunc processes a single operation of the batch, which is provided raw in order to be decoded by the function.
+// The returned data are rendered in the result of the item.
+type OperationFunc func(ctx context.Context, op json.RawMessage) (interface{}, error)
+
+// ErrorMapperFunc maps an operation error to the HTTP status code of the item.
+type ErrorMapperFunc func(err error) int
+
+// Result of a single operation.
+type Result struct {
+	Index  int         `json:"index"`
+	Status int         `json:"status"`
+	Data   interface{} `json:"data,omitempty"`
+	Error  string      `json:"error,omitempty"`
+}
+
+// Response is the envelope of the per item results.
+type Response struct {
+	Results []Result `json:"results"`
+}
+
+type statusError struct {
+	code int
+	err  error
+}
+
+func (e *statusError) Error() string {
+	return e.err.Error()
+}
+
+func (e *statusError) Unwrap() error {
+	return e.err
+}
+
+// StatusCode returns the HTTP status code of the error.
+func (e *statusError) StatusCode() int {
+	return e.code
+}
+
+// WithStatus annotates an operation error with the HTTP status code of the item.
+func WithStatus(code int, err error) error {
+	return &statusError{code: code, err: err}
+}
+
+// Processor decodes and processes batches of operations.
+type Processor struct {
+	maxItems    int
+	concurrency int
+	mapErr      ErrorMapperFunc
+}
+
+// New creates a processor.
+func New(oo ...OptionFunc) (*Processor, error) {
+	p := &Processor{
+		maxItems:    defaultMaxItems,
+		concurrency: defaultConcurrency,
+		mapErr:      MapError,
+	}
+
+	for _, o := range oo {
+		err := o(p)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return p, nil
+}
+
+// Decode the request body, which should be a JSON array of operations, not exceeding the max items.
+func (p *Processor) Decode(r *http.Request) ([]json.RawMessage, error) {
+	var ops []json.RawMessage
+	if err := patronjson.Decode(r.Body, &ops); err != nil {
+		return nil, fmt.Errorf("failed to decode operations: %w", err)
+	}
+	if len(ops) == 0 {
+		return nil, errors.New("operations are empty")
+	}
+	if len(ops) > p.maxItems {
+		return nil, fmt.Errorf("operations exceed the max items: %d > %d", len(ops), p.maxItems)
+	}
+	return ops, nil
+}
+
+// Process runs the operations with bounded concurrency and returns their results in the order of the operations.
+// Operations which have not started when the context gets canceled fail with the context error.
+func (p *Processor) Process(ctx context.Context, ops []json.RawMessage, fn OperationFunc) []Result {
+	rr := make([]Result, len(ops))
+	sem := make(chan struct{}, p.concurrency)
+	wg := sync.WaitGroup{}
+
+	for i, op := range ops {
+		if !acquire(ctx, sem) {
+			rr[i] = p.result(i, nil, ctx.Err())
+			continue
+		}
+		wg.Add(1)
+		go func(i int, op json.RawMessage) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			data, err := fn(ctx, op)
+			rr[i] = p.result(i, data, err)
+		}(i, op)
+	}
+
+	wg.Wait()
+	return rr
+}
+
+// Handler returns a handler which decodes, processes and responds to a batch request.
+// Requests which cannot be decoded are rejected as a whole with a bad request.
+func (p *Processor) Handler(fn OperationFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ops, err := p.Decode(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		err = Respond(w, p.Process(r.Context(), ops, fn))
+		if err != nil {
+			log.FromContext(r.Context()).Errorf("failed to write bulk response: %v", err)
+		}
+	}
+}
+
+// Respond writes the results in the standard envelope. The response status is OK if all operations succeeded,
+// and Multi-Status otherwise, with the status of every item available in its result.
+func Respond(w http.ResponseWriter, rr []Result) error {
+	body, err := patronjson.Encode(Response{Results: rr})
+	if err != nil {
+		return fmt.Errorf("failed to encode results: %w", err)
+	}
+
+	status := http.StatusOK
+	for _, r := range rr {
+		if r.Status >= http.StatusBadRequest {
+			status = http.StatusMultiStatus
+			break
+		}
+	}
+
+	w.Header().Set(encoding.ContentTypeHeader, patronjson.TypeCharset)
+	w.WriteHeader(status)
+	_, err = w.Write(body)
+	return err
+}
+
+// MapError is the default error mapper. Errors annotated with a status code, like the ones created with WithStatus,
+// keep their code, context errors map to gateway timeout and everything else to internal server error.
+func MapError(err error) int {
+	var sc interface{ StatusCode() int }
+	switch {
+	case errors.As(err, &sc):
+		return sc.StatusCode()
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, context.Canceled):
+		return http.StatusGatewayTimeout
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func (p *Processor) result(idx int, data interface{}, err error) Result {
+	if err != nil {
+		return Result{Index: idx, Status: p.mapErr(err), Error: err.Error()}
+	}
+	return Result{Index: idx, Status: http.StatusOK, Data: data}
+}
+
+// acquire waits for a concurrency slot, unless the context is done.
+func acquire(ctx context.Context, sem chan struct{}) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	select {
+	case <-ctx.Done():
+		return false
+	case sem <- struct{}{}:
+		return true
+	}
+}
@@ -0,0 +1,197 @@
+package bulk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		oo          []OptionFunc
+		expectedErr string
+	}{
+		"success":             {oo: []OptionFunc{MaxItems(10), Concurrency(2), ErrorMapper(MapError)}},
+		"invalid max items":   {oo: []OptionFunc{MaxItems(0)}, expectedErr: "max items should be positive"},
+		"invalid concurrency": {oo: []OptionFunc{Concurrency(0)}, expectedErr: "concurrency should be positive"},
+		"nil error mapper":    {oo: []OptionFunc{ErrorMapper(nil)}, expectedErr: "error mapper is nil"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			got, err := New(tt.oo...)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, got)
+			}
+		})
+	}
+}
+
+func TestProcessor_Decode(t *testing.T) {
+	t.Parallel()
+	p, err := New(MaxItems(2))
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		body        string
+		expected    int
+		expectedErr string
+	}{
+		"success":      {body: `[{"id":1},{"id":2}]`, expected: 2},
+		"not an array": {body: `{"id":1}`, expectedErr: "failed to decode operations"},
+		"empty":        {body: `[]`, expectedErr: "operations are empty"},
+		"too many":     {body: `[1,2,3]`, expectedErr: "operations exceed the max items: 3 > 2"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			got, err := p.Decode(req)
+			if tt.expectedErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedErr)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.Len(t, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestProcessor_Process(t *testing.T) {
+	t.Parallel()
+	p, err := New(Concurrency(2))
+	require.NoError(t, err)
+
+	var inFlight, maxInFlight int32
+	fn := func(ctx context.Context, op json.RawMessage) (interface{}, error) {
+		cur := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			max := atomic.LoadInt32(&maxInFlight)
+			if cur <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, cur) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+
+		var id int
+		if err := json.Unmarshal(op, &id); err != nil {
+			return nil, WithStatus(http.StatusBadRequest, err)
+		}
+		if id < 0 {
+			return nil, errors.New("negative id")
+		}
+		return id * 10, nil
+	}
+
+	ops := []json.RawMessage{json.RawMessage(`1`), json.RawMessage(`"a"`), json.RawMessage(`-1`), json.RawMessage(`2`)}
+	rr := p.Process(context.Background(), ops, fn)
+
+	require.Len(t, rr, 4)
+	assert.Equal(t, Result{Index: 0, Status: http.StatusOK, Data: 10}, rr[0])
+	assert.Equal(t, 1, rr[1].Index)
+	assert.Equal(t, http.StatusBadRequest, rr[1].Status)
+	assert.Equal(t, Result{Index: 2, Status: http.StatusInternalServerError, Error: "negative id"}, rr[2])
+	assert.Equal(t, Result{Index: 3, Status: http.StatusOK, Data: 20}, rr[3])
+	assert.LessOrEqual(t, atomic.LoadInt32(&maxInFlight), int32(2))
+}
+
+func TestProcessor_Process_Canceled(t *testing.T) {
+	t.Parallel()
+	p, err := New()
+	require.NoError(t, err)
+	ctx, cnl := context.WithCancel(context.Background())
+	cnl()
+
+	rr := p.Process(ctx, []json.RawMessage{json.RawMessage(`1`)}, func(ctx context.Context, op json.RawMessage) (interface{}, error) {
+		return nil, nil
+	})
+
+	require.Len(t, rr, 1)
+	assert.Equal(t, Result{Index: 0, Status: http.StatusGatewayTimeout, Error: context.Canceled.Error()}, rr[0])
+}
+
+func TestProcessor_Handler(t *testing.T) {
+	t.Parallel()
+	p, err := New()
+	require.NoError(t, err)
+	h := p.Handler(func(ctx context.Context, op json.RawMessage) (interface{}, error) {
+		if string(op) == `"fail"` {
+			return nil, WithStatus(http.StatusConflict, errors.New("conflict"))
+		}
+		return string(op), nil
+	})
+
+	tests := map[string]struct {
+		body           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		"all succeeded": {
+			body:           `["a"]`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"results":[{"index":0,"status":200,"data":"\"a\""}]}`,
+		},
+		"partial success": {
+			body:           `["a","fail"]`,
+			expectedStatus: http.StatusMultiStatus,
+			expectedBody:   `{"results":[{"index":0,"status":200,"data":"\"a\""},{"index":1,"status":409,"error":"conflict"}]}`,
+		},
+		"invalid request": {
+			body:           `{}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			rsp := httptest.NewRecorder()
+			h(rsp, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body)))
+			assert.Equal(t, tt.expectedStatus, rsp.Code)
+			if tt.expectedBody != "" {
+				assert.JSONEq(t, tt.expectedBody, rsp.Body.String())
+			}
+		})
+	}
+}
+
+func TestMapError(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		err      error
+		expected int
+	}{
+		"with status":       {err: WithStatus(http.StatusNotFound, errors.New("not found")), expected: http.StatusNotFound},
+		"wrapped status":    {err: fmt.Errorf("failed: %w", WithStatus(http.StatusNotFound, errors.New("not found"))), expected: http.StatusNotFound},
+		"deadline exceeded": {err: context.DeadlineExceeded, expected: http.StatusGatewayTimeout},
+		"canceled":          {err: context.Canceled, expected: http.StatusGatewayTimeout},
+		"other":             {err: errors.New("error"), expected: http.StatusInternalServerError},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.expected, MapError(tt.err))
+		})
+	}
+}
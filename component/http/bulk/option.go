@@ -0,0 +1,39 @@
+package bulk
+
+import "errors"
+
+// OptionFunc definition for configuring the processor in a functional way.
+type OptionFunc func(*Processor) error
+
+// MaxItems option for setting the maximum number of operations of a batch.
+func MaxItems(max int) OptionFunc {
+	return func(p *Processor) error {
+		if max <= 0 {
+			return errors.New("max items should be positive")
+		}
+		p.maxItems = max
+		return nil
+	}
+}
+
+// Concurrency option for setting the maximum number of operations processed concurrently.
+func Concurrency(concurrency int) OptionFunc {
+	return func(p *Processor) error {
+		if concurrency <= 0 {
+			return errors.New("concurrency should be positive")
+		}
+		p.concurrency = concurrency
+		return nil
+	}
+}
+
+// ErrorMapper option for setting the function which maps operation errors to item status codes.
+func ErrorMapper(fn ErrorMapperFunc) OptionFunc {
+	return func(p *Processor) error {
+		if fn == nil {
+			return errors.New("error mapper is nil")
+		}
+		p.mapErr = fn
+		return nil
+	}
+}
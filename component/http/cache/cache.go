@@ -9,7 +9,6 @@ import (
 	"strings"
 	"time"
 
-	"github.com/beatlabs/patron/cache"
 	"github.com/beatlabs/patron/log"
 )
 
@@ -38,6 +37,18 @@ const (
 	headerCacheMaxAge    = "max-age"
 	headerMustRevalidate = "must-revalidate"
 	headerWarning        = "Warning"
+	headerVary           = "Vary"
+
+	controlStaleWhileRevalidate = "stale-while-revalidate"
+	controlStaleIfError         = "stale-if-error"
+	controlPublic               = "public"
+	controlPrivate              = "private"
+	controlSharedMaxAge         = "s-maxage"
+	controlNoTransform          = "no-transform"
+
+	warningLastValid            = "last-valid"
+	warningStaleWhileRevalidate = "stale-while-revalidate"
+	warningRefreshing           = "refreshing"
 )
 
 var monitor metrics
@@ -51,6 +62,11 @@ var NowSeconds = func() int64 {
 	return time.Now().Unix()
 }
 
+// runBackground runs the background revalidation of stale entries.
+var runBackground = func(fn func()) {
+	go fn()
+}
+
 // validator is a conditional function on an objects age and the configured ttl.
 type validator func(age, ttl int64) (bool, validationContext)
 
@@ -73,8 +89,9 @@ type executor func(now int64, key string) *response
 
 // handler wraps an execution logic with a cache layer
 // exec is the processor func that the cache will wrap
+// revalidate is the processor func used for refreshing stale entries in the background
 // rc is the route cache implementation to be used.
-func handler(exec executor, rc *RouteCache) func(request *handlerRequest) (response *handlerResponse, e error) {
+func handler(exec, revalidate executor, rc *RouteCache) func(request *handlerRequest) (response *handlerResponse, e error) {
 	return func(request *handlerRequest) (handlerResponse *handlerResponse, e error) {
 		now := NowSeconds()
 
@@ -84,6 +101,7 @@ func handler(exec executor, rc *RouteCache) func(request *handlerRequest) (respo
 
 		if hasNoAgeConfig(rc.age.min, rc.age.max) {
 			rsp = exec(now, key)
+			rsp.Response.StatusCode = rsp.StatusCode
 			return &rsp.Response, rsp.Err
 		}
 
@@ -92,23 +110,30 @@ func handler(exec executor, rc *RouteCache) func(request *handlerRequest) (respo
 			cfg.expiryValidator = expiryCheck
 		}
 
-		rsp = getResponse(cfg, request.path, key, now, rc, exec)
+		rsp = getResponse(cfg, request.path, key, now, rc, exec, revalidate)
 		e = rsp.Err
 
 		if e == nil {
 			handlerResponse = &rsp.Response
-			addResponseHeaders(now, handlerResponse.Header, rsp, rc.age.max)
-			if !rsp.FromCache && !cfg.noCache {
-				save(request.path, key, rsp, rc.cache, time.Duration(rc.age.max)*time.Second)
+			handlerResponse.StatusCode = rsp.StatusCode
+			addResponseHeaders(now, handlerResponse.Header, rsp, rc.ageOf(rsp), rc.directives)
+			if len(rc.vary) > 0 {
+				handlerResponse.Header.Set(headerVary, strings.Join(rc.vary, ", "))
+			}
+			if !rsp.FromCache && !rsp.shared && !cfg.noCache && rc.cacheable(rsp) {
+				save(request.path, key, rsp, rc, rc.ageOf(rsp).ttl())
 			}
 		}
+		if rsp.locked {
+			unlock(request.path, key, rc)
+		}
 
 		return
 	}
 }
 
 // getResponse will get the appropriate Response either using the cache or the executor.
-func getResponse(cfg *control, path, key string, now int64, rc *RouteCache, exec executor) *response {
+func getResponse(cfg *control, path, key string, now int64, rc *RouteCache, exec, revalidate executor) *response {
 	if cfg.noCache {
 		return exec(now, key)
 	}
@@ -116,35 +141,111 @@ func getResponse(cfg *control, path, key string, now int64, rc *RouteCache, exec
 	rsp := get(key, rc)
 	if rsp == nil {
 		monitor.miss(path)
-		response := exec(now, key)
-		return response
+		return rc.flights.coalesce(now, key, exec)
 	}
 	if rsp.Err != nil {
 		log.Errorf("error during cache interaction: %v", rsp.Err)
-		monitor.err(path)
-		return exec(now, key)
+		monitor.err(path, errGet)
+		return rc.flights.coalesce(now, key, exec)
 	}
+	a := rc.ageOf(rsp)
 	// if the object has expired
-	if isValid, cx := isValid(now-rsp.LastValid, rc.age.max, append(cfg.validators, cfg.expiryValidator)...); !isValid {
-		tmpRsp := exec(now, key)
+	if isValid, cx := isValid(now-rsp.LastValid, a.max, append(cfg.validators, cfg.expiryValidator)...); !isValid {
+		staleness := now - rsp.LastValid - a.max
+		// if only the ttl has expired and the entry is within the stale-while-revalidate window,
+		// serve it and refresh it in the background
+		if cx == ttlValidation && a.staleWhileRevalidate > 0 && staleness <= a.staleWhileRevalidate {
+			rsp.Warning = warningStaleWhileRevalidate
+			monitor.hit(path, now-rsp.LastValid)
+			monitor.stale(path, warningStaleWhileRevalidate)
+			revalidateInBackground(path, key, rc, revalidate)
+			return rsp
+		}
+		// if another instance is refreshing the expired entry, serve it until the refreshed one is cached
+		locked := false
+		if cx == ttlValidation && rc.locker != nil {
+			if locked = tryLock(path, key, rc); !locked {
+				rsp.Warning = warningRefreshing
+				monitor.hit(path, now-rsp.LastValid)
+				monitor.stale(path, warningRefreshing)
+				return rsp
+			}
+		}
+		tmpRsp := rc.flights.coalesce(now, key, exec)
 		// if we could not retrieve a fresh Response,
 		// serve the last cached value, with a Warning Header
-		if cfg.forceCache || tmpRsp.Err != nil {
-			rsp.Warning = "last-valid"
-			monitor.hit(path)
+		if cfg.forceCache || serveStaleOnError(tmpRsp, staleness, a.staleIfError) {
+			rsp.Warning = warningLastValid
+			monitor.hit(path, now-rsp.LastValid)
+			monitor.stale(path, warningLastValid)
 		} else {
 			rsp = tmpRsp
 			monitor.evict(path, cx, now-rsp.LastValid)
 		}
+		rsp.locked = locked
 	} else {
 		// add any Warning generated while parsing the headers
 		rsp.Warning = cfg.warning
-		monitor.hit(path)
+		monitor.hit(path, now-rsp.LastValid)
 	}
 
 	return rsp
 }
 
+// serveStaleOnError returns true if the cached entry should be served instead of the failed fresh response.
+// Entries which have not exceeded the max age are served if the response could not be produced at all,
+// while stale entries are served within the stale-if-error window, also in case of server errors.
+func serveStaleOnError(rsp *response, staleness, staleIfError int64) bool {
+	if rsp.Err != nil && staleness <= 0 {
+		return true
+	}
+	return staleIfError > 0 && rsp.failed() && staleness <= staleIfError
+}
+
+// revalidateInBackground refreshes the cached entry, unless a revalidation for the key is already in progress.
+func revalidateInBackground(path, key string, rc *RouteCache, exec executor) {
+	if _, inProgress := rc.revalidating.LoadOrStore(key, struct{}{}); inProgress {
+		return
+	}
+	runBackground(func() {
+		defer rc.revalidating.Delete(key)
+		if rc.locker != nil {
+			if !tryLock(path, key, rc) {
+				return
+			}
+			defer unlock(path, key, rc)
+		}
+		rsp := exec(NowSeconds(), key)
+		if rsp.failed() {
+			log.Warnf("could not revalidate stale response for request key %s: %v", key, rsp.Err)
+			monitor.err(path, errRevalidate)
+			return
+		}
+		monitor.revalidate(path)
+		save(path, key, rsp, rc, rc.age.ttl())
+	})
+}
+
+// tryLock acquires the refresh lock of the key cluster-wide. Failing to communicate with the locker is not fatal,
+// so the entry is refreshed anyway, as if there was no lock.
+func tryLock(path, key string, rc *RouteCache) bool {
+	ok, err := rc.locker.TryLock(key, rc.lockTTL)
+	if err != nil {
+		log.Errorf("could not acquire refresh lock for request key %s: %v", key, err)
+		monitor.err(path, errLock)
+		return true
+	}
+	return ok
+}
+
+// unlock releases the refresh lock of the key, so that it can be refreshed again without waiting for the lock to expire.
+func unlock(path, key string, rc *RouteCache) {
+	if err := rc.locker.Unlock(key); err != nil {
+		log.Errorf("could not release refresh lock for request key %s: %v", key, err)
+		monitor.err(path, errLock)
+	}
+}
+
 func isValid(age, maxAge int64, validators ...validator) (bool, validationContext) {
 	if len(validators) == 0 {
 		return false, 0
@@ -192,18 +293,36 @@ func get(key string, rc *RouteCache) *response {
 
 // save caches the given Response if required with a ttl
 // as we are putting the objects in the cache, if it's a TTL one, we need to manage the expiration on our own.
-func save(path, key string, rsp *response, cache cache.TTLCache, maxAge time.Duration) {
+func save(path, key string, rsp *response, rc *RouteCache, maxAge time.Duration) {
 	if !rsp.FromCache && rsp.Err == nil {
+		if rc.maxBodySize > 0 && len(rsp.Response.Bytes) > rc.maxBodySize {
+			monitor.skip(path, skipTooLarge)
+			return
+		}
+		// expired entries are kept for the duration of the refresh lock, so that they can be served while another instance refreshes them
+		if rc.locker != nil {
+			maxAge += rc.lockTTL
+		}
 		// encode to a byte array on our side to avoid cache specific encoding / marshaling requirements
 		bytes, err := rsp.encode()
 		if err != nil {
 			log.Errorf("could not encode response for request key %s: %v", key, err)
-			monitor.err(path)
+			monitor.err(path, errSet)
 			return
 		}
-		if err := cache.SetTTL(key, bytes, maxAge); err != nil {
+		if rc.compressor != nil {
+			uncompressed := len(bytes)
+			bytes, err = rc.compressor.compress(bytes)
+			if err != nil {
+				log.Errorf("could not compress response for request key %s: %v", key, err)
+				monitor.err(path, errSet)
+				return
+			}
+			monitor.compress(path, rc.compressor.encoding, uncompressed, len(bytes))
+		}
+		if err := rc.cache.SetTTL(key, bytes, maxAge); err != nil {
 			log.Errorf("could not cache response for request key %s: %v", key, err)
-			monitor.err(path)
+			monitor.err(path, errSet)
 			return
 		}
 		monitor.add(path)
@@ -211,9 +330,11 @@ func save(path, key string, rsp *response, cache cache.TTLCache, maxAge time.Dur
 }
 
 // addResponseHeaders adds the appropriate headers according to the response conditions.
-func addResponseHeaders(now int64, header http.Header, rsp *response, maxAge int64) {
+func addResponseHeaders(now int64, header http.Header, rsp *response, a age, d directives) {
 	header.Set(HeaderETagHeader, rsp.Etag)
-	header.Set(HeaderCacheControl, createCacheControlHeader(maxAge, now-rsp.LastValid))
+	header.Set(HeaderLastModified, lastModified(rsp.LastValid))
+	cacheControl := createCacheControlHeader(a.max, now-rsp.LastValid)
+	header.Set(HeaderCacheControl, cacheControl+staleDirectives(a)+d.header(now-rsp.LastValid, rsp, cacheControl == headerMustRevalidate))
 	if rsp.Warning != "" && rsp.FromCache {
 		header.Set(headerWarning, rsp.Warning)
 	} else {
@@ -322,6 +443,49 @@ func createCacheControlHeader(ttl, lastValid int64) string {
 	return fmt.Sprintf("%s=%d", headerCacheMaxAge, ttl-lastValid)
 }
 
+// staleDirectives returns the stale cache control extensions of RFC 5861, which apply to the configured age.
+func staleDirectives(a age) string {
+	var directives string
+	if a.staleWhileRevalidate > 0 {
+		directives += fmt.Sprintf(", %s=%d", controlStaleWhileRevalidate, a.staleWhileRevalidate)
+	}
+	if a.staleIfError > 0 {
+		directives += fmt.Sprintf(", %s=%d", controlStaleIfError, a.staleIfError)
+	}
+	return directives
+}
+
+// directives are the additional directives of the Cache-Control response header, configured by the route cache options.
+type directives struct {
+	visibility     string
+	sharedMaxAge   int64
+	noTransform    bool
+	mustRevalidate bool
+}
+
+// header returns the directives for a response of the provided age.
+// The s-maxage directive is omitted for error responses, which should not be kept longer by shared caches.
+func (d directives) header(age int64, rsp *response, revalidating bool) string {
+	var header string
+	if d.visibility != "" {
+		header += ", " + d.visibility
+	}
+	if d.sharedMaxAge > 0 && !rsp.isError() {
+		sMaxAge := d.sharedMaxAge - age
+		if sMaxAge < 0 {
+			sMaxAge = 0
+		}
+		header += fmt.Sprintf(", %s=%d", controlSharedMaxAge, sMaxAge)
+	}
+	if d.noTransform {
+		header += ", " + controlNoTransform
+	}
+	if d.mustRevalidate && !revalidating {
+		header += ", " + headerMustRevalidate
+	}
+	return header
+}
+
 func min(value, threshold int64) (int64, bool) {
 	if value < threshold {
 		return threshold, true
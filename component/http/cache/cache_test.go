@@ -16,7 +16,12 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
+// prometheusMonitor is the metrics implementation set up by the package, before tests replace it.
+var prometheusMonitor *prometheusMetrics
+
 func TestMain(m *testing.M) {
+	prometheusMonitor = monitor.(*prometheusMetrics)
+
 	err := log.Setup(std.New(os.Stderr, log.DebugLevel, make(map[string]interface{})))
 	if err != nil {
 		os.Exit(1)
@@ -1594,6 +1599,238 @@ func TestCache_WithForceCacheHeaders(t *testing.T) {
 	assertCache(t, args)
 }
 
+func TestCache_WithStaleWhileRevalidate(t *testing.T) {
+	runBackground = func(fn func()) { fn() }
+	defer func() { runBackground = func(fn func()) { go fn() } }()
+
+	rc := routeConfig{
+		path: "/",
+		age:  Age{Max: 10 * time.Second, StaleWhileRevalidate: 5 * time.Second},
+	}
+
+	args := [][]testArgs{
+		{
+			// initial request
+			{
+				requestParams: newRequestAt(0),
+				routeConfig:   rc,
+				response:      &responseStruct{Payload: 0, Header: map[string]string{HeaderCacheControl: "max-age=10, stale-while-revalidate=5"}},
+				metrics: testMetrics{
+					map[string]*metricState{
+						"/": {
+							additions: 1,
+							misses:    1,
+						},
+					},
+				},
+			},
+			// expired, but within the stale-while-revalidate window, so the stale entry is served and revalidated
+			{
+				requestParams: newRequestAt(12),
+				routeConfig:   rc,
+				response: &responseStruct{Payload: 0, Header: map[string]string{
+					HeaderCacheControl: "must-revalidate, stale-while-revalidate=5",
+					headerWarning:      warningStaleWhileRevalidate,
+				}},
+				metrics: testMetrics{
+					map[string]*metricState{
+						"/": {
+							additions:     2,
+							misses:        1,
+							hits:          1,
+							stales:        1,
+							revalidations: 1,
+						},
+					},
+				},
+			},
+			// the revalidated entry is served
+			{
+				requestParams: newRequestAt(13),
+				routeConfig:   rc,
+				response:      &responseStruct{Payload: 120, Header: map[string]string{HeaderCacheControl: "max-age=9, stale-while-revalidate=5"}},
+				metrics: testMetrics{
+					map[string]*metricState{
+						"/": {
+							additions:     2,
+							misses:        1,
+							hits:          2,
+							stales:        1,
+							revalidations: 1,
+						},
+					},
+				},
+			},
+			// beyond the stale-while-revalidate window, the entry has been evicted
+			{
+				requestParams: newRequestAt(30),
+				routeConfig:   rc,
+				response:      &responseStruct{Payload: 300, Header: map[string]string{HeaderCacheControl: "max-age=10, stale-while-revalidate=5"}},
+				metrics: testMetrics{
+					map[string]*metricState{
+						"/": {
+							additions:     3,
+							misses:        2,
+							hits:          2,
+							stales:        1,
+							revalidations: 1,
+						},
+					},
+				},
+			},
+		},
+	}
+	assertCache(t, args)
+}
+
+func TestCache_WithStaleIfError(t *testing.T) {
+	hndErr := errors.New("error encountered on handler")
+
+	rc := routeConfig{
+		path: "/",
+		age:  Age{Max: 10 * time.Second, StaleIfError: 5 * time.Second},
+	}
+	failing := routeConfig{
+		path: rc.path,
+		hnd: func(now int64, key string) *response {
+			return &response{Err: hndErr}
+		},
+		age: rc.age,
+	}
+	serverError := routeConfig{
+		path: rc.path,
+		hnd: func(now int64, key string) *response {
+			return &response{
+				Response:   handlerResponse{Bytes: []byte("500"), Header: make(http.Header)},
+				LastValid:  now,
+				StatusCode: http.StatusInternalServerError,
+			}
+		},
+		age: rc.age,
+	}
+
+	args := [][]testArgs{
+		{
+			// initial request
+			{
+				requestParams: newRequestAt(0),
+				routeConfig:   rc,
+				response:      &responseStruct{Payload: 0, Header: map[string]string{HeaderCacheControl: "max-age=10, stale-if-error=5"}},
+				metrics: testMetrics{
+					map[string]*metricState{
+						"/": {
+							additions: 1,
+							misses:    1,
+						},
+					},
+				},
+			},
+			// the handler fails within the stale-if-error window, so the stale entry is served
+			{
+				requestParams: newRequestAt(12),
+				routeConfig:   failing,
+				response: &responseStruct{Payload: 0, Header: map[string]string{
+					HeaderCacheControl: "must-revalidate, stale-if-error=5",
+					headerWarning:      warningLastValid,
+				}},
+				metrics: testMetrics{
+					map[string]*metricState{
+						"/": {
+							additions: 1,
+							misses:    1,
+							hits:      1,
+							stales:    1,
+						},
+					},
+				},
+			},
+			// the handler responds with a server error within the stale-if-error window, so the stale entry is served
+			{
+				requestParams: newRequestAt(14),
+				routeConfig:   serverError,
+				response: &responseStruct{Payload: 0, Header: map[string]string{
+					HeaderCacheControl: "must-revalidate, stale-if-error=5",
+					headerWarning:      warningLastValid,
+				}},
+				metrics: testMetrics{
+					map[string]*metricState{
+						"/": {
+							additions: 1,
+							misses:    1,
+							hits:      2,
+							stales:    2,
+						},
+					},
+				},
+			},
+			// beyond the stale-if-error window, the entry has been evicted and the error is returned
+			{
+				requestParams: newRequestAt(20),
+				routeConfig:   failing,
+				metrics: testMetrics{
+					map[string]*metricState{
+						"/": {
+							additions: 1,
+							misses:    2,
+							hits:      2,
+							stales:    2,
+						},
+					},
+				},
+				err: hndErr,
+			},
+			// server errors are not cached, in order not to replace the entry which would be served stale
+			{
+				requestParams: newRequestAt(21),
+				routeConfig:   serverError,
+				response:      &responseStruct{Payload: 500, Header: map[string]string{HeaderCacheControl: "max-age=10, stale-if-error=5"}},
+				metrics: testMetrics{
+					map[string]*metricState{
+						"/": {
+							additions: 1,
+							misses:    3,
+							hits:      2,
+							stales:    2,
+						},
+					},
+				},
+			},
+		},
+	}
+	assertCache(t, args)
+}
+
+func TestRevalidateInBackground(t *testing.T) {
+	var scheduled []func()
+	runBackground = func(fn func()) { scheduled = append(scheduled, fn) }
+	defer func() { runBackground = func(fn func()) { go fn() } }()
+	monitor = &testMetrics{}
+
+	ch := newTestingCache()
+	ch.instant = func() int64 { return 0 }
+	rc, errs := NewRouteCache(ch, Age{Max: 10 * time.Second, StaleWhileRevalidate: 5 * time.Second})
+	assert.Empty(t, errs)
+
+	executions := 0
+	exec := func(now int64, key string) *response {
+		executions++
+		return &response{Response: handlerResponse{Bytes: []byte("1")}, LastValid: now}
+	}
+
+	revalidateInBackground("/", "key", rc, exec)
+	revalidateInBackground("/", "key", rc, exec)
+	assert.Len(t, scheduled, 1)
+
+	scheduled[0]()
+	assert.Equal(t, 1, executions)
+	assert.Equal(t, 1, ch.setCount)
+	assert.Equal(t, int64(15), ch.cache["key"].ttl)
+
+	// a new revalidation is allowed after the previous one finished
+	revalidateInBackground("/", "key", rc, exec)
+	assert.Len(t, scheduled, 2)
+}
+
 func assertCache(t *testing.T, args [][]testArgs) {
 	monitor = &testMetrics{}
 
@@ -1659,7 +1896,7 @@ func assertCache(t *testing.T, args [][]testArgs) {
 			routeCache, errs := NewRouteCache(ch, arg.routeConfig.age)
 			assert.Empty(t, errs)
 
-			response, err := handler(hnd, routeCache)(request)
+			response, err := handler(hnd, hnd, routeCache)(request)
 
 			if arg.err != nil {
 				assert.Error(t, err)
@@ -1782,11 +2019,15 @@ type testMetrics struct {
 }
 
 type metricState struct {
-	additions int
-	misses    int
-	evictions int
-	hits      int
-	errors    int
+	additions     int
+	misses        int
+	evictions     int
+	hits          int
+	errors        int
+	skips         int
+	stales        int
+	revalidations int
+	compressions  int
 }
 
 func (m *testMetrics) init(path string) {
@@ -1808,17 +2049,37 @@ func (m *testMetrics) miss(path string) {
 	m.values[path].misses++
 }
 
-func (m *testMetrics) hit(path string) {
+func (m *testMetrics) hit(path string, _ int64) {
 	m.init(path)
 	m.values[path].hits++
 }
 
-func (m *testMetrics) err(path string) {
+func (m *testMetrics) err(path, _ string) {
 	m.init(path)
 	m.values[path].errors++
 }
 
+func (m *testMetrics) skip(path, _ string) {
+	m.init(path)
+	m.values[path].skips++
+}
+
+func (m *testMetrics) stale(path, _ string) {
+	m.init(path)
+	m.values[path].stales++
+}
+
+func (m *testMetrics) revalidate(path string) {
+	m.init(path)
+	m.values[path].revalidations++
+}
+
 func (m *testMetrics) evict(path string, _ validationContext, _ int64) {
 	m.init(path)
 	m.values[path].evictions++
 }
+
+func (m *testMetrics) compress(path, _ string, _, _ int) {
+	m.init(path)
+	m.values[path].compressions++
+}
@@ -0,0 +1,56 @@
+package cache
+
+import (
+	"errors"
+	"sync"
+)
+
+var errFlightPanicked = errors.New("coalesced handler execution panicked")
+
+// flight is an in-flight execution of the handler, whose response is shared by the concurrent requests of the same key.
+type flight struct {
+	wg  sync.WaitGroup
+	rsp *response
+}
+
+// flights keeps track of the in-flight executions of the handler per key.
+type flights struct {
+	sync.Mutex
+	m map[string]*flight
+}
+
+// coalesce executes the handler once per key at a time, so that concurrent requests missing the same key
+// wait for the response of the first one, instead of hitting the backend themselves.
+// Waiting requests receive a copy of the response, which is marked as shared, since it is cached by the first request.
+func (f *flights) coalesce(now int64, key string, exec executor) *response {
+	f.Lock()
+	if fl, ok := f.m[key]; ok {
+		f.Unlock()
+		fl.wg.Wait()
+		rsp := fl.rsp.clone()
+		rsp.shared = true
+		return rsp
+	}
+	if f.m == nil {
+		f.m = make(map[string]*flight)
+	}
+	fl := &flight{}
+	fl.wg.Add(1)
+	f.m[key] = fl
+	f.Unlock()
+
+	defer func() {
+		if fl.rsp == nil {
+			fl.rsp = &response{Err: errFlightPanicked}
+		}
+		f.Lock()
+		delete(f.m, key)
+		f.Unlock()
+		fl.wg.Done()
+	}()
+
+	rsp := exec(now, key)
+	// the response of the first request is modified afterwards, so the waiting requests copy a snapshot of it
+	fl.rsp = rsp.clone()
+	return rsp
+}
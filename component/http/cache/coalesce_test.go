@@ -0,0 +1,141 @@
+package cache
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFlights_Coalesce(t *testing.T) {
+	var f flights
+	var calls int32
+	release := make(chan struct{})
+	exec := func(now int64, key string) *response {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return &response{Response: handlerResponse{Bytes: []byte(key), Header: http.Header{"A": []string{"b"}}}, LastValid: now}
+	}
+
+	const requests = 10
+	responses := make([]*response, requests)
+	var wg sync.WaitGroup
+	wg.Add(requests)
+	for i := 0; i < requests; i++ {
+		go func(i int) {
+			defer wg.Done()
+			responses[i] = f.coalesce(1, "key", exec)
+		}(i)
+	}
+	// wait for all requests to join the flight
+	require.Eventually(t, func() bool {
+		return atomic.LoadInt32(&calls) == 1
+	}, time.Second, time.Millisecond)
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	shared := 0
+	for _, rsp := range responses {
+		assert.Equal(t, "key", string(rsp.Response.Bytes))
+		if rsp.shared {
+			shared++
+		}
+	}
+	assert.Equal(t, requests-1, shared)
+	assert.Empty(t, f.m)
+
+	// the responses can be modified independently
+	responses[0].Response.Header.Set("A", "c")
+	assert.Equal(t, "b", responses[1].Response.Header.Get("A"))
+
+	// subsequent requests execute the handler again
+	f.coalesce(2, "key", exec)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestFlights_CoalescePanic(t *testing.T) {
+	var f flights
+	assert.Panics(t, func() {
+		f.coalesce(1, "key", func(int64, string) *response {
+			panic("handler")
+		})
+	})
+	assert.Empty(t, f.m)
+}
+
+func TestHandler_CoalesceMisses(t *testing.T) {
+	monitor = nopMetrics{}
+	NowSeconds = func() int64 { return 1 }
+	c := &lockedTestingCache{testingCache: newTestingCache()}
+	c.instant = NowSeconds
+	rc, errs := NewRouteCache(c, Age{Max: 10 * time.Second})
+	require.Empty(t, errs)
+
+	var calls int32
+	release := make(chan struct{})
+	hnd := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		_, _ = w.Write([]byte("body"))
+	})
+
+	const requests = 5
+	var wg sync.WaitGroup
+	wg.Add(requests)
+	for i := 0; i < requests; i++ {
+		go func() {
+			defer wg.Done()
+			rsp := httptest.NewRecorder()
+			assert.NoError(t, Handler(rsp, httptest.NewRequest(http.MethodGet, "/", nil), rc, hnd))
+			assert.Equal(t, "body", rsp.Body.String())
+			assert.NotEmpty(t, rsp.Header().Get(HeaderETagHeader))
+		}()
+	}
+	require.Eventually(t, func() bool {
+		return atomic.LoadInt32(&calls) == 1
+	}, time.Second, time.Millisecond)
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	assert.Equal(t, 1, c.setCount)
+}
+
+// lockedTestingCache allows using the testing cache from concurrent requests.
+type lockedTestingCache struct {
+	sync.Mutex
+	*testingCache
+}
+
+func (l *lockedTestingCache) Get(key string) (interface{}, bool, error) {
+	l.Lock()
+	defer l.Unlock()
+	return l.testingCache.Get(key)
+}
+
+func (l *lockedTestingCache) SetTTL(key string, value interface{}, ttl time.Duration) error {
+	l.Lock()
+	defer l.Unlock()
+	return l.testingCache.SetTTL(key, value, ttl)
+}
+
+// nopMetrics allows using the cache from concurrent requests, without the testing metrics.
+type nopMetrics struct{}
+
+func (nopMetrics) add(string)                             {}
+func (nopMetrics) miss(string)                            {}
+func (nopMetrics) hit(string, int64)                      {}
+func (nopMetrics) stale(string, string)                   {}
+func (nopMetrics) err(string, string)                     {}
+func (nopMetrics) skip(string, string)                    {}
+func (nopMetrics) revalidate(string)                      {}
+func (nopMetrics) evict(string, validationContext, int64) {}
+func (nopMetrics) compress(string, string, int, int)      {}
@@ -0,0 +1,125 @@
+package cache
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	gzipEncoding   = "gzip"
+	zstdEncoding   = "zstd"
+	brotliEncoding = "br"
+)
+
+var (
+	// gzipMagic is the header of gzip data, which cannot be mistaken for the JSON encoded responses.
+	gzipMagic = []byte{0x1f, 0x8b}
+	// zstdMagic is the header of zstd frames, which cannot be mistaken for the JSON encoded responses either.
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+	// brotliMagic prefixes the brotli data, which lacks a header of its own. It starts with a byte, which is invalid in UTF-8.
+	brotliMagic = []byte{0xff, 'b', 'r'}
+)
+
+// compressor compresses the stored responses with the encoding at the level.
+type compressor struct {
+	encoding string
+	level    int
+}
+
+func (c compressor) compress(data []byte) ([]byte, error) {
+	switch c.encoding {
+	case zstdEncoding:
+		return compressZstd(data, c.level)
+	case brotliEncoding:
+		return compressBrotli(data, c.level)
+	default:
+		return compress(data, c.level)
+	}
+}
+
+func compress(data []byte, level int) ([]byte, error) {
+	var buf bytes.Buffer
+	w, err := gzip.NewWriterLevel(&buf, level)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(data); err != nil {
+		return nil, fmt.Errorf("could not compress cache response object: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("could not compress cache response object: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func compressZstd(data []byte, level int) ([]byte, error) {
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level)), zstd.WithEncoderConcurrency(1))
+	if err != nil {
+		return nil, fmt.Errorf("could not compress cache response object: %w", err)
+	}
+	defer func() {
+		_ = enc.Close()
+	}()
+	return enc.EncodeAll(data, nil), nil
+}
+
+func compressBrotli(data []byte, level int) ([]byte, error) {
+	buf := bytes.NewBuffer(append([]byte{}, brotliMagic...))
+	w := brotli.NewWriterLevel(buf, level)
+	if _, err := w.Write(data); err != nil {
+		return nil, fmt.Errorf("could not compress cache response object: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("could not compress cache response object: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// isCompressed returns true if the cached data has been compressed, which allows reading entries stored
+// before compression was enabled or disabled.
+func isCompressed(data []byte) bool {
+	return bytes.HasPrefix(data, gzipMagic) || bytes.HasPrefix(data, zstdMagic) || bytes.HasPrefix(data, brotliMagic)
+}
+
+func decompress(data []byte) ([]byte, error) {
+	if bytes.HasPrefix(data, zstdMagic) {
+		return decompressZstd(data)
+	}
+	if bytes.HasPrefix(data, brotliMagic) {
+		b, err := ioutil.ReadAll(brotli.NewReader(bytes.NewReader(data[len(brotliMagic):])))
+		if err != nil {
+			return nil, fmt.Errorf("could not decompress cache response object: %w", err)
+		}
+		return b, nil
+	}
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("could not decompress cache response object: %w", err)
+	}
+	defer func() {
+		_ = r.Close()
+	}()
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("could not decompress cache response object: %w", err)
+	}
+	return b, nil
+}
+
+func decompressZstd(data []byte) ([]byte, error) {
+	dec, err := zstd.NewReader(nil, zstd.WithDecoderConcurrency(1))
+	if err != nil {
+		return nil, fmt.Errorf("could not decompress cache response object: %w", err)
+	}
+	defer dec.Close()
+	b, err := dec.DecodeAll(data, nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not decompress cache response object: %w", err)
+	}
+	return b, nil
+}
@@ -0,0 +1,51 @@
+package cache
+
+import (
+	"compress/gzip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompression(t *testing.T) {
+	rsp := &response{Response: handlerResponse{Bytes: []byte("body"), StatusCode: 200}, LastValid: 1, Etag: "etag"}
+	encoded, err := rsp.encode()
+	require.NoError(t, err)
+	assert.False(t, isCompressed(encoded))
+
+	compressed, err := compress(encoded, gzip.BestSpeed)
+	require.NoError(t, err)
+	assert.True(t, isCompressed(compressed))
+
+	zstdCompressed, err := compressor{encoding: zstdEncoding, level: 3}.compress(encoded)
+	require.NoError(t, err)
+	assert.True(t, isCompressed(zstdCompressed))
+
+	brotliCompressed, err := compressor{encoding: brotliEncoding, level: 5}.compress(encoded)
+	require.NoError(t, err)
+	assert.True(t, isCompressed(brotliCompressed))
+
+	for name, data := range map[string][]byte{
+		"compressed": compressed, "zstd compressed": zstdCompressed, "brotli compressed": brotliCompressed, "uncompressed": encoded,
+	} {
+		data := data
+		t.Run(name, func(t *testing.T) {
+			got := &response{}
+			require.NoError(t, got.decode(data))
+			assert.Equal(t, rsp, got)
+		})
+	}
+
+	_, err = compress(encoded, 42)
+	assert.Error(t, err)
+
+	err = (&response{}).decode(append(gzipMagic, 0x00))
+	assert.Error(t, err)
+
+	err = (&response{}).decode(append(zstdMagic, 0x00))
+	assert.Error(t, err)
+
+	err = (&response{}).decode(append(brotliMagic, 0xff, 0xff))
+	assert.Error(t, err)
+}
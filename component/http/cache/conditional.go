@@ -0,0 +1,60 @@
+package cache
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	headerIfNoneMatch     = "If-None-Match"
+	headerIfModifiedSince = "If-Modified-Since"
+	// HeaderLastModified is the constant representing the Last-Modified http header.
+	HeaderLastModified = "Last-Modified"
+)
+
+// isNotModified evaluates the conditional headers of the request against the validators of the response, following RFC7232 section 6.
+// If-Modified-Since is ignored when the request contains an If-None-Match header, since the ETag is the more accurate validator.
+func isNotModified(req http.Header, rsp http.Header) bool {
+	if ifNoneMatch := req.Get(headerIfNoneMatch); ifNoneMatch != "" {
+		return ETagMatches(ifNoneMatch, rsp.Get(HeaderETagHeader))
+	}
+
+	ifModifiedSince, err := http.ParseTime(req.Get(headerIfModifiedSince))
+	if err != nil {
+		return false
+	}
+	lastModified, err := http.ParseTime(rsp.Get(HeaderLastModified))
+	if err != nil {
+		return false
+	}
+	return !lastModified.After(ifModifiedSince)
+}
+
+// ETagMatches reports whether the If-None-Match header matches the ETag, using the weak comparison function
+// required by RFC7232 section 3.2. The ETags generated by the cache are unquoted, so the tags are compared without the quotes.
+func ETagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" || etag == "" {
+		return false
+	}
+	if strings.TrimSpace(ifNoneMatch) == "*" {
+		return true
+	}
+	etag = opaqueTag(etag)
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		if opaqueTag(candidate) == etag {
+			return true
+		}
+	}
+	return false
+}
+
+func opaqueTag(etag string) string {
+	etag = strings.TrimSpace(etag)
+	etag = strings.TrimPrefix(etag, "W/")
+	return strings.Trim(etag, `"`)
+}
+
+func lastModified(lastValid int64) string {
+	return time.Unix(lastValid, 0).UTC().Format(http.TimeFormat)
+}
@@ -0,0 +1,63 @@
+package cache
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsNotModified(t *testing.T) {
+	rsp := http.Header{}
+	rsp.Set(HeaderETagHeader, "123-456")
+	rsp.Set(HeaderLastModified, lastModified(100))
+
+	tests := map[string]struct {
+		header   map[string]string
+		expected bool
+	}{
+		"no conditional headers":          {expected: false},
+		"matching etag":                   {header: map[string]string{headerIfNoneMatch: "123-456"}, expected: true},
+		"matching quoted weak etag":       {header: map[string]string{headerIfNoneMatch: `"abc", W/"123-456"`}, expected: true},
+		"wildcard etag":                   {header: map[string]string{headerIfNoneMatch: "*"}, expected: true},
+		"different etag":                  {header: map[string]string{headerIfNoneMatch: "123-789"}, expected: false},
+		"not modified since":              {header: map[string]string{headerIfModifiedSince: lastModified(100)}, expected: true},
+		"modified since":                  {header: map[string]string{headerIfModifiedSince: lastModified(99)}, expected: false},
+		"invalid modified since":          {header: map[string]string{headerIfModifiedSince: "yesterday"}, expected: false},
+		"etag takes precedence over date": {header: map[string]string{headerIfNoneMatch: "123-789", headerIfModifiedSince: lastModified(100)}, expected: false},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			req := http.Header{}
+			propagateHeaders(tt.header, req)
+			assert.Equal(t, tt.expected, isNotModified(req, rsp))
+		})
+	}
+}
+
+func TestETagMatches(t *testing.T) {
+	tests := map[string]struct {
+		ifNoneMatch string
+		etag        string
+		expected    bool
+	}{
+		"no if-none-match":     {etag: `"abc"`, expected: false},
+		"no etag":              {ifNoneMatch: "*", expected: false},
+		"wildcard":             {ifNoneMatch: " * ", etag: `"abc"`, expected: true},
+		"quoted match":         {ifNoneMatch: `"abc"`, etag: `"abc"`, expected: true},
+		"unquoted etag":        {ifNoneMatch: `"abc"`, etag: "abc", expected: true},
+		"weak match in a list": {ifNoneMatch: `"def", W/"abc"`, etag: `"abc"`, expected: true},
+		"no match":             {ifNoneMatch: `"def", "ghi"`, etag: `"abc"`, expected: false},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ETagMatches(tt.ifNoneMatch, tt.etag))
+		})
+	}
+}
+
+func TestLastModified(t *testing.T) {
+	assert.Equal(t, "Thu, 01 Jan 1970 00:01:40 GMT", lastModified(100))
+}
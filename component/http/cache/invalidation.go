@@ -0,0 +1,106 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/beatlabs/patron/cache"
+	"github.com/beatlabs/patron/component/http/auth"
+	"github.com/beatlabs/patron/log"
+)
+
+const (
+	invalidationKey    = "key"
+	invalidationPrefix = "prefix"
+	invalidationPurge  = "purge"
+)
+
+var errPrefixNotSupported = errors.New("cache does not support removing keys by prefix")
+
+// Invalidate evicts the cached responses of the provided key, which consists of the request path and the raw query
+// separated by a colon e.g. `/users:page=1`. When the cache implements cache.PrefixRemover, all cached variants of the key
+// are evicted along with it, i.e. the responses of HEAD and POST requests and the representations of route caches
+// varying on request headers. Otherwise, the variants expire on their own.
+func (rc *RouteCache) Invalidate(key string) error {
+	if err := rc.cache.Remove(key); err != nil {
+		return fmt.Errorf("could not invalidate cached response for key %s: %w", key, err)
+	}
+	if _, ok := rc.cache.(cache.PrefixRemover); !ok {
+		return nil
+	}
+	return rc.InvalidatePrefix(key + ":")
+}
+
+// InvalidatePrefix evicts the cached responses of all keys starting with the provided prefix e.g. `/users/`.
+// It requires the cache to implement cache.PrefixRemover.
+func (rc *RouteCache) InvalidatePrefix(prefix string) error {
+	pr, ok := rc.cache.(cache.PrefixRemover)
+	if !ok {
+		return errPrefixNotSupported
+	}
+	if err := pr.RemovePrefix(prefix); err != nil {
+		return fmt.Errorf("could not invalidate cached responses for prefix %s: %w", prefix, err)
+	}
+	return nil
+}
+
+// Purge evicts all cached responses by purging the underlying cache,
+// which affects any other route or component sharing it.
+func (rc *RouteCache) Purge() error {
+	if err := rc.cache.Purge(); err != nil {
+		return fmt.Errorf("could not purge cached responses: %w", err)
+	}
+	return nil
+}
+
+// InvalidationHandler returns the handler of an admin endpoint, which evicts cached responses of the route cache
+// after writes, instead of waiting for them to expire. Requests must provide exactly one of the query parameters
+// `key`, `prefix` or `purge=true`, which map to Invalidate, InvalidatePrefix and Purge respectively.
+// Requests are authenticated with the authenticator, which is required so that the endpoint is never exposed without authentication.
+func InvalidationHandler(rc *RouteCache, authenticator auth.Authenticator) (http.Handler, error) {
+	if rc == nil {
+		return nil, errors.New("route cache is nil")
+	}
+	if authenticator == nil {
+		return nil, errors.New("authenticator is nil")
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authenticated, err := authenticator.Authenticate(r)
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		if !authenticated {
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+
+		query := r.URL.Query()
+		key, prefix, purge := query.Get(invalidationKey), query.Get(invalidationPrefix), query.Get(invalidationPurge) == "true"
+
+		switch {
+		case key != "" && prefix == "" && !purge:
+			err = rc.Invalidate(key)
+		case prefix != "" && key == "" && !purge:
+			err = rc.InvalidatePrefix(prefix)
+		case purge && key == "" && prefix == "":
+			err = rc.Purge()
+		default:
+			http.Error(w, "exactly one of the key, prefix or purge=true query parameters is required", http.StatusBadRequest)
+			return
+		}
+
+		if errors.Is(err, errPrefixNotSupported) {
+			http.Error(w, err.Error(), http.StatusNotImplemented)
+			return
+		}
+		if err != nil {
+			log.FromContext(r.Context()).Errorf("could not invalidate route cache: %v", err)
+			http.Error(w, "could not invalidate route cache", http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}), nil
+}
@@ -0,0 +1,167 @@
+package cache
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type prefixTestingCache struct {
+	*testingCache
+}
+
+func (p prefixTestingCache) RemovePrefix(prefix string) error {
+	for k := range p.cache {
+		if strings.HasPrefix(k, prefix) {
+			delete(p.cache, k)
+		}
+	}
+	return nil
+}
+
+func newInvalidationCache(t *testing.T, c *testingCache, oo ...OptionFunc) *RouteCache {
+	c.instant = func() int64 { return 0 }
+	for _, k := range []string{"/users:", "/users:page=1", "/orders:"} {
+		require.NoError(t, c.SetTTL(k, []byte("value"), time.Minute))
+	}
+	rc, errs := NewRouteCache(c, Age{Max: time.Minute}, oo...)
+	require.Empty(t, errs)
+	return rc
+}
+
+func TestRouteCache_Invalidate(t *testing.T) {
+	c := prefixTestingCache{testingCache: newTestingCache()}
+	rc := newInvalidationCache(t, c.testingCache)
+	require.NoError(t, c.SetTTL("/users::HEAD", []byte("value"), time.Minute))
+	require.NoError(t, c.SetTTL("/users::POST:hash", []byte("value"), time.Minute))
+	rc.cache = c
+
+	assert.NoError(t, rc.Invalidate("/users:"))
+	assert.Len(t, c.cache, 2)
+	assert.NotContains(t, c.cache, "/users:")
+	assert.NotContains(t, c.cache, "/users::HEAD")
+	assert.NotContains(t, c.cache, "/users::POST:hash")
+	assert.Contains(t, c.cache, "/users:page=1")
+
+	assert.NoError(t, rc.Purge())
+	assert.Empty(t, c.cache)
+}
+
+func TestRouteCache_InvalidatePrefixNotSupported(t *testing.T) {
+	c := newTestingCache()
+	rc := newInvalidationCache(t, c)
+
+	require.NoError(t, c.SetTTL("/users::HEAD", []byte("value"), time.Minute))
+
+	// the variants of the key are left to expire
+	assert.NoError(t, rc.Invalidate("/users:"))
+	assert.Len(t, c.cache, 3)
+	assert.NotContains(t, c.cache, "/users:")
+	assert.Contains(t, c.cache, "/users::HEAD")
+
+	assert.ErrorIs(t, rc.InvalidatePrefix("/users"), errPrefixNotSupported)
+}
+
+func TestRouteCache_InvalidateVary(t *testing.T) {
+	c := prefixTestingCache{testingCache: newTestingCache()}
+	rc := newInvalidationCache(t, c.testingCache, Vary("Accept"))
+	require.NoError(t, c.SetTTL("/users::hash1", []byte("value"), time.Minute))
+	require.NoError(t, c.SetTTL("/users::hash2", []byte("value"), time.Minute))
+	rc.cache = c
+
+	assert.NoError(t, rc.Invalidate("/users:"))
+	assert.Len(t, c.cache, 2)
+	assert.NotContains(t, c.cache, "/users::hash1")
+	assert.NotContains(t, c.cache, "/users::hash2")
+
+	assert.NoError(t, rc.InvalidatePrefix("/users"))
+	assert.Len(t, c.cache, 1)
+	assert.Contains(t, c.cache, "/orders:")
+}
+
+type testAuthenticator struct {
+	authenticated bool
+	err           error
+}
+
+func (a testAuthenticator) Authenticate(*http.Request) (bool, error) {
+	return a.authenticated, a.err
+}
+
+func TestInvalidationHandler(t *testing.T) {
+	tests := map[string]struct {
+		query          string
+		auth           *testAuthenticator
+		prefixRemover  bool
+		removeErr      error
+		expectedStatus int
+		expectedKeys   int
+	}{
+		"key":                  {query: "key=/users:", prefixRemover: true, expectedStatus: http.StatusNoContent, expectedKeys: 2},
+		"key without prefixes": {query: "key=/users:", expectedStatus: http.StatusNoContent, expectedKeys: 2},
+		"purge":                {query: "purge=true", expectedStatus: http.StatusNoContent, expectedKeys: 0},
+		"prefix not supported": {query: "prefix=/users", expectedStatus: http.StatusNotImplemented, expectedKeys: 3},
+		"missing parameters":   {query: "", expectedStatus: http.StatusBadRequest, expectedKeys: 3},
+		"multiple parameters":  {query: "key=/users:&purge=true", expectedStatus: http.StatusBadRequest, expectedKeys: 3},
+		"purge false":          {query: "purge=false", expectedStatus: http.StatusBadRequest, expectedKeys: 3},
+		"cache failure":        {query: "key=/users:", removeErr: errors.New("failed"), expectedStatus: http.StatusInternalServerError, expectedKeys: 3},
+		"unauthenticated": {
+			query: "purge=true", auth: &testAuthenticator{authenticated: false}, expectedStatus: http.StatusUnauthorized, expectedKeys: 3,
+		},
+		"authentication failure": {
+			query: "purge=true", auth: &testAuthenticator{err: errors.New("failed")}, expectedStatus: http.StatusInternalServerError, expectedKeys: 3,
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			c := newTestingCache()
+			rc := newInvalidationCache(t, c)
+			if tt.prefixRemover {
+				rc.cache = prefixTestingCache{testingCache: c}
+			}
+			if tt.removeErr != nil {
+				rc.cache = failingRemoveCache{testingCache: c, err: tt.removeErr}
+			}
+			authenticator := testAuthenticator{authenticated: true}
+			if tt.auth != nil {
+				authenticator = *tt.auth
+			}
+			h, err := InvalidationHandler(rc, authenticator)
+			require.NoError(t, err)
+
+			rsp := httptest.NewRecorder()
+			h.ServeHTTP(rsp, httptest.NewRequest(http.MethodDelete, "/cache?"+tt.query, nil))
+
+			assert.Equal(t, tt.expectedStatus, rsp.Code)
+			assert.Len(t, c.cache, tt.expectedKeys)
+		})
+	}
+}
+
+func TestInvalidationHandler_Validation(t *testing.T) {
+	rc := newInvalidationCache(t, newTestingCache())
+
+	h, err := InvalidationHandler(nil, testAuthenticator{})
+	assert.EqualError(t, err, "route cache is nil")
+	assert.Nil(t, h)
+
+	h, err = InvalidationHandler(rc, nil)
+	assert.EqualError(t, err, "authenticator is nil")
+	assert.Nil(t, h)
+}
+
+type failingRemoveCache struct {
+	*testingCache
+	err error
+}
+
+func (f failingRemoveCache) Remove(string) error {
+	return f.err
+}
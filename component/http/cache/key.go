@@ -0,0 +1,19 @@
+package cache
+
+import "net/http"
+
+// KeyFunc extracts the cache key of a request e.g. including a tenant header.
+// Returning an empty key falls back to the default key, which consists of the request path and the raw query.
+type KeyFunc func(r *http.Request) string
+
+// NormalizedQueryKey returns a key func, which sorts the query parameters by key and drops the provided ones
+// e.g. tracking parameters, so that requests differing only in those share the same cached response.
+func NormalizedQueryKey(ignored ...string) KeyFunc {
+	return func(r *http.Request) string {
+		query := r.URL.Query()
+		for _, param := range ignored {
+			query.Del(param)
+		}
+		return r.URL.Path + ":" + query.Encode()
+	}
+}
@@ -0,0 +1,31 @@
+package cache
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizedQueryKey(t *testing.T) {
+	tests := map[string]struct {
+		target  string
+		ignored []string
+		want    string
+	}{
+		"no query":        {target: "/users", want: "/users:"},
+		"sorted":          {target: "/users?b=2&a=1", want: "/users:a=1&b=2"},
+		"multiple values": {target: "/users?b=2&a=3&a=1", want: "/users:a=3&a=1&b=2"},
+		"ignored":         {target: "/users?utm_source=x&b=2&a=1&fbclid=y", ignored: []string{"utm_source", "fbclid"}, want: "/users:a=1&b=2"},
+		"only ignored":    {target: "/users?utm_source=x", ignored: []string{"utm_source"}, want: "/users:"},
+		"escaped values":  {target: "/users?q=a%20b", want: "/users:q=a+b"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			assert.Equal(t, tt.want, NormalizedQueryKey(tt.ignored...)(req))
+		})
+	}
+}
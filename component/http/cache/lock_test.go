@@ -0,0 +1,135 @@
+package cache
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type lockingCache struct {
+	*testingCache
+	locks   map[string]time.Duration
+	lockErr error
+}
+
+func newLockingCache() *lockingCache {
+	return &lockingCache{testingCache: newTestingCache(), locks: make(map[string]time.Duration)}
+}
+
+func (l *lockingCache) TryLock(key string, ttl time.Duration) (bool, error) {
+	if l.lockErr != nil {
+		return false, l.lockErr
+	}
+	if _, ok := l.locks[key]; ok {
+		return false, nil
+	}
+	l.locks[key] = ttl
+	return true, nil
+}
+
+func (l *lockingCache) Unlock(key string) error {
+	delete(l.locks, key)
+	return nil
+}
+
+func TestRefreshLock(t *testing.T) {
+	rc, errs := NewRouteCache(newLockingCache(), Age{Max: 10 * time.Second}, RefreshLock(5*time.Second))
+	assert.Empty(t, errs)
+	assert.NotNil(t, rc.locker)
+	assert.Equal(t, 5*time.Second, rc.lockTTL)
+
+	_, errs = NewRouteCache(newLockingCache(), Age{Max: 10 * time.Second}, RefreshLock(time.Millisecond))
+	require.Len(t, errs, 1)
+	assert.EqualError(t, errs[0], "refresh lock ttl should be at least 10ms")
+
+	_, errs = NewRouteCache(newTestingCache(), Age{Max: 10 * time.Second}, RefreshLock(5*time.Second))
+	require.Len(t, errs, 1)
+	assert.EqualError(t, errs[0], "cache does not support locking")
+}
+
+func TestHandler_RefreshLock(t *testing.T) {
+	now := int64(1)
+	NowSeconds = func() int64 { return now }
+	defer func() { NowSeconds = func() int64 { return time.Now().Unix() } }()
+
+	executions := 0
+	hnd := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		executions++
+		_, _ = w.Write([]byte("body"))
+	})
+	serve := func(rc *RouteCache) *httptest.ResponseRecorder {
+		rsp := httptest.NewRecorder()
+		assert.NoError(t, Handler(rsp, httptest.NewRequest(http.MethodGet, "/", nil), rc, hnd))
+		assert.Equal(t, "body", rsp.Body.String())
+		return rsp
+	}
+
+	metrics := &testMetrics{}
+	monitor = metrics
+	c := newLockingCache()
+	c.instant = NowSeconds
+	rc, errs := NewRouteCache(c, Age{Max: 10 * time.Second}, RefreshLock(5*time.Second))
+	require.Empty(t, errs)
+
+	serve(rc)
+	assert.Equal(t, 1, executions)
+	// the entry is kept for the duration of the lock, after it has expired
+	assert.Equal(t, int64(15), c.cache["/:"].ttl)
+	assert.Empty(t, c.locks)
+
+	// another instance refreshes the expired entry
+	now = 12
+	c.locks["/:"] = time.Second
+	rsp := serve(rc)
+	assert.Equal(t, 1, executions)
+	assert.Equal(t, warningRefreshing, rsp.Header().Get(headerWarning))
+	assert.Equal(t, 1, metrics.values["/"].stales)
+
+	// the lock is acquired and released after refreshing the entry
+	delete(c.locks, "/:")
+	rsp = serve(rc)
+	assert.Equal(t, 2, executions)
+	assert.Empty(t, rsp.Header().Get(headerWarning))
+	assert.Equal(t, int64(12), c.cache["/:"].t0)
+	assert.Empty(t, c.locks)
+
+	// failing to lock does not prevent refreshing
+	now = 23
+	c.lockErr = errors.New("lock error")
+	serve(rc)
+	assert.Equal(t, 3, executions)
+	assert.Equal(t, 1, metrics.values["/"].errors)
+}
+
+func TestRevalidateInBackground_RefreshLock(t *testing.T) {
+	NowSeconds = func() int64 { return 1 }
+	runBackground = func(fn func()) { fn() }
+	defer func() { runBackground = func(fn func()) { go fn() } }()
+	monitor = &testMetrics{}
+
+	c := newLockingCache()
+	c.instant = NowSeconds
+	rc, errs := NewRouteCache(c, Age{Max: 10 * time.Second, StaleWhileRevalidate: 10 * time.Second}, RefreshLock(5*time.Second))
+	require.Empty(t, errs)
+
+	executions := 0
+	exec := func(now int64, key string) *response {
+		executions++
+		return &response{LastValid: now}
+	}
+
+	c.locks["key"] = time.Second
+	revalidateInBackground("/", "key", rc, exec)
+	assert.Equal(t, 0, executions)
+
+	delete(c.locks, "key")
+	revalidateInBackground("/", "key", rc, exec)
+	assert.Equal(t, 1, executions)
+	assert.Contains(t, c.cache, "key")
+	assert.Empty(t, c.locks)
+}
@@ -2,20 +2,43 @@ package cache
 
 import "github.com/prometheus/client_golang/prometheus"
 
+const (
+	// skipTooLarge is the reason of responses not cached, because their body exceeds the max body size.
+	skipTooLarge = "too_large"
+
+	// errGet is the reason of errors reading or decoding cached responses.
+	errGet = "get"
+	// errSet is the reason of errors encoding or storing responses.
+	errSet = "set"
+	// errRevalidate is the reason of failed background revalidations.
+	errRevalidate = "revalidate"
+	// errLock is the reason of errors acquiring or releasing refresh locks.
+	errLock = "lock"
+)
+
 var validationReason = map[validationContext]string{0: "nil", ttlValidation: "expired", maxAgeValidation: "max_age", minFreshValidation: "min_fresh"}
 
 type metrics interface {
 	add(path string)
 	miss(path string)
-	hit(path string)
-	err(path string)
+	// hit records a response served from the cache, along with the age of the cached entry.
+	hit(path string, age int64)
+	// stale records a stale response served from the cache, the reason being the Warning of the response.
+	stale(path, reason string)
+	err(path, reason string)
+	skip(path, reason string)
+	revalidate(path string)
 	evict(path string, context validationContext, age int64)
+	// compress records the size of a stored response before and after its compression with the encoding.
+	compress(path, encoding string, uncompressed, compressed int)
 }
 
 // prometheusMetrics is the prometheus implementation for exposing cache metrics.
 type prometheusMetrics struct {
-	ageHistogram *prometheus.HistogramVec
-	operations   *prometheus.CounterVec
+	ageHistogram    *prometheus.HistogramVec
+	hitAgeHistogram *prometheus.HistogramVec
+	operations      *prometheus.CounterVec
+	compression     *prometheus.CounterVec
 }
 
 func (m *prometheusMetrics) add(path string) {
@@ -26,12 +49,25 @@ func (m *prometheusMetrics) miss(path string) {
 	m.operations.WithLabelValues(path, "miss", "").Inc()
 }
 
-func (m *prometheusMetrics) hit(path string) {
+func (m *prometheusMetrics) hit(path string, age int64) {
+	m.hitAgeHistogram.WithLabelValues(path).Observe(float64(age))
 	m.operations.WithLabelValues(path, "hit", "").Inc()
 }
 
-func (m *prometheusMetrics) err(path string) {
-	m.operations.WithLabelValues(path, "Err", "").Inc()
+func (m *prometheusMetrics) stale(path, reason string) {
+	m.operations.WithLabelValues(path, "stale", reason).Inc()
+}
+
+func (m *prometheusMetrics) err(path, reason string) {
+	m.operations.WithLabelValues(path, "Err", reason).Inc()
+}
+
+func (m *prometheusMetrics) skip(path, reason string) {
+	m.operations.WithLabelValues(path, "skip", reason).Inc()
+}
+
+func (m *prometheusMetrics) revalidate(path string) {
+	m.operations.WithLabelValues(path, "revalidate", "").Inc()
 }
 
 func (m *prometheusMetrics) evict(path string, context validationContext, age int64) {
@@ -39,14 +75,29 @@ func (m *prometheusMetrics) evict(path string, context validationContext, age in
 	m.operations.WithLabelValues(path, "evict", validationReason[context]).Inc()
 }
 
+func (m *prometheusMetrics) compress(path, encoding string, uncompressed, compressed int) {
+	m.compression.WithLabelValues(path, encoding, "uncompressed").Add(float64(uncompressed))
+	m.compression.WithLabelValues(path, encoding, "compressed").Add(float64(compressed))
+}
+
 // newPrometheusMetrics constructs a new prometheus metrics implementation instance.
 func newPrometheusMetrics() *prometheusMetrics {
+	buckets := []float64{1, 10, 30, 60, 60 * 5, 60 * 10, 60 * 30, 60 * 60}
+
 	histogram := prometheus.NewHistogramVec(prometheus.HistogramOpts{
 		Namespace: "http_cache",
 		Subsystem: "handler",
 		Name:      "expiration",
 		Help:      "Expiry age for evicted objects.",
-		Buckets:   []float64{1, 10, 30, 60, 60 * 5, 60 * 10, 60 * 30, 60 * 60},
+		Buckets:   buckets,
+	}, []string{"route"})
+
+	hitHistogram := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "http_cache",
+		Subsystem: "handler",
+		Name:      "hit_age",
+		Help:      "Age of the objects served from the cache.",
+		Buckets:   buckets,
 	}, []string{"route"})
 
 	operations := prometheus.NewCounterVec(prometheus.CounterOpts{
@@ -56,12 +107,21 @@ func newPrometheusMetrics() *prometheusMetrics {
 		Help:      "Number of cache operations.",
 	}, []string{"route", "operation", "reason"})
 
+	compression := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "http_cache",
+		Subsystem: "handler",
+		Name:      "compression_bytes",
+		Help:      "Bytes of the stored objects before and after their compression, classified by encoding.",
+	}, []string{"route", "encoding", "stage"})
+
 	m := &prometheusMetrics{
-		ageHistogram: histogram,
-		operations:   operations,
+		ageHistogram:    histogram,
+		hitAgeHistogram: hitHistogram,
+		operations:      operations,
+		compression:     compression,
 	}
 
-	prometheus.MustRegister(m.ageHistogram, m.operations)
+	prometheus.MustRegister(m.ageHistogram, m.hitAgeHistogram, m.operations, m.compression)
 
 	return m
 }
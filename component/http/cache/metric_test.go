@@ -0,0 +1,34 @@
+package cache
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrometheusMetrics(t *testing.T) {
+	m := prometheusMonitor
+	path := "/metrics-test"
+
+	m.add(path)
+	m.miss(path)
+	m.hit(path, 5)
+	m.stale(path, warningLastValid)
+	m.err(path, errRevalidate)
+	m.skip(path, skipTooLarge)
+	m.revalidate(path)
+	m.evict(path, ttlValidation, 12)
+	m.compress(path, zstdEncoding, 100, 10)
+
+	for operation, reason := range map[string]string{
+		"add": "", "miss": "", "hit": "", "stale": warningLastValid, "Err": errRevalidate,
+		"skip": skipTooLarge, "revalidate": "", "evict": "expired",
+	} {
+		assert.Equal(t, 1.0, testutil.ToFloat64(m.operations.WithLabelValues(path, operation, reason)), operation)
+	}
+	assert.Equal(t, 1, testutil.CollectAndCount(m.hitAgeHistogram))
+	assert.Equal(t, 1, testutil.CollectAndCount(m.ageHistogram))
+	assert.Equal(t, 100.0, testutil.ToFloat64(m.compression.WithLabelValues(path, zstdEncoding, "uncompressed")))
+	assert.Equal(t, 10.0, testutil.ToFloat64(m.compression.WithLabelValues(path, zstdEncoding, "compressed")))
+}
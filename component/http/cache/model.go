@@ -1,9 +1,12 @@
 package cache
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
 )
 
 // handlerRequest is the dedicated request object for the cache handler.
@@ -11,6 +14,12 @@ type handlerRequest struct {
 	header string
 	path   string
 	query  string
+	// vary is the hash of the request header values the route cache varies on.
+	vary string
+	// key is the key extracted by a custom key func, which replaces the path and the query.
+	key string
+	// variant distinguishes the requests of other methods than GET, which do not share the cached responses of GET requests.
+	variant string
 }
 
 // toCacheHandlerRequest transforms the http Request object to the cache handler request.
@@ -32,15 +41,42 @@ func toCacheHandlerRequest(req *http.Request) *handlerRequest {
 	}
 }
 
-// getKey generates a unique cache key based on the route path and the query parameters.
+// getKey generates a unique cache key based on the route path, the query parameters and the varying request headers.
+// The path and the query are replaced by the key extracted by a custom key func, if any.
+// The requests of other methods than GET are distinguished by a suffix e.g. `/users:page=1:HEAD`.
 func (c *handlerRequest) getKey() string {
-	return fmt.Sprintf("%s:%s", c.path, c.query)
+	key := c.key
+	if key == "" {
+		key = fmt.Sprintf("%s:%s", c.path, c.query)
+	}
+	if c.vary != "" {
+		key = fmt.Sprintf("%s:%s", key, c.vary)
+	}
+	if c.variant != "" {
+		key = fmt.Sprintf("%s:%s", key, c.variant)
+	}
+	return key
+}
+
+// varyKey hashes the values of the provided request headers, so that they can safely be part of the cache key.
+func varyKey(header http.Header, vary []string) string {
+	if len(vary) == 0 {
+		return ""
+	}
+	hash := sha256.New()
+	for _, h := range vary {
+		// the header key acts as a delimiter, so that values cannot be shifted from one header to another
+		_, _ = fmt.Fprintf(hash, "%s=%q\n", h, strings.Join(header.Values(h), ","))
+	}
+	return hex.EncodeToString(hash.Sum(nil))
 }
 
 // handlerResponse is the dedicated Response object for the cache handler.
 type handlerResponse struct {
 	Bytes  []byte
 	Header http.Header
+	// StatusCode of the handler response, where zero stands for OK.
+	StatusCode int
 }
 
 // response is the struct representing an object retrieved or ready to be put into the route cache.
@@ -51,6 +87,29 @@ type response struct {
 	Warning   string
 	FromCache bool
 	Err       error
+	// StatusCode of the handler response, where zero stands for OK.
+	StatusCode int
+	// shared marks a response produced for another concurrent request of the same key, which caches it.
+	shared bool
+	// locked marks a response refreshing an expired entry, whose refresh lock should be released once it is cached.
+	locked bool
+}
+
+// isError returns true if the handler responded with a client or server error.
+func (c *response) isError() bool {
+	return c.StatusCode >= http.StatusBadRequest
+}
+
+// clone returns a copy of the response, which can be modified independently.
+func (c *response) clone() *response {
+	cp := *c
+	cp.Response.Header = c.Response.Header.Clone()
+	return &cp
+}
+
+// failed returns true if the response could not be produced or the handler responded with a server error.
+func (c *response) failed() bool {
+	return c.Err != nil || c.StatusCode >= http.StatusInternalServerError
 }
 
 func (c *response) encode() ([]byte, error) {
@@ -61,6 +120,14 @@ func (c *response) encode() ([]byte, error) {
 	return b, nil
 }
 
+// decode decodes the cached data, which might have been compressed.
 func (c *response) decode(data []byte) error {
+	if isCompressed(data) {
+		var err error
+		data, err = decompress(data)
+		if err != nil {
+			return err
+		}
+	}
 	return json.Unmarshal(data, c)
 }
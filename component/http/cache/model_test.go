@@ -1,6 +1,9 @@
 package cache
 
 import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/beatlabs/patron/encoding/json"
@@ -60,3 +63,33 @@ func assertForHandlerResponse(t *testing.T, payload interface{}) {
 
 	assert.Equal(t, r, rsp)
 }
+
+func TestHandlerRequest_KeyWithVary(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/path?a=1", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	assert.Equal(t, "/path:a=1", toCacheHandlerRequest(req).getKey())
+
+	vary := []string{"Accept", "Authorization"}
+	hr := toCacheHandlerRequest(req)
+	hr.vary = varyKey(req.Header, vary)
+	key := hr.getKey()
+	assert.True(t, strings.HasPrefix(key, "/path:a=1:"))
+	assert.NotContains(t, key, "secret")
+	assert.Equal(t, key, hr.getKey())
+
+	// values cannot be shifted between headers
+	other := http.Header{}
+	other.Set("Accept", "Bearer secret")
+	assert.NotEqual(t, hr.vary, varyKey(other, vary))
+	assert.Empty(t, varyKey(req.Header, nil))
+}
+
+func TestHandlerRequest_CustomKey(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/path?a=1", nil)
+	hr := toCacheHandlerRequest(req)
+	hr.key = "tenant:/path"
+	assert.Equal(t, "tenant:/path", hr.getKey())
+
+	hr.vary = "hash"
+	assert.Equal(t, "tenant:/path:hash", hr.getKey())
+}
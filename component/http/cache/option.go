@@ -0,0 +1,215 @@
+package cache
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/andybalholm/brotli"
+	"github.com/beatlabs/patron/cache"
+)
+
+// OptionFunc definition for configuring the route cache in a functional way.
+type OptionFunc func(*RouteCache) error
+
+// Vary option for caching a separate representation for every combination of the values of the provided request headers,
+// e.g. `Accept`, `Accept-Encoding` or `Authorization`.
+// The header values are hashed into the cache key, so that credentials are never stored as part of it,
+// and the headers are advertised in the `Vary` header of the responses.
+func Vary(headers ...string) OptionFunc {
+	return func(rc *RouteCache) error {
+		if len(headers) == 0 {
+			return errors.New("vary headers are empty")
+		}
+		vary := make(map[string]struct{}, len(rc.vary)+len(headers))
+		for _, h := range rc.vary {
+			vary[h] = struct{}{}
+		}
+		for _, h := range headers {
+			if h == "" {
+				return errors.New("vary header is empty")
+			}
+			vary[http.CanonicalHeaderKey(h)] = struct{}{}
+		}
+		rc.vary = make([]string, 0, len(vary))
+		for h := range vary {
+			rc.vary = append(rc.vary, h)
+		}
+		// the order needs to be stable in order to produce the same key for the same request
+		sort.Strings(rc.vary)
+		return nil
+	}
+}
+
+// NegativeCaching option for caching error responses (4xx/5xx) according to the provided age, which should be much shorter
+// than the age of successful responses. This way, aggressive clients hammering a failing endpoint do not amplify the load on the backend.
+// Without it, error responses are not cached at all.
+func NegativeCaching(errAge Age) OptionFunc {
+	return func(rc *RouteCache) error {
+		if errAge.Max < time.Second {
+			return errors.New("max age of error responses should be at least one second")
+		}
+		if errAge.Min > errAge.Max {
+			return errors.New("max age of error responses must always be greater than min age")
+		}
+		if errAge.StaleWhileRevalidate != 0 || errAge.StaleIfError != 0 {
+			return errors.New("error responses cannot be served stale")
+		}
+		a := errAge.toAgeInSeconds()
+		rc.errorAge = &a
+		return nil
+	}
+}
+
+// Public option for adding the `public` directive to the Cache-Control header of the responses,
+// which allows shared caches, e.g. CDNs, to store responses of authenticated requests.
+func Public() OptionFunc {
+	return func(rc *RouteCache) error {
+		if rc.directives.visibility == controlPrivate {
+			return errors.New("public and private directives are mutually exclusive")
+		}
+		rc.directives.visibility = controlPublic
+		return nil
+	}
+}
+
+// Private option for adding the `private` directive to the Cache-Control header of the responses,
+// which prevents shared caches, e.g. CDNs, from storing responses meant for a single user.
+func Private() OptionFunc {
+	return func(rc *RouteCache) error {
+		if rc.directives.visibility == controlPublic {
+			return errors.New("public and private directives are mutually exclusive")
+		}
+		rc.directives.visibility = controlPrivate
+		return nil
+	}
+}
+
+// SharedMaxAge option for adding the `s-maxage` directive to the Cache-Control header of successful responses,
+// which overrides the max-age for shared caches, e.g. CDNs. Like max-age, it is reduced by the age of the cached response.
+func SharedMaxAge(maxAge time.Duration) OptionFunc {
+	return func(rc *RouteCache) error {
+		if maxAge < time.Second {
+			return errors.New("shared max age should be at least one second")
+		}
+		rc.directives.sharedMaxAge = int64(maxAge / time.Second)
+		return nil
+	}
+}
+
+// NoTransform option for adding the `no-transform` directive to the Cache-Control header of the responses,
+// which prevents intermediaries from modifying the payload, e.g. by recompressing images.
+func NoTransform() OptionFunc {
+	return func(rc *RouteCache) error {
+		rc.directives.noTransform = true
+		return nil
+	}
+}
+
+// MustRevalidate option for adding the `must-revalidate` directive to the Cache-Control header of the responses,
+// which prevents caches from serving stale responses without revalidating them first.
+func MustRevalidate() OptionFunc {
+	return func(rc *RouteCache) error {
+		rc.directives.mustRevalidate = true
+		return nil
+	}
+}
+
+// Key option for extracting the cache key with the provided func, instead of the request path and the raw query,
+// e.g. to include a tenant header or to ignore tracking query parameters. See NormalizedQueryKey.
+// The keys are also the ones expected by Invalidate.
+func Key(fn KeyFunc) OptionFunc {
+	return func(rc *RouteCache) error {
+		if fn == nil {
+			return errors.New("key func is nil")
+		}
+		rc.keyFunc = fn
+		return nil
+	}
+}
+
+// MaxBodySize option for caching only responses with a body up to the provided size in bytes,
+// so that huge responses do not exhaust the memory of the cache. Larger responses are served, but not cached.
+func MaxBodySize(bytes int) OptionFunc {
+	return func(rc *RouteCache) error {
+		if bytes <= 0 {
+			return errors.New("max body size should be positive")
+		}
+		rc.maxBodySize = bytes
+		return nil
+	}
+}
+
+// MaxResponseSize option for capping the total size of the responses written by the handler, which are buffered in memory
+// until the handler completes, e.g. to protect against streaming handlers producing unbounded responses.
+// Once the cap is exceeded, the writes of the handler fail with ErrResponseTooLarge and the request fails with an internal server error.
+// Unlike MaxBodySize, which only prevents caching, it should be set well above the size of the expected responses.
+func MaxResponseSize(bytes int) OptionFunc {
+	return func(rc *RouteCache) error {
+		if bytes <= 0 {
+			return errors.New("max response size should be positive")
+		}
+		rc.maxResponseSize = bytes
+		return nil
+	}
+}
+
+// Compression option for storing the responses compressed with gzip at the provided level, e.g. gzip.DefaultCompression,
+// trading CPU for the memory of the cache. Compressed and uncompressed entries can be read regardless of the option,
+// so that it can be toggled without purging the cache.
+func Compression(level int) OptionFunc {
+	return func(rc *RouteCache) error {
+		if level < gzip.HuffmanOnly || level > gzip.BestCompression {
+			return fmt.Errorf("invalid compression level: %d", level)
+		}
+		rc.compressor = &compressor{encoding: gzipEncoding, level: level}
+		return nil
+	}
+}
+
+// ZstdCompression option for storing the responses compressed with zstd at the provided level, from 1 (fastest) to 22 (best),
+// which compresses faster and smaller than gzip. Entries compressed with gzip, zstd, brotli or not at all can be read regardless of the option.
+func ZstdCompression(level int) OptionFunc {
+	return func(rc *RouteCache) error {
+		if level < 1 || level > 22 {
+			return fmt.Errorf("invalid zstd compression level: %d", level)
+		}
+		rc.compressor = &compressor{encoding: zstdEncoding, level: level}
+		return nil
+	}
+}
+
+// BrotliCompression option for storing the responses compressed with brotli at the provided level, from 0 (fastest) to 11 (best),
+// which compresses smaller than gzip at a higher CPU cost on its best levels. Entries compressed with gzip, zstd, brotli or not at all
+// can be read regardless of the option.
+func BrotliCompression(level int) OptionFunc {
+	return func(rc *RouteCache) error {
+		if level < brotli.BestSpeed || level > brotli.BestCompression {
+			return fmt.Errorf("invalid brotli compression level: %d", level)
+		}
+		rc.compressor = &compressor{encoding: brotliEncoding, level: level}
+		return nil
+	}
+}
+
+// RefreshLock option for refreshing expired entries from a single instance cluster-wide, preventing refresh stampedes across replicas.
+// It requires the cache to implement cache.Locker e.g. the Redis cache. The instance acquiring the lock of an expired entry refreshes it,
+// while the rest serve the expired entry with a Warning header, until the lock is released or its ttl, which should exceed the
+// duration of the handler, elapses.
+func RefreshLock(ttl time.Duration) OptionFunc {
+	return func(rc *RouteCache) error {
+		if ttl < 10*time.Millisecond {
+			return errors.New("refresh lock ttl should be at least 10ms")
+		}
+		locker, ok := rc.cache.(cache.Locker)
+		if !ok {
+			return errors.New("cache does not support locking")
+		}
+		rc.locker = locker
+		rc.lockTTL = ttl
+		return nil
+	}
+}
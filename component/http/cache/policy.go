@@ -0,0 +1,133 @@
+package cache
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+// Policy defines which requests and responses are cacheable by a route cache.
+type Policy struct {
+	// Methods holds the cacheable request methods, which can be GET, HEAD and POST. GET and HEAD are cacheable, if it is empty.
+	// POST requests are keyed by the hash of their body, so they should be cacheable only for safe operations e.g. search queries.
+	// POST requests with a body larger than 1 MiB bypass the cache.
+	Methods []string
+	// StatusCodes holds the cacheable response status codes. If it is empty, successful responses are cacheable,
+	// as well as error responses if NegativeCaching is set.
+	StatusCodes []int
+}
+
+// defaultMethods are the request methods cacheable by default.
+var defaultMethods = []string{http.MethodGet, http.MethodHead}
+
+// policy is the compiled form of the policy, which is looked up per request.
+type policy struct {
+	methods     map[string]struct{}
+	statusCodes map[int]struct{}
+}
+
+func newPolicy(p Policy) (policy, error) {
+	methods := p.Methods
+	if len(methods) == 0 {
+		methods = defaultMethods
+	}
+	pl := policy{methods: make(map[string]struct{}, len(methods))}
+	for _, m := range methods {
+		switch m {
+		case http.MethodGet, http.MethodHead, http.MethodPost:
+			pl.methods[m] = struct{}{}
+		default:
+			return policy{}, fmt.Errorf("method %s is not cacheable", m)
+		}
+	}
+	if len(p.StatusCodes) == 0 {
+		return pl, nil
+	}
+	pl.statusCodes = make(map[int]struct{}, len(p.StatusCodes))
+	for _, code := range p.StatusCodes {
+		if code < 100 || code > 599 {
+			return policy{}, fmt.Errorf("invalid status code: %d", code)
+		}
+		pl.statusCodes[code] = struct{}{}
+	}
+	return pl, nil
+}
+
+// CachePolicy option for setting which request methods and response status codes are cacheable,
+// instead of GET and HEAD requests with successful responses.
+func CachePolicy(p Policy) OptionFunc {
+	return func(rc *RouteCache) error {
+		pl, err := newPolicy(p)
+		if err != nil {
+			return err
+		}
+		rc.policy = pl
+		return nil
+	}
+}
+
+// CacheableMethod returns true if requests of the method are cacheable by the route cache.
+// Requests of other methods should bypass the route cache.
+func (rc *RouteCache) CacheableMethod(method string) bool {
+	_, ok := rc.policy.methods[method]
+	return ok
+}
+
+// cacheableStatus returns true if the status code of the response is cacheable according to the policy.
+// Without status codes in the policy, error responses are cacheable only with negative caching.
+func (p policy) cacheableStatus(rsp *response, negativeCaching bool) bool {
+	if p.statusCodes == nil {
+		return !rsp.isError() || negativeCaching
+	}
+	code := rsp.StatusCode
+	if code == 0 {
+		code = http.StatusOK
+	}
+	_, ok := p.statusCodes[code]
+	return ok
+}
+
+// maxHashedBodySize is the size of the largest POST request body to be hashed into the cache key.
+// Requests with larger bodies bypass the cache, so that they are not buffered in memory.
+const maxHashedBodySize = 1 << 20
+
+// methodVariant returns the part of the cache key distinguishing the requests of other methods than GET,
+// which is empty for GET requests, so that their keys are not affected. POST requests are distinguished by the hash of their body,
+// which is restored afterwards, so that it can be read by the handler. POST requests whose body exceeds maxHashedBodySize
+// are not cacheable.
+func methodVariant(r *http.Request) (string, bool, error) {
+	switch r.Method {
+	case http.MethodGet, "":
+		return "", true, nil
+	case http.MethodPost:
+		if r.Body == nil {
+			return r.Method, true, nil
+		}
+		body, err := ioutil.ReadAll(io.LimitReader(r.Body, maxHashedBodySize+1))
+		if err != nil {
+			return "", false, fmt.Errorf("could not read request body: %w", err)
+		}
+		if len(body) > maxHashedBodySize {
+			// the handler reads the body from the start, followed by its unread part
+			r.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(body), r.Body), Closer: r.Body}
+			return "", false, nil
+		}
+		if err := r.Body.Close(); err != nil {
+			return "", false, fmt.Errorf("could not close request body: %w", err)
+		}
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		hash := sha256.Sum256(body)
+		return r.Method + ":" + hex.EncodeToString(hash[:]), true, nil
+	default:
+		return r.Method, true, nil
+	}
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,181 @@
+package cache
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCachePolicy(t *testing.T) {
+	tests := map[string]struct {
+		policy      Policy
+		cacheable   []string
+		uncacheable []string
+		expectedErr string
+	}{
+		"default": {
+			cacheable:   []string{http.MethodGet, http.MethodHead},
+			uncacheable: []string{http.MethodPost, http.MethodDelete},
+		},
+		"post": {
+			policy:      Policy{Methods: []string{http.MethodGet, http.MethodPost}},
+			cacheable:   []string{http.MethodGet, http.MethodPost},
+			uncacheable: []string{http.MethodHead},
+		},
+		"invalid method": {
+			policy:      Policy{Methods: []string{http.MethodGet, http.MethodPut}},
+			expectedErr: "method PUT is not cacheable",
+		},
+		"invalid status code": {
+			policy:      Policy{StatusCodes: []int{http.StatusOK, 600}},
+			expectedErr: "invalid status code: 600",
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			rc, errs := NewRouteCache(newTestingCache(), Age{Max: 10 * time.Second}, CachePolicy(tt.policy))
+			if tt.expectedErr != "" {
+				require.Len(t, errs, 1)
+				assert.EqualError(t, errs[0], tt.expectedErr)
+				return
+			}
+			assert.Empty(t, errs)
+			for _, m := range tt.cacheable {
+				assert.True(t, rc.CacheableMethod(m), m)
+			}
+			for _, m := range tt.uncacheable {
+				assert.False(t, rc.CacheableMethod(m), m)
+			}
+		})
+	}
+}
+
+func TestPolicy_cacheableStatus(t *testing.T) {
+	defaultPolicy, err := newPolicy(Policy{})
+	require.NoError(t, err)
+	codesPolicy, err := newPolicy(Policy{StatusCodes: []int{http.StatusOK, http.StatusNotFound}})
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		policy          policy
+		statusCode      int
+		negativeCaching bool
+		want            bool
+	}{
+		"default ok":                    {policy: defaultPolicy, statusCode: 0, want: true},
+		"default error":                 {policy: defaultPolicy, statusCode: http.StatusNotFound, want: false},
+		"default error negative":        {policy: defaultPolicy, statusCode: http.StatusNotFound, negativeCaching: true, want: true},
+		"codes implicit ok":             {policy: codesPolicy, statusCode: 0, want: true},
+		"codes listed error":            {policy: codesPolicy, statusCode: http.StatusNotFound, want: true},
+		"codes unlisted success":        {policy: codesPolicy, statusCode: http.StatusPartialContent, want: false},
+		"codes unlisted error negative": {policy: codesPolicy, statusCode: http.StatusInternalServerError, negativeCaching: true, want: false},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.policy.cacheableStatus(&response{StatusCode: tt.statusCode}, tt.negativeCaching))
+		})
+	}
+}
+
+func TestMethodVariant(t *testing.T) {
+	variant, cacheable, err := methodVariant(httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.NoError(t, err)
+	assert.True(t, cacheable)
+	assert.Empty(t, variant)
+
+	variant, cacheable, err = methodVariant(httptest.NewRequest(http.MethodHead, "/", nil))
+	assert.NoError(t, err)
+	assert.True(t, cacheable)
+	assert.Equal(t, "HEAD", variant)
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"q":"a"}`))
+	variant, cacheable, err = methodVariant(req)
+	assert.NoError(t, err)
+	assert.True(t, cacheable)
+	assert.Equal(t, "POST:29a9829b3c03948275ca3be1cb7b633c0207849d2c0bd3215060f2ac05abce64", variant)
+	// the body can still be read by the handler
+	body, err := ioutil.ReadAll(req.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"q":"a"}`, string(body))
+
+	other, _, err := methodVariant(httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"q":"b"}`)))
+	assert.NoError(t, err)
+	assert.NotEqual(t, variant, other)
+
+	// large bodies are not hashed, but can still be read by the handler
+	large := strings.Repeat("a", maxHashedBodySize+10)
+	req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(large))
+	variant, cacheable, err = methodVariant(req)
+	assert.NoError(t, err)
+	assert.False(t, cacheable)
+	assert.Empty(t, variant)
+	body, err = ioutil.ReadAll(req.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, large, string(body))
+	assert.NoError(t, req.Body.Close())
+}
+
+func TestHandler_Policy(t *testing.T) {
+	NowSeconds = func() int64 { return 1 }
+
+	hnd := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+		_, _ = w.Write([]byte(r.Method + string(body)))
+	})
+
+	serve := func(rc *RouteCache, method, path, body string) {
+		rsp := httptest.NewRecorder()
+		assert.NoError(t, Handler(rsp, httptest.NewRequest(method, path, strings.NewReader(body)), rc, hnd))
+		assert.Equal(t, method+body, rsp.Body.String())
+	}
+
+	t.Run("methods", func(t *testing.T) {
+		monitor = &testMetrics{}
+		c := newTestingCache()
+		c.instant = NowSeconds
+		rc, errs := NewRouteCache(c, Age{Max: 10 * time.Second}, CachePolicy(Policy{Methods: []string{http.MethodGet, http.MethodHead, http.MethodPost}}))
+		assert.Empty(t, errs)
+
+		serve(rc, http.MethodGet, "/search", "")
+		serve(rc, http.MethodHead, "/search", "")
+		serve(rc, http.MethodPost, "/search", "a")
+		serve(rc, http.MethodPost, "/search", "b")
+		// served from the cache
+		serve(rc, http.MethodPost, "/search", "a")
+		serve(rc, http.MethodGet, "/search", "")
+		assert.Len(t, c.cache, 4)
+		assert.Contains(t, c.cache, "/search:")
+		assert.Contains(t, c.cache, "/search::HEAD")
+		assert.Equal(t, 2, monitor.(*testMetrics).values["/search"].hits)
+
+		// bypasses the cache
+		serve(rc, http.MethodDelete, "/search", "")
+		serve(rc, http.MethodPost, "/search", strings.Repeat("a", maxHashedBodySize+1))
+		assert.Len(t, c.cache, 4)
+	})
+
+	t.Run("status codes", func(t *testing.T) {
+		monitor = &testMetrics{}
+		c := newTestingCache()
+		c.instant = NowSeconds
+		rc, errs := NewRouteCache(c, Age{Max: 10 * time.Second}, CachePolicy(Policy{StatusCodes: []int{http.StatusNotFound}}))
+		assert.Empty(t, errs)
+
+		serve(rc, http.MethodGet, "/missing", "")
+		serve(rc, http.MethodGet, "/found", "")
+		assert.Contains(t, c.cache, "/missing:")
+		assert.NotContains(t, c.cache, "/found:")
+	})
+}
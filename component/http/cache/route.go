@@ -2,9 +2,11 @@ package cache
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/beatlabs/patron/cache"
@@ -18,10 +20,34 @@ type RouteCache struct {
 	// age specifies the minimum and maximum amount for max-age and min-fresh Header values respectively
 	// regarding the client cache-control requests in seconds.
 	age age
+	// revalidating holds the keys which are being revalidated in the background.
+	revalidating sync.Map
+	// flights holds the in-flight executions of the handler, which are shared by concurrent requests of the same key.
+	flights flights
+	// errorAge specifies the age of the cached error responses, which are not cached if it is nil.
+	errorAge *age
+	// vary holds the canonical request header keys, a separate representation is cached for.
+	vary []string
+	// directives holds the additional directives of the Cache-Control response header.
+	directives directives
+	// keyFunc extracts the cache key from the request, instead of the path and the query.
+	keyFunc KeyFunc
+	// maxBodySize is the size of the largest response body to be cached, zero if unlimited.
+	maxBodySize int
+	// compressor compresses the stored responses, which are not compressed if it is nil.
+	compressor *compressor
+	// maxResponseSize is the size of the largest response the handler is allowed to write, zero if unlimited.
+	maxResponseSize int
+	// policy defines the cacheable request methods and response status codes.
+	policy policy
+	// locker locks expired entries cluster-wide while they are refreshed, if it is set.
+	locker cache.Locker
+	// lockTTL is the ttl of the refresh locks.
+	lockTTL time.Duration
 }
 
 // NewRouteCache creates a new cache implementation for an http route.
-func NewRouteCache(ttlCache cache.TTLCache, age Age) (*RouteCache, []error) {
+func NewRouteCache(ttlCache cache.TTLCache, age Age, oo ...OptionFunc) (*RouteCache, []error) {
 	errs := make([]error, 0)
 
 	if ttlCache == nil {
@@ -32,14 +58,29 @@ func NewRouteCache(ttlCache cache.TTLCache, age Age) (*RouteCache, []error) {
 		errs = append(errs, errors.New("max age must always be greater than min age"))
 	}
 
+	if age.StaleWhileRevalidate < 0 || age.StaleIfError < 0 {
+		errs = append(errs, errors.New("stale durations must not be negative"))
+	}
+
 	if hasNoAgeConfig(age.Min.Milliseconds(), age.Max.Milliseconds()) {
 		log.Warnf("route cache disabled because of empty Age property %v", age)
 	}
 
-	return &RouteCache{
-		cache: ttlCache,
-		age:   age.toAgeInSeconds(),
-	}, errs
+	// the default policy is always valid
+	pl, _ := newPolicy(Policy{})
+	rc := &RouteCache{
+		cache:  ttlCache,
+		age:    age.toAgeInSeconds(),
+		policy: pl,
+	}
+
+	for _, option := range oo {
+		if err := option(rc); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return rc, errs
 }
 
 // Age defines the route cache life-time boundaries for cached objects.
@@ -53,26 +94,70 @@ type Age struct {
 	// The difference of maxAge-minAge sets automatically the max threshold for min-fresh requests
 	// This will avoid cases where a single client with high request rate and no cache control headers might effectively disable the cache
 	// This means that if this parameter is very high (e.g. greater than ttl , the cache can effectively be made obsolete in the above scenario).
+	// StaleWhileRevalidate allows serving an expired entry for the given duration after it expired,
+	// while the entry is refreshed in the background (RFC 5861).
+	StaleWhileRevalidate time.Duration
+	// StaleIfError allows serving an expired entry for the given duration after it expired,
+	// if the handler fails to produce a fresh response (RFC 5861).
+	StaleIfError time.Duration
 }
 
 func (a Age) toAgeInSeconds() age {
 	return age{
-		min: int64(a.Min / time.Second),
-		max: int64(a.Max / time.Second),
+		min:                  int64(a.Min / time.Second),
+		max:                  int64(a.Max / time.Second),
+		staleWhileRevalidate: int64(a.StaleWhileRevalidate / time.Second),
+		staleIfError:         int64(a.StaleIfError / time.Second),
 	}
 }
 
 type age struct {
-	min int64
-	max int64
+	min                  int64
+	max                  int64
+	staleWhileRevalidate int64
+	staleIfError         int64
 }
 
+// ageOf returns the age applying to the response, depending on whether it is an error response.
+func (rc *RouteCache) ageOf(rsp *response) age {
+	if rsp.isError() && rc.errorAge != nil {
+		return *rc.errorAge
+	}
+	return rc.age
+}
+
+// cacheable returns true if the fresh response should be cached.
+func (rc *RouteCache) cacheable(rsp *response) bool {
+	// failed responses should not replace the entry which would be served stale on error
+	if rc.age.staleIfError > 0 && rsp.failed() {
+		return false
+	}
+	return rc.policy.cacheableStatus(rsp, rc.errorAge != nil)
+}
+
+// ttl returns the time an entry needs to be kept in the cache for, including the time it might be served stale.
+func (a age) ttl() time.Duration {
+	stale := a.staleIfError
+	if a.staleWhileRevalidate > stale {
+		stale = a.staleWhileRevalidate
+	}
+	return time.Duration(a.max+stale) * time.Second
+}
+
+// ErrResponseTooLarge is returned when the handler writes a response larger than the max response size of the route cache.
+var ErrResponseTooLarge = errors.New("response exceeds the max response size of the route cache")
+
 // responseReadWriter is a Response writer able to Read the Payload.
+// The payload is buffered across all writes, so that handlers writing the response in chunks, e.g. streaming handlers
+// flushing every chunk, are captured as a whole.
 type responseReadWriter struct {
 	buffer     *bytes.Buffer
-	len        int
 	header     http.Header
 	statusCode int
+	// limit is the max size of the payload in bytes, zero if unlimited.
+	limit int
+	// size is the total size of the writes, including the ones exceeding the limit.
+	size int
 }
 
 // newResponseReadWriter creates a new responseReadWriter.
@@ -88,15 +173,15 @@ func (rw *responseReadWriter) Read(p []byte) (n int, err error) {
 	return rw.buffer.Read(p)
 }
 
-// ReadAll returns the Response Payload Bytes.
+// ReadAll returns the Response Payload Bytes, which have not been read yet.
+// It returns ErrResponseTooLarge if the payload has exceeded the limit.
 func (rw *responseReadWriter) ReadAll() ([]byte, error) {
-	if rw.len == 0 {
-		// nothing has been written
-		return []byte{}, nil
+	if rw.exceeded() {
+		return nil, ErrResponseTooLarge
 	}
-	b := make([]byte, rw.len)
-	_, err := rw.Read(b)
-	return b, err
+	b := make([]byte, rw.buffer.Len())
+	copy(b, rw.buffer.Next(len(b)))
+	return b, nil
 }
 
 // Header returns the Header object.
@@ -105,8 +190,14 @@ func (rw *responseReadWriter) Header() http.Header {
 }
 
 // Write writes the provied Bytes to the byte buffer.
+// Once the limit is exceeded, the buffered payload is discarded and the writes fail with ErrResponseTooLarge,
+// so that the handler can stop producing the response.
 func (rw *responseReadWriter) Write(p []byte) (int, error) {
-	rw.len = len(p)
+	rw.size += len(p)
+	if rw.exceeded() {
+		rw.buffer = new(bytes.Buffer)
+		return 0, ErrResponseTooLarge
+	}
 	return rw.buffer.Write(p)
 }
 
@@ -115,18 +206,55 @@ func (rw *responseReadWriter) WriteHeader(statusCode int) {
 	rw.statusCode = statusCode
 }
 
+// Flush implements http.Flusher for streaming handlers. The chunks are kept in the buffer,
+// since the response is served only after the handler has completed.
+func (rw *responseReadWriter) Flush() {}
+
+func (rw *responseReadWriter) exceeded() bool {
+	return rw.limit > 0 && rw.size > rw.limit
+}
+
 // Handler will wrap the handler func with the route cache abstraction.
+// Requests of methods, which are not cacheable according to the policy of the route cache, bypass it.
 func Handler(w http.ResponseWriter, r *http.Request, rc *RouteCache, httpHandler http.Handler) error {
+	if !rc.CacheableMethod(r.Method) {
+		httpHandler.ServeHTTP(w, r)
+		return nil
+	}
 	req := toCacheHandlerRequest(r)
-	response, err := handler(httpExecutor(w, r, func(writer http.ResponseWriter, request *http.Request) {
-		httpHandler.ServeHTTP(writer, request)
-	}), rc)(req)
+	req.vary = varyKey(r.Header, rc.vary)
+	if rc.keyFunc != nil {
+		req.key = rc.keyFunc(r)
+	}
+	variant, cacheable, err := methodVariant(r)
+	if err != nil {
+		return fmt.Errorf("could not handle request with the cache processor: %w", err)
+	}
+	if !cacheable {
+		httpHandler.ServeHTTP(w, r)
+		return nil
+	}
+	req.variant = variant
+	exec := httpExecutor(w, r, httpHandler.ServeHTTP, rc.maxResponseSize)
+	// background revalidations outlive the request, so they should not be canceled along with it
+	revalidate := httpExecutor(w, r.WithContext(detachedContext{parent: r.Context()}), httpHandler.ServeHTTP, rc.maxResponseSize)
+	response, err := handler(exec, revalidate, rc)(req)
 	if err != nil {
+		if errors.Is(err, ErrResponseTooLarge) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
 		return fmt.Errorf("could not handle request with the cache processor: %w", err)
 	}
 	for k, h := range response.Header {
 		w.Header().Set(k, h[0])
 	}
+	if response.StatusCode != 0 && response.StatusCode != http.StatusOK {
+		w.WriteHeader(response.StatusCode)
+	} else if isNotModified(r.Header, response.Header) {
+		// clients holding the same representation only need to be notified that it is still valid
+		w.WriteHeader(http.StatusNotModified)
+		return nil
+	}
 	if i, err := w.Write(response.Bytes); err != nil {
 		return fmt.Errorf("could not Write cache processor result into Response %d: %w", i, err)
 	}
@@ -135,10 +263,12 @@ func Handler(w http.ResponseWriter, r *http.Request, rc *RouteCache, httpHandler
 
 // httpExecutor is the function that will create a new response based on a HandlerFunc implementation
 // this wrapper adapts the http handler signature to the cache layer abstraction.
-func httpExecutor(_ http.ResponseWriter, request *http.Request, hnd http.HandlerFunc) executor {
+// The size of the response is capped by the provided limit in bytes, if it is positive.
+func httpExecutor(_ http.ResponseWriter, request *http.Request, hnd http.HandlerFunc, limit int) executor {
 	return func(now int64, key string) *response {
 		var err error
 		responseReadWriter := newResponseReadWriter()
+		responseReadWriter.limit = limit
 		hnd(responseReadWriter, request)
 		payload, err := responseReadWriter.ReadAll()
 		rw := *responseReadWriter
@@ -149,10 +279,32 @@ func httpExecutor(_ http.ResponseWriter, request *http.Request, hnd http.Handler
 					// cache also the headers generated by the handler
 					Header: rw.Header(),
 				},
-				LastValid: now,
-				Etag:      generateETag([]byte(key), time.Now().Nanosecond()),
+				LastValid:  now,
+				Etag:       generateETag([]byte(key), time.Now().Nanosecond()),
+				StatusCode: rw.statusCode,
 			}
 		}
 		return &response{Err: err}
 	}
 }
+
+// detachedContext keeps the values of the parent context, but not its cancellation and deadline.
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (detachedContext) Err() error {
+	return nil
+}
+
+func (c detachedContext) Value(key interface{}) interface{} {
+	return c.parent.Value(key)
+}
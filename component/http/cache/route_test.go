@@ -1,9 +1,18 @@
 package cache
 
 import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestResponseReadWriter_Header(t *testing.T) {
@@ -54,3 +63,474 @@ func TestResponseReadWriter_ReadAllEmpty(t *testing.T) {
 	assert.Equal(t, 0, len(b))
 	assert.Equal(t, "", string(b))
 }
+
+func TestResponseReadWriter_MultipleWrites(t *testing.T) {
+	rw := newResponseReadWriter()
+	for _, chunk := range []string{"first,", "second,", "third"} {
+		_, err := rw.Write([]byte(chunk))
+		assert.NoError(t, err)
+		rw.Flush()
+	}
+
+	b, err := rw.ReadAll()
+	assert.NoError(t, err)
+	assert.Equal(t, "first,second,third", string(b))
+
+	// the payload has been read
+	b, err = rw.ReadAll()
+	assert.NoError(t, err)
+	assert.Empty(t, b)
+}
+
+func TestResponseReadWriter_Limit(t *testing.T) {
+	rw := newResponseReadWriter()
+	rw.limit = 5
+	i, err := rw.Write([]byte("abc"))
+	assert.NoError(t, err)
+	assert.Equal(t, 3, i)
+	i, err = rw.Write([]byte("de"))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, i)
+
+	i, err = rw.Write([]byte("f"))
+	assert.Equal(t, ErrResponseTooLarge, err)
+	assert.Equal(t, 0, i)
+	assert.Equal(t, 0, rw.buffer.Len())
+
+	b, err := rw.ReadAll()
+	assert.Equal(t, ErrResponseTooLarge, err)
+	assert.Nil(t, b)
+}
+
+func TestNewRouteCache_StaleDurations(t *testing.T) {
+	rc, errs := NewRouteCache(newTestingCache(), Age{Max: 10 * time.Second, StaleWhileRevalidate: -time.Second})
+	assert.NotNil(t, rc)
+	assert.Len(t, errs, 1)
+	assert.EqualError(t, errs[0], "stale durations must not be negative")
+
+	rc, errs = NewRouteCache(newTestingCache(), Age{Max: 10 * time.Second, StaleWhileRevalidate: 5 * time.Second, StaleIfError: 20 * time.Second})
+	assert.Empty(t, errs)
+	assert.Equal(t, 30*time.Second, rc.age.ttl())
+}
+
+func TestHTTPExecutor_StatusCode(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	exec := httpExecutor(nil, req, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte("unavailable"))
+	}, 0)
+
+	rsp := exec(1, "key")
+	assert.Equal(t, http.StatusServiceUnavailable, rsp.StatusCode)
+	assert.True(t, rsp.failed())
+}
+
+func TestDetachedContext(t *testing.T) {
+	type key struct{}
+	ctx, cnl := context.WithTimeout(context.WithValue(context.Background(), key{}, "value"), time.Millisecond)
+	cnl()
+
+	detached := detachedContext{parent: ctx}
+	assert.Error(t, ctx.Err())
+	assert.NoError(t, detached.Err())
+	assert.Nil(t, detached.Done())
+	_, ok := detached.Deadline()
+	assert.False(t, ok)
+	assert.Equal(t, "value", detached.Value(key{}))
+}
+
+func TestNewRouteCache_Vary(t *testing.T) {
+	rc, errs := NewRouteCache(newTestingCache(), Age{Max: 10 * time.Second}, Vary("accept", "Authorization"), Vary("Accept-Encoding", "ACCEPT"))
+	assert.Empty(t, errs)
+	assert.Equal(t, []string{"Accept", "Accept-Encoding", "Authorization"}, rc.vary)
+
+	_, errs = NewRouteCache(newTestingCache(), Age{Max: 10 * time.Second}, Vary())
+	assert.Len(t, errs, 1)
+	assert.EqualError(t, errs[0], "vary headers are empty")
+
+	_, errs = NewRouteCache(newTestingCache(), Age{Max: 10 * time.Second}, Vary("Accept", ""))
+	assert.Len(t, errs, 1)
+	assert.EqualError(t, errs[0], "vary header is empty")
+}
+
+func TestHandler_Vary(t *testing.T) {
+	monitor = &testMetrics{}
+	NowSeconds = func() int64 { return 1 }
+	c := newTestingCache()
+	c.instant = NowSeconds
+	rc, errs := NewRouteCache(c, Age{Max: 10 * time.Second}, Vary("Accept", "Authorization"))
+	assert.Empty(t, errs)
+
+	calls := 0
+	hnd := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		_, _ = w.Write([]byte(r.Header.Get("Accept") + r.Header.Get("Authorization")))
+	})
+
+	serve := func(accept, authorization string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/?a=1", nil)
+		req.Header.Set("Accept", accept)
+		req.Header.Set("Authorization", authorization)
+		rsp := httptest.NewRecorder()
+		assert.NoError(t, Handler(rsp, req, rc, hnd))
+		return rsp
+	}
+
+	rsp := serve("application/json", "Bearer a")
+	assert.Equal(t, "application/jsonBearer a", rsp.Body.String())
+	assert.Equal(t, "Accept, Authorization", rsp.Header().Get(headerVary))
+
+	assert.Equal(t, "application/xmlBearer a", serve("application/xml", "Bearer a").Body.String())
+	assert.Equal(t, "application/jsonBearer b", serve("application/json", "Bearer b").Body.String())
+	assert.Equal(t, 3, calls)
+
+	// the same representation is served from the cache
+	assert.Equal(t, "application/jsonBearer a", serve("application/json", "Bearer a").Body.String())
+	assert.Equal(t, 3, calls)
+}
+
+func TestHandler_ConditionalRequests(t *testing.T) {
+	monitor = &testMetrics{}
+	NowSeconds = func() int64 { return 100 }
+	c := newTestingCache()
+	c.instant = NowSeconds
+	rc, errs := NewRouteCache(c, Age{Max: 10 * time.Second})
+	assert.Empty(t, errs)
+
+	hnd := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("body"))
+	})
+
+	serve := func(header map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		propagateHeaders(header, req.Header)
+		rsp := httptest.NewRecorder()
+		assert.NoError(t, Handler(rsp, req, rc, hnd))
+		return rsp
+	}
+
+	rsp := serve(nil)
+	assert.Equal(t, http.StatusOK, rsp.Code)
+	assert.Equal(t, "body", rsp.Body.String())
+	etag := rsp.Header().Get(HeaderETagHeader)
+	assert.NotEmpty(t, etag)
+	assert.Equal(t, lastModified(100), rsp.Header().Get(HeaderLastModified))
+
+	rsp = serve(map[string]string{headerIfNoneMatch: `"` + etag + `"`})
+	assert.Equal(t, http.StatusNotModified, rsp.Code)
+	assert.Empty(t, rsp.Body.String())
+	assert.Equal(t, etag, rsp.Header().Get(HeaderETagHeader))
+
+	rsp = serve(map[string]string{headerIfModifiedSince: lastModified(100)})
+	assert.Equal(t, http.StatusNotModified, rsp.Code)
+	assert.Empty(t, rsp.Body.String())
+
+	rsp = serve(map[string]string{headerIfNoneMatch: "other"})
+	assert.Equal(t, http.StatusOK, rsp.Code)
+	assert.Equal(t, "body", rsp.Body.String())
+}
+
+func TestNewRouteCache_NegativeCaching(t *testing.T) {
+	tests := map[string]struct {
+		age         Age
+		expectedErr string
+	}{
+		"success":     {age: Age{Max: 2 * time.Second}},
+		"missing max": {age: Age{Max: 500 * time.Millisecond}, expectedErr: "max age of error responses should be at least one second"},
+		"invalid min": {age: Age{Min: 3 * time.Second, Max: 2 * time.Second}, expectedErr: "max age of error responses must always be greater than min age"},
+		"stale":       {age: Age{Max: 2 * time.Second, StaleIfError: time.Second}, expectedErr: "error responses cannot be served stale"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			rc, errs := NewRouteCache(newTestingCache(), Age{Max: 10 * time.Second}, NegativeCaching(tt.age))
+			if tt.expectedErr != "" {
+				assert.Len(t, errs, 1)
+				assert.EqualError(t, errs[0], tt.expectedErr)
+			} else {
+				assert.Empty(t, errs)
+				assert.Equal(t, int64(2), rc.errorAge.max)
+			}
+		})
+	}
+}
+
+func TestHandler_NegativeCaching(t *testing.T) {
+	monitor = &testMetrics{}
+	now := int64(1)
+	NowSeconds = func() int64 { return now }
+
+	calls := 0
+	hnd := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte("not found"))
+	})
+
+	serve := func(rc *RouteCache) *httptest.ResponseRecorder {
+		rsp := httptest.NewRecorder()
+		assert.NoError(t, Handler(rsp, httptest.NewRequest(http.MethodGet, "/", nil), rc, hnd))
+		assert.Equal(t, http.StatusNotFound, rsp.Code)
+		assert.Equal(t, "not found", rsp.Body.String())
+		return rsp
+	}
+
+	t.Run("error responses are not cached by default", func(t *testing.T) {
+		calls = 0
+		c := newTestingCache()
+		c.instant = NowSeconds
+		rc, errs := NewRouteCache(c, Age{Max: 10 * time.Second})
+		assert.Empty(t, errs)
+
+		serve(rc)
+		serve(rc)
+		assert.Equal(t, 2, calls)
+		assert.Empty(t, c.cache)
+	})
+
+	t.Run("error responses are cached with their own age", func(t *testing.T) {
+		calls = 0
+		now = 1
+		c := newTestingCache()
+		c.instant = NowSeconds
+		rc, errs := NewRouteCache(c, Age{Max: 10 * time.Second}, NegativeCaching(Age{Max: 2 * time.Second}))
+		assert.Empty(t, errs)
+
+		rsp := serve(rc)
+		assert.Equal(t, "max-age=2", rsp.Header().Get(HeaderCacheControl))
+		now = 2
+		rsp = serve(rc)
+		assert.Equal(t, "max-age=1", rsp.Header().Get(HeaderCacheControl))
+		assert.Equal(t, 1, calls)
+
+		now = 4
+		serve(rc)
+		assert.Equal(t, 2, calls)
+	})
+}
+
+func TestNewRouteCache_Directives(t *testing.T) {
+	rc, errs := NewRouteCache(newTestingCache(), Age{Max: 10 * time.Second}, Public(), SharedMaxAge(time.Minute), NoTransform(), MustRevalidate())
+	assert.Empty(t, errs)
+	assert.Equal(t, directives{visibility: controlPublic, sharedMaxAge: 60, noTransform: true, mustRevalidate: true}, rc.directives)
+
+	_, errs = NewRouteCache(newTestingCache(), Age{Max: 10 * time.Second}, Public(), Private())
+	assert.Len(t, errs, 1)
+	assert.EqualError(t, errs[0], "public and private directives are mutually exclusive")
+
+	_, errs = NewRouteCache(newTestingCache(), Age{Max: 10 * time.Second}, SharedMaxAge(time.Millisecond))
+	assert.Len(t, errs, 1)
+	assert.EqualError(t, errs[0], "shared max age should be at least one second")
+}
+
+func TestHandler_Directives(t *testing.T) {
+	monitor = &testMetrics{}
+	now := int64(1)
+	NowSeconds = func() int64 { return now }
+
+	status := http.StatusOK
+	hnd := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	})
+
+	serve := func(rc *RouteCache) string {
+		rsp := httptest.NewRecorder()
+		assert.NoError(t, Handler(rsp, httptest.NewRequest(http.MethodGet, "/", nil), rc, hnd))
+		return rsp.Header().Get(HeaderCacheControl)
+	}
+
+	c := newTestingCache()
+	c.instant = NowSeconds
+	rc, errs := NewRouteCache(c, Age{Max: 10 * time.Second}, Private(), SharedMaxAge(30*time.Second), NoTransform(), MustRevalidate())
+	assert.Empty(t, errs)
+
+	assert.Equal(t, "max-age=10, private, s-maxage=30, no-transform, must-revalidate", serve(rc))
+	now = 5
+	assert.Equal(t, "max-age=6, private, s-maxage=26, no-transform, must-revalidate", serve(rc))
+
+	t.Run("error responses", func(t *testing.T) {
+		now = 1
+		status = http.StatusInternalServerError
+		c := newTestingCache()
+		c.instant = NowSeconds
+		rc, errs := NewRouteCache(c, Age{Max: 10 * time.Second}, Public(), SharedMaxAge(30*time.Second), NegativeCaching(Age{Max: 2 * time.Second}))
+		assert.Empty(t, errs)
+
+		assert.Equal(t, "max-age=2, public", serve(rc))
+	})
+}
+
+func TestDirectives_Header(t *testing.T) {
+	d := directives{sharedMaxAge: 10, mustRevalidate: true}
+	assert.Equal(t, ", s-maxage=0", d.header(20, &response{}, true))
+	assert.Equal(t, ", s-maxage=5, must-revalidate", d.header(5, &response{}, false))
+}
+
+func TestHandler_Key(t *testing.T) {
+	monitor = &testMetrics{}
+	NowSeconds = func() int64 { return 1 }
+	c := newTestingCache()
+	c.instant = NowSeconds
+	keyFunc := func(r *http.Request) string {
+		tenant := r.Header.Get("X-Tenant")
+		if tenant == "" {
+			return ""
+		}
+		return tenant + ":" + NormalizedQueryKey("utm_source")(r)
+	}
+	rc, errs := NewRouteCache(c, Age{Max: 10 * time.Second}, Key(keyFunc))
+	assert.Empty(t, errs)
+
+	calls := 0
+	hnd := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		_, _ = w.Write([]byte(r.Header.Get("X-Tenant")))
+	})
+
+	serve := func(target, tenant string) string {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.Header.Set("X-Tenant", tenant)
+		rsp := httptest.NewRecorder()
+		assert.NoError(t, Handler(rsp, req, rc, hnd))
+		return rsp.Body.String()
+	}
+
+	assert.Equal(t, "a", serve("/users?b=2&a=1", "a"))
+	assert.Equal(t, "a", serve("/users?a=1&b=2&utm_source=x", "a"))
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, "b", serve("/users?a=1&b=2", "b"))
+	assert.Equal(t, 2, calls)
+
+	// the default key is used, if the key func returns an empty key
+	assert.Equal(t, "", serve("/users?b=2&a=1", ""))
+	assert.Equal(t, 3, calls)
+	assert.Contains(t, c.cache, "a:/users:a=1&b=2")
+	assert.Contains(t, c.cache, "/users:b=2&a=1")
+
+	_, errs = NewRouteCache(c, Age{Max: 10 * time.Second}, Key(nil))
+	assert.Len(t, errs, 1)
+	assert.EqualError(t, errs[0], "key func is nil")
+}
+
+func TestNewRouteCache_Storage(t *testing.T) {
+	rc, errs := NewRouteCache(newTestingCache(), Age{Max: 10 * time.Second}, MaxBodySize(1024), MaxResponseSize(4096), Compression(gzip.BestSpeed))
+	assert.Empty(t, errs)
+	assert.Equal(t, 1024, rc.maxBodySize)
+	assert.Equal(t, 4096, rc.maxResponseSize)
+	assert.Equal(t, &compressor{encoding: gzipEncoding, level: gzip.BestSpeed}, rc.compressor)
+
+	rc, errs = NewRouteCache(newTestingCache(), Age{Max: 10 * time.Second}, ZstdCompression(3))
+	assert.Empty(t, errs)
+	assert.Equal(t, &compressor{encoding: zstdEncoding, level: 3}, rc.compressor)
+
+	rc, errs = NewRouteCache(newTestingCache(), Age{Max: 10 * time.Second}, BrotliCompression(5))
+	assert.Empty(t, errs)
+	assert.Equal(t, &compressor{encoding: brotliEncoding, level: 5}, rc.compressor)
+
+	_, errs = NewRouteCache(newTestingCache(), Age{Max: 10 * time.Second}, MaxBodySize(0), MaxResponseSize(-1), Compression(10), ZstdCompression(23),
+		BrotliCompression(12))
+	assert.Len(t, errs, 5)
+	assert.EqualError(t, errs[0], "max body size should be positive")
+	assert.EqualError(t, errs[1], "max response size should be positive")
+	assert.EqualError(t, errs[2], "invalid compression level: 10")
+	assert.EqualError(t, errs[3], "invalid zstd compression level: 23")
+	assert.EqualError(t, errs[4], "invalid brotli compression level: 12")
+}
+
+func TestHandler_Storage(t *testing.T) {
+	NowSeconds = func() int64 { return 1 }
+
+	hnd := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(strings.Repeat("a", len(r.URL.Path))))
+	})
+
+	serve := func(rc *RouteCache, path string) {
+		rsp := httptest.NewRecorder()
+		assert.NoError(t, Handler(rsp, httptest.NewRequest(http.MethodGet, path, nil), rc, hnd))
+		assert.Equal(t, strings.Repeat("a", len(path)), rsp.Body.String())
+	}
+
+	t.Run("max body size", func(t *testing.T) {
+		metrics := &testMetrics{}
+		monitor = metrics
+		c := newTestingCache()
+		c.instant = NowSeconds
+		rc, errs := NewRouteCache(c, Age{Max: 10 * time.Second}, MaxBodySize(3))
+		assert.Empty(t, errs)
+
+		serve(rc, "/ab")
+		serve(rc, "/abc")
+		assert.Contains(t, c.cache, "/ab:")
+		assert.NotContains(t, c.cache, "/abc:")
+		assert.Equal(t, 1, metrics.values["/abc"].skips)
+	})
+
+	for name, opt := range map[string]OptionFunc{
+		"gzip compression": Compression(gzip.DefaultCompression), "zstd compression": ZstdCompression(3), "brotli compression": BrotliCompression(5),
+	} {
+		opt := opt
+		t.Run(name, func(t *testing.T) {
+			monitor = &testMetrics{}
+			c := newTestingCache()
+			c.instant = NowSeconds
+			rc, errs := NewRouteCache(c, Age{Max: 10 * time.Second}, opt)
+			assert.Empty(t, errs)
+
+			serve(rc, "/abc")
+			stored, ok, err := c.Get("/abc:")
+			require.NoError(t, err)
+			require.True(t, ok)
+			assert.True(t, isCompressed(stored.([]byte)))
+			assert.Equal(t, 1, monitor.(*testMetrics).values["/abc"].compressions)
+
+			// served from the cache
+			serve(rc, "/abc")
+			assert.Equal(t, 1, monitor.(*testMetrics).values["/abc"].hits)
+		})
+	}
+}
+
+func TestHandler_Streaming(t *testing.T) {
+	NowSeconds = func() int64 { return 1 }
+
+	hnd := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		require.True(t, ok)
+		for i := 0; i < 3; i++ {
+			if _, err := w.Write([]byte(fmt.Sprintf("chunk-%d;", i))); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	})
+
+	t.Run("cached as a whole", func(t *testing.T) {
+		monitor = &testMetrics{}
+		c := newTestingCache()
+		c.instant = NowSeconds
+		rc, errs := NewRouteCache(c, Age{Max: 10 * time.Second})
+		assert.Empty(t, errs)
+
+		for i := 0; i < 2; i++ {
+			rsp := httptest.NewRecorder()
+			assert.NoError(t, Handler(rsp, httptest.NewRequest(http.MethodGet, "/stream", nil), rc, hnd))
+			assert.Equal(t, "chunk-0;chunk-1;chunk-2;", rsp.Body.String())
+		}
+		assert.Equal(t, 1, monitor.(*testMetrics).values["/stream"].hits)
+	})
+
+	t.Run("max response size", func(t *testing.T) {
+		monitor = &testMetrics{}
+		c := newTestingCache()
+		c.instant = NowSeconds
+		rc, errs := NewRouteCache(c, Age{Max: 10 * time.Second}, MaxResponseSize(10))
+		assert.Empty(t, errs)
+
+		rsp := httptest.NewRecorder()
+		err := Handler(rsp, httptest.NewRequest(http.MethodGet, "/stream", nil), rc, hnd)
+		assert.True(t, errors.Is(err, ErrResponseTooLarge))
+		assert.Equal(t, http.StatusInternalServerError, rsp.Code)
+		assert.Empty(t, rsp.Body.String())
+		assert.NotContains(t, c.cache, "/stream:")
+	})
+}
@@ -304,7 +304,7 @@ func (cb *Builder) Create() (*Component, error) {
 	}
 
 	routes, err := cb.routesBuilder.Append(aliveCheckRoute(cb.ac)).Append(readyCheckRoute(cb.rc)).
-		Append(metricRoute()).Build()
+		Append(metricRoute()).Append(diagnosticsRoute()).Append(dependenciesRoute()).Build()
 	if err != nil {
 		return nil, err
 	}
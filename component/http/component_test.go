@@ -31,7 +31,7 @@ func TestComponent_ListenAndServe_DefaultRoutes_Shutdown(t *testing.T) {
 		done <- true
 	}()
 	time.Sleep(100 * time.Millisecond)
-	assert.Len(t, s.routes, 15)
+	assert.Len(t, s.routes, 17)
 	cnl()
 	assert.True(t, <-done)
 }
@@ -47,7 +47,7 @@ func TestComponent_ListenAndServeTLS_DefaultRoutes_Shutdown(t *testing.T) {
 		done <- true
 	}()
 	time.Sleep(100 * time.Millisecond)
-	assert.Len(t, s.routes, 15)
+	assert.Len(t, s.routes, 17)
 	cnl()
 	assert.True(t, <-done)
 }
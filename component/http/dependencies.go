@@ -0,0 +1,10 @@
+package http
+
+import "github.com/beatlabs/patron/dependency"
+
+// DependenciesPath definition.
+const DependenciesPath = "/debug/dependencies"
+
+func dependenciesRoute() *RouteBuilder {
+	return NewRawRouteBuilder(DependenciesPath, dependency.Handler).MethodGet()
+}
@@ -0,0 +1,16 @@
+package http
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_dependenciesRoute(t *testing.T) {
+	route, err := dependenciesRoute().Build()
+	assert.NoError(t, err)
+	assert.Equal(t, http.MethodGet, route.method)
+	assert.Equal(t, "/debug/dependencies", route.path)
+	assert.NotNil(t, route.handler)
+}
@@ -0,0 +1,10 @@
+package http
+
+import "github.com/beatlabs/patron/internal/diagnostics"
+
+// DiagnosticsPath definition.
+const DiagnosticsPath = "/debug/diagnostics"
+
+func diagnosticsRoute() *RouteBuilder {
+	return NewRawRouteBuilder(DiagnosticsPath, diagnostics.Handler).MethodGet()
+}
@@ -0,0 +1,16 @@
+package http
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_diagnosticsRoute(t *testing.T) {
+	route, err := diagnosticsRoute().Build()
+	assert.NoError(t, err)
+	assert.Equal(t, http.MethodGet, route.method)
+	assert.Equal(t, "/debug/diagnostics", route.path)
+	assert.NotNil(t, route.handler)
+}
@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"errors"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/beatlabs/patron/trace"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	primaryVariant   = "primary"
+	alternateVariant = "alternate"
+)
+
+var (
+	canaryInit          sync.Once
+	canaryVariantMetric *prometheus.CounterVec
+)
+
+func initCanaryMetrics() {
+	canaryVariantMetric = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "component",
+			Subsystem: "http",
+			Name:      "canary_requests_total",
+			Help:      "Total number of HTTP requests routed by the canary middleware, classified by variant.",
+		},
+		[]string{"path", "variant"},
+	)
+	prometheus.MustRegister(canaryVariantMetric)
+}
+
+// CanaryConfig configures which requests are routed to the alternate handler.
+type CanaryConfig struct {
+	// Percentage of requests, from 0 to 100, routed to the alternate handler.
+	Percentage float64
+	// Header which, when set to true, routes the request to the alternate handler and, when set to false, to the primary one.
+	Header string
+	// Cookie which, when set to true, routes the request to the alternate handler and, when set to false, to the primary one.
+	Cookie string
+}
+
+// NewCanary creates a Func that routes requests either to the next (primary) handler or to the alternate one,
+// in order to support in-process canarying of new logic on the same route.
+// Requests are routed based on the configured header or cookie, if present, or else based on the configured percentage.
+// The number of requests per variant is exposed via Prometheus.
+func NewCanary(path string, alternate http.Handler, cfg CanaryConfig) (Func, error) {
+	if alternate == nil {
+		return nil, errors.New("alternate handler is nil")
+	}
+	if cfg.Percentage < 0 || cfg.Percentage > 100 {
+		return nil, errors.New("percentage should be between 0 and 100")
+	}
+
+	canaryInit.Do(initCanaryMetrics)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			variant := primaryVariant
+			h := next
+			if cfg.useAlternate(r) {
+				variant = alternateVariant
+				h = alternate
+			}
+
+			counter := trace.Counter{Counter: canaryVariantMetric.WithLabelValues(path, variant)}
+			counter.Inc(r.Context())
+
+			h.ServeHTTP(w, r)
+		})
+	}, nil
+}
+
+func (cfg CanaryConfig) useAlternate(r *http.Request) bool {
+	if cfg.Header != "" {
+		if alternate, err := strconv.ParseBool(r.Header.Get(cfg.Header)); err == nil {
+			return alternate
+		}
+	}
+
+	if cfg.Cookie != "" {
+		if c, err := r.Cookie(cfg.Cookie); err == nil {
+			if alternate, err := strconv.ParseBool(c.Value); err == nil {
+				return alternate
+			}
+		}
+	}
+
+	return cfg.Percentage > 0 && rand.Float64()*100 < cfg.Percentage // nolint:gosec
+}
@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewCanary(t *testing.T) {
+	alternate := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	type args struct {
+		alternate http.Handler
+		cfg       CanaryConfig
+	}
+	tests := map[string]struct {
+		args        args
+		expectedErr string
+	}{
+		"success":                    {args: args{alternate: alternate, cfg: CanaryConfig{Percentage: 10}}},
+		"missing alternate":          {args: args{cfg: CanaryConfig{Percentage: 10}}, expectedErr: "alternate handler is nil"},
+		"negative percentage":        {args: args{alternate: alternate, cfg: CanaryConfig{Percentage: -1}}, expectedErr: "percentage should be between 0 and 100"},
+		"percentage larger than 100": {args: args{alternate: alternate, cfg: CanaryConfig{Percentage: 101}}, expectedErr: "percentage should be between 0 and 100"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			got, err := NewCanary("/", tt.args.alternate, tt.args.cfg)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, got)
+			}
+		})
+	}
+}
+
+func TestCanary(t *testing.T) {
+	primary := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) })
+	alternate := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusAccepted) })
+
+	tests := map[string]struct {
+		cfg          CanaryConfig
+		header       string
+		cookie       string
+		expectedCode int
+	}{
+		"no percentage":                       {cfg: CanaryConfig{}, expectedCode: http.StatusOK},
+		"full percentage":                     {cfg: CanaryConfig{Percentage: 100}, expectedCode: http.StatusAccepted},
+		"header routes to alternate":          {cfg: CanaryConfig{Header: "X-Canary"}, header: "true", expectedCode: http.StatusAccepted},
+		"header routes to primary":            {cfg: CanaryConfig{Header: "X-Canary", Percentage: 100}, header: "false", expectedCode: http.StatusOK},
+		"invalid header uses percentage":      {cfg: CanaryConfig{Header: "X-Canary", Percentage: 100}, header: "maybe", expectedCode: http.StatusAccepted},
+		"cookie routes to alternate":          {cfg: CanaryConfig{Cookie: "canary"}, cookie: "1", expectedCode: http.StatusAccepted},
+		"cookie routes to primary":            {cfg: CanaryConfig{Cookie: "canary", Percentage: 100}, cookie: "0", expectedCode: http.StatusOK},
+		"header takes precedence over cookie": {cfg: CanaryConfig{Header: "X-Canary", Cookie: "canary"}, header: "false", cookie: "true", expectedCode: http.StatusOK},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			mw, err := NewCanary("/canary", alternate, tt.cfg)
+			require.NoError(t, err)
+			canaryVariantMetric.Reset()
+
+			req := httptest.NewRequest(http.MethodGet, "/canary", nil)
+			if tt.header != "" {
+				req.Header.Set("X-Canary", tt.header)
+			}
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: "canary", Value: tt.cookie})
+			}
+			rc := httptest.NewRecorder()
+			mw(primary).ServeHTTP(rc, req)
+
+			assert.Equal(t, tt.expectedCode, rc.Code)
+			variant := primaryVariant
+			if tt.expectedCode == http.StatusAccepted {
+				variant = alternateVariant
+			}
+			assert.Equal(t, 1.0, testutil.ToFloat64(canaryVariantMetric.WithLabelValues("/canary", variant)))
+		})
+	}
+}
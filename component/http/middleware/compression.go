@@ -0,0 +1,417 @@
+package middleware
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/beatlabs/patron/encoding"
+	"github.com/beatlabs/patron/log"
+	"github.com/klauspost/compress/zstd"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	compressionInit           sync.Once
+	compressedResponsesMetric *prometheus.CounterVec
+	compressionBytesMetric    *prometheus.CounterVec
+)
+
+func initCompressionMetrics() {
+	compressedResponsesMetric = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "component",
+			Subsystem: "http",
+			Name:      "compressed_responses_total",
+			Help:      "Total number of HTTP responses compressed by the response compression middleware, classified by encoding.",
+		},
+		[]string{"encoding"},
+	)
+	compressionBytesMetric = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "component",
+			Subsystem: "http",
+			Name:      "compression_bytes_total",
+			Help:      "Bytes of the HTTP responses compressed by the response compression middleware, classified by encoding and stage i.e. uncompressed or compressed.",
+		},
+		[]string{"encoding", "stage"},
+	)
+	prometheus.MustRegister(compressedResponsesMetric, compressionBytesMetric)
+}
+
+// DefaultCompressibleTypes are the content types compressed when no content types are configured.
+var DefaultCompressibleTypes = []string{
+	"text/*",
+	"application/json",
+	"application/problem+json",
+	"application/javascript",
+	"application/xml",
+	"image/svg+xml",
+}
+
+// EncoderFunc creates a writer, which compresses the data written to it into w.
+type EncoderFunc func(w io.Writer) (io.WriteCloser, error)
+
+// CompressionConfig configures the response compression.
+type CompressionConfig struct {
+	// Level of the gzip and deflate compression, between flate.HuffmanOnly and flate.BestCompression.
+	// Zero stands for flate.DefaultCompression. The zstd and brotli compressions use their fastest level for flate.BestSpeed and below,
+	// their best level for flate.BestCompression and their default level otherwise.
+	Level int
+	// MinSize in bytes of the responses to be compressed, since compressing small responses does not pay off.
+	// Responses flushed by the handler before reaching it are compressed regardless.
+	MinSize int
+	// ContentTypes which are compressed, e.g. `application/json`, or `text/*` for all text types.
+	// Defaults to DefaultCompressibleTypes.
+	ContentTypes []string
+	// Encoders for content codings on top of zstd, brotli, gzip and deflate, keyed by the name of the coding.
+	// They are preferred over the built-in ones, when accepted equally by the client, and replace the built-in ones of the same name.
+	Encoders map[string]EncoderFunc
+}
+
+type compression struct {
+	minSize      int
+	contentTypes []string
+	encoders     map[string]EncoderFunc
+	// preference holds the names of the encoders, in the order they are selected when accepted equally
+	preference []string
+}
+
+// NewResponseCompression creates a Func which compresses the responses of the next handler with the encoding negotiated
+// via the Accept-Encoding header, among zstd, brotli, gzip, deflate and the configured encoders, if their content type is allowed
+// and their size exceeds the minimum. Clients accepting none of them are served uncompressed responses,
+// unlike NewCompression, which makes it suitable to be applied on specific routes.
+// Responses already encoded by the handler, as well as responses to HEAD requests, are left intact.
+// The compressed responses and their bytes before and after the compression are measured per encoding.
+func NewResponseCompression(cfg CompressionConfig) (Func, error) {
+	cmp, err := newCompression(cfg)
+	if err != nil {
+		return nil, err
+	}
+	compressionInit.Do(initCompressionMetrics)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			cw := &compressionResponseWriter{
+				writer:   w,
+				cmp:      cmp,
+				encoding: cmp.negotiate(r.Header.Get(encoding.AcceptEncodingHeader)),
+			}
+			next.ServeHTTP(cw, r)
+			if err := cw.Close(); err != nil {
+				log.FromContext(r.Context()).Errorf("failed to complete compressed response: %v", err)
+			}
+		})
+	}, nil
+}
+
+func newCompression(cfg CompressionConfig) (*compression, error) {
+	if cfg.MinSize < 0 {
+		return nil, errors.New("min size should not be negative")
+	}
+	level := cfg.Level
+	if level == 0 {
+		level = flate.DefaultCompression
+	}
+	if level < flate.HuffmanOnly || level > flate.BestCompression {
+		return nil, fmt.Errorf("invalid compression level: %d", cfg.Level)
+	}
+
+	contentTypes := cfg.ContentTypes
+	if len(contentTypes) == 0 {
+		contentTypes = DefaultCompressibleTypes
+	}
+	cmp := &compression{
+		minSize:      cfg.MinSize,
+		contentTypes: make([]string, 0, len(contentTypes)),
+		encoders: map[string]EncoderFunc{
+			gzipHeader: func(w io.Writer) (io.WriteCloser, error) {
+				return gzip.NewWriterLevel(w, level)
+			},
+			deflateHeader: func(w io.Writer) (io.WriteCloser, error) {
+				return flate.NewWriter(w, level)
+			},
+			zstdHeader: func(w io.Writer) (io.WriteCloser, error) {
+				return zstd.NewWriter(w, zstd.WithEncoderLevel(zstdLevel(level)), zstd.WithEncoderConcurrency(1))
+			},
+			brotliHeader: func(w io.Writer) (io.WriteCloser, error) {
+				return brotli.NewWriterLevel(w, brotliLevel(level)), nil
+			},
+		},
+	}
+	for _, ct := range contentTypes {
+		ct = strings.ToLower(strings.TrimSpace(ct))
+		if ct == "" {
+			return nil, errors.New("content type is empty")
+		}
+		cmp.contentTypes = append(cmp.contentTypes, ct)
+	}
+
+	custom := make([]string, 0, len(cfg.Encoders))
+	for name, fn := range cfg.Encoders {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" || name == identityHeader || name == anythingHeader {
+			return nil, fmt.Errorf("invalid encoder name: %q", name)
+		}
+		if fn == nil {
+			return nil, fmt.Errorf("encoder of %s is nil", name)
+		}
+		if _, ok := cmp.encoders[name]; !ok {
+			custom = append(custom, name)
+		}
+		cmp.encoders[name] = fn
+	}
+	sort.Strings(custom)
+	cmp.preference = append(custom, zstdHeader, brotliHeader, gzipHeader, deflateHeader)
+
+	return cmp, nil
+}
+
+// zstdLevel maps a flate compression level to the closest zstd encoder level.
+func zstdLevel(level int) zstd.EncoderLevel {
+	switch {
+	case level == flate.DefaultCompression:
+		return zstd.SpeedDefault
+	case level <= flate.BestSpeed:
+		return zstd.SpeedFastest
+	case level == flate.BestCompression:
+		return zstd.SpeedBestCompression
+	default:
+		return zstd.SpeedDefault
+	}
+}
+
+// brotliLevel maps a flate compression level to the closest brotli level.
+func brotliLevel(level int) int {
+	switch {
+	case level == flate.DefaultCompression:
+		return brotli.DefaultCompression
+	case level <= flate.BestSpeed:
+		return brotli.BestSpeed
+	case level == flate.BestCompression:
+		return brotli.BestCompression
+	default:
+		return brotli.DefaultCompression
+	}
+}
+
+// negotiate returns the encoding with the highest weight in the Accept-Encoding header, or an empty one if none is acceptable.
+func (c *compression) negotiate(header string) string {
+	if header == "" {
+		return ""
+	}
+
+	weights := make(map[string]float64)
+	for _, a := range strings.Split(header, ",") {
+		algAndWeight := strings.Split(a, ";")
+		algorithm := strings.ToLower(strings.TrimSpace(algAndWeight[0]))
+		weight := 1.0
+		if len(algAndWeight) == 2 {
+			weight = parseWeight(algAndWeight[1])
+		}
+		weights[algorithm] = weight
+	}
+
+	selected, selectedWeight := "", 0.0
+	for _, name := range c.preference {
+		weight, ok := weights[name]
+		if !ok {
+			weight = weights[anythingHeader]
+		}
+		if weight > selectedWeight {
+			selected, selectedWeight = name, weight
+		}
+	}
+	return selected
+}
+
+func (c *compression) compressible(contentType string) bool {
+	mediaType := strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	if mediaType == "" {
+		return false
+	}
+	for _, ct := range c.contentTypes {
+		if strings.HasSuffix(ct, "/*") && strings.HasPrefix(mediaType, ct[:len(ct)-1]) {
+			return true
+		}
+		if ct == mediaType {
+			return true
+		}
+	}
+	return false
+}
+
+// compressionResponseWriter buffers the response up to the min size, before deciding whether to compress it.
+type compressionResponseWriter struct {
+	writer   http.ResponseWriter
+	cmp      *compression
+	encoding string
+	status   int
+	body     bytes.Buffer
+	decided  bool
+	encoder  io.WriteCloser
+	// written counts the bytes written to the encoder, while compressed counts the bytes written by it
+	written    int
+	compressed *countingWriter
+}
+
+func (cw *compressionResponseWriter) Header() http.Header {
+	return cw.writer.Header()
+}
+
+func (cw *compressionResponseWriter) WriteHeader(statusCode int) {
+	if cw.status != 0 {
+		return
+	}
+	cw.status = statusCode
+	if !bodyAllowedForStatus(statusCode) {
+		_ = cw.decide(false)
+	}
+}
+
+func (cw *compressionResponseWriter) Write(p []byte) (int, error) {
+	if cw.status == 0 {
+		cw.status = http.StatusOK
+	}
+	if !cw.decided {
+		cw.body.Write(p)
+		if cw.body.Len() < cw.cmp.minSize {
+			return len(p), nil
+		}
+		if err := cw.decide(true); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+	if cw.encoder != nil {
+		n, err := cw.encoder.Write(p)
+		cw.written += n
+		return n, err
+	}
+	return cw.writer.Write(p)
+}
+
+// Flush compresses the response regardless of its size, since the handler is streaming it.
+func (cw *compressionResponseWriter) Flush() {
+	if cw.status == 0 {
+		cw.status = http.StatusOK
+	}
+	if !cw.decided {
+		if err := cw.decide(true); err != nil {
+			log.Errorf("failed to flush compressed response: %v", err)
+			return
+		}
+	}
+	if f, ok := cw.encoder.(interface{ Flush() error }); ok {
+		if err := f.Flush(); err != nil {
+			log.Errorf("failed to flush compressed response: %v", err)
+			return
+		}
+	}
+	if f, ok := cw.writer.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (cw *compressionResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return hijack(cw.writer)
+}
+
+func (cw *compressionResponseWriter) Push(target string, opts *http.PushOptions) error {
+	return push(cw.writer, target, opts)
+}
+
+// Close writes any buffered response and completes the compressed one.
+func (cw *compressionResponseWriter) Close() error {
+	if cw.status == 0 {
+		return nil
+	}
+	if !cw.decided {
+		if err := cw.decide(false); err != nil {
+			return err
+		}
+	}
+	if cw.encoder == nil {
+		return nil
+	}
+	err := cw.encoder.Close()
+	compressedResponsesMetric.WithLabelValues(cw.encoding).Inc()
+	compressionBytesMetric.WithLabelValues(cw.encoding, "uncompressed").Add(float64(cw.written))
+	compressionBytesMetric.WithLabelValues(cw.encoding, "compressed").Add(float64(cw.compressed.written))
+	return err
+}
+
+// decide writes the header of the response, compressed if its status, content type, encoding and size allow it,
+// followed by the buffered body.
+func (cw *compressionResponseWriter) decide(streaming bool) error {
+	cw.decided = true
+	h := cw.writer.Header()
+
+	if bodyAllowedForStatus(cw.status) && h.Get(encoding.ContentEncodingHeader) == "" {
+		if h.Get(encoding.ContentTypeHeader) == "" && cw.body.Len() > 0 {
+			h.Set(encoding.ContentTypeHeader, http.DetectContentType(cw.body.Bytes()))
+		}
+		if cw.cmp.compressible(h.Get(encoding.ContentTypeHeader)) {
+			h.Add(varyHeader, encoding.AcceptEncodingHeader)
+			if cw.encoding != "" && (streaming || (cw.body.Len() > 0 && cw.body.Len() >= cw.cmp.minSize)) {
+				cw.startEncoder()
+			}
+		}
+	}
+
+	cw.writer.WriteHeader(cw.status)
+	if cw.body.Len() == 0 {
+		return nil
+	}
+	var err error
+	if cw.encoder != nil {
+		var n int
+		n, err = cw.encoder.Write(cw.body.Bytes())
+		cw.written += n
+	} else {
+		_, err = cw.writer.Write(cw.body.Bytes())
+	}
+	cw.body.Reset()
+	return err
+}
+
+func (cw *compressionResponseWriter) startEncoder() {
+	compressed := &countingWriter{writer: cw.writer}
+	encoder, err := cw.cmp.encoders[cw.encoding](compressed)
+	if err != nil {
+		log.Errorf("failed to create %s encoder, serving uncompressed response: %v", cw.encoding, err)
+		return
+	}
+	cw.encoder = encoder
+	cw.compressed = compressed
+	h := cw.writer.Header()
+	h.Set(encoding.ContentEncodingHeader, cw.encoding)
+	h.Del(contentLengthHeader)
+}
+
+// countingWriter counts the bytes written to the writer.
+type countingWriter struct {
+	writer  io.Writer
+	written int
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.writer.Write(p)
+	c.written += n
+	return n, err
+}
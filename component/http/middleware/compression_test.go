@@ -0,0 +1,319 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/beatlabs/patron/encoding"
+	"github.com/klauspost/compress/zstd"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// upperEncoder is a fake encoder, which upper cases the data instead of compressing it.
+type upperEncoder struct {
+	w io.Writer
+}
+
+func (u upperEncoder) Write(p []byte) (int, error) {
+	return u.w.Write(bytes.ToUpper(p))
+}
+
+func (u upperEncoder) Close() error {
+	return nil
+}
+
+func newUpperEncoder(w io.Writer) (io.WriteCloser, error) {
+	return upperEncoder{w: w}, nil
+}
+
+func TestNewResponseCompression(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		cfg         CompressionConfig
+		expectedErr string
+	}{
+		"success":              {cfg: CompressionConfig{Level: gzip.BestSpeed, MinSize: 1024, Encoders: map[string]EncoderFunc{"upper": newUpperEncoder}}},
+		"default config":       {cfg: CompressionConfig{}},
+		"negative min size":    {cfg: CompressionConfig{MinSize: -1}, expectedErr: "min size should not be negative"},
+		"invalid level":        {cfg: CompressionConfig{Level: 10}, expectedErr: "invalid compression level: 10"},
+		"empty content type":   {cfg: CompressionConfig{ContentTypes: []string{" "}}, expectedErr: "content type is empty"},
+		"invalid encoder name": {cfg: CompressionConfig{Encoders: map[string]EncoderFunc{"*": newUpperEncoder}}, expectedErr: `invalid encoder name: "*"`},
+		"nil encoder":          {cfg: CompressionConfig{Encoders: map[string]EncoderFunc{"upper": nil}}, expectedErr: "encoder of upper is nil"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			got, err := NewResponseCompression(tt.cfg)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, got)
+			}
+		})
+	}
+}
+
+func TestCompression_Negotiate(t *testing.T) {
+	t.Parallel()
+	cmp, err := newCompression(CompressionConfig{Encoders: map[string]EncoderFunc{"upper": newUpperEncoder}})
+	require.NoError(t, err)
+	tests := map[string]struct {
+		header   string
+		expected string
+	}{
+		"empty":              {header: "", expected: ""},
+		"unsupported":        {header: "compress", expected: ""},
+		"gzip":               {header: "gzip", expected: "gzip"},
+		"custom preferred":   {header: "gzip, deflate, upper", expected: "upper"},
+		"weights":            {header: "gzip;q=0.8, deflate;q=0.9, upper;q=0.1", expected: "deflate"},
+		"anything":           {header: "*", expected: "upper"},
+		"anything excluding": {header: "*, upper;q=0", expected: "zstd"},
+		"zstd preferred":     {header: "gzip, deflate, zstd", expected: "zstd"},
+		"zstd weighted":      {header: "gzip, zstd;q=0.5", expected: "gzip"},
+		"brotli preferred":   {header: "gzip, deflate, br", expected: "br"},
+		"zstd over brotli":   {header: "br, zstd", expected: "zstd"},
+		"identity only":      {header: "identity", expected: ""},
+		"case insensitive":   {header: "GZIP", expected: "gzip"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.expected, cmp.negotiate(tt.header))
+		})
+	}
+}
+
+func TestResponseCompression(t *testing.T) {
+	t.Parallel()
+	body := strings.Repeat(`{"name":"patron"}`, 100)
+	jsonHandler := func(status int, body string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set(encoding.ContentTypeHeader, "application/json; charset=utf-8")
+			w.WriteHeader(status)
+			_, _ = w.Write([]byte(body))
+		})
+	}
+	tests := map[string]struct {
+		method           string
+		acceptEncoding   string
+		cfg              CompressionConfig
+		handler          http.Handler
+		expectedStatus   int
+		expectedEncoding string
+		expectedVary     string
+		expectedBody     string
+	}{
+		"gzip": {
+			acceptEncoding: "gzip", handler: jsonHandler(http.StatusOK, body),
+			expectedStatus: http.StatusOK, expectedEncoding: "gzip", expectedVary: "Accept-Encoding", expectedBody: body,
+		},
+		"deflate": {
+			acceptEncoding: "deflate", handler: jsonHandler(http.StatusCreated, body),
+			expectedStatus: http.StatusCreated, expectedEncoding: "deflate", expectedVary: "Accept-Encoding", expectedBody: body,
+		},
+		"zstd": {
+			acceptEncoding: "gzip, zstd", handler: jsonHandler(http.StatusOK, body),
+			expectedStatus: http.StatusOK, expectedEncoding: "zstd", expectedVary: "Accept-Encoding", expectedBody: body,
+		},
+		"brotli": {
+			acceptEncoding: "gzip, br", handler: jsonHandler(http.StatusOK, body),
+			expectedStatus: http.StatusOK, expectedEncoding: "br", expectedVary: "Accept-Encoding", expectedBody: body,
+		},
+		"custom encoder": {
+			acceptEncoding: "upper, gzip", cfg: CompressionConfig{Encoders: map[string]EncoderFunc{"upper": newUpperEncoder}},
+			handler:        jsonHandler(http.StatusOK, body),
+			expectedStatus: http.StatusOK, expectedEncoding: "upper", expectedVary: "Accept-Encoding", expectedBody: body,
+		},
+		"not accepted": {
+			acceptEncoding: "compress", handler: jsonHandler(http.StatusOK, body),
+			expectedStatus: http.StatusOK, expectedVary: "Accept-Encoding", expectedBody: body,
+		},
+		"below min size": {
+			acceptEncoding: "gzip", cfg: CompressionConfig{MinSize: 2048}, handler: jsonHandler(http.StatusOK, body),
+			expectedStatus: http.StatusOK, expectedVary: "Accept-Encoding", expectedBody: body,
+		},
+		"content type not allowed": {
+			acceptEncoding: "gzip", cfg: CompressionConfig{ContentTypes: []string{"text/*"}}, handler: jsonHandler(http.StatusOK, body),
+			expectedStatus: http.StatusOK, expectedBody: body,
+		},
+		"detected content type": {
+			acceptEncoding: "gzip",
+			handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte("plain text"))
+			}),
+			expectedStatus: http.StatusOK, expectedEncoding: "gzip", expectedVary: "Accept-Encoding", expectedBody: "plain text",
+		},
+		"already encoded": {
+			acceptEncoding: "gzip",
+			handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set(encoding.ContentTypeHeader, "text/plain")
+				w.Header().Set(encoding.ContentEncodingHeader, "upper")
+				_, _ = w.Write([]byte(body))
+			}),
+			expectedStatus: http.StatusOK, expectedEncoding: "upper", expectedBody: body,
+		},
+		"no content": {
+			acceptEncoding: "gzip", handler: jsonHandler(http.StatusNoContent, ""),
+			expectedStatus: http.StatusNoContent,
+		},
+		"head request": {
+			method: http.MethodHead, acceptEncoding: "gzip", handler: jsonHandler(http.StatusOK, ""),
+			expectedStatus: http.StatusOK,
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			mw, err := NewResponseCompression(tt.cfg)
+			require.NoError(t, err)
+			method := tt.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			req := httptest.NewRequest(method, "/", nil)
+			req.Header.Set(encoding.AcceptEncodingHeader, tt.acceptEncoding)
+			rc := httptest.NewRecorder()
+
+			mw(tt.handler).ServeHTTP(rc, req)
+
+			assert.Equal(t, tt.expectedStatus, rc.Code)
+			assert.Equal(t, tt.expectedEncoding, rc.Header().Get(encoding.ContentEncodingHeader))
+			assert.Equal(t, tt.expectedVary, rc.Header().Get(varyHeader))
+			assert.Equal(t, tt.expectedBody, decode(t, tt.expectedEncoding, rc.Body))
+		})
+	}
+}
+
+func TestResponseCompression_Flush(t *testing.T) {
+	t.Parallel()
+	mw, err := NewResponseCompression(CompressionConfig{MinSize: 1024})
+	require.NoError(t, err)
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(encoding.AcceptEncodingHeader, "gzip")
+	rc := httptest.NewRecorder()
+
+	mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(encoding.ContentTypeHeader, "text/event-stream")
+		_, _ = w.Write([]byte("data: 1\n\n"))
+		w.(http.Flusher).Flush()
+		assert.True(t, rc.Flushed)
+		assert.NotZero(t, rc.Body.Len())
+		_, _ = w.Write([]byte("data: 2\n\n"))
+	})).ServeHTTP(rc, req)
+
+	assert.Equal(t, "gzip", rc.Header().Get(encoding.ContentEncodingHeader))
+	assert.Equal(t, "data: 1\n\ndata: 2\n\n", decode(t, "gzip", rc.Body))
+}
+
+func TestCompression_NotCompressedTwice(t *testing.T) {
+	t.Parallel()
+	routeMw, err := NewResponseCompression(CompressionConfig{})
+	require.NoError(t, err)
+	body := strings.Repeat("patron ", 100)
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(encoding.AcceptEncodingHeader, "gzip")
+	rc := httptest.NewRecorder()
+
+	NewCompression(flate.DefaultCompression)(routeMw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(encoding.ContentTypeHeader, "text/plain")
+		_, _ = w.Write([]byte(body))
+	}))).ServeHTTP(rc, req)
+
+	assert.Equal(t, "gzip", rc.Header().Get(encoding.ContentEncodingHeader))
+	assert.Equal(t, body, decode(t, "gzip", rc.Body))
+}
+
+func TestResponseCompression_Metrics(t *testing.T) {
+	mw, err := NewResponseCompression(CompressionConfig{Level: flate.BestSpeed})
+	require.NoError(t, err)
+	body := strings.Repeat("patron ", 1000)
+	responses := testutil.ToFloat64(compressedResponsesMetric.WithLabelValues("zstd"))
+	uncompressed := testutil.ToFloat64(compressionBytesMetric.WithLabelValues("zstd", "uncompressed"))
+	compressed := testutil.ToFloat64(compressionBytesMetric.WithLabelValues("zstd", "compressed"))
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(encoding.AcceptEncodingHeader, "zstd")
+	rc := httptest.NewRecorder()
+
+	mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(encoding.ContentTypeHeader, "text/plain")
+		_, _ = w.Write([]byte(body))
+	})).ServeHTTP(rc, req)
+
+	assert.Equal(t, body, decode(t, "zstd", bytes.NewReader(rc.Body.Bytes())))
+	assert.Equal(t, 1.0, testutil.ToFloat64(compressedResponsesMetric.WithLabelValues("zstd"))-responses)
+	assert.Equal(t, float64(len(body)), testutil.ToFloat64(compressionBytesMetric.WithLabelValues("zstd", "uncompressed"))-uncompressed)
+	assert.Equal(t, float64(rc.Body.Len()), testutil.ToFloat64(compressionBytesMetric.WithLabelValues("zstd", "compressed"))-compressed)
+}
+
+func TestCompression_Zstd(t *testing.T) {
+	t.Parallel()
+	body := strings.Repeat("patron ", 100)
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(encoding.AcceptEncodingHeader, "zstd")
+	rc := httptest.NewRecorder()
+
+	NewCompression(flate.DefaultCompression)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(body))
+	})).ServeHTTP(rc, req)
+
+	assert.Equal(t, "zstd", rc.Header().Get(encoding.ContentEncodingHeader))
+	assert.Equal(t, body, decode(t, "zstd", rc.Body))
+}
+
+func TestCompression_Brotli(t *testing.T) {
+	t.Parallel()
+	body := strings.Repeat("patron ", 100)
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(encoding.AcceptEncodingHeader, "br")
+	rc := httptest.NewRecorder()
+
+	NewCompression(flate.BestSpeed)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(body))
+	})).ServeHTTP(rc, req)
+
+	assert.Equal(t, "br", rc.Header().Get(encoding.ContentEncodingHeader))
+	assert.Equal(t, body, decode(t, "br", rc.Body))
+}
+
+func decode(t *testing.T, encoding string, body io.Reader) string {
+	var r io.Reader
+	switch encoding {
+	case "gzip":
+		gr, err := gzip.NewReader(body)
+		require.NoError(t, err)
+		r = gr
+	case "deflate":
+		r = flate.NewReader(body)
+	case "zstd":
+		zr, err := zstd.NewReader(body)
+		require.NoError(t, err)
+		defer zr.Close()
+		r = zr
+	case "br":
+		r = brotli.NewReader(body)
+	case "upper":
+		data, err := ioutil.ReadAll(body)
+		require.NoError(t, err)
+		return strings.ToLower(string(data))
+	default:
+		r = body
+	}
+	data, err := ioutil.ReadAll(r)
+	require.NoError(t, err)
+	return string(data)
+}
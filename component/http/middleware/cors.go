@@ -0,0 +1,246 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	originHeader           = "Origin"
+	allowOriginHeader      = "Access-Control-Allow-Origin"
+	allowMethodsHeader     = "Access-Control-Allow-Methods"
+	allowHeadersHeader     = "Access-Control-Allow-Headers"
+	allowCredentialsHeader = "Access-Control-Allow-Credentials"
+	exposeHeadersHeader    = "Access-Control-Expose-Headers"
+	maxAgeHeader           = "Access-Control-Max-Age"
+	requestMethodHeader    = "Access-Control-Request-Method"
+	requestHeadersHeader   = "Access-Control-Request-Headers"
+	corsWildcard           = "*"
+)
+
+// CORSConfig defines the cross-origin requests allowed by the CORS middleware.
+type CORSConfig struct {
+	// AllowedOrigins are the origins allowed to make requests, e.g. https://example.com. A single * allows any origin,
+	// while a wildcard in an origin allows any subdomain, e.g. https://*.example.com.
+	AllowedOrigins []string
+	// AllowedMethods are the methods allowed in requests. Defaults to GET, HEAD and POST.
+	AllowedMethods []string
+	// AllowedHeaders are the non-simple headers allowed in requests. A single * allows any header.
+	AllowedHeaders []string
+	// ExposedHeaders are the response headers, besides the simple ones, which are exposed to the client.
+	ExposedHeaders []string
+	// AllowCredentials allows requests with credentials, e.g. cookies. It cannot be combined with any origin being allowed.
+	AllowCredentials bool
+	// MaxAge is the duration the result of a preflight request can be cached by the client. Zero leaves it to the client.
+	MaxAge time.Duration
+}
+
+type originPattern struct {
+	prefix, suffix string
+}
+
+func (op originPattern) match(origin string) bool {
+	return len(origin) > len(op.prefix)+len(op.suffix) && strings.HasPrefix(origin, op.prefix) && strings.HasSuffix(origin, op.suffix)
+}
+
+type cors struct {
+	anyOrigin      bool
+	origins        map[string]struct{}
+	patterns       []originPattern
+	methods        map[string]struct{}
+	methodsList    string
+	anyHeader      bool
+	headers        map[string]struct{}
+	exposedHeaders string
+	credentials    bool
+	maxAge         string
+}
+
+// NewCORS creates a Func which handles cross-origin requests according to the provided config.
+// Preflight requests are answered with a 204 No Content, without reaching the handler, while the response of
+// any other request from an allowed origin carries the CORS headers. Requests from other origins are passed
+// to the handler without CORS headers, so that their responses are blocked by the client.
+func NewCORS(cfg CORSConfig) (Func, error) {
+	c, err := newCORS(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h := w.Header()
+			h.Add(varyHeader, originHeader)
+			origin := r.Header.Get(originHeader)
+			if origin == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if r.Method == http.MethodOptions && r.Header.Get(requestMethodHeader) != "" {
+				c.preflight(w, r, origin)
+				return
+			}
+
+			if c.allowedOrigin(origin) {
+				c.setOrigin(h, origin)
+				if c.exposedHeaders != "" {
+					h.Set(exposeHeadersHeader, c.exposedHeaders)
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}, nil
+}
+
+func newCORS(cfg CORSConfig) (*cors, error) {
+	if len(cfg.AllowedOrigins) == 0 {
+		return nil, errors.New("allowed origins are empty")
+	}
+	if cfg.MaxAge < 0 {
+		return nil, errors.New("max age should not be negative")
+	}
+
+	c := &cors{
+		origins:     make(map[string]struct{}),
+		methods:     make(map[string]struct{}),
+		headers:     make(map[string]struct{}),
+		credentials: cfg.AllowCredentials,
+	}
+
+	for _, origin := range cfg.AllowedOrigins {
+		switch {
+		case origin == corsWildcard:
+			c.anyOrigin = true
+		case origin == "":
+			return nil, errors.New("origin is empty")
+		case strings.Count(origin, corsWildcard) > 1:
+			return nil, fmt.Errorf("invalid origin: %s", origin)
+		case strings.Contains(origin, corsWildcard):
+			i := strings.Index(origin, corsWildcard)
+			c.patterns = append(c.patterns, originPattern{prefix: strings.ToLower(origin[:i]), suffix: strings.ToLower(origin[i+1:])})
+		default:
+			c.origins[strings.ToLower(origin)] = struct{}{}
+		}
+	}
+	if c.anyOrigin && c.credentials {
+		return nil, errors.New("credentials cannot be allowed for any origin")
+	}
+
+	allowedMethods := cfg.AllowedMethods
+	if len(allowedMethods) == 0 {
+		allowedMethods = []string{http.MethodGet, http.MethodHead, http.MethodPost}
+	}
+	methods := make([]string, 0, len(allowedMethods))
+	for _, method := range allowedMethods {
+		if method == "" {
+			return nil, errors.New("method is empty")
+		}
+		method = strings.ToUpper(method)
+		c.methods[method] = struct{}{}
+		methods = append(methods, method)
+	}
+	c.methodsList = strings.Join(methods, ", ")
+
+	for _, header := range cfg.AllowedHeaders {
+		switch header {
+		case corsWildcard:
+			c.anyHeader = true
+		case "":
+			return nil, errors.New("header is empty")
+		default:
+			c.headers[http.CanonicalHeaderKey(header)] = struct{}{}
+		}
+	}
+	c.exposedHeaders = strings.Join(cfg.ExposedHeaders, ", ")
+
+	if cfg.MaxAge > 0 {
+		c.maxAge = strconv.Itoa(int(cfg.MaxAge.Seconds()))
+	}
+	return c, nil
+}
+
+// preflight answers a preflight request, allowing it only if the origin, the method and all the headers of the actual request are allowed.
+func (c *cors) preflight(w http.ResponseWriter, r *http.Request, origin string) {
+	h := w.Header()
+	h.Add(varyHeader, requestMethodHeader)
+	h.Add(varyHeader, requestHeadersHeader)
+
+	method := strings.ToUpper(r.Header.Get(requestMethodHeader))
+	headers := requestedHeaders(r.Header.Values(requestHeadersHeader))
+	if c.allowedOrigin(origin) && c.allowedMethod(method) && c.allowedHeaders(headers) {
+		c.setOrigin(h, origin)
+		h.Set(allowMethodsHeader, c.methodsList)
+		if len(headers) > 0 {
+			h.Set(allowHeadersHeader, strings.Join(headers, ", "))
+		}
+		if c.maxAge != "" {
+			h.Set(maxAgeHeader, c.maxAge)
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (c *cors) setOrigin(h http.Header, origin string) {
+	if c.anyOrigin {
+		h.Set(allowOriginHeader, corsWildcard)
+		return
+	}
+	h.Set(allowOriginHeader, origin)
+	if c.credentials {
+		h.Set(allowCredentialsHeader, "true")
+	}
+}
+
+func (c *cors) allowedOrigin(origin string) bool {
+	if c.anyOrigin {
+		return true
+	}
+	origin = strings.ToLower(origin)
+	if _, ok := c.origins[origin]; ok {
+		return true
+	}
+	for _, p := range c.patterns {
+		if p.match(origin) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *cors) allowedMethod(method string) bool {
+	// simple methods are always allowed by clients, regardless of the response
+	if method == http.MethodGet || method == http.MethodHead || method == http.MethodPost {
+		return true
+	}
+	_, ok := c.methods[method]
+	return ok
+}
+
+func (c *cors) allowedHeaders(headers []string) bool {
+	if c.anyHeader {
+		return true
+	}
+	for _, header := range headers {
+		if _, ok := c.headers[header]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func requestedHeaders(values []string) []string {
+	var headers []string
+	for _, value := range values {
+		for _, header := range strings.Split(value, ",") {
+			header = strings.TrimSpace(header)
+			if header != "" {
+				headers = append(headers, http.CanonicalHeaderKey(header))
+			}
+		}
+	}
+	return headers
+}
@@ -0,0 +1,155 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewCORS(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		cfg         CORSConfig
+		expectedErr string
+	}{
+		"success": {cfg: CORSConfig{
+			AllowedOrigins: []string{"https://example.com", "https://*.example.com"}, AllowedMethods: []string{http.MethodPut},
+			AllowedHeaders: []string{"*"}, AllowCredentials: true, MaxAge: time.Hour,
+		}},
+		"missing origins":           {cfg: CORSConfig{}, expectedErr: "allowed origins are empty"},
+		"empty origin":              {cfg: CORSConfig{AllowedOrigins: []string{""}}, expectedErr: "origin is empty"},
+		"invalid origin":            {cfg: CORSConfig{AllowedOrigins: []string{"https://*.*.com"}}, expectedErr: "invalid origin: https://*.*.com"},
+		"credentials of any origin": {cfg: CORSConfig{AllowedOrigins: []string{"*"}, AllowCredentials: true}, expectedErr: "credentials cannot be allowed for any origin"},
+		"empty method":              {cfg: CORSConfig{AllowedOrigins: []string{"*"}, AllowedMethods: []string{""}}, expectedErr: "method is empty"},
+		"empty header":              {cfg: CORSConfig{AllowedOrigins: []string{"*"}, AllowedHeaders: []string{""}}, expectedErr: "header is empty"},
+		"negative max age":          {cfg: CORSConfig{AllowedOrigins: []string{"*"}, MaxAge: -time.Second}, expectedErr: "max age should not be negative"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			got, err := NewCORS(tt.cfg)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, got)
+			}
+		})
+	}
+}
+
+func TestCORS(t *testing.T) {
+	t.Parallel()
+	cfg := CORSConfig{
+		AllowedOrigins:   []string{"https://example.com", "https://*.example.org"},
+		AllowedMethods:   []string{http.MethodGet, http.MethodPut},
+		AllowedHeaders:   []string{"x-api-key", "Content-Type"},
+		ExposedHeaders:   []string{"X-Request-Id"},
+		AllowCredentials: true,
+		MaxAge:           10 * time.Minute,
+	}
+	tests := map[string]struct {
+		cfg             CORSConfig
+		method          string
+		headers         map[string]string
+		expectedStatus  int
+		expectedHeaders map[string]string
+		expectedCalled  bool
+	}{
+		"same origin request": {
+			cfg: cfg, method: http.MethodGet,
+			expectedStatus: http.StatusOK, expectedHeaders: map[string]string{allowOriginHeader: "", varyHeader: originHeader}, expectedCalled: true,
+		},
+		"allowed request": {
+			cfg: cfg, method: http.MethodPut, headers: map[string]string{originHeader: "https://example.com"},
+			expectedStatus: http.StatusOK, expectedCalled: true,
+			expectedHeaders: map[string]string{
+				allowOriginHeader: "https://example.com", allowCredentialsHeader: "true", exposeHeadersHeader: "X-Request-Id", allowMethodsHeader: "",
+			},
+		},
+		"allowed request of subdomain": {
+			cfg: cfg, method: http.MethodGet, headers: map[string]string{originHeader: "https://api.example.org"},
+			expectedStatus: http.StatusOK, expectedHeaders: map[string]string{allowOriginHeader: "https://api.example.org"}, expectedCalled: true,
+		},
+		"request of other origin": {
+			cfg: cfg, method: http.MethodGet, headers: map[string]string{originHeader: "https://example.org"},
+			expectedStatus: http.StatusOK, expectedHeaders: map[string]string{allowOriginHeader: "", exposeHeadersHeader: ""}, expectedCalled: true,
+		},
+		"request of any origin": {
+			cfg: CORSConfig{AllowedOrigins: []string{"*"}}, method: http.MethodGet, headers: map[string]string{originHeader: "https://example.org"},
+			expectedStatus: http.StatusOK, expectedHeaders: map[string]string{allowOriginHeader: "*", allowCredentialsHeader: ""}, expectedCalled: true,
+		},
+		"allowed preflight": {
+			cfg: cfg, method: http.MethodOptions,
+			headers: map[string]string{
+				originHeader: "https://example.com", requestMethodHeader: http.MethodPut, requestHeadersHeader: "content-type, X-API-KEY",
+			},
+			expectedStatus: http.StatusNoContent,
+			expectedHeaders: map[string]string{
+				allowOriginHeader: "https://example.com", allowCredentialsHeader: "true", allowMethodsHeader: "GET, PUT",
+				allowHeadersHeader: "Content-Type, X-Api-Key", maxAgeHeader: "600",
+			},
+		},
+		"preflight of simple method": {
+			cfg: cfg, method: http.MethodOptions, headers: map[string]string{originHeader: "https://example.com", requestMethodHeader: http.MethodPost},
+			expectedStatus: http.StatusNoContent, expectedHeaders: map[string]string{allowOriginHeader: "https://example.com", allowHeadersHeader: ""},
+		},
+		"preflight of any header": {
+			cfg:    CORSConfig{AllowedOrigins: []string{"*"}, AllowedHeaders: []string{"*"}},
+			method: http.MethodOptions, headers: map[string]string{originHeader: "https://example.com", requestMethodHeader: http.MethodGet, requestHeadersHeader: "X-Custom"},
+			expectedStatus: http.StatusNoContent,
+			expectedHeaders: map[string]string{
+				allowOriginHeader: "*", allowMethodsHeader: "GET, HEAD, POST", allowHeadersHeader: "X-Custom", maxAgeHeader: "",
+			},
+		},
+		"preflight of other origin": {
+			cfg: cfg, method: http.MethodOptions, headers: map[string]string{originHeader: "https://example.net", requestMethodHeader: http.MethodPut},
+			expectedStatus: http.StatusNoContent, expectedHeaders: map[string]string{allowOriginHeader: "", allowMethodsHeader: ""},
+		},
+		"preflight of disallowed method": {
+			cfg: cfg, method: http.MethodOptions, headers: map[string]string{originHeader: "https://example.com", requestMethodHeader: http.MethodDelete},
+			expectedStatus: http.StatusNoContent, expectedHeaders: map[string]string{allowOriginHeader: "", allowMethodsHeader: ""},
+		},
+		"preflight of disallowed header": {
+			cfg: cfg, method: http.MethodOptions,
+			headers:        map[string]string{originHeader: "https://example.com", requestMethodHeader: http.MethodPut, requestHeadersHeader: "X-Other"},
+			expectedStatus: http.StatusNoContent, expectedHeaders: map[string]string{allowOriginHeader: "", allowHeadersHeader: ""},
+		},
+		"options request": {
+			cfg: cfg, method: http.MethodOptions, headers: map[string]string{originHeader: "https://example.com"},
+			expectedStatus: http.StatusOK, expectedHeaders: map[string]string{allowOriginHeader: "https://example.com"}, expectedCalled: true,
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			mw, err := NewCORS(tt.cfg)
+			require.NoError(t, err)
+			called := false
+			handler := mw(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				called = true
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest(tt.method, "/", nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			rc := httptest.NewRecorder()
+			handler.ServeHTTP(rc, req)
+
+			assert.Equal(t, tt.expectedStatus, rc.Code)
+			assert.Equal(t, tt.expectedCalled, called)
+			for k, v := range tt.expectedHeaders {
+				assert.Equal(t, v, rc.Header().Get(k), k)
+			}
+		})
+	}
+}
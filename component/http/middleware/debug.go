@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/beatlabs/patron/log"
+	"github.com/opentracing/opentracing-go"
+	"github.com/uber/jaeger-client-go"
+)
+
+// DebugHeader is the default header which requests debug level logging for a single request.
+const DebugHeader = "X-Patron-Debug"
+
+// NewDebugLogging creates a Func that flags the request context for debug level logging, regardless of the configured level,
+// when the request carries the header set to true or, optionally, when the request trace is sampled or flagged as debug.
+// Loggers acquired via log.FromContext along the whole context chain of the request log on debug level.
+// It should follow the tracing middleware in the chain, in order for the span to be available.
+func NewDebugLogging(header string, sampledTraces bool) Func {
+	if header == "" {
+		header = DebugHeader
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if debugRequested(r, header, sampledTraces) {
+				r = r.WithContext(log.WithDebug(r.Context()))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func debugRequested(r *http.Request, header string, sampledTraces bool) bool {
+	if debug, err := strconv.ParseBool(r.Header.Get(header)); err == nil && debug {
+		return true
+	}
+	if !sampledTraces {
+		return false
+	}
+	sp := opentracing.SpanFromContext(r.Context())
+	if sp == nil {
+		return false
+	}
+	sctx, ok := sp.Context().(jaeger.SpanContext)
+	return ok && (sctx.IsSampled() || sctx.IsDebug())
+}
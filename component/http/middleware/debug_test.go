@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/beatlabs/patron/log"
+	"github.com/opentracing/opentracing-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber/jaeger-client-go"
+)
+
+func TestNewDebugLogging(t *testing.T) {
+	t.Parallel()
+	tracer, closer := jaeger.NewTracer("test", jaeger.NewConstSampler(true), jaeger.NewNullReporter())
+	defer func() { require.NoError(t, closer.Close()) }()
+	unsampledTracer, unsampledCloser := jaeger.NewTracer("test", jaeger.NewConstSampler(false), jaeger.NewNullReporter())
+	defer func() { require.NoError(t, unsampledCloser.Close()) }()
+
+	tests := map[string]struct {
+		header        string
+		value         string
+		tracer        opentracing.Tracer
+		sampledTraces bool
+		expected      bool
+	}{
+		"no header":             {expected: false},
+		"default header":        {value: "true", expected: true},
+		"custom header":         {header: "X-Debug", value: "1", expected: true},
+		"header false":          {value: "false", expected: false},
+		"header invalid":        {value: "yes", expected: false},
+		"sampled trace":         {tracer: tracer, sampledTraces: true, expected: true},
+		"sampled trace ignored": {tracer: tracer, expected: false},
+		"unsampled trace":       {tracer: unsampledTracer, sampledTraces: true, expected: false},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.value != "" {
+				header := tt.header
+				if header == "" {
+					header = DebugHeader
+				}
+				req.Header.Set(header, tt.value)
+			}
+			if tt.tracer != nil {
+				sp := tt.tracer.StartSpan("test")
+				defer sp.Finish()
+				req = req.WithContext(opentracing.ContextWithSpan(req.Context(), sp))
+			}
+
+			var debug bool
+			handler := NewDebugLogging(tt.header, tt.sampledTraces)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				debug = log.DebugFromContext(r.Context())
+			}))
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			assert.Equal(t, tt.expected, debug)
+		})
+	}
+}
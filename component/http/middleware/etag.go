@@ -0,0 +1,156 @@
+package middleware
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net"
+	"net/http"
+
+	"github.com/beatlabs/patron/component/http/cache"
+	"github.com/beatlabs/patron/encoding"
+	"github.com/beatlabs/patron/log"
+)
+
+const (
+	ifNoneMatchHeader = "If-None-Match"
+
+	// DefaultETagMaxBodySize is the default maximum size of a response body for which an ETag is computed.
+	DefaultETagMaxBodySize = 1 << 20
+)
+
+// NewConditionalGet creates a Func which computes a strong ETag from the body of successful GET responses
+// and responds with 304 Not Modified when it matches the If-None-Match header of the request.
+// HEAD requests are revalidated only against an ETag set by the handler or the route cache.
+// Responses with a body larger than the max body size are streamed as is, without an ETag.
+// An ETag already set by the handler or the route cache is respected, so placing the middleware
+// before the caching middleware in a chain allows clients to revalidate cached responses as well.
+func NewConditionalGet(maxBodySize int) (Func, error) {
+	if maxBodySize <= 0 {
+		return nil, errors.New("max body size should be positive")
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet && r.Method != http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ew := &etagResponseWriter{writer: w, maxBodySize: maxBodySize}
+			next.ServeHTTP(ew, r)
+			if ew.streaming {
+				return
+			}
+
+			status := ew.statusCode()
+			if status != http.StatusOK {
+				ew.flush()
+				return
+			}
+
+			etag := w.Header().Get(cache.HeaderETagHeader)
+			if etag == "" {
+				if r.Method == http.MethodHead {
+					// there is no body to compute the ETag from
+					ew.flush()
+					return
+				}
+				etag = computeETag(ew.body.Bytes())
+				w.Header().Set(cache.HeaderETagHeader, etag)
+			}
+
+			if cache.ETagMatches(r.Header.Get(ifNoneMatchHeader), etag) {
+				w.Header().Del(contentLengthHeader)
+				w.Header().Del(encoding.ContentTypeHeader)
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+
+			ew.flush()
+		})
+	}, nil
+}
+
+func computeETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// etagResponseWriter buffers the response up to the max body size, after which it switches to streaming.
+type etagResponseWriter struct {
+	writer      http.ResponseWriter
+	maxBodySize int
+	status      int
+	body        bytes.Buffer
+	streaming   bool
+}
+
+func (ew *etagResponseWriter) Header() http.Header {
+	return ew.writer.Header()
+}
+
+func (ew *etagResponseWriter) WriteHeader(statusCode int) {
+	if ew.status == 0 {
+		ew.status = statusCode
+	}
+}
+
+func (ew *etagResponseWriter) Write(p []byte) (int, error) {
+	if ew.status == 0 {
+		ew.status = http.StatusOK
+	}
+	if ew.streaming {
+		return ew.writer.Write(p)
+	}
+	if ew.body.Len()+len(p) <= ew.maxBodySize {
+		return ew.body.Write(p)
+	}
+
+	ew.streaming = true
+	ew.flush()
+	return ew.writer.Write(p)
+}
+
+// Flush streams the response as is without an ETag, since the handler is streaming it.
+func (ew *etagResponseWriter) Flush() {
+	if ew.status == 0 {
+		ew.status = http.StatusOK
+	}
+	if !ew.streaming {
+		ew.streaming = true
+		ew.flush()
+	}
+	if f, ok := ew.writer.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (ew *etagResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	ew.streaming = true
+	return hijack(ew.writer)
+}
+
+func (ew *etagResponseWriter) Push(target string, opts *http.PushOptions) error {
+	return push(ew.writer, target, opts)
+}
+
+func (ew *etagResponseWriter) statusCode() int {
+	if ew.status == 0 {
+		return http.StatusOK
+	}
+	return ew.status
+}
+
+func (ew *etagResponseWriter) flush() {
+	ew.writer.WriteHeader(ew.statusCode())
+	if ew.body.Len() == 0 {
+		return
+	}
+	if _, err := ew.writer.Write(ew.body.Bytes()); err != nil {
+		log.Errorf("failed to write response: %v", err)
+	}
+	ew.body.Reset()
+}
@@ -0,0 +1,212 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/beatlabs/patron/cache"
+	httpcache "github.com/beatlabs/patron/component/http/cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewConditionalGet(t *testing.T) {
+	t.Parallel()
+	got, err := NewConditionalGet(0)
+	assert.EqualError(t, err, "max body size should be positive")
+	assert.Nil(t, got)
+
+	got, err = NewConditionalGet(DefaultETagMaxBodySize)
+	assert.NoError(t, err)
+	assert.NotNil(t, got)
+}
+
+func TestConditionalGet(t *testing.T) {
+	t.Parallel()
+	body := `{"id":1}`
+	etag := computeETag([]byte(body))
+	tests := map[string]struct {
+		method         string
+		handler        http.Handler
+		ifNoneMatch    string
+		maxBodySize    int
+		expectedStatus int
+		expectedBody   string
+		expectedETag   string
+	}{
+		"etag computed": {
+			handler:        jsonHandler(http.StatusOK, body),
+			expectedStatus: http.StatusOK,
+			expectedBody:   body,
+			expectedETag:   etag,
+		},
+		"not modified": {
+			handler:        jsonHandler(http.StatusOK, body),
+			ifNoneMatch:    `"other", ` + etag,
+			expectedStatus: http.StatusNotModified,
+			expectedETag:   etag,
+		},
+		"weak match": {
+			handler:        jsonHandler(http.StatusOK, body),
+			ifNoneMatch:    "W/" + etag,
+			expectedStatus: http.StatusNotModified,
+			expectedETag:   etag,
+		},
+		"any": {
+			handler:        jsonHandler(http.StatusOK, body),
+			ifNoneMatch:    "*",
+			expectedStatus: http.StatusNotModified,
+			expectedETag:   etag,
+		},
+		"modified": {
+			handler:        jsonHandler(http.StatusOK, body),
+			ifNoneMatch:    `"other"`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   body,
+			expectedETag:   etag,
+		},
+		"handler etag": {
+			handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set(httpcache.HeaderETagHeader, "123-456")
+				_, _ = w.Write([]byte(body))
+			}),
+			ifNoneMatch:    `"123-456"`,
+			expectedStatus: http.StatusNotModified,
+			expectedETag:   "123-456",
+		},
+		"error response": {
+			handler:        jsonHandler(http.StatusNotFound, body),
+			ifNoneMatch:    "*",
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   body,
+		},
+		"body too large": {
+			handler:        jsonHandler(http.StatusOK, body),
+			maxBodySize:    4,
+			ifNoneMatch:    "*",
+			expectedStatus: http.StatusOK,
+			expectedBody:   body,
+		},
+		"post": {
+			method:         http.MethodPost,
+			handler:        jsonHandler(http.StatusOK, body),
+			ifNoneMatch:    "*",
+			expectedStatus: http.StatusOK,
+			expectedBody:   body,
+		},
+		"head without etag": {
+			method:         http.MethodHead,
+			handler:        jsonHandler(http.StatusOK, ""),
+			ifNoneMatch:    "*",
+			expectedStatus: http.StatusOK,
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			if tt.method == "" {
+				tt.method = http.MethodGet
+			}
+			if tt.maxBodySize == 0 {
+				tt.maxBodySize = DefaultETagMaxBodySize
+			}
+			mw, err := NewConditionalGet(tt.maxBodySize)
+			require.NoError(t, err)
+			rc := httptest.NewRecorder()
+			req := httptest.NewRequest(tt.method, "/", nil)
+			if tt.ifNoneMatch != "" {
+				req.Header.Set("If-None-Match", tt.ifNoneMatch)
+			}
+
+			mw(tt.handler).ServeHTTP(rc, req)
+
+			assert.Equal(t, tt.expectedStatus, rc.Code)
+			assert.Equal(t, tt.expectedBody, rc.Body.String())
+			assert.Equal(t, tt.expectedETag, rc.Header().Get("Etag"))
+		})
+	}
+}
+
+func TestConditionalGet_RouteCache(t *testing.T) {
+	t.Parallel()
+	rc, ee := httpcache.NewRouteCache(newMemoryTTLCache(), httpcache.Age{Max: 10 * time.Second})
+	require.Empty(t, ee)
+	conditional, err := NewConditionalGet(DefaultETagMaxBodySize)
+	require.NoError(t, err)
+
+	calls := 0
+	handler := Chain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		_, _ = w.Write([]byte("body"))
+	}), conditional, NewCaching(rc))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	etag := rec.Header().Get("Etag")
+	require.NotEmpty(t, etag)
+
+	rec = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("If-None-Match", `"`+etag+`"`)
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+	assert.Equal(t, etag, rec.Header().Get("Etag"))
+	assert.Equal(t, 1, calls)
+	assert.Empty(t, bytes.TrimSpace(rec.Body.Bytes()))
+}
+
+func TestConditionalGet_Flush(t *testing.T) {
+	t.Parallel()
+	conditional, err := NewConditionalGet(DefaultETagMaxBodySize)
+	require.NoError(t, err)
+	handler := conditional(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("data: 1\n\n"))
+		w.(http.Flusher).Flush()
+		_, _ = w.Write([]byte("data: 2\n\n"))
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.True(t, rec.Flushed)
+	assert.Empty(t, rec.Header().Get("Etag"))
+	assert.Equal(t, "data: 1\n\ndata: 2\n\n", rec.Body.String())
+}
+
+type memoryTTLCache struct {
+	values map[string]interface{}
+}
+
+func newMemoryTTLCache() cache.TTLCache {
+	return &memoryTTLCache{values: make(map[string]interface{})}
+}
+
+func (m *memoryTTLCache) Get(key string) (interface{}, bool, error) {
+	v, ok := m.values[key]
+	return v, ok, nil
+}
+
+func (m *memoryTTLCache) Purge() error {
+	m.values = make(map[string]interface{})
+	return nil
+}
+
+func (m *memoryTTLCache) Remove(key string) error {
+	delete(m.values, key)
+	return nil
+}
+
+func (m *memoryTTLCache) Set(key string, value interface{}) error {
+	m.values[key] = value
+	return nil
+}
+
+func (m *memoryTTLCache) SetTTL(key string, value interface{}, _ time.Duration) error {
+	m.values[key] = value
+	return nil
+}
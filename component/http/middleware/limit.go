@@ -0,0 +1,139 @@
+package middleware
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+
+	"github.com/beatlabs/patron/encoding"
+	"github.com/beatlabs/patron/log"
+)
+
+// ErrRequestBodyTooLarge is returned when reading a request body exceeding the limit of the body limit middleware.
+var ErrRequestBodyTooLarge = errors.New("request body too large")
+
+// NewRequestBodyLimit creates a Func which rejects requests with a body larger than the provided size in bytes
+// with a 413 Request Entity Too Large. Requests declaring a larger Content-Length are rejected before reaching the handler.
+// The rest, e.g. chunked uploads, are limited while being streamed to the handler, whose reads fail with ErrRequestBodyTooLarge
+// once the limit is exceeded, so that the body is never buffered in memory. The response of the handler is then replaced by the rejection,
+// unless the handler has already started writing it.
+func NewRequestBodyLimit(maxBytes int64) (Func, error) {
+	if maxBytes <= 0 {
+		return nil, errors.New("max request body size should be positive")
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > maxBytes {
+				log.FromContext(r.Context()).Debugf("rejecting request with content length %d exceeding %d bytes", r.ContentLength, maxBytes)
+				rejectTooLarge(w)
+				return
+			}
+			if r.Body == nil || r.Body == http.NoBody {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			body := &limitedBody{body: r.Body, remaining: maxBytes}
+			lw := &bodyLimitResponseWriter{writer: w, body: body}
+			r.Body = body
+			next.ServeHTTP(lw, r)
+			if !lw.wroteHeader && body.exceeded {
+				rejectTooLarge(w)
+			}
+		})
+	}, nil
+}
+
+func rejectTooLarge(w http.ResponseWriter) {
+	h := w.Header()
+	h.Del(contentLengthHeader)
+	h.Del(encoding.ContentEncodingHeader)
+	// the rest of the body is not read, so the connection cannot be reused
+	h.Set("Connection", "close")
+	http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+}
+
+// limitedBody fails reads with ErrRequestBodyTooLarge, once more than the remaining bytes have been read.
+type limitedBody struct {
+	body      io.ReadCloser
+	remaining int64
+	exceeded  bool
+}
+
+func (lb *limitedBody) Read(p []byte) (int, error) {
+	if lb.exceeded {
+		return 0, ErrRequestBodyTooLarge
+	}
+	// reading one more byte than the remaining ones reveals whether the limit is exceeded
+	if int64(len(p)) > lb.remaining+1 {
+		p = p[:lb.remaining+1]
+	}
+	n, err := lb.body.Read(p)
+	if int64(n) <= lb.remaining {
+		lb.remaining -= int64(n)
+		return n, err
+	}
+	n = int(lb.remaining)
+	lb.remaining = 0
+	lb.exceeded = true
+	return n, ErrRequestBodyTooLarge
+}
+
+func (lb *limitedBody) Close() error {
+	return lb.body.Close()
+}
+
+// bodyLimitResponseWriter replaces the response with a rejection, if the limit was exceeded before it was written.
+type bodyLimitResponseWriter struct {
+	writer      http.ResponseWriter
+	body        *limitedBody
+	wroteHeader bool
+	rejected    bool
+}
+
+func (lw *bodyLimitResponseWriter) Header() http.Header {
+	return lw.writer.Header()
+}
+
+func (lw *bodyLimitResponseWriter) WriteHeader(statusCode int) {
+	if lw.wroteHeader {
+		return
+	}
+	lw.wroteHeader = true
+	if lw.body.exceeded {
+		lw.rejected = true
+		rejectTooLarge(lw.writer)
+		return
+	}
+	lw.writer.WriteHeader(statusCode)
+}
+
+func (lw *bodyLimitResponseWriter) Write(p []byte) (int, error) {
+	if !lw.wroteHeader {
+		lw.WriteHeader(http.StatusOK)
+	}
+	if lw.rejected {
+		return len(p), nil
+	}
+	return lw.writer.Write(p)
+}
+
+func (lw *bodyLimitResponseWriter) Flush() {
+	if !lw.wroteHeader {
+		lw.WriteHeader(http.StatusOK)
+	}
+	if f, ok := lw.writer.(http.Flusher); ok && !lw.rejected {
+		f.Flush()
+	}
+}
+
+func (lw *bodyLimitResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return hijack(lw.writer)
+}
+
+func (lw *bodyLimitResponseWriter) Push(target string, opts *http.PushOptions) error {
+	return push(lw.writer, target, opts)
+}
@@ -0,0 +1,125 @@
+package middleware
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRequestBodyLimit(t *testing.T) {
+	t.Parallel()
+	got, err := NewRequestBodyLimit(0)
+	assert.EqualError(t, err, "max request body size should be positive")
+	assert.Nil(t, got)
+
+	got, err = NewRequestBodyLimit(1024)
+	assert.NoError(t, err)
+	assert.NotNil(t, got)
+}
+
+func TestRequestBodyLimit(t *testing.T) {
+	t.Parallel()
+	echo := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write(data)
+	})
+	tests := map[string]struct {
+		body           string
+		chunked        bool
+		handler        http.Handler
+		expectedStatus int
+		expectedBody   string
+		expectedCalled bool
+	}{
+		"within limit": {
+			body: "0123456789", handler: echo,
+			expectedStatus: http.StatusCreated, expectedBody: "0123456789", expectedCalled: true,
+		},
+		"within limit chunked": {
+			body: "0123456789", chunked: true, handler: echo,
+			expectedStatus: http.StatusCreated, expectedBody: "0123456789", expectedCalled: true,
+		},
+		"content length exceeded": {
+			body: "0123456789A", handler: echo,
+			expectedStatus: http.StatusRequestEntityTooLarge, expectedBody: "Request Entity Too Large\n",
+		},
+		"chunked exceeded": {
+			body: "0123456789A", chunked: true, handler: echo,
+			expectedStatus: http.StatusRequestEntityTooLarge, expectedBody: "Request Entity Too Large\n", expectedCalled: true,
+		},
+		"chunked exceeded without response": {
+			body: strings.Repeat("0", 1000), chunked: true,
+			handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, err := io.Copy(ioutil.Discard, r.Body)
+				assert.True(t, errors.Is(err, ErrRequestBodyTooLarge))
+			}),
+			expectedStatus: http.StatusRequestEntityTooLarge, expectedBody: "Request Entity Too Large\n", expectedCalled: true,
+		},
+		"no body": {
+			handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNoContent)
+			}),
+			expectedStatus: http.StatusNoContent, expectedCalled: true,
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			mw, err := NewRequestBodyLimit(10)
+			require.NoError(t, err)
+			called := false
+			handler := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				called = true
+				tt.handler.ServeHTTP(w, r)
+			}))
+
+			var body io.Reader
+			if tt.body != "" {
+				body = strings.NewReader(tt.body)
+			}
+			req := httptest.NewRequest(http.MethodPost, "/", body)
+			if tt.chunked {
+				req.ContentLength = -1
+			}
+			rc := httptest.NewRecorder()
+
+			handler.ServeHTTP(rc, req)
+
+			assert.Equal(t, tt.expectedStatus, rc.Code)
+			assert.Equal(t, tt.expectedBody, rc.Body.String())
+			assert.Equal(t, tt.expectedCalled, called)
+			if tt.expectedStatus == http.StatusRequestEntityTooLarge {
+				assert.Equal(t, "close", rc.Header().Get("Connection"))
+			}
+		})
+	}
+}
+
+func TestLimitedBody(t *testing.T) {
+	t.Parallel()
+	lb := &limitedBody{body: ioutil.NopCloser(strings.NewReader("0123456789")), remaining: 5}
+	p := make([]byte, 3)
+
+	n, err := lb.Read(p)
+	assert.NoError(t, err)
+	assert.Equal(t, "012", string(p[:n]))
+	n, err = lb.Read(p)
+	assert.Equal(t, ErrRequestBodyTooLarge, err)
+	assert.Equal(t, "34", string(p[:n]))
+	n, err = lb.Read(p)
+	assert.Equal(t, ErrRequestBodyTooLarge, err)
+	assert.Zero(t, n)
+}
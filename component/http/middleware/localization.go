@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/beatlabs/patron/i18n"
+)
+
+// NewLocalization creates a Func which negotiates the locale of the catalog best matching the Accept-Language header of each request,
+// and associates its localizer with the context of the request, which handlers retrieve with i18n.FromContext.
+// The locale is set as the Content-Language of the response, which varies by the Accept-Language header.
+func NewLocalization(catalog *i18n.Catalog) (Func, error) {
+	if catalog == nil {
+		return nil, errors.New("catalog is nil")
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			localizer := catalog.Negotiate(r.Header.Get("Accept-Language"))
+			w.Header().Set("Content-Language", localizer.Locale())
+			w.Header().Add("Vary", "Accept-Language")
+			next.ServeHTTP(w, r.WithContext(i18n.WithLocalizer(r.Context(), localizer)))
+		})
+	}, nil
+}
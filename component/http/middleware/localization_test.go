@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/beatlabs/patron/i18n"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewLocalization(t *testing.T) {
+	t.Parallel()
+	_, err := NewLocalization(nil)
+	assert.EqualError(t, err, "catalog is nil")
+
+	catalog, err := i18n.Load(fstest.MapFS{
+		"en.json": {Data: []byte(`{"greeting": "Hello!"}`)},
+		"el.json": {Data: []byte(`{"greeting": "Γεια σου!"}`)},
+	}, "en")
+	require.NoError(t, err)
+	mw, err := NewLocalization(catalog)
+	require.NoError(t, err)
+	handler := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		greeting, err := i18n.FromContext(r.Context()).Message("greeting", nil)
+		require.NoError(t, err)
+		_, _ = w.Write([]byte(greeting))
+	}))
+
+	tests := map[string]struct {
+		acceptLanguage   string
+		expectedLanguage string
+		expectedBody     string
+	}{
+		"default":     {expectedLanguage: "en", expectedBody: "Hello!"},
+		"negotiated":  {acceptLanguage: "el-GR, en;q=0.5", expectedLanguage: "el", expectedBody: "Γεια σου!"},
+		"unsupported": {acceptLanguage: "de", expectedLanguage: "en", expectedBody: "Hello!"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Accept-Language", tt.acceptLanguage)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			assert.Equal(t, tt.expectedLanguage, rec.Header().Get("Content-Language"))
+			assert.Equal(t, "Accept-Language", rec.Header().Get("Vary"))
+			assert.Equal(t, tt.expectedBody, rec.Body.String())
+		})
+	}
+}
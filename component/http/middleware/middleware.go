@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"bufio"
 	"bytes"
 	"compress/flate"
 	"compress/gzip"
@@ -8,6 +9,7 @@ import (
 	"fmt"
 	"io"
 	"math"
+	"net"
 	"net/http"
 	"net/url"
 	"runtime/debug"
@@ -17,12 +19,15 @@ import (
 	"sync"
 	"time"
 
+	"github.com/andybalholm/brotli"
 	"github.com/beatlabs/patron/component/http/auth"
 	"github.com/beatlabs/patron/component/http/cache"
 	"github.com/beatlabs/patron/correlation"
 	"github.com/beatlabs/patron/encoding"
 	"github.com/beatlabs/patron/log"
+	"github.com/beatlabs/patron/propagation"
 	"github.com/beatlabs/patron/trace"
+	"github.com/klauspost/compress/zstd"
 	"github.com/opentracing/opentracing-go"
 	"github.com/opentracing/opentracing-go/ext"
 	tracinglog "github.com/opentracing/opentracing-go/log"
@@ -36,6 +41,8 @@ const (
 
 	gzipHeader     = "gzip"
 	deflateHeader  = "deflate"
+	zstdHeader     = "zstd"
+	brotliHeader   = "br"
 	identityHeader = "identity"
 	anythingHeader = "*"
 )
@@ -94,6 +101,48 @@ func (w *responseWriter) WriteHeader(code int) {
 	w.statusHeaderWritten = true
 }
 
+// Flush implements http.Flusher, in order for streamed responses e.g. server-sent events to pass through the middlewares.
+func (w *responseWriter) Flush() {
+	if !w.statusHeaderWritten {
+		w.status = http.StatusOK
+		w.statusHeaderWritten = true
+	}
+	if f, ok := w.writer.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Push implements http.Pusher, in order for the HTTP/2 server pushes to pass through the middlewares.
+func (w *responseWriter) Push(target string, opts *http.PushOptions) error {
+	return push(w.writer, target, opts)
+}
+
+// Hijack implements http.Hijacker, in order for the connections to be upgraded e.g. to WebSockets through the middlewares.
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := hijack(w.writer)
+	if err == nil {
+		w.status = http.StatusSwitchingProtocols
+		w.statusHeaderWritten = true
+	}
+	return conn, rw, err
+}
+
+// hijack takes over the connection of the wrapped response writer, if it supports it.
+func hijack(w http.ResponseWriter) (net.Conn, *bufio.ReadWriter, error) {
+	if hijacker, ok := w.(http.Hijacker); ok {
+		return hijacker.Hijack()
+	}
+	return nil, nil, errors.New("response writer does not support hijacking")
+}
+
+// push initiates a server push with the wrapped response writer, if it supports them.
+func push(w http.ResponseWriter, target string, opts *http.PushOptions) error {
+	if pusher, ok := w.(http.Pusher); ok {
+		return pusher.Push(target, opts)
+	}
+	return http.ErrNotSupported
+}
+
 // Func type declaration of middleware func.
 type Func func(next http.Handler) http.Handler
 
@@ -146,7 +195,7 @@ func NewAuth(auth auth.Authenticator) Func {
 func NewLoggingTracing(path string, statusCodeLogger StatusCodeLoggerHandler) Func {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			corID := correlation.GetOrSetHeaderID(r.Header)
+			corID := correlationID(r.Header)
 			sp, r := span(path, corID, r)
 			lw := newResponseWriter(w, true)
 			next.ServeHTTP(lw, r)
@@ -163,7 +212,7 @@ func NewLoggingTracing(path string, statusCodeLogger StatusCodeLoggerHandler) Fu
 func NewInjectObservability() Func {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			corID := correlation.GetOrSetHeaderID(r.Header)
+			corID := correlationID(r.Header)
 			ctx := correlation.ContextWithID(r.Context(), corID)
 			logger := log.Sub(map[string]interface{}{correlation.ID: corID})
 			ctx = log.WithContext(ctx, logger)
@@ -172,6 +221,25 @@ func NewInjectObservability() Func {
 	}
 }
 
+// NewRequestScopedLogger creates a Func that stores in the request context a sub-logger with
+// the method, path, correlation ID and remote address of the request attached,
+// so that handlers can acquire request-correlated logging via log.FromContext.
+func NewRequestScopedLogger() Func {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			corID := correlationID(r.Header)
+			ctx := correlation.ContextWithID(r.Context(), corID)
+			logger := log.Sub(map[string]interface{}{
+				correlation.ID:   corID,
+				"method":         r.Method,
+				"path":           r.URL.Path,
+				"remote-address": remoteAddress(r),
+			})
+			next.ServeHTTP(w, r.WithContext(log.WithContext(ctx, logger)))
+		})
+	}
+}
+
 func initHTTPServerMetrics() {
 	httpStatusTracingHandledMetric = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
@@ -287,7 +355,8 @@ func addWithWeight(mapWeighted map[float64]string, weight float64, algorithm str
 }
 
 func notSupportedCompression(algorithm string) bool {
-	return gzipHeader != algorithm && deflateHeader != algorithm && anythingHeader != algorithm && identityHeader != algorithm
+	return gzipHeader != algorithm && deflateHeader != algorithm && zstdHeader != algorithm && brotliHeader != algorithm &&
+		anythingHeader != algorithm && identityHeader != algorithm
 }
 
 // When not present, the default value is 1 according to https://developer.mozilla.org/en-US/docs/Glossary/Quality_values
@@ -322,7 +391,8 @@ func selectByWeight(weighted map[float64]string) (string, error) {
 }
 
 // NewCompression initializes a compression middleware.
-// As per Section 3.5 of the HTTP/1.1 RFC, GZIP and Deflate compression methods are supported.
+// As per Section 3.5 of the HTTP/1.1 RFC, GZIP and Deflate compression methods are supported,
+// as well as Zstandard as per RFC 8878 and Brotli as per RFC 7932.
 // https://tools.ietf.org/html/rfc2616#section-14.3
 func NewCompression(deflateLevel int, ignoreRoutes ...string) Func {
 	return func(next http.Handler) http.Handler {
@@ -418,6 +488,13 @@ func (w *dynamicCompressionResponseWriter) WriteHeader(statusCode int) {
 			return
 		}
 
+		// responses already encoded e.g. by a route compression middleware are not compressed twice
+		if w.ResponseWriter.Header().Get(encoding.ContentEncodingHeader) != "" {
+			w.writer = w.ResponseWriter
+			w.ResponseWriter.WriteHeader(statusCode)
+			return
+		}
+
 		switch w.Encoding {
 		case gzipHeader:
 			w.writer = gzip.NewWriter(w.ResponseWriter)
@@ -432,6 +509,19 @@ func (w *dynamicCompressionResponseWriter) WriteHeader(statusCode int) {
 				w.ResponseWriter.Header().Set(encoding.ContentEncodingHeader, deflateHeader)
 				w.ResponseWriter.Header().Del("Content-Length")
 			}
+		case zstdHeader:
+			zw, err := zstd.NewWriter(w.ResponseWriter, zstd.WithEncoderLevel(zstdLevel(w.deflateLevel)), zstd.WithEncoderConcurrency(1))
+			if err != nil {
+				w.writer = w.ResponseWriter
+			} else {
+				w.writer = zw
+				w.ResponseWriter.Header().Set(encoding.ContentEncodingHeader, zstdHeader)
+				w.ResponseWriter.Header().Del("Content-Length")
+			}
+		case brotliHeader:
+			w.writer = brotli.NewWriterLevel(w.ResponseWriter, brotliLevel(w.deflateLevel))
+			w.ResponseWriter.Header().Set(encoding.ContentEncodingHeader, brotliHeader)
+			w.ResponseWriter.Header().Del("Content-Length")
 		case identityHeader, "":
 			w.ResponseWriter.Header().Set(encoding.ContentEncodingHeader, identityHeader)
 			fallthrough
@@ -459,13 +549,37 @@ func (w *dynamicCompressionResponseWriter) Close() error {
 	return nil
 }
 
+// Flush writes the compressed data buffered by the encoder, before flushing the response.
+func (w *dynamicCompressionResponseWriter) Flush() {
+	if w.statusCode == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	if f, ok := w.writer.(interface{ Flush() error }); ok {
+		if err := f.Flush(); err != nil {
+			log.Errorf("failed to flush compressed response: %v", err)
+			return
+		}
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *dynamicCompressionResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return hijack(w.ResponseWriter)
+}
+
+func (w *dynamicCompressionResponseWriter) Push(target string, opts *http.PushOptions) error {
+	return push(w.ResponseWriter, target, opts)
+}
+
 // NewCaching creates a cache layer as a middleware
 // when used as part of a middleware chain any middleware later in the chain,
 // will not be executed, but the headers it appends will be part of the cache.
 func NewCaching(rc *cache.RouteCache) Func {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			if r.Method != http.MethodGet {
+			if !rc.CacheableMethod(r.Method) {
 				next.ServeHTTP(w, r)
 				return
 			}
@@ -491,22 +605,33 @@ func logRequestResponse(corID string, w *responseWriter, r *http.Request) {
 		return
 	}
 
-	remoteAddr := r.RemoteAddr
-	if i := strings.LastIndex(remoteAddr, ":"); i != -1 {
-		remoteAddr = remoteAddr[:i]
-	}
-
 	info := map[string]interface{}{
 		correlation.ID:   corID,
 		"method":         r.Method,
 		"url":            r.URL,
 		"status":         w.Status(),
-		"remote-address": remoteAddr,
+		"remote-address": remoteAddress(r),
 		"proto":          r.Proto,
 	}
 	log.FromContext(r.Context()).Sub(info).Debug("request log")
 }
 
+func remoteAddress(r *http.Request) string {
+	remoteAddr := r.RemoteAddr
+	if i := strings.LastIndex(remoteAddr, ":"); i != -1 {
+		remoteAddr = remoteAddr[:i]
+	}
+	return remoteAddr
+}
+
+// correlationID returns the correlation ID of the request headers, setting a new one if it is missing.
+func correlationID(h http.Header) string {
+	c := propagation.HTTPHeaders(h)
+	corID := propagation.CorrelationID(c)
+	c.Set(propagation.CorrelationHeader(), corID)
+	return corID
+}
+
 func span(path, corID string, r *http.Request) (opentracing.Span, *http.Request) {
 	ctx, err := opentracing.GlobalTracer().Extract(opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier(r.Header))
 	if err != nil && !errors.Is(err, opentracing.ErrSpanContextNotFound) {
@@ -525,6 +650,7 @@ func span(path, corID string, r *http.Request) (opentracing.Span, *http.Request)
 	ext.Component.Set(sp, serverComponent)
 	sp.SetTag(trace.VersionTag, trace.Version)
 	sp.SetTag(correlation.ID, corID)
+	propagation.SetBaggage(sp, propagation.HTTPHeaders(r.Header))
 	return sp, r.WithContext(opentracing.ContextWithSpan(r.Context(), sp))
 }
 
@@ -1,13 +1,20 @@
 package middleware
 
 import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
 	httpcache "github.com/beatlabs/patron/component/http/cache"
+	"github.com/beatlabs/patron/correlation"
+	"github.com/beatlabs/patron/log"
+	"github.com/beatlabs/patron/log/std"
 	"github.com/opentracing/opentracing-go"
 	"github.com/opentracing/opentracing-go/mocktracer"
 	"github.com/stretchr/testify/assert"
@@ -196,6 +203,77 @@ func TestResponseWriter(t *testing.T) {
 	assert.Equal(t, "test", rc.Body.String(), "body expected to be test but was %s", rc.Body.String())
 }
 
+func TestResponseWriter_Flush(t *testing.T) {
+	rc := httptest.NewRecorder()
+	rw := newResponseWriter(rc, false)
+	rw.Flush()
+	assert.Equal(t, http.StatusOK, rw.Status())
+	assert.True(t, rc.Flushed)
+}
+
+func TestDynamicCompressionResponseWriter_Flush(t *testing.T) {
+	rc := httptest.NewRecorder()
+	var flushed []byte
+	handler := NewCompression(8)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("data: 1\n\n"))
+		w.(http.Flusher).Flush()
+		flushed = append(flushed, rc.Body.Bytes()...)
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	handler.ServeHTTP(rc, req)
+	assert.True(t, rc.Flushed)
+
+	// the flushed data can be decompressed before the compressed response is complete
+	gr, err := gzip.NewReader(bytes.NewReader(flushed))
+	require.NoError(t, err)
+	data := make([]byte, 16)
+	n, _ := gr.Read(data)
+	assert.Equal(t, "data: 1\n\n", string(data[:n]))
+}
+
+type stubHijacker struct {
+	*httptest.ResponseRecorder
+}
+
+func (sh stubHijacker) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	server, client := net.Pipe()
+	_ = client.Close()
+	return server, nil, nil
+}
+
+func TestResponseWriter_Hijack(t *testing.T) {
+	rw := newResponseWriter(httptest.NewRecorder(), false)
+	_, _, err := rw.Hijack()
+	assert.EqualError(t, err, "response writer does not support hijacking")
+
+	rw = newResponseWriter(stubHijacker{ResponseRecorder: httptest.NewRecorder()}, false)
+	conn, _, err := rw.Hijack()
+	require.NoError(t, err)
+	assert.NoError(t, conn.Close())
+	assert.Equal(t, http.StatusSwitchingProtocols, rw.Status())
+}
+
+type stubPusher struct {
+	*httptest.ResponseRecorder
+	targets []string
+}
+
+func (sp *stubPusher) Push(target string, _ *http.PushOptions) error {
+	sp.targets = append(sp.targets, target)
+	return nil
+}
+
+func TestResponseWriter_Push(t *testing.T) {
+	rw := newResponseWriter(httptest.NewRecorder(), false)
+	assert.Equal(t, http.ErrNotSupported, rw.Push("/app.css", nil))
+
+	pusher := &stubPusher{ResponseRecorder: httptest.NewRecorder()}
+	rw = newResponseWriter(pusher, false)
+	assert.NoError(t, rw.Push("/app.css", nil))
+	assert.Equal(t, []string{"/app.css"}, pusher.targets)
+}
+
 func TestStripQueryString(t *testing.T) {
 	t.Parallel()
 	type args struct {
@@ -765,6 +843,28 @@ func TestNewInjectObservability(t *testing.T) {
 	assert.Equal(t, 200, rc.Code)
 }
 
+func TestNewRequestScopedLogger(t *testing.T) {
+	var b bytes.Buffer
+	require.NoError(t, log.Setup(std.New(&b, log.DebugLevel, nil)))
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "123", correlation.IDFromContext(r.Context()))
+		log.FromContext(r.Context()).Info("handled")
+		w.WriteHeader(http.StatusAccepted)
+	})
+
+	req, err := http.NewRequest(http.MethodPost, "/api/items?id=1", nil)
+	require.NoError(t, err)
+	req.Header.Set(correlation.HeaderID, "123")
+	req.RemoteAddr = "10.0.0.1:4321"
+
+	rc := httptest.NewRecorder()
+	NewRequestScopedLogger()(handler).ServeHTTP(rc, req)
+
+	assert.Equal(t, http.StatusAccepted, rc.Code)
+	assert.Contains(t, b.String(), "correlationID=123 method=POST path=/api/items remote-address=10.0.0.1 handled")
+}
+
 func TestNewCaching(t *testing.T) {
 	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(200) })
 	middleware := NewCaching(&httpcache.RouteCache{})
@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/beatlabs/patron/slo"
+)
+
+// NewSLO creates a Func which records the requests as events of the service level objective,
+// which are successful unless their response status is 5xx or their handler panics.
+func NewSLO(objective *slo.Objective) (Func, error) {
+	if objective == nil {
+		return nil, errors.New("objective is nil")
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			lw := newResponseWriter(w, false)
+			defer func() {
+				// panics are recorded as failures, before being recovered by the recovery middleware
+				if p := recover(); p != nil {
+					objective.Record(false, time.Since(start))
+					panic(p)
+				}
+			}()
+			next.ServeHTTP(lw, r)
+			objective.Record(lw.Status() < http.StatusInternalServerError, time.Since(start))
+		})
+	}, nil
+}
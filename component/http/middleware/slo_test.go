@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/beatlabs/patron/slo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSLO(t *testing.T) {
+	t.Parallel()
+	_, err := NewSLO(nil)
+	assert.EqualError(t, err, "objective is nil")
+
+	objective, err := slo.New("middleware-test", 0.5, slo.BurnRateWindows(time.Minute))
+	require.NoError(t, err)
+	t.Cleanup(objective.Close)
+	mw, err := NewSLO(objective)
+	require.NoError(t, err)
+
+	for _, status := range []int{http.StatusOK, http.StatusNotFound, http.StatusServiceUnavailable, http.StatusInternalServerError} {
+		status := status
+		handler := mw(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(status)
+		}))
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	}
+	// 2 bad out of 4 events exhaust the error budget of 50%
+	assert.Equal(t, 1.0, objective.BurnRate(time.Minute))
+
+	panicking := mw(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic("failure")
+	}))
+	assert.Panics(t, func() {
+		panicking.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	})
+	assert.Equal(t, 1.2, objective.BurnRate(time.Minute))
+}
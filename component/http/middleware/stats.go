@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/beatlabs/patron/stats"
+)
+
+// NewStats creates a Func which observes the latency of the requests in the latency window, and counts the failed ones,
+// i.e. whose response status is 5xx or whose handler panics, in the optional failures window, so that the middleware and the handlers
+// following it can query them at runtime, e.g. to shed load while the latency or the failure rate is high.
+func NewStats(latency, failures *stats.Window) (Func, error) {
+	if latency == nil {
+		return nil, errors.New("latency window is nil")
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			lw := newResponseWriter(w, false)
+			defer func() {
+				// panics are counted as failures, before being recovered by the recovery middleware
+				if p := recover(); p != nil {
+					latency.ObserveDuration(time.Since(start))
+					if failures != nil {
+						failures.Inc()
+					}
+					panic(p)
+				}
+			}()
+			next.ServeHTTP(lw, r)
+			latency.ObserveDuration(time.Since(start))
+			if failures != nil && lw.Status() >= http.StatusInternalServerError {
+				failures.Inc()
+			}
+		})
+	}, nil
+}
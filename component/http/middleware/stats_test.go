@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/beatlabs/patron/stats"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewStats(t *testing.T) {
+	t.Parallel()
+	_, err := NewStats(nil, nil)
+	assert.EqualError(t, err, "latency window is nil")
+
+	latency, err := stats.NewWindow(time.Minute)
+	require.NoError(t, err)
+	failures, err := stats.NewWindow(time.Minute)
+	require.NoError(t, err)
+	mw, err := NewStats(latency, failures)
+	require.NoError(t, err)
+
+	for _, status := range []int{http.StatusOK, http.StatusNotFound, http.StatusInternalServerError} {
+		status := status
+		handler := mw(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(status)
+		}))
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	}
+	assert.Equal(t, uint64(3), latency.Count(time.Minute))
+	assert.Equal(t, uint64(1), failures.Count(time.Minute))
+
+	panicking := mw(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic("failure")
+	}))
+	assert.Panics(t, func() {
+		panicking.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	})
+	assert.Equal(t, uint64(4), latency.Count(time.Minute))
+	assert.Equal(t, uint64(2), failures.Count(time.Minute))
+
+	// the failures window is optional
+	mw, err = NewStats(latency, nil)
+	require.NoError(t, err)
+	mw(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, uint64(5), latency.Count(time.Minute))
+}
@@ -0,0 +1,168 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/beatlabs/patron/component/http/problem"
+	"github.com/beatlabs/patron/log"
+)
+
+// NewTimeout creates a Func which limits the duration of the handler to the provided timeout. The handler runs with a context
+// which is canceled once the timeout is exceeded, and its response is buffered, so that it can be replaced by a problem with the
+// provided status, i.e. 503 Service Unavailable or 504 Gateway Timeout, e.g. when the handler waits for a slow upstream service.
+// The writes of the handler fail with http.ErrHandlerTimeout after the timeout, and its panics are propagated to the server.
+// Since the response is buffered, it should not be used for streamed responses, e.g. event streams or WebSockets.
+func NewTimeout(timeout time.Duration, status int) (Func, error) {
+	if timeout <= 0 {
+		return nil, errors.New("timeout should be positive")
+	}
+	if status != http.StatusServiceUnavailable && status != http.StatusGatewayTimeout {
+		return nil, fmt.Errorf("timeout status should be %d or %d", http.StatusServiceUnavailable, http.StatusGatewayTimeout)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tw := &timeoutResponseWriter{header: make(http.Header)}
+			parent := r.Context()
+			ctx, cancel := context.WithCancel(parent)
+			defer cancel()
+			expired := make(chan struct{})
+			// the response is discarded before the context of the handler is canceled, so that late writes always fail
+			timer := time.AfterFunc(timeout, func() {
+				tw.timeout()
+				close(expired)
+				cancel()
+			})
+			defer timer.Stop()
+			r = r.WithContext(&timeoutContext{Context: ctx, deadline: time.Now().Add(timeout), expired: expired})
+
+			done := make(chan struct{})
+			panicked := make(chan interface{}, 1)
+			go func() {
+				defer func() {
+					if p := recover(); p != nil {
+						panicked <- p
+					}
+				}()
+				next.ServeHTTP(tw, r)
+				close(done)
+			}()
+
+			select {
+			case p := <-panicked:
+				panic(p)
+			case <-done:
+				if tw.writeTo(w) {
+					return
+				}
+			case <-expired:
+			case <-parent.Done():
+				// the client went away, so there is no one to respond to
+				tw.timeout()
+				return
+			}
+			log.FromContext(r.Context()).Warnf("handler of %s %s exceeded timeout of %v", r.Method, r.URL.Path, timeout)
+			problem.Write(w, r, problem.New(status, fmt.Sprintf("request exceeded timeout of %v", timeout)))
+		})
+	}, nil
+}
+
+// timeoutContext is the context of the handler, which reports the deadline of the timeout,
+// and is canceled with context.DeadlineExceeded once the response of the handler has been discarded.
+type timeoutContext struct {
+	context.Context
+	deadline time.Time
+	expired  <-chan struct{}
+}
+
+func (c *timeoutContext) Deadline() (time.Time, bool) {
+	if d, ok := c.Context.Deadline(); ok && d.Before(c.deadline) {
+		return d, true
+	}
+	return c.deadline, true
+}
+
+func (c *timeoutContext) Err() error {
+	err := c.Context.Err()
+	if err == nil {
+		return nil
+	}
+	select {
+	case <-c.expired:
+		return context.DeadlineExceeded
+	default:
+		return err
+	}
+}
+
+// timeoutResponseWriter buffers the response of the handler, until it completes or exceeds the timeout.
+type timeoutResponseWriter struct {
+	mu          sync.Mutex
+	header      http.Header
+	buf         bytes.Buffer
+	status      int
+	wroteHeader bool
+	timedOut    bool
+}
+
+func (tw *timeoutResponseWriter) Header() http.Header {
+	return tw.header
+}
+
+func (tw *timeoutResponseWriter) WriteHeader(statusCode int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut || tw.wroteHeader {
+		return
+	}
+	tw.wroteHeader = true
+	tw.status = statusCode
+}
+
+func (tw *timeoutResponseWriter) Write(p []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if !tw.wroteHeader {
+		tw.wroteHeader = true
+		tw.status = http.StatusOK
+	}
+	return tw.buf.Write(p)
+}
+
+// timeout discards the response, failing the subsequent writes of the handler.
+func (tw *timeoutResponseWriter) timeout() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	tw.timedOut = true
+	tw.buf.Reset()
+}
+
+// writeTo writes the buffered response of the completed handler, unless it has been discarded.
+func (tw *timeoutResponseWriter) writeTo(w http.ResponseWriter) bool {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return false
+	}
+	dst := w.Header()
+	for k, vv := range tw.header {
+		dst[k] = vv
+	}
+	if !tw.wroteHeader {
+		tw.status = http.StatusOK
+	}
+	w.WriteHeader(tw.status)
+	if _, err := w.Write(tw.buf.Bytes()); err != nil {
+		log.Errorf("failed to write response: %v", err)
+	}
+	return true
+}
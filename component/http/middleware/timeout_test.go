@@ -0,0 +1,153 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/beatlabs/patron/component/http/problem"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTimeout(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		timeout     time.Duration
+		status      int
+		expectedErr string
+	}{
+		"service unavailable": {timeout: time.Second, status: http.StatusServiceUnavailable},
+		"gateway timeout":     {timeout: time.Second, status: http.StatusGatewayTimeout},
+		"zero timeout":        {timeout: 0, status: http.StatusGatewayTimeout, expectedErr: "timeout should be positive"},
+		"invalid status":      {timeout: time.Second, status: http.StatusInternalServerError, expectedErr: "timeout status should be 503 or 504"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			got, err := NewTimeout(tt.timeout, tt.status)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, got)
+			}
+		})
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	t.Parallel()
+	lateWrite := make(chan error, 1)
+	tests := map[string]struct {
+		status         int
+		handler        http.HandlerFunc
+		expectedStatus int
+		expectedBody   string
+		expectedHeader string
+		lateWrite      chan error
+	}{
+		"completed": {
+			status: http.StatusGatewayTimeout,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Custom", "value")
+				w.WriteHeader(http.StatusCreated)
+				_, _ = w.Write([]byte("created"))
+			},
+			expectedStatus: http.StatusCreated,
+			expectedBody:   "created",
+			expectedHeader: "value",
+		},
+		"completed without status": {
+			status: http.StatusGatewayTimeout,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+			},
+			expectedStatus: http.StatusOK,
+		},
+		"exceeded": {
+			status: http.StatusGatewayTimeout,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Custom", "value")
+				<-r.Context().Done()
+				_, err := w.Write([]byte("late"))
+				lateWrite <- err
+			},
+			expectedStatus: http.StatusGatewayTimeout,
+			expectedBody:   `"detail":"request exceeded timeout of 50ms"`,
+			lateWrite:      lateWrite,
+		},
+		"exceeded with service unavailable": {
+			status: http.StatusServiceUnavailable,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				<-r.Context().Done()
+			},
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedBody:   `"status":503`,
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			mw, err := NewTimeout(50*time.Millisecond, tt.status)
+			require.NoError(t, err)
+			rsp := httptest.NewRecorder()
+			mw(tt.handler).ServeHTTP(rsp, httptest.NewRequest(http.MethodGet, "/orders", nil))
+			assert.Equal(t, tt.expectedStatus, rsp.Code)
+			assert.Contains(t, rsp.Body.String(), tt.expectedBody)
+			assert.Equal(t, tt.expectedHeader, rsp.Header().Get("X-Custom"))
+			if tt.expectedStatus == tt.status {
+				assert.Equal(t, problem.ContentType, rsp.Header().Get("Content-Type"))
+			}
+			if tt.lateWrite != nil {
+				assert.Equal(t, http.ErrHandlerTimeout, <-tt.lateWrite)
+			}
+		})
+	}
+}
+
+func TestTimeout_Context(t *testing.T) {
+	t.Parallel()
+	mw, err := NewTimeout(50*time.Millisecond, http.StatusGatewayTimeout)
+	require.NoError(t, err)
+	errs := make(chan error, 1)
+	start := time.Now()
+	mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		deadline, ok := r.Context().Deadline()
+		assert.True(t, ok)
+		assert.WithinDuration(t, start.Add(50*time.Millisecond), deadline, 10*time.Millisecond)
+		assert.NoError(t, r.Context().Err())
+		<-r.Context().Done()
+		errs <- r.Context().Err()
+	})).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/orders", nil))
+	assert.Equal(t, context.DeadlineExceeded, <-errs)
+}
+
+func TestTimeout_ClientGone(t *testing.T) {
+	t.Parallel()
+	mw, err := NewTimeout(time.Minute, http.StatusGatewayTimeout)
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	rsp := httptest.NewRecorder()
+	mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	})).ServeHTTP(rsp, httptest.NewRequest(http.MethodGet, "/orders", nil).WithContext(ctx))
+	assert.Empty(t, rsp.Body.String())
+	assert.False(t, rsp.Flushed)
+}
+
+func TestTimeout_Panic(t *testing.T) {
+	t.Parallel()
+	mw, err := NewTimeout(time.Minute, http.StatusGatewayTimeout)
+	require.NoError(t, err)
+	assert.PanicsWithValue(t, "failure", func() {
+		mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic("failure")
+		})).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/orders", nil))
+	})
+}
@@ -0,0 +1,241 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/beatlabs/patron/encoding"
+	"github.com/beatlabs/patron/i18n"
+	"github.com/beatlabs/patron/log"
+)
+
+const (
+	acceptLanguageHeader  = "Accept-Language"
+	contentLanguageHeader = "Content-Language"
+	contentLengthHeader   = "Content-Length"
+	varyHeader            = "Vary"
+)
+
+// Response is the buffered response of a handler, as passed through the transformations.
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// TransformFunc transforms the buffered response of a handler.
+type TransformFunc func(r *http.Request, rsp *Response) error
+
+// LocalizeFunc formats the response according to the negotiated locale.
+type LocalizeFunc func(locale string, rsp *Response) error
+
+// NewTransformation creates a Func which buffers the response of the next handler and runs it through the transformations,
+// in the provided order, before writing it. Placed after the caching middleware in a chain, the transformed response is cached.
+// If a transformation fails, a 500 Internal Server Error is returned instead.
+func NewTransformation(tt ...TransformFunc) (Func, error) {
+	if len(tt) == 0 {
+		return nil, errors.New("transformations are empty")
+	}
+	for _, t := range tt {
+		if t == nil {
+			return nil, errors.New("transformation is nil")
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			bw := &bufferedResponseWriter{header: make(http.Header)}
+			next.ServeHTTP(bw, r)
+
+			rsp := &Response{StatusCode: bw.statusCode(), Header: bw.header, Body: bw.body.Bytes()}
+			for _, t := range tt {
+				if err := t(r, rsp); err != nil {
+					log.FromContext(r.Context()).Errorf("failed to transform response: %v", err)
+					http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+					return
+				}
+			}
+
+			for k, vv := range rsp.Header {
+				w.Header()[k] = vv
+			}
+			w.Header().Del(contentLengthHeader)
+			w.WriteHeader(rsp.StatusCode)
+			if _, err := w.Write(rsp.Body); err != nil {
+				log.FromContext(r.Context()).Errorf("failed to write transformed response: %v", err)
+			}
+		})
+	}, nil
+}
+
+// FieldFilter creates a transformation which keeps only the fields of a JSON response listed in the query parameter,
+// e.g. `?fields=id,name,address.city`. Nested fields are selected with dot notation and arrays are filtered element-wise.
+// Non successful and non JSON responses, as well as requests without the query parameter, are left intact.
+func FieldFilter(param string) TransformFunc {
+	return func(r *http.Request, rsp *Response) error {
+		if !successfulJSON(rsp) {
+			return nil
+		}
+		fields := r.URL.Query().Get(param)
+		if fields == "" {
+			return nil
+		}
+
+		var v interface{}
+		if err := json.Unmarshal(rsp.Body, &v); err != nil {
+			return fmt.Errorf("failed to decode response for field filtering: %w", err)
+		}
+		body, err := json.Marshal(filterFields(v, fieldTree(fields)))
+		if err != nil {
+			return fmt.Errorf("failed to encode filtered response: %w", err)
+		}
+		rsp.Body = body
+		return nil
+	}
+}
+
+// Envelope creates a transformation which wraps a successful JSON response in an object under the provided key,
+// e.g. `{"data": <response>}`.
+func Envelope(key string) TransformFunc {
+	return func(_ *http.Request, rsp *Response) error {
+		if !successfulJSON(rsp) {
+			return nil
+		}
+		body, err := json.Marshal(map[string]json.RawMessage{key: bytes.TrimSpace(rsp.Body)})
+		if err != nil {
+			return fmt.Errorf("failed to wrap response in envelope: %w", err)
+		}
+		rsp.Body = body
+		return nil
+	}
+}
+
+// Localize creates a transformation which negotiates the locale of the response among the supported ones,
+// based on the Accept-Language header of the request, and calls the provided function to format the response.
+// The first supported locale is used as the fallback. The Content-Language header of the response is set accordingly.
+func Localize(supported []string, fn LocalizeFunc) TransformFunc {
+	return func(r *http.Request, rsp *Response) error {
+		if fn == nil || len(supported) == 0 {
+			return nil
+		}
+		locale := negotiateLocale(r.Header.Get(acceptLanguageHeader), supported)
+		if err := fn(locale, rsp); err != nil {
+			return err
+		}
+		rsp.Header.Set(contentLanguageHeader, locale)
+		rsp.Header.Add(varyHeader, acceptLanguageHeader)
+		return nil
+	}
+}
+
+func successfulJSON(rsp *Response) bool {
+	return rsp.StatusCode >= 200 && rsp.StatusCode < 300 && len(rsp.Body) > 0 &&
+		strings.Contains(rsp.Header.Get(encoding.ContentTypeHeader), "json")
+}
+
+// fieldTree converts a comma separated list of dotted field paths into a tree of fields.
+func fieldTree(fields string) map[string]interface{} {
+	tree := make(map[string]interface{})
+	for _, field := range strings.Split(fields, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		node := tree
+		parts := strings.Split(field, ".")
+		for i, part := range parts {
+			if i == len(parts)-1 {
+				node[part] = nil
+				break
+			}
+			child, ok := node[part].(map[string]interface{})
+			if !ok {
+				if _, leaf := node[part]; leaf {
+					// the parent field is already selected as a whole
+					break
+				}
+				child = make(map[string]interface{})
+				node[part] = child
+			}
+			node = child
+		}
+	}
+	return tree
+}
+
+func filterFields(v interface{}, tree map[string]interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		filtered := make(map[string]interface{}, len(tree))
+		for key, sub := range tree {
+			fv, ok := val[key]
+			if !ok {
+				continue
+			}
+			if subTree, ok := sub.(map[string]interface{}); ok {
+				filtered[key] = filterFields(fv, subTree)
+			} else {
+				filtered[key] = fv
+			}
+		}
+		return filtered
+	case []interface{}:
+		filtered := make([]interface{}, 0, len(val))
+		for _, item := range val {
+			filtered = append(filtered, filterFields(item, tree))
+		}
+		return filtered
+	default:
+		return v
+	}
+}
+
+// negotiateLocale returns the supported locale with the highest weight in the Accept-Language header,
+// matching either exactly or by primary language subtag e.g. `en-US` matches `en`.
+func negotiateLocale(header string, supported []string) string {
+	for _, locale := range i18n.ParseAcceptLanguage(header) {
+		tag := strings.ToLower(locale)
+		for _, s := range supported {
+			ls := strings.ToLower(s)
+			if tag == ls || strings.SplitN(tag, "-", 2)[0] == ls || strings.SplitN(ls, "-", 2)[0] == tag {
+				return s
+			}
+		}
+	}
+	return supported[0]
+}
+
+// bufferedResponseWriter buffers the response, so that it can be transformed before it gets written.
+type bufferedResponseWriter struct {
+	status int
+	header http.Header
+	body   bytes.Buffer
+}
+
+func (bw *bufferedResponseWriter) Header() http.Header {
+	return bw.header
+}
+
+func (bw *bufferedResponseWriter) Write(p []byte) (int, error) {
+	if bw.status == 0 {
+		bw.status = http.StatusOK
+	}
+	return bw.body.Write(p)
+}
+
+func (bw *bufferedResponseWriter) WriteHeader(statusCode int) {
+	if bw.status == 0 {
+		bw.status = statusCode
+	}
+}
+
+func (bw *bufferedResponseWriter) statusCode() int {
+	if bw.status == 0 {
+		return http.StatusOK
+	}
+	return bw.status
+}
@@ -0,0 +1,164 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func jsonHandler(status int, body string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	})
+}
+
+func TestNewTransformation(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		tt          []TransformFunc
+		expectedErr string
+	}{
+		"success":       {tt: []TransformFunc{Envelope("data")}},
+		"empty":         {expectedErr: "transformations are empty"},
+		"nil transform": {tt: []TransformFunc{Envelope("data"), nil}, expectedErr: "transformation is nil"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			got, err := NewTransformation(tt.tt...)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, got)
+			}
+		})
+	}
+}
+
+func TestTransformation(t *testing.T) {
+	t.Parallel()
+	failing := func(*http.Request, *Response) error { return errors.New("failed") }
+	tests := map[string]struct {
+		handler        http.Handler
+		tt             []TransformFunc
+		url            string
+		expectedStatus int
+		expectedBody   string
+	}{
+		"filter and envelope": {
+			handler:        jsonHandler(http.StatusOK, `{"id":1,"name":"john","address":{"city":"athens","street":"main"}}`),
+			tt:             []TransformFunc{FieldFilter("fields"), Envelope("data")},
+			url:            "/?fields=id,address.city",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"data":{"address":{"city":"athens"},"id":1}}`,
+		},
+		"filter array": {
+			handler:        jsonHandler(http.StatusOK, `[{"id":1,"name":"john"},{"id":2,"name":"jane"}]`),
+			tt:             []TransformFunc{FieldFilter("fields")},
+			url:            "/?fields=name",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `[{"name":"john"},{"name":"jane"}]`,
+		},
+		"no filter parameter": {
+			handler:        jsonHandler(http.StatusOK, `{"id":1,"name":"john"}`),
+			tt:             []TransformFunc{FieldFilter("fields")},
+			url:            "/",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"id":1,"name":"john"}`,
+		},
+		"error response left intact": {
+			handler:        jsonHandler(http.StatusNotFound, `{"error":"not found"}`),
+			tt:             []TransformFunc{FieldFilter("fields"), Envelope("data")},
+			url:            "/?fields=id",
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"error":"not found"}`,
+		},
+		"invalid json": {
+			handler:        jsonHandler(http.StatusOK, `{"id":`),
+			tt:             []TransformFunc{FieldFilter("fields")},
+			url:            "/?fields=id",
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   "Internal Server Error\n",
+		},
+		"failing transformation": {
+			handler:        jsonHandler(http.StatusCreated, `{}`),
+			tt:             []TransformFunc{failing},
+			url:            "/",
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   "Internal Server Error\n",
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			mw, err := NewTransformation(tt.tt...)
+			require.NoError(t, err)
+			rc := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+
+			mw(tt.handler).ServeHTTP(rc, req)
+
+			assert.Equal(t, tt.expectedStatus, rc.Code)
+			assert.Equal(t, tt.expectedBody, rc.Body.String())
+		})
+	}
+}
+
+func TestLocalize(t *testing.T) {
+	t.Parallel()
+	supported := []string{"en", "el", "pt-BR"}
+	tests := map[string]struct {
+		acceptLanguage string
+		expected       string
+	}{
+		"no header":     {expected: "en"},
+		"exact":         {acceptLanguage: "el", expected: "el"},
+		"region":        {acceptLanguage: "el-GR", expected: "el"},
+		"primary tag":   {acceptLanguage: "pt", expected: "pt-BR"},
+		"weighted":      {acceptLanguage: "de;q=0.9, el;q=0.5, pt-BR;q=0.8", expected: "pt-BR"},
+		"zero weight":   {acceptLanguage: "el;q=0", expected: "en"},
+		"not supported": {acceptLanguage: "fr-FR,fr", expected: "en"},
+		"wildcard":      {acceptLanguage: "fr, *;q=0.5", expected: "en"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			mw, err := NewTransformation(Localize(supported, func(locale string, rsp *Response) error {
+				rsp.Body = []byte(strings.ToUpper(locale))
+				return nil
+			}))
+			require.NoError(t, err)
+			rc := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.acceptLanguage != "" {
+				req.Header.Set("Accept-Language", tt.acceptLanguage)
+			}
+
+			mw(jsonHandler(http.StatusOK, `{}`)).ServeHTTP(rc, req)
+
+			assert.Equal(t, strings.ToUpper(tt.expected), rc.Body.String())
+			assert.Equal(t, tt.expected, rc.Header().Get("Content-Language"))
+			assert.Equal(t, "Accept-Language", rc.Header().Get("Vary"))
+		})
+	}
+}
+
+func TestFieldTree(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, map[string]interface{}{
+		"a": nil,
+		"b": map[string]interface{}{"c": nil, "d": map[string]interface{}{"e": nil}},
+	}, fieldTree("a, a.x, b.c,b.d.e,,"))
+}
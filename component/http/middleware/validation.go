@@ -0,0 +1,220 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strconv"
+
+	"github.com/beatlabs/patron/component/http/problem"
+	"github.com/beatlabs/patron/i18n"
+	"github.com/beatlabs/patron/validation"
+)
+
+type validatedRequestKey struct{}
+
+// NewValidation creates a Func which validates the requests against the schema of the struct type of the provided value, e.g. CreateOrder{}.
+// Every request is decoded into a new value of the type, with its JSON body, if any, and its query parameters,
+// which are bound to the fields with a query tag, e.g. `query:"page"`. The value is then validated against the rules of the validate tags
+// of its fields (see package validation), and invalid requests are rejected with a 400 Bad Request problem (see package problem)
+// before reaching the handler, whose errors member lists the fields failing validation.
+// The handler retrieves the pointer to the validated value with ValidatedRequest, while the body remains available to read.
+func NewValidation(v interface{}) (Func, error) {
+	schema, err := validation.Compile(v)
+	if err != nil {
+		return nil, err
+	}
+	fields, err := queryFields(schema.Type())
+	if err != nil {
+		return nil, err
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			value := reflect.New(schema.Type())
+
+			if r.Body != nil && r.Body != http.NoBody {
+				body, err := ioutil.ReadAll(r.Body)
+				if err != nil {
+					if errors.Is(err, ErrRequestBodyTooLarge) {
+						rejectTooLarge(w)
+						return
+					}
+					writeValidationProblem(w, r, "failed to read request body", nil)
+					return
+				}
+				r.Body = ioutil.NopCloser(bytes.NewReader(body))
+				if len(bytes.TrimSpace(body)) > 0 {
+					if err := json.Unmarshal(body, value.Interface()); err != nil {
+						writeValidationProblem(w, r, fmt.Sprintf("request body is not valid JSON: %v", err), nil)
+						return
+					}
+				}
+			}
+
+			fieldErrors := bindQuery(value.Elem(), fields, r)
+			if len(fieldErrors) == 0 {
+				fieldErrors, err = schema.Validate(value.Interface())
+				if err != nil {
+					problem.Write(w, r, fmt.Errorf("failed to validate request: %w", err))
+					return
+				}
+			}
+			if len(fieldErrors) > 0 {
+				writeValidationProblem(w, r, "request validation failed", fieldErrors)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), validatedRequestKey{}, value.Interface())))
+		})
+	}, nil
+}
+
+// ValidatedRequest returns the pointer to the value decoded from the request and validated by the validation middleware, e.g. *CreateOrder.
+func ValidatedRequest(ctx context.Context) (interface{}, bool) {
+	v := ctx.Value(validatedRequestKey{})
+	return v, v != nil
+}
+
+type queryField struct {
+	index int
+	name  string
+}
+
+// queryFields returns the fields bound to query parameters, which should be of a basic type or a slice of one.
+func queryFields(t reflect.Type) ([]queryField, error) {
+	var ff []queryField
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name := sf.Tag.Get("query")
+		if name == "" || sf.PkgPath != "" {
+			continue
+		}
+		ft := sf.Type
+		if ft.Kind() == reflect.Slice || ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if _, err := parseQueryValue(ft, ""); errors.Is(err, errUnsupportedQueryType) {
+			return nil, fmt.Errorf("type %s of query field %s is not supported", sf.Type, sf.Name)
+		}
+		ff = append(ff, queryField{index: i, name: name})
+	}
+	return ff, nil
+}
+
+var errUnsupportedQueryType = errors.New("unsupported query type")
+
+func bindQuery(v reflect.Value, ff []queryField, r *http.Request) []validation.FieldError {
+	if len(ff) == 0 {
+		return nil
+	}
+	query := r.URL.Query()
+	var ee []validation.FieldError
+	for _, f := range ff {
+		values, ok := query[f.name]
+		if !ok || len(values) == 0 {
+			continue
+		}
+		fv := v.Field(f.index)
+		var err error
+		switch fv.Kind() {
+		case reflect.Slice:
+			sv := reflect.MakeSlice(fv.Type(), 0, len(values))
+			for _, value := range values {
+				var ev reflect.Value
+				ev, err = parseQueryValue(fv.Type().Elem(), value)
+				if err != nil {
+					break
+				}
+				sv = reflect.Append(sv, ev)
+			}
+			if err == nil {
+				fv.Set(sv)
+			}
+		case reflect.Ptr:
+			var ev reflect.Value
+			ev, err = parseQueryValue(fv.Type().Elem(), values[0])
+			if err == nil {
+				pv := reflect.New(fv.Type().Elem())
+				pv.Elem().Set(ev)
+				fv.Set(pv)
+			}
+		default:
+			var ev reflect.Value
+			ev, err = parseQueryValue(fv.Type(), values[0])
+			if err == nil {
+				fv.Set(ev)
+			}
+		}
+		if err != nil {
+			ee = append(ee, validation.FieldError{Field: f.name, Rule: "type", Message: err.Error()})
+		}
+	}
+	return ee
+}
+
+func parseQueryValue(t reflect.Type, value string) (reflect.Value, error) {
+	v := reflect.New(t).Elem()
+	switch t.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Bool:
+		if value == "" {
+			return v, nil
+		}
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return v, errors.New("must be a boolean")
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if value == "" {
+			return v, nil
+		}
+		n, err := strconv.ParseInt(value, 10, t.Bits())
+		if err != nil {
+			return v, errors.New("must be an integer")
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if value == "" {
+			return v, nil
+		}
+		n, err := strconv.ParseUint(value, 10, t.Bits())
+		if err != nil {
+			return v, errors.New("must be a non-negative integer")
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		if value == "" {
+			return v, nil
+		}
+		n, err := strconv.ParseFloat(value, t.Bits())
+		if err != nil {
+			return v, errors.New("must be a number")
+		}
+		v.SetFloat(n)
+	default:
+		return v, errUnsupportedQueryType
+	}
+	return v, nil
+}
+
+// writeValidationProblem rejects the request with a problem, whose title is localized if the request has a localizer.
+func writeValidationProblem(w http.ResponseWriter, r *http.Request, detail string, ee []validation.FieldError) {
+	p := problem.New(http.StatusBadRequest, detail)
+	if localizer := i18n.FromContext(r.Context()); localizer != nil {
+		if lp, err := localizer.Problem(http.StatusBadRequest, "", nil); err == nil {
+			p.WithTitle(lp.Title)
+		}
+	}
+	if len(ee) > 0 {
+		p.With("errors", ee)
+	}
+	problem.Write(w, r, p)
+}
@@ -0,0 +1,162 @@
+package middleware
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/beatlabs/patron/i18n"
+	"github.com/beatlabs/patron/validation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type createOrder struct {
+	Email    string   `json:"email" validate:"required,email"`
+	Quantity int      `json:"quantity" validate:"min=1,max=10"`
+	DryRun   bool     `json:"-" query:"dry_run"`
+	Tags     []string `json:"-" query:"tag" validate:"max=2"`
+	Limit    *uint    `json:"-" query:"limit" validate:"max=100"`
+}
+
+type validationProblem struct {
+	Title         string                  `json:"title"`
+	Status        int                     `json:"status"`
+	Detail        string                  `json:"detail"`
+	Instance      string                  `json:"instance"`
+	CorrelationID string                  `json:"correlation_id"`
+	Errors        []validation.FieldError `json:"errors"`
+}
+
+func TestNewValidation(t *testing.T) {
+	t.Parallel()
+	type unsupportedQuery struct {
+		Filter map[string]string `query:"filter"`
+	}
+	_, err := NewValidation(nil)
+	assert.EqualError(t, err, "value is nil")
+	_, err = NewValidation(unsupportedQuery{})
+	assert.EqualError(t, err, "type map[string]string of query field Filter is not supported")
+
+	mw, err := NewValidation(createOrder{})
+	require.NoError(t, err)
+	handler := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		v, ok := ValidatedRequest(r.Context())
+		require.True(t, ok)
+		order := v.(*createOrder)
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		limit := "none"
+		if order.Limit != nil {
+			limit = strconv.Itoa(int(*order.Limit))
+		}
+		_, _ = fmt.Fprintf(w, "%s %d %t %v %s %s", order.Email, order.Quantity, order.DryRun, order.Tags, limit, body)
+	}))
+
+	tests := map[string]struct {
+		query          string
+		body           string
+		expectedStatus int
+		expectedBody   string
+		expected       validationProblem
+	}{
+		"valid": {
+			query: "?dry_run=true&tag=a&tag=b&limit=5", body: `{"email":"jane@example.com","quantity":2}`,
+			expectedStatus: http.StatusOK, expectedBody: `jane@example.com 2 true [a b] 5 {"email":"jane@example.com","quantity":2}`,
+		},
+		"invalid fields": {
+			query: "?tag=a&tag=b&tag=c", body: `{"email":"jane","quantity":0}`,
+			expectedStatus: http.StatusBadRequest,
+			expected: validationProblem{Title: "Bad Request", Status: http.StatusBadRequest, Instance: "/orders", Detail: "request validation failed", Errors: []validation.FieldError{
+				{Field: "email", Rule: "email", Message: "must be an email address"},
+				{Field: "quantity", Rule: "min", Message: "must be at least 1"},
+				{Field: "tag", Rule: "max", Message: "must have at most 2 items"},
+			}},
+		},
+		"missing body": {
+			expectedStatus: http.StatusBadRequest,
+			expected: validationProblem{Title: "Bad Request", Status: http.StatusBadRequest, Instance: "/orders", Detail: "request validation failed", Errors: []validation.FieldError{
+				{Field: "email", Rule: "required", Message: "is required"},
+				{Field: "quantity", Rule: "min", Message: "must be at least 1"},
+			}},
+		},
+		"invalid query": {
+			query: "?dry_run=maybe&limit=-1", body: `{"email":"jane@example.com","quantity":2}`,
+			expectedStatus: http.StatusBadRequest,
+			expected: validationProblem{Title: "Bad Request", Status: http.StatusBadRequest, Instance: "/orders", Detail: "request validation failed", Errors: []validation.FieldError{
+				{Field: "dry_run", Rule: "type", Message: "must be a boolean"},
+				{Field: "limit", Rule: "type", Message: "must be a non-negative integer"},
+			}},
+		},
+		"invalid json": {
+			body:           `{"email":`,
+			expectedStatus: http.StatusBadRequest,
+			expected:       validationProblem{Title: "Bad Request", Status: http.StatusBadRequest, Instance: "/orders", Detail: "request body is not valid JSON: unexpected end of JSON input"},
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			req := httptest.NewRequest(http.MethodPost, "/orders"+tt.query, strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			if tt.expectedStatus == http.StatusOK {
+				assert.Equal(t, tt.expectedBody, rec.Body.String())
+				return
+			}
+			assert.Equal(t, "application/problem+json", rec.Header().Get("Content-Type"))
+			var got validationProblem
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&got))
+			assert.NotEmpty(t, got.CorrelationID)
+			got.CorrelationID = ""
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestNewValidation_BodyTooLarge(t *testing.T) {
+	t.Parallel()
+	validationMw, err := NewValidation(createOrder{})
+	require.NoError(t, err)
+	limitMw, err := NewRequestBodyLimit(8)
+	require.NoError(t, err)
+	handler := limitMw(validationMw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Fail(t, "handler should not be called")
+	})))
+
+	req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(`{"email":"jane@example.com"}`))
+	req.ContentLength = -1
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+}
+
+func TestNewValidation_Localized(t *testing.T) {
+	t.Parallel()
+	catalog, err := i18n.Load(fstest.MapFS{
+		"en.json": {Data: []byte(`{"problem.400": "Invalid request"}`)},
+	}, "en")
+	require.NoError(t, err)
+	mw, err := NewValidation(createOrder{})
+	require.NoError(t, err)
+	handler := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	req := httptest.NewRequest(http.MethodPost, "/orders", nil)
+	req = req.WithContext(i18n.WithLocalizer(req.Context(), catalog.Localizer()))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	var got validationProblem
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&got))
+	assert.Equal(t, "Invalid request", got.Title)
+}
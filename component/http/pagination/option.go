@@ -0,0 +1,28 @@
+package pagination
+
+import "errors"
+
+// OptionFunc definition for configuring the paginator in a functional way.
+type OptionFunc func(*Paginator) error
+
+// DefaultLimit option for setting the page size used when the request does not define one.
+func DefaultLimit(limit int) OptionFunc {
+	return func(p *Paginator) error {
+		if limit <= 0 {
+			return errors.New("default limit should be positive")
+		}
+		p.defaultLimit = limit
+		return nil
+	}
+}
+
+// MaxLimit option for setting the maximum page size a request can ask for.
+func MaxLimit(limit int) OptionFunc {
+	return func(p *Paginator) error {
+		if limit <= 0 {
+			return errors.New("max limit should be positive")
+		}
+		p.maxLimit = limit
+		return nil
+	}
+}
@@ -0,0 +1,23 @@
+package pagination
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultLimit(t *testing.T) {
+	t.Parallel()
+	p := &Paginator{}
+	assert.EqualError(t, DefaultLimit(0)(p), "default limit should be positive")
+	assert.NoError(t, DefaultLimit(10)(p))
+	assert.Equal(t, 10, p.defaultLimit)
+}
+
+func TestMaxLimit(t *testing.T) {
+	t.Parallel()
+	p := &Paginator{}
+	assert.EqualError(t, MaxLimit(-1)(p), "max limit should be positive")
+	assert.NoError(t, MaxLimit(10)(p))
+	assert.Equal(t, 10, p.maxLimit)
+}
@@ -0,0 +1,209 @@
+// Package pagination provides helpers for consistent cursor and offset based pagination of list endpoints.
+package pagination
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/beatlabs/patron/encoding"
+	"github.com/beatlabs/patron/encoding/json"
+)
+
+const (
+	// LimitParam is the query parameter holding the page size.
+	LimitParam = "limit"
+	// CursorParam is the query parameter holding the opaque cursor.
+	CursorParam = "cursor"
+	// OffsetParam is the query parameter holding the offset.
+	OffsetParam = "offset"
+
+	defaultLimit    = 20
+	defaultMaxLimit = 100
+
+	linkHeader = "Link"
+)
+
+// Request holds the pagination parameters of a request.
+type Request struct {
+	Limit  int
+	Offset int
+	// Cursor is the verified payload of the cursor, if one was provided.
+	Cursor []byte
+}
+
+// Info describes the returned page. Cursor based endpoints set the cursors, while offset based ones set the offset and,
+// optionally, the total number of items.
+type Info struct {
+	Limit      int    `json:"limit"`
+	Offset     int    `json:"offset,omitempty"`
+	Total      *int   `json:"total,omitempty"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+}
+
+// Page is the standard pagination envelope.
+type Page struct {
+	Data       interface{} `json:"data"`
+	Pagination Info        `json:"pagination"`
+}
+
+// Paginator parses pagination parameters and generates HMAC signed opaque cursors.
+type Paginator struct {
+	key          []byte
+	defaultLimit int
+	maxLimit     int
+}
+
+// New creates a paginator, which signs the cursors with the provided key.
+func New(key []byte, oo ...OptionFunc) (*Paginator, error) {
+	if len(key) == 0 {
+		return nil, errors.New("key is empty")
+	}
+
+	p := &Paginator{
+		key:          key,
+		defaultLimit: defaultLimit,
+		maxLimit:     defaultMaxLimit,
+	}
+
+	for _, o := range oo {
+		err := o(p)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if p.defaultLimit > p.maxLimit {
+		return nil, errors.New("default limit should not be greater than max limit")
+	}
+
+	return p, nil
+}
+
+// Parse extracts the pagination parameters of the request. A missing limit results in the default limit,
+// while a limit above the max limit is capped. Cursors are verified and decoded.
+func (p *Paginator) Parse(r *http.Request) (Request, error) {
+	q := r.URL.Query()
+	req := Request{Limit: p.defaultLimit}
+
+	if val := q.Get(LimitParam); val != "" {
+		limit, err := strconv.Atoi(val)
+		if err != nil || limit <= 0 {
+			return Request{}, fmt.Errorf("%s should be a positive integer: %q", LimitParam, val)
+		}
+		if limit > p.maxLimit {
+			limit = p.maxLimit
+		}
+		req.Limit = limit
+	}
+
+	if val := q.Get(OffsetParam); val != "" {
+		offset, err := strconv.Atoi(val)
+		if err != nil || offset < 0 {
+			return Request{}, fmt.Errorf("%s should be a non negative integer: %q", OffsetParam, val)
+		}
+		req.Offset = offset
+	}
+
+	if val := q.Get(CursorParam); val != "" {
+		if req.Offset != 0 {
+			return Request{}, fmt.Errorf("%s and %s are mutually exclusive", CursorParam, OffsetParam)
+		}
+		payload, err := p.DecodeCursor(val)
+		if err != nil {
+			return Request{}, err
+		}
+		req.Cursor = payload
+	}
+
+	return req, nil
+}
+
+// EncodeCursor creates an opaque cursor from the payload, e.g. the sort key of the last item of the page.
+func (p *Paginator) EncodeCursor(payload []byte) string {
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(p.sign(payload))
+}
+
+// DecodeCursor verifies the signature of the cursor and returns its payload.
+func (p *Paginator) DecodeCursor(cursor string) ([]byte, error) {
+	parts := strings.Split(cursor, ".")
+	if len(parts) != 2 {
+		return nil, errors.New("cursor is malformed")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, errors.New("cursor is malformed")
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, errors.New("cursor is malformed")
+	}
+	if !hmac.Equal(sig, p.sign(payload)) {
+		return nil, errors.New("cursor signature is invalid")
+	}
+	return payload, nil
+}
+
+// Links returns the value of the Link header (RFC8288) with the next and previous pages of the request,
+// or an empty string if there are none.
+func Links(r *http.Request, info Info) string {
+	var links []string
+
+	if info.NextCursor != "" {
+		links = append(links, link(r, "next", CursorParam, info.NextCursor, info.Limit))
+	} else if info.Total != nil && info.Offset+info.Limit < *info.Total {
+		links = append(links, link(r, "next", OffsetParam, strconv.Itoa(info.Offset+info.Limit), info.Limit))
+	}
+
+	if info.PrevCursor != "" {
+		links = append(links, link(r, "prev", CursorParam, info.PrevCursor, info.Limit))
+	} else if info.Offset > 0 {
+		prev := info.Offset - info.Limit
+		if prev < 0 {
+			prev = 0
+		}
+		links = append(links, link(r, "prev", OffsetParam, strconv.Itoa(prev), info.Limit))
+	}
+
+	return strings.Join(links, ", ")
+}
+
+// Respond writes the data in the standard pagination envelope, along with the Link header.
+func Respond(w http.ResponseWriter, r *http.Request, data interface{}, info Info) error {
+	body, err := json.Encode(Page{Data: data, Pagination: info})
+	if err != nil {
+		return fmt.Errorf("failed to encode page: %w", err)
+	}
+
+	if links := Links(r, info); links != "" {
+		w.Header().Set(linkHeader, links)
+	}
+	w.Header().Set(encoding.ContentTypeHeader, json.TypeCharset)
+	w.WriteHeader(http.StatusOK)
+	_, err = w.Write(body)
+	return err
+}
+
+func (p *Paginator) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, p.key)
+	_, _ = mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+func link(r *http.Request, rel, param, value string, limit int) string {
+	u := url.URL{Path: r.URL.Path}
+	q := r.URL.Query()
+	q.Del(CursorParam)
+	q.Del(OffsetParam)
+	q.Set(param, value)
+	q.Set(LimitParam, strconv.Itoa(limit))
+	u.RawQuery = q.Encode()
+	return fmt.Sprintf(`<%s>; rel="%s"`, u.String(), rel)
+}
@@ -0,0 +1,148 @@
+package pagination
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		key         []byte
+		oo          []OptionFunc
+		expectedErr string
+	}{
+		"success":                  {key: []byte("key"), oo: []OptionFunc{DefaultLimit(10), MaxLimit(50)}},
+		"missing key":              {expectedErr: "key is empty"},
+		"option failure":           {key: []byte("key"), oo: []OptionFunc{MaxLimit(0)}, expectedErr: "max limit should be positive"},
+		"default greater than max": {key: []byte("key"), oo: []OptionFunc{DefaultLimit(200)}, expectedErr: "default limit should not be greater than max limit"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			got, err := New(tt.key, tt.oo...)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, got)
+			}
+		})
+	}
+}
+
+func TestCursor(t *testing.T) {
+	t.Parallel()
+	p, err := New([]byte("key"))
+	require.NoError(t, err)
+	other, err := New([]byte("other"))
+	require.NoError(t, err)
+
+	cursor := p.EncodeCursor([]byte("id:42"))
+	payload, err := p.DecodeCursor(cursor)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("id:42"), payload)
+
+	_, err = other.DecodeCursor(cursor)
+	assert.EqualError(t, err, "cursor signature is invalid")
+	_, err = p.DecodeCursor("abc")
+	assert.EqualError(t, err, "cursor is malformed")
+	_, err = p.DecodeCursor("a!c.def")
+	assert.EqualError(t, err, "cursor is malformed")
+	_, err = p.DecodeCursor("abc.d!f")
+	assert.EqualError(t, err, "cursor is malformed")
+}
+
+func TestPaginator_Parse(t *testing.T) {
+	t.Parallel()
+	p, err := New([]byte("key"), DefaultLimit(10), MaxLimit(50))
+	require.NoError(t, err)
+	cursor := p.EncodeCursor([]byte("id:42"))
+
+	tests := map[string]struct {
+		query       string
+		expected    Request
+		expectedErr string
+	}{
+		"defaults":          {query: "", expected: Request{Limit: 10}},
+		"limit and offset":  {query: "limit=20&offset=40", expected: Request{Limit: 20, Offset: 40}},
+		"capped limit":      {query: "limit=500", expected: Request{Limit: 50}},
+		"cursor":            {query: "cursor=" + cursor, expected: Request{Limit: 10, Cursor: []byte("id:42")}},
+		"invalid limit":     {query: "limit=0", expectedErr: "limit should be a positive integer: \"0\""},
+		"invalid offset":    {query: "offset=a", expectedErr: "offset should be a non negative integer: \"a\""},
+		"invalid cursor":    {query: "cursor=abc", expectedErr: "cursor is malformed"},
+		"cursor and offset": {query: "offset=1&cursor=" + cursor, expectedErr: "cursor and offset are mutually exclusive"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			got, err := p.Parse(httptest.NewRequest(http.MethodGet, "/items?"+tt.query, nil))
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestLinks(t *testing.T) {
+	t.Parallel()
+	total := 45
+	tests := map[string]struct {
+		url      string
+		info     Info
+		expected string
+	}{
+		"no links": {url: "/items", info: Info{Limit: 10}},
+		"cursors": {
+			url:      "/items?cursor=abc&sort=name",
+			info:     Info{Limit: 10, NextCursor: "next", PrevCursor: "prev"},
+			expected: `</items?cursor=next&limit=10&sort=name>; rel="next", </items?cursor=prev&limit=10&sort=name>; rel="prev"`,
+		},
+		"offset first page": {
+			url:      "/items",
+			info:     Info{Limit: 10, Total: &total},
+			expected: `</items?limit=10&offset=10>; rel="next"`,
+		},
+		"offset last page": {
+			url:      "/items?offset=40",
+			info:     Info{Limit: 10, Offset: 40, Total: &total},
+			expected: `</items?limit=10&offset=30>; rel="prev"`,
+		},
+		"offset unaligned": {
+			url:      "/items?offset=5",
+			info:     Info{Limit: 10, Offset: 5},
+			expected: `</items?limit=10&offset=0>; rel="prev"`,
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.expected, Links(httptest.NewRequest(http.MethodGet, tt.url, nil), tt.info))
+		})
+	}
+}
+
+func TestRespond(t *testing.T) {
+	t.Parallel()
+	rc := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/items", nil)
+
+	err := Respond(rc, req, []string{"a", "b"}, Info{Limit: 2, NextCursor: "next"})
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rc.Code)
+	assert.Equal(t, "application/json; charset=utf-8", rc.Header().Get("Content-Type"))
+	assert.Equal(t, `</items?cursor=next&limit=2>; rel="next"`, rc.Header().Get("Link"))
+	assert.JSONEq(t, `{"data":["a","b"],"pagination":{"limit":2,"next_cursor":"next"}}`, rc.Body.String())
+}
@@ -0,0 +1,186 @@
+// Package params provides typed extraction of HTTP path and query parameters with aggregated validation errors.
+//
+//	p := params.Path(r)
+//	id := p.Int("id")
+//	ref := p.UUID("ref")
+//	if err := p.Err(); err != nil {
+//		http.Error(w, err.Error(), http.StatusBadRequest)
+//		return
+//	}
+package params
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	patronerrors "github.com/beatlabs/patron/errors"
+	"github.com/google/uuid"
+	"github.com/julienschmidt/httprouter"
+)
+
+const (
+	pathSource  = "path"
+	querySource = "query"
+)
+
+// Params extracts typed parameters from a request. Conversion failures and missing required parameters
+// are collected and returned, aggregated, by Err, while the zero value of the type is returned in their place.
+type Params struct {
+	source   string
+	required bool
+	lookup   func(name string) (string, bool)
+	ee       []error
+}
+
+// Path returns the path parameters of the request, as matched by the router. All path parameters are required.
+func Path(r *http.Request) *Params {
+	pp := httprouter.ParamsFromContext(r.Context())
+	return &Params{
+		source:   pathSource,
+		required: true,
+		lookup: func(name string) (string, bool) {
+			for _, p := range pp {
+				if p.Key == name {
+					return p.Value, true
+				}
+			}
+			return "", false
+		},
+	}
+}
+
+// Query returns the query parameters of the request. Query parameters are optional, so a missing one
+// results in the zero value without an error; use Has in order to tell them apart.
+func Query(r *http.Request) *Params {
+	q := r.URL.Query()
+	return &Params{
+		source: querySource,
+		lookup: func(name string) (string, bool) {
+			vv, ok := q[name]
+			if !ok || len(vv) == 0 {
+				return "", false
+			}
+			return vv[0], true
+		},
+	}
+}
+
+// Has returns true if the parameter is present.
+func (p *Params) Has(name string) bool {
+	_, ok := p.lookup(name)
+	return ok
+}
+
+// String returns the parameter value.
+func (p *Params) String(name string) string {
+	val, _ := p.value(name)
+	return val
+}
+
+// Int returns the parameter as an int.
+func (p *Params) Int(name string) int {
+	val, ok := p.value(name)
+	if !ok {
+		return 0
+	}
+	i, err := strconv.Atoi(val)
+	if err != nil {
+		p.invalid(name, "an integer", val)
+		return 0
+	}
+	return i
+}
+
+// Int64 returns the parameter as an int64.
+func (p *Params) Int64(name string) int64 {
+	val, ok := p.value(name)
+	if !ok {
+		return 0
+	}
+	i, err := strconv.ParseInt(val, 10, 64)
+	if err != nil {
+		p.invalid(name, "an integer", val)
+		return 0
+	}
+	return i
+}
+
+// Float64 returns the parameter as a float64.
+func (p *Params) Float64(name string) float64 {
+	val, ok := p.value(name)
+	if !ok {
+		return 0
+	}
+	f, err := strconv.ParseFloat(val, 64)
+	if err != nil {
+		p.invalid(name, "a number", val)
+		return 0
+	}
+	return f
+}
+
+// Bool returns the parameter as a bool.
+func (p *Params) Bool(name string) bool {
+	val, ok := p.value(name)
+	if !ok {
+		return false
+	}
+	b, err := strconv.ParseBool(val)
+	if err != nil {
+		p.invalid(name, "a boolean", val)
+		return false
+	}
+	return b
+}
+
+// UUID returns the parameter as a UUID.
+func (p *Params) UUID(name string) uuid.UUID {
+	val, ok := p.value(name)
+	if !ok {
+		return uuid.Nil
+	}
+	u, err := uuid.Parse(val)
+	if err != nil {
+		p.invalid(name, "a UUID", val)
+		return uuid.Nil
+	}
+	return u
+}
+
+// Time returns the parameter as a time, trying the layouts in order. If no layout is provided, RFC3339 is used.
+func (p *Params) Time(name string, layouts ...string) time.Time {
+	val, ok := p.value(name)
+	if !ok {
+		return time.Time{}
+	}
+	if len(layouts) == 0 {
+		layouts = []string{time.RFC3339}
+	}
+	for _, layout := range layouts {
+		t, err := time.Parse(layout, val)
+		if err == nil {
+			return t
+		}
+	}
+	p.invalid(name, "a time", val)
+	return time.Time{}
+}
+
+// Err returns the aggregated errors of all extractions, or nil if there were none.
+func (p *Params) Err() error {
+	return patronerrors.Aggregate(p.ee...)
+}
+
+func (p *Params) value(name string) (string, bool) {
+	val, ok := p.lookup(name)
+	if !ok && p.required {
+		p.ee = append(p.ee, fmt.Errorf("%s parameter %s is missing", p.source, name))
+	}
+	return val, ok
+}
+
+func (p *Params) invalid(name, kind, val string) {
+	p.ee = append(p.ee, fmt.Errorf("%s parameter %s should be %s: %q", p.source, name, kind, val))
+}
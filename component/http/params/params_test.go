@@ -0,0 +1,88 @@
+package params
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+)
+
+func pathRequest(pp httprouter.Params) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	return req.WithContext(context.WithValue(req.Context(), httprouter.ParamsKey, pp))
+}
+
+func TestPath(t *testing.T) {
+	t.Parallel()
+	ref := uuid.New()
+	req := pathRequest(httprouter.Params{
+		{Key: "id", Value: "42"},
+		{Key: "big", Value: "9007199254740993"},
+		{Key: "price", Value: "9.99"},
+		{Key: "active", Value: "true"},
+		{Key: "ref", Value: ref.String()},
+		{Key: "day", Value: "2021-03-04"},
+		{Key: "name", Value: "john"},
+	})
+
+	p := Path(req)
+
+	assert.Equal(t, 42, p.Int("id"))
+	assert.Equal(t, int64(9007199254740993), p.Int64("big"))
+	assert.Equal(t, 9.99, p.Float64("price"))
+	assert.True(t, p.Bool("active"))
+	assert.Equal(t, ref, p.UUID("ref"))
+	assert.Equal(t, time.Date(2021, 3, 4, 0, 0, 0, 0, time.UTC), p.Time("day", time.RFC3339, "2006-01-02"))
+	assert.Equal(t, "john", p.String("name"))
+	assert.True(t, p.Has("name"))
+	assert.NoError(t, p.Err())
+}
+
+func TestPath_Errors(t *testing.T) {
+	t.Parallel()
+	req := pathRequest(httprouter.Params{
+		{Key: "id", Value: "a"},
+		{Key: "price", Value: "b"},
+		{Key: "active", Value: "c"},
+		{Key: "ref", Value: "d"},
+		{Key: "day", Value: "e"},
+	})
+
+	p := Path(req)
+
+	assert.Equal(t, 0, p.Int("id"))
+	assert.Equal(t, int64(0), p.Int64("id"))
+	assert.Equal(t, 0.0, p.Float64("price"))
+	assert.False(t, p.Bool("active"))
+	assert.Equal(t, uuid.Nil, p.UUID("ref"))
+	assert.True(t, p.Time("day").IsZero())
+	assert.Equal(t, "", p.String("missing"))
+	assert.False(t, p.Has("missing"))
+	assert.EqualError(t, p.Err(), `path parameter id should be an integer: "a"
+path parameter id should be an integer: "a"
+path parameter price should be a number: "b"
+path parameter active should be a boolean: "c"
+path parameter ref should be a UUID: "d"
+path parameter day should be a time: "e"
+path parameter missing is missing
+`)
+}
+
+func TestQuery(t *testing.T) {
+	t.Parallel()
+	req := httptest.NewRequest(http.MethodGet, "/?limit=10&limit=20&since=2021-03-04T05:06:07Z&active=x", nil)
+
+	p := Query(req)
+
+	assert.Equal(t, 10, p.Int("limit"))
+	assert.Equal(t, time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC), p.Time("since"))
+	assert.Equal(t, 0, p.Int("offset"))
+	assert.False(t, p.Has("offset"))
+	assert.False(t, p.Bool("active"))
+	assert.EqualError(t, p.Err(), "query parameter active should be a boolean: \"x\"\n")
+}
@@ -0,0 +1,162 @@
+// Package problem provides the problem details responses of RFC 7807, which describe the errors of HTTP handlers to the clients
+// as application/problem+json, in place of ad-hoc plain-text errors.
+package problem
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/beatlabs/patron/correlation"
+	"github.com/beatlabs/patron/log"
+)
+
+// ContentType of the problem details responses.
+const ContentType = "application/problem+json"
+
+// reserved members of the problem details, which extensions cannot override.
+var reserved = map[string]struct{}{
+	"type": {}, "title": {}, "status": {}, "detail": {}, "instance": {}, "correlation_id": {},
+}
+
+// Problem is a problem details error of RFC 7807. Handlers return it to respond with its status and its details,
+// while the rest of the errors are responded with a 500 Internal Server Error, without exposing their details.
+type Problem struct {
+	// Type is a URI identifying the type of the problem, which defaults to about:blank.
+	Type string
+	// Title is a short summary of the type of the problem, which defaults to the text of the status.
+	Title  string
+	Status int
+	// Detail is an explanation specific to this occurrence of the problem.
+	Detail string
+	// Instance is a URI identifying this occurrence of the problem, which defaults to the path of the request.
+	Instance string
+	// Extensions are additional members of the problem, e.g. the fields failing validation.
+	Extensions map[string]interface{}
+	err        error
+}
+
+// New creates a problem of the status, with the provided detail.
+func New(status int, detail string) *Problem {
+	return &Problem{Status: status, Detail: detail}
+}
+
+// Wrap creates a problem of the status, with the error as its detail, which is also returned by Unwrap.
+func Wrap(status int, err error) *Problem {
+	p := &Problem{Status: status, err: err}
+	if err != nil {
+		p.Detail = err.Error()
+	}
+	return p
+}
+
+// Error returns the status and the detail of the problem.
+func (p *Problem) Error() string {
+	if p.Detail == "" {
+		return fmt.Sprintf("%d %s", p.Status, p.title())
+	}
+	return fmt.Sprintf("%d %s: %s", p.Status, p.title(), p.Detail)
+}
+
+// Unwrap returns the wrapped error, if any.
+func (p *Problem) Unwrap() error {
+	return p.err
+}
+
+// WithType sets the type URI of the problem.
+func (p *Problem) WithType(uri string) *Problem {
+	p.Type = uri
+	return p
+}
+
+// WithTitle sets the title of the problem.
+func (p *Problem) WithTitle(title string) *Problem {
+	p.Title = title
+	return p
+}
+
+// WithInstance sets the instance URI of the problem.
+func (p *Problem) WithInstance(uri string) *Problem {
+	p.Instance = uri
+	return p
+}
+
+// With adds an extension member to the problem. Members of the problem details, e.g. status, are ignored.
+func (p *Problem) With(key string, value interface{}) *Problem {
+	if _, ok := reserved[key]; ok {
+		return p
+	}
+	if p.Extensions == nil {
+		p.Extensions = make(map[string]interface{})
+	}
+	p.Extensions[key] = value
+	return p
+}
+
+func (p *Problem) title() string {
+	if p.Title != "" {
+		return p.Title
+	}
+	return http.StatusText(p.Status)
+}
+
+// MarshalJSON encodes the problem with its extensions as members of the same object.
+func (p *Problem) MarshalJSON() ([]byte, error) {
+	m := make(map[string]interface{}, len(p.Extensions)+5)
+	for k, v := range p.Extensions {
+		m[k] = v
+	}
+	if p.Type != "" {
+		m["type"] = p.Type
+	}
+	m["title"] = p.title()
+	m["status"] = p.Status
+	if p.Detail != "" {
+		m["detail"] = p.Detail
+	}
+	if p.Instance != "" {
+		m["instance"] = p.Instance
+	}
+	return json.Marshal(m)
+}
+
+// Write responds with the error as a problem, with the correlation ID of the request as the correlation_id member
+// and its path as the instance of the problem, unless set. Errors which are not problems are logged, and responded
+// with a 500 Internal Server Error problem without their details.
+func Write(w http.ResponseWriter, r *http.Request, err error) {
+	var p *Problem
+	if !errors.As(err, &p) {
+		log.FromContext(r.Context()).Errorf("failed to handle request: %v", err)
+		p = New(http.StatusInternalServerError, "")
+	}
+
+	body := *p
+	if body.Instance == "" {
+		body.Instance = r.URL.Path
+	}
+	extensions := make(map[string]interface{}, len(p.Extensions)+1)
+	for k, v := range p.Extensions {
+		extensions[k] = v
+	}
+	extensions["correlation_id"] = correlation.IDFromContext(r.Context())
+	body.Extensions = extensions
+
+	w.Header().Set("Content-Type", ContentType)
+	w.Header().Del("Content-Length")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(p.Status)
+	if err := json.NewEncoder(w).Encode(&body); err != nil {
+		log.FromContext(r.Context()).Errorf("failed to write problem: %v", err)
+	}
+}
+
+// HandlerFunc adapts a handler returning an error to an http.HandlerFunc, which responds with the error as a problem.
+// The handler should not write the response when returning an error.
+func HandlerFunc(h func(w http.ResponseWriter, r *http.Request) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := h(w, r); err != nil {
+			Write(w, r, err)
+		}
+	}
+}
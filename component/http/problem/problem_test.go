@@ -0,0 +1,114 @@
+package problem
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/beatlabs/patron/correlation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProblem_Error(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		problem  *Problem
+		expected string
+	}{
+		"without detail": {problem: New(http.StatusNotFound, ""), expected: "404 Not Found"},
+		"with detail":    {problem: New(http.StatusConflict, "order is cancelled"), expected: "409 Conflict: order is cancelled"},
+		"with title":     {problem: New(http.StatusConflict, "").WithTitle("Order conflict"), expected: "409 Order conflict"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.expected, tt.problem.Error())
+		})
+	}
+}
+
+func TestWrap(t *testing.T) {
+	t.Parallel()
+	errNotFound := errors.New("order not found")
+	p := Wrap(http.StatusNotFound, errNotFound)
+	assert.Equal(t, "order not found", p.Detail)
+	assert.True(t, errors.Is(p, errNotFound))
+}
+
+func TestProblem_MarshalJSON(t *testing.T) {
+	t.Parallel()
+	p := New(http.StatusBadRequest, "invalid order").
+		WithType("https://example.com/problems/invalid-order").
+		WithInstance("/orders/1").
+		With("balance", 30).
+		With("status", 200)
+
+	got, err := json.Marshal(p)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"type":"https://example.com/problems/invalid-order","title":"Bad Request","status":400,
+		"detail":"invalid order","instance":"/orders/1","balance":30}`, string(got))
+}
+
+func TestWrite(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		err            error
+		expectedStatus int
+		expected       string
+	}{
+		"problem": {
+			err:            New(http.StatusConflict, "order is cancelled").With("order", "1"),
+			expectedStatus: http.StatusConflict,
+			expected: `{"title":"Conflict","status":409,"detail":"order is cancelled","instance":"/orders/1",
+				"correlation_id":"123","order":"1"}`,
+		},
+		"wrapped problem": {
+			err:            fmt.Errorf("failed to get order: %w", New(http.StatusNotFound, "").WithInstance("/orders")),
+			expectedStatus: http.StatusNotFound,
+			expected:       `{"title":"Not Found","status":404,"instance":"/orders","correlation_id":"123"}`,
+		},
+		"error": {
+			err:            errors.New("connection refused"),
+			expectedStatus: http.StatusInternalServerError,
+			expected:       `{"title":"Internal Server Error","status":500,"instance":"/orders/1","correlation_id":"123"}`,
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			req := httptest.NewRequest(http.MethodGet, "/orders/1", nil)
+			req = req.WithContext(correlation.ContextWithID(req.Context(), "123"))
+			rc := httptest.NewRecorder()
+			Write(rc, req, tt.err)
+			assert.Equal(t, tt.expectedStatus, rc.Code)
+			assert.Equal(t, ContentType, rc.Header().Get("Content-Type"))
+			assert.JSONEq(t, tt.expected, rc.Body.String())
+		})
+	}
+}
+
+func TestHandlerFunc(t *testing.T) {
+	t.Parallel()
+	handler := HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		if r.URL.Query().Get("id") == "" {
+			return New(http.StatusBadRequest, "id is required")
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	})
+
+	rc := httptest.NewRecorder()
+	handler(rc, httptest.NewRequest(http.MethodGet, "/orders?id=1", nil))
+	assert.Equal(t, http.StatusNoContent, rc.Code)
+
+	rc = httptest.NewRecorder()
+	handler(rc, httptest.NewRequest(http.MethodGet, "/orders", nil))
+	assert.Equal(t, http.StatusBadRequest, rc.Code)
+	assert.Contains(t, rc.Body.String(), `"detail":"id is required"`)
+}
@@ -0,0 +1,169 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"time"
+)
+
+// TokenBucket is an in-memory token bucket limiter, which allows bursts of requests up to the size of the bucket,
+// while its tokens are refilled at a constant rate.
+type TokenBucket struct {
+	rate      float64
+	burst     int
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewTokenBucket creates a token bucket limiter, which refills the bucket of every key with the provided rate of tokens per second,
+// up to the burst size.
+func NewTokenBucket(rate float64, burst int) (*TokenBucket, error) {
+	if rate <= 0 {
+		return nil, errors.New("rate should be positive")
+	}
+	if burst <= 0 {
+		return nil, errors.New("burst should be positive")
+	}
+	return &TokenBucket{rate: rate, burst: burst, buckets: make(map[string]*bucket), now: time.Now}, nil
+}
+
+// Allow takes a token from the bucket of the key, if there is one.
+func (tb *TokenBucket) Allow(_ context.Context, key string) (Decision, error) {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+
+	now := tb.now()
+	tb.sweep(now)
+
+	b, ok := tb.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(tb.burst), last: now}
+		tb.buckets[key] = b
+	}
+	b.tokens = tb.refill(b, now)
+	b.last = now
+
+	d := Decision{Limit: tb.burst}
+	if b.tokens >= 1 {
+		b.tokens--
+		d.Allowed = true
+		d.Remaining = int(b.tokens)
+		return d, nil
+	}
+	d.RetryAfter = time.Duration((1 - b.tokens) / tb.rate * float64(time.Second))
+	return d, nil
+}
+
+func (tb *TokenBucket) refill(b *bucket, now time.Time) float64 {
+	return math.Min(float64(tb.burst), b.tokens+now.Sub(b.last).Seconds()*tb.rate)
+}
+
+// sweep removes the full buckets, which are equivalent to missing ones, once per the time needed to fill a bucket.
+func (tb *TokenBucket) sweep(now time.Time) {
+	if now.Sub(tb.lastSweep).Seconds() < float64(tb.burst)/tb.rate {
+		return
+	}
+	tb.lastSweep = now
+	for key, b := range tb.buckets {
+		if tb.refill(b, now) >= float64(tb.burst) {
+			delete(tb.buckets, key)
+		}
+	}
+}
+
+// SlidingWindow is an in-memory sliding window limiter, which allows a number of requests per window.
+// The count of the sliding window is approximated by weighting the count of the previous fixed window
+// with its overlap with the sliding one, which requires keeping only two counters per key.
+type SlidingWindow struct {
+	limit     int
+	window    time.Duration
+	mu        sync.Mutex
+	counters  map[string]*windowCounter
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+type windowCounter struct {
+	start      time.Time
+	prev, curr int
+}
+
+// NewSlidingWindow creates a sliding window limiter, which allows the provided number of requests per window for every key.
+func NewSlidingWindow(limit int, window time.Duration) (*SlidingWindow, error) {
+	if limit <= 0 {
+		return nil, errors.New("limit should be positive")
+	}
+	if window < time.Millisecond {
+		return nil, errors.New("window should be at least 1ms")
+	}
+	return &SlidingWindow{limit: limit, window: window, counters: make(map[string]*windowCounter), now: time.Now}, nil
+}
+
+// Allow counts the request towards the window of the key, if the window has not reached the limit.
+func (sw *SlidingWindow) Allow(_ context.Context, key string) (Decision, error) {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+
+	now := sw.now()
+	start := now.Truncate(sw.window)
+	sw.sweep(now, start)
+
+	c, ok := sw.counters[key]
+	if !ok {
+		c = &windowCounter{start: start}
+		sw.counters[key] = c
+	}
+	if !c.start.Equal(start) {
+		if c.start.Add(sw.window).Equal(start) {
+			c.prev = c.curr
+		} else {
+			c.prev = 0
+		}
+		c.curr = 0
+		c.start = start
+	}
+
+	allowed, remaining, retryAfter := slidingWindow(sw.limit, sw.window, now.Sub(start), c.prev, c.curr)
+	if allowed {
+		c.curr++
+	}
+	return Decision{Allowed: allowed, Limit: sw.limit, Remaining: remaining, RetryAfter: retryAfter}, nil
+}
+
+// sweep removes the counters which have not been used in the current or the previous window, once per window.
+func (sw *SlidingWindow) sweep(now, start time.Time) {
+	if now.Sub(sw.lastSweep) < sw.window {
+		return
+	}
+	sw.lastSweep = now
+	for key, c := range sw.counters {
+		if c.start.Add(sw.window).Before(start) {
+			delete(sw.counters, key)
+		}
+	}
+}
+
+// slidingWindow decides whether a request is allowed, given the elapsed time of the current fixed window
+// and the counts of the previous and the current one.
+func slidingWindow(limit int, window, elapsed time.Duration, prev, curr int) (bool, int, time.Duration) {
+	weight := 1 - float64(elapsed)/float64(window)
+	estimated := float64(prev)*weight + float64(curr)
+	if estimated+1 <= float64(limit) {
+		return true, int(float64(limit) - estimated - 1), 0
+	}
+	if curr+1 <= limit && prev > 0 {
+		// the weight of the previous window should drop enough to fit the request
+		target := 1 - float64(limit-1-curr)/float64(prev)
+		return false, 0, time.Duration(target*float64(window)) - elapsed
+	}
+	return false, 0, window - elapsed
+}
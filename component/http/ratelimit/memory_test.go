@@ -0,0 +1,118 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func TestNewTokenBucket(t *testing.T) {
+	t.Parallel()
+	_, err := NewTokenBucket(0, 1)
+	assert.EqualError(t, err, "rate should be positive")
+	_, err = NewTokenBucket(1, 0)
+	assert.EqualError(t, err, "burst should be positive")
+	got, err := NewTokenBucket(1, 1)
+	assert.NoError(t, err)
+	assert.NotNil(t, got)
+}
+
+func TestTokenBucket(t *testing.T) {
+	t.Parallel()
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	tb, err := NewTokenBucket(2, 3)
+	require.NoError(t, err)
+	tb.now = clock.Now
+	ctx := context.Background()
+
+	// the burst is allowed at once
+	for i := 2; i >= 0; i-- {
+		d, err := tb.Allow(ctx, "a")
+		require.NoError(t, err)
+		assert.Equal(t, Decision{Allowed: true, Limit: 3, Remaining: i}, d)
+	}
+	d, err := tb.Allow(ctx, "a")
+	require.NoError(t, err)
+	assert.Equal(t, Decision{Limit: 3, RetryAfter: 500 * time.Millisecond}, d)
+
+	// other keys have their own bucket
+	d, err = tb.Allow(ctx, "b")
+	require.NoError(t, err)
+	assert.True(t, d.Allowed)
+
+	// tokens are refilled at the rate
+	clock.Advance(500 * time.Millisecond)
+	d, err = tb.Allow(ctx, "a")
+	require.NoError(t, err)
+	assert.Equal(t, Decision{Allowed: true, Limit: 3, Remaining: 0}, d)
+
+	// full buckets are swept
+	clock.Advance(time.Minute)
+	_, err = tb.Allow(ctx, "c")
+	require.NoError(t, err)
+	assert.Len(t, tb.buckets, 1)
+}
+
+func TestNewSlidingWindow(t *testing.T) {
+	t.Parallel()
+	_, err := NewSlidingWindow(0, time.Second)
+	assert.EqualError(t, err, "limit should be positive")
+	_, err = NewSlidingWindow(1, 0)
+	assert.EqualError(t, err, "window should be at least 1ms")
+	got, err := NewSlidingWindow(1, time.Second)
+	assert.NoError(t, err)
+	assert.NotNil(t, got)
+}
+
+func TestSlidingWindow(t *testing.T) {
+	t.Parallel()
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	sw, err := NewSlidingWindow(4, 10*time.Second)
+	require.NoError(t, err)
+	sw.now = clock.Now
+	ctx := context.Background()
+
+	for i := 3; i >= 0; i-- {
+		d, err := sw.Allow(ctx, "a")
+		require.NoError(t, err)
+		assert.Equal(t, Decision{Allowed: true, Limit: 4, Remaining: i}, d)
+	}
+	clock.Advance(6 * time.Second)
+	d, err := sw.Allow(ctx, "a")
+	require.NoError(t, err)
+	assert.Equal(t, Decision{Limit: 4, RetryAfter: 4 * time.Second}, d)
+
+	// half of the previous window overlaps with the sliding one
+	clock.Advance(9 * time.Second)
+	d, err = sw.Allow(ctx, "a")
+	require.NoError(t, err)
+	assert.Equal(t, Decision{Allowed: true, Limit: 4, Remaining: 1}, d)
+	d, err = sw.Allow(ctx, "a")
+	require.NoError(t, err)
+	assert.Equal(t, Decision{Allowed: true, Limit: 4, Remaining: 0}, d)
+	d, err = sw.Allow(ctx, "a")
+	require.NoError(t, err)
+	// the weight of the previous window should drop to a quarter
+	assert.Equal(t, Decision{Limit: 4, RetryAfter: 2500 * time.Millisecond}, d)
+
+	// counters unused in the previous window are swept
+	clock.Advance(time.Minute)
+	_, err = sw.Allow(ctx, "b")
+	require.NoError(t, err)
+	assert.Len(t, sw.counters, 1)
+}
@@ -0,0 +1,127 @@
+// Package ratelimit provides an HTTP middleware, which rate limits requests per key e.g. the client IP,
+// with a token bucket or a sliding window strategy. The state of the limits is kept in memory,
+// or in Redis by the limiters of the redis subpackage, in order to be shared across replicas.
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/beatlabs/patron/component/http/middleware"
+	"github.com/beatlabs/patron/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// HeaderLimit is the response header holding the number of requests allowed by the limit.
+	HeaderLimit = "X-RateLimit-Limit"
+	// HeaderRemaining is the response header holding the number of requests remaining in the limit.
+	HeaderRemaining = "X-RateLimit-Remaining"
+	// HeaderRetryAfter is the response header holding the seconds after which a throttled request can be retried.
+	HeaderRetryAfter = "Retry-After"
+
+	allowedOutcome   = "allowed"
+	throttledOutcome = "throttled"
+	errorOutcome     = "error"
+)
+
+var requestsMetric *prometheus.CounterVec
+
+func init() {
+	requestsMetric = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "component",
+			Subsystem: "http",
+			Name:      "rate_limit_requests_total",
+			Help:      "Total number of HTTP requests checked by the rate limiting middleware, classified by path and outcome.",
+		},
+		[]string{"path", "outcome"},
+	)
+	prometheus.MustRegister(requestsMetric)
+}
+
+// Decision is the outcome of checking a request against a limit.
+type Decision struct {
+	// Allowed reports whether the request is allowed.
+	Allowed bool
+	// Limit is the number of requests allowed in a burst or a window.
+	Limit int
+	// Remaining is the number of requests which are still allowed.
+	Remaining int
+	// RetryAfter is the time after which a throttled request would be allowed.
+	RetryAfter time.Duration
+}
+
+// Limiter checks requests against the limit of their key, counting the allowed ones towards it.
+type Limiter interface {
+	Allow(ctx context.Context, key string) (Decision, error)
+}
+
+// KeyFunc extracts the key a request is limited by. Requests with an empty key are not limited.
+type KeyFunc func(r *http.Request) string
+
+// ByIP limits requests by the IP of the client, as found in the remote address of the request.
+// Behind a proxy, requests should rather be limited with ByHeader e.g. by the X-Real-IP header set by the proxy.
+func ByIP() KeyFunc {
+	return func(r *http.Request) string {
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			return r.RemoteAddr
+		}
+		return host
+	}
+}
+
+// ByHeader limits requests by the value of the provided header, e.g. an API key.
+func ByHeader(header string) KeyFunc {
+	return func(r *http.Request) string {
+		return r.Header.Get(header)
+	}
+}
+
+// New creates a middleware which limits the requests of the route with the provided path, grouped by the key extracted from them.
+// Throttled requests are rejected with a 429 Too Many Requests and a Retry-After header, while the X-RateLimit-Limit
+// and X-RateLimit-Remaining headers are added to all responses. If the limiter fails, requests are allowed.
+func New(path string, limiter Limiter, key KeyFunc) (middleware.Func, error) {
+	if limiter == nil {
+		return nil, errors.New("limiter is nil")
+	}
+	if key == nil {
+		return nil, errors.New("key func is nil")
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			k := key(r)
+			if k == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			d, err := limiter.Allow(r.Context(), k)
+			if err != nil {
+				log.FromContext(r.Context()).Errorf("failed to check rate limit of %s, allowing request: %v", path, err)
+				requestsMetric.WithLabelValues(path, errorOutcome).Inc()
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h := w.Header()
+			h.Set(HeaderLimit, strconv.Itoa(d.Limit))
+			h.Set(HeaderRemaining, strconv.Itoa(d.Remaining))
+			if !d.Allowed {
+				requestsMetric.WithLabelValues(path, throttledOutcome).Inc()
+				h.Set(HeaderRetryAfter, strconv.Itoa(int(math.Ceil(d.RetryAfter.Seconds()))))
+				http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+				return
+			}
+			requestsMetric.WithLabelValues(path, allowedOutcome).Inc()
+			next.ServeHTTP(w, r)
+		})
+	}, nil
+}
@@ -0,0 +1,115 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeLimiter struct {
+	decision Decision
+	err      error
+	keys     []string
+}
+
+func (f *fakeLimiter) Allow(_ context.Context, key string) (Decision, error) {
+	f.keys = append(f.keys, key)
+	return f.decision, f.err
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		limiter     Limiter
+		key         KeyFunc
+		expectedErr string
+	}{
+		"success":         {limiter: &fakeLimiter{}, key: ByIP()},
+		"missing limiter": {key: ByIP(), expectedErr: "limiter is nil"},
+		"missing key":     {limiter: &fakeLimiter{}, expectedErr: "key func is nil"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			got, err := New("/", tt.limiter, tt.key)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, got)
+			}
+		})
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		limiter            *fakeLimiter
+		apiKey             string
+		expectedStatus     int
+		expectedKeys       []string
+		expectedLimit      string
+		expectedRemaining  string
+		expectedRetryAfter string
+	}{
+		"allowed": {
+			limiter:        &fakeLimiter{decision: Decision{Allowed: true, Limit: 10, Remaining: 9}},
+			apiKey:         "key",
+			expectedStatus: http.StatusOK, expectedKeys: []string{"key"}, expectedLimit: "10", expectedRemaining: "9",
+		},
+		"throttled": {
+			limiter:        &fakeLimiter{decision: Decision{Limit: 10, RetryAfter: 1500 * time.Millisecond}},
+			apiKey:         "key",
+			expectedStatus: http.StatusTooManyRequests, expectedKeys: []string{"key"}, expectedLimit: "10", expectedRemaining: "0",
+			expectedRetryAfter: "2",
+		},
+		"limiter failure": {
+			limiter:        &fakeLimiter{err: errors.New("limiter error")},
+			apiKey:         "key",
+			expectedStatus: http.StatusOK, expectedKeys: []string{"key"},
+		},
+		"missing key": {
+			limiter:        &fakeLimiter{},
+			expectedStatus: http.StatusOK,
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			mw, err := New("/", tt.limiter, ByHeader("X-Api-Key"))
+			require.NoError(t, err)
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("X-Api-Key", tt.apiKey)
+			rc := httptest.NewRecorder()
+
+			mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})).ServeHTTP(rc, req)
+
+			assert.Equal(t, tt.expectedStatus, rc.Code)
+			assert.Equal(t, tt.expectedKeys, tt.limiter.keys)
+			assert.Equal(t, tt.expectedLimit, rc.Header().Get(HeaderLimit))
+			assert.Equal(t, tt.expectedRemaining, rc.Header().Get(HeaderRemaining))
+			assert.Equal(t, tt.expectedRetryAfter, rc.Header().Get(HeaderRetryAfter))
+		})
+	}
+}
+
+func TestByIP(t *testing.T) {
+	t.Parallel()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	assert.Equal(t, "10.0.0.1", ByIP()(req))
+	req.RemoteAddr = "10.0.0.1"
+	assert.Equal(t, "10.0.0.1", ByIP()(req))
+}
@@ -0,0 +1,54 @@
+//go:build integration
+// +build integration
+
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/beatlabs/patron/client/redis"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const dsn = "localhost:6379"
+
+func TestTokenBucket(t *testing.T) {
+	client := redis.New(redis.Options{Addr: dsn})
+	tb, err := NewTokenBucket(&client, "ratelimit:", 1, 3)
+	require.NoError(t, err)
+	ctx := context.Background()
+	key := uuid.New().String()
+
+	for i := 2; i >= 0; i-- {
+		d, err := tb.Allow(ctx, key)
+		require.NoError(t, err)
+		assert.True(t, d.Allowed)
+		assert.Equal(t, i, d.Remaining)
+	}
+	d, err := tb.Allow(ctx, key)
+	require.NoError(t, err)
+	assert.False(t, d.Allowed)
+	assert.True(t, d.RetryAfter > 0 && d.RetryAfter <= time.Second)
+}
+
+func TestSlidingWindow(t *testing.T) {
+	client := redis.New(redis.Options{Addr: dsn})
+	sw, err := NewSlidingWindow(&client, "ratelimit:", 3, time.Minute)
+	require.NoError(t, err)
+	ctx := context.Background()
+	key := uuid.New().String()
+
+	for i := 0; i < 3; i++ {
+		d, err := sw.Allow(ctx, key)
+		require.NoError(t, err)
+		assert.True(t, d.Allowed)
+	}
+	d, err := sw.Allow(ctx, key)
+	require.NoError(t, err)
+	assert.False(t, d.Allowed)
+	assert.True(t, d.RetryAfter > 0)
+}
@@ -0,0 +1,145 @@
+// Package redis provides rate limiters, which keep the state of the limits in Redis, in order to share them across replicas.
+package redis
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/beatlabs/patron/client/redis"
+	"github.com/beatlabs/patron/component/http/ratelimit"
+	redisv8 "github.com/go-redis/redis/v8"
+)
+
+// The scripts use the server time, so that the limits do not depend on the clocks of the replicas.
+var (
+	// tokenBucketScript keeps the tokens of the bucket and the time they were last refilled in a hash,
+	// which expires once the bucket has been refilled.
+	tokenBucketScript = redisv8.NewScript(`
+local t = redis.call('TIME')
+local now = t[1] * 1000 + math.floor(t[2] / 1000)
+local rate = tonumber(ARGV[1]) / 1000
+local burst = tonumber(ARGV[2])
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'last')
+local tokens = tonumber(state[1]) or burst
+local last = tonumber(state[2]) or now
+tokens = math.min(burst, tokens + math.max(0, now - last) * rate)
+local allowed = 0
+local retry = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	retry = math.ceil((1 - tokens) / rate)
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'last', now)
+redis.call('PEXPIRE', KEYS[1], math.ceil(burst / rate) + 1000)
+return {allowed, math.floor(tokens), retry}
+`)
+	// slidingWindowScript keeps the counts of the fixed windows in separate keys, which expire after the following window.
+	slidingWindowScript = redisv8.NewScript(`
+local t = redis.call('TIME')
+local now = t[1] * 1000 + math.floor(t[2] / 1000)
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local idx = math.floor(now / window)
+local elapsed = now - idx * window
+local currKey = KEYS[1] .. ':' .. idx
+local prev = tonumber(redis.call('GET', KEYS[1] .. ':' .. (idx - 1))) or 0
+local curr = tonumber(redis.call('GET', currKey)) or 0
+local estimated = prev * (1 - elapsed / window) + curr
+if estimated + 1 <= limit then
+	redis.call('INCR', currKey)
+	redis.call('PEXPIRE', currKey, 2 * window)
+	return {1, math.floor(limit - estimated - 1), 0}
+end
+if curr + 1 <= limit and prev > 0 then
+	return {0, 0, math.ceil((1 - (limit - 1 - curr) / prev) * window - elapsed)}
+end
+return {0, 0, window - elapsed}
+`)
+)
+
+// TokenBucket is a token bucket limiter, which keeps the buckets in Redis.
+type TokenBucket struct {
+	client redisv8.Scripter
+	prefix string
+	rate   float64
+	burst  int
+}
+
+// NewTokenBucket creates a token bucket limiter, which refills the bucket of every key with the provided rate of tokens per second,
+// up to the burst size. The buckets are stored in keys with the provided prefix.
+func NewTokenBucket(client *redis.Client, prefix string, rate float64, burst int) (*TokenBucket, error) {
+	if client == nil {
+		return nil, errors.New("client is nil")
+	}
+	if prefix == "" {
+		return nil, errors.New("prefix is empty")
+	}
+	if rate <= 0 {
+		return nil, errors.New("rate should be positive")
+	}
+	if burst <= 0 {
+		return nil, errors.New("burst should be positive")
+	}
+	return &TokenBucket{client: &client.Client, prefix: prefix, rate: rate, burst: burst}, nil
+}
+
+// Allow takes a token from the bucket of the key, if there is one.
+func (tb *TokenBucket) Allow(ctx context.Context, key string) (ratelimit.Decision, error) {
+	res, err := tokenBucketScript.Run(ctx, tb.client, []string{tb.prefix + key}, tb.rate, tb.burst).Int64Slice()
+	if err != nil {
+		return ratelimit.Decision{}, err
+	}
+	return decision(res, tb.burst)
+}
+
+// SlidingWindow is a sliding window limiter, which keeps the counters of the windows in Redis.
+// Like its in-memory counterpart, the count of the sliding window is approximated from the counts of the current and the previous fixed windows.
+type SlidingWindow struct {
+	client redisv8.Scripter
+	prefix string
+	limit  int
+	window time.Duration
+}
+
+// NewSlidingWindow creates a sliding window limiter, which allows the provided number of requests per window for every key.
+// The counters are stored in keys with the provided prefix.
+func NewSlidingWindow(client *redis.Client, prefix string, limit int, window time.Duration) (*SlidingWindow, error) {
+	if client == nil {
+		return nil, errors.New("client is nil")
+	}
+	if prefix == "" {
+		return nil, errors.New("prefix is empty")
+	}
+	if limit <= 0 {
+		return nil, errors.New("limit should be positive")
+	}
+	if window < time.Millisecond {
+		return nil, errors.New("window should be at least 1ms")
+	}
+	return &SlidingWindow{client: &client.Client, prefix: prefix, limit: limit, window: window}, nil
+}
+
+// Allow counts the request towards the window of the key, if the window has not reached the limit.
+func (sw *SlidingWindow) Allow(ctx context.Context, key string) (ratelimit.Decision, error) {
+	// the hash tag keeps the counters of the key in the same slot of a cluster
+	res, err := slidingWindowScript.Run(ctx, sw.client, []string{sw.prefix + "{" + key + "}"}, sw.limit, sw.window.Milliseconds()).Int64Slice()
+	if err != nil {
+		return ratelimit.Decision{}, err
+	}
+	return decision(res, sw.limit)
+}
+
+func decision(res []int64, limit int) (ratelimit.Decision, error) {
+	if len(res) != 3 {
+		return ratelimit.Decision{}, errors.New("unexpected response of rate limit script")
+	}
+	return ratelimit.Decision{
+		Allowed:    res[0] == 1,
+		Limit:      limit,
+		Remaining:  int(res[1]),
+		RetryAfter: time.Duration(res[2]) * time.Millisecond,
+	}, nil
+}
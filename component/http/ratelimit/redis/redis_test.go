@@ -0,0 +1,84 @@
+package redis
+
+import (
+	"testing"
+	"time"
+
+	"github.com/beatlabs/patron/client/redis"
+	"github.com/beatlabs/patron/component/http/ratelimit"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewTokenBucket(t *testing.T) {
+	t.Parallel()
+	client := redis.New(redis.Options{})
+	tests := map[string]struct {
+		client      *redis.Client
+		prefix      string
+		rate        float64
+		burst       int
+		expectedErr string
+	}{
+		"success":        {client: &client, prefix: "rl:", rate: 1, burst: 1},
+		"missing client": {prefix: "rl:", rate: 1, burst: 1, expectedErr: "client is nil"},
+		"missing prefix": {client: &client, rate: 1, burst: 1, expectedErr: "prefix is empty"},
+		"invalid rate":   {client: &client, prefix: "rl:", burst: 1, expectedErr: "rate should be positive"},
+		"invalid burst":  {client: &client, prefix: "rl:", rate: 1, expectedErr: "burst should be positive"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			got, err := NewTokenBucket(tt.client, tt.prefix, tt.rate, tt.burst)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, got)
+			}
+		})
+	}
+}
+
+func TestNewSlidingWindow(t *testing.T) {
+	t.Parallel()
+	client := redis.New(redis.Options{})
+	tests := map[string]struct {
+		client      *redis.Client
+		prefix      string
+		limit       int
+		window      time.Duration
+		expectedErr string
+	}{
+		"success":        {client: &client, prefix: "rl:", limit: 1, window: time.Second},
+		"missing client": {prefix: "rl:", limit: 1, window: time.Second, expectedErr: "client is nil"},
+		"missing prefix": {client: &client, limit: 1, window: time.Second, expectedErr: "prefix is empty"},
+		"invalid limit":  {client: &client, prefix: "rl:", window: time.Second, expectedErr: "limit should be positive"},
+		"invalid window": {client: &client, prefix: "rl:", limit: 1, expectedErr: "window should be at least 1ms"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			got, err := NewSlidingWindow(tt.client, tt.prefix, tt.limit, tt.window)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, got)
+			}
+		})
+	}
+}
+
+func TestDecision(t *testing.T) {
+	t.Parallel()
+	d, err := decision([]int64{0, 0, 1500}, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, ratelimit.Decision{Limit: 10, RetryAfter: 1500 * time.Millisecond}, d)
+
+	_, err = decision([]int64{1}, 10)
+	assert.EqualError(t, err, "unexpected response of rate limit script")
+}
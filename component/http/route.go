@@ -91,8 +91,8 @@ func (rb *RouteBuilder) WithAuth(auth auth.Authenticator) *RouteBuilder {
 }
 
 // WithRouteCache adds a cache to the corresponding route.
-func (rb *RouteBuilder) WithRouteCache(cache cache.TTLCache, ageBounds httpcache.Age) *RouteBuilder {
-	rc, ee := httpcache.NewRouteCache(cache, ageBounds)
+func (rb *RouteBuilder) WithRouteCache(cache cache.TTLCache, ageBounds httpcache.Age, oo ...httpcache.OptionFunc) *RouteBuilder {
+	rc, ee := httpcache.NewRouteCache(cache, ageBounds, oo...)
 
 	rb.routeCache = rc
 	rb.errors = append(rb.errors, ee...)
@@ -190,8 +190,8 @@ func (rb *RouteBuilder) Build() (Route, error) {
 	}
 	// cache middleware is always last, so that it caches only the headers of the handler
 	if rb.routeCache != nil {
-		if rb.method != http.MethodGet {
-			return Route{}, errors.New("cannot apply cache to a route with any method other than GET ")
+		if !rb.routeCache.CacheableMethod(rb.method) {
+			return Route{}, fmt.Errorf("cannot apply cache to a route with method %s, which is not cacheable", rb.method)
 		}
 		middlewares = append(middlewares, middleware.NewCaching(rb.routeCache))
 	}
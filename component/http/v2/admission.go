@@ -0,0 +1,188 @@
+package v2
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/beatlabs/patron/component/http/problem"
+	"github.com/gorilla/websocket"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	admissionMaxWait    = time.Second
+	admissionRetryAfter = time.Second
+
+	admissionAdmitted  = "admitted"
+	admissionQueueFull = "queue_full"
+	admissionTimeout   = "timeout"
+	admissionCanceled  = "canceled"
+)
+
+var (
+	admissionRequestsMetric   *prometheus.CounterVec
+	admissionQueueDepthMetric prometheus.Gauge
+	admissionWaitMetric       prometheus.Histogram
+)
+
+func init() {
+	admissionRequestsMetric = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "component",
+			Subsystem: "http",
+			Name:      "admission_requests_total",
+			Help:      "Requests handled by the admission control, classified by result (admitted, queue_full, timeout or canceled).",
+		},
+		[]string{"result"},
+	)
+	admissionQueueDepthMetric = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: "component",
+			Subsystem: "http",
+			Name:      "admission_queue_depth",
+			Help:      "Requests waiting in the admission queue.",
+		},
+	)
+	admissionWaitMetric = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Namespace: "component",
+			Subsystem: "http",
+			Name:      "admission_wait_seconds",
+			Help:      "Time the requests waited in the admission queue before being admitted or rejected.",
+			Buckets:   []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+		},
+	)
+	prometheus.MustRegister(admissionRequestsMetric, admissionQueueDepthMetric, admissionWaitMetric)
+}
+
+// AdmissionConfig configures the admission control of the component. Zero values are replaced by the defaults.
+type AdmissionConfig struct {
+	// MaxConcurrent limits the requests served concurrently by the handler.
+	MaxConcurrent int
+	// MaxQueue limits the requests waiting for one of the concurrent requests to complete, which are rejected immediately if zero.
+	MaxQueue int
+	// MaxWait is the longest time a request waits in the queue before it is rejected (default: 1s).
+	MaxWait time.Duration
+	// RetryAfter is the delay sent to the rejected clients in the Retry-After header, rounded up to seconds (default: 1s).
+	RetryAfter time.Duration
+}
+
+func (cfg AdmissionConfig) validate() error {
+	if cfg.MaxConcurrent <= 0 {
+		return errors.New("max concurrent requests should be positive")
+	}
+	if cfg.MaxQueue < 0 {
+		return errors.New("negative max queue provided")
+	}
+	if cfg.MaxWait < 0 {
+		return errors.New("negative max wait provided")
+	}
+	if cfg.RetryAfter < 0 {
+		return errors.New("negative retry after provided")
+	}
+	return nil
+}
+
+// admission bounds the requests served concurrently and the requests queued for them,
+// rejecting the rest with 503 Service Unavailable instead of letting them pile up.
+type admission struct {
+	// queued is accessed atomically, and is the first field for its 64-bit alignment
+	queued     int64
+	slots      chan struct{}
+	maxQueue   int64
+	maxWait    time.Duration
+	retryAfter string
+}
+
+func newAdmission(cfg AdmissionConfig) *admission {
+	if cfg.MaxWait == 0 {
+		cfg.MaxWait = admissionMaxWait
+	}
+	if cfg.RetryAfter == 0 {
+		cfg.RetryAfter = admissionRetryAfter
+	}
+	return &admission{
+		slots:      make(chan struct{}, cfg.MaxConcurrent),
+		maxQueue:   int64(cfg.MaxQueue),
+		maxWait:    cfg.MaxWait,
+		retryAfter: strconv.Itoa(int(math.Ceil(cfg.RetryAfter.Seconds()))),
+	}
+}
+
+// handler admits the requests to the next handler, except for the health checks, the metrics, the event streams,
+// the WebSocket connections and the requests of the long-lived routes, which are not limited, since they
+// should be served regardless of the load or would hold their slots for as long as they are connected.
+func (a *admission) handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if exemptFromAdmission(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		result := a.acquire(r)
+		admissionRequestsMetric.WithLabelValues(result).Inc()
+		switch result {
+		case admissionAdmitted:
+			defer a.release()
+			next.ServeHTTP(w, r)
+		case admissionCanceled:
+			// the client is gone, so there is no one to answer
+		default:
+			w.Header().Set("Retry-After", a.retryAfter)
+			problem.Write(w, r, problem.New(http.StatusServiceUnavailable, "server is overloaded"))
+		}
+	})
+}
+
+// acquire admits the request immediately if there is a free slot, or queues it until a slot is freed,
+// its wait exceeds the max wait or its context is done.
+func (a *admission) acquire(r *http.Request) string {
+	select {
+	case a.slots <- struct{}{}:
+		return admissionAdmitted
+	default:
+	}
+
+	if atomic.AddInt64(&a.queued, 1) > a.maxQueue {
+		atomic.AddInt64(&a.queued, -1)
+		return admissionQueueFull
+	}
+	admissionQueueDepthMetric.Inc()
+	start := time.Now()
+	timer := time.NewTimer(a.maxWait)
+	defer func() {
+		timer.Stop()
+		atomic.AddInt64(&a.queued, -1)
+		admissionQueueDepthMetric.Dec()
+		admissionWaitMetric.Observe(time.Since(start).Seconds())
+	}()
+
+	select {
+	case a.slots <- struct{}{}:
+		return admissionAdmitted
+	case <-timer.C:
+		return admissionTimeout
+	case <-r.Context().Done():
+		return admissionCanceled
+	}
+}
+
+func (a *admission) queuedRequests() int64 {
+	return atomic.LoadInt64(&a.queued)
+}
+
+func (a *admission) release() {
+	<-a.slots
+}
+
+func exemptFromAdmission(r *http.Request) bool {
+	switch r.URL.Path {
+	case AlivePath, ReadyPath, MetricsPath:
+		return true
+	}
+	return acceptsEventStream(r) || websocket.IsWebSocketUpgrade(r) || longLivedRoutes.match(r)
+}
@@ -0,0 +1,197 @@
+package v2
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdmissionConfig_validate(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		cfg         AdmissionConfig
+		expectedErr string
+	}{
+		"success":                {cfg: AdmissionConfig{MaxConcurrent: 10, MaxQueue: 100, MaxWait: time.Second, RetryAfter: time.Second}},
+		"without queue":          {cfg: AdmissionConfig{MaxConcurrent: 10}},
+		"zero max concurrent":    {cfg: AdmissionConfig{}, expectedErr: "max concurrent requests should be positive"},
+		"negative max queue":     {cfg: AdmissionConfig{MaxConcurrent: 1, MaxQueue: -1}, expectedErr: "negative max queue provided"},
+		"negative max wait":      {cfg: AdmissionConfig{MaxConcurrent: 1, MaxWait: -time.Second}, expectedErr: "negative max wait provided"},
+		"negative retry after":   {cfg: AdmissionConfig{MaxConcurrent: 1, RetryAfter: -time.Second}, expectedErr: "negative retry after provided"},
+		"sub-second retry after": {cfg: AdmissionConfig{MaxConcurrent: 1, RetryAfter: time.Millisecond}},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			err := tt.cfg.validate()
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestNewAdmission(t *testing.T) {
+	t.Parallel()
+	a := newAdmission(AdmissionConfig{MaxConcurrent: 2, MaxQueue: 3})
+	assert.Equal(t, 2, cap(a.slots))
+	assert.Equal(t, int64(3), a.maxQueue)
+	assert.Equal(t, admissionMaxWait, a.maxWait)
+	assert.Equal(t, "1", a.retryAfter)
+
+	a = newAdmission(AdmissionConfig{MaxConcurrent: 1, MaxWait: time.Minute, RetryAfter: 1500 * time.Millisecond})
+	assert.Equal(t, time.Minute, a.maxWait)
+	assert.Equal(t, "2", a.retryAfter)
+}
+
+// blockingHandler blocks the requests until it is released, signaling when each of them is served.
+type blockingHandler struct {
+	served  chan struct{}
+	release chan struct{}
+}
+
+func newBlockingHandler() *blockingHandler {
+	return &blockingHandler{served: make(chan struct{}, 10), release: make(chan struct{})}
+}
+
+func (h *blockingHandler) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	h.served <- struct{}{}
+	<-h.release
+	w.WriteHeader(http.StatusOK)
+}
+
+func serveAsync(handler http.Handler, r *http.Request) <-chan *httptest.ResponseRecorder {
+	ch := make(chan *httptest.ResponseRecorder, 1)
+	go func() {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, r)
+		ch <- rec
+	}()
+	return ch
+}
+
+func waitQueued(t *testing.T, a *admission, queued int64) {
+	assert.Eventually(t, func() bool {
+		return a.queuedRequests() == queued
+	}, time.Second, time.Millisecond)
+}
+
+// The admission metrics are global, so the tests are not parallel and compare them before and after.
+func TestAdmission_Handler(t *testing.T) {
+	next := newBlockingHandler()
+	a := newAdmission(AdmissionConfig{MaxConcurrent: 1, MaxQueue: 1, MaxWait: time.Minute, RetryAfter: 2 * time.Second})
+	handler := a.handler(next)
+
+	admitted := testutil.ToFloat64(admissionRequestsMetric.WithLabelValues(admissionAdmitted))
+	queueFull := testutil.ToFloat64(admissionRequestsMetric.WithLabelValues(admissionQueueFull))
+
+	first := serveAsync(handler, httptest.NewRequest(http.MethodGet, "/orders", nil))
+	<-next.served
+	second := serveAsync(handler, httptest.NewRequest(http.MethodGet, "/orders", nil))
+	waitQueued(t, a, 1)
+	assert.Equal(t, 1.0, testutil.ToFloat64(admissionQueueDepthMetric))
+
+	// the queue is full
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/orders", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, "2", rec.Header().Get("Retry-After"))
+	assert.Equal(t, queueFull+1, testutil.ToFloat64(admissionRequestsMetric.WithLabelValues(admissionQueueFull)))
+
+	// releasing the first request admits the queued one
+	next.release <- struct{}{}
+	assert.Equal(t, http.StatusOK, (<-first).Code)
+	<-next.served
+	waitQueued(t, a, 0)
+	next.release <- struct{}{}
+	assert.Equal(t, http.StatusOK, (<-second).Code)
+
+	assert.Equal(t, admitted+2, testutil.ToFloat64(admissionRequestsMetric.WithLabelValues(admissionAdmitted)))
+	assert.Equal(t, 0.0, testutil.ToFloat64(admissionQueueDepthMetric))
+	assert.Len(t, a.slots, 0)
+}
+
+func TestAdmission_Handler_Timeout(t *testing.T) {
+	next := newBlockingHandler()
+	a := newAdmission(AdmissionConfig{MaxConcurrent: 1, MaxQueue: 1, MaxWait: 10 * time.Millisecond})
+	handler := a.handler(next)
+	timeouts := testutil.ToFloat64(admissionRequestsMetric.WithLabelValues(admissionTimeout))
+
+	first := serveAsync(handler, httptest.NewRequest(http.MethodGet, "/orders", nil))
+	<-next.served
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/orders", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, "1", rec.Header().Get("Retry-After"))
+	assert.Equal(t, timeouts+1, testutil.ToFloat64(admissionRequestsMetric.WithLabelValues(admissionTimeout)))
+	assert.Equal(t, int64(0), a.queuedRequests())
+
+	next.release <- struct{}{}
+	assert.Equal(t, http.StatusOK, (<-first).Code)
+}
+
+func TestAdmission_Handler_Canceled(t *testing.T) {
+	next := newBlockingHandler()
+	a := newAdmission(AdmissionConfig{MaxConcurrent: 1, MaxQueue: 1, MaxWait: time.Minute})
+	handler := a.handler(next)
+	canceled := testutil.ToFloat64(admissionRequestsMetric.WithLabelValues(admissionCanceled))
+
+	first := serveAsync(handler, httptest.NewRequest(http.MethodGet, "/orders", nil))
+	<-next.served
+
+	ctx, cancel := context.WithCancel(context.Background())
+	second := serveAsync(handler, httptest.NewRequest(http.MethodGet, "/orders", nil).WithContext(ctx))
+	waitQueued(t, a, 1)
+	cancel()
+	rec := <-second
+	assert.False(t, rec.Flushed)
+	assert.Empty(t, rec.Header().Get("Retry-After"))
+	assert.Equal(t, canceled+1, testutil.ToFloat64(admissionRequestsMetric.WithLabelValues(admissionCanceled)))
+
+	next.release <- struct{}{}
+	assert.Equal(t, http.StatusOK, (<-first).Code)
+}
+
+func TestAdmission_Handler_Exempt(t *testing.T) {
+	t.Parallel()
+	longLivedRoutes.add(http.MethodGet, "/admission/downloads/*name")
+
+	tests := map[string]*http.Request{
+		"alive":      httptest.NewRequest(http.MethodGet, AlivePath, nil),
+		"ready":      httptest.NewRequest(http.MethodGet, ReadyPath, nil),
+		"metrics":    httptest.NewRequest(http.MethodGet, MetricsPath, nil),
+		"long-lived": httptest.NewRequest(http.MethodGet, "/admission/downloads/file.txt", nil),
+	}
+	stream := httptest.NewRequest(http.MethodGet, "/events", nil)
+	stream.Header.Set("Accept", "text/event-stream")
+	tests["event stream"] = stream
+	ws := httptest.NewRequest(http.MethodGet, "/ws", nil)
+	ws.Header.Set("Connection", "upgrade")
+	ws.Header.Set("Upgrade", "websocket")
+	tests["websocket"] = ws
+
+	for name, r := range tests {
+		r := r
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			// without a queue and with its only slot taken, any limited request would be rejected
+			a := newAdmission(AdmissionConfig{MaxConcurrent: 1})
+			a.slots <- struct{}{}
+			handler := a.handler(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusAccepted)
+			}))
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, r)
+			assert.Equal(t, http.StatusAccepted, rec.Code)
+		})
+	}
+}
@@ -3,13 +3,20 @@ package v2
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"net/http"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/beatlabs/patron/component/http/auth/mtls"
 	"github.com/beatlabs/patron/log"
+	"github.com/gorilla/websocket"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 )
 
 const (
@@ -21,17 +28,41 @@ const (
 	shutdownGracePeriod = 5 * time.Second
 )
 
+var inFlightRequestsMetric prometheus.Gauge
+
+func init() {
+	inFlightRequestsMetric = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: "component",
+			Subsystem: "http",
+			Name:      "inflight_requests",
+			Help:      "Number of HTTP requests being served by the component.",
+		},
+	)
+	prometheus.MustRegister(inFlightRequestsMetric)
+}
+
 // Component implementation of an HTTP router.
 type Component struct {
+	// inFlight is accessed atomically, and is the first field for its 64-bit alignment
+	inFlight            int64
 	port                int
 	readTimeout         time.Duration
 	writeTimeout        time.Duration
 	shutdownGracePeriod time.Duration
+	drainDelay          time.Duration
 	handlerTimeout      time.Duration
 	handler             http.Handler
 	mu                  sync.Mutex
 	certFile            string
 	keyFile             string
+	clientTLS           *tls.Config
+	http2               *HTTP2Config
+	h2c                 bool
+	admission           *admission
+	shutdownCh          chan struct{}
+	draining            int32
+	srv                 *http.Server
 }
 
 // New creates an HTTP component configurable by functional options.
@@ -47,6 +78,7 @@ func New(handler http.Handler, oo ...OptionFunc) (*Component, error) {
 		shutdownGracePeriod: shutdownGracePeriod,
 		handlerTimeout:      handlerTimeout,
 		handler:             handler,
+		shutdownCh:          make(chan struct{}),
 	}
 
 	for _, option := range oo {
@@ -56,6 +88,13 @@ func New(handler http.Handler, oo ...OptionFunc) (*Component, error) {
 		}
 	}
 
+	if cmp.h2c && cmp.certFile != "" {
+		return nil, errors.New("h2c cannot be used with TLS")
+	}
+	if cmp.clientTLS != nil && cmp.certFile == "" {
+		return nil, errors.New("client certificates cannot be verified without TLS")
+	}
+
 	return cmp, nil
 }
 
@@ -63,29 +102,150 @@ func New(handler http.Handler, oo ...OptionFunc) (*Component, error) {
 func (c *Component) Run(ctx context.Context) error {
 	c.mu.Lock()
 	chFail := make(chan error)
-	srv := c.createHTTPServer()
+	srv, err := c.createHTTPServer()
+	if err != nil {
+		c.mu.Unlock()
+		return err
+	}
+	c.srv = srv
+	if atomic.LoadInt32(&c.draining) == 1 {
+		srv.SetKeepAlivesEnabled(false)
+	}
 	go c.listenAndServe(srv, chFail)
 	c.mu.Unlock()
 
 	select {
 	case <-ctx.Done():
-		log.Info("shutting down HTTP component")
-		ctx, cancel := context.WithTimeout(context.Background(), c.shutdownGracePeriod)
-		defer cancel()
-		return srv.Shutdown(ctx)
+		return c.shutdown(srv)
 	case err := <-chFail:
 		return err
 	}
 }
 
-func (c *Component) createHTTPServer() *http.Server {
-	return &http.Server{
+// InFlight returns the number of requests being served.
+func (c *Component) InFlight() int64 {
+	return atomic.LoadInt64(&c.inFlight)
+}
+
+// Drain fails the readiness checks of the component, which keeps serving the requests, in order for the load balancers to stop sending them
+// before it shuts down. The service drains its components when it is terminated, before shutting them down.
+func (c *Component) Drain() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	atomic.StoreInt32(&c.draining, 1)
+	if c.srv != nil {
+		// closing the connections after their responses makes clients reconnect to other instances
+		c.srv.SetKeepAlivesEnabled(false)
+	}
+}
+
+// shutdown fails the readiness checks and keeps serving for the drain delay, in order for the load balancers to stop sending requests,
+// and then stops accepting connections and waits for the in-flight requests to complete, up to the shutdown grace period.
+// The drain delay is skipped if the component has already been drained, e.g. by the service.
+func (c *Component) shutdown(srv *http.Server) error {
+	if c.drainDelay > 0 && atomic.LoadInt32(&c.draining) == 0 {
+		log.Infof("draining HTTP component for %v before shutting down", c.drainDelay)
+		c.Drain()
+		time.Sleep(c.drainDelay)
+	}
+
+	log.Infof("shutting down HTTP component with %d in-flight requests", c.InFlight())
+	// ends the event streams and the WebSocket connections, which would otherwise be served until the clients disconnect
+	close(c.shutdownCh)
+	ctx, cancel := context.WithTimeout(context.Background(), c.shutdownGracePeriod)
+	defer cancel()
+	err := srv.Shutdown(ctx)
+	if err == nil {
+		// the server does not wait for the hijacked connections e.g. of WebSockets
+		err = c.waitInFlight(ctx)
+	}
+	if err != nil {
+		inFlight := c.InFlight()
+		_ = srv.Close()
+		return fmt.Errorf("failed to complete %d in-flight requests within shutdown grace period: %w", inFlight, err)
+	}
+	return nil
+}
+
+func (c *Component) waitInFlight(ctx context.Context) error {
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	for c.InFlight() > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+func (c *Component) createHTTPServer() (*http.Server, error) {
+	var handler http.Handler = c.timeoutHandler()
+	if c.admission != nil {
+		handler = c.admission.handler(handler)
+	}
+	handler = c.trackingHandler(handler)
+	if c.clientTLS != nil {
+		handler = mtls.Identify(handler)
+	}
+	srv := &http.Server{
 		Addr:         fmt.Sprintf(":%d", c.port),
 		ReadTimeout:  c.readTimeout,
 		WriteTimeout: c.writeTimeout,
 		IdleTimeout:  idleTimeout,
-		Handler:      http.TimeoutHandler(c.handler, c.handlerTimeout, ""),
+		Handler:      handler,
+	}
+	if c.clientTLS != nil {
+		// the config is cloned, since it is modified by the server e.g. for HTTP/2
+		srv.TLSConfig = c.clientTLS.Clone()
 	}
+	if c.http2 == nil {
+		return srv, nil
+	}
+
+	h2s := c.http2.server()
+	// configuring the server registers the HTTP/2 connections, including the h2c ones, for its graceful shutdown
+	if err := http2.ConfigureServer(srv, h2s); err != nil {
+		return nil, fmt.Errorf("failed to configure HTTP/2: %w", err)
+	}
+	if c.h2c {
+		srv.Handler = h2c.NewHandler(handler, h2s)
+	}
+	return srv, nil
+}
+
+// trackingHandler counts the in-flight requests, and fails the readiness checks while draining.
+func (c *Component) trackingHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&c.inFlight, 1)
+		inFlightRequestsMetric.Inc()
+		defer func() {
+			atomic.AddInt64(&c.inFlight, -1)
+			inFlightRequestsMetric.Dec()
+		}()
+
+		if r.URL.Path == ReadyPath && atomic.LoadInt32(&c.draining) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// timeoutHandler limits the duration of the handler, except for the event streams and the WebSocket connections, which are long-lived,
+// and which would be buffered and could not be upgraded by http.TimeoutHandler respectively. They end instead when the component shuts down.
+// The requests of the routes created with the LongLived option, which would be buffered as well, are not limited either.
+func (c *Component) timeoutHandler() http.Handler {
+	timeout := http.TimeoutHandler(c.handler, c.handlerTimeout, "")
+	var shutdown <-chan struct{} = c.shutdownCh
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if acceptsEventStream(r) || websocket.IsWebSocketUpgrade(r) || longLivedRoutes.match(r) {
+			c.handler.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), shutdownContextKey{}, shutdown)))
+			return
+		}
+		timeout.ServeHTTP(w, r)
+	})
 }
 
 func (c *Component) listenAndServe(srv *http.Server, ch chan<- error) {
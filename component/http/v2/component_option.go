@@ -3,6 +3,8 @@ package v2
 import (
 	"errors"
 	"time"
+
+	"github.com/beatlabs/patron/component/http/auth/mtls"
 )
 
 // OptionFunc definition for configuring the component in a functional way.
@@ -21,6 +23,57 @@ func TLS(cert, key string) OptionFunc {
 	}
 }
 
+// ClientCertificates functional option, which verifies the certificates of the clients, i.e. mutual TLS, against the authorities
+// of the config, optionally checking their revocation status. It requires the TLS option. The handlers get the identity of the
+// verified certificate with mtls.IdentityFromContext, and the ClientCertificate route option authorizes the requests by it.
+func ClientCertificates(cfg mtls.Config) OptionFunc {
+	return func(cmp *Component) error {
+		tlsCfg, err := cfg.TLSConfig()
+		if err != nil {
+			return err
+		}
+		cmp.clientTLS = tlsCfg
+		return nil
+	}
+}
+
+// HTTP2 functional option, which configures the HTTP/2 support of the component, e.g. its concurrent streams and flow control windows.
+// HTTP/2 is negotiated with the clients over TLS, while H2C enables it without TLS.
+func HTTP2(cfg HTTP2Config) OptionFunc {
+	return func(cmp *Component) error {
+		if err := cfg.validate(); err != nil {
+			return err
+		}
+		cmp.http2 = &cfg
+		return nil
+	}
+}
+
+// H2C functional option, which enables HTTP/2 over cleartext connections, both with prior knowledge and upgrades from HTTP/1.1,
+// e.g. for gRPC gateways or service meshes terminating TLS. It cannot be used with TLS.
+func H2C() OptionFunc {
+	return func(cmp *Component) error {
+		cmp.h2c = true
+		if cmp.http2 == nil {
+			cmp.http2 = &HTTP2Config{}
+		}
+		return nil
+	}
+}
+
+// Admission functional option, which bounds the requests served concurrently and the requests queued for them, so that overload
+// is answered with fast 503 Service Unavailable responses with a Retry-After header, instead of an unbounded growth of the requests being served.
+// The health checks, the metrics and the long-lived requests, e.g. event streams and WebSocket connections, are not limited.
+func Admission(cfg AdmissionConfig) OptionFunc {
+	return func(cmp *Component) error {
+		if err := cfg.validate(); err != nil {
+			return err
+		}
+		cmp.admission = newAdmission(cfg)
+		return nil
+	}
+}
+
 // ReadTimeout functional option.
 func ReadTimeout(rt time.Duration) OptionFunc {
 	return func(cmp *Component) error {
@@ -65,6 +118,19 @@ func ShutdownGracePeriod(gp time.Duration) OptionFunc {
 	}
 }
 
+// ShutdownDrainDelay functional option, which delays the shutdown of the component while failing its readiness checks,
+// in order for the load balancers e.g. of Kubernetes to stop routing requests to it before it stops accepting connections.
+// The delay should exceed the time needed for the readiness probe to fail, e.g. its period times its failure threshold.
+func ShutdownDrainDelay(d time.Duration) OptionFunc {
+	return func(cmp *Component) error {
+		if d <= 0*time.Second {
+			return errors.New("negative or zero shutdown drain delay provided")
+		}
+		cmp.drainDelay = d
+		return nil
+	}
+}
+
 // Port functional option.
 func Port(port int) OptionFunc {
 	return func(cmp *Component) error {
@@ -1,9 +1,11 @@
 package v2
 
 import (
+	"crypto/tls"
 	"testing"
 	"time"
 
+	"github.com/beatlabs/patron/component/http/auth/mtls"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -183,3 +185,116 @@ func TestPort(t *testing.T) {
 		})
 	}
 }
+
+func TestShutdownDrainDelay(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		delay       time.Duration
+		expectedErr string
+	}{
+		"success":        {delay: time.Second},
+		"negative delay": {delay: -1 * time.Second, expectedErr: "negative or zero shutdown drain delay provided"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			cmp := &Component{}
+			err := ShutdownDrainDelay(tt.delay)(cmp)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.delay, cmp.drainDelay)
+			}
+		})
+	}
+}
+
+func TestHTTP2(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		cfg         HTTP2Config
+		expectedErr string
+	}{
+		"success":  {cfg: HTTP2Config{MaxConcurrentStreams: 100, MaxReadFrameSize: 1 << 16, IdleTimeout: time.Minute}},
+		"defaults": {cfg: HTTP2Config{}},
+		"invalid max read frame size": {
+			cfg: HTTP2Config{MaxReadFrameSize: 1 << 10}, expectedErr: "max read frame size should be between 16KB and 16MB",
+		},
+		"invalid max upload buffer per connection": {
+			cfg: HTTP2Config{MaxUploadBufferPerConnection: 1 << 10}, expectedErr: "max upload buffer per connection should be at least 64KB",
+		},
+		"invalid max upload buffer per stream": {
+			cfg: HTTP2Config{MaxUploadBufferPerStream: -1}, expectedErr: "max upload buffer per stream should be at least 64KB",
+		},
+		"negative idle timeout": {cfg: HTTP2Config{IdleTimeout: -time.Second}, expectedErr: "negative idle timeout provided"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			cmp := &Component{}
+			err := HTTP2(tt.cfg)(cmp)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, &tt.cfg, cmp.http2)
+				assert.False(t, cmp.h2c)
+			}
+		})
+	}
+}
+
+func TestAdmission(t *testing.T) {
+	t.Parallel()
+	cmp := &Component{}
+	assert.NoError(t, Admission(AdmissionConfig{MaxConcurrent: 10, MaxQueue: 100})(cmp))
+	assert.Equal(t, 10, cap(cmp.admission.slots))
+	assert.Equal(t, int64(100), cmp.admission.maxQueue)
+
+	cmp = &Component{}
+	assert.EqualError(t, Admission(AdmissionConfig{MaxQueue: 100})(cmp), "max concurrent requests should be positive")
+	assert.Nil(t, cmp.admission)
+}
+
+func TestH2C(t *testing.T) {
+	t.Parallel()
+	cmp := &Component{}
+	assert.NoError(t, H2C()(cmp))
+	assert.True(t, cmp.h2c)
+	assert.Equal(t, &HTTP2Config{}, cmp.http2)
+
+	cfg := HTTP2Config{MaxConcurrentStreams: 10}
+	cmp = &Component{}
+	assert.NoError(t, HTTP2(cfg)(cmp))
+	assert.NoError(t, H2C()(cmp))
+	assert.Equal(t, &cfg, cmp.http2)
+}
+
+func TestClientCertificates(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		cfg         mtls.Config
+		expectedErr string
+	}{
+		"success":         {cfg: mtls.Config{CAFile: "../testdata/server.pem"}},
+		"missing CA file": {cfg: mtls.Config{}, expectedErr: "CA file is empty"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			cmp := &Component{}
+			err := ClientCertificates(tt.cfg)(cmp)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.Nil(t, cmp.clientTLS)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tls.RequireAndVerifyClientCert, cmp.clientTLS.ClientAuth)
+			}
+		})
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/beatlabs/patron/component/http/auth/mtls"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -40,6 +41,14 @@ func TestNew(t *testing.T) {
 			handler: &stubHandler{},
 			oo:      []OptionFunc{Port(500000)},
 		}, expectedErr: "invalid HTTP Port provided"},
+		"h2c with TLS": {args: args{
+			handler: &stubHandler{},
+			oo:      []OptionFunc{H2C(), TLS("cert", "key")},
+		}, expectedErr: "h2c cannot be used with TLS"},
+		"client certificates without TLS": {args: args{
+			handler: &stubHandler{},
+			oo:      []OptionFunc{ClientCertificates(mtls.Config{CAFile: "../testdata/server.pem"})},
+		}, expectedErr: "client certificates cannot be verified without TLS"},
 	}
 	for name, tt := range tests {
 		tt := tt
@@ -79,3 +88,123 @@ func TestComponent_ListenAndServe_DefaultRoutes_Shutdown(t *testing.T) {
 	cnl()
 	assert.True(t, <-done)
 }
+
+func freePort(t *testing.T) int {
+	listener, err := net.Listen("tcp", ":0") //nolint:gosec
+	require.NoError(t, err)
+	port, ok := listener.Addr().(*net.TCPAddr)
+	require.True(t, ok)
+	require.NoError(t, listener.Close())
+	return port.Port
+}
+
+func TestComponent_Shutdown_Drain(t *testing.T) {
+	port := freePort(t)
+	release := make(chan struct{})
+	mux := http.NewServeMux()
+	mux.HandleFunc(ReadyPath, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, _ *http.Request) {
+		<-release
+		w.WriteHeader(http.StatusOK)
+	})
+
+	cmp, err := New(mux, Port(port), ShutdownDrainDelay(200*time.Millisecond))
+	require.NoError(t, err)
+	done := make(chan error)
+	ctx, cnl := context.WithCancel(context.Background())
+	go func() {
+		done <- cmp.Run(ctx)
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	rsp, err := http.Get(fmt.Sprintf("http://localhost:%d%s", port, ReadyPath))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rsp.StatusCode)
+
+	slow := make(chan int)
+	go func() {
+		rsp, err := http.Get(fmt.Sprintf("http://localhost:%d/slow", port))
+		if err != nil {
+			slow <- 0
+			return
+		}
+		slow <- rsp.StatusCode
+	}()
+	assert.Eventually(t, func() bool { return cmp.InFlight() == 1 }, time.Second, 5*time.Millisecond)
+
+	cnl()
+	time.Sleep(50 * time.Millisecond)
+	// while draining, readiness fails while requests are still served
+	rsp, err = http.Get(fmt.Sprintf("http://localhost:%d%s", port, ReadyPath))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, rsp.StatusCode)
+	assert.True(t, rsp.Close)
+
+	close(release)
+	assert.Equal(t, http.StatusOK, <-slow)
+	assert.NoError(t, <-done)
+	assert.Equal(t, int64(0), cmp.InFlight())
+}
+
+func TestComponent_Drain(t *testing.T) {
+	port := freePort(t)
+	mux := http.NewServeMux()
+	mux.HandleFunc(ReadyPath, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	// the drain delay is skipped, since the component has already been drained
+	cmp, err := New(mux, Port(port), ShutdownDrainDelay(time.Hour))
+	require.NoError(t, err)
+	done := make(chan error)
+	ctx, cnl := context.WithCancel(context.Background())
+	go func() {
+		done <- cmp.Run(ctx)
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	cmp.Drain()
+	rsp, err := http.Get(fmt.Sprintf("http://localhost:%d%s", port, ReadyPath))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, rsp.StatusCode)
+	assert.True(t, rsp.Close)
+
+	cnl()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		assert.Fail(t, "component was not shut down")
+	}
+}
+
+func TestComponent_Shutdown_GracePeriodExceeded(t *testing.T) {
+	port := freePort(t)
+	release := make(chan struct{})
+	defer close(release)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		<-release
+	})
+
+	cmp, err := New(handler, Port(port), ShutdownGracePeriod(50*time.Millisecond))
+	require.NoError(t, err)
+	done := make(chan error)
+	ctx, cnl := context.WithCancel(context.Background())
+	go func() {
+		done <- cmp.Run(ctx)
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	go func() {
+		rsp, err := http.Get(fmt.Sprintf("http://localhost:%d/", port))
+		if err == nil {
+			_ = rsp.Body.Close()
+		}
+	}()
+	assert.Eventually(t, func() bool { return cmp.InFlight() == 1 }, time.Second, 5*time.Millisecond)
+
+	cnl()
+	assert.EqualError(t, <-done, "failed to complete 1 in-flight requests within shutdown grace period: context deadline exceeded")
+}
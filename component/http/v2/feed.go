@@ -0,0 +1,153 @@
+package v2
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Feed retains the latest events published to it, e.g. the messages of a Redis broadcast subscription, so that long polls
+// and event streams are served the events following the ID of the last event they received, which is their resume token.
+// Polls without a resume token are served the events published after them. The IDs of the events are unique per feed,
+// so polls resuming with the ID of another feed, e.g. of another replica, or of an event no longer retained,
+// are served all the retained events, which they may have received already. It is safe for concurrent use.
+type Feed struct {
+	mu     sync.Mutex
+	epoch  string
+	size   int
+	seq    uint64
+	events []Event
+	// notify is closed and replaced when an event is published, waking up the waiting polls
+	notify chan struct{}
+}
+
+// NewFeed creates a feed retaining up to size events.
+func NewFeed(size int) (*Feed, error) {
+	if size <= 0 {
+		return nil, errors.New("feed size should be positive")
+	}
+	return &Feed{
+		epoch:  strconv.FormatInt(time.Now().UnixNano(), 36),
+		size:   size,
+		events: make([]Event, 0, size),
+		notify: make(chan struct{}),
+	}, nil
+}
+
+// Publish publishes an event of the name and the data, returning it with its ID.
+func (f *Feed) Publish(name, data string) Event {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.seq++
+	e := Event{ID: f.id(f.seq), Name: name, Data: data}
+	if len(f.events) == f.size {
+		f.events = append(f.events[1:], e)
+	} else {
+		f.events = append(f.events, e)
+	}
+	close(f.notify)
+	f.notify = make(chan struct{})
+	return e
+}
+
+// Consume publishes the messages of the channel as events of the name, e.g. the messages of a Redis broadcast subscription,
+// until the channel is closed or the context is done.
+func (f *Feed) Consume(ctx context.Context, messages <-chan []byte, name string) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+			f.Publish(name, string(msg))
+		}
+	}
+}
+
+// Wait waits for the events following the resume token, until they are published or the context is done.
+// It returns the events, along with the resume token of the next poll, or no events once the context is done.
+func (f *Feed) Wait(ctx context.Context, token string) ([]Event, string, error) {
+	for {
+		f.mu.Lock()
+		if token == "" {
+			token = f.id(f.seq)
+		}
+		events := f.after(token)
+		notify := f.notify
+		f.mu.Unlock()
+
+		if len(events) > 0 {
+			return events, events[len(events)-1].ID, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, token, nil
+		case <-notify:
+		}
+	}
+}
+
+// LongPoll serves the polls of a long polling route with the events of the feed.
+func (f *Feed) LongPoll(ctx context.Context, _ *http.Request, token string) ([]Event, string, error) {
+	return f.Wait(ctx, token)
+}
+
+// Stream serves the event streams of a server-sent events route with the events of the feed,
+// resuming from the Last-Event-ID of the reconnecting clients.
+func (f *Feed) Stream(r *http.Request, s *Stream) error {
+	token := LastEventID(r)
+	for {
+		events, next, err := f.Wait(s.Context(), token)
+		if err != nil {
+			return err
+		}
+		if len(events) == 0 {
+			return s.Context().Err()
+		}
+		for _, e := range events {
+			if err := s.Send(e); err != nil {
+				return err
+			}
+		}
+		token = next
+	}
+}
+
+func (f *Feed) id(seq uint64) string {
+	return f.epoch + "-" + strconv.FormatUint(seq, 10)
+}
+
+// after returns a copy of the retained events following the event of the ID.
+func (f *Feed) after(id string) []Event {
+	seq, ok := f.parse(id)
+	if ok && seq >= f.seq {
+		return nil
+	}
+	first := f.seq - uint64(len(f.events)) + 1
+	var events []Event
+	if !ok || seq+1 < first {
+		events = f.events
+	} else {
+		events = f.events[seq+1-first:]
+	}
+	return append([]Event(nil), events...)
+}
+
+// parse returns the sequence of the ID, if it is an ID of the feed.
+func (f *Feed) parse(id string) (uint64, bool) {
+	i := strings.LastIndex(id, "-")
+	if i < 0 || id[:i] != f.epoch {
+		return 0, false
+	}
+	seq, err := strconv.ParseUint(id[i+1:], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return seq, true
+}
@@ -0,0 +1,138 @@
+package v2
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewFeed(t *testing.T) {
+	t.Parallel()
+	feed, err := NewFeed(10)
+	assert.NoError(t, err)
+	assert.NotNil(t, feed)
+
+	feed, err = NewFeed(0)
+	assert.EqualError(t, err, "feed size should be positive")
+	assert.Nil(t, feed)
+}
+
+func TestFeed_Wait(t *testing.T) {
+	t.Parallel()
+	feed, err := NewFeed(3)
+	require.NoError(t, err)
+	e1 := feed.Publish("order", "1")
+	e2 := feed.Publish("order", "2")
+
+	expired := func() context.Context {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		return ctx
+	}
+
+	events, next, err := feed.Wait(expired(), e1.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, []Event{e2}, events)
+	assert.Equal(t, e2.ID, next)
+
+	// polls without a token wait for the events published after them
+	events, next, err = feed.Wait(expired(), "")
+	assert.NoError(t, err)
+	assert.Empty(t, events)
+	assert.Equal(t, e2.ID, next)
+
+	// unknown tokens are served all the retained events
+	events, _, err = feed.Wait(expired(), "other-1")
+	assert.NoError(t, err)
+	assert.Equal(t, []Event{e1, e2}, events)
+
+	e3 := feed.Publish("order", "3")
+	e4 := feed.Publish("order", "4")
+	// the event of the token is no longer retained
+	events, next, err = feed.Wait(expired(), e1.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, []Event{e2, e3, e4}, events)
+	assert.Equal(t, e4.ID, next)
+}
+
+func TestFeed_WaitPublished(t *testing.T) {
+	t.Parallel()
+	feed, err := NewFeed(3)
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	time.AfterFunc(10*time.Millisecond, func() { feed.Publish("", "published") })
+
+	events, next, err := feed.Wait(ctx, "")
+
+	assert.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, "published", events[0].Data)
+	assert.Equal(t, events[0].ID, next)
+}
+
+func TestFeed_Consume(t *testing.T) {
+	t.Parallel()
+	feed, err := NewFeed(3)
+	require.NoError(t, err)
+	messages := make(chan []byte, 2)
+	messages <- []byte("1")
+	messages <- []byte("2")
+	close(messages)
+
+	feed.Consume(context.Background(), messages, "order")
+
+	events, _, err := feed.Wait(context.Background(), feed.id(0))
+	assert.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, Event{ID: feed.id(1), Name: "order", Data: "1"}, events[0])
+	assert.Equal(t, Event{ID: feed.id(2), Name: "order", Data: "2"}, events[1])
+}
+
+func TestFeed_LongPoll(t *testing.T) {
+	t.Parallel()
+	feed, err := NewFeed(3)
+	require.NoError(t, err)
+	e1 := feed.Publish("", "1")
+	e2 := feed.Publish("", "2")
+	route, err := NewLongPollRoute("/poll", feed.LongPoll, LongPollConfig{Timeout: 20 * time.Millisecond})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "/poll", nil)
+	req.Header.Set(resumeTokenHeader, e1.ID)
+	rec := httptest.NewRecorder()
+	route.Handler()(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, e2.ID, rec.Header().Get(resumeTokenHeader))
+
+	req = httptest.NewRequest(http.MethodGet, "/poll", nil)
+	req.Header.Set(resumeTokenHeader, e2.ID)
+	rec = httptest.NewRecorder()
+	route.Handler()(rec, req)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, e2.ID, rec.Header().Get(resumeTokenHeader))
+}
+
+func TestFeed_Stream(t *testing.T) {
+	t.Parallel()
+	feed, err := NewFeed(3)
+	require.NoError(t, err)
+	e1 := feed.Publish("", "1")
+	feed.Publish("order", "2")
+	route, err := NewSSERoute("/events", feed.Stream, SSEConfig{Heartbeat: time.Hour})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	req := httptest.NewRequest(http.MethodGet, "/events", nil).WithContext(ctx)
+	req.Header.Set(lastEventIDHeader, e1.ID)
+	rec := httptest.NewRecorder()
+	route.Handler()(rec, req)
+
+	assert.Equal(t, ":\n\nid: "+feed.id(2)+"\nevent: order\ndata: 2\n\n", rec.Body.String())
+}
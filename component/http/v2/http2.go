@@ -0,0 +1,87 @@
+package v2
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"golang.org/x/net/http2"
+)
+
+const (
+	http2MaxConcurrentStreams         = 250
+	http2MaxReadFrameSize             = 1 << 20
+	http2MaxUploadBufferPerConnection = 4 << 20
+	http2MaxUploadBufferPerStream     = 1 << 20
+
+	// limits of the frame size and the flow control windows of the HTTP/2 specification
+	http2MinFrameSize    = 1 << 14
+	http2MaxFrameSize    = 1<<24 - 1
+	http2MinUploadBuffer = 1<<16 - 1
+)
+
+// HTTP2Config configures the HTTP/2 support of the component. Zero values are replaced by the defaults.
+type HTTP2Config struct {
+	// MaxConcurrentStreams limits the streams each client may open concurrently (default: 250).
+	MaxConcurrentStreams uint32
+	// MaxReadFrameSize is the largest frame the server is willing to read, between 16KB and 16MB (default: 1MB).
+	MaxReadFrameSize uint32
+	// MaxUploadBufferPerConnection is the flow control window of each connection, at least 64KB (default: 4MB).
+	MaxUploadBufferPerConnection int32
+	// MaxUploadBufferPerStream is the flow control window of each stream, at least 64KB (default: 1MB).
+	MaxUploadBufferPerStream int32
+	// IdleTimeout closes the connections idle for longer (default: the idle timeout of the component, 240s).
+	IdleTimeout time.Duration
+}
+
+func (cfg HTTP2Config) validate() error {
+	if cfg.MaxReadFrameSize != 0 && (cfg.MaxReadFrameSize < http2MinFrameSize || cfg.MaxReadFrameSize > http2MaxFrameSize) {
+		return errors.New("max read frame size should be between 16KB and 16MB")
+	}
+	if cfg.MaxUploadBufferPerConnection < 0 || (cfg.MaxUploadBufferPerConnection > 0 && cfg.MaxUploadBufferPerConnection < http2MinUploadBuffer) {
+		return errors.New("max upload buffer per connection should be at least 64KB")
+	}
+	if cfg.MaxUploadBufferPerStream < 0 || (cfg.MaxUploadBufferPerStream > 0 && cfg.MaxUploadBufferPerStream < http2MinUploadBuffer) {
+		return errors.New("max upload buffer per stream should be at least 64KB")
+	}
+	if cfg.IdleTimeout < 0 {
+		return errors.New("negative idle timeout provided")
+	}
+	return nil
+}
+
+func (cfg HTTP2Config) server() *http2.Server {
+	srv := &http2.Server{
+		MaxConcurrentStreams:         cfg.MaxConcurrentStreams,
+		MaxReadFrameSize:             cfg.MaxReadFrameSize,
+		MaxUploadBufferPerConnection: cfg.MaxUploadBufferPerConnection,
+		MaxUploadBufferPerStream:     cfg.MaxUploadBufferPerStream,
+		IdleTimeout:                  cfg.IdleTimeout,
+	}
+	if srv.MaxConcurrentStreams == 0 {
+		srv.MaxConcurrentStreams = http2MaxConcurrentStreams
+	}
+	if srv.MaxReadFrameSize == 0 {
+		srv.MaxReadFrameSize = http2MaxReadFrameSize
+	}
+	if srv.MaxUploadBufferPerConnection == 0 {
+		srv.MaxUploadBufferPerConnection = http2MaxUploadBufferPerConnection
+	}
+	if srv.MaxUploadBufferPerStream == 0 {
+		srv.MaxUploadBufferPerStream = http2MaxUploadBufferPerStream
+	}
+	if srv.IdleTimeout == 0 {
+		srv.IdleTimeout = idleTimeout
+	}
+	return srv
+}
+
+// Push initiates an HTTP/2 server push of the target, e.g. a stylesheet needed by the response, before the response is written.
+// It fails if the protocol of the request or the client do not support pushes, e.g. with http.ErrNotSupported for HTTP/1.1.
+func Push(w http.ResponseWriter, target string, opts *http.PushOptions) error {
+	pusher, ok := w.(http.Pusher)
+	if !ok {
+		return http.ErrNotSupported
+	}
+	return pusher.Push(target, opts)
+}
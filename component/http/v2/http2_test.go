@@ -0,0 +1,96 @@
+package v2
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/http2"
+)
+
+func TestHTTP2Config_Server(t *testing.T) {
+	t.Parallel()
+	srv := HTTP2Config{}.server()
+	assert.Equal(t, uint32(http2MaxConcurrentStreams), srv.MaxConcurrentStreams)
+	assert.Equal(t, uint32(http2MaxReadFrameSize), srv.MaxReadFrameSize)
+	assert.Equal(t, int32(http2MaxUploadBufferPerConnection), srv.MaxUploadBufferPerConnection)
+	assert.Equal(t, int32(http2MaxUploadBufferPerStream), srv.MaxUploadBufferPerStream)
+	assert.Equal(t, idleTimeout, srv.IdleTimeout)
+
+	srv = HTTP2Config{MaxConcurrentStreams: 10, IdleTimeout: time.Minute}.server()
+	assert.Equal(t, uint32(10), srv.MaxConcurrentStreams)
+	assert.Equal(t, time.Minute, srv.IdleTimeout)
+}
+
+type stubPusher struct {
+	*httptest.ResponseRecorder
+	targets []string
+}
+
+func (sp *stubPusher) Push(target string, _ *http.PushOptions) error {
+	sp.targets = append(sp.targets, target)
+	return nil
+}
+
+func TestPush(t *testing.T) {
+	t.Parallel()
+	assert.True(t, errors.Is(Push(httptest.NewRecorder(), "/app.css", nil), http.ErrNotSupported))
+
+	pusher := &stubPusher{ResponseRecorder: httptest.NewRecorder()}
+	assert.NoError(t, Push(pusher, "/app.css", nil))
+	assert.Equal(t, []string{"/app.css"}, pusher.targets)
+}
+
+func TestComponent_H2C(t *testing.T) {
+	port := freePort(t)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprintf(w, "HTTP/%d", r.ProtoMajor)
+	})
+	cmp, err := New(handler, Port(port), H2C())
+	require.NoError(t, err)
+	done := make(chan error)
+	ctx, cnl := context.WithCancel(context.Background())
+	go func() {
+		done <- cmp.Run(ctx)
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	h2cClient := &http.Client{Transport: &http2.Transport{
+		AllowHTTP: true,
+		DialTLS: func(network, addr string, _ *tls.Config) (net.Conn, error) {
+			return net.Dial(network, addr)
+		},
+	}}
+	tests := map[string]struct {
+		client   *http.Client
+		expected string
+	}{
+		"prior knowledge": {client: h2cClient, expected: "HTTP/2"},
+		"HTTP/1.1":        {client: http.DefaultClient, expected: "HTTP/1"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			rsp, err := tt.client.Get(fmt.Sprintf("http://localhost:%d/", port))
+			require.NoError(t, err)
+			defer func() {
+				_ = rsp.Body.Close()
+			}()
+			body, err := ioutil.ReadAll(rsp.Body)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, string(body))
+		})
+	}
+
+	cnl()
+	assert.NoError(t, <-done)
+}
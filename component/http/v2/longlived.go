@@ -0,0 +1,62 @@
+package v2
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// longLivedRoutes are the routes created with the LongLived option, which the component serves outside its handler timeout.
+var longLivedRoutes = &routePatterns{}
+
+// routePatterns matches the paths of requests against the paths of routes, with named and catch-all parameters, e.g. /files/:id or /files/*path.
+type routePatterns struct {
+	mu       sync.RWMutex
+	patterns map[string][][]string
+}
+
+func (p *routePatterns) add(method, path string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.patterns == nil {
+		p.patterns = make(map[string][][]string)
+	}
+	p.patterns[method] = append(p.patterns[method], strings.Split(path, "/"))
+}
+
+func (p *routePatterns) match(r *http.Request) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	patterns := p.patterns[r.Method]
+	if len(patterns) == 0 {
+		return false
+	}
+	segments := strings.Split(r.URL.Path, "/")
+	for _, pattern := range patterns {
+		if matchSegments(pattern, segments) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchSegments(pattern, segments []string) bool {
+	for i, p := range pattern {
+		if strings.HasPrefix(p, "*") {
+			return true
+		}
+		if i >= len(segments) {
+			return false
+		}
+		if strings.HasPrefix(p, ":") {
+			if segments[i] == "" {
+				return false
+			}
+			continue
+		}
+		if p != segments[i] {
+			return false
+		}
+	}
+	return len(pattern) == len(segments)
+}
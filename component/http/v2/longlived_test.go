@@ -0,0 +1,73 @@
+package v2
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoutePatterns_Match(t *testing.T) {
+	t.Parallel()
+	p := &routePatterns{}
+	p.add(http.MethodGet, "/files/*name")
+	p.add(http.MethodGet, "/orders/:id/invoice")
+
+	tests := map[string]struct {
+		method   string
+		path     string
+		expected bool
+	}{
+		"catch-all":             {method: http.MethodGet, path: "/files/reports/2021.csv", expected: true},
+		"catch-all root":        {method: http.MethodGet, path: "/files/", expected: true},
+		"named parameter":       {method: http.MethodGet, path: "/orders/1/invoice", expected: true},
+		"empty named parameter": {method: http.MethodGet, path: "/orders//invoice"},
+		"longer path":           {method: http.MethodGet, path: "/orders/1/invoice/pdf"},
+		"shorter path":          {method: http.MethodGet, path: "/orders/1"},
+		"other path":            {method: http.MethodGet, path: "/reports/1"},
+		"other method":          {method: http.MethodPost, path: "/files/reports/2021.csv"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.expected, p.match(httptest.NewRequest(tt.method, tt.path, nil)))
+		})
+	}
+}
+
+func TestLongLived(t *testing.T) {
+	t.Parallel()
+	route, err := NewGetRoute("/long-lived/:id", func(http.ResponseWriter, *http.Request) {}, LongLived())
+	require.NoError(t, err)
+	assert.NotNil(t, route)
+	assert.True(t, longLivedRoutes.match(httptest.NewRequest(http.MethodGet, "/long-lived/1", nil)))
+	assert.False(t, longLivedRoutes.match(httptest.NewRequest(http.MethodGet, "/long-lived/1/2", nil)))
+}
+
+func TestComponent_TimeoutHandler_LongLived(t *testing.T) {
+	t.Parallel()
+	mux := http.NewServeMux()
+	handler := func(w http.ResponseWriter, _ *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+		_, _ = w.Write([]byte("done"))
+	}
+	mux.HandleFunc("/timeout/short", handler)
+	mux.HandleFunc("/timeout/long", handler)
+	_, err := NewGetRoute("/timeout/long", handler, LongLived())
+	require.NoError(t, err)
+	cmp, err := New(mux, HandlerTimeout(10*time.Millisecond))
+	require.NoError(t, err)
+
+	rsp := httptest.NewRecorder()
+	cmp.timeoutHandler().ServeHTTP(rsp, httptest.NewRequest(http.MethodGet, "/timeout/short", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rsp.Code)
+
+	rsp = httptest.NewRecorder()
+	cmp.timeoutHandler().ServeHTTP(rsp, httptest.NewRequest(http.MethodGet, "/timeout/long", nil))
+	assert.Equal(t, http.StatusOK, rsp.Code)
+	assert.Equal(t, "done", rsp.Body.String())
+}
@@ -0,0 +1,147 @@
+package v2
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/beatlabs/patron/component/http/problem"
+	"github.com/beatlabs/patron/encoding"
+	"github.com/beatlabs/patron/encoding/json"
+	"github.com/beatlabs/patron/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	resumeTokenHeader  = "Resume-Token"
+	resumeTokenQuery   = "token"
+	defaultPollTimeout = 30 * time.Second
+
+	pollResultEvents  = "events"
+	pollResultTimeout = "timeout"
+	pollResultError   = "error"
+)
+
+var (
+	longPollsMetric        *prometheus.CounterVec
+	longPollsWaitingMetric *prometheus.GaugeVec
+)
+
+func init() {
+	longPollsMetric = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "component",
+			Subsystem: "http",
+			Name:      "long_polls_total",
+			Help:      "Long polls answered per route, classified by result (events, timeout or error).",
+		},
+		[]string{"path", "result"},
+	)
+	longPollsWaitingMetric = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "component",
+			Subsystem: "http",
+			Name:      "long_polls_waiting",
+			Help:      "Long polls waiting for events per route.",
+		},
+		[]string{"path"},
+	)
+	prometheus.MustRegister(longPollsMetric, longPollsWaitingMetric)
+}
+
+// LongPollConfig configures a long polling route.
+type LongPollConfig struct {
+	// Timeout of the polls waiting for events, after which they are answered with 204 No Content (default: 30s).
+	// It should be shorter than the write timeout of the component, as well as the timeouts of the proxies in front of it.
+	Timeout time.Duration
+}
+
+// LongPollHandlerFunc waits for the events following the resume token of a poll, until they are available or the context is done.
+// It returns the events, along with the resume token of the next poll, or no events once the context is done.
+type LongPollHandlerFunc func(ctx context.Context, r *http.Request, token string) ([]Event, string, error)
+
+// LongPoll is the response of a poll answered with events.
+type LongPoll struct {
+	Events []Event `json:"events"`
+	// Token to resume the next poll from.
+	Token string `json:"token"`
+}
+
+// NewLongPollRoute creates a long-lived GET route answering the polls of the clients which cannot use WebSockets or server-sent events,
+// with the events following their resume token, as soon as the handler returns them. Polls without events after the timeout,
+// or when the component shuts down, are answered with 204 No Content. The resume token of the next poll is sent in the Resume-Token
+// header of both responses, and in the body of the responses with events, while the clients send it in the Resume-Token header
+// or the token query parameter of their polls.
+func NewLongPollRoute(path string, handler LongPollHandlerFunc, cfg LongPollConfig, oo ...RouteOptionFunc) (*Route, error) {
+	if handler == nil {
+		return nil, errors.New("handler is nil")
+	}
+	if cfg.Timeout < 0 {
+		return nil, errors.New("negative timeout provided")
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = defaultPollTimeout
+	}
+
+	return NewGetRoute(path, longPollHandler(path, handler, cfg), append(oo, LongLived())...)
+}
+
+func longPollHandler(path string, handler LongPollHandlerFunc, cfg LongPollConfig) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := ResumeToken(r)
+
+		ctx, cancel := context.WithTimeout(r.Context(), cfg.Timeout)
+		defer cancel()
+		if shutdown, ok := shutdownFromContext(r.Context()); ok {
+			go func() {
+				select {
+				case <-shutdown:
+					cancel()
+				case <-ctx.Done():
+				}
+			}()
+		}
+
+		longPollsWaitingMetric.WithLabelValues(path).Inc()
+		events, next, err := handler(ctx, r, token)
+		longPollsWaitingMetric.WithLabelValues(path).Dec()
+		if err != nil && !errors.Is(err, context.DeadlineExceeded) && !errors.Is(err, context.Canceled) {
+			longPollsMetric.WithLabelValues(path, pollResultError).Inc()
+			problem.Write(w, r, err)
+			return
+		}
+		if next == "" {
+			next = token
+		}
+
+		w.Header().Set("Cache-Control", "no-store")
+		w.Header().Set(resumeTokenHeader, next)
+		if len(events) == 0 {
+			longPollsMetric.WithLabelValues(path, pollResultTimeout).Inc()
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		body, err := json.Encode(LongPoll{Events: events, Token: next})
+		if err != nil {
+			longPollsMetric.WithLabelValues(path, pollResultError).Inc()
+			problem.Write(w, r, err)
+			return
+		}
+		longPollsMetric.WithLabelValues(path, pollResultEvents).Inc()
+		w.Header().Set(encoding.ContentTypeHeader, json.TypeCharset)
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write(body); err != nil {
+			log.FromContext(r.Context()).Debugf("failed to write long poll: %v", err)
+		}
+	}
+}
+
+// ResumeToken returns the resume token of a poll, sent in its Resume-Token header or its token query parameter, if any.
+func ResumeToken(r *http.Request) string {
+	if token := r.Header.Get(resumeTokenHeader); token != "" {
+		return token
+	}
+	return r.URL.Query().Get(resumeTokenQuery)
+}
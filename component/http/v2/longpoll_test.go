@@ -0,0 +1,142 @@
+package v2
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/beatlabs/patron/encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewLongPollRoute(t *testing.T) {
+	t.Parallel()
+	handler := func(context.Context, *http.Request, string) ([]Event, string, error) { return nil, "", nil }
+	tests := map[string]struct {
+		path        string
+		handler     LongPollHandlerFunc
+		cfg         LongPollConfig
+		expectedErr string
+	}{
+		"success":          {path: "/poll", handler: handler, cfg: LongPollConfig{Timeout: time.Second}},
+		"missing handler":  {path: "/poll", expectedErr: "handler is nil"},
+		"missing path":     {handler: handler, expectedErr: "path is empty"},
+		"negative timeout": {path: "/poll", handler: handler, cfg: LongPollConfig{Timeout: -time.Second}, expectedErr: "negative timeout provided"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			got, err := NewLongPollRoute(tt.path, tt.handler, tt.cfg)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, http.MethodGet, got.Method())
+				assert.Equal(t, tt.path, got.Path())
+			}
+		})
+	}
+}
+
+func TestLongPollHandler(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		target         string
+		token          string
+		handler        LongPollHandlerFunc
+		expectedStatus int
+		expectedToken  string
+		expectedBody   *LongPoll
+	}{
+		"events": {
+			target: "/poll", token: "1",
+			handler: func(_ context.Context, _ *http.Request, token string) ([]Event, string, error) {
+				return []Event{{ID: "2", Data: "after " + token}}, "2", nil
+			},
+			expectedStatus: http.StatusOK, expectedToken: "2",
+			expectedBody: &LongPoll{Events: []Event{{ID: "2", Data: "after 1"}}, Token: "2"},
+		},
+		"query token": {
+			target: "/poll?token=5",
+			handler: func(_ context.Context, _ *http.Request, token string) ([]Event, string, error) {
+				return []Event{{ID: "6", Data: "after " + token}}, "6", nil
+			},
+			expectedStatus: http.StatusOK, expectedToken: "6",
+			expectedBody: &LongPoll{Events: []Event{{ID: "6", Data: "after 5"}}, Token: "6"},
+		},
+		"timeout": {
+			target: "/poll", token: "3",
+			handler: func(ctx context.Context, _ *http.Request, _ string) ([]Event, string, error) {
+				<-ctx.Done()
+				return nil, "", ctx.Err()
+			},
+			expectedStatus: http.StatusNoContent, expectedToken: "3",
+		},
+		"error": {
+			target: "/poll",
+			handler: func(context.Context, *http.Request, string) ([]Event, string, error) {
+				return nil, "", errors.New("failed")
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			route, err := NewLongPollRoute("/poll", tt.handler, LongPollConfig{Timeout: 20 * time.Millisecond})
+			require.NoError(t, err)
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.token != "" {
+				req.Header.Set(resumeTokenHeader, tt.token)
+			}
+			rec := httptest.NewRecorder()
+
+			route.Handler()(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			assert.Equal(t, tt.expectedToken, rec.Header().Get(resumeTokenHeader))
+			if tt.expectedBody != nil {
+				assert.Equal(t, json.TypeCharset, rec.Header().Get("Content-Type"))
+				assert.Equal(t, "no-store", rec.Header().Get("Cache-Control"))
+				got := &LongPoll{}
+				require.NoError(t, json.DecodeRaw(rec.Body.Bytes(), got))
+				assert.Equal(t, tt.expectedBody, got)
+			}
+		})
+	}
+}
+
+func TestLongPollHandler_Shutdown(t *testing.T) {
+	t.Parallel()
+	route, err := NewLongPollRoute("/poll", func(ctx context.Context, _ *http.Request, _ string) ([]Event, string, error) {
+		<-ctx.Done()
+		return nil, "", nil
+	}, LongPollConfig{Timeout: time.Hour})
+	require.NoError(t, err)
+	shutdown := make(chan struct{})
+	close(shutdown)
+	var ch <-chan struct{} = shutdown
+	req := httptest.NewRequest(http.MethodGet, "/poll", nil)
+	req = req.WithContext(context.WithValue(req.Context(), shutdownContextKey{}, ch))
+	rec := httptest.NewRecorder()
+
+	route.Handler()(rec, req)
+
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+}
+
+func TestResumeToken(t *testing.T) {
+	t.Parallel()
+	req := httptest.NewRequest(http.MethodGet, "/poll?token=query", nil)
+	assert.Equal(t, "query", ResumeToken(req))
+	req.Header.Set(resumeTokenHeader, "header")
+	assert.Equal(t, "header", ResumeToken(req))
+	assert.Empty(t, ResumeToken(httptest.NewRequest(http.MethodGet, "/poll", nil)))
+}
@@ -6,12 +6,18 @@ import (
 	"net/http"
 	"net/http/pprof"
 
+	"github.com/beatlabs/patron/dependency"
+	"github.com/beatlabs/patron/internal/diagnostics"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 const (
 	// MetricsPath of the component.
 	MetricsPath = "/metrics"
+	// DiagnosticsPath of the component.
+	DiagnosticsPath = "/debug/diagnostics"
+	// DependenciesPath of the component.
+	DependenciesPath = "/debug/dependencies"
 )
 
 // MetricRoute creation.
@@ -23,6 +29,24 @@ func MetricRoute() *Route {
 	}
 }
 
+// DiagnosticsRoute creation, which serves the environment diagnostics report of the service.
+func DiagnosticsRoute() *Route {
+	return &Route{
+		method:  http.MethodGet,
+		path:    DiagnosticsPath,
+		handler: diagnostics.Handler,
+	}
+}
+
+// DependenciesRoute creation, which serves the summary of the calls to the dependencies of the service.
+func DependenciesRoute() *Route {
+	return &Route{
+		method:  http.MethodGet,
+		path:    DependenciesPath,
+		handler: dependency.Handler,
+	}
+}
+
 func ProfilingRoutes(enableExpVar bool) []*Route {
 	var routes []*Route
 
@@ -6,6 +6,7 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"github.com/beatlabs/patron/internal/diagnostics"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -24,6 +25,38 @@ func Test_metricRoute(t *testing.T) {
 	assert.Equal(t, http.StatusOK, resp.Code)
 }
 
+func Test_diagnosticsRoute(t *testing.T) {
+	route := DiagnosticsRoute()
+	assert.Equal(t, http.MethodGet, route.method)
+	assert.Equal(t, "/debug/diagnostics", route.path)
+
+	diagnostics.Set(diagnostics.Collect("test", "1.0.0"))
+
+	resp := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "/debug/diagnostics", nil)
+	require.NoError(t, err)
+
+	route.handler(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Body.String(), `"service":"test"`)
+}
+
+func Test_dependenciesRoute(t *testing.T) {
+	route := DependenciesRoute()
+	assert.Equal(t, http.MethodGet, route.method)
+	assert.Equal(t, "/debug/dependencies", route.path)
+
+	resp := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "/debug/dependencies", nil)
+	require.NoError(t, err)
+
+	route.handler(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Body.String(), `"window":"5m0s"`)
+}
+
 type profilingTestCase struct {
 	path string
 	want int
@@ -0,0 +1,313 @@
+// Package openapi generates the OpenAPI 3 document of the API from the routes of the HTTP component,
+// and serves it along with an optional Swagger UI.
+package openapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	v2 "github.com/beatlabs/patron/component/http/v2"
+)
+
+const (
+	// SpecPath is the path the document is served at.
+	SpecPath = "/swagger.json"
+	// Version of the OpenAPI specification of the document.
+	Version = "3.0.3"
+
+	authSchemeName = "auth"
+)
+
+// Info of the API.
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// Server of the API.
+type Server struct {
+	URL         string `json:"url"`
+	Description string `json:"description,omitempty"`
+}
+
+// SecurityScheme of the routes requiring authentication.
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Description  string `json:"description,omitempty"`
+	Name         string `json:"name,omitempty"`
+	In           string `json:"in,omitempty"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+}
+
+// Config of the document.
+type Config struct {
+	Info    Info
+	Servers []Server
+	// AuthScheme is the security scheme of the routes created with the Auth option, which defaults to the API key
+	// of the Authorization header, e.g. Authorization: Apikey {api key}, of package apikey.
+	AuthScheme *SecurityScheme
+	// UIPath is the path of the Swagger UI, which is not served if empty.
+	UIPath string
+}
+
+// Document is an OpenAPI 3 document.
+type Document struct {
+	OpenAPI    string                          `json:"openapi"`
+	Info       Info                            `json:"info"`
+	Servers    []Server                        `json:"servers,omitempty"`
+	Paths      map[string]map[string]Operation `json:"paths"`
+	Components Components                      `json:"components,omitempty"`
+}
+
+// Components of the document.
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas,omitempty"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// Operation of a path.
+type Operation struct {
+	Summary     string                `json:"summary,omitempty"`
+	Description string                `json:"description,omitempty"`
+	OperationID string                `json:"operationId"`
+	Tags        []string              `json:"tags,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]Response   `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+	Deprecated  bool                  `json:"deprecated,omitempty"`
+}
+
+// Parameter of an operation.
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required,omitempty"`
+	Schema   *Schema `json:"schema"`
+}
+
+// RequestBody of an operation.
+type RequestBody struct {
+	Required bool                 `json:"required,omitempty"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// Response of an operation.
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType of a request or a response body.
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// New generates the document of the routes, from their methods, paths, authentication and documentation (see v2.Doc).
+func New(cfg Config, routes ...*v2.Route) (*Document, error) {
+	if cfg.Info.Title == "" {
+		return nil, errors.New("title is empty")
+	}
+	if cfg.Info.Version == "" {
+		return nil, errors.New("version is empty")
+	}
+
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    cfg.Info,
+		Servers: cfg.Servers,
+		Paths:   make(map[string]map[string]Operation),
+	}
+	ss := newSchemas()
+	authenticated := false
+
+	for _, route := range routes {
+		if route == nil {
+			return nil, errors.New("route is nil")
+		}
+		op, err := operation(ss, route)
+		if err != nil {
+			return nil, fmt.Errorf("failed to document route %s: %w", route, err)
+		}
+		authenticated = authenticated || route.Authenticated()
+
+		path, _ := pathTemplate(route.Path())
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = make(map[string]Operation)
+		}
+		doc.Paths[path][strings.ToLower(route.Method())] = op
+	}
+
+	if len(ss.components) > 0 {
+		doc.Components.Schemas = ss.components
+	}
+	if authenticated {
+		scheme := SecurityScheme{
+			Type: "apiKey", Name: "Authorization", In: "header",
+			Description: "API key, e.g. Authorization: Apikey {api key}",
+		}
+		if cfg.AuthScheme != nil {
+			scheme = *cfg.AuthScheme
+		}
+		doc.Components.SecuritySchemes = map[string]SecurityScheme{authSchemeName: scheme}
+	}
+	return doc, nil
+}
+
+func operation(ss *schemas, route *v2.Route) (Operation, error) {
+	routeDoc := route.Doc()
+	path, params := pathTemplate(route.Path())
+	op := Operation{
+		Summary:     routeDoc.Summary,
+		Description: routeDoc.Description,
+		OperationID: operationID(route.Method(), path),
+		Tags:        routeDoc.Tags,
+		Responses:   make(map[string]Response),
+		Deprecated:  routeDoc.Deprecated,
+	}
+	for _, name := range params {
+		op.Parameters = append(op.Parameters, Parameter{Name: name, In: "path", Required: true, Schema: &Schema{Type: "string"}})
+	}
+
+	req, err := typeOf(routeDoc.Request)
+	if err != nil {
+		return Operation{}, err
+	}
+	if req != nil {
+		query := ss.queryParameters(req)
+		op.Parameters = append(op.Parameters, query...)
+		if hasBody(req) && bodyMethod(route.Method()) {
+			var schema *Schema
+			if len(query) > 0 {
+				// the query fields are not part of the body, so its schema differs from the schema of the type
+				schema = ss.object(req, bodyField)
+			} else {
+				schema = ss.of(req)
+			}
+			op.RequestBody = &RequestBody{
+				Required: true,
+				Content:  map[string]MediaType{"application/json": {Schema: schema}},
+			}
+		}
+	}
+
+	for status, v := range routeDoc.Responses {
+		rsp := Response{Description: http.StatusText(status)}
+		if v != nil {
+			rsp.Content = map[string]MediaType{"application/json": {Schema: ss.of(reflect.TypeOf(v))}}
+		}
+		op.Responses[strconv.Itoa(status)] = rsp
+	}
+	if len(op.Responses) == 0 {
+		op.Responses["default"] = Response{Description: "default response"}
+	}
+
+	if route.Authenticated() {
+		op.Security = []map[string][]string{{authSchemeName: {}}}
+	}
+	return op, nil
+}
+
+// pathTemplate converts the path of the router, e.g. /orders/:id, to the path template of the document, e.g. /orders/{id},
+// returning the names of its parameters.
+func pathTemplate(path string) (string, []string) {
+	segments := strings.Split(path, "/")
+	var params []string
+	for i, segment := range segments {
+		if len(segment) > 1 && (segment[0] == ':' || segment[0] == '*') {
+			params = append(params, segment[1:])
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/"), params
+}
+
+// operationID returns a unique ID of the operation, e.g. get-orders-id for GET /orders/{id}.
+func operationID(method, path string) string {
+	parts := []string{strings.ToLower(method)}
+	for _, segment := range strings.Split(path, "/") {
+		segment = strings.Trim(segment, "{}")
+		if segment != "" {
+			parts = append(parts, segment)
+		}
+	}
+	return strings.Join(parts, "-")
+}
+
+func bodyMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodDelete, http.MethodOptions, http.MethodTrace:
+		return false
+	default:
+		return true
+	}
+}
+
+// Routes creates the route serving the document at SpecPath, and the route of the Swagger UI if the UIPath of the config is set.
+func Routes(cfg Config, routes ...*v2.Route) ([]*v2.Route, error) {
+	doc, err := New(cfg, routes...)
+	if err != nil {
+		return nil, err
+	}
+	body, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode document: %w", err)
+	}
+
+	spec, err := v2.NewGetRoute(SpecPath, func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(body)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if cfg.UIPath == "" {
+		return []*v2.Route{spec}, nil
+	}
+
+	ui, err := uiRoute(cfg.UIPath, cfg.Info.Title)
+	if err != nil {
+		return nil, err
+	}
+	return []*v2.Route{spec, ui}, nil
+}
+
+var uiTemplate = template.Must(template.New("ui").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>{{.Title}}</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@3/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@3/swagger-ui-bundle.js"></script>
+  <script>
+    window.onload = function() {
+      SwaggerUIBundle({url: "{{.Spec}}", dom_id: "#swagger-ui"});
+    };
+  </script>
+</body>
+</html>
+`))
+
+// uiRoute creates the route of the Swagger UI, which loads its assets from a CDN.
+func uiRoute(path, title string) (*v2.Route, error) {
+	var page strings.Builder
+	if err := uiTemplate.Execute(&page, struct{ Title, Spec string }{Title: title, Spec: SpecPath}); err != nil {
+		return nil, fmt.Errorf("failed to render ui: %w", err)
+	}
+	body := []byte(page.String())
+	return v2.NewGetRoute(path, func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write(body)
+	})
+}
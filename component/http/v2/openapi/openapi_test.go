@@ -0,0 +1,215 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	v2 "github.com/beatlabs/patron/component/http/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type item struct {
+	SKU      string `json:"sku" validate:"required,len=8"`
+	Quantity int    `json:"quantity" validate:"min=1,max=100"`
+}
+
+type createOrder struct {
+	Email    string `json:"email" validate:"required,email"`
+	Currency string `json:"currency" validate:"oneof=EUR USD"`
+	Items    []item `json:"items" validate:"required,max=50"`
+	DryRun   bool   `json:"-" query:"dry_run"`
+}
+
+type order struct {
+	ID        string    `json:"id" validate:"uuid"`
+	Note      *string   `json:"note,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	Parent    *order    `json:"parent,omitempty"`
+}
+
+type mockAuthenticator struct{}
+
+func (mockAuthenticator) Authenticate(*http.Request) (bool, error) { return true, nil }
+
+func handler(http.ResponseWriter, *http.Request) {}
+
+func testRoutes(t *testing.T) []*v2.Route {
+	create, err := v2.NewPostRoute("/orders", handler, v2.Validation(createOrder{}), v2.Auth(mockAuthenticator{}),
+		v2.Doc(v2.RouteDoc{Summary: "Create an order", Tags: []string{"orders"}, Responses: map[int]interface{}{
+			http.StatusCreated:    order{},
+			http.StatusBadRequest: nil,
+		}}))
+	require.NoError(t, err)
+	get, err := v2.NewGetRoute("/orders/:id", handler, v2.Doc(v2.RouteDoc{Responses: map[int]interface{}{http.StatusOK: order{}}}))
+	require.NoError(t, err)
+	list, err := v2.NewGetRoute("/orders", handler, v2.Doc(v2.RouteDoc{Deprecated: true, Responses: map[int]interface{}{http.StatusOK: []order{}}}))
+	require.NoError(t, err)
+	files, err := v2.NewGetRoute("/files/*path", handler)
+	require.NoError(t, err)
+	return []*v2.Route{create, get, list, files}
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+	invalid, err := v2.NewGetRoute("/orders", handler, v2.Doc(v2.RouteDoc{Request: "order"}))
+	require.NoError(t, err)
+	tests := map[string]struct {
+		cfg         Config
+		routes      []*v2.Route
+		expectedErr string
+	}{
+		"success":         {cfg: Config{Info: Info{Title: "orders", Version: "1.0.0"}}, routes: testRoutes(t)},
+		"missing title":   {cfg: Config{Info: Info{Version: "1.0.0"}}, expectedErr: "title is empty"},
+		"missing version": {cfg: Config{Info: Info{Title: "orders"}}, expectedErr: "version is empty"},
+		"nil route":       {cfg: Config{Info: Info{Title: "orders", Version: "1.0.0"}}, routes: []*v2.Route{nil}, expectedErr: "route is nil"},
+		"invalid request": {
+			cfg: Config{Info: Info{Title: "orders", Version: "1.0.0"}}, routes: []*v2.Route{invalid},
+			expectedErr: "failed to document route GET /orders: type string is not a struct",
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			got, err := New(tt.cfg, tt.routes...)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, got)
+			}
+		})
+	}
+}
+
+func TestNew_Document(t *testing.T) {
+	t.Parallel()
+	doc, err := New(Config{Info: Info{Title: "orders", Version: "1.0.0"}, Servers: []Server{{URL: "https://api.example.com"}}}, testRoutes(t)...)
+	require.NoError(t, err)
+	got, err := json.Marshal(doc)
+	require.NoError(t, err)
+
+	expected := `{
+  "openapi": "3.0.3",
+  "info": {"title": "orders", "version": "1.0.0"},
+  "servers": [{"url": "https://api.example.com"}],
+  "paths": {
+    "/orders": {
+      "post": {
+        "summary": "Create an order",
+        "operationId": "post-orders",
+        "tags": ["orders"],
+        "parameters": [{"name": "dry_run", "in": "query", "schema": {"type": "boolean"}}],
+        "requestBody": {"required": true, "content": {"application/json": {"schema": {
+          "type": "object",
+          "properties": {
+            "email": {"type": "string", "format": "email"},
+            "currency": {"type": "string", "enum": ["EUR", "USD"]},
+            "items": {"type": "array", "items": {"$ref": "#/components/schemas/item"}, "maxItems": 50}
+          },
+          "required": ["email", "items"]
+        }}}},
+        "responses": {
+          "201": {"description": "Created", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/order"}}}},
+          "400": {"description": "Bad Request"}
+        },
+        "security": [{"auth": []}]
+      },
+      "get": {
+        "operationId": "get-orders",
+        "responses": {
+          "200": {"description": "OK", "content": {"application/json": {"schema": {"type": "array", "items": {"$ref": "#/components/schemas/order"}}}}}
+        },
+        "deprecated": true
+      }
+    },
+    "/orders/{id}": {
+      "get": {
+        "operationId": "get-orders-id",
+        "parameters": [{"name": "id", "in": "path", "required": true, "schema": {"type": "string"}}],
+        "responses": {
+          "200": {"description": "OK", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/order"}}}}
+        }
+      }
+    },
+    "/files/{path}": {
+      "get": {
+        "operationId": "get-files-path",
+        "parameters": [{"name": "path", "in": "path", "required": true, "schema": {"type": "string"}}],
+        "responses": {"default": {"description": "default response"}}
+      }
+    }
+  },
+  "components": {
+    "schemas": {
+      "item": {
+        "type": "object",
+        "properties": {
+          "sku": {"type": "string", "minLength": 8, "maxLength": 8},
+          "quantity": {"type": "integer", "format": "int64", "minimum": 1, "maximum": 100}
+        },
+        "required": ["sku"]
+      },
+      "order": {
+        "type": "object",
+        "properties": {
+          "id": {"type": "string", "format": "uuid"},
+          "note": {"type": "string", "nullable": true},
+          "created_at": {"type": "string", "format": "date-time"},
+          "parent": {"$ref": "#/components/schemas/order"}
+        }
+      }
+    },
+    "securitySchemes": {
+      "auth": {"type": "apiKey", "description": "API key, e.g. Authorization: Apikey {api key}", "name": "Authorization", "in": "header"}
+    }
+  }
+}`
+	assert.JSONEq(t, expected, string(got))
+}
+
+func TestRoutes(t *testing.T) {
+	t.Parallel()
+	routes, err := Routes(Config{Info: Info{Title: "orders", Version: "1.0.0"}}, testRoutes(t)...)
+	require.NoError(t, err)
+	require.Len(t, routes, 1)
+	assert.Equal(t, SpecPath, routes[0].Path())
+
+	rc := httptest.NewRecorder()
+	routes[0].Handler()(rc, httptest.NewRequest(http.MethodGet, SpecPath, nil))
+	assert.Equal(t, http.StatusOK, rc.Code)
+	assert.Equal(t, "application/json", rc.Header().Get("Content-Type"))
+	var doc Document
+	require.NoError(t, json.NewDecoder(rc.Body).Decode(&doc))
+	assert.Len(t, doc.Paths, 3)
+
+	routes, err = Routes(Config{Info: Info{Title: "orders <api>", Version: "1.0.0"}, UIPath: "/docs"}, testRoutes(t)...)
+	require.NoError(t, err)
+	require.Len(t, routes, 2)
+	assert.Equal(t, "/docs", routes[1].Path())
+
+	rc = httptest.NewRecorder()
+	routes[1].Handler()(rc, httptest.NewRequest(http.MethodGet, "/docs", nil))
+	assert.Equal(t, "text/html; charset=utf-8", rc.Header().Get("Content-Type"))
+	assert.Contains(t, rc.Body.String(), "<title>orders &lt;api&gt;</title>")
+	assert.Contains(t, rc.Body.String(), `url: "\/swagger.json"`)
+
+	_, err = Routes(Config{})
+	assert.EqualError(t, err, "title is empty")
+}
+
+func TestSchemaGenerator(t *testing.T) {
+	t.Parallel()
+	g := NewSchemaGenerator()
+	assert.Equal(t, &Schema{}, g.Of(nil))
+	assert.Equal(t, &Schema{Type: "string"}, g.Of(""))
+	assert.Equal(t, &Schema{Ref: "#/components/schemas/order"}, g.Of(&order{}))
+	assert.Equal(t, &Schema{Type: "array", Items: &Schema{Ref: "#/components/schemas/order"}}, g.Of([]order{}))
+	require.Len(t, g.Components(), 1)
+	assert.Equal(t, "object", g.Components()["order"].Type)
+}
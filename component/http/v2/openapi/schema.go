@@ -0,0 +1,316 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schema is a schema object of OpenAPI 3, describing the JSON values of a Go type.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+}
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	rawType       = reflect.TypeOf(json.RawMessage{})
+	marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+// schemas generates the schemas of Go types, keeping the schemas of the named struct types as components, which are referenced by name.
+type schemas struct {
+	components map[string]*Schema
+	names      map[reflect.Type]string
+}
+
+func newSchemas() *schemas {
+	return &schemas{components: make(map[string]*Schema), names: make(map[reflect.Type]string)}
+}
+
+// SchemaGenerator generates the schemas of Go values for other documents, e.g. the AsyncAPI document of package asyncapi,
+// keeping the schemas of the named struct types as components, which are referenced under #/components/schemas.
+type SchemaGenerator struct {
+	ss *schemas
+}
+
+// NewSchemaGenerator constructor.
+func NewSchemaGenerator() *SchemaGenerator {
+	return &SchemaGenerator{ss: newSchemas()}
+}
+
+// Of returns the schema of the type of the value, which is empty for nil.
+func (g *SchemaGenerator) Of(v interface{}) *Schema {
+	if v == nil {
+		return &Schema{}
+	}
+	return g.ss.of(reflect.TypeOf(v))
+}
+
+// Components returns the schemas of the named struct types generated so far, keyed by name.
+func (g *SchemaGenerator) Components() map[string]*Schema {
+	return g.ss.components
+}
+
+// of returns the schema of the type.
+func (s *schemas) of(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t == rawType:
+		return &Schema{}
+	case t.Implements(marshalerType) || reflect.PtrTo(t).Implements(marshalerType):
+		// the JSON representation of custom marshalers is unknown
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 && t.Kind() == reflect.Slice {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: s.of(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: s.of(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.object(t, nil)
+		}
+		return &Schema{Ref: "#/components/schemas/" + s.component(t)}
+	default:
+		return &Schema{}
+	}
+}
+
+// component returns the name of the component of the named struct type, generating its schema on first use.
+func (s *schemas) component(t reflect.Type) string {
+	if name, ok := s.names[t]; ok {
+		return name
+	}
+	name := t.Name()
+	for i := 2; ; i++ {
+		if _, ok := s.components[name]; !ok {
+			break
+		}
+		name = t.Name() + strconv.Itoa(i)
+	}
+	s.names[t] = name
+	// reserve the name before generating the schema, which may reference the type itself
+	s.components[name] = nil
+	s.components[name] = s.object(t, nil)
+	return name
+}
+
+// object returns the schema of the struct type, with the fields accepted by the filter, if any.
+func (s *schemas) object(t reflect.Type, filter func(reflect.StructField) bool) *Schema {
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" || (filter != nil && !filter(sf)) {
+			continue
+		}
+		name, ok := jsonName(sf)
+		if !ok {
+			continue
+		}
+		if sf.Anonymous && sf.Tag.Get("json") == "" && structType(sf.Type) != nil {
+			embedded := s.object(structType(sf.Type), filter)
+			for k, v := range embedded.Properties {
+				schema.Properties[k] = v
+			}
+			schema.Required = append(schema.Required, embedded.Required...)
+			continue
+		}
+		fs := s.of(sf.Type)
+		if applyRules(fs, sf) {
+			schema.Required = append(schema.Required, name)
+		}
+		if sf.Type.Kind() == reflect.Ptr && fs.Ref == "" {
+			fs.Nullable = true
+		}
+		schema.Properties[name] = fs
+	}
+	return schema
+}
+
+// jsonName returns the JSON name of the field, and false if it is not encoded.
+func jsonName(sf reflect.StructField) (string, bool) {
+	tag := sf.Tag.Get("json")
+	if tag == "-" {
+		return "", false
+	}
+	if name := strings.Split(tag, ",")[0]; name != "" {
+		return name, true
+	}
+	return sf.Name, true
+}
+
+func structType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	return t
+}
+
+// applyRules documents the rules of the validate tag of the field in its schema, returning whether the field is required.
+// References are left intact, since their siblings are ignored.
+func applyRules(schema *Schema, sf reflect.StructField) bool {
+	tag := sf.Tag.Get("validate")
+	if tag == "" || tag == "-" {
+		return false
+	}
+	required := false
+	for _, part := range strings.Split(tag, ",") {
+		name, param := part, ""
+		if i := strings.Index(part, "="); i >= 0 {
+			name, param = part[:i], part[i+1:]
+		}
+		if name == "required" {
+			required = true
+			continue
+		}
+		if schema.Ref != "" {
+			continue
+		}
+		switch name {
+		case "min", "max", "len":
+			applyBound(schema, name, param)
+		case "oneof":
+			for _, v := range strings.Fields(param) {
+				schema.Enum = append(schema.Enum, enumValue(schema.Type, v))
+			}
+		case "email":
+			schema.Format = "email"
+		case "url":
+			schema.Format = "uri"
+		case "uuid":
+			schema.Format = "uuid"
+		}
+	}
+	return required
+}
+
+func applyBound(schema *Schema, rule, param string) {
+	n, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return
+	}
+	switch schema.Type {
+	case "integer", "number":
+		if rule == "min" || rule == "len" {
+			schema.Minimum = &n
+		}
+		if rule == "max" || rule == "len" {
+			schema.Maximum = &n
+		}
+	case "string":
+		l := int(n)
+		if rule == "min" || rule == "len" {
+			schema.MinLength = &l
+		}
+		if rule == "max" || rule == "len" {
+			schema.MaxLength = &l
+		}
+	case "array":
+		l := int(n)
+		if rule == "min" || rule == "len" {
+			schema.MinItems = &l
+		}
+		if rule == "max" || rule == "len" {
+			schema.MaxItems = &l
+		}
+	}
+}
+
+func enumValue(typ, v string) interface{} {
+	switch typ {
+	case "integer":
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return n
+		}
+	case "number":
+		if n, err := strconv.ParseFloat(v, 64); err == nil {
+			return n
+		}
+	}
+	return v
+}
+
+// queryParameters returns the parameters of the fields of the struct type with a query tag.
+func (s *schemas) queryParameters(t reflect.Type) []Parameter {
+	var pp []Parameter
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name := sf.Tag.Get("query")
+		if name == "" || sf.PkgPath != "" {
+			continue
+		}
+		schema := s.of(sf.Type)
+		required := applyRules(schema, sf)
+		pp = append(pp, Parameter{Name: name, In: "query", Required: required, Schema: schema})
+	}
+	return pp
+}
+
+// hasBody returns whether the struct type has fields decoded from the body, i.e. fields with a JSON name which are not bound to a query parameter.
+func hasBody(t reflect.Type) bool {
+	for i := 0; i < t.NumField(); i++ {
+		if bodyField(t.Field(i)) && t.Field(i).PkgPath == "" {
+			if _, ok := jsonName(t.Field(i)); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// bodyField returns whether the field is decoded from the body, excluding the query fields without a json tag.
+func bodyField(sf reflect.StructField) bool {
+	return sf.Tag.Get("query") == "" || sf.Tag.Get("json") != ""
+}
+
+func typeOf(v interface{}) (reflect.Type, error) {
+	if v == nil {
+		return nil, nil
+	}
+	t := structType(reflect.TypeOf(v))
+	if t == nil {
+		return nil, fmt.Errorf("type %T is not a struct", v)
+	}
+	return t, nil
+}
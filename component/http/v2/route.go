@@ -17,6 +17,23 @@ type Route struct {
 	path        string
 	handler     http.HandlerFunc
 	middlewares []patronhttp.Func
+	cors        patronhttp.Func
+	doc         RouteDoc
+	validated   interface{}
+	auth        bool
+}
+
+// RouteDoc documents a route in the OpenAPI document of the API. See package openapi.
+type RouteDoc struct {
+	Summary     string
+	Description string
+	Tags        []string
+	// Request is a value of the type of the request, e.g. CreateOrder{}, whose fields with a JSON name form the body,
+	// and whose fields with a query tag the query parameters. It defaults to the value of the Validation option.
+	Request interface{}
+	// Responses are values of the types of the response bodies, keyed by status. Nil values document responses without a body.
+	Responses  map[int]interface{}
+	Deprecated bool
 }
 
 func (r Route) Method() string {
@@ -35,6 +52,25 @@ func (r Route) Middlewares() []patronhttp.Func {
 	return r.middlewares
 }
 
+// CORS returns the CORS middleware of the route, if configured, which the router applies to both its requests and their preflights.
+func (r Route) CORS() patronhttp.Func {
+	return r.cors
+}
+
+// Doc returns the documentation of the route.
+func (r Route) Doc() RouteDoc {
+	doc := r.doc
+	if doc.Request == nil {
+		doc.Request = r.validated
+	}
+	return doc
+}
+
+// Authenticated returns whether the route requires authentication, i.e. it was created with the Auth option.
+func (r Route) Authenticated() bool {
+	return r.auth
+}
+
 func (r Route) String() string {
 	return r.method + " " + r.path
 }
@@ -133,6 +169,17 @@ func (r *Routes) Append(route *Route, err error) {
 	r.routes = append(r.routes, route)
 }
 
+// AppendAll appends routes, e.g. the routes of a logical route across the versions of the API.
+func (r *Routes) AppendAll(routes []*Route, err error) {
+	if err != nil {
+		r.ee = append(r.ee, err)
+		return
+	}
+	for _, route := range routes {
+		r.Append(route, nil)
+	}
+}
+
 // Result of the route aggregation.
 func (r *Routes) Result() ([]*Route, error) {
 	return r.routes, patronerrors.Aggregate(r.ee...)
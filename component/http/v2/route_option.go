@@ -2,13 +2,19 @@ package v2
 
 import (
 	"errors"
-	"net/http"
+	"fmt"
+	"time"
 
 	"github.com/beatlabs/patron/cache"
 	"github.com/beatlabs/patron/component/http/auth"
+	"github.com/beatlabs/patron/component/http/auth/jwt"
+	"github.com/beatlabs/patron/component/http/auth/mtls"
 	httpcache "github.com/beatlabs/patron/component/http/cache"
 	patronhttp "github.com/beatlabs/patron/component/http/middleware"
+	"github.com/beatlabs/patron/component/http/ratelimit"
 	errs "github.com/beatlabs/patron/errors"
+	"github.com/beatlabs/patron/slo"
+	"github.com/beatlabs/patron/stats"
 	"golang.org/x/time/rate"
 )
 
@@ -20,6 +26,67 @@ func RateLimiting(limit float64, burst int) RouteOptionFunc {
 	}
 }
 
+// KeyedRateLimiting option for limiting the requests of the route per key e.g. the client IP, with the provided limiter,
+// which implements a token bucket or a sliding window strategy, kept in memory or in Redis. See package ratelimit.
+func KeyedRateLimiting(limiter ratelimit.Limiter, key ratelimit.KeyFunc) RouteOptionFunc {
+	return func(r *Route) error {
+		mw, err := ratelimit.New(r.path, limiter, key)
+		if err != nil {
+			return err
+		}
+		r.middlewares = append(r.middlewares, mw)
+		return nil
+	}
+}
+
+// SLO option for recording the requests of the route as events of the service level objective,
+// which are successful unless their response status is 5xx.
+func SLO(objective *slo.Objective) RouteOptionFunc {
+	return func(r *Route) error {
+		mw, err := patronhttp.NewSLO(objective)
+		if err != nil {
+			return err
+		}
+		r.middlewares = append(r.middlewares, mw)
+		return nil
+	}
+}
+
+// Stats option for observing the latency of the requests of the route in the latency window, and counting the failed ones,
+// whose response status is 5xx, in the optional failures window. See package stats.
+func Stats(latency, failures *stats.Window) RouteOptionFunc {
+	return func(r *Route) error {
+		mw, err := patronhttp.NewStats(latency, failures)
+		if err != nil {
+			return err
+		}
+		r.middlewares = append(r.middlewares, mw)
+		return nil
+	}
+}
+
+// Validation option for validating the requests of the route against the schema of the struct type of the provided value,
+// rejecting invalid requests with a 400 problem details response. See middleware.NewValidation.
+func Validation(v interface{}) RouteOptionFunc {
+	return func(r *Route) error {
+		mw, err := patronhttp.NewValidation(v)
+		if err != nil {
+			return err
+		}
+		r.middlewares = append(r.middlewares, mw)
+		r.validated = v
+		return nil
+	}
+}
+
+// Doc option for documenting the route in the OpenAPI document of the API. See package openapi.
+func Doc(doc RouteDoc) RouteOptionFunc {
+	return func(r *Route) error {
+		r.doc = doc
+		return nil
+	}
+}
+
 // Middlewares option for setting the route optionFuncs.
 func Middlewares(mm ...patronhttp.Func) RouteOptionFunc {
 	return func(r *Route) error {
@@ -38,21 +105,124 @@ func Auth(auth auth.Authenticator) RouteOptionFunc {
 			return errors.New("authenticator is nil")
 		}
 		r.middlewares = append(r.middlewares, patronhttp.NewAuth(auth))
+		r.auth = true
 		return nil
 	}
 }
 
-// Cache option for setting the route cache.
-func Cache(cache cache.TTLCache, ageBounds httpcache.Age) RouteOptionFunc {
+// JWT option for authenticating the requests of the route by their JWT bearer tokens, and authorizing them by the scopes and roles
+// of the requirement. The handler gets the claims of the token with jwt.ClaimsFromContext.
+func JWT(auth *jwt.Authenticator, req jwt.Requirement) RouteOptionFunc {
 	return func(r *Route) error {
-		if r.method != http.MethodGet {
-			return errors.New("cannot apply cache to a route with any method other than GET")
+		mw, err := jwt.NewMiddleware(auth, req)
+		if err != nil {
+			return err
 		}
-		rc, ee := httpcache.NewRouteCache(cache, ageBounds)
+		r.middlewares = append(r.middlewares, mw)
+		r.auth = true
+		return nil
+	}
+}
+
+// ClientCertificate option for authorizing the requests of the route by the identities of their verified client certificates,
+// which requires the ClientCertificates component option. See mtls.NewMiddleware.
+func ClientCertificate(req mtls.Requirement) RouteOptionFunc {
+	return func(r *Route) error {
+		r.middlewares = append(r.middlewares, mtls.NewMiddleware(req))
+		r.auth = true
+		return nil
+	}
+}
+
+// Cache option for setting the route cache.
+func Cache(cache cache.TTLCache, ageBounds httpcache.Age, oo ...httpcache.OptionFunc) RouteOptionFunc {
+	return func(r *Route) error {
+		rc, ee := httpcache.NewRouteCache(cache, ageBounds, oo...)
 		if len(ee) != 0 {
 			return errs.Aggregate(ee...)
 		}
+		if !rc.CacheableMethod(r.method) {
+			return fmt.Errorf("cannot apply cache to a route with method %s, which is not cacheable", r.method)
+		}
 		r.middlewares = append(r.middlewares, patronhttp.NewCaching(rc))
 		return nil
 	}
 }
+
+// Transform option for transforming the response of the route before it gets cached and written.
+// Since options are applied in order, it should follow the Cache option in order for the transformed response to be cached.
+// Sharing the same transformations across a group of routes is a matter of passing the same option to each of them.
+func Transform(tt ...patronhttp.TransformFunc) RouteOptionFunc {
+	return func(r *Route) error {
+		mw, err := patronhttp.NewTransformation(tt...)
+		if err != nil {
+			return err
+		}
+		r.middlewares = append(r.middlewares, mw)
+		return nil
+	}
+}
+
+// Compression option for compressing the responses of the route according to the provided config, e.g. with a minimum size
+// or a brotli encoder. Since options are applied in order, it should precede the Cache option in order for the cached
+// responses to be compressed per request, instead of caching a single compressed representation.
+func Compression(cfg patronhttp.CompressionConfig) RouteOptionFunc {
+	return func(r *Route) error {
+		mw, err := patronhttp.NewResponseCompression(cfg)
+		if err != nil {
+			return err
+		}
+		r.middlewares = append(r.middlewares, mw)
+		return nil
+	}
+}
+
+// MaxRequestBodySize option for rejecting requests with a body larger than the provided size in bytes with a 413 Request Entity Too Large,
+// protecting the service from memory exhaustion by large uploads. Bodies are limited while being streamed to the handler.
+func MaxRequestBodySize(bytes int64) RouteOptionFunc {
+	return func(r *Route) error {
+		mw, err := patronhttp.NewRequestBodyLimit(bytes)
+		if err != nil {
+			return err
+		}
+		r.middlewares = append(r.middlewares, mw)
+		return nil
+	}
+}
+
+// Timeout option for limiting the duration of the handler of the route, which gets a context canceled once the timeout is exceeded.
+// The requests exceeding the timeout are responded with a problem of the status, i.e. 503 Service Unavailable or 504 Gateway Timeout.
+// It should be shorter than the handler timeout of the component, and not be used for event streams or WebSockets. See middleware.NewTimeout.
+func Timeout(timeout time.Duration, status int) RouteOptionFunc {
+	return func(r *Route) error {
+		mw, err := patronhttp.NewTimeout(timeout, status)
+		if err != nil {
+			return err
+		}
+		r.middlewares = append(r.middlewares, mw)
+		return nil
+	}
+}
+
+// CORS option for handling the cross-origin requests of the route according to the provided config, overriding the CORS config of the router.
+// The router answers the preflight requests of the route's path, without the route's middlewares e.g. authentication.
+func CORS(cfg patronhttp.CORSConfig) RouteOptionFunc {
+	return func(r *Route) error {
+		mw, err := patronhttp.NewCORS(cfg)
+		if err != nil {
+			return err
+		}
+		r.cors = mw
+		return nil
+	}
+}
+
+// LongLived option for serving the requests of the route outside the handler timeout of the component, e.g. large downloads,
+// whose responses would otherwise be buffered in memory until the handler returns. Like the event streams, they are limited by the write timeout
+// of the component instead.
+func LongLived() RouteOptionFunc {
+	return func(r *Route) error {
+		longLivedRoutes.add(r.method, r.path)
+		return nil
+	}
+}
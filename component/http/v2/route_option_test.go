@@ -3,13 +3,20 @@ package v2
 import (
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/beatlabs/patron/cache"
 	"github.com/beatlabs/patron/cache/redis"
 	"github.com/beatlabs/patron/component/http/auth"
+	"github.com/beatlabs/patron/component/http/auth/jwt"
+	"github.com/beatlabs/patron/component/http/auth/mtls"
 	httpcache "github.com/beatlabs/patron/component/http/cache"
 	patronhttp "github.com/beatlabs/patron/component/http/middleware"
+	"github.com/beatlabs/patron/component/http/ratelimit"
+	"github.com/beatlabs/patron/slo"
+	"github.com/beatlabs/patron/stats"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 type mockAuthenticator struct {
@@ -79,11 +86,32 @@ func TestAuth(t *testing.T) {
 				assert.EqualError(t, err, tt.expectedErr)
 			} else {
 				assert.Len(t, route.middlewares, 1)
+				assert.True(t, route.Authenticated())
 			}
 		})
 	}
 }
 
+func TestJWT(t *testing.T) {
+	t.Parallel()
+	authenticator, err := jwt.New("https://example.com/jwks")
+	require.NoError(t, err)
+
+	route := &Route{}
+	assert.EqualError(t, JWT(nil, jwt.Requirement{})(route), "authenticator is nil")
+	assert.NoError(t, JWT(authenticator, jwt.Requirement{Scopes: []string{"orders:read"}})(route))
+	assert.Len(t, route.middlewares, 1)
+	assert.True(t, route.Authenticated())
+}
+
+func TestClientCertificate(t *testing.T) {
+	t.Parallel()
+	route := &Route{}
+	assert.NoError(t, ClientCertificate(mtls.Requirement{CommonNames: []string{"orders"}})(route))
+	assert.Len(t, route.middlewares, 1)
+	assert.True(t, route.Authenticated())
+}
+
 func TestCache(t *testing.T) {
 	t.Parallel()
 	type fields struct {
@@ -92,6 +120,7 @@ func TestCache(t *testing.T) {
 	type args struct {
 		cache     cache.TTLCache
 		ageBounds httpcache.Age
+		oo        []httpcache.OptionFunc
 	}
 	tests := map[string]struct {
 		fields      fields
@@ -103,10 +132,22 @@ func TestCache(t *testing.T) {
 			args:        args{cache: &redis.Cache{}, ageBounds: httpcache.Age{}},
 			expectedErr: "",
 		},
-		"fail with missing get": {
+		"success with cacheable post": {
+			fields: fields{httpMethod: http.MethodPost},
+			args: args{cache: &redis.Cache{}, ageBounds: httpcache.Age{}, oo: []httpcache.OptionFunc{
+				httpcache.CachePolicy(httpcache.Policy{Methods: []string{http.MethodPost}}),
+			}},
+			expectedErr: "",
+		},
+		"fail with non cacheable method": {
 			fields:      fields{httpMethod: http.MethodDelete},
 			args:        args{cache: &redis.Cache{}, ageBounds: httpcache.Age{}},
-			expectedErr: "cannot apply cache to a route with any method other than GET",
+			expectedErr: "cannot apply cache to a route with method DELETE, which is not cacheable",
+		},
+		"fail with method outside of the policy": {
+			fields:      fields{httpMethod: http.MethodHead},
+			args:        args{cache: &redis.Cache{}, ageBounds: httpcache.Age{}, oo: []httpcache.OptionFunc{httpcache.CachePolicy(httpcache.Policy{Methods: []string{http.MethodGet}})}},
+			expectedErr: "cannot apply cache to a route with method HEAD, which is not cacheable",
 		},
 		"fail with args": {
 			fields:      fields{httpMethod: http.MethodGet},
@@ -119,7 +160,7 @@ func TestCache(t *testing.T) {
 		t.Run(name, func(t *testing.T) {
 			t.Parallel()
 			route := &Route{method: tt.fields.httpMethod}
-			err := Cache(tt.args.cache, tt.args.ageBounds)(route)
+			err := Cache(tt.args.cache, tt.args.ageBounds, tt.args.oo...)(route)
 			if tt.expectedErr != "" {
 				assert.EqualError(t, err, tt.expectedErr)
 			} else {
@@ -128,3 +169,208 @@ func TestCache(t *testing.T) {
 		})
 	}
 }
+
+func TestTransform(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		tt          []patronhttp.TransformFunc
+		expectedErr string
+	}{
+		"success":       {tt: []patronhttp.TransformFunc{patronhttp.Envelope("data")}},
+		"empty":         {tt: nil, expectedErr: "transformations are empty"},
+		"nil transform": {tt: []patronhttp.TransformFunc{nil}, expectedErr: "transformation is nil"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			route := &Route{}
+			err := Transform(tt.tt...)(route)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Len(t, route.middlewares, 1)
+			}
+		})
+	}
+}
+
+func TestCompression(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		cfg         patronhttp.CompressionConfig
+		expectedErr string
+	}{
+		"success":           {cfg: patronhttp.CompressionConfig{MinSize: 1024}},
+		"negative min size": {cfg: patronhttp.CompressionConfig{MinSize: -1}, expectedErr: "min size should not be negative"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			route := &Route{}
+			err := Compression(tt.cfg)(route)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Len(t, route.middlewares, 1)
+			}
+		})
+	}
+}
+
+func TestMaxRequestBodySize(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		bytes       int64
+		expectedErr string
+	}{
+		"success": {bytes: 1024},
+		"zero":    {bytes: 0, expectedErr: "max request body size should be positive"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			route := &Route{}
+			err := MaxRequestBodySize(tt.bytes)(route)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Len(t, route.middlewares, 1)
+			}
+		})
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		timeout     time.Duration
+		status      int
+		expectedErr string
+	}{
+		"success":        {timeout: time.Second, status: http.StatusGatewayTimeout},
+		"zero timeout":   {timeout: 0, status: http.StatusGatewayTimeout, expectedErr: "timeout should be positive"},
+		"invalid status": {timeout: time.Second, status: http.StatusOK, expectedErr: "timeout status should be 503 or 504"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			route := &Route{}
+			err := Timeout(tt.timeout, tt.status)(route)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Len(t, route.middlewares, 1)
+			}
+		})
+	}
+}
+
+func TestKeyedRateLimiting(t *testing.T) {
+	t.Parallel()
+	limiter, err := ratelimit.NewSlidingWindow(10, time.Second)
+	require.NoError(t, err)
+	tests := map[string]struct {
+		limiter     ratelimit.Limiter
+		expectedErr string
+	}{
+		"success":         {limiter: limiter},
+		"missing limiter": {expectedErr: "limiter is nil"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			route := &Route{path: "/"}
+			err := KeyedRateLimiting(tt.limiter, ratelimit.ByIP())(route)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Len(t, route.middlewares, 1)
+			}
+		})
+	}
+}
+
+func TestCORS(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		cfg         patronhttp.CORSConfig
+		expectedErr string
+	}{
+		"success":         {cfg: patronhttp.CORSConfig{AllowedOrigins: []string{"https://example.com"}}},
+		"missing origins": {cfg: patronhttp.CORSConfig{}, expectedErr: "allowed origins are empty"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			route := &Route{}
+			err := CORS(tt.cfg)(route)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, route.CORS())
+				assert.Empty(t, route.middlewares)
+			}
+		})
+	}
+}
+
+func TestSLO(t *testing.T) {
+	t.Parallel()
+	objective, err := slo.New("route-option-test", 0.999)
+	require.NoError(t, err)
+	t.Cleanup(objective.Close)
+
+	route := &Route{}
+	assert.EqualError(t, SLO(nil)(route), "objective is nil")
+	assert.NoError(t, SLO(objective)(route))
+	assert.Len(t, route.middlewares, 1)
+}
+
+func TestStats(t *testing.T) {
+	t.Parallel()
+	latency, err := stats.NewWindow(time.Minute)
+	require.NoError(t, err)
+
+	route := &Route{}
+	assert.EqualError(t, Stats(nil, nil)(route), "latency window is nil")
+	assert.NoError(t, Stats(latency, nil)(route))
+	assert.Len(t, route.middlewares, 1)
+}
+
+func TestValidation(t *testing.T) {
+	t.Parallel()
+	type request struct {
+		Name string `json:"name" validate:"required"`
+	}
+	route := &Route{}
+	assert.EqualError(t, Validation("name")(route), "type string is not a struct")
+	assert.NoError(t, Validation(request{})(route))
+	assert.Len(t, route.middlewares, 1)
+	assert.Equal(t, request{}, route.Doc().Request)
+}
+
+func TestDoc(t *testing.T) {
+	t.Parallel()
+	type request struct {
+		Name string `json:"name"`
+	}
+	type response struct {
+		ID string `json:"id"`
+	}
+	route := &Route{}
+	doc := RouteDoc{Summary: "create", Tags: []string{"orders"}, Request: request{}, Responses: map[int]interface{}{201: response{}}}
+	assert.NoError(t, Doc(doc)(route))
+	assert.Equal(t, doc, route.Doc())
+}
@@ -173,3 +173,17 @@ func TestRoutes_Append(t *testing.T) {
 		})
 	}
 }
+
+func TestRoutes_AppendAll(t *testing.T) {
+	t.Parallel()
+	r := &Routes{}
+	r.AppendAll([]*Route{{}, {}}, nil)
+	routes, err := r.Result()
+	assert.NoError(t, err)
+	assert.Len(t, routes, 2)
+
+	r.AppendAll(nil, errors.New("TEST"))
+	r.AppendAll([]*Route{nil}, nil)
+	_, err = r.Result()
+	assert.EqualError(t, err, "TEST\nroute is nil\n")
+}
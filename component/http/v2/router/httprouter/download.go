@@ -0,0 +1,328 @@
+package httprouter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/beatlabs/patron/component/http/problem"
+	v2 "github.com/beatlabs/patron/component/http/v2"
+	"github.com/beatlabs/patron/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/time/rate"
+)
+
+// maxThrottledWrite is the largest write of a throttled download, which smooths the bandwidth of the fast clients.
+const maxThrottledWrite = 32 * 1024
+
+var (
+	downloadsMetric           *prometheus.CounterVec
+	downloadBytesMetric       *prometheus.CounterVec
+	downloadsInProgressMetric *prometheus.GaugeVec
+)
+
+func init() {
+	downloadsMetric = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "component",
+			Subsystem: "http",
+			Name:      "downloads_total",
+			Help:      "Downloads served per route, classified by status e.g. 200, 206 or 304.",
+		},
+		[]string{"path", "status"},
+	)
+	downloadBytesMetric = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "component",
+			Subsystem: "http",
+			Name:      "download_bytes_total",
+			Help:      "Bytes of the downloads written to the clients per route.",
+		},
+		[]string{"path"},
+	)
+	downloadsInProgressMetric = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "component",
+			Subsystem: "http",
+			Name:      "downloads_in_progress",
+			Help:      "Downloads being served per route.",
+		},
+		[]string{"path"},
+	)
+	prometheus.MustRegister(downloadsMetric, downloadBytesMetric, downloadsInProgressMetric)
+}
+
+// DownloadObject is an object served by a download route.
+type DownloadObject struct {
+	// Content of the object, which is read only for the requested ranges, and closed once the object is served.
+	Content io.ReadSeekCloser
+	// ContentType of the object, which is detected from the extension of its name, or its content, if empty.
+	ContentType string
+	// ETag of the object, which is quoted if it is not, e.g. the ETag of an S3 object.
+	ETag string
+	// ModTime of the object, which is the Last-Modified header of its responses if not zero.
+	ModTime time.Time
+}
+
+// DownloadSource opens the objects served by a download route, e.g. the files of a directory or the objects of an S3 bucket.
+type DownloadSource interface {
+	// Open returns the object of the name, or an error wrapping fs.ErrNotExist if it does not exist.
+	Open(ctx context.Context, name string) (*DownloadObject, error)
+}
+
+// DownloadConfig configures a download route.
+type DownloadConfig struct {
+	// BytesPerSecond limits the bandwidth of every download, which is not limited if zero.
+	BytesPerSecond int
+	// CacheControl is the value of the Cache-Control header of the objects, which is not set if empty.
+	CacheControl string
+	// Attachment sets the Content-Disposition header of the objects for the browsers to save them as files instead of displaying them.
+	Attachment bool
+}
+
+// NewDownloadRoute returns a long-lived GET route streaming the objects of the source at the catch-all parameter of its path, e.g. /files/*name,
+// without buffering them in memory. It answers range and conditional requests, i.e. with 206 Partial Content and 304 Not Modified responses,
+// while paths with parent directory elements are rejected.
+func NewDownloadRoute(path string, source DownloadSource, cfg DownloadConfig, oo ...v2.RouteOptionFunc) (*v2.Route, error) {
+	if path == "" {
+		return nil, errors.New("path is empty")
+	}
+	if !strings.Contains(path, "/*") {
+		return nil, errors.New("path should end with a catch-all parameter")
+	}
+	if source == nil {
+		return nil, errors.New("download source is nil")
+	}
+	if cfg.BytesPerSecond < 0 {
+		return nil, errors.New("negative bytes per second provided")
+	}
+
+	d := &download{path: path, source: source, cfg: cfg}
+	return v2.NewGetRoute(path, d.serve, append(oo, v2.LongLived())...)
+}
+
+type download struct {
+	path   string
+	source DownloadSource
+	cfg    DownloadConfig
+}
+
+func (d *download) serve(w http.ResponseWriter, r *http.Request) {
+	name, ok := staticName(r)
+	if !ok {
+		problem.Write(w, r, problem.New(http.StatusBadRequest, "invalid file path"))
+		return
+	}
+	if name == "." {
+		problem.Write(w, r, problem.New(http.StatusNotFound, ""))
+		return
+	}
+
+	obj, err := d.source.Open(r.Context(), name)
+	switch {
+	case err == nil:
+	case errors.Is(err, fs.ErrNotExist):
+		problem.Write(w, r, problem.New(http.StatusNotFound, ""))
+		return
+	case errors.Is(err, fs.ErrPermission):
+		problem.Write(w, r, problem.New(http.StatusForbidden, ""))
+		return
+	default:
+		problem.Write(w, r, fmt.Errorf("failed to open object %s: %w", name, err))
+		return
+	}
+	defer func() {
+		if err := obj.Content.Close(); err != nil {
+			log.FromContext(r.Context()).Warnf("failed to close object %s: %v", name, err)
+		}
+	}()
+
+	downloadsInProgressMetric.WithLabelValues(d.path).Inc()
+	dw := &downloadWriter{ResponseWriter: w, ctx: r.Context(), status: http.StatusOK}
+	if d.cfg.BytesPerSecond > 0 {
+		burst := d.cfg.BytesPerSecond
+		if burst > maxThrottledWrite {
+			burst = maxThrottledWrite
+		}
+		dw.limiter = rate.NewLimiter(rate.Limit(d.cfg.BytesPerSecond), burst)
+	}
+	defer func() {
+		downloadsInProgressMetric.WithLabelValues(d.path).Dec()
+		downloadsMetric.WithLabelValues(d.path, strconv.Itoa(dw.status)).Inc()
+		downloadBytesMetric.WithLabelValues(d.path).Add(float64(dw.written))
+	}()
+
+	if obj.ETag != "" {
+		w.Header().Set("ETag", quoteETag(obj.ETag))
+	}
+	if obj.ContentType != "" {
+		w.Header().Set("Content-Type", obj.ContentType)
+	}
+	if d.cfg.CacheControl != "" {
+		w.Header().Set("Cache-Control", d.cfg.CacheControl)
+	}
+	if d.cfg.Attachment {
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": path.Base(name)}))
+	}
+	http.ServeContent(dw, r, name, obj.ModTime, obj.Content)
+}
+
+// quoteETag returns the entity tag quoted, unless it is already, e.g. "abc" or W/"abc".
+func quoteETag(etag string) string {
+	if strings.HasPrefix(etag, `"`) || strings.HasPrefix(etag, `W/"`) {
+		return etag
+	}
+	return strconv.Quote(etag)
+}
+
+// downloadWriter records the status and the bytes written of a download, limiting its bandwidth if it has a limiter.
+type downloadWriter struct {
+	http.ResponseWriter
+	ctx     context.Context
+	limiter *rate.Limiter
+	status  int
+	written int64
+}
+
+func (w *downloadWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *downloadWriter) Write(p []byte) (int, error) {
+	if w.limiter == nil {
+		n, err := w.ResponseWriter.Write(p)
+		w.written += int64(n)
+		return n, err
+	}
+
+	var total int
+	for len(p) > 0 {
+		chunk := p
+		if len(chunk) > w.limiter.Burst() {
+			chunk = chunk[:w.limiter.Burst()]
+		}
+		if err := w.limiter.WaitN(w.ctx, len(chunk)); err != nil {
+			return total, err
+		}
+		n, err := w.ResponseWriter.Write(chunk)
+		total += n
+		w.written += int64(n)
+		if err != nil {
+			return total, err
+		}
+		p = p[n:]
+	}
+	return total, nil
+}
+
+type fileSource struct {
+	dir string
+}
+
+// NewFileSource returns a download source of the files of the directory, whose ETags are derived from their size and modification time.
+func NewFileSource(dir string) (DownloadSource, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat directory: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", dir)
+	}
+	return fileSource{dir: dir}, nil
+}
+
+func (s fileSource) Open(_ context.Context, name string) (*DownloadObject, error) {
+	f, err := os.Open(filepath.Join(s.dir, filepath.FromSlash(name)))
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	if info.IsDir() {
+		_ = f.Close()
+		return nil, fs.ErrNotExist
+	}
+	return &DownloadObject{
+		Content: f,
+		ETag:    fmt.Sprintf(`"%x-%x"`, info.Size(), info.ModTime().UnixNano()),
+		ModTime: info.ModTime(),
+	}, nil
+}
+
+// RangeReadFunc returns the content of an object from the offset to its end, e.g. with a GetObject request to S3 with a bytes=<offset>- range.
+type RangeReadFunc func(ctx context.Context, offset int64) (io.ReadCloser, error)
+
+// NewRangeReadSeeker returns the content of an object of the size, which is read with the function only from the offsets
+// of the requested ranges, so that remote objects e.g. in S3 are neither downloaded nor buffered whole.
+func NewRangeReadSeeker(ctx context.Context, size int64, read RangeReadFunc) io.ReadSeekCloser {
+	return &rangeReadSeeker{ctx: ctx, size: size, read: read}
+}
+
+type rangeReadSeeker struct {
+	ctx    context.Context
+	size   int64
+	read   RangeReadFunc
+	offset int64
+	body   io.ReadCloser
+}
+
+func (rs *rangeReadSeeker) Read(p []byte) (int, error) {
+	if rs.offset >= rs.size {
+		return 0, io.EOF
+	}
+	if rs.body == nil {
+		body, err := rs.read(rs.ctx, rs.offset)
+		if err != nil {
+			return 0, err
+		}
+		rs.body = body
+	}
+	n, err := rs.body.Read(p)
+	rs.offset += int64(n)
+	return n, err
+}
+
+func (rs *rangeReadSeeker) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += rs.offset
+	case io.SeekEnd:
+		offset += rs.size
+	default:
+		return 0, errors.New("invalid whence")
+	}
+	if offset < 0 {
+		return 0, errors.New("negative offset")
+	}
+	if offset != rs.offset {
+		if err := rs.Close(); err != nil {
+			return 0, err
+		}
+		rs.offset = offset
+	}
+	return offset, nil
+}
+
+func (rs *rangeReadSeeker) Close() error {
+	if rs.body == nil {
+		return nil
+	}
+	err := rs.body.Close()
+	rs.body = nil
+	return err
+}
@@ -0,0 +1,286 @@
+package httprouter
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/beatlabs/patron/component/http/problem"
+	"github.com/julienschmidt/httprouter"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const downloadContent = "0123456789abcdefghijklmnopqrstuvwxyz"
+
+type downloadSourceFunc func(ctx context.Context, name string) (*DownloadObject, error)
+
+func (f downloadSourceFunc) Open(ctx context.Context, name string) (*DownloadObject, error) {
+	return f(ctx, name)
+}
+
+// rangeSource serves the objects from their offsets, like S3 does with ranged GetObject requests, recording the offsets requested.
+type rangeSource struct {
+	offsets []int64
+}
+
+func (s *rangeSource) Open(ctx context.Context, name string) (*DownloadObject, error) {
+	if name != "reports/2021.csv" {
+		return nil, fmt.Errorf("object %s: %w", name, fs.ErrNotExist)
+	}
+	content := NewRangeReadSeeker(ctx, int64(len(downloadContent)), func(_ context.Context, offset int64) (io.ReadCloser, error) {
+		s.offsets = append(s.offsets, offset)
+		return ioutil.NopCloser(strings.NewReader(downloadContent[offset:])), nil
+	})
+	return &DownloadObject{Content: content, ContentType: "text/csv", ETag: `"s3-etag"`, ModTime: staticModTime}, nil
+}
+
+func newDownloadServer(t *testing.T, path string, source DownloadSource, cfg DownloadConfig) *httprouter.Router {
+	route, err := NewDownloadRoute(path, source, cfg)
+	require.NoError(t, err)
+	assert.Equal(t, http.MethodGet, route.Method())
+	mux := httprouter.New()
+	mux.Handler(route.Method(), route.Path(), route.Handler())
+	return mux
+}
+
+func TestNewDownloadRoute(t *testing.T) {
+	t.Parallel()
+	source := &rangeSource{}
+	tests := map[string]struct {
+		path        string
+		source      DownloadSource
+		cfg         DownloadConfig
+		expectedErr string
+	}{
+		"success":                   {path: "/files/*name", source: source, cfg: DownloadConfig{BytesPerSecond: 1024}},
+		"missing path":              {source: source, expectedErr: "path is empty"},
+		"missing catch-all":         {path: "/files", source: source, expectedErr: "path should end with a catch-all parameter"},
+		"missing source":            {path: "/files/*name", expectedErr: "download source is nil"},
+		"negative bytes per second": {path: "/files/*name", source: source, cfg: DownloadConfig{BytesPerSecond: -1}, expectedErr: "negative bytes per second provided"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			got, err := NewDownloadRoute(tt.path, tt.source, tt.cfg)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.path, got.Path())
+			}
+		})
+	}
+}
+
+func TestDownloadRoute(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		path           string
+		header         http.Header
+		expectedCode   int
+		expectedBody   string
+		expectedRange  string
+		expectedOffset []int64
+	}{
+		"object": {
+			path: "/reports/reports/2021.csv", expectedCode: http.StatusOK, expectedBody: downloadContent, expectedOffset: []int64{0},
+		},
+		"range": {
+			path: "/reports/reports/2021.csv", header: http.Header{"Range": []string{"bytes=10-19"}}, expectedCode: http.StatusPartialContent,
+			expectedBody: "abcdefghij", expectedRange: "bytes 10-19/36", expectedOffset: []int64{10},
+		},
+		"suffix range": {
+			path: "/reports/reports/2021.csv", header: http.Header{"Range": []string{"bytes=-6"}}, expectedCode: http.StatusPartialContent,
+			expectedBody: "uvwxyz", expectedRange: "bytes 30-35/36", expectedOffset: []int64{30},
+		},
+		"unsatisfiable range": {
+			path: "/reports/reports/2021.csv", header: http.Header{"Range": []string{"bytes=100-"}}, expectedCode: http.StatusRequestedRangeNotSatisfiable,
+		},
+		"not modified": {
+			path: "/reports/reports/2021.csv", header: http.Header{"If-None-Match": []string{`"s3-etag"`}}, expectedCode: http.StatusNotModified,
+		},
+		"modified": {
+			path: "/reports/reports/2021.csv", header: http.Header{"If-None-Match": []string{`"other"`}}, expectedCode: http.StatusOK,
+			expectedBody: downloadContent, expectedOffset: []int64{0},
+		},
+		"stale if-range": {
+			path: "/reports/reports/2021.csv", header: http.Header{"Range": []string{"bytes=10-19"}, "If-Range": []string{`"other"`}},
+			expectedCode: http.StatusOK, expectedBody: downloadContent, expectedOffset: []int64{0},
+		},
+		"not found": {path: "/reports/reports/2020.csv", expectedCode: http.StatusNotFound},
+		"root":      {path: "/reports/", expectedCode: http.StatusNotFound},
+		"traversal": {path: "/reports/..%2F..%2Fetc/passwd", expectedCode: http.StatusBadRequest},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			source := &rangeSource{}
+			mux := newDownloadServer(t, "/reports/*name", source, DownloadConfig{CacheControl: "private, max-age=60", Attachment: true})
+			rsp := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			for k, v := range tt.header {
+				req.Header[k] = v
+			}
+			mux.ServeHTTP(rsp, req)
+
+			assert.Equal(t, tt.expectedCode, rsp.Code)
+			assert.Equal(t, tt.expectedOffset, source.offsets)
+			switch tt.expectedCode {
+			case http.StatusNotFound, http.StatusBadRequest:
+				assert.Equal(t, problem.ContentType, rsp.Header().Get("Content-Type"))
+				return
+			case http.StatusNotModified, http.StatusRequestedRangeNotSatisfiable:
+				return
+			}
+			assert.Equal(t, tt.expectedBody, rsp.Body.String())
+			assert.Equal(t, tt.expectedRange, rsp.Header().Get("Content-Range"))
+			assert.Equal(t, "text/csv", rsp.Header().Get("Content-Type"))
+			assert.Equal(t, `"s3-etag"`, rsp.Header().Get("ETag"))
+			assert.Equal(t, "private, max-age=60", rsp.Header().Get("Cache-Control"))
+			assert.Equal(t, `attachment; filename=2021.csv`, rsp.Header().Get("Content-Disposition"))
+			assert.Equal(t, "Tue, 01 Jun 2021 10:00:00 GMT", rsp.Header().Get("Last-Modified"))
+		})
+	}
+}
+
+func TestDownloadRoute_SourceErrors(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		err          error
+		expectedCode int
+	}{
+		"permission": {err: fs.ErrPermission, expectedCode: http.StatusForbidden},
+		"failure":    {err: errors.New("unavailable"), expectedCode: http.StatusInternalServerError},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			mux := newDownloadServer(t, "/files/*name", downloadSourceFunc(func(context.Context, string) (*DownloadObject, error) {
+				return nil, tt.err
+			}), DownloadConfig{})
+			rsp := httptest.NewRecorder()
+			mux.ServeHTTP(rsp, httptest.NewRequest(http.MethodGet, "/files/a.txt", nil))
+			assert.Equal(t, tt.expectedCode, rsp.Code)
+		})
+	}
+}
+
+func TestDownloadRoute_Throttled(t *testing.T) {
+	t.Parallel()
+	content := bytes.Repeat([]byte("a"), 1500)
+	mux := newDownloadServer(t, "/throttled/*name", downloadSourceFunc(func(ctx context.Context, _ string) (*DownloadObject, error) {
+		return &DownloadObject{Content: NewRangeReadSeeker(ctx, int64(len(content)), func(_ context.Context, offset int64) (io.ReadCloser, error) {
+			return ioutil.NopCloser(bytes.NewReader(content[offset:])), nil
+		})}, nil
+	}), DownloadConfig{BytesPerSecond: 1000})
+
+	start := time.Now()
+	rsp := httptest.NewRecorder()
+	mux.ServeHTTP(rsp, httptest.NewRequest(http.MethodGet, "/throttled/a.bin", nil))
+	assert.Equal(t, http.StatusOK, rsp.Code)
+	assert.Equal(t, content, rsp.Body.Bytes())
+	// the content exceeds the burst of 1000 bytes by 500 bytes, which are written after half a second
+	assert.GreaterOrEqual(t, int64(time.Since(start)), int64(400*time.Millisecond))
+	assert.Equal(t, 1.0, testutil.ToFloat64(downloadsMetric.WithLabelValues("/throttled/*name", "200")))
+	assert.Equal(t, 1500.0, testutil.ToFloat64(downloadBytesMetric.WithLabelValues("/throttled/*name")))
+	assert.Equal(t, 0.0, testutil.ToFloat64(downloadsInProgressMetric.WithLabelValues("/throttled/*name")))
+}
+
+func TestDownloadRoute_Canceled(t *testing.T) {
+	t.Parallel()
+	mux := newDownloadServer(t, "/canceled/*name", &rangeSource{}, DownloadConfig{BytesPerSecond: 1})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	rsp := httptest.NewRecorder()
+	mux.ServeHTTP(rsp, httptest.NewRequest(http.MethodGet, "/canceled/reports/2021.csv", nil).WithContext(ctx))
+	assert.Empty(t, rsp.Body.String())
+}
+
+func TestFileSource(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "reports"), 0o750))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "reports", "2021.csv"), []byte(downloadContent), 0o600))
+
+	_, err := NewFileSource(filepath.Join(dir, "missing"))
+	assert.Error(t, err)
+	_, err = NewFileSource(filepath.Join(dir, "reports", "2021.csv"))
+	assert.EqualError(t, err, filepath.Join(dir, "reports", "2021.csv")+" is not a directory")
+
+	source, err := NewFileSource(dir)
+	require.NoError(t, err)
+	mux := newDownloadServer(t, "/files/*name", source, DownloadConfig{})
+
+	rsp := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/files/reports/2021.csv", nil)
+	req.Header.Set("Range", "bytes=0-9")
+	mux.ServeHTTP(rsp, req)
+	assert.Equal(t, http.StatusPartialContent, rsp.Code)
+	assert.Equal(t, "0123456789", rsp.Body.String())
+	assert.Equal(t, "text/csv; charset=utf-8", rsp.Header().Get("Content-Type"))
+	etag := rsp.Header().Get("ETag")
+	assert.Regexp(t, `^"24-[0-9a-f]+"$`, etag)
+
+	rsp = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/files/reports/2021.csv", nil)
+	req.Header.Set("If-None-Match", etag)
+	mux.ServeHTTP(rsp, req)
+	assert.Equal(t, http.StatusNotModified, rsp.Code)
+
+	for _, path := range []string{"/files/reports", "/files/reports/2020.csv"} {
+		rsp = httptest.NewRecorder()
+		mux.ServeHTTP(rsp, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, http.StatusNotFound, rsp.Code, path)
+	}
+}
+
+func TestRangeReadSeeker(t *testing.T) {
+	t.Parallel()
+	var offsets []int64
+	rs := NewRangeReadSeeker(context.Background(), int64(len(downloadContent)), func(_ context.Context, offset int64) (io.ReadCloser, error) {
+		offsets = append(offsets, offset)
+		return ioutil.NopCloser(strings.NewReader(downloadContent[offset:])), nil
+	})
+
+	size, err := rs.Seek(0, io.SeekEnd)
+	require.NoError(t, err)
+	assert.Equal(t, int64(36), size)
+	_, err = rs.Seek(-26, io.SeekCurrent)
+	require.NoError(t, err)
+	b := make([]byte, 5)
+	_, err = io.ReadFull(rs, b)
+	require.NoError(t, err)
+	assert.Equal(t, "abcde", string(b))
+	_, err = io.ReadFull(rs, b)
+	require.NoError(t, err)
+	assert.Equal(t, "fghij", string(b))
+	_, err = rs.Seek(-1, io.SeekStart)
+	assert.EqualError(t, err, "negative offset")
+	_, err = rs.Seek(0, 3)
+	assert.EqualError(t, err, "invalid whence")
+	_, err = rs.Seek(36, io.SeekStart)
+	require.NoError(t, err)
+	_, err = rs.Read(b)
+	assert.Equal(t, io.EOF, err)
+	assert.NoError(t, rs.Close())
+	// the content is read once per seek to another offset
+	assert.Equal(t, []int64{10}, offsets)
+}
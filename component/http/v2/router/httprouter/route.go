@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"os"
 
+	"github.com/beatlabs/patron/component/http/problem"
 	v2 "github.com/beatlabs/patron/component/http/v2"
 	"github.com/julienschmidt/httprouter"
 )
@@ -59,7 +60,7 @@ func NewFileServerRoute(path string, assetsDir string, fallbackPath string) (*v2
 		} else if err != nil {
 			// if we got an error (that wasn't that the file doesn't exist) stating the
 			// file, return a 500 internal server error and stop
-			http.Error(w, "", http.StatusInternalServerError)
+			problem.Write(w, r, fmt.Errorf("failed to stat file %s: %w", path, err))
 			return
 		}
 
@@ -3,10 +3,14 @@ package httprouter
 import (
 	"errors"
 	"fmt"
+	"net/http"
 	"os"
 
+	"github.com/beatlabs/patron/component/async/asyncapi"
 	"github.com/beatlabs/patron/component/http/middleware"
+	"github.com/beatlabs/patron/component/http/problem"
 	"github.com/beatlabs/patron/component/http/v2"
+	"github.com/beatlabs/patron/component/http/v2/openapi"
 	"github.com/beatlabs/patron/log"
 	"github.com/julienschmidt/httprouter"
 )
@@ -22,8 +26,12 @@ type Config struct {
 	readyCheckFunc        v2.ReadyCheckFunc
 	deflateLevel          int
 	middlewares           []middleware.Func
+	cors                  middleware.Func
 	routes                []*v2.Route
 	enableProfilingExpVar bool
+	openAPI               *openapi.Config
+	asyncAPI              *asyncapi.Config
+	asyncAPIChannels      []asyncapi.Channel
 }
 
 func New(oo ...OptionFunc) (*httprouter.Router, error) {
@@ -44,6 +52,8 @@ func New(oo ...OptionFunc) (*httprouter.Router, error) {
 
 	mux := httprouter.New()
 	stdRoutes = append(stdRoutes, v2.MetricRoute())
+	stdRoutes = append(stdRoutes, v2.DiagnosticsRoute())
+	stdRoutes = append(stdRoutes, v2.DependenciesRoute())
 	stdRoutes = append(stdRoutes, v2.ProfilingRoutes(cfg.enableProfilingExpVar)...)
 
 	route, err := v2.LivenessCheckRoute(cfg.aliveCheckFunc)
@@ -58,6 +68,22 @@ func New(oo ...OptionFunc) (*httprouter.Router, error) {
 	}
 	stdRoutes = append(stdRoutes, route)
 
+	if cfg.openAPI != nil {
+		routes, err := openapi.Routes(*cfg.openAPI, cfg.routes...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create OpenAPI routes: %w", err)
+		}
+		stdRoutes = append(stdRoutes, routes...)
+	}
+
+	if cfg.asyncAPI != nil {
+		routes, err := asyncapi.Routes(*cfg.asyncAPI, cfg.asyncAPIChannels...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create AsyncAPI routes: %w", err)
+		}
+		stdRoutes = append(stdRoutes, routes...)
+	}
+
 	for _, route := range stdRoutes {
 		handler := middleware.Chain(route.Handler(), middleware.NewRecovery())
 		mux.Handler(route.Method(), route.Path(), handler)
@@ -71,6 +97,14 @@ func New(oo ...OptionFunc) (*httprouter.Router, error) {
 		return nil, fmt.Errorf("failed to parse status codes %s: %w", statusCodeLoggerCfg, err)
 	}
 
+	// paths with an OPTIONS route answer their own preflight requests
+	optionsPaths := make(map[string]struct{})
+	for _, route := range cfg.routes {
+		if route.Method() == http.MethodOptions {
+			optionsPaths[route.Path()] = struct{}{}
+		}
+	}
+
 	for _, route := range cfg.routes {
 		// add standard middlewares
 		middlewares := []middleware.Func{
@@ -80,6 +114,18 @@ func New(oo ...OptionFunc) (*httprouter.Router, error) {
 			middleware.NewRequestObserver(route.Method(), route.Path()),
 			middleware.NewCompression(cfg.deflateLevel),
 		}
+		// add the CORS middleware before the rest, in order for their rejections e.g. by authentication to carry the CORS headers
+		cors := cfg.cors
+		if route.CORS() != nil {
+			cors = route.CORS()
+			if _, ok := optionsPaths[route.Path()]; !ok {
+				optionsPaths[route.Path()] = struct{}{}
+				mux.Handler(http.MethodOptions, route.Path(), preflightHandler(cors))
+			}
+		}
+		if cors != nil {
+			middlewares = append(middlewares, cors)
+		}
 		// add router middlewares
 		middlewares = append(middlewares, cfg.middlewares...)
 		// add route middlewares
@@ -90,9 +136,29 @@ func New(oo ...OptionFunc) (*httprouter.Router, error) {
 		log.Debugf("added route %s with %d middlewares", route, len(middlewares))
 	}
 
+	if cfg.cors != nil {
+		mux.GlobalOPTIONS = preflightHandler(cfg.cors)
+	}
+	mux.NotFound = problemHandler(http.StatusNotFound)
+	mux.MethodNotAllowed = problemHandler(http.StatusMethodNotAllowed)
+
 	return mux, nil
 }
 
+// problemHandler responds to the requests not matching any route with a problem of the status.
+func problemHandler(status int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		problem.Write(w, r, problem.New(status, ""))
+	})
+}
+
+// preflightHandler answers the preflight requests with the CORS middleware, and any other OPTIONS request with a 204 No Content.
+func preflightHandler(cors middleware.Func) http.Handler {
+	return middleware.Chain(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}), middleware.NewRecovery(), cors)
+}
+
 // Routes option for providing routes to the router.
 func Routes(routes ...*v2.Route) OptionFunc {
 	return func(cfg *Config) error {
@@ -104,6 +170,28 @@ func Routes(routes ...*v2.Route) OptionFunc {
 	}
 }
 
+// OpenAPI option for serving the OpenAPI document of the routes of the router at /swagger.json,
+// and the Swagger UI at the UI path of the config, if set. See package openapi.
+func OpenAPI(openAPICfg openapi.Config) OptionFunc {
+	return func(cfg *Config) error {
+		cfg.openAPI = &openAPICfg
+		return nil
+	}
+}
+
+// AsyncAPI option for serving the AsyncAPI document of the channels the service consumes from and produces to
+// at /asyncapi.json. See package asyncapi.
+func AsyncAPI(asyncAPICfg asyncapi.Config, channels ...asyncapi.Channel) OptionFunc {
+	return func(cfg *Config) error {
+		if len(channels) == 0 {
+			return errors.New("channels are empty")
+		}
+		cfg.asyncAPI = &asyncAPICfg
+		cfg.asyncAPIChannels = channels
+		return nil
+	}
+}
+
 // AliveCheck option for the router.
 func AliveCheck(acf v2.LivenessCheckFunc) OptionFunc {
 	return func(cfg *Config) error {
@@ -152,3 +240,16 @@ func EnableExpVarProfiling() OptionFunc {
 		return nil
 	}
 }
+
+// CORS option for handling the cross-origin requests of all routes according to the provided config,
+// unless overridden by the CORS option of a route. Preflight requests are answered by the router.
+func CORS(corsCfg middleware.CORSConfig) OptionFunc {
+	return func(cfg *Config) error {
+		mw, err := middleware.NewCORS(corsCfg)
+		if err != nil {
+			return err
+		}
+		cfg.cors = mw
+		return nil
+	}
+}
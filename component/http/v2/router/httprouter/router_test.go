@@ -2,10 +2,16 @@ package httprouter
 
 import (
 	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
+	"github.com/beatlabs/patron/component/async/asyncapi"
 	"github.com/beatlabs/patron/component/http/middleware"
+	"github.com/beatlabs/patron/component/http/problem"
 	"github.com/beatlabs/patron/component/http/v2"
+	"github.com/beatlabs/patron/component/http/v2/openapi"
+	"github.com/gorilla/websocket"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -163,3 +169,189 @@ func TestDisableProfiling(t *testing.T) {
 	assert.NoError(t, err)
 	assert.True(t, cfg.enableProfilingExpVar)
 }
+
+func TestCORS(t *testing.T) {
+	t.Parallel()
+	cfg := &Config{}
+	assert.EqualError(t, CORS(middleware.CORSConfig{})(cfg), "allowed origins are empty")
+	assert.NoError(t, CORS(middleware.CORSConfig{AllowedOrigins: []string{"*"}})(cfg))
+	assert.NotNil(t, cfg.cors)
+}
+
+func TestNew_CORS(t *testing.T) {
+	t.Parallel()
+	handler := func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}
+	deny := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+		})
+	}
+	routerRoute, err := v2.NewGetRoute("/router", handler)
+	require.NoError(t, err)
+	protectedRoute, err := v2.NewPutRoute("/route", handler, v2.Middlewares(deny),
+		v2.CORS(middleware.CORSConfig{AllowedOrigins: []string{"https://route.com"}, AllowedMethods: []string{http.MethodPut}}))
+	require.NoError(t, err)
+
+	mux, err := New(Routes(routerRoute, protectedRoute), CORS(middleware.CORSConfig{AllowedOrigins: []string{"https://router.com"}}))
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		method         string
+		path           string
+		origin         string
+		requestMethod  string
+		expectedStatus int
+		expectedOrigin string
+	}{
+		"router preflight": {
+			method: http.MethodOptions, path: "/router", origin: "https://router.com", requestMethod: http.MethodGet,
+			expectedStatus: http.StatusNoContent, expectedOrigin: "https://router.com",
+		},
+		"router request": {
+			method: http.MethodGet, path: "/router", origin: "https://router.com",
+			expectedStatus: http.StatusAccepted, expectedOrigin: "https://router.com",
+		},
+		"router request of route origin": {
+			method: http.MethodGet, path: "/router", origin: "https://route.com",
+			expectedStatus: http.StatusAccepted,
+		},
+		"route preflight skips route middlewares": {
+			method: http.MethodOptions, path: "/route", origin: "https://route.com", requestMethod: http.MethodPut,
+			expectedStatus: http.StatusNoContent, expectedOrigin: "https://route.com",
+		},
+		"route preflight of router origin": {
+			method: http.MethodOptions, path: "/route", origin: "https://router.com", requestMethod: http.MethodPut,
+			expectedStatus: http.StatusNoContent,
+		},
+		"route rejection carries headers": {
+			method: http.MethodPut, path: "/route", origin: "https://route.com",
+			expectedStatus: http.StatusUnauthorized, expectedOrigin: "https://route.com",
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set("Origin", tt.origin)
+			if tt.requestMethod != "" {
+				req.Header.Set("Access-Control-Request-Method", tt.requestMethod)
+			}
+			rc := httptest.NewRecorder()
+			mux.ServeHTTP(rc, req)
+			assert.Equal(t, tt.expectedStatus, rc.Code)
+			assert.Equal(t, tt.expectedOrigin, rc.Header().Get("Access-Control-Allow-Origin"))
+		})
+	}
+}
+
+func TestNew_SSE(t *testing.T) {
+	t.Parallel()
+	route, err := v2.NewSSERoute("/events", func(_ *http.Request, s *v2.Stream) error {
+		return s.Send(v2.Event{Data: "hello"})
+	}, v2.SSEConfig{})
+	require.NoError(t, err)
+	mux, err := New(Routes(route))
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "/events", nil)
+	req.Header.Set("Accept", "text/event-stream")
+	rc := httptest.NewRecorder()
+	mux.ServeHTTP(rc, req)
+	assert.Equal(t, http.StatusOK, rc.Code)
+	// the events are flushed through the standard middlewares
+	assert.True(t, rc.Flushed)
+	assert.Equal(t, ":\n\ndata: hello\n\n", rc.Body.String())
+}
+
+func TestNew_Problems(t *testing.T) {
+	t.Parallel()
+	route, err := v2.NewGetRoute("/orders", func(w http.ResponseWriter, r *http.Request) {})
+	require.NoError(t, err)
+	mux, err := New(Routes(route))
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		method         string
+		path           string
+		expectedStatus int
+		expectedAllow  string
+	}{
+		"not found":          {method: http.MethodGet, path: "/customers", expectedStatus: http.StatusNotFound},
+		"method not allowed": {method: http.MethodDelete, path: "/orders", expectedStatus: http.StatusMethodNotAllowed, expectedAllow: "GET, OPTIONS"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			rc := httptest.NewRecorder()
+			mux.ServeHTTP(rc, httptest.NewRequest(tt.method, tt.path, nil))
+			assert.Equal(t, tt.expectedStatus, rc.Code)
+			assert.Equal(t, problem.ContentType, rc.Header().Get("Content-Type"))
+			assert.Equal(t, tt.expectedAllow, rc.Header().Get("Allow"))
+			assert.Contains(t, rc.Body.String(), `"instance":"`+tt.path+`"`)
+		})
+	}
+}
+
+func TestNew_OpenAPI(t *testing.T) {
+	t.Parallel()
+	route, err := v2.NewGetRoute("/orders/:id", func(w http.ResponseWriter, r *http.Request) {})
+	require.NoError(t, err)
+	_, err = New(Routes(route), OpenAPI(openapi.Config{}))
+	assert.EqualError(t, err, "failed to create OpenAPI routes: title is empty")
+
+	mux, err := New(Routes(route), OpenAPI(openapi.Config{Info: openapi.Info{Title: "orders", Version: "1.0.0"}, UIPath: "/docs"}))
+	require.NoError(t, err)
+
+	rc := httptest.NewRecorder()
+	mux.ServeHTTP(rc, httptest.NewRequest(http.MethodGet, openapi.SpecPath, nil))
+	assert.Equal(t, http.StatusOK, rc.Code)
+	assert.Contains(t, rc.Body.String(), `"/orders/{id}":{"get":{"operationId":"get-orders-id"`)
+
+	rc = httptest.NewRecorder()
+	mux.ServeHTTP(rc, httptest.NewRequest(http.MethodGet, "/docs", nil))
+	assert.Equal(t, http.StatusOK, rc.Code)
+	assert.Contains(t, rc.Body.String(), "swagger-ui")
+}
+
+func TestNew_AsyncAPI(t *testing.T) {
+	t.Parallel()
+	channel := asyncapi.Channel{Name: "orders", Direction: asyncapi.Consume}
+	_, err := New(AsyncAPI(asyncapi.Config{Info: asyncapi.Info{Title: "orders", Version: "1.0.0"}}))
+	assert.EqualError(t, err, "channels are empty")
+	_, err = New(AsyncAPI(asyncapi.Config{}, channel))
+	assert.EqualError(t, err, "failed to create AsyncAPI routes: title is empty")
+
+	mux, err := New(AsyncAPI(asyncapi.Config{Info: asyncapi.Info{Title: "orders", Version: "1.0.0"}}, channel))
+	require.NoError(t, err)
+
+	rc := httptest.NewRecorder()
+	mux.ServeHTTP(rc, httptest.NewRequest(http.MethodGet, asyncapi.SpecPath, nil))
+	assert.Equal(t, http.StatusOK, rc.Code)
+	assert.Contains(t, rc.Body.String(), `"orders":{"publish":{"operationId":"consume-orders"}}`)
+}
+
+func TestNew_WebSocket(t *testing.T) {
+	t.Parallel()
+	route, err := v2.NewWebSocketRoute("/ws", func(_ *http.Request, c *v2.Conn) error {
+		return c.WriteMessage(v2.TextMessage, []byte("hello"))
+	}, v2.WebSocketConfig{})
+	require.NoError(t, err)
+	mux, err := New(Routes(route))
+	require.NoError(t, err)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	// the connection is upgraded through the standard middlewares
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/ws", http.Header{"Accept-Encoding": []string{"gzip"}})
+	require.NoError(t, err)
+	defer func() {
+		_ = conn.Close()
+	}()
+	_, data, err := conn.ReadMessage()
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(data))
+}
@@ -0,0 +1,192 @@
+package httprouter
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/beatlabs/patron/component/http/problem"
+	v2 "github.com/beatlabs/patron/component/http/v2"
+	"github.com/julienschmidt/httprouter"
+)
+
+const defaultStaticIndex = "index.html"
+
+// StaticConfig configures a static route.
+type StaticConfig struct {
+	// Index is the file served for the directories, e.g. the root of the route (default: index.html).
+	Index string
+	// SPA serves the index of the root for the requests of files which don't exist, e.g. the client-side routes of a single-page app.
+	SPA bool
+	// CacheControl is the value of the Cache-Control header of the files, which is not set if empty.
+	// The index is always served with no-cache, for the clients of single-page apps to pick up new releases.
+	CacheControl string
+}
+
+// NewStaticRoute returns a GET route serving the files of the file system, e.g. an embed.FS or an os.DirFS,
+// at the catch-all parameter of its path, e.g. /assets/*path.
+// The files are served with ETag and Last-Modified headers, answering conditional and range requests,
+// while paths escaping the root of the file system are not served.
+func NewStaticRoute(path string, fsys fs.FS, cfg StaticConfig, oo ...v2.RouteOptionFunc) (*v2.Route, error) {
+	if path == "" {
+		return nil, errors.New("path is empty")
+	}
+	if !strings.Contains(path, "/*") {
+		return nil, errors.New("path should end with a catch-all parameter")
+	}
+	if fsys == nil {
+		return nil, errors.New("file system is nil")
+	}
+	if cfg.Index == "" {
+		cfg.Index = defaultStaticIndex
+	}
+	if strings.Contains(cfg.Index, "/") {
+		return nil, errors.New("index should be a file name")
+	}
+	if cfg.SPA {
+		info, err := fs.Stat(fsys, cfg.Index)
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat index: %w", err)
+		}
+		if info.IsDir() {
+			return nil, fmt.Errorf("index %s is a directory", cfg.Index)
+		}
+	}
+
+	s := &static{fsys: fsys, cfg: cfg}
+	return v2.NewGetRoute(path, s.serve, oo...)
+}
+
+type static struct {
+	fsys  fs.FS
+	cfg   StaticConfig
+	etags sync.Map
+}
+
+type staticETag struct {
+	size    int64
+	modTime time.Time
+	value   string
+}
+
+func (s *static) serve(w http.ResponseWriter, r *http.Request) {
+	name, ok := staticName(r)
+	if !ok {
+		problem.Write(w, r, problem.New(http.StatusBadRequest, "invalid file path"))
+		return
+	}
+
+	err := s.serveFile(w, r, name)
+	if errors.Is(err, fs.ErrNotExist) && s.cfg.SPA && path.Ext(name) == "" {
+		err = s.serveFile(w, r, s.cfg.Index)
+	}
+	switch {
+	case err == nil:
+	case errors.Is(err, fs.ErrNotExist):
+		problem.Write(w, r, problem.New(http.StatusNotFound, ""))
+	case errors.Is(err, fs.ErrPermission):
+		problem.Write(w, r, problem.New(http.StatusForbidden, ""))
+	default:
+		problem.Write(w, r, fmt.Errorf("failed to serve file %s: %w", name, err))
+	}
+}
+
+// staticName returns the name of the requested file in the file system from the catch-all parameter of the route,
+// rejecting the paths with parent directory elements instead of cleaning them.
+func staticName(r *http.Request) (string, bool) {
+	params := httprouter.ParamsFromContext(r.Context())
+	if len(params) == 0 {
+		return ".", true
+	}
+	name := strings.Trim(params[len(params)-1].Value, "/")
+	if name == "" {
+		return ".", true
+	}
+	if strings.Contains(name, "\\") || strings.Contains(name, "\x00") {
+		return "", false
+	}
+	for _, elem := range strings.Split(name, "/") {
+		if elem == ".." {
+			return "", false
+		}
+	}
+	name = path.Clean(name)
+	return name, fs.ValidPath(name)
+}
+
+func (s *static) serveFile(w http.ResponseWriter, r *http.Request, name string) error {
+	info, err := fs.Stat(s.fsys, name)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		name = path.Join(name, s.cfg.Index)
+		info, err = fs.Stat(s.fsys, name)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return fs.ErrNotExist
+		}
+	}
+
+	f, err := s.fsys.Open(name)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	content, ok := f.(io.ReadSeeker)
+	if !ok {
+		data, err := ioutil.ReadAll(f)
+		if err != nil {
+			return err
+		}
+		content = bytes.NewReader(data)
+	}
+
+	etag, err := s.etag(name, info, content)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("ETag", etag)
+	if path.Base(name) == s.cfg.Index {
+		w.Header().Set("Cache-Control", "no-cache")
+	} else if s.cfg.CacheControl != "" {
+		w.Header().Set("Cache-Control", s.cfg.CacheControl)
+	}
+	http.ServeContent(w, r, name, info.ModTime(), content)
+	return nil
+}
+
+// etag returns the hash of the content of the file, which is cached until its size or its modification time changes.
+func (s *static) etag(name string, info fs.FileInfo, content io.ReadSeeker) (string, error) {
+	if v, ok := s.etags.Load(name); ok {
+		cached := v.(staticETag)
+		if cached.size == info.Size() && cached.modTime.Equal(info.ModTime()) {
+			return cached.value, nil
+		}
+	}
+
+	h := fnv.New64a()
+	if _, err := io.Copy(h, content); err != nil {
+		return "", err
+	}
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	value := fmt.Sprintf(`"%x-%x"`, info.Size(), h.Sum64())
+	s.etags.Store(name, staticETag{size: info.Size(), modTime: info.ModTime(), value: value})
+	return value, nil
+}
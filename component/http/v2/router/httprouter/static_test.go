@@ -0,0 +1,190 @@
+package httprouter
+
+import (
+	"fmt"
+	"io/fs"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/beatlabs/patron/component/http/problem"
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var staticModTime = time.Date(2021, 6, 1, 10, 0, 0, 0, time.UTC)
+
+func staticFS() fstest.MapFS {
+	return fstest.MapFS{
+		"index.html":      {Data: []byte("<html>app</html>"), ModTime: staticModTime},
+		"js/app.js":       {Data: []byte("console.log('app');"), ModTime: staticModTime},
+		"docs/index.html": {Data: []byte("<html>docs</html>"), ModTime: staticModTime},
+		"img/logo.svg":    {Data: []byte("<svg></svg>"), ModTime: staticModTime},
+	}
+}
+
+func TestNewStaticRoute(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		path        string
+		fsys        fs.FS
+		cfg         StaticConfig
+		expectedErr string
+	}{
+		"success":            {path: "/assets/*path", fsys: staticFS()},
+		"success spa":        {path: "/*path", fsys: staticFS(), cfg: StaticConfig{SPA: true}},
+		"missing path":       {fsys: staticFS(), expectedErr: "path is empty"},
+		"missing catch-all":  {path: "/assets", fsys: staticFS(), expectedErr: "path should end with a catch-all parameter"},
+		"missing fs":         {path: "/assets/*path", expectedErr: "file system is nil"},
+		"invalid index":      {path: "/*path", fsys: staticFS(), cfg: StaticConfig{Index: "docs/index.html"}, expectedErr: "index should be a file name"},
+		"missing spa index":  {path: "/*path", fsys: staticFS(), cfg: StaticConfig{SPA: true, Index: "app.html"}, expectedErr: "failed to stat index: open app.html: file does not exist"},
+		"spa index is a dir": {path: "/*path", fsys: staticFS(), cfg: StaticConfig{SPA: true, Index: "docs"}, expectedErr: "index docs is a directory"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			got, err := NewStaticRoute(tt.path, tt.fsys, tt.cfg)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.path, got.Path())
+			}
+		})
+	}
+}
+
+func newStaticServer(t *testing.T, cfg StaticConfig) *httprouter.Router {
+	route, err := NewStaticRoute("/app/*path", staticFS(), cfg)
+	require.NoError(t, err)
+	assert.Equal(t, http.MethodGet, route.Method())
+	mux := httprouter.New()
+	mux.Handler(route.Method(), route.Path(), route.Handler())
+	return mux
+}
+
+func TestStaticRoute(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		cfg                  StaticConfig
+		path                 string
+		expectedCode         int
+		expectedBody         string
+		expectedContentType  string
+		expectedCacheControl string
+	}{
+		"file": {
+			cfg: StaticConfig{CacheControl: "public, max-age=3600"}, path: "/app/js/app.js", expectedCode: http.StatusOK,
+			expectedBody: "console.log('app');", expectedCacheControl: "public, max-age=3600",
+		},
+		"root index": {
+			cfg: StaticConfig{CacheControl: "public, max-age=3600"}, path: "/app/", expectedCode: http.StatusOK,
+			expectedBody: "<html>app</html>", expectedContentType: "text/html; charset=utf-8", expectedCacheControl: "no-cache",
+		},
+		"directory index": {
+			path: "/app/docs", expectedCode: http.StatusOK, expectedBody: "<html>docs</html>",
+			expectedContentType: "text/html; charset=utf-8", expectedCacheControl: "no-cache",
+		},
+		"directory without index": {path: "/app/img/", expectedCode: http.StatusNotFound},
+		"not found":               {path: "/app/orders/1", expectedCode: http.StatusNotFound},
+		"spa fallback": {
+			cfg: StaticConfig{SPA: true}, path: "/app/orders/1", expectedCode: http.StatusOK, expectedBody: "<html>app</html>",
+			expectedContentType: "text/html; charset=utf-8", expectedCacheControl: "no-cache",
+		},
+		"spa missing asset": {cfg: StaticConfig{SPA: true}, path: "/app/js/missing.js", expectedCode: http.StatusNotFound},
+		"traversal":         {cfg: StaticConfig{SPA: true}, path: "/app/js/..%2F..%2Fetc/passwd", expectedCode: http.StatusBadRequest},
+		"backslash":         {path: "/app/js\\app.js", expectedCode: http.StatusBadRequest},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			mux := newStaticServer(t, tt.cfg)
+			rsp := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			mux.ServeHTTP(rsp, req)
+
+			assert.Equal(t, tt.expectedCode, rsp.Code)
+			if tt.expectedCode != http.StatusOK {
+				assert.Equal(t, problem.ContentType, rsp.Header().Get("Content-Type"))
+				assert.Contains(t, rsp.Body.String(), fmt.Sprintf(`"status":%d`, tt.expectedCode))
+				return
+			}
+			assert.Equal(t, tt.expectedBody, rsp.Body.String())
+			if tt.expectedContentType != "" {
+				assert.Equal(t, tt.expectedContentType, rsp.Header().Get("Content-Type"))
+			}
+			assert.Equal(t, tt.expectedCacheControl, rsp.Header().Get("Cache-Control"))
+			assert.Equal(t, "Tue, 01 Jun 2021 10:00:00 GMT", rsp.Header().Get("Last-Modified"))
+			assert.NotEmpty(t, rsp.Header().Get("ETag"))
+		})
+	}
+}
+
+func TestStaticRoute_Conditional(t *testing.T) {
+	t.Parallel()
+	mux := newStaticServer(t, StaticConfig{})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	rsp, err := http.Get(srv.URL + "/app/js/app.js")
+	require.NoError(t, err)
+	require.NoError(t, rsp.Body.Close())
+	etag := rsp.Header.Get("ETag")
+	assert.Regexp(t, `^"13-[0-9a-f]+"$`, etag)
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL+"/app/js/app.js", nil)
+	require.NoError(t, err)
+	req.Header.Set("If-None-Match", etag)
+	rsp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	require.NoError(t, rsp.Body.Close())
+	assert.Equal(t, http.StatusNotModified, rsp.StatusCode)
+
+	req, err = http.NewRequest(http.MethodGet, srv.URL+"/app/js/app.js", nil)
+	require.NoError(t, err)
+	req.Header.Set("If-Modified-Since", staticModTime.Format(http.TimeFormat))
+	rsp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	require.NoError(t, rsp.Body.Close())
+	assert.Equal(t, http.StatusNotModified, rsp.StatusCode)
+
+	req, err = http.NewRequest(http.MethodGet, srv.URL+"/app/js/app.js", nil)
+	require.NoError(t, err)
+	req.Header.Set("If-None-Match", `"other"`)
+	rsp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	require.NoError(t, rsp.Body.Close())
+	assert.Equal(t, http.StatusOK, rsp.StatusCode)
+}
+
+func TestStaticRoute_Range(t *testing.T) {
+	t.Parallel()
+	mux := newStaticServer(t, StaticConfig{})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL+"/app/js/app.js", nil)
+	require.NoError(t, err)
+	req.Header.Set("Range", "bytes=0-10")
+	rsp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	body, err := ioutil.ReadAll(rsp.Body)
+	require.NoError(t, err)
+	require.NoError(t, rsp.Body.Close())
+	assert.Equal(t, http.StatusPartialContent, rsp.StatusCode)
+	assert.Equal(t, "bytes 0-10/19", rsp.Header.Get("Content-Range"))
+	assert.Equal(t, "console.log", string(body))
+
+	req.Header.Set("Range", "bytes=100-200")
+	rsp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	require.NoError(t, rsp.Body.Close())
+	assert.Equal(t, http.StatusRequestedRangeNotSatisfiable, rsp.StatusCode)
+}
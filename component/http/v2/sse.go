@@ -0,0 +1,257 @@
+package v2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/beatlabs/patron/component/http/problem"
+	"github.com/beatlabs/patron/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	eventStreamContentType = "text/event-stream"
+	lastEventIDHeader      = "Last-Event-ID"
+	sseHeartbeat           = 15 * time.Second
+)
+
+var (
+	sseStreamsMetric        *prometheus.GaugeVec
+	sseEventsMetric         *prometheus.CounterVec
+	sseStreamDurationMetric *prometheus.HistogramVec
+)
+
+func init() {
+	sseStreamsMetric = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "component",
+			Subsystem: "http",
+			Name:      "sse_streams",
+			Help:      "Number of server-sent event streams open per route.",
+		},
+		[]string{"path"},
+	)
+	sseEventsMetric = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "component",
+			Subsystem: "http",
+			Name:      "sse_events_total",
+			Help:      "Server-sent events sent per route.",
+		},
+		[]string{"path"},
+	)
+	sseStreamDurationMetric = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "component",
+			Subsystem: "http",
+			Name:      "sse_stream_duration_seconds",
+			Help:      "Duration of the server-sent event streams per route.",
+			Buckets:   []float64{1, 10, 60, 300, 900, 1800, 3600},
+		},
+		[]string{"path"},
+	)
+	prometheus.MustRegister(sseStreamsMetric, sseEventsMetric, sseStreamDurationMetric)
+}
+
+// Event is a server-sent event, or an event of a long poll.
+type Event struct {
+	// ID of the event, which the client sends back in the Last-Event-ID header when reconnecting.
+	ID string `json:"id,omitempty"`
+	// Name of the event, which defaults to message in the client.
+	Name string `json:"name,omitempty"`
+	// Data of the event, which may span multiple lines.
+	Data string `json:"data"`
+}
+
+// SSEConfig configures a server-sent events route.
+type SSEConfig struct {
+	// Heartbeat is the interval of the comments keeping idle streams alive through proxies and detecting disconnected clients (default: 15s).
+	Heartbeat time.Duration
+	// Retry is the reconnection delay sent to the clients, which they use after the stream ends. Zero leaves it to the clients.
+	Retry time.Duration
+}
+
+// SSEHandlerFunc streams server-sent events to a client, until the context of the stream is done or the handler returns.
+type SSEHandlerFunc func(r *http.Request, s *Stream) error
+
+// Stream sends server-sent events to a client, flushing every one of them.
+// It is safe for concurrent use.
+type Stream struct {
+	ctx     context.Context
+	cancel  context.CancelFunc
+	mu      sync.Mutex
+	w       http.ResponseWriter
+	flusher http.Flusher
+	path    string
+	err     error
+}
+
+// Context returns the context of the stream, which is done once the client disconnects, a write fails or the component shuts down.
+func (s *Stream) Context() context.Context {
+	return s.ctx
+}
+
+// Send sends the event to the client.
+func (s *Stream) Send(e Event) error {
+	if strings.ContainsAny(e.ID, "\r\n") {
+		return errors.New("event id contains a line break")
+	}
+	if strings.ContainsAny(e.Name, "\r\n") {
+		return errors.New("event name contains a line break")
+	}
+
+	var b strings.Builder
+	if e.ID != "" {
+		b.WriteString("id: " + e.ID + "\n")
+	}
+	if e.Name != "" {
+		b.WriteString("event: " + e.Name + "\n")
+	}
+	for _, line := range strings.Split(strings.ReplaceAll(e.Data, "\r\n", "\n"), "\n") {
+		b.WriteString("data: " + line + "\n")
+	}
+	b.WriteString("\n")
+
+	if err := s.write(b.String()); err != nil {
+		return err
+	}
+	sseEventsMetric.WithLabelValues(s.path).Inc()
+	return nil
+}
+
+func (s *Stream) write(data string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	if err := s.ctx.Err(); err != nil {
+		return err
+	}
+	if _, err := s.w.Write([]byte(data)); err != nil {
+		s.err = fmt.Errorf("failed to write to stream: %w", err)
+		s.cancel()
+		return s.err
+	}
+	s.flusher.Flush()
+	return nil
+}
+
+func (s *Stream) heartbeat(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.write(":\n\n"); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// NewSSERoute creates a GET route streaming server-sent events to the clients with the handler.
+// The handler runs until it returns or the context of the stream is done. Since streams are long-lived,
+// they are not limited by the handler timeout of the component, but they are by its write timeout,
+// after which the clients reconnect, resuming from the Last-Event-ID of their request, if the handler supports it.
+func NewSSERoute(path string, handler SSEHandlerFunc, cfg SSEConfig, oo ...RouteOptionFunc) (*Route, error) {
+	if handler == nil {
+		return nil, errors.New("handler is nil")
+	}
+	if cfg.Heartbeat < 0 {
+		return nil, errors.New("negative heartbeat provided")
+	}
+	if cfg.Retry < 0 {
+		return nil, errors.New("negative retry provided")
+	}
+	if cfg.Heartbeat == 0 {
+		cfg.Heartbeat = sseHeartbeat
+	}
+
+	return NewGetRoute(path, sseHandler(path, handler, cfg), oo...)
+}
+
+func sseHandler(path string, handler SSEHandlerFunc, cfg SSEConfig) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			problem.Write(w, r, problem.New(http.StatusInternalServerError, "streaming is not supported"))
+			return
+		}
+
+		ctx, cancel := context.WithCancel(r.Context())
+		defer cancel()
+		if shutdown, ok := shutdownFromContext(r.Context()); ok {
+			go func() {
+				select {
+				case <-shutdown:
+					cancel()
+				case <-ctx.Done():
+				}
+			}()
+		}
+
+		w.Header().Set("Content-Type", eventStreamContentType)
+		w.Header().Set("Cache-Control", "no-cache")
+		// disables the buffering of proxies e.g. nginx
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+
+		s := &Stream{ctx: ctx, cancel: cancel, w: w, flusher: flusher, path: path}
+		preamble := ":\n\n"
+		if cfg.Retry > 0 {
+			preamble = "retry: " + strconv.FormatInt(cfg.Retry.Milliseconds(), 10) + "\n\n"
+		}
+		if err := s.write(preamble); err != nil {
+			log.FromContext(r.Context()).Debugf("failed to open event stream: %v", err)
+			return
+		}
+
+		start := time.Now()
+		sseStreamsMetric.WithLabelValues(path).Inc()
+		defer func() {
+			sseStreamsMetric.WithLabelValues(path).Dec()
+			sseStreamDurationMetric.WithLabelValues(path).Observe(time.Since(start).Seconds())
+		}()
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.heartbeat(cfg.Heartbeat)
+		}()
+
+		err := handler(r, s)
+		cancel()
+		wg.Wait()
+		if err != nil && !errors.Is(err, context.Canceled) {
+			log.FromContext(r.Context()).Errorf("event stream of %s failed: %v", path, err)
+		}
+	}
+}
+
+// LastEventID returns the ID of the last event received by a reconnecting client, if any.
+func LastEventID(r *http.Request) string {
+	return r.Header.Get(lastEventIDHeader)
+}
+
+type shutdownContextKey struct{}
+
+// shutdownFromContext returns the channel closed when the component shuts down, if the request is long-lived.
+func shutdownFromContext(ctx context.Context) (<-chan struct{}, bool) {
+	shutdown, ok := ctx.Value(shutdownContextKey{}).(<-chan struct{})
+	return shutdown, ok
+}
+
+// acceptsEventStream reports whether the request is for a stream of server-sent events, e.g. of an EventSource.
+func acceptsEventStream(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), eventStreamContentType)
+}
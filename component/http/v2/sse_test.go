@@ -0,0 +1,228 @@
+package v2
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSSERoute(t *testing.T) {
+	t.Parallel()
+	handler := func(_ *http.Request, _ *Stream) error { return nil }
+	tests := map[string]struct {
+		path        string
+		handler     SSEHandlerFunc
+		cfg         SSEConfig
+		expectedErr string
+	}{
+		"success":            {path: "/events", handler: handler, cfg: SSEConfig{Heartbeat: time.Second, Retry: time.Second}},
+		"missing handler":    {path: "/events", expectedErr: "handler is nil"},
+		"missing path":       {handler: handler, expectedErr: "path is empty"},
+		"negative heartbeat": {path: "/events", handler: handler, cfg: SSEConfig{Heartbeat: -time.Second}, expectedErr: "negative heartbeat provided"},
+		"negative retry":     {path: "/events", handler: handler, cfg: SSEConfig{Retry: -time.Second}, expectedErr: "negative retry provided"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			got, err := NewSSERoute(tt.path, tt.handler, tt.cfg)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, http.MethodGet, got.Method())
+				assert.Equal(t, tt.path, got.Path())
+			}
+		})
+	}
+}
+
+func TestStream_Send(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		events      []Event
+		expected    string
+		expectedErr string
+	}{
+		"data": {events: []Event{{Data: "hello"}}, expected: "retry: 3000\n\ndata: hello\n\n"},
+		"full": {
+			events:   []Event{{ID: "1", Name: "order", Data: "{\"id\":1}"}, {ID: "2", Data: "line 1\r\nline 2"}},
+			expected: "retry: 3000\n\nid: 1\nevent: order\ndata: {\"id\":1}\n\nid: 2\ndata: line 1\ndata: line 2\n\n",
+		},
+		"invalid id":   {events: []Event{{ID: "1\n2", Data: "hello"}}, expected: "retry: 3000\n\n", expectedErr: "event id contains a line break"},
+		"invalid name": {events: []Event{{Name: "a\rb", Data: "hello"}}, expected: "retry: 3000\n\n", expectedErr: "event name contains a line break"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			var sendErr error
+			route, err := NewSSERoute("/events", func(_ *http.Request, s *Stream) error {
+				for _, e := range tt.events {
+					if sendErr = s.Send(e); sendErr != nil {
+						return sendErr
+					}
+				}
+				return nil
+			}, SSEConfig{Retry: 3 * time.Second})
+			require.NoError(t, err)
+
+			rec := httptest.NewRecorder()
+			route.Handler()(rec, httptest.NewRequest(http.MethodGet, "/events", nil))
+			if tt.expectedErr != "" {
+				assert.EqualError(t, sendErr, tt.expectedErr)
+			} else {
+				assert.NoError(t, sendErr)
+			}
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, eventStreamContentType, rec.Header().Get("Content-Type"))
+			assert.Equal(t, "no-cache", rec.Header().Get("Cache-Control"))
+			assert.True(t, rec.Flushed)
+			assert.Equal(t, tt.expected, rec.Body.String())
+		})
+	}
+}
+
+type nonFlushingWriter struct {
+	http.ResponseWriter
+}
+
+func TestSSEHandler_StreamingNotSupported(t *testing.T) {
+	t.Parallel()
+	route, err := NewSSERoute("/events", func(_ *http.Request, _ *Stream) error { return nil }, SSEConfig{})
+	require.NoError(t, err)
+	rec := httptest.NewRecorder()
+	route.Handler()(nonFlushingWriter{ResponseWriter: rec}, httptest.NewRequest(http.MethodGet, "/events", nil))
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+}
+
+func TestLastEventID(t *testing.T) {
+	t.Parallel()
+	req := httptest.NewRequest(http.MethodGet, "/events", nil)
+	assert.Empty(t, LastEventID(req))
+	req.Header.Set(lastEventIDHeader, "42")
+	assert.Equal(t, "42", LastEventID(req))
+}
+
+func TestComponent_SSE(t *testing.T) {
+	port := freePort(t)
+	disconnected := make(chan error, 1)
+	route, err := NewSSERoute("/events", func(r *http.Request, s *Stream) error {
+		if err := s.Send(Event{ID: "1", Data: "resumed after " + LastEventID(r)}); err != nil {
+			return err
+		}
+		<-s.Context().Done()
+		disconnected <- s.Context().Err()
+		return nil
+	}, SSEConfig{Heartbeat: 20 * time.Millisecond})
+	require.NoError(t, err)
+
+	// the stream outlives the handler timeout of the component
+	cmp, err := New(route.Handler(), Port(port), HandlerTimeout(50*time.Millisecond))
+	require.NoError(t, err)
+	done := make(chan error)
+	ctx, cnl := context.WithCancel(context.Background())
+	go func() {
+		done <- cmp.Run(ctx)
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	reqCtx, reqCnl := context.WithCancel(context.Background())
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodGet, fmt.Sprintf("http://localhost:%d/events", port), nil)
+	require.NoError(t, err)
+	req.Header.Set("Accept", eventStreamContentType)
+	req.Header.Set(lastEventIDHeader, "0")
+	rsp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	assert.Equal(t, eventStreamContentType, rsp.Header.Get("Content-Type"))
+
+	lines := make(chan string)
+	go func() {
+		scanner := bufio.NewScanner(rsp.Body)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		close(lines)
+	}()
+	assert.Equal(t, ":", <-lines)
+	assert.Equal(t, "", <-lines)
+	assert.Equal(t, "id: 1", <-lines)
+	assert.Equal(t, "data: resumed after 0", <-lines)
+	assert.Equal(t, "", <-lines)
+
+	heartbeats := 0
+	timeout := time.After(200 * time.Millisecond)
+	for heartbeats < 3 {
+		select {
+		case line := <-lines:
+			if line == ":" {
+				heartbeats++
+			}
+		case <-timeout:
+			require.FailNow(t, "missing heartbeats")
+		}
+	}
+
+	reqCnl()
+	for range lines {
+	}
+	_ = rsp.Body.Close()
+	select {
+	case err := <-disconnected:
+		assert.True(t, errors.Is(err, context.Canceled))
+	case <-time.After(time.Second):
+		require.FailNow(t, "client disconnection was not detected")
+	}
+
+	cnl()
+	assert.NoError(t, <-done)
+}
+
+func TestComponent_SSE_Shutdown(t *testing.T) {
+	port := freePort(t)
+	route, err := NewSSERoute("/events", func(_ *http.Request, s *Stream) error {
+		<-s.Context().Done()
+		return nil
+	}, SSEConfig{})
+	require.NoError(t, err)
+	cmp, err := New(route.Handler(), Port(port), ShutdownGracePeriod(time.Second))
+	require.NoError(t, err)
+	done := make(chan error)
+	ctx, cnl := context.WithCancel(context.Background())
+	go func() {
+		done <- cmp.Run(ctx)
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("http://localhost:%d/events", port), nil)
+	require.NoError(t, err)
+	req.Header.Set("Accept", eventStreamContentType)
+	rsp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer func() {
+		_ = rsp.Body.Close()
+	}()
+	assert.Equal(t, int64(1), cmp.InFlight())
+
+	cnl()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(500 * time.Millisecond):
+		require.FailNow(t, "event stream blocked the shutdown")
+	}
+	// the stream ended, instead of being cut off
+	body, err := ioutil.ReadAll(rsp.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, ":\n\n", string(body))
+}
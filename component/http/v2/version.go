@@ -0,0 +1,230 @@
+package v2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/beatlabs/patron/component/http/problem"
+)
+
+const defaultVersionHeader = "Api-Version"
+
+// VersionScheme defines how the version of the API is selected by the requests.
+type VersionScheme int
+
+const (
+	// PathVersioning selects the version by the prefix of the path, e.g. /v1/orders.
+	PathVersioning VersionScheme = iota
+	// AcceptVersioning selects the version by the Accept header, either by the version parameter of the media type,
+	// e.g. application/json; version=v1, or by the vendor media type, e.g. application/vnd.acme.v1+json.
+	AcceptVersioning
+	// HeaderVersioning selects the version by a custom header, e.g. Api-Version: v1.
+	HeaderVersioning
+)
+
+// APIVersion definition of a version of the API.
+type APIVersion struct {
+	// Name of the version, e.g. v1, which prefixes the paths of the PathVersioning scheme.
+	Name string
+	// Deprecation is the time the version is (or will be) deprecated at, emitted in the Deprecation header, if set.
+	Deprecation time.Time
+	// Sunset is the time the version will stop being served at, emitted in the Sunset header, if set.
+	Sunset time.Time
+	// Link to the documentation of the deprecation, e.g. a migration guide, emitted in the Link header of the deprecated version.
+	Link string
+}
+
+func (v APIVersion) deprecated() bool {
+	return !v.Deprecation.IsZero() || !v.Sunset.IsZero()
+}
+
+// VersionConfig definition of the versioning of the API.
+type VersionConfig struct {
+	Scheme VersionScheme
+	// Header selecting the version of the HeaderVersioning scheme (default: Api-Version).
+	Header string
+	// Vendor of the vendor media types of the AcceptVersioning scheme, e.g. acme for application/vnd.acme.v1+json.
+	Vendor string
+	// Default version served to the requests which do not select one, in the AcceptVersioning and HeaderVersioning schemes.
+	// It defaults to the last of the versions.
+	Default string
+}
+
+// Versioning registers the same logical routes across the versions of the API.
+type Versioning struct {
+	cfg      VersionConfig
+	versions map[string]APIVersion
+	names    []string
+}
+
+type versionKey struct{}
+
+// NewVersioning creates the versioning of the API with the provided versions, in ascending order.
+func NewVersioning(cfg VersionConfig, vv ...APIVersion) (*Versioning, error) {
+	if cfg.Scheme < PathVersioning || cfg.Scheme > HeaderVersioning {
+		return nil, errors.New("invalid version scheme")
+	}
+	if len(vv) == 0 {
+		return nil, errors.New("versions are empty")
+	}
+	if cfg.Header == "" {
+		cfg.Header = defaultVersionHeader
+	}
+
+	v := &Versioning{cfg: cfg, versions: make(map[string]APIVersion, len(vv))}
+	for _, version := range vv {
+		if version.Name == "" {
+			return nil, errors.New("version name is empty")
+		}
+		if strings.ContainsAny(version.Name, "/:*") {
+			return nil, fmt.Errorf("version name %s should not contain path separators or parameters", version.Name)
+		}
+		if _, ok := v.versions[version.Name]; ok {
+			return nil, fmt.Errorf("version %s is duplicated", version.Name)
+		}
+		v.versions[version.Name] = version
+		v.names = append(v.names, version.Name)
+	}
+
+	if v.cfg.Default == "" {
+		v.cfg.Default = v.names[len(v.names)-1]
+	}
+	if _, ok := v.versions[v.cfg.Default]; !ok {
+		return nil, fmt.Errorf("default version %s is not defined", v.cfg.Default)
+	}
+	return v, nil
+}
+
+// Route creates the routes of the handlers of the versions, keyed by name, which are not required to cover every version.
+// The PathVersioning scheme creates a route per version, whose path is prefixed by the name of the version, while the rest of the schemes
+// create a single route, which dispatches the requests to the handler of their version. The requests selecting a version which is not defined
+// are rejected with a 400 Bad Request problem, or a 406 Not Acceptable one in the AcceptVersioning scheme, and the requests of a version
+// without a handler with a 404 Not Found one. The responses of deprecated versions carry the Deprecation, Sunset and Link headers.
+func (v *Versioning) Route(method, path string, handlers map[string]http.HandlerFunc, oo ...RouteOptionFunc) ([]*Route, error) {
+	if len(handlers) == 0 {
+		return nil, errors.New("handlers are empty")
+	}
+	for name, handler := range handlers {
+		if _, ok := v.versions[name]; !ok {
+			return nil, fmt.Errorf("version %s is not defined", name)
+		}
+		if handler == nil {
+			return nil, fmt.Errorf("handler of version %s is nil", name)
+		}
+	}
+
+	if v.cfg.Scheme != PathVersioning {
+		route, err := NewRoute(method, path, v.dispatch(handlers), oo...)
+		if err != nil {
+			return nil, err
+		}
+		return []*Route{route}, nil
+	}
+
+	routes := make([]*Route, 0, len(handlers))
+	for _, name := range v.names {
+		handler, ok := handlers[name]
+		if !ok {
+			continue
+		}
+		route, err := NewRoute(method, "/"+name+path, versionHandler(v.versions[name], handler), oo...)
+		if err != nil {
+			return nil, err
+		}
+		route.doc.Deprecated = route.doc.Deprecated || v.versions[name].deprecated()
+		routes = append(routes, route)
+	}
+	return routes, nil
+}
+
+func (v *Versioning) dispatch(handlers map[string]http.HandlerFunc) http.HandlerFunc {
+	vary := v.cfg.Header
+	if v.cfg.Scheme == AcceptVersioning {
+		vary = "Accept"
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", vary)
+
+		name := v.requestVersion(r)
+		if name == "" {
+			name = v.cfg.Default
+		}
+		version, ok := v.versions[name]
+		if !ok {
+			status := http.StatusBadRequest
+			if v.cfg.Scheme == AcceptVersioning {
+				status = http.StatusNotAcceptable
+			}
+			problem.Write(w, r, problem.New(status, fmt.Sprintf("version %s is not supported", name)))
+			return
+		}
+		handler, ok := handlers[name]
+		if !ok {
+			problem.Write(w, r, problem.New(http.StatusNotFound, fmt.Sprintf("resource is not available in version %s", name)))
+			return
+		}
+		if v.cfg.Scheme == HeaderVersioning {
+			w.Header().Set(v.cfg.Header, name)
+		}
+		versionHandler(version, handler)(w, r)
+	}
+}
+
+// requestVersion returns the version selected by the request, if any.
+func (v *Versioning) requestVersion(r *http.Request) string {
+	if v.cfg.Scheme == HeaderVersioning {
+		return strings.TrimSpace(r.Header.Get(v.cfg.Header))
+	}
+
+	vendorPrefix := "vnd." + v.cfg.Vendor + "."
+	for _, accept := range r.Header.Values("Accept") {
+		for _, mediaRange := range strings.Split(accept, ",") {
+			mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(mediaRange))
+			if err != nil {
+				continue
+			}
+			if version, ok := params["version"]; ok {
+				return version
+			}
+			i := strings.IndexByte(mediaType, '/')
+			if v.cfg.Vendor == "" || i < 0 || !strings.HasPrefix(mediaType[i+1:], vendorPrefix) {
+				continue
+			}
+			version := strings.TrimPrefix(mediaType[i+1:], vendorPrefix)
+			if j := strings.IndexByte(version, '+'); j >= 0 {
+				version = version[:j]
+			}
+			return version
+		}
+	}
+	return ""
+}
+
+// versionHandler adds the version to the context of the request, and the deprecation headers of the version to its response.
+func versionHandler(version APIVersion, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if version.deprecated() {
+			if !version.Deprecation.IsZero() {
+				w.Header().Set("Deprecation", fmt.Sprintf("@%d", version.Deprecation.Unix()))
+			}
+			if !version.Sunset.IsZero() {
+				w.Header().Set("Sunset", version.Sunset.UTC().Format(http.TimeFormat))
+			}
+			if version.Link != "" {
+				w.Header().Add("Link", fmt.Sprintf("<%s>; rel=\"deprecation\"", version.Link))
+			}
+		}
+		handler(w, r.WithContext(context.WithValue(r.Context(), versionKey{}, version.Name)))
+	}
+}
+
+// VersionFromContext returns the name of the version of the request, which is served by a versioned route.
+func VersionFromContext(ctx context.Context) (string, bool) {
+	name, ok := ctx.Value(versionKey{}).(string)
+	return name, ok
+}
@@ -0,0 +1,202 @@
+package v2
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/beatlabs/patron/component/http/problem"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	deprecation = time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	sunset      = time.Date(2021, 12, 1, 0, 0, 0, 0, time.UTC)
+)
+
+func testVersions() []APIVersion {
+	return []APIVersion{
+		{Name: "v1", Deprecation: deprecation, Sunset: sunset, Link: "https://example.com/migration"},
+		{Name: "v2"},
+		{Name: "v3"},
+	}
+}
+
+func versionedHandler(w http.ResponseWriter, r *http.Request) {
+	version, _ := VersionFromContext(r.Context())
+	_, _ = fmt.Fprint(w, version)
+}
+
+func TestNewVersioning(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		cfg         VersionConfig
+		versions    []APIVersion
+		expectedErr string
+	}{
+		"success":           {versions: testVersions()},
+		"invalid scheme":    {cfg: VersionConfig{Scheme: 3}, versions: testVersions(), expectedErr: "invalid version scheme"},
+		"empty versions":    {expectedErr: "versions are empty"},
+		"empty name":        {versions: []APIVersion{{}}, expectedErr: "version name is empty"},
+		"invalid name":      {versions: []APIVersion{{Name: "v1/beta"}}, expectedErr: "version name v1/beta should not contain path separators or parameters"},
+		"duplicate version": {versions: []APIVersion{{Name: "v1"}, {Name: "v1"}}, expectedErr: "version v1 is duplicated"},
+		"undefined default": {cfg: VersionConfig{Default: "v4"}, versions: testVersions(), expectedErr: "default version v4 is not defined"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			got, err := NewVersioning(tt.cfg, tt.versions...)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, "v3", got.cfg.Default)
+				assert.Equal(t, defaultVersionHeader, got.cfg.Header)
+			}
+		})
+	}
+}
+
+func TestVersioning_Route(t *testing.T) {
+	t.Parallel()
+	versioning, err := NewVersioning(VersionConfig{}, testVersions()...)
+	require.NoError(t, err)
+	tests := map[string]struct {
+		handlers    map[string]http.HandlerFunc
+		expectedErr string
+	}{
+		"empty handlers":    {expectedErr: "handlers are empty"},
+		"undefined version": {handlers: map[string]http.HandlerFunc{"v4": versionedHandler}, expectedErr: "version v4 is not defined"},
+		"nil handler":       {handlers: map[string]http.HandlerFunc{"v1": nil}, expectedErr: "handler of version v1 is nil"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			got, err := versioning.Route(http.MethodGet, "/orders", tt.handlers)
+			assert.EqualError(t, err, tt.expectedErr)
+			assert.Nil(t, got)
+		})
+	}
+}
+
+func TestVersioning_Route_Path(t *testing.T) {
+	t.Parallel()
+	versioning, err := NewVersioning(VersionConfig{}, testVersions()...)
+	require.NoError(t, err)
+	routes, err := versioning.Route(http.MethodGet, "/orders", map[string]http.HandlerFunc{
+		"v3": versionedHandler,
+		"v1": versionedHandler,
+	}, RateLimiting(1, 1))
+	require.NoError(t, err)
+	require.Len(t, routes, 2)
+	assert.Equal(t, "/v1/orders", routes[0].Path())
+	assert.Equal(t, "/v3/orders", routes[1].Path())
+	assert.Len(t, routes[0].Middlewares(), 1)
+
+	rc := httptest.NewRecorder()
+	routes[0].Handler()(rc, httptest.NewRequest(http.MethodGet, "/v1/orders", nil))
+	assert.Equal(t, "v1", rc.Body.String())
+	assert.Equal(t, "@1622505600", rc.Header().Get("Deprecation"))
+	assert.Equal(t, "Wed, 01 Dec 2021 00:00:00 GMT", rc.Header().Get("Sunset"))
+	assert.Equal(t, `<https://example.com/migration>; rel="deprecation"`, rc.Header().Get("Link"))
+
+	rc = httptest.NewRecorder()
+	routes[1].Handler()(rc, httptest.NewRequest(http.MethodGet, "/v3/orders", nil))
+	assert.Equal(t, "v3", rc.Body.String())
+	assert.Empty(t, rc.Header().Get("Deprecation"))
+	assert.Empty(t, rc.Header().Get("Sunset"))
+}
+
+func TestVersioning_Route_Header(t *testing.T) {
+	t.Parallel()
+	versioning, err := NewVersioning(VersionConfig{Scheme: HeaderVersioning, Header: "X-Api-Version", Default: "v2"}, testVersions()...)
+	require.NoError(t, err)
+	routes, err := versioning.Route(http.MethodGet, "/orders", map[string]http.HandlerFunc{
+		"v1": versionedHandler,
+		"v2": versionedHandler,
+	})
+	require.NoError(t, err)
+	require.Len(t, routes, 1)
+	assert.Equal(t, "/orders", routes[0].Path())
+
+	tests := map[string]struct {
+		version             string
+		expectedStatus      int
+		expectedBody        string
+		expectedDeprecation string
+	}{
+		"deprecated version": {version: "v1", expectedStatus: http.StatusOK, expectedBody: "v1", expectedDeprecation: "@1622505600"},
+		"default version":    {expectedStatus: http.StatusOK, expectedBody: "v2"},
+		"unsupported":        {version: "v9", expectedStatus: http.StatusBadRequest},
+		"missing handler":    {version: "v3", expectedStatus: http.StatusNotFound},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+			if tt.version != "" {
+				req.Header.Set("X-Api-Version", tt.version)
+			}
+			rc := httptest.NewRecorder()
+			routes[0].Handler()(rc, req)
+			assert.Equal(t, tt.expectedStatus, rc.Code)
+			assert.Equal(t, "X-Api-Version", rc.Header().Get("Vary"))
+			if tt.expectedStatus != http.StatusOK {
+				assert.Equal(t, problem.ContentType, rc.Header().Get("Content-Type"))
+				return
+			}
+			assert.Equal(t, tt.expectedBody, rc.Body.String())
+			assert.Equal(t, tt.expectedBody, rc.Header().Get("X-Api-Version"))
+			assert.Equal(t, tt.expectedDeprecation, rc.Header().Get("Deprecation"))
+		})
+	}
+}
+
+func TestVersioning_Route_Accept(t *testing.T) {
+	t.Parallel()
+	versioning, err := NewVersioning(VersionConfig{Scheme: AcceptVersioning, Vendor: "acme"}, testVersions()...)
+	require.NoError(t, err)
+	routes, err := versioning.Route(http.MethodGet, "/orders", map[string]http.HandlerFunc{
+		"v1": versionedHandler,
+		"v3": versionedHandler,
+	})
+	require.NoError(t, err)
+	require.Len(t, routes, 1)
+
+	tests := map[string]struct {
+		accept         string
+		expectedStatus int
+		expectedBody   string
+	}{
+		"vendor media type": {accept: "application/vnd.acme.v1+json", expectedStatus: http.StatusOK, expectedBody: "v1"},
+		"version parameter": {accept: "text/html, application/json; version=v1", expectedStatus: http.StatusOK, expectedBody: "v1"},
+		"other vendor":      {accept: "application/vnd.other.v1+json", expectedStatus: http.StatusOK, expectedBody: "v3"},
+		"no version":        {accept: "application/json", expectedStatus: http.StatusOK, expectedBody: "v3"},
+		"no accept":         {expectedStatus: http.StatusOK, expectedBody: "v3"},
+		"unsupported":       {accept: "application/vnd.acme.v9+json", expectedStatus: http.StatusNotAcceptable},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			rc := httptest.NewRecorder()
+			routes[0].Handler()(rc, req)
+			assert.Equal(t, tt.expectedStatus, rc.Code)
+			assert.Equal(t, "Accept", rc.Header().Get("Vary"))
+			if tt.expectedStatus == http.StatusOK {
+				assert.Equal(t, tt.expectedBody, rc.Body.String())
+			}
+		})
+	}
+}
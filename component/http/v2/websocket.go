@@ -0,0 +1,251 @@
+package v2
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/beatlabs/patron/correlation"
+	"github.com/beatlabs/patron/log"
+	"github.com/gorilla/websocket"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// TextMessage is the type of the WebSocket messages of UTF-8 encoded text.
+	TextMessage = websocket.TextMessage
+	// BinaryMessage is the type of the WebSocket messages of binary data.
+	BinaryMessage = websocket.BinaryMessage
+
+	wsReadTimeout  = 60 * time.Second
+	wsWriteTimeout = 10 * time.Second
+	wsReadLimit    = 1 << 20
+)
+
+var (
+	wsConnectionsMetric *prometheus.GaugeVec
+	wsMessagesMetric    *prometheus.CounterVec
+)
+
+func init() {
+	wsConnectionsMetric = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "component",
+			Subsystem: "http",
+			Name:      "websocket_connections",
+			Help:      "Number of WebSocket connections open per route.",
+		},
+		[]string{"path"},
+	)
+	wsMessagesMetric = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "component",
+			Subsystem: "http",
+			Name:      "websocket_messages_total",
+			Help:      "WebSocket messages per route, classified by direction, i.e. received or sent.",
+		},
+		[]string{"path", "direction"},
+	)
+	prometheus.MustRegister(wsConnectionsMetric, wsMessagesMetric)
+}
+
+// WebSocketConfig configures a WebSocket route.
+type WebSocketConfig struct {
+	// ReadTimeout closes the connections which receive neither a message nor a pong within it (default: 60s).
+	ReadTimeout time.Duration
+	// WriteTimeout fails the writes which do not complete within it (default: 10s).
+	WriteTimeout time.Duration
+	// PingInterval is the interval of the pings keeping the connections alive, which should be shorter than the read timeout
+	// (default: 9/10 of the read timeout).
+	PingInterval time.Duration
+	// ReadLimit is the maximum size of the received messages in bytes, which close the connection when exceeded (default: 1MB).
+	ReadLimit int64
+	// Subprotocols are the supported subprotocols, in order of preference.
+	Subprotocols []string
+	// CheckOrigin accepts the upgrade requests of cross-origin clients e.g. browsers. By default, only requests without an Origin header
+	// or with the same origin as the host of the request are accepted.
+	CheckOrigin func(r *http.Request) bool
+	// EnableCompression negotiates the compression of the messages with the clients.
+	EnableCompression bool
+}
+
+// WebSocketHandlerFunc serves a WebSocket connection, which is closed when the handler returns.
+type WebSocketHandlerFunc func(r *http.Request, c *Conn) error
+
+// Conn is a WebSocket connection. Its messages should be read by a single goroutine, which processes the control messages
+// e.g. pongs and close frames as well, while its messages can be written concurrently.
+type Conn struct {
+	ctx          context.Context
+	cancel       context.CancelFunc
+	conn         *websocket.Conn
+	writeMu      sync.Mutex
+	path         string
+	readTimeout  time.Duration
+	writeTimeout time.Duration
+}
+
+// Context returns the context of the connection, carrying the correlation ID and the logger of its upgrade request,
+// which is done once the connection fails or closes, or the component shuts down.
+func (c *Conn) Context() context.Context {
+	return c.ctx
+}
+
+// Subprotocol returns the subprotocol negotiated with the client, if any.
+func (c *Conn) Subprotocol() string {
+	return c.conn.Subprotocol()
+}
+
+// ReadMessage reads the next message of the connection, returning its type i.e. TextMessage or BinaryMessage.
+// It returns io.EOF once the client closes the connection normally.
+func (c *Conn) ReadMessage() (int, []byte, error) {
+	messageType, data, err := c.conn.ReadMessage()
+	if err != nil {
+		c.cancel()
+		if websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway, websocket.CloseNoStatusReceived) {
+			return 0, nil, io.EOF
+		}
+		return 0, nil, err
+	}
+	_ = c.conn.SetReadDeadline(time.Now().Add(c.readTimeout))
+	wsMessagesMetric.WithLabelValues(c.path, "received").Inc()
+	return messageType, data, nil
+}
+
+// WriteMessage writes a message of the provided type, i.e. TextMessage or BinaryMessage, to the connection.
+func (c *Conn) WriteMessage(messageType int, data []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	_ = c.conn.SetWriteDeadline(time.Now().Add(c.writeTimeout))
+	if err := c.conn.WriteMessage(messageType, data); err != nil {
+		c.cancel()
+		return err
+	}
+	wsMessagesMetric.WithLabelValues(c.path, "sent").Inc()
+	return nil
+}
+
+// close sends a close frame with the code and the reason to the client, if the connection is still open.
+func (c *Conn) close(code int, reason string) {
+	deadline := time.Now().Add(c.writeTimeout)
+	err := c.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), deadline)
+	if err != nil && !errors.Is(err, websocket.ErrCloseSent) {
+		log.FromContext(c.ctx).Debugf("failed to close WebSocket connection: %v", err)
+	}
+}
+
+func (c *Conn) ping(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-ticker.C:
+			if err := c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(c.writeTimeout)); err != nil {
+				c.cancel()
+				return
+			}
+		}
+	}
+}
+
+// NewWebSocketRoute creates a GET route upgrading its requests to WebSocket connections, which are served by the handler.
+// The connections are kept alive with pings, and are closed by the component when it shuts down, with a going away close frame.
+// Since they are long-lived, they are not limited by the handler timeout of the component.
+func NewWebSocketRoute(path string, handler WebSocketHandlerFunc, cfg WebSocketConfig, oo ...RouteOptionFunc) (*Route, error) {
+	if handler == nil {
+		return nil, errors.New("handler is nil")
+	}
+	if cfg.ReadTimeout < 0 || cfg.WriteTimeout < 0 || cfg.PingInterval < 0 {
+		return nil, errors.New("negative timeout provided")
+	}
+	if cfg.ReadLimit < 0 {
+		return nil, errors.New("negative read limit provided")
+	}
+	if cfg.ReadTimeout == 0 {
+		cfg.ReadTimeout = wsReadTimeout
+	}
+	if cfg.WriteTimeout == 0 {
+		cfg.WriteTimeout = wsWriteTimeout
+	}
+	if cfg.PingInterval == 0 {
+		cfg.PingInterval = cfg.ReadTimeout * 9 / 10
+	}
+	if cfg.PingInterval >= cfg.ReadTimeout {
+		return nil, errors.New("ping interval should be shorter than read timeout")
+	}
+	if cfg.ReadLimit == 0 {
+		cfg.ReadLimit = wsReadLimit
+	}
+
+	return NewGetRoute(path, webSocketHandler(path, handler, cfg), oo...)
+}
+
+func webSocketHandler(path string, handler WebSocketHandlerFunc, cfg WebSocketConfig) http.HandlerFunc {
+	upgrader := websocket.Upgrader{
+		HandshakeTimeout:  cfg.WriteTimeout,
+		Subprotocols:      cfg.Subprotocols,
+		CheckOrigin:       cfg.CheckOrigin,
+		EnableCompression: cfg.EnableCompression,
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		corID := correlation.GetOrSetHeaderID(r.Header)
+		ctx := correlation.ContextWithID(r.Context(), corID)
+
+		// the upgrade failures are answered by the upgrader
+		conn, err := upgrader.Upgrade(w, r, http.Header{correlation.HeaderID: []string{corID}})
+		if err != nil {
+			log.FromContext(ctx).Debugf("failed to upgrade to WebSocket connection: %v", err)
+			return
+		}
+		defer func() {
+			_ = conn.Close()
+		}()
+
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		c := &Conn{ctx: ctx, cancel: cancel, conn: conn, path: path, readTimeout: cfg.ReadTimeout, writeTimeout: cfg.WriteTimeout}
+		conn.SetReadLimit(cfg.ReadLimit)
+		_ = conn.SetReadDeadline(time.Now().Add(cfg.ReadTimeout))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(cfg.ReadTimeout))
+		})
+
+		wsConnectionsMetric.WithLabelValues(path).Inc()
+		defer wsConnectionsMetric.WithLabelValues(path).Dec()
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.ping(cfg.PingInterval)
+		}()
+		if shutdown, ok := shutdownFromContext(r.Context()); ok {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				select {
+				case <-shutdown:
+					c.close(websocket.CloseGoingAway, "server shutting down")
+					// the handler reads the close frame of the client, unless it does not reply in time
+					_ = conn.SetReadDeadline(time.Now().Add(cfg.WriteTimeout))
+				case <-ctx.Done():
+				}
+			}()
+		}
+
+		err = handler(r, c)
+		if err != nil && !errors.Is(err, io.EOF) {
+			log.FromContext(ctx).Errorf("WebSocket connection of %s failed: %v", path, err)
+			c.close(websocket.CloseInternalServerErr, "")
+		} else {
+			c.close(websocket.CloseNormalClosure, "")
+		}
+		cancel()
+		wg.Wait()
+	}
+}
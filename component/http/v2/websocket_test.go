@@ -0,0 +1,198 @@
+package v2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/beatlabs/patron/correlation"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewWebSocketRoute(t *testing.T) {
+	t.Parallel()
+	handler := func(_ *http.Request, _ *Conn) error { return nil }
+	tests := map[string]struct {
+		handler     WebSocketHandlerFunc
+		cfg         WebSocketConfig
+		expectedErr string
+	}{
+		"success":          {handler: handler, cfg: WebSocketConfig{ReadTimeout: time.Second, PingInterval: 500 * time.Millisecond}},
+		"defaults":         {handler: handler},
+		"missing handler":  {expectedErr: "handler is nil"},
+		"negative timeout": {handler: handler, cfg: WebSocketConfig{WriteTimeout: -time.Second}, expectedErr: "negative timeout provided"},
+		"negative limit":   {handler: handler, cfg: WebSocketConfig{ReadLimit: -1}, expectedErr: "negative read limit provided"},
+		"invalid ping interval": {
+			handler: handler, cfg: WebSocketConfig{ReadTimeout: time.Second, PingInterval: time.Second},
+			expectedErr: "ping interval should be shorter than read timeout",
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			got, err := NewWebSocketRoute("/ws", tt.handler, tt.cfg)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, http.MethodGet, got.Method())
+				assert.Equal(t, "/ws", got.Path())
+			}
+		})
+	}
+}
+
+func echo(_ *http.Request, c *Conn) error {
+	for {
+		messageType, data, err := c.ReadMessage()
+		if err != nil {
+			return err
+		}
+		if string(data) == "fail" {
+			return errors.New("failure requested")
+		}
+		if err := c.WriteMessage(messageType, []byte(correlation.IDFromContext(c.Context())+": "+string(data))); err != nil {
+			return err
+		}
+	}
+}
+
+func TestWebSocket(t *testing.T) {
+	t.Parallel()
+	route, err := NewWebSocketRoute("/ws", echo, WebSocketConfig{Subprotocols: []string{"chat"}})
+	require.NoError(t, err)
+	srv := httptest.NewServer(route.Handler())
+	defer srv.Close()
+	url := "ws" + strings.TrimPrefix(srv.URL, "http")
+
+	rsp, err := http.Get(srv.URL)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, rsp.StatusCode)
+	_ = rsp.Body.Close()
+
+	dialer := websocket.Dialer{Subprotocols: []string{"chat"}}
+	conn, rsp, err := dialer.Dial(url, http.Header{correlation.HeaderID: []string{"123"}})
+	require.NoError(t, err)
+	assert.Equal(t, "123", rsp.Header.Get(correlation.HeaderID))
+	assert.Equal(t, "chat", conn.Subprotocol())
+
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte("hello")))
+	messageType, data, err := conn.ReadMessage()
+	require.NoError(t, err)
+	assert.Equal(t, websocket.TextMessage, messageType)
+	assert.Equal(t, "123: hello", string(data))
+
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte("fail")))
+	_, _, err = conn.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseInternalServerErr))
+	_ = conn.Close()
+
+	conn, _, err = dialer.Dial(url, nil)
+	require.NoError(t, err)
+	require.NoError(t, conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")))
+	_, _, err = conn.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseNormalClosure))
+	_ = conn.Close()
+}
+
+func TestWebSocket_Ping(t *testing.T) {
+	t.Parallel()
+	closed := make(chan error, 1)
+	route, err := NewWebSocketRoute("/ws", func(r *http.Request, c *Conn) error {
+		err := echo(r, c)
+		closed <- err
+		return err
+	}, WebSocketConfig{ReadTimeout: 100 * time.Millisecond, PingInterval: 20 * time.Millisecond})
+	require.NoError(t, err)
+	srv := httptest.NewServer(route.Handler())
+	defer srv.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	require.NoError(t, err)
+	pings := make(chan struct{}, 100)
+	var muted int32
+	conn.SetPingHandler(func(data string) error {
+		if atomic.LoadInt32(&muted) == 1 {
+			return nil
+		}
+		pings <- struct{}{}
+		return conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(time.Second))
+	})
+	go func() {
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	// the pongs keep the connection alive beyond the read timeout
+	time.Sleep(250 * time.Millisecond)
+	assert.GreaterOrEqual(t, len(pings), 5)
+	select {
+	case err := <-closed:
+		require.FailNow(t, "connection closed", err)
+	default:
+	}
+
+	// without pongs, the connection times out
+	atomic.StoreInt32(&muted, 1)
+	select {
+	case err := <-closed:
+		assert.Error(t, err)
+		assert.False(t, errors.Is(err, io.EOF))
+	case <-time.After(time.Second):
+		require.FailNow(t, "connection did not time out")
+	}
+	_ = conn.Close()
+}
+
+func TestComponent_WebSocket_Shutdown(t *testing.T) {
+	port := freePort(t)
+	route, err := NewWebSocketRoute("/ws", echo, WebSocketConfig{})
+	require.NoError(t, err)
+	// the connection outlives the handler timeout of the component
+	cmp, err := New(route.Handler(), Port(port), HandlerTimeout(50*time.Millisecond), ShutdownGracePeriod(time.Second))
+	require.NoError(t, err)
+	done := make(chan error)
+	ctx, cnl := context.WithCancel(context.Background())
+	go func() {
+		done <- cmp.Run(ctx)
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	conn, _, err := websocket.DefaultDialer.Dial(fmt.Sprintf("ws://localhost:%d/ws", port), nil)
+	require.NoError(t, err)
+	defer func() {
+		_ = conn.Close()
+	}()
+	time.Sleep(100 * time.Millisecond)
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte("hello")))
+	_, data, err := conn.ReadMessage()
+	require.NoError(t, err)
+	assert.True(t, strings.HasSuffix(string(data), ": hello"))
+	assert.Equal(t, int64(1), cmp.InFlight())
+
+	cnl()
+	// the client replies to the close frame of the server, completing the connection
+	_, _, err = conn.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseGoingAway))
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(500 * time.Millisecond):
+		require.FailNow(t, "connection blocked the shutdown")
+	}
+	assert.Equal(t, int64(0), cmp.InFlight())
+}
@@ -0,0 +1,184 @@
+// Package forwarder provides a component, which forwards the messages of Kafka topics to a topic of another cluster,
+// e.g. for topic migrations and cross-cluster mirroring.
+package forwarder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/beatlabs/patron/component/kafka"
+	"github.com/beatlabs/patron/component/kafka/group"
+	"github.com/beatlabs/patron/correlation"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	subsystem = "kafka_forwarder"
+
+	// HeaderSourceTopic is the header of the forwarded messages holding the topic they were consumed from.
+	HeaderSourceTopic = "X-Source-Topic"
+	// HeaderSourcePartition is the header of the forwarded messages holding the partition they were consumed from.
+	HeaderSourcePartition = "X-Source-Partition"
+	// HeaderSourceOffset is the header of the forwarded messages holding the offset they were consumed from.
+	HeaderSourceOffset = "X-Source-Offset"
+
+	messageForwarded = "forwarded"
+	messageDropped   = "dropped"
+	messageFailed    = "failed"
+)
+
+var (
+	messageStatus *prometheus.CounterVec
+	forwardLag    *prometheus.GaugeVec
+)
+
+func init() {
+	messageStatus = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "component",
+			Subsystem: subsystem,
+			Name:      "message_status",
+			Help:      "Message status counter (forwarded, dropped, failed) classified by forwarder name and source topic",
+		},
+		[]string{"name", "topic", "status"},
+	)
+	forwardLag = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "component",
+			Subsystem: subsystem,
+			Name:      "lag_seconds",
+			Help:      "Time elapsed between producing a message to the source and forwarding it, classified by forwarder name, source topic and partition",
+		},
+		[]string{"name", "topic", "partition"},
+	)
+	prometheus.MustRegister(messageStatus, forwardLag)
+}
+
+// Producer is the producer of the target cluster e.g. the sync producer of the Kafka client.
+type Producer interface {
+	Send(ctx context.Context, msg *sarama.ProducerMessage) (partition int32, offset int64, err error)
+}
+
+// TransformFunc transforms the message to be forwarded, which is created from the consumed one.
+// Returning false drops the message.
+type TransformFunc func(src *sarama.ConsumerMessage, dst *sarama.ProducerMessage) (bool, error)
+
+// Component forwarding the messages consumed by a consumer group to a target topic.
+type Component struct {
+	name        string
+	producer    Producer
+	targetTopic string
+	transforms  []TransformFunc
+	consumerOO  []group.OptionFunc
+	consumer    *group.Component
+}
+
+// New creates a forwarder, which consumes the topics with a consumer group and produces their messages to the target topic
+// with the provided producer. If the target topic is empty, the messages are produced to the topic they were consumed from,
+// which mirrors the topics to the cluster of the producer.
+// Offsets are committed after every batch has been forwarded, so that messages are forwarded at least once.
+func New(name, groupID string, brokers, topics []string, saramaCfg *sarama.Config, producer Producer, targetTopic string,
+	oo ...OptionFunc,
+) (*Component, error) {
+	if producer == nil {
+		return nil, errors.New("producer is required")
+	}
+
+	cmp := &Component{
+		name:        name,
+		producer:    producer,
+		targetTopic: targetTopic,
+	}
+
+	for _, optionFunc := range oo {
+		if err := optionFunc(cmp); err != nil {
+			return nil, err
+		}
+	}
+
+	consumer, err := group.New(name, groupID, brokers, topics, cmp.forward, saramaCfg, append(cmp.consumerOO, group.CommitSync())...)
+	if err != nil {
+		return nil, err
+	}
+	cmp.consumer = consumer
+
+	return cmp, nil
+}
+
+// Run starts forwarding messages until the context is done.
+func (c *Component) Run(ctx context.Context) error {
+	return c.consumer.Run(ctx)
+}
+
+// forward produces the messages of the batch in order. Failing to produce a message fails the batch,
+// which is then handled by the failure strategy of the consumer group.
+func (c *Component) forward(btc kafka.Batch) error {
+	for _, m := range btc.Messages() {
+		src := m.Message()
+		dst, ok, err := c.transform(src)
+		if err != nil {
+			messageStatus.WithLabelValues(c.name, src.Topic, messageFailed).Inc()
+			return fmt.Errorf("failed to transform message of topic %s at offset %d: %w", src.Topic, src.Offset, err)
+		}
+		if !ok {
+			messageStatus.WithLabelValues(c.name, src.Topic, messageDropped).Inc()
+			continue
+		}
+		if _, _, err := c.producer.Send(m.Context(), dst); err != nil {
+			messageStatus.WithLabelValues(c.name, src.Topic, messageFailed).Inc()
+			return fmt.Errorf("failed to forward message of topic %s at offset %d: %w", src.Topic, src.Offset, err)
+		}
+		messageStatus.WithLabelValues(c.name, src.Topic, messageForwarded).Inc()
+		if !src.Timestamp.IsZero() {
+			forwardLag.WithLabelValues(c.name, src.Topic, strconv.FormatInt(int64(src.Partition), 10)).Set(time.Since(src.Timestamp).Seconds())
+		}
+	}
+	return nil
+}
+
+// transform creates the message to be forwarded, carrying the key, the value and the headers of the consumed one,
+// along with its source topic, partition and offset, and applies the transformations in order.
+func (c *Component) transform(src *sarama.ConsumerMessage) (*sarama.ProducerMessage, bool, error) {
+	topic := c.targetTopic
+	if topic == "" {
+		topic = src.Topic
+	}
+
+	headers := make([]sarama.RecordHeader, 0, len(src.Headers)+3)
+	for _, h := range src.Headers {
+		// the correlation ID is injected by the producer from the context of the message
+		if h == nil || string(h.Key) == correlation.HeaderID {
+			continue
+		}
+		headers = append(headers, *h)
+	}
+	headers = append(headers,
+		sarama.RecordHeader{Key: []byte(HeaderSourceTopic), Value: []byte(src.Topic)},
+		sarama.RecordHeader{Key: []byte(HeaderSourcePartition), Value: []byte(strconv.FormatInt(int64(src.Partition), 10))},
+		sarama.RecordHeader{Key: []byte(HeaderSourceOffset), Value: []byte(strconv.FormatInt(src.Offset, 10))},
+	)
+
+	dst := &sarama.ProducerMessage{
+		Topic:     topic,
+		Headers:   headers,
+		Timestamp: src.Timestamp,
+	}
+	if src.Key != nil {
+		dst.Key = sarama.ByteEncoder(src.Key)
+	}
+	if src.Value != nil {
+		dst.Value = sarama.ByteEncoder(src.Value)
+	}
+
+	for _, t := range c.transforms {
+		ok, err := t(src, dst)
+		if err != nil || !ok {
+			return nil, false, err
+		}
+	}
+	return dst, true, nil
+}
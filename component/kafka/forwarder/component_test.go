@@ -0,0 +1,157 @@
+package forwarder
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/beatlabs/patron/component/kafka"
+	"github.com/beatlabs/patron/component/kafka/group"
+	"github.com/beatlabs/patron/correlation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeProducer struct {
+	messages []*sarama.ProducerMessage
+	corIDs   []string
+	err      error
+}
+
+func (f *fakeProducer) Send(ctx context.Context, msg *sarama.ProducerMessage) (int32, int64, error) {
+	if f.err != nil {
+		return -1, -1, f.err
+	}
+	f.messages = append(f.messages, msg)
+	f.corIDs = append(f.corIDs, correlation.IDFromContext(ctx))
+	return 0, int64(len(f.messages)), nil
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+	type args struct {
+		producer Producer
+		oo       []OptionFunc
+	}
+	tests := map[string]struct {
+		args        args
+		expectedErr string
+	}{
+		"success": {
+			args: args{producer: &fakeProducer{}, oo: []OptionFunc{ConsumerOptions(group.BatchSize(10))}},
+		},
+		"missing producer": {
+			args:        args{},
+			expectedErr: "producer is required",
+		},
+		"option failure": {
+			args:        args{producer: &fakeProducer{}, oo: []OptionFunc{Transform()}},
+			expectedErr: "transformations are empty",
+		},
+		"consumer option failure": {
+			args:        args{producer: &fakeProducer{}, oo: []OptionFunc{ConsumerOptions(group.BatchSize(0))}},
+			expectedErr: "zero batch size provided",
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			got, err := New("name", "group", []string{"localhost:9092"}, []string{"source"}, sarama.NewConfig(), tt.args.producer, "target", tt.args.oo...)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, got)
+			}
+		})
+	}
+}
+
+func TestComponent_forward(t *testing.T) {
+	t.Parallel()
+	batch := func() kafka.Batch {
+		var mm []kafka.Message
+		for i, v := range []string{"first", "second", "third"} {
+			ctx := correlation.ContextWithID(context.Background(), v)
+			mm = append(mm, kafka.NewMessage(ctx, nil, &sarama.ConsumerMessage{
+				Topic:     "source",
+				Partition: 1,
+				Offset:    int64(10 + i),
+				Key:       []byte("key-" + v),
+				Value:     []byte(v),
+				Timestamp: time.Now(),
+				Headers: []*sarama.RecordHeader{
+					{Key: []byte("tenant"), Value: []byte("beat")},
+					{Key: []byte(correlation.HeaderID), Value: []byte(v)},
+				},
+			}))
+		}
+		return kafka.NewBatch(mm)
+	}
+
+	t.Run("mirror", func(t *testing.T) {
+		t.Parallel()
+		prod := &fakeProducer{}
+		cmp := &Component{name: "mirror", producer: prod}
+
+		require.NoError(t, cmp.forward(batch()))
+		require.Len(t, prod.messages, 3)
+		assert.Equal(t, []string{"first", "second", "third"}, prod.corIDs)
+
+		msg := prod.messages[1]
+		assert.Equal(t, "source", msg.Topic)
+		assert.Equal(t, sarama.ByteEncoder("key-second"), msg.Key)
+		assert.Equal(t, sarama.ByteEncoder("second"), msg.Value)
+		assert.Equal(t, []sarama.RecordHeader{
+			{Key: []byte("tenant"), Value: []byte("beat")},
+			{Key: []byte(HeaderSourceTopic), Value: []byte("source")},
+			{Key: []byte(HeaderSourcePartition), Value: []byte("1")},
+			{Key: []byte(HeaderSourceOffset), Value: []byte("11")},
+		}, msg.Headers)
+	})
+
+	t.Run("transform", func(t *testing.T) {
+		t.Parallel()
+		prod := &fakeProducer{}
+		cmp := &Component{name: "transform", producer: prod, targetTopic: "target"}
+		require.NoError(t, Transform(func(src *sarama.ConsumerMessage, dst *sarama.ProducerMessage) (bool, error) {
+			return string(src.Value) != "second", nil
+		}, func(src *sarama.ConsumerMessage, dst *sarama.ProducerMessage) (bool, error) {
+			dst.Value = sarama.StringEncoder("transformed-" + string(src.Value))
+			return true, nil
+		})(cmp))
+
+		require.NoError(t, cmp.forward(batch()))
+		require.Len(t, prod.messages, 2)
+		assert.Equal(t, "target", prod.messages[0].Topic)
+		assert.Equal(t, sarama.StringEncoder("transformed-first"), prod.messages[0].Value)
+		assert.Equal(t, sarama.StringEncoder("transformed-third"), prod.messages[1].Value)
+	})
+
+	t.Run("transform failure", func(t *testing.T) {
+		t.Parallel()
+		prod := &fakeProducer{}
+		cmp := &Component{name: "transform-failure", producer: prod, transforms: []TransformFunc{
+			func(src *sarama.ConsumerMessage, dst *sarama.ProducerMessage) (bool, error) {
+				return false, errors.New("transform error")
+			},
+		}}
+
+		err := cmp.forward(batch())
+		assert.EqualError(t, err, "failed to transform message of topic source at offset 10: transform error")
+		assert.Empty(t, prod.messages)
+	})
+
+	t.Run("producer failure", func(t *testing.T) {
+		t.Parallel()
+		prod := &fakeProducer{err: errors.New("send error")}
+		cmp := &Component{name: "producer-failure", producer: prod}
+
+		err := cmp.forward(batch())
+		assert.EqualError(t, err, "failed to forward message of topic source at offset 10: send error")
+	})
+}
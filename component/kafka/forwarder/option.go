@@ -0,0 +1,36 @@
+package forwarder
+
+import (
+	"errors"
+
+	"github.com/beatlabs/patron/component/kafka/group"
+)
+
+// OptionFunc definition for configuring the component in a functional way.
+type OptionFunc func(*Component) error
+
+// Transform sets the transformations applied in order to the messages before they are forwarded,
+// e.g. to rename headers or to drop messages which should not be migrated.
+func Transform(tt ...TransformFunc) OptionFunc {
+	return func(c *Component) error {
+		if len(tt) == 0 {
+			return errors.New("transformations are empty")
+		}
+		for _, t := range tt {
+			if t == nil {
+				return errors.New("transformation is nil")
+			}
+		}
+		c.transforms = append(c.transforms, tt...)
+		return nil
+	}
+}
+
+// ConsumerOptions sets the options of the underlying consumer group e.g. the batch size or the failure strategy.
+// Offsets are always committed synchronously after every batch.
+func ConsumerOptions(oo ...group.OptionFunc) OptionFunc {
+	return func(c *Component) error {
+		c.consumerOO = append(c.consumerOO, oo...)
+		return nil
+	}
+}
@@ -0,0 +1,43 @@
+package forwarder
+
+import (
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/beatlabs/patron/component/kafka/group"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransform(t *testing.T) {
+	t.Parallel()
+	transform := func(src *sarama.ConsumerMessage, dst *sarama.ProducerMessage) (bool, error) { return true, nil }
+	tests := map[string]struct {
+		tt          []TransformFunc
+		expectedErr string
+	}{
+		"success":            {tt: []TransformFunc{transform, transform}},
+		"empty":              {expectedErr: "transformations are empty"},
+		"nil transformation": {tt: []TransformFunc{transform, nil}, expectedErr: "transformation is nil"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			c := &Component{}
+			err := Transform(tt.tt...)(c)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Len(t, c.transforms, len(tt.tt))
+			}
+		})
+	}
+}
+
+func TestConsumerOptions(t *testing.T) {
+	t.Parallel()
+	c := &Component{}
+	assert.NoError(t, ConsumerOptions(group.BatchSize(10), group.Retries(1))(c))
+	assert.Len(t, c.consumerOO, 2)
+}
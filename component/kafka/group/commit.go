@@ -0,0 +1,81 @@
+package group
+
+import (
+	"sync"
+
+	"github.com/Shopify/sarama"
+	"github.com/beatlabs/patron/component/kafka"
+	"github.com/beatlabs/patron/log"
+)
+
+// CommitStrategy defines when the offsets of the processed messages are committed.
+type CommitStrategy int
+
+const (
+	// CommitInterval marks the messages of every batch processed successfully, whose offsets are committed by Sarama periodically,
+	// every Consumer.Offsets.AutoCommit.Interval, and when the session ends. Messages processed since the last commit are consumed
+	// again after a crash.
+	CommitInterval CommitStrategy = iota
+	// CommitAfterBatch commits the offsets of every batch processed successfully in a blocking operation.
+	CommitAfterBatch
+	// CommitAfterMessage processes the messages one at a time, regardless of the batch size, and commits the offset of every message
+	// processed successfully in a blocking operation.
+	CommitAfterMessage
+	// CommitManual leaves the marking and the committing of the offsets to the processor, with the committer of the batch
+	// returned by kafka.BatchCommitter. The failure strategy does not mark the offsets of the failed batches either,
+	// unless they are published to the dead letter topic.
+	CommitManual
+)
+
+func (s CommitStrategy) String() string {
+	switch s {
+	case CommitInterval:
+		return "interval"
+	case CommitAfterBatch:
+		return "after-batch"
+	case CommitAfterMessage:
+		return "after-message"
+	case CommitManual:
+		return "manual"
+	default:
+		return "unknown"
+	}
+}
+
+// committer marks and commits the offsets of the messages of a batch on behalf of the processor, with the CommitManual strategy.
+// It is valid until the processor returns, since the partitions of the session may be assigned to other members afterwards,
+// so later calls are ignored.
+type committer struct {
+	session sarama.ConsumerGroupSession
+	mu      sync.Mutex
+	closed  bool
+}
+
+// Mark marks the message as processed.
+func (c *committer) Mark(msg kafka.Message) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		log.Warnf("ignoring the mark of the offset %d of topic %s, partition %d after the processing of its batch",
+			msg.Message().Offset, msg.Message().Topic, msg.Message().Partition)
+		return
+	}
+	c.session.MarkMessage(msg.Message(), "")
+}
+
+// Commit commits the offsets of the marked messages in a blocking operation.
+func (c *committer) Commit() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		log.Warn("ignoring the commit of the offsets after the processing of their batch")
+		return
+	}
+	c.session.Commit()
+}
+
+func (c *committer) close() {
+	c.mu.Lock()
+	c.closed = true
+	c.mu.Unlock()
+}
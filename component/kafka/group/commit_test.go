@@ -0,0 +1,130 @@
+package group
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/beatlabs/patron/component/kafka"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// committingConsumerSession records the marked offsets and the commits.
+type committingConsumerSession struct {
+	mockConsumerSession
+	marked  []int64
+	commits int
+}
+
+func (m *committingConsumerSession) MarkMessage(msg *sarama.ConsumerMessage, _ string) {
+	m.marked = append(m.marked, msg.Offset)
+}
+
+func (m *committingConsumerSession) Commit() {
+	m.commits++
+}
+
+func TestConsumerHandler_CommitStrategy(t *testing.T) {
+	t.Parallel()
+	// marks the first message of every batch and commits
+	manual := func(btc kafka.Batch) error {
+		committer, ok := kafka.BatchCommitter(btc)
+		if !ok {
+			return errors.New("batch has no committer")
+		}
+		committer.Mark(btc.Messages()[0])
+		committer.Commit()
+		return nil
+	}
+	tests := map[string]struct {
+		strategy        CommitStrategy
+		batchSize       uint
+		proc            kafka.BatchProcessorFunc
+		failStrategy    kafka.FailStrategy
+		expectedMarked  []int64
+		expectedCommits int
+	}{
+		"interval":             {strategy: CommitInterval, batchSize: 2, expectedMarked: []int64{0, 1, 2}},
+		"after batch":          {strategy: CommitAfterBatch, batchSize: 2, expectedMarked: []int64{0, 1, 2}, expectedCommits: 2},
+		"after message":        {strategy: CommitAfterMessage, batchSize: 1, expectedMarked: []int64{0, 1, 2}, expectedCommits: 3},
+		"manual":               {strategy: CommitManual, batchSize: 2, proc: manual, expectedMarked: []int64{0, 2}, expectedCommits: 2},
+		"manual without marks": {strategy: CommitManual, batchSize: 2},
+		"manual skipped failure": {
+			strategy:     CommitManual,
+			batchSize:    2,
+			proc:         func(kafka.Batch) error { return errors.New("failed") },
+			failStrategy: kafka.SkipStrategy,
+		},
+	}
+	for name, tt := range tests {
+		name := name
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			proc := tt.proc
+			if proc == nil {
+				proc = func(kafka.Batch) error { return nil }
+			}
+			h := newConsumerHandler(context.Background(), name, "grp", proc, tt.failStrategy, tt.batchSize,
+				time.Second, tt.strategy, false, nil)
+			defer h.ticker.Stop()
+			session := &committingConsumerSession{}
+			for i := 0; i < 3; i++ {
+				msg := saramaConsumerMessage("value", &sarama.RecordHeader{})
+				msg.Offset = int64(i)
+				require.NoError(t, h.insertMessage(session, msg))
+			}
+			require.NoError(t, h.flush(session, batchTriggerTimeout))
+
+			assert.Equal(t, tt.expectedMarked, session.marked)
+			assert.Equal(t, tt.expectedCommits, session.commits)
+		})
+	}
+}
+
+func TestConsumerHandler_Cleanup(t *testing.T) {
+	t.Parallel()
+	for _, strategy := range []CommitStrategy{CommitInterval, CommitAfterBatch, CommitAfterMessage, CommitManual} {
+		strategy := strategy
+		t.Run(strategy.String(), func(t *testing.T) {
+			t.Parallel()
+			h := &consumerHandler{commitStrategy: strategy}
+			session := &committingConsumerSession{}
+			require.NoError(t, h.Cleanup(session))
+			if strategy == CommitInterval {
+				assert.Zero(t, session.commits)
+			} else {
+				assert.Equal(t, 1, session.commits)
+			}
+		})
+	}
+}
+
+func TestCommitter_Closed(t *testing.T) {
+	t.Parallel()
+	session := &committingConsumerSession{}
+	c := &committer{session: session}
+	msg := kafka.NewMessage(context.Background(), nil, &sarama.ConsumerMessage{Offset: 1})
+
+	c.Mark(msg)
+	c.Commit()
+	c.close()
+	c.Mark(msg)
+	c.Commit()
+
+	assert.Equal(t, []int64{1}, session.marked)
+	assert.Equal(t, 1, session.commits)
+}
+
+func TestNew_CommitAfterMessage(t *testing.T) {
+	t.Parallel()
+	proc := func(kafka.Batch) error { return nil }
+	cmp, err := New("name", "grp", []string{"localhost:9092"}, []string{"orders"}, proc, sarama.NewConfig(),
+		BatchSize(10), OffsetCommit(CommitAfterMessage))
+	require.NoError(t, err)
+	assert.Equal(t, uint(1), cmp.batchSize)
+	assert.Equal(t, CommitAfterMessage, cmp.commitStrategy)
+}
@@ -15,8 +15,8 @@ import (
 	patronErrors "github.com/beatlabs/patron/errors"
 	"github.com/beatlabs/patron/internal/validation"
 	"github.com/beatlabs/patron/log"
+	"github.com/beatlabs/patron/propagation"
 	"github.com/beatlabs/patron/trace"
-	"github.com/google/uuid"
 	"github.com/opentracing/opentracing-go"
 	"github.com/prometheus/client_golang/prometheus"
 )
@@ -28,6 +28,10 @@ const (
 	messageProcessed  = "processed"
 	messageErrored    = "errored"
 	messageSkipped    = "skipped"
+
+	batchTriggerSize    = "size"
+	batchTriggerBytes   = "bytes"
+	batchTriggerTimeout = "timeout"
 )
 
 const (
@@ -36,12 +40,16 @@ const (
 	defaultBatchSize       = 1
 	defaultBatchTimeout    = 100 * time.Millisecond
 	defaultFailureStrategy = kafka.ExitStrategy
+	// defaultRevokeTimeout is the default rebalance timeout of Sarama.
+	defaultRevokeTimeout = 60 * time.Second
 )
 
 var (
 	consumerErrors           *prometheus.CounterVec
 	topicPartitionOffsetDiff *prometheus.GaugeVec
 	messageStatus            *prometheus.CounterVec
+	batchSizeHistogram       *prometheus.HistogramVec
+	processingAge            *prometheus.HistogramVec
 )
 
 func init() {
@@ -74,10 +82,34 @@ func init() {
 		}, []string{"status", "group", "topic"},
 	)
 
+	batchSizeHistogram = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "component",
+			Subsystem: subsystem,
+			Name:      "batch_size",
+			Help:      "Messages of the processed batches, classified by group and trigger (size, bytes or timeout)",
+			Buckets:   prometheus.ExponentialBuckets(1, 2, 12),
+		},
+		[]string{"group", "trigger"},
+	)
+
+	processingAge = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "component",
+			Subsystem: subsystem,
+			Name:      "message_processing_age_seconds",
+			Help:      "Time elapsed between producing the messages and processing them, classified by group and topic",
+			Buckets:   prometheus.ExponentialBuckets(0.01, 2, 20),
+		},
+		[]string{"group", "topic"},
+	)
+
 	prometheus.MustRegister(
 		consumerErrors,
 		topicPartitionOffsetDiff,
 		messageStatus,
+		batchSizeHistogram,
+		processingAge,
 	)
 }
 
@@ -91,6 +123,15 @@ func topicPartitionOffsetDiffGaugeSet(group, topic string, partition int32, high
 	topicPartitionOffsetDiff.WithLabelValues(group, topic, strconv.FormatInt(int64(partition), 10)).Set(float64(high - offset))
 }
 
+// processingAgeObserve observes the time elapsed since the message was produced, unless the message has no timestamp,
+// i.e. it was produced with a version of the protocol preceding 0.10.
+func processingAgeObserve(group string, msg *sarama.ConsumerMessage) {
+	if msg.Timestamp.IsZero() {
+		return
+	}
+	processingAge.WithLabelValues(group, msg.Topic).Observe(time.Since(msg.Timestamp).Seconds())
+}
+
 // messageStatusCountInc increments the messageStatus counter for a certain status.
 func messageStatusCountInc(status, group, topic string) {
 	messageStatus.WithLabelValues(status, group, topic).Inc()
@@ -151,6 +192,10 @@ func New(name, group string, brokers, topics []string, proc kafka.BatchProcessor
 		}
 	}
 
+	if cmp.commitStrategy == CommitAfterMessage {
+		cmp.batchSize = 1
+	}
+
 	return cmp, nil
 }
 
@@ -165,27 +210,67 @@ type Component struct {
 	failStrategy              kafka.FailStrategy
 	batchSize                 uint
 	batchTimeout              time.Duration
+	batchMaxBytes             int
 	batchMessageDeduplication bool
 	retries                   uint
 	retryWait                 time.Duration
-	commitSync                bool
+	commitStrategy            CommitStrategy
 	sessionCallback           func(sarama.ConsumerGroupSession) error
+	deadline                  processingDeadline
+	deadLetter                *deadLetter
+	stuckDetection            bool
+	stuckThreshold            time.Duration
+	onStuck                   func(StuckBatch)
+	partitionParallelism      uint
+	partitionMaxInFlight      uint
+	decoder                   kafka.Decoder
+	lagInterval               time.Duration
+	lagThreshold              int64
+	onLag                     func(PartitionLag)
+	onAssigned                PartitionsFunc
+	onRevoked                 PartitionsFunc
 }
 
 // Run starts the consumer processing loop to process messages from Kafka.
 func (c *Component) Run(ctx context.Context) error {
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
-	return c.processing(ctx)
+
+	var wd *watchdog
+	if c.stuckDetection {
+		threshold := c.stuckThreshold
+		if threshold == 0 {
+			threshold = c.saramaConfig.Consumer.Group.Rebalance.Timeout / 2
+		}
+		wd = newWatchdog(c.group, threshold, c.onStuck)
+		go wd.run(ctx)
+	}
+	if c.lagInterval > 0 {
+		go newLagMonitor(c.group, c.topics, c.brokers, c.saramaConfig, c.lagInterval, c.lagThreshold, c.onLag).run(ctx)
+	}
+
+	return c.processing(ctx, wd)
 }
 
-func (c *Component) processing(ctx context.Context) error {
+func (c *Component) processing(ctx context.Context, wd *watchdog) error {
 	var componentError error
 
 	retries := int(c.retries)
 	for i := 0; i <= retries; i++ {
 		handler := newConsumerHandler(ctx, c.name, c.group, c.proc, c.failStrategy, c.batchSize,
-			c.batchTimeout, c.commitSync, c.batchMessageDeduplication, c.sessionCallback)
+			c.batchTimeout, c.commitStrategy, c.batchMessageDeduplication, c.sessionCallback)
+		handler.batchMaxBytes = c.batchMaxBytes
+		handler.deadline = c.deadline
+		handler.deadLetter = c.deadLetter
+		handler.watchdog = wd
+		handler.decoder = c.decoder
+		handler.onAssigned = c.onAssigned
+		handler.onRevoked = c.onRevoked
+		handler.revokeTimeout = c.saramaConfig.Consumer.Group.Rebalance.Timeout
+		if c.partitionParallelism > 0 {
+			handler.parallelism = make(chan struct{}, c.partitionParallelism)
+			handler.maxInFlight = int(c.partitionMaxInFlight)
+		}
 
 		client, err := sarama.NewConsumerGroup(c.brokers, c.group, c.saramaConfig)
 		componentError = err
@@ -264,6 +349,8 @@ type consumerHandler struct {
 
 	// buffer
 	batchSize                 int
+	batchMaxBytes             int
+	batchTimeout              time.Duration
 	ticker                    *time.Ticker
 	batchMessageDeduplication bool
 
@@ -273,12 +360,14 @@ type consumerHandler struct {
 	// failures strategy
 	failStrategy kafka.FailStrategy
 
-	// committing after every batch
-	commitSync bool
+	// strategy of committing the offsets of the processed messages
+	commitStrategy CommitStrategy
 
 	// lock to protect buffer operation
 	mu     sync.RWMutex
 	msgBuf []*sarama.ConsumerMessage
+	// bytes of the keys and values of the buffered messages
+	bufBytes int
 
 	// processing error
 	err error
@@ -286,10 +375,29 @@ type consumerHandler struct {
 	// whether the handler has processed any messages
 	processedMessages bool
 	sessionCallback   func(sarama.ConsumerGroupSession) error
+
+	// processing deadline of every batch
+	deadline processingDeadline
+	// dead letter topic of the failed batches, if enabled
+	deadLetter *deadLetter
+	// detector of the batches processed for too long, if enabled
+	watchdog *watchdog
+
+	// slots of the batches of different partitions processed at the same time, if the partitions are processed concurrently
+	parallelism chan struct{}
+	// capacity of the queue of the messages of every partition, if the partitions are processed concurrently
+	maxInFlight int
+	// decoder of the values of the messages, if set
+	decoder kafka.Decoder
+	// callbacks of the partitions assigned to the member and revoked from it, if set
+	onAssigned PartitionsFunc
+	onRevoked  PartitionsFunc
+	// timeout of the callback of the revoked partitions, i.e. the rebalance timeout of the group
+	revokeTimeout time.Duration
 }
 
 func newConsumerHandler(ctx context.Context, name, group string, processorFunc kafka.BatchProcessorFunc,
-	fs kafka.FailStrategy, batchSize uint, batchTimeout time.Duration, commitSync, batchMessageDeduplication bool,
+	fs kafka.FailStrategy, batchSize uint, batchTimeout time.Duration, commitStrategy CommitStrategy, batchMessageDeduplication bool,
 	sessionCallback func(sarama.ConsumerGroupSession) error,
 ) *consumerHandler {
 	return &consumerHandler{
@@ -298,31 +406,44 @@ func newConsumerHandler(ctx context.Context, name, group string, processorFunc k
 		group:                     group,
 		batchSize:                 int(batchSize),
 		batchMessageDeduplication: batchMessageDeduplication,
+		batchTimeout:              batchTimeout,
 		ticker:                    time.NewTicker(batchTimeout),
 		msgBuf:                    make([]*sarama.ConsumerMessage, 0, batchSize),
 		mu:                        sync.RWMutex{},
 		proc:                      processorFunc,
 		failStrategy:              fs,
-		commitSync:                commitSync,
+		commitStrategy:            commitStrategy,
 		sessionCallback:           sessionCallback,
+		revokeTimeout:             defaultRevokeTimeout,
 	}
 }
 
 // Setup is run at the beginning of a new session, before ConsumeClaim.
 func (c *consumerHandler) Setup(cgs sarama.ConsumerGroupSession) error {
-	if c.sessionCallback == nil {
-		return nil
+	if c.sessionCallback != nil {
+		if err := c.sessionCallback(cgs); err != nil {
+			return err
+		}
 	}
-	return c.sessionCallback(cgs)
+	return c.partitionsAssigned(cgs)
 }
 
 // Cleanup is run at the end of a session, once all ConsumeClaim goroutines have exited.
-func (c *consumerHandler) Cleanup(sarama.ConsumerGroupSession) error {
-	return nil
+// Unless the offsets are committed periodically, the marked offsets are committed before the partitions are revoked
+// e.g. on a rebalance, even if auto-commit is disabled.
+func (c *consumerHandler) Cleanup(session sarama.ConsumerGroupSession) error {
+	err := c.partitionsRevoked(session)
+	if c.commitStrategy != CommitInterval {
+		session.Commit()
+	}
+	return err
 }
 
 // ConsumeClaim must start a consumer loop of ConsumerGroupClaim's Messages().
 func (c *consumerHandler) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	if c.parallelism != nil {
+		return c.consumeOrdered(session, claim)
+	}
 	for {
 		select {
 		case msg, ok := <-claim.Messages():
@@ -340,7 +461,7 @@ func (c *consumerHandler) ConsumeClaim(session sarama.ConsumerGroupSession, clai
 			}
 		case <-c.ticker.C:
 			c.mu.Lock()
-			err := c.flush(session)
+			err := c.flush(session, batchTriggerTimeout)
 			c.mu.Unlock()
 			if err != nil {
 				return err
@@ -354,48 +475,133 @@ func (c *consumerHandler) ConsumeClaim(session sarama.ConsumerGroupSession, clai
 	}
 }
 
-func (c *consumerHandler) flush(session sarama.ConsumerGroupSession) error {
+func (c *consumerHandler) flush(session sarama.ConsumerGroupSession, trigger string) error {
 	if len(c.msgBuf) == 0 {
 		return nil
 	}
+	if c.parallelism != nil {
+		select {
+		case c.parallelism <- struct{}{}:
+			defer func() { <-c.parallelism }()
+		case <-c.ctx.Done():
+			return nil
+		}
+	}
+	batchSizeHistogram.WithLabelValues(c.group, trigger).Observe(float64(len(c.msgBuf)))
+
+	ctx := c.ctx
+	if c.deadline.timeout > 0 {
+		if c.deadline.action == AlertOnDeadline {
+			size := len(c.msgBuf)
+			timer := time.AfterFunc(c.deadline.timeout, func() {
+				deadlineExceeded.WithLabelValues(c.group, c.deadline.action.String()).Inc()
+				log.Warnf("processing of %d message(s) exceeded the deadline of %v", size, c.deadline.timeout)
+			})
+			defer timer.Stop()
+		} else {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(c.ctx, c.deadline.timeout)
+			defer cancel()
+		}
+	}
 
 	messages := make([]kafka.Message, 0, len(c.msgBuf))
 	for _, msg := range c.msgBuf {
 		messageStatusCountInc(messageProcessed, c.group, msg.Topic)
-		ctx, sp := c.getContextWithCorrelation(msg)
-		messages = append(messages, kafka.NewMessage(ctx, sp, msg))
+		processingAgeObserve(c.group, msg)
+		msgCtx, sp := c.getContextWithCorrelation(ctx, msg)
+		messages = append(messages, kafka.NewDecodableMessage(msgCtx, sp, msg, c.decoder))
 	}
 
 	if c.batchMessageDeduplication {
 		messages = deduplicateMessages(messages)
 	}
 	btc := kafka.NewBatch(messages)
-	err := c.proc(btc)
+	if c.commitStrategy == CommitManual {
+		cmt := &committer{session: session}
+		defer cmt.close()
+		btc = kafka.NewCommittableBatch(messages, cmt)
+	}
+	c.watchdog.start(c.msgBuf)
+	attempts := uint(1)
+	var err error
+	if c.deadLetter != nil {
+		attempts, err = c.deadLetter.process(ctx, c.proc, btc)
+	} else {
+		err = c.proc(btc)
+	}
+	c.watchdog.done(c.msgBuf)
+	deadLettered := false
 	if err != nil {
 		if errors.Is(c.ctx.Err(), context.Canceled) {
 			return fmt.Errorf("context was cancelled after processing error: %w", err)
 		}
-		err := c.executeFailureStrategy(messages, err)
-		if err != nil {
-			return err
+		if c.deadline.action != AlertOnDeadline && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			deadlineExceeded.WithLabelValues(c.group, c.deadline.action.String()).Inc()
+			if c.deadline.action == RetryOnDeadline {
+				return c.retryOnDeadline(messages, err)
+			}
+		}
+		if c.deadLetter != nil {
+			deadLettered = c.publishDeadLetters(messages, attempts, err)
+		}
+		if !deadLettered {
+			err := c.executeFailureStrategy(messages, err)
+			if err != nil {
+				return err
+			}
 		}
 	}
 
 	c.processedMessages = true
 	for _, m := range messages {
-		trace.SpanSuccess(m.Span())
-		session.MarkMessage(m.Message(), "")
+		if deadLettered {
+			trace.SpanError(m.Span())
+		} else {
+			trace.SpanSuccess(m.Span())
+		}
+		if c.commitStrategy != CommitManual || deadLettered {
+			session.MarkMessage(m.Message(), "")
+		}
 	}
 
-	if c.commitSync {
+	if c.commitStrategy == CommitAfterBatch || c.commitStrategy == CommitAfterMessage {
 		session.Commit()
 	}
 
 	c.msgBuf = c.msgBuf[:0]
+	c.bufBytes = 0
 
 	return nil
 }
 
+// retryOnDeadline fails the handler without committing the offsets of the messages, so that the component reconnects and retries them.
+func (c *consumerHandler) retryOnDeadline(messages []kafka.Message, err error) error {
+	for _, m := range messages {
+		trace.SpanError(m.Span())
+		messageStatusCountInc(messageErrored, c.group, m.Message().Topic)
+	}
+	log.Errorf("processing of %d message(s) exceeded the deadline of %v, retrying: %v", len(messages), c.deadline.timeout, err)
+	c.err = fmt.Errorf("processing deadline of %v exceeded: %w", c.deadline.timeout, err)
+	return c.err
+}
+
+// publishDeadLetters publishes the messages of a failed batch to the dead letter topic, so that their offsets are committed.
+// If publishing fails, the failure strategy applies instead.
+func (c *consumerHandler) publishDeadLetters(messages []kafka.Message, attempts uint, err error) bool {
+	if dlqErr := c.deadLetter.publish(c.ctx, messages, attempts, err); dlqErr != nil {
+		log.Errorf("failed to publish %d message(s) to the dead letter topic %s: %v", len(messages), c.deadLetter.policy.Topic, dlqErr)
+		return false
+	}
+	for _, m := range messages {
+		messageStatusCountInc(messageErrored, c.group, m.Message().Topic)
+		messageStatusCountInc(messageDeadLettered, c.group, m.Message().Topic)
+	}
+	log.Errorf("could not process %d message(s) after %d attempt(s) so publishing them to the dead letter topic %s: %v",
+		len(messages), attempts, c.deadLetter.policy.Topic, err)
+	return true
+}
+
 func (c *consumerHandler) executeFailureStrategy(messages []kafka.Message, err error) error {
 	switch c.failStrategy {
 	case kafka.ExitStrategy:
@@ -420,13 +626,11 @@ func (c *consumerHandler) executeFailureStrategy(messages []kafka.Message, err e
 	return nil
 }
 
-func (c *consumerHandler) getContextWithCorrelation(msg *sarama.ConsumerMessage) (context.Context, opentracing.Span) {
-	corID := getCorrelationID(msg.Headers)
-
-	sp, ctxCh := trace.ConsumerSpan(c.ctx, trace.ComponentOpName(consumerComponent, msg.Topic),
-		consumerComponent, corID, mapHeader(msg.Headers))
-	ctxCh = correlation.ContextWithID(ctxCh, corID)
-	ctxCh = log.WithContext(ctxCh, log.Sub(map[string]interface{}{correlation.ID: corID}))
+func (c *consumerHandler) getContextWithCorrelation(ctx context.Context, msg *sarama.ConsumerMessage) (context.Context, opentracing.Span) {
+	headers := (*propagation.KafkaConsumerHeaders)(&msg.Headers)
+	sp, ctxCh := propagation.ConsumerSpan(ctx, trace.ComponentOpName(consumerComponent, msg.Topic), consumerComponent, headers)
+	ctxCh = propagation.SyntheticContext(ctxCh, headers)
+	ctxCh = log.WithContext(ctxCh, log.Sub(map[string]interface{}{correlation.ID: correlation.IDFromContext(ctxCh)}))
 	return ctxCh, sp
 }
 
@@ -434,31 +638,14 @@ func (c *consumerHandler) insertMessage(session sarama.ConsumerGroupSession, msg
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	c.msgBuf = append(c.msgBuf, msg)
+	c.bufBytes += len(msg.Key) + len(msg.Value)
 	if len(c.msgBuf) >= c.batchSize {
-		return c.flush(session)
+		return c.flush(session, batchTriggerSize)
 	}
-	return nil
-}
-
-func getCorrelationID(hh []*sarama.RecordHeader) string {
-	for _, h := range hh {
-		if string(h.Key) == correlation.HeaderID {
-			if len(h.Value) > 0 {
-				return string(h.Value)
-			}
-			break
-		}
+	if c.batchMaxBytes > 0 && c.bufBytes >= c.batchMaxBytes {
+		return c.flush(session, batchTriggerBytes)
 	}
-	log.Debug("correlation header not found, creating new correlation UUID")
-	return uuid.New().String()
-}
-
-func mapHeader(hh []*sarama.RecordHeader) map[string]string {
-	mp := make(map[string]string)
-	for _, h := range hh {
-		mp[string(h.Key)] = string(h.Value)
-	}
-	return mp
+	return nil
 }
 
 // deduplicateMessages takes a slice of Messages and de-duplicates the messages based on the Key of those messages.
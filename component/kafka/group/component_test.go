@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -12,9 +13,13 @@ import (
 	"github.com/beatlabs/patron/correlation"
 	"github.com/beatlabs/patron/encoding"
 	"github.com/beatlabs/patron/encoding/json"
+	"github.com/beatlabs/patron/propagation"
 	"github.com/google/uuid"
 	"github.com/opentracing/opentracing-go"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNew(t *testing.T) {
@@ -160,23 +165,6 @@ func (mp *mockProcessor) GetExecs() int {
 	return mp.execs
 }
 
-func Test_mapHeader(t *testing.T) {
-	mp := mapHeader([]*sarama.RecordHeader{
-		{
-			Key:   []byte("X-HEADER-1"),
-			Value: []byte("1"),
-		},
-		{
-			Key:   []byte("X-HEADER-2"),
-			Value: []byte("2"),
-		},
-	})
-	assert.Equal(t, "1", mp["X-HEADER-1"])
-	assert.Equal(t, "2", mp["X-HEADER-2"])
-	_, ok := mp["X-HEADER-3"]
-	assert.False(t, ok)
-}
-
 type mockConsumerClaim struct {
 	ch     chan *sarama.ConsumerMessage
 	proc   *mockProcessor
@@ -310,7 +298,7 @@ func TestHandler_ConsumeClaim(t *testing.T) {
 			ctx, cancel := context.WithCancel(context.Background())
 			defer cancel()
 			h := newConsumerHandler(ctx, tt.name, "grp", tt.proc.Process, tt.failStrategy, tt.batchSize,
-				10*time.Millisecond, true, tt.batchMessageDeduplication, nil)
+				10*time.Millisecond, CommitAfterBatch, tt.batchMessageDeduplication, nil)
 
 			ch := make(chan *sarama.ConsumerMessage, len(tt.msgs))
 			for _, m := range tt.msgs {
@@ -331,6 +319,84 @@ func TestHandler_ConsumeClaim(t *testing.T) {
 	}
 }
 
+func TestConsumerHandler_BatchTriggers(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		group           string
+		batchSize       uint
+		batchMaxBytes   int
+		messages        int
+		expectedTrigger string
+		expectedBatches int
+	}{
+		"size":    {group: "batch-size", batchSize: 2, messages: 4, expectedTrigger: batchTriggerSize, expectedBatches: 2},
+		"bytes":   {group: "batch-bytes", batchSize: 100, batchMaxBytes: 16, messages: 4, expectedTrigger: batchTriggerBytes, expectedBatches: 2},
+		"timeout": {group: "batch-timeout", batchSize: 100, messages: 3, expectedTrigger: batchTriggerTimeout, expectedBatches: 1},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			before := batchSizeSamples(t, tt.group, tt.expectedTrigger)
+			var batches int32
+			proc := func(kafka.Batch) error {
+				atomic.AddInt32(&batches, 1)
+				return nil
+			}
+			h := newConsumerHandler(ctx, "batch", tt.group, proc, kafka.ExitStrategy, tt.batchSize,
+				10*time.Millisecond, CommitAfterBatch, false, nil)
+			h.batchMaxBytes = tt.batchMaxBytes
+
+			session := &mockConsumerSession{}
+			for i := 0; i < tt.messages; i++ {
+				// every message has a key of 3 bytes and a value of 5 bytes
+				require.NoError(t, h.insertMessage(session, saramaConsumerMessage("value", &sarama.RecordHeader{})))
+			}
+			if tt.expectedTrigger == batchTriggerTimeout {
+				ch := make(chan *sarama.ConsumerMessage)
+				time.AfterFunc(50*time.Millisecond, func() { close(ch) })
+				require.NoError(t, h.ConsumeClaim(session, &mockConsumerClaim{ch: ch, proc: &mockProcessor{}}))
+			}
+
+			assert.Equal(t, int32(tt.expectedBatches), atomic.LoadInt32(&batches))
+			assert.Empty(t, h.msgBuf)
+			assert.Zero(t, h.bufBytes)
+			assert.Equal(t, before+uint64(tt.expectedBatches), batchSizeSamples(t, tt.group, tt.expectedTrigger))
+		})
+	}
+}
+
+func TestConsumerHandler_Decoder(t *testing.T) {
+	t.Parallel()
+	var decoded []string
+	proc := func(btc kafka.Batch) error {
+		for _, msg := range btc.Messages() {
+			var v string
+			if err := kafka.Decode(msg, &v); err != nil {
+				return err
+			}
+			decoded = append(decoded, v)
+		}
+		return nil
+	}
+	h := newConsumerHandler(context.Background(), "decoder", "decoder", proc, kafka.ExitStrategy, 2,
+		time.Second, CommitAfterBatch, false, nil)
+	h.decoder = kafka.JSONDecoder
+
+	session := &mockConsumerSession{}
+	require.NoError(t, h.insertMessage(session, saramaConsumerMessage(`"first"`, &sarama.RecordHeader{})))
+	require.NoError(t, h.insertMessage(session, saramaConsumerMessage(`"second"`, &sarama.RecordHeader{})))
+	assert.Equal(t, []string{"first", "second"}, decoded)
+}
+
+func batchSizeSamples(t *testing.T, group, trigger string) uint64 {
+	m := &dto.Metric{}
+	require.NoError(t, batchSizeHistogram.WithLabelValues(group, trigger).(prometheus.Histogram).Write(m))
+	return m.GetHistogram().GetSampleCount()
+}
+
 func saramaConsumerMessages(ct string) []*sarama.ConsumerMessage {
 	return []*sarama.ConsumerMessage{
 		saramaConsumerMessage("value", &sarama.RecordHeader{
@@ -363,24 +429,25 @@ func versionedConsumerMessage(value string, header *sarama.RecordHeader, version
 	}
 }
 
-func Test_getCorrelationID(t *testing.T) {
+func Test_getContextWithCorrelation(t *testing.T) {
 	corID := uuid.New().String()
-	got := getCorrelationID([]*sarama.RecordHeader{
-		{
-			Key:   []byte(correlation.HeaderID),
-			Value: []byte(corID),
-		},
-	})
-	assert.Equal(t, corID, got)
+	h := consumerHandler{}
+	ctx, sp := h.getContextWithCorrelation(context.Background(), &sarama.ConsumerMessage{Topic: "topic", Headers: []*sarama.RecordHeader{
+		{Key: []byte(correlation.HeaderID), Value: []byte(corID)},
+	}})
+	assert.Equal(t, corID, correlation.IDFromContext(ctx))
+	assert.Equal(t, sp, opentracing.SpanFromContext(ctx))
 
-	emptyCorID := ""
-	got = getCorrelationID([]*sarama.RecordHeader{
-		{
-			Key:   []byte(correlation.HeaderID),
-			Value: []byte(emptyCorID),
-		},
-	})
-	assert.NotEqual(t, emptyCorID, got)
+	ctx, _ = h.getContextWithCorrelation(context.Background(), &sarama.ConsumerMessage{Topic: "topic", Headers: []*sarama.RecordHeader{
+		{Key: []byte(correlation.HeaderID), Value: []byte("")},
+	}})
+	assert.NotEmpty(t, correlation.IDFromContext(ctx))
+	assert.False(t, propagation.IsSynthetic(ctx))
+
+	ctx, _ = h.getContextWithCorrelation(context.Background(), &sarama.ConsumerMessage{Topic: "topic", Headers: []*sarama.RecordHeader{
+		{Key: []byte(propagation.SyntheticHeader), Value: []byte("true")},
+	}})
+	assert.True(t, propagation.IsSynthetic(ctx))
 }
 
 func Test_deduplicateMessages(t *testing.T) {
@@ -414,3 +481,24 @@ func Test_deduplicateMessages(t *testing.T) {
 	assert.Equal(t, []byte("v1.3"), find(cleaned, "k1").Message().Value)
 	assert.Equal(t, []byte("v2.2"), find(cleaned, "k2").Message().Value)
 }
+
+func TestConsumerHandler_ProcessingAge(t *testing.T) {
+	t.Parallel()
+	h := newConsumerHandler(context.Background(), "age", "processing-age", func(kafka.Batch) error { return nil },
+		kafka.ExitStrategy, 2, time.Second, CommitInterval, false, nil)
+	defer h.ticker.Stop()
+
+	produced := saramaConsumerMessage("value", &sarama.RecordHeader{})
+	produced.Timestamp = time.Now().Add(-time.Minute)
+	session := &mockConsumerSession{}
+	require.NoError(t, h.insertMessage(session, produced))
+	// messages of protocol versions preceding 0.10 have no timestamp
+	legacy := saramaConsumerMessage("value", &sarama.RecordHeader{})
+	legacy.Timestamp = time.Time{}
+	require.NoError(t, h.insertMessage(session, legacy))
+
+	m := &dto.Metric{}
+	require.NoError(t, processingAge.WithLabelValues("processing-age", produced.Topic).(prometheus.Histogram).Write(m))
+	assert.Equal(t, uint64(1), m.GetHistogram().GetSampleCount())
+	assert.GreaterOrEqual(t, m.GetHistogram().GetSampleSum(), time.Minute.Seconds())
+}
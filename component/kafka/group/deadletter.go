@@ -0,0 +1,110 @@
+package group
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/beatlabs/patron/component/kafka"
+)
+
+const (
+	// HeaderOriginalTopic is the header of dead-lettered messages holding the topic they were consumed from.
+	HeaderOriginalTopic = "original-topic"
+	// HeaderOriginalPartition is the header of dead-lettered messages holding the partition they were consumed from.
+	HeaderOriginalPartition = "original-partition"
+	// HeaderOriginalOffset is the header of dead-lettered messages holding their offset in the partition they were consumed from.
+	HeaderOriginalOffset = "original-offset"
+	// HeaderError is the header of dead-lettered messages holding the error of the last processing attempt.
+	HeaderError = "error"
+	// HeaderAttempts is the header of dead-lettered messages holding the number of processing attempts.
+	HeaderAttempts = "attempts"
+
+	messageDeadLettered = "dead-lettered"
+
+	defaultDeadLetterAttempts = 3
+)
+
+// DeadLetterProducer is the producer of the dead-lettered messages, e.g. the sync producer of the Kafka client.
+type DeadLetterProducer interface {
+	SendBatch(ctx context.Context, messages []*sarama.ProducerMessage) error
+}
+
+// DeadLetterPolicy defines when the messages of a batch are dead-lettered.
+type DeadLetterPolicy struct {
+	// Topic the messages are published to.
+	Topic string
+	// Attempts to process a batch before its messages are dead-lettered, including the first one (default: 3).
+	Attempts uint
+	// Backoff between the attempts.
+	Backoff time.Duration
+}
+
+type deadLetter struct {
+	producer DeadLetterProducer
+	policy   DeadLetterPolicy
+}
+
+func newDeadLetter(producer DeadLetterProducer, policy DeadLetterPolicy) (*deadLetter, error) {
+	if producer == nil {
+		return nil, errors.New("dead letter producer is nil")
+	}
+	if policy.Topic == "" {
+		return nil, errors.New("dead letter topic is empty")
+	}
+	if policy.Backoff < 0 {
+		return nil, errors.New("dead letter backoff should not be negative")
+	}
+	if policy.Attempts == 0 {
+		policy.Attempts = defaultDeadLetterAttempts
+	}
+	return &deadLetter{producer: producer, policy: policy}, nil
+}
+
+// process processes the batch up to the attempts of the policy, until it succeeds or the context is done.
+func (d *deadLetter) process(ctx context.Context, proc kafka.BatchProcessorFunc, btc kafka.Batch) (uint, error) {
+	var err error
+	attempt := uint(1)
+	for ; ; attempt++ {
+		err = proc(btc)
+		if err == nil || attempt == d.policy.Attempts || ctx.Err() != nil {
+			return attempt, err
+		}
+		select {
+		case <-ctx.Done():
+			return attempt, err
+		case <-time.After(d.policy.Backoff):
+		}
+	}
+}
+
+// publish publishes the messages to the dead letter topic, with the failure metadata in their headers.
+func (d *deadLetter) publish(ctx context.Context, messages []kafka.Message, attempts uint, err error) error {
+	pp := make([]*sarama.ProducerMessage, 0, len(messages))
+	for _, m := range messages {
+		msg := m.Message()
+		headers := make([]sarama.RecordHeader, 0, len(msg.Headers)+5)
+		for _, h := range msg.Headers {
+			headers = append(headers, *h)
+		}
+		headers = append(headers,
+			sarama.RecordHeader{Key: []byte(HeaderOriginalTopic), Value: []byte(msg.Topic)},
+			sarama.RecordHeader{Key: []byte(HeaderOriginalPartition), Value: []byte(strconv.FormatInt(int64(msg.Partition), 10))},
+			sarama.RecordHeader{Key: []byte(HeaderOriginalOffset), Value: []byte(strconv.FormatInt(msg.Offset, 10))},
+			sarama.RecordHeader{Key: []byte(HeaderError), Value: []byte(err.Error())},
+			sarama.RecordHeader{Key: []byte(HeaderAttempts), Value: []byte(strconv.FormatUint(uint64(attempts), 10))},
+		)
+		pm := &sarama.ProducerMessage{
+			Topic:   d.policy.Topic,
+			Value:   sarama.ByteEncoder(msg.Value),
+			Headers: headers,
+		}
+		if msg.Key != nil {
+			pm.Key = sarama.ByteEncoder(msg.Key)
+		}
+		pp = append(pp, pm)
+	}
+	return d.producer.SendBatch(ctx, pp)
+}
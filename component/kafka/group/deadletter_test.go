@@ -0,0 +1,178 @@
+package group
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/beatlabs/patron/component/kafka"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockDeadLetterProducer struct {
+	mu       sync.Mutex
+	err      error
+	messages []*sarama.ProducerMessage
+}
+
+func (m *mockDeadLetterProducer) SendBatch(_ context.Context, messages []*sarama.ProducerMessage) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.err != nil {
+		return m.err
+	}
+	m.messages = append(m.messages, messages...)
+	return nil
+}
+
+// markingConsumerSession records the marked messages.
+type markingConsumerSession struct {
+	mockConsumerSession
+	marked []*sarama.ConsumerMessage
+}
+
+func (m *markingConsumerSession) MarkMessage(msg *sarama.ConsumerMessage, _ string) {
+	m.marked = append(m.marked, msg)
+}
+
+func TestDeadLetter(t *testing.T) {
+	t.Parallel()
+	producer := &mockDeadLetterProducer{}
+	tests := map[string]struct {
+		producer         DeadLetterProducer
+		policy           DeadLetterPolicy
+		expectedAttempts uint
+		expectedErr      string
+	}{
+		"success":          {producer: producer, policy: DeadLetterPolicy{Topic: "dlq", Attempts: 5, Backoff: time.Second}, expectedAttempts: 5},
+		"default attempts": {producer: producer, policy: DeadLetterPolicy{Topic: "dlq"}, expectedAttempts: 3},
+		"nil producer":     {policy: DeadLetterPolicy{Topic: "dlq"}, expectedErr: "dead letter producer is nil"},
+		"empty topic":      {producer: producer, expectedErr: "dead letter topic is empty"},
+		"negative backoff": {producer: producer, policy: DeadLetterPolicy{Topic: "dlq", Backoff: -1}, expectedErr: "dead letter backoff should not be negative"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			c := &Component{}
+			err := DeadLetter(tt.producer, tt.policy)(c)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.Nil(t, c.deadLetter)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedAttempts, c.deadLetter.policy.Attempts)
+			}
+		})
+	}
+}
+
+func TestHandler_DeadLetter(t *testing.T) {
+	t.Parallel()
+	errProcessing := errors.New("invalid order")
+	tests := map[string]struct {
+		failures         int
+		producerErr      error
+		failStrategy     kafka.FailStrategy
+		expectedAttempts int
+		expectedErr      string
+		expectedDLQ      bool
+		expectedMarked   bool
+	}{
+		"processed after retry": {
+			failures: 1, failStrategy: kafka.ExitStrategy, expectedAttempts: 2, expectedMarked: true,
+		},
+		"dead-lettered": {
+			failures: 5, failStrategy: kafka.ExitStrategy, expectedAttempts: 3, expectedDLQ: true, expectedMarked: true,
+		},
+		"publishing failed with exit strategy": {
+			failures: 5, producerErr: errors.New("broker down"), failStrategy: kafka.ExitStrategy, expectedAttempts: 3,
+			expectedErr: "invalid order",
+		},
+		"publishing failed with skip strategy": {
+			failures: 5, producerErr: errors.New("broker down"), failStrategy: kafka.SkipStrategy, expectedAttempts: 3,
+			expectedMarked: true,
+		},
+	}
+	for name, tt := range tests {
+		name, tt := name, tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			attempts := 0
+			proc := func(kafka.Batch) error {
+				attempts++
+				if attempts <= tt.failures {
+					return errProcessing
+				}
+				return nil
+			}
+			producer := &mockDeadLetterProducer{err: tt.producerErr}
+			h := newConsumerHandler(context.Background(), name, "grp", proc, tt.failStrategy, 2,
+				time.Second, CommitInterval, false, nil)
+			dl, err := newDeadLetter(producer, DeadLetterPolicy{Topic: "orders-dlq", Backoff: time.Millisecond})
+			require.NoError(t, err)
+			h.deadLetter = dl
+
+			session := &markingConsumerSession{}
+			msg1 := saramaConsumerMessage("1", &sarama.RecordHeader{Key: []byte("tenant"), Value: []byte("acme")})
+			msg1.Partition, msg1.Offset = 3, 42
+			msg2 := saramaConsumerMessage("2", &sarama.RecordHeader{})
+			require.NoError(t, h.insertMessage(session, msg1))
+			err = h.insertMessage(session, msg2)
+
+			assert.Equal(t, tt.expectedAttempts, attempts)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			if tt.expectedMarked {
+				assert.Equal(t, []*sarama.ConsumerMessage{msg1, msg2}, session.marked)
+			} else {
+				assert.Empty(t, session.marked)
+			}
+			if !tt.expectedDLQ {
+				assert.Empty(t, producer.messages)
+				return
+			}
+
+			require.Len(t, producer.messages, 2)
+			dlq := producer.messages[0]
+			assert.Equal(t, "orders-dlq", dlq.Topic)
+			assert.Equal(t, sarama.ByteEncoder("key"), dlq.Key)
+			assert.Equal(t, sarama.ByteEncoder("1"), dlq.Value)
+			headers := make(map[string]string)
+			for _, h := range dlq.Headers {
+				headers[string(h.Key)] = string(h.Value)
+			}
+			assert.Equal(t, map[string]string{
+				"tenant":                "acme",
+				HeaderOriginalTopic:     "TEST_TOPIC",
+				HeaderOriginalPartition: "3",
+				HeaderOriginalOffset:    "42",
+				HeaderError:             "invalid order",
+				HeaderAttempts:          "3",
+			}, headers)
+		})
+	}
+}
+
+func TestDeadLetter_Process_ContextDone(t *testing.T) {
+	t.Parallel()
+	dl, err := newDeadLetter(&mockDeadLetterProducer{}, DeadLetterPolicy{Topic: "dlq", Attempts: 10, Backoff: time.Hour})
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	attempts := 0
+	got, err := dl.process(ctx, func(kafka.Batch) error {
+		attempts++
+		cancel()
+		return errors.New("failed")
+	}, kafka.NewBatch(nil))
+	assert.EqualError(t, err, "failed")
+	assert.Equal(t, uint(1), got)
+	assert.Equal(t, 1, attempts)
+}
@@ -0,0 +1,200 @@
+package group
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/beatlabs/patron/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// DeadlineAction is the action taken when the processing of a batch of messages exceeds the processing deadline.
+type DeadlineAction int
+
+const (
+	// CancelOnDeadline cancels the context of the messages, so that their processing fails according to the failure strategy,
+	// if the processor returns an error.
+	CancelOnDeadline DeadlineAction = iota
+	// RetryOnDeadline cancels the context of the messages, and retries them if the processor returns an error,
+	// without committing their offsets regardless of the failure strategy. The component reconnects in order to retry them,
+	// which depletes its retries.
+	RetryOnDeadline
+	// AlertOnDeadline logs and counts the batches exceeding the deadline, without interrupting their processing.
+	AlertOnDeadline
+)
+
+func (a DeadlineAction) String() string {
+	switch a {
+	case CancelOnDeadline:
+		return "cancel"
+	case RetryOnDeadline:
+		return "retry"
+	case AlertOnDeadline:
+		return "alert"
+	default:
+		return "unknown"
+	}
+}
+
+type processingDeadline struct {
+	timeout time.Duration
+	action  DeadlineAction
+}
+
+// StuckBatch describes a batch of messages processed for longer than the threshold of the stuck handler detection.
+type StuckBatch struct {
+	Group string
+	// Topic, Partition and Offset of the first message of the batch.
+	Topic     string
+	Partition int32
+	Offset    int64
+	// Size of the batch.
+	Size    int
+	Elapsed time.Duration
+}
+
+var (
+	deadlineExceeded *prometheus.CounterVec
+	consumerStuck    *prometheus.GaugeVec
+)
+
+func init() {
+	deadlineExceeded = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "component",
+			Subsystem: subsystem,
+			Name:      "processing_deadline_exceeded",
+			Help:      "Batches exceeding the processing deadline, classified by group and deadline action",
+		},
+		[]string{"group", "action"},
+	)
+
+	consumerStuck = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "component",
+			Subsystem: subsystem,
+			Name:      "consumer_stuck",
+			Help:      "Whether the processing of a batch exceeds the stuck handler threshold (1) or not (0), classified by group",
+		},
+		[]string{"group"},
+	)
+
+	prometheus.MustRegister(deadlineExceeded, consumerStuck)
+}
+
+// watchdog detects the batches whose processing exceeds the threshold, which surfaces the handlers
+// that would get the member kicked out of the group on the next rebalance, since it would not rejoin in time.
+// It tracks a batch per partition, since the partitions are processed concurrently with the PartitionConcurrency option.
+type watchdog struct {
+	group     string
+	threshold time.Duration
+	onStuck   func(StuckBatch)
+
+	mu      sync.Mutex
+	batches map[topicPartition]*trackedBatch
+}
+
+type topicPartition struct {
+	topic     string
+	partition int32
+}
+
+type trackedBatch struct {
+	batch    StuckBatch
+	started  time.Time
+	reported bool
+}
+
+func newWatchdog(group string, threshold time.Duration, onStuck func(StuckBatch)) *watchdog {
+	consumerStuck.WithLabelValues(group).Set(0)
+	return &watchdog{group: group, threshold: threshold, onStuck: onStuck, batches: make(map[topicPartition]*trackedBatch)}
+}
+
+// start tracks the processing of a batch of messages, keyed by the partition of its first message. A nil watchdog tracks nothing.
+func (w *watchdog) start(msgs []*sarama.ConsumerMessage) {
+	if w == nil || len(msgs) == 0 {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.batches[topicPartition{topic: msgs[0].Topic, partition: msgs[0].Partition}] = &trackedBatch{
+		batch:   StuckBatch{Group: w.group, Topic: msgs[0].Topic, Partition: msgs[0].Partition, Offset: msgs[0].Offset, Size: len(msgs)},
+		started: time.Now(),
+	}
+}
+
+// done stops tracking the batch, clearing the stuck state if it was reported and no other batch is stuck.
+func (w *watchdog) done(msgs []*sarama.ConsumerMessage) {
+	if w == nil || len(msgs) == 0 {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	key := topicPartition{topic: msgs[0].Topic, partition: msgs[0].Partition}
+	tb, ok := w.batches[key]
+	if !ok {
+		return
+	}
+	delete(w.batches, key)
+	if !tb.reported {
+		return
+	}
+	log.Infof("kafka consumer group %s recovered after processing a batch for %v", w.group, time.Since(tb.started))
+	for _, other := range w.batches {
+		if other.reported {
+			return
+		}
+	}
+	consumerStuck.WithLabelValues(w.group).Set(0)
+}
+
+// check reports the batches in progress once, if their processing exceeds the threshold.
+func (w *watchdog) check() {
+	w.mu.Lock()
+	var stuck []StuckBatch
+	for _, tb := range w.batches {
+		if tb.reported {
+			continue
+		}
+		elapsed := time.Since(tb.started)
+		if elapsed < w.threshold {
+			continue
+		}
+		tb.reported = true
+		batch := tb.batch
+		batch.Elapsed = elapsed
+		stuck = append(stuck, batch)
+	}
+	if len(stuck) > 0 {
+		consumerStuck.WithLabelValues(w.group).Set(1)
+	}
+	w.mu.Unlock()
+
+	for _, batch := range stuck {
+		log.Warnf("kafka consumer group %s is stuck processing %d message(s) from topic %s, partition %d, offset %d for %v",
+			batch.Group, batch.Size, batch.Topic, batch.Partition, batch.Offset, batch.Elapsed)
+		if w.onStuck != nil {
+			w.onStuck(batch)
+		}
+	}
+}
+
+// run checks the batch in progress until the context is done.
+func (w *watchdog) run(ctx context.Context) {
+	interval := w.threshold / 4
+	if interval <= 0 {
+		interval = w.threshold
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.check()
+		}
+	}
+}
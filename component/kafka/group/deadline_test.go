@@ -0,0 +1,116 @@
+package group
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/beatlabs/patron/component/kafka"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler_ProcessingDeadline(t *testing.T) {
+	t.Parallel()
+	blocking := func(batch kafka.Batch) error {
+		ctx := batch.Messages()[0].Context()
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	slow := func(kafka.Batch) error {
+		time.Sleep(20 * time.Millisecond)
+		return nil
+	}
+	tests := map[string]struct {
+		proc         kafka.BatchProcessorFunc
+		failStrategy kafka.FailStrategy
+		action       DeadlineAction
+		expectedErr  string
+	}{
+		"cancel with skip strategy": {proc: blocking, failStrategy: kafka.SkipStrategy, action: CancelOnDeadline},
+		"cancel with exit strategy": {
+			proc: blocking, failStrategy: kafka.ExitStrategy, action: CancelOnDeadline,
+			expectedErr: "context deadline exceeded",
+		},
+		"retry with skip strategy": {
+			proc: blocking, failStrategy: kafka.SkipStrategy, action: RetryOnDeadline,
+			expectedErr: "processing deadline of 10ms exceeded: context deadline exceeded",
+		},
+		"alert": {proc: slow, failStrategy: kafka.ExitStrategy, action: AlertOnDeadline},
+	}
+	for name, tt := range tests {
+		name := name
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			h := newConsumerHandler(context.Background(), name, "grp", tt.proc, tt.failStrategy, 1,
+				time.Second, CommitInterval, false, nil)
+			h.deadline = processingDeadline{timeout: 10 * time.Millisecond, action: tt.action}
+
+			err := h.insertMessage(&mockConsumerSession{}, saramaConsumerMessage("1", &sarama.RecordHeader{}))
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.Equal(t, err, h.err)
+			} else {
+				assert.NoError(t, err)
+				assert.True(t, h.processedMessages)
+			}
+		})
+	}
+}
+
+func TestWatchdog(t *testing.T) {
+	t.Parallel()
+	var stuck []StuckBatch
+	wd := newWatchdog("grp", 10*time.Millisecond, func(b StuckBatch) { stuck = append(stuck, b) })
+
+	msgs := []*sarama.ConsumerMessage{
+		{Topic: "orders", Partition: 2, Offset: 10},
+		{Topic: "orders", Partition: 2, Offset: 11},
+	}
+	wd.start(msgs)
+	wd.check()
+	assert.Empty(t, stuck)
+
+	time.Sleep(15 * time.Millisecond)
+	wd.check()
+	wd.check()
+	if assert.Len(t, stuck, 1) {
+		assert.Equal(t, "grp", stuck[0].Group)
+		assert.Equal(t, "orders", stuck[0].Topic)
+		assert.Equal(t, int32(2), stuck[0].Partition)
+		assert.Equal(t, int64(10), stuck[0].Offset)
+		assert.Equal(t, 2, stuck[0].Size)
+		assert.GreaterOrEqual(t, stuck[0].Elapsed, 10*time.Millisecond)
+	}
+
+	wd.done(msgs)
+	time.Sleep(15 * time.Millisecond)
+	wd.check()
+	assert.Len(t, stuck, 1)
+
+	var nilWatchdog *watchdog
+	nilWatchdog.start(msgs)
+	nilWatchdog.done(msgs)
+}
+
+func TestWatchdog_Partitions(t *testing.T) {
+	t.Parallel()
+	var stuck []StuckBatch
+	wd := newWatchdog("grp-partitions", 10*time.Millisecond, func(b StuckBatch) { stuck = append(stuck, b) })
+	partition1 := []*sarama.ConsumerMessage{{Topic: "orders", Partition: 1, Offset: 5}}
+	partition2 := []*sarama.ConsumerMessage{{Topic: "orders", Partition: 2, Offset: 7}}
+
+	wd.start(partition1)
+	wd.start(partition2)
+	time.Sleep(15 * time.Millisecond)
+	wd.check()
+	assert.Len(t, stuck, 2)
+	assert.Equal(t, 1.0, testutil.ToFloat64(consumerStuck.WithLabelValues("grp-partitions")))
+
+	wd.done(partition1)
+	assert.Equal(t, 1.0, testutil.ToFloat64(consumerStuck.WithLabelValues("grp-partitions")))
+	wd.done(partition2)
+	assert.Equal(t, 0.0, testutil.ToFloat64(consumerStuck.WithLabelValues("grp-partitions")))
+}
@@ -0,0 +1,185 @@
+package group
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/beatlabs/patron/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// PartitionLag describes the lag of the consumer group on a partition, i.e. the number of messages following its committed offset.
+type PartitionLag struct {
+	Group     string
+	Topic     string
+	Partition int32
+	// HighWaterMark is the offset of the next message written to the partition.
+	HighWaterMark int64
+	// Committed is the offset committed by the group.
+	Committed int64
+	Lag       int64
+}
+
+var consumerLag *prometheus.GaugeVec
+
+func init() {
+	consumerLag = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "component",
+			Subsystem: subsystem,
+			Name:      "consumer_lag",
+			Help:      "Messages following the offset committed by the consumer group, classified by group, topic and partition",
+		},
+		[]string{"group", "topic", "partition"},
+	)
+
+	prometheus.MustRegister(consumerLag)
+}
+
+// lagMonitor collects the lag of the consumer group on the partitions of its topics periodically, with a client of its own,
+// so that it is collected regardless of the consumption, e.g. while the group rebalances or its processing is stuck.
+type lagMonitor struct {
+	group     string
+	topics    []string
+	brokers   []string
+	cfg       *sarama.Config
+	interval  time.Duration
+	threshold int64
+	onLag     func(PartitionLag)
+
+	client sarama.Client
+	// exceeded holds the partitions whose lag exceeds the threshold, which are reported once until they recover
+	exceeded map[topicPartition]bool
+}
+
+func newLagMonitor(group string, topics, brokers []string, cfg *sarama.Config, interval time.Duration, threshold int64,
+	onLag func(PartitionLag)) *lagMonitor {
+	return &lagMonitor{
+		group:     group,
+		topics:    topics,
+		brokers:   brokers,
+		cfg:       cfg,
+		interval:  interval,
+		threshold: threshold,
+		onLag:     onLag,
+		exceeded:  make(map[topicPartition]bool),
+	}
+}
+
+// run collects the lag until the context is done. Failed collections are logged, and retried on the next interval.
+func (m *lagMonitor) run(ctx context.Context) {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+	defer m.close()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := m.collect(); err != nil {
+				log.Warnf("failed to collect the lag of kafka consumer group %s: %v", m.group, err)
+			}
+		}
+	}
+}
+
+func (m *lagMonitor) close() {
+	if m.client == nil {
+		return
+	}
+	if err := m.client.Close(); err != nil {
+		log.Errorf("failed to close the lag client of kafka consumer group %s: %v", m.group, err)
+	}
+	m.client = nil
+}
+
+// collect sets the lag of the partitions which have committed offsets, and reports the ones exceeding the threshold.
+func (m *lagMonitor) collect() error {
+	if m.client == nil {
+		client, err := sarama.NewClient(m.brokers, m.cfg)
+		if err != nil {
+			return fmt.Errorf("failed to create client: %w", err)
+		}
+		m.client = client
+	}
+
+	req := &sarama.OffsetFetchRequest{ConsumerGroup: m.group}
+	if m.cfg.Version.IsAtLeast(sarama.V0_10_2_0) {
+		req.Version = 2
+	} else if m.cfg.Version.IsAtLeast(sarama.V0_8_2_2) {
+		req.Version = 1
+	}
+	partitions := make(map[string][]int32, len(m.topics))
+	for _, topic := range m.topics {
+		pp, err := m.client.Partitions(topic)
+		if err != nil {
+			return fmt.Errorf("failed to get partitions of topic %s: %w", topic, err)
+		}
+		for _, p := range pp {
+			req.AddPartition(topic, p)
+		}
+		partitions[topic] = pp
+	}
+
+	coordinator, err := m.client.Coordinator(m.group)
+	if err != nil {
+		return fmt.Errorf("failed to get coordinator: %w", err)
+	}
+	rsp, err := coordinator.FetchOffset(req)
+	if err != nil {
+		_ = m.client.RefreshCoordinator(m.group)
+		return fmt.Errorf("failed to fetch committed offsets: %w", err)
+	}
+	if rsp.Err != sarama.ErrNoError {
+		_ = m.client.RefreshCoordinator(m.group)
+		return fmt.Errorf("failed to fetch committed offsets: %w", rsp.Err)
+	}
+
+	var lags []PartitionLag
+	for topic, pp := range partitions {
+		for _, p := range pp {
+			block := rsp.GetBlock(topic, p)
+			// the partitions without committed offsets have no lag yet
+			if block == nil || block.Err != sarama.ErrNoError || block.Offset < 0 {
+				continue
+			}
+			high, err := m.client.GetOffset(topic, p, sarama.OffsetNewest)
+			if err != nil {
+				return fmt.Errorf("failed to get high watermark of topic %s, partition %d: %w", topic, p, err)
+			}
+			lag := high - block.Offset
+			if lag < 0 {
+				lag = 0
+			}
+			consumerLag.WithLabelValues(m.group, topic, strconv.FormatInt(int64(p), 10)).Set(float64(lag))
+			lags = append(lags, PartitionLag{Group: m.group, Topic: topic, Partition: p, HighWaterMark: high, Committed: block.Offset, Lag: lag})
+		}
+	}
+
+	if m.threshold > 0 {
+		m.check(lags)
+	}
+	return nil
+}
+
+// check reports the partitions once, when their lag exceeds the threshold, and logs their recovery.
+func (m *lagMonitor) check(lags []PartitionLag) {
+	for _, l := range lags {
+		key := topicPartition{topic: l.Topic, partition: l.Partition}
+		switch {
+		case l.Lag > m.threshold && !m.exceeded[key]:
+			m.exceeded[key] = true
+			log.Warnf("kafka consumer group %s lags %d message(s) on topic %s, partition %d, exceeding the threshold of %d",
+				l.Group, l.Lag, l.Topic, l.Partition, m.threshold)
+			if m.onLag != nil {
+				m.onLag(l)
+			}
+		case l.Lag <= m.threshold && m.exceeded[key]:
+			delete(m.exceeded, key)
+			log.Infof("kafka consumer group %s recovered with lag %d on topic %s, partition %d", l.Group, l.Lag, l.Topic, l.Partition)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package group
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLagMonitoring(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		interval    time.Duration
+		threshold   int64
+		expectedErr string
+	}{
+		"success":            {interval: time.Minute, threshold: 1000},
+		"without alerts":     {interval: time.Minute},
+		"zero interval":      {threshold: 1000, expectedErr: "lag monitoring interval should be positive"},
+		"negative threshold": {interval: time.Minute, threshold: -1, expectedErr: "lag threshold should be a positive number or zero"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			c := &Component{}
+			err := LagMonitoring(tt.interval, tt.threshold, func(PartitionLag) {})(c)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.interval, c.lagInterval)
+				assert.Equal(t, tt.threshold, c.lagThreshold)
+				assert.NotNil(t, c.onLag)
+			}
+		})
+	}
+}
+
+func TestLagMonitor_Collect(t *testing.T) {
+	t.Parallel()
+	group := "lag-collect"
+	broker := newLagBroker(t, group)
+
+	var lags []PartitionLag
+	m := newLagMonitor(group, []string{"orders"}, []string{broker.Addr()}, lagConfig(), time.Minute, 10, func(l PartitionLag) {
+		lags = append(lags, l)
+	})
+	defer m.close()
+
+	require.NoError(t, m.collect())
+	assert.Equal(t, float64(15), testutil.ToFloat64(consumerLag.WithLabelValues(group, "orders", "0")))
+	// the partition without a committed offset has no lag
+	assert.Equal(t, float64(0), testutil.ToFloat64(consumerLag.WithLabelValues(group, "orders", "1")))
+	assert.Equal(t, []PartitionLag{{Group: group, Topic: "orders", Partition: 0, HighWaterMark: 25, Committed: 10, Lag: 15}}, lags)
+
+	// the partition is reported once, until it recovers
+	require.NoError(t, m.collect())
+	assert.Len(t, lags, 1)
+}
+
+func TestLagMonitor_Collect_Failure(t *testing.T) {
+	t.Parallel()
+	cfg := lagConfig()
+	cfg.Metadata.Retry.Max = 0
+	m := newLagMonitor("lag-failure", []string{"orders"}, []string{"127.0.0.1:1"}, cfg, time.Minute, 10, nil)
+
+	err := m.collect()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to create client")
+}
+
+func TestLagMonitor_Check(t *testing.T) {
+	t.Parallel()
+	var reported []int64
+	m := newLagMonitor("lag-check", nil, nil, nil, time.Minute, 100, func(l PartitionLag) {
+		reported = append(reported, l.Lag)
+	})
+	lag := func(partition int32, lag int64) PartitionLag {
+		return PartitionLag{Group: "lag-check", Topic: "orders", Partition: partition, Lag: lag}
+	}
+
+	m.check([]PartitionLag{lag(0, 50), lag(1, 150)})
+	m.check([]PartitionLag{lag(0, 50), lag(1, 200)})
+	m.check([]PartitionLag{lag(0, 101), lag(1, 100)})
+	m.check([]PartitionLag{lag(0, 50), lag(1, 300)})
+
+	assert.Equal(t, []int64{150, 101, 300}, reported)
+}
+
+func TestLagMonitor_Run(t *testing.T) {
+	t.Parallel()
+	group := "lag-run"
+	broker := newLagBroker(t, group)
+
+	var mu sync.Mutex
+	var lags []PartitionLag
+	m := newLagMonitor(group, []string{"orders"}, []string{broker.Addr()}, lagConfig(), 10*time.Millisecond, 10, func(l PartitionLag) {
+		mu.Lock()
+		lags = append(lags, l)
+		mu.Unlock()
+	})
+
+	ctx, cnl := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		m.run(ctx)
+		close(done)
+	}()
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(lags) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	cnl()
+	<-done
+	assert.Nil(t, m.client)
+}
+
+func lagConfig() *sarama.Config {
+	cfg := sarama.NewConfig()
+	cfg.Version = sarama.V0_11_0_0
+	return cfg
+}
+
+// newLagBroker returns a broker for a topic of two partitions, where the group has committed the offset 10 of the first one,
+// whose high watermark is 25.
+func newLagBroker(t *testing.T, group string) *sarama.MockBroker {
+	broker := sarama.NewMockBroker(t, 1)
+	t.Cleanup(broker.Close)
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"MetadataRequest": sarama.NewMockMetadataResponse(t).
+			SetBroker(broker.Addr(), broker.BrokerID()).
+			SetLeader("orders", 0, broker.BrokerID()).
+			SetLeader("orders", 1, broker.BrokerID()),
+		"FindCoordinatorRequest": sarama.NewMockFindCoordinatorResponse(t).SetCoordinator(sarama.CoordinatorGroup, group, broker),
+		"OffsetFetchRequest":     sarama.NewMockOffsetFetchResponse(t).SetOffset(group, "orders", 0, 10, "", sarama.ErrNoError),
+		"OffsetRequest": sarama.NewMockOffsetResponse(t).
+			SetOffset("orders", 0, sarama.OffsetNewest, 25).
+			SetOffset("orders", 1, sarama.OffsetNewest, 30),
+	})
+	return broker
+}
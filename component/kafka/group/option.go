@@ -102,6 +102,19 @@ func BatchTimeout(timeout time.Duration) OptionFunc {
 	}
 }
 
+// BatchMaxBytes bounds the message batches by the bytes of the keys and values of their messages as well,
+// processing the messages in the buffer as a batch once they reach the bytes, even if the batch size is not reached,
+// e.g. to keep the bulk writes of the processor below the request size limit of a sink.
+func BatchMaxBytes(bytes int) OptionFunc {
+	return func(c *Component) error {
+		if bytes <= 0 {
+			return errors.New("batch max bytes should be positive")
+		}
+		c.batchMaxBytes = bytes
+		return nil
+	}
+}
+
 // BatchMessageDeduplication enables the deduplication of messages based on the message's key.
 // This implementation does not do additional sorting, but instead relies on the ordering guarantees that Kafka gives
 // within partitions of a topic. Don't use this functionality if you've changed your producer's partition hashing
@@ -113,14 +126,41 @@ func BatchMessageDeduplication() OptionFunc {
 	}
 }
 
-// CommitSync instructs the consumer to commit offsets in a blocking operation after processing every batch of messages.
+// CommitSync instructs the consumer to commit offsets in a blocking operation after processing every batch of messages,
+// i.e. the CommitAfterBatch strategy.
 func CommitSync() OptionFunc {
 	return func(c *Component) error {
 		if c.saramaConfig != nil && c.saramaConfig.Consumer.Offsets.AutoCommit.Enable {
 			// redundant commits warning
 			log.Warn("consumer is set to commit offsets after processing each batch and auto-commit is enabled")
 		}
-		c.commitSync = true
+		c.commitStrategy = CommitAfterBatch
+		return nil
+	}
+}
+
+// OffsetCommit sets the strategy of committing the offsets of the processed messages, which defaults to CommitInterval.
+// Unless the strategy is CommitInterval, the marked offsets are committed when the session ends as well, e.g. on a rebalance,
+// before the partitions are revoked, while the messages not marked yet are consumed again by the members they are assigned to.
+func OffsetCommit(strategy CommitStrategy) OptionFunc {
+	return func(c *Component) error {
+		if strategy < CommitInterval || strategy > CommitManual {
+			return errors.New("invalid commit strategy provided")
+		}
+		c.commitStrategy = strategy
+		return nil
+	}
+}
+
+// AutoCommitInterval enables the periodic commit of the marked offsets by Sarama, and sets its interval,
+// which is the commit interval of the CommitInterval strategy.
+func AutoCommitInterval(interval time.Duration) OptionFunc {
+	return func(c *Component) error {
+		if interval <= 0 {
+			return errors.New("auto-commit interval should be positive")
+		}
+		c.saramaConfig.Consumer.Offsets.AutoCommit.Enable = true
+		c.saramaConfig.Consumer.Offsets.AutoCommit.Interval = interval
 		return nil
 	}
 }
@@ -136,3 +176,124 @@ func NewSessionCallback(sessionCallback func(sarama.ConsumerGroupSession) error)
 		return nil
 	}
 }
+
+// PartitionsAssigned sets the callback of the partitions assigned to the member of the group at the beginning of every session,
+// i.e. after every rebalance, which is called before their messages are consumed. An error fails the session, so the component reconnects.
+// Sarama revokes all the partitions of the member on every rebalance, so the callback is called with partitions which were assigned before as well.
+func PartitionsAssigned(onAssigned PartitionsFunc) OptionFunc {
+	return func(c *Component) error {
+		if onAssigned == nil {
+			return errors.New("nil partitions assigned callback")
+		}
+		c.onAssigned = onAssigned
+		return nil
+	}
+}
+
+// PartitionsRevoked sets the callback of the partitions revoked from the member of the group at the end of every session,
+// e.g. on a rebalance or when the component stops, which is called once their messages are no longer processed,
+// and before their marked offsets are committed, unless the commit strategy is CommitInterval.
+func PartitionsRevoked(onRevoked PartitionsFunc) OptionFunc {
+	return func(c *Component) error {
+		if onRevoked == nil {
+			return errors.New("nil partitions revoked callback")
+		}
+		c.onRevoked = onRevoked
+		return nil
+	}
+}
+
+// ProcessingDeadline sets the deadline of the processing of every batch of messages, and the action taken when the processing exceeds it.
+// The context of the messages is cancelled on the deadline, unless the action is AlertOnDeadline, so the processor should respect it.
+func ProcessingDeadline(timeout time.Duration, action DeadlineAction) OptionFunc {
+	return func(c *Component) error {
+		if timeout <= 0 {
+			return errors.New("processing deadline should be a positive number")
+		}
+		if action < CancelOnDeadline || action > AlertOnDeadline {
+			return errors.New("invalid deadline action provided")
+		}
+		c.deadline = processingDeadline{timeout: timeout, action: action}
+		return nil
+	}
+}
+
+// DeadLetter enables the dead-lettering of the batches failing the attempts of the policy, whose messages are published to its topic
+// with the producer, along with their original topic, partition and offset, and the error of the last attempt in their headers.
+// Their offsets are then committed, instead of applying the failure strategy, which applies only if publishing fails.
+// The attempts of a batch stop once the context of its messages is done, e.g. on the processing deadline.
+func DeadLetter(producer DeadLetterProducer, policy DeadLetterPolicy) OptionFunc {
+	return func(c *Component) error {
+		dl, err := newDeadLetter(producer, policy)
+		if err != nil {
+			return err
+		}
+		c.deadLetter = dl
+		return nil
+	}
+}
+
+// StuckHandlerDetection enables the detection of the batches processed for longer than the threshold, which are logged,
+// flagged by the consumer_stuck metric and passed to the optional callback, before the member is kicked out of the group.
+// A member which does not return from processing within the rebalance timeout of Sarama (Consumer.Group.Rebalance.Timeout)
+// fails to rejoin the group on the next rebalance, so a zero threshold defaults to half of it.
+func StuckHandlerDetection(threshold time.Duration, onStuck func(StuckBatch)) OptionFunc {
+	return func(c *Component) error {
+		if threshold < 0 {
+			return errors.New("stuck handler threshold should be a positive number or zero")
+		}
+		c.stuckDetection = true
+		c.stuckThreshold = threshold
+		c.onStuck = onStuck
+		return nil
+	}
+}
+
+// LagMonitoring collects the lag of the consumer group on the partitions of its topics every interval, i.e. the messages following
+// its committed offsets, which is exposed by the consumer_lag metric, unlike the offset_diff metric which is set on consumption.
+// The partitions whose lag exceeds the threshold are logged and passed to the optional callback once, until they recover.
+// A zero threshold disables the alerts.
+func LagMonitoring(interval time.Duration, threshold int64, onLag func(PartitionLag)) OptionFunc {
+	return func(c *Component) error {
+		if interval <= 0 {
+			return errors.New("lag monitoring interval should be positive")
+		}
+		if threshold < 0 {
+			return errors.New("lag threshold should be a positive number or zero")
+		}
+		c.lagInterval = interval
+		c.lagThreshold = threshold
+		c.onLag = onLag
+		return nil
+	}
+}
+
+// PartitionConcurrency processes the assigned partitions concurrently, while preserving the order of the messages of every partition.
+// Every partition is consumed by a goroutine of its own, which batches its messages separately from the other partitions,
+// through a queue of up to maxInFlight messages received but not processed yet, which stops consuming the partition once full.
+// Up to parallelism batches of different partitions are processed at the same time, so the processor should be safe for concurrent use.
+func PartitionConcurrency(parallelism, maxInFlight uint) OptionFunc {
+	return func(c *Component) error {
+		if parallelism == 0 {
+			return errors.New("partition parallelism should be positive")
+		}
+		if maxInFlight == 0 {
+			return errors.New("partition max in-flight messages should be positive")
+		}
+		c.partitionParallelism = parallelism
+		c.partitionMaxInFlight = maxInFlight
+		return nil
+	}
+}
+
+// Decoder sets the decoder of the values of the consumed messages, which the processor decodes with kafka.Decode,
+// e.g. kafka.JSONDecoder or a decoder of the Schema Registry wire format.
+func Decoder(dec kafka.Decoder) OptionFunc {
+	return func(c *Component) error {
+		if dec == nil {
+			return errors.New("decoder is nil")
+		}
+		c.decoder = dec
+		return nil
+	}
+}
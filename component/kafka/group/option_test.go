@@ -1,6 +1,7 @@
 package group
 
 import (
+	"context"
 	"testing"
 	"time"
 
@@ -151,6 +152,32 @@ func TestBatchTimeout(t *testing.T) {
 	}
 }
 
+func TestBatchMaxBytes(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		bytes       int
+		expectedErr string
+	}{
+		"success":        {bytes: 1 << 20},
+		"zero bytes":     {bytes: 0, expectedErr: "batch max bytes should be positive"},
+		"negative bytes": {bytes: -1, expectedErr: "batch max bytes should be positive"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			c := &Component{}
+			err := BatchMaxBytes(tt.bytes)(c)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.bytes, c.batchMaxBytes)
+			}
+		})
+	}
+}
+
 func TestNewSessionCallback(t *testing.T) {
 	t.Parallel()
 	type args struct {
@@ -193,3 +220,132 @@ func TestBatchMessageDeduplication(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, c.batchMessageDeduplication, true)
 }
+
+func TestProcessingDeadline(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		timeout     time.Duration
+		action      DeadlineAction
+		expectedErr string
+	}{
+		"success":        {timeout: time.Second, action: RetryOnDeadline},
+		"zero timeout":   {action: CancelOnDeadline, expectedErr: "processing deadline should be a positive number"},
+		"invalid action": {timeout: time.Second, action: 3, expectedErr: "invalid deadline action provided"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			c := &Component{}
+			err := ProcessingDeadline(tt.timeout, tt.action)(c)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, processingDeadline{timeout: tt.timeout, action: tt.action}, c.deadline)
+			}
+		})
+	}
+}
+
+func TestStuckHandlerDetection(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		threshold   time.Duration
+		expectedErr string
+	}{
+		"success":            {threshold: time.Minute},
+		"default threshold":  {},
+		"negative threshold": {threshold: -time.Second, expectedErr: "stuck handler threshold should be a positive number or zero"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			c := &Component{}
+			err := StuckHandlerDetection(tt.threshold, func(StuckBatch) {})(c)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.True(t, c.stuckDetection)
+				assert.Equal(t, tt.threshold, c.stuckThreshold)
+				assert.NotNil(t, c.onStuck)
+			}
+		})
+	}
+}
+
+func TestPartitionConcurrency(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		parallelism uint
+		maxInFlight uint
+		expectedErr string
+	}{
+		"success":          {parallelism: 4, maxInFlight: 100},
+		"zero parallelism": {maxInFlight: 100, expectedErr: "partition parallelism should be positive"},
+		"zero in-flight":   {parallelism: 4, expectedErr: "partition max in-flight messages should be positive"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			c := &Component{}
+			err := PartitionConcurrency(tt.parallelism, tt.maxInFlight)(c)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.parallelism, c.partitionParallelism)
+				assert.Equal(t, tt.maxInFlight, c.partitionMaxInFlight)
+			}
+		})
+	}
+}
+
+func TestDecoder(t *testing.T) {
+	t.Parallel()
+	c := &Component{}
+	assert.EqualError(t, Decoder(nil)(c), "decoder is nil")
+	assert.Nil(t, c.decoder)
+	assert.NoError(t, Decoder(kafka.JSONDecoder)(c))
+	assert.NotNil(t, c.decoder)
+}
+
+func TestOffsetCommit(t *testing.T) {
+	t.Parallel()
+	c := &Component{}
+	assert.EqualError(t, OffsetCommit(CommitStrategy(-1))(c), "invalid commit strategy provided")
+	assert.EqualError(t, OffsetCommit(CommitManual+1)(c), "invalid commit strategy provided")
+	assert.Equal(t, CommitInterval, c.commitStrategy)
+	assert.NoError(t, OffsetCommit(CommitManual)(c))
+	assert.Equal(t, CommitManual, c.commitStrategy)
+}
+
+func TestAutoCommitInterval(t *testing.T) {
+	t.Parallel()
+	cfg := sarama.NewConfig()
+	cfg.Consumer.Offsets.AutoCommit.Enable = false
+	c := &Component{saramaConfig: cfg}
+	assert.EqualError(t, AutoCommitInterval(0)(c), "auto-commit interval should be positive")
+	assert.NoError(t, AutoCommitInterval(5*time.Second)(c))
+	assert.True(t, cfg.Consumer.Offsets.AutoCommit.Enable)
+	assert.Equal(t, 5*time.Second, cfg.Consumer.Offsets.AutoCommit.Interval)
+}
+
+func TestPartitionsAssigned(t *testing.T) {
+	t.Parallel()
+	c := &Component{}
+	assert.EqualError(t, PartitionsAssigned(nil)(c), "nil partitions assigned callback")
+	assert.NoError(t, PartitionsAssigned(func(context.Context, map[string][]int32) error { return nil })(c))
+	assert.NotNil(t, c.onAssigned)
+}
+
+func TestPartitionsRevoked(t *testing.T) {
+	t.Parallel()
+	c := &Component{}
+	assert.EqualError(t, PartitionsRevoked(nil)(c), "nil partitions revoked callback")
+	assert.NoError(t, PartitionsRevoked(func(context.Context, map[string][]int32) error { return nil })(c))
+	assert.NotNil(t, c.onRevoked)
+}
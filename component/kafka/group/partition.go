@@ -0,0 +1,109 @@
+package group
+
+import (
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/beatlabs/patron/log"
+)
+
+// consumeOrdered consumes the messages of the claim through a bounded queue, which is processed by a goroutine of its partition
+// in batches of its own, so that the partitions are processed concurrently up to the parallelism of the handler,
+// while the messages of each partition are processed in order.
+func (c *consumerHandler) consumeOrdered(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	ph := c.partitionHandler()
+	defer ph.ticker.Stop()
+
+	queue := make(chan *sarama.ConsumerMessage, c.maxInFlight)
+	stop := make(chan struct{})
+	done := make(chan error, 1)
+	go func() {
+		done <- ph.processQueue(session, queue, stop)
+	}()
+
+	for {
+		select {
+		case msg, ok := <-claim.Messages():
+			if !ok {
+				log.Debug("messages channel closed")
+				close(stop)
+				return c.partitionDone(ph, <-done)
+			}
+			log.Debugf("message claimed: value = %s, timestamp = %v, topic = %s", string(msg.Value), msg.Timestamp, msg.Topic)
+			topicPartitionOffsetDiffGaugeSet(c.group, msg.Topic, msg.Partition, claim.HighWaterMarkOffset(), msg.Offset)
+			messageStatusCountInc(messageReceived, c.group, msg.Topic)
+			select {
+			case queue <- msg:
+			case err := <-done:
+				return c.partitionDone(ph, err)
+			}
+		case err := <-done:
+			return c.partitionDone(ph, err)
+		case <-c.ctx.Done():
+			close(stop)
+			return c.partitionDone(ph, <-done)
+		}
+	}
+}
+
+// processQueue processes the queued messages of a partition until it is stopped, or its processing fails.
+// The messages left in the queue or the buffer are not marked, so they are consumed again by the next session.
+func (c *consumerHandler) processQueue(session sarama.ConsumerGroupSession, queue <-chan *sarama.ConsumerMessage, stop <-chan struct{}) error {
+	for {
+		select {
+		case <-stop:
+			return nil
+		case <-c.ctx.Done():
+			return nil
+		case msg := <-queue:
+			if err := c.insertMessage(session, msg); err != nil {
+				return err
+			}
+		case <-c.ticker.C:
+			c.mu.Lock()
+			err := c.flush(session, batchTriggerTimeout)
+			c.mu.Unlock()
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// partitionHandler returns a handler of the same configuration, whose buffer and batch timeout concern a single partition.
+func (c *consumerHandler) partitionHandler() *consumerHandler {
+	return &consumerHandler{
+		ctx:                       c.ctx,
+		name:                      c.name,
+		group:                     c.group,
+		batchSize:                 c.batchSize,
+		batchMaxBytes:             c.batchMaxBytes,
+		batchTimeout:              c.batchTimeout,
+		ticker:                    time.NewTicker(c.batchTimeout),
+		batchMessageDeduplication: c.batchMessageDeduplication,
+		proc:                      c.proc,
+		failStrategy:              c.failStrategy,
+		commitStrategy:            c.commitStrategy,
+		msgBuf:                    make([]*sarama.ConsumerMessage, 0, c.batchSize),
+		deadline:                  c.deadline,
+		deadLetter:                c.deadLetter,
+		watchdog:                  c.watchdog,
+		parallelism:               c.parallelism,
+		decoder:                   c.decoder,
+		onAssigned:                c.onAssigned,
+		onRevoked:                 c.onRevoked,
+	}
+}
+
+// partitionDone records the outcome of the processing of a partition in the handler, whose error fails the component.
+func (c *consumerHandler) partitionDone(ph *consumerHandler, err error) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if ph.processedMessages {
+		c.processedMessages = true
+	}
+	if c.err == nil {
+		c.err = ph.err
+	}
+	return err
+}
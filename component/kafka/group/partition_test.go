@@ -0,0 +1,147 @@
+package group
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/beatlabs/patron/component/kafka"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type partitionClaim struct {
+	partition int32
+	ch        chan *sarama.ConsumerMessage
+}
+
+func (p *partitionClaim) Messages() <-chan *sarama.ConsumerMessage { return p.ch }
+func (p *partitionClaim) Topic() string                            { return "orders" }
+func (p *partitionClaim) Partition() int32                         { return p.partition }
+func (p *partitionClaim) InitialOffset() int64                     { return 0 }
+func (p *partitionClaim) HighWaterMarkOffset() int64               { return 1 }
+
+func newPartitionClaim(partition int32, messages int) *partitionClaim {
+	ch := make(chan *sarama.ConsumerMessage, messages)
+	for i := 0; i < messages; i++ {
+		msg := saramaConsumerMessage("value", &sarama.RecordHeader{})
+		msg.Topic = "orders"
+		msg.Partition = partition
+		msg.Offset = int64(i)
+		ch <- msg
+	}
+	return &partitionClaim{partition: partition, ch: ch}
+}
+
+func TestConsumerHandler_PartitionConcurrency(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		parallelism            int
+		expectedMaxConcurrency int32
+	}{
+		"parallel partitions":   {parallelism: 3, expectedMaxConcurrency: 3},
+		"bounded parallelism":   {parallelism: 2, expectedMaxConcurrency: 2},
+		"sequential partitions": {parallelism: 1, expectedMaxConcurrency: 1},
+	}
+	for name, tt := range tests {
+		name, tt := name, tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			const partitions, messages = 3, 6
+			claims := make([]*partitionClaim, 0, partitions)
+			for p := int32(0); p < partitions; p++ {
+				claims = append(claims, newPartitionClaim(p, messages))
+			}
+
+			var mu sync.Mutex
+			offsets := make(map[int32][]int64)
+			var concurrent, maxConcurrent, processed int32
+			proc := func(btc kafka.Batch) error {
+				current := atomic.AddInt32(&concurrent, 1)
+				defer atomic.AddInt32(&concurrent, -1)
+				for {
+					max := atomic.LoadInt32(&maxConcurrent)
+					if current <= max || atomic.CompareAndSwapInt32(&maxConcurrent, max, current) {
+						break
+					}
+				}
+				time.Sleep(10 * time.Millisecond)
+				mu.Lock()
+				for _, m := range btc.Messages() {
+					offsets[m.Message().Partition] = append(offsets[m.Message().Partition], m.Message().Offset)
+				}
+				mu.Unlock()
+				if atomic.AddInt32(&processed, int32(len(btc.Messages()))) == partitions*messages {
+					for _, c := range claims {
+						close(c.ch)
+					}
+				}
+				return nil
+			}
+
+			h := newConsumerHandler(context.Background(), name, "grp", proc, kafka.ExitStrategy, 2,
+				10*time.Millisecond, CommitInterval, false, nil)
+			h.parallelism = make(chan struct{}, tt.parallelism)
+			h.maxInFlight = 2
+
+			var wg sync.WaitGroup
+			session := &mockConsumerSession{}
+			for _, c := range claims {
+				wg.Add(1)
+				go func(c *partitionClaim) {
+					defer wg.Done()
+					assert.NoError(t, h.ConsumeClaim(session, c))
+				}(c)
+			}
+			wg.Wait()
+
+			assert.Equal(t, tt.expectedMaxConcurrency, atomic.LoadInt32(&maxConcurrent))
+			assert.True(t, h.processedMessages)
+			for p := int32(0); p < partitions; p++ {
+				assert.Equal(t, []int64{0, 1, 2, 3, 4, 5}, offsets[p])
+			}
+		})
+	}
+}
+
+func TestConsumerHandler_PartitionConcurrency_Failure(t *testing.T) {
+	t.Parallel()
+	errProcessing := errors.New("processing failed")
+	var batches int32
+	proc := func(kafka.Batch) error {
+		atomic.AddInt32(&batches, 1)
+		return errProcessing
+	}
+	h := newConsumerHandler(context.Background(), "failure", "grp", proc, kafka.ExitStrategy, 1,
+		10*time.Millisecond, CommitInterval, false, nil)
+	h.parallelism = make(chan struct{}, 2)
+	h.maxInFlight = 1
+
+	err := h.ConsumeClaim(&mockConsumerSession{}, newPartitionClaim(0, 3))
+
+	assert.Equal(t, errProcessing, err)
+	assert.Equal(t, errProcessing, h.err)
+	assert.False(t, h.processedMessages)
+	// the messages following the failed one are not processed, in order to preserve their order
+	assert.Equal(t, int32(1), atomic.LoadInt32(&batches))
+}
+
+func TestConsumerHandler_PartitionConcurrency_Cancel(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	proc := func(kafka.Batch) error {
+		cancel()
+		return nil
+	}
+	h := newConsumerHandler(ctx, "cancel", "grp", proc, kafka.ExitStrategy, 1,
+		10*time.Millisecond, CommitInterval, false, nil)
+	h.parallelism = make(chan struct{}, 1)
+	h.maxInFlight = 1
+
+	require.NoError(t, h.ConsumeClaim(&mockConsumerSession{}, newPartitionClaim(0, 3)))
+	assert.NoError(t, h.err)
+}
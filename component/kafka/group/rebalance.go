@@ -0,0 +1,42 @@
+package group
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Shopify/sarama"
+	"github.com/beatlabs/patron/log"
+)
+
+// PartitionsFunc is called with the partitions of a rebalance, keyed by topic, e.g. to warm caches for the assigned partitions,
+// or to flush local state and close the resources of the revoked ones. The context of the assigned partitions is done when the session ends,
+// while the context of the revoked ones, whose session has already ended, is done when the rebalance timeout of the group elapses.
+type PartitionsFunc func(ctx context.Context, partitions map[string][]int32) error
+
+// partitionsAssigned calls the callback of the assigned partitions, whose error fails the session before consuming them.
+func (c *consumerHandler) partitionsAssigned(session sarama.ConsumerGroupSession) error {
+	if c.onAssigned == nil {
+		return nil
+	}
+	log.Debugf("partitions assigned to member %s of kafka consumer group %s: %v", session.MemberID(), c.group, session.Claims())
+	if err := c.onAssigned(session.Context(), session.Claims()); err != nil {
+		return fmt.Errorf("failed to handle the assigned partitions: %w", err)
+	}
+	return nil
+}
+
+// partitionsRevoked calls the callback of the revoked partitions, once their messages are no longer processed.
+func (c *consumerHandler) partitionsRevoked(session sarama.ConsumerGroupSession) error {
+	if c.onRevoked == nil {
+		return nil
+	}
+	log.Debugf("partitions revoked from member %s of kafka consumer group %s: %v", session.MemberID(), c.group, session.Claims())
+	// Sarama cancels the context of the session before cleaning it up, as does the component when it stops
+	ctx, cnl := context.WithTimeout(context.Background(), c.revokeTimeout)
+	defer cnl()
+	ctx = log.WithContext(ctx, log.FromContext(c.ctx))
+	if err := c.onRevoked(ctx, session.Claims()); err != nil {
+		return fmt.Errorf("failed to handle the revoked partitions: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package group
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/beatlabs/patron/component/kafka"
+	"github.com/stretchr/testify/assert"
+)
+
+// claimingConsumerSession has claims, and records the commits.
+type claimingConsumerSession struct {
+	committingConsumerSession
+	claims map[string][]int32
+	ctx    context.Context
+}
+
+func (m *claimingConsumerSession) Context() context.Context {
+	if m.ctx == nil {
+		return context.Background()
+	}
+	return m.ctx
+}
+
+func (m *claimingConsumerSession) Claims() map[string][]int32 {
+	return m.claims
+}
+
+func TestConsumerHandler_Rebalance(t *testing.T) {
+	t.Parallel()
+	claims := map[string][]int32{"orders": {0, 2}}
+	tests := map[string]struct {
+		sessionErr         error
+		assignedErr        error
+		revokedErr         error
+		expectedSetupErr   string
+		expectedCleanupErr string
+		expectedCalls      []string
+	}{
+		"success": {expectedCalls: []string{"session", "assigned", "revoked"}},
+		"session callback failure": {
+			sessionErr:       errors.New("session failure"),
+			expectedSetupErr: "session failure",
+			expectedCalls:    []string{"session", "revoked"},
+		},
+		"assigned failure": {
+			assignedErr:      errors.New("cache failure"),
+			expectedSetupErr: "failed to handle the assigned partitions: cache failure",
+			expectedCalls:    []string{"session", "assigned", "revoked"},
+		},
+		"revoked failure": {
+			revokedErr:         errors.New("flush failure"),
+			expectedCleanupErr: "failed to handle the revoked partitions: flush failure",
+			expectedCalls:      []string{"session", "assigned", "revoked"},
+		},
+	}
+	for name, tt := range tests {
+		name := name
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			var calls []string
+			callback := func(call string, err error) PartitionsFunc {
+				return func(_ context.Context, partitions map[string][]int32) error {
+					assert.Equal(t, claims, partitions)
+					calls = append(calls, call)
+					return err
+				}
+			}
+			h := newConsumerHandler(context.Background(), name, "grp", func(kafka.Batch) error { return nil },
+				kafka.ExitStrategy, 1, time.Second, CommitAfterBatch, false, func(sarama.ConsumerGroupSession) error {
+					calls = append(calls, "session")
+					return tt.sessionErr
+				})
+			defer h.ticker.Stop()
+			h.onAssigned = callback("assigned", tt.assignedErr)
+			h.onRevoked = callback("revoked", tt.revokedErr)
+			session := &claimingConsumerSession{claims: claims}
+
+			// Sarama cleans up the session even if its setup fails
+			err := h.Setup(session)
+			if tt.expectedSetupErr != "" {
+				assert.EqualError(t, err, tt.expectedSetupErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			err = h.Cleanup(session)
+			if tt.expectedCleanupErr != "" {
+				assert.EqualError(t, err, tt.expectedCleanupErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.expectedCalls, calls)
+			// the marked offsets are committed regardless of the callback of the revoked partitions
+			assert.Equal(t, 1, session.commits)
+		})
+	}
+}
+
+func TestConsumerHandler_Rebalance_RevokedContext(t *testing.T) {
+	t.Parallel()
+	h := newConsumerHandler(context.Background(), "name", "grp", func(kafka.Batch) error { return nil },
+		kafka.ExitStrategy, 1, time.Second, CommitAfterBatch, false, nil)
+	defer h.ticker.Stop()
+	h.revokeTimeout = time.Minute
+	h.onRevoked = func(ctx context.Context, _ map[string][]int32) error {
+		deadline, ok := ctx.Deadline()
+		assert.True(t, ok)
+		assert.WithinDuration(t, time.Now().Add(time.Minute), deadline, time.Second)
+		return ctx.Err()
+	}
+
+	// Sarama cancels the context of the session before cleaning it up
+	sessionCtx, cnl := context.WithCancel(context.Background())
+	cnl()
+	assert.NoError(t, h.Cleanup(&claimingConsumerSession{claims: map[string][]int32{"orders": {0}}, ctx: sessionCtx}))
+}
+
+func TestConsumerHandler_Rebalance_WithoutCallbacks(t *testing.T) {
+	t.Parallel()
+	h := &consumerHandler{}
+	session := &claimingConsumerSession{claims: map[string][]int32{"orders": {0}}}
+	assert.NoError(t, h.Setup(session))
+	assert.NoError(t, h.Cleanup(session))
+	assert.Zero(t, session.commits)
+}
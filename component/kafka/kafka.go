@@ -8,6 +8,7 @@ import (
 	"os"
 
 	"github.com/Shopify/sarama"
+	"github.com/beatlabs/patron/encoding/json"
 	"github.com/opentracing/opentracing-go"
 )
 
@@ -35,6 +36,24 @@ type Message interface {
 	Span() opentracing.Span
 }
 
+// Decoder decodes the values of the consumed messages, e.g. JSON values or values of the Schema Registry wire format.
+type Decoder interface {
+	Decode(ctx context.Context, msg *sarama.ConsumerMessage, v interface{}) error
+}
+
+// DecoderFunc is an adapter to use ordinary functions as decoders.
+type DecoderFunc func(ctx context.Context, msg *sarama.ConsumerMessage, v interface{}) error
+
+// Decode calls f(ctx, msg, v).
+func (f DecoderFunc) Decode(ctx context.Context, msg *sarama.ConsumerMessage, v interface{}) error {
+	return f(ctx, msg, v)
+}
+
+// JSONDecoder decodes JSON message values.
+var JSONDecoder Decoder = DecoderFunc(func(_ context.Context, msg *sarama.ConsumerMessage, v interface{}) error {
+	return json.DecodeRaw(msg.Value, v)
+})
+
 // NewMessage initializes a new message which is an implementation of the kafka Message interface.
 func NewMessage(ctx context.Context, sp opentracing.Span, msg *sarama.ConsumerMessage) Message {
 	return &message{
@@ -44,10 +63,31 @@ func NewMessage(ctx context.Context, sp opentracing.Span, msg *sarama.ConsumerMe
 	}
 }
 
+// NewDecodableMessage initializes a new message whose value is decoded by Decode with the provided decoder.
+func NewDecodableMessage(ctx context.Context, sp opentracing.Span, msg *sarama.ConsumerMessage, dec Decoder) Message {
+	return &message{
+		ctx: ctx,
+		sp:  sp,
+		msg: msg,
+		dec: dec,
+	}
+}
+
+// Decode decodes the value of the message into v, with the decoder of the consumer of the message,
+// e.g. the one set with the Decoder option of the group component.
+func Decode(msg Message, v interface{}) error {
+	m, ok := msg.(*message)
+	if !ok || m.dec == nil {
+		return errors.New("message has no decoder")
+	}
+	return m.dec.Decode(m.ctx, m.msg, v)
+}
+
 type message struct {
 	ctx context.Context
 	sp  opentracing.Span
 	msg *sarama.ConsumerMessage
+	dec Decoder
 }
 
 // Context will contain the context to be used for processing.
@@ -79,8 +119,36 @@ func NewBatch(messages []Message) Batch {
 	}
 }
 
+// Committer marks and commits the offsets of the consumed messages, when the processor manages them,
+// e.g. with the CommitManual strategy of the group component.
+type Committer interface {
+	// Mark marks the message as processed, so that its offset, along with the ones of the previous messages of its partition,
+	// is committed by the next commit.
+	Mark(msg Message)
+	// Commit commits the offsets of the marked messages in a blocking operation.
+	Commit()
+}
+
+// NewCommittableBatch initializes a new batch of messages, whose offsets are marked and committed by the processor with the committer.
+func NewCommittableBatch(messages []Message, committer Committer) Batch {
+	return &batch{
+		messages:  messages,
+		committer: committer,
+	}
+}
+
+// BatchCommitter returns the committer of the batch, if the processor manages the offsets of its messages.
+func BatchCommitter(b Batch) (Committer, bool) {
+	btc, ok := b.(*batch)
+	if !ok || btc.committer == nil {
+		return nil, false
+	}
+	return btc.committer, true
+}
+
 type batch struct {
-	messages []Message
+	messages  []Message
+	committer Committer
 }
 
 // Messages of the batch.
@@ -2,6 +2,7 @@ package kafka
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"testing"
@@ -58,6 +59,26 @@ func Test_NewBatch(t *testing.T) {
 	assert.Equal(t, 1, len(btc.Messages()))
 }
 
+type stubCommitter struct{}
+
+func (stubCommitter) Mark(Message) {}
+func (stubCommitter) Commit()      {}
+
+func Test_BatchCommitter(t *testing.T) {
+	msg := NewMessage(context.Background(), nil, &sarama.ConsumerMessage{Topic: "topicone"})
+
+	_, ok := BatchCommitter(NewBatch([]Message{msg}))
+	assert.False(t, ok)
+	_, ok = BatchCommitter(NewCommittableBatch([]Message{msg}, nil))
+	assert.False(t, ok)
+
+	btc := NewCommittableBatch([]Message{msg}, stubCommitter{})
+	committer, ok := BatchCommitter(btc)
+	assert.True(t, ok)
+	assert.Equal(t, stubCommitter{}, committer)
+	assert.Equal(t, []Message{msg}, btc.Messages())
+}
+
 func Test_Message(t *testing.T) {
 	ctx := context.Background()
 	cm := &sarama.ConsumerMessage{
@@ -78,6 +99,26 @@ func Test_Message(t *testing.T) {
 	assert.Equal(t, cm, msg.Message())
 }
 
+func Test_Decode(t *testing.T) {
+	ctx := context.Background()
+	cm := &sarama.ConsumerMessage{Topic: "topicone", Value: []byte(`{"key":"value"}`)}
+
+	var v map[string]string
+	assert.EqualError(t, Decode(NewMessage(ctx, nil, cm), &v), "message has no decoder")
+	assert.EqualError(t, Decode(NewDecodableMessage(ctx, nil, cm, nil), &v), "message has no decoder")
+
+	require.NoError(t, Decode(NewDecodableMessage(ctx, nil, cm, JSONDecoder), &v))
+	assert.Equal(t, map[string]string{"key": "value"}, v)
+
+	var decodedCtx context.Context
+	dec := DecoderFunc(func(ctx context.Context, msg *sarama.ConsumerMessage, v interface{}) error {
+		decodedCtx = ctx
+		return errors.New("invalid value")
+	})
+	assert.EqualError(t, Decode(NewDecodableMessage(ctx, nil, cm, dec), &v), "invalid value")
+	assert.Equal(t, ctx, decodedCtx)
+}
+
 func Test_DefaultConsumerSaramaConfig(t *testing.T) {
 	sc, err := DefaultConsumerSaramaConfig("name", true)
 	require.NoError(t, err)
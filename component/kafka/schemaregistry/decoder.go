@@ -0,0 +1,115 @@
+package schemaregistry
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/Shopify/sarama"
+	"github.com/beatlabs/patron/encoding/json"
+	"github.com/beatlabs/patron/encoding/protobuf"
+)
+
+// the values of the wire format start with a zero magic byte, followed by the schema ID as a big-endian 32-bit integer
+const (
+	magicByte  = 0
+	headerSize = 5
+)
+
+// AvroDecodeFunc decodes the Avro data written with the schema into v.
+type AvroDecodeFunc func(schema *Schema, data []byte, v interface{}) error
+
+// JSONValidateFunc validates the JSON data against the schema.
+type JSONValidateFunc func(schema *Schema, data []byte) error
+
+// Decoder decodes the message values of the wire format, i.e. a zero magic byte and the ID of the schema they were written with,
+// followed by the data. Protobuf values are decoded into proto.Message values and JSON values with the json package,
+// while Avro values are decoded with the function of the Avro option, since the Avro libraries are left to the services.
+type Decoder struct {
+	registry     *Registry
+	avro         AvroDecodeFunc
+	validateJSON JSONValidateFunc
+}
+
+// NewDecoder creates a decoder of the message values whose schemas are fetched from the registry.
+func NewDecoder(registry *Registry, oo ...DecoderOptionFunc) (*Decoder, error) {
+	if registry == nil {
+		return nil, errors.New("registry is nil")
+	}
+
+	d := &Decoder{registry: registry}
+	for _, option := range oo {
+		if err := option(d); err != nil {
+			return nil, err
+		}
+	}
+	return d, nil
+}
+
+// Decode decodes the value of the message into v.
+func (d *Decoder) Decode(ctx context.Context, msg *sarama.ConsumerMessage, v interface{}) error {
+	id, data, err := Parse(msg.Value)
+	if err != nil {
+		return err
+	}
+	schema, err := d.registry.Schema(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	switch schema.Type {
+	case TypeAvro:
+		if d.avro == nil {
+			return errors.New("avro decode function is not configured")
+		}
+		err = d.avro(schema, data, v)
+	case TypeProtobuf:
+		data, err = skipMessageIndexes(data)
+		if err == nil {
+			err = protobuf.DecodeRaw(data, v)
+		}
+	case TypeJSON:
+		if d.validateJSON != nil {
+			if err := d.validateJSON(schema, data); err != nil {
+				return fmt.Errorf("invalid value of schema %d: %w", id, err)
+			}
+		}
+		err = json.DecodeRaw(data, v)
+	default:
+		return fmt.Errorf("unsupported type %s of schema %d", schema.Type, id)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to decode value of schema %d: %w", id, err)
+	}
+	return nil
+}
+
+// Parse returns the schema ID and the data of a value of the wire format.
+func Parse(value []byte) (int, []byte, error) {
+	if len(value) < headerSize {
+		return 0, nil, errors.New("value is shorter than the wire format header")
+	}
+	if value[0] != magicByte {
+		return 0, nil, fmt.Errorf("unknown magic byte %d", value[0])
+	}
+	return int(binary.BigEndian.Uint32(value[1:headerSize])), value[headerSize:], nil
+}
+
+// skipMessageIndexes skips the indexes of the message type in the schema, which precede the data of the Protobuf values,
+// i.e. their count and the indexes as zigzag varints, or a single zero for the first message type.
+func skipMessageIndexes(data []byte) ([]byte, error) {
+	count, n := binary.Varint(data)
+	if n <= 0 || count < 0 {
+		return nil, errors.New("invalid message indexes")
+	}
+	data = data[n:]
+	for i := int64(0); i < count; i++ {
+		_, n = binary.Varint(data)
+		if n <= 0 {
+			return nil, errors.New("invalid message indexes")
+		}
+		data = data[n:]
+	}
+	return data, nil
+}
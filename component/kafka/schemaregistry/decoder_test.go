@@ -0,0 +1,174 @@
+package schemaregistry
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/beatlabs/patron/component/kafka"
+	"github.com/beatlabs/patron/encoding/protobuf"
+	"github.com/beatlabs/patron/examples"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var _ kafka.Decoder = &Decoder{}
+
+func TestNewDecoder(t *testing.T) {
+	t.Parallel()
+	r, err := New("http://localhost:8081")
+	require.NoError(t, err)
+	avro := func(*Schema, []byte, interface{}) error { return nil }
+	validate := func(*Schema, []byte) error { return nil }
+
+	tests := map[string]struct {
+		registry    *Registry
+		oo          []DecoderOptionFunc
+		expectedErr string
+	}{
+		"success":        {registry: r, oo: []DecoderOptionFunc{Avro(avro), JSONSchema(validate)}},
+		"nil registry":   {expectedErr: "registry is nil"},
+		"nil avro":       {registry: r, oo: []DecoderOptionFunc{Avro(nil)}, expectedErr: "avro decode function is nil"},
+		"nil validation": {registry: r, oo: []DecoderOptionFunc{JSONSchema(nil)}, expectedErr: "json schema validate function is nil"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			d, err := NewDecoder(tt.registry, tt.oo...)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.Nil(t, d)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, d.avro)
+				assert.NotNil(t, d.validateJSON)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+	id, data, err := Parse(wireFormat(42, []byte("data")))
+	assert.NoError(t, err)
+	assert.Equal(t, 42, id)
+	assert.Equal(t, []byte("data"), data)
+
+	_, _, err = Parse([]byte{0, 0, 0})
+	assert.EqualError(t, err, "value is shorter than the wire format header")
+	_, _, err = Parse([]byte{1, 0, 0, 0, 1})
+	assert.EqualError(t, err, "unknown magic byte 1")
+}
+
+func TestDecoder_Decode(t *testing.T) {
+	t.Parallel()
+	rs := newRegistryServer(t, map[int]string{
+		1: `{"schema":"{\"type\":\"string\"}"}`,
+		2: `{"schema":"syntax = \"proto3\";","schemaType":"PROTOBUF"}`,
+		3: `{"schema":"{\"type\":\"object\"}","schemaType":"JSON"}`,
+		4: `{"schema":"","schemaType":"XML"}`,
+	})
+	r, err := New(rs.URL)
+	require.NoError(t, err)
+
+	user, err := protobuf.Encode(&examples.User{Firstname: "John", Lastname: "Doe"})
+	require.NoError(t, err)
+
+	avro := Avro(func(schema *Schema, data []byte, v interface{}) error {
+		if schema.Schema != `{"type":"string"}` {
+			return errors.New("unexpected schema")
+		}
+		*(v.(*string)) = string(data)
+		return nil
+	})
+	validate := JSONSchema(func(_ *Schema, data []byte) error {
+		if string(data) == `{}` {
+			return errors.New("firstname is required")
+		}
+		return nil
+	})
+
+	tests := map[string]struct {
+		oo          []DecoderOptionFunc
+		value       []byte
+		v           interface{}
+		expected    interface{}
+		expectedErr string
+	}{
+		"avro": {
+			oo: []DecoderOptionFunc{avro}, value: wireFormat(1, []byte("John")), v: new(string), expected: "John",
+		},
+		"avro without decode function": {
+			value: wireFormat(1, []byte("John")), v: new(string), expectedErr: "avro decode function is not configured",
+		},
+		"protobuf of the first message type": {
+			value: wireFormat(2, append([]byte{0}, user...)), v: &examples.User{}, expected: "John Doe",
+		},
+		"protobuf of a nested message type": {
+			value: wireFormat(2, append(messageIndexes(1, 0), user...)), v: &examples.User{}, expected: "John Doe",
+		},
+		"protobuf with invalid indexes": {
+			value: wireFormat(2, []byte{0x80}), v: &examples.User{}, expectedErr: "failed to decode value of schema 2: invalid message indexes",
+		},
+		"protobuf into a non-proto value": {
+			value:       wireFormat(2, append([]byte{0}, user...)),
+			v:           new(string),
+			expectedErr: "failed to decode value of schema 2: failed to type assert to proto message",
+		},
+		"json": {
+			oo: []DecoderOptionFunc{validate}, value: wireFormat(3, []byte(`{"firstname":"John","lastname":"Doe"}`)), v: &examples.User{}, expected: "John Doe",
+		},
+		"invalid json": {
+			oo: []DecoderOptionFunc{validate}, value: wireFormat(3, []byte(`{}`)), v: &examples.User{},
+			expectedErr: "invalid value of schema 3: firstname is required",
+		},
+		"unsupported type": {
+			value: wireFormat(4, nil), v: new(string), expectedErr: "unsupported type XML of schema 4",
+		},
+		"unknown schema": {
+			value: wireFormat(5, nil), v: new(string), expectedErr: "failed to fetch schema 5: unexpected status 404: 40403 Schema not found",
+		},
+		"invalid wire format": {
+			value: []byte(`{}`), v: new(string), expectedErr: "value is shorter than the wire format header",
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			d, err := NewDecoder(r, tt.oo...)
+			require.NoError(t, err)
+			msg := kafka.NewDecodableMessage(context.Background(), nil, &sarama.ConsumerMessage{Value: tt.value}, d)
+			err = kafka.Decode(msg, tt.v)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			switch v := tt.v.(type) {
+			case *string:
+				assert.Equal(t, tt.expected, *v)
+			case *examples.User:
+				assert.Equal(t, tt.expected, v.GetFirstname()+" "+v.GetLastname())
+			}
+		})
+	}
+}
+
+func wireFormat(id uint32, data []byte) []byte {
+	value := make([]byte, headerSize, headerSize+len(data))
+	binary.BigEndian.PutUint32(value[1:], id)
+	return append(value, data...)
+}
+
+func messageIndexes(indexes ...int64) []byte {
+	b := make([]byte, binary.MaxVarintLen64*(len(indexes)+1))
+	n := binary.PutVarint(b, int64(len(indexes)))
+	for _, i := range indexes {
+		n += binary.PutVarint(b[n:], i)
+	}
+	return b[:n]
+}
@@ -0,0 +1,57 @@
+package schemaregistry
+
+import (
+	"errors"
+	"net/http"
+)
+
+// OptionFunc definition for configuring the registry in a functional way.
+type OptionFunc func(*Registry) error
+
+// Client option for setting the HTTP client fetching the schemas (default: a client with a 10s timeout).
+func Client(client *http.Client) OptionFunc {
+	return func(r *Registry) error {
+		if client == nil {
+			return errors.New("client is nil")
+		}
+		r.client = client
+		return nil
+	}
+}
+
+// BasicAuth option for authenticating to the registry, e.g. with the API key and secret of Confluent Cloud.
+func BasicAuth(username, password string) OptionFunc {
+	return func(r *Registry) error {
+		if username == "" {
+			return errors.New("username is empty")
+		}
+		r.username = username
+		r.password = password
+		return nil
+	}
+}
+
+// DecoderOptionFunc definition for configuring the decoder in a functional way.
+type DecoderOptionFunc func(*Decoder) error
+
+// Avro option for decoding the values of Avro schemas, with a function of an Avro library, e.g. hamba/avro or goavro.
+func Avro(decode AvroDecodeFunc) DecoderOptionFunc {
+	return func(d *Decoder) error {
+		if decode == nil {
+			return errors.New("avro decode function is nil")
+		}
+		d.avro = decode
+		return nil
+	}
+}
+
+// JSONSchema option for validating the values of JSON schemas before decoding them, with a function of a JSON Schema library.
+func JSONSchema(validate JSONValidateFunc) DecoderOptionFunc {
+	return func(d *Decoder) error {
+		if validate == nil {
+			return errors.New("json schema validate function is nil")
+		}
+		d.validateJSON = validate
+		return nil
+	}
+}
@@ -0,0 +1,143 @@
+// Package schemaregistry provides a decoder of the Kafka message values of the Confluent Schema Registry wire format,
+// whose schemas are fetched from the registry and cached.
+package schemaregistry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	registryTimeout = 10 * time.Second
+	contentType     = "application/vnd.schemaregistry.v1+json"
+)
+
+// Type of a schema.
+type Type string
+
+const (
+	// TypeAvro of the Avro schemas, which is the type of the schemas without one.
+	TypeAvro Type = "AVRO"
+	// TypeProtobuf of the Protobuf schemas.
+	TypeProtobuf Type = "PROTOBUF"
+	// TypeJSON of the JSON schemas.
+	TypeJSON Type = "JSON"
+)
+
+// Schema of the registry.
+type Schema struct {
+	ID     int
+	Type   Type
+	Schema string
+}
+
+// Registry fetches the schemas of a Schema Registry by their ID, caching them, since the schema of an ID never changes.
+type Registry struct {
+	url      string
+	client   *http.Client
+	username string
+	password string
+
+	mu      sync.RWMutex
+	schemas map[int]*Schema
+	// fetchMu serializes the fetches of the schemas, so that a schema is fetched once by concurrent consumers.
+	fetchMu sync.Mutex
+}
+
+// New creates a client of the registry of the URL, e.g. http://schema-registry:8081.
+func New(url string, oo ...OptionFunc) (*Registry, error) {
+	if url == "" {
+		return nil, errors.New("url is empty")
+	}
+
+	r := &Registry{
+		url:     strings.TrimSuffix(url, "/"),
+		client:  &http.Client{Timeout: registryTimeout},
+		schemas: make(map[int]*Schema),
+	}
+	for _, option := range oo {
+		if err := option(r); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// Schema returns the schema of the ID, which is fetched from the registry only if it is not cached.
+func (r *Registry) Schema(ctx context.Context, id int) (*Schema, error) {
+	if s, ok := r.cached(id); ok {
+		return s, nil
+	}
+
+	r.fetchMu.Lock()
+	defer r.fetchMu.Unlock()
+	if s, ok := r.cached(id); ok {
+		return s, nil
+	}
+	s, err := r.fetch(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch schema %d: %w", id, err)
+	}
+
+	r.mu.Lock()
+	r.schemas[id] = s
+	r.mu.Unlock()
+	return s, nil
+}
+
+func (r *Registry) cached(id int) (*Schema, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	s, ok := r.schemas[id]
+	return s, ok
+}
+
+func (r *Registry) fetch(ctx context.Context, id int) (*Schema, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/schemas/ids/%d", r.url, id), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", contentType)
+	if r.username != "" {
+		req.SetBasicAuth(r.username, r.password)
+	}
+	rsp, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_, _ = io.Copy(ioutil.Discard, rsp.Body)
+		_ = rsp.Body.Close()
+	}()
+
+	if rsp.StatusCode != http.StatusOK {
+		var rspErr struct {
+			Code    int    `json:"error_code"`
+			Message string `json:"message"`
+		}
+		if err := json.NewDecoder(rsp.Body).Decode(&rspErr); err != nil || rspErr.Message == "" {
+			return nil, fmt.Errorf("unexpected status %d", rsp.StatusCode)
+		}
+		return nil, fmt.Errorf("unexpected status %d: %d %s", rsp.StatusCode, rspErr.Code, rspErr.Message)
+	}
+
+	var doc struct {
+		Schema     string `json:"schema"`
+		SchemaType Type   `json:"schemaType"`
+	}
+	if err := json.NewDecoder(rsp.Body).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if doc.SchemaType == "" {
+		doc.SchemaType = TypeAvro
+	}
+	return &Schema{ID: id, Type: doc.SchemaType, Schema: doc.Schema}, nil
+}
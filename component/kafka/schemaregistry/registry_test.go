@@ -0,0 +1,154 @@
+package schemaregistry
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// registryServer serves the schemas of the IDs, counting the requests per ID.
+type registryServer struct {
+	*httptest.Server
+	schemas  map[int]string
+	requests map[int]*int32
+}
+
+func newRegistryServer(t *testing.T, schemas map[int]string) *registryServer {
+	rs := &registryServer{schemas: schemas, requests: make(map[int]*int32)}
+	for id := range schemas {
+		rs.requests[id] = new(int32)
+	}
+	rs.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var id int
+		if _, err := fmt.Sscanf(r.URL.Path, "/schemas/ids/%d", &id); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if user, pass, ok := r.BasicAuth(); ok && (user != "key" || pass != "secret") {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error_code":401,"message":"Unauthorized"}`))
+			return
+		}
+		doc, ok := rs.schemas[id]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error_code":40403,"message":"Schema not found"}`))
+			return
+		}
+		atomic.AddInt32(rs.requests[id], 1)
+		assert.Equal(t, contentType, r.Header.Get("Accept"))
+		w.Header().Set("Content-Type", contentType)
+		_, _ = w.Write([]byte(doc))
+	}))
+	t.Cleanup(rs.Close)
+	return rs
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		url         string
+		oo          []OptionFunc
+		expectedErr string
+	}{
+		"success":         {url: "http://localhost:8081", oo: []OptionFunc{Client(http.DefaultClient), BasicAuth("key", "secret")}},
+		"empty url":       {expectedErr: "url is empty"},
+		"nil client":      {url: "http://localhost:8081", oo: []OptionFunc{Client(nil)}, expectedErr: "client is nil"},
+		"empty username":  {url: "http://localhost:8081", oo: []OptionFunc{BasicAuth("", "secret")}, expectedErr: "username is empty"},
+		"trailing slash":  {url: "http://localhost:8081/"},
+		"without options": {url: "http://localhost:8081"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			r, err := New(tt.url, tt.oo...)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.Nil(t, r)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, "http://localhost:8081", r.url)
+				assert.NotNil(t, r.client)
+			}
+		})
+	}
+}
+
+func TestRegistry_Schema(t *testing.T) {
+	t.Parallel()
+	rs := newRegistryServer(t, map[int]string{
+		1: `{"schema":"{\"type\":\"string\"}"}`,
+		2: `{"schema":"syntax = \"proto3\";","schemaType":"PROTOBUF"}`,
+		3: `{"schema":"{\"type\":\"object\"}","schemaType":"JSON"}`,
+		4: `not json`,
+	})
+	r, err := New(rs.URL, BasicAuth("key", "secret"))
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		id             int
+		expectedSchema *Schema
+		expectedErr    string
+	}{
+		"avro":      {id: 1, expectedSchema: &Schema{ID: 1, Type: TypeAvro, Schema: `{"type":"string"}`}},
+		"protobuf":  {id: 2, expectedSchema: &Schema{ID: 2, Type: TypeProtobuf, Schema: `syntax = "proto3";`}},
+		"json":      {id: 3, expectedSchema: &Schema{ID: 3, Type: TypeJSON, Schema: `{"type":"object"}`}},
+		"invalid":   {id: 4, expectedErr: "failed to fetch schema 4: failed to decode response: invalid character 'o' in literal null (expecting 'u')"},
+		"not found": {id: 5, expectedErr: "failed to fetch schema 5: unexpected status 404: 40403 Schema not found"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			s, err := r.Schema(context.Background(), tt.id)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.Nil(t, s)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedSchema, s)
+			}
+		})
+	}
+}
+
+func TestRegistry_Schema_Cached(t *testing.T) {
+	t.Parallel()
+	rs := newRegistryServer(t, map[int]string{1: `{"schema":"{\"type\":\"string\"}"}`})
+	r, err := New(rs.URL)
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s, err := r.Schema(context.Background(), 1)
+			assert.NoError(t, err)
+			assert.Equal(t, TypeAvro, s.Type)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), atomic.LoadInt32(rs.requests[1]))
+}
+
+func TestRegistry_Schema_Unauthorized(t *testing.T) {
+	t.Parallel()
+	rs := newRegistryServer(t, map[int]string{1: `{"schema":"{\"type\":\"string\"}"}`})
+	r, err := New(rs.URL, BasicAuth("key", "wrong"))
+	require.NoError(t, err)
+
+	_, err = r.Schema(context.Background(), 1)
+	assert.EqualError(t, err, "failed to fetch schema 1: unexpected status 401: 401 Unauthorized")
+	// failures are not cached
+	_, err = r.Schema(context.Background(), 1)
+	assert.Error(t, err)
+}
@@ -0,0 +1,322 @@
+// Package mqtt provides a component bridging MQTT v5 topics to HTTP handlers,
+// which allows MQTT workloads to be migrated incrementally to HTTP routes.
+package mqtt
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/beatlabs/patron/correlation"
+	"github.com/beatlabs/patron/log"
+	"github.com/beatlabs/patron/propagation"
+	"github.com/beatlabs/patron/trace"
+	"github.com/eclipse/paho.golang/autopaho"
+	"github.com/eclipse/paho.golang/paho"
+	"github.com/opentracing/opentracing-go"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	componentType = "mqtt-bridge"
+
+	defaultRetries    = 3
+	defaultRetryDelay = time.Second
+	disconnectTimeout = 5 * time.Second
+
+	// HeaderTopic is the request header holding the topic the forwarded message was published to.
+	HeaderTopic = "X-MQTT-Topic"
+	// PropertyOriginalTopic is the user property of dead-lettered messages holding the topic they were published to.
+	PropertyOriginalTopic = "original-topic"
+	// PropertyError is the user property of dead-lettered messages holding the error of the last attempt.
+	PropertyError = "error"
+
+	forwardedState    = "forwarded"
+	retriedState      = "retried"
+	failedState       = "failed"
+	deadLetteredState = "dead-lettered"
+)
+
+var messageCounterVec *prometheus.CounterVec
+
+func init() {
+	messageCounterVec = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "component",
+			Subsystem: "mqtt_bridge",
+			Name:      "message_counter",
+			Help:      "Message counter by route topic filter and state",
+		},
+		[]string{"filter", "state"},
+	)
+	prometheus.MustRegister(messageCounterVec)
+}
+
+// Route forwards the messages of the topics matching a filter as HTTP POST requests to a handler.
+type Route struct {
+	filter  string
+	qos     byte
+	path    string
+	handler http.Handler
+}
+
+// NewRoute creates a route for the topic filter, which may contain the `+` and `#` wildcards, subscribed with the provided QoS.
+// The handler can be a handler func or the router of the HTTP component, in which case the path selects the internal route.
+func NewRoute(filter string, qos byte, path string, handler http.Handler) (Route, error) {
+	if filter == "" {
+		return Route{}, errors.New("topic filter is empty")
+	}
+	if qos > 2 {
+		return Route{}, errors.New("qos should be between 0 and 2")
+	}
+	if !strings.HasPrefix(path, "/") {
+		return Route{}, errors.New("path should start with a slash")
+	}
+	if handler == nil {
+		return Route{}, errors.New("handler is nil")
+	}
+	return Route{filter: filter, qos: qos, path: path, handler: handler}, nil
+}
+
+type retryConfig struct {
+	count uint
+	delay time.Duration
+}
+
+// publisher is the part of the connection manager used for dead-lettering messages.
+type publisher interface {
+	Publish(ctx context.Context, p *paho.Publish) (*paho.PublishResponse, error)
+}
+
+// connection holds the connection manager, once the connection to the broker is up.
+type connection struct {
+	sync.Mutex
+	cm *autopaho.ConnectionManager
+}
+
+func (c *connection) set(cm *autopaho.ConnectionManager) {
+	c.Lock()
+	defer c.Unlock()
+	c.cm = cm
+}
+
+func (c *connection) Publish(ctx context.Context, p *paho.Publish) (*paho.PublishResponse, error) {
+	c.Lock()
+	cm := c.cm
+	c.Unlock()
+	if cm == nil {
+		return nil, errors.New("connection is not up")
+	}
+	return cm.Publish(ctx, p)
+}
+
+// Component subscribing to MQTT topics and forwarding their messages to HTTP handlers.
+type Component struct {
+	cfg             autopaho.ClientConfig
+	routes          []Route
+	retryCfg        retryConfig
+	deadLetterTopic string
+}
+
+// New creates a new component with support for functional configuration.
+// The config can be created with the DefaultConfig func of the MQTT client package.
+func New(cfg autopaho.ClientConfig, routes []Route, oo ...OptionFunc) (*Component, error) {
+	if len(cfg.BrokerUrls) == 0 {
+		return nil, errors.New("no broker URLs provided")
+	}
+	if len(routes) == 0 {
+		return nil, errors.New("no routes provided")
+	}
+
+	cmp := &Component{
+		cfg:    cfg,
+		routes: routes,
+		retryCfg: retryConfig{
+			count: defaultRetries,
+			delay: defaultRetryDelay,
+		},
+	}
+
+	for _, optionFunc := range oo {
+		if err := optionFunc(cmp); err != nil {
+			return nil, err
+		}
+	}
+
+	return cmp, nil
+}
+
+// Run connects to the broker and forwards the messages of the subscribed topics until the context is done.
+// The topics are subscribed again whenever the connection is re-established.
+func (c *Component) Run(ctx context.Context) error {
+	router := paho.NewStandardRouter()
+	subscribe := &paho.Subscribe{Subscriptions: make(map[string]paho.SubscribeOptions, len(c.routes))}
+	conn := &connection{}
+	for _, route := range c.routes {
+		route := route
+		subscribe.Subscriptions[route.filter] = paho.SubscribeOptions{QoS: route.qos}
+		router.RegisterHandler(route.filter, func(msg *paho.Publish) {
+			_ = c.process(ctx, conn, route, msg)
+		})
+	}
+
+	cfg := c.cfg
+	cfg.Router = router
+	onConnectionUp := cfg.OnConnectionUp
+	cfg.OnConnectionUp = func(cm *autopaho.ConnectionManager, connAck *paho.Connack) {
+		conn.set(cm)
+		if onConnectionUp != nil {
+			onConnectionUp(cm, connAck)
+		}
+		if _, err := cm.Subscribe(ctx, subscribe); err != nil {
+			log.Errorf("failed to subscribe to topics: %v", err)
+		}
+	}
+
+	cm, err := autopaho.NewConnection(ctx, cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create connection manager: %w", err)
+	}
+
+	<-ctx.Done()
+	disconnectCtx, cnl := context.WithTimeout(context.Background(), disconnectTimeout)
+	defer cnl()
+	if err := cm.Disconnect(disconnectCtx); err != nil {
+		log.Warnf("failed to disconnect from broker: %v", err)
+	}
+	return nil
+}
+
+// process forwards the message to the handler of the route, retrying failed attempts.
+// Messages failing all attempts are published to the dead letter topic, if any.
+func (c *Component) process(ctx context.Context, pub publisher, route Route, msg *paho.Publish) error {
+	hdr := userProperties(msg)
+
+	sp, ctx := propagation.ConsumerSpan(ctx, trace.ComponentOpName(componentType, route.filter), componentType,
+		propagation.MapCarrier(hdr), opentracing.Tag{Key: "topic", Value: msg.Topic})
+	logger := log.Sub(map[string]interface{}{correlation.ID: correlation.IDFromContext(ctx)})
+	ctx = log.WithContext(ctx, logger)
+
+	err := c.forwardWithRetries(ctx, route, msg, hdr)
+	if err == nil {
+		messageCounterVec.WithLabelValues(route.filter, forwardedState).Inc()
+		trace.SpanSuccess(sp)
+		return nil
+	}
+
+	logger.Errorf("failed to forward message of topic %s: %v", msg.Topic, err)
+	messageCounterVec.WithLabelValues(route.filter, failedState).Inc()
+	if c.deadLetterTopic != "" {
+		if dlqErr := c.deadLetter(ctx, pub, msg, err); dlqErr != nil {
+			logger.Errorf("failed to publish message of topic %s to the dead letter topic: %v", msg.Topic, dlqErr)
+		} else {
+			messageCounterVec.WithLabelValues(route.filter, deadLetteredState).Inc()
+		}
+	}
+	trace.SpanError(sp)
+	return err
+}
+
+func (c *Component) forwardWithRetries(ctx context.Context, route Route, msg *paho.Publish, hdr map[string]string) error {
+	var err error
+	for attempt := uint(0); attempt <= c.retryCfg.count; attempt++ {
+		if attempt > 0 {
+			messageCounterVec.WithLabelValues(route.filter, retriedState).Inc()
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(c.retryCfg.delay):
+			}
+		}
+		var retryable bool
+		retryable, err = forward(ctx, route, msg, hdr)
+		if err == nil || !retryable {
+			return err
+		}
+	}
+	return err
+}
+
+// forward posts the message to the handler of the route. Client errors of the handler are not retryable,
+// since the same request is expected to fail again.
+func forward(ctx context.Context, route Route, msg *paho.Publish, hdr map[string]string) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, route.path, bytes.NewReader(msg.Payload))
+	if err != nil {
+		return false, fmt.Errorf("failed to create request: %w", err)
+	}
+	for k, v := range hdr {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set(HeaderTopic, msg.Topic)
+	if msg.Properties != nil && msg.Properties.ContentType != "" {
+		req.Header.Set("Content-Type", msg.Properties.ContentType)
+	}
+	if err := propagation.Inject(ctx, opentracing.SpanFromContext(ctx), propagation.HTTPHeaders(req.Header)); err != nil {
+		log.FromContext(ctx).Warnf("failed to inject tracing headers: %v", err)
+	}
+
+	rw := &statusWriter{header: make(http.Header), statusCode: http.StatusOK}
+	route.handler.ServeHTTP(rw, req)
+	if rw.statusCode < http.StatusBadRequest {
+		return false, nil
+	}
+	return rw.statusCode >= http.StatusInternalServerError, fmt.Errorf("handler responded with status %d", rw.statusCode)
+}
+
+func (c *Component) deadLetter(ctx context.Context, pub publisher, msg *paho.Publish, err error) error {
+	props := &paho.PublishProperties{}
+	if msg.Properties != nil {
+		props.ContentType = msg.Properties.ContentType
+		props.User = append(props.User, msg.Properties.User...)
+	}
+	props.User.Add(PropertyOriginalTopic, msg.Topic)
+	props.User.Add(PropertyError, err.Error())
+
+	_, err = pub.Publish(ctx, &paho.Publish{
+		QoS:        1,
+		Topic:      c.deadLetterTopic,
+		Properties: props,
+		Payload:    msg.Payload,
+	})
+	return err
+}
+
+func userProperties(msg *paho.Publish) map[string]string {
+	hdr := make(map[string]string)
+	if msg.Properties == nil {
+		return hdr
+	}
+	for _, p := range msg.Properties.User {
+		hdr[p.Key] = p.Value
+	}
+	return hdr
+}
+
+// statusWriter is a response writer, which keeps only the status code of the handler.
+type statusWriter struct {
+	header     http.Header
+	statusCode int
+	written    bool
+}
+
+func (w *statusWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *statusWriter) Write(p []byte) (int, error) {
+	w.written = true
+	return len(p), nil
+}
+
+func (w *statusWriter) WriteHeader(statusCode int) {
+	if w.written {
+		return
+	}
+	w.statusCode = statusCode
+	w.written = true
+}
@@ -0,0 +1,211 @@
+package mqtt
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/beatlabs/patron/correlation"
+	"github.com/eclipse/paho.golang/autopaho"
+	"github.com/eclipse/paho.golang/paho"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakePublisher struct {
+	published []*paho.Publish
+	err       error
+}
+
+func (f *fakePublisher) Publish(_ context.Context, p *paho.Publish) (*paho.PublishResponse, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.published = append(f.published, p)
+	return &paho.PublishResponse{}, nil
+}
+
+func TestNewRoute(t *testing.T) {
+	t.Parallel()
+	hnd := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	type args struct {
+		filter  string
+		qos     byte
+		path    string
+		handler http.Handler
+	}
+	tests := map[string]struct {
+		args        args
+		expectedErr string
+	}{
+		"success":        {args: args{filter: "devices/+/events", qos: 1, path: "/events", handler: hnd}},
+		"missing filter": {args: args{qos: 1, path: "/events", handler: hnd}, expectedErr: "topic filter is empty"},
+		"invalid qos":    {args: args{filter: "events", qos: 3, path: "/events", handler: hnd}, expectedErr: "qos should be between 0 and 2"},
+		"invalid path":   {args: args{filter: "events", path: "events", handler: hnd}, expectedErr: "path should start with a slash"},
+		"missing handler": {
+			args:        args{filter: "events", path: "/events"},
+			expectedErr: "handler is nil",
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			got, err := NewRoute(tt.args.filter, tt.args.qos, tt.args.path, tt.args.handler)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.args.filter, got.filter)
+				assert.Equal(t, tt.args.path, got.path)
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+	u, err := url.Parse("tcp://localhost:1883")
+	require.NoError(t, err)
+	route, err := NewRoute("events", 1, "/events", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	require.NoError(t, err)
+
+	type args struct {
+		cfg    autopaho.ClientConfig
+		routes []Route
+		oo     []OptionFunc
+	}
+	tests := map[string]struct {
+		args        args
+		expectedErr string
+	}{
+		"success": {
+			args: args{cfg: autopaho.ClientConfig{BrokerUrls: []*url.URL{u}}, routes: []Route{route}, oo: []OptionFunc{DeadLetterTopic("dlq")}},
+		},
+		"missing broker urls": {
+			args:        args{routes: []Route{route}},
+			expectedErr: "no broker URLs provided",
+		},
+		"missing routes": {
+			args:        args{cfg: autopaho.ClientConfig{BrokerUrls: []*url.URL{u}}},
+			expectedErr: "no routes provided",
+		},
+		"option failure": {
+			args:        args{cfg: autopaho.ClientConfig{BrokerUrls: []*url.URL{u}}, routes: []Route{route}, oo: []OptionFunc{DeadLetterTopic("")}},
+			expectedErr: "dead letter topic is empty",
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			got, err := New(tt.args.cfg, tt.args.routes, tt.args.oo...)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, got)
+			}
+		})
+	}
+}
+
+func TestComponent_process(t *testing.T) {
+	t.Parallel()
+	msg := func() *paho.Publish {
+		return &paho.Publish{
+			Topic:   "devices/1/events",
+			Payload: []byte(`{"event":"on"}`),
+			Properties: &paho.PublishProperties{
+				ContentType: "application/json",
+				User:        paho.UserProperties{{Key: correlation.HeaderID, Value: "123"}, {Key: "tenant", Value: "beat"}},
+			},
+		}
+	}
+
+	tests := map[string]struct {
+		statuses     []int
+		deadLetter   bool
+		publishErr   error
+		expectedErr  string
+		expectedCnt  int
+		deadLettered bool
+	}{
+		"success":                 {statuses: []int{http.StatusAccepted}, expectedCnt: 1},
+		"success after retry":     {statuses: []int{http.StatusServiceUnavailable, http.StatusOK}, expectedCnt: 2},
+		"client error":            {statuses: []int{http.StatusBadRequest}, expectedErr: "handler responded with status 400", expectedCnt: 1},
+		"retries exhausted":       {statuses: []int{http.StatusInternalServerError}, expectedErr: "handler responded with status 500", expectedCnt: 3},
+		"dead lettered":           {statuses: []int{http.StatusBadRequest}, deadLetter: true, expectedErr: "handler responded with status 400", expectedCnt: 1, deadLettered: true},
+		"dead letter publish err": {statuses: []int{http.StatusBadRequest}, deadLetter: true, publishErr: errors.New("publish error"), expectedErr: "handler responded with status 400", expectedCnt: 1},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			cnt := 0
+			hnd := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, http.MethodPost, r.Method)
+				assert.Equal(t, "/events", r.URL.Path)
+				assert.Equal(t, "123", r.Header.Get(correlation.HeaderID))
+				assert.Equal(t, "beat", r.Header.Get("tenant"))
+				assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+				assert.Equal(t, "devices/1/events", r.Header.Get(HeaderTopic))
+				body, err := ioutil.ReadAll(r.Body)
+				assert.NoError(t, err)
+				assert.Equal(t, `{"event":"on"}`, string(body))
+
+				status := tt.statuses[len(tt.statuses)-1]
+				if cnt < len(tt.statuses) {
+					status = tt.statuses[cnt]
+				}
+				cnt++
+				w.WriteHeader(status)
+			})
+			route, err := NewRoute("devices/+/events", 1, "/events", hnd)
+			require.NoError(t, err)
+			cmp := &Component{retryCfg: retryConfig{count: 2, delay: time.Millisecond}}
+			if tt.deadLetter {
+				cmp.deadLetterTopic = "dlq"
+			}
+			pub := &fakePublisher{err: tt.publishErr}
+
+			err = cmp.process(context.Background(), pub, route, msg())
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.expectedCnt, cnt)
+			if !tt.deadLettered {
+				assert.Empty(t, pub.published)
+				return
+			}
+			require.Len(t, pub.published, 1)
+			dlq := pub.published[0]
+			assert.Equal(t, "dlq", dlq.Topic)
+			assert.Equal(t, []byte(`{"event":"on"}`), dlq.Payload)
+			assert.Equal(t, "devices/1/events", dlq.Properties.User.Get(PropertyOriginalTopic))
+			assert.Equal(t, "handler responded with status 400", dlq.Properties.User.Get(PropertyError))
+			assert.Equal(t, "beat", dlq.Properties.User.Get("tenant"))
+		})
+	}
+}
+
+func TestComponent_process_ContextDone(t *testing.T) {
+	t.Parallel()
+	route, err := NewRoute("events", 1, "/events", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	require.NoError(t, err)
+	cmp := &Component{retryCfg: retryConfig{count: 2, delay: time.Hour}}
+	ctx, cnl := context.WithCancel(context.Background())
+	cnl()
+
+	err = cmp.process(ctx, &fakePublisher{}, route, &paho.Publish{Topic: "events"})
+	assert.Equal(t, context.Canceled, err)
+}
@@ -0,0 +1,35 @@
+package mqtt
+
+import (
+	"errors"
+	"time"
+)
+
+// OptionFunc definition for configuring the component in a functional way.
+type OptionFunc func(*Component) error
+
+// Retries sets the number of times a message is forwarded again, after the handler has failed with a server error,
+// and the delay between the attempts. Since messages are forwarded in order, the retries hold back the following messages.
+func Retries(count uint, delay time.Duration) OptionFunc {
+	return func(c *Component) error {
+		if delay < 0 {
+			return errors.New("retry delay should not be negative")
+		}
+		c.retryCfg.count = count
+		c.retryCfg.delay = delay
+		return nil
+	}
+}
+
+// DeadLetterTopic sets the topic messages are published to, after they have failed all attempts.
+// The original topic and the error are added to the user properties of the dead-lettered messages.
+// Without it, the failed messages are dropped.
+func DeadLetterTopic(topic string) OptionFunc {
+	return func(c *Component) error {
+		if topic == "" {
+			return errors.New("dead letter topic is empty")
+		}
+		c.deadLetterTopic = topic
+		return nil
+	}
+}
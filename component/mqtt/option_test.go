@@ -0,0 +1,64 @@
+package mqtt
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetries(t *testing.T) {
+	t.Parallel()
+	type args struct {
+		count uint
+		delay time.Duration
+	}
+	tests := map[string]struct {
+		args        args
+		expectedErr string
+	}{
+		"success":        {args: args{count: 5, delay: time.Second}},
+		"no retries":     {args: args{count: 0, delay: 0}},
+		"negative delay": {args: args{count: 5, delay: -time.Second}, expectedErr: "retry delay should not be negative"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			c := &Component{}
+			err := Retries(tt.args.count, tt.args.delay)(c)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.args.count, c.retryCfg.count)
+				assert.Equal(t, tt.args.delay, c.retryCfg.delay)
+			}
+		})
+	}
+}
+
+func TestDeadLetterTopic(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		topic       string
+		expectedErr string
+	}{
+		"success":     {topic: "dlq"},
+		"empty topic": {topic: "", expectedErr: "dead letter topic is empty"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			c := &Component{}
+			err := DeadLetterTopic(tt.topic)(c)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.topic, c.deadLetterTopic)
+			}
+		})
+	}
+}
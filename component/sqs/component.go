@@ -13,8 +13,8 @@ import (
 	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
 	"github.com/beatlabs/patron/correlation"
 	"github.com/beatlabs/patron/log"
+	"github.com/beatlabs/patron/propagation"
 	"github.com/beatlabs/patron/trace"
-	"github.com/google/uuid"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -49,6 +49,7 @@ var (
 	messageAge        *prometheus.GaugeVec
 	messageCounterVec *prometheus.CounterVec
 	queueSize         *prometheus.GaugeVec
+	processingAge     *prometheus.HistogramVec
 )
 
 func init() {
@@ -82,6 +83,17 @@ func init() {
 		[]string{"state"},
 	)
 	prometheus.MustRegister(queueSize)
+	processingAge = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "component",
+			Subsystem: "sqs",
+			Name:      "message_processing_age_seconds",
+			Help:      "Time elapsed between sending the messages and processing them, based on the SentTimestamp SQS attribute",
+			Buckets:   prometheus.ExponentialBuckets(0.01, 2, 20),
+		},
+		[]string{"queue"},
+	)
+	prometheus.MustRegister(processingAge)
 }
 
 type retry struct {
@@ -258,14 +270,14 @@ func (c *Component) createBatch(ctx context.Context, output *sqs.ReceiveMessageO
 
 	for _, msg := range output.Messages {
 		observerMessageAge(c.queue.name, msg.Attributes)
+		if sent, ok := sentTimestamp(msg.Attributes); ok {
+			processingAge.WithLabelValues(c.queue.name).Observe(time.Since(sent).Seconds())
+		}
 
-		corID := getCorrelationID(msg.MessageAttributes)
-
-		sp, ctxCh := trace.ConsumerSpan(ctx, trace.ComponentOpName(consumerComponent, c.queue.name),
-			consumerComponent, corID, mapHeader(msg.MessageAttributes))
+		sp, ctxCh := propagation.ConsumerSpan(ctx, trace.ComponentOpName(consumerComponent, c.queue.name),
+			consumerComponent, messageCarrier(msg))
 
-		ctxCh = correlation.ContextWithID(ctxCh, corID)
-		logger := log.Sub(map[string]interface{}{correlation.ID: corID})
+		logger := log.Sub(map[string]interface{}{correlation.ID: correlation.IDFromContext(ctxCh)})
 		ctxCh = log.WithContext(ctxCh, logger)
 
 		btc.messages = append(btc.messages, message{
@@ -327,15 +339,24 @@ func getAttributeFloat64(attr map[string]*string, key string) (float64, error) {
 }
 
 func observerMessageAge(queue string, attributes map[string]*string) {
+	sent, ok := sentTimestamp(attributes)
+	if !ok {
+		return
+	}
+	messageAge.WithLabelValues(queue).Set(time.Since(sent).Seconds())
+}
+
+// sentTimestamp returns the time the message was sent to the queue, based on the SentTimestamp attribute in epoch milliseconds.
+func sentTimestamp(attributes map[string]*string) (time.Time, bool) {
 	attribute, ok := attributes[sqsAttributeSentTimestamp]
 	if !ok || attribute == nil {
-		return
+		return time.Time{}, false
 	}
 	timestamp, err := strconv.ParseInt(*attribute, 10, 64)
 	if err != nil {
-		return
+		return time.Time{}, false
 	}
-	messageAge.WithLabelValues(queue).Set(time.Now().UTC().Sub(time.Unix(timestamp, 0)).Seconds())
+	return time.Unix(0, timestamp*int64(time.Millisecond)), true
 }
 
 func messageCountInc(queue string, state messageState, hasError bool, count int) {
@@ -347,24 +368,15 @@ func messageCountInc(queue string, state messageState, hasError bool, count int)
 	messageCounterVec.WithLabelValues(queue, string(state), hasErrorString).Add(float64(count))
 }
 
-func getCorrelationID(ma map[string]*sqs.MessageAttributeValue) string {
-	for key, value := range ma {
-		if key == correlation.HeaderID {
-			if value.StringValue != nil {
-				return *value.StringValue
-			}
-			break
-		}
+// messageCarrier returns the carrier of the message attributes. The messages of SNS topics delivered without raw message delivery
+// carry their trace context and correlation ID in the attributes of the SNS notification of their body instead.
+func messageCarrier(msg *sqs.Message) propagation.Carrier {
+	attributes := propagation.SQSAttributes(msg.MessageAttributes)
+	if len(attributes.Keys()) > 0 || msg.Body == nil {
+		return attributes
 	}
-	return uuid.New().String()
-}
-
-func mapHeader(ma map[string]*sqs.MessageAttributeValue) map[string]string {
-	mp := make(map[string]string)
-	for key, value := range ma {
-		if value.StringValue != nil {
-			mp[key] = *value.StringValue
-		}
+	if notification, ok := propagation.SNSNotificationAttributes(*msg.Body); ok {
+		return notification
 	}
-	return mp
+	return attributes
 }
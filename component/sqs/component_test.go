@@ -7,7 +7,10 @@ import (
 	"testing"
 	"time"
 
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sqs"
 	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
+	"github.com/beatlabs/patron/correlation"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -219,3 +222,45 @@ func (sp stubProcessor) process(_ context.Context, b Batch) {
 	_, err := b.ACK()
 	require.NoError(sp.t, err)
 }
+
+func Test_messageCarrier(t *testing.T) {
+	t.Parallel()
+	notification := `{"Type":"Notification","Message":"{}","MessageAttributes":{` +
+		`"X-Correlation-Id":{"Type":"String","Value":"sns-id"},"count":{"Type":"Number","Value":"1"}}}`
+	tests := map[string]struct {
+		msg           *sqs.Message
+		expectedCorID string
+	}{
+		"message attributes": {
+			msg: &sqs.Message{
+				Body: aws.String(notification),
+				MessageAttributes: map[string]*sqs.MessageAttributeValue{
+					correlation.HeaderID: {DataType: aws.String("String"), StringValue: aws.String("sqs-id")},
+				},
+			},
+			expectedCorID: "sqs-id",
+		},
+		"sns notification":   {msg: &sqs.Message{Body: aws.String(notification)}, expectedCorID: "sns-id"},
+		"without attributes": {msg: &sqs.Message{Body: aws.String(`{"key":"value"}`)}},
+		"without body":       {msg: &sqs.Message{}},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.expectedCorID, messageCarrier(tt.msg).Get(correlation.HeaderID))
+		})
+	}
+}
+
+func Test_sentTimestamp(t *testing.T) {
+	t.Parallel()
+	sent, ok := sentTimestamp(map[string]*string{sqsAttributeSentTimestamp: aws.String("1600000000123")})
+	assert.True(t, ok)
+	assert.Equal(t, time.Unix(1600000000, 123*int64(time.Millisecond)), sent)
+
+	_, ok = sentTimestamp(map[string]*string{sqsAttributeSentTimestamp: aws.String("invalid")})
+	assert.False(t, ok)
+	_, ok = sentTimestamp(map[string]*string{})
+	assert.False(t, ok)
+}
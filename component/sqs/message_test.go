@@ -353,7 +353,7 @@ func (s stubSQSAPI) ReceiveMessageWithContext(aws.Context, *sqs.ReceiveMessageIn
 		Messages: []*sqs.Message{
 			{
 				Attributes: map[string]*string{
-					sqsAttributeSentTimestamp: aws.String(strconv.FormatInt(time.Now().Unix(), 10)),
+					sqsAttributeSentTimestamp: aws.String(strconv.FormatInt(time.Now().UnixNano()/int64(time.Millisecond), 10)),
 				},
 				Body:          aws.String(`{"key":"value"}`),
 				MessageId:     s.succeededMessage.Message().MessageId,
@@ -361,7 +361,7 @@ func (s stubSQSAPI) ReceiveMessageWithContext(aws.Context, *sqs.ReceiveMessageIn
 			},
 			{
 				Attributes: map[string]*string{
-					sqsAttributeSentTimestamp: aws.String(strconv.FormatInt(time.Now().Unix(), 10)),
+					sqsAttributeSentTimestamp: aws.String(strconv.FormatInt(time.Now().UnixNano()/int64(time.Millisecond), 10)),
 				},
 				Body:          aws.String(`{"key":"value"}`),
 				MessageId:     s.failedMessage.Message().MessageId,
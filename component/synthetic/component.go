@@ -0,0 +1,159 @@
+// Package synthetic provides a component generating synthetic traffic against the service itself, e.g. for canary soak tests.
+package synthetic
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/beatlabs/patron/log"
+	"github.com/beatlabs/patron/propagation"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// Header is used to tag synthetic HTTP requests and the headers of synthetic messages.
+	Header = propagation.SyntheticHeader
+
+	defaultTimeout     = 5 * time.Second
+	defaultMaxInFlight = 10
+)
+
+var requestCounter *prometheus.CounterVec
+
+func init() {
+	requestCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "component",
+			Subsystem: "synthetic",
+			Name:      "requests_total",
+			Help:      "Synthetic requests generated, classified by generator and success",
+		},
+		[]string{"generator", "success"},
+	)
+	prometheus.MustRegister(requestCounter)
+}
+
+// WithContext flags the context as carrying synthetic traffic.
+func WithContext(ctx context.Context) context.Context {
+	return propagation.WithSynthetic(ctx)
+}
+
+// FromContext returns true if the context carries synthetic traffic.
+// Handlers should use it in order to exclude synthetic traffic from business metrics.
+func FromContext(ctx context.Context) bool {
+	return propagation.IsSynthetic(ctx)
+}
+
+// GeneratorFunc generates a single synthetic request or message.
+// The provided context is flagged as synthetic and should be used for the generated traffic.
+type GeneratorFunc func(ctx context.Context) error
+
+// Component generating synthetic traffic at a configured rate.
+type Component struct {
+	name        string
+	interval    time.Duration
+	gen         GeneratorFunc
+	timeout     time.Duration
+	maxInFlight int
+	duration    time.Duration
+}
+
+// New creates a new component which calls the generator rate times per second.
+func New(name string, rate float64, gen GeneratorFunc, oo ...OptionFunc) (*Component, error) {
+	if name == "" {
+		return nil, errors.New("name is empty")
+	}
+	if rate <= 0 {
+		return nil, errors.New("rate should be a positive number")
+	}
+	interval := time.Duration(float64(time.Second) / rate)
+	if interval <= 0 {
+		return nil, errors.New("rate should be at most one per nanosecond")
+	}
+	if gen == nil {
+		return nil, errors.New("generator function is nil")
+	}
+
+	cmp := &Component{
+		name:        name,
+		interval:    interval,
+		gen:         gen,
+		timeout:     defaultTimeout,
+		maxInFlight: defaultMaxInFlight,
+	}
+
+	for _, o := range oo {
+		err := o(cmp)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return cmp, nil
+}
+
+// Run generates traffic until the context is canceled or the configured duration elapses.
+// Ticks are skipped when the maximum number of in-flight requests has been reached.
+// Once the duration elapses the component stays idle until the context is canceled, since returning would shut the service down.
+func (c *Component) Run(ctx context.Context) error {
+	genCtx := ctx
+	if c.duration > 0 {
+		var cnl context.CancelFunc
+		genCtx, cnl = context.WithTimeout(ctx, c.duration)
+		defer cnl()
+	}
+
+	c.run(genCtx)
+	<-ctx.Done()
+	return nil
+}
+
+func (c *Component) run(ctx context.Context) {
+	logger := log.FromContext(ctx)
+	sem := make(chan struct{}, c.maxInFlight)
+	wg := sync.WaitGroup{}
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			logger.Infof("synthetic traffic generator %s stopped", c.name)
+			return
+		case <-ticker.C:
+			if ctx.Err() != nil {
+				// the tick raced with the cancellation
+				continue
+			}
+			select {
+			case sem <- struct{}{}:
+			default:
+				logger.Debugf("synthetic traffic generator %s reached max in-flight requests, skipping", c.name)
+				continue
+			}
+			wg.Add(1)
+			go func() {
+				defer func() {
+					<-sem
+					wg.Done()
+				}()
+				c.generate(ctx)
+			}()
+		}
+	}
+}
+
+func (c *Component) generate(ctx context.Context) {
+	ctx, cnl := context.WithTimeout(WithContext(ctx), c.timeout)
+	defer cnl()
+
+	err := c.gen(ctx)
+	if err != nil {
+		log.FromContext(ctx).Warnf("synthetic traffic generator %s failed: %v", c.name, err)
+	}
+	requestCounter.WithLabelValues(c.name, strconv.FormatBool(err == nil)).Inc()
+}
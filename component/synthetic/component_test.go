@@ -0,0 +1,109 @@
+package synthetic
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+	gen := func(context.Context) error { return nil }
+	type args struct {
+		name string
+		rate float64
+		gen  GeneratorFunc
+		oo   []OptionFunc
+	}
+	tests := map[string]struct {
+		args        args
+		expectedErr string
+	}{
+		"success":           {args: args{name: "test", rate: 10, gen: gen, oo: []OptionFunc{Timeout(time.Second)}}},
+		"missing name":      {args: args{rate: 10, gen: gen}, expectedErr: "name is empty"},
+		"invalid rate":      {args: args{name: "test", gen: gen}, expectedErr: "rate should be a positive number"},
+		"rate too high":     {args: args{name: "test", rate: 2e9, gen: gen}, expectedErr: "rate should be at most one per nanosecond"},
+		"missing generator": {args: args{name: "test", rate: 10}, expectedErr: "generator function is nil"},
+		"invalid option":    {args: args{name: "test", rate: 10, gen: gen, oo: []OptionFunc{Timeout(0)}}, expectedErr: "timeout should be a positive number"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			got, err := New(tt.args.name, tt.args.rate, tt.args.gen, tt.args.oo...)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, got)
+				assert.Equal(t, 100*time.Millisecond, got.interval)
+			}
+		})
+	}
+}
+
+func TestComponent_Run(t *testing.T) {
+	requestCounter.Reset()
+	var calls int32
+	gen := func(ctx context.Context) error {
+		assert.True(t, FromContext(ctx))
+		if atomic.AddInt32(&calls, 1)%2 == 0 {
+			return errors.New("failed")
+		}
+		return nil
+	}
+	cmp, err := New("test", 100, gen, Duration(100*time.Millisecond))
+	require.NoError(t, err)
+
+	ctx, cnl := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- cmp.Run(ctx)
+	}()
+
+	// the component stays idle once the duration elapses, until the context is canceled
+	time.Sleep(300 * time.Millisecond)
+	select {
+	case <-done:
+		assert.Fail(t, "component returned before the context was canceled")
+	default:
+	}
+	generated := atomic.LoadInt32(&calls)
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, generated, atomic.LoadInt32(&calls))
+	cnl()
+	assert.NoError(t, <-done)
+
+	assert.Greater(t, generated, int32(1))
+	assert.Equal(t, float64(generated),
+		testutil.ToFloat64(requestCounter.WithLabelValues("test", "true"))+testutil.ToFloat64(requestCounter.WithLabelValues("test", "false")))
+	assert.Equal(t, float64(generated/2), testutil.ToFloat64(requestCounter.WithLabelValues("test", "false")))
+}
+
+func TestComponent_Run_MaxInFlight(t *testing.T) {
+	var calls int32
+	gen := func(ctx context.Context) error {
+		atomic.AddInt32(&calls, 1)
+		<-ctx.Done()
+		return nil
+	}
+	cmp, err := New("test-in-flight", 1000, gen, MaxInFlight(2), Timeout(time.Minute))
+	require.NoError(t, err)
+
+	ctx, cnl := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cnl()
+	assert.NoError(t, cmp.Run(ctx))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestFromContext(t *testing.T) {
+	assert.False(t, FromContext(context.Background()))
+	assert.True(t, FromContext(WithContext(context.Background())))
+}
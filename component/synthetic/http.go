@@ -0,0 +1,46 @@
+package synthetic
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	patronhttp "github.com/beatlabs/patron/client/http"
+)
+
+// HTTPGenerator returns a generator which sends a request, tagged with the synthetic header, using the provided client.
+// Responses with a status code of 400 or higher are reported as failures.
+func HTTPGenerator(cl patronhttp.Client, method, url string, body []byte) GeneratorFunc {
+	return func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+		if err != nil {
+			return fmt.Errorf("failed to create request: %w", err)
+		}
+		req.Header.Set(Header, "true")
+
+		rsp, err := cl.Do(req)
+		if err != nil {
+			return fmt.Errorf("failed to send request: %w", err)
+		}
+		_, _ = io.Copy(io.Discard, rsp.Body)
+		_ = rsp.Body.Close()
+
+		if rsp.StatusCode >= http.StatusBadRequest {
+			return fmt.Errorf("unexpected status code %d", rsp.StatusCode)
+		}
+		return nil
+	}
+}
+
+// Middleware flags the context of requests carrying the synthetic header, so that handlers can use FromContext
+// in order to exclude them from business metrics.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(Header) != "" {
+			r = r.WithContext(WithContext(r.Context()))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,42 @@
+package synthetic
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTPGenerator(t *testing.T) {
+	ts := httptest.NewServer(Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.True(t, FromContext(r.Context()))
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	})))
+	defer ts.Close()
+
+	ctx := context.Background()
+	assert.NoError(t, HTTPGenerator(http.DefaultClient, http.MethodPost, ts.URL, []byte("body"))(ctx))
+	assert.EqualError(t, HTTPGenerator(http.DefaultClient, http.MethodGet, ts.URL+"/fail", nil)(ctx), "unexpected status code 500")
+	assert.Error(t, HTTPGenerator(http.DefaultClient, "bad method", ts.URL, nil)(ctx))
+}
+
+func TestMiddleware(t *testing.T) {
+	var synthetic bool
+	h := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		synthetic = FromContext(r.Context())
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	assert.False(t, synthetic)
+
+	req.Header.Set(Header, "true")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	assert.True(t, synthetic)
+}
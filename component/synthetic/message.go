@@ -0,0 +1,28 @@
+package synthetic
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Shopify/sarama"
+	"github.com/beatlabs/patron/propagation"
+)
+
+// KafkaSender sends a message to Kafka, e.g. the synchronous producer of the Kafka client.
+type KafkaSender interface {
+	Send(ctx context.Context, msg *sarama.ProducerMessage) (partition int32, offset int64, err error)
+}
+
+// KafkaGenerator returns a generator which sends a message with the value to the topic, tagged with the synthetic header,
+// using the provided sender.
+func KafkaGenerator(s KafkaSender, topic string, value []byte) GeneratorFunc {
+	return func(ctx context.Context) error {
+		msg := &sarama.ProducerMessage{Topic: topic, Value: sarama.ByteEncoder(value)}
+		propagation.TagSynthetic((*propagation.KafkaHeaders)(&msg.Headers))
+
+		if _, _, err := s.Send(ctx, msg); err != nil {
+			return fmt.Errorf("failed to send message: %w", err)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,35 @@
+package synthetic
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type kafkaSenderFunc func(ctx context.Context, msg *sarama.ProducerMessage) (int32, int64, error)
+
+func (f kafkaSenderFunc) Send(ctx context.Context, msg *sarama.ProducerMessage) (int32, int64, error) {
+	return f(ctx, msg)
+}
+
+func TestKafkaGenerator(t *testing.T) {
+	var sent *sarama.ProducerMessage
+	gen := KafkaGenerator(kafkaSenderFunc(func(_ context.Context, msg *sarama.ProducerMessage) (int32, int64, error) {
+		sent = msg
+		return 0, 1, nil
+	}), "topic", []byte("value"))
+
+	require.NoError(t, gen(context.Background()))
+	assert.Equal(t, "topic", sent.Topic)
+	assert.Equal(t, sarama.ByteEncoder("value"), sent.Value)
+	assert.Equal(t, []sarama.RecordHeader{{Key: []byte(Header), Value: []byte("true")}}, sent.Headers)
+
+	gen = KafkaGenerator(kafkaSenderFunc(func(context.Context, *sarama.ProducerMessage) (int32, int64, error) {
+		return 0, 0, errors.New("broker down")
+	}), "topic", nil)
+	assert.EqualError(t, gen(context.Background()), "failed to send message: broker down")
+}
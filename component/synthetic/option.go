@@ -0,0 +1,42 @@
+package synthetic
+
+import (
+	"errors"
+	"time"
+)
+
+// OptionFunc definition for configuring the component in a functional way.
+type OptionFunc func(*Component) error
+
+// Timeout option for setting the timeout of a single generated request.
+func Timeout(timeout time.Duration) OptionFunc {
+	return func(c *Component) error {
+		if timeout <= 0 {
+			return errors.New("timeout should be a positive number")
+		}
+		c.timeout = timeout
+		return nil
+	}
+}
+
+// MaxInFlight option for setting the maximum number of concurrent generated requests.
+func MaxInFlight(max int) OptionFunc {
+	return func(c *Component) error {
+		if max <= 0 {
+			return errors.New("max in-flight should be a positive number")
+		}
+		c.maxInFlight = max
+		return nil
+	}
+}
+
+// Duration option for limiting the time the component generates traffic.
+func Duration(duration time.Duration) OptionFunc {
+	return func(c *Component) error {
+		if duration <= 0 {
+			return errors.New("duration should be a positive number")
+		}
+		c.duration = duration
+		return nil
+	}
+}
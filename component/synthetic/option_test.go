@@ -0,0 +1,29 @@
+package synthetic
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimeout(t *testing.T) {
+	c := &Component{}
+	assert.NoError(t, Timeout(time.Second)(c))
+	assert.Equal(t, time.Second, c.timeout)
+	assert.EqualError(t, Timeout(0)(c), "timeout should be a positive number")
+}
+
+func TestMaxInFlight(t *testing.T) {
+	c := &Component{}
+	assert.NoError(t, MaxInFlight(5)(c))
+	assert.Equal(t, 5, c.maxInFlight)
+	assert.EqualError(t, MaxInFlight(0)(c), "max in-flight should be a positive number")
+}
+
+func TestDuration(t *testing.T) {
+	c := &Component{}
+	assert.NoError(t, Duration(time.Minute)(c))
+	assert.Equal(t, time.Minute, c.duration)
+	assert.EqualError(t, Duration(-1)(c), "duration should be a positive number")
+}
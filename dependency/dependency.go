@@ -0,0 +1,118 @@
+// Package dependency tracks the calls of the clients to the dependencies of the service, e.g. databases, caches, brokers or HTTP upstreams,
+// keeping their rolling success rates and latency percentiles in-process. The summary of the dependencies is served by the HTTP component,
+// which helps triaging incidents without access to the metrics backend.
+package dependency
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/beatlabs/patron/log"
+)
+
+const (
+	// Window over which the calls of the dependencies are summarized.
+	Window     = 5 * time.Minute
+	resolution = 10 * time.Second
+)
+
+var defaultRegistry = newRegistry(time.Now)
+
+// Summary of the calls to a dependency over the window.
+type Summary struct {
+	// Kind of the dependency, e.g. sql, redis, kafka, mqtt or http.
+	Kind string `json:"kind"`
+	// Name of the dependency, e.g. the database, the address of the server or the host of the upstream.
+	Name   string `json:"name"`
+	Calls  uint64 `json:"calls"`
+	Errors uint64 `json:"errors"`
+	// SuccessRate is the ratio of the calls which succeeded, one if there were no calls.
+	SuccessRate float64 `json:"success_rate"`
+	// LatencyP50 to LatencyP99 are the latency percentiles of the calls in milliseconds, estimated from a histogram of exponential buckets.
+	LatencyP50 float64 `json:"latency_p50_ms"`
+	LatencyP90 float64 `json:"latency_p90_ms"`
+	LatencyP99 float64 `json:"latency_p99_ms"`
+	// LastError is the time of the last failed call, if any failed since the service started.
+	LastError *time.Time `json:"last_error,omitempty"`
+}
+
+// Report of the dependencies of the service.
+type Report struct {
+	Window       string    `json:"window"`
+	Dependencies []Summary `json:"dependencies"`
+}
+
+// Observe records a call to the dependency of the provided kind and name, registering the dependency on its first call.
+// The clients of patron observe their calls, while other clients may observe theirs in order to be included in the summary.
+func Observe(kind, name string, success bool, latency time.Duration) {
+	defaultRegistry.observe(kind, name, success, latency)
+}
+
+// Summaries returns the summaries of the dependencies, sorted by kind and name.
+func Summaries() []Summary {
+	return defaultRegistry.summaries()
+}
+
+// Handler responds with the report of the dependencies as JSON.
+func Handler(w http.ResponseWriter, r *http.Request) {
+	report := Report{Window: Window.String(), Dependencies: Summaries()}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		log.FromContext(r.Context()).Errorf("could not write dependencies report: %v", err)
+	}
+}
+
+type key struct {
+	kind, name string
+}
+
+type registry struct {
+	now      func() time.Time
+	mu       sync.RWMutex
+	trackers map[key]*tracker
+}
+
+func newRegistry(now func() time.Time) *registry {
+	return &registry{now: now, trackers: make(map[key]*tracker)}
+}
+
+func (r *registry) observe(kind, name string, success bool, latency time.Duration) {
+	k := key{kind: kind, name: name}
+	r.mu.RLock()
+	t, ok := r.trackers[k]
+	r.mu.RUnlock()
+	if !ok {
+		r.mu.Lock()
+		t, ok = r.trackers[k]
+		if !ok {
+			t = newTracker()
+			r.trackers[k] = t
+		}
+		r.mu.Unlock()
+	}
+	t.add(r.now(), success, latency)
+}
+
+func (r *registry) summaries() []Summary {
+	now := r.now()
+	r.mu.RLock()
+	ss := make([]Summary, 0, len(r.trackers))
+	for k, t := range r.trackers {
+		s := t.summary(now)
+		s.Kind = k.kind
+		s.Name = k.name
+		ss = append(ss, s)
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(ss, func(i, j int) bool {
+		if ss[i].Kind != ss[j].Kind {
+			return ss[i].Kind < ss[j].Kind
+		}
+		return ss[i].Name < ss[j].Name
+	})
+	return ss
+}
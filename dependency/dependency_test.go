@@ -0,0 +1,100 @@
+package dependency
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	return c.now
+}
+
+func TestRegistry_Summaries(t *testing.T) {
+	t.Parallel()
+	c := &clock{now: time.Date(2021, 6, 1, 10, 0, 0, 0, time.UTC)}
+	r := newRegistry(c.Now)
+	assert.Empty(t, r.summaries())
+
+	for i := 0; i < 90; i++ {
+		r.observe("sql", "orders", true, 10*time.Millisecond)
+	}
+	for i := 0; i < 9; i++ {
+		r.observe("sql", "orders", false, 100*time.Millisecond)
+	}
+	failedAt := c.now
+	r.observe("sql", "orders", false, 2*time.Minute)
+	r.observe("http", "payments.internal", true, time.Millisecond)
+
+	ss := r.summaries()
+	require.Len(t, ss, 2)
+	assert.Equal(t, Summary{Kind: "http", Name: "payments.internal", Calls: 1, SuccessRate: 1,
+		LatencyP50: ss[0].LatencyP50, LatencyP90: ss[0].LatencyP90, LatencyP99: ss[0].LatencyP99}, ss[0])
+	assert.InDelta(t, 1, ss[0].LatencyP99, 0.25)
+
+	orders := ss[1]
+	assert.Equal(t, "sql", orders.Kind)
+	assert.Equal(t, "orders", orders.Name)
+	assert.Equal(t, uint64(100), orders.Calls)
+	assert.Equal(t, uint64(10), orders.Errors)
+	assert.Equal(t, 0.9, orders.SuccessRate)
+	assert.InDelta(t, 10, orders.LatencyP50, 2.5)
+	assert.InDelta(t, 10, orders.LatencyP90, 2.5)
+	assert.InDelta(t, 100, orders.LatencyP99, 25)
+	assert.Equal(t, &failedAt, orders.LastError)
+
+	// calls older than the window are dropped, while the last error is kept
+	c.now = c.now.Add(Window - resolution)
+	r.observe("sql", "orders", true, 50*time.Millisecond)
+	orders = r.summaries()[1]
+	assert.Equal(t, uint64(101), orders.Calls)
+	c.now = c.now.Add(resolution)
+	orders = r.summaries()[1]
+	assert.Equal(t, uint64(1), orders.Calls)
+	assert.Equal(t, uint64(0), orders.Errors)
+	assert.Equal(t, 1.0, orders.SuccessRate)
+	assert.InDelta(t, 50, orders.LatencyP99, 12.5)
+	assert.Equal(t, &failedAt, orders.LastError)
+
+	c.now = c.now.Add(Window)
+	assert.Equal(t, Summary{Kind: "sql", Name: "orders", SuccessRate: 1, LastError: &failedAt}, r.summaries()[1])
+}
+
+func TestPercentile(t *testing.T) {
+	t.Parallel()
+	latencies := make([]uint64, len(latencyBounds)+1)
+	assert.Equal(t, 0.0, percentile(latencies, 0, 0.5))
+
+	latencies[0] = 10
+	assert.Equal(t, 0.25, percentile(latencies, 10, 0.5))
+
+	latencies[len(latencyBounds)] = 90
+	assert.Equal(t, float64(latencyBounds[len(latencyBounds)-1])/float64(time.Millisecond), percentile(latencies, 100, 0.5))
+	assert.Equal(t, 0.5, percentile(latencies, 100, 0.1))
+}
+
+func TestHandler(t *testing.T) {
+	Observe("redis", "cache:6379", true, 5*time.Millisecond)
+
+	rsp := httptest.NewRecorder()
+	Handler(rsp, httptest.NewRequest(http.MethodGet, "/debug/dependencies", nil))
+	assert.Equal(t, http.StatusOK, rsp.Code)
+	assert.Equal(t, "application/json", rsp.Header().Get("Content-Type"))
+
+	var report Report
+	require.NoError(t, json.NewDecoder(rsp.Body).Decode(&report))
+	assert.Equal(t, "5m0s", report.Window)
+	require.Len(t, report.Dependencies, 1)
+	assert.Equal(t, "redis", report.Dependencies[0].Kind)
+	assert.Equal(t, "cache:6379", report.Dependencies[0].Name)
+	assert.Equal(t, uint64(1), report.Dependencies[0].Calls)
+}
@@ -0,0 +1,127 @@
+package dependency
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// latencyBounds are the upper bounds of the latency histogram, growing exponentially from 0.5ms to about 1m.
+var latencyBounds = func() []time.Duration {
+	var bb []time.Duration
+	for b := 500 * time.Microsecond; b < 75*time.Second; b = b * 5 / 4 {
+		bb = append(bb, b)
+	}
+	return bb
+}()
+
+type bucket struct {
+	start     int64
+	calls     uint64
+	errors    uint64
+	latencies []uint64
+}
+
+// tracker counts the calls of a dependency in a ring of buckets covering the window,
+// reusing the bucket of the oldest period for the current one.
+type tracker struct {
+	mu        sync.Mutex
+	buckets   []bucket
+	lastError time.Time
+}
+
+func newTracker() *tracker {
+	size := int(Window / resolution)
+	t := &tracker{buckets: make([]bucket, size)}
+	for i := range t.buckets {
+		t.buckets[i].start = -1
+		t.buckets[i].latencies = make([]uint64, len(latencyBounds)+1)
+	}
+	return t
+}
+
+func period(t time.Time) int64 {
+	return t.UnixNano() / int64(resolution)
+}
+
+func (t *tracker) add(now time.Time, success bool, latency time.Duration) {
+	p := period(now)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	b := &t.buckets[p%int64(len(t.buckets))]
+	if b.start != p {
+		b.start = p
+		b.calls = 0
+		b.errors = 0
+		for i := range b.latencies {
+			b.latencies[i] = 0
+		}
+	}
+	b.calls++
+	if !success {
+		b.errors++
+		t.lastError = now
+	}
+	b.latencies[sort.Search(len(latencyBounds), func(i int) bool { return latency <= latencyBounds[i] })]++
+}
+
+func (t *tracker) summary(now time.Time) Summary {
+	p := period(now)
+	periods := int64(len(t.buckets))
+	latencies := make([]uint64, len(latencyBounds)+1)
+	s := Summary{}
+
+	t.mu.Lock()
+	for _, b := range t.buckets {
+		if b.start <= p-periods || b.start > p {
+			continue
+		}
+		s.Calls += b.calls
+		s.Errors += b.errors
+		for i, c := range b.latencies {
+			latencies[i] += c
+		}
+	}
+	if !t.lastError.IsZero() {
+		lastError := t.lastError
+		s.LastError = &lastError
+	}
+	t.mu.Unlock()
+
+	s.SuccessRate = 1
+	if s.Calls > 0 {
+		s.SuccessRate = float64(s.Calls-s.Errors) / float64(s.Calls)
+	}
+	s.LatencyP50 = percentile(latencies, s.Calls, 0.5)
+	s.LatencyP90 = percentile(latencies, s.Calls, 0.9)
+	s.LatencyP99 = percentile(latencies, s.Calls, 0.99)
+	return s
+}
+
+// percentile estimates the percentile of the histogram in milliseconds, interpolating linearly within the bucket containing it.
+// Latencies beyond the last bound are estimated at the last bound.
+func percentile(latencies []uint64, total uint64, q float64) float64 {
+	if total == 0 {
+		return 0
+	}
+	rank := q * float64(total)
+	var count uint64
+	for i, c := range latencies {
+		if c == 0 || float64(count+c) < rank {
+			count += c
+			continue
+		}
+		if i == len(latencyBounds) {
+			break
+		}
+		lower := time.Duration(0)
+		if i > 0 {
+			lower = latencyBounds[i-1]
+		}
+		upper := latencyBounds[i]
+		v := float64(lower) + float64(upper-lower)*(rank-float64(count))/float64(c)
+		return v / float64(time.Millisecond)
+	}
+	return float64(latencyBounds[len(latencyBounds)-1]) / float64(time.Millisecond)
+}
@@ -52,6 +52,16 @@ The SNS and SQS clients provide a set of interfaces and structs useful for publi
 $(cat ../../go.mod | grep github.com/aws/aws-sdk-go | xargs)
 
 
+## Kinesis
+The Kinesis producer publishes records to AWS Kinesis data streams, aggregating them in the format of the Kinesis Producer Library, with integrating tracing.
+
+**Third-party dependencies**  
+$(cat ../../go.mod | grep github.com/aws/aws-sdk-go | xargs)
+
+
+## Azure Service Bus
+The Service Bus sender sends messages to Azure Service Bus queues and topics over its REST API, with integrated tracing.
+
 ## Elasticsearch
 The Elasticsearch client allows users to connect to an elasticsearch instance. Its behavior can be configured by providing an [\`elasticsearch.Config\`](https://github.com/elastic/go-elasticsearch/blob/4b40206692088570801280584e614027e6ce818b/elasticsearch.go#L32) struct
 
@@ -0,0 +1,198 @@
+// Package compression provides a codec compressing the payloads of messages, e.g. published to Kafka, SQS or AMQP,
+// with gzip, zstd or brotli. The encoding of a compressed payload is set in the Content-Encoding header of its message,
+// so that consumers decompress it accordingly, while payloads without the header are passed through intact.
+package compression
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/beatlabs/patron/encoding"
+	"github.com/beatlabs/patron/propagation"
+	"github.com/klauspost/compress/zstd"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// Gzip encoding.
+	Gzip string = "gzip"
+	// Zstd encoding, i.e. Zstandard.
+	Zstd string = "zstd"
+	// Brotli encoding.
+	Brotli string = "br"
+	// Identity encoding, i.e. no compression.
+	Identity string = "identity"
+)
+
+const (
+	operationCompress   = "compress"
+	operationDecompress = "decompress"
+)
+
+var (
+	payloadsMetric     *prometheus.CounterVec
+	payloadBytesMetric *prometheus.CounterVec
+
+	zstdDecoderOnce sync.Once
+	zstdDecoder     *zstd.Decoder
+	errZstdDecoder  error
+)
+
+func init() {
+	payloadsMetric = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "encoding",
+			Subsystem: "compression",
+			Name:      "payloads_total",
+			Help:      "Payloads compressed and decompressed by the codecs, classified by encoding and operation.",
+		},
+		[]string{"encoding", "operation"},
+	)
+	payloadBytesMetric = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "encoding",
+			Subsystem: "compression",
+			Name:      "payload_bytes_total",
+			Help:      "Bytes of the payloads compressed and decompressed by the codecs, classified by encoding, operation and stage i.e. uncompressed or compressed.",
+		},
+		[]string{"encoding", "operation", "stage"},
+	)
+	prometheus.MustRegister(payloadsMetric, payloadBytesMetric)
+}
+
+// Codec compresses payloads with an encoding at a level. It is safe for concurrent use.
+type Codec struct {
+	encoding string
+	level    int
+	zstd     *zstd.Encoder
+}
+
+// New creates a codec of the encoding, i.e. Gzip, Zstd or Brotli, at the level, which ranges from gzip.HuffmanOnly
+// to gzip.BestCompression for gzip, from 1 (fastest) to 22 (best) for zstd and from brotli.BestSpeed to brotli.BestCompression for brotli.
+func New(enc string, level int) (*Codec, error) {
+	c := &Codec{encoding: enc, level: level}
+	switch enc {
+	case Gzip:
+		if level < gzip.HuffmanOnly || level > gzip.BestCompression {
+			return nil, fmt.Errorf("invalid gzip compression level: %d", level)
+		}
+	case Zstd:
+		if level < 1 || level > 22 {
+			return nil, fmt.Errorf("invalid zstd compression level: %d", level)
+		}
+		enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level)))
+		if err != nil {
+			return nil, fmt.Errorf("could not create zstd encoder: %w", err)
+		}
+		c.zstd = enc
+	case Brotli:
+		if level < brotli.BestSpeed || level > brotli.BestCompression {
+			return nil, fmt.Errorf("invalid brotli compression level: %d", level)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported encoding: %s", enc)
+	}
+	return c, nil
+}
+
+// Encoding returns the encoding of the codec, which is set in the Content-Encoding header of the compressed messages.
+func (c *Codec) Encoding() string {
+	return c.encoding
+}
+
+// Compress compresses the data.
+func (c *Codec) Compress(data []byte) ([]byte, error) {
+	var compressed []byte
+	switch c.encoding {
+	case Zstd:
+		compressed = c.zstd.EncodeAll(data, nil)
+	case Brotli:
+		var buf bytes.Buffer
+		w := brotli.NewWriterLevel(&buf, c.level)
+		if err := writeAndClose(w, data); err != nil {
+			return nil, fmt.Errorf("could not compress payload with %s: %w", c.encoding, err)
+		}
+		compressed = buf.Bytes()
+	default:
+		var buf bytes.Buffer
+		w, err := gzip.NewWriterLevel(&buf, c.level)
+		if err != nil {
+			return nil, fmt.Errorf("could not compress payload with %s: %w", c.encoding, err)
+		}
+		if err := writeAndClose(w, data); err != nil {
+			return nil, fmt.Errorf("could not compress payload with %s: %w", c.encoding, err)
+		}
+		compressed = buf.Bytes()
+	}
+	observe(c.encoding, operationCompress, len(data), len(compressed))
+	return compressed, nil
+}
+
+// CompressMessage compresses the payload of a message, and sets the encoding in the Content-Encoding header of the message,
+// e.g. propagation.KafkaHeaders or propagation.SQSAttributes.
+func (c *Codec) CompressMessage(data []byte, headers propagation.Carrier) ([]byte, error) {
+	compressed, err := c.Compress(data)
+	if err != nil {
+		return nil, err
+	}
+	headers.Set(encoding.ContentEncodingHeader, c.encoding)
+	return compressed, nil
+}
+
+// Decompress decompresses the data of the encoding, i.e. Gzip, Zstd or Brotli. Data of the Identity or an empty encoding
+// are returned intact.
+func Decompress(enc string, data []byte) ([]byte, error) {
+	var decompressed []byte
+	var err error
+	switch enc {
+	case "", Identity:
+		return data, nil
+	case Gzip:
+		var r *gzip.Reader
+		r, err = gzip.NewReader(bytes.NewReader(data))
+		if err == nil {
+			decompressed, err = ioutil.ReadAll(r)
+		}
+	case Zstd:
+		zstdDecoderOnce.Do(func() {
+			zstdDecoder, errZstdDecoder = zstd.NewReader(nil)
+		})
+		if errZstdDecoder != nil {
+			return nil, fmt.Errorf("could not create zstd decoder: %w", errZstdDecoder)
+		}
+		decompressed, err = zstdDecoder.DecodeAll(data, nil)
+	case Brotli:
+		decompressed, err = ioutil.ReadAll(brotli.NewReader(bytes.NewReader(data)))
+	default:
+		return nil, fmt.Errorf("unsupported encoding: %s", enc)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not decompress payload with %s: %w", enc, err)
+	}
+	observe(enc, operationDecompress, len(decompressed), len(data))
+	return decompressed, nil
+}
+
+// DecompressMessage decompresses the payload of a message according to the Content-Encoding header of the message,
+// which is returned intact without it.
+func DecompressMessage(data []byte, headers propagation.Carrier) ([]byte, error) {
+	return Decompress(headers.Get(encoding.ContentEncodingHeader), data)
+}
+
+func writeAndClose(w io.WriteCloser, data []byte) error {
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+func observe(enc, operation string, uncompressed, compressed int) {
+	payloadsMetric.WithLabelValues(enc, operation).Inc()
+	payloadBytesMetric.WithLabelValues(enc, operation, "uncompressed").Add(float64(uncompressed))
+	payloadBytesMetric.WithLabelValues(enc, operation, "compressed").Add(float64(compressed))
+}
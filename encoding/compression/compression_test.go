@@ -0,0 +1,136 @@
+package compression
+
+import (
+	"compress/gzip"
+	"strings"
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/andybalholm/brotli"
+	"github.com/beatlabs/patron/encoding"
+	"github.com/beatlabs/patron/propagation"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		encoding    string
+		level       int
+		expectedErr string
+	}{
+		"gzip":                 {encoding: Gzip, level: gzip.BestSpeed},
+		"zstd":                 {encoding: Zstd, level: 3},
+		"brotli":               {encoding: Brotli, level: brotli.DefaultCompression},
+		"invalid gzip level":   {encoding: Gzip, level: 10, expectedErr: "invalid gzip compression level: 10"},
+		"invalid zstd level":   {encoding: Zstd, level: 0, expectedErr: "invalid zstd compression level: 0"},
+		"invalid brotli level": {encoding: Brotli, level: 12, expectedErr: "invalid brotli compression level: 12"},
+		"unsupported encoding": {encoding: "lz4", level: 1, expectedErr: "unsupported encoding: lz4"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			got, err := New(tt.encoding, tt.level)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.Nil(t, got)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.encoding, got.Encoding())
+		})
+	}
+}
+
+func TestCodec_Compress(t *testing.T) {
+	t.Parallel()
+	data := []byte(strings.Repeat(`{"name":"patron"}`, 100))
+	for _, enc := range []string{Gzip, Zstd, Brotli} {
+		enc := enc
+		t.Run(enc, func(t *testing.T) {
+			t.Parallel()
+			codec, err := New(enc, 5)
+			require.NoError(t, err)
+
+			compressed, err := codec.Compress(data)
+			require.NoError(t, err)
+			assert.Less(t, len(compressed), len(data))
+
+			decompressed, err := Decompress(enc, compressed)
+			require.NoError(t, err)
+			assert.Equal(t, data, decompressed)
+		})
+	}
+}
+
+func TestDecompress(t *testing.T) {
+	t.Parallel()
+	data := []byte("patron")
+	tests := map[string]struct {
+		encoding    string
+		data        []byte
+		expected    []byte
+		expectedErr string
+	}{
+		"no encoding":          {encoding: "", data: data, expected: data},
+		"identity":             {encoding: Identity, data: data, expected: data},
+		"unsupported encoding": {encoding: "lz4", data: data, expectedErr: "unsupported encoding: lz4"},
+		"invalid gzip":         {encoding: Gzip, data: data, expectedErr: "could not decompress payload with gzip: unexpected EOF"},
+		"invalid zstd":         {encoding: Zstd, data: data, expectedErr: "could not decompress payload with zstd: invalid input: magic number mismatch"},
+		"invalid brotli":       {encoding: Brotli, data: []byte{0xff, 0xff}, expectedErr: "could not decompress payload with br: brotli: PADDING_2"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			got, err := Decompress(tt.encoding, tt.data)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.Nil(t, got)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestCodec_CompressMessage(t *testing.T) {
+	t.Parallel()
+	codec, err := New(Zstd, 3)
+	require.NoError(t, err)
+	data := []byte(strings.Repeat("patron ", 100))
+	var headers []sarama.RecordHeader
+
+	compressed, err := codec.CompressMessage(data, (*propagation.KafkaHeaders)(&headers))
+	require.NoError(t, err)
+	assert.Equal(t, []sarama.RecordHeader{{Key: []byte(encoding.ContentEncodingHeader), Value: []byte(Zstd)}}, headers)
+
+	decompressed, err := DecompressMessage(compressed, (*propagation.KafkaHeaders)(&headers))
+	require.NoError(t, err)
+	assert.Equal(t, data, decompressed)
+
+	// messages without the header are passed through
+	decompressed, err = DecompressMessage(data, propagation.MapCarrier{})
+	require.NoError(t, err)
+	assert.Equal(t, data, decompressed)
+}
+
+func TestMetrics(t *testing.T) {
+	codec, err := New(Brotli, 5)
+	require.NoError(t, err)
+	data := []byte(strings.Repeat("patron ", 100))
+	payloads := testutil.ToFloat64(payloadsMetric.WithLabelValues(Brotli, operationCompress))
+	uncompressed := testutil.ToFloat64(payloadBytesMetric.WithLabelValues(Brotli, operationDecompress, "uncompressed"))
+
+	compressed, err := codec.Compress(data)
+	require.NoError(t, err)
+	_, err = Decompress(Brotli, compressed)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(payloadsMetric.WithLabelValues(Brotli, operationCompress))-payloads)
+	assert.Equal(t, float64(len(data)), testutil.ToFloat64(payloadBytesMetric.WithLabelValues(Brotli, operationDecompress, "uncompressed"))-uncompressed)
+}
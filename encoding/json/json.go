@@ -2,8 +2,12 @@
 package json
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"io"
+	"sync"
+	"sync/atomic"
 )
 
 const (
@@ -11,19 +15,130 @@ const (
 	Type string = "application/json"
 	// TypeCharset JSON definition with charset.
 	TypeCharset string = "application/json; charset=utf-8"
+
+	// maxPooledBufferSize is the capacity of the largest buffers returned to the pools, so that they don't retain the memory of rare large payloads.
+	maxPooledBufferSize = 64 * 1024
 )
 
+// API is a JSON implementation, e.g. jsoniter.ConfigCompatibleWithStandardLibrary of github.com/json-iterator/go.
+type API interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// Funcs adapts the marshal and unmarshal functions of a JSON implementation to an API,
+// e.g. the Marshal and Unmarshal functions of github.com/segmentio/encoding/json.
+type Funcs struct {
+	MarshalFunc   func(v interface{}) ([]byte, error)
+	UnmarshalFunc func(data []byte, v interface{}) error
+}
+
+// Marshal encodes the value with the marshal function.
+func (f Funcs) Marshal(v interface{}) ([]byte, error) {
+	return f.MarshalFunc(v)
+}
+
+// Unmarshal decodes the data into the value with the unmarshal function.
+func (f Funcs) Unmarshal(data []byte, v interface{}) error {
+	return f.UnmarshalFunc(data, v)
+}
+
+// apiHolder wraps the API, since an atomic.Value requires its values to have the same concrete type.
+type apiHolder struct {
+	api API
+}
+
+var current atomic.Value
+
+// SetAPI replaces the JSON implementation of the package, which defaults to encoding/json, and is used by the encoders of the HTTP component.
+// It should be called once, when the service starts, e.g. before building its components.
+func SetAPI(api API) error {
+	if api == nil {
+		return errors.New("api is nil")
+	}
+	current.Store(apiHolder{api: api})
+	return nil
+}
+
+// ResetAPI restores encoding/json as the JSON implementation of the package.
+func ResetAPI() {
+	current.Store(apiHolder{})
+}
+
+func currentAPI() API {
+	h, _ := current.Load().(apiHolder)
+	return h.api
+}
+
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		return new(bytes.Buffer)
+	},
+}
+
+// encoder is a pooled encoding/json encoder, writing to its own buffer.
+type encoder struct {
+	buf bytes.Buffer
+	enc *json.Encoder
+}
+
+var encoderPool = sync.Pool{
+	New: func() interface{} {
+		e := &encoder{}
+		e.enc = json.NewEncoder(&e.buf)
+		return e
+	},
+}
+
 // Decode a JSON input in the form of a read.
+// The input is read into a pooled buffer, and should contain a single JSON value.
 func Decode(data io.Reader, v interface{}) error {
-	return json.NewDecoder(data).Decode(v)
+	buf := bufferPool.Get().(*bytes.Buffer)
+	defer putBuffer(buf)
+	buf.Reset()
+	if _, err := buf.ReadFrom(data); err != nil {
+		return err
+	}
+	if buf.Len() == 0 {
+		return io.EOF
+	}
+	return DecodeRaw(buf.Bytes(), v)
 }
 
 // DecodeRaw a JSON input in the form of a byte slice.
 func DecodeRaw(data []byte, v interface{}) error {
+	if api := currentAPI(); api != nil {
+		return api.Unmarshal(data, v)
+	}
 	return json.Unmarshal(data, v)
 }
 
 // Encode a model to JSON.
+// The default implementation reuses pooled encoders and buffers, copying the result out of them.
 func Encode(v interface{}) ([]byte, error) {
-	return json.Marshal(v)
+	if api := currentAPI(); api != nil {
+		return api.Marshal(v)
+	}
+
+	e := encoderPool.Get().(*encoder)
+	defer func() {
+		if e.buf.Cap() <= maxPooledBufferSize {
+			encoderPool.Put(e)
+		}
+	}()
+	e.buf.Reset()
+	if err := e.enc.Encode(v); err != nil {
+		return nil, err
+	}
+	// the encoder terminates every value with a newline, which json.Marshal does not
+	b := bytes.TrimSuffix(e.buf.Bytes(), []byte{'\n'})
+	out := make([]byte, len(b))
+	copy(out, b)
+	return out, nil
+}
+
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() <= maxPooledBufferSize {
+		bufferPool.Put(buf)
+	}
 }
@@ -2,9 +2,13 @@ package json
 
 import (
 	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestEncodeDecode(t *testing.T) {
@@ -19,3 +23,122 @@ func TestEncodeDecode(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "string", data)
 }
+
+type order struct {
+	ID       string   `json:"id"`
+	Customer string   `json:"customer"`
+	Items    []string `json:"items"`
+	Total    float64  `json:"total"`
+	Note     string   `json:"note,omitempty"`
+}
+
+var benchmarkOrder = order{ID: "1", Customer: "john@example.com", Items: []string{"book", "pen", "notebook"}, Total: 42.5, Note: "<fragile> & urgent"}
+
+func TestEncode_MatchesMarshal(t *testing.T) {
+	expected, err := json.Marshal(benchmarkOrder)
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		got, err := Encode(benchmarkOrder)
+		require.NoError(t, err)
+		assert.Equal(t, expected, got)
+	}
+
+	_, err = Encode(make(chan int))
+	assert.Error(t, err)
+}
+
+func TestDecode(t *testing.T) {
+	var got order
+	assert.Equal(t, io.EOF, Decode(bytes.NewReader(nil), &got))
+	assert.Error(t, Decode(bytes.NewBufferString("{"), &got))
+
+	large := order{Note: strings.Repeat("a", 2*maxPooledBufferSize)}
+	b, err := Encode(large)
+	require.NoError(t, err)
+	require.NoError(t, Decode(bytes.NewReader(b), &got))
+	assert.Equal(t, large, got)
+}
+
+// recordingAPI is a JSON implementation, which records its calls and delegates them to encoding/json.
+type recordingAPI struct {
+	marshaled, unmarshaled int
+}
+
+func (r *recordingAPI) Marshal(v interface{}) ([]byte, error) {
+	r.marshaled++
+	return json.Marshal(v)
+}
+
+func (r *recordingAPI) Unmarshal(data []byte, v interface{}) error {
+	r.unmarshaled++
+	return json.Unmarshal(data, v)
+}
+
+func TestSetAPI(t *testing.T) {
+	assert.EqualError(t, SetAPI(nil), "api is nil")
+
+	api := &recordingAPI{}
+	require.NoError(t, SetAPI(api))
+	t.Cleanup(ResetAPI)
+
+	b, err := Encode(benchmarkOrder)
+	require.NoError(t, err)
+	var got order
+	require.NoError(t, Decode(bytes.NewReader(b), &got))
+	require.NoError(t, DecodeRaw(b, &got))
+	assert.Equal(t, benchmarkOrder, got)
+	assert.Equal(t, 1, api.marshaled)
+	assert.Equal(t, 2, api.unmarshaled)
+
+	ResetAPI()
+	_, err = Encode(benchmarkOrder)
+	require.NoError(t, err)
+	assert.Equal(t, 1, api.marshaled)
+}
+
+func TestFuncs(t *testing.T) {
+	require.NoError(t, SetAPI(Funcs{MarshalFunc: json.Marshal, UnmarshalFunc: json.Unmarshal}))
+	t.Cleanup(ResetAPI)
+
+	b, err := Encode(benchmarkOrder)
+	require.NoError(t, err)
+	var got order
+	require.NoError(t, DecodeRaw(b, &got))
+	assert.Equal(t, benchmarkOrder, got)
+}
+
+func BenchmarkEncode(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, _ = Encode(benchmarkOrder)
+	}
+}
+
+func BenchmarkEncode_Marshal(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, _ = json.Marshal(benchmarkOrder)
+	}
+}
+
+func BenchmarkDecode(b *testing.B) {
+	data, err := json.Marshal(benchmarkOrder)
+	require.NoError(b, err)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var o order
+		_ = Decode(bytes.NewReader(data), &o)
+	}
+}
+
+func BenchmarkDecode_Decoder(b *testing.B) {
+	data, err := json.Marshal(benchmarkOrder)
+	require.NoError(b, err)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var o order
+		_ = json.NewDecoder(bytes.NewReader(data)).Decode(&o)
+	}
+}
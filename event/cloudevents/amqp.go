@@ -0,0 +1,47 @@
+package cloudevents
+
+import (
+	"fmt"
+
+	"github.com/streadway/amqp"
+)
+
+const (
+	amqpPrefix         = "cloudEvents_"
+	amqpPrefixReserved = "cloudEvents:"
+)
+
+// ToAMQP sets the body, the content type and the headers of the publishing to the event, according to the mode.
+// In binary mode the attributes are mapped to cloudEvents_ prefixed headers, and the data content type to the content type.
+func ToAMQP(pub *amqp.Publishing, e Event, mode Mode) error {
+	m, err := encode(e, mode, amqpPrefix)
+	if err != nil {
+		return err
+	}
+	if len(m.headers) > 0 && pub.Headers == nil {
+		pub.Headers = make(amqp.Table, len(m.headers))
+	}
+	for name, value := range m.headers {
+		pub.Headers[name] = value
+	}
+	pub.ContentType = m.contentType
+	pub.Body = m.body
+	return nil
+}
+
+// FromAMQP decodes the event of the delivery, in structured or binary mode.
+// Headers with both the cloudEvents_ and cloudEvents: prefixes are accepted.
+func FromAMQP(d amqp.Delivery) (Event, error) {
+	m := message{headers: make(map[string]string, len(d.Headers)), contentType: d.ContentType, body: d.Body}
+	for name, value := range d.Headers {
+		switch v := value.(type) {
+		case string:
+			m.headers[name] = v
+		case []byte:
+			m.headers[name] = string(v)
+		default:
+			m.headers[name] = fmt.Sprint(v)
+		}
+	}
+	return decode(m, amqpPrefix, amqpPrefixReserved)
+}
@@ -0,0 +1,58 @@
+package cloudevents
+
+import (
+	"testing"
+
+	"github.com/streadway/amqp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAMQP(t *testing.T) {
+	t.Parallel()
+	for name, mode := range map[string]Mode{"binary": Binary, "structured": Structured} {
+		mode := mode
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			pub := amqp.Publishing{Headers: amqp.Table{"X-Correlation-Id": "456"}}
+			require.NoError(t, ToAMQP(&pub, testEvent(), mode))
+
+			got, err := FromAMQP(amqp.Delivery{Headers: pub.Headers, ContentType: pub.ContentType, Body: pub.Body})
+			require.NoError(t, err)
+			assert.Equal(t, testEvent(), got)
+		})
+	}
+}
+
+func TestToAMQP_BinaryHeaders(t *testing.T) {
+	t.Parallel()
+	var pub amqp.Publishing
+	require.NoError(t, ToAMQP(&pub, testEvent(), Binary))
+	assert.Equal(t, "1", pub.Headers["cloudEvents_id"])
+	assert.Equal(t, "com.example.order.created", pub.Headers["cloudEvents_type"])
+	assert.Equal(t, "application/json", pub.ContentType)
+	assert.Equal(t, testEvent().Data, pub.Body)
+}
+
+func TestFromAMQP(t *testing.T) {
+	t.Parallel()
+	got, err := FromAMQP(amqp.Delivery{
+		Headers: amqp.Table{
+			"cloudEvents:id":          "1",
+			"cloudEvents:source":      []byte("/orders"),
+			"cloudEvents:specversion": "1.0",
+			"cloudEvents:type":        "created",
+			"cloudEvents:count":       int32(3),
+		},
+		ContentType: "text/plain",
+		Body:        []byte("hello"),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, Event{
+		ID: "1", Source: "/orders", SpecVersion: "1.0", Type: "created", DataContentType: "text/plain",
+		Extensions: map[string]string{"count": "3"}, Data: []byte("hello"),
+	}, got)
+
+	_, err = FromAMQP(amqp.Delivery{Body: []byte("hello")})
+	assert.Equal(t, ErrNotEvent, err)
+}
@@ -0,0 +1,93 @@
+package cloudevents
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/beatlabs/patron/encoding"
+)
+
+const (
+	awsPrefix = "ce_"
+	// awsAttributeLimit is the maximum number of message attributes of SQS and SNS messages.
+	awsAttributeLimit = 10
+	stringDataType    = "String"
+)
+
+// ToSQS sets the body and the message attributes of the input to the event, according to the mode.
+// In binary mode the attributes are mapped to ce_ prefixed message attributes, and the data content type to the
+// Content-Type attribute. Since SQS messages carry text and up to 10 attributes, structured mode should be preferred
+// for binary data, e.g. protobuf, and for events with many extensions.
+func ToSQS(in *sqs.SendMessageInput, e Event, mode Mode) error {
+	m, err := encodeAWS(e, mode, len(in.MessageAttributes))
+	if err != nil {
+		return err
+	}
+	if in.MessageAttributes == nil {
+		in.MessageAttributes = make(map[string]*sqs.MessageAttributeValue, len(m.headers))
+	}
+	for name, value := range m.headers {
+		in.MessageAttributes[name] = &sqs.MessageAttributeValue{DataType: aws.String(stringDataType), StringValue: aws.String(value)}
+	}
+	in.MessageBody = aws.String(string(m.body))
+	return nil
+}
+
+// ToSNS sets the message and the message attributes of the input to the event, according to the mode, as ToSQS.
+// Subscribed SQS queues should have raw message delivery enabled, so that the events can be decoded with FromSQS.
+func ToSNS(in *sns.PublishInput, e Event, mode Mode) error {
+	m, err := encodeAWS(e, mode, len(in.MessageAttributes))
+	if err != nil {
+		return err
+	}
+	if in.MessageAttributes == nil {
+		in.MessageAttributes = make(map[string]*sns.MessageAttributeValue, len(m.headers))
+	}
+	for name, value := range m.headers {
+		in.MessageAttributes[name] = &sns.MessageAttributeValue{DataType: aws.String(stringDataType), StringValue: aws.String(value)}
+	}
+	in.Message = aws.String(string(m.body))
+	return nil
+}
+
+// FromSQS decodes the event of the SQS message, in structured or binary mode.
+func FromSQS(msg *sqs.Message) (Event, error) {
+	m := message{headers: make(map[string]string, len(msg.MessageAttributes)), body: []byte(aws.StringValue(msg.Body))}
+	for name, value := range msg.MessageAttributes {
+		if value == nil || value.StringValue == nil {
+			continue
+		}
+		if strings.EqualFold(name, encoding.ContentTypeHeader) {
+			m.contentType = *value.StringValue
+			continue
+		}
+		m.headers[name] = *value.StringValue
+	}
+	return decode(m, awsPrefix)
+}
+
+// encodeAWS encodes the event with the content type as a header, checking the limits of the messages.
+func encodeAWS(e Event, mode Mode, attributes int) (message, error) {
+	m, err := encode(e, mode, awsPrefix)
+	if err != nil {
+		return message{}, err
+	}
+	if !utf8.Valid(m.body) {
+		return message{}, errors.New("data is not valid UTF-8, use structured mode")
+	}
+	if m.contentType != "" {
+		if m.headers == nil {
+			m.headers = make(map[string]string, 1)
+		}
+		m.headers[encoding.ContentTypeHeader] = m.contentType
+	}
+	if n := attributes + len(m.headers); n > awsAttributeLimit {
+		return message{}, fmt.Errorf("%d message attributes exceed the limit of %d, use structured mode", n, awsAttributeLimit)
+	}
+	return m, nil
+}
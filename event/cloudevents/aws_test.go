@@ -0,0 +1,75 @@
+package cloudevents
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSQS(t *testing.T) {
+	t.Parallel()
+	for name, mode := range map[string]Mode{"binary": Binary, "structured": Structured} {
+		mode := mode
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			in := &sqs.SendMessageInput{QueueUrl: aws.String("url")}
+			require.NoError(t, ToSQS(in, testEvent(), mode))
+
+			got, err := FromSQS(&sqs.Message{Body: in.MessageBody, MessageAttributes: in.MessageAttributes})
+			require.NoError(t, err)
+			assert.Equal(t, testEvent(), got)
+		})
+	}
+}
+
+func TestSNS(t *testing.T) {
+	t.Parallel()
+	for name, mode := range map[string]Mode{"binary": Binary, "structured": Structured} {
+		mode := mode
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			in := &sns.PublishInput{TopicArn: aws.String("arn")}
+			require.NoError(t, ToSNS(in, testEvent(), mode))
+
+			// raw message delivery keeps the message and its attributes
+			attrs := make(map[string]*sqs.MessageAttributeValue, len(in.MessageAttributes))
+			for name, value := range in.MessageAttributes {
+				attrs[name] = &sqs.MessageAttributeValue{DataType: value.DataType, StringValue: value.StringValue}
+			}
+			got, err := FromSQS(&sqs.Message{Body: in.Message, MessageAttributes: attrs})
+			require.NoError(t, err)
+			assert.Equal(t, testEvent(), got)
+		})
+	}
+}
+
+func TestToSQS_Binary(t *testing.T) {
+	t.Parallel()
+	in := &sqs.SendMessageInput{}
+	require.NoError(t, ToSQS(in, testEvent(), Binary))
+	assert.Equal(t, "1", aws.StringValue(in.MessageAttributes["ce_id"].StringValue))
+	assert.Equal(t, "application/json", aws.StringValue(in.MessageAttributes["Content-Type"].StringValue))
+	assert.Equal(t, string(testEvent().Data), aws.StringValue(in.MessageBody))
+}
+
+func TestToSQS_Failure(t *testing.T) {
+	t.Parallel()
+	binary := testEvent()
+	binary.DataContentType = "application/octet-stream"
+	binary.Data = []byte{0xff}
+	assert.EqualError(t, ToSQS(&sqs.SendMessageInput{}, binary, Binary), "data is not valid UTF-8, use structured mode")
+	assert.NoError(t, ToSQS(&sqs.SendMessageInput{}, binary, Structured))
+
+	many := testEvent()
+	many.Extensions["ext1"] = "1"
+	many.Extensions["ext2"] = "2"
+	assert.EqualError(t, ToSNS(&sns.PublishInput{}, many, Binary), "11 message attributes exceed the limit of 10, use structured mode")
+	assert.NoError(t, ToSNS(&sns.PublishInput{}, many, Structured))
+
+	_, err := FromSQS(&sqs.Message{Body: aws.String("hello")})
+	assert.Equal(t, ErrNotEvent, err)
+}
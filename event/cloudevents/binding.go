@@ -0,0 +1,90 @@
+package cloudevents
+
+import (
+	"errors"
+	"sort"
+	"strings"
+)
+
+// ErrNotEvent is returned when decoding a message, which is neither in structured mode nor has a spec version header in binary mode.
+var ErrNotEvent = errors.New("message is not a cloud event")
+
+// message is the transport-agnostic representation of an event in a transport message.
+type message struct {
+	headers     map[string]string
+	contentType string
+	body        []byte
+}
+
+// sortedHeaders returns the names of the headers in order, so that the headers of transports with lists of them are deterministic.
+func (m message) sortedHeaders() []string {
+	names := make([]string, 0, len(m.headers))
+	for name := range m.headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// encode the event according to the mode, prefixing the names of the attributes in binary mode.
+func encode(e Event, mode Mode, prefix string) (message, error) {
+	switch mode {
+	case Structured:
+		b, err := Marshal(e)
+		if err != nil {
+			return message{}, err
+		}
+		return message{contentType: JSONType, body: b}, nil
+	case Binary:
+		if err := e.Validate(); err != nil {
+			return message{}, err
+		}
+		attrs := e.attributes()
+		hdr := make(map[string]string, len(attrs))
+		for name, value := range attrs {
+			hdr[prefix+name] = value
+		}
+		return message{headers: hdr, contentType: e.DataContentType, body: e.Data}, nil
+	}
+	return message{}, errors.New("content mode is unsupported")
+}
+
+// decode an event in structured mode, if the message has its content type, or else in binary mode,
+// from the headers with any of the prefixes, which are matched case-insensitively.
+// The headers not matching a prefix, or whose names are not valid attribute names, e.g. tracing headers, are skipped.
+func decode(m message, prefixes ...string) (Event, error) {
+	if isStructured(m.contentType) {
+		return Unmarshal(m.body)
+	}
+
+	var e Event
+	for key, value := range m.headers {
+		name, ok := trimPrefix(key, prefixes)
+		if !ok || !extensionNameRegex.MatchString(name) || name == attrDataContentType {
+			continue
+		}
+		if err := e.setAttribute(name, value); err != nil {
+			return Event{}, err
+		}
+	}
+	if e.SpecVersion == "" {
+		return Event{}, ErrNotEvent
+	}
+	e.DataContentType = m.contentType
+	if len(m.body) > 0 {
+		e.Data = m.body
+	}
+	if err := e.Validate(); err != nil {
+		return Event{}, err
+	}
+	return e, nil
+}
+
+func trimPrefix(key string, prefixes []string) (string, bool) {
+	for _, prefix := range prefixes {
+		if len(key) >= len(prefix) && strings.EqualFold(key[:len(prefix)], prefix) {
+			return strings.ToLower(key[len(prefix):]), true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,269 @@
+// Package cloudevents provides the encoding and decoding of CloudEvents 1.0 over the messaging transports,
+// i.e. Kafka, AWS SQS and SNS, AMQP, MQTT and HTTP, in both the binary and structured content modes.
+// In binary mode the attributes of an event are mapped to the headers of the transport, and the data to its body,
+// while in structured mode the whole event is encoded as JSON in the body.
+// The correlation ID and the trace context of the context are carried by the correlationid and traceparent extension attributes.
+package cloudevents
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/beatlabs/patron/correlation"
+	"github.com/beatlabs/patron/encoding/json"
+	"github.com/beatlabs/patron/encoding/protobuf"
+	"github.com/google/uuid"
+	"github.com/opentracing/opentracing-go"
+	"github.com/uber/jaeger-client-go"
+)
+
+const (
+	// SpecVersion of the CloudEvents specification.
+	SpecVersion string = "1.0"
+	// JSONType is the content type of events in structured mode.
+	JSONType string = "application/cloudevents+json"
+
+	// CorrelationIDExtension is the extension attribute of the correlation ID.
+	CorrelationIDExtension string = "correlationid"
+	// TraceParentExtension is the extension attribute of the W3C trace context, as defined by the distributed tracing extension.
+	TraceParentExtension string = "traceparent"
+	// TraceStateExtension is the extension attribute of the W3C trace state, as defined by the distributed tracing extension.
+	TraceStateExtension string = "tracestate"
+)
+
+// Mode of the content of an event in a transport message.
+type Mode int
+
+const (
+	// Binary mode maps the attributes of the event to the headers of the message, and its data to the body.
+	Binary Mode = iota
+	// Structured mode encodes the event as JSON in the body of the message.
+	Structured
+)
+
+// attribute names of the context attributes.
+const (
+	attrID              = "id"
+	attrSource          = "source"
+	attrSpecVersion     = "specversion"
+	attrType            = "type"
+	attrDataContentType = "datacontenttype"
+	attrDataSchema      = "dataschema"
+	attrSubject         = "subject"
+	attrTime            = "time"
+)
+
+var extensionNameRegex = regexp.MustCompile(`^[a-z0-9]+$`)
+
+// Event of the CloudEvents specification.
+type Event struct {
+	ID          string
+	Source      string
+	SpecVersion string
+	Type        string
+	// DataContentType is the content type of the data, e.g. application/json.
+	DataContentType string
+	DataSchema      string
+	Subject         string
+	Time            time.Time
+	// Extensions are the extension attributes, whose names consist of lowercase letters and digits.
+	Extensions map[string]string
+	Data       []byte
+}
+
+// New creates an event of the source and type with a new ID and the current time.
+// The correlation ID and the trace context of the context are set as extension attributes.
+func New(ctx context.Context, source, typ string) Event {
+	e := Event{
+		ID:          uuid.New().String(),
+		Source:      source,
+		SpecVersion: SpecVersion,
+		Type:        typ,
+		Time:        time.Now().UTC(),
+		Extensions:  map[string]string{CorrelationIDExtension: correlation.IDFromContext(ctx)},
+	}
+	if tp := traceParent(ctx); tp != "" {
+		e.Extensions[TraceParentExtension] = tp
+	}
+	return e
+}
+
+// Validate the required attributes, the spec version and the names of the extensions of the event.
+func (e Event) Validate() error {
+	if e.ID == "" {
+		return errors.New("id is empty")
+	}
+	if e.Source == "" {
+		return errors.New("source is empty")
+	}
+	if e.Type == "" {
+		return errors.New("type is empty")
+	}
+	if e.SpecVersion != SpecVersion {
+		return fmt.Errorf("spec version %s is unsupported", e.SpecVersion)
+	}
+	for name := range e.Extensions {
+		if !extensionNameRegex.MatchString(name) {
+			return fmt.Errorf("extension name %s should consist of lowercase letters and digits", name)
+		}
+		if isContextAttribute(name) {
+			return fmt.Errorf("extension name %s is a context attribute", name)
+		}
+	}
+	return nil
+}
+
+// CorrelationID returns the correlation ID of the correlationid extension, if any.
+func (e Event) CorrelationID() string {
+	return e.Extensions[CorrelationIDExtension]
+}
+
+// Context returns the context with the correlation ID of the event, if any.
+func (e Event) Context(ctx context.Context) context.Context {
+	if id := e.CorrelationID(); id != "" {
+		return correlation.ContextWithID(ctx, id)
+	}
+	return ctx
+}
+
+// SpanContext returns the span context of the traceparent extension, e.g. to reference the span of the producer
+// of the event, if it was not propagated by the headers of the transport.
+func (e Event) SpanContext() (opentracing.SpanContext, bool) {
+	tp, ok := e.Extensions[TraceParentExtension]
+	if !ok {
+		return nil, false
+	}
+	return parseTraceParent(tp)
+}
+
+// DecodeData decodes the data of the event into v, according to its content type, which defaults to JSON.
+func (e Event) DecodeData(v interface{}) error {
+	switch {
+	case e.DataContentType == "" || isJSON(e.DataContentType):
+		return json.DecodeRaw(e.Data, v)
+	case e.DataContentType == protobuf.Type || e.DataContentType == protobuf.TypeGoogle:
+		return protobuf.DecodeRaw(e.Data, v)
+	}
+	return fmt.Errorf("data content type %s is unsupported", e.DataContentType)
+}
+
+// attributes returns the context and extension attributes of the event, except for its data content type.
+func (e Event) attributes() map[string]string {
+	attrs := make(map[string]string, len(e.Extensions)+7)
+	for name, value := range e.Extensions {
+		attrs[name] = value
+	}
+	attrs[attrID] = e.ID
+	attrs[attrSource] = e.Source
+	attrs[attrSpecVersion] = e.SpecVersion
+	attrs[attrType] = e.Type
+	if e.DataSchema != "" {
+		attrs[attrDataSchema] = e.DataSchema
+	}
+	if e.Subject != "" {
+		attrs[attrSubject] = e.Subject
+	}
+	if !e.Time.IsZero() {
+		attrs[attrTime] = e.Time.UTC().Format(time.RFC3339Nano)
+	}
+	return attrs
+}
+
+// setAttribute sets an attribute of the event, other than its data content type.
+func (e *Event) setAttribute(name, value string) error {
+	switch name {
+	case attrID:
+		e.ID = value
+	case attrSource:
+		e.Source = value
+	case attrSpecVersion:
+		e.SpecVersion = value
+	case attrType:
+		e.Type = value
+	case attrDataContentType:
+		e.DataContentType = value
+	case attrDataSchema:
+		e.DataSchema = value
+	case attrSubject:
+		e.Subject = value
+	case attrTime:
+		t, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			return fmt.Errorf("invalid time %s: %w", value, err)
+		}
+		e.Time = t.UTC()
+	default:
+		if e.Extensions == nil {
+			e.Extensions = make(map[string]string)
+		}
+		e.Extensions[name] = value
+	}
+	return nil
+}
+
+func isContextAttribute(name string) bool {
+	switch name {
+	case attrID, attrSource, attrSpecVersion, attrType, attrDataContentType, attrDataSchema, attrSubject, attrTime, "data", "data_base64":
+		return true
+	}
+	return false
+}
+
+// isJSON returns true for the JSON media types, e.g. application/json, text/json or application/geo+json.
+func isJSON(contentType string) bool {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mt == "application/json" || mt == "text/json" || strings.HasSuffix(mt, "+json")
+}
+
+// isStructured returns true if the content type is the one of the structured mode.
+func isStructured(contentType string) bool {
+	mt, _, err := mime.ParseMediaType(contentType)
+	return err == nil && mt == JSONType
+}
+
+// traceParent returns the W3C trace context of the span of the context, if it is a jaeger span.
+func traceParent(ctx context.Context) string {
+	sp := opentracing.SpanFromContext(ctx)
+	if sp == nil {
+		return ""
+	}
+	sc, ok := sp.Context().(jaeger.SpanContext)
+	if !ok || !sc.IsValid() {
+		return ""
+	}
+	flags := 0
+	if sc.IsSampled() {
+		flags = 1
+	}
+	return fmt.Sprintf("00-%016x%016x-%016x-%02x", sc.TraceID().High, sc.TraceID().Low, uint64(sc.SpanID()), flags)
+}
+
+var traceParentRegex = regexp.MustCompile(`^([0-9a-f]{2})-([0-9a-f]{32})-([0-9a-f]{16})-([0-9a-f]{2})$`)
+
+func parseTraceParent(tp string) (opentracing.SpanContext, bool) {
+	m := traceParentRegex.FindStringSubmatch(tp)
+	if m == nil || m[1] == "ff" {
+		return nil, false
+	}
+	traceID, err := jaeger.TraceIDFromString(m[2])
+	if err != nil || !traceID.IsValid() {
+		return nil, false
+	}
+	spanID, err := jaeger.SpanIDFromString(m[3])
+	if err != nil || spanID == 0 {
+		return nil, false
+	}
+	var flags byte
+	if _, err := fmt.Sscanf(m[4], "%02x", &flags); err != nil {
+		return nil, false
+	}
+	return jaeger.NewSpanContext(traceID, spanID, 0, flags&1 == 1, nil), true
+}
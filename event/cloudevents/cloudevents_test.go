@@ -0,0 +1,157 @@
+package cloudevents
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/beatlabs/patron/correlation"
+	"github.com/beatlabs/patron/encoding/protobuf"
+	"github.com/opentracing/opentracing-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber/jaeger-client-go"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+func testEvent() Event {
+	return Event{
+		ID:              "1",
+		Source:          "/orders",
+		SpecVersion:     SpecVersion,
+		Type:            "com.example.order.created",
+		DataContentType: "application/json",
+		DataSchema:      "https://example.com/order.json",
+		Subject:         "123",
+		Time:            time.Date(2021, 3, 4, 5, 6, 7, 8, time.UTC),
+		Extensions:      map[string]string{CorrelationIDExtension: "456"},
+		Data:            []byte(`{"id":"123"}`),
+	}
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+	tracer, closer := jaeger.NewTracer("test", jaeger.NewConstSampler(true), jaeger.NewNullReporter())
+	t.Cleanup(func() { _ = closer.Close() })
+	sp := tracer.StartSpan("publish")
+	ctx := opentracing.ContextWithSpan(correlation.ContextWithID(context.Background(), "123"), sp)
+
+	got := New(ctx, "/orders", "com.example.order.created")
+	assert.NotEmpty(t, got.ID)
+	assert.Equal(t, "/orders", got.Source)
+	assert.Equal(t, SpecVersion, got.SpecVersion)
+	assert.Equal(t, "com.example.order.created", got.Type)
+	assert.False(t, got.Time.IsZero())
+	assert.Equal(t, "123", got.CorrelationID())
+	assert.NoError(t, got.Validate())
+
+	sc, ok := got.SpanContext()
+	require.True(t, ok)
+	jsc, ok := sc.(jaeger.SpanContext)
+	require.True(t, ok)
+	expected := sp.Context().(jaeger.SpanContext)
+	assert.Equal(t, expected.TraceID(), jsc.TraceID())
+	assert.Equal(t, expected.SpanID(), jsc.SpanID())
+	assert.True(t, jsc.IsSampled())
+
+	got = New(context.Background(), "/orders", "com.example.order.created")
+	assert.NotEmpty(t, got.CorrelationID())
+	_, ok = got.SpanContext()
+	assert.False(t, ok)
+}
+
+func TestEvent_Validate(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		modify      func(*Event)
+		expectedErr string
+	}{
+		"success":              {modify: func(*Event) {}},
+		"missing id":           {modify: func(e *Event) { e.ID = "" }, expectedErr: "id is empty"},
+		"missing source":       {modify: func(e *Event) { e.Source = "" }, expectedErr: "source is empty"},
+		"missing type":         {modify: func(e *Event) { e.Type = "" }, expectedErr: "type is empty"},
+		"unsupported version":  {modify: func(e *Event) { e.SpecVersion = "0.3" }, expectedErr: "spec version 0.3 is unsupported"},
+		"invalid extension":    {modify: func(e *Event) { e.Extensions["Trace-ID"] = "1" }, expectedErr: "extension name Trace-ID should consist of lowercase letters and digits"},
+		"attribute extension":  {modify: func(e *Event) { e.Extensions["subject"] = "1" }, expectedErr: "extension name subject is a context attribute"},
+		"data extension":       {modify: func(e *Event) { e.Extensions["data"] = "1" }, expectedErr: "extension name data is a context attribute"},
+		"extension with digit": {modify: func(e *Event) { e.Extensions["ext1"] = "1" }},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			e := testEvent()
+			tt.modify(&e)
+			err := e.Validate()
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestEvent_Context(t *testing.T) {
+	t.Parallel()
+	ctx := testEvent().Context(context.Background())
+	assert.Equal(t, "456", correlation.IDFromContext(ctx))
+	ctx = Event{}.Context(correlation.ContextWithID(context.Background(), "123"))
+	assert.Equal(t, "123", correlation.IDFromContext(ctx))
+}
+
+func TestEvent_DecodeData(t *testing.T) {
+	t.Parallel()
+	type order struct {
+		ID string `json:"id"`
+	}
+	var o order
+	require.NoError(t, testEvent().DecodeData(&o))
+	assert.Equal(t, "123", o.ID)
+
+	e := Event{Data: []byte(`{"id":"456"}`)}
+	require.NoError(t, e.DecodeData(&o))
+	assert.Equal(t, "456", o.ID)
+
+	b, err := proto.Marshal(durationpb.New(time.Minute))
+	require.NoError(t, err)
+	d := &durationpb.Duration{}
+	require.NoError(t, Event{DataContentType: protobuf.Type, Data: b}.DecodeData(d))
+	assert.Equal(t, time.Minute, d.AsDuration())
+
+	assert.EqualError(t, Event{DataContentType: "text/plain"}.DecodeData(&o), "data content type text/plain is unsupported")
+}
+
+func TestParseTraceParent(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		traceParent string
+		valid       bool
+		sampled     bool
+	}{
+		"sampled":         {traceParent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", valid: true, sampled: true},
+		"not sampled":     {traceParent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", valid: true},
+		"invalid version": {traceParent: "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+		"zero trace id":   {traceParent: "00-00000000000000000000000000000000-00f067aa0ba902b7-01"},
+		"zero span id":    {traceParent: "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01"},
+		"invalid format":  {traceParent: "00-4bf92f3577b34da6a3ce929d0e0e4736"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			got, ok := parseTraceParent(tt.traceParent)
+			assert.Equal(t, tt.valid, ok)
+			if !tt.valid {
+				assert.Nil(t, got)
+				return
+			}
+			sc, ok := got.(jaeger.SpanContext)
+			require.True(t, ok)
+			assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", sc.TraceID().String())
+			assert.Equal(t, "00f067aa0ba902b7", sc.SpanID().String())
+			assert.Equal(t, tt.sampled, sc.IsSampled())
+		})
+	}
+}
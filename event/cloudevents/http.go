@@ -0,0 +1,102 @@
+package cloudevents
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/beatlabs/patron/encoding"
+)
+
+const httpPrefix = "ce-"
+
+// ToHTTPRequest sets the body and the headers of the request to the event, according to the mode.
+// In binary mode the attributes are mapped to ce- prefixed headers, and the data content type to the Content-Type header.
+func ToHTTPRequest(req *http.Request, e Event, mode Mode) error {
+	m, err := encode(e, mode, httpPrefix)
+	if err != nil {
+		return err
+	}
+	setHTTPHeaders(req.Header, m)
+	body := m.body
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(body)), nil
+	}
+	req.ContentLength = int64(len(body))
+	return nil
+}
+
+// WriteHTTPResponse writes the event as the response with the status code, according to the mode.
+func WriteHTTPResponse(w http.ResponseWriter, status int, e Event, mode Mode) error {
+	m, err := encode(e, mode, httpPrefix)
+	if err != nil {
+		return err
+	}
+	setHTTPHeaders(w.Header(), m)
+	w.Header().Set("Content-Length", strconv.Itoa(len(m.body)))
+	w.WriteHeader(status)
+	_, err = w.Write(m.body)
+	return err
+}
+
+// FromHTTPRequest decodes the event of the request, in structured or binary mode, reading its body.
+func FromHTTPRequest(req *http.Request) (Event, error) {
+	return fromHTTP(req.Header, req.Body)
+}
+
+// FromHTTPResponse decodes the event of the response, in structured or binary mode, reading its body.
+func FromHTTPResponse(rsp *http.Response) (Event, error) {
+	return fromHTTP(rsp.Header, rsp.Body)
+}
+
+func setHTTPHeaders(h http.Header, m message) {
+	for name, value := range m.headers {
+		h.Set(name, escapeHeaderValue(value))
+	}
+	if m.contentType != "" {
+		h.Set(encoding.ContentTypeHeader, m.contentType)
+	}
+}
+
+func fromHTTP(h http.Header, body io.Reader) (Event, error) {
+	m := message{headers: make(map[string]string, len(h)), contentType: h.Get(encoding.ContentTypeHeader)}
+	for name, values := range h {
+		if len(values) == 0 || !strings.HasPrefix(strings.ToLower(name), httpPrefix) {
+			continue
+		}
+		value, err := url.PathUnescape(values[0])
+		if err != nil {
+			return Event{}, fmt.Errorf("invalid header %s: %w", name, err)
+		}
+		m.headers[name] = value
+	}
+	if body != nil {
+		b, err := ioutil.ReadAll(body)
+		if err != nil {
+			return Event{}, fmt.Errorf("failed to read body: %w", err)
+		}
+		m.body = b
+	}
+	return decode(m, httpPrefix)
+}
+
+// escapeHeaderValue percent-encodes the space, double quote, percent and non-printable characters of header values,
+// as required by the HTTP binding.
+func escapeHeaderValue(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c <= ' ' || c >= 0x7f || c == '"' || c == '%' {
+			fmt.Fprintf(&b, "%%%02X", c)
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
@@ -0,0 +1,89 @@
+package cloudevents
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPRequest(t *testing.T) {
+	t.Parallel()
+	e := testEvent()
+	e.Subject = "order 123 \"created\" 100%"
+	for name, mode := range map[string]Mode{"binary": Binary, "structured": Structured} {
+		mode := mode
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			req := httptest.NewRequest(http.MethodPost, "/orders", nil)
+			require.NoError(t, ToHTTPRequest(req, e, mode))
+			assert.Equal(t, req.ContentLength, int64(len(mustRead(t, req.GetBody))))
+
+			got, err := FromHTTPRequest(req)
+			require.NoError(t, err)
+			assert.Equal(t, e, got)
+		})
+	}
+}
+
+func TestToHTTPRequest_BinaryHeaders(t *testing.T) {
+	t.Parallel()
+	e := testEvent()
+	e.Subject = "order 123 \"created\" 100%"
+	req := httptest.NewRequest(http.MethodPost, "/orders", nil)
+	require.NoError(t, ToHTTPRequest(req, e, Binary))
+	assert.Equal(t, "1", req.Header.Get("ce-id"))
+	assert.Equal(t, "order%20123%20%22created%22%20100%25", req.Header.Get("ce-subject"))
+	assert.Equal(t, "application/json", req.Header.Get("Content-Type"))
+}
+
+func TestWriteHTTPResponse(t *testing.T) {
+	t.Parallel()
+	for name, mode := range map[string]Mode{"binary": Binary, "structured": Structured} {
+		mode := mode
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			rec := httptest.NewRecorder()
+			require.NoError(t, WriteHTTPResponse(rec, http.StatusAccepted, testEvent(), mode))
+			rsp := rec.Result()
+			t.Cleanup(func() { _ = rsp.Body.Close() })
+			assert.Equal(t, http.StatusAccepted, rsp.StatusCode)
+
+			got, err := FromHTTPResponse(rsp)
+			require.NoError(t, err)
+			assert.Equal(t, testEvent(), got)
+		})
+	}
+
+	e := testEvent()
+	e.Source = ""
+	rec := httptest.NewRecorder()
+	assert.EqualError(t, WriteHTTPResponse(rec, http.StatusOK, e, Binary), "source is empty")
+	assert.Empty(t, rec.Body.String())
+}
+
+func TestFromHTTPRequest_Failure(t *testing.T) {
+	t.Parallel()
+	req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader("hello"))
+	_, err := FromHTTPRequest(req)
+	assert.Equal(t, ErrNotEvent, err)
+
+	req = httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader("hello"))
+	req.Header.Set("ce-specversion", "1.0")
+	req.Header.Set("ce-id", "%zz")
+	_, err = FromHTTPRequest(req)
+	assert.EqualError(t, err, `invalid header Ce-Id: invalid URL escape "%zz"`)
+}
+
+func mustRead(t *testing.T, body func() (io.ReadCloser, error)) []byte {
+	rc, err := body()
+	require.NoError(t, err)
+	b, err := ioutil.ReadAll(rc)
+	require.NoError(t, err)
+	return b
+}
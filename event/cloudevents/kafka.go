@@ -0,0 +1,45 @@
+package cloudevents
+
+import (
+	"strings"
+
+	"github.com/Shopify/sarama"
+)
+
+const (
+	kafkaPrefix            = "ce_"
+	kafkaContentTypeHeader = "content-type"
+)
+
+// ToKafka sets the value and the headers of the producer message to the event, according to the mode.
+// In binary mode the attributes are mapped to ce_ prefixed headers, and the data content type to the content-type header.
+func ToKafka(msg *sarama.ProducerMessage, e Event, mode Mode) error {
+	m, err := encode(e, mode, kafkaPrefix)
+	if err != nil {
+		return err
+	}
+	for _, name := range m.sortedHeaders() {
+		msg.Headers = append(msg.Headers, sarama.RecordHeader{Key: []byte(name), Value: []byte(m.headers[name])})
+	}
+	if m.contentType != "" {
+		msg.Headers = append(msg.Headers, sarama.RecordHeader{Key: []byte(kafkaContentTypeHeader), Value: []byte(m.contentType)})
+	}
+	msg.Value = sarama.ByteEncoder(m.body)
+	return nil
+}
+
+// FromKafka decodes the event of the consumer message, in structured or binary mode.
+func FromKafka(msg *sarama.ConsumerMessage) (Event, error) {
+	m := message{headers: make(map[string]string, len(msg.Headers)), body: msg.Value}
+	for _, h := range msg.Headers {
+		if h == nil {
+			continue
+		}
+		if strings.EqualFold(string(h.Key), kafkaContentTypeHeader) {
+			m.contentType = string(h.Value)
+			continue
+		}
+		m.headers[string(h.Key)] = string(h.Value)
+	}
+	return decode(m, kafkaPrefix)
+}
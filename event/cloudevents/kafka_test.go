@@ -0,0 +1,60 @@
+package cloudevents
+
+import (
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKafka(t *testing.T) {
+	t.Parallel()
+	for name, mode := range map[string]Mode{"binary": Binary, "structured": Structured} {
+		mode := mode
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			pm := &sarama.ProducerMessage{Topic: "orders"}
+			require.NoError(t, ToKafka(pm, testEvent(), mode))
+			value, err := pm.Value.Encode()
+			require.NoError(t, err)
+
+			cm := &sarama.ConsumerMessage{Topic: "orders", Value: value}
+			for i := range pm.Headers {
+				cm.Headers = append(cm.Headers, &pm.Headers[i])
+			}
+			// headers of other producers, e.g. tracing, are ignored
+			cm.Headers = append(cm.Headers, &sarama.RecordHeader{Key: []byte("uber-trace-id"), Value: []byte("1:2:3:1")})
+
+			got, err := FromKafka(cm)
+			require.NoError(t, err)
+			assert.Equal(t, testEvent(), got)
+		})
+	}
+}
+
+func TestToKafka_BinaryHeaders(t *testing.T) {
+	t.Parallel()
+	pm := &sarama.ProducerMessage{}
+	require.NoError(t, ToKafka(pm, testEvent(), Binary))
+	hdr := make(map[string]string)
+	for _, h := range pm.Headers {
+		hdr[string(h.Key)] = string(h.Value)
+	}
+	assert.Equal(t, "1", hdr["ce_id"])
+	assert.Equal(t, "1.0", hdr["ce_specversion"])
+	assert.Equal(t, "456", hdr["ce_correlationid"])
+	assert.Equal(t, "application/json", hdr["content-type"])
+	assert.Equal(t, sarama.ByteEncoder(testEvent().Data), pm.Value)
+}
+
+func TestKafka_Failure(t *testing.T) {
+	t.Parallel()
+	e := testEvent()
+	e.Type = ""
+	assert.EqualError(t, ToKafka(&sarama.ProducerMessage{}, e, Binary), "type is empty")
+	assert.EqualError(t, ToKafka(&sarama.ProducerMessage{}, testEvent(), Mode(5)), "content mode is unsupported")
+
+	_, err := FromKafka(&sarama.ConsumerMessage{Value: []byte("{}")})
+	assert.Equal(t, ErrNotEvent, err)
+}
@@ -0,0 +1,35 @@
+package cloudevents
+
+import (
+	"github.com/eclipse/paho.golang/paho"
+)
+
+// ToMQTT sets the payload and the properties of the MQTT v5 publish to the event, according to the mode.
+// In binary mode the attributes are mapped to user properties, and the data content type to the content type.
+func ToMQTT(pub *paho.Publish, e Event, mode Mode) error {
+	m, err := encode(e, mode, "")
+	if err != nil {
+		return err
+	}
+	if pub.Properties == nil {
+		pub.Properties = &paho.PublishProperties{}
+	}
+	for _, name := range m.sortedHeaders() {
+		pub.Properties.User.Add(name, m.headers[name])
+	}
+	pub.Properties.ContentType = m.contentType
+	pub.Payload = m.body
+	return nil
+}
+
+// FromMQTT decodes the event of the MQTT v5 publish, in structured or binary mode.
+func FromMQTT(pub *paho.Publish) (Event, error) {
+	m := message{headers: make(map[string]string), body: pub.Payload}
+	if pub.Properties != nil {
+		m.contentType = pub.Properties.ContentType
+		for _, p := range pub.Properties.User {
+			m.headers[p.Key] = p.Value
+		}
+	}
+	return decode(m, "")
+}
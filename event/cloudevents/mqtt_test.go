@@ -0,0 +1,40 @@
+package cloudevents
+
+import (
+	"testing"
+
+	"github.com/eclipse/paho.golang/paho"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMQTT(t *testing.T) {
+	t.Parallel()
+	for name, mode := range map[string]Mode{"binary": Binary, "structured": Structured} {
+		mode := mode
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			pub := &paho.Publish{Topic: "orders"}
+			require.NoError(t, ToMQTT(pub, testEvent(), mode))
+			// properties of other publishers, e.g. tracing, are ignored
+			pub.Properties.User.Add("uber-trace-id", "1:2:3:1")
+
+			got, err := FromMQTT(pub)
+			require.NoError(t, err)
+			assert.Equal(t, testEvent(), got)
+		})
+	}
+}
+
+func TestToMQTT_BinaryProperties(t *testing.T) {
+	t.Parallel()
+	pub := &paho.Publish{Topic: "orders"}
+	require.NoError(t, ToMQTT(pub, testEvent(), Binary))
+	assert.Equal(t, "1", pub.Properties.User.Get("id"))
+	assert.Equal(t, "1.0", pub.Properties.User.Get("specversion"))
+	assert.Equal(t, "application/json", pub.Properties.ContentType)
+	assert.Equal(t, testEvent().Data, pub.Payload)
+
+	_, err := FromMQTT(&paho.Publish{Payload: []byte("hello")})
+	assert.Equal(t, ErrNotEvent, err)
+}
@@ -0,0 +1,108 @@
+package cloudevents
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Marshal encodes the event as JSON, i.e. in structured mode. JSON data is embedded as is, and the rest is encoded in base64.
+func Marshal(e Event) ([]byte, error) {
+	if err := e.Validate(); err != nil {
+		return nil, err
+	}
+	obj := make(map[string]interface{}, len(e.Extensions)+9)
+	for name, value := range e.attributes() {
+		obj[name] = value
+	}
+	if e.DataContentType != "" {
+		obj[attrDataContentType] = e.DataContentType
+	}
+	if len(e.Data) > 0 {
+		if e.DataContentType == "" || isJSON(e.DataContentType) {
+			if !json.Valid(e.Data) {
+				return nil, errors.New("data is not valid JSON")
+			}
+			obj["data"] = json.RawMessage(e.Data)
+		} else {
+			obj["data_base64"] = base64.StdEncoding.EncodeToString(e.Data)
+		}
+	}
+	return json.Marshal(obj)
+}
+
+// Unmarshal decodes an event from JSON, i.e. in structured mode.
+func Unmarshal(b []byte) (Event, error) {
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(b, &obj); err != nil {
+		return Event{}, fmt.Errorf("invalid event: %w", err)
+	}
+	var e Event
+	var data, dataBase64 json.RawMessage
+	for name, raw := range obj {
+		switch name {
+		case "data":
+			data = raw
+			continue
+		case "data_base64":
+			dataBase64 = raw
+			continue
+		}
+		if string(raw) == "null" {
+			continue
+		}
+		value := string(raw)
+		// the values of extensions of other types than string, e.g. integers or booleans, are kept as encoded
+		if len(raw) > 0 && raw[0] == '"' {
+			if err := json.Unmarshal(raw, &value); err != nil {
+				return Event{}, fmt.Errorf("invalid attribute %s: %w", name, err)
+			}
+		}
+		if err := e.setAttribute(name, value); err != nil {
+			return Event{}, err
+		}
+	}
+
+	switch {
+	case len(dataBase64) > 0 && string(dataBase64) != "null":
+		var s string
+		if err := json.Unmarshal(dataBase64, &s); err != nil {
+			return Event{}, fmt.Errorf("invalid data_base64: %w", err)
+		}
+		d, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return Event{}, fmt.Errorf("invalid data_base64: %w", err)
+		}
+		e.Data = d
+	case len(data) > 0 && string(data) != "null":
+		e.Data = []byte(data)
+		// the data of non-JSON content types may be encoded as a JSON string
+		if e.DataContentType != "" && !isJSON(e.DataContentType) && data[0] == '"' {
+			var s string
+			if err := json.Unmarshal(data, &s); err != nil {
+				return Event{}, fmt.Errorf("invalid data: %w", err)
+			}
+			e.Data = []byte(s)
+		}
+	}
+
+	if err := e.Validate(); err != nil {
+		return Event{}, err
+	}
+	return e, nil
+}
+
+// DecodeRaw decodes an event in structured mode into v, if it is an *Event, or else the data of the event.
+// It implements encoding.DecodeRawFunc, e.g. for the messages of the async components.
+func DecodeRaw(data []byte, v interface{}) error {
+	e, err := Unmarshal(data)
+	if err != nil {
+		return err
+	}
+	if ev, ok := v.(*Event); ok {
+		*ev = e
+		return nil
+	}
+	return e.DecodeData(v)
+}
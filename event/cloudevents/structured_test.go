@@ -0,0 +1,122 @@
+package cloudevents
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarshalUnmarshal(t *testing.T) {
+	t.Parallel()
+	binary := testEvent()
+	binary.DataContentType = "application/octet-stream"
+	binary.Data = []byte{0x00, 0xff}
+	noData := testEvent()
+	noData.DataContentType = ""
+	noData.Data = nil
+	cloudJSON := testEvent()
+	cloudJSON.DataContentType = "application/geo+json"
+
+	tests := map[string]struct {
+		event Event
+	}{
+		"json data":        {event: testEvent()},
+		"json suffix data": {event: cloudJSON},
+		"binary data":      {event: binary},
+		"no data":          {event: noData},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			b, err := Marshal(tt.event)
+			require.NoError(t, err)
+			got, err := Unmarshal(b)
+			require.NoError(t, err)
+			assert.Equal(t, tt.event, got)
+		})
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	t.Parallel()
+	b, err := Marshal(testEvent())
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"id":"1",
+		"source":"/orders",
+		"specversion":"1.0",
+		"type":"com.example.order.created",
+		"datacontenttype":"application/json",
+		"dataschema":"https://example.com/order.json",
+		"subject":"123",
+		"time":"2021-03-04T05:06:07.000000008Z",
+		"correlationid":"456",
+		"data":{"id":"123"}
+	}`, string(b))
+
+	e := testEvent()
+	e.Data = []byte("{")
+	_, err = Marshal(e)
+	assert.EqualError(t, err, "data is not valid JSON")
+
+	e = testEvent()
+	e.ID = ""
+	_, err = Marshal(e)
+	assert.EqualError(t, err, "id is empty")
+}
+
+func TestUnmarshal(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		data        string
+		expected    Event
+		expectedErr string
+	}{
+		"string data": {
+			data:     `{"id":"1","source":"/orders","specversion":"1.0","type":"created","datacontenttype":"text/plain","data":"hello"}`,
+			expected: Event{ID: "1", Source: "/orders", SpecVersion: "1.0", Type: "created", DataContentType: "text/plain", Data: []byte("hello")},
+		},
+		"extension types": {
+			data: `{"id":"1","source":"/orders","specversion":"1.0","type":"created","count":3,"valid":true,"empty":null}`,
+			expected: Event{ID: "1", Source: "/orders", SpecVersion: "1.0", Type: "created",
+				Extensions: map[string]string{"count": "3", "valid": "true"}},
+		},
+		"invalid json":        {data: `{`, expectedErr: "invalid event: unexpected end of JSON input"},
+		"invalid time":        {data: `{"id":"1","source":"/orders","specversion":"1.0","type":"created","time":"today"}`, expectedErr: `invalid time today: parsing time "today" as "2006-01-02T15:04:05.999999999Z07:00": cannot parse "today" as "2006"`},
+		"invalid data_base64": {data: `{"id":"1","source":"/orders","specversion":"1.0","type":"created","data_base64":"!"}`, expectedErr: "invalid data_base64: illegal base64 data at input byte 0"},
+		"missing spec":        {data: `{"id":"1","source":"/orders","type":"created"}`, expectedErr: "spec version  is unsupported"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			got, err := Unmarshal([]byte(tt.data))
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestDecodeRaw(t *testing.T) {
+	t.Parallel()
+	b, err := Marshal(testEvent())
+	require.NoError(t, err)
+
+	var e Event
+	require.NoError(t, DecodeRaw(b, &e))
+	assert.Equal(t, testEvent(), e)
+
+	var o struct {
+		ID string `json:"id"`
+	}
+	require.NoError(t, DecodeRaw(b, &o))
+	assert.Equal(t, "123", o.ID)
+
+	assert.Error(t, DecodeRaw([]byte("{"), &e))
+}
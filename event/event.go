@@ -0,0 +1,342 @@
+// Package event provides a standard envelope for the events published and consumed over messaging, e.g. Kafka, SQS or AMQP.
+// Envelopes are encoded as protobuf, or as JSON, and carry the ID, type, source, time, correlation ID and schema version
+// of the event along with its encoded payload. A registry of the event types creates and decodes the envelopes of
+// protobuf payloads, and checks the evolution of the payloads against a snapshot of their previous schemas.
+package event
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/beatlabs/patron/encoding/json"
+	"github.com/beatlabs/patron/encoding/protobuf"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// ProtobufType is the content type of protobuf-encoded envelopes.
+	ProtobufType string = "application/vnd.patron.event+protobuf"
+	// JSONType is the content type of JSON-encoded envelopes.
+	JSONType string = "application/vnd.patron.event+json"
+)
+
+const (
+	// field numbers of the Envelope message, see event.proto.
+	fieldID              protowire.Number = 1
+	fieldType            protowire.Number = 2
+	fieldSource          protowire.Number = 3
+	fieldTime            protowire.Number = 4
+	fieldCorrelationID   protowire.Number = 5
+	fieldSchemaVersion   protowire.Number = 6
+	fieldDataContentType protowire.Number = 7
+	fieldData            protowire.Number = 8
+	// field numbers of the google.protobuf.Timestamp message.
+	fieldSeconds protowire.Number = 1
+	fieldNanos   protowire.Number = 2
+)
+
+// Envelope of an event.
+type Envelope struct {
+	ID string
+	// Type of the event, i.e. the full name of the protobuf message of its payload, e.g. orders.OrderCreated.
+	Type string
+	// Source of the event, e.g. the name of the service publishing it.
+	Source        string
+	Time          time.Time
+	CorrelationID string
+	// SchemaVersion of the payload, which changes on incompatible changes of its type.
+	SchemaVersion uint32
+	// DataContentType is the content type of the data, i.e. protobuf.Type or json.Type.
+	DataContentType string
+	Data            []byte
+}
+
+// Encode the envelope according to the content type, i.e. ProtobufType or JSONType.
+func Encode(env Envelope, contentType string) ([]byte, error) {
+	switch contentType {
+	case ProtobufType:
+		return marshalProtobuf(env), nil
+	case JSONType:
+		return marshalJSON(env)
+	}
+	return nil, fmt.Errorf("content type %s is unsupported", contentType)
+}
+
+// Decode the envelope according to the content type, i.e. ProtobufType or JSONType.
+func Decode(data []byte, contentType string) (Envelope, error) {
+	switch contentType {
+	case ProtobufType:
+		return unmarshalProtobuf(data)
+	case JSONType:
+		return unmarshalJSON(data)
+	}
+	return Envelope{}, fmt.Errorf("content type %s is unsupported", contentType)
+}
+
+// EncodeProtobuf encodes v, which should be an Envelope or an *Envelope, as protobuf.
+// It implements encoding.EncodeFunc, e.g. for the encoders of the Kafka producers along with the ProtobufType content type.
+func EncodeProtobuf(v interface{}) ([]byte, error) {
+	return encodeValue(v, ProtobufType)
+}
+
+// EncodeJSON encodes v, which should be an Envelope or an *Envelope, as JSON.
+// It implements encoding.EncodeFunc, e.g. for the encoders of the Kafka producers along with the JSONType content type.
+func EncodeJSON(v interface{}) ([]byte, error) {
+	return encodeValue(v, JSONType)
+}
+
+func encodeValue(v interface{}, contentType string) ([]byte, error) {
+	switch env := v.(type) {
+	case Envelope:
+		return Encode(env, contentType)
+	case *Envelope:
+		if env == nil {
+			return nil, errors.New("envelope is nil")
+		}
+		return Encode(*env, contentType)
+	}
+	return nil, fmt.Errorf("%T is not an envelope", v)
+}
+
+// DecodeRawProtobuf decodes a protobuf-encoded envelope into v, if it is an *Envelope, or else the payload of the envelope.
+// It implements encoding.DecodeRawFunc, e.g. for the messages of the async components.
+func DecodeRawProtobuf(data []byte, v interface{}) error {
+	return decodeRaw(data, ProtobufType, v)
+}
+
+// DecodeRawJSON decodes a JSON-encoded envelope into v, if it is an *Envelope, or else the payload of the envelope.
+// It implements encoding.DecodeRawFunc, e.g. for the messages of the async components.
+func DecodeRawJSON(data []byte, v interface{}) error {
+	return decodeRaw(data, JSONType, v)
+}
+
+func decodeRaw(data []byte, contentType string, v interface{}) error {
+	env, err := Decode(data, contentType)
+	if err != nil {
+		return err
+	}
+	if e, ok := v.(*Envelope); ok {
+		*e = env
+		return nil
+	}
+	return decodeData(env, v)
+}
+
+// decodeData decodes the data of the envelope into v, according to its content type.
+func decodeData(env Envelope, v interface{}) error {
+	switch env.DataContentType {
+	case protobuf.Type, protobuf.TypeGoogle:
+		return protobuf.DecodeRaw(env.Data, v)
+	case json.Type, json.TypeCharset:
+		// protobuf messages are encoded as JSON by protojson, which follows the JSON mapping of protobuf
+		if m, ok := v.(proto.Message); ok {
+			return protojson.Unmarshal(env.Data, m)
+		}
+		return json.DecodeRaw(env.Data, v)
+	}
+	return fmt.Errorf("data content type %s is unsupported", env.DataContentType)
+}
+
+func marshalProtobuf(env Envelope) []byte {
+	var b []byte
+	b = appendString(b, fieldID, env.ID)
+	b = appendString(b, fieldType, env.Type)
+	b = appendString(b, fieldSource, env.Source)
+	if !env.Time.IsZero() {
+		var ts []byte
+		if s := env.Time.Unix(); s != 0 {
+			ts = protowire.AppendTag(ts, fieldSeconds, protowire.VarintType)
+			ts = protowire.AppendVarint(ts, uint64(s))
+		}
+		if n := env.Time.Nanosecond(); n != 0 {
+			ts = protowire.AppendTag(ts, fieldNanos, protowire.VarintType)
+			ts = protowire.AppendVarint(ts, uint64(n))
+		}
+		b = protowire.AppendTag(b, fieldTime, protowire.BytesType)
+		b = protowire.AppendBytes(b, ts)
+	}
+	b = appendString(b, fieldCorrelationID, env.CorrelationID)
+	if env.SchemaVersion != 0 {
+		b = protowire.AppendTag(b, fieldSchemaVersion, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(env.SchemaVersion))
+	}
+	b = appendString(b, fieldDataContentType, env.DataContentType)
+	if len(env.Data) > 0 {
+		b = protowire.AppendTag(b, fieldData, protowire.BytesType)
+		b = protowire.AppendBytes(b, env.Data)
+	}
+	return b
+}
+
+func appendString(b []byte, num protowire.Number, s string) []byte {
+	if s == "" {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, s)
+}
+
+var errInvalidEnvelope = errors.New("invalid envelope")
+
+func unmarshalProtobuf(b []byte) (Envelope, error) {
+	var env Envelope
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return Envelope{}, fmt.Errorf("%w: %v", errInvalidEnvelope, protowire.ParseError(n))
+		}
+		b = b[n:]
+
+		switch {
+		case num == fieldSchemaVersion && typ == protowire.VarintType:
+			var v uint64
+			v, n = protowire.ConsumeVarint(b)
+			env.SchemaVersion = uint32(v)
+		case num == fieldTime && typ == protowire.BytesType:
+			var ts []byte
+			ts, n = protowire.ConsumeBytes(b)
+			if n >= 0 {
+				t, err := unmarshalTimestamp(ts)
+				if err != nil {
+					return Envelope{}, err
+				}
+				env.Time = t
+			}
+		case typ == protowire.BytesType && num >= fieldID && num <= fieldData:
+			var v []byte
+			v, n = protowire.ConsumeBytes(b)
+			switch num {
+			case fieldID:
+				env.ID = string(v)
+			case fieldType:
+				env.Type = string(v)
+			case fieldSource:
+				env.Source = string(v)
+			case fieldCorrelationID:
+				env.CorrelationID = string(v)
+			case fieldDataContentType:
+				env.DataContentType = string(v)
+			case fieldData:
+				env.Data = append([]byte(nil), v...)
+			}
+		default:
+			// unknown fields are skipped, e.g. those of newer versions of the envelope
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return Envelope{}, fmt.Errorf("%w: %v", errInvalidEnvelope, protowire.ParseError(n))
+		}
+		b = b[n:]
+	}
+	return env, nil
+}
+
+func unmarshalTimestamp(b []byte) (time.Time, error) {
+	var seconds, nanos uint64
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return time.Time{}, fmt.Errorf("%w: %v", errInvalidEnvelope, protowire.ParseError(n))
+		}
+		b = b[n:]
+		switch {
+		case num == fieldSeconds && typ == protowire.VarintType:
+			seconds, n = protowire.ConsumeVarint(b)
+		case num == fieldNanos && typ == protowire.VarintType:
+			nanos, n = protowire.ConsumeVarint(b)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return time.Time{}, fmt.Errorf("%w: %v", errInvalidEnvelope, protowire.ParseError(n))
+		}
+		b = b[n:]
+	}
+	return time.Unix(int64(seconds), int64(int32(nanos))).UTC(), nil
+}
+
+// jsonEnvelope is the JSON encoding of the envelope. JSON data is embedded as is, and the rest is encoded in base64.
+type jsonEnvelope struct {
+	ID              string     `json:"id"`
+	Type            string     `json:"type"`
+	Source          string     `json:"source,omitempty"`
+	Time            *time.Time `json:"time,omitempty"`
+	CorrelationID   string     `json:"correlation_id,omitempty"`
+	SchemaVersion   uint32     `json:"schema_version,omitempty"`
+	DataContentType string     `json:"data_content_type,omitempty"`
+	Data            rawJSON    `json:"data,omitempty"`
+	DataBase64      string     `json:"data_base64,omitempty"`
+}
+
+// rawJSON is a raw JSON value, which is omitted when empty.
+type rawJSON []byte
+
+func (r rawJSON) MarshalJSON() ([]byte, error) {
+	if len(r) == 0 {
+		return []byte("null"), nil
+	}
+	return r, nil
+}
+
+func (r *rawJSON) UnmarshalJSON(b []byte) error {
+	*r = append((*r)[:0], b...)
+	return nil
+}
+
+func isJSON(contentType string) bool {
+	return contentType == json.Type || contentType == json.TypeCharset
+}
+
+func marshalJSON(env Envelope) ([]byte, error) {
+	je := jsonEnvelope{
+		ID:              env.ID,
+		Type:            env.Type,
+		Source:          env.Source,
+		CorrelationID:   env.CorrelationID,
+		SchemaVersion:   env.SchemaVersion,
+		DataContentType: env.DataContentType,
+	}
+	if !env.Time.IsZero() {
+		t := env.Time.UTC()
+		je.Time = &t
+	}
+	if isJSON(env.DataContentType) {
+		je.Data = env.Data
+	} else if len(env.Data) > 0 {
+		je.DataBase64 = base64.StdEncoding.EncodeToString(env.Data)
+	}
+	return json.Encode(je)
+}
+
+func unmarshalJSON(b []byte) (Envelope, error) {
+	var je jsonEnvelope
+	if err := json.DecodeRaw(b, &je); err != nil {
+		return Envelope{}, fmt.Errorf("%w: %v", errInvalidEnvelope, err)
+	}
+	env := Envelope{
+		ID:              je.ID,
+		Type:            je.Type,
+		Source:          je.Source,
+		CorrelationID:   je.CorrelationID,
+		SchemaVersion:   je.SchemaVersion,
+		DataContentType: je.DataContentType,
+	}
+	if je.Time != nil {
+		env.Time = je.Time.UTC()
+	}
+	switch {
+	case je.DataBase64 != "":
+		data, err := base64.StdEncoding.DecodeString(je.DataBase64)
+		if err != nil {
+			return Envelope{}, fmt.Errorf("%w: %v", errInvalidEnvelope, err)
+		}
+		env.Data = data
+	case len(je.Data) > 0 && string(je.Data) != "null":
+		env.Data = []byte(je.Data)
+	}
+	return env, nil
+}
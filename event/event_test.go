@@ -0,0 +1,175 @@
+package event
+
+import (
+	"testing"
+	"time"
+
+	"github.com/beatlabs/patron/encoding/json"
+	"github.com/beatlabs/patron/encoding/protobuf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	t.Parallel()
+	payload, err := proto.Marshal(durationpb.New(time.Minute))
+	require.NoError(t, err)
+	full := Envelope{
+		ID:              "1",
+		Type:            "google.protobuf.Duration",
+		Source:          "orders",
+		Time:            time.Date(2021, 3, 4, 5, 6, 7, 8, time.UTC),
+		CorrelationID:   "123",
+		SchemaVersion:   2,
+		DataContentType: protobuf.Type,
+		Data:            payload,
+	}
+	jsonData := full
+	jsonData.DataContentType = json.Type
+	jsonData.Data = []byte(`{"name":"order"}`)
+
+	tests := map[string]struct {
+		env         Envelope
+		contentType string
+	}{
+		"protobuf":                {env: full, contentType: ProtobufType},
+		"protobuf empty":          {env: Envelope{}, contentType: ProtobufType},
+		"protobuf before epoch":   {env: Envelope{Time: time.Date(1969, 1, 1, 0, 0, 0, 5, time.UTC)}, contentType: ProtobufType},
+		"json":                    {env: full, contentType: JSONType},
+		"json with json data":     {env: jsonData, contentType: JSONType},
+		"json empty":              {env: Envelope{}, contentType: JSONType},
+		"protobuf with json data": {env: jsonData, contentType: ProtobufType},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			b, err := Encode(tt.env, tt.contentType)
+			require.NoError(t, err)
+			got, err := Decode(b, tt.contentType)
+			require.NoError(t, err)
+			assert.Equal(t, tt.env, got)
+		})
+	}
+}
+
+func TestEncode_Unsupported(t *testing.T) {
+	t.Parallel()
+	got, err := Encode(Envelope{}, "text/plain")
+	assert.EqualError(t, err, "content type text/plain is unsupported")
+	assert.Nil(t, got)
+}
+
+func TestEncodeFunc(t *testing.T) {
+	t.Parallel()
+	env := Envelope{ID: "1", Type: "type"}
+	expectedProtobuf, err := Encode(env, ProtobufType)
+	require.NoError(t, err)
+	expectedJSON, err := Encode(env, JSONType)
+	require.NoError(t, err)
+
+	got, err := EncodeProtobuf(env)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedProtobuf, got)
+	got, err = EncodeJSON(&env)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedJSON, got)
+
+	var nilEnv *Envelope
+	_, err = EncodeProtobuf(nilEnv)
+	assert.EqualError(t, err, "envelope is nil")
+	_, err = EncodeJSON("event")
+	assert.EqualError(t, err, "string is not an envelope")
+}
+
+func TestDecode_Failure(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		data        []byte
+		contentType string
+		expectedErr string
+	}{
+		"unsupported":         {data: []byte("{}"), contentType: "text/plain", expectedErr: "content type text/plain is unsupported"},
+		"invalid protobuf":    {data: []byte{0x0a, 0x05, 'a'}, contentType: ProtobufType, expectedErr: "invalid envelope: unexpected EOF"},
+		"invalid timestamp":   {data: []byte{0x22, 0x02, 0x08, 0x80}, contentType: ProtobufType, expectedErr: "invalid envelope: unexpected EOF"},
+		"invalid json":        {data: []byte("{"), contentType: JSONType, expectedErr: "invalid envelope: unexpected end of JSON input"},
+		"invalid json base64": {data: []byte(`{"data_base64":"!"}`), contentType: JSONType, expectedErr: "invalid envelope: illegal base64 data at input byte 0"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			_, err := Decode(tt.data, tt.contentType)
+			assert.EqualError(t, err, tt.expectedErr)
+		})
+	}
+}
+
+func TestDecode_UnknownFields(t *testing.T) {
+	t.Parallel()
+	b, err := Encode(Envelope{ID: "1", Type: "type"}, ProtobufType)
+	require.NoError(t, err)
+	// field 15 of type varint
+	b = append(b, 0x78, 0x01)
+	got, err := Decode(b, ProtobufType)
+	require.NoError(t, err)
+	assert.Equal(t, Envelope{ID: "1", Type: "type"}, got)
+}
+
+func TestDecodeRaw(t *testing.T) {
+	t.Parallel()
+	payload, err := proto.Marshal(durationpb.New(time.Minute))
+	require.NoError(t, err)
+	protoEnv := Envelope{ID: "1", Type: "google.protobuf.Duration", DataContentType: protobuf.Type, Data: payload}
+	jsonEnv := Envelope{ID: "2", Type: "google.protobuf.Duration", DataContentType: json.Type, Data: []byte(`"60s"`)}
+
+	t.Run("envelope", func(t *testing.T) {
+		t.Parallel()
+		b, err := Encode(protoEnv, ProtobufType)
+		require.NoError(t, err)
+		var got Envelope
+		require.NoError(t, DecodeRawProtobuf(b, &got))
+		assert.Equal(t, protoEnv, got)
+	})
+	t.Run("protobuf payload", func(t *testing.T) {
+		t.Parallel()
+		b, err := Encode(protoEnv, ProtobufType)
+		require.NoError(t, err)
+		got := &durationpb.Duration{}
+		require.NoError(t, DecodeRawProtobuf(b, got))
+		assert.Equal(t, time.Minute, got.AsDuration())
+	})
+	t.Run("json payload into message", func(t *testing.T) {
+		t.Parallel()
+		b, err := Encode(jsonEnv, JSONType)
+		require.NoError(t, err)
+		got := &durationpb.Duration{}
+		require.NoError(t, DecodeRawJSON(b, got))
+		assert.Equal(t, time.Minute, got.AsDuration())
+	})
+	t.Run("json payload into struct", func(t *testing.T) {
+		t.Parallel()
+		env := Envelope{DataContentType: json.TypeCharset, Data: []byte(`{"name":"order"}`)}
+		b, err := Encode(env, JSONType)
+		require.NoError(t, err)
+		var got struct {
+			Name string `json:"name"`
+		}
+		require.NoError(t, DecodeRawJSON(b, &got))
+		assert.Equal(t, "order", got.Name)
+	})
+	t.Run("unsupported data content type", func(t *testing.T) {
+		t.Parallel()
+		b, err := Encode(Envelope{DataContentType: "text/plain"}, ProtobufType)
+		require.NoError(t, err)
+		var got string
+		assert.EqualError(t, DecodeRawProtobuf(b, &got), "data content type text/plain is unsupported")
+	})
+	t.Run("invalid envelope", func(t *testing.T) {
+		t.Parallel()
+		var got Envelope
+		assert.Error(t, DecodeRawJSON([]byte("{"), &got))
+	})
+}
@@ -0,0 +1,151 @@
+package event
+
+import (
+	"fmt"
+
+	patronErrors "github.com/beatlabs/patron/errors"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// kindGroups are the groups of field kinds with the same encoding, which can be changed into each other without breaking
+// the decoding of the previous payloads, although values may be truncated, e.g. from int64 to int32.
+var kindGroups = map[protoreflect.Kind]int{
+	protoreflect.Int32Kind:    1,
+	protoreflect.Uint32Kind:   1,
+	protoreflect.Int64Kind:    1,
+	protoreflect.Uint64Kind:   1,
+	protoreflect.BoolKind:     1,
+	protoreflect.EnumKind:     1,
+	protoreflect.Sint32Kind:   2,
+	protoreflect.Sint64Kind:   2,
+	protoreflect.Fixed32Kind:  3,
+	protoreflect.Sfixed32Kind: 3,
+	protoreflect.Fixed64Kind:  4,
+	protoreflect.Sfixed64Kind: 4,
+	protoreflect.StringKind:   5,
+	protoreflect.BytesKind:    5,
+}
+
+// CheckCompatibility checks the current schema of a message against its previous one, returning the changes which break
+// the decoding of the payloads of either schema with the other, in protobuf or JSON:
+//
+// - fields changing their kind to one of another encoding, e.g. from int32 to string, or their cardinality, e.g. to repeated
+// - fields changing their name, which is used by JSON
+// - fields being removed, without their numbers and names being reserved
+// - required fields being added
+//
+// The messages of the fields are checked recursively.
+func CheckCompatibility(previous, current protoreflect.MessageDescriptor) error {
+	return patronErrors.Aggregate(incompatibilities(previous, current)...)
+}
+
+func incompatibilities(previous, current protoreflect.MessageDescriptor) []error {
+	c := &compatibility{checked: make(map[protoreflect.FullName]struct{})}
+	c.check(previous, current)
+	return c.ee
+}
+
+type compatibility struct {
+	checked map[protoreflect.FullName]struct{}
+	ee      []error
+}
+
+func (c *compatibility) errorf(md protoreflect.MessageDescriptor, format string, args ...interface{}) {
+	c.ee = append(c.ee, fmt.Errorf("%s: %s", md.FullName(), fmt.Sprintf(format, args...)))
+}
+
+func (c *compatibility) check(previous, current protoreflect.MessageDescriptor) {
+	if _, ok := c.checked[current.FullName()]; ok {
+		return
+	}
+	c.checked[current.FullName()] = struct{}{}
+
+	prevFields := previous.Fields()
+	currFields := current.Fields()
+	for i := 0; i < prevFields.Len(); i++ {
+		prev := prevFields.Get(i)
+		curr := currFields.ByNumber(prev.Number())
+		if curr == nil {
+			if !current.ReservedRanges().Has(prev.Number()) || !current.ReservedNames().Has(prev.Name()) {
+				c.errorf(current, "field %s (%d) was removed without reserving its number and name", prev.Name(), prev.Number())
+			}
+			continue
+		}
+		c.checkField(current, prev, curr)
+	}
+
+	for i := 0; i < currFields.Len(); i++ {
+		curr := currFields.Get(i)
+		if prevFields.ByNumber(curr.Number()) == nil && curr.Cardinality() == protoreflect.Required {
+			c.errorf(current, "required field %s (%d) was added", curr.Name(), curr.Number())
+		}
+	}
+}
+
+func (c *compatibility) checkField(md protoreflect.MessageDescriptor, prev, curr protoreflect.FieldDescriptor) {
+	if prev.Name() != curr.Name() {
+		c.errorf(md, "field %s (%d) was renamed to %s", prev.Name(), prev.Number(), curr.Name())
+	}
+	if prev.IsMap() != curr.IsMap() || prev.IsList() != curr.IsList() {
+		c.errorf(md, "field %s (%d) changed its cardinality", curr.Name(), curr.Number())
+		return
+	}
+	if prev.Cardinality() != protoreflect.Required && curr.Cardinality() == protoreflect.Required {
+		c.errorf(md, "field %s (%d) became required", curr.Name(), curr.Number())
+	}
+
+	if prev.IsMap() {
+		c.checkField(md, prev.MapKey(), curr.MapKey())
+		c.checkField(md, prev.MapValue(), curr.MapValue())
+		return
+	}
+
+	switch {
+	case prev.Kind() == curr.Kind():
+	case kindGroups[prev.Kind()] != 0 && kindGroups[prev.Kind()] == kindGroups[curr.Kind()]:
+		// the JSON mapping of the kinds differs, e.g. 64-bit integers are strings, except for the ones of the same width
+		if !sameJSONKind(prev.Kind(), curr.Kind()) {
+			c.errorf(md, "field %s (%d) changed its kind from %s to %s, which are encoded differently in JSON", curr.Name(), curr.Number(), prev.Kind(), curr.Kind())
+		}
+		return
+	default:
+		c.errorf(md, "field %s (%d) changed its kind from %s to %s", curr.Name(), curr.Number(), prev.Kind(), curr.Kind())
+		return
+	}
+
+	switch curr.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		if prev.Message().FullName() != curr.Message().FullName() {
+			c.errorf(md, "field %s (%d) changed its message from %s to %s", curr.Name(), curr.Number(), prev.Message().FullName(), curr.Message().FullName())
+			return
+		}
+		c.check(prev.Message(), curr.Message())
+	case protoreflect.EnumKind:
+		if prev.Enum().FullName() != curr.Enum().FullName() {
+			c.errorf(md, "field %s (%d) changed its enum from %s to %s", curr.Name(), curr.Number(), prev.Enum().FullName(), curr.Enum().FullName())
+		}
+	}
+}
+
+// sameJSONKind returns true if the kinds have the same JSON mapping.
+func sameJSONKind(a, b protoreflect.Kind) bool {
+	return jsonKind(a) == jsonKind(b)
+}
+
+func jsonKind(k protoreflect.Kind) string {
+	switch k {
+	case protoreflect.Int32Kind, protoreflect.Uint32Kind, protoreflect.Sint32Kind, protoreflect.Fixed32Kind, protoreflect.Sfixed32Kind:
+		return "number"
+	case protoreflect.Int64Kind, protoreflect.Uint64Kind, protoreflect.Sint64Kind, protoreflect.Fixed64Kind, protoreflect.Sfixed64Kind:
+		return "string"
+	case protoreflect.StringKind:
+		return "string"
+	case protoreflect.BytesKind:
+		return "base64"
+	case protoreflect.BoolKind:
+		return "bool"
+	case protoreflect.EnumKind:
+		return "enum"
+	}
+	return k.String()
+}
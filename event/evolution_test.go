@@ -0,0 +1,189 @@
+package event
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func field(name string, number int32, typ descriptorpb.FieldDescriptorProto_Type) *descriptorpb.FieldDescriptorProto {
+	return &descriptorpb.FieldDescriptorProto{
+		Name:     proto.String(name),
+		JsonName: proto.String(name),
+		Number:   proto.Int32(number),
+		Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+		Type:     typ.Enum(),
+	}
+}
+
+func messageField(name string, number int32, typeName string) *descriptorpb.FieldDescriptorProto {
+	f := field(name, number, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE)
+	f.TypeName = proto.String(typeName)
+	return f
+}
+
+func repeated(f *descriptorpb.FieldDescriptorProto) *descriptorpb.FieldDescriptorProto {
+	f.Label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+	return f
+}
+
+func orderDescriptor(t *testing.T, syntax string, order *descriptorpb.DescriptorProto) protoreflect.MessageDescriptor {
+	item := &descriptorpb.DescriptorProto{
+		Name: proto.String("Item"),
+		Field: []*descriptorpb.FieldDescriptorProto{
+			field("sku", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING),
+			messageField("parent", 2, ".orders.Item"),
+		},
+	}
+	fd, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:        proto.String("orders.proto"),
+		Package:     proto.String("orders"),
+		Syntax:      proto.String(syntax),
+		MessageType: []*descriptorpb.DescriptorProto{order, item},
+	}, nil)
+	require.NoError(t, err)
+	return fd.Messages().ByName("Order")
+}
+
+func order(ff ...*descriptorpb.FieldDescriptorProto) *descriptorpb.DescriptorProto {
+	return &descriptorpb.DescriptorProto{Name: proto.String("Order"), Field: ff}
+}
+
+func TestCheckCompatibility(t *testing.T) {
+	t.Parallel()
+	previous := order(
+		field("id", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING),
+		field("amount", 2, descriptorpb.FieldDescriptorProto_TYPE_INT32),
+		repeated(messageField("items", 3, ".orders.Item")),
+	)
+	reserved := order(
+		field("id", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING),
+		repeated(messageField("items", 3, ".orders.Item")),
+	)
+	reserved.ReservedRange = []*descriptorpb.DescriptorProto_ReservedRange{{Start: proto.Int32(2), End: proto.Int32(3)}}
+	reserved.ReservedName = []string{"amount"}
+
+	tests := map[string]struct {
+		current     *descriptorpb.DescriptorProto
+		syntax      string
+		expectedErr string
+	}{
+		"unchanged": {current: previous},
+		"field added": {current: order(
+			field("id", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING),
+			field("amount", 2, descriptorpb.FieldDescriptorProto_TYPE_INT32),
+			repeated(messageField("items", 3, ".orders.Item")),
+			field("currency", 4, descriptorpb.FieldDescriptorProto_TYPE_STRING),
+		)},
+		"compatible kind": {current: order(
+			field("id", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING),
+			field("amount", 2, descriptorpb.FieldDescriptorProto_TYPE_UINT32),
+			repeated(messageField("items", 3, ".orders.Item")),
+		)},
+		"field removed and reserved": {current: reserved},
+		"field removed": {
+			current: order(
+				field("id", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING),
+				repeated(messageField("items", 3, ".orders.Item")),
+			),
+			expectedErr: "orders.Order: field amount (2) was removed without reserving its number and name\n",
+		},
+		"field renamed": {
+			current: order(
+				field("id", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING),
+				field("total", 2, descriptorpb.FieldDescriptorProto_TYPE_INT32),
+				repeated(messageField("items", 3, ".orders.Item")),
+			),
+			expectedErr: "orders.Order: field amount (2) was renamed to total\n",
+		},
+		"incompatible kind": {
+			current: order(
+				field("id", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING),
+				field("amount", 2, descriptorpb.FieldDescriptorProto_TYPE_DOUBLE),
+				repeated(messageField("items", 3, ".orders.Item")),
+			),
+			expectedErr: "orders.Order: field amount (2) changed its kind from int32 to double\n",
+		},
+		"incompatible json kind": {
+			current: order(
+				field("id", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING),
+				field("amount", 2, descriptorpb.FieldDescriptorProto_TYPE_INT64),
+				repeated(messageField("items", 3, ".orders.Item")),
+			),
+			expectedErr: "orders.Order: field amount (2) changed its kind from int32 to int64, which are encoded differently in JSON\n",
+		},
+		"cardinality changed": {
+			current: order(
+				field("id", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING),
+				field("amount", 2, descriptorpb.FieldDescriptorProto_TYPE_INT32),
+				messageField("items", 3, ".orders.Item"),
+			),
+			expectedErr: "orders.Order: field items (3) changed its cardinality\n",
+		},
+		"message changed": {
+			current: order(
+				field("id", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING),
+				field("amount", 2, descriptorpb.FieldDescriptorProto_TYPE_INT32),
+				repeated(messageField("items", 3, ".orders.Order")),
+			),
+			expectedErr: "orders.Order: field items (3) changed its message from orders.Item to orders.Order\n",
+		},
+		"required field added": {
+			current: func() *descriptorpb.DescriptorProto {
+				o := order(
+					field("id", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING),
+					field("amount", 2, descriptorpb.FieldDescriptorProto_TYPE_INT32),
+					repeated(messageField("items", 3, ".orders.Item")),
+					field("currency", 4, descriptorpb.FieldDescriptorProto_TYPE_STRING),
+				)
+				o.Field[3].Label = descriptorpb.FieldDescriptorProto_LABEL_REQUIRED.Enum()
+				return o
+			}(),
+			syntax:      "proto2",
+			expectedErr: "orders.Order: required field currency (4) was added\n",
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			syntax := tt.syntax
+			if syntax == "" {
+				syntax = "proto3"
+			}
+			err := CheckCompatibility(orderDescriptor(t, syntax, previous), orderDescriptor(t, syntax, tt.current))
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestCheckCompatibility_Nested(t *testing.T) {
+	t.Parallel()
+	previous := orderDescriptor(t, "proto3", order(repeated(messageField("items", 1, ".orders.Item"))))
+	currentOrder := order(repeated(messageField("items", 1, ".orders.Item")))
+	fd, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:    proto.String("orders.proto"),
+		Package: proto.String("orders"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{currentOrder, {
+			Name: proto.String("Item"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				field("sku", 1, descriptorpb.FieldDescriptorProto_TYPE_BYTES),
+				messageField("parent", 2, ".orders.Item"),
+			},
+		}},
+	}, nil)
+	require.NoError(t, err)
+
+	err = CheckCompatibility(previous, fd.Messages().ByName("Order"))
+	assert.EqualError(t, err, "orders.Item: field sku (1) changed its kind from string to bytes, which are encoded differently in JSON\n")
+}
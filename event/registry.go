@@ -0,0 +1,196 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/beatlabs/patron/correlation"
+	"github.com/beatlabs/patron/encoding/protobuf"
+	patronErrors "github.com/beatlabs/patron/errors"
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// ErrUnknownType is returned when decoding the payload of an event, whose type is not registered.
+var ErrUnknownType = errors.New("unknown event type")
+
+// ErrUnsupportedVersion is returned when decoding the payload of an event, whose schema version differs from the registered one.
+var ErrUnsupportedVersion = errors.New("unsupported schema version")
+
+// Registry of the event types of a source, i.e. of the protobuf messages of their payloads along with their schema versions.
+type Registry struct {
+	source string
+	mu     sync.RWMutex
+	types  map[string]registration
+}
+
+type registration struct {
+	message protoreflect.MessageType
+	version uint32
+}
+
+// NewRegistry creates a registry of the event types of the source, e.g. the name of the service.
+func NewRegistry(source string) (*Registry, error) {
+	if source == "" {
+		return nil, errors.New("source is empty")
+	}
+	return &Registry{source: source, types: make(map[string]registration)}, nil
+}
+
+// Register the protobuf message as the payload of the event type of its full name, e.g. orders.OrderCreated, with the schema version.
+// The version should be increased on incompatible changes of the message, which are rejected by the consumers of the previous versions.
+func (r *Registry) Register(msg proto.Message, version uint32) error {
+	if msg == nil {
+		return errors.New("message is nil")
+	}
+	if version == 0 {
+		return errors.New("schema version should be positive")
+	}
+	mt := msg.ProtoReflect().Type()
+	typ := string(mt.Descriptor().FullName())
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.types[typ]; ok {
+		return fmt.Errorf("event type %s is already registered", typ)
+	}
+	r.types[typ] = registration{message: mt, version: version}
+	return nil
+}
+
+// Envelope creates the envelope of the payload, which should be registered, with a new ID, the current time and the correlation ID of the context.
+func (r *Registry) Envelope(ctx context.Context, payload proto.Message) (Envelope, error) {
+	if payload == nil {
+		return Envelope{}, errors.New("payload is nil")
+	}
+	typ := string(payload.ProtoReflect().Descriptor().FullName())
+	reg, ok := r.registration(typ)
+	if !ok {
+		return Envelope{}, fmt.Errorf("%w: %s", ErrUnknownType, typ)
+	}
+	data, err := proto.Marshal(payload)
+	if err != nil {
+		return Envelope{}, fmt.Errorf("failed to encode payload: %w", err)
+	}
+	return Envelope{
+		ID:              uuid.New().String(),
+		Type:            typ,
+		Source:          r.source,
+		Time:            time.Now().UTC(),
+		CorrelationID:   correlation.IDFromContext(ctx),
+		SchemaVersion:   reg.version,
+		DataContentType: protobuf.Type,
+		Data:            data,
+	}, nil
+}
+
+// Payload decodes the payload of the envelope into a new message of its registered type.
+// It fails with ErrUnknownType for unregistered types, and ErrUnsupportedVersion for schema versions other than the registered one.
+func (r *Registry) Payload(env Envelope) (proto.Message, error) {
+	reg, ok := r.registration(env.Type)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownType, env.Type)
+	}
+	if env.SchemaVersion != reg.version {
+		return nil, fmt.Errorf("%w: %d of event type %s, expected %d", ErrUnsupportedVersion, env.SchemaVersion, env.Type, reg.version)
+	}
+	msg := reg.message.New().Interface()
+	if err := decodeData(env, msg); err != nil {
+		return nil, fmt.Errorf("failed to decode payload of event type %s: %w", env.Type, err)
+	}
+	return msg, nil
+}
+
+func (r *Registry) registration(typ string) (registration, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	reg, ok := r.types[typ]
+	return reg, ok
+}
+
+// Snapshot returns the schemas of the registered types, i.e. the descriptors of their files and of the files they depend on,
+// which should be stored, e.g. in the repository of the service, to check the evolution of the types against it.
+func (r *Registry) Snapshot() *descriptorpb.FileDescriptorSet {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	set := &descriptorpb.FileDescriptorSet{}
+	seen := make(map[string]struct{})
+	var add func(fd protoreflect.FileDescriptor)
+	add = func(fd protoreflect.FileDescriptor) {
+		if _, ok := seen[fd.Path()]; ok {
+			return
+		}
+		seen[fd.Path()] = struct{}{}
+		// the dependencies precede the files depending on them
+		imports := fd.Imports()
+		for i := 0; i < imports.Len(); i++ {
+			add(imports.Get(i).FileDescriptor)
+		}
+		set.File = append(set.File, protodesc.ToFileDescriptorProto(fd))
+	}
+	for _, typ := range r.sortedTypes() {
+		add(r.types[typ].message.Descriptor().ParentFile())
+	}
+	return set
+}
+
+// CheckEvolution checks the registered types against their schemas in the snapshot, returning the incompatible changes, if any.
+// It should be called at startup, so that a service fails fast instead of publishing or consuming incompatible payloads.
+// The types missing from the snapshot are considered new. Intended incompatible changes require increasing the schema version
+// of the type, so that the consumers of the previous version reject its events, and replacing the snapshot.
+func (r *Registry) CheckEvolution(snapshot *descriptorpb.FileDescriptorSet) error {
+	if snapshot == nil {
+		return errors.New("snapshot is nil")
+	}
+	files, err := protodesc.NewFiles(snapshot)
+	if err != nil {
+		return fmt.Errorf("invalid snapshot: %w", err)
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var ee []error
+	for _, typ := range r.sortedTypes() {
+		d, err := files.FindDescriptorByName(protoreflect.FullName(typ))
+		if err != nil {
+			continue
+		}
+		previous, ok := d.(protoreflect.MessageDescriptor)
+		if !ok {
+			continue
+		}
+		ee = append(ee, incompatibilities(previous, r.types[typ].message.Descriptor())...)
+	}
+	return patronErrors.Aggregate(ee...)
+}
+
+// LoadSnapshot reads a snapshot from a file, containing a serialized FileDescriptorSet, e.g. the output of the
+// --descriptor_set_out option of protoc with --include_imports, or a marshaled snapshot of the registry.
+func LoadSnapshot(path string) (*descriptorpb.FileDescriptorSet, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+	set := &descriptorpb.FileDescriptorSet{}
+	if err := proto.Unmarshal(b, set); err != nil {
+		return nil, fmt.Errorf("failed to decode snapshot: %w", err)
+	}
+	return set, nil
+}
+
+func (r *Registry) sortedTypes() []string {
+	tt := make([]string, 0, len(r.types))
+	for typ := range r.types {
+		tt = append(tt, typ)
+	}
+	sort.Strings(tt)
+	return tt
+}
@@ -0,0 +1,191 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/beatlabs/patron/correlation"
+	"github.com/beatlabs/patron/encoding/protobuf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestNewRegistry(t *testing.T) {
+	t.Parallel()
+	got, err := NewRegistry("orders")
+	assert.NoError(t, err)
+	assert.NotNil(t, got)
+	got, err = NewRegistry("")
+	assert.EqualError(t, err, "source is empty")
+	assert.Nil(t, got)
+}
+
+func TestRegistry_Register(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		msg         proto.Message
+		version     uint32
+		expectedErr string
+	}{
+		"success":            {msg: &timestamppb.Timestamp{}, version: 1},
+		"nil message":        {msg: nil, version: 1, expectedErr: "message is nil"},
+		"zero version":       {msg: &timestamppb.Timestamp{}, version: 0, expectedErr: "schema version should be positive"},
+		"already registered": {msg: &durationpb.Duration{}, version: 1, expectedErr: "event type google.protobuf.Duration is already registered"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			r, err := NewRegistry("orders")
+			require.NoError(t, err)
+			require.NoError(t, r.Register(&durationpb.Duration{}, 1))
+			err = r.Register(tt.msg, tt.version)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestRegistry_EnvelopePayload(t *testing.T) {
+	t.Parallel()
+	r, err := NewRegistry("orders")
+	require.NoError(t, err)
+	require.NoError(t, r.Register(&durationpb.Duration{}, 2))
+
+	ctx := correlation.ContextWithID(context.Background(), "123")
+	env, err := r.Envelope(ctx, durationpb.New(time.Minute))
+	require.NoError(t, err)
+	assert.NotEmpty(t, env.ID)
+	assert.Equal(t, "google.protobuf.Duration", env.Type)
+	assert.Equal(t, "orders", env.Source)
+	assert.False(t, env.Time.IsZero())
+	assert.Equal(t, "123", env.CorrelationID)
+	assert.Equal(t, uint32(2), env.SchemaVersion)
+	assert.Equal(t, protobuf.Type, env.DataContentType)
+
+	for _, contentType := range []string{ProtobufType, JSONType} {
+		b, err := Encode(env, contentType)
+		require.NoError(t, err)
+		decoded, err := Decode(b, contentType)
+		require.NoError(t, err)
+		got, err := r.Payload(decoded)
+		require.NoError(t, err)
+		d, ok := got.(*durationpb.Duration)
+		require.True(t, ok)
+		assert.Equal(t, time.Minute, d.AsDuration())
+	}
+}
+
+func TestRegistry_EnvelopeFailure(t *testing.T) {
+	t.Parallel()
+	r, err := NewRegistry("orders")
+	require.NoError(t, err)
+	_, err = r.Envelope(context.Background(), nil)
+	assert.EqualError(t, err, "payload is nil")
+	_, err = r.Envelope(context.Background(), &durationpb.Duration{})
+	assert.True(t, errors.Is(err, ErrUnknownType))
+}
+
+func TestRegistry_PayloadFailure(t *testing.T) {
+	t.Parallel()
+	r, err := NewRegistry("orders")
+	require.NoError(t, err)
+	require.NoError(t, r.Register(&durationpb.Duration{}, 2))
+
+	tests := map[string]struct {
+		env         Envelope
+		expectedErr error
+	}{
+		"unknown type":        {env: Envelope{Type: "orders.Unknown", SchemaVersion: 2}, expectedErr: ErrUnknownType},
+		"unsupported version": {env: Envelope{Type: "google.protobuf.Duration", SchemaVersion: 1}, expectedErr: ErrUnsupportedVersion},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			got, err := r.Payload(tt.env)
+			assert.True(t, errors.Is(err, tt.expectedErr))
+			assert.Nil(t, got)
+		})
+	}
+
+	got, err := r.Payload(Envelope{Type: "google.protobuf.Duration", SchemaVersion: 2, DataContentType: protobuf.Type, Data: []byte{0xff}})
+	assert.Error(t, err)
+	assert.Nil(t, got)
+}
+
+func TestRegistry_Snapshot(t *testing.T) {
+	t.Parallel()
+	r, err := NewRegistry("orders")
+	require.NoError(t, err)
+	require.NoError(t, r.Register(&timestamppb.Timestamp{}, 1))
+	require.NoError(t, r.Register(&durationpb.Duration{}, 1))
+
+	snapshot := r.Snapshot()
+	require.Len(t, snapshot.File, 2)
+	assert.Equal(t, "google/protobuf/duration.proto", snapshot.File[0].GetName())
+	assert.Equal(t, "google/protobuf/timestamp.proto", snapshot.File[1].GetName())
+	_, err = protodesc.NewFiles(snapshot)
+	assert.NoError(t, err)
+	assert.NoError(t, r.CheckEvolution(snapshot))
+}
+
+func TestRegistry_CheckEvolution(t *testing.T) {
+	t.Parallel()
+	r, err := NewRegistry("orders")
+	require.NoError(t, err)
+	require.NoError(t, r.Register(&durationpb.Duration{}, 1))
+
+	// the previous schema of google.protobuf.Duration had a string seconds field
+	previous := protodesc.ToFileDescriptorProto(durationpb.File_google_protobuf_duration_proto)
+	previous.MessageType[0].Field[0].Type = descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum()
+
+	err = r.CheckEvolution(&descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{previous}})
+	assert.EqualError(t, err, "google.protobuf.Duration: field seconds (1) changed its kind from string to int64\n")
+
+	// new types are missing from the snapshot
+	assert.NoError(t, r.CheckEvolution(&descriptorpb.FileDescriptorSet{}))
+
+	assert.EqualError(t, r.CheckEvolution(nil), "snapshot is nil")
+	invalid := &descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{{Name: proto.String("a.proto"), Dependency: []string{"b.proto"}}}}
+	assert.Error(t, r.CheckEvolution(invalid))
+}
+
+func TestLoadSnapshot(t *testing.T) {
+	t.Parallel()
+	r, err := NewRegistry("orders")
+	require.NoError(t, err)
+	require.NoError(t, r.Register(&durationpb.Duration{}, 1))
+	b, err := proto.Marshal(r.Snapshot())
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "events.pb")
+	require.NoError(t, ioutil.WriteFile(path, b, 0o600))
+	invalidPath := filepath.Join(dir, "invalid.pb")
+	require.NoError(t, ioutil.WriteFile(invalidPath, []byte{0xff}, 0o600))
+
+	got, err := LoadSnapshot(path)
+	require.NoError(t, err)
+	assert.True(t, proto.Equal(r.Snapshot(), got))
+
+	got, err = LoadSnapshot(filepath.Join(dir, "missing.pb"))
+	assert.Error(t, err)
+	assert.Nil(t, got)
+
+	got, err = LoadSnapshot(invalidPath)
+	assert.Error(t, err)
+	assert.Nil(t, got)
+}
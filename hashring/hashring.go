@@ -0,0 +1,231 @@
+// Package hashring provides a consistent hashing ring, which maps keys to members for client-side sharding,
+// e.g. of cache backends, custom Kafka partitioning or sharded Redis/memcached setups.
+package hashring
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// DefaultVirtualNodes is the default number of virtual nodes placed in the ring for every unit of member weight.
+const DefaultVirtualNodes = 160
+
+// ErrEmpty is returned when looking up a key in a ring without members.
+var ErrEmpty = errors.New("hash ring has no members")
+
+// EventType defines the type of change of the ring members.
+type EventType int
+
+const (
+	// MemberAdded is the event type of a member joining the ring.
+	MemberAdded EventType = iota + 1
+	// MemberRemoved is the event type of a member leaving the ring.
+	MemberRemoved
+	// MemberUpdated is the event type of a member whose weight has changed.
+	MemberUpdated
+)
+
+// Event describes a change of the ring members, after which some keys map to different members.
+type Event struct {
+	Type   EventType
+	Member string
+	// Members holds the members of the ring after the change.
+	Members []string
+}
+
+// RebalanceFunc is notified of every change of the ring members, e.g. in order to move or invalidate keys.
+type RebalanceFunc func(Event)
+
+// HashFunc definition of the function placing keys and virtual nodes in the ring.
+type HashFunc func([]byte) uint64
+
+type node struct {
+	hash   uint64
+	member string
+}
+
+// Ring is a consistent hashing ring with weighted members. It is safe for concurrent use.
+type Ring struct {
+	sync.RWMutex
+	virtualNodes int
+	hash         HashFunc
+	onRebalance  []RebalanceFunc
+	weights      map[string]int
+	nodes        []node
+}
+
+// New creates an empty ring.
+func New(oo ...OptionFunc) (*Ring, error) {
+	r := &Ring{
+		virtualNodes: DefaultVirtualNodes,
+		hash:         defaultHash,
+		weights:      make(map[string]int),
+	}
+
+	for _, option := range oo {
+		if err := option(r); err != nil {
+			return nil, err
+		}
+	}
+
+	return r, nil
+}
+
+// Add adds a member with the provided weight to the ring, or updates its weight if it is already a member.
+// Members with higher weight are assigned proportionally more keys.
+func (r *Ring) Add(member string, weight int) error {
+	if member == "" {
+		return errors.New("member is empty")
+	}
+	if weight <= 0 {
+		return fmt.Errorf("weight of member %s should be positive", member)
+	}
+
+	r.Lock()
+	previous, exists := r.weights[member]
+	if exists && previous == weight {
+		r.Unlock()
+		return nil
+	}
+	r.weights[member] = weight
+	r.build()
+	event := Event{Type: MemberAdded, Member: member, Members: r.members()}
+	r.Unlock()
+
+	if exists {
+		event.Type = MemberUpdated
+	}
+	r.notify(event)
+	return nil
+}
+
+// Remove removes a member from the ring. Removing a member which does not exist is a no-op.
+func (r *Ring) Remove(member string) {
+	r.Lock()
+	if _, ok := r.weights[member]; !ok {
+		r.Unlock()
+		return
+	}
+	delete(r.weights, member)
+	r.build()
+	event := Event{Type: MemberRemoved, Member: member, Members: r.members()}
+	r.Unlock()
+
+	r.notify(event)
+}
+
+// Get returns the member the key maps to.
+func (r *Ring) Get(key string) (string, error) {
+	r.RLock()
+	defer r.RUnlock()
+
+	if len(r.nodes) == 0 {
+		return "", ErrEmpty
+	}
+	return r.nodes[r.search(key)].member, nil
+}
+
+// GetN returns up to n distinct members for the key, in the order they are found clockwise in the ring.
+// The first member is the one returned by Get, while the rest can be used e.g. for replicas or fallbacks.
+func (r *Ring) GetN(key string, n int) ([]string, error) {
+	if n <= 0 {
+		return nil, errors.New("number of members should be positive")
+	}
+
+	r.RLock()
+	defer r.RUnlock()
+
+	if len(r.nodes) == 0 {
+		return nil, ErrEmpty
+	}
+	if n > len(r.weights) {
+		n = len(r.weights)
+	}
+
+	members := make([]string, 0, n)
+	seen := make(map[string]struct{}, n)
+	for i, start := 0, r.search(key); len(members) < n && i < len(r.nodes); i++ {
+		member := r.nodes[(start+i)%len(r.nodes)].member
+		if _, ok := seen[member]; ok {
+			continue
+		}
+		seen[member] = struct{}{}
+		members = append(members, member)
+	}
+	return members, nil
+}
+
+// Members returns the sorted members of the ring.
+func (r *Ring) Members() []string {
+	r.RLock()
+	defer r.RUnlock()
+	return r.members()
+}
+
+// search returns the index of the first virtual node clockwise from the hash of the key.
+func (r *Ring) search(key string) int {
+	h := r.hash([]byte(key))
+	i := sort.Search(len(r.nodes), func(i int) bool {
+		return r.nodes[i].hash >= h
+	})
+	if i == len(r.nodes) {
+		return 0
+	}
+	return i
+}
+
+// build places the virtual nodes of all members in the ring, which is rebuilt as a whole
+// so that the result does not depend on the order the members were added in.
+func (r *Ring) build() {
+	size := 0
+	for _, weight := range r.weights {
+		size += weight * r.virtualNodes
+	}
+	nodes := make([]node, 0, size)
+	for member, weight := range r.weights {
+		for i := 0; i < weight*r.virtualNodes; i++ {
+			nodes = append(nodes, node{hash: r.hash([]byte(member + "#" + strconv.Itoa(i))), member: member})
+		}
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		if nodes[i].hash == nodes[j].hash {
+			// collisions are resolved deterministically
+			return nodes[i].member < nodes[j].member
+		}
+		return nodes[i].hash < nodes[j].hash
+	})
+	r.nodes = nodes
+}
+
+func (r *Ring) members() []string {
+	members := make([]string, 0, len(r.weights))
+	for member := range r.weights {
+		members = append(members, member)
+	}
+	sort.Strings(members)
+	return members
+}
+
+func (r *Ring) notify(event Event) {
+	for _, fn := range r.onRebalance {
+		fn(event)
+	}
+}
+
+// defaultHash is the 64-bit FNV-1a hash, followed by a finalizer mixing the bits,
+// since the plain FNV hash of similar keys, like the ones of the virtual nodes, is poorly distributed.
+func defaultHash(data []byte) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write(data)
+	x := h.Sum64()
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
@@ -0,0 +1,150 @@
+package hashring
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRing_Empty(t *testing.T) {
+	r, err := New()
+	require.NoError(t, err)
+
+	_, err = r.Get("key")
+	assert.ErrorIs(t, err, ErrEmpty)
+	_, err = r.GetN("key", 2)
+	assert.ErrorIs(t, err, ErrEmpty)
+	assert.Empty(t, r.Members())
+}
+
+func TestRing_Add(t *testing.T) {
+	r, err := New()
+	require.NoError(t, err)
+
+	assert.EqualError(t, r.Add("", 1), "member is empty")
+	assert.EqualError(t, r.Add("a", 0), "weight of member a should be positive")
+
+	assert.NoError(t, r.Add("b", 1))
+	assert.NoError(t, r.Add("a", 1))
+	assert.Equal(t, []string{"a", "b"}, r.Members())
+}
+
+func TestRing_Distribution(t *testing.T) {
+	r, err := New()
+	require.NoError(t, err)
+	require.NoError(t, r.Add("a", 1))
+	require.NoError(t, r.Add("b", 1))
+	require.NoError(t, r.Add("c", 2))
+
+	counts := distribution(t, r, 100000)
+	assert.InDelta(t, 25000, counts["a"], 3000)
+	assert.InDelta(t, 25000, counts["b"], 3000)
+	assert.InDelta(t, 50000, counts["c"], 3000)
+}
+
+func TestRing_Consistency(t *testing.T) {
+	r, err := New()
+	require.NoError(t, err)
+	for _, m := range []string{"a", "b", "c", "d"} {
+		require.NoError(t, r.Add(m, 1))
+	}
+
+	const keys = 10000
+	before := mapping(t, r, keys)
+
+	require.NoError(t, r.Add("e", 1))
+	after := mapping(t, r, keys)
+	moved := 0
+	for k, m := range before {
+		if after[k] != m {
+			// keys move only to the new member
+			assert.Equal(t, "e", after[k])
+			moved++
+		}
+	}
+	assert.InDelta(t, keys/5, moved, keys/20)
+
+	r.Remove("e")
+	assert.Equal(t, before, mapping(t, r, keys))
+}
+
+func TestRing_OrderIndependence(t *testing.T) {
+	r1, err := New()
+	require.NoError(t, err)
+	r2, err := New()
+	require.NoError(t, err)
+	for _, m := range []string{"a", "b", "c"} {
+		require.NoError(t, r1.Add(m, 1))
+	}
+	for _, m := range []string{"c", "a", "b"} {
+		require.NoError(t, r2.Add(m, 1))
+	}
+	assert.Equal(t, mapping(t, r1, 1000), mapping(t, r2, 1000))
+}
+
+func TestRing_GetN(t *testing.T) {
+	r, err := New()
+	require.NoError(t, err)
+	for _, m := range []string{"a", "b", "c"} {
+		require.NoError(t, r.Add(m, 1))
+	}
+
+	_, err = r.GetN("key", 0)
+	assert.EqualError(t, err, "number of members should be positive")
+
+	members, err := r.GetN("key", 2)
+	assert.NoError(t, err)
+	assert.Len(t, members, 2)
+	assert.NotEqual(t, members[0], members[1])
+	first, err := r.Get("key")
+	assert.NoError(t, err)
+	assert.Equal(t, first, members[0])
+
+	members, err = r.GetN("key", 5)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"a", "b", "c"}, members)
+}
+
+func TestRing_OnRebalance(t *testing.T) {
+	var events []Event
+	r, err := New(OnRebalance(func(e Event) {
+		events = append(events, e)
+	}))
+	require.NoError(t, err)
+
+	require.NoError(t, r.Add("a", 1))
+	require.NoError(t, r.Add("b", 1))
+	// unchanged weight is not a rebalance
+	require.NoError(t, r.Add("b", 1))
+	require.NoError(t, r.Add("b", 2))
+	r.Remove("a")
+	r.Remove("a")
+
+	assert.Equal(t, []Event{
+		{Type: MemberAdded, Member: "a", Members: []string{"a"}},
+		{Type: MemberAdded, Member: "b", Members: []string{"a", "b"}},
+		{Type: MemberUpdated, Member: "b", Members: []string{"a", "b"}},
+		{Type: MemberRemoved, Member: "a", Members: []string{"b"}},
+	}, events)
+}
+
+func distribution(t *testing.T, r *Ring, keys int) map[string]int {
+	counts := make(map[string]int)
+	for _, m := range mapping(t, r, keys) {
+		counts[m]++
+	}
+	return counts
+}
+
+func mapping(t *testing.T, r *Ring, keys int) map[string]string {
+	m := make(map[string]string, keys)
+	for i := 0; i < keys; i++ {
+		key := "key-" + strconv.Itoa(i)
+		member, err := r.Get(key)
+		require.NoError(t, err)
+		m[key] = member
+	}
+	return m
+}
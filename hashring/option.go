@@ -0,0 +1,41 @@
+package hashring
+
+import "errors"
+
+// OptionFunc definition for configuring the ring in a functional way.
+type OptionFunc func(*Ring) error
+
+// VirtualNodes option for setting the number of virtual nodes placed in the ring for every unit of member weight.
+// More virtual nodes distribute the keys more evenly, at the cost of memory and lookup time.
+func VirtualNodes(n int) OptionFunc {
+	return func(r *Ring) error {
+		if n <= 0 {
+			return errors.New("virtual nodes should be positive")
+		}
+		r.virtualNodes = n
+		return nil
+	}
+}
+
+// Hash option for setting the hash function placing keys and virtual nodes in the ring.
+func Hash(fn HashFunc) OptionFunc {
+	return func(r *Ring) error {
+		if fn == nil {
+			return errors.New("hash function is nil")
+		}
+		r.hash = fn
+		return nil
+	}
+}
+
+// OnRebalance option for registering a function, which is notified of every change of the ring members.
+// The function is called synchronously after the change is applied, outside of any lock of the ring.
+func OnRebalance(fn RebalanceFunc) OptionFunc {
+	return func(r *Ring) error {
+		if fn == nil {
+			return errors.New("rebalance function is nil")
+		}
+		r.onRebalance = append(r.onRebalance, fn)
+		return nil
+	}
+}
@@ -0,0 +1,28 @@
+package hashring
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVirtualNodes(t *testing.T) {
+	r := &Ring{}
+	assert.EqualError(t, VirtualNodes(0)(r), "virtual nodes should be positive")
+	assert.NoError(t, VirtualNodes(10)(r))
+	assert.Equal(t, 10, r.virtualNodes)
+}
+
+func TestHash(t *testing.T) {
+	r := &Ring{}
+	assert.EqualError(t, Hash(nil)(r), "hash function is nil")
+	assert.NoError(t, Hash(func([]byte) uint64 { return 1 })(r))
+	assert.Equal(t, uint64(1), r.hash(nil))
+}
+
+func TestOnRebalance(t *testing.T) {
+	r := &Ring{}
+	assert.EqualError(t, OnRebalance(nil)(r), "rebalance function is nil")
+	assert.NoError(t, OnRebalance(func(Event) {})(r))
+	assert.Len(t, r.onRebalance, 1)
+}
@@ -0,0 +1,250 @@
+// Package i18n provides message catalogs loaded from file systems e.g. embed.FS, the negotiation of their locales
+// with the Accept-Language header of requests, and the localization of messages, including their plural forms.
+package i18n
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+const messageFileExt = ".json"
+
+// message is a localized message, with a template per plural form. Messages without plural forms have only the other one.
+type message map[string]*template.Template
+
+// Catalog contains the messages of every locale, keyed by their ID.
+type Catalog struct {
+	defaultLocale string
+	locales       []string
+	messages      map[string]map[string]message
+}
+
+// Load loads the catalog from the JSON files at the root of the file system, one per locale named after it, e.g. en.json or el-GR.json.
+// Every file contains the messages of the locale, which are text/template templates, keyed by their ID.
+// Messages with plural forms contain a template per form, i.e. zero, one, two, few, many or other, where other is required:
+//
+//	{"greeting": "Hello {{.Name}}!", "cart.items": {"one": "{{.}} item", "other": "{{.}} items"}}
+//
+// Messages missing from a locale fall back to the default locale, whose file is required.
+func Load(fsys fs.FS, defaultLocale string) (*Catalog, error) {
+	if fsys == nil {
+		return nil, errors.New("file system is nil")
+	}
+	if defaultLocale == "" {
+		return nil, errors.New("default locale is empty")
+	}
+
+	files, err := fs.Glob(fsys, "*"+messageFileExt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list message files: %w", err)
+	}
+
+	c := &Catalog{defaultLocale: canonical(defaultLocale), messages: make(map[string]map[string]message)}
+	for _, file := range files {
+		locale := canonical(strings.TrimSuffix(path.Base(file), messageFileExt))
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read messages of locale %s: %w", locale, err)
+		}
+		messages, err := parseMessages(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse messages of locale %s: %w", locale, err)
+		}
+		c.messages[locale] = messages
+		c.locales = append(c.locales, locale)
+	}
+	sort.Strings(c.locales)
+
+	if _, ok := c.messages[c.defaultLocale]; !ok {
+		return nil, fmt.Errorf("messages of default locale %s are missing", c.defaultLocale)
+	}
+	return c, nil
+}
+
+func parseMessages(data []byte) (map[string]message, error) {
+	raw := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	messages := make(map[string]message, len(raw))
+	for id, value := range raw {
+		forms := make(map[string]string)
+		var text string
+		if err := json.Unmarshal(value, &text); err == nil {
+			forms[pluralOther] = text
+		} else if err := json.Unmarshal(value, &forms); err != nil {
+			return nil, fmt.Errorf("message %s should be a string or an object of plural forms", id)
+		}
+
+		if _, ok := forms[pluralOther]; !ok {
+			return nil, fmt.Errorf("message %s is missing the other plural form", id)
+		}
+		msg := make(message, len(forms))
+		for form, text := range forms {
+			if !validPluralForm(form) {
+				return nil, fmt.Errorf("message %s has invalid plural form %s", id, form)
+			}
+			tmpl, err := template.New(id).Option("missingkey=error").Parse(text)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse message %s: %w", id, err)
+			}
+			msg[form] = tmpl
+		}
+		messages[id] = msg
+	}
+	return messages, nil
+}
+
+// DefaultLocale returns the default locale of the catalog.
+func (c *Catalog) DefaultLocale() string {
+	return c.defaultLocale
+}
+
+// Locales returns the locales of the catalog, sorted.
+func (c *Catalog) Locales() []string {
+	return append([]string(nil), c.locales...)
+}
+
+// Localizer returns a localizer for the first of the preferred locales supported by the catalog, or for the default locale.
+// Locales are matched exactly first, and then by their language, e.g. en-US matches en, and en matches en-GB.
+func (c *Catalog) Localizer(preferred ...string) *Localizer {
+	for _, locale := range preferred {
+		if match, ok := c.match(locale); ok {
+			return &Localizer{catalog: c, locale: match}
+		}
+	}
+	return &Localizer{catalog: c, locale: c.defaultLocale}
+}
+
+func (c *Catalog) match(locale string) (string, bool) {
+	locale = canonical(locale)
+	if _, ok := c.messages[locale]; ok {
+		return locale, true
+	}
+	lang := language(locale)
+	if _, ok := c.messages[lang]; ok {
+		return lang, true
+	}
+	for _, l := range c.locales {
+		if language(l) == lang {
+			return l, true
+		}
+	}
+	return "", false
+}
+
+// Localizer localizes the messages of a catalog in a locale.
+type Localizer struct {
+	catalog *Catalog
+	locale  string
+}
+
+// Locale returns the locale of the localizer, e.g. for the Content-Language header of responses.
+func (l *Localizer) Locale() string {
+	return l.locale
+}
+
+// Message renders the message of the provided ID with the data.
+func (l *Localizer) Message(id string, data interface{}) (string, error) {
+	msg, err := l.message(id)
+	if err != nil {
+		return "", err
+	}
+	return render(msg[pluralOther], data)
+}
+
+// Plural renders the plural form of the message of the provided ID for the count, according to the plural rules of the locale.
+// The message is rendered with the data, or with the count if the data is nil.
+func (l *Localizer) Plural(id string, count int, data interface{}) (string, error) {
+	msg, err := l.message(id)
+	if err != nil {
+		return "", err
+	}
+	if data == nil {
+		data = count
+	}
+	tmpl, ok := msg[pluralForm(l.locale, count)]
+	if !ok {
+		tmpl = msg[pluralOther]
+	}
+	return render(tmpl, data)
+}
+
+// Funcs returns the functions localizing messages in templates, e.g. of html/template or text/template responses:
+//
+//	{{t "greeting" .}}
+//	{{plural "cart.items" .Count nil}}
+//
+// Templates are parsed with the functions of any localizer, and cloned before executing them with the functions of another.
+func (l *Localizer) Funcs() map[string]interface{} {
+	return map[string]interface{}{
+		"t":      l.Message,
+		"plural": l.Plural,
+	}
+}
+
+func (l *Localizer) message(id string) (message, error) {
+	if msg, ok := l.catalog.messages[l.locale][id]; ok {
+		return msg, nil
+	}
+	if msg, ok := l.catalog.messages[l.catalog.defaultLocale][id]; ok {
+		return msg, nil
+	}
+	return nil, fmt.Errorf("message %s not found", id)
+}
+
+func render(tmpl *template.Template, data interface{}) (string, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render message %s: %w", tmpl.Name(), err)
+	}
+	return buf.String(), nil
+}
+
+type localizerContextKey struct{}
+
+// WithLocalizer associates a localizer with a context.
+func WithLocalizer(ctx context.Context, l *Localizer) context.Context {
+	return context.WithValue(ctx, localizerContextKey{}, l)
+}
+
+// FromContext returns the localizer of the context, or nil if it has none.
+func FromContext(ctx context.Context) *Localizer {
+	if l, ok := ctx.Value(localizerContextKey{}).(*Localizer); ok {
+		return l
+	}
+	return nil
+}
+
+// canonical returns the locale in the case conventions of BCP 47 tags, e.g. en-US for en_us and zh-Hant for ZH-HANT.
+func canonical(locale string) string {
+	parts := strings.Split(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"), "-")
+	parts[0] = strings.ToLower(parts[0])
+	for i := 1; i < len(parts); i++ {
+		switch len(parts[i]) {
+		case 2:
+			parts[i] = strings.ToUpper(parts[i])
+		case 4:
+			parts[i] = strings.ToUpper(parts[i][:1]) + strings.ToLower(parts[i][1:])
+		default:
+			parts[i] = strings.ToLower(parts[i])
+		}
+	}
+	return strings.Join(parts, "-")
+}
+
+func language(locale string) string {
+	if i := strings.Index(locale, "-"); i >= 0 {
+		return locale[:i]
+	}
+	return locale
+}
@@ -0,0 +1,215 @@
+package i18n
+
+import (
+	"bytes"
+	"context"
+	"html/template"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testFS() fstest.MapFS {
+	return fstest.MapFS{
+		"en.json": {Data: []byte(`{
+			"greeting": "Hello {{.Name}}!",
+			"farewell": "Goodbye!",
+			"cart.items": {"one": "{{.}} item", "other": "{{.}} items"},
+			"problem.404": "Not found"
+		}`)},
+		"el.json":    {Data: []byte(`{"greeting": "Γεια σου {{.Name}}!", "cart.items": {"one": "{{.}} προϊόν", "other": "{{.}} προϊόντα"}}`)},
+		"en_GB.json": {Data: []byte(`{"greeting": "Hiya {{.Name}}!"}`)},
+		"ru.json":    {Data: []byte(`{"cart.items": {"one": "{{.}} товар", "few": "{{.}} товара", "many": "{{.}} товаров", "other": "{{.}} товара"}}`)},
+		"README.md":  {Data: []byte("not a message file")},
+	}
+}
+
+func testCatalog(t *testing.T) *Catalog {
+	c, err := Load(testFS(), "en")
+	require.NoError(t, err)
+	return c
+}
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		files         fstest.MapFS
+		defaultLocale string
+		expectedErr   string
+	}{
+		"success":                {files: testFS(), defaultLocale: "en"},
+		"missing default locale": {files: testFS(), expectedErr: "default locale is empty"},
+		"unknown default locale": {files: testFS(), defaultLocale: "de", expectedErr: "messages of default locale de are missing"},
+		"invalid json": {
+			files: fstest.MapFS{"en.json": {Data: []byte(`{`)}}, defaultLocale: "en",
+			expectedErr: "failed to parse messages of locale en: unexpected end of JSON input",
+		},
+		"invalid message": {
+			files: fstest.MapFS{"en.json": {Data: []byte(`{"greeting": 1}`)}}, defaultLocale: "en",
+			expectedErr: "failed to parse messages of locale en: message greeting should be a string or an object of plural forms",
+		},
+		"missing other form": {
+			files: fstest.MapFS{"en.json": {Data: []byte(`{"items": {"one": "item"}}`)}}, defaultLocale: "en",
+			expectedErr: "failed to parse messages of locale en: message items is missing the other plural form",
+		},
+		"invalid plural form": {
+			files: fstest.MapFS{"en.json": {Data: []byte(`{"items": {"single": "item", "other": "items"}}`)}}, defaultLocale: "en",
+			expectedErr: "failed to parse messages of locale en: message items has invalid plural form single",
+		},
+		"invalid template": {
+			files: fstest.MapFS{"en.json": {Data: []byte(`{"greeting": "Hello {{.Name"}`)}}, defaultLocale: "en",
+			expectedErr: "failed to parse messages of locale en: failed to parse message greeting: template: greeting:1: unclosed action",
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			got, err := Load(tt.files, tt.defaultLocale)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, "en", got.DefaultLocale())
+				assert.Equal(t, []string{"el", "en", "en-GB", "ru"}, got.Locales())
+			}
+		})
+	}
+
+	_, err := Load(nil, "en")
+	assert.EqualError(t, err, "file system is nil")
+}
+
+func TestCatalog_Localizer(t *testing.T) {
+	t.Parallel()
+	c := testCatalog(t)
+	tests := map[string]struct {
+		preferred []string
+		expected  string
+	}{
+		"none":               {expected: "en"},
+		"exact":              {preferred: []string{"el"}, expected: "el"},
+		"case insensitive":   {preferred: []string{"EN_gb"}, expected: "en-GB"},
+		"language":           {preferred: []string{"el-GR"}, expected: "el"},
+		"region of language": {preferred: []string{"en-US"}, expected: "en"},
+		"other region":       {preferred: []string{"ru-UA"}, expected: "ru"},
+		"first supported":    {preferred: []string{"de", "fr", "el"}, expected: "el"},
+		"unsupported":        {preferred: []string{"de"}, expected: "en"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.expected, c.Localizer(tt.preferred...).Locale())
+		})
+	}
+}
+
+func TestLocalizer_Message(t *testing.T) {
+	t.Parallel()
+	c := testCatalog(t)
+	tests := map[string]struct {
+		locale      string
+		id          string
+		expected    string
+		expectedErr string
+	}{
+		"default locale":  {locale: "en", id: "greeting", expected: "Hello Maria!"},
+		"locale":          {locale: "el", id: "greeting", expected: "Γεια σου Maria!"},
+		"regional locale": {locale: "en-GB", id: "greeting", expected: "Hiya Maria!"},
+		"fallback":        {locale: "el", id: "farewell", expected: "Goodbye!"},
+		"missing":         {locale: "el", id: "unknown", expectedErr: "message unknown not found"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			got, err := c.Localizer(tt.locale).Message(tt.id, map[string]string{"Name": "Maria"})
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expected, got)
+			}
+		})
+	}
+
+	_, err := c.Localizer("en").Message("greeting", map[string]string{})
+	assert.EqualError(t, err, `failed to render message greeting: template: greeting:1:8: `+
+		`executing "greeting" at <.Name>: map has no entry for key "Name"`)
+}
+
+func TestLocalizer_Plural(t *testing.T) {
+	t.Parallel()
+	c := testCatalog(t)
+	tests := map[string]struct {
+		locale   string
+		count    int
+		expected string
+	}{
+		"one":         {locale: "en", count: 1, expected: "1 item"},
+		"other":       {locale: "en", count: 0, expected: "0 items"},
+		"localized":   {locale: "el", count: 3, expected: "3 προϊόντα"},
+		"few":         {locale: "ru", count: 3, expected: "3 товара"},
+		"many":        {locale: "ru", count: 5, expected: "5 товаров"},
+		"one of many": {locale: "ru", count: 21, expected: "21 товар"},
+		"fallback":    {locale: "en-GB", count: 2, expected: "2 items"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			got, err := c.Localizer(tt.locale).Plural("cart.items", tt.count, nil)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+
+	got, err := c.Localizer("en").Plural("greeting", 2, map[string]string{"Name": "Maria"})
+	assert.NoError(t, err)
+	assert.Equal(t, "Hello Maria!", got)
+	_, err = c.Localizer("en").Plural("unknown", 2, nil)
+	assert.EqualError(t, err, "message unknown not found")
+}
+
+func TestLocalizer_Funcs(t *testing.T) {
+	t.Parallel()
+	c := testCatalog(t)
+	tmpl, err := template.New("page").Funcs(c.Localizer().Funcs()).Parse(`<p>{{t "greeting" .}}</p><p>{{plural "cart.items" .Count nil}}</p>`)
+	require.NoError(t, err)
+
+	for locale, expected := range map[string]string{
+		"en": "<p>Hello Maria &amp; Nikos!</p><p>2 items</p>",
+		"el": "<p>Γεια σου Maria &amp; Nikos!</p><p>2 προϊόντα</p>",
+	} {
+		localized, err := tmpl.Clone()
+		require.NoError(t, err)
+		var buf bytes.Buffer
+		require.NoError(t, localized.Funcs(c.Localizer(locale).Funcs()).Execute(&buf, map[string]interface{}{"Name": "Maria & Nikos", "Count": 2}))
+		assert.Equal(t, expected, buf.String())
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	t.Parallel()
+	assert.Nil(t, FromContext(context.Background()))
+	l := testCatalog(t).Localizer("el")
+	assert.Equal(t, l, FromContext(WithLocalizer(context.Background(), l)))
+}
+
+func TestCanonical(t *testing.T) {
+	t.Parallel()
+	for locale, expected := range map[string]string{
+		"en":         "en",
+		"EN":         "en",
+		"en_us":      "en-US",
+		" el-gr ":    "el-GR",
+		"zh-hant-tw": "zh-Hant-TW",
+		"es-419":     "es-419",
+	} {
+		assert.Equal(t, expected, canonical(locale))
+	}
+}
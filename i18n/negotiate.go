@@ -0,0 +1,54 @@
+package i18n
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Negotiate returns a localizer for the locale of the catalog best matching the Accept-Language header of a request,
+// e.g. "el-GR, el;q=0.9, en;q=0.8", or for the default locale if none matches.
+func (c *Catalog) Negotiate(acceptLanguage string) *Localizer {
+	return c.Localizer(ParseAcceptLanguage(acceptLanguage)...)
+}
+
+type weightedLocale struct {
+	locale string
+	weight float64
+}
+
+// ParseAcceptLanguage returns the locales of an Accept-Language header by descending weight, omitting the wildcard
+// and the unacceptable ones, i.e. the ones with a zero weight. Locales with an invalid weight are considered fully acceptable.
+func ParseAcceptLanguage(header string) []string {
+	var weighted []weightedLocale
+	for _, entry := range strings.Split(header, ",") {
+		parts := strings.Split(entry, ";")
+		locale := strings.TrimSpace(parts[0])
+		if locale == "" || locale == "*" {
+			continue
+		}
+		weight := 1.0
+		for _, param := range parts[1:] {
+			param = strings.TrimSpace(param)
+			if !strings.HasPrefix(param, "q=") {
+				continue
+			}
+			if q, err := strconv.ParseFloat(strings.TrimPrefix(param, "q="), 64); err == nil {
+				weight = q
+			}
+		}
+		if weight <= 0 {
+			continue
+		}
+		weighted = append(weighted, weightedLocale{locale: locale, weight: weight})
+	}
+
+	sort.SliceStable(weighted, func(i, j int) bool {
+		return weighted[i].weight > weighted[j].weight
+	})
+	locales := make([]string, 0, len(weighted))
+	for _, wl := range weighted {
+		locales = append(locales, wl.locale)
+	}
+	return locales
+}
@@ -0,0 +1,39 @@
+package i18n
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAcceptLanguage(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		header   string
+		expected []string
+	}{
+		"empty":        {header: "", expected: []string{}},
+		"single":       {header: "el", expected: []string{"el"}},
+		"weighted":     {header: "en;q=0.5, el-GR, el;q=0.9", expected: []string{"el-GR", "el", "en"}},
+		"equal weight": {header: "fr, de", expected: []string{"fr", "de"}},
+		"wildcard":     {header: "el, *;q=0.5", expected: []string{"el"}},
+		"unacceptable": {header: "el;q=0, en", expected: []string{"en"}},
+		"invalid q":    {header: "el;q=high, en;q=0.5", expected: []string{"el", "en"}},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.expected, ParseAcceptLanguage(tt.header))
+		})
+	}
+}
+
+func TestCatalog_Negotiate(t *testing.T) {
+	t.Parallel()
+	c := testCatalog(t)
+	assert.Equal(t, "el", c.Negotiate("de-DE, el-GR;q=0.8, en;q=0.5").Locale())
+	assert.Equal(t, "en-GB", c.Negotiate("en-GB, en;q=0.9").Locale())
+	assert.Equal(t, "en", c.Negotiate("de, *").Locale())
+	assert.Equal(t, "en", c.Negotiate("").Locale())
+}
@@ -0,0 +1,114 @@
+package i18n
+
+// Plural forms of the Unicode CLDR plural rules.
+const (
+	pluralZero  = "zero"
+	pluralOne   = "one"
+	pluralTwo   = "two"
+	pluralFew   = "few"
+	pluralMany  = "many"
+	pluralOther = "other"
+)
+
+func validPluralForm(form string) bool {
+	switch form {
+	case pluralZero, pluralOne, pluralTwo, pluralFew, pluralMany, pluralOther:
+		return true
+	default:
+		return false
+	}
+}
+
+// pluralRule selects the plural form of a non-negative count.
+type pluralRule func(n int) string
+
+// pluralRules maps languages to the CLDR plural rules of their cardinal integers.
+// Languages missing from it use the rule of English, i.e. one for 1 and other otherwise.
+var pluralRules = map[string]pluralRule{
+	// languages without plural forms
+	"ja": otherRule, "ko": otherRule, "zh": otherRule, "vi": otherRule, "th": otherRule, "id": otherRule, "tr": otherRule,
+	// languages treating 0 as singular
+	"fr": zeroOneRule, "pt": zeroOneRule, "hi": zeroOneRule,
+	// east slavic languages
+	"ru": eastSlavicRule, "uk": eastSlavicRule, "be": eastSlavicRule,
+	"pl": polishRule,
+	"cs": westSlavicRule, "sk": westSlavicRule,
+	"ar": arabicRule,
+}
+
+func pluralForm(locale string, n int) string {
+	if n < 0 {
+		n = -n
+	}
+	if rule, ok := pluralRules[language(locale)]; ok {
+		return rule(n)
+	}
+	if n == 1 {
+		return pluralOne
+	}
+	return pluralOther
+}
+
+func otherRule(int) string {
+	return pluralOther
+}
+
+func zeroOneRule(n int) string {
+	if n <= 1 {
+		return pluralOne
+	}
+	return pluralOther
+}
+
+func eastSlavicRule(n int) string {
+	mod10, mod100 := n%10, n%100
+	switch {
+	case mod10 == 1 && mod100 != 11:
+		return pluralOne
+	case mod10 >= 2 && mod10 <= 4 && (mod100 < 12 || mod100 > 14):
+		return pluralFew
+	default:
+		return pluralMany
+	}
+}
+
+func polishRule(n int) string {
+	mod10, mod100 := n%10, n%100
+	switch {
+	case n == 1:
+		return pluralOne
+	case mod10 >= 2 && mod10 <= 4 && (mod100 < 12 || mod100 > 14):
+		return pluralFew
+	default:
+		return pluralMany
+	}
+}
+
+func westSlavicRule(n int) string {
+	switch {
+	case n == 1:
+		return pluralOne
+	case n >= 2 && n <= 4:
+		return pluralFew
+	default:
+		return pluralOther
+	}
+}
+
+func arabicRule(n int) string {
+	mod100 := n % 100
+	switch {
+	case n == 0:
+		return pluralZero
+	case n == 1:
+		return pluralOne
+	case n == 2:
+		return pluralTwo
+	case mod100 >= 3 && mod100 <= 10:
+		return pluralFew
+	case mod100 >= 11:
+		return pluralMany
+	default:
+		return pluralOther
+	}
+}
@@ -0,0 +1,36 @@
+package i18n
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPluralForm(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		locale   string
+		expected map[int]string
+	}{
+		"english":  {locale: "en-US", expected: map[int]string{0: "other", 1: "one", 2: "other", -1: "one"}},
+		"default":  {locale: "de", expected: map[int]string{0: "other", 1: "one", 21: "other"}},
+		"japanese": {locale: "ja", expected: map[int]string{0: "other", 1: "other", 2: "other"}},
+		"french":   {locale: "fr-CA", expected: map[int]string{0: "one", 1: "one", 2: "other"}},
+		"russian": {
+			locale:   "ru",
+			expected: map[int]string{1: "one", 2: "few", 4: "few", 5: "many", 11: "many", 12: "many", 21: "one", 22: "few", 111: "many"},
+		},
+		"polish": {locale: "pl", expected: map[int]string{1: "one", 2: "few", 5: "many", 12: "many", 21: "many", 22: "few"}},
+		"czech":  {locale: "cs", expected: map[int]string{1: "one", 3: "few", 5: "other"}},
+		"arabic": {locale: "ar", expected: map[int]string{0: "zero", 1: "one", 2: "two", 3: "few", 11: "many", 100: "other", 103: "few"}},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			for n, expected := range tt.expected {
+				assert.Equal(t, expected, pluralForm(tt.locale, n), "count %d", n)
+			}
+		})
+	}
+}
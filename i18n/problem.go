@@ -0,0 +1,53 @@
+package i18n
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// ProblemContentType is the content type of problem details responses.
+const ProblemContentType = "application/problem+json"
+
+// Problem is a problem details response of RFC 7807, describing an error to the client.
+type Problem struct {
+	Type     string `json:"type,omitempty"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+}
+
+// Problem creates a problem of the status, with its detail rendered from the message of the provided ID with the data, if any.
+// Its title is rendered from the message problem.<status> e.g. problem.404, if the catalog has it, or the status text otherwise.
+func (l *Localizer) Problem(status int, detailID string, data interface{}) (Problem, error) {
+	p := Problem{Status: status, Title: http.StatusText(status)}
+	titleID := fmt.Sprintf("problem.%d", status)
+	if _, err := l.message(titleID); err == nil {
+		title, err := l.Message(titleID, data)
+		if err != nil {
+			return Problem{}, err
+		}
+		p.Title = title
+	}
+
+	if detailID != "" {
+		detail, err := l.Message(detailID, data)
+		if err != nil {
+			return Problem{}, err
+		}
+		p.Detail = detail
+	}
+	return p, nil
+}
+
+// WriteProblem writes the problem to the response, with its status and the locale of the localizer as its language.
+func (l *Localizer) WriteProblem(w http.ResponseWriter, p Problem) error {
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.Header().Set("Content-Language", l.locale)
+	w.WriteHeader(p.Status)
+	if err := json.NewEncoder(w).Encode(p); err != nil {
+		return fmt.Errorf("failed to write problem: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package i18n
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalizer_Problem(t *testing.T) {
+	t.Parallel()
+	c := testCatalog(t)
+	tests := map[string]struct {
+		locale      string
+		status      int
+		detailID    string
+		expected    Problem
+		expectedErr string
+	}{
+		"localized title": {
+			locale: "el", status: http.StatusNotFound, detailID: "greeting",
+			expected: Problem{Status: http.StatusNotFound, Title: "Not found", Detail: "Γεια σου Maria!"},
+		},
+		"status text title": {
+			locale: "en", status: http.StatusConflict,
+			expected: Problem{Status: http.StatusConflict, Title: "Conflict"},
+		},
+		"missing detail": {locale: "en", status: http.StatusConflict, detailID: "unknown", expectedErr: "message unknown not found"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			got, err := c.Localizer(tt.locale).Problem(tt.status, tt.detailID, map[string]string{"Name": "Maria"})
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestLocalizer_WriteProblem(t *testing.T) {
+	t.Parallel()
+	l := testCatalog(t).Localizer("el")
+	p, err := l.Problem(http.StatusNotFound, "", nil)
+	require.NoError(t, err)
+	p.Type = "https://example.com/problems/not-found"
+
+	rec := httptest.NewRecorder()
+	require.NoError(t, l.WriteProblem(rec, p))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, ProblemContentType, rec.Header().Get("Content-Type"))
+	assert.Equal(t, "el", rec.Header().Get("Content-Language"))
+	assert.JSONEq(t, `{"type": "https://example.com/problems/not-found", "title": "Not found", "status": 404}`, rec.Body.String())
+}
@@ -0,0 +1,24 @@
+// Package awsretry records the attempts of the AWS SDK requests, including the retries of the SDK, with the shared
+// retry observability of the reliability/retry package.
+package awsretry
+
+import (
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/beatlabs/patron/reliability/retry"
+	"github.com/opentracing/opentracing-go"
+)
+
+// Observe returns a request option, which records every attempt of the request on the span and the shared retry metric
+// of the client, and tags the span with the total number of attempts once the request completes.
+func Observe(sp opentracing.Span, client string) request.Option {
+	return func(r *request.Request) {
+		attempt := 0
+		r.Handlers.CompleteAttempt.PushBack(func(r *request.Request) {
+			attempt++
+			retry.ObserveAttempt(sp, client, attempt, r.Error)
+		})
+		r.Handlers.Complete.PushBack(func(*request.Request) {
+			retry.ObserveAttempts(sp, attempt)
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package awsretry
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/beatlabs/patron/reliability/retry"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestObserve(t *testing.T) {
+	errAttempt := errors.New("attempt failed")
+	testCases := map[string]struct {
+		errs []error
+	}{
+		"no attempt":             {},
+		"instant success":        {errs: []error{nil}},
+		"success after an error": {errs: []error{errAttempt, nil}},
+		"failure":                {errs: []error{errAttempt, errAttempt, errAttempt}},
+	}
+	for name, tt := range testCases {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			sp := mocktracer.New().StartSpan("test").(*mocktracer.MockSpan)
+			r := &request.Request{}
+			r.ApplyOptions(Observe(sp, "test-client"))
+
+			for _, err := range tt.errs {
+				r.Error = err
+				r.Handlers.CompleteAttempt.Run(r)
+			}
+			r.Handlers.Complete.Run(r)
+
+			assert.Equal(t, len(tt.errs), sp.Tag(retry.AttemptsTag))
+			logs := sp.Logs()
+			require.Len(t, logs, len(tt.errs))
+			for i, l := range logs {
+				assert.Equal(t, retry.AttemptEvent, l.Fields[0].ValueString)
+				assert.Equal(t, strconv.Itoa(i+1), l.Fields[1].ValueString)
+				if tt.errs[i] != nil {
+					require.Len(t, l.Fields, 3)
+					assert.Equal(t, errAttempt.Error(), l.Fields[2].ValueString)
+				} else {
+					assert.Len(t, l.Fields, 2)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+// Package cgroup provides reading the CPU and memory limits of the container the process runs in.
+package cgroup
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Root is the mount point of the cgroup filesystem. In containers with a private cgroup namespace
+// the cgroup of the process is mounted at the root.
+const Root = "/sys/fs/cgroup"
+
+// unlimitedV1 is the threshold above which cgroup v1 memory limits are considered unset,
+// since v1 reports the maximum page aligned int64 instead.
+const unlimitedV1 = math.MaxInt64 / 2
+
+// Limits of the cgroup. Zero values mean that the resource is not limited.
+type Limits struct {
+	// CPU is the CPU quota in cores, e.g. 1.5 for a quota of 150ms every 100ms.
+	CPU float64
+	// Memory is the memory limit in bytes.
+	Memory int64
+}
+
+// Read returns the limits of the cgroup mounted at the provided root, supporting both cgroup v2 and v1.
+// If the root does not exist, e.g. outside Linux, no limits and no error are returned.
+func Read(root string) (Limits, error) {
+	if _, err := os.Stat(root); errors.Is(err, os.ErrNotExist) {
+		return Limits{}, nil
+	}
+
+	if _, err := os.Stat(filepath.Join(root, "cgroup.controllers")); err == nil {
+		return readV2(root)
+	}
+	return readV1(root)
+}
+
+func readV2(root string) (Limits, error) {
+	var limits Limits
+
+	cpu, err := readFile(filepath.Join(root, "cpu.max"))
+	if err != nil {
+		return Limits{}, err
+	}
+	if cpu != "" {
+		ff := strings.Fields(cpu)
+		if len(ff) != 2 {
+			return Limits{}, fmt.Errorf("invalid cpu.max value: %s", cpu)
+		}
+		if ff[0] != "max" {
+			limits.CPU, err = quota(ff[0], ff[1])
+			if err != nil {
+				return Limits{}, err
+			}
+		}
+	}
+
+	mem, err := readFile(filepath.Join(root, "memory.max"))
+	if err != nil {
+		return Limits{}, err
+	}
+	if mem != "" && mem != "max" {
+		limits.Memory, err = strconv.ParseInt(mem, 10, 64)
+		if err != nil {
+			return Limits{}, fmt.Errorf("invalid memory.max value: %w", err)
+		}
+	}
+
+	return limits, nil
+}
+
+func readV1(root string) (Limits, error) {
+	var limits Limits
+
+	cfsQuota, err := readFile(filepath.Join(root, "cpu", "cpu.cfs_quota_us"))
+	if err != nil {
+		return Limits{}, err
+	}
+	cfsPeriod, err := readFile(filepath.Join(root, "cpu", "cpu.cfs_period_us"))
+	if err != nil {
+		return Limits{}, err
+	}
+	if cfsQuota != "" && cfsQuota != "-1" && cfsPeriod != "" {
+		limits.CPU, err = quota(cfsQuota, cfsPeriod)
+		if err != nil {
+			return Limits{}, err
+		}
+	}
+
+	mem, err := readFile(filepath.Join(root, "memory", "memory.limit_in_bytes"))
+	if err != nil {
+		return Limits{}, err
+	}
+	if mem != "" {
+		limit, err := strconv.ParseInt(mem, 10, 64)
+		if err != nil {
+			return Limits{}, fmt.Errorf("invalid memory.limit_in_bytes value: %w", err)
+		}
+		if limit < unlimitedV1 {
+			limits.Memory = limit
+		}
+	}
+
+	return limits, nil
+}
+
+func quota(q, p string) (float64, error) {
+	qv, err := strconv.ParseFloat(q, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid cpu quota: %w", err)
+	}
+	pv, err := strconv.ParseFloat(p, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid cpu period: %w", err)
+	}
+	if qv <= 0 || pv <= 0 {
+		return 0, nil
+	}
+	return qv / pv, nil
+}
+
+// readFile returns the trimmed content of the file, or an empty string if it does not exist.
+func readFile(path string) (string, error) {
+	b, err := ioutil.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(b)), nil
+}
@@ -0,0 +1,81 @@
+package cgroup
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRead(t *testing.T) {
+	tests := map[string]struct {
+		files       map[string]string
+		want        Limits
+		expectedErr string
+	}{
+		"v2 limited": {
+			files: map[string]string{"cgroup.controllers": "cpu memory", "cpu.max": "150000 100000", "memory.max": "536870912"},
+			want:  Limits{CPU: 1.5, Memory: 536870912},
+		},
+		"v2 unlimited": {
+			files: map[string]string{"cgroup.controllers": "cpu memory", "cpu.max": "max 100000", "memory.max": "max"},
+			want:  Limits{},
+		},
+		"v2 invalid cpu": {
+			files:       map[string]string{"cgroup.controllers": "cpu memory", "cpu.max": "150000"},
+			expectedErr: "invalid cpu.max value: 150000",
+		},
+		"v2 invalid memory": {
+			files:       map[string]string{"cgroup.controllers": "cpu memory", "memory.max": "abc"},
+			expectedErr: "invalid memory.max value: strconv.ParseInt: parsing \"abc\": invalid syntax",
+		},
+		"v1 limited": {
+			files: map[string]string{
+				"cpu/cpu.cfs_quota_us":         "50000",
+				"cpu/cpu.cfs_period_us":        "100000",
+				"memory/memory.limit_in_bytes": "268435456",
+			},
+			want: Limits{CPU: 0.5, Memory: 268435456},
+		},
+		"v1 unlimited": {
+			files: map[string]string{
+				"cpu/cpu.cfs_quota_us":         "-1",
+				"cpu/cpu.cfs_period_us":        "100000",
+				"memory/memory.limit_in_bytes": "9223372036854771712",
+			},
+			want: Limits{},
+		},
+		"no controllers": {
+			files: map[string]string{},
+			want:  Limits{},
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			root := t.TempDir()
+			for name, content := range tt.files {
+				path := filepath.Join(root, name)
+				require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+				require.NoError(t, ioutil.WriteFile(path, []byte(content+"\n"), 0o600))
+			}
+
+			got, err := Read(root)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestRead_MissingRoot(t *testing.T) {
+	got, err := Read(filepath.Join(t.TempDir(), "missing"))
+	require.NoError(t, err)
+	assert.Equal(t, Limits{}, got)
+}
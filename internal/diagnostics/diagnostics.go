@@ -0,0 +1,146 @@
+// Package diagnostics provides a report of the environment the service runs in,
+// which helps debugging issues caused by environment mismatches, e.g. wrong limits or configuration.
+package diagnostics
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/beatlabs/patron/internal/cgroup"
+	"github.com/beatlabs/patron/log"
+)
+
+const envPrefix = "PATRON_"
+
+var (
+	mu      sync.RWMutex
+	current *Report
+)
+
+// Report of the environment of the service.
+type Report struct {
+	Service    string    `json:"service"`
+	Version    string    `json:"version"`
+	StartedAt  time.Time `json:"started_at"`
+	GoVersion  string    `json:"go_version"`
+	OS         string    `json:"os"`
+	Arch       string    `json:"arch"`
+	NumCPU     int       `json:"num_cpu"`
+	GOMAXPROCS int       `json:"gomaxprocs"`
+	// CgroupCPU is the CPU quota of the container in cores, zero if there is no limit.
+	CgroupCPU float64 `json:"cgroup_cpu_limit"`
+	// CgroupMemory is the memory limit of the container in bytes, zero if there is no limit.
+	CgroupMemory int64 `json:"cgroup_memory_limit"`
+	// Components contains the types of the components of the service.
+	Components []string `json:"components"`
+	// ConfigSources contains the names of the environment variables configuring patron, without their values,
+	// which may contain secrets.
+	ConfigSources []string `json:"config_sources"`
+	Module        string   `json:"module,omitempty"`
+	ModuleVersion string   `json:"module_version,omitempty"`
+}
+
+// Collect gathers the report of the environment for the provided service and components.
+// Failing to read the cgroup limits is logged, since the rest of the report is still useful.
+func Collect(service, version string, components ...interface{}) Report {
+	r := Report{
+		Service:       service,
+		Version:       version,
+		StartedAt:     time.Now().UTC(),
+		GoVersion:     runtime.Version(),
+		OS:            runtime.GOOS,
+		Arch:          runtime.GOARCH,
+		NumCPU:        runtime.NumCPU(),
+		GOMAXPROCS:    runtime.GOMAXPROCS(0),
+		Components:    make([]string, 0, len(components)),
+		ConfigSources: configSources(os.Environ()),
+	}
+
+	limits, err := cgroup.Read(cgroup.Root)
+	if err != nil {
+		log.Warnf("could not read cgroup limits: %v", err)
+	}
+	r.CgroupCPU = limits.CPU
+	r.CgroupMemory = limits.Memory
+
+	for _, cp := range components {
+		r.Components = append(r.Components, fmt.Sprintf("%T", cp))
+	}
+
+	if info, ok := debug.ReadBuildInfo(); ok {
+		r.Module = info.Main.Path
+		r.ModuleVersion = info.Main.Version
+	}
+
+	return r
+}
+
+// configSources returns the sorted names of the patron environment variables.
+func configSources(env []string) []string {
+	sources := make([]string, 0)
+	for _, kv := range env {
+		if !strings.HasPrefix(kv, envPrefix) {
+			continue
+		}
+		sources = append(sources, strings.SplitN(kv, "=", 2)[0])
+	}
+	sort.Strings(sources)
+	return sources
+}
+
+// Fields returns the report as log fields.
+func (r Report) Fields() map[string]interface{} {
+	return map[string]interface{}{
+		"service":             r.Service,
+		"version":             r.Version,
+		"go_version":          r.GoVersion,
+		"os":                  r.OS,
+		"arch":                r.Arch,
+		"num_cpu":             r.NumCPU,
+		"gomaxprocs":          r.GOMAXPROCS,
+		"cgroup_cpu_limit":    r.CgroupCPU,
+		"cgroup_memory_limit": r.CgroupMemory,
+		"components":          strings.Join(r.Components, ","),
+		"config_sources":      strings.Join(r.ConfigSources, ","),
+		"module":              r.Module,
+		"module_version":      r.ModuleVersion,
+	}
+}
+
+// Set stores the report of the running service, which is served by Handler.
+func Set(r Report) {
+	mu.Lock()
+	defer mu.Unlock()
+	current = &r
+}
+
+// Get returns the report of the running service, if it has been set.
+func Get() (Report, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	if current == nil {
+		return Report{}, false
+	}
+	return *current, true
+}
+
+// Handler responds with the report of the running service as JSON.
+func Handler(w http.ResponseWriter, r *http.Request) {
+	report, ok := Get()
+	if !ok {
+		http.Error(w, "diagnostics report has not been collected", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		log.FromContext(r.Context()).Errorf("could not write diagnostics report: %v", err)
+	}
+}
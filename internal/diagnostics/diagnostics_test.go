@@ -0,0 +1,65 @@
+package diagnostics
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testComponent struct{}
+
+func TestCollect(t *testing.T) {
+	require.NoError(t, os.Setenv("PATRON_DIAGNOSTICS_TEST", "secret"))
+	t.Cleanup(func() {
+		require.NoError(t, os.Unsetenv("PATRON_DIAGNOSTICS_TEST"))
+	})
+
+	r := Collect("test", "1.0.0", &testComponent{})
+	assert.Equal(t, "test", r.Service)
+	assert.Equal(t, "1.0.0", r.Version)
+	assert.Equal(t, runtime.Version(), r.GoVersion)
+	assert.Equal(t, runtime.GOMAXPROCS(0), r.GOMAXPROCS)
+	assert.Equal(t, []string{"*diagnostics.testComponent"}, r.Components)
+	assert.Contains(t, r.ConfigSources, "PATRON_DIAGNOSTICS_TEST")
+	assert.False(t, r.StartedAt.IsZero())
+
+	ff := r.Fields()
+	assert.Equal(t, "test", ff["service"])
+	assert.Equal(t, "*diagnostics.testComponent", ff["components"])
+}
+
+func Test_configSources(t *testing.T) {
+	got := configSources([]string{"PATRON_B=1", "HOME=/root", "PATRON_A=x=y", "PATRON_EMPTY="})
+	assert.Equal(t, []string{"PATRON_A", "PATRON_B", "PATRON_EMPTY"}, got)
+}
+
+func TestHandler(t *testing.T) {
+	t.Cleanup(func() {
+		mu.Lock()
+		current = nil
+		mu.Unlock()
+	})
+
+	rsp := httptest.NewRecorder()
+	Handler(rsp, httptest.NewRequest(http.MethodGet, "/debug/diagnostics", nil))
+	assert.Equal(t, http.StatusNotFound, rsp.Code)
+
+	Set(Report{Service: "test", Version: "1.0.0", GOMAXPROCS: 2, Components: []string{"*http.Component"}})
+
+	rsp = httptest.NewRecorder()
+	Handler(rsp, httptest.NewRequest(http.MethodGet, "/debug/diagnostics", nil))
+	assert.Equal(t, http.StatusOK, rsp.Code)
+	assert.Equal(t, "application/json", rsp.Header().Get("Content-Type"))
+
+	var got Report
+	require.NoError(t, json.Unmarshal(rsp.Body.Bytes(), &got))
+	assert.Equal(t, "test", got.Service)
+	assert.Equal(t, 2, got.GOMAXPROCS)
+	assert.Equal(t, []string{"*http.Component"}, got.Components)
+}
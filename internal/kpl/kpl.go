@@ -0,0 +1,206 @@
+// Package kpl implements the aggregated record format of the Kinesis Producer Library (KPL), which packs many user records
+// into a single Kinesis record. An aggregated record consists of a magic number, an AggregatedRecord protobuf message
+// and the MD5 digest of the message.
+package kpl
+
+import (
+	"bytes"
+	"crypto/md5" // nolint:gosec
+	"errors"
+	"fmt"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Magic number prefixing aggregated records.
+var Magic = []byte{0xF3, 0x89, 0x9A, 0xC2}
+
+const (
+	// field numbers of the AggregatedRecord message.
+	fieldPartitionKeyTable    protowire.Number = 1
+	fieldExplicitHashKeyTable protowire.Number = 2
+	fieldRecords              protowire.Number = 3
+	// field numbers of the Record message.
+	fieldPartitionKeyIndex    protowire.Number = 1
+	fieldExplicitHashKeyIndex protowire.Number = 2
+	fieldData                 protowire.Number = 3
+	fieldTags                 protowire.Number = 4
+	// field numbers of the Tag message.
+	fieldTagKey   protowire.Number = 1
+	fieldTagValue protowire.Number = 2
+)
+
+// Record is a user record of an aggregated record.
+type Record struct {
+	PartitionKey    string
+	ExplicitHashKey string
+	Data            []byte
+	Tags            map[string]string
+}
+
+// Marshal aggregates the records. The partition and explicit hash keys are written once to the tables of the aggregated record.
+func Marshal(rr []Record) []byte {
+	var msg, records []byte
+	partitionKeys := make(map[string]uint64)
+	hashKeys := make(map[string]uint64)
+
+	for _, r := range rr {
+		idx, ok := partitionKeys[r.PartitionKey]
+		if !ok {
+			idx = uint64(len(partitionKeys))
+			partitionKeys[r.PartitionKey] = idx
+			msg = protowire.AppendTag(msg, fieldPartitionKeyTable, protowire.BytesType)
+			msg = protowire.AppendString(msg, r.PartitionKey)
+		}
+
+		var rec []byte
+		rec = protowire.AppendTag(rec, fieldPartitionKeyIndex, protowire.VarintType)
+		rec = protowire.AppendVarint(rec, idx)
+		if r.ExplicitHashKey != "" {
+			hashIdx, ok := hashKeys[r.ExplicitHashKey]
+			if !ok {
+				hashIdx = uint64(len(hashKeys))
+				hashKeys[r.ExplicitHashKey] = hashIdx
+				msg = protowire.AppendTag(msg, fieldExplicitHashKeyTable, protowire.BytesType)
+				msg = protowire.AppendString(msg, r.ExplicitHashKey)
+			}
+			rec = protowire.AppendTag(rec, fieldExplicitHashKeyIndex, protowire.VarintType)
+			rec = protowire.AppendVarint(rec, hashIdx)
+		}
+		rec = protowire.AppendTag(rec, fieldData, protowire.BytesType)
+		rec = protowire.AppendBytes(rec, r.Data)
+		for k, v := range r.Tags {
+			var tag []byte
+			tag = protowire.AppendTag(tag, fieldTagKey, protowire.BytesType)
+			tag = protowire.AppendString(tag, k)
+			tag = protowire.AppendTag(tag, fieldTagValue, protowire.BytesType)
+			tag = protowire.AppendString(tag, v)
+			rec = protowire.AppendTag(rec, fieldTags, protowire.BytesType)
+			rec = protowire.AppendBytes(rec, tag)
+		}
+
+		records = protowire.AppendTag(records, fieldRecords, protowire.BytesType)
+		records = protowire.AppendBytes(records, rec)
+	}
+	msg = append(msg, records...)
+
+	sum := md5.Sum(msg) // nolint:gosec
+	data := make([]byte, 0, len(Magic)+len(msg)+len(sum))
+	data = append(data, Magic...)
+	data = append(data, msg...)
+	return append(data, sum[:]...)
+}
+
+// IsAggregated returns whether the data are an aggregated record, i.e. they start with the magic number and end with a valid digest.
+func IsAggregated(data []byte) bool {
+	if len(data) < len(Magic)+md5.Size || !bytes.HasPrefix(data, Magic) {
+		return false
+	}
+	msg := data[len(Magic) : len(data)-md5.Size]
+	sum := md5.Sum(msg) // nolint:gosec
+	return bytes.Equal(sum[:], data[len(data)-md5.Size:])
+}
+
+// Unmarshal de-aggregates the records of an aggregated record.
+func Unmarshal(data []byte) ([]Record, error) {
+	if !IsAggregated(data) {
+		return nil, errors.New("data are not an aggregated record")
+	}
+	msg := data[len(Magic) : len(data)-md5.Size]
+
+	var partitionKeys, hashKeys []string
+	var rawRecords [][]byte
+	err := fields(msg, func(num protowire.Number, v []byte, _ uint64) error {
+		switch num {
+		case fieldPartitionKeyTable:
+			partitionKeys = append(partitionKeys, string(v))
+		case fieldExplicitHashKeyTable:
+			hashKeys = append(hashKeys, string(v))
+		case fieldRecords:
+			rawRecords = append(rawRecords, v)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rr := make([]Record, 0, len(rawRecords))
+	for _, raw := range rawRecords {
+		var r Record
+		err := fields(raw, func(num protowire.Number, v []byte, n uint64) error {
+			switch num {
+			case fieldPartitionKeyIndex:
+				if n >= uint64(len(partitionKeys)) {
+					return fmt.Errorf("partition key index %d is out of range", n)
+				}
+				r.PartitionKey = partitionKeys[n]
+			case fieldExplicitHashKeyIndex:
+				if n >= uint64(len(hashKeys)) {
+					return fmt.Errorf("explicit hash key index %d is out of range", n)
+				}
+				r.ExplicitHashKey = hashKeys[n]
+			case fieldData:
+				r.Data = v
+			case fieldTags:
+				var key, value string
+				if err := fields(v, func(num protowire.Number, v []byte, _ uint64) error {
+					switch num {
+					case fieldTagKey:
+						key = string(v)
+					case fieldTagValue:
+						value = string(v)
+					}
+					return nil
+				}); err != nil {
+					return err
+				}
+				if r.Tags == nil {
+					r.Tags = make(map[string]string)
+				}
+				r.Tags[key] = value
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		rr = append(rr, r)
+	}
+	return rr, nil
+}
+
+// fields iterates over the fields of a protobuf message, passing the value of length-delimited fields and varints to the function.
+// Fields of other types are skipped.
+func fields(msg []byte, fn func(num protowire.Number, v []byte, n uint64) error) error {
+	for len(msg) > 0 {
+		num, typ, n := protowire.ConsumeTag(msg)
+		if n < 0 {
+			return fmt.Errorf("invalid aggregated record: %w", protowire.ParseError(n))
+		}
+		msg = msg[n:]
+
+		var v []byte
+		var varint uint64
+		switch typ {
+		case protowire.BytesType:
+			v, n = protowire.ConsumeBytes(msg)
+		case protowire.VarintType:
+			varint, n = protowire.ConsumeVarint(msg)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, msg)
+		}
+		if n < 0 {
+			return fmt.Errorf("invalid aggregated record: %w", protowire.ParseError(n))
+		}
+		msg = msg[n:]
+
+		if typ != protowire.BytesType && typ != protowire.VarintType {
+			continue
+		}
+		if err := fn(num, v, varint); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package kpl
+
+import (
+	"crypto/md5" // nolint:gosec
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarshalUnmarshal(t *testing.T) {
+	t.Parallel()
+	rr := []Record{
+		{PartitionKey: "key-1", Data: []byte("data 1"), Tags: map[string]string{"X-Correlation-Id": "123", "empty": ""}},
+		{PartitionKey: "key-2", ExplicitHashKey: "42", Data: []byte("data 2")},
+		{PartitionKey: "key-1", ExplicitHashKey: "42", Data: []byte{}},
+	}
+
+	data := Marshal(rr)
+	assert.Equal(t, Magic, data[:4])
+	assert.True(t, IsAggregated(data))
+
+	got, err := Unmarshal(data)
+	require.NoError(t, err)
+	require.Len(t, got, 3)
+	assert.Equal(t, rr[0], got[0])
+	assert.Equal(t, rr[1], got[1])
+	assert.Equal(t, "key-1", got[2].PartitionKey)
+	assert.Equal(t, "42", got[2].ExplicitHashKey)
+	assert.Empty(t, got[2].Data)
+}
+
+func TestUnmarshal_Invalid(t *testing.T) {
+	t.Parallel()
+	valid := Marshal([]Record{{PartitionKey: "key", Data: []byte("data")}})
+	corrupted := append([]byte{}, valid...)
+	corrupted[5]++
+
+	outOfRange := append(append([]byte{}, Magic...), 0x1a, 0x02, 0x08, 0x01)
+	sum := md5.Sum(outOfRange[4:]) // nolint:gosec
+	outOfRange = append(outOfRange, sum[:]...)
+
+	truncated := append(append([]byte{}, Magic...), 0x0a, 0x05, 'k')
+	sum = md5.Sum(truncated[4:]) // nolint:gosec
+	truncated = append(truncated, sum[:]...)
+
+	tests := map[string]struct {
+		data        []byte
+		expectedErr string
+	}{
+		"plain data":      {data: []byte("data"), expectedErr: "data are not an aggregated record"},
+		"invalid digest":  {data: corrupted, expectedErr: "data are not an aggregated record"},
+		"index out range": {data: outOfRange, expectedErr: "partition key index 1 is out of range"},
+		"truncated":       {data: truncated, expectedErr: "invalid aggregated record: unexpected EOF"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			got, err := Unmarshal(tt.data)
+			assert.EqualError(t, err, tt.expectedErr)
+			assert.Nil(t, got)
+		})
+	}
+}
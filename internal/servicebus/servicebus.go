@@ -0,0 +1,178 @@
+// Package servicebus provides access to the REST API of Azure Service Bus, authenticated with shared access signatures.
+package servicebus
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// BrokerPropertiesHeader carries the broker properties of a message.
+	BrokerPropertiesHeader = "BrokerProperties"
+
+	tokenValidity = time.Hour
+)
+
+// BrokerProperties of a message, as defined by the REST API of Service Bus.
+type BrokerProperties struct {
+	MessageID               string  `json:"MessageId,omitempty"`
+	CorrelationID           string  `json:"CorrelationId,omitempty"`
+	SessionID               string  `json:"SessionId,omitempty"`
+	PartitionKey            string  `json:"PartitionKey,omitempty"`
+	Label                   string  `json:"Label,omitempty"`
+	ReplyTo                 string  `json:"ReplyTo,omitempty"`
+	To                      string  `json:"To,omitempty"`
+	TimeToLive              float64 `json:"TimeToLive,omitempty"`
+	ScheduledEnqueueTimeUtc string  `json:"ScheduledEnqueueTimeUtc,omitempty"`
+	// The following properties are set by the broker.
+	LockToken        string `json:"LockToken,omitempty"`
+	LockedUntilUtc   string `json:"LockedUntilUtc,omitempty"`
+	SequenceNumber   int64  `json:"SequenceNumber,omitempty"`
+	DeliveryCount    int    `json:"DeliveryCount,omitempty"`
+	EnqueuedTimeUtc  string `json:"EnqueuedTimeUtc,omitempty"`
+	DeadLetterSource string `json:"DeadLetterSource,omitempty"`
+}
+
+// Namespace of Service Bus, along with the shared access key used to sign requests.
+type Namespace struct {
+	// Endpoint of the namespace, e.g. https://my-namespace.servicebus.windows.net.
+	Endpoint *url.URL
+	KeyName  string
+	Key      string
+	// EntityPath is the queue or topic of the connection string, if any.
+	EntityPath string
+	Client     *http.Client
+}
+
+// ParseConnectionString parses a connection string of a namespace, e.g.
+// "Endpoint=sb://my-namespace.servicebus.windows.net/;SharedAccessKeyName=RootManageSharedAccessKey;SharedAccessKey=...".
+// The sb scheme of the endpoint is replaced by https.
+func ParseConnectionString(s string) (*Namespace, error) {
+	if s == "" {
+		return nil, errors.New("connection string is empty")
+	}
+	ns := &Namespace{Client: http.DefaultClient}
+	var endpoint string
+	for _, part := range strings.Split(s, ";") {
+		if part == "" {
+			continue
+		}
+		i := strings.Index(part, "=")
+		if i < 0 {
+			return nil, fmt.Errorf("invalid connection string part %q", part)
+		}
+		key, value := part[:i], part[i+1:]
+		switch strings.ToLower(key) {
+		case "endpoint":
+			endpoint = value
+		case "sharedaccesskeyname":
+			ns.KeyName = value
+		case "sharedaccesskey":
+			ns.Key = value
+		case "entitypath":
+			ns.EntityPath = value
+		}
+	}
+	if endpoint == "" {
+		return nil, errors.New("connection string has no endpoint")
+	}
+	if ns.KeyName == "" || ns.Key == "" {
+		return nil, errors.New("connection string has no shared access key")
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint: %w", err)
+	}
+	if u.Scheme == "sb" {
+		u.Scheme = "https"
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/")
+	ns.Endpoint = u
+	return ns, nil
+}
+
+// URL of the path of an entity, e.g. "orders/messages/head".
+func (n *Namespace) URL(path string) string {
+	return n.Endpoint.String() + "/" + strings.TrimPrefix(path, "/")
+}
+
+// Token returns a shared access signature for the resource, which expires at the given time.
+func (n *Namespace) Token(resource string, expires time.Time) string {
+	sr := strings.ToLower(url.QueryEscape(resource))
+	se := strconv.FormatInt(expires.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(n.Key))
+	_, _ = mac.Write([]byte(sr + "\n" + se))
+	sig := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	return fmt.Sprintf("SharedAccessSignature sr=%s&sig=%s&se=%s&skn=%s", sr, url.QueryEscape(sig), se, n.KeyName)
+}
+
+// StatusError of a request, which failed with an unexpected status.
+type StatusError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *StatusError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("unexpected status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("unexpected status %d: %s", e.StatusCode, e.Message)
+}
+
+// Do sends a request to the URL, signed for the namespace, returning the response if its status is one of the expected ones.
+// The body of the response should be closed by the caller.
+func (n *Namespace) Do(ctx context.Context, method, u string, hdr http.Header, body io.Reader, expected ...int) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return nil, err
+	}
+	for k, vv := range hdr {
+		req.Header[k] = vv
+	}
+	req.Header.Set("Authorization", n.Token(n.Endpoint.String(), time.Now().Add(tokenValidity)))
+
+	rsp, err := n.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	for _, status := range expected {
+		if rsp.StatusCode == status {
+			return rsp, nil
+		}
+	}
+	defer func() {
+		_ = rsp.Body.Close()
+	}()
+	msg, _ := ioutil.ReadAll(io.LimitReader(rsp.Body, 1024))
+	return nil, &StatusError{StatusCode: rsp.StatusCode, Message: strings.TrimSpace(string(msg))}
+}
+
+// EncodeProperty encodes the value of a custom property as a header value, i.e. as a JSON string.
+func EncodeProperty(v string) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+// DecodeProperty decodes the header value of a custom property, which is quoted for strings.
+func DecodeProperty(v string) string {
+	if len(v) < 2 || v[0] != '"' || v[len(v)-1] != '"' {
+		return v
+	}
+	var s string
+	if err := json.Unmarshal([]byte(v), &s); err != nil {
+		return v
+	}
+	return s
+}
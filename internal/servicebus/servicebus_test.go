@@ -0,0 +1,98 @@
+package servicebus
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseConnectionString(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		connectionString string
+		expectedEndpoint string
+		expectedEntity   string
+		expectedErr      string
+	}{
+		"success": {
+			connectionString: "Endpoint=sb://ns.servicebus.windows.net/;SharedAccessKeyName=root;SharedAccessKey=a2V5=;EntityPath=orders",
+			expectedEndpoint: "https://ns.servicebus.windows.net",
+			expectedEntity:   "orders",
+		},
+		"http endpoint": {
+			connectionString: "Endpoint=http://localhost:8080;SharedAccessKeyName=root;SharedAccessKey=key",
+			expectedEndpoint: "http://localhost:8080",
+		},
+		"empty":          {expectedErr: "connection string is empty"},
+		"invalid part":   {connectionString: "Endpoint", expectedErr: `invalid connection string part "Endpoint"`},
+		"no endpoint":    {connectionString: "SharedAccessKeyName=root;SharedAccessKey=key", expectedErr: "connection string has no endpoint"},
+		"no key":         {connectionString: "Endpoint=sb://ns/;SharedAccessKeyName=root", expectedErr: "connection string has no shared access key"},
+		"invalid scheme": {connectionString: "Endpoint=:ns;SharedAccessKeyName=root;SharedAccessKey=key", expectedErr: `invalid endpoint: parse ":ns": missing protocol scheme`},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ns, err := ParseConnectionString(tt.connectionString)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.Nil(t, ns)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.expectedEndpoint, ns.Endpoint.String())
+				assert.Equal(t, tt.expectedEntity, ns.EntityPath)
+				assert.Equal(t, "root", ns.KeyName)
+				assert.Equal(t, tt.expectedEndpoint+"/orders/messages", ns.URL("/orders/messages"))
+			}
+		})
+	}
+}
+
+func TestNamespace_Token(t *testing.T) {
+	t.Parallel()
+	ns := &Namespace{KeyName: "root", Key: "key"}
+	token := ns.Token("https://ns.servicebus.windows.net", time.Unix(1600000000, 0))
+	assert.Equal(t, "SharedAccessSignature sr=https%3a%2f%2fns.servicebus.windows.net&sig=8tWu4KnGLJ4Sp%2F8viG6TxmFYLdxOQGvqEsVitScN3xg%3D&se=1600000000&skn=root", token)
+}
+
+func TestNamespace_Do(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"), "SharedAccessSignature sr="))
+		assert.Equal(t, "value", r.Header.Get("Custom"))
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte("entity not found\n"))
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer srv.Close()
+	ns, err := ParseConnectionString("Endpoint=" + srv.URL + ";SharedAccessKeyName=root;SharedAccessKey=key")
+	require.NoError(t, err)
+	hdr := http.Header{"Custom": []string{"value"}}
+
+	rsp, err := ns.Do(context.Background(), http.MethodPost, ns.URL("orders"), hdr, nil, http.StatusCreated)
+	require.NoError(t, err)
+	assert.NoError(t, rsp.Body.Close())
+
+	rsp, err = ns.Do(context.Background(), http.MethodPost, ns.URL("missing"), hdr, nil, http.StatusCreated)
+	assert.EqualError(t, err, "unexpected status 404: entity not found")
+	assert.Equal(t, http.StatusNotFound, err.(*StatusError).StatusCode)
+	assert.Nil(t, rsp)
+}
+
+func TestProperty(t *testing.T) {
+	t.Parallel()
+	for _, v := range []string{"", "value", `"quoted"`, "ünïcode"} {
+		assert.Equal(t, v, DecodeProperty(EncodeProperty(v)))
+	}
+	assert.Equal(t, "12", DecodeProperty("12"))
+	assert.Equal(t, `"invalid\"`, DecodeProperty(`"invalid\"`))
+}
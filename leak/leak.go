@@ -0,0 +1,231 @@
+// Package leak provides detecting goroutines leaked by the components of a service, and by tests.
+//
+// The goroutines of a component are attributed to it with a pprof label, which is inherited by every goroutine
+// started by the component, so that goroutines still running after the component has returned are reported as leaks.
+package leak
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"regexp"
+	"runtime/pprof"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/beatlabs/patron/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	componentLabel = "component"
+	defaultGrace   = 5 * time.Second
+	pollInterval   = 50 * time.Millisecond
+)
+
+var (
+	labelRegex      = regexp.MustCompile(`"` + componentLabel + `":("(?:[^"\\]|\\.)*")`)
+	goroutinesGauge *prometheus.GaugeVec
+)
+
+func init() {
+	goroutinesGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "runtime",
+			Subsystem: "leak",
+			Name:      "goroutines",
+			Help:      "Goroutines started by a component, classified by component.",
+		},
+		[]string{componentLabel},
+	)
+	prometheus.MustRegister(goroutinesGauge)
+}
+
+// Do executes fn with the provided component label, which is inherited by all goroutines started by fn.
+func Do(ctx context.Context, component string, fn func(ctx context.Context)) {
+	pprof.Do(ctx, pprof.Labels(componentLabel, component), fn)
+}
+
+// Count returns the number of running goroutines of every component.
+func Count() (map[string]int, error) {
+	var buf bytes.Buffer
+	if err := pprof.Lookup("goroutine").WriteTo(&buf, 1); err != nil {
+		return nil, err
+	}
+	return parseCounts(&buf)
+}
+
+// parseCounts parses the goroutine profile in debug format 1, where every group of identical stacks
+// starts with the number of goroutines, followed by their labels.
+func parseCounts(buf *bytes.Buffer) (map[string]int, error) {
+	counts := make(map[string]int)
+	group := 0
+	scanner := bufio.NewScanner(buf)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if idx := strings.Index(line, " @ "); idx > 0 {
+			n, err := strconv.Atoi(line[:idx])
+			if err != nil {
+				return nil, fmt.Errorf("invalid goroutine profile line %q: %w", line, err)
+			}
+			group = n
+			continue
+		}
+		if !strings.HasPrefix(line, "# labels: ") {
+			continue
+		}
+		match := labelRegex.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		component, err := strconv.Unquote(match[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid goroutine profile labels %q: %w", line, err)
+		}
+		counts[component] += group
+	}
+	return counts, scanner.Err()
+}
+
+// Detector reports the goroutines of components, which are still running after the service has shut down.
+type Detector struct {
+	grace    time.Duration
+	interval time.Duration
+}
+
+// New creates a leak detector.
+func New(oo ...OptionFunc) (*Detector, error) {
+	d := &Detector{grace: defaultGrace}
+	for _, option := range oo {
+		if err := option(d); err != nil {
+			return nil, err
+		}
+	}
+	return d, nil
+}
+
+// Monitor exposes the number of goroutines of every component as metrics periodically, until the context is done.
+// It returns immediately, if no interval has been set.
+func (d *Detector) Monitor(ctx context.Context) {
+	if d.interval == 0 {
+		return
+	}
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		counts, err := Count()
+		if err != nil {
+			log.FromContext(ctx).Warnf("could not count goroutines: %v", err)
+			continue
+		}
+		goroutinesGauge.Reset()
+		for component, n := range counts {
+			goroutinesGauge.WithLabelValues(component).Set(float64(n))
+		}
+	}
+}
+
+// Report waits up to the grace period for the goroutines of the components to exit, and logs the ones still running.
+// It should be called after all components have returned. The leaked goroutines of every component are returned.
+func (d *Detector) Report() map[string]int {
+	counts, err := wait(d.grace)
+	if err != nil {
+		log.Warnf("could not count goroutines: %v", err)
+		return nil
+	}
+	if len(counts) == 0 {
+		return counts
+	}
+	components := make([]string, 0, len(counts))
+	for component := range counts {
+		components = append(components, component)
+	}
+	sort.Strings(components)
+	for _, component := range components {
+		log.Sub(map[string]interface{}{componentLabel: component}).
+			Warnf("%d goroutines of component %s leaked after shutdown", counts[component], component)
+	}
+	return counts
+}
+
+func wait(grace time.Duration) (map[string]int, error) {
+	deadline := time.Now().Add(grace)
+	for {
+		counts, err := Count()
+		if err != nil || len(counts) == 0 || time.Now().After(deadline) {
+			return counts, err
+		}
+		time.Sleep(pollInterval)
+	}
+}
+
+// TB is the part of testing.TB used to report leaks.
+type TB interface {
+	Helper()
+	Cleanup(func())
+	Errorf(format string, args ...interface{})
+}
+
+// VerifyNone fails the test, if goroutines started during the test are still running when it completes,
+// after waiting for them to exit up to the provided timeout.
+func VerifyNone(t TB, timeout time.Duration) {
+	t.Helper()
+	before, err := goroutines()
+	if err != nil {
+		t.Errorf("could not list goroutines: %v", err)
+		return
+	}
+	t.Cleanup(func() {
+		var leaked []string
+		deadline := time.Now().Add(timeout)
+		for {
+			after, err := goroutines()
+			if err != nil {
+				t.Errorf("could not list goroutines: %v", err)
+				return
+			}
+			leaked = leaked[:0]
+			for id, stack := range after {
+				if _, ok := before[id]; !ok {
+					leaked = append(leaked, stack)
+				}
+			}
+			if len(leaked) == 0 || time.Now().After(deadline) {
+				break
+			}
+			time.Sleep(pollInterval)
+		}
+		if len(leaked) > 0 {
+			sort.Strings(leaked)
+			t.Errorf("%d goroutines leaked:\n%s", len(leaked), strings.Join(leaked, "\n\n"))
+		}
+	})
+}
+
+// goroutines returns the stacks of the running goroutines by id, excluding the calling one.
+func goroutines() (map[string]string, error) {
+	var buf bytes.Buffer
+	if err := pprof.Lookup("goroutine").WriteTo(&buf, 2); err != nil {
+		return nil, err
+	}
+	stacks := strings.Split(buf.String(), "\n\n")
+	gg := make(map[string]string, len(stacks)-1)
+	// the first stack is the one of the calling goroutine
+	for _, stack := range stacks[1:] {
+		stack = strings.TrimSpace(stack)
+		if !strings.HasPrefix(stack, "goroutine ") {
+			continue
+		}
+		id := strings.Fields(stack)[1]
+		gg[id] = stack
+	}
+	return gg, nil
+}
@@ -0,0 +1,142 @@
+package leak
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	d, err := New(Grace(time.Second), Interval(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, time.Second, d.grace)
+	assert.Equal(t, time.Minute, d.interval)
+
+	_, err = New(Grace(0))
+	assert.EqualError(t, err, "grace period should be positive")
+	_, err = New(Interval(-time.Second))
+	assert.EqualError(t, err, "interval should be positive")
+}
+
+func TestDo_Count(t *testing.T) {
+	stop := make(chan struct{})
+	started := make(chan struct{}, 2)
+	Do(context.Background(), "leaky", func(ctx context.Context) {
+		for i := 0; i < 2; i++ {
+			go func() {
+				started <- struct{}{}
+				<-stop
+			}()
+		}
+	})
+	<-started
+	<-started
+
+	counts, err := Count()
+	require.NoError(t, err)
+	assert.Equal(t, 2, counts["leaky"])
+
+	close(stop)
+	counts, err = wait(time.Second)
+	require.NoError(t, err)
+	assert.Empty(t, counts)
+}
+
+func Test_parseCounts(t *testing.T) {
+	profile := `goroutine profile: total 4
+2 @ 0x43a 0x40b
+# labels: {"component":"*http.Component"}
+#	0x43a	main.run+0x1	main.go:1
+
+1 @ 0x43a 0x40c
+# labels: {"component":"quoted \"name\"", "other":"x"}
+#	0x43a	main.run+0x1	main.go:2
+
+1 @ 0x43a 0x40d
+#	0x43a	main.main+0x1	main.go:3
+`
+	counts, err := parseCounts(bytes.NewBufferString(profile))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"*http.Component": 2, `quoted "name"`: 1}, counts)
+
+	_, err = parseCounts(bytes.NewBufferString("x @ 0x43a\n"))
+	assert.Error(t, err)
+}
+
+func TestDetector_Report(t *testing.T) {
+	d, err := New(Grace(100 * time.Millisecond))
+	require.NoError(t, err)
+
+	stop := make(chan struct{})
+	started := make(chan struct{})
+	Do(context.Background(), "reported", func(ctx context.Context) {
+		go func() {
+			close(started)
+			<-stop
+		}()
+	})
+	<-started
+
+	assert.Equal(t, map[string]int{"reported": 1}, d.Report())
+	close(stop)
+	assert.Empty(t, d.Report())
+}
+
+func TestDetector_Monitor(t *testing.T) {
+	d, err := New(Interval(time.Millisecond))
+	require.NoError(t, err)
+
+	ctx, cnl := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cnl()
+	d.Monitor(ctx)
+
+	d, err = New()
+	require.NoError(t, err)
+	// returns immediately without an interval
+	d.Monitor(context.Background())
+}
+
+type testTB struct {
+	cleanup func()
+	errors  []string
+}
+
+func (tb *testTB) Helper() {}
+
+func (tb *testTB) Cleanup(f func()) {
+	tb.cleanup = f
+}
+
+func (tb *testTB) Errorf(format string, args ...interface{}) {
+	tb.errors = append(tb.errors, fmt.Sprintf(format, args...))
+}
+
+func TestVerifyNone(t *testing.T) {
+	tb := &testTB{}
+	VerifyNone(tb, 10*time.Millisecond)
+	stop := make(chan struct{})
+	go func() {
+		<-stop
+	}()
+	tb.cleanup()
+	require.Len(t, tb.errors, 1)
+	assert.Contains(t, tb.errors[0], "1 goroutines leaked")
+	assert.Contains(t, tb.errors[0], "TestVerifyNone")
+	close(stop)
+
+	tb = &testTB{}
+	VerifyNone(tb, time.Second)
+	done := make(chan struct{})
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		close(done)
+	}()
+	tb.cleanup()
+	<-done
+	assert.Empty(t, tb.errors)
+}
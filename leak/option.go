@@ -0,0 +1,32 @@
+package leak
+
+import (
+	"errors"
+	"time"
+)
+
+// OptionFunc definition for configuring the leak detector in a functional way.
+type OptionFunc func(*Detector) error
+
+// Grace option for setting the time the goroutines of the components are given to exit after shutdown,
+// before they are reported as leaked. The default is five seconds.
+func Grace(grace time.Duration) OptionFunc {
+	return func(d *Detector) error {
+		if grace <= 0 {
+			return errors.New("grace period should be positive")
+		}
+		d.grace = grace
+		return nil
+	}
+}
+
+// Interval option for exposing the number of goroutines of every component as metrics periodically at runtime.
+func Interval(interval time.Duration) OptionFunc {
+	return func(d *Detector) error {
+		if interval <= 0 {
+			return errors.New("interval should be positive")
+		}
+		d.interval = interval
+		return nil
+	}
+}
@@ -0,0 +1,89 @@
+package patron
+
+import (
+	"context"
+	"time"
+
+	"github.com/beatlabs/patron/log"
+	"github.com/beatlabs/patron/trace"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+)
+
+const (
+	startupOpName      = "service startup"
+	shutdownOpName     = "service shutdown"
+	lifecycleComponent = "service"
+	phasesField        = "phases_ms"
+	durationField      = "duration_ms"
+)
+
+// StartupPhaseFunc is executed before the components of the service are started, e.g. for running database migrations
+// or warming up caches.
+type StartupPhaseFunc func(ctx context.Context) error
+
+type startupPhase struct {
+	name string
+	fn   StartupPhaseFunc
+}
+
+// lifecycle traces the phases of the startup or the shutdown of the service as child spans of a single span,
+// and logs their timings, so that slow startups and shutdowns can be diagnosed.
+type lifecycle struct {
+	opName  string
+	sp      opentracing.Span
+	ctx     context.Context
+	start   time.Time
+	timings map[string]interface{}
+}
+
+func newLifecycle(ctx context.Context, opName string, start time.Time) *lifecycle {
+	sp, ctx := opentracing.StartSpanFromContext(ctx, opName, opentracing.StartTime(start))
+	ext.Component.Set(sp, lifecycleComponent)
+	sp.SetTag(trace.VersionTag, trace.Version)
+	return &lifecycle{
+		opName:  opName,
+		sp:      sp,
+		ctx:     ctx,
+		start:   start,
+		timings: make(map[string]interface{}),
+	}
+}
+
+// phase executes fn as a phase of the lifecycle.
+func (l *lifecycle) phase(name string, fn func(ctx context.Context) error) error {
+	start := time.Now()
+	sp, ctx := trace.ChildSpan(l.ctx, name, lifecycleComponent)
+	err := fn(ctx)
+	trace.SpanComplete(sp, err)
+	l.timings[name] = time.Since(start).Milliseconds()
+	return err
+}
+
+// record adds a phase which has already taken place.
+func (l *lifecycle) record(name string, start, end time.Time, err error) {
+	if end.Before(start) {
+		end = start
+	}
+	sp := opentracing.StartSpan(name, opentracing.ChildOf(l.sp.Context()), opentracing.StartTime(start))
+	ext.Component.Set(sp, lifecycleComponent)
+	sp.SetTag(trace.VersionTag, trace.Version)
+	ext.Error.Set(sp, err != nil)
+	sp.FinishWithOptions(opentracing.FinishOptions{FinishTime: end})
+	l.timings[name] = end.Sub(start).Milliseconds()
+}
+
+// finish completes the lifecycle span and logs the timings of the phases.
+func (l *lifecycle) finish(err error) {
+	trace.SpanComplete(l.sp, err)
+	duration := time.Since(l.start)
+	logger := log.FromContext(l.ctx).Sub(map[string]interface{}{
+		phasesField:   l.timings,
+		durationField: duration.Milliseconds(),
+	})
+	if err != nil {
+		logger.Errorf("%s failed after %s: %v", l.opName, duration, err)
+		return
+	}
+	logger.Infof("%s completed in %s", l.opName, duration)
+}
@@ -0,0 +1,58 @@
+package patron
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLifecycle(t *testing.T) {
+	mtr := mocktracer.New()
+	opentracing.SetGlobalTracer(mtr)
+	t.Cleanup(func() {
+		opentracing.SetGlobalTracer(opentracing.NoopTracer{})
+	})
+
+	start := time.Now().Add(-time.Second)
+	l := newLifecycle(context.Background(), startupOpName, start)
+	l.record("configuration", start, start.Add(100*time.Millisecond), nil)
+	l.record("stopped early", start, start.Add(-time.Millisecond), nil)
+	assert.NoError(t, l.phase("migrations", func(ctx context.Context) error {
+		assert.NotNil(t, opentracing.SpanFromContext(ctx))
+		return nil
+	}))
+	phaseErr := errors.New("warmup failed")
+	assert.Equal(t, phaseErr, l.phase("warmup", func(context.Context) error {
+		return phaseErr
+	}))
+	l.finish(phaseErr)
+
+	spans := mtr.FinishedSpans()
+	require.Len(t, spans, 5)
+	root := spans[4]
+	assert.Equal(t, startupOpName, root.OperationName)
+	assert.Equal(t, start, root.StartTime)
+	assert.Equal(t, true, root.Tag("error"))
+	assert.Equal(t, lifecycleComponent, root.Tag("component"))
+
+	names := make([]string, 0, 4)
+	for _, sp := range spans[:4] {
+		names = append(names, sp.OperationName)
+		assert.Equal(t, root.SpanContext.SpanID, sp.ParentID)
+	}
+	assert.Equal(t, []string{"configuration", "stopped early", "migrations", "warmup"}, names)
+	assert.Equal(t, 100*time.Millisecond, spans[0].FinishTime.Sub(spans[0].StartTime))
+	assert.Equal(t, spans[1].StartTime, spans[1].FinishTime)
+	assert.Equal(t, true, spans[3].Tag("error"))
+
+	assert.Equal(t, int64(100), l.timings["configuration"])
+	assert.Equal(t, int64(0), l.timings["stopped early"])
+	assert.Contains(t, l.timings, "migrations")
+	assert.Contains(t, l.timings, "warmup")
+}
@@ -0,0 +1,168 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ComponentField is the field used to attribute log entries to a component, e.g. `log.Sub(map[string]interface{}{log.ComponentField: "kafka"})`.
+const ComponentField = "component"
+
+const defaultErrorRateWindow = time.Minute
+
+// ErrorRateFunc is called with the current error rate, in entries per second, when it exceeds the configured threshold.
+type ErrorRateFunc func(rate float64)
+
+var (
+	componentCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "observability",
+			Subsystem: "log",
+			Name:      "component_counter",
+			Help:      "Counts logger calls per component and level",
+		},
+		[]string{"component", "level"},
+	)
+	errRate = newErrorRateTracker(defaultErrorRateWindow)
+)
+
+func init() {
+	prometheus.MustRegister(componentCounter)
+}
+
+// ComponentLevelCount returns the total level count of a component.
+func ComponentLevelCount(component, level string) prometheus.Counter {
+	return componentCounter.WithLabelValues(component, level)
+}
+
+// IncreaseCounter increases the counter of the level and, if the fields contain the component field,
+// the counter of the component. Error, fatal and panic entries are taken into account by the error rate.
+func IncreaseCounter(lvl Level, fields map[string]interface{}) {
+	logCounter.WithLabelValues(string(lvl)).Inc()
+	if c, ok := fields[ComponentField]; ok {
+		componentCounter.WithLabelValues(fmt.Sprint(c), string(lvl)).Inc()
+	}
+	if levelOrder[lvl] >= levelOrder[ErrorLevel] {
+		errRate.record(time.Now())
+	}
+}
+
+// ErrorRate returns the rate of error, fatal and panic entries per second, over the configured window
+// which is a minute by default.
+func ErrorRate() float64 {
+	return errRate.rate(time.Now())
+}
+
+// SetErrorRateAlert configures a callback, which is fired when the error rate over the window exceeds the threshold,
+// e.g. in order to trip a circuit breaker or fail a health check. The callback runs in its own goroutine,
+// so it is safe to log from it, and is fired again only after the rate has dropped below the threshold.
+// The window is rounded down to seconds.
+func SetErrorRateAlert(threshold float64, window time.Duration, fn ErrorRateFunc) error {
+	if threshold <= 0 {
+		return errors.New("threshold should be positive")
+	}
+	if window < time.Second {
+		return errors.New("window should be at least a second")
+	}
+	if fn == nil {
+		return errors.New("callback is nil")
+	}
+
+	tracker := newErrorRateTracker(window)
+	tracker.threshold = threshold
+	tracker.fn = fn
+
+	errRate.mu.Lock()
+	defer errRate.mu.Unlock()
+	errRate.reset(tracker)
+	return nil
+}
+
+// ResetErrorRateAlert removes the error rate alert and restores the default window.
+func ResetErrorRateAlert() {
+	errRate.mu.Lock()
+	defer errRate.mu.Unlock()
+	errRate.reset(newErrorRateTracker(defaultErrorRateWindow))
+}
+
+// errorRateTracker counts entries in per second buckets over the window.
+type errorRateTracker struct {
+	mu        sync.Mutex
+	buckets   []int64
+	last      int64
+	threshold float64
+	fn        ErrorRateFunc
+	firing    bool
+}
+
+func newErrorRateTracker(window time.Duration) *errorRateTracker {
+	return &errorRateTracker{buckets: make([]int64, int(window/time.Second))}
+}
+
+// reset replaces the state of the tracker, while the lock is held by the caller.
+func (t *errorRateTracker) reset(other *errorRateTracker) {
+	t.buckets = other.buckets
+	t.last = other.last
+	t.threshold = other.threshold
+	t.fn = other.fn
+	t.firing = false
+}
+
+func (t *errorRateTracker) record(now time.Time) {
+	t.mu.Lock()
+	t.advance(now.Unix())
+	t.buckets[t.last%int64(len(t.buckets))]++
+	rate := t.sum()
+	fire := false
+	if t.fn != nil {
+		if rate > t.threshold {
+			fire = !t.firing
+			t.firing = true
+		} else {
+			t.firing = false
+		}
+	}
+	fn := t.fn
+	t.mu.Unlock()
+
+	if fire {
+		go fn(rate)
+	}
+}
+
+func (t *errorRateTracker) rate(now time.Time) float64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.advance(now.Unix())
+	return t.sum()
+}
+
+// advance clears the buckets of the seconds elapsed since the last update.
+func (t *errorRateTracker) advance(sec int64) {
+	size := int64(len(t.buckets))
+	if sec <= t.last {
+		return
+	}
+	if sec-t.last >= size {
+		for i := range t.buckets {
+			t.buckets[i] = 0
+		}
+	} else {
+		for s := t.last + 1; s <= sec; s++ {
+			t.buckets[s%size] = 0
+		}
+	}
+	t.last = sec
+}
+
+func (t *errorRateTracker) sum() float64 {
+	var total int64
+	for _, c := range t.buckets {
+		total += c
+	}
+	return float64(total) / float64(len(t.buckets))
+}
@@ -0,0 +1,98 @@
+package log
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIncreaseCounter_Component(t *testing.T) {
+	IncreaseCounter(WarnLevel, map[string]interface{}{ComponentField: "counter-test"})
+	IncreaseCounter(WarnLevel, map[string]interface{}{ComponentField: "counter-test"})
+	IncreaseCounter(WarnLevel, map[string]interface{}{"other": "counter-test"})
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(ComponentLevelCount("counter-test", string(WarnLevel))))
+	assert.Equal(t, 0.0, testutil.ToFloat64(ComponentLevelCount("counter-test", string(ErrorLevel))))
+}
+
+func TestSetErrorRateAlert(t *testing.T) {
+	defer ResetErrorRateAlert()
+	fn := func(float64) {}
+	tests := map[string]struct {
+		threshold   float64
+		window      time.Duration
+		fn          ErrorRateFunc
+		expectedErr string
+	}{
+		"success":           {threshold: 1, window: time.Second, fn: fn},
+		"invalid threshold": {threshold: 0, window: time.Second, fn: fn, expectedErr: "threshold should be positive"},
+		"invalid window":    {threshold: 1, window: time.Millisecond, fn: fn, expectedErr: "window should be at least a second"},
+		"missing callback":  {threshold: 1, window: time.Second, expectedErr: "callback is nil"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			err := SetErrorRateAlert(tt.threshold, tt.window, tt.fn)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestErrorRate_Alert(t *testing.T) {
+	defer ResetErrorRateAlert()
+	ch := make(chan float64, 10)
+	require.NoError(t, SetErrorRateAlert(2, 10*time.Second, func(rate float64) { ch <- rate }))
+
+	for i := 0; i < 20; i++ {
+		IncreaseCounter(ErrorLevel, nil)
+	}
+	IncreaseCounter(WarnLevel, nil)
+	assert.Equal(t, 2.0, ErrorRate())
+
+	IncreaseCounter(FatalLevel, nil)
+	select {
+	case rate := <-ch:
+		assert.Equal(t, 2.1, rate)
+	case <-time.After(time.Second):
+		assert.Fail(t, "alert was not fired")
+	}
+
+	IncreaseCounter(PanicLevel, nil)
+	select {
+	case <-ch:
+		assert.Fail(t, "alert should not fire again while above the threshold")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestErrorRateTracker(t *testing.T) {
+	t.Parallel()
+	fired := make(chan float64, 10)
+	tr := newErrorRateTracker(3 * time.Second)
+	tr.threshold = 1
+	tr.fn = func(rate float64) { fired <- rate }
+	now := time.Unix(1000, 0)
+
+	tr.record(now)
+	tr.record(now)
+	tr.record(now.Add(time.Second))
+	assert.Equal(t, 1.0, tr.rate(now.Add(time.Second)))
+	tr.record(now.Add(2 * time.Second))
+	assert.Equal(t, 4.0/3, <-fired)
+
+	// the first bucket expires
+	assert.Equal(t, 2.0/3, tr.rate(now.Add(3*time.Second)))
+	tr.record(now.Add(3 * time.Second))
+	assert.False(t, tr.firing)
+	// the whole window expires
+	assert.Equal(t, 0.0, tr.rate(now.Add(time.Minute)))
+	// time going backwards is ignored
+	assert.Equal(t, 0.0, tr.rate(now))
+}
@@ -0,0 +1,34 @@
+package log
+
+import "context"
+
+type debugCtxKey struct{}
+
+// Leveler is implemented by loggers which are able to derive a copy of themselves with a different level.
+type Leveler interface {
+	WithLevel(lvl Level) Logger
+}
+
+// WithDebug flags the context, so that FromContext returns a debug level logger for it,
+// regardless of the configured level, e.g. in order to debug a single request.
+// The logger needs to implement Leveler, which all the bundled logger implementations do.
+func WithDebug(ctx context.Context) context.Context {
+	return context.WithValue(ctx, debugCtxKey{}, true)
+}
+
+// DebugFromContext returns true if the context has been flagged for debug level logging.
+func DebugFromContext(ctx context.Context) bool {
+	debug, _ := ctx.Value(debugCtxKey{}).(bool)
+	return debug
+}
+
+func debugLogger(ctx context.Context, l Logger) Logger {
+	if l.Level() == DebugLevel || !DebugFromContext(ctx) {
+		return l
+	}
+	lv, ok := l.(Leveler)
+	if !ok {
+		return l
+	}
+	return lv.WithLevel(DebugLevel)
+}
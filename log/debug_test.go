@@ -0,0 +1,42 @@
+package log
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type levelerLogger struct {
+	testLogger
+}
+
+func (l *levelerLogger) WithLevel(lvl Level) Logger {
+	return &levelerLogger{testLogger: testLogger{level: lvl}}
+}
+
+func TestFromContext_Debug(t *testing.T) {
+	infoLogger := &levelerLogger{testLogger: testLogger{level: InfoLevel}}
+	tests := map[string]struct {
+		ctx      context.Context
+		expected Level
+	}{
+		"not flagged":            {ctx: WithContext(context.Background(), infoLogger), expected: InfoLevel},
+		"flagged":                {ctx: WithDebug(WithContext(context.Background(), infoLogger)), expected: DebugLevel},
+		"flagged without logger": {ctx: WithDebug(context.Background()), expected: DebugLevel},
+		"flagged, not a leveler": {ctx: WithDebug(WithContext(context.Background(), &testLogger{level: WarnLevel})), expected: WarnLevel},
+	}
+	logger = infoLogger
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, FromContext(tt.ctx).Level())
+		})
+	}
+}
+
+func TestDebugFromContext(t *testing.T) {
+	t.Parallel()
+	assert.False(t, DebugFromContext(context.Background()))
+	assert.True(t, DebugFromContext(WithDebug(context.Background())))
+}
@@ -0,0 +1,161 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// RepeatedField is the field attached to the entry summarizing the suppressed duplicates.
+const RepeatedField = "repeated"
+
+type dedupKey struct {
+	lvl Level
+	msg string
+}
+
+type dedupLogger struct {
+	logger Logger
+	window time.Duration
+	mu     sync.Mutex
+	seen   map[dedupKey]int
+}
+
+// NewDeduplicator decorates a logger in order to protect against log storms.
+// The first occurrence of a message is written immediately, while identical messages of the same level
+// that follow within the window are suppressed. When the window elapses, a single entry with the
+// number of suppressed messages in the `repeated` field is written.
+// Fatal and panic entries are never suppressed.
+func NewDeduplicator(l Logger, window time.Duration) (Logger, error) {
+	if l == nil {
+		return nil, errors.New("logger is nil")
+	}
+	if window <= 0 {
+		return nil, errors.New("window must be positive")
+	}
+	return &dedupLogger{logger: l, window: window, seen: make(map[dedupKey]int)}, nil
+}
+
+// Sub returns a deduplicating sub logger with new fields attached.
+func (dl *dedupLogger) Sub(ff map[string]interface{}) Logger {
+	return &dedupLogger{logger: dl.logger.Sub(ff), window: dl.window, seen: make(map[dedupKey]int)}
+}
+
+// WithLevel returns a deduplicating copy of the decorated logger with a different level,
+// if the decorated logger supports it.
+func (dl *dedupLogger) WithLevel(lvl Level) Logger {
+	lv, ok := dl.logger.(Leveler)
+	if !ok {
+		return dl
+	}
+	return &dedupLogger{logger: lv.WithLevel(lvl), window: dl.window, seen: make(map[dedupKey]int)}
+}
+
+// Fatal logging.
+func (dl *dedupLogger) Fatal(args ...interface{}) {
+	dl.logger.Fatal(args...)
+}
+
+// Fatalf logging.
+func (dl *dedupLogger) Fatalf(msg string, args ...interface{}) {
+	dl.logger.Fatalf(msg, args...)
+}
+
+// Panic logging.
+func (dl *dedupLogger) Panic(args ...interface{}) {
+	dl.logger.Panic(args...)
+}
+
+// Panicf logging.
+func (dl *dedupLogger) Panicf(msg string, args ...interface{}) {
+	dl.logger.Panicf(msg, args...)
+}
+
+// Error logging.
+func (dl *dedupLogger) Error(args ...interface{}) {
+	dl.log(ErrorLevel, fmt.Sprint(args...))
+}
+
+// Errorf logging.
+func (dl *dedupLogger) Errorf(msg string, args ...interface{}) {
+	dl.log(ErrorLevel, fmt.Sprintf(msg, args...))
+}
+
+// Warn logging.
+func (dl *dedupLogger) Warn(args ...interface{}) {
+	dl.log(WarnLevel, fmt.Sprint(args...))
+}
+
+// Warnf logging.
+func (dl *dedupLogger) Warnf(msg string, args ...interface{}) {
+	dl.log(WarnLevel, fmt.Sprintf(msg, args...))
+}
+
+// Info logging.
+func (dl *dedupLogger) Info(args ...interface{}) {
+	dl.log(InfoLevel, fmt.Sprint(args...))
+}
+
+// Infof logging.
+func (dl *dedupLogger) Infof(msg string, args ...interface{}) {
+	dl.log(InfoLevel, fmt.Sprintf(msg, args...))
+}
+
+// Debug logging.
+func (dl *dedupLogger) Debug(args ...interface{}) {
+	dl.log(DebugLevel, fmt.Sprint(args...))
+}
+
+// Debugf logging.
+func (dl *dedupLogger) Debugf(msg string, args ...interface{}) {
+	dl.log(DebugLevel, fmt.Sprintf(msg, args...))
+}
+
+// Level returns the level of the decorated logger.
+func (dl *dedupLogger) Level() Level {
+	return dl.logger.Level()
+}
+
+func (dl *dedupLogger) log(lvl Level, msg string) {
+	key := dedupKey{lvl: lvl, msg: msg}
+
+	dl.mu.Lock()
+	if _, ok := dl.seen[key]; ok {
+		dl.seen[key]++
+		dl.mu.Unlock()
+		return
+	}
+	dl.seen[key] = 0
+	dl.mu.Unlock()
+
+	write(dl.logger, lvl, msg)
+	time.AfterFunc(dl.window, func() {
+		dl.flush(key)
+	})
+}
+
+func (dl *dedupLogger) flush(key dedupKey) {
+	dl.mu.Lock()
+	repeated := dl.seen[key]
+	delete(dl.seen, key)
+	dl.mu.Unlock()
+
+	if repeated == 0 {
+		return
+	}
+	write(dl.logger.Sub(map[string]interface{}{RepeatedField: repeated}), key.lvl, key.msg)
+}
+
+func write(l Logger, lvl Level, msg string) {
+	switch lvl {
+	case DebugLevel:
+		l.Debug(msg)
+	case InfoLevel:
+		l.Info(msg)
+	case WarnLevel:
+		l.Warn(msg)
+	case ErrorLevel:
+		l.Error(msg)
+	}
+}
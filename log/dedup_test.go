@@ -0,0 +1,101 @@
+package log
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewDeduplicator(t *testing.T) {
+	tests := map[string]struct {
+		logger      Logger
+		window      time.Duration
+		expectedErr string
+	}{
+		"success":                 {logger: &testLogger{}, window: time.Second},
+		"failure, missing logger": {window: time.Second, expectedErr: "logger is nil"},
+		"failure, invalid window": {logger: &testLogger{}, expectedErr: "window must be positive"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			got, err := NewDeduplicator(tt.logger, tt.window)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, got)
+			}
+		})
+	}
+}
+
+func TestDeduplicator(t *testing.T) {
+	rl := &recordingLogger{}
+	l, err := NewDeduplicator(rl, 50*time.Millisecond)
+	require.NoError(t, err)
+
+	for i := 0; i < 5; i++ {
+		l.Errorf("failed to connect to %s", "db")
+		l.Warn("slow query")
+	}
+	l.Info("once")
+	l.Panic("panic")
+	l.Panic("panic")
+
+	assert.Equal(t, []string{
+		"error failed to connect to db map[]",
+		"warn slow query map[]",
+		"info once map[]",
+		"panic panic map[]",
+		"panic panic map[]",
+	}, rl.entries())
+
+	assert.Eventually(t, func() bool { return len(rl.entries()) == 7 }, time.Second, 10*time.Millisecond)
+	assert.ElementsMatch(t, []string{
+		"error failed to connect to db map[repeated:4]",
+		"warn slow query map[repeated:4]",
+	}, rl.entries()[5:])
+
+	l.Errorf("failed to connect to %s", "db")
+	assert.Equal(t, "error failed to connect to db map[]", rl.entries()[7])
+}
+
+type recordingLogger struct {
+	testLogger
+	fields  map[string]interface{}
+	parent  *recordingLogger
+	mu      sync.Mutex
+	written []string
+}
+
+func (r *recordingLogger) Sub(ff map[string]interface{}) Logger {
+	return &recordingLogger{fields: ff, parent: r}
+}
+
+func (r *recordingLogger) Panic(args ...interface{}) { r.record(PanicLevel, args...) }
+func (r *recordingLogger) Error(args ...interface{}) { r.record(ErrorLevel, args...) }
+func (r *recordingLogger) Warn(args ...interface{})  { r.record(WarnLevel, args...) }
+func (r *recordingLogger) Info(args ...interface{})  { r.record(InfoLevel, args...) }
+func (r *recordingLogger) Debug(args ...interface{}) { r.record(DebugLevel, args...) }
+
+func (r *recordingLogger) record(lvl Level, args ...interface{}) {
+	root := r
+	if r.parent != nil {
+		root = r.parent
+	}
+	root.mu.Lock()
+	defer root.mu.Unlock()
+	root.written = append(root.written, fmt.Sprintf("%s %s %v", lvl, fmt.Sprint(args...), r.fields))
+}
+
+func (r *recordingLogger) entries() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.written...)
+}
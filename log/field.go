@@ -0,0 +1,52 @@
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// MergeFields returns a new map with the fields of the base overridden by the ones provided.
+// Field groups, which are fields with a map[string]interface{} value, are merged recursively,
+// so that a sub logger can add fields to a group of its parent, e.g. "db": {"host": ..., "name": ...}.
+// Neither of the provided maps is modified.
+func MergeFields(base, ff map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(base)+len(ff))
+	for k, v := range base {
+		merged[k] = copyGroup(v)
+	}
+	for k, v := range ff {
+		group, ok := v.(map[string]interface{})
+		if !ok {
+			merged[k] = v
+			continue
+		}
+		if baseGroup, ok := merged[k].(map[string]interface{}); ok {
+			merged[k] = MergeFields(baseGroup, group)
+			continue
+		}
+		merged[k] = copyGroup(group)
+	}
+	return merged
+}
+
+// FormatFieldValue formats the value of a field for text based outputs.
+// Field groups are formatted as JSON objects, instead of the Go map representation.
+func FormatFieldValue(v interface{}) string {
+	group, ok := v.(map[string]interface{})
+	if !ok {
+		return fmt.Sprintf("%v", v)
+	}
+	b, err := json.Marshal(group)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(b)
+}
+
+func copyGroup(v interface{}) interface{} {
+	group, ok := v.(map[string]interface{})
+	if !ok {
+		return v
+	}
+	return MergeFields(group, nil)
+}
@@ -0,0 +1,87 @@
+package log
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeFields(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		base     map[string]interface{}
+		ff       map[string]interface{}
+		expected map[string]interface{}
+	}{
+		"both empty": {expected: map[string]interface{}{}},
+		"flat fields": {
+			base:     map[string]interface{}{"a": 1, "b": 2},
+			ff:       map[string]interface{}{"b": 3, "c": 4},
+			expected: map[string]interface{}{"a": 1, "b": 3, "c": 4},
+		},
+		"groups merged": {
+			base:     map[string]interface{}{"db": map[string]interface{}{"host": "localhost", "port": 5432}},
+			ff:       map[string]interface{}{"db": map[string]interface{}{"name": "patron", "port": 5433}},
+			expected: map[string]interface{}{"db": map[string]interface{}{"host": "localhost", "name": "patron", "port": 5433}},
+		},
+		"nested groups merged": {
+			base:     map[string]interface{}{"a": map[string]interface{}{"b": map[string]interface{}{"c": 1}}},
+			ff:       map[string]interface{}{"a": map[string]interface{}{"b": map[string]interface{}{"d": 2}}},
+			expected: map[string]interface{}{"a": map[string]interface{}{"b": map[string]interface{}{"c": 1, "d": 2}}},
+		},
+		"group overrides value": {
+			base:     map[string]interface{}{"db": "postgres"},
+			ff:       map[string]interface{}{"db": map[string]interface{}{"name": "patron"}},
+			expected: map[string]interface{}{"db": map[string]interface{}{"name": "patron"}},
+		},
+		"value overrides group": {
+			base:     map[string]interface{}{"db": map[string]interface{}{"name": "patron"}},
+			ff:       map[string]interface{}{"db": "postgres"},
+			expected: map[string]interface{}{"db": "postgres"},
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.expected, MergeFields(tt.base, tt.ff))
+		})
+	}
+}
+
+func TestMergeFields_DoesNotModifyInput(t *testing.T) {
+	t.Parallel()
+	base := map[string]interface{}{"db": map[string]interface{}{"host": "localhost"}}
+	ff := map[string]interface{}{"db": map[string]interface{}{"name": "patron"}}
+
+	merged := MergeFields(base, ff)
+	merged["db"].(map[string]interface{})["port"] = 5432
+
+	assert.Equal(t, map[string]interface{}{"db": map[string]interface{}{"host": "localhost"}}, base)
+	assert.Equal(t, map[string]interface{}{"db": map[string]interface{}{"name": "patron"}}, ff)
+}
+
+func TestFormatFieldValue(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		value    interface{}
+		expected string
+	}{
+		"string":  {value: "value", expected: "value"},
+		"integer": {value: 1, expected: "1"},
+		"nil":     {value: nil, expected: "<nil>"},
+		"group":   {value: map[string]interface{}{"name": "patron", "host": "localhost"}, expected: `{"host":"localhost","name":"patron"}`},
+		"nested group": {
+			value:    map[string]interface{}{"db": map[string]interface{}{"port": 5432}},
+			expected: `{"db":{"port":5432}}`,
+		},
+		"unsupported group value": {value: map[string]interface{}{"ch": make(chan int)}, expected: "map[ch:"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			assert.Contains(t, FormatFieldValue(tt.value), tt.expected)
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package log
+
+import "sync"
+
+// Entry describes a log entry as it is passed through the registered hooks.
+type Entry struct {
+	Level   Level
+	Message string
+	Fields  map[string]interface{}
+}
+
+// Hook allows mutating or enriching a log entry before it is written.
+type Hook func(Entry) Entry
+
+var (
+	hooksMu sync.RWMutex
+	hooks   []Hook
+)
+
+// RegisterHook registers a hook which is applied, in registration order, to every entry
+// before it is written by any of the bundled logger implementations.
+func RegisterHook(h Hook) {
+	if h == nil {
+		return
+	}
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+	hooks = append(hooks, h)
+}
+
+// ResetHooks removes all registered hooks.
+func ResetHooks() {
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+	hooks = nil
+}
+
+// HasHooks returns true if at least one hook is registered.
+func HasHooks() bool {
+	hooksMu.RLock()
+	defer hooksMu.RUnlock()
+	return len(hooks) > 0
+}
+
+// ApplyHooks runs the entry through all registered hooks and returns the result.
+// The fields of the entry are copied beforehand, so hooks are free to modify them.
+// Logger implementations should call this right before writing an entry.
+func ApplyHooks(e Entry) Entry {
+	hooksMu.RLock()
+	defer hooksMu.RUnlock()
+	if len(hooks) == 0 {
+		return e
+	}
+
+	ff := make(map[string]interface{}, len(e.Fields))
+	for k, v := range e.Fields {
+		ff[k] = v
+	}
+	e.Fields = ff
+
+	for _, h := range hooks {
+		e = h(e)
+		if e.Fields == nil {
+			e.Fields = make(map[string]interface{})
+		}
+	}
+	return e
+}
@@ -0,0 +1,50 @@
+package log
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegisterHook(t *testing.T) {
+	defer ResetHooks()
+	assert.False(t, HasHooks())
+	RegisterHook(nil)
+	assert.False(t, HasHooks())
+
+	RegisterHook(func(e Entry) Entry {
+		e.Fields["host"] = "local"
+		return e
+	})
+	RegisterHook(func(e Entry) Entry {
+		e.Message = e.Fields["host"].(string) + ": " + e.Message
+		return e
+	})
+	assert.True(t, HasHooks())
+
+	fields := map[string]interface{}{"key": "value"}
+	got := ApplyHooks(Entry{Level: InfoLevel, Message: "hello", Fields: fields})
+	assert.Equal(t, Entry{
+		Level:   InfoLevel,
+		Message: "local: hello",
+		Fields:  map[string]interface{}{"key": "value", "host": "local"},
+	}, got)
+	assert.Equal(t, map[string]interface{}{"key": "value"}, fields, "original fields should not be modified")
+
+	ResetHooks()
+	assert.False(t, HasHooks())
+}
+
+func TestApplyHooks_NilFields(t *testing.T) {
+	defer ResetHooks()
+	RegisterHook(func(e Entry) Entry {
+		e.Fields = nil
+		return e
+	})
+	RegisterHook(func(e Entry) Entry {
+		e.Fields["version"] = "1.0.0"
+		return e
+	})
+	got := ApplyHooks(Entry{Level: ErrorLevel, Message: "hello"})
+	assert.Equal(t, map[string]interface{}{"version": "1.0.0"}, got.Fields)
+}
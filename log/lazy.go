@@ -0,0 +1,37 @@
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+type lazy struct {
+	fn func() interface{}
+}
+
+// Lazy defers the evaluation of an expensive log argument, like a big struct or an SQL dump, until the entry is actually written,
+// so that it is skipped altogether when the level is not enabled:
+//
+//	log.Debug("request: ", log.Lazy(func() interface{} { return dump(req) }))
+//
+// The function is evaluated each time the value is formatted. When used as a field value, it is evaluated
+// whenever the logger implementation serializes the fields, which for most implementations is when they are attached.
+func Lazy(fn func() interface{}) fmt.Stringer {
+	return lazy{fn: fn}
+}
+
+// String evaluates the function and formats the result.
+func (l lazy) String() string {
+	if l.fn == nil {
+		return ""
+	}
+	return fmt.Sprint(l.fn())
+}
+
+// MarshalJSON evaluates the function and marshals the result.
+func (l lazy) MarshalJSON() ([]byte, error) {
+	if l.fn == nil {
+		return []byte("null"), nil
+	}
+	return json.Marshal(l.fn())
+}
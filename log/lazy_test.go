@@ -0,0 +1,38 @@
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLazy(t *testing.T) {
+	t.Parallel()
+	calls := 0
+	l := Lazy(func() interface{} {
+		calls++
+		return struct {
+			Name string `json:"name"`
+		}{Name: "john"}
+	})
+	assert.Equal(t, 0, calls)
+
+	assert.Equal(t, "value {john}", fmt.Sprint("value ", l))
+	assert.Equal(t, "value {john}", fmt.Sprintf("value %v", l))
+	b, err := json.Marshal(map[string]interface{}{"user": l})
+	require.NoError(t, err)
+	assert.Equal(t, `{"user":{"name":"john"}}`, string(b))
+	assert.Equal(t, 3, calls)
+}
+
+func TestLazy_Nil(t *testing.T) {
+	t.Parallel()
+	l := Lazy(nil)
+	assert.Equal(t, "", l.String())
+	b, err := json.Marshal(l)
+	require.NoError(t, err)
+	assert.Equal(t, "null", string(b))
+}
@@ -6,6 +6,8 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"sort"
+	"strings"
 	"sync"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -93,32 +95,32 @@ func ResetLogCounter() {
 
 // IncreaseFatalCounter increases the fatal counter.
 func IncreaseFatalCounter() {
-	logCounter.WithLabelValues(string(FatalLevel)).Inc()
+	IncreaseCounter(FatalLevel, nil)
 }
 
 // IncreasePanicCounter increases the panic counter.
 func IncreasePanicCounter() {
-	logCounter.WithLabelValues(string(PanicLevel)).Inc()
+	IncreaseCounter(PanicLevel, nil)
 }
 
 // IncreaseErrorCounter increases the error counter.
 func IncreaseErrorCounter() {
-	logCounter.WithLabelValues(string(ErrorLevel)).Inc()
+	IncreaseCounter(ErrorLevel, nil)
 }
 
 // IncreaseWarnCounter increases the warn counter.
 func IncreaseWarnCounter() {
-	logCounter.WithLabelValues(string(WarnLevel)).Inc()
+	IncreaseCounter(WarnLevel, nil)
 }
 
 // IncreaseInfoCounter increases the info counter.
 func IncreaseInfoCounter() {
-	logCounter.WithLabelValues(string(InfoLevel)).Inc()
+	IncreaseCounter(InfoLevel, nil)
 }
 
 // IncreaseDebugCounter increases the debug counter.
 func IncreaseDebugCounter() {
-	logCounter.WithLabelValues(string(DebugLevel)).Inc()
+	IncreaseCounter(DebugLevel, nil)
 }
 
 // LevelOrder returns the numerical order of the level.
@@ -139,14 +141,15 @@ func Setup(l Logger) error {
 }
 
 // FromContext returns the logger in the context or a nil logger.
+// If the context has been flagged with WithDebug, a debug level copy of the logger is returned.
 func FromContext(ctx context.Context) Logger {
 	if l, ok := ctx.Value(ctxKey{}).(Logger); ok {
 		if l == nil {
-			return logger
+			return debugLogger(ctx, logger)
 		}
-		return l
+		return debugLogger(ctx, l)
 	}
-	return logger
+	return debugLogger(ctx, logger)
 }
 
 // WithContext associates a logger with a context for later reuse.
@@ -161,61 +164,109 @@ func Sub(ff map[string]interface{}) Logger {
 
 // Panic logging.
 func Panic(args ...interface{}) {
+	if !Enabled(PanicLevel) {
+		IncreaseCounter(PanicLevel, nil)
+		return
+	}
 	logger.Panic(args...)
 }
 
 // Panicf logging.
 func Panicf(msg string, args ...interface{}) {
+	if !Enabled(PanicLevel) {
+		IncreaseCounter(PanicLevel, nil)
+		return
+	}
 	logger.Panicf(msg, args...)
 }
 
 // Fatal logging.
 func Fatal(args ...interface{}) {
+	if !Enabled(FatalLevel) {
+		IncreaseCounter(FatalLevel, nil)
+		return
+	}
 	logger.Fatal(args...)
 }
 
 // Fatalf logging.
 func Fatalf(msg string, args ...interface{}) {
+	if !Enabled(FatalLevel) {
+		IncreaseCounter(FatalLevel, nil)
+		return
+	}
 	logger.Fatalf(msg, args...)
 }
 
 // Error logging.
 func Error(args ...interface{}) {
+	if !Enabled(ErrorLevel) {
+		IncreaseCounter(ErrorLevel, nil)
+		return
+	}
 	logger.Error(args...)
 }
 
 // Errorf logging.
 func Errorf(msg string, args ...interface{}) {
+	if !Enabled(ErrorLevel) {
+		IncreaseCounter(ErrorLevel, nil)
+		return
+	}
 	logger.Errorf(msg, args...)
 }
 
 // Warn logging.
 func Warn(args ...interface{}) {
+	if !Enabled(WarnLevel) {
+		IncreaseCounter(WarnLevel, nil)
+		return
+	}
 	logger.Warn(args...)
 }
 
 // Warnf logging.
 func Warnf(msg string, args ...interface{}) {
+	if !Enabled(WarnLevel) {
+		IncreaseCounter(WarnLevel, nil)
+		return
+	}
 	logger.Warnf(msg, args...)
 }
 
 // Info logging.
 func Info(args ...interface{}) {
+	if !Enabled(InfoLevel) {
+		IncreaseCounter(InfoLevel, nil)
+		return
+	}
 	logger.Info(args...)
 }
 
 // Infof logging.
 func Infof(msg string, args ...interface{}) {
+	if !Enabled(InfoLevel) {
+		IncreaseCounter(InfoLevel, nil)
+		return
+	}
 	logger.Infof(msg, args...)
 }
 
 // Debug logging.
 func Debug(args ...interface{}) {
+	if !Enabled(DebugLevel) {
+		IncreaseCounter(DebugLevel, nil)
+		return
+	}
 	logger.Debug(args...)
 }
 
 // Debugf logging.
 func Debugf(msg string, args ...interface{}) {
+	if !Enabled(DebugLevel) {
+		IncreaseCounter(DebugLevel, nil)
+		return
+	}
 	logger.Debugf(msg, args...)
 }
 
@@ -234,80 +285,101 @@ func (fl *fmtLogger) Sub(map[string]interface{}) Logger {
 // Panic logging.
 func (fl *fmtLogger) Panic(args ...interface{}) {
 	IncreasePanicCounter()
-	fmt.Print(args...)
+	fl.print(PanicLevel, fmt.Sprint(args...))
 	panic(args)
 }
 
 // Panicf logging.
 func (fl *fmtLogger) Panicf(msg string, args ...interface{}) {
 	IncreasePanicCounter()
-	fmt.Printf(msg, args...)
+	fl.print(PanicLevel, fmt.Sprintf(msg, args...))
 	panic(args)
 }
 
 // Fatal logging.
 func (fl *fmtLogger) Fatal(args ...interface{}) {
 	IncreaseFatalCounter()
-	fmt.Print(args...)
+	fl.print(FatalLevel, fmt.Sprint(args...))
 	os.Exit(1)
 }
 
 // Fatalf logging.
 func (fl *fmtLogger) Fatalf(msg string, args ...interface{}) {
 	IncreaseFatalCounter()
-	fmt.Printf(msg, args...)
+	fl.print(FatalLevel, fmt.Sprintf(msg, args...))
 	os.Exit(1)
 }
 
 // Error logging.
 func (fl *fmtLogger) Error(args ...interface{}) {
 	IncreaseErrorCounter()
-	fmt.Print(args...)
+	fl.print(ErrorLevel, fmt.Sprint(args...))
 }
 
 // Errorf logging.
 func (fl *fmtLogger) Errorf(msg string, args ...interface{}) {
 	IncreaseErrorCounter()
-	fmt.Printf(msg, args...)
+	fl.print(ErrorLevel, fmt.Sprintf(msg, args...))
 }
 
 // Warn logging.
 func (fl *fmtLogger) Warn(args ...interface{}) {
 	IncreaseWarnCounter()
-	fmt.Print(args...)
+	fl.print(WarnLevel, fmt.Sprint(args...))
 }
 
 // Warnf logging.
 func (fl *fmtLogger) Warnf(msg string, args ...interface{}) {
 	IncreaseWarnCounter()
-	fmt.Printf(msg, args...)
+	fl.print(WarnLevel, fmt.Sprintf(msg, args...))
 }
 
 // Info logging.
 func (fl *fmtLogger) Info(args ...interface{}) {
 	IncreaseInfoCounter()
-	fmt.Print(args...)
+	fl.print(InfoLevel, fmt.Sprint(args...))
 }
 
 // Infof logging.
 func (fl *fmtLogger) Infof(msg string, args ...interface{}) {
 	IncreaseInfoCounter()
-	fmt.Printf(msg, args...)
+	fl.print(InfoLevel, fmt.Sprintf(msg, args...))
 }
 
 // Debug logging.
 func (fl *fmtLogger) Debug(args ...interface{}) {
 	IncreaseDebugCounter()
-	fmt.Print(args...)
+	fl.print(DebugLevel, fmt.Sprint(args...))
 }
 
 // Debugf logging.
 func (fl *fmtLogger) Debugf(msg string, args ...interface{}) {
 	IncreaseDebugCounter()
-	fmt.Printf(msg, args...)
+	fl.print(DebugLevel, fmt.Sprintf(msg, args...))
 }
 
 // Level returns the debug level of the nil logger.
 func (fl *fmtLogger) Level() Level {
 	return DebugLevel
 }
+
+func (fl *fmtLogger) print(lvl Level, msg string) {
+	if !HasHooks() {
+		fmt.Print(msg)
+		return
+	}
+
+	e := ApplyHooks(Entry{Level: lvl, Message: msg})
+	keys := make([]string, 0, len(e.Fields))
+	for key := range e.Fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	sb := strings.Builder{}
+	sb.WriteString(e.Message)
+	for _, key := range keys {
+		sb.WriteString(fmt.Sprintf(" %s=%s", key, FormatFieldValue(e.Fields[key])))
+	}
+	fmt.Print(sb.String())
+}
@@ -118,91 +118,91 @@ func TestFromContext(t *testing.T) {
 }
 
 func TestLog_Sub(t *testing.T) {
-	l := testLogger{}
+	l := testLogger{level: DebugLevel}
 	logger = &l
 	sl := Sub(map[string]interface{}{})
 	assert.NotNil(t, sl)
 }
 
 func TestLog_Panic(t *testing.T) {
-	l := testLogger{}
+	l := testLogger{level: DebugLevel}
 	logger = &l
 	Panic("panic")
 	assert.Equal(t, 1, l.panicCount)
 }
 
 func TestLog_Panicf(t *testing.T) {
-	l := testLogger{}
+	l := testLogger{level: DebugLevel}
 	logger = &l
 	Panicf("panic %s", "1")
 	assert.Equal(t, 1, l.panicCount)
 }
 
 func TestLog_Fatal(t *testing.T) {
-	l := testLogger{}
+	l := testLogger{level: DebugLevel}
 	logger = &l
 	Fatal("fatal")
 	assert.Equal(t, 1, l.fatalCount)
 }
 
 func TestLog_Fatalf(t *testing.T) {
-	l := testLogger{}
+	l := testLogger{level: DebugLevel}
 	logger = &l
 	Fatalf("fatal %s", "1")
 	assert.Equal(t, 1, l.fatalCount)
 }
 
 func TestLog_Error(t *testing.T) {
-	l := testLogger{}
+	l := testLogger{level: DebugLevel}
 	logger = &l
 	Error("error")
 	assert.Equal(t, 1, l.errorCount)
 }
 
 func TestLog_Errorf(t *testing.T) {
-	l := testLogger{}
+	l := testLogger{level: DebugLevel}
 	logger = &l
 	Errorf("error %s", "1")
 	assert.Equal(t, 1, l.errorCount)
 }
 
 func TestLog_Warn(t *testing.T) {
-	l := testLogger{}
+	l := testLogger{level: DebugLevel}
 	logger = &l
 	Warn("warn")
 	assert.Equal(t, 1, l.warnCount)
 }
 
 func TestLog_Warnf(t *testing.T) {
-	l := testLogger{}
+	l := testLogger{level: DebugLevel}
 	logger = &l
 	Warnf("warn %s", "1")
 	assert.Equal(t, 1, l.warnCount)
 }
 
 func TestLog_Info(t *testing.T) {
-	l := testLogger{}
+	l := testLogger{level: DebugLevel}
 	logger = &l
 	Info("info")
 	assert.Equal(t, 1, l.infoCount)
 }
 
 func TestLog_Infof(t *testing.T) {
-	l := testLogger{}
+	l := testLogger{level: DebugLevel}
 	logger = &l
 	Infof("info %s", "1")
 	assert.Equal(t, 1, l.infoCount)
 }
 
 func TestLog_Debug(t *testing.T) {
-	l := testLogger{}
+	l := testLogger{level: DebugLevel}
 	logger = &l
 	Debug("debug")
 	assert.Equal(t, 1, l.debugCount)
 }
 
 func TestLog_Debugf(t *testing.T) {
-	l := testLogger{}
+	l := testLogger{level: DebugLevel}
 	logger = &l
 	Debugf("debug %s", "1")
 	assert.Equal(t, 1, l.debugCount)
@@ -335,3 +335,19 @@ func (t *testLogger) Debugf(_ string, _ ...interface{}) {
 func (t *testLogger) Level() Level {
 	return t.level
 }
+
+func TestLog_DisabledLevel(t *testing.T) {
+	l := testLogger{level: WarnLevel}
+	logger = &l
+	evaluated := false
+	Info("info ", Lazy(func() interface{} {
+		evaluated = true
+		return "value"
+	}))
+	Debugf("debug %s", "1")
+	Warn("warn")
+	assert.Equal(t, 0, l.infoCount)
+	assert.Equal(t, 0, l.debugCount)
+	assert.Equal(t, 1, l.warnCount)
+	assert.False(t, evaluated)
+}
@@ -0,0 +1,113 @@
+package sink
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/beatlabs/patron/log"
+)
+
+// DefaultJournaldSocket is the socket journald listens on for the native protocol.
+const DefaultJournaldSocket = "/run/systemd/journal/socket"
+
+// Journald sink writes entries to systemd-journald using its native protocol.
+// Entry fields are mapped to journal fields by upper casing the keys and replacing
+// any character that is not allowed with an underscore, e.g. `correlationID` becomes `CORRELATIONID`.
+type Journald struct {
+	identifier string
+	conn       *net.UnixConn
+}
+
+// NewJournald creates a journald sink. If the socket is empty, the default journald socket is used.
+// If the identifier is empty, the executable name is used.
+func NewJournald(socket, identifier string) (*Journald, error) {
+	if socket == "" {
+		socket = DefaultJournaldSocket
+	}
+	if identifier == "" {
+		identifier = filepath.Base(os.Args[0])
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to journald at %s: %w", socket, err)
+	}
+	return &Journald{identifier: identifier, conn: conn}, nil
+}
+
+// Write sends the entry to journald.
+func (j *Journald) Write(e log.Entry) error {
+	_, err := j.conn.Write(j.format(e))
+	return err
+}
+
+// Close closes the underlying connection.
+func (j *Journald) Close() error {
+	if j.conn == nil {
+		return errors.New("connection is nil")
+	}
+	return j.conn.Close()
+}
+
+func (j *Journald) format(e log.Entry) []byte {
+	sev, ok := syslogSeverity[e.Level]
+	if !ok {
+		sev = syslogSeverity[log.InfoLevel]
+	}
+
+	b := bytes.Buffer{}
+	writeJournalField(&b, "MESSAGE", e.Message)
+	writeJournalField(&b, "PRIORITY", strconv.Itoa(sev))
+	writeJournalField(&b, "SYSLOG_IDENTIFIER", j.identifier)
+
+	keys := make([]string, 0, len(e.Fields))
+	for key := range e.Fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		name := journalFieldName(key)
+		if name == "" {
+			continue
+		}
+		writeJournalField(&b, name, log.FormatFieldValue(e.Fields[key]))
+	}
+	return b.Bytes()
+}
+
+// writeJournalField writes the field in the native protocol format.
+// Values containing new lines are written in the binary safe, length prefixed format.
+func writeJournalField(b *bytes.Buffer, name, value string) {
+	b.WriteString(name)
+	if !strings.ContainsRune(value, '\n') {
+		b.WriteRune('=')
+		b.WriteString(value)
+		b.WriteRune('\n')
+		return
+	}
+	b.WriteRune('\n')
+	_ = binary.Write(b, binary.LittleEndian, uint64(len(value)))
+	b.WriteString(value)
+	b.WriteRune('\n')
+}
+
+// journalFieldName maps a key to a valid journal field name, which consists of upper case letters,
+// digits and underscores and does not start with an underscore or a digit.
+func journalFieldName(key string) string {
+	name := []byte(strings.ToUpper(key))
+	for i, c := range name {
+		if (c < 'A' || c > 'Z') && (c < '0' || c > '9') {
+			name[i] = '_'
+		}
+	}
+	return strings.TrimLeft(string(name), "_0123456789")
+}
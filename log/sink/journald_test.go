@@ -0,0 +1,96 @@
+package sink
+
+import (
+	"encoding/binary"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/beatlabs/patron/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJournald_Format(t *testing.T) {
+	t.Parallel()
+	j := &Journald{identifier: "app"}
+
+	got := j.format(log.Entry{
+		Level:   log.WarnLevel,
+		Message: "msg",
+		Fields:  map[string]interface{}{"correlationID": "123", "multi": "a\nb", "_hidden": 1, "9": "dropped"},
+	})
+
+	size := make([]byte, 8)
+	binary.LittleEndian.PutUint64(size, 3)
+	expected := "MESSAGE=msg\nPRIORITY=4\nSYSLOG_IDENTIFIER=app\nHIDDEN=1\nCORRELATIONID=123\nMULTI\n" + string(size) + "a\nb\n"
+	assert.Equal(t, expected, string(got))
+}
+
+func TestJournald_Format_FieldGroup(t *testing.T) {
+	t.Parallel()
+	j := &Journald{identifier: "app"}
+
+	got := j.format(log.Entry{
+		Level:   log.InfoLevel,
+		Message: "msg",
+		Fields:  map[string]interface{}{"db": map[string]interface{}{"host": "localhost", "port": 5432}},
+	})
+
+	expected := "MESSAGE=msg\nPRIORITY=6\nSYSLOG_IDENTIFIER=app\nDB={\"host\":\"localhost\",\"port\":5432}\n"
+	assert.Equal(t, expected, string(got))
+}
+
+func TestJournalFieldName(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		key      string
+		expected string
+	}{
+		"lower case":         {key: "key", expected: "KEY"},
+		"invalid characters": {key: "http.status-code", expected: "HTTP_STATUS_CODE"},
+		"leading underscore": {key: "__key", expected: "KEY"},
+		"leading digits":     {key: "1key", expected: "KEY"},
+		"only digits":        {key: "123", expected: ""},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.expected, journalFieldName(tt.key))
+		})
+	}
+}
+
+func TestJournald_Write(t *testing.T) {
+	t.Parallel()
+	dir, err := os.MkdirTemp("", "journald")
+	require.NoError(t, err)
+	defer func() { _ = os.RemoveAll(dir) }()
+	path := filepath.Join(dir, "socket")
+
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	require.NoError(t, err)
+	defer func() { _ = conn.Close() }()
+
+	j, err := NewJournald(path, "app")
+	require.NoError(t, err)
+	defer func() { _ = j.Close() }()
+
+	require.NoError(t, j.Write(log.Entry{Level: log.InfoLevel, Message: "hello"}))
+
+	buf := make([]byte, 1024)
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+	n, err := conn.Read(buf)
+	require.NoError(t, err)
+	assert.Equal(t, "MESSAGE=hello\nPRIORITY=6\nSYSLOG_IDENTIFIER=app\n", string(buf[:n]))
+}
+
+func TestNewJournald_Failure(t *testing.T) {
+	t.Parallel()
+	got, err := NewJournald("/non/existent/socket", "")
+	assert.Nil(t, got)
+	assert.EqualError(t, err, "failed to connect to journald at /non/existent/socket: dial unixgram /non/existent/socket: connect: no such file or directory")
+}
@@ -0,0 +1,178 @@
+// Package sink provides a logger implementation which writes structured entries to system log sinks,
+// like syslog and systemd-journald.
+package sink
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/beatlabs/patron/log"
+)
+
+// Sink writes a single structured log entry to its destination.
+type Sink interface {
+	Write(e log.Entry) error
+}
+
+// Logger implementation which writes entries to a sink.
+type Logger struct {
+	sink   Sink
+	level  log.Level
+	fields map[string]interface{}
+}
+
+// New constructor.
+func New(s Sink, lvl log.Level, fields map[string]interface{}) *Logger {
+	return &Logger{sink: s, level: lvl, fields: log.MergeFields(fields, nil)}
+}
+
+// Sub returns a sub logger with additional fields.
+func (l *Logger) Sub(fields map[string]interface{}) log.Logger {
+	return &Logger{sink: l.sink, level: l.level, fields: log.MergeFields(l.fields, fields)}
+}
+
+// WithLevel returns a copy of the logger with a different level.
+func (l *Logger) WithLevel(lvl log.Level) log.Logger {
+	return &Logger{sink: l.sink, level: lvl, fields: l.fields}
+}
+
+// Fatal logging.
+func (l *Logger) Fatal(args ...interface{}) {
+	log.IncreaseCounter(log.FatalLevel, l.fields)
+	if !l.shouldLog(log.FatalLevel) {
+		return
+	}
+
+	l.write(log.FatalLevel, fmt.Sprint(args...))
+	os.Exit(1)
+}
+
+// Fatalf logging.
+func (l *Logger) Fatalf(msg string, args ...interface{}) {
+	log.IncreaseCounter(log.FatalLevel, l.fields)
+	if !l.shouldLog(log.FatalLevel) {
+		return
+	}
+
+	l.write(log.FatalLevel, fmt.Sprintf(msg, args...))
+	os.Exit(1)
+}
+
+// Panic logging.
+func (l *Logger) Panic(args ...interface{}) {
+	log.IncreaseCounter(log.PanicLevel, l.fields)
+	if !l.shouldLog(log.PanicLevel) {
+		return
+	}
+
+	panic(l.write(log.PanicLevel, fmt.Sprint(args...)))
+}
+
+// Panicf logging.
+func (l *Logger) Panicf(msg string, args ...interface{}) {
+	log.IncreaseCounter(log.PanicLevel, l.fields)
+	if !l.shouldLog(log.PanicLevel) {
+		return
+	}
+
+	panic(l.write(log.PanicLevel, fmt.Sprintf(msg, args...)))
+}
+
+// Error logging.
+func (l *Logger) Error(args ...interface{}) {
+	log.IncreaseCounter(log.ErrorLevel, l.fields)
+	if !l.shouldLog(log.ErrorLevel) {
+		return
+	}
+
+	l.write(log.ErrorLevel, fmt.Sprint(args...))
+}
+
+// Errorf logging.
+func (l *Logger) Errorf(msg string, args ...interface{}) {
+	log.IncreaseCounter(log.ErrorLevel, l.fields)
+	if !l.shouldLog(log.ErrorLevel) {
+		return
+	}
+
+	l.write(log.ErrorLevel, fmt.Sprintf(msg, args...))
+}
+
+// Warn logging.
+func (l *Logger) Warn(args ...interface{}) {
+	log.IncreaseCounter(log.WarnLevel, l.fields)
+	if !l.shouldLog(log.WarnLevel) {
+		return
+	}
+
+	l.write(log.WarnLevel, fmt.Sprint(args...))
+}
+
+// Warnf logging.
+func (l *Logger) Warnf(msg string, args ...interface{}) {
+	log.IncreaseCounter(log.WarnLevel, l.fields)
+	if !l.shouldLog(log.WarnLevel) {
+		return
+	}
+
+	l.write(log.WarnLevel, fmt.Sprintf(msg, args...))
+}
+
+// Info logging.
+func (l *Logger) Info(args ...interface{}) {
+	log.IncreaseCounter(log.InfoLevel, l.fields)
+	if !l.shouldLog(log.InfoLevel) {
+		return
+	}
+
+	l.write(log.InfoLevel, fmt.Sprint(args...))
+}
+
+// Infof logging.
+func (l *Logger) Infof(msg string, args ...interface{}) {
+	log.IncreaseCounter(log.InfoLevel, l.fields)
+	if !l.shouldLog(log.InfoLevel) {
+		return
+	}
+
+	l.write(log.InfoLevel, fmt.Sprintf(msg, args...))
+}
+
+// Debug logging.
+func (l *Logger) Debug(args ...interface{}) {
+	log.IncreaseCounter(log.DebugLevel, l.fields)
+	if !l.shouldLog(log.DebugLevel) {
+		return
+	}
+
+	l.write(log.DebugLevel, fmt.Sprint(args...))
+}
+
+// Debugf logging.
+func (l *Logger) Debugf(msg string, args ...interface{}) {
+	log.IncreaseCounter(log.DebugLevel, l.fields)
+	if !l.shouldLog(log.DebugLevel) {
+		return
+	}
+
+	l.write(log.DebugLevel, fmt.Sprintf(msg, args...))
+}
+
+// Level returns the debugging level.
+func (l *Logger) Level() log.Level {
+	return l.level
+}
+
+func (l *Logger) shouldLog(lvl log.Level) bool {
+	return log.LevelOrder(lvl) >= log.LevelOrder(l.level)
+}
+
+// write sends the entry to the sink and returns the message, so that it can be used for panicking.
+// Sink failures are reported to stderr, since there is no other place to report them.
+func (l *Logger) write(lvl log.Level, msg string) string {
+	e := log.ApplyHooks(log.Entry{Level: lvl, Message: msg, Fields: l.fields})
+	if err := l.sink.Write(e); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "failed to write log entry to sink: %v\n", err)
+	}
+	return e.Message
+}
@@ -0,0 +1,110 @@
+package sink
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/beatlabs/patron/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memorySink struct {
+	mu      sync.Mutex
+	entries []log.Entry
+	err     error
+}
+
+func (ms *memorySink) Write(e log.Entry) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	ms.entries = append(ms.entries, e)
+	return ms.err
+}
+
+func TestLogger(t *testing.T) {
+	t.Parallel()
+	ms := &memorySink{}
+	l := New(ms, log.InfoLevel, map[string]interface{}{"key": "value"})
+
+	l.Debug("debug")
+	l.Debugf("debug %d", 1)
+	l.Info("info")
+	l.Infof("info %d", 1)
+	l.Warn("warn")
+	l.Warnf("warn %d", 1)
+	l.Error("error")
+	l.Errorf("error %d", 1)
+	assert.PanicsWithValue(t, "panic", func() { l.Panic("panic") })
+	assert.PanicsWithValue(t, "panic 1", func() { l.Panicf("panic %d", 1) })
+
+	require.Len(t, ms.entries, 8)
+	expected := []log.Entry{
+		{Level: log.InfoLevel, Message: "info"},
+		{Level: log.InfoLevel, Message: "info 1"},
+		{Level: log.WarnLevel, Message: "warn"},
+		{Level: log.WarnLevel, Message: "warn 1"},
+		{Level: log.ErrorLevel, Message: "error"},
+		{Level: log.ErrorLevel, Message: "error 1"},
+		{Level: log.PanicLevel, Message: "panic"},
+		{Level: log.PanicLevel, Message: "panic 1"},
+	}
+	for i, e := range expected {
+		assert.Equal(t, e.Level, ms.entries[i].Level)
+		assert.Equal(t, e.Message, ms.entries[i].Message)
+		assert.Equal(t, map[string]interface{}{"key": "value"}, ms.entries[i].Fields)
+	}
+	assert.Equal(t, log.InfoLevel, l.Level())
+}
+
+func TestLogger_Sub(t *testing.T) {
+	t.Parallel()
+	ms := &memorySink{}
+	l := New(ms, log.DebugLevel, map[string]interface{}{"key": "value"})
+
+	l.Sub(map[string]interface{}{"sub": 1}).Debug("sub")
+	l.Debug("parent")
+
+	require.Len(t, ms.entries, 2)
+	assert.Equal(t, map[string]interface{}{"key": "value", "sub": 1}, ms.entries[0].Fields)
+	assert.Equal(t, map[string]interface{}{"key": "value"}, ms.entries[1].Fields)
+}
+
+func TestLogger_Sub_FieldGroups(t *testing.T) {
+	t.Parallel()
+	ms := &memorySink{}
+	l := New(ms, log.DebugLevel, map[string]interface{}{"db": map[string]interface{}{"host": "localhost"}})
+
+	l.Sub(map[string]interface{}{"db": map[string]interface{}{"name": "patron"}}).Debug("sub")
+	l.Debug("parent")
+
+	require.Len(t, ms.entries, 2)
+	assert.Equal(t, map[string]interface{}{"db": map[string]interface{}{"host": "localhost", "name": "patron"}}, ms.entries[0].Fields)
+	assert.Equal(t, map[string]interface{}{"db": map[string]interface{}{"host": "localhost"}}, ms.entries[1].Fields)
+}
+
+func TestLogger_SinkError(t *testing.T) {
+	t.Parallel()
+	ms := &memorySink{err: errors.New("sink error")}
+	l := New(ms, log.DebugLevel, nil)
+
+	assert.NotPanics(t, func() { l.Info("info") })
+	assert.Len(t, ms.entries, 1)
+}
+
+func TestLogger_WithLevel(t *testing.T) {
+	t.Parallel()
+	ms := &memorySink{}
+	l := New(ms, log.InfoLevel, map[string]interface{}{"key": "value"})
+
+	l.Debug("dropped")
+	dl := l.WithLevel(log.DebugLevel)
+	dl.Debug("debug")
+
+	assert.Equal(t, log.DebugLevel, dl.Level())
+	assert.Equal(t, log.InfoLevel, l.Level())
+	require.Len(t, ms.entries, 1)
+	assert.Equal(t, "debug", ms.entries[0].Message)
+	assert.Equal(t, map[string]interface{}{"key": "value"}, ms.entries[0].Fields)
+}
@@ -0,0 +1,223 @@
+package sink
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/beatlabs/patron/log"
+)
+
+const (
+	// SyslogFacilityUser is the default syslog facility.
+	SyslogFacilityUser = 1
+	// SyslogFacilityLocal0 is the first of the local use syslog facilities (local0-local7).
+	SyslogFacilityLocal0 = 16
+
+	// syslogSDID is the structured data element holding the entry fields, using the private enterprise number
+	// reserved for documentation purposes.
+	syslogSDID   = "patron@32473"
+	nilValue     = "-"
+	maxSDNameLen = 32
+	maxAppLen    = 48
+)
+
+var syslogSeverity = map[log.Level]int{
+	log.PanicLevel: 1,
+	log.FatalLevel: 2,
+	log.ErrorLevel: 3,
+	log.WarnLevel:  4,
+	log.InfoLevel:  6,
+	log.DebugLevel: 7,
+}
+
+// Syslog sink writes entries as RFC5424 messages over UDP, TCP or unix sockets.
+// Entry fields are mapped to the parameters of a single structured data element.
+type Syslog struct {
+	network  string
+	address  string
+	appName  string
+	hostname string
+	facility int
+	mu       sync.Mutex
+	conn     net.Conn
+}
+
+// NewSyslog creates a syslog sink. Supported networks are udp, tcp, unix (stream) and unixgram.
+// Messages sent over stream connections are framed using octet counting (RFC6587).
+// If the app name is empty, the executable name is used.
+func NewSyslog(network, address, appName string, facility int) (*Syslog, error) {
+	switch network {
+	case "udp", "udp4", "udp6", "tcp", "tcp4", "tcp6", "unix", "unixgram":
+	default:
+		return nil, fmt.Errorf("network %q is not supported", network)
+	}
+	if address == "" {
+		return nil, errors.New("address is empty")
+	}
+	if facility < 0 || facility > 23 {
+		return nil, errors.New("facility should be between 0 and 23")
+	}
+	if appName == "" {
+		appName = filepath.Base(os.Args[0])
+	}
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = nilValue
+	}
+
+	s := &Syslog{
+		network:  network,
+		address:  address,
+		appName:  printASCII(appName, maxAppLen),
+		hostname: printASCII(hostname, 255),
+		facility: facility,
+	}
+
+	if err := s.connect(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Write sends the entry to the syslog server, reconnecting once on failure.
+func (s *Syslog) Write(e log.Entry) error {
+	msg := s.format(e, time.Now())
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn != nil {
+		if _, err := s.conn.Write(msg); err == nil {
+			return nil
+		}
+		_ = s.conn.Close()
+		s.conn = nil
+	}
+
+	if err := s.connect(); err != nil {
+		return err
+	}
+	_, err := s.conn.Write(msg)
+	return err
+}
+
+// Close closes the underlying connection.
+func (s *Syslog) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+func (s *Syslog) connect() error {
+	conn, err := net.Dial(s.network, s.address)
+	if err != nil {
+		return fmt.Errorf("failed to connect to syslog at %s://%s: %w", s.network, s.address, err)
+	}
+	s.conn = conn
+	return nil
+}
+
+func (s *Syslog) stream() bool {
+	return s.network != "unixgram" && !strings.HasPrefix(s.network, "udp")
+}
+
+func (s *Syslog) format(e log.Entry, t time.Time) []byte {
+	sev, ok := syslogSeverity[e.Level]
+	if !ok {
+		sev = syslogSeverity[log.InfoLevel]
+	}
+
+	sb := strings.Builder{}
+	sb.WriteRune('<')
+	sb.WriteString(strconv.Itoa(s.facility*8 + sev))
+	sb.WriteString(">1 ")
+	sb.WriteString(t.UTC().Format(time.RFC3339Nano))
+	sb.WriteRune(' ')
+	sb.WriteString(s.hostname)
+	sb.WriteRune(' ')
+	sb.WriteString(s.appName)
+	sb.WriteRune(' ')
+	sb.WriteString(strconv.Itoa(os.Getpid()))
+	sb.WriteString(" - ")
+	writeStructuredData(&sb, e.Fields)
+	if e.Message != "" {
+		sb.WriteRune(' ')
+		sb.WriteString(e.Message)
+	}
+
+	msg := sb.String()
+	if s.stream() {
+		msg = strconv.Itoa(len(msg)) + " " + msg
+	}
+	return []byte(msg)
+}
+
+func writeStructuredData(sb *strings.Builder, fields map[string]interface{}) {
+	if len(fields) == 0 {
+		sb.WriteString(nilValue)
+		return
+	}
+
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	sb.WriteRune('[')
+	sb.WriteString(syslogSDID)
+	for _, key := range keys {
+		sb.WriteRune(' ')
+		sb.WriteString(sdName(key))
+		sb.WriteString(`="`)
+		sb.WriteString(sdValue(log.FormatFieldValue(fields[key])))
+		sb.WriteRune('"')
+	}
+	sb.WriteRune(']')
+}
+
+// sdName replaces the characters which are not allowed in structured data parameter names.
+func sdName(key string) string {
+	name := []rune(printASCII(key, maxSDNameLen))
+	for i, r := range name {
+		if r == '=' || r == ']' || r == '"' {
+			name[i] = '_'
+		}
+	}
+	return string(name)
+}
+
+// sdValue escapes the characters which are not allowed in structured data parameter values.
+func sdValue(val string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`).Replace(val)
+}
+
+// printASCII replaces any non printable US-ASCII characters and truncates the value to the max length.
+func printASCII(val string, max int) string {
+	if val == "" {
+		return nilValue
+	}
+	b := []byte(val)
+	for i, c := range b {
+		if c < 33 || c > 126 {
+			b[i] = '_'
+		}
+	}
+	if len(b) > max {
+		b = b[:max]
+	}
+	return string(b)
+}
@@ -0,0 +1,163 @@
+package sink
+
+import (
+	"bufio"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/beatlabs/patron/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSyslog(t *testing.T) {
+	t.Parallel()
+	type args struct {
+		network  string
+		address  string
+		facility int
+	}
+	tests := map[string]struct {
+		args        args
+		expectedErr string
+	}{
+		"success":              {args: args{network: "udp", address: "127.0.0.1:514", facility: SyslogFacilityUser}},
+		"unsupported network":  {args: args{network: "ip", address: "127.0.0.1:514"}, expectedErr: "network \"ip\" is not supported"},
+		"missing address":      {args: args{network: "udp"}, expectedErr: "address is empty"},
+		"invalid facility":     {args: args{network: "udp", address: "127.0.0.1:514", facility: 24}, expectedErr: "facility should be between 0 and 23"},
+		"connection failed":    {args: args{network: "unix", address: "/non/existent/socket"}, expectedErr: "failed to connect to syslog at unix:///non/existent/socket"},
+		"local facility works": {args: args{network: "udp", address: "127.0.0.1:514", facility: SyslogFacilityLocal0}},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			got, err := NewSyslog(tt.args.network, tt.args.address, "app", tt.args.facility)
+			if tt.expectedErr != "" {
+				assert.Nil(t, got)
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedErr)
+			} else {
+				require.NoError(t, err)
+				assert.NotNil(t, got)
+				assert.NoError(t, got.Close())
+			}
+		})
+	}
+}
+
+func TestSyslog_Format(t *testing.T) {
+	t.Parallel()
+	s := &Syslog{network: "udp", appName: "app", hostname: "host", facility: SyslogFacilityLocal0}
+	tm := time.Date(2021, 3, 4, 5, 6, 7, 8000, time.UTC)
+	pid := strconv.Itoa(os.Getpid())
+
+	tests := map[string]struct {
+		entry    log.Entry
+		network  string
+		expected string
+	}{
+		"no fields": {
+			entry:    log.Entry{Level: log.ErrorLevel, Message: "msg"},
+			expected: "<131>1 2021-03-04T05:06:07.000008Z host app " + pid + " - - msg",
+		},
+		"fields": {
+			entry:    log.Entry{Level: log.InfoLevel, Message: "msg", Fields: map[string]interface{}{"b": `a "quoted" \ value]`, "a key=": 1}},
+			expected: "<134>1 2021-03-04T05:06:07.000008Z host app " + pid + ` - [patron@32473 a_key_="1" b="a \"quoted\" \\ value\]"] msg`,
+		},
+		"field group": {
+			entry:    log.Entry{Level: log.InfoLevel, Message: "msg", Fields: map[string]interface{}{"db": map[string]interface{}{"host": "localhost", "name": "patron"}}},
+			expected: "<134>1 2021-03-04T05:06:07.000008Z host app " + pid + ` - [patron@32473 db="{\"host\":\"localhost\",\"name\":\"patron\"}"] msg`,
+		},
+		"tcp framing": {
+			entry:    log.Entry{Level: log.DebugLevel, Message: "msg"},
+			network:  "tcp",
+			expected: "<135>1 2021-03-04T05:06:07.000008Z host app " + pid + " - - msg",
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			s := &Syslog{network: s.network, appName: s.appName, hostname: s.hostname, facility: s.facility}
+			if tt.network != "" {
+				s.network = tt.network
+			}
+			got := string(s.format(tt.entry, tm))
+			if s.stream() {
+				assert.Equal(t, strconv.Itoa(len(tt.expected))+" "+tt.expected, got)
+			} else {
+				assert.Equal(t, tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestSyslog_Write_UDP(t *testing.T) {
+	t.Parallel()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer func() { _ = conn.Close() }()
+
+	s, err := NewSyslog("udp", conn.LocalAddr().String(), "app", SyslogFacilityUser)
+	require.NoError(t, err)
+	defer func() { _ = s.Close() }()
+
+	require.NoError(t, s.Write(log.Entry{Level: log.WarnLevel, Message: "hello", Fields: map[string]interface{}{"key": "value"}}))
+
+	buf := make([]byte, 1024)
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+	n, _, err := conn.ReadFrom(buf)
+	require.NoError(t, err)
+	msg := string(buf[:n])
+	assert.True(t, strings.HasPrefix(msg, "<12>1 "))
+	assert.True(t, strings.HasSuffix(msg, ` - [patron@32473 key="value"] hello`))
+}
+
+func TestSyslog_Write_Unix(t *testing.T) {
+	t.Parallel()
+	dir, err := os.MkdirTemp("", "syslog")
+	require.NoError(t, err)
+	defer func() { _ = os.RemoveAll(dir) }()
+	path := filepath.Join(dir, "log.sock")
+
+	ln, err := net.Listen("unix", path)
+	require.NoError(t, err)
+	defer func() { _ = ln.Close() }()
+
+	ch := make(chan string, 2)
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			line, err := bufio.NewReader(c).ReadString('\n')
+			if err == nil {
+				ch <- line
+			}
+			_ = c.Close()
+		}
+	}()
+
+	s, err := NewSyslog("unix", path, "app", SyslogFacilityUser)
+	require.NoError(t, err)
+	defer func() { _ = s.Close() }()
+
+	require.NoError(t, s.Write(log.Entry{Level: log.InfoLevel, Message: "hello\n"}))
+
+	select {
+	case line := <-ch:
+		idx := strings.IndexRune(line, ' ')
+		require.Greater(t, idx, 0)
+		assert.Equal(t, line[:idx], strconv.Itoa(len(line)-idx-1))
+		assert.True(t, strings.HasSuffix(line, " - - hello\n"))
+	case <-time.After(time.Second):
+		assert.Fail(t, "message was not received")
+	}
+}
@@ -0,0 +1,103 @@
+//go:build go1.21
+// +build go1.21
+
+package log
+
+import (
+	"context"
+	"log/slog"
+)
+
+// AsSlogHandler returns a slog.Handler which writes to the logger set up in this package,
+// or the one found in the context passed to the slog logging methods.
+// This allows third-party libraries logging via slog to end up in the same sink, with the same fields and level filtering.
+// Groups are rendered by prefixing the keys with the group name, separated by a dot.
+func AsSlogHandler() slog.Handler {
+	return &slogHandler{}
+}
+
+type slogHandler struct {
+	fields map[string]interface{}
+	group  string
+}
+
+// Enabled reports whether the configured logger logs at the given level.
+func (h *slogHandler) Enabled(ctx context.Context, lvl slog.Level) bool {
+	return LevelOrder(FromContext(ctx).Level()) <= LevelOrder(fromSlogLevel(lvl))
+}
+
+// Handle writes the record with its attributes as fields.
+func (h *slogHandler) Handle(ctx context.Context, r slog.Record) error {
+	ff := make(map[string]interface{}, len(h.fields)+r.NumAttrs())
+	for k, v := range h.fields {
+		ff[k] = v
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		addSlogAttr(ff, h.group, a)
+		return true
+	})
+
+	l := FromContext(ctx)
+	if len(ff) > 0 {
+		l = l.Sub(ff)
+	}
+	write(l, fromSlogLevel(r.Level), r.Message)
+	return nil
+}
+
+// WithAttrs returns a new handler with the attributes added as fields.
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	ff := make(map[string]interface{}, len(h.fields)+len(attrs))
+	for k, v := range h.fields {
+		ff[k] = v
+	}
+	for _, a := range attrs {
+		addSlogAttr(ff, h.group, a)
+	}
+	return &slogHandler{fields: ff, group: h.group}
+}
+
+// WithGroup returns a new handler which prefixes all following attribute keys with the group name.
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &slogHandler{fields: h.fields, group: groupKey(h.group, name)}
+}
+
+func addSlogAttr(ff map[string]interface{}, group string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		for _, ga := range a.Value.Group() {
+			addSlogAttr(ff, groupKey(group, a.Key), ga)
+		}
+		return
+	}
+	ff[groupKey(group, a.Key)] = a.Value.Any()
+}
+
+func groupKey(group, key string) string {
+	if group == "" {
+		return key
+	}
+	if key == "" {
+		return group
+	}
+	return group + "." + key
+}
+
+func fromSlogLevel(lvl slog.Level) Level {
+	switch {
+	case lvl < slog.LevelInfo:
+		return DebugLevel
+	case lvl < slog.LevelWarn:
+		return InfoLevel
+	case lvl < slog.LevelError:
+		return WarnLevel
+	default:
+		return ErrorLevel
+	}
+}
@@ -0,0 +1,63 @@
+//go:build go1.21
+// +build go1.21
+
+package log
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAsSlogHandler(t *testing.T) {
+	rl := &recordingLogger{}
+	rl.level = InfoLevel
+	logger = rl
+
+	sl := slog.New(AsSlogHandler()).With("service", "test")
+	sl.Debug("filtered")
+	sl.Info("hello", "key", "value")
+	sl.WithGroup("db").Warn("slow", slog.String("host", "localhost"), slog.Group("stats", slog.Int("rows", 1)))
+	sl.Error("failed")
+
+	assert.Equal(t, []string{
+		"info hello map[key:value service:test]",
+		"warn slow map[db.host:localhost db.stats.rows:1 service:test]",
+		"error failed map[service:test]",
+	}, rl.entries())
+}
+
+func TestAsSlogHandler_Context(t *testing.T) {
+	logger = &recordingLogger{}
+	rl := &recordingLogger{}
+	rl.level = DebugLevel
+
+	ctx := WithContext(context.Background(), rl)
+	slog.New(AsSlogHandler()).DebugContext(ctx, "from context")
+
+	assert.Equal(t, []string{"debug from context map[]"}, rl.entries())
+}
+
+func TestFromSlogLevel(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		lvl  slog.Level
+		want Level
+	}{
+		"below debug": {lvl: slog.LevelDebug - 4, want: DebugLevel},
+		"debug":       {lvl: slog.LevelDebug, want: DebugLevel},
+		"info":        {lvl: slog.LevelInfo, want: InfoLevel},
+		"warn":        {lvl: slog.LevelWarn, want: WarnLevel},
+		"error":       {lvl: slog.LevelError, want: ErrorLevel},
+		"above error": {lvl: slog.LevelError + 4, want: ErrorLevel},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.want, fromSlogLevel(tt.lvl))
+		})
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"os"
 	"sort"
 	"strings"
+	"time"
 
 	patronLog "github.com/beatlabs/patron/log"
 )
@@ -26,6 +27,7 @@ type Logger struct {
 	level      patronLog.Level
 	fields     map[string]interface{}
 	fieldsLine string
+	flags      int
 	out        io.Writer
 	debug      *log.Logger
 	info       *log.Logger
@@ -41,7 +43,16 @@ func New(out io.Writer, lvl patronLog.Level, fields map[string]interface{}) *Log
 }
 
 // NewWithFlags constructor.
+// If a timestamp format has been configured in the log package and the flags contain the date or time,
+// the timestamp is written in the configured format instead.
 func NewWithFlags(out io.Writer, lvl patronLog.Level, fields map[string]interface{}, flags int) *Logger {
+	if patronLog.TimestampConfigured() && flags&(log.Ldate|log.Ltime) != 0 {
+		flags &^= log.Ldate | log.Ltime | log.Lmicroseconds | log.LUTC
+		if _, ok := out.(*timestampWriter); !ok {
+			out = &timestampWriter{out: out}
+		}
+	}
+
 	fieldsLine := createFieldsLine(fields)
 
 	return &Logger{
@@ -54,6 +65,7 @@ func NewWithFlags(out io.Writer, lvl patronLog.Level, fields map[string]interfac
 		level:      lvl,
 		fields:     fields,
 		fieldsLine: fieldsLine,
+		flags:      flags,
 		out:        out,
 	}
 }
@@ -76,13 +88,30 @@ func createFieldsLine(fields map[string]interface{}) string {
 	for _, key := range keys {
 		sb.WriteString(key)
 		sb.WriteRune('=')
-		sb.WriteString(fmt.Sprintf("%v", fields[key]))
+		sb.WriteString(patronLog.FormatFieldValue(fields[key]))
 		sb.WriteRune(' ')
 	}
 
 	return sb.String()
 }
 
+// timestampWriter prefixes every entry with the timestamp in the configured format.
+// The standard logger writes each entry with a single call to Write.
+type timestampWriter struct {
+	out io.Writer
+}
+
+func (tw *timestampWriter) Write(p []byte) (int, error) {
+	ts := patronLog.FormatTimestamp(time.Now()) + " "
+	n, err := tw.out.Write(append([]byte(ts), p...))
+	if n > len(ts) {
+		n -= len(ts)
+	} else {
+		n = 0
+	}
+	return n, err
+}
+
 func createLogger(out io.Writer, lvl patronLog.Level, fieldLine string, flags int) *log.Logger {
 	logger := log.New(out, "lvl="+levelMap[lvl]+" "+fieldLine, flags)
 	return logger
@@ -90,133 +119,134 @@ func createLogger(out io.Writer, lvl patronLog.Level, fieldLine string, flags in
 
 // Sub returns a sub logger with additional fields.
 func (l *Logger) Sub(fields map[string]interface{}) patronLog.Logger {
-	for key, value := range l.fields {
-		fields[key] = value
-	}
+	return New(l.out, l.level, patronLog.MergeFields(l.fields, fields))
+}
 
-	return New(l.out, l.level, fields)
+// WithLevel returns a copy of the logger with a different level.
+func (l *Logger) WithLevel(lvl patronLog.Level) patronLog.Logger {
+	return NewWithFlags(l.out, lvl, l.fields, l.flags)
 }
 
 // Fatal logging.
 func (l *Logger) Fatal(args ...interface{}) {
-	patronLog.IncreaseFatalCounter()
+	patronLog.IncreaseCounter(patronLog.FatalLevel, l.fields)
 	if !l.shouldLog(patronLog.FatalLevel) {
 		return
 	}
 
-	output(l.fatal, args...)
+	output(l.hooked(l.fatal, patronLog.FatalLevel, fmt.Sprint(args...)))
 	os.Exit(1)
 }
 
 // Fatalf logging.
 func (l *Logger) Fatalf(msg string, args ...interface{}) {
-	patronLog.IncreaseFatalCounter()
+	patronLog.IncreaseCounter(patronLog.FatalLevel, l.fields)
 	if !l.shouldLog(patronLog.FatalLevel) {
 		return
 	}
 
-	outputf(l.fatal, msg, args...)
+	output(l.hooked(l.fatal, patronLog.FatalLevel, fmt.Sprintf(msg, args...)))
 	os.Exit(1)
 }
 
 // Panic logging.
 func (l *Logger) Panic(args ...interface{}) {
-	patronLog.IncreasePanicCounter()
+	patronLog.IncreaseCounter(patronLog.PanicLevel, l.fields)
 	if !l.shouldLog(patronLog.PanicLevel) {
 		return
 	}
 
-	panic(output(l.panic, args...))
+	panic(output(l.hooked(l.panic, patronLog.PanicLevel, fmt.Sprint(args...))))
 }
 
 // Panicf logging.
 func (l *Logger) Panicf(msg string, args ...interface{}) {
-	patronLog.IncreasePanicCounter()
+	patronLog.IncreaseCounter(patronLog.PanicLevel, l.fields)
 	if !l.shouldLog(patronLog.PanicLevel) {
 		return
 	}
 
-	panic(outputf(l.panic, msg, args...))
+	panic(output(l.hooked(l.panic, patronLog.PanicLevel, fmt.Sprintf(msg, args...))))
 }
 
 // Error logging.
 func (l *Logger) Error(args ...interface{}) {
-	patronLog.IncreaseErrorCounter()
+	patronLog.IncreaseCounter(patronLog.ErrorLevel, l.fields)
 	if !l.shouldLog(patronLog.ErrorLevel) {
 		return
 	}
 
-	output(l.error, args...)
+	output(l.hooked(l.error, patronLog.ErrorLevel, fmt.Sprint(args...)))
 }
 
 // Errorf logging.
 func (l *Logger) Errorf(msg string, args ...interface{}) {
-	patronLog.IncreaseErrorCounter()
+	patronLog.IncreaseCounter(patronLog.ErrorLevel, l.fields)
 	if !l.shouldLog(patronLog.ErrorLevel) {
 		return
 	}
 
-	outputf(l.error, msg, args...)
+	output(l.hooked(l.error, patronLog.ErrorLevel, fmt.Sprintf(msg, args...)))
 }
 
 // Warn logging.
 func (l *Logger) Warn(args ...interface{}) {
-	patronLog.IncreaseWarnCounter()
+	patronLog.IncreaseCounter(patronLog.WarnLevel, l.fields)
 	if !l.shouldLog(patronLog.WarnLevel) {
 		return
 	}
 
-	output(l.warn, args...)
+	output(l.hooked(l.warn, patronLog.WarnLevel, fmt.Sprint(args...)))
 }
 
 // Warnf logging.
 func (l *Logger) Warnf(msg string, args ...interface{}) {
-	patronLog.IncreaseWarnCounter()
+	patronLog.IncreaseCounter(patronLog.WarnLevel, l.fields)
 	if !l.shouldLog(patronLog.WarnLevel) {
 		return
 	}
 
-	outputf(l.warn, msg, args...)
+	output(l.hooked(l.warn, patronLog.WarnLevel, fmt.Sprintf(msg, args...)))
 }
 
 // Info logging.
 func (l *Logger) Info(args ...interface{}) {
-	patronLog.IncreaseInfoCounter()
+	patronLog.IncreaseCounter(patronLog.InfoLevel, l.fields)
 	if !l.shouldLog(patronLog.InfoLevel) {
 		return
 	}
 
-	output(l.info, args...)
+	output(l.hooked(l.info, patronLog.InfoLevel, fmt.Sprint(args...)))
 }
 
 // Infof logging.
 func (l *Logger) Infof(msg string, args ...interface{}) {
-	patronLog.IncreaseInfoCounter()
+	patronLog.IncreaseCounter(patronLog.InfoLevel, l.fields)
 	if !l.shouldLog(patronLog.InfoLevel) {
 		return
 	}
 
-	outputf(l.info, msg, args...)
+	output(l.hooked(l.info, patronLog.InfoLevel, fmt.Sprintf(msg, args...)))
 }
 
 // Debug logging.
 func (l *Logger) Debug(args ...interface{}) {
-	patronLog.IncreaseDebugCounter()
+	patronLog.IncreaseCounter(patronLog.DebugLevel, l.fields)
 	if !l.shouldLog(patronLog.DebugLevel) {
 		return
 	}
 
-	output(l.debug, args...)
+	output(l.hooked(l.debug, patronLog.DebugLevel, fmt.Sprint(args...)))
 }
 
 // Debugf logging.
 func (l *Logger) Debugf(msg string, args ...interface{}) {
-	patronLog.IncreaseDebugCounter()
+	patronLog.IncreaseCounter(patronLog.DebugLevel, l.fields)
 	if !l.shouldLog(patronLog.DebugLevel) {
 		return
 	}
 
-	outputf(l.debug, msg, args...)
+	output(l.hooked(l.debug, patronLog.DebugLevel, fmt.Sprintf(msg, args...)))
 }
 
 // Level of the logging.
@@ -228,14 +258,17 @@ func (l *Logger) shouldLog(lvl patronLog.Level) bool {
 	return patronLog.LevelOrder(l.level) <= patronLog.LevelOrder(lvl)
 }
 
-func output(logger *log.Logger, args ...interface{}) string {
-	msg := fmt.Sprint(args...)
-	_ = logger.Output(4, msg)
-	return msg
+// hooked applies the registered hooks to the entry and returns a logger carrying the resulting fields.
+func (l *Logger) hooked(logger *log.Logger, lvl patronLog.Level, msg string) (*log.Logger, string) {
+	if !patronLog.HasHooks() {
+		return logger, msg
+	}
+
+	e := patronLog.ApplyHooks(patronLog.Entry{Level: lvl, Message: msg, Fields: l.fields})
+	return createLogger(l.out, lvl, createFieldsLine(e.Fields), l.flags), e.Message
 }
 
-func outputf(logger *log.Logger, msg string, args ...interface{}) string {
-	fmtMsg := fmt.Sprintf(msg, args...)
-	_ = logger.Output(4, fmtMsg)
-	return fmtMsg
+func output(logger *log.Logger, msg string) string {
+	_ = logger.Output(4, msg)
+	return msg
 }
@@ -3,12 +3,14 @@ package std
 import (
 	"bytes"
 	"fmt"
+	"regexp"
 	"testing"
 	"time"
 
 	"github.com/beatlabs/patron/log"
 	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewLogger(t *testing.T) {
@@ -275,3 +277,64 @@ func BenchmarkLogger(b *testing.B) {
 	}
 	buf = tmpBuf
 }
+
+func TestLogger_Hooks(t *testing.T) {
+	defer log.ResetHooks()
+	log.RegisterHook(func(e log.Entry) log.Entry {
+		e.Fields["host"] = "local"
+		e.Fields["name"] = "jane doe"
+		e.Message = "[" + string(e.Level) + "] " + e.Message
+		return e
+	})
+
+	var b bytes.Buffer
+	logger := New(&b, log.DebugLevel, map[string]interface{}{"name": "john doe", "age": 18})
+	logger.Info("hello world")
+	assert.Contains(t, b.String(), "lvl=INF age=18 host=local name=jane doe [info] hello world")
+	b.Reset()
+	logger.Warnf("Hi, %s", "John")
+	assert.Contains(t, b.String(), "lvl=WRN age=18 host=local name=jane doe [warn] Hi, John")
+	assert.Equal(t, map[string]interface{}{"name": "john doe", "age": 18}, logger.fields)
+}
+
+func TestLogger_TimestampFormat(t *testing.T) {
+	defer log.ResetTimestampFormat()
+	require.NoError(t, log.SetTimestampFormat(log.TimestampEpochMillis, nil))
+
+	var b bytes.Buffer
+	l := New(&b, log.DebugLevel, map[string]interface{}{"key": "value"})
+	l.Sub(map[string]interface{}{"sub": "value"}).Info("message")
+	assert.Regexp(t, regexp.MustCompile(`^\d{13} lvl=INF key=value sub=value message\n$`), b.String())
+
+	b.Reset()
+	l = NewWithFlags(&b, log.DebugLevel, nil, 0)
+	l.Info("message")
+	assert.Equal(t, "lvl=INF message\n", b.String())
+}
+
+func TestLogger_WithLevel(t *testing.T) {
+	var b bytes.Buffer
+	l := New(&b, log.InfoLevel, map[string]interface{}{"name": "john doe"})
+
+	l.Debug("dropped")
+	assert.Empty(t, b.String())
+
+	dl := l.WithLevel(log.DebugLevel)
+	assert.Equal(t, log.DebugLevel, dl.Level())
+	assert.Equal(t, log.InfoLevel, l.Level())
+	dl.Debug("debug")
+	assert.Contains(t, b.String(), "debug")
+	assert.Contains(t, b.String(), "name=john doe")
+}
+
+func TestLogger_FieldGroups(t *testing.T) {
+	var b bytes.Buffer
+	l := New(&b, log.InfoLevel, map[string]interface{}{"db": map[string]interface{}{"host": "localhost"}})
+
+	l.Sub(map[string]interface{}{"db": map[string]interface{}{"name": "patron"}}).Info("sub")
+	assert.Contains(t, b.String(), `db={"host":"localhost","name":"patron"} sub`)
+
+	b.Reset()
+	l.Info("parent")
+	assert.Contains(t, b.String(), `db={"host":"localhost"} parent`)
+}
@@ -0,0 +1,90 @@
+package log
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// TimestampRFC3339 formats timestamps according to RFC3339 with nanosecond precision, which is the default.
+	TimestampRFC3339 = "rfc3339"
+	// TimestampEpochMillis formats timestamps as the number of milliseconds elapsed since the Unix epoch.
+	TimestampEpochMillis = "epoch_millis"
+)
+
+var (
+	tsMu         sync.RWMutex
+	tsLayout     = time.RFC3339Nano
+	tsLocation   = time.Local
+	tsEpoch      bool
+	tsConfigured bool
+)
+
+// SetTimestampFormat configures the format and timezone of the timestamps written by the bundled logger implementations.
+// The format is either TimestampRFC3339, TimestampEpochMillis or a custom time layout e.g. "2006-01-02 15:04:05.000".
+// A nil location defaults to UTC. Loggers created before the call are not affected.
+func SetTimestampFormat(format string, loc *time.Location) error {
+	if format == "" {
+		return errors.New("timestamp format is empty")
+	}
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	tsMu.Lock()
+	defer tsMu.Unlock()
+
+	tsEpoch = false
+	switch format {
+	case TimestampRFC3339:
+		tsLayout = time.RFC3339Nano
+	case TimestampEpochMillis:
+		tsLayout = ""
+		tsEpoch = true
+	default:
+		tsLayout = format
+	}
+	tsLocation = loc
+	tsConfigured = true
+	return nil
+}
+
+// ResetTimestampFormat restores the default timestamp format, which is RFC3339 in the local timezone.
+func ResetTimestampFormat() {
+	tsMu.Lock()
+	defer tsMu.Unlock()
+	tsLayout = time.RFC3339Nano
+	tsLocation = time.Local
+	tsEpoch = false
+	tsConfigured = false
+}
+
+// TimestampConfigured returns true if the timestamp format has been set explicitly.
+func TimestampConfigured() bool {
+	tsMu.RLock()
+	defer tsMu.RUnlock()
+	return tsConfigured
+}
+
+// TimestampValue returns the time according to the configured format,
+// either as an int64 for epoch millis or as a string in every other case.
+func TimestampValue(t time.Time) interface{} {
+	tsMu.RLock()
+	defer tsMu.RUnlock()
+	if tsEpoch {
+		return t.UnixNano() / int64(time.Millisecond)
+	}
+	return t.In(tsLocation).Format(tsLayout)
+}
+
+// FormatTimestamp returns the time formatted according to the configured format.
+func FormatTimestamp(t time.Time) string {
+	switch v := TimestampValue(t).(type) {
+	case int64:
+		return strconv.FormatInt(v, 10)
+	default:
+		return v.(string)
+	}
+}
@@ -0,0 +1,56 @@
+package log
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetTimestampFormat(t *testing.T) {
+	defer ResetTimestampFormat()
+
+	athens, err := time.LoadLocation("Europe/Athens")
+	require.NoError(t, err)
+	tm := time.Date(2021, 3, 4, 5, 6, 7, 8000000, time.UTC)
+
+	tests := map[string]struct {
+		format        string
+		loc           *time.Location
+		expectedValue interface{}
+		expectedErr   string
+	}{
+		"rfc3339":       {format: TimestampRFC3339, expectedValue: "2021-03-04T05:06:07.008Z"},
+		"rfc3339 local": {format: TimestampRFC3339, loc: athens, expectedValue: "2021-03-04T07:06:07.008+02:00"},
+		"epoch millis":  {format: TimestampEpochMillis, loc: athens, expectedValue: int64(1614834367008)},
+		"custom layout": {format: "2006-01-02 15:04:05.000", loc: athens, expectedValue: "2021-03-04 07:06:07.008"},
+		"empty format":  {format: "", expectedErr: "timestamp format is empty"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			ResetTimestampFormat()
+			err := SetTimestampFormat(tt.format, tt.loc)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.False(t, TimestampConfigured())
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, TimestampConfigured())
+			assert.Equal(t, tt.expectedValue, TimestampValue(tm))
+		})
+	}
+}
+
+func TestFormatTimestamp(t *testing.T) {
+	defer ResetTimestampFormat()
+	tm := time.Date(2021, 3, 4, 5, 6, 7, 8000000, time.UTC)
+
+	require.NoError(t, SetTimestampFormat(TimestampEpochMillis, nil))
+	assert.Equal(t, "1614834367008", FormatTimestamp(tm))
+
+	require.NoError(t, SetTimestampFormat(time.Kitchen, nil))
+	assert.Equal(t, "5:06AM", FormatTimestamp(tm))
+}
@@ -41,20 +41,27 @@ func init() {
 type Logger struct {
 	logger  *zerolog.Logger
 	loggerf *zerolog.Logger
-	level   log.Level
+	// bare and baref have no fields attached and are used when hooks are registered,
+	// since hooks might override any of the logger fields.
+	bare   *zerolog.Logger
+	baref  *zerolog.Logger
+	fields map[string]interface{}
+	level  log.Level
 }
 
 // New creates a new logger.
 func New(out io.Writer, lvl log.Level, f map[string]interface{}) log.Logger {
-	zl := zerolog.New(out).With().Timestamp().Logger().Hook(defaultSourceHook)
-	zlf := zerolog.New(out).With().Timestamp().Logger().Hook(defaultSourceHookWithFormat)
+	zl := zerolog.New(out).Hook(timestampHook{}).Hook(defaultSourceHook)
+	zlf := zerolog.New(out).Hook(timestampHook{}).Hook(defaultSourceHookWithFormat)
 
 	if len(f) == 0 {
 		f = make(map[string]interface{})
 	}
-	logger := zl.Level(levelMap[lvl]).With().Fields(f).Logger()
-	loggerf := zlf.Level(levelMap[lvl]).With().Fields(f).Logger()
-	return &Logger{logger: &logger, loggerf: &loggerf, level: lvl}
+	bare := zl.Level(levelMap[lvl])
+	baref := zlf.Level(levelMap[lvl])
+	logger := bare.With().Fields(f).Logger()
+	loggerf := baref.With().Fields(f).Logger()
+	return &Logger{logger: &logger, loggerf: &loggerf, bare: &bare, baref: &baref, fields: f, level: lvl}
 }
 
 // Sub returns a sub logger with new fields attached.
@@ -62,80 +69,187 @@ func (l *Logger) Sub(ff map[string]interface{}) log.Logger {
 	if ff == nil {
 		return l
 	}
-	logger := l.logger.With().Fields(ff).Logger()
-	loggerf := l.loggerf.With().Fields(ff).Logger()
-	return &Logger{logger: &logger, loggerf: &loggerf, level: l.level}
+	// the loggers are recreated from the bare ones, so that field groups of the parent are merged instead of duplicated
+	fields := log.MergeFields(l.fields, ff)
+	logger := l.bare.With().Fields(fields).Logger()
+	loggerf := l.baref.With().Fields(fields).Logger()
+	return &Logger{logger: &logger, loggerf: &loggerf, bare: l.bare, baref: l.baref, fields: fields, level: l.level}
+}
+
+// WithLevel returns a copy of the logger with a different level.
+func (l *Logger) WithLevel(lvl log.Level) log.Logger {
+	logger := l.logger.Level(levelMap[lvl])
+	loggerf := l.loggerf.Level(levelMap[lvl])
+	bare := l.bare.Level(levelMap[lvl])
+	baref := l.baref.Level(levelMap[lvl])
+	return &Logger{logger: &logger, loggerf: &loggerf, bare: &bare, baref: &baref, fields: l.fields, level: lvl}
 }
 
 // Panic logging.
 func (l *Logger) Panic(args ...interface{}) {
-	log.IncreasePanicCounter()
+	log.IncreaseCounter(log.PanicLevel, l.fields)
+	if !l.shouldLog(log.PanicLevel) {
+		return
+	}
+	if log.HasHooks() {
+		e, msg := l.hooked(l.bare.Panic(), log.PanicLevel, fmt.Sprint(args...))
+		e.Msg(msg)
+		return
+	}
 	l.logger.Panic().Msg(fmt.Sprint(args...))
 }
 
 // Panicf logging.
 func (l *Logger) Panicf(msg string, args ...interface{}) {
-	log.IncreasePanicCounter()
+	log.IncreaseCounter(log.PanicLevel, l.fields)
+	if !l.shouldLog(log.PanicLevel) {
+		return
+	}
+	if log.HasHooks() {
+		e, msg := l.hooked(l.baref.Panic(), log.PanicLevel, fmt.Sprintf(msg, args...))
+		e.Msg(msg)
+		return
+	}
 	l.loggerf.Panic().Msgf(msg, args...)
 }
 
 // Fatal logging.
 func (l *Logger) Fatal(args ...interface{}) {
-	log.IncreaseFatalCounter()
+	log.IncreaseCounter(log.FatalLevel, l.fields)
+	if !l.shouldLog(log.FatalLevel) {
+		return
+	}
+	if log.HasHooks() {
+		e, msg := l.hooked(l.bare.Fatal(), log.FatalLevel, fmt.Sprint(args...))
+		e.Msg(msg)
+		return
+	}
 	l.logger.Fatal().Msg(fmt.Sprint(args...))
 }
 
 // Fatalf logging.
 func (l *Logger) Fatalf(msg string, args ...interface{}) {
-	log.IncreaseFatalCounter()
+	log.IncreaseCounter(log.FatalLevel, l.fields)
+	if !l.shouldLog(log.FatalLevel) {
+		return
+	}
+	if log.HasHooks() {
+		e, msg := l.hooked(l.baref.Fatal(), log.FatalLevel, fmt.Sprintf(msg, args...))
+		e.Msg(msg)
+		return
+	}
 	l.loggerf.Fatal().Msgf(msg, args...)
 }
 
 // Error logging.
 func (l *Logger) Error(args ...interface{}) {
-	log.IncreaseErrorCounter()
+	log.IncreaseCounter(log.ErrorLevel, l.fields)
+	if !l.shouldLog(log.ErrorLevel) {
+		return
+	}
+	if log.HasHooks() {
+		e, msg := l.hooked(l.bare.Error(), log.ErrorLevel, fmt.Sprint(args...))
+		e.Msg(msg)
+		return
+	}
 	l.logger.Error().Msg(fmt.Sprint(args...))
 }
 
 // Errorf logging.
 func (l *Logger) Errorf(msg string, args ...interface{}) {
-	log.IncreaseErrorCounter()
+	log.IncreaseCounter(log.ErrorLevel, l.fields)
+	if !l.shouldLog(log.ErrorLevel) {
+		return
+	}
+	if log.HasHooks() {
+		e, msg := l.hooked(l.baref.Error(), log.ErrorLevel, fmt.Sprintf(msg, args...))
+		e.Msg(msg)
+		return
+	}
 	l.loggerf.Error().Msgf(msg, args...)
 }
 
 // Warn logging.
 func (l *Logger) Warn(args ...interface{}) {
-	log.IncreaseWarnCounter()
+	log.IncreaseCounter(log.WarnLevel, l.fields)
+	if !l.shouldLog(log.WarnLevel) {
+		return
+	}
+	if log.HasHooks() {
+		e, msg := l.hooked(l.bare.Warn(), log.WarnLevel, fmt.Sprint(args...))
+		e.Msg(msg)
+		return
+	}
 	l.logger.Warn().Msg(fmt.Sprint(args...))
 }
 
 // Warnf logging.
 func (l *Logger) Warnf(msg string, args ...interface{}) {
-	log.IncreaseWarnCounter()
+	log.IncreaseCounter(log.WarnLevel, l.fields)
+	if !l.shouldLog(log.WarnLevel) {
+		return
+	}
+	if log.HasHooks() {
+		e, msg := l.hooked(l.baref.Warn(), log.WarnLevel, fmt.Sprintf(msg, args...))
+		e.Msg(msg)
+		return
+	}
 	l.loggerf.Warn().Msgf(msg, args...)
 }
 
 // Info logging.
 func (l *Logger) Info(args ...interface{}) {
-	log.IncreaseInfoCounter()
+	log.IncreaseCounter(log.InfoLevel, l.fields)
+	if !l.shouldLog(log.InfoLevel) {
+		return
+	}
+	if log.HasHooks() {
+		e, msg := l.hooked(l.bare.Info(), log.InfoLevel, fmt.Sprint(args...))
+		e.Msg(msg)
+		return
+	}
 	l.logger.Info().Msg(fmt.Sprint(args...))
 }
 
 // Infof logging.
 func (l *Logger) Infof(msg string, args ...interface{}) {
-	log.IncreaseInfoCounter()
+	log.IncreaseCounter(log.InfoLevel, l.fields)
+	if !l.shouldLog(log.InfoLevel) {
+		return
+	}
+	if log.HasHooks() {
+		e, msg := l.hooked(l.baref.Info(), log.InfoLevel, fmt.Sprintf(msg, args...))
+		e.Msg(msg)
+		return
+	}
 	l.loggerf.Info().Msgf(msg, args...)
 }
 
 // Debug logging.
 func (l *Logger) Debug(args ...interface{}) {
-	log.IncreaseDebugCounter()
+	log.IncreaseCounter(log.DebugLevel, l.fields)
+	if !l.shouldLog(log.DebugLevel) {
+		return
+	}
+	if log.HasHooks() {
+		e, msg := l.hooked(l.bare.Debug(), log.DebugLevel, fmt.Sprint(args...))
+		e.Msg(msg)
+		return
+	}
 	l.logger.Debug().Msg(fmt.Sprint(args...))
 }
 
 // Debugf logging.
 func (l *Logger) Debugf(msg string, args ...interface{}) {
-	log.IncreaseDebugCounter()
+	log.IncreaseCounter(log.DebugLevel, l.fields)
+	if !l.shouldLog(log.DebugLevel) {
+		return
+	}
+	if log.HasHooks() {
+		e, msg := l.hooked(l.baref.Debug(), log.DebugLevel, fmt.Sprintf(msg, args...))
+		e.Msg(msg)
+		return
+	}
 	l.loggerf.Debug().Msgf(msg, args...)
 }
 
@@ -144,6 +258,31 @@ func (l *Logger) Level() log.Level {
 	return l.level
 }
 
+func (l *Logger) shouldLog(lvl log.Level) bool {
+	return log.LevelOrder(l.level) <= log.LevelOrder(lvl)
+}
+
+// hooked applies the registered hooks to the entry and attaches the resulting fields to the event.
+func (l *Logger) hooked(e *zerolog.Event, lvl log.Level, msg string) (*zerolog.Event, string) {
+	if e == nil {
+		return e, msg
+	}
+	entry := log.ApplyHooks(log.Entry{Level: lvl, Message: msg, Fields: l.fields})
+	return e.Fields(entry.Fields), entry.Message
+}
+
+// timestampHook adds the timestamp according to the format configured in the log package.
+type timestampHook struct{}
+
+func (timestampHook) Run(e *zerolog.Event, _ zerolog.Level, _ string) {
+	switch v := log.TimestampValue(time.Now()).(type) {
+	case int64:
+		e.Int64(zerolog.TimestampFieldName, v)
+	case string:
+		e.Str(zerolog.TimestampFieldName, v)
+	}
+}
+
 type sourceHook interface {
 	Run(e *zerolog.Event, _ zerolog.Level, _ string)
 }
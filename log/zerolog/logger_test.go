@@ -4,12 +4,15 @@ import (
 	"bytes"
 	"fmt"
 	"regexp"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/beatlabs/patron/log"
 	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 const (
@@ -248,3 +251,92 @@ func Benchmark_Sub(b *testing.B) {
 		t = n
 	}
 }
+
+func TestLogger_Hooks(t *testing.T) {
+	defer log.ResetHooks()
+	log.RegisterHook(func(e log.Entry) log.Entry {
+		e.Fields["host"] = "local"
+		e.Fields["key"] = "other"
+		e.Message = "[" + string(e.Level) + "] " + e.Message
+		return e
+	})
+
+	var b bytes.Buffer
+	l := New(&b, log.DebugLevel, f).Sub(map[string]interface{}{"subkey1": "subval1"})
+	l.Info(logMsg)
+	out := b.String()
+	assert.Contains(t, out, `"host":"local"`, out)
+	assert.Contains(t, out, `"subkey1":"subval1"`, out)
+	assert.Contains(t, out, `"key":"other"`, out)
+	assert.NotContains(t, out, `"key":"value"`, out)
+	assert.Contains(t, out, `"msg":"[info] testing"`, out)
+	assert.Regexp(t, regexp.MustCompile(`"src":"zerolog/logger_test.go:.*"`), out)
+
+	b.Reset()
+	l.Errorf("testing %d", 1)
+	assert.Contains(t, b.String(), `"msg":"[error] testing 1"`, b.String())
+
+	b.Reset()
+	l.Debug(logMsg)
+	assert.Contains(t, b.String(), `"lvl":"debug"`, b.String())
+}
+
+func TestLogger_TimestampFormat(t *testing.T) {
+	defer log.ResetTimestampFormat()
+
+	var b bytes.Buffer
+	require.NoError(t, log.SetTimestampFormat(log.TimestampEpochMillis, nil))
+	New(&b, log.DebugLevel, nil).Info(logMsg)
+	assert.Regexp(t, regexp.MustCompile(`"time":\d{13},`), b.String())
+
+	b.Reset()
+	require.NoError(t, log.SetTimestampFormat("2006-01-02", time.UTC))
+	New(&b, log.DebugLevel, nil).Info(logMsg)
+	assert.Contains(t, b.String(), `"time":"`+time.Now().UTC().Format("2006-01-02")+`"`)
+}
+
+func TestLogger_Lazy(t *testing.T) {
+	var b bytes.Buffer
+	evaluated := false
+	lazy := log.Lazy(func() interface{} {
+		evaluated = true
+		return "value"
+	})
+
+	l := New(&b, log.InfoLevel, nil)
+	l.Debug("debug ", lazy)
+	l.Debugf("debug %v", lazy)
+	assert.False(t, evaluated)
+	assert.Empty(t, b.String())
+
+	l.Info("info ", lazy)
+	assert.True(t, evaluated)
+	assert.Contains(t, b.String(), `"msg":"info value"`)
+}
+
+func TestLogger_WithLevel(t *testing.T) {
+	var b bytes.Buffer
+	l := New(&b, log.InfoLevel, f)
+
+	l.Debug(logMsg)
+	assert.Empty(t, b.String())
+
+	dl := l.(*Logger).WithLevel(log.DebugLevel)
+	assert.Equal(t, log.DebugLevel, dl.Level())
+	assert.Equal(t, log.InfoLevel, l.Level())
+	dl.Debug(logMsg)
+	assertLog(t, b, log.DebugLevel, logMsg)
+}
+
+func TestLogger_FieldGroups(t *testing.T) {
+	var b bytes.Buffer
+	l := New(&b, log.InfoLevel, map[string]interface{}{"db": map[string]interface{}{"host": "localhost"}})
+
+	l.Sub(map[string]interface{}{"db": map[string]interface{}{"name": "patron"}}).Info(logMsg)
+	assert.Contains(t, b.String(), `"db":{"host":"localhost","name":"patron"}`)
+	assert.Equal(t, 1, strings.Count(b.String(), `"db"`))
+
+	b.Reset()
+	l.Info(logMsg)
+	assert.Contains(t, b.String(), `"db":{"host":"localhost"}`)
+}
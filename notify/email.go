@@ -0,0 +1,38 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"net/mail"
+
+	"github.com/beatlabs/patron/client/email"
+	"github.com/beatlabs/patron/log"
+)
+
+// EmailSender sends email messages, e.g. an email.Client.
+type EmailSender interface {
+	Send(ctx context.Context, msg *email.Message) error
+}
+
+// Email creates a provider sending the notifications as text emails from the provided address.
+// Notifications whose recipients are all suppressed by the email client are dropped.
+func Email(sender EmailSender, from string) (Provider, error) {
+	if sender == nil {
+		return nil, errors.New("email sender is nil")
+	}
+	if _, err := mail.ParseAddress(from); err != nil {
+		return nil, errors.New("invalid sender address")
+	}
+
+	return ProviderFunc(func(ctx context.Context, n Notification) error {
+		if len(n.Recipients) == 0 {
+			return Permanent(errors.New("recipients are empty"))
+		}
+		err := sender.Send(ctx, &email.Message{From: from, To: n.Recipients, Subject: n.Subject, Text: n.Body})
+		if errors.Is(err, email.ErrSuppressed) {
+			log.FromContext(ctx).Debugf("dropping notification %s, since all its recipients are suppressed", n.Kind)
+			return nil
+		}
+		return err
+	}), nil
+}
@@ -0,0 +1,118 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+
+	patronhttp "github.com/beatlabs/patron/client/http"
+	"github.com/beatlabs/patron/encoding"
+	patronjson "github.com/beatlabs/patron/encoding/json"
+)
+
+// Slack creates a provider posting the notifications to the provided Slack incoming webhook with the HTTP client,
+// e.g. a traced patron HTTP client. Notifications are posted to the channel of the webhook, or to each of their recipients,
+// if the webhook is allowed to override its channel.
+func Slack(webhookURL string, client patronhttp.Client) (Provider, error) {
+	if err := validateHTTP(webhookURL, client); err != nil {
+		return nil, err
+	}
+
+	type message struct {
+		Channel string `json:"channel,omitempty"`
+		Text    string `json:"text"`
+	}
+
+	return ProviderFunc(func(ctx context.Context, n Notification) error {
+		text := n.Body
+		if n.Subject != "" {
+			text = "*" + n.Subject + "*\n" + n.Body
+		}
+		if len(n.Recipients) == 0 {
+			return postJSON(ctx, client, webhookURL, nil, message{Text: text})
+		}
+
+		var failed []string
+		var lastErr error
+		for _, r := range n.Recipients {
+			if err := postJSON(ctx, client, webhookURL, nil, message{Channel: r, Text: text}); err != nil {
+				failed = append(failed, r)
+				lastErr = err
+			}
+		}
+		if len(failed) == len(n.Recipients) {
+			return lastErr
+		}
+		return Partial(lastErr, failed...)
+	}), nil
+}
+
+// Webhook creates a provider posting the notifications as JSON to the provided URL with the HTTP client,
+// e.g. a traced patron HTTP client, adding the provided headers e.g. for authentication.
+func Webhook(webhookURL string, client patronhttp.Client, headers map[string]string) (Provider, error) {
+	if err := validateHTTP(webhookURL, client); err != nil {
+		return nil, err
+	}
+
+	type payload struct {
+		Kind       string   `json:"kind,omitempty"`
+		Recipients []string `json:"recipients,omitempty"`
+		Subject    string   `json:"subject,omitempty"`
+		Body       string   `json:"body"`
+	}
+
+	return ProviderFunc(func(ctx context.Context, n Notification) error {
+		return postJSON(ctx, client, webhookURL, headers, payload{Kind: n.Kind, Recipients: n.Recipients, Subject: n.Subject, Body: n.Body})
+	}), nil
+}
+
+func validateHTTP(rawURL string, client patronhttp.Client) error {
+	if client == nil {
+		return errors.New("http client is nil")
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return errors.New("invalid webhook url")
+	}
+	return nil
+}
+
+// postJSON posts the payload, failing permanently on client errors, apart from throttling.
+func postJSON(ctx context.Context, client patronhttp.Client, url string, headers map[string]string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return Permanent(err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return Permanent(err)
+	}
+	req.Header.Set(encoding.ContentTypeHeader, patronjson.TypeCharset)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	rsp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_, _ = io.Copy(ioutil.Discard, rsp.Body)
+		_ = rsp.Body.Close()
+	}()
+
+	if rsp.StatusCode >= 200 && rsp.StatusCode < 300 {
+		return nil
+	}
+	err = fmt.Errorf("unexpected response status %d", rsp.StatusCode)
+	if rsp.StatusCode >= 400 && rsp.StatusCode < 500 && rsp.StatusCode != http.StatusTooManyRequests {
+		return Permanent(err)
+	}
+	return err
+}
@@ -0,0 +1,257 @@
+// Package notify provides a notifier, which dispatches notifications to channels e.g. email, Slack, webhooks or SMS,
+// rendering them with the templates of each channel, and retrying and rate limiting their delivery per channel.
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	patronerrors "github.com/beatlabs/patron/errors"
+	"github.com/beatlabs/patron/reliability/retry"
+	"github.com/beatlabs/patron/trace"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/time/rate"
+)
+
+const (
+	component = "notifier"
+
+	defaultRetries    = 3
+	defaultBackoff    = time.Second
+	defaultMaxBackoff = 30 * time.Second
+)
+
+var sendDurationMetrics *prometheus.HistogramVec
+
+func init() {
+	sendDurationMetrics = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "notify",
+			Subsystem: "notifier",
+			Name:      "send_duration_seconds",
+			Help:      "Notifications sent per channel, including their retries.",
+		},
+		[]string{"channel", "success"},
+	)
+	prometheus.MustRegister(sendDurationMetrics)
+}
+
+// Notification is a notification dispatched to one or more channels.
+type Notification struct {
+	// Kind of the notification, e.g. order-failed, which selects the templates of the channels rendering its data.
+	Kind string
+	// Data rendered by the templates of the kind of the notification.
+	Data interface{}
+	// Recipients of the notification in the channel, e.g. email addresses, Slack channels or phone numbers,
+	// which default to the recipients of the channel.
+	Recipients []string
+	// Subject of the notification, unless rendered by a template.
+	Subject string
+	// Body of the notification, unless rendered by a template.
+	Body string
+}
+
+// Provider delivers notifications to the recipients of a channel.
+// Errors which should not be retried, e.g. invalid recipients, should be marked with Permanent,
+// while providers delivering to every recipient separately should report the recipients which failed with Partial.
+type Provider interface {
+	Send(ctx context.Context, n Notification) error
+}
+
+// ProviderFunc is an adapter of a func to a Provider.
+type ProviderFunc func(ctx context.Context, n Notification) error
+
+// Send calls the func.
+func (f ProviderFunc) Send(ctx context.Context, n Notification) error {
+	return f(ctx, n)
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+// Permanent marks the error of a provider as permanent, in order not to be retried.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+type partialError struct {
+	err    error
+	failed []string
+}
+
+func (e *partialError) Error() string {
+	return e.err.Error()
+}
+
+func (e *partialError) Unwrap() error {
+	return e.err
+}
+
+// Partial reports the recipients to which a provider failed to deliver a notification, in order for the retries to be limited to them.
+func Partial(err error, failed ...string) error {
+	if err == nil {
+		return nil
+	}
+	return &partialError{err: err, failed: failed}
+}
+
+type channel struct {
+	name       string
+	provider   Provider
+	recipients []string
+	templates  map[string]*kindTemplate
+	retries    uint
+	backoff    time.Duration
+	maxBackoff time.Duration
+	limiter    *rate.Limiter
+}
+
+// Notifier dispatches notifications to channels.
+type Notifier struct {
+	channels map[string]*channel
+}
+
+// New creates a notifier, which dispatches notifications to the provided channels.
+func New(oo ...OptionFunc) (*Notifier, error) {
+	n := &Notifier{channels: make(map[string]*channel)}
+
+	for _, optionFunc := range oo {
+		if err := optionFunc(n); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(n.channels) == 0 {
+		return nil, errors.New("channels are empty")
+	}
+	return n, nil
+}
+
+// Send sends the notification to the channel. By default, failed deliveries are retried 3 times,
+// with an exponential backoff starting at 1s and capped at 30s.
+func (nt *Notifier) Send(ctx context.Context, channelName string, n Notification) error {
+	ch, ok := nt.channels[channelName]
+	if !ok {
+		return fmt.Errorf("unknown channel %s", channelName)
+	}
+	return ch.send(ctx, n)
+}
+
+// Broadcast sends the notification to the provided channels, or to all of them if none is provided,
+// returning the aggregated errors of the channels which failed.
+func (nt *Notifier) Broadcast(ctx context.Context, n Notification, channelNames ...string) error {
+	if len(channelNames) == 0 {
+		for name := range nt.channels {
+			channelNames = append(channelNames, name)
+		}
+		sort.Strings(channelNames)
+	}
+
+	var ee []error
+	for _, name := range channelNames {
+		if err := nt.Send(ctx, name, n); err != nil {
+			ee = append(ee, err)
+		}
+	}
+	return patronerrors.Aggregate(ee...)
+}
+
+func (ch *channel) send(ctx context.Context, n Notification) error {
+	n, err := ch.render(n)
+	if err != nil {
+		return fmt.Errorf("failed to render notification for channel %s: %w", ch.name, err)
+	}
+
+	sp, ctx := trace.ChildSpan(ctx, trace.ComponentOpName(component, ch.name), component, ext.SpanKindProducer,
+		opentracing.Tag{Key: "channel", Value: ch.name}, opentracing.Tag{Key: "kind", Value: n.Kind})
+	start := time.Now()
+
+	attempt := 0
+	backoff := ch.backoff
+	for {
+		attempt++
+		err = ch.attempt(ctx, n)
+		retry.ObserveAttempt(sp, component, attempt, err)
+		if err == nil || !retryable(err) || uint(attempt) > ch.retries {
+			break
+		}
+		var pe *partialError
+		if errors.As(err, &pe) {
+			n.Recipients = pe.failed
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(backoff):
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		backoff *= 2
+		if backoff > ch.maxBackoff {
+			backoff = ch.maxBackoff
+		}
+	}
+
+	retry.ObserveAttempts(sp, attempt)
+	sendDurationMetrics.WithLabelValues(ch.name, strconv.FormatBool(err == nil)).Observe(time.Since(start).Seconds())
+	trace.SpanComplete(sp, err)
+	if err != nil {
+		return fmt.Errorf("failed to send notification to channel %s: %w", ch.name, err)
+	}
+	return nil
+}
+
+func (ch *channel) attempt(ctx context.Context, n Notification) error {
+	if ch.limiter != nil {
+		if err := ch.limiter.Wait(ctx); err != nil {
+			return err
+		}
+	}
+	return ch.provider.Send(ctx, n)
+}
+
+// render renders the subject and the body of the notification with the templates of its kind, if any,
+// and sets the recipients of the channel, if it has none.
+func (ch *channel) render(n Notification) (Notification, error) {
+	if tmpl, ok := ch.templates[n.Kind]; ok && n.Kind != "" {
+		subject, body, err := tmpl.render(n.Data)
+		if err != nil {
+			return n, err
+		}
+		if tmpl.subject != nil {
+			n.Subject = subject
+		}
+		n.Body = body
+	}
+	if n.Body == "" {
+		return n, errors.New("body is empty")
+	}
+	if len(n.Recipients) == 0 {
+		n.Recipients = ch.recipients
+	}
+	return n, nil
+}
+
+func retryable(err error) bool {
+	var pe *permanentError
+	return !errors.As(err, &pe) && !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+}
@@ -0,0 +1,219 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeProvider records the notifications it receives, failing the configured number of times with the configured error.
+type fakeProvider struct {
+	sync.Mutex
+	err      error
+	failures int
+	received []Notification
+}
+
+func (fp *fakeProvider) Send(_ context.Context, n Notification) error {
+	fp.Lock()
+	defer fp.Unlock()
+	fp.received = append(fp.received, n)
+	if fp.failures > 0 {
+		fp.failures--
+		return fp.err
+	}
+	return nil
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		oo          []OptionFunc
+		expectedErr string
+	}{
+		"success":          {oo: []OptionFunc{Channel("slack", &fakeProvider{})}},
+		"missing channels": {expectedErr: "channels are empty"},
+		"option failed":    {oo: []OptionFunc{Channel("", &fakeProvider{})}, expectedErr: "channel name is empty"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			got, err := New(tt.oo...)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, got)
+			}
+		})
+	}
+}
+
+func TestNotifier_Send(t *testing.T) {
+	t.Parallel()
+	errUnavailable := errors.New("unavailable")
+	tests := map[string]struct {
+		provider         *fakeProvider
+		oo               []ChannelOptionFunc
+		channel          string
+		notification     Notification
+		expectedErr      string
+		expectedReceived []Notification
+	}{
+		"success": {
+			provider:         &fakeProvider{},
+			notification:     Notification{Recipients: []string{"#ops"}, Subject: "Alert", Body: "Disk full"},
+			expectedReceived: []Notification{{Recipients: []string{"#ops"}, Subject: "Alert", Body: "Disk full"}},
+		},
+		"default recipients": {
+			provider:         &fakeProvider{},
+			oo:               []ChannelOptionFunc{Recipients("#ops")},
+			notification:     Notification{Body: "Disk full"},
+			expectedReceived: []Notification{{Recipients: []string{"#ops"}, Body: "Disk full"}},
+		},
+		"rendered": {
+			provider: &fakeProvider{},
+			oo:       []ChannelOptionFunc{Template("disk", "Disk of {{.Host}}", "Disk of {{.Host}} is {{.Usage}}% full")},
+			notification: Notification{
+				Kind: "disk", Data: map[string]interface{}{"Host": "db-1", "Usage": 95}, Recipients: []string{"#ops"},
+			},
+			expectedReceived: []Notification{{
+				Kind: "disk", Data: map[string]interface{}{"Host": "db-1", "Usage": 95}, Recipients: []string{"#ops"},
+				Subject: "Disk of db-1", Body: "Disk of db-1 is 95% full",
+			}},
+		},
+		"rendered body only": {
+			provider:     &fakeProvider{},
+			oo:           []ChannelOptionFunc{Template("disk", "", "Disk of {{.}} is full")},
+			notification: Notification{Kind: "disk", Data: "db-1", Subject: "Alert"},
+			expectedReceived: []Notification{{
+				Kind: "disk", Data: "db-1", Subject: "Alert", Body: "Disk of db-1 is full",
+			}},
+		},
+		"render failure": {
+			provider:     &fakeProvider{},
+			oo:           []ChannelOptionFunc{Template("disk", "", "Disk of {{.Host}} is full")},
+			notification: Notification{Kind: "disk", Data: map[string]string{}},
+			expectedErr: `failed to render notification for channel test: failed to render body: template: body:1:10: ` +
+				`executing "body" at <.Host>: map has no entry for key "Host"`,
+		},
+		"missing body": {
+			provider:     &fakeProvider{},
+			notification: Notification{Kind: "disk"},
+			expectedErr:  "failed to render notification for channel test: body is empty",
+		},
+		"unknown channel": {
+			provider:     &fakeProvider{},
+			channel:      "sms",
+			notification: Notification{Body: "Disk full"},
+			expectedErr:  "unknown channel sms",
+		},
+		"retried": {
+			provider:     &fakeProvider{err: errUnavailable, failures: 2},
+			notification: Notification{Body: "Disk full"},
+			expectedReceived: []Notification{
+				{Body: "Disk full"}, {Body: "Disk full"}, {Body: "Disk full"},
+			},
+		},
+		"retries exhausted": {
+			provider:     &fakeProvider{err: errUnavailable, failures: 5},
+			notification: Notification{Body: "Disk full"},
+			expectedErr:  "failed to send notification to channel test: unavailable",
+			expectedReceived: []Notification{
+				{Body: "Disk full"}, {Body: "Disk full"}, {Body: "Disk full"},
+			},
+		},
+		"permanent failure": {
+			provider:         &fakeProvider{err: Permanent(errors.New("invalid recipient")), failures: 5},
+			notification:     Notification{Body: "Disk full"},
+			expectedErr:      "failed to send notification to channel test: invalid recipient",
+			expectedReceived: []Notification{{Body: "Disk full"}},
+		},
+		"partial failure": {
+			provider:     &fakeProvider{err: Partial(errUnavailable, "+306900000002"), failures: 1},
+			notification: Notification{Recipients: []string{"+306900000001", "+306900000002"}, Body: "Disk full"},
+			expectedReceived: []Notification{
+				{Recipients: []string{"+306900000001", "+306900000002"}, Body: "Disk full"},
+				{Recipients: []string{"+306900000002"}, Body: "Disk full"},
+			},
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			oo := append([]ChannelOptionFunc{Retries(2, time.Millisecond, time.Millisecond)}, tt.oo...)
+			n, err := New(Channel("test", tt.provider, oo...))
+			require.NoError(t, err)
+			channel := tt.channel
+			if channel == "" {
+				channel = "test"
+			}
+
+			err = n.Send(context.Background(), channel, tt.notification)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.expectedReceived, tt.provider.received)
+		})
+	}
+}
+
+func TestNotifier_Send_Cancelled(t *testing.T) {
+	t.Parallel()
+	provider := &fakeProvider{err: errors.New("unavailable"), failures: 5}
+	n, err := New(Channel("test", provider, Retries(5, time.Hour, time.Hour)))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err = n.Send(ctx, "test", Notification{Body: "Disk full"})
+	assert.EqualError(t, err, "failed to send notification to channel test: unavailable")
+	assert.Len(t, provider.received, 1)
+}
+
+func TestNotifier_Send_RateLimited(t *testing.T) {
+	t.Parallel()
+	provider := &fakeProvider{}
+	n, err := New(Channel("test", provider, RateLimit(0.001, 1), Retries(0, time.Millisecond, time.Millisecond)))
+	require.NoError(t, err)
+
+	require.NoError(t, n.Send(context.Background(), "test", Notification{Body: "first"}))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Error(t, n.Send(ctx, "test", Notification{Body: "second"}))
+	assert.Equal(t, []Notification{{Body: "first"}}, provider.received)
+}
+
+func TestNotifier_Broadcast(t *testing.T) {
+	t.Parallel()
+	slack := &fakeProvider{}
+	email := &fakeProvider{err: Permanent(errors.New("rejected")), failures: 1}
+	webhook := &fakeProvider{}
+	n, err := New(
+		Channel("slack", slack, Recipients("#ops")),
+		Channel("email", email, Recipients("ops@example.com")),
+		Channel("webhook", webhook),
+	)
+	require.NoError(t, err)
+
+	err = n.Broadcast(context.Background(), Notification{Body: "Disk full"})
+	assert.EqualError(t, err, "failed to send notification to channel email: rejected\n")
+	assert.Equal(t, []Notification{{Recipients: []string{"#ops"}, Body: "Disk full"}}, slack.received)
+	assert.Equal(t, []Notification{{Recipients: []string{"ops@example.com"}, Body: "Disk full"}}, email.received)
+	assert.Equal(t, []Notification{{Body: "Disk full"}}, webhook.received)
+
+	err = n.Broadcast(context.Background(), Notification{Body: "Disk full"}, "slack", "sms")
+	assert.EqualError(t, err, "unknown channel sms\n")
+	assert.Len(t, slack.received, 2)
+	assert.Len(t, webhook.received, 1)
+}
@@ -0,0 +1,105 @@
+package notify
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// OptionFunc definition for configuring the notifier in a functional way.
+type OptionFunc func(*Notifier) error
+
+// ChannelOptionFunc definition for configuring a channel in a functional way.
+type ChannelOptionFunc func(*channel) error
+
+// Channel adds a channel of the provided name, which delivers the notifications with the provider.
+func Channel(name string, provider Provider, oo ...ChannelOptionFunc) OptionFunc {
+	return func(n *Notifier) error {
+		if name == "" {
+			return errors.New("channel name is empty")
+		}
+		if provider == nil {
+			return fmt.Errorf("provider of channel %s is nil", name)
+		}
+		if _, ok := n.channels[name]; ok {
+			return fmt.Errorf("channel %s already exists", name)
+		}
+
+		ch := &channel{
+			name:       name,
+			provider:   provider,
+			templates:  make(map[string]*kindTemplate),
+			retries:    defaultRetries,
+			backoff:    defaultBackoff,
+			maxBackoff: defaultMaxBackoff,
+		}
+		for _, optionFunc := range oo {
+			if err := optionFunc(ch); err != nil {
+				return fmt.Errorf("failed to configure channel %s: %w", name, err)
+			}
+		}
+		n.channels[name] = ch
+		return nil
+	}
+}
+
+// Recipients sets the recipients of the notifications which have none, e.g. the Slack channel or the email address of a team.
+func Recipients(recipients ...string) ChannelOptionFunc {
+	return func(ch *channel) error {
+		if len(recipients) == 0 {
+			return errors.New("recipients are empty")
+		}
+		ch.recipients = recipients
+		return nil
+	}
+}
+
+// Template sets the templates rendering the subject and the body of the notifications of the provided kind,
+// with text/template. The body template is required, while the subject one may be empty.
+func Template(kind, subject, body string) ChannelOptionFunc {
+	return func(ch *channel) error {
+		if kind == "" {
+			return errors.New("kind is empty")
+		}
+		tmpl, err := newKindTemplate(subject, body)
+		if err != nil {
+			return fmt.Errorf("failed to create template of kind %s: %w", kind, err)
+		}
+		ch.templates[kind] = tmpl
+		return nil
+	}
+}
+
+// Retries sets the number of times a failed delivery is retried, and the backoff between the attempts,
+// which doubles after every attempt up to the max backoff.
+func Retries(count uint, backoff, maxBackoff time.Duration) ChannelOptionFunc {
+	return func(ch *channel) error {
+		if backoff <= 0 {
+			return errors.New("backoff should be positive")
+		}
+		if maxBackoff < backoff {
+			return errors.New("max backoff should not be less than backoff")
+		}
+		ch.retries = count
+		ch.backoff = backoff
+		ch.maxBackoff = maxBackoff
+		return nil
+	}
+}
+
+// RateLimit limits the deliveries of the channel, including retries, to the provided rate per second with bursts up to the provided size.
+// Deliveries exceeding the limit wait for it, unless their context is done first.
+func RateLimit(limit float64, burst int) ChannelOptionFunc {
+	return func(ch *channel) error {
+		if limit <= 0 {
+			return errors.New("limit should be positive")
+		}
+		if burst <= 0 {
+			return errors.New("burst should be positive")
+		}
+		ch.limiter = rate.NewLimiter(rate.Limit(limit), burst)
+		return nil
+	}
+}
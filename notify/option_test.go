@@ -0,0 +1,124 @@
+package notify
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChannel(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		name        string
+		provider    Provider
+		oo          []ChannelOptionFunc
+		expectedErr string
+	}{
+		"success":          {name: "slack", provider: &fakeProvider{}},
+		"existing channel": {name: "email", provider: &fakeProvider{}, expectedErr: "channel email already exists"},
+		"missing name":     {provider: &fakeProvider{}, expectedErr: "channel name is empty"},
+		"missing provider": {name: "slack", expectedErr: "provider of channel slack is nil"},
+		"option failed": {
+			name: "slack", provider: &fakeProvider{}, oo: []ChannelOptionFunc{Recipients()},
+			expectedErr: "failed to configure channel slack: recipients are empty",
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			n := &Notifier{channels: map[string]*channel{"email": {}}}
+			err := Channel(tt.name, tt.provider, tt.oo...)(n)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				ch := n.channels[tt.name]
+				assert.Equal(t, uint(defaultRetries), ch.retries)
+				assert.Equal(t, defaultBackoff, ch.backoff)
+				assert.Equal(t, defaultMaxBackoff, ch.maxBackoff)
+			}
+		})
+	}
+}
+
+func TestTemplate(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		kind, subject, body string
+		expectedErr         string
+	}{
+		"success":         {kind: "disk", subject: "Disk of {{.Host}}", body: "Disk of {{.Host}} is full"},
+		"without subject": {kind: "disk", body: "Disk of {{.Host}} is full"},
+		"missing kind":    {body: "Disk full", expectedErr: "kind is empty"},
+		"missing body":    {kind: "disk", expectedErr: "failed to create template of kind disk: body template is empty"},
+		"invalid subject": {
+			kind: "disk", subject: "{{.Host", body: "Disk full",
+			expectedErr: "failed to create template of kind disk: failed to parse subject template: template: subject:1: unclosed action",
+		},
+		"invalid body": {
+			kind: "disk", body: "{{.Host",
+			expectedErr: "failed to create template of kind disk: failed to parse body template: template: body:1: unclosed action",
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ch := &channel{templates: make(map[string]*kindTemplate)}
+			err := Template(tt.kind, tt.subject, tt.body)(ch)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Contains(t, ch.templates, tt.kind)
+			}
+		})
+	}
+}
+
+func TestRetries(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		backoff, maxBackoff time.Duration
+		expectedErr         string
+	}{
+		"success":             {backoff: time.Second, maxBackoff: time.Minute},
+		"invalid backoff":     {backoff: 0, maxBackoff: time.Minute, expectedErr: "backoff should be positive"},
+		"invalid max backoff": {backoff: time.Second, maxBackoff: time.Millisecond, expectedErr: "max backoff should not be less than backoff"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ch := &channel{}
+			err := Retries(5, tt.backoff, tt.maxBackoff)(ch)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, uint(5), ch.retries)
+				assert.Equal(t, tt.backoff, ch.backoff)
+				assert.Equal(t, tt.maxBackoff, ch.maxBackoff)
+			}
+		})
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	t.Parallel()
+	ch := &channel{}
+	assert.EqualError(t, RateLimit(0, 1)(ch), "limit should be positive")
+	assert.EqualError(t, RateLimit(1, 0)(ch), "burst should be positive")
+	assert.NoError(t, RateLimit(1, 10)(ch))
+	assert.NotNil(t, ch.limiter)
+}
+
+func TestRecipients(t *testing.T) {
+	t.Parallel()
+	ch := &channel{}
+	assert.EqualError(t, Recipients()(ch), "recipients are empty")
+	assert.NoError(t, Recipients("#ops")(ch))
+	assert.Equal(t, []string{"#ops"}, ch.recipients)
+}
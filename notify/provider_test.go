@@ -0,0 +1,250 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/beatlabs/patron/client/email"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeEmailSender struct {
+	err  error
+	sent []*email.Message
+}
+
+func (fs *fakeEmailSender) Send(_ context.Context, msg *email.Message) error {
+	fs.sent = append(fs.sent, msg)
+	return fs.err
+}
+
+func TestEmail(t *testing.T) {
+	t.Parallel()
+	_, err := Email(nil, "alerts@example.com")
+	assert.EqualError(t, err, "email sender is nil")
+	_, err = Email(&fakeEmailSender{}, "alerts")
+	assert.EqualError(t, err, "invalid sender address")
+
+	tests := map[string]struct {
+		senderErr   error
+		recipients  []string
+		expectedErr string
+		permanent   bool
+	}{
+		"success":            {recipients: []string{"ops@example.com"}},
+		"suppressed":         {recipients: []string{"ops@example.com"}, senderErr: email.ErrSuppressed},
+		"failure":            {recipients: []string{"ops@example.com"}, senderErr: errors.New("timeout"), expectedErr: "timeout"},
+		"missing recipients": {expectedErr: "recipients are empty", permanent: true},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			sender := &fakeEmailSender{err: tt.senderErr}
+			p, err := Email(sender, "alerts@example.com")
+			require.NoError(t, err)
+
+			err = p.Send(context.Background(), Notification{Recipients: tt.recipients, Subject: "Alert", Body: "Disk full"})
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.Equal(t, tt.permanent, !retryable(err))
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, []*email.Message{{From: "alerts@example.com", To: tt.recipients, Subject: "Alert", Text: "Disk full"}}, sender.sent)
+		})
+	}
+}
+
+// fakeWebhook records the payloads posted to it, responding with the status of their channel.
+type fakeWebhook struct {
+	sync.Mutex
+	statuses map[string]int
+	payloads []map[string]interface{}
+	headers  []http.Header
+}
+
+func (fw *fakeWebhook) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	fw.Lock()
+	defer fw.Unlock()
+	data, _ := ioutil.ReadAll(r.Body)
+	payload := make(map[string]interface{})
+	_ = json.Unmarshal(data, &payload)
+	fw.payloads = append(fw.payloads, payload)
+	fw.headers = append(fw.headers, r.Header)
+	channel, _ := payload["channel"].(string)
+	if status, ok := fw.statuses[channel]; ok {
+		w.WriteHeader(status)
+	}
+}
+
+func TestSlack(t *testing.T) {
+	t.Parallel()
+	_, err := Slack("https://hooks.slack.com/services/x", nil)
+	assert.EqualError(t, err, "http client is nil")
+	_, err = Slack("hooks.slack.com", http.DefaultClient)
+	assert.EqualError(t, err, "invalid webhook url")
+
+	tests := map[string]struct {
+		statuses         map[string]int
+		recipients       []string
+		expectedErr      string
+		expectedFailed   []string
+		expectedPayloads []map[string]interface{}
+	}{
+		"webhook channel": {
+			expectedPayloads: []map[string]interface{}{{"text": "*Alert*\nDisk full"}},
+		},
+		"recipients": {
+			recipients: []string{"#ops", "#dev"},
+			expectedPayloads: []map[string]interface{}{
+				{"channel": "#ops", "text": "*Alert*\nDisk full"}, {"channel": "#dev", "text": "*Alert*\nDisk full"},
+			},
+		},
+		"partial failure": {
+			statuses: map[string]int{"#dev": http.StatusServiceUnavailable}, recipients: []string{"#ops", "#dev"},
+			expectedErr: "unexpected response status 503", expectedFailed: []string{"#dev"},
+			expectedPayloads: []map[string]interface{}{
+				{"channel": "#ops", "text": "*Alert*\nDisk full"}, {"channel": "#dev", "text": "*Alert*\nDisk full"},
+			},
+		},
+		"failure": {
+			statuses: map[string]int{"#ops": http.StatusNotFound}, recipients: []string{"#ops"},
+			expectedErr:      "unexpected response status 404",
+			expectedPayloads: []map[string]interface{}{{"channel": "#ops", "text": "*Alert*\nDisk full"}},
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			webhook := &fakeWebhook{statuses: tt.statuses}
+			srv := httptest.NewServer(webhook)
+			defer srv.Close()
+			p, err := Slack(srv.URL, srv.Client())
+			require.NoError(t, err)
+
+			err = p.Send(context.Background(), Notification{Recipients: tt.recipients, Subject: "Alert", Body: "Disk full"})
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				var pe *partialError
+				if errors.As(err, &pe) {
+					assert.Equal(t, tt.expectedFailed, pe.failed)
+				} else {
+					assert.Empty(t, tt.expectedFailed)
+				}
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.expectedPayloads, webhook.payloads)
+		})
+	}
+}
+
+func TestWebhook(t *testing.T) {
+	t.Parallel()
+	_, err := Webhook("ftp://example.com", http.DefaultClient, nil)
+	assert.EqualError(t, err, "invalid webhook url")
+
+	tests := map[string]struct {
+		status      int
+		expectedErr string
+		retryable   bool
+	}{
+		"success":        {status: http.StatusNoContent},
+		"client error":   {status: http.StatusBadRequest, expectedErr: "unexpected response status 400"},
+		"throttled":      {status: http.StatusTooManyRequests, expectedErr: "unexpected response status 429", retryable: true},
+		"server failure": {status: http.StatusBadGateway, expectedErr: "unexpected response status 502", retryable: true},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			webhook := &fakeWebhook{statuses: map[string]int{"": tt.status}}
+			srv := httptest.NewServer(webhook)
+			defer srv.Close()
+			p, err := Webhook(srv.URL, srv.Client(), map[string]string{"Authorization": "Bearer token"})
+			require.NoError(t, err)
+
+			err = p.Send(context.Background(), Notification{Kind: "disk", Recipients: []string{"ops"}, Body: "Disk full"})
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.Equal(t, tt.retryable, retryable(err))
+			} else {
+				assert.NoError(t, err)
+			}
+			require.Len(t, webhook.payloads, 1)
+			assert.Equal(t, map[string]interface{}{"kind": "disk", "recipients": []interface{}{"ops"}, "body": "Disk full"}, webhook.payloads[0])
+			assert.Equal(t, "Bearer token", webhook.headers[0].Get("Authorization"))
+			assert.Equal(t, "application/json; charset=utf-8", webhook.headers[0].Get("Content-Type"))
+		})
+	}
+}
+
+type fakeSMSSender struct {
+	failing map[string]bool
+	sent    []string
+}
+
+func (fs *fakeSMSSender) SendSMS(_ context.Context, phoneNumber, text string) error {
+	if fs.failing[phoneNumber] {
+		return errors.New("unavailable")
+	}
+	fs.sent = append(fs.sent, phoneNumber+": "+text)
+	return nil
+}
+
+func TestSMS(t *testing.T) {
+	t.Parallel()
+	_, err := SMS(nil)
+	assert.EqualError(t, err, "sms sender is nil")
+
+	tests := map[string]struct {
+		failing        map[string]bool
+		recipients     []string
+		expectedErr    string
+		expectedFailed []string
+		expectedSent   []string
+	}{
+		"success": {
+			recipients:   []string{"+306900000001", "+306900000002"},
+			expectedSent: []string{"+306900000001: Disk full", "+306900000002: Disk full"},
+		},
+		"missing recipients": {expectedErr: "recipients are empty"},
+		"invalid recipient":  {recipients: []string{"+306900000001", "6900000002"}, expectedErr: "invalid phone number 6900000002"},
+		"partial failure": {
+			failing: map[string]bool{"+306900000002": true}, recipients: []string{"+306900000001", "+306900000002"},
+			expectedErr: "unavailable", expectedFailed: []string{"+306900000002"}, expectedSent: []string{"+306900000001: Disk full"},
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			sender := &fakeSMSSender{failing: tt.failing}
+			p, err := SMS(sender)
+			require.NoError(t, err)
+
+			err = p.Send(context.Background(), Notification{Recipients: tt.recipients, Subject: "Alert", Body: "Disk full"})
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				var pe *partialError
+				if errors.As(err, &pe) {
+					assert.Equal(t, tt.expectedFailed, pe.failed)
+				} else {
+					assert.False(t, retryable(err))
+				}
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.expectedSent, sender.sent)
+		})
+	}
+}
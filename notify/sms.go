@@ -0,0 +1,47 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+var phoneNumberRegexp = regexp.MustCompile(`^\+[1-9][0-9]{1,14}$`)
+
+// SMSSender sends text messages, e.g. an adapter of the API of an SMS provider.
+type SMSSender interface {
+	SendSMS(ctx context.Context, phoneNumber, text string) error
+}
+
+// SMS creates a provider sending the body of the notifications as text messages to their recipients,
+// which should be phone numbers in E.164 format, e.g. +306912345678.
+func SMS(sender SMSSender) (Provider, error) {
+	if sender == nil {
+		return nil, errors.New("sms sender is nil")
+	}
+
+	return ProviderFunc(func(ctx context.Context, n Notification) error {
+		if len(n.Recipients) == 0 {
+			return Permanent(errors.New("recipients are empty"))
+		}
+		for _, r := range n.Recipients {
+			if !phoneNumberRegexp.MatchString(r) {
+				return Permanent(fmt.Errorf("invalid phone number %s", r))
+			}
+		}
+
+		var failed []string
+		var lastErr error
+		for _, r := range n.Recipients {
+			if err := sender.SendSMS(ctx, r, n.Body); err != nil {
+				failed = append(failed, r)
+				lastErr = err
+			}
+		}
+		if len(failed) == len(n.Recipients) {
+			return lastErr
+		}
+		return Partial(lastErr, failed...)
+	}), nil
+}
@@ -0,0 +1,50 @@
+package notify
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"text/template"
+)
+
+// kindTemplate renders the subject and the body of the notifications of a kind.
+type kindTemplate struct {
+	subject *template.Template
+	body    *template.Template
+}
+
+// newKindTemplate parses the templates of the subject and the body of notifications. The subject template may be empty.
+func newKindTemplate(subject, body string) (*kindTemplate, error) {
+	if body == "" {
+		return nil, errors.New("body template is empty")
+	}
+
+	t := &kindTemplate{}
+	var err error
+	if subject != "" {
+		t.subject, err = template.New("subject").Option("missingkey=error").Parse(subject)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse subject template: %w", err)
+		}
+	}
+	t.body, err = template.New("body").Option("missingkey=error").Parse(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse body template: %w", err)
+	}
+	return t, nil
+}
+
+func (t *kindTemplate) render(data interface{}) (subject, body string, err error) {
+	var buf bytes.Buffer
+	if t.subject != nil {
+		if err := t.subject.Execute(&buf, data); err != nil {
+			return "", "", fmt.Errorf("failed to render subject: %w", err)
+		}
+		subject = buf.String()
+		buf.Reset()
+	}
+	if err := t.body.Execute(&buf, data); err != nil {
+		return "", "", fmt.Errorf("failed to render body: %w", err)
+	}
+	return subject, buf.String(), nil
+}
@@ -0,0 +1,280 @@
+package propagation
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/Shopify/sarama"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/eclipse/paho.golang/paho"
+	"github.com/streadway/amqp"
+	"google.golang.org/grpc/metadata"
+)
+
+// HTTPHeaders is a carrier of HTTP headers.
+type HTTPHeaders http.Header
+
+// Get returns the first value of the key.
+func (h HTTPHeaders) Get(key string) string {
+	return http.Header(h).Get(key)
+}
+
+// Set sets the value of the key.
+func (h HTTPHeaders) Set(key, value string) {
+	http.Header(h).Set(key, value)
+}
+
+// Keys returns the sorted keys of the headers.
+func (h HTTPHeaders) Keys() []string {
+	keys := make([]string, 0, len(h))
+	for key := range h {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// GRPCMetadata is a carrier of gRPC metadata, whose keys are lowercase.
+type GRPCMetadata metadata.MD
+
+// Get returns the first value of the key.
+func (md GRPCMetadata) Get(key string) string {
+	values := metadata.MD(md).Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// Set sets the value of the key.
+func (md GRPCMetadata) Set(key, value string) {
+	metadata.MD(md).Set(key, value)
+}
+
+// Keys returns the sorted keys of the metadata.
+func (md GRPCMetadata) Keys() []string {
+	keys := make([]string, 0, len(md))
+	for key, values := range md {
+		if len(values) > 0 {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// KafkaHeaders is a carrier of the headers of the produced Kafka messages, e.g. (*KafkaHeaders)(&msg.Headers).
+type KafkaHeaders []sarama.RecordHeader
+
+// Get returns the value of the key.
+func (h *KafkaHeaders) Get(key string) string {
+	for _, hdr := range *h {
+		if string(hdr.Key) == key {
+			return string(hdr.Value)
+		}
+	}
+	return ""
+}
+
+// Set sets the value of the key.
+func (h *KafkaHeaders) Set(key, value string) {
+	for i := range *h {
+		if string((*h)[i].Key) == key {
+			(*h)[i].Value = []byte(value)
+			return
+		}
+	}
+	*h = append(*h, sarama.RecordHeader{Key: []byte(key), Value: []byte(value)})
+}
+
+// Keys returns the keys of the headers in order.
+func (h *KafkaHeaders) Keys() []string {
+	keys := make([]string, 0, len(*h))
+	for _, hdr := range *h {
+		keys = append(keys, string(hdr.Key))
+	}
+	return keys
+}
+
+// KafkaConsumerHeaders is a carrier of the headers of the consumed Kafka messages, e.g. (*KafkaConsumerHeaders)(&msg.Headers).
+type KafkaConsumerHeaders []*sarama.RecordHeader
+
+// Get returns the value of the key.
+func (h *KafkaConsumerHeaders) Get(key string) string {
+	for _, hdr := range *h {
+		if hdr != nil && string(hdr.Key) == key {
+			return string(hdr.Value)
+		}
+	}
+	return ""
+}
+
+// Set sets the value of the key.
+func (h *KafkaConsumerHeaders) Set(key, value string) {
+	for _, hdr := range *h {
+		if hdr != nil && string(hdr.Key) == key {
+			hdr.Value = []byte(value)
+			return
+		}
+	}
+	*h = append(*h, &sarama.RecordHeader{Key: []byte(key), Value: []byte(value)})
+}
+
+// Keys returns the keys of the headers in order.
+func (h *KafkaConsumerHeaders) Keys() []string {
+	keys := make([]string, 0, len(*h))
+	for _, hdr := range *h {
+		if hdr != nil {
+			keys = append(keys, string(hdr.Key))
+		}
+	}
+	return keys
+}
+
+// SQSAttributes is a carrier of the attributes of SQS messages, whose values are set as strings.
+type SQSAttributes map[string]*sqs.MessageAttributeValue
+
+// Get returns the string value of the key.
+func (a SQSAttributes) Get(key string) string {
+	if v, ok := a[key]; ok && v != nil && v.StringValue != nil {
+		return *v.StringValue
+	}
+	return ""
+}
+
+// Set sets the string value of the key.
+func (a SQSAttributes) Set(key, value string) {
+	a[key] = &sqs.MessageAttributeValue{DataType: aws.String("String"), StringValue: aws.String(value)}
+}
+
+// Keys returns the sorted keys of the string attributes.
+func (a SQSAttributes) Keys() []string {
+	keys := make([]string, 0, len(a))
+	for key, v := range a {
+		if v != nil && v.StringValue != nil {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// SNSAttributes is a carrier of the attributes of SNS messages, whose values are set as strings.
+type SNSAttributes map[string]*sns.MessageAttributeValue
+
+// Get returns the string value of the key.
+func (a SNSAttributes) Get(key string) string {
+	if v, ok := a[key]; ok && v != nil && v.StringValue != nil {
+		return *v.StringValue
+	}
+	return ""
+}
+
+// Set sets the string value of the key.
+func (a SNSAttributes) Set(key, value string) {
+	a[key] = &sns.MessageAttributeValue{DataType: aws.String("String"), StringValue: aws.String(value)}
+}
+
+// Keys returns the sorted keys of the string attributes.
+func (a SNSAttributes) Keys() []string {
+	keys := make([]string, 0, len(a))
+	for key, v := range a {
+		if v != nil && v.StringValue != nil {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// snsNotification is the envelope of the SNS messages delivered to SQS queues without raw message delivery,
+// which carries the attributes of the SNS messages instead of the ones of the SQS messages.
+type snsNotification struct {
+	Type              string `json:"Type"`
+	MessageAttributes map[string]struct {
+		Type  string `json:"Type"`
+		Value string `json:"Value"`
+	} `json:"MessageAttributes"`
+}
+
+// SNSNotificationAttributes returns a carrier of the string attributes of the SNS message delivered in the body of an SQS message,
+// if the body is an SNS notification, since the trace context and the correlation ID are not set as attributes of the SQS message.
+func SNSNotificationAttributes(body string) (MapCarrier, bool) {
+	var n snsNotification
+	if err := json.Unmarshal([]byte(body), &n); err != nil || n.Type != "Notification" {
+		return nil, false
+	}
+	m := make(MapCarrier, len(n.MessageAttributes))
+	for key, attr := range n.MessageAttributes {
+		if attr.Type == "String" {
+			m[key] = attr.Value
+		}
+	}
+	return m, true
+}
+
+// AMQPHeaders is a carrier of AMQP headers, whose values are set as strings.
+type AMQPHeaders amqp.Table
+
+// Get returns the value of the key, formatted as a string if it is not one.
+func (h AMQPHeaders) Get(key string) string {
+	switch v := h[key].(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// Set sets the value of the key.
+func (h AMQPHeaders) Set(key, value string) {
+	h[key] = value
+}
+
+// Keys returns the sorted keys of the headers.
+func (h AMQPHeaders) Keys() []string {
+	keys := make([]string, 0, len(h))
+	for key, v := range h {
+		if v != nil {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// MQTTUserProperties is a carrier of the user properties of MQTT v5 messages, e.g. (*MQTTUserProperties)(&msg.Properties.User).
+type MQTTUserProperties paho.UserProperties
+
+// Get returns the first value of the key.
+func (u *MQTTUserProperties) Get(key string) string {
+	return paho.UserProperties(*u).Get(key)
+}
+
+// Set sets the value of the key.
+func (u *MQTTUserProperties) Set(key, value string) {
+	for i := range *u {
+		if (*u)[i].Key == key {
+			(*u)[i].Value = value
+			return
+		}
+	}
+	*u = append(*u, paho.UserProperty{Key: key, Value: value})
+}
+
+// Keys returns the keys of the user properties in order.
+func (u *MQTTUserProperties) Keys() []string {
+	keys := make([]string, 0, len(*u))
+	for _, p := range *u {
+		keys = append(keys, p.Key)
+	}
+	return keys
+}
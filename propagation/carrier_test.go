@@ -0,0 +1,145 @@
+package propagation
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/eclipse/paho.golang/paho"
+	"github.com/streadway/amqp"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestCarriers(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		carrier      Carrier
+		expectedKeys []string
+	}{
+		"http headers": {
+			carrier:      HTTPHeaders(http.Header{"B": {"2"}, "A": {"1"}}),
+			expectedKeys: []string{"A", "B", "Key"},
+		},
+		"grpc metadata": {
+			carrier:      GRPCMetadata(metadata.Pairs("b", "2", "a", "1")),
+			expectedKeys: []string{"a", "b", "key"},
+		},
+		"kafka headers": {
+			carrier:      &KafkaHeaders{{Key: []byte("b"), Value: []byte("2")}, {Key: []byte("a"), Value: []byte("1")}},
+			expectedKeys: []string{"b", "a", "key"},
+		},
+		"kafka consumer headers": {
+			carrier:      &KafkaConsumerHeaders{{Key: []byte("b"), Value: []byte("2")}, nil, {Key: []byte("a"), Value: []byte("1")}},
+			expectedKeys: []string{"b", "a", "key"},
+		},
+		"sqs attributes": {
+			carrier: SQSAttributes{
+				"b":      {DataType: aws.String("String"), StringValue: aws.String("2")},
+				"a":      {DataType: aws.String("String"), StringValue: aws.String("1")},
+				"binary": {DataType: aws.String("Binary"), BinaryValue: []byte("3")},
+			},
+			expectedKeys: []string{"a", "b", "key"},
+		},
+		"sns attributes": {
+			carrier: SNSAttributes{
+				"b":      {DataType: aws.String("String"), StringValue: aws.String("2")},
+				"a":      {DataType: aws.String("String"), StringValue: aws.String("1")},
+				"binary": {DataType: aws.String("Binary"), BinaryValue: []byte("3")},
+			},
+			expectedKeys: []string{"a", "b", "key"},
+		},
+		"amqp headers": {
+			carrier:      AMQPHeaders(amqp.Table{"b": "2", "a": []byte("1")}),
+			expectedKeys: []string{"a", "b", "key"},
+		},
+		"mqtt user properties": {
+			carrier:      &MQTTUserProperties{{Key: "b", Value: "2"}, {Key: "a", Value: "1"}},
+			expectedKeys: []string{"b", "a", "key"},
+		},
+		"map": {
+			carrier:      MapCarrier{"b": "2", "a": "1"},
+			expectedKeys: []string{"a", "b", "key"},
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, "1", tt.carrier.Get("a"))
+			assert.Equal(t, "2", tt.carrier.Get("b"))
+			assert.Empty(t, tt.carrier.Get("missing"))
+
+			tt.carrier.Set("key", "value")
+			tt.carrier.Set("key", "new value")
+			tt.carrier.Set("b", "3")
+			assert.Equal(t, "new value", tt.carrier.Get("key"))
+			assert.Equal(t, "3", tt.carrier.Get("b"))
+			assert.Equal(t, tt.expectedKeys, tt.carrier.Keys())
+		})
+	}
+}
+
+func TestAMQPHeaders_Get(t *testing.T) {
+	t.Parallel()
+	h := AMQPHeaders(amqp.Table{"int": int32(1), "nil": nil})
+	assert.Equal(t, "1", h.Get("int"))
+	assert.Empty(t, h.Get("nil"))
+	assert.Equal(t, []string{"int"}, h.Keys())
+}
+
+func TestKafkaHeaders_Set(t *testing.T) {
+	t.Parallel()
+	var hh []sarama.RecordHeader
+	(*KafkaHeaders)(&hh).Set("key", "value")
+	assert.Equal(t, []sarama.RecordHeader{{Key: []byte("key"), Value: []byte("value")}}, hh)
+}
+
+func TestMQTTUserProperties_Set(t *testing.T) {
+	t.Parallel()
+	props := &paho.PublishProperties{}
+	(*MQTTUserProperties)(&props.User).Set("key", "value")
+	assert.Equal(t, paho.UserProperties{{Key: "key", Value: "value"}}, props.User)
+}
+
+func TestSQSAttributes_Set(t *testing.T) {
+	t.Parallel()
+	a := SQSAttributes{}
+	a.Set("key", "value")
+	assert.Equal(t, &sqs.MessageAttributeValue{DataType: aws.String("String"), StringValue: aws.String("value")}, a["key"])
+	s := SNSAttributes{}
+	s.Set("key", "value")
+	assert.Equal(t, &sns.MessageAttributeValue{DataType: aws.String("String"), StringValue: aws.String("value")}, s["key"])
+}
+
+func TestSNSNotificationAttributes(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		body     string
+		expected MapCarrier
+		ok       bool
+	}{
+		"notification": {
+			body: `{"Type":"Notification","Message":"{}","MessageAttributes":{` +
+				`"X-Correlation-Id":{"Type":"String","Value":"123"},"count":{"Type":"Number","Value":"1"}}}`,
+			expected: MapCarrier{"X-Correlation-Id": "123"},
+			ok:       true,
+		},
+		"notification without attributes": {body: `{"Type":"Notification","Message":"{}"}`, expected: MapCarrier{}, ok: true},
+		"other type":                      {body: `{"Type":"SubscriptionConfirmation"}`},
+		"json":                            {body: `{"key":"value"}`},
+		"text":                            {body: "text"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			got, ok := SNSNotificationAttributes(tt.body)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package propagation
+
+import "errors"
+
+// OptionFunc definition for configuring the propagation in a functional way.
+type OptionFunc func(*config) error
+
+// CorrelationKey option for setting the key of the correlation ID in the carriers, which defaults to correlation.HeaderID.
+func CorrelationKey(key string) OptionFunc {
+	return func(c *config) error {
+		if key == "" {
+			return errors.New("correlation key is empty")
+		}
+		c.correlationHeader = key
+		return nil
+	}
+}
+
+// Baggage option for propagating the keys of the carriers as baggage items of the spans, so that they are propagated
+// across every transport e.g. the ID of the tenant of the requests, without being handled by the services.
+func Baggage(keys ...string) OptionFunc {
+	return func(c *config) error {
+		if len(keys) == 0 {
+			return errors.New("baggage keys are empty")
+		}
+		for _, key := range keys {
+			if key == "" {
+				return errors.New("baggage key is empty")
+			}
+		}
+		c.baggage = append(c.baggage, keys...)
+		return nil
+	}
+}
@@ -0,0 +1,169 @@
+// Package propagation injects and extracts the trace context, the correlation ID and the baggage of the requests and the messages
+// of every transport, i.e. HTTP headers, gRPC metadata, Kafka headers, SQS and SNS message attributes, AMQP headers and MQTT user properties,
+// according to a single configuration.
+package propagation
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"github.com/beatlabs/patron/correlation"
+	"github.com/beatlabs/patron/log"
+	"github.com/beatlabs/patron/trace"
+	"github.com/google/uuid"
+	"github.com/opentracing/opentracing-go"
+)
+
+// Carrier is implemented by the headers of the transports.
+type Carrier interface {
+	// Get returns the value of the key, or an empty string if the key is missing.
+	Get(key string) string
+	// Set sets the value of the key, replacing the existing one.
+	Set(key, value string)
+	// Keys returns the keys of the carrier.
+	Keys() []string
+}
+
+type config struct {
+	correlationHeader string
+	baggage           []string
+}
+
+var cfg = defaultConfig()
+
+func defaultConfig() config {
+	return config{correlationHeader: correlation.HeaderID}
+}
+
+// Setup configures the propagation of every transport, and should be called before the service starts.
+// Without options, the default configuration is restored.
+func Setup(oo ...OptionFunc) error {
+	c := defaultConfig()
+	for _, option := range oo {
+		if err := option(&c); err != nil {
+			return err
+		}
+	}
+	cfg = c
+	return nil
+}
+
+// CorrelationHeader returns the key of the correlation ID in the carriers.
+func CorrelationHeader() string {
+	return cfg.correlationHeader
+}
+
+// Inject injects the trace context of the span, its configured baggage items and the correlation ID of the context into the carrier.
+// The correlation ID of the carrier is kept, if it is already set. If the span is nil, e.g. when the trace context is injected
+// by the instrumentation of the HTTP client, the configured baggage items of the span of the context are injected instead.
+func Inject(ctx context.Context, sp opentracing.Span, c Carrier) error {
+	if c.Get(cfg.correlationHeader) == "" {
+		c.Set(cfg.correlationHeader, correlation.IDFromContext(ctx))
+	}
+	baggage := sp
+	if baggage == nil {
+		baggage = opentracing.SpanFromContext(ctx)
+	}
+	if baggage != nil {
+		for _, key := range cfg.baggage {
+			if val := baggage.BaggageItem(key); val != "" {
+				c.Set(key, val)
+			}
+		}
+	}
+	if sp == nil {
+		return nil
+	}
+	return sp.Tracer().Inject(sp.Context(), opentracing.TextMap, textMap{c})
+}
+
+// CorrelationID returns the correlation ID of the carrier, or a new one if it is missing.
+func CorrelationID(c Carrier) string {
+	if corID := c.Get(cfg.correlationHeader); corID != "" {
+		return corID
+	}
+	log.Debug("correlation header not found, creating new correlation UUID")
+	return uuid.New().String()
+}
+
+// Extract returns the span context of the carrier, or nil if it is missing, and its correlation ID, or a new one if it is missing.
+func Extract(c Carrier) (opentracing.SpanContext, string) {
+	spCtx, err := opentracing.GlobalTracer().Extract(opentracing.HTTPHeaders, textMap{c})
+	if err != nil {
+		if !errors.Is(err, opentracing.ErrSpanContextNotFound) {
+			log.Errorf("failed to extract span context: %v", err)
+		}
+		spCtx = nil
+	}
+	return spCtx, CorrelationID(c)
+}
+
+// ConsumerSpan starts a consumer span of the request or the message of the carrier, which follows its span context and carries
+// its configured baggage items. The returned context carries the span and the correlation ID of the carrier.
+func ConsumerSpan(ctx context.Context, opName, cmp string, c Carrier, tags ...opentracing.Tag) (opentracing.Span, context.Context) {
+	corID := CorrelationID(c)
+	sp, ctx := trace.ConsumerSpan(ctx, opName, cmp, corID, Map(c), tags...)
+	SetBaggage(sp, c)
+	return sp, correlation.ContextWithID(ctx, corID)
+}
+
+// SetBaggage sets the configured baggage items of the carrier on the span, e.g. of the server spans of the incoming requests.
+func SetBaggage(sp opentracing.Span, c Carrier) {
+	for _, key := range cfg.baggage {
+		if val := c.Get(key); val != "" {
+			sp.SetBaggageItem(key, val)
+		}
+	}
+}
+
+// Map returns the keys and the values of the carrier.
+func Map(c Carrier) map[string]string {
+	keys := c.Keys()
+	m := make(map[string]string, len(keys))
+	for _, key := range keys {
+		m[key] = c.Get(key)
+	}
+	return m
+}
+
+// MapCarrier is a carrier of a map, e.g. of the tags of the Kinesis records.
+type MapCarrier map[string]string
+
+// Get returns the value of the key.
+func (m MapCarrier) Get(key string) string {
+	return m[key]
+}
+
+// Set sets the value of the key.
+func (m MapCarrier) Set(key, value string) {
+	m[key] = value
+}
+
+// Keys returns the sorted keys of the map.
+func (m MapCarrier) Keys() []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// textMap adapts a carrier to the opentracing text map reader and writer.
+type textMap struct {
+	c Carrier
+}
+
+func (t textMap) Set(key, val string) {
+	t.c.Set(key, val)
+}
+
+func (t textMap) ForeachKey(handler func(key, val string) error) error {
+	for _, key := range t.c.Keys() {
+		if err := handler(key, t.c.Get(key)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package propagation
+
+import (
+	"context"
+	"testing"
+
+	"github.com/beatlabs/patron/correlation"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var mtr = mocktracer.New()
+
+func TestMain(m *testing.M) {
+	opentracing.SetGlobalTracer(mtr)
+	m.Run()
+}
+
+func TestSetup(t *testing.T) {
+	tests := map[string]struct {
+		options             []OptionFunc
+		expectedCorrelation string
+		expectedBaggage     []string
+		expectedErr         string
+	}{
+		"default":           {expectedCorrelation: correlation.HeaderID},
+		"success":           {options: []OptionFunc{CorrelationKey("X-Request-Id"), Baggage("tenant"), Baggage("region")}, expectedCorrelation: "X-Request-Id", expectedBaggage: []string{"tenant", "region"}},
+		"empty correlation": {options: []OptionFunc{CorrelationKey("")}, expectedErr: "correlation key is empty"},
+		"empty baggage":     {options: []OptionFunc{Baggage()}, expectedErr: "baggage keys are empty"},
+		"empty baggage key": {options: []OptionFunc{Baggage("tenant", "")}, expectedErr: "baggage key is empty"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Cleanup(func() { require.NoError(t, Setup()) })
+			err := Setup(tt.options...)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.Equal(t, correlation.HeaderID, CorrelationHeader())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedCorrelation, CorrelationHeader())
+				assert.Equal(t, tt.expectedBaggage, cfg.baggage)
+			}
+		})
+	}
+}
+
+func TestInject(t *testing.T) {
+	require.NoError(t, Setup(Baggage("tenant")))
+	t.Cleanup(func() {
+		require.NoError(t, Setup())
+		mtr.Reset()
+	})
+
+	sp := mtr.StartSpan("test")
+	sp.SetBaggageItem("tenant", "beat")
+	sp.SetBaggageItem("other", "value")
+	ctx := correlation.ContextWithID(context.Background(), "123")
+
+	c := MapCarrier{}
+	require.NoError(t, Inject(ctx, sp, c))
+	assert.Equal(t, "123", c[correlation.HeaderID])
+	assert.Equal(t, "beat", c["tenant"])
+	assert.NotContains(t, c, "other")
+	spCtx, err := mtr.Extract(opentracing.TextMap, opentracing.TextMapCarrier(c))
+	require.NoError(t, err)
+	assert.Equal(t, sp.Context().(mocktracer.MockSpanContext).SpanID, spCtx.(mocktracer.MockSpanContext).SpanID)
+
+	// the correlation ID of the carrier is kept
+	c = MapCarrier{correlation.HeaderID: "456"}
+	require.NoError(t, Inject(ctx, sp, c))
+	assert.Equal(t, "456", c[correlation.HeaderID])
+
+	// the baggage of the span of the context is injected without the span
+	c = MapCarrier{}
+	require.NoError(t, Inject(opentracing.ContextWithSpan(ctx, sp), nil, c))
+	assert.Equal(t, MapCarrier{correlation.HeaderID: "123", "tenant": "beat"}, c)
+}
+
+func TestCorrelationID(t *testing.T) {
+	require.NoError(t, Setup(CorrelationKey("X-Request-Id")))
+	t.Cleanup(func() { require.NoError(t, Setup()) })
+
+	assert.Equal(t, "123", CorrelationID(MapCarrier{"X-Request-Id": "123"}))
+	corID := CorrelationID(MapCarrier{correlation.HeaderID: "123"})
+	assert.NotEmpty(t, corID)
+	assert.NotEqual(t, "123", corID)
+}
+
+func TestExtract(t *testing.T) {
+	t.Cleanup(func() { mtr.Reset() })
+	sp := mtr.StartSpan("test")
+	c := MapCarrier{correlation.HeaderID: "123"}
+	require.NoError(t, mtr.Inject(sp.Context(), opentracing.TextMap, opentracing.TextMapCarrier(c)))
+
+	spCtx, corID := Extract(c)
+	require.NotNil(t, spCtx)
+	assert.Equal(t, sp.Context().(mocktracer.MockSpanContext).SpanID, spCtx.(mocktracer.MockSpanContext).SpanID)
+	assert.Equal(t, "123", corID)
+
+	spCtx, corID = Extract(MapCarrier{})
+	assert.Nil(t, spCtx)
+	assert.NotEmpty(t, corID)
+}
+
+func TestConsumerSpan(t *testing.T) {
+	require.NoError(t, Setup(Baggage("tenant")))
+	t.Cleanup(func() {
+		require.NoError(t, Setup())
+		mtr.Reset()
+	})
+
+	c := MapCarrier{correlation.HeaderID: "123", "tenant": "beat", "other": "value"}
+	sp, ctx := ConsumerSpan(context.Background(), "op", "cmp", c, opentracing.Tag{Key: "key", Value: "value"})
+	require.NotNil(t, sp)
+	assert.Equal(t, sp, opentracing.SpanFromContext(ctx))
+	assert.Equal(t, "123", correlation.IDFromContext(ctx))
+	assert.Equal(t, "beat", sp.BaggageItem("tenant"))
+	assert.Empty(t, sp.BaggageItem("other"))
+	sp.Finish()
+
+	spans := mtr.FinishedSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "123", spans[0].Tag(correlation.ID))
+	assert.Equal(t, "value", spans[0].Tag("key"))
+}
+
+func TestMap(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, map[string]string{"a": "1", "b": "2"}, Map(MapCarrier{"a": "1", "b": "2"}))
+	assert.Empty(t, Map(MapCarrier{}))
+}
@@ -0,0 +1,33 @@
+package propagation
+
+import "context"
+
+// SyntheticHeader is used to tag synthetic HTTP requests and the headers of synthetic messages.
+const SyntheticHeader = "X-Patron-Synthetic"
+
+type syntheticKey struct{}
+
+// WithSynthetic flags the context as carrying synthetic traffic.
+func WithSynthetic(ctx context.Context) context.Context {
+	return context.WithValue(ctx, syntheticKey{}, true)
+}
+
+// IsSynthetic returns true if the context carries synthetic traffic.
+// Handlers and processors should use it in order to exclude synthetic traffic from business metrics.
+func IsSynthetic(ctx context.Context) bool {
+	synthetic, _ := ctx.Value(syntheticKey{}).(bool)
+	return synthetic
+}
+
+// TagSynthetic sets the synthetic header on the carrier, e.g. the headers of a message published by a synthetic generator.
+func TagSynthetic(c Carrier) {
+	c.Set(SyntheticHeader, "true")
+}
+
+// SyntheticContext flags the context of a request or a consumed message whose carrier holds the synthetic header.
+func SyntheticContext(ctx context.Context, c Carrier) context.Context {
+	if c.Get(SyntheticHeader) == "" {
+		return ctx
+	}
+	return WithSynthetic(ctx)
+}
@@ -0,0 +1,20 @@
+package propagation
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSyntheticContext(t *testing.T) {
+	assert.False(t, IsSynthetic(context.Background()))
+	assert.True(t, IsSynthetic(WithSynthetic(context.Background())))
+
+	c := MapCarrier{}
+	assert.False(t, IsSynthetic(SyntheticContext(context.Background(), c)))
+
+	TagSynthetic(c)
+	assert.Equal(t, "true", c.Get(SyntheticHeader))
+	assert.True(t, IsSynthetic(SyntheticContext(context.Background(), c)))
+}
@@ -0,0 +1,83 @@
+package retry
+
+import (
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// AttemptsTag is the span tag holding the total number of attempts.
+	AttemptsTag = "attempts"
+	// AttemptEvent is the name of the span event recorded for every attempt.
+	AttemptEvent = "attempt"
+
+	outcomeSuccess = "success"
+	outcomeFailure = "failure"
+	outcomeUnknown = "unknown"
+)
+
+var retryCounter *prometheus.CounterVec
+
+func init() {
+	retryCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "reliability",
+			Subsystem: "retry",
+			Name:      "attempts_total",
+			Help:      "Counts retry attempts (excluding the initial one) per client and outcome.",
+		},
+		[]string{"client", "outcome"},
+	)
+	prometheus.MustRegister(retryCounter)
+}
+
+// ObserveAttempt records an attempt of a client as an event on the span. Attempts after the first one
+// also increment the shared retry metric. Attempts are numbered starting from 1.
+func ObserveAttempt(sp opentracing.Span, client string, attempt int, err error) {
+	if sp != nil {
+		ff := []log.Field{log.String("event", AttemptEvent), log.Int(AttemptsTag, attempt)}
+		if err != nil {
+			ff = append(ff, log.Error(err))
+		}
+		sp.LogFields(ff...)
+	}
+
+	if attempt <= 1 {
+		return
+	}
+	outcome := outcomeSuccess
+	if err != nil {
+		outcome = outcomeFailure
+	}
+	retryCounter.WithLabelValues(client, outcome).Inc()
+}
+
+// ObserveRetry increments the shared retry metric for a retry of a client, whose outcome is not known. It is meant for
+// clients relying on the retries of their libraries, which do not report the outcome of the retries.
+func ObserveRetry(client string) {
+	retryCounter.WithLabelValues(client, outcomeUnknown).Inc()
+}
+
+// ObserveAttempts tags the span with the total number of attempts.
+func ObserveAttempts(sp opentracing.Span, attempts int) {
+	if sp == nil {
+		return
+	}
+	sp.SetTag(AttemptsTag, attempts)
+}
+
+// ExecuteTraced executes the action like Execute, while recording every attempt on the span and the shared retry metric.
+func (r Retry) ExecuteTraced(sp opentracing.Span, client string, act Action) (interface{}, error) {
+	attempt := 0
+	defer func() {
+		ObserveAttempts(sp, attempt)
+	}()
+
+	return r.Execute(func() (interface{}, error) {
+		attempt++
+		res, err := act()
+		ObserveAttempt(sp, client, attempt, err)
+		return res, err
+	})
+}
@@ -0,0 +1,73 @@
+package retry
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetry_ExecuteTraced(t *testing.T) {
+	testCases := map[string]struct {
+		action           mockAction
+		expectedAttempts int
+		expectedSuccess  float64
+		expectedFailure  float64
+		expectErr        bool
+	}{
+		"instant success":        {action: mockAction{errors: 0}, expectedAttempts: 1},
+		"success after an error": {action: mockAction{errors: 1}, expectedAttempts: 2, expectedSuccess: 1},
+		"error after exceeding number of failed attempts": {
+			action:           mockAction{errors: 3},
+			expectedAttempts: 3,
+			expectedFailure:  2,
+			expectErr:        true,
+		},
+	}
+	for name, tC := range testCases {
+		tC := tC
+		t.Run(name, func(t *testing.T) {
+			retryCounter.Reset()
+			r, err := New(3, 0)
+			require.NoError(t, err)
+			sp := mocktracer.New().StartSpan("test").(*mocktracer.MockSpan)
+
+			_, err = r.ExecuteTraced(sp, "test-client", func() (interface{}, error) {
+				return tC.action.Execute()
+			})
+			if tC.expectErr {
+				assert.Equal(t, errTest, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tC.expectedAttempts, sp.Tag(AttemptsTag))
+			logs := sp.Logs()
+			require.Len(t, logs, tC.expectedAttempts)
+			for i, l := range logs {
+				assert.Equal(t, AttemptEvent, l.Fields[0].ValueString)
+				assert.Equal(t, strconv.Itoa(i+1), l.Fields[1].ValueString)
+			}
+			assert.Equal(t, tC.expectedSuccess, testutil.ToFloat64(retryCounter.WithLabelValues("test-client", outcomeSuccess)))
+			assert.Equal(t, tC.expectedFailure, testutil.ToFloat64(retryCounter.WithLabelValues("test-client", outcomeFailure)))
+		})
+	}
+}
+
+func TestObserveAttempt_NilSpan(t *testing.T) {
+	retryCounter.Reset()
+	ObserveAttempt(nil, "test-client", 2, errTest)
+	ObserveAttempts(nil, 2)
+	assert.Equal(t, 1.0, testutil.ToFloat64(retryCounter.WithLabelValues("test-client", outcomeFailure)))
+}
+
+func TestObserveRetry(t *testing.T) {
+	retryCounter.Reset()
+	ObserveRetry("test-client")
+	ObserveRetry("test-client")
+	assert.Equal(t, 2.0, testutil.ToFloat64(retryCounter.WithLabelValues("test-client", outcomeUnknown)))
+	assert.Equal(t, 0.0, testutil.ToFloat64(retryCounter.WithLabelValues("test-client", outcomeFailure)))
+}
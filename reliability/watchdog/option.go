@@ -0,0 +1,60 @@
+package watchdog
+
+import (
+	"errors"
+	"time"
+)
+
+// OptionFunc definition for configuring the watchdog in a functional way.
+type OptionFunc func(*Watchdog) error
+
+// Limit option for setting the memory limit in bytes, instead of the memory limit of the container.
+func Limit(bytes uint64) OptionFunc {
+	return func(w *Watchdog) error {
+		if bytes == 0 {
+			return errors.New("limit should be positive")
+		}
+		w.limit = bytes
+		return nil
+	}
+}
+
+// Thresholds option for setting the ratios of the limit, above which the memory pressure becomes elevated and critical.
+// The defaults are 0.8 and 0.9 respectively.
+func Thresholds(elevated, critical float64) OptionFunc {
+	return func(w *Watchdog) error {
+		if elevated <= 0 || critical > 1 {
+			return errors.New("thresholds should be in (0, 1]")
+		}
+		w.elevated = elevated
+		w.critical = critical
+		return nil
+	}
+}
+
+// Interval option for setting the interval of checking the memory usage, which defaults to one second.
+func Interval(interval time.Duration) OptionFunc {
+	return func(w *Watchdog) error {
+		if interval <= 0 {
+			return errors.New("interval should be positive")
+		}
+		w.interval = interval
+		return nil
+	}
+}
+
+// Hooks option for registering hooks, which are invoked in order on every change of the memory pressure level.
+func Hooks(hh ...HookFunc) OptionFunc {
+	return func(w *Watchdog) error {
+		if len(hh) == 0 {
+			return errors.New("hooks are empty")
+		}
+		for _, h := range hh {
+			if h == nil {
+				return errors.New("hook is nil")
+			}
+		}
+		w.hooks = append(w.hooks, hh...)
+		return nil
+	}
+}
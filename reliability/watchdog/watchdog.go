@@ -0,0 +1,243 @@
+// Package watchdog provides a memory pressure watchdog, which triggers degradation hooks before the process
+// is killed for running out of memory.
+package watchdog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	v2 "github.com/beatlabs/patron/component/http/v2"
+	"github.com/beatlabs/patron/internal/cgroup"
+	"github.com/beatlabs/patron/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Level of memory pressure.
+type Level int32
+
+const (
+	// Normal represents a memory usage below the elevated threshold.
+	Normal Level = iota
+	// Elevated represents a memory usage above the elevated threshold.
+	Elevated
+	// Critical represents a memory usage above the critical threshold.
+	Critical
+)
+
+func (l Level) String() string {
+	switch l {
+	case Normal:
+		return "normal"
+	case Elevated:
+		return "elevated"
+	case Critical:
+		return "critical"
+	default:
+		return "unknown"
+	}
+}
+
+const (
+	defaultInterval          = time.Second
+	defaultElevatedThreshold = 0.8
+	defaultCriticalThreshold = 0.9
+)
+
+var (
+	levelGauge  prometheus.Gauge
+	memoryGauge *prometheus.GaugeVec
+)
+
+func init() {
+	levelGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "reliability",
+		Subsystem: "watchdog",
+		Name:      "level",
+		Help:      "Memory pressure level, 0 for normal, 1 for elevated and 2 for critical.",
+	})
+	memoryGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "reliability",
+		Subsystem: "watchdog",
+		Name:      "memory_bytes",
+		Help:      "Memory monitored by the watchdog, classified by type (heap, rss and limit).",
+	}, []string{"type"})
+	prometheus.MustRegister(levelGauge, memoryGauge)
+}
+
+// Usage of memory.
+type Usage struct {
+	// Heap is the memory allocated by the Go heap.
+	Heap uint64
+	// RSS is the resident memory of the process, zero if it could not be read.
+	RSS uint64
+}
+
+// HookFunc is invoked on every change of the memory pressure level, in order to degrade the service when the pressure rises
+// e.g. by shrinking caches, and to restore it when the pressure falls.
+type HookFunc func(ctx context.Context, level Level, usage Usage)
+
+// Watchdog monitors the memory usage of the process against a limit.
+type Watchdog struct {
+	limit    uint64
+	elevated float64
+	critical float64
+	interval time.Duration
+	hooks    []HookFunc
+	level    int32
+	read     func() (Usage, error)
+}
+
+// New creates a watchdog. The limit defaults to the memory limit of the container, if any.
+func New(oo ...OptionFunc) (*Watchdog, error) {
+	w := &Watchdog{
+		elevated: defaultElevatedThreshold,
+		critical: defaultCriticalThreshold,
+		interval: defaultInterval,
+		read:     readUsage,
+	}
+
+	for _, option := range oo {
+		if err := option(w); err != nil {
+			return nil, err
+		}
+	}
+
+	if w.elevated >= w.critical {
+		return nil, errors.New("elevated threshold should be lower than the critical threshold")
+	}
+
+	if w.limit == 0 {
+		limits, err := cgroup.Read(cgroup.Root)
+		if err != nil {
+			return nil, fmt.Errorf("could not read memory limit: %w", err)
+		}
+		if limits.Memory <= 0 {
+			return nil, errors.New("memory limit is not set and could not be detected")
+		}
+		w.limit = uint64(limits.Memory)
+	}
+
+	return w, nil
+}
+
+// Run monitors the memory usage until the context is done, which allows the watchdog to be used as a component of the service.
+func (w *Watchdog) Run(ctx context.Context) error {
+	memoryGauge.WithLabelValues("limit").Set(float64(w.limit))
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		w.check(ctx)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Level returns the current memory pressure level, which can be used e.g. for rejecting low priority traffic.
+func (w *Watchdog) Level() Level {
+	return Level(atomic.LoadInt32(&w.level))
+}
+
+// ReadyCheck returns a readiness check, which reports the service as not ready while the memory pressure is critical,
+// so that traffic is routed to other instances. Otherwise, the provided check is used, if any.
+func (w *Watchdog) ReadyCheck(rcf v2.ReadyCheckFunc) v2.ReadyCheckFunc {
+	return func() v2.ReadyStatus {
+		if w.Level() == Critical {
+			return v2.NotReady
+		}
+		if rcf == nil {
+			return v2.Ready
+		}
+		return rcf()
+	}
+}
+
+func (w *Watchdog) check(ctx context.Context) {
+	usage, err := w.read()
+	if err != nil {
+		log.FromContext(ctx).Warnf("could not read memory usage: %v", err)
+		return
+	}
+	memoryGauge.WithLabelValues("heap").Set(float64(usage.Heap))
+	memoryGauge.WithLabelValues("rss").Set(float64(usage.RSS))
+
+	level := w.levelOf(usage)
+	previous := Level(atomic.SwapInt32(&w.level, int32(level)))
+	levelGauge.Set(float64(level))
+	if level == previous {
+		return
+	}
+
+	log.FromContext(ctx).Warnf("memory pressure changed from %s to %s: heap %d, rss %d, limit %d bytes", previous, level, usage.Heap, usage.RSS, w.limit)
+	for _, hook := range w.hooks {
+		hook(ctx, level, usage)
+	}
+}
+
+// levelOf returns the level of the highest of the heap and the RSS usage.
+func (w *Watchdog) levelOf(usage Usage) Level {
+	used := usage.Heap
+	if usage.RSS > used {
+		used = usage.RSS
+	}
+	ratio := float64(used) / float64(w.limit)
+	switch {
+	case ratio >= w.critical:
+		return Critical
+	case ratio >= w.elevated:
+		return Elevated
+	default:
+		return Normal
+	}
+}
+
+// ForceGC is a hook, which forces a garbage collection and returns as much memory as possible to the OS,
+// when the memory pressure becomes critical.
+func ForceGC(_ context.Context, level Level, _ Usage) {
+	if level == Critical {
+		debug.FreeOSMemory()
+	}
+}
+
+func readUsage() (Usage, error) {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	rss, err := readRSS("/proc/self/statm")
+	if err != nil {
+		return Usage{}, err
+	}
+	return Usage{Heap: ms.HeapAlloc, RSS: rss}, nil
+}
+
+// readRSS reads the resident memory of the process from the provided statm file, returning zero if it does not exist.
+func readRSS(path string) (uint64, error) {
+	b, err := ioutil.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	ff := strings.Fields(string(b))
+	if len(ff) < 2 {
+		return 0, fmt.Errorf("invalid statm content: %s", string(b))
+	}
+	pages, err := strconv.ParseUint(ff[1], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid resident pages: %w", err)
+	}
+	return pages * uint64(os.Getpagesize()), nil
+}
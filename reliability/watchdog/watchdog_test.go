@@ -0,0 +1,151 @@
+package watchdog
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	v2 "github.com/beatlabs/patron/component/http/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	hook := func(context.Context, Level, Usage) {}
+
+	tests := map[string]struct {
+		oo          []OptionFunc
+		expectedErr string
+	}{
+		"success":             {oo: []OptionFunc{Limit(100), Thresholds(0.5, 0.7), Interval(time.Millisecond), Hooks(hook, ForceGC)}},
+		"invalid limit":       {oo: []OptionFunc{Limit(0)}, expectedErr: "limit should be positive"},
+		"invalid thresholds":  {oo: []OptionFunc{Limit(100), Thresholds(0, 2)}, expectedErr: "thresholds should be in (0, 1]"},
+		"inverted thresholds": {oo: []OptionFunc{Limit(100), Thresholds(0.9, 0.8)}, expectedErr: "elevated threshold should be lower than the critical threshold"},
+		"invalid interval":    {oo: []OptionFunc{Limit(100), Interval(0)}, expectedErr: "interval should be positive"},
+		"empty hooks":         {oo: []OptionFunc{Limit(100), Hooks()}, expectedErr: "hooks are empty"},
+		"nil hook":            {oo: []OptionFunc{Limit(100), Hooks(nil)}, expectedErr: "hook is nil"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			got, err := New(tt.oo...)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, got)
+			}
+		})
+	}
+}
+
+func TestWatchdog_Check(t *testing.T) {
+	type transition struct {
+		level Level
+		usage Usage
+	}
+	var transitions []transition
+	hook := func(_ context.Context, level Level, usage Usage) {
+		transitions = append(transitions, transition{level: level, usage: usage})
+	}
+
+	w, err := New(Limit(100), Hooks(hook))
+	require.NoError(t, err)
+
+	check := func(usage Usage, err error) {
+		w.read = func() (Usage, error) { return usage, err }
+		w.check(context.Background())
+	}
+
+	check(Usage{Heap: 10, RSS: 20}, nil)
+	assert.Equal(t, Normal, w.Level())
+	assert.Empty(t, transitions)
+
+	check(Usage{Heap: 10, RSS: 85}, nil)
+	assert.Equal(t, Elevated, w.Level())
+	check(Usage{Heap: 95, RSS: 85}, nil)
+	assert.Equal(t, Critical, w.Level())
+	check(Usage{Heap: 96, RSS: 85}, nil)
+	assert.Equal(t, Critical, w.Level())
+
+	// a failed read keeps the level
+	check(Usage{}, errors.New("failed"))
+	assert.Equal(t, Critical, w.Level())
+
+	check(Usage{Heap: 10, RSS: 20}, nil)
+	assert.Equal(t, Normal, w.Level())
+
+	assert.Equal(t, []transition{
+		{level: Elevated, usage: Usage{Heap: 10, RSS: 85}},
+		{level: Critical, usage: Usage{Heap: 95, RSS: 85}},
+		{level: Normal, usage: Usage{Heap: 10, RSS: 20}},
+	}, transitions)
+}
+
+func TestWatchdog_Run(t *testing.T) {
+	critical := make(chan struct{})
+	w, err := New(Limit(100), Interval(time.Millisecond), Hooks(func(_ context.Context, level Level, _ Usage) {
+		if level == Critical {
+			close(critical)
+		}
+	}))
+	require.NoError(t, err)
+	w.read = func() (Usage, error) { return Usage{Heap: 99}, nil }
+
+	ctx, cnl := context.WithCancel(context.Background())
+	chErr := make(chan error)
+	go func() {
+		chErr <- w.Run(ctx)
+	}()
+
+	<-critical
+	cnl()
+	assert.NoError(t, <-chErr)
+}
+
+func TestWatchdog_ReadyCheck(t *testing.T) {
+	w, err := New(Limit(100))
+	require.NoError(t, err)
+
+	assert.Equal(t, v2.Ready, w.ReadyCheck(nil)())
+	assert.Equal(t, v2.NotReady, w.ReadyCheck(func() v2.ReadyStatus { return v2.NotReady })())
+
+	w.level = int32(Critical)
+	assert.Equal(t, v2.NotReady, w.ReadyCheck(func() v2.ReadyStatus { return v2.Ready })())
+}
+
+func TestLevel_String(t *testing.T) {
+	assert.Equal(t, "normal", Normal.String())
+	assert.Equal(t, "elevated", Elevated.String())
+	assert.Equal(t, "critical", Critical.String())
+	assert.Equal(t, "unknown", Level(5).String())
+}
+
+func Test_readRSS(t *testing.T) {
+	dir := t.TempDir()
+	statm := filepath.Join(dir, "statm")
+	require.NoError(t, ioutil.WriteFile(statm, []byte("1000 10 5 1 0 100 0\n"), 0o600))
+
+	rss, err := readRSS(statm)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(10*os.Getpagesize()), rss)
+
+	rss, err = readRSS(filepath.Join(dir, "missing"))
+	require.NoError(t, err)
+	assert.Zero(t, rss)
+
+	require.NoError(t, ioutil.WriteFile(statm, []byte("1000"), 0o600))
+	_, err = readRSS(statm)
+	assert.EqualError(t, err, "invalid statm content: 1000")
+}
+
+func Test_readUsage(t *testing.T) {
+	usage, err := readUsage()
+	require.NoError(t, err)
+	assert.NotZero(t, usage.Heap)
+}
@@ -10,14 +10,19 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
+	"github.com/beatlabs/patron/autotune"
 	patronhttp "github.com/beatlabs/patron/component/http"
 	"github.com/beatlabs/patron/component/http/middleware"
 	v2 "github.com/beatlabs/patron/component/http/v2"
 	patronErrors "github.com/beatlabs/patron/errors"
+	"github.com/beatlabs/patron/internal/diagnostics"
+	"github.com/beatlabs/patron/leak"
 	"github.com/beatlabs/patron/log"
+	"github.com/beatlabs/patron/log/sink"
 	"github.com/beatlabs/patron/log/std"
 	patronzerolog "github.com/beatlabs/patron/log/zerolog"
 	"github.com/beatlabs/patron/trace"
@@ -35,10 +40,16 @@ type Component interface {
 	Run(ctx context.Context) error
 }
 
+// drainer is implemented by the components, which fail their readiness checks while the service is draining, e.g. the HTTP v2 component.
+type drainer interface {
+	Drain()
+}
+
 // service is responsible for managing and setting up everything.
 // The service will start by default an HTTP component in order to host management endpoint.
 type service struct {
 	name              string
+	version           string
 	cps               []Component
 	routesBuilder     *patronhttp.RoutesBuilder
 	middlewares       []middleware.Func
@@ -48,6 +59,12 @@ type service struct {
 	sighupHandler     func()
 	uncompressedPaths []string
 	httpRouter        http.Handler
+	leakDetector      *leak.Detector
+	startup           *lifecycle
+	startupPhases     []startupPhase
+	drainDelay        time.Duration
+	gracePeriod       time.Duration
+	draining          int32
 }
 
 func (s *service) setupOSSignal() {
@@ -61,29 +78,111 @@ func (s *service) run(ctx context.Context) error {
 			log.Errorf("failed to close trace %v", err)
 		}
 	}()
+
+	for _, p := range s.startupPhases {
+		if err := s.startup.phase(p.name, p.fn); err != nil {
+			err = fmt.Errorf("startup phase %s failed: %w", p.name, err)
+			s.startup.finish(err)
+			return err
+		}
+	}
+	s.reportDiagnostics(ctx)
+
 	cctx, cnl := context.WithCancel(ctx)
 	chErr := make(chan error, len(s.cps))
+	stopped := make([]time.Time, len(s.cps))
 	wg := sync.WaitGroup{}
 	wg.Add(len(s.cps))
-	for _, cp := range s.cps {
-		go func(c Component) {
+	for i, cp := range s.cps {
+		go func(i int, c Component) {
 			defer wg.Done()
-			chErr <- c.Run(cctx)
-		}(cp)
+			// the goroutines of the component are labeled, in order to attribute leaks to it
+			leak.Do(cctx, fmt.Sprintf("%T", c), func(ctx context.Context) {
+				err := c.Run(ctx)
+				stopped[i] = time.Now()
+				chErr <- err
+			})
+		}(i, cp)
+	}
+	if s.leakDetector != nil {
+		go s.leakDetector.Monitor(cctx)
 	}
+	s.startup.finish(nil)
 
 	log.FromContext(ctx).Infof("service %s started", s.name)
 	ee := make([]error, 0, len(s.cps))
-	ee = append(ee, s.waitTermination(chErr))
+	termErr := s.waitTermination(chErr)
+	ee = append(ee, termErr)
+
+	shutdown := newLifecycle(ctx, shutdownOpName, time.Now())
+	// a failed component shuts the service down without draining it
+	if s.drainDelay > 0 && termErr == nil {
+		_ = shutdown.phase("drain", s.drain)
+	}
 	cnl()
 
 	wg.Wait()
 	close(chErr)
+	// the components returning before the shutdown are recorded with no duration
+	for i, cp := range s.cps {
+		shutdown.record(fmt.Sprintf("stop %T", cp), shutdown.start, stopped[i], nil)
+	}
+	if s.leakDetector != nil {
+		_ = shutdown.phase("leak detection", func(context.Context) error {
+			s.leakDetector.Report()
+			return nil
+		})
+	}
 
 	for err := range chErr {
 		ee = append(ee, err)
 	}
-	return patronErrors.Aggregate(ee...)
+	err := patronErrors.Aggregate(ee...)
+	shutdown.finish(err)
+	return err
+}
+
+// drain fails the readiness checks of the HTTP components, which keep serving for the drain delay, in order for the load balancers
+// to deregister the service before its components are shut down. Another termination signal skips the rest of the delay.
+func (s *service) drain(ctx context.Context) error {
+	log.FromContext(ctx).Infof("draining service for %v before shutting down", s.drainDelay)
+	atomic.StoreInt32(&s.draining, 1)
+	for _, cp := range s.cps {
+		if d, ok := cp.(drainer); ok {
+			d.Drain()
+		}
+	}
+
+	timer := time.NewTimer(s.drainDelay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case sig := <-s.termSig:
+		log.FromContext(ctx).Infof("signal %s received while draining, shutting down", sig.String())
+	case <-ctx.Done():
+	}
+	return nil
+}
+
+// readyCheck fails the readiness check of the default v1 HTTP component while the service is draining.
+func (s *service) readyCheck() patronhttp.ReadyCheckFunc {
+	return func() patronhttp.ReadyStatus {
+		if atomic.LoadInt32(&s.draining) == 1 {
+			return patronhttp.NotReady
+		}
+		return s.rcf()
+	}
+}
+
+// reportDiagnostics logs the diagnostics report of the environment, which is also served by the default HTTP component.
+func (s *service) reportDiagnostics(ctx context.Context) {
+	cps := make([]interface{}, 0, len(s.cps))
+	for _, cp := range s.cps {
+		cps = append(cps, cp)
+	}
+	report := diagnostics.Collect(s.name, s.version, cps...)
+	diagnostics.Set(report)
+	log.FromContext(ctx).Sub(map[string]interface{}{"diagnostics": report.Fields()}).Infof("service %s %s starting", s.name, s.version)
 }
 
 func (s *service) createHTTPComponent() (Component, error) {
@@ -176,7 +275,11 @@ func (s *service) createHTTPv1(port int, readTimeout, writeTimeout *time.Duratio
 	}
 
 	if s.rcf != nil {
-		b.WithReadyCheckFunc(s.rcf)
+		b.WithReadyCheckFunc(s.readyCheck())
+	}
+
+	if s.gracePeriod > 0 {
+		b.WithShutdownGracePeriod(s.gracePeriod)
 	}
 
 	if s.routesBuilder != nil {
@@ -210,6 +313,10 @@ func (s *service) createHTTPv2(port int, readTimeout, writeTimeout *time.Duratio
 		oo = append(oo, v2.WriteTimeout(*writeTimeout))
 	}
 
+	if s.gracePeriod > 0 {
+		oo = append(oo, v2.ShutdownGracePeriod(s.gracePeriod))
+	}
+
 	return v2.New(s.httpRouter, oo...)
 }
 
@@ -250,6 +357,12 @@ type Builder struct {
 	sighupHandler     func()
 	uncompressedPaths []string
 	httpRouter        http.Handler
+	autoTuning        []autotune.OptionFunc
+	leakDetection     []leak.OptionFunc
+	startupPhases     []startupPhase
+	drainDelay        time.Duration
+	gracePeriod       time.Duration
+	created           time.Time
 }
 
 // Config for setting up the builder.
@@ -293,13 +406,18 @@ func New(name, version string, options ...Option) (*Builder, error) {
 	if name == "" {
 		return nil, errors.New("name is required")
 	}
+	created := time.Now()
 	if version == "" {
 		version = "dev"
 	}
 
-	// default config with structured logger and default fields.
+	err := setupLogTimestamp()
+	if err != nil {
+		return nil, err
+	}
+
+	// default config with default fields, the structured logger is set up afterwards if none is provided.
 	cfg := Config{
-		logger: patronzerolog.New(os.Stderr, getLogLevel(), nil),
 		fields: defaultLogFields(name, version),
 	}
 
@@ -307,7 +425,15 @@ func New(name, version string, options ...Option) (*Builder, error) {
 		option(&cfg)
 	}
 
-	err := setupLogging(cfg.fields, cfg.logger)
+	if cfg.logger == nil {
+		logger, err := defaultLogger(name)
+		if err != nil {
+			return nil, err
+		}
+		cfg.logger = logger
+	}
+
+	err = setupLogging(cfg.fields, cfg.logger)
 	if err != nil {
 		return nil, err
 	}
@@ -320,6 +446,7 @@ func New(name, version string, options ...Option) (*Builder, error) {
 		rcf:           patronhttp.DefaultReadyCheck,
 		termSig:       make(chan os.Signal, 1),
 		sighupHandler: func() { log.Debug("SIGHUP received: nothing setup") },
+		created:       created,
 	}, nil
 }
 
@@ -331,6 +458,69 @@ func getLogLevel() log.Level {
 	return log.Level(lvl)
 }
 
+// defaultLogger returns the structured logger writing to stderr, unless a sink is selected with `PATRON_LOG_SINK`.
+func defaultLogger(name string) (log.Logger, error) {
+	snk, ok := os.LookupEnv("PATRON_LOG_SINK")
+	if !ok || snk == "" || snk == "stderr" {
+		return patronzerolog.New(os.Stderr, getLogLevel(), nil), nil
+	}
+
+	switch snk {
+	case "syslog":
+		network, ok := os.LookupEnv("PATRON_LOG_SYSLOG_NETWORK")
+		if !ok {
+			network = "udp"
+		}
+		address, ok := os.LookupEnv("PATRON_LOG_SYSLOG_ADDRESS")
+		if !ok {
+			address = "localhost:514"
+		}
+		facility := sink.SyslogFacilityUser
+		if fac, ok := os.LookupEnv("PATRON_LOG_SYSLOG_FACILITY"); ok {
+			val, err := strconv.Atoi(fac)
+			if err != nil {
+				return nil, fmt.Errorf("env var for syslog facility is not valid: %w", err)
+			}
+			facility = val
+		}
+		s, err := sink.NewSyslog(network, address, name, facility)
+		if err != nil {
+			return nil, err
+		}
+		return sink.New(s, getLogLevel(), nil), nil
+	case "journald":
+		j, err := sink.NewJournald(os.Getenv("PATRON_LOG_JOURNALD_SOCKET"), name)
+		if err != nil {
+			return nil, err
+		}
+		return sink.New(j, getLogLevel(), nil), nil
+	default:
+		return nil, fmt.Errorf("env var for log sink is not valid: %s", snk)
+	}
+}
+
+func setupLogTimestamp() error {
+	format, formatOk := os.LookupEnv("PATRON_LOG_TIMESTAMP_FORMAT")
+	tz, tzOk := os.LookupEnv("PATRON_LOG_TIMEZONE")
+	if !formatOk && !tzOk {
+		return nil
+	}
+	if format == "" {
+		format = log.TimestampRFC3339
+	}
+
+	loc := time.UTC
+	if tz != "" {
+		var err error
+		loc, err = time.LoadLocation(tz)
+		if err != nil {
+			return fmt.Errorf("env var for log timezone is not valid: %w", err)
+		}
+	}
+
+	return log.SetTimestampFormat(format, loc)
+}
+
 func defaultLogFields(name, version string) map[string]interface{} {
 	hostname, err := os.Hostname()
 	if err != nil {
@@ -501,6 +691,61 @@ func (b *Builder) WithRouter(handler http.Handler) *Builder {
 	return b
 }
 
+// WithAutoTuning aligns GOMAXPROCS, GOMEMLIMIT and GOGC with the CPU and memory limits of the container
+// when the service starts. See the autotune package for details.
+func (b *Builder) WithAutoTuning(oo ...autotune.OptionFunc) *Builder {
+	log.Debug("setting runtime auto tuning")
+	b.autoTuning = append(make([]autotune.OptionFunc, 0, len(oo)), oo...)
+
+	return b
+}
+
+// WithLeakDetection reports the goroutines of the components, which are still running after the service has shut down.
+// See the leak package for details.
+func (b *Builder) WithLeakDetection(oo ...leak.OptionFunc) *Builder {
+	log.Debug("setting goroutine leak detection")
+	b.leakDetection = append(make([]leak.OptionFunc, 0, len(oo)), oo...)
+
+	return b
+}
+
+// WithStartupPhase adds a phase, which is executed before the components are started e.g. running database migrations
+// or warming up caches. Phases are executed in the order they are added, and the service fails to start if one fails.
+// Like the rest of the startup, every phase is traced and its duration is logged.
+func (b *Builder) WithStartupPhase(name string, fn StartupPhaseFunc) *Builder {
+	switch {
+	case name == "":
+		b.errors = append(b.errors, errors.New("startup phase name is empty"))
+	case fn == nil:
+		b.errors = append(b.errors, errors.New("startup phase func is nil"))
+	default:
+		log.Debugf("setting startup phase %s", name)
+		b.startupPhases = append(b.startupPhases, startupPhase{name: name, fn: fn})
+	}
+
+	return b
+}
+
+// WithShutdownDrain sets up the shutdown sequence of the service for the load balancers, e.g. of Kubernetes, so that rolling deployments
+// do not fail requests. When the service is terminated, the readiness checks of the default HTTP component and of the HTTP v2 components fail,
+// while all the components keep running for the drain delay, in order for the load balancers to deregister the service.
+// The components are shut down afterwards, with the default HTTP component completing the in-flight requests up to the grace period.
+// The drain delay should exceed the time needed for the readiness probe to fail, e.g. its period times its failure threshold.
+func (b *Builder) WithShutdownDrain(delay, gracePeriod time.Duration) *Builder {
+	switch {
+	case delay <= 0:
+		b.errors = append(b.errors, errors.New("shutdown drain delay should be positive"))
+	case gracePeriod <= 0:
+		b.errors = append(b.errors, errors.New("shutdown grace period should be positive"))
+	default:
+		log.Debugf("setting shutdown drain delay %v and grace period %v", delay, gracePeriod)
+		b.drainDelay = delay
+		b.gracePeriod = gracePeriod
+	}
+
+	return b
+}
+
 // Build constructs the Patron service by applying the gathered properties.
 func (b *Builder) build() (*service, error) {
 	if len(b.errors) > 0 {
@@ -512,8 +757,35 @@ func (b *Builder) build() (*service, error) {
 		return nil, err
 	}
 
+	// the startup is traced from the creation of the builder, which loads the configuration
+	startup := newLifecycle(context.Background(), startupOpName, b.created)
+	startup.record("configuration", b.created, time.Now(), nil)
+	s, err := b.buildService(startup)
+	if err != nil {
+		startup.finish(err)
+		return nil, err
+	}
+	return s, nil
+}
+
+func (b *Builder) buildService(startup *lifecycle) (*service, error) {
+	if b.autoTuning != nil {
+		err := startup.phase("runtime tuning", func(context.Context) error {
+			settings, err := autotune.Apply(b.autoTuning...)
+			if err != nil {
+				return fmt.Errorf("failed to tune runtime: %w", err)
+			}
+			log.Infof("runtime tuned with GOMAXPROCS %d, GOGC %d and GOMEMLIMIT %d", settings.GOMAXPROCS, settings.GCPercent, settings.MemoryLimit)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	s := service{
 		name:              b.name,
+		version:           b.version,
 		cps:               b.cps,
 		routesBuilder:     b.routesBuilder,
 		middlewares:       b.middlewares,
@@ -523,9 +795,26 @@ func (b *Builder) build() (*service, error) {
 		sighupHandler:     b.sighupHandler,
 		uncompressedPaths: b.uncompressedPaths,
 		httpRouter:        b.httpRouter,
+		startup:           startup,
+		startupPhases:     b.startupPhases,
+		drainDelay:        b.drainDelay,
+		gracePeriod:       b.gracePeriod,
+	}
+
+	if b.leakDetection != nil {
+		var err error
+		s.leakDetector, err = leak.New(b.leakDetection...)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	httpCp, err := s.createHTTPComponent()
+	var httpCp Component
+	err := startup.phase("default HTTP component", func(context.Context) error {
+		var err error
+		httpCp, err = s.createHTTPComponent()
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -8,10 +8,16 @@ import (
 	"net/http"
 	"os"
 	"strconv"
+	"sync/atomic"
+	"syscall"
 	"testing"
+	"time"
 
+	"github.com/beatlabs/patron/autotune"
 	patronhttp "github.com/beatlabs/patron/component/http"
 	"github.com/beatlabs/patron/component/http/middleware"
+	"github.com/beatlabs/patron/component/synthetic"
+	"github.com/beatlabs/patron/leak"
 	"github.com/beatlabs/patron/log"
 	"github.com/beatlabs/patron/log/std"
 	"github.com/stretchr/testify/assert"
@@ -205,6 +211,52 @@ func TestBuilder_WithComponentsTwice(t *testing.T) {
 	assert.Len(t, bld.cps, 2)
 }
 
+func TestBuilder_WithAutoTuning(t *testing.T) {
+	svc, err := New("test", "", TextLogger())
+	require.NoError(t, err)
+	bld := svc.WithAutoTuning(autotune.MemoryLimitRatio(2))
+	assert.Len(t, bld.autoTuning, 1)
+
+	_, err = bld.build()
+	assert.EqualError(t, err, "failed to tune runtime: memory limit ratio should be in (0, 1]")
+}
+
+func TestBuilder_WithLeakDetection(t *testing.T) {
+	svc, err := New("test", "", TextLogger())
+	require.NoError(t, err)
+	bld := svc.WithLeakDetection(leak.Grace(0))
+	assert.Len(t, bld.leakDetection, 1)
+
+	_, err = bld.build()
+	assert.EqualError(t, err, "grace period should be positive")
+}
+
+func TestBuilder_WithStartupPhase(t *testing.T) {
+	svc, err := New("test", "", TextLogger())
+	require.NoError(t, err)
+
+	var executed []string
+	phase := func(name string, err error) StartupPhaseFunc {
+		return func(ctx context.Context) error {
+			executed = append(executed, name)
+			return err
+		}
+	}
+	bld := svc.WithStartupPhase("migrations", phase("migrations", nil)).
+		WithStartupPhase("warmup", phase("warmup", errors.New("cache unavailable"))).
+		WithStartupPhase("never", phase("never", nil))
+	assert.Len(t, bld.startupPhases, 3)
+
+	err = bld.Run(context.Background())
+	assert.EqualError(t, err, "startup phase warmup failed: cache unavailable")
+	assert.Equal(t, []string{"migrations", "warmup"}, executed)
+
+	svc, err = New("test", "", TextLogger())
+	require.NoError(t, err)
+	_, err = svc.WithStartupPhase("", phase("", nil)).WithStartupPhase("nil", nil).build()
+	assert.EqualError(t, err, "startup phase name is empty\nstartup phase func is nil\n")
+}
+
 func TestBuild_FailingConditions(t *testing.T) {
 	tests := map[string]struct {
 		jaegerSamplerParam string
@@ -398,3 +450,201 @@ func TestLogger(t *testing.T) {
 	Logger(logger)(&cfg)
 	assert.Equal(t, logger, cfg.logger)
 }
+
+func TestDefaultLogger(t *testing.T) {
+	defer os.Clearenv()
+
+	tests := map[string]struct {
+		env         map[string]string
+		expectedErr string
+	}{
+		"default":            {env: map[string]string{}},
+		"stderr":             {env: map[string]string{"PATRON_LOG_SINK": "stderr"}},
+		"syslog":             {env: map[string]string{"PATRON_LOG_SINK": "syslog", "PATRON_LOG_SYSLOG_ADDRESS": "127.0.0.1:514"}},
+		"invalid sink":       {env: map[string]string{"PATRON_LOG_SINK": "file"}, expectedErr: "env var for log sink is not valid: file"},
+		"invalid facility":   {env: map[string]string{"PATRON_LOG_SINK": "syslog", "PATRON_LOG_SYSLOG_FACILITY": "a"}, expectedErr: "env var for syslog facility is not valid: strconv.Atoi: parsing \"a\": invalid syntax"},
+		"unsupported syslog": {env: map[string]string{"PATRON_LOG_SINK": "syslog", "PATRON_LOG_SYSLOG_NETWORK": "ip"}, expectedErr: "network \"ip\" is not supported"},
+		"missing journald": {
+			env:         map[string]string{"PATRON_LOG_SINK": "journald", "PATRON_LOG_JOURNALD_SOCKET": "/non/existent/socket"},
+			expectedErr: "failed to connect to journald at /non/existent/socket",
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			os.Clearenv()
+			for k, v := range tt.env {
+				require.NoError(t, os.Setenv(k, v))
+			}
+
+			got, err := defaultLogger("test")
+
+			if tt.expectedErr != "" {
+				assert.Nil(t, got)
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedErr)
+			} else {
+				require.NoError(t, err)
+				assert.NotNil(t, got)
+			}
+		})
+	}
+}
+
+func TestSetupLogTimestamp(t *testing.T) {
+	defer os.Clearenv()
+	defer log.ResetTimestampFormat()
+
+	tests := map[string]struct {
+		env         map[string]string
+		configured  bool
+		expectedErr string
+	}{
+		"not configured":   {env: map[string]string{}},
+		"epoch millis":     {env: map[string]string{"PATRON_LOG_TIMESTAMP_FORMAT": "epoch_millis"}, configured: true},
+		"timezone only":    {env: map[string]string{"PATRON_LOG_TIMEZONE": "Europe/Athens"}, configured: true},
+		"invalid timezone": {env: map[string]string{"PATRON_LOG_TIMEZONE": "Mars/Olympus"}, expectedErr: "env var for log timezone is not valid: unknown time zone Mars/Olympus"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			os.Clearenv()
+			log.ResetTimestampFormat()
+			for k, v := range tt.env {
+				require.NoError(t, os.Setenv(k, v))
+			}
+
+			err := setupLogTimestamp()
+
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.configured, log.TimestampConfigured())
+		})
+	}
+}
+
+func TestBuilder_WithShutdownDrain(t *testing.T) {
+	svc, err := New("test", "", TextLogger())
+	require.NoError(t, err)
+	bld := svc.WithShutdownDrain(time.Second, 2*time.Second)
+	assert.Equal(t, time.Second, bld.drainDelay)
+	assert.Equal(t, 2*time.Second, bld.gracePeriod)
+
+	svc, err = New("test", "", TextLogger())
+	require.NoError(t, err)
+	_, err = svc.WithShutdownDrain(0, time.Second).WithShutdownDrain(time.Second, -time.Second).build()
+	assert.EqualError(t, err, "shutdown drain delay should be positive\nshutdown grace period should be positive\n")
+}
+
+func TestServer_Run_ShutdownDrain(t *testing.T) {
+	defer os.Clearenv()
+	port := getRandomPort(t)
+	require.NoError(t, os.Setenv("PATRON_HTTP_DEFAULT_PORT", port))
+	svc, err := New("test", "", TextLogger())
+	require.NoError(t, err)
+	cp := &drainComponent{}
+	s, err := svc.WithComponents(cp).WithShutdownDrain(200*time.Millisecond, time.Second).build()
+	require.NoError(t, err)
+
+	done := make(chan error)
+	go func() {
+		done <- s.run(context.Background())
+	}()
+	readyURL := "http://localhost:" + port + patronhttp.ReadyPath
+	assert.Eventually(t, func() bool {
+		rsp, err := http.Get(readyURL)
+		if err != nil {
+			return false
+		}
+		_ = rsp.Body.Close()
+		return rsp.StatusCode == http.StatusOK
+	}, time.Second, 10*time.Millisecond)
+
+	start := time.Now()
+	s.termSig <- syscall.SIGTERM
+	// while draining, the readiness check fails and the components keep running
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&cp.drained) == 1 }, time.Second, 5*time.Millisecond)
+	rsp, err := http.Get(readyURL)
+	require.NoError(t, err)
+	_ = rsp.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, rsp.StatusCode)
+
+	assert.NoError(t, <-done)
+	assert.GreaterOrEqual(t, int64(time.Since(start)), int64(200*time.Millisecond))
+}
+
+func TestServer_Run_ShutdownDrain_SecondSignal(t *testing.T) {
+	defer os.Clearenv()
+	require.NoError(t, os.Setenv("PATRON_HTTP_DEFAULT_PORT", getRandomPort(t)))
+	svc, err := New("test", "", TextLogger())
+	require.NoError(t, err)
+	cp := &drainComponent{}
+	s, err := svc.WithComponents(cp).WithShutdownDrain(time.Hour, time.Second).build()
+	require.NoError(t, err)
+
+	done := make(chan error)
+	go func() {
+		done <- s.run(context.Background())
+	}()
+	s.termSig <- syscall.SIGTERM
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&cp.drained) == 1 }, time.Second, 5*time.Millisecond)
+	s.termSig <- syscall.SIGINT
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "service was not shut down")
+	}
+}
+
+func TestServer_Run_SyntheticDurationElapsed(t *testing.T) {
+	defer os.Clearenv()
+	require.NoError(t, os.Setenv("PATRON_HTTP_DEFAULT_PORT", getRandomPort(t)))
+	svc, err := New("test", "", TextLogger())
+	require.NoError(t, err)
+	cp, err := synthetic.New("soak", 100, func(context.Context) error { return nil }, synthetic.Duration(50*time.Millisecond))
+	require.NoError(t, err)
+	s, err := svc.WithComponents(cp).build()
+	require.NoError(t, err)
+
+	done := make(chan error)
+	go func() {
+		done <- s.run(context.Background())
+	}()
+
+	// the service keeps running after the soak test finishes
+	select {
+	case err := <-done:
+		assert.Fail(t, "service was shut down when the synthetic traffic stopped", err)
+	case <-time.After(300 * time.Millisecond):
+	}
+
+	s.termSig <- syscall.SIGTERM
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "service was not shut down")
+	}
+}
+
+// drainComponent fails if it is shut down before it is drained.
+type drainComponent struct {
+	drained int32
+}
+
+func (c *drainComponent) Run(ctx context.Context) error {
+	<-ctx.Done()
+	if atomic.LoadInt32(&c.drained) == 0 {
+		return errors.New("component shut down before it was drained")
+	}
+	return nil
+}
+
+func (c *drainComponent) Drain() {
+	atomic.StoreInt32(&c.drained, 1)
+}
@@ -0,0 +1,48 @@
+package slo
+
+import (
+	"errors"
+	"time"
+)
+
+// OptionFunc definition for configuring the objective in a functional way.
+type OptionFunc func(*Objective) error
+
+// Latency sets the latency threshold of the objective, above which successful events are bad.
+func Latency(threshold time.Duration) OptionFunc {
+	return func(o *Objective) error {
+		if threshold <= 0 {
+			return errors.New("latency threshold should be positive")
+		}
+		o.latency = threshold
+		return nil
+	}
+}
+
+// Period sets the period of the error budget of the objective (default: 30 days), with a resolution of an hour.
+func Period(period time.Duration) OptionFunc {
+	return func(o *Objective) error {
+		if period < time.Hour {
+			return errors.New("period should be at least an hour")
+		}
+		o.period = period
+		return nil
+	}
+}
+
+// BurnRateWindows sets the windows over which the burn rates of the objective are exported, with a resolution of a minute
+// (default: 5m, 30m, 1h, 6h, 1d and 3d).
+func BurnRateWindows(windows ...time.Duration) OptionFunc {
+	return func(o *Objective) error {
+		if len(windows) == 0 {
+			return errors.New("burn rate windows are empty")
+		}
+		for _, w := range windows {
+			if w < time.Minute {
+				return errors.New("burn rate window should be at least a minute")
+			}
+		}
+		o.windows = windows
+		return nil
+	}
+}
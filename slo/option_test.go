@@ -0,0 +1,50 @@
+package slo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLatency(t *testing.T) {
+	t.Parallel()
+	o := &Objective{}
+	assert.EqualError(t, Latency(0)(o), "latency threshold should be positive")
+	assert.NoError(t, Latency(time.Second)(o))
+	assert.Equal(t, time.Second, o.latency)
+}
+
+func TestPeriod(t *testing.T) {
+	t.Parallel()
+	o := &Objective{}
+	assert.EqualError(t, Period(time.Minute)(o), "period should be at least an hour")
+	assert.NoError(t, Period(7*24*time.Hour)(o))
+	assert.Equal(t, 7*24*time.Hour, o.period)
+}
+
+func TestBurnRateWindows(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		windows     []time.Duration
+		expectedErr string
+	}{
+		"success":        {windows: []time.Duration{time.Minute, time.Hour}},
+		"missing":        {expectedErr: "burn rate windows are empty"},
+		"invalid window": {windows: []time.Duration{time.Second}, expectedErr: "burn rate window should be at least a minute"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			o := &Objective{}
+			err := BurnRateWindows(tt.windows...)(o)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.windows, o.windows)
+			}
+		})
+	}
+}
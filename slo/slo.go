@@ -0,0 +1,204 @@
+// Package slo provides service level objectives e.g. of the availability and the latency of HTTP routes or the processing of topics,
+// tracking their events and exporting their error budget and its burn rates over multiple windows as metrics,
+// so that alerts can be defined directly on the objectives declared in the code of the services.
+package slo
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	defaultPeriod = 30 * 24 * time.Hour
+
+	// resolutions of the windows of the burn rates and of the error budget
+	burnRateResolution = time.Minute
+	budgetResolution   = time.Hour
+)
+
+// DefaultBurnRateWindows are the windows over which the burn rates are exported by default,
+// pairing the long and short windows of the multi-window alerts of the Google SRE workbook.
+var DefaultBurnRateWindows = []time.Duration{
+	5 * time.Minute, 30 * time.Minute, time.Hour, 6 * time.Hour, 24 * time.Hour, 72 * time.Hour,
+}
+
+var eventsMetric *prometheus.CounterVec
+
+func init() {
+	eventsMetric = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "slo",
+			Subsystem: "objective",
+			Name:      "events_total",
+			Help:      "Events of the service level objectives, classified as good or bad.",
+		},
+		[]string{"objective", "good"},
+	)
+	prometheus.MustRegister(eventsMetric)
+}
+
+// Objective is a service level objective, e.g. 99.9% of the requests of a route succeeding within 300ms.
+// Its error budget is the share of the events allowed to be bad over its period.
+type Objective struct {
+	name       string
+	target     float64
+	latency    time.Duration
+	period     time.Duration
+	windows    []time.Duration
+	now        func() time.Time
+	mu         sync.Mutex
+	burnRates  *window
+	budget     *window
+	collectors []prometheus.Collector
+}
+
+// New creates an objective of the provided name, e.g. the route or the topic it covers, with the target ratio of good events, e.g. 0.999.
+// Events are good when they succeed, and within the latency threshold of the objective, if any.
+// The objective exports the following metrics, labeled by its name:
+//   - slo_objective_events_total, the good and bad events
+//   - slo_objective_target, the target ratio of good events
+//   - slo_objective_burn_rate, the rate the error budget is consumed at over every burn rate window, where 1 exhausts it at the end of the period
+//   - slo_objective_error_budget_remaining, the ratio of the error budget remaining over the period, which is negative once exceeded
+func New(name string, target float64, oo ...OptionFunc) (*Objective, error) {
+	if name == "" {
+		return nil, errors.New("objective name is empty")
+	}
+	if target <= 0 || target >= 1 {
+		return nil, errors.New("target should be between 0 and 1")
+	}
+
+	o := &Objective{
+		name:    name,
+		target:  target,
+		period:  defaultPeriod,
+		windows: DefaultBurnRateWindows,
+		now:     time.Now,
+	}
+	for _, optionFunc := range oo {
+		if err := optionFunc(o); err != nil {
+			return nil, err
+		}
+	}
+
+	longest := time.Duration(0)
+	for _, w := range o.windows {
+		if w > longest {
+			longest = w
+		}
+	}
+	o.burnRates = newWindow(burnRateResolution, longest)
+	o.budget = newWindow(budgetResolution, o.period)
+
+	if err := o.register(); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// Name returns the name of the objective.
+func (o *Objective) Name() string {
+	return o.name
+}
+
+// Record records an event of the objective, which is good if it succeeded within the latency threshold of the objective, if any.
+func (o *Objective) Record(success bool, latency time.Duration) {
+	good := success && (o.latency == 0 || latency <= o.latency)
+	now := o.now()
+
+	o.mu.Lock()
+	o.burnRates.add(now, !good)
+	o.budget.add(now, !good)
+	o.mu.Unlock()
+
+	eventsMetric.WithLabelValues(o.name, strconv.FormatBool(good)).Inc()
+}
+
+// BurnRate returns the rate the error budget was consumed at over the window, i.e. the ratio of bad events
+// divided by the ratio allowed by the target. A burn rate of 1 exhausts the error budget at the end of the period.
+func (o *Objective) BurnRate(window time.Duration) float64 {
+	o.mu.Lock()
+	total, bad := o.burnRates.sum(o.now(), window)
+	o.mu.Unlock()
+	if total == 0 {
+		return 0
+	}
+	return (float64(bad) / float64(total)) / (1 - o.target)
+}
+
+// ErrorBudgetRemaining returns the ratio of the error budget remaining over the period of the objective,
+// which is 1 if no event was bad and negative once the budget is exceeded.
+func (o *Objective) ErrorBudgetRemaining() float64 {
+	o.mu.Lock()
+	total, bad := o.budget.sum(o.now(), o.period)
+	o.mu.Unlock()
+	if total == 0 {
+		return 1
+	}
+	return 1 - float64(bad)/(float64(total)*(1-o.target))
+}
+
+func (o *Objective) register() error {
+	labels := prometheus.Labels{"objective": o.name}
+	o.collectors = append(o.collectors,
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace:   "slo",
+			Subsystem:   "objective",
+			Name:        "target",
+			Help:        "Target ratio of the good events of the service level objectives.",
+			ConstLabels: labels,
+		}, func() float64 { return o.target }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace:   "slo",
+			Subsystem:   "objective",
+			Name:        "error_budget_remaining",
+			Help:        "Ratio of the error budget of the service level objectives remaining over their period.",
+			ConstLabels: labels,
+		}, o.ErrorBudgetRemaining),
+	)
+	for _, w := range o.windows {
+		w := w
+		o.collectors = append(o.collectors, prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace:   "slo",
+			Subsystem:   "objective",
+			Name:        "burn_rate",
+			Help:        "Rate the error budget of the service level objectives is consumed at over the window.",
+			ConstLabels: prometheus.Labels{"objective": o.name, "window": formatWindow(w)},
+		}, func() float64 { return o.BurnRate(w) }))
+	}
+
+	for i, c := range o.collectors {
+		if err := prometheus.Register(c); err != nil {
+			for _, registered := range o.collectors[:i] {
+				prometheus.Unregister(registered)
+			}
+			return fmt.Errorf("failed to register metrics of objective %s: %w", o.name, err)
+		}
+	}
+	return nil
+}
+
+// Close unregisters the metrics of the objective, e.g. in order to declare it again.
+func (o *Objective) Close() {
+	for _, c := range o.collectors {
+		prometheus.Unregister(c)
+	}
+}
+
+// formatWindow formats the window in the notation of Prometheus durations, e.g. 5m, 6h or 3d.
+func formatWindow(d time.Duration) string {
+	switch {
+	case d%(24*time.Hour) == 0:
+		return strconv.FormatInt(int64(d/(24*time.Hour)), 10) + "d"
+	case d%time.Hour == 0:
+		return strconv.FormatInt(int64(d/time.Hour), 10) + "h"
+	case d%time.Minute == 0:
+		return strconv.FormatInt(int64(d/time.Minute), 10) + "m"
+	default:
+		return d.String()
+	}
+}
@@ -0,0 +1,122 @@
+package slo
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		name        string
+		target      float64
+		oo          []OptionFunc
+		expectedErr string
+	}{
+		"success":        {name: "new-success", target: 0.999, oo: []OptionFunc{Latency(time.Second)}},
+		"missing name":   {target: 0.999, expectedErr: "objective name is empty"},
+		"invalid target": {name: "new-invalid-target", target: 1, expectedErr: "target should be between 0 and 1"},
+		"option failed":  {name: "new-option-failed", target: 0.999, oo: []OptionFunc{Latency(0)}, expectedErr: "latency threshold should be positive"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			got, err := New(tt.name, tt.target, tt.oo...)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.name, got.Name())
+				got.Close()
+			}
+		})
+	}
+}
+
+func TestNew_Duplicate(t *testing.T) {
+	t.Parallel()
+	o, err := New("duplicate", 0.99)
+	require.NoError(t, err)
+	_, err = New("duplicate", 0.99)
+	assert.Error(t, err)
+	assert.True(t, strings.HasPrefix(err.Error(), "failed to register metrics of objective duplicate: "))
+
+	o.Close()
+	o, err = New("duplicate", 0.99)
+	require.NoError(t, err)
+	o.Close()
+}
+
+func TestObjective_Record(t *testing.T) {
+	t.Parallel()
+	o, err := New("record", 0.75, Latency(100*time.Millisecond), BurnRateWindows(5*time.Minute, time.Hour), Period(24*time.Hour))
+	require.NoError(t, err)
+	t.Cleanup(o.Close)
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	o.now = func() time.Time { return now }
+
+	assert.Equal(t, 0.0, o.BurnRate(time.Hour))
+	assert.Equal(t, 1.0, o.ErrorBudgetRemaining())
+
+	// 8 good events an hour ago
+	now = now.Add(-time.Hour + time.Minute)
+	for i := 0; i < 8; i++ {
+		o.Record(true, 10*time.Millisecond)
+	}
+
+	// 8 recent events, 2 of them failed and 2 slow
+	now = now.Add(time.Hour - time.Minute)
+	for i := 0; i < 4; i++ {
+		o.Record(true, 10*time.Millisecond)
+	}
+	o.Record(false, 10*time.Millisecond)
+	o.Record(false, 10*time.Millisecond)
+	o.Record(true, time.Second)
+	o.Record(true, time.Second)
+
+	assert.Equal(t, 2.0, o.BurnRate(5*time.Minute))
+	assert.Equal(t, 1.0, o.BurnRate(time.Hour))
+	assert.Equal(t, 0.0, o.ErrorBudgetRemaining())
+
+	assert.Equal(t, 12.0, testutil.ToFloat64(eventsMetric.WithLabelValues("record", "true")))
+	assert.Equal(t, 4.0, testutil.ToFloat64(eventsMetric.WithLabelValues("record", "false")))
+
+	expected := `
+# HELP slo_objective_burn_rate Rate the error budget of the service level objectives is consumed at over the window.
+# TYPE slo_objective_burn_rate gauge
+slo_objective_burn_rate{objective="record",window="1h"} 1
+slo_objective_burn_rate{objective="record",window="5m"} 2
+# HELP slo_objective_error_budget_remaining Ratio of the error budget of the service level objectives remaining over their period.
+# TYPE slo_objective_error_budget_remaining gauge
+slo_objective_error_budget_remaining{objective="record"} 0
+# HELP slo_objective_target Target ratio of the good events of the service level objectives.
+# TYPE slo_objective_target gauge
+slo_objective_target{objective="record"} 0.75
+`
+	reg := prometheus.NewPedanticRegistry()
+	for _, c := range o.collectors {
+		require.NoError(t, reg.Register(c))
+	}
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expected)))
+}
+
+func TestFormatWindow(t *testing.T) {
+	t.Parallel()
+	for d, expected := range map[time.Duration]string{
+		5 * time.Minute:  "5m",
+		90 * time.Minute: "90m",
+		6 * time.Hour:    "6h",
+		72 * time.Hour:   "3d",
+		90 * time.Second: "1m30s",
+	} {
+		assert.Equal(t, expected, formatWindow(d))
+	}
+}
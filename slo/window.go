@@ -0,0 +1,59 @@
+package slo
+
+import "time"
+
+type bucket struct {
+	start int64
+	total uint64
+	bad   uint64
+}
+
+// window counts the events of the recent past in a ring of buckets of a fixed resolution,
+// reusing the bucket of the oldest period for the current one.
+type window struct {
+	resolution time.Duration
+	buckets    []bucket
+}
+
+func newWindow(resolution, length time.Duration) *window {
+	size := int(length / resolution)
+	if length%resolution != 0 {
+		size++
+	}
+	return &window{resolution: resolution, buckets: make([]bucket, size)}
+}
+
+func (w *window) period(t time.Time) int64 {
+	return t.UnixNano() / int64(w.resolution)
+}
+
+func (w *window) add(t time.Time, bad bool) {
+	p := w.period(t)
+	b := &w.buckets[p%int64(len(w.buckets))]
+	if b.start != p {
+		*b = bucket{start: p}
+	}
+	b.total++
+	if bad {
+		b.bad++
+	}
+}
+
+// sum returns the events of the provided duration up to the time, rounded up to the resolution of the window.
+func (w *window) sum(t time.Time, d time.Duration) (total, bad uint64) {
+	p := w.period(t)
+	periods := int64(d / w.resolution)
+	if d%w.resolution != 0 {
+		periods++
+	}
+	if periods > int64(len(w.buckets)) {
+		periods = int64(len(w.buckets))
+	}
+	for _, b := range w.buckets {
+		if b.start > p-periods && b.start <= p {
+			total += b.total
+			bad += b.bad
+		}
+	}
+	return total, bad
+}
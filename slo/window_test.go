@@ -0,0 +1,59 @@
+package slo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWindow(t *testing.T) {
+	t.Parallel()
+	w := newWindow(time.Minute, 10*time.Minute)
+	assert.Len(t, w.buckets, 10)
+	assert.Len(t, newWindow(time.Hour, 90*time.Minute).buckets, 2)
+
+	start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	w.add(start, false)
+	w.add(start.Add(30*time.Second), true)
+	w.add(start.Add(5*time.Minute), true)
+	w.add(start.Add(9*time.Minute), false)
+
+	now := start.Add(9 * time.Minute)
+	tests := map[string]struct {
+		now           time.Time
+		d             time.Duration
+		expectedTotal uint64
+		expectedBad   uint64
+	}{
+		"current bucket":            {now: now, d: time.Minute, expectedTotal: 1},
+		"recent buckets":            {now: now, d: 5 * time.Minute, expectedTotal: 2, expectedBad: 1},
+		"whole window":              {now: now, d: 10 * time.Minute, expectedTotal: 4, expectedBad: 2},
+		"longer than window":        {now: now, d: time.Hour, expectedTotal: 4, expectedBad: 2},
+		"rounded to resolution":     {now: now, d: 90 * time.Second, expectedTotal: 1},
+		"expired buckets":           {now: start.Add(12 * time.Minute), d: 10 * time.Minute, expectedTotal: 2, expectedBad: 1},
+		"all buckets expired":       {now: start.Add(time.Hour), d: 10 * time.Minute},
+		"buckets in future ignored": {now: start.Add(4 * time.Minute), d: 10 * time.Minute, expectedTotal: 2, expectedBad: 1},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			total, bad := w.sum(tt.now, tt.d)
+			assert.Equal(t, tt.expectedTotal, total)
+			assert.Equal(t, tt.expectedBad, bad)
+		})
+	}
+}
+
+func TestWindow_ReusesBuckets(t *testing.T) {
+	t.Parallel()
+	w := newWindow(time.Minute, 2*time.Minute)
+	start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	w.add(start, true)
+	w.add(start.Add(2*time.Minute), false)
+
+	total, bad := w.sum(start.Add(2*time.Minute), 2*time.Minute)
+	assert.Equal(t, uint64(1), total)
+	assert.Equal(t, uint64(0), bad)
+}
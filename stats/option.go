@@ -0,0 +1,37 @@
+package stats
+
+import (
+	"errors"
+	"time"
+)
+
+// OptionFunc definition for configuring the window in a functional way.
+type OptionFunc func(*Window) error
+
+// Resolution sets the resolution of the window, i.e. the steps it slides in, which defaults to a tenth of its length.
+// A finer resolution keeps more buckets, each of them with a histogram of the values.
+func Resolution(resolution time.Duration) OptionFunc {
+	return func(w *Window) error {
+		if resolution <= 0 {
+			return errors.New("resolution should be positive")
+		}
+		w.resolution = resolution
+		return nil
+	}
+}
+
+// Bounds sets the upper bounds of the histogram of the observed values, which the percentiles are estimated from (default: DefaultBounds).
+func Bounds(bounds ...float64) OptionFunc {
+	return func(w *Window) error {
+		if len(bounds) == 0 {
+			return errors.New("bounds are empty")
+		}
+		for i := 1; i < len(bounds); i++ {
+			if bounds[i] <= bounds[i-1] {
+				return errors.New("bounds should be increasing")
+			}
+		}
+		w.bounds = bounds
+		return nil
+	}
+}
@@ -0,0 +1,60 @@
+package stats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolution(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		resolution  time.Duration
+		expectedErr string
+	}{
+		"success":  {resolution: time.Second},
+		"zero":     {resolution: 0, expectedErr: "resolution should be positive"},
+		"negative": {resolution: -time.Second, expectedErr: "resolution should be positive"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			w := &Window{}
+			err := Resolution(tt.resolution)(w)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.resolution, w.resolution)
+		})
+	}
+}
+
+func TestBounds(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		bounds      []float64
+		expectedErr string
+	}{
+		"success":        {bounds: []float64{1, 2, 5}},
+		"empty":          {expectedErr: "bounds are empty"},
+		"not increasing": {bounds: []float64{1, 2, 2}, expectedErr: "bounds should be increasing"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			w := &Window{}
+			err := Bounds(tt.bounds...)(w)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.bounds, w.bounds)
+		})
+	}
+}
@@ -0,0 +1,246 @@
+// Package stats provides sliding-window statistics of the recent events of the service kept in-process, i.e. their rolling counts,
+// rates, means and percentiles, which middleware and handlers can query at runtime in order to make decisions, e.g. to shed load
+// while the latency is high or to adjust the size of batches, without scraping the metrics.
+package stats
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultBounds are the upper bounds of the histogram of the observed values, growing exponentially by a quarter from 0.001 to about 10^6,
+// e.g. latencies from a millisecond to days in seconds, or batch sizes. Percentiles are estimated within a quarter of their value.
+var DefaultBounds = func() []float64 {
+	var bb []float64
+	for b := 0.001; b < 1e6; b = b * 5 / 4 {
+		bb = append(bb, b)
+	}
+	return bb
+}()
+
+type bucket struct {
+	start  int64
+	count  uint64
+	values uint64
+	sum    float64
+	hist   []uint64
+}
+
+// Window keeps the events of the recent past in a ring of buckets of a fixed resolution, reusing the bucket of the oldest period
+// for the current one, so that it slides in steps of the resolution. It is safe for concurrent use.
+type Window struct {
+	length     time.Duration
+	resolution time.Duration
+	bounds     []float64
+	now        func() time.Time
+	mu         sync.Mutex
+	buckets    []bucket
+}
+
+// NewWindow creates a window of the provided length, with a resolution of a tenth of the length, unless set otherwise.
+func NewWindow(length time.Duration, oo ...OptionFunc) (*Window, error) {
+	if length <= 0 {
+		return nil, errors.New("window length should be positive")
+	}
+
+	w := &Window{
+		length:     length,
+		resolution: length / 10,
+		bounds:     DefaultBounds,
+		now:        time.Now,
+	}
+	for _, option := range oo {
+		if err := option(w); err != nil {
+			return nil, err
+		}
+	}
+	if w.resolution <= 0 || w.resolution > length {
+		return nil, errors.New("resolution should be positive and not exceed the window length")
+	}
+
+	size := int(length / w.resolution)
+	if length%w.resolution != 0 {
+		size++
+	}
+	w.buckets = make([]bucket, size)
+	for i := range w.buckets {
+		w.buckets[i].start = -1
+		w.buckets[i].hist = make([]uint64, len(w.bounds)+1)
+	}
+	return w, nil
+}
+
+// Length returns the length of the window.
+func (w *Window) Length() time.Duration {
+	return w.length
+}
+
+// Inc records an event without a value, e.g. a request or a failure, which is counted but not included in the mean and the percentiles.
+func (w *Window) Inc() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.current().count++
+}
+
+// Observe records an event with a value, e.g. the latency of a request in seconds, which should not be negative.
+func (w *Window) Observe(v float64) {
+	i := sort.SearchFloat64s(w.bounds, v)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	b := w.current()
+	b.count++
+	b.values++
+	b.sum += v
+	b.hist[i]++
+}
+
+// ObserveDuration records an event with a duration, in seconds.
+func (w *Window) ObserveDuration(d time.Duration) {
+	w.Observe(d.Seconds())
+}
+
+// Count returns the events of the last duration, which is rounded up to the resolution and capped to the length of the window.
+func (w *Window) Count(d time.Duration) uint64 {
+	return w.Summary(d).Count
+}
+
+// Rate returns the events per second of the last duration, which is rounded up to the resolution and capped to the length of the window.
+func (w *Window) Rate(d time.Duration) float64 {
+	return w.Summary(d).Rate
+}
+
+// Mean returns the mean of the values observed during the last duration, or zero if there are none.
+func (w *Window) Mean(d time.Duration) float64 {
+	return w.Summary(d).Mean
+}
+
+// Percentile returns the estimated percentile q, e.g. 0.99, of the values observed during the last duration, or zero if there are none.
+func (w *Window) Percentile(d time.Duration, q float64) float64 {
+	now := w.now()
+	w.mu.Lock()
+	hist, values, _, _ := w.sum(now, d)
+	w.mu.Unlock()
+	return w.percentile(hist, values, q)
+}
+
+// Summary of the events of the last duration of a window.
+type Summary struct {
+	// Count of the events.
+	Count uint64
+	// Rate of the events per second.
+	Rate float64
+	// Values is the count of the events observed with a value.
+	Values uint64
+	// Mean of the values.
+	Mean float64
+	// P50 to P99 are the estimated percentiles of the values.
+	P50 float64
+	P90 float64
+	P99 float64
+}
+
+// Summary returns the summary of the events of the last duration, which is rounded up to the resolution and capped to the length of the window.
+func (w *Window) Summary(d time.Duration) Summary {
+	now := w.now()
+	w.mu.Lock()
+	hist, values, count, sum := w.sum(now, d)
+	w.mu.Unlock()
+
+	s := Summary{Count: count, Values: values}
+	if elapsed := w.elapsed(now, d); elapsed > 0 {
+		s.Rate = float64(count) / elapsed.Seconds()
+	}
+	if values > 0 {
+		s.Mean = sum / float64(values)
+	}
+	s.P50 = w.percentile(hist, values, 0.5)
+	s.P90 = w.percentile(hist, values, 0.9)
+	s.P99 = w.percentile(hist, values, 0.99)
+	return s
+}
+
+func (w *Window) period(t time.Time) int64 {
+	return t.UnixNano() / int64(w.resolution)
+}
+
+func (w *Window) periods(d time.Duration) int64 {
+	periods := int64(d / w.resolution)
+	if d%w.resolution != 0 {
+		periods++
+	}
+	if periods > int64(len(w.buckets)) {
+		periods = int64(len(w.buckets))
+	}
+	return periods
+}
+
+// current returns the bucket of the current period, resetting it if it belongs to an older one.
+func (w *Window) current() *bucket {
+	p := w.period(w.now())
+	b := &w.buckets[p%int64(len(w.buckets))]
+	if b.start != p {
+		b.start = p
+		b.count = 0
+		b.values = 0
+		b.sum = 0
+		for i := range b.hist {
+			b.hist[i] = 0
+		}
+	}
+	return b
+}
+
+func (w *Window) sum(now time.Time, d time.Duration) (hist []uint64, values, count uint64, sum float64) {
+	p := w.period(now)
+	periods := w.periods(d)
+	hist = make([]uint64, len(w.bounds)+1)
+	for _, b := range w.buckets {
+		if b.start <= p-periods || b.start > p {
+			continue
+		}
+		count += b.count
+		values += b.values
+		sum += b.sum
+		for i, c := range b.hist {
+			hist[i] += c
+		}
+	}
+	return hist, values, count, sum
+}
+
+// elapsed returns the time covered by the buckets of the duration, whose current one covers the time elapsed since its start.
+func (w *Window) elapsed(now time.Time, d time.Duration) time.Duration {
+	periods := w.periods(d)
+	if periods == 0 {
+		return 0
+	}
+	return time.Duration(periods-1)*w.resolution + time.Duration(now.UnixNano()%int64(w.resolution))
+}
+
+// percentile estimates the percentile of the histogram, interpolating linearly within the bucket containing it.
+// Values beyond the last bound are estimated at the last bound.
+func (w *Window) percentile(hist []uint64, total uint64, q float64) float64 {
+	if total == 0 {
+		return 0
+	}
+	rank := q * float64(total)
+	var count uint64
+	for i, c := range hist {
+		if c == 0 || float64(count+c) < rank {
+			count += c
+			continue
+		}
+		if i == len(w.bounds) {
+			break
+		}
+		lower := 0.0
+		if i > 0 {
+			lower = w.bounds[i-1]
+		}
+		upper := w.bounds[i]
+		return lower + (upper-lower)*(rank-float64(count))/float64(c)
+	}
+	return w.bounds[len(w.bounds)-1]
+}
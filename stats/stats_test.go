@@ -0,0 +1,168 @@
+package stats
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *clock) Add(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	c.mu.Unlock()
+}
+
+func newTestWindow(t *testing.T, length time.Duration, oo ...OptionFunc) (*Window, *clock) {
+	w, err := NewWindow(length, oo...)
+	require.NoError(t, err)
+	c := &clock{now: time.Unix(1000, 0)}
+	w.now = c.Now
+	return w, c
+}
+
+func TestNewWindow(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		length          time.Duration
+		options         []OptionFunc
+		expectedBuckets int
+		expectedErr     string
+	}{
+		"default resolution":        {length: time.Minute, expectedBuckets: 10},
+		"resolution":                {length: time.Minute, options: []OptionFunc{Resolution(time.Second)}, expectedBuckets: 60},
+		"resolution not a divisor":  {length: time.Minute, options: []OptionFunc{Resolution(7 * time.Second)}, expectedBuckets: 9},
+		"zero length":               {length: 0, expectedErr: "window length should be positive"},
+		"resolution exceeds length": {length: time.Second, options: []OptionFunc{Resolution(time.Minute)}, expectedErr: "resolution should be positive and not exceed the window length"},
+		"too short length":          {length: 5, expectedErr: "resolution should be positive and not exceed the window length"},
+		"invalid option":            {length: time.Minute, options: []OptionFunc{Bounds()}, expectedErr: "bounds are empty"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			w, err := NewWindow(tt.length, tt.options...)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.Nil(t, w)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.length, w.Length())
+			assert.Len(t, w.buckets, tt.expectedBuckets)
+		})
+	}
+}
+
+func TestWindow_Slide(t *testing.T) {
+	t.Parallel()
+	w, c := newTestWindow(t, 10*time.Second, Resolution(time.Second))
+
+	for i := 0; i < 10; i++ {
+		w.Inc()
+		c.Add(time.Second)
+	}
+	// the oldest event is out of the window
+	assert.Equal(t, uint64(9), w.Count(10*time.Second))
+	// the current period has no events yet
+	assert.Equal(t, uint64(2), w.Count(3*time.Second))
+	// rounded up to the resolution
+	assert.Equal(t, uint64(2), w.Count(2500*time.Millisecond))
+	// capped to the length of the window
+	assert.Equal(t, uint64(9), w.Count(time.Hour))
+	assert.Zero(t, w.Count(0))
+
+	c.Add(5 * time.Second)
+	assert.Equal(t, uint64(4), w.Count(10*time.Second))
+
+	// buckets of old periods are reset when reused
+	c.Add(time.Minute)
+	assert.Zero(t, w.Count(10*time.Second))
+	w.Inc()
+	assert.Equal(t, uint64(1), w.Count(10*time.Second))
+}
+
+func TestWindow_Rate(t *testing.T) {
+	t.Parallel()
+	w, c := newTestWindow(t, 10*time.Second, Resolution(time.Second))
+	assert.Zero(t, w.Rate(10*time.Second))
+
+	for i := 0; i < 20; i++ {
+		w.Inc()
+		w.Inc()
+		c.Add(500 * time.Millisecond)
+	}
+	// 9 full periods and half of the current one
+	assert.InDelta(t, 4.0, w.Rate(10*time.Second), 0.01)
+	assert.InDelta(t, 4.0, w.Rate(2*time.Second), 0.01)
+}
+
+func TestWindow_Values(t *testing.T) {
+	t.Parallel()
+	w, _ := newTestWindow(t, time.Minute, Bounds(1, 2, 3, 4, 5, 6, 7, 8, 9, 10))
+	assert.Zero(t, w.Mean(time.Minute))
+	assert.Zero(t, w.Percentile(time.Minute, 0.5))
+
+	for i := 1; i <= 100; i++ {
+		w.Observe(float64(i) / 10)
+	}
+	w.Inc()
+
+	s := w.Summary(time.Minute)
+	assert.Equal(t, uint64(101), s.Count)
+	assert.Equal(t, uint64(100), s.Values)
+	assert.InDelta(t, 5.05, s.Mean, 0.001)
+	assert.InDelta(t, 5, s.P50, 0.001)
+	assert.InDelta(t, 9, s.P90, 0.001)
+	assert.InDelta(t, 9.9, s.P99, 0.001)
+	assert.InDelta(t, 5.05, w.Mean(time.Minute), 0.001)
+	assert.InDelta(t, 2.5, w.Percentile(time.Minute, 0.25), 0.001)
+
+	// values beyond the last bound are estimated at it
+	w.Observe(100)
+	assert.Equal(t, 10.0, w.Percentile(time.Minute, 1))
+}
+
+func TestWindow_ObserveDuration(t *testing.T) {
+	t.Parallel()
+	w, _ := newTestWindow(t, time.Minute)
+	for i := 0; i < 100; i++ {
+		w.ObserveDuration(100 * time.Millisecond)
+	}
+	assert.InDelta(t, 0.1, w.Mean(time.Minute), 0.001)
+	// within a quarter of the value with the default bounds
+	assert.InDelta(t, 0.1, w.Percentile(time.Minute, 0.99), 0.025)
+}
+
+func TestWindow_Concurrency(t *testing.T) {
+	t.Parallel()
+	w, err := NewWindow(time.Minute)
+	require.NoError(t, err)
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				w.Observe(1)
+				_ = w.Summary(time.Minute)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, uint64(1000), w.Count(time.Minute))
+}
@@ -0,0 +1,198 @@
+package validation
+
+import (
+	"fmt"
+	"math"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+type rule struct {
+	name  string
+	param string
+	num   float64
+	oneOf []string
+	check func(r rule, v reflect.Value) (string, bool)
+}
+
+var checks = map[string]func(r rule, v reflect.Value) (string, bool){
+	"required": checkRequired,
+	"min":      checkMin,
+	"max":      checkMax,
+	"len":      checkLen,
+	"oneof":    checkOneOf,
+	"email":    checkEmail,
+	"url":      checkURL,
+	"uuid":     checkUUID,
+}
+
+// parseRules parses the rules of a validate tag, checking that their parameters are valid and that they apply to the type of the field.
+func parseRules(tag string, t reflect.Type) ([]rule, error) {
+	if tag == "" || tag == "-" {
+		return nil, nil
+	}
+	var rr []rule
+	for _, part := range strings.Split(tag, ",") {
+		name, param := part, ""
+		if i := strings.Index(part, "="); i >= 0 {
+			name, param = part[:i], part[i+1:]
+		}
+		if name == "omitempty" {
+			rr = append(rr, rule{name: name})
+			continue
+		}
+		check, ok := checks[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown rule %q", name)
+		}
+		r := rule{name: name, param: param, check: check}
+
+		base := t
+		if base.Kind() == reflect.Ptr {
+			base = base.Elem()
+		}
+		switch name {
+		case "min", "max", "len":
+			n, err := strconv.ParseFloat(param, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid parameter %q of rule %s", param, name)
+			}
+			if !sized(base.Kind()) {
+				return nil, fmt.Errorf("rule %s does not apply to type %s", name, t)
+			}
+			r.num = n
+		case "oneof":
+			r.oneOf = strings.Fields(param)
+			if len(r.oneOf) == 0 {
+				return nil, fmt.Errorf("rule %s has no values", name)
+			}
+			if base.Kind() != reflect.String && !numeric(base.Kind()) {
+				return nil, fmt.Errorf("rule %s does not apply to type %s", name, t)
+			}
+		case "email", "url", "uuid":
+			if base.Kind() != reflect.String {
+				return nil, fmt.Errorf("rule %s does not apply to type %s", name, t)
+			}
+		}
+		rr = append(rr, r)
+	}
+	return rr, nil
+}
+
+func sized(k reflect.Kind) bool {
+	switch k {
+	case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+		return true
+	}
+	return numeric(k)
+}
+
+func numeric(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// validateRules checks the value against the rules in order, returning the error of the first one failing.
+// Apart from required, the rules are skipped for nil pointers, and for zero values after omitempty.
+func validateRules(v reflect.Value, rr []rule) (FieldError, bool) {
+	for _, r := range rr {
+		if r.name == "omitempty" {
+			if v.IsZero() {
+				return FieldError{}, true
+			}
+			continue
+		}
+		if r.name != "required" && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return FieldError{}, true
+			}
+			v = v.Elem()
+		}
+		if msg, ok := r.check(r, v); !ok {
+			return FieldError{Rule: r.name, Message: msg}, false
+		}
+	}
+	return FieldError{}, true
+}
+
+func checkRequired(_ rule, v reflect.Value) (string, bool) {
+	return "is required", !v.IsZero()
+}
+
+// size returns the value of numbers, the characters of strings or the items of collections, with the unit of the messages.
+func size(v reflect.Value) (float64, string) {
+	switch v.Kind() {
+	case reflect.String:
+		return float64(utf8.RuneCountInString(v.String())), " characters"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return float64(v.Len()), " items"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), ""
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), ""
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), ""
+	}
+	return math.NaN(), ""
+}
+
+func checkMin(r rule, v reflect.Value) (string, bool) {
+	n, unit := size(v)
+	if unit == "" {
+		return "must be at least " + r.param, n >= r.num
+	}
+	return "must have at least " + r.param + unit, n >= r.num
+}
+
+func checkMax(r rule, v reflect.Value) (string, bool) {
+	n, unit := size(v)
+	if unit == "" {
+		return "must be at most " + r.param, n <= r.num
+	}
+	return "must have at most " + r.param + unit, n <= r.num
+}
+
+func checkLen(r rule, v reflect.Value) (string, bool) {
+	n, unit := size(v)
+	if unit == "" {
+		return "must be " + r.param, n == r.num
+	}
+	return "must have " + r.param + unit, n == r.num
+}
+
+func checkOneOf(r rule, v reflect.Value) (string, bool) {
+	msg := "must be one of " + strings.Join(r.oneOf, ", ")
+	value := fmt.Sprint(v.Interface())
+	for _, o := range r.oneOf {
+		if value == o {
+			return msg, true
+		}
+	}
+	return msg, false
+}
+
+func checkEmail(_ rule, v reflect.Value) (string, bool) {
+	addr, err := mail.ParseAddress(v.String())
+	return "must be an email address", err == nil && addr.Address == v.String()
+}
+
+func checkURL(_ rule, v reflect.Value) (string, bool) {
+	u, err := url.ParseRequestURI(v.String())
+	return "must be an absolute URL", err == nil && u.Scheme != "" && u.Host != ""
+}
+
+func checkUUID(_ rule, v reflect.Value) (string, bool) {
+	return "must be a UUID", uuidPattern.MatchString(v.String())
+}
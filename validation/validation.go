@@ -0,0 +1,183 @@
+// Package validation provides the validation of structs against the rules of their validate tags, e.g. `validate:"required,max=64"`,
+// which is used by the HTTP component to reject invalid requests before they reach the handlers.
+//
+// The supported rules are:
+//
+//   - required: the field is not the zero value, e.g. an empty string or a nil pointer
+//   - omitempty: the rest of the rules are skipped if the field is the zero value
+//   - min=N, max=N, len=N: the value of numbers, the characters of strings or the items of slices and maps
+//   - oneof=a b c: the value is one of the space separated values
+//   - email, url, uuid: the string is an email address, an absolute URL or a UUID
+//
+// Fields of struct types, pointers to structs and slices of structs are validated recursively.
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// FieldError describes a field failing a validation rule.
+type FieldError struct {
+	// Field is the path of the field, named after its JSON name or its query tag, e.g. items[0].quantity.
+	Field string `json:"field"`
+	// Rule is the failing rule, e.g. required or max.
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// Error returns the field and the message of the error.
+func (e FieldError) Error() string {
+	return e.Field + " " + e.Message
+}
+
+// Schema validates the values of a struct type.
+type Schema struct {
+	typ    reflect.Type
+	fields []field
+}
+
+type field struct {
+	index  int
+	name   string
+	query  string
+	rules  []rule
+	nested *Schema
+}
+
+// Compile compiles the schema of the struct type of the value, or of the struct it points to,
+// returning an error if the type is not a struct or one of its validate tags is invalid.
+func Compile(v interface{}) (*Schema, error) {
+	if v == nil {
+		return nil, errors.New("value is nil")
+	}
+	t := reflect.TypeOf(v)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("type %s is not a struct", t)
+	}
+	return compile(t, map[reflect.Type]*Schema{})
+}
+
+func compile(t reflect.Type, seen map[reflect.Type]*Schema) (*Schema, error) {
+	if s, ok := seen[t]; ok {
+		return s, nil
+	}
+	s := &Schema{typ: t}
+	seen[t] = s
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" {
+			continue
+		}
+		f := field{index: i, name: fieldName(sf), query: sf.Tag.Get("query")}
+		rr, err := parseRules(sf.Tag.Get("validate"), sf.Type)
+		if err != nil {
+			return nil, fmt.Errorf("invalid validate tag of field %s.%s: %w", t.Name(), sf.Name, err)
+		}
+		f.rules = rr
+
+		if et := structType(sf.Type); et != nil {
+			f.nested, err = compile(et, seen)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if len(f.rules) > 0 || f.nested != nil {
+			s.fields = append(s.fields, f)
+		}
+	}
+	return s, nil
+}
+
+// fieldName returns the JSON name of the field, falling back to its query tag and its Go name.
+func fieldName(sf reflect.StructField) string {
+	if name := strings.Split(sf.Tag.Get("json"), ",")[0]; name != "" && name != "-" {
+		return name
+	}
+	if name := sf.Tag.Get("query"); name != "" {
+		return name
+	}
+	return sf.Name
+}
+
+// structType returns the struct type of fields validated recursively, i.e. structs, pointers to them and slices of both.
+func structType(t reflect.Type) reflect.Type {
+	if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Struct {
+		return t
+	}
+	return nil
+}
+
+// Type returns the struct type of the schema.
+func (s *Schema) Type() reflect.Type {
+	return s.typ
+}
+
+// Validate validates the struct, or the struct it points to, returning the errors of the fields failing their rules.
+func (s *Schema) Validate(v interface{}) ([]FieldError, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil, errors.New("value is nil")
+		}
+		rv = rv.Elem()
+	}
+	if rv.Type() != s.typ {
+		return nil, fmt.Errorf("type %s does not match the type %s of the schema", rv.Type(), s.typ)
+	}
+	var ee []FieldError
+	s.validate(rv, "", &ee)
+	return ee, nil
+}
+
+func (s *Schema) validate(rv reflect.Value, prefix string, ee *[]FieldError) {
+	for _, f := range s.fields {
+		fv := rv.Field(f.index)
+		path := prefix + f.name
+		if fe, ok := validateRules(fv, f.rules); !ok {
+			fe.Field = path
+			*ee = append(*ee, fe)
+			continue
+		}
+		if f.nested != nil {
+			f.nested.validateNested(fv, path, ee)
+		}
+	}
+}
+
+func (s *Schema) validateNested(fv reflect.Value, path string, ee *[]FieldError) {
+	switch fv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < fv.Len(); i++ {
+			s.validateNested(fv.Index(i), fmt.Sprintf("%s[%d]", path, i), ee)
+		}
+	case reflect.Ptr:
+		if !fv.IsNil() {
+			s.validate(fv.Elem(), path+".", ee)
+		}
+	case reflect.Struct:
+		s.validate(fv, path+".", ee)
+	}
+}
+
+// Struct validates the struct, or the struct it points to, compiling its schema on every call.
+// Schemas of types validated repeatedly should be compiled once with Compile instead.
+func Struct(v interface{}) ([]FieldError, error) {
+	s, err := Compile(v)
+	if err != nil {
+		return nil, err
+	}
+	return s.Validate(v)
+}
@@ -0,0 +1,198 @@
+package validation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type item struct {
+	SKU      string `json:"sku" validate:"required,len=8"`
+	Quantity int    `json:"quantity" validate:"min=1,max=100"`
+}
+
+type address struct {
+	City string `json:"city" validate:"required"`
+}
+
+type order struct {
+	ID       string   `json:"id" validate:"required,uuid"`
+	Email    string   `json:"email" validate:"required,email"`
+	Callback string   `json:"callback,omitempty" validate:"omitempty,url"`
+	Currency string   `json:"currency" validate:"oneof=EUR USD"`
+	Note     *string  `json:"note" validate:"max=5"`
+	Items    []item   `json:"items" validate:"required,max=3"`
+	Address  *address `json:"address"`
+	Page     int      `query:"page" validate:"omitempty,min=1"`
+	Internal string   `validate:"len=2"`
+}
+
+func validOrder() order {
+	return order{
+		ID:       "7c9e6679-7425-40de-944b-e07fc1f90ae7",
+		Email:    "jane@example.com",
+		Currency: "EUR",
+		Items:    []item{{SKU: "ABCD1234", Quantity: 2}},
+		Internal: "ok",
+	}
+}
+
+func TestCompile(t *testing.T) {
+	t.Parallel()
+	type unknownRule struct {
+		Name string `validate:"required,alpha"`
+	}
+	type invalidParam struct {
+		Name string `validate:"max=ten"`
+	}
+	type invalidType struct {
+		Enabled bool `validate:"min=1"`
+	}
+	type invalidEmail struct {
+		Age int `validate:"email"`
+	}
+	type emptyOneOf struct {
+		Kind string `validate:"oneof="`
+	}
+	type nested struct {
+		Child unknownRule
+	}
+	type recursive struct {
+		Name     string       `validate:"required"`
+		Children []*recursive `json:"children"`
+	}
+	tests := map[string]struct {
+		value       interface{}
+		expectedErr string
+	}{
+		"success":           {value: order{}},
+		"success pointer":   {value: &order{}},
+		"success recursive": {value: recursive{}},
+		"nil":               {expectedErr: "value is nil"},
+		"not a struct":      {value: "order", expectedErr: "type string is not a struct"},
+		"unknown rule":      {value: unknownRule{}, expectedErr: `invalid validate tag of field unknownRule.Name: unknown rule "alpha"`},
+		"invalid parameter": {value: invalidParam{}, expectedErr: `invalid validate tag of field invalidParam.Name: invalid parameter "ten" of rule max`},
+		"invalid type":      {value: invalidType{}, expectedErr: "invalid validate tag of field invalidType.Enabled: rule min does not apply to type bool"},
+		"invalid email":     {value: invalidEmail{}, expectedErr: "invalid validate tag of field invalidEmail.Age: rule email does not apply to type int"},
+		"empty oneof":       {value: emptyOneOf{}, expectedErr: "invalid validate tag of field emptyOneOf.Kind: rule oneof has no values"},
+		"invalid nested":    {value: nested{}, expectedErr: `invalid validate tag of field unknownRule.Name: unknown rule "alpha"`},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			got, err := Compile(tt.value)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, got)
+			}
+		})
+	}
+}
+
+func TestSchema_Validate(t *testing.T) {
+	t.Parallel()
+	note := "too long"
+	tests := map[string]struct {
+		modify   func(o *order)
+		expected []FieldError
+	}{
+		"valid": {modify: func(o *order) {}},
+		"missing fields": {
+			modify: func(o *order) { *o = order{Internal: "ok", Currency: "USD"} },
+			expected: []FieldError{
+				{Field: "id", Rule: "required", Message: "is required"},
+				{Field: "email", Rule: "required", Message: "is required"},
+				{Field: "items", Rule: "required", Message: "is required"},
+			},
+		},
+		"invalid formats": {
+			modify: func(o *order) {
+				o.ID = "7c9e6679"
+				o.Email = "Jane <jane@example.com>"
+				o.Callback = "/orders"
+				o.Currency = "GBP"
+				o.Internal = "κόσμος"
+			},
+			expected: []FieldError{
+				{Field: "id", Rule: "uuid", Message: "must be a UUID"},
+				{Field: "email", Rule: "email", Message: "must be an email address"},
+				{Field: "callback", Rule: "url", Message: "must be an absolute URL"},
+				{Field: "currency", Rule: "oneof", Message: "must be one of EUR, USD"},
+				{Field: "Internal", Rule: "len", Message: "must have 2 characters"},
+			},
+		},
+		"optional fields": {
+			modify: func(o *order) {
+				o.Callback = "https://example.com/orders"
+				o.Page = 2
+			},
+		},
+		"pointers": {
+			modify: func(o *order) {
+				o.Note = &note
+				o.Address = &address{}
+			},
+			expected: []FieldError{
+				{Field: "note", Rule: "max", Message: "must have at most 5 characters"},
+				{Field: "address.city", Rule: "required", Message: "is required"},
+			},
+		},
+		"nested items": {
+			modify: func(o *order) {
+				o.Items = []item{{SKU: "ABCD1234", Quantity: 1}, {SKU: "ABCD", Quantity: 0}, {SKU: "ABCD1234", Quantity: 101}}
+				o.Page = -1
+			},
+			expected: []FieldError{
+				{Field: "items[1].sku", Rule: "len", Message: "must have 8 characters"},
+				{Field: "items[1].quantity", Rule: "min", Message: "must be at least 1"},
+				{Field: "items[2].quantity", Rule: "max", Message: "must be at most 100"},
+				{Field: "page", Rule: "min", Message: "must be at least 1"},
+			},
+		},
+		"too many items": {
+			modify: func(o *order) {
+				o.Items = make([]item, 4)
+			},
+			expected: []FieldError{{Field: "items", Rule: "max", Message: "must have at most 3 items"}},
+		},
+	}
+	schema, err := Compile(order{})
+	require.NoError(t, err)
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			o := validOrder()
+			tt.modify(&o)
+			got, err := schema.Validate(&o)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestSchema_Validate_InvalidValue(t *testing.T) {
+	t.Parallel()
+	schema, err := Compile(order{})
+	require.NoError(t, err)
+	_, err = schema.Validate((*order)(nil))
+	assert.EqualError(t, err, "value is nil")
+	_, err = schema.Validate(item{})
+	assert.EqualError(t, err, "type validation.item does not match the type validation.order of the schema")
+}
+
+func TestStruct(t *testing.T) {
+	t.Parallel()
+	got, err := Struct(item{SKU: "ABCD1234"})
+	assert.NoError(t, err)
+	assert.Equal(t, []FieldError{{Field: "quantity", Rule: "min", Message: "must be at least 1"}}, got)
+	assert.Equal(t, "quantity must be at least 1", got[0].Error())
+
+	_, err = Struct(1)
+	assert.EqualError(t, err, "type int is not a struct")
+}